@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/core/redis"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	MigrateDBFromFlag = "from"
+	MigrateDBToFlag   = "to"
+)
+
+// MigrateDBCmd copies the state of Atlantis from one locking database to
+// another, ex. before switching --locking-db-type from boltdb to redis.
+type MigrateDBCmd struct {
+	Viper *viper.Viper
+}
+
+// Init returns the runnable cobra command.
+func (m *MigrateDBCmd) Init() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "migrate-db",
+		Short: "Copy locks and pull statuses between locking databases",
+		Long: "Copy the locks, pull statuses, drift detection results and command locks of one locking database to another" +
+			" and verify them, ex. before changing --locking-db-type. Stop Atlantis before running it." +
+			" The databases are configured with the same flags and environment variables as the server.",
+		Example:       "atlantis migrate-db --from boltdb --to redis --data-dir /atlantis --redis-host redis.example.com",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return m.run()
+		},
+	}
+
+	m.Viper.SetEnvPrefix("ATLANTIS")
+	m.Viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	m.Viper.AutomaticEnv()
+
+	c.Flags().String(MigrateDBFromFlag, "", "Locking database type to copy from: boltdb or redis.")
+	c.Flags().String(MigrateDBToFlag, "", "Locking database type to copy to: boltdb or redis.")
+	c.Flags().String(DataDirFlag, DefaultDataDir, stringFlags[DataDirFlag].description)
+	c.Flags().String(RedisHost, "", stringFlags[RedisHost].description)
+	c.Flags().String(RedisPassword, "", stringFlags[RedisPassword].description)
+	c.Flags().Int(RedisPort, DefaultRedisPort, intFlags[RedisPort].description)
+	c.Flags().Int(RedisDB, DefaultRedisDB, intFlags[RedisDB].description)
+	c.Flags().Bool(RedisTLSEnabled, DefaultRedisTLSEnabled, boolFlags[RedisTLSEnabled].description)
+	c.Flags().Bool(RedisInsecureSkipVerify, DefaultRedisInsecureSkipVerify, boolFlags[RedisInsecureSkipVerify].description)
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		m.Viper.BindPFlag(f.Name, f) // nolint: errcheck
+	})
+	return c
+}
+
+func (m *MigrateDBCmd) run() error {
+	fromType := m.Viper.GetString(MigrateDBFromFlag)
+	toType := m.Viper.GetString(MigrateDBToFlag)
+	if fromType == "" || toType == "" {
+		return fmt.Errorf("--%s and --%s must be set", MigrateDBFromFlag, MigrateDBToFlag)
+	}
+	if fromType == toType {
+		return fmt.Errorf("--%s and --%s must be different", MigrateDBFromFlag, MigrateDBToFlag)
+	}
+
+	from, err := m.openDB(fromType)
+	if err != nil {
+		return errors.Wrapf(err, "opening %s", fromType)
+	}
+	to, err := m.openDB(toType)
+	if err != nil {
+		return errors.Wrapf(err, "opening %s", toType)
+	}
+
+	result, err := locking.Migrate(from, to)
+	if err != nil {
+		return errors.Wrapf(err, "migrating from %s to %s", fromType, toType)
+	}
+	fmt.Printf("Copied and verified %d locks, %d pull statuses, %d drift detection results and %d command locks from %s to %s.\n",
+		result.Locks, result.PullStatuses, result.DriftResults, result.CommandLocks, fromType, toType)
+	return nil
+}
+
+// openDB opens the locking database of type dbType, ex. boltdb.
+func (m *MigrateDBCmd) openDB(dbType string) (locking.MigrationTarget, error) {
+	switch dbType {
+	case "boltdb":
+		dataDir, err := homedir.Expand(m.Viper.GetString(DataDirFlag))
+		if err != nil {
+			return nil, err
+		}
+		return db.New(dataDir)
+	case "redis":
+		return redis.New(
+			m.Viper.GetString(RedisHost),
+			m.Viper.GetInt(RedisPort),
+			m.Viper.GetString(RedisPassword),
+			m.Viper.GetBool(RedisTLSEnabled),
+			m.Viper.GetBool(RedisInsecureSkipVerify),
+			m.Viper.GetInt(RedisDB),
+		)
+	}
+	return nil, fmt.Errorf("unknown locking database type %q, must be boltdb or redis", dbType)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestMigrateDBCmd_Validation(t *testing.T) {
+	cases := []struct {
+		args   []string
+		expErr string
+	}{
+		{[]string{"--from", "boltdb"}, "--from and --to must be set"},
+		{[]string{"--from", "boltdb", "--to", "boltdb"}, "--from and --to must be different"},
+		{[]string{"--from", "postgres", "--to", "boltdb"}, "opening postgres: unknown locking database type \"postgres\", must be boltdb or redis"},
+	}
+	for _, c := range cases {
+		t.Run(c.expErr, func(t *testing.T) {
+			cmd := (&MigrateDBCmd{Viper: viper.New()}).Init()
+			cmd.SetArgs(c.args)
+			ErrEquals(t, c.expErr, cmd.Execute())
+		})
+	}
+}
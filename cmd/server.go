@@ -37,12 +37,31 @@ const (
 	CheckoutStrategyMerge  = "merge"
 )
 
+// comment cleanups of closed pull requests
+const (
+	PullClosedCommentsKeep     = "keep"
+	PullClosedCommentsCollapse = "collapse"
+	PullClosedCommentsDelete   = "delete"
+)
+
+// Bitbucket token types
+const (
+	BitbucketTokenTypeAppPassword = "app-password"
+	BitbucketTokenTypeAccessToken = "access-token"
+)
+
 // TF distributions
 const (
 	TFDistributionTerraform = "terraform"
 	TFDistributionOpenTofu  = "opentofu"
 )
 
+// Executors
+const (
+	ExecutorLocal      = "local"
+	ExecutorKubernetes = "kubernetes"
+)
+
 // To add a new flag you must:
 // 1. Add a const with the flag name (in alphabetic order).
 // 2. Add a new field to server.UserConfig and set the mapstructure tag equal to the flag name.
@@ -54,26 +73,46 @@ const (
 	ADTokenFlag                      = "azuredevops-token" // nolint: gosec
 	ADUserFlag                       = "azuredevops-user"
 	ADHostnameFlag                   = "azuredevops-hostname"
+	ADClientIDFlag                   = "azuredevops-client-id"
+	ADClientSecretFlag               = "azuredevops-client-secret" // nolint: gosec
+	ADFederatedTokenFileFlag         = "azuredevops-federated-token-file"
+	ADTenantIDFlag                   = "azuredevops-tenant-id"
 	AllowCommandsFlag                = "allow-commands"
 	AllowForkPRsFlag                 = "allow-fork-prs"
+	ApplyTimeoutFlag                 = "apply-timeout"
 	AtlantisURLFlag                  = "atlantis-url"
 	AutoDiscoverModeFlag             = "autodiscover-mode"
 	AutomergeFlag                    = "automerge"
 	ParallelPlanFlag                 = "parallel-plan"
 	ParallelApplyFlag                = "parallel-apply"
+	ParallelPoolAdaptiveFlag         = "parallel-pool-adaptive"
+	PlanDiffCommentsFlag             = "plan-diff-comments"
+	PlanStoreURLFlag                 = "plan-store-url"
+	PlanTimeoutFlag                  = "plan-timeout"
+	PolicyCheckTimeoutFlag           = "policy-check-timeout"
 	AutoplanModules                  = "autoplan-modules"
 	AutoplanModulesFromProjects      = "autoplan-modules-from-projects"
 	AutoplanFileListFlag             = "autoplan-file-list"
 	BitbucketBaseURLFlag             = "bitbucket-base-url"
+	BitbucketOAuthClientIDFlag       = "bitbucket-oauth-client-id"
+	BitbucketOAuthClientSecretFlag   = "bitbucket-oauth-client-secret" // nolint: gosec
 	BitbucketTokenFlag               = "bitbucket-token"
+	BitbucketTokenTypeFlag           = "bitbucket-token-type"
 	BitbucketUserFlag                = "bitbucket-user"
 	BitbucketWebhookSecretFlag       = "bitbucket-webhook-secret"
+	BoltDBAutoCompactFlag            = "boltdb-auto-compact"
+	BoltDBSizeWarningFlag            = "boltdb-size-warning"
 	CheckoutDepthFlag                = "checkout-depth"
+	CheckoutFilterFlag               = "checkout-filter"
 	CheckoutStrategyFlag             = "checkout-strategy"
+	CircuitBreakerCooldownFlag       = "circuit-breaker-cooldown"
+	CircuitBreakerThresholdFlag      = "circuit-breaker-threshold"
 	ConfigFlag                       = "config"
+	CustomCommandTimeoutFlag         = "custom-command-timeout"
 	DataDirFlag                      = "data-dir"
 	DefaultTFDistributionFlag        = "default-tf-distribution"
 	DefaultTFVersionFlag             = "default-tf-version"
+	DestroyApproversFlag             = "destroy-approvers"
 	DisableApplyAllFlag              = "disable-apply-all"
 	DisableAutoplanFlag              = "disable-autoplan"
 	DisableAutoplanLabelFlag         = "disable-autoplan-label"
@@ -82,12 +121,23 @@ const (
 	DisableGlobalApplyLockFlag       = "disable-global-apply-lock"
 	DisableUnlockLabelFlag           = "disable-unlock-label"
 	DiscardApprovalOnPlanFlag        = "discard-approval-on-plan"
+	DriftDetectionIntervalFlag       = "drift-detection-interval"
 	EmojiReaction                    = "emoji-reaction"
 	EnableDiffMarkdownFormat         = "enable-diff-markdown-format"
+	EnablePlanCacheFlag              = "enable-plan-cache"
+	EnablePlanHistoryFlag            = "enable-plan-history"
 	EnablePolicyChecksFlag           = "enable-policy-checks"
 	EnableRegExpCmdFlag              = "enable-regexp-cmd"
 	ExecutableName                   = "executable-name"
+	ExecutorFlag                     = "executor"
 	FailOnPreWorkflowHookError       = "fail-on-pre-workflow-hook-error"
+	ForkPRApprovalLabelFlag          = "fork-pr-approval-label"
+	ForkPRApproversFlag              = "fork-pr-approvers"
+	ForkPRRequireApprovalFlag        = "fork-pr-require-approval"
+	GCDryRunFlag                     = "gc-dry-run"
+	GCIntervalFlag                   = "gc-interval"
+	GCPlanMaxAgeFlag                 = "gc-plan-max-age"
+	GCWorkingDirMaxAgeFlag           = "gc-working-dir-max-age"
 	HideUnchangedPlanComments        = "hide-unchanged-plan-comments"
 	GHHostnameFlag                   = "gh-hostname"
 	GHTeamAllowlistFlag              = "gh-team-allowlist"
@@ -99,7 +149,10 @@ const (
 	GHAppKeyFileFlag                 = "gh-app-key-file"
 	GHAppSlugFlag                    = "gh-app-slug"
 	GHAppInstallationIDFlag          = "gh-app-installation-id"
+	GHCheckRunsFlag                  = "gh-check-runs"
 	GHOrganizationFlag               = "gh-org"
+	GHDeletePrevCommentChunksFlag    = "gh-delete-prev-comment-chunks"
+	GHResponseCacheTTLFlag           = "gh-response-cache-ttl"
 	GHWebhookSecretFlag              = "gh-webhook-secret"               // nolint: gosec
 	GHAllowMergeableBypassApply      = "gh-allow-mergeable-bypass-apply" // nolint: gosec
 	GiteaBaseURLFlag                 = "gitea-base-url"
@@ -113,17 +166,30 @@ const (
 	GitlabUserFlag                   = "gitlab-user"
 	GitlabWebhookSecretFlag          = "gitlab-webhook-secret" // nolint: gosec
 	IncludeGitUntrackedFiles         = "include-git-untracked-files"
+	JobOutputRetentionFlag           = "job-output-retention"
+	KubernetesDataDirClaimFlag       = "kubernetes-executor-data-dir-claim"
+	KubernetesNamespaceFlag          = "kubernetes-executor-namespace"
+	KubernetesPodTemplateFlag        = "kubernetes-executor-pod-template"
 	APISecretFlag                    = "api-secret"
+	APIPlanReadTokensFlag            = "api-plan-read-tokens"
 	HidePrevPlanComments             = "hide-prev-plan-comments"
 	QuietPolicyChecks                = "quiet-policy-checks"
+	ReconcileOnStartupFlag           = "reconcile-on-startup"
+	ReplanDependentsFlag             = "replan-dependents"
 	LockingDBType                    = "locking-db-type"
 	LogLevelFlag                     = "log-level"
 	MarkdownTemplateOverridesDirFlag = "markdown-template-overrides-dir"
 	MaxCommentsPerCommand            = "max-comments-per-command"
+	MaxConcurrentAppliesFlag         = "max-concurrent-applies"
+	MaxConcurrentAppliesPerRepoFlag  = "max-concurrent-applies-per-repo"
+	OrphanedLockCleanupIntervalFlag  = "orphaned-lock-cleanup-interval"
 	ParallelPoolSize                 = "parallel-pool-size"
 	StatsNamespace                   = "stats-namespace"
 	AllowDraftPRs                    = "allow-draft-prs"
+	PersistJobOutputFlag             = "persist-job-output"
 	PortFlag                         = "port"
+	PullClosedCommentsFlag           = "pull-closed-comments"
+	ProjectCommandHookURLFlag        = "project-command-hook-url"
 	RedisDB                          = "redis-db"
 	RedisHost                        = "redis-host"
 	RedisPassword                    = "redis-password"
@@ -132,6 +198,7 @@ const (
 	RedisInsecureSkipVerify          = "redis-insecure-skip-verify"
 	RepoConfigFlag                   = "repo-config"
 	RepoConfigJSONFlag               = "repo-config-json"
+	RepoConfigValidationIntervalFlag = "repo-config-validation-interval"
 	RepoAllowlistFlag                = "repo-allowlist"
 	SilenceNoProjectsFlag            = "silence-no-projects"
 	SilenceForkPRErrorsFlag          = "silence-fork-pr-errors"
@@ -140,20 +207,33 @@ const (
 	SilenceAllowlistErrorsFlag       = "silence-allowlist-errors"
 	SkipCloneNoChanges               = "skip-clone-no-changes"
 	SlackTokenFlag                   = "slack-token"
+	SparseCheckoutFlag               = "sparse-checkout"
+	SparseCheckoutDirsFlag           = "sparse-checkout-dirs"
 	SSLCertFileFlag                  = "ssl-cert-file"
 	SSLKeyFileFlag                   = "ssl-key-file"
+	StatePushAdminsFlag              = "state-push-admins"
+	StepPluginsFlag                  = "step-plugins"
+	SummaryCommentFlag               = "summary-comment"
 	RestrictFileList                 = "restrict-file-list"
+	TelemetryEndpointFlag            = "telemetry-endpoint"
+	TelemetryIntervalFlag            = "telemetry-interval"
 	TFDistributionFlag               = "tf-distribution" // deprecated for DefaultTFDistributionFlag
 	TFDownloadFlag                   = "tf-download"
 	TFDownloadURLFlag                = "tf-download-url"
+	TofuDownloadURLFlag              = "tofu-download-url"
+	UseTFInitCacheFlag               = "use-tf-init-cache"
 	UseTFPluginCache                 = "use-tf-plugin-cache"
 	VarFileAllowlistFlag             = "var-file-allowlist"
+	VCSAdapterFlag                   = "vcs-adapter"
+	VCSStatusDebounceFlag            = "vcs-status-debounce"
 	VCSStatusName                    = "vcs-status-name"
 	IgnoreVCSStatusNames             = "ignore-vcs-status-names"
 	TFEHostnameFlag                  = "tfe-hostname"
 	TFELocalExecutionModeFlag        = "tfe-local-execution-mode"
+	TFERunTaskHMACKeyFlag            = "tfe-run-task-hmac-key" // nolint: gosec
 	TFETokenFlag                     = "tfe-token"
 	WriteGitCredsFlag                = "write-git-creds" // nolint: gosec
+	WebhookDedupWindowFlag           = "webhook-dedup-window"
 	WebhookHttpHeaders               = "webhook-http-headers"
 	WebBasicAuthFlag                 = "web-basic-auth"
 	WebUsernameFlag                  = "web-username"
@@ -168,16 +248,24 @@ const (
 	DefaultAutoplanFileList             = "**/*.tf,**/*.tfvars,**/*.tfvars.json,**/terragrunt.hcl,**/.terraform.lock.hcl"
 	DefaultAllowCommands                = "version,plan,apply,unlock,approve_policies"
 	DefaultCheckoutStrategy             = CheckoutStrategyBranch
+	DefaultPullClosedComments           = PullClosedCommentsKeep
+	DefaultCircuitBreakerCooldown       = 15
 	DefaultCheckoutDepth                = 0
 	DefaultBitbucketBaseURL             = bitbucketcloud.BaseURL
+	DefaultBitbucketTokenType           = BitbucketTokenTypeAppPassword
 	DefaultDataDir                      = "~/.atlantis"
+	DefaultDriftDetectionInterval       = 1440
 	DefaultEmojiReaction                = ""
 	DefaultExecutableName               = "atlantis"
+	DefaultExecutor                     = ExecutorLocal
+	DefaultGCPlanMaxAge                 = 168
+	DefaultGCWorkingDirMaxAge           = 720
 	DefaultMarkdownTemplateOverridesDir = "~/.markdown_templates"
 	DefaultGHHostname                   = "github.com"
 	DefaultGiteaBaseURL                 = "https://gitea.com"
 	DefaultGiteaPageSize                = 30
 	DefaultGitlabHostname               = "gitlab.com"
+	DefaultJobOutputRetention           = 168
 	DefaultLockingDBType                = "boltdb"
 	DefaultLogLevel                     = "info"
 	DefaultIgnoreVCSStatusNames         = ""
@@ -189,12 +277,14 @@ const (
 	DefaultRedisPort                    = 6379
 	DefaultRedisTLSEnabled              = false
 	DefaultRedisInsecureSkipVerify      = false
+	DefaultTelemetryInterval            = 1440
 	DefaultTFDistribution               = TFDistributionTerraform
 	DefaultTFDownloadURL                = "https://releases.hashicorp.com"
 	DefaultTFDownload                   = true
 	DefaultTFEHostname                  = "app.terraform.io"
 	DefaultVCSStatusName                = "atlantis"
 	DefaultWebBasicAuth                 = false
+	DefaultWebhookDedupWindow           = 10
 	DefaultWebUsername                  = "atlantis"
 	DefaultWebPassword                  = "atlantis"
 )
@@ -222,6 +312,18 @@ var stringFlags = map[string]stringFlag{
 		description:  "Azure DevOps hostname to support cloud and self hosted instances.",
 		defaultValue: "dev.azure.com",
 	},
+	ADClientIDFlag: {
+		description: "Client ID of the Entra ID service principal Atlantis authenticates to Azure DevOps as, instead of using --" + ADTokenFlag + ". Requires --" + ADTenantIDFlag + " and --" + ADClientSecretFlag + " or --" + ADFederatedTokenFileFlag + ".",
+	},
+	ADClientSecretFlag: {
+		description: "Client secret of the Entra ID service principal set with --" + ADClientIDFlag + ". Should be specified via the ATLANTIS_AZUREDEVOPS_CLIENT_SECRET environment variable.",
+	},
+	ADFederatedTokenFileFlag: {
+		description: "File with the federated token the Entra ID service principal set with --" + ADClientIDFlag + " authenticates with when using workload identity federation, ex. the value of AZURE_FEDERATED_TOKEN_FILE on AKS. The file is re-read when the token is rotated.",
+	},
+	ADTenantIDFlag: {
+		description: "Entra ID tenant of the service principal set with --" + ADClientIDFlag + ".",
+	},
 	AllowCommandsFlag: {
 		description:  "Comma separated list of acceptable atlantis commands.",
 		defaultValue: DefaultAllowCommands,
@@ -252,8 +354,19 @@ var stringFlags = map[string]stringFlag{
 	BitbucketUserFlag: {
 		description: "Bitbucket username of API user.",
 	},
+	BitbucketOAuthClientIDFlag: {
+		description: "Key of the Bitbucket Cloud OAuth consumer Atlantis authenticates as, instead of using --" + BitbucketTokenFlag + ". Requires --" + BitbucketOAuthClientSecretFlag + ".",
+	},
+	BitbucketOAuthClientSecretFlag: {
+		description: "Secret of the Bitbucket Cloud OAuth consumer set with --" + BitbucketOAuthClientIDFlag + ". Should be specified via the ATLANTIS_BITBUCKET_OAUTH_CLIENT_SECRET environment variable.",
+	},
 	BitbucketTokenFlag: {
-		description: "Bitbucket app password of API user. Can also be specified via the ATLANTIS_BITBUCKET_TOKEN environment variable.",
+		description: "Bitbucket app password of API user, or a Bitbucket Cloud access token if --" + BitbucketTokenTypeFlag + " is " + BitbucketTokenTypeAccessToken + ". Can also be specified via the ATLANTIS_BITBUCKET_TOKEN environment variable.",
+	},
+	BitbucketTokenTypeFlag: {
+		description: "Type of --" + BitbucketTokenFlag + ". Accepts either '" + BitbucketTokenTypeAppPassword + "' (default) or '" + BitbucketTokenTypeAccessToken + "'" +
+			" for a Bitbucket Cloud repository, project or workspace access token.",
+		defaultValue: DefaultBitbucketTokenType,
 	},
 	BitbucketBaseURLFlag: {
 		description: "Base URL of Bitbucket Server (aka Stash) installation." +
@@ -267,6 +380,10 @@ var stringFlags = map[string]stringFlag{
 			"This means that an attacker could spoof calls to Atlantis and cause it to perform malicious actions. " +
 			"Should be specified via the ATLANTIS_BITBUCKET_WEBHOOK_SECRET environment variable.",
 	},
+	CheckoutFilterFlag: {
+		description: "Filter of partial clones, ex. 'blob:none', so that only the contents of the files that are checked out are fetched." +
+			" If not set, repos are cloned in full.",
+	},
 	CheckoutStrategyFlag: {
 		description: "How to check out pull requests. Accepts either 'branch' (default) or 'merge'." +
 			" If set to branch, Atlantis will check out the source branch of the pull request." +
@@ -283,6 +400,10 @@ var stringFlags = map[string]stringFlag{
 		description:  "Path to directory to store Atlantis data.",
 		defaultValue: DefaultDataDir,
 	},
+	DestroyApproversFlag: {
+		description: "Comma separated list of users allowed to confirm atlantis destroy. If not set, anyone that can apply can confirm it." +
+			" Entries are usernames, team:<team> or org:<org>, as for --" + ForkPRApproversFlag + ".",
+	},
 	DisableAutoplanLabelFlag: {
 		description:  "Pull request label to disable atlantis auto planning feature only if present.",
 		defaultValue: "",
@@ -299,6 +420,19 @@ var stringFlags = map[string]stringFlag{
 		description:  "Comment command executable name.",
 		defaultValue: DefaultExecutableName,
 	},
+	ExecutorFlag: {
+		description: fmt.Sprintf("Where the shell commands of workflow steps run, ex. terraform plan and run steps. One of %q, on the Atlantis server, or %q, in a Kubernetes Job per command."+
+			" The %q executor needs --%s and --%s.", ExecutorLocal, ExecutorKubernetes, ExecutorKubernetes, KubernetesPodTemplateFlag, KubernetesDataDirClaimFlag),
+		defaultValue: DefaultExecutor,
+	},
+	ForkPRApprovalLabelFlag: {
+		description: "Pull request label that approves running Atlantis commands on the current commit of a pull request from a fork when --" + ForkPRRequireApprovalFlag + " is set." +
+			" Only supported on GitHub and GitLab. If --" + ForkPRApproversFlag + " is set, only they can approve with the label, otherwise anyone who can label pull requests can.",
+	},
+	ForkPRApproversFlag: {
+		description: "Comma separated list of users allowed to approve running Atlantis commands on a pull request from a fork with the ok-to-test command when --" + ForkPRRequireApprovalFlag + " is set." +
+			" Entries are usernames, team:<team> for the members of a GitHub team or GitLab group, or org:<org> for the members of a GitHub organization or GitLab group.",
+	},
 	GHHostnameFlag: {
 		description:  "Hostname of your Github Enterprise installation. If using github.com, no need to set.",
 		defaultValue: DefaultGHHostname,
@@ -306,7 +440,7 @@ var stringFlags = map[string]stringFlag{
 	GHTeamAllowlistFlag: {
 		description: "Comma separated list of key-value pairs representing the GitHub teams and the operations that " +
 			"the members of a particular team are allowed to perform. " +
-			"The format is {team}:{command},{team}:{command}. " +
+			"The format is {team}:{command},{team}:{command}, where {team} can be org:{org} for the members of a GitHub organization. " +
 			"Valid values for 'command' are 'plan', 'apply' and '*', e.g. 'dev:plan,ops:apply,devops:*'" +
 			"This example gives the users from the 'dev' GitHub team the permissions to execute the 'plan' command, " +
 			"the 'ops' team the permissions to execute the 'apply' command, " +
@@ -364,7 +498,7 @@ var stringFlags = map[string]stringFlag{
 	GitlabGroupAllowlistFlag: {
 		description: "Comma separated list of key-value pairs representing the GitLab groups and the operations that " +
 			"the members of a particular group are allowed to perform. " +
-			"The format is {group}:{command},{group}:{command}. " +
+			"The format is {group}:{command},{group}:{command}, where {group} can be org:{group} for the members of a GitLab group and its subgroups. " +
 			"Valid values for 'command' are 'plan', 'apply' and '*', e.g. 'myorg/dev:plan,myorg/ops:apply,myorg/devops:*'" +
 			"This example gives the users from the 'myorg/dev' GitLab group the permissions to execute the 'plan' command, " +
 			"the 'myorg/ops' group the permissions to execute the 'apply' command, " +
@@ -391,6 +525,19 @@ var stringFlags = map[string]stringFlag{
 	APISecretFlag: {
 		description: "Secret used to validate requests made to the /api/* endpoints",
 	},
+	APIPlanReadTokensFlag: {
+		description: "Comma-separated {token}:{repo} pairs of tokens that can only download the plans of the repos that match {repo}, ex. 'token1:github.com/org/*'." +
+			" {repo} is a pattern like those of --" + RepoAllowlistFlag + ". Should be specified via the ATLANTIS_API_PLAN_READ_TOKENS environment variable.",
+	},
+	KubernetesDataDirClaimFlag: {
+		description: "Name of the PersistentVolumeClaim of --" + DataDirFlag + " that the Jobs of the kubernetes executor mount at the same path as the server.",
+	},
+	KubernetesNamespaceFlag: {
+		description: "Namespace that the kubernetes executor creates its Jobs in. Defaults to the namespace of kubectl's context.",
+	},
+	KubernetesPodTemplateFlag: {
+		description: "Path to a YAML file with the pod template of the Jobs of the kubernetes executor. Its first container runs the commands.",
+	},
 	LockingDBType: {
 		description:  "The locking database type to use for storing plan and apply locks.",
 		defaultValue: DefaultLockingDBType,
@@ -413,6 +560,21 @@ var stringFlags = map[string]stringFlag{
 	RedisPassword: {
 		description: "The Redis Password for when using a Locking DB type of 'redis'.",
 	},
+	PlanStoreURLFlag: {
+		description: "URL of the bucket that plans are stored in so that they can be applied after a restart or by another Atlantis server," +
+			" ex. s3://my-bucket?region=us-east-1, gs://my-bucket, azblob://my-container or file:///path/to/dir." +
+			" If not set, plans are only kept in the data dir.",
+	},
+	ProjectCommandHookURLFlag: {
+		description: "URL of an HTTP service that the project commands Atlantis built are POSTed to before they're run." +
+			" It can reorder and filter the projects and set environment variables for their steps.",
+	},
+	PullClosedCommentsFlag: {
+		description: "What to do with the comments Atlantis made on a pull request once it's merged or closed. Accepts 'keep' (default), 'collapse' or 'delete'." +
+			" If set to collapse or delete, they're collapsed or deleted and replaced by a single summary comment." +
+			" VCS hosts that can't collapse comments delete them instead. VCS support is limited to: GitHub, GitLab, Gitea, Bitbucket Cloud, Bitbucket Server and Azure DevOps.",
+		defaultValue: DefaultPullClosedComments,
+	},
 	RepoConfigFlag: {
 		description: "Path to a repo config file, used to customize how Atlantis runs on each repo. See runatlantis.io/docs for more details.",
 	},
@@ -423,17 +585,33 @@ var stringFlags = map[string]stringFlag{
 		description: "Comma separated list of repositories that Atlantis will operate on. " +
 			"The format is {hostname}/{owner}/{repo}, ex. github.com/runatlantis/atlantis. '*' matches any characters until the next comma. Examples: " +
 			"all repos: '*' (not secure), an entire hostname: 'internalgithub.com/*' or an organization: 'github.com/runatlantis/*'." +
+			" Entries starting with '!' exclude the repos they match, ex. 'github.com/runatlantis/*,!github.com/runatlantis/sandbox-*'." +
 			" For Bitbucket Server, {owner} is the name of the project (not the key).",
 	},
 	SlackTokenFlag: {
 		description: "API token for Slack notifications.",
 	},
+	SparseCheckoutDirsFlag: {
+		description: fmt.Sprintf("Comma separated list of dirs that are always checked out with --%s, ex. modules.", SparseCheckoutFlag),
+	},
 	SSLCertFileFlag: {
 		description: "File containing x509 Certificate used for serving HTTPS. If the cert is signed by a CA, the file should be the concatenation of the server's certificate, any intermediates, and the CA's certificate.",
 	},
 	SSLKeyFileFlag: {
 		description: fmt.Sprintf("File containing x509 private key matching --%s.", SSLCertFileFlag),
 	},
+	StatePushAdminsFlag: {
+		description: "Comma separated list of users allowed to replace the state of a project with the state push command. If not set, state push is disabled." +
+			" Entries are usernames, team:<team> or org:<org>, as for --" + ForkPRApproversFlag + ".",
+	},
+	StepPluginsFlag: {
+		description: "Comma-separated list of step plugins in the format <name>=<path to binary>, ex. pulumi=/usr/local/bin/atlantis-pulumi." +
+			" Plugin steps in workflows are run by the plugin with their name.",
+	},
+	TelemetryEndpointFlag: {
+		description: "URL to periodically POST anonymous usage telemetry to: the Atlantis version, the configured VCS hosts, and counts of project commands and their error classes." +
+			" Telemetry is off unless this is set, and every report is logged before it's sent.",
+	},
 	TFDistributionFlag: {
 		description: "[Deprecated for --default-tf-distribution].",
 		hidden:      true,
@@ -442,10 +620,21 @@ var stringFlags = map[string]stringFlag{
 		description:  "Base URL to download Terraform versions from.",
 		defaultValue: DefaultTFDownloadURL,
 	},
+	TofuDownloadURLFlag: {
+		description: "Base URL of a mirror to download OpenTofu versions from, ex. one created with tofudl." +
+			" The mirror must serve the OpenTofu releases API at <url>/api.json and the release files at <url>/v<version>/<file>." +
+			" Downloads are verified against the release checksums and the OpenTofu signing key." +
+			" If not set, OpenTofu is downloaded from the official OpenTofu releases.",
+	},
 	TFEHostnameFlag: {
 		description:  "Hostname of your Terraform Enterprise installation. If using Terraform Cloud no need to set.",
 		defaultValue: DefaultTFEHostname,
 	},
+	TFERunTaskHMACKeyFlag: {
+		description: "HMAC key of the Terraform Cloud/Enterprise run task that calls Atlantis." +
+			" If set, the /tfe/run-task endpoint checks the plans of runs that call it against the server's policy sets." +
+			" Should be specified via the ATLANTIS_TFE_RUN_TASK_HMAC_KEY environment variable for security.",
+	},
 	TFETokenFlag: {
 		description: "API token for Terraform Cloud/Enterprise. This will be used to generate a ~/.terraformrc file." +
 			" Only set if using TFC/E as a remote backend." +
@@ -469,6 +658,10 @@ var stringFlags = map[string]stringFlag{
 			" Currently only implemented for GitHub.",
 		defaultValue: DefaultIgnoreVCSStatusNames,
 	},
+	VCSAdapterFlag: {
+		description: "Path to a VCS adapter binary that Atlantis starts to support a VCS host it has no built-in client for." +
+			" Webhooks for that host are sent to /events/vcs-adapter.",
+	},
 	VCSStatusName: {
 		description:  "Name used to identify Atlantis for pull request statuses.",
 		defaultValue: DefaultVCSStatusName,
@@ -502,6 +695,10 @@ var boolFlags = map[string]boolFlag{
 		description:  "Automatically merge pull requests when all plans are successfully applied.",
 		defaultValue: false,
 	},
+	BoltDBAutoCompactFlag: {
+		description:  "Compact the BoltDB database file once most of it is free space, so that it doesn't grow without bound. Lock operations wait while the file is compacted. Only used with the boltdb locking-db-type.",
+		defaultValue: false,
+	},
 	DisableApplyAllFlag: {
 		description:  "Disable \"atlantis apply\" command without any flags (i.e. apply all). A specific project/workspace/directory has to be specified for applies.",
 		defaultValue: false,
@@ -521,6 +718,14 @@ var boolFlags = map[string]boolFlag{
 		description:  "Enables the discarding of approval if a new plan has been executed. Currently only Github is supported",
 		defaultValue: false,
 	},
+	EnablePlanCacheFlag: {
+		description:  "Reuse the last plan of a project instead of planning it again if the project, the local modules and var files it uses, the base branch and its state haven't changed since. Drift of the infrastructure isn't detected.",
+		defaultValue: false,
+	},
+	EnablePlanHistoryFlag: {
+		description:  "Store the resource changes of the successive plans of each project of a pull request and show what changed between them at /plan-history.",
+		defaultValue: false,
+	},
 	EnablePolicyChecksFlag: {
 		description:  "Enable atlantis to run user defined policy checks.  This is explicitly disabled for TFE/TFC backends since plan files are inaccessible.",
 		defaultValue: false,
@@ -537,10 +742,23 @@ var boolFlags = map[string]boolFlag{
 		description:  "Fail and do not run the requested Atlantis command if any of the pre workflow hooks error.",
 		defaultValue: false,
 	},
+	ForkPRRequireApprovalFlag: {
+		description: "Require pull requests from forks to be approved, with the --" + ForkPRApprovalLabelFlag + " label or an ok-to-test comment from one of --" + ForkPRApproversFlag +
+			", before any Atlantis command is run on them. Requires --" + AllowForkPRsFlag + ".",
+		defaultValue: false,
+	},
+	GCDryRunFlag: {
+		description:  "Log what garbage collection would delete instead of deleting it.",
+		defaultValue: false,
+	},
 	GHAllowMergeableBypassApply: {
 		description:  "Feature flag to enable functionality to allow mergeable check to ignore apply required check",
 		defaultValue: false,
 	},
+	GHCheckRunsFlag: {
+		description:  "Report the status of each project of GitHub pull requests as a check run with the output of the command and a button to re-plan, instead of a commit status. Requires --" + GHAppIDFlag + ".",
+		defaultValue: false,
+	},
 	AllowDraftPRs: {
 		description:  "Enable autoplan for Github Draft Pull Requests",
 		defaultValue: false,
@@ -550,22 +768,55 @@ var boolFlags = map[string]boolFlag{
 			"VCS support is limited to: GitHub.",
 		defaultValue: false,
 	},
+	GHDeletePrevCommentChunksFlag: {
+		description: fmt.Sprintf("With --%s, delete the continuation comments of long previous outputs instead of hiding them, so that only the first comment of each output is kept.", HidePrevPlanComments) +
+			" VCS support is limited to: GitHub.",
+		defaultValue: false,
+	},
 	IncludeGitUntrackedFiles: {
 		description:  "Include git untracked files in the Atlantis modified file scope.",
 		defaultValue: false,
 	},
+	PersistJobOutputFlag: {
+		description:  "Persist the output of jobs to the locking database so the jobs web UI can replay it after Atlantis restarts, and list it with the /api/jobs endpoint.",
+		defaultValue: false,
+	},
 	ParallelPlanFlag: {
 		description:  "Run plan operations in parallel.",
 		defaultValue: false,
 	},
+	SummaryCommentFlag: {
+		description: "Keep the output of the latest command of each project in a single summary comment per pull request that's edited, instead of commenting every command." +
+			" VCS support is limited to: GitHub, GitLab.",
+		defaultValue: false,
+	},
+	PlanDiffCommentsFlag: {
+		description:  "Add the resources whose changes differ from the previous plan of the project to plan comments. Implies --" + EnablePlanHistoryFlag + ".",
+		defaultValue: false,
+	},
 	ParallelApplyFlag: {
 		description:  "Run apply operations in parallel.",
 		defaultValue: false,
 	},
+	ParallelPoolAdaptiveFlag: {
+		description:  "Shrink the parallel pool while the host is under CPU or memory pressure. Only supported on Linux.",
+		defaultValue: false,
+	},
 	QuietPolicyChecks: {
 		description:  "Exclude policy check comments from pull requests unless there's an actual error from conftest. This also excludes warnings.",
 		defaultValue: false,
 	},
+	ReplanDependentsFlag: {
+		description: "Re-plan the projects of a pull request that depend on a project that was just applied, or are in a later execution order group than it," +
+			" since their plans were made before it was applied and may be out of date. Only projects that have a plan are re-planned.",
+		defaultValue: false,
+	},
+	ReconcileOnStartupFlag: {
+		description: "Reconcile the working dirs and plans on disk with the database and the VCS hosts when Atlantis starts." +
+			" Pull requests that were closed while Atlantis was down are cleaned up, working dirs and plans that the database doesn't know about are deleted" +
+			" and the plan and apply commit statuses of open pull requests are restored, so that a crash doesn't leave them inconsistent.",
+		defaultValue: false,
+	},
 	RedisTLSEnabled: {
 		description:  "Enable TLS on the connection to Redis with a min TLS version of 1.2",
 		defaultValue: DefaultRedisTLSEnabled,
@@ -607,6 +858,11 @@ var boolFlags = map[string]boolFlag{
 		description:  "Skips cloning the PR repo if there are no projects were changed in the PR.",
 		defaultValue: false,
 	},
+	SparseCheckoutFlag: {
+		description: "Only check out the root files of repos and the dirs of the files modified by pull requests." +
+			fmt.Sprintf(" Dirs that projects use but aren't modified, ex. modules, must be set with --%s.", SparseCheckoutDirsFlag),
+		defaultValue: false,
+	},
 	TFDownloadFlag: {
 		description:  "Allow Atlantis to list & download Terraform versions. Setting this to false can be helpful in air-gapped environments.",
 		defaultValue: DefaultTFDownload,
@@ -631,22 +887,91 @@ var boolFlags = map[string]boolFlag{
 		description:  "Remove no-changes plan comments from the pull request.",
 		defaultValue: false,
 	},
+	UseTFInitCacheFlag: {
+		description:  "Cache the providers downloaded by terraform init, keyed by the lock file, and share them between the projects of a repo.",
+		defaultValue: false,
+	},
 	UseTFPluginCache: {
 		description:  "Enable the use of the Terraform plugin cache",
 		defaultValue: true,
 	},
 }
 var intFlags = map[string]intFlag{
+	ApplyTimeoutFlag: {
+		description:  "Number of minutes an apply of a project can run before it's canceled. Repos can override it with command_timeouts. Set to 0 to disable.",
+		defaultValue: 0,
+	},
+	BoltDBSizeWarningFlag: {
+		description:  "Size in megabytes of the BoltDB database file above which a warning is logged every hour. Set to 0 to disable the warning. Only used with the boltdb locking-db-type.",
+		defaultValue: 0,
+	},
+	CircuitBreakerCooldownFlag: {
+		description:  fmt.Sprintf("Number of minutes commands aren't run on a pull request once its circuit is open. See --%s.", CircuitBreakerThresholdFlag),
+		defaultValue: DefaultCircuitBreakerCooldown,
+	},
+	CircuitBreakerThresholdFlag: {
+		description:  "Number of consecutive projects of a pull request that must fail with credential or provider errors, ex. an expired token or 5xx responses, before Atlantis stops running commands on the pull request for a while. Set to 0 to disable.",
+		defaultValue: 0,
+	},
 	CheckoutDepthFlag: {
 		description: fmt.Sprintf("Used only if --%s=%s.", CheckoutStrategyFlag, CheckoutStrategyMerge) +
 			" How many commits to include in each of base and feature branches when cloning repository." +
 			" If merge base is further behind than this number of commits from any of branches heads, full fetch will be performed.",
 		defaultValue: DefaultCheckoutDepth,
 	},
+	CustomCommandTimeoutFlag: {
+		description:  "Number of minutes a custom command can run on a project before it's canceled. Repos can override it with command_timeouts. Set to 0 to disable.",
+		defaultValue: 0,
+	},
+	DriftDetectionIntervalFlag: {
+		description:  "Number of minutes between drift detection runs for the repos with drift_detection set in the server-side repo config. Set to 0 to disable drift detection.",
+		defaultValue: DefaultDriftDetectionInterval,
+	},
+	GCIntervalFlag: {
+		description: "Number of minutes between garbage collection runs, which delete the plans, working dirs and pull request data left behind when Atlantis misses a pull request being closed." +
+			" Set to 0 to disable garbage collection.",
+		defaultValue: 0,
+	},
+	GCPlanMaxAgeFlag: {
+		description:  "Number of hours after which garbage collection deletes an unapplied plan. Set to 0 to keep plans until their working dir is deleted.",
+		defaultValue: DefaultGCPlanMaxAge,
+	},
+	GCWorkingDirMaxAgeFlag: {
+		description:  "Number of hours after which garbage collection deletes a pull request's working dir if no command was run on it. Set to 0 to never delete working dirs.",
+		defaultValue: DefaultGCWorkingDirMaxAge,
+	},
+	JobOutputRetentionFlag: {
+		description:  "Number of hours the output of a job is kept after it was last updated, with --" + PersistJobOutputFlag + ". Set to 0 to keep it forever.",
+		defaultValue: DefaultJobOutputRetention,
+	},
 	MaxCommentsPerCommand: {
 		description:  "If non-zero, the maximum number of comments to split command output into before truncating.",
 		defaultValue: DefaultMaxCommentsPerCommand,
 	},
+	MaxConcurrentAppliesFlag: {
+		description: "Maximum number of projects applied at the same time across all repos. Applies over the limit wait in a queue" +
+			" and their position is commented on the pull request. Set to 0 for no limit.",
+		defaultValue: 0,
+	},
+	MaxConcurrentAppliesPerRepoFlag: {
+		description: "Maximum number of projects of the same repo applied at the same time. Applies over the limit wait in a queue" +
+			" and their position is commented on the pull request. Set to 0 for no limit.",
+		defaultValue: 0,
+	},
+	RepoConfigValidationIntervalFlag: {
+		description: "Number of minutes between validations of the repo config on the default branch of every known repo against the current server-side config." +
+			" Set to 0 to disable.",
+		defaultValue: 0,
+	},
+	TelemetryIntervalFlag: {
+		description:  "Number of minutes between telemetry reports. Only used if --" + TelemetryEndpointFlag + " is set.",
+		defaultValue: DefaultTelemetryInterval,
+	},
+	OrphanedLockCleanupIntervalFlag: {
+		description: "Number of minutes between checks of whether the pull requests holding locks are still open." +
+			" Pull requests that were closed without Atlantis receiving the webhook are cleaned up and their locks released. Set to 0 to disable.",
+		defaultValue: 0,
+	},
 	GiteaPageSizeFlag: {
 		description:  "Optional value that specifies the number of results per page to expect from Gitea.",
 		defaultValue: DefaultGiteaPageSize,
@@ -655,6 +980,14 @@ var intFlags = map[string]intFlag{
 		description:  "Max size of the wait group that runs parallel plans and applies (if enabled).",
 		defaultValue: DefaultParallelPoolSize,
 	},
+	PlanTimeoutFlag: {
+		description:  "Number of minutes a plan of a project can run before it's canceled. Repos can override it with command_timeouts. Set to 0 to disable.",
+		defaultValue: 0,
+	},
+	PolicyCheckTimeoutFlag: {
+		description:  "Number of minutes a policy check of a project can run before it's canceled. Repos can override it with command_timeouts. Set to 0 to disable.",
+		defaultValue: 0,
+	},
 	PortFlag: {
 		description:  "Port to bind to.",
 		defaultValue: DefaultPort,
@@ -667,6 +1000,20 @@ var intFlags = map[string]intFlag{
 		description:  "The Redis Port for when using a Locking DB type of 'redis'.",
 		defaultValue: DefaultRedisPort,
 	},
+	GHResponseCacheTTLFlag: {
+		description: "Number of seconds to cache the responses of GitHub reads that are made many times per command, ex. the changed files of a pull request." +
+			" Cached reads are revalidated with conditional requests after that, and pull request metadata is revalidated every time. Set to 0 to disable.",
+		defaultValue: 0,
+	},
+	VCSStatusDebounceFlag: {
+		description: "Number of milliseconds to wait before writing the commit status of a project so that updates made in the meantime are coalesced." +
+			" Set to 0 to write each status right away.",
+		defaultValue: 0,
+	},
+	WebhookDedupWindowFlag: {
+		description:  "Number of minutes during which a repeated webhook delivery (same delivery ID or payload) is ignored. Deliveries of events older than this are rejected. Set to 0 to disable deduplication.",
+		defaultValue: DefaultWebhookDedupWindow,
+	},
 }
 
 var int64Flags = map[string]int64Flag{
@@ -869,8 +1216,11 @@ func (s *ServerCmd) run() error {
 		AtlantisVersion:           s.AtlantisVersion,
 		DefaultTFDistributionFlag: DefaultTFDistributionFlag,
 		DefaultTFVersionFlag:      DefaultTFVersionFlag,
+		KubernetesPodTemplateFlag: KubernetesPodTemplateFlag,
 		RepoConfigJSONFlag:        RepoConfigJSONFlag,
 		SilenceForkPRErrorsFlag:   SilenceForkPRErrorsFlag,
+		StepPluginsFlag:           StepPluginsFlag,
+		VCSAdapterFlag:            VCSAdapterFlag,
 	})
 
 	if err != nil {
@@ -889,12 +1239,18 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig, v *viper.Viper) {
 	if c.CheckoutDepth <= 0 {
 		c.CheckoutDepth = DefaultCheckoutDepth
 	}
+	if c.CircuitBreakerCooldown <= 0 {
+		c.CircuitBreakerCooldown = DefaultCircuitBreakerCooldown
+	}
 	if c.AllowCommands == "" {
 		c.AllowCommands = DefaultAllowCommands
 	}
 	if c.CheckoutStrategy == "" {
 		c.CheckoutStrategy = DefaultCheckoutStrategy
 	}
+	if c.PullClosedComments == "" {
+		c.PullClosedComments = DefaultPullClosedComments
+	}
 	if c.DataDir == "" {
 		c.DataDir = DefaultDataDir
 	}
@@ -913,12 +1269,18 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig, v *viper.Viper) {
 	if c.BitbucketBaseURL == "" {
 		c.BitbucketBaseURL = DefaultBitbucketBaseURL
 	}
+	if c.BitbucketTokenType == "" {
+		c.BitbucketTokenType = DefaultBitbucketTokenType
+	}
 	if c.EmojiReaction == "" {
 		c.EmojiReaction = DefaultEmojiReaction
 	}
 	if c.ExecutableName == "" {
 		c.ExecutableName = DefaultExecutableName
 	}
+	if c.Executor == "" {
+		c.Executor = DefaultExecutor
+	}
 	if c.LockingDBType == "" {
 		c.LockingDBType = DefaultLockingDBType
 	}
@@ -931,6 +1293,24 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig, v *viper.Viper) {
 	if !v.IsSet("max-comments-per-command") {
 		c.MaxCommentsPerCommand = DefaultMaxCommentsPerCommand
 	}
+	if !v.IsSet("telemetry-interval") {
+		c.TelemetryInterval = DefaultTelemetryInterval
+	}
+	if !v.IsSet("drift-detection-interval") {
+		c.DriftDetectionInterval = DefaultDriftDetectionInterval
+	}
+	if !v.IsSet("job-output-retention") {
+		c.JobOutputRetention = DefaultJobOutputRetention
+	}
+	if !v.IsSet("gc-plan-max-age") {
+		c.GCPlanMaxAge = DefaultGCPlanMaxAge
+	}
+	if !v.IsSet("gc-working-dir-max-age") {
+		c.GCWorkingDirMaxAge = DefaultGCWorkingDirMaxAge
+	}
+	if !v.IsSet("webhook-dedup-window") {
+		c.WebhookDedupWindow = DefaultWebhookDedupWindow
+	}
 	if c.ParallelPoolSize == 0 {
 		c.ParallelPoolSize = DefaultParallelPoolSize
 	}
@@ -986,12 +1366,34 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 			TFDistributionTerraform, TFDistributionOpenTofu)
 	}
 
+	switch userConfig.Executor {
+	case ExecutorLocal:
+	case ExecutorKubernetes:
+		if userConfig.KubernetesExecutorPodTemplate == "" || userConfig.KubernetesExecutorDataDirClaim == "" {
+			return fmt.Errorf("--%s and --%s must be set when --%s is %s",
+				KubernetesPodTemplateFlag, KubernetesDataDirClaimFlag, ExecutorFlag, ExecutorKubernetes)
+		}
+	default:
+		return fmt.Errorf("invalid executor: expected one of %s or %s", ExecutorLocal, ExecutorKubernetes)
+	}
+
 	checkoutStrategy := userConfig.CheckoutStrategy
 	if checkoutStrategy != CheckoutStrategyBranch && checkoutStrategy != CheckoutStrategyMerge {
 		return fmt.Errorf("invalid checkout strategy: not one of %s or %s",
 			CheckoutStrategyBranch, CheckoutStrategyMerge)
 	}
 
+	if userConfig.SparseCheckoutDirs != "" && !userConfig.SparseCheckout {
+		return fmt.Errorf("--%s requires --%s", SparseCheckoutDirsFlag, SparseCheckoutFlag)
+	}
+
+	switch userConfig.PullClosedComments {
+	case PullClosedCommentsKeep, PullClosedCommentsCollapse, PullClosedCommentsDelete:
+	default:
+		return fmt.Errorf("invalid --%s: not one of %s, %s or %s", PullClosedCommentsFlag,
+			PullClosedCommentsKeep, PullClosedCommentsCollapse, PullClosedCommentsDelete)
+	}
+
 	if (userConfig.SSLKeyFile == "") != (userConfig.SSLCertFile == "") {
 		return fmt.Errorf("--%s and --%s are both required for ssl", SSLKeyFileFlag, SSLCertFileFlag)
 	}
@@ -1003,14 +1405,21 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 	// 4. gitlab user and token set
 	// 5. bitbucket user and token set
 	// 6. azuredevops user and token set
-	// 7. any combination of the above
-	vcsErr := fmt.Errorf("--%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s must be set", GHUserFlag, GHTokenFlag, GHUserFlag, GHTokenFileFlag, GHAppIDFlag, GHAppKeyFileFlag, GHAppIDFlag, GHAppKeyFlag, GiteaUserFlag, GiteaTokenFlag, GitlabUserFlag, GitlabTokenFlag, BitbucketUserFlag, BitbucketTokenFlag, ADUserFlag, ADTokenFlag)
+	// 7. vcs adapter set
+	// 8. any combination of the above
+	vcsErr := fmt.Errorf("--%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s must be set", GHUserFlag, GHTokenFlag, GHUserFlag, GHTokenFileFlag, GHAppIDFlag, GHAppKeyFileFlag, GHAppIDFlag, GHAppKeyFlag, GiteaUserFlag, GiteaTokenFlag, GitlabUserFlag, GitlabTokenFlag, BitbucketUserFlag, BitbucketTokenFlag, ADUserFlag, ADTokenFlag, VCSAdapterFlag)
 	if ((userConfig.GiteaUser == "") != (userConfig.GiteaToken == "")) ||
 		((userConfig.GitlabUser == "") != (userConfig.GitlabToken == "")) ||
-		((userConfig.BitbucketUser == "") != (userConfig.BitbucketToken == "")) ||
-		((userConfig.AzureDevopsUser == "") != (userConfig.AzureDevopsToken == "")) {
+		((userConfig.BitbucketUser == "") != (userConfig.BitbucketToken == "" && userConfig.BitbucketOAuthClientID == "")) ||
+		((userConfig.AzureDevopsUser == "") != (userConfig.AzureDevopsToken == "" && userConfig.AzureDevopsClientID == "")) {
 		return vcsErr
 	}
+	if err := s.validateAzureDevopsServicePrincipal(userConfig); err != nil {
+		return err
+	}
+	if err := s.validateBitbucketAuth(userConfig); err != nil {
+		return err
+	}
 	if userConfig.GithubUser != "" {
 		if (userConfig.GithubToken == "") == (userConfig.GithubTokenFile == "") {
 			return vcsErr
@@ -1023,7 +1432,7 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 	}
 	// At this point, we know that there can't be a single user/token without
 	// its partner, but we haven't checked if any user/token is set at all.
-	if userConfig.GithubAppID == 0 && userConfig.GithubUser == "" && userConfig.GiteaUser == "" && userConfig.GitlabUser == "" && userConfig.BitbucketUser == "" && userConfig.AzureDevopsUser == "" {
+	if userConfig.GithubAppID == 0 && userConfig.GithubUser == "" && userConfig.GiteaUser == "" && userConfig.GitlabUser == "" && userConfig.BitbucketUser == "" && userConfig.AzureDevopsUser == "" && userConfig.VCSAdapter == "" {
 		return vcsErr
 	}
 
@@ -1050,10 +1459,52 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 		return fmt.Errorf("--%s must have http:// or https://, got %q", GiteaBaseURLFlag, userConfig.GiteaBaseURL)
 	}
 
+	if userConfig.ProjectCommandHookURL != "" {
+		parsed, err = url.Parse(userConfig.ProjectCommandHookURL)
+		if err != nil {
+			return fmt.Errorf("error parsing --%s flag value %q: %s", ProjectCommandHookURLFlag, userConfig.ProjectCommandHookURL, err)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("--%s must have http:// or https://, got %q", ProjectCommandHookURLFlag, userConfig.ProjectCommandHookURL)
+		}
+	}
+
+	if userConfig.TofuDownloadURL != "" {
+		parsed, err = url.Parse(userConfig.TofuDownloadURL)
+		if err != nil {
+			return fmt.Errorf("error parsing --%s flag value %q: %s", TofuDownloadURLFlag, userConfig.TofuDownloadURL, err)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("--%s must have http:// or https://, got %q", TofuDownloadURLFlag, userConfig.TofuDownloadURL)
+		}
+	}
+
+	if userConfig.TelemetryEndpoint != "" {
+		parsed, err = url.Parse(userConfig.TelemetryEndpoint)
+		if err != nil {
+			return fmt.Errorf("error parsing --%s flag value %q: %s", TelemetryEndpointFlag, userConfig.TelemetryEndpoint, err)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("--%s must have http:// or https://, got %q", TelemetryEndpointFlag, userConfig.TelemetryEndpoint)
+		}
+		if userConfig.TelemetryInterval <= 0 {
+			return fmt.Errorf("--%s must be greater than 0 when --%s is set", TelemetryIntervalFlag, TelemetryEndpointFlag)
+		}
+	}
+
 	if userConfig.RepoConfig != "" && userConfig.RepoConfigJSON != "" {
 		return fmt.Errorf("cannot use --%s and --%s at the same time", RepoConfigFlag, RepoConfigJSONFlag)
 	}
 
+	if userConfig.ForkPRRequireApproval && userConfig.ForkPRApprovalLabel == "" && userConfig.ForkPRApprovers == "" {
+		return fmt.Errorf("--%s requires --%s or --%s to be set", ForkPRRequireApprovalFlag, ForkPRApprovalLabelFlag, ForkPRApproversFlag)
+	}
+
+	// Only GitHub Apps can create check runs.
+	if userConfig.GithubCheckRuns && userConfig.GithubAppID == 0 {
+		return fmt.Errorf("--%s requires --%s to be set", GHCheckRunsFlag, GHAppIDFlag)
+	}
+
 	// Warn if any tokens have newlines.
 	for name, token := range map[string]string{
 		GHTokenFlag:                userConfig.GithubToken,
@@ -1092,6 +1543,50 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 }
 
 // setAtlantisURL sets the externally accessible URL for atlantis.
+// validateAzureDevopsServicePrincipal validates the flags that authenticate to
+// Azure DevOps as an Entra ID service principal.
+func (s *ServerCmd) validateAzureDevopsServicePrincipal(userConfig server.UserConfig) error {
+	if userConfig.AzureDevopsClientID == "" && userConfig.AzureDevopsTenantID == "" &&
+		userConfig.AzureDevopsClientSecret == "" && userConfig.AzureDevopsOIDCTokenFile == "" {
+		return nil
+	}
+	if userConfig.AzureDevopsToken != "" {
+		return fmt.Errorf("--%s can't be used with --%s", ADTokenFlag, ADClientIDFlag)
+	}
+	if userConfig.AzureDevopsClientID == "" || userConfig.AzureDevopsTenantID == "" {
+		return fmt.Errorf("--%s and --%s must both be set to authenticate to Azure DevOps as a service principal", ADClientIDFlag, ADTenantIDFlag)
+	}
+	if (userConfig.AzureDevopsClientSecret == "") == (userConfig.AzureDevopsOIDCTokenFile == "") {
+		return fmt.Errorf("exactly one of --%s or --%s must be set with --%s", ADClientSecretFlag, ADFederatedTokenFileFlag, ADClientIDFlag)
+	}
+	return nil
+}
+
+// validateBitbucketAuth validates the flags that authenticate to Bitbucket
+// with an access token or as an OAuth consumer.
+func (s *ServerCmd) validateBitbucketAuth(userConfig server.UserConfig) error {
+	if userConfig.BitbucketTokenType != BitbucketTokenTypeAppPassword && userConfig.BitbucketTokenType != BitbucketTokenTypeAccessToken {
+		return fmt.Errorf("invalid --%s: not one of %s or %s", BitbucketTokenTypeFlag, BitbucketTokenTypeAppPassword, BitbucketTokenTypeAccessToken)
+	}
+	cloud := userConfig.BitbucketBaseURL == DefaultBitbucketBaseURL
+	if userConfig.BitbucketTokenType == BitbucketTokenTypeAccessToken && !cloud {
+		return fmt.Errorf("--%s=%s is only supported for Bitbucket Cloud", BitbucketTokenTypeFlag, BitbucketTokenTypeAccessToken)
+	}
+	if userConfig.BitbucketOAuthClientID == "" && userConfig.BitbucketOAuthClientSecret == "" {
+		return nil
+	}
+	if userConfig.BitbucketOAuthClientID == "" || userConfig.BitbucketOAuthClientSecret == "" {
+		return fmt.Errorf("--%s and --%s must both be set", BitbucketOAuthClientIDFlag, BitbucketOAuthClientSecretFlag)
+	}
+	if userConfig.BitbucketToken != "" {
+		return fmt.Errorf("--%s can't be used with --%s", BitbucketTokenFlag, BitbucketOAuthClientIDFlag)
+	}
+	if !cloud {
+		return fmt.Errorf("--%s is only supported for Bitbucket Cloud", BitbucketOAuthClientIDFlag)
+	}
+	return nil
+}
+
 func (s *ServerCmd) setAtlantisURL(userConfig *server.UserConfig) error {
 	if userConfig.AtlantisURL == "" {
 		hostname, err := os.Hostname()
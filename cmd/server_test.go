@@ -55,7 +55,11 @@ func (s *ServerStarterMock) Start() error {
 // Adding a new flag? Add it to this slice for testing in alphabetical
 // order.
 var testFlags = map[string]interface{}{
+	ADClientIDFlag:                   "",
+	ADClientSecretFlag:               "",
+	ADFederatedTokenFileFlag:         "",
 	ADHostnameFlag:                   "dev.azure.com",
+	ADTenantIDFlag:                   "",
 	ADTokenFlag:                      "ad-token",
 	ADUserFlag:                       "ad-user",
 	ADWebhookPasswordFlag:            "ad-wh-pass",
@@ -65,29 +69,54 @@ var testFlags = map[string]interface{}{
 	AutoplanModulesFromProjects:      "",
 	AllowCommandsFlag:                "version,plan,apply,unlock,import,approve_policies",
 	AllowForkPRsFlag:                 true,
+	ApplyTimeoutFlag:                 120,
 	APISecretFlag:                    "",
+	APIPlanReadTokensFlag:            "token:github.com/org/*",
 	AutoDiscoverModeFlag:             "auto",
 	AutomergeFlag:                    true,
 	AutoplanFileListFlag:             "**/*.tf,**/*.yml",
 	BitbucketBaseURLFlag:             "https://bitbucket-base-url.com",
+	BitbucketOAuthClientIDFlag:       "",
+	BitbucketOAuthClientSecretFlag:   "",
 	BitbucketTokenFlag:               "bitbucket-token",
+	BitbucketTokenTypeFlag:           "app-password",
 	BitbucketUserFlag:                "bitbucket-user",
 	BitbucketWebhookSecretFlag:       "bitbucket-secret",
+	BoltDBAutoCompactFlag:            true,
+	BoltDBSizeWarningFlag:            512,
+	CheckoutFilterFlag:               "blob:none",
 	CheckoutStrategyFlag:             CheckoutStrategyMerge,
+	CircuitBreakerCooldownFlag:       30,
+	CircuitBreakerThresholdFlag:      5,
+	CustomCommandTimeoutFlag:         15,
 	CheckoutDepthFlag:                0,
 	DataDirFlag:                      "/path",
 	DefaultTFDistributionFlag:        "terraform",
 	DefaultTFVersionFlag:             "v0.11.0",
+	DestroyApproversFlag:             "admin1,team:infra",
 	DisableApplyAllFlag:              true,
 	DisableMarkdownFoldingFlag:       true,
 	DisableRepoLockingFlag:           true,
 	DisableGlobalApplyLockFlag:       false,
 	DiscardApprovalOnPlanFlag:        true,
+	DriftDetectionIntervalFlag:       60,
 	EmojiReaction:                    "eyes",
+	EnablePlanCacheFlag:              true,
+	EnablePlanHistoryFlag:            true,
 	ExecutableName:                   "atlantis",
+	ExecutorFlag:                     "local",
 	FailOnPreWorkflowHookError:       false,
+	ForkPRApprovalLabelFlag:          "ok-to-test",
+	ForkPRApproversFlag:              "maintainer1,maintainer2",
+	ForkPRRequireApprovalFlag:        true,
+	GCDryRunFlag:                     true,
+	GCIntervalFlag:                   60,
+	GCPlanMaxAgeFlag:                 24,
+	GCWorkingDirMaxAgeFlag:           48,
 	GHAllowMergeableBypassApply:      false,
+	GHDeletePrevCommentChunksFlag:    true,
 	GHHostnameFlag:                   "ghhostname",
+	GHResponseCacheTTLFlag:           10,
 	GHTeamAllowlistFlag:              "",
 	GHTokenFlag:                      "token",
 	GHTokenFileFlag:                  "",
@@ -97,6 +126,7 @@ var testFlags = map[string]interface{}{
 	GHAppKeyFileFlag:                 "",
 	GHAppSlugFlag:                    "atlantis",
 	GHAppInstallationIDFlag:          int64(0),
+	GHCheckRunsFlag:                  false,
 	GHOrganizationFlag:               "",
 	GHWebhookSecretFlag:              "secret",
 	GiteaBaseURLFlag:                 "http://localhost",
@@ -112,17 +142,34 @@ var testFlags = map[string]interface{}{
 	HideUnchangedPlanComments:        false,
 	HidePrevPlanComments:             false,
 	IncludeGitUntrackedFiles:         false,
+	JobOutputRetentionFlag:           24,
+	KubernetesDataDirClaimFlag:       "atlantis-data",
+	KubernetesNamespaceFlag:          "atlantis-jobs",
+	KubernetesPodTemplateFlag:        "/etc/atlantis/pod.yaml",
 	LockingDBType:                    "boltdb",
 	LogLevelFlag:                     "debug",
 	MarkdownTemplateOverridesDirFlag: "/path2",
 	MaxCommentsPerCommand:            10,
+	MaxConcurrentAppliesFlag:         8,
+	MaxConcurrentAppliesPerRepoFlag:  2,
+	OrphanedLockCleanupIntervalFlag:  30,
 	StatsNamespace:                   "atlantis",
 	AllowDraftPRs:                    true,
+	PersistJobOutputFlag:             true,
 	PortFlag:                         8181,
+	PullClosedCommentsFlag:           "collapse",
+	ProjectCommandHookURLFlag:        "https://scheduler.example.com/atlantis",
 	ParallelPoolSize:                 100,
 	ParallelPlanFlag:                 true,
 	ParallelApplyFlag:                true,
+	ParallelPoolAdaptiveFlag:         true,
+	PlanDiffCommentsFlag:             true,
+	PlanTimeoutFlag:                  30,
+	PlanStoreURLFlag:                 "s3://bucket?region=us-east-1",
+	PolicyCheckTimeoutFlag:           10,
 	QuietPolicyChecks:                false,
+	ReconcileOnStartupFlag:           true,
+	ReplanDependentsFlag:             true,
 	RedisHost:                        "",
 	RedisInsecureSkipVerify:          false,
 	RedisPassword:                    "",
@@ -132,6 +179,7 @@ var testFlags = map[string]interface{}{
 	RepoAllowlistFlag:                "github.com/runatlantis/atlantis",
 	RepoConfigFlag:                   "",
 	RepoConfigJSONFlag:               "",
+	RepoConfigValidationIntervalFlag: 120,
 	SilenceNoProjectsFlag:            false,
 	SilenceVCSStatusNoProjectsFlag:   false,
 	SilenceForkPRErrorsFlag:          true,
@@ -139,19 +187,32 @@ var testFlags = map[string]interface{}{
 	SilenceVCSStatusNoPlans:          true,
 	SkipCloneNoChanges:               true,
 	SlackTokenFlag:                   "slack-token",
+	SparseCheckoutFlag:               true,
+	SparseCheckoutDirsFlag:           "modules",
 	SSLCertFileFlag:                  "cert-file",
 	SSLKeyFileFlag:                   "key-file",
+	StatePushAdminsFlag:              "admin1,admin2",
+	StepPluginsFlag:                  "pulumi=/bin/atlantis-pulumi",
+	SummaryCommentFlag:               true,
 	RestrictFileList:                 false,
+	TelemetryEndpointFlag:            "https://telemetry.example.com",
+	TelemetryIntervalFlag:            60,
 	TFDistributionFlag:               "terraform",
 	TFDownloadFlag:                   true,
 	TFDownloadURLFlag:                "https://my-hostname.com",
+	TofuDownloadURLFlag:              "https://tofu-mirror.example.com",
 	TFEHostnameFlag:                  "my-hostname",
 	TFELocalExecutionModeFlag:        true,
+	TFERunTaskHMACKeyFlag:            "my-hmac-key",
 	TFETokenFlag:                     "my-token",
+	UseTFInitCacheFlag:               true,
 	UseTFPluginCache:                 true,
 	VarFileAllowlistFlag:             "/path",
+	VCSAdapterFlag:                   "/bin/atlantis-vcs-adapter",
+	VCSStatusDebounceFlag:            500,
 	VCSStatusName:                    "my-status",
 	IgnoreVCSStatusNames:             "",
+	WebhookDedupWindowFlag:           30,
 	WebhookHttpHeaders:               `{"Authorization":"Bearer some-token","X-Custom-Header":["value1","value2"]}`,
 	WebBasicAuthFlag:                 false,
 	WebPasswordFlag:                  "atlantis",
@@ -482,6 +543,14 @@ func TestExecute_ValidateLogLevel(t *testing.T) {
 	}
 }
 
+func TestExecute_ValidatePullClosedComments(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		PullClosedCommentsFlag: "hide",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "invalid --pull-closed-comments: not one of keep, collapse or delete", err)
+}
+
 func TestExecute_ValidateCheckoutStrategy(t *testing.T) {
 	c := setupWithDefaults(map[string]interface{}{
 		CheckoutStrategyFlag: "invalid",
@@ -490,6 +559,14 @@ func TestExecute_ValidateCheckoutStrategy(t *testing.T) {
 	ErrEquals(t, "invalid checkout strategy: not one of branch or merge", err)
 }
 
+func TestExecute_ValidateSparseCheckoutDirs(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		SparseCheckoutDirsFlag: "modules",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--sparse-checkout-dirs requires --sparse-checkout", err)
+}
+
 func TestExecute_ValidateSSLConfig(t *testing.T) {
 	expErr := "--ssl-key-file and --ssl-cert-file are both required for ssl"
 	cases := []struct {
@@ -539,7 +616,7 @@ func TestExecute_ValidateSSLConfig(t *testing.T) {
 }
 
 func TestExecute_ValidateVCSConfig(t *testing.T) {
-	expErr := "--gh-user/--gh-token or --gh-user/--gh-token-file or --gh-app-id/--gh-app-key-file or --gh-app-id/--gh-app-key or --gitea-user/--gitea-token or --gitlab-user/--gitlab-token or --bitbucket-user/--bitbucket-token or --azuredevops-user/--azuredevops-token must be set"
+	expErr := "--gh-user/--gh-token or --gh-user/--gh-token-file or --gh-app-id/--gh-app-key-file or --gh-app-id/--gh-app-key or --gitea-user/--gitea-token or --gitlab-user/--gitlab-token or --bitbucket-user/--bitbucket-token or --azuredevops-user/--azuredevops-token or --vcs-adapter must be set"
 	cases := []struct {
 		description string
 		flags       map[string]interface{}
@@ -754,6 +831,13 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"vcs adapter set and should be successful",
+			map[string]interface{}{
+				VCSAdapterFlag: "/bin/atlantis-vcs-adapter",
+			},
+			false,
+		},
 		{
 			"all set should be successful",
 			map[string]interface{}{
@@ -941,6 +1025,82 @@ func TestExecute_ADUser(t *testing.T) {
 }
 
 // Base URL must have a scheme.
+func TestExecute_BitbucketAuth(t *testing.T) {
+	cases := []struct {
+		description string
+		flags       map[string]interface{}
+		expErr      string
+	}{
+		{
+			"access token",
+			map[string]interface{}{
+				BitbucketUserFlag:      "atlantis",
+				BitbucketTokenFlag:     "token",
+				BitbucketTokenTypeFlag: "access-token",
+			},
+			"",
+		},
+		{
+			"oauth consumer",
+			map[string]interface{}{
+				BitbucketUserFlag:              "atlantis",
+				BitbucketOAuthClientIDFlag:     "key",
+				BitbucketOAuthClientSecretFlag: "secret",
+			},
+			"",
+		},
+		{
+			"invalid token type",
+			map[string]interface{}{
+				BitbucketUserFlag:      "atlantis",
+				BitbucketTokenFlag:     "token",
+				BitbucketTokenTypeFlag: "password",
+			},
+			"invalid --bitbucket-token-type: not one of app-password or access-token",
+		},
+		{
+			"access token for bitbucket server",
+			map[string]interface{}{
+				BitbucketUserFlag:      "atlantis",
+				BitbucketTokenFlag:     "token",
+				BitbucketTokenTypeFlag: "access-token",
+				BitbucketBaseURLFlag:   "https://bitbucket.example.com",
+			},
+			"--bitbucket-token-type=access-token is only supported for Bitbucket Cloud",
+		},
+		{
+			"oauth consumer without secret",
+			map[string]interface{}{
+				BitbucketUserFlag:          "atlantis",
+				BitbucketOAuthClientIDFlag: "key",
+			},
+			"--bitbucket-oauth-client-id and --bitbucket-oauth-client-secret must both be set",
+		},
+		{
+			"oauth consumer and token",
+			map[string]interface{}{
+				BitbucketUserFlag:              "atlantis",
+				BitbucketTokenFlag:             "token",
+				BitbucketOAuthClientIDFlag:     "key",
+				BitbucketOAuthClientSecretFlag: "secret",
+			},
+			"--bitbucket-token can't be used with --bitbucket-oauth-client-id",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			c.flags[RepoAllowlistFlag] = "*"
+			cmd := setup(c.flags, t)
+			err := cmd.Execute()
+			if c.expErr == "" {
+				Ok(t, err)
+			} else {
+				ErrEquals(t, c.expErr, err)
+			}
+		})
+	}
+}
+
 func TestExecute_BitbucketServerBaseURLScheme(t *testing.T) {
 	c := setup(map[string]interface{}{
 		BitbucketUserFlag:    "user",
@@ -984,6 +1144,110 @@ func TestExecute_RepoCfgFlags(t *testing.T) {
 	ErrEquals(t, "cannot use --repo-config and --repo-config-json at the same time", err)
 }
 
+// Can't use --fork-pr-require-approval without a way to approve.
+func TestExecute_ForkPRRequireApprovalFlags(t *testing.T) {
+	c := setup(map[string]interface{}{
+		GHUserFlag:                "user",
+		GHTokenFlag:               "token",
+		RepoAllowlistFlag:         "github.com",
+		ForkPRRequireApprovalFlag: true,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--fork-pr-require-approval requires --fork-pr-approval-label or --fork-pr-approvers to be set", err)
+}
+
+func TestExecute_GHCheckRunsRequiresApp(t *testing.T) {
+	c := setup(map[string]interface{}{
+		GHUserFlag:        "user",
+		GHTokenFlag:       "token",
+		RepoAllowlistFlag: "github.com",
+		GHCheckRunsFlag:   true,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--gh-check-runs requires --gh-app-id to be set", err)
+}
+
+func TestExecute_AzureDevopsServicePrincipal(t *testing.T) {
+	cases := []struct {
+		description string
+		flags       map[string]interface{}
+		expErr      string
+	}{
+		{
+			"client secret",
+			map[string]interface{}{
+				ADUserFlag:         "atlantis",
+				ADTenantIDFlag:     "tenant",
+				ADClientIDFlag:     "client",
+				ADClientSecretFlag: "secret",
+			},
+			"",
+		},
+		{
+			"federated token file",
+			map[string]interface{}{
+				ADUserFlag:               "atlantis",
+				ADTenantIDFlag:           "tenant",
+				ADClientIDFlag:           "client",
+				ADFederatedTokenFileFlag: "/var/run/secrets/azure/tokens/azure-identity-token",
+			},
+			"",
+		},
+		{
+			"token and client id",
+			map[string]interface{}{
+				ADUserFlag:         "atlantis",
+				ADTokenFlag:        "token",
+				ADTenantIDFlag:     "tenant",
+				ADClientIDFlag:     "client",
+				ADClientSecretFlag: "secret",
+			},
+			"--azuredevops-token can't be used with --azuredevops-client-id",
+		},
+		{
+			"no tenant id",
+			map[string]interface{}{
+				ADUserFlag:         "atlantis",
+				ADClientIDFlag:     "client",
+				ADClientSecretFlag: "secret",
+			},
+			"--azuredevops-client-id and --azuredevops-tenant-id must both be set to authenticate to Azure DevOps as a service principal",
+		},
+		{
+			"secret and federated token file",
+			map[string]interface{}{
+				ADUserFlag:               "atlantis",
+				ADTenantIDFlag:           "tenant",
+				ADClientIDFlag:           "client",
+				ADClientSecretFlag:       "secret",
+				ADFederatedTokenFileFlag: "/token",
+			},
+			"exactly one of --azuredevops-client-secret or --azuredevops-federated-token-file must be set with --azuredevops-client-id",
+		},
+		{
+			"no secret or federated token file",
+			map[string]interface{}{
+				ADUserFlag:     "atlantis",
+				ADTenantIDFlag: "tenant",
+				ADClientIDFlag: "client",
+			},
+			"exactly one of --azuredevops-client-secret or --azuredevops-federated-token-file must be set with --azuredevops-client-id",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			c.flags[RepoAllowlistFlag] = "*"
+			cmd := setup(c.flags, t)
+			err := cmd.Execute()
+			if c.expErr == "" {
+				Ok(t, err)
+			} else {
+				ErrEquals(t, c.expErr, err)
+			}
+		})
+	}
+}
+
 // Can't use both --tfe-hostname flag without --tfe-token.
 func TestExecute_TFEHostnameOnly(t *testing.T) {
 	c := setup(map[string]interface{}{
@@ -1070,6 +1334,56 @@ func TestExecute_AutoplanFileList(t *testing.T) {
 	}
 }
 
+func TestExecute_ValidateExecutor(t *testing.T) {
+	cases := []struct {
+		description string
+		flags       map[string]interface{}
+		expectErr   string
+	}{
+		{
+			"local",
+			map[string]interface{}{
+				ExecutorFlag: "local",
+			},
+			"",
+		},
+		{
+			"kubernetes",
+			map[string]interface{}{
+				ExecutorFlag:               "kubernetes",
+				KubernetesPodTemplateFlag:  "/etc/atlantis/pod.yaml",
+				KubernetesDataDirClaimFlag: "atlantis-data",
+			},
+			"",
+		},
+		{
+			"errs on kubernetes without a pod template",
+			map[string]interface{}{
+				ExecutorFlag:               "kubernetes",
+				KubernetesDataDirClaimFlag: "atlantis-data",
+			},
+			"--kubernetes-executor-pod-template and --kubernetes-executor-data-dir-claim must be set when --executor is kubernetes",
+		},
+		{
+			"errs on invalid executor",
+			map[string]interface{}{
+				ExecutorFlag: "docker",
+			},
+			"invalid executor: expected one of local or kubernetes",
+		},
+	}
+	for _, testCase := range cases {
+		t.Log("Should validate executor when " + testCase.description)
+		c := setupWithDefaults(testCase.flags, t)
+		err := c.Execute()
+		if testCase.expectErr != "" {
+			ErrEquals(t, testCase.expectErr, err)
+		} else {
+			Ok(t, err)
+		}
+	}
+}
+
 func TestExecute_ValidateDefaultTFDistribution(t *testing.T) {
 	cases := []struct {
 		description string
@@ -1184,6 +1498,39 @@ func TestExecute_GiteaBaseURLScheme(t *testing.T) {
 	ErrEquals(t, "error parsing --gitea-webhook-secret flag value \"://mydomain.com\": parse \"://mydomain.com\": missing protocol scheme", c.Execute())
 }
 
+// Project command hook URL must have a scheme.
+func TestExecute_ProjectCommandHookURLScheme(t *testing.T) {
+	c := setup(map[string]interface{}{
+		GHUserFlag:                "user",
+		GHTokenFlag:               "token",
+		RepoAllowlistFlag:         "*",
+		ProjectCommandHookURLFlag: "scheduler.example.com",
+	}, t)
+	ErrEquals(t, "--project-command-hook-url must have http:// or https://, got \"scheduler.example.com\"", c.Execute())
+}
+
+// Telemetry endpoint must have a scheme.
+func TestExecute_TelemetryEndpointScheme(t *testing.T) {
+	c := setup(map[string]interface{}{
+		GHUserFlag:            "user",
+		GHTokenFlag:           "token",
+		RepoAllowlistFlag:     "*",
+		TelemetryEndpointFlag: "telemetry.example.com",
+	}, t)
+	ErrEquals(t, "--telemetry-endpoint must have http:// or https://, got \"telemetry.example.com\"", c.Execute())
+}
+
+// OpenTofu download URL must have a scheme.
+func TestExecute_TofuDownloadURLScheme(t *testing.T) {
+	c := setup(map[string]interface{}{
+		GHUserFlag:          "user",
+		GHTokenFlag:         "token",
+		RepoAllowlistFlag:   "*",
+		TofuDownloadURLFlag: "tofu-mirror.example.com",
+	}, t)
+	ErrEquals(t, "--tofu-download-url must have http:// or https://, got \"tofu-mirror.example.com\"", c.Execute())
+}
+
 func TestExecute_GiteaWithWebhookSecret(t *testing.T) {
 	c := setup(map[string]interface{}{
 		GiteaUserFlag:          "user",
@@ -37,6 +37,8 @@ const en = [
           { text: "Post Workflow Hooks", link: "/docs/post-workflow-hooks" },
           { text: "Conftest Policy Checking", link: "/docs/policy-checking" },
           { text: "Custom Workflows", link: "/docs/custom-workflows" },
+          { text: "Custom Commands", link: "/docs/custom-commands" },
+          { text: "Filter Expressions", link: "/docs/filter-expressions" },
           { text: "Repo and Project Permissions", link: "/docs/repo-and-project-permissions" },
           { text: "Repo Level atlantis.yaml", link: "/docs/repo-level-atlantis-yaml" },
           { text: "Upgrading atlantis.yaml", link: "/docs/upgrading-atlantis-yaml" },
@@ -45,6 +47,8 @@ const en = [
           { text: "Terraform Versions", link: "/docs/terraform-versions" },
           { text: "Terraform Cloud", link: "/docs/terraform-cloud" },
           { text: "Sending Notifications via Webhooks", link: "/docs/sending-notifications-via-webhooks" },
+          { text: "Audit Logging", link: "/docs/audit-logging" },
+          { text: "VCS Adapters", link: "/docs/vcs-adapters" },
           { text: "Stats", link: "/docs/stats" },
           { text: "FAQ", link: "/docs/faq" },
         ]
@@ -5,37 +5,63 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
 	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/core/runtime"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled"
 	tally "github.com/uber-go/tally/v4"
 )
 
 const atlantisTokenHeader = "X-Atlantis-Token"
 
 type APIController struct {
-	APISecret                      []byte
-	Locker                         locking.Locker
-	Logger                         logging.SimpleLogging
-	Parser                         events.EventParsing
+	APISecret []byte
+	// AutoplanSimulator is used by the simulate endpoint.
+	AutoplanSimulator events.AutoplanSimulator
+	Backend           locking.Backend
+	// DBCompactor is only set when using BoltDB.
+	DBCompactor scheduled.DBCompactor
+	// JobCanceler is used by the job cancel endpoint.
+	JobCanceler *jobs.JobCanceler
+	Locker      locking.Locker
+	// LockExplainer is used by the lock explain endpoint.
+	LockExplainer *events.LockExplainer
+	Logger        logging.SimpleLogging
+	Parser        events.EventParsing
+	// PlanReadTokens can download the plans of the repos they're allowed to
+	// read but can't use the other endpoints.
+	PlanReadTokens                 APIPlanReadTokens
+	ParallelPoolSize               *events.ParallelPoolSize
 	ProjectCommandBuilder          events.ProjectCommandBuilder
+	ProjectRenamer                 *events.ProjectRenamer
 	ProjectPlanCommandRunner       events.ProjectPlanCommandRunner
 	ProjectApplyCommandRunner      events.ProjectApplyCommandRunner
 	FailOnPreWorkflowHookError     bool
 	PreWorkflowHooksCommandRunner  events.PreWorkflowHooksCommandRunner
 	PostWorkflowHooksCommandRunner events.PostWorkflowHooksCommandRunner
 	RepoAllowlistChecker           *events.RepoAllowlistChecker
+	RepoConfigReport               *scheduled.RepoConfigReport
 	Scope                          tally.Scope
 	VCSClient                      vcs.Client
 	WorkingDir                     events.WorkingDir
 	WorkingDirLocker               events.WorkingDirLocker
 	CommitStatusUpdater            events.CommitStatusUpdater
+	// ShadowModeChecker rejects applies of repos in shadow mode, which only
+	// run plans. If nil, no repos are in shadow mode.
+	ShadowModeChecker vcs.ShadowModeChecker
 }
 
 type APIRequest struct {
@@ -48,6 +74,87 @@ type APIRequest struct {
 		Directory string
 		Workspace string
 	}
+	// ModifiedFiles are the files to simulate an autoplan with. They're only
+	// used by the simulate endpoint.
+	ModifiedFiles []string
+}
+
+// SimulateResponse is what an autoplan of the modified files would do.
+type SimulateResponse struct {
+	events.AutoplanSimulation
+	// Log is what Atlantis logged while working out the projects, which
+	// explains why they were or weren't matched.
+	Log string
+}
+
+// ParallelPoolSizeRequest is the request to change the parallel pool size.
+type ParallelPoolSizeRequest struct {
+	Size int `validate:"required"`
+}
+
+// ParallelPoolSizeResponse is the parallel pool size.
+type ParallelPoolSizeResponse struct {
+	// Size is the size set with --parallel-pool-size or the API.
+	Size int
+	// Limit caps Size while the host is under pressure. It's 0 if there's no
+	// cap.
+	Limit int
+	// Effective is the size that new commands use.
+	Effective int
+}
+
+// DBCompactionResponse is the result of compacting the database.
+type DBCompactionResponse struct {
+	// SizeBefore is the size of the database file in bytes before compacting.
+	SizeBefore int64
+	// SizeAfter is the size of the database file in bytes after compacting.
+	SizeAfter int64
+}
+
+// ProjectRenameRequest is the request to move what's stored for a project
+// after it was renamed or moved.
+type ProjectRenameRequest struct {
+	// Repository is the full name of the repo, ex. owner/repo.
+	Repository string     `validate:"required"`
+	From       APIProject `validate:"required"`
+	To         APIProject `validate:"required"`
+}
+
+// APIProject identifies a project of a repo.
+type APIProject struct {
+	Directory string `validate:"required"`
+	Workspace string `validate:"required"`
+	Name      string
+}
+
+// APIPlanReadTokens are tokens that can only download plans, keyed by token.
+// Each token can read the plans of the repos its allowlist matches.
+type APIPlanReadTokens map[string]*events.RepoAllowlistChecker
+
+// ParseAPIPlanReadTokens parses the comma-separated {token}:{repo} pairs of
+// --api-plan-read-tokens. {repo} is a pattern like those of --repo-allowlist
+// and a token is listed once per pattern it can read.
+func ParseAPIPlanReadTokens(tokens string) (APIPlanReadTokens, error) {
+	patterns := make(map[string][]string)
+	for _, pair := range strings.Split(tokens, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		token, pattern, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found || token == "" || pattern == "" {
+			return nil, fmt.Errorf("plan read token %q must be of the form {token}:{repo}", pair)
+		}
+		patterns[token] = append(patterns[token], pattern)
+	}
+	readTokens := make(APIPlanReadTokens)
+	for token, tokenPatterns := range patterns {
+		checker, err := events.NewRepoAllowlistChecker(strings.Join(tokenPatterns, ","))
+		if err != nil {
+			return nil, err
+		}
+		readTokens[token] = checker
+	}
+	return readTokens, nil
 }
 
 func (a *APIRequest) getCommands(ctx *command.Context, cmdBuilder func(*command.Context, *events.CommentCommand) ([]command.ProjectContext, error)) ([]command.ProjectContext, []*events.CommentCommand, error) {
@@ -118,6 +225,41 @@ func (a *APIController) Plan(w http.ResponseWriter, r *http.Request) {
 	a.respond(w, logging.Warn, code, "%s", string(response))
 }
 
+// Simulate is the POST /api/simulate route. It returns which projects the
+// request's modified files would autoplan at ref and the workflow and
+// requirements each of them would have after merging the repo config with
+// the server-side config. Nothing is planned and no project locks are taken.
+func (a *APIController) Simulate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	request, ctx, code, err := a.apiParseAndValidate(r)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.AutoplanSimulator == nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("simulating autoplans is not supported"))
+		return
+	}
+
+	ctx.Log = a.Logger.WithHistory("repo", ctx.Pull.BaseRepo.FullName, "ref", request.Ref)
+	simulation, err := a.AutoplanSimulator.SimulateAutoplan(ctx, request.ModifiedFiles)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := json.Marshal(SimulateResponse{
+		AutoplanSimulation: simulation,
+		Log:                ctx.Log.GetHistory(),
+	})
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
 func (a *APIController) Apply(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -127,6 +269,12 @@ func (a *APIController) Apply(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	baseRepo := ctx.Pull.BaseRepo
+	if a.ShadowModeChecker != nil && a.ShadowModeChecker.ShadowMode(baseRepo.ID()) {
+		a.apiReportError(w, http.StatusForbidden, fmt.Errorf("%s is in shadow mode so it can only be planned", baseRepo.FullName))
+		return
+	}
+
 	err = a.apiSetup(ctx)
 	if err != nil {
 		a.apiReportError(w, http.StatusInternalServerError, err)
@@ -159,6 +307,368 @@ func (a *APIController) Apply(w http.ResponseWriter, r *http.Request) {
 	a.respond(w, logging.Warn, code, "%s", string(response))
 }
 
+// Drift returns the results of the last drift detection run for each project.
+// Results can be filtered to a single repo with the repository query
+// parameter, ex. ?repository=github.com/runatlantis/atlantis.
+func (a *APIController) Drift(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	statuses, err := a.Backend.GetDriftStatuses()
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	repoID := r.URL.Query().Get("repository")
+	result := make([]models.DriftStatus, 0, len(statuses))
+	for _, status := range statuses {
+		if repoID == "" || status.RepoID == repoID {
+			result = append(result, status)
+		}
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// ExplainLock returns everything that stops commands from running on a
+// project right now, ex. ?repo=owner/repo&path=dir&workspace=default. The
+// workspace defaults to default.
+func (a *APIController) ExplainLock(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	query := r.URL.Query()
+	repoFullName, path, workspace := query.Get("repo"), query.Get("path"), query.Get("workspace")
+	if repoFullName == "" || path == "" {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("request must specify a repo and a path"))
+		return
+	}
+	if workspace == "" {
+		workspace = events.DefaultWorkspace
+	}
+
+	explanation, err := a.LockExplainer.Explain(repoFullName, path, workspace)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	response, err := json.Marshal(explanation)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// Jobs returns the persisted jobs of a pull request without their output,
+// newest first, ex. ?repo=owner/repo&pull=1.
+func (a *APIController) Jobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	query := r.URL.Query()
+	repoFullName := query.Get("repo")
+	pullNum, err := strconv.Atoi(query.Get("pull"))
+	if repoFullName == "" || err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("request must specify a repo and a pull number"))
+		return
+	}
+
+	jobs, err := a.Backend.GetJobOutputs(repoFullName, pullNum)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if jobs == nil {
+		jobs = []models.JobOutput{}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.After(jobs[j].StartedAt)
+	})
+	response, err := json.Marshal(jobs)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// GetJob returns a persisted job with its output.
+func (a *APIController) GetJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	jobID := mux.Vars(r)["job-id"]
+	job, err := a.Backend.GetJobOutput(jobID)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if job == nil {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("no job with ID %q", jobID))
+		return
+	}
+	response, err := json.Marshal(job)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// CancelJob is the POST /api/jobs/{job-id}/cancel route. It cancels a
+// running job, which terminates the process group of the step it's running.
+func (a *APIController) CancelJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	jobID := mux.Vars(r)["job-id"]
+	if a.JobCanceler == nil || !a.JobCanceler.Cancel(jobID) {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("job %q isn't running or can't be canceled", jobID))
+		return
+	}
+	a.Logger.Info("canceled job %s", jobID)
+	response, err := json.Marshal(map[string]string{
+		"JobID": jobID,
+	})
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// RepoConfigs returns the result of the last repo config validation run for
+// each repo, invalid repos first.
+func (a *APIController) RepoConfigs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.RepoConfigReport == nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("repo config validation is disabled"))
+		return
+	}
+
+	result := a.RepoConfigReport.Statuses()
+	if result == nil {
+		result = []models.RepoConfigStatus{}
+	}
+	response, err := json.Marshal(result)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// GetParallelPoolSize returns the parallel pool size.
+func (a *APIController) GetParallelPoolSize(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	a.respondParallelPoolSize(w)
+}
+
+// SetParallelPoolSize changes the parallel pool size. The new size is used by
+// commands that start after the change, commands that are already running
+// keep their size.
+func (a *APIController) SetParallelPoolSize(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("failed to read request"))
+		return
+	}
+	var request ParallelPoolSizeRequest
+	if err = json.Unmarshal(bytes, &request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err.Error()))
+		return
+	}
+	if err = validator.New().Struct(request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("request %q is missing fields", string(bytes)))
+		return
+	}
+	if err := a.ParallelPoolSize.Set(request.Size); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	a.Logger.Info("parallel pool size set to %d", request.Size)
+	a.respondParallelPoolSize(w)
+}
+
+func (a *APIController) respondParallelPoolSize(w http.ResponseWriter) {
+	response, err := json.Marshal(ParallelPoolSizeResponse{
+		Size:      a.ParallelPoolSize.Size(),
+		Limit:     a.ParallelPoolSize.Limit(),
+		Effective: a.ParallelPoolSize.Get(),
+	})
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// GetPlan returns the plan file of a project of a pull request or, with
+// ?format=json or ?format=text, the JSON or text output of terraform show for
+// it, so that external tools can inspect exactly what Atlantis will apply. The project is matched by
+// name or, for projects without a name, by dir, and ?workspace= picks between
+// projects that match in several workspaces. Besides the API secret, the
+// tokens of --api-plan-read-tokens can download the plans of their repos.
+func (a *APIController) GetPlan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	repoFullName, project := vars["repo"], vars["project"]
+	pullNum, err := strconv.Atoi(vars["pull"])
+	if repoFullName == "" || project == "" || err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("request must specify a repo, a pull number and a project"))
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "plan"
+	}
+	if format != "plan" && format != "json" && format != "text" {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("format %q is not one of plan, json or text", format))
+		return
+	}
+
+	readChecker, isReadToken := a.PlanReadTokens[r.Header.Get(atlantisTokenHeader)]
+	if !isReadToken {
+		if code, err := a.apiAuthenticate(r); err != nil {
+			a.apiReportError(w, code, err)
+			return
+		}
+	}
+
+	pull, err := a.findPullStatus(repoFullName, pullNum)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if pull == nil {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("no plans for %s#%d", repoFullName, pullNum))
+		return
+	}
+	if isReadToken && !readChecker.IsAllowlisted(pull.Pull.BaseRepo.FullName, pull.Pull.BaseRepo.VCSHost.Hostname) {
+		a.apiReportError(w, http.StatusForbidden, fmt.Errorf("token is not allowed to read the plans of %s", repoFullName))
+		return
+	}
+
+	workspace := r.URL.Query().Get("workspace")
+	var matches []models.ProjectStatus
+	for _, status := range pull.Projects {
+		if status.ProjectName != project && (status.ProjectName != "" || status.RepoRelDir != project) {
+			continue
+		}
+		if workspace == "" || status.Workspace == workspace {
+			matches = append(matches, status)
+		}
+	}
+	if len(matches) == 0 {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("no project %q in %s#%d", project, repoFullName, pullNum))
+		return
+	}
+	if len(matches) > 1 {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("%d projects match %q, specify the workspace", len(matches), project))
+		return
+	}
+	status := matches[0]
+	if status.Status == models.ErroredPlanStatus {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("project %q failed to plan", project))
+		return
+	}
+
+	repoDir, err := a.WorkingDir.GetWorkingDir(pull.Pull.BaseRepo, pull.Pull, status.Workspace)
+	if err != nil {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("project %q has no plan", project))
+		return
+	}
+	filename := runtime.GetPlanFilename(status.Workspace, status.ProjectName)
+	showCtx := command.ProjectContext{ProjectName: status.ProjectName, Workspace: status.Workspace}
+	switch format {
+	case "json":
+		filename = showCtx.GetShowResultFileName()
+	case "text":
+		filename = showCtx.GetShowTextFileName()
+	}
+	data, err := os.ReadFile(filepath.Join(repoDir, status.RepoRelDir, filename))
+	if os.IsNotExist(err) {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("project %q has no %s plan", project, format))
+		return
+	}
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	a.Logger.Info("%s plan of project %q in %s#%d downloaded", format, project, repoFullName, pullNum)
+	switch format {
+	case "plan":
+		w.Header().Set("Content-Type", "application/octet-stream")
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	// The head commit lets tools check that the plan is of the commit they
+	// expect.
+	w.Header().Set("X-Atlantis-Head-Commit", pull.Pull.HeadCommit)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data) // nolint: errcheck
+}
+
+// findPullStatus returns the status of the pull numbered pullNum of the repo
+// repoFullName, or nil if it has none.
+func (a *APIController) findPullStatus(repoFullName string, pullNum int) (*models.PullStatus, error) {
+	statuses, err := a.Backend.GetPullStatuses()
+	if err != nil {
+		return nil, err
+	}
+	for i := range statuses {
+		if statuses[i].Pull.BaseRepo.FullName == repoFullName && statuses[i].Pull.Num == pullNum {
+			return &statuses[i], nil
+		}
+	}
+	return nil, nil
+}
+
 func (a *APIController) apiSetup(ctx *command.Context) error {
 	pull := ctx.Pull
 	baseRepo := ctx.Pull.BaseRepo
@@ -236,15 +746,24 @@ func (a *APIController) apiApply(request *APIRequest, ctx *command.Context) (*co
 	return &command.Result{ProjectResults: projectResults}, nil
 }
 
-func (a *APIController) apiParseAndValidate(r *http.Request) (*APIRequest, *command.Context, int, error) {
+// apiAuthenticate checks that the API is enabled and that the request has the
+// right secret token.
+func (a *APIController) apiAuthenticate(r *http.Request) (int, error) {
 	if len(a.APISecret) == 0 {
-		return nil, nil, http.StatusBadRequest, fmt.Errorf("ignoring request since API is disabled")
+		return http.StatusBadRequest, fmt.Errorf("ignoring request since API is disabled")
 	}
 
 	// Validate the secret token
 	secret := r.Header.Get(atlantisTokenHeader)
 	if secret != string(a.APISecret) {
-		return nil, nil, http.StatusUnauthorized, fmt.Errorf("header %s did not match expected secret", atlantisTokenHeader)
+		return http.StatusUnauthorized, fmt.Errorf("header %s did not match expected secret", atlantisTokenHeader)
+	}
+	return http.StatusOK, nil
+}
+
+func (a *APIController) apiParseAndValidate(r *http.Request) (*APIRequest, *command.Context, int, error) {
+	if code, err := a.apiAuthenticate(r); err != nil {
+		return nil, nil, code, err
 	}
 
 	// Parse the JSON payload
@@ -300,3 +819,77 @@ func (a *APIController) respond(w http.ResponseWriter, lvl logging.LogLevel, res
 	w.WriteHeader(responseCode)
 	fmt.Fprintln(w, response)
 }
+
+// CompactDB compacts the BoltDB database file, reclaiming the space left by
+// deleted locks and pull statuses. Lock operations wait until it's done.
+func (a *APIController) CompactDB(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.DBCompactor == nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("compaction is only supported with the boltdb locking-db-type"))
+		return
+	}
+
+	sizeBefore, sizeAfter, err := a.DBCompactor.Compact()
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.Logger.Info("compacted database from %d to %d bytes", sizeBefore, sizeAfter)
+	response, err := json.Marshal(DBCompactionResponse{
+		SizeBefore: sizeBefore,
+		SizeAfter:  sizeAfter,
+	})
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// RenameProject is the POST /api/projects/rename route. It moves the locks,
+// pull statuses and plan history of a project that was renamed or whose dir
+// or workspace changed, so that the locks held under its old dir and
+// workspace don't block it.
+func (a *APIController) RenameProject(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	var request ProjectRenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err))
+		return
+	}
+	if err := validator.New().Struct(request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("request is missing fields: %v", err))
+		return
+	}
+
+	rename := models.ProjectRename{
+		RepoFullName: request.Repository,
+		From:         models.ProjectLocation{RepoRelDir: request.From.Directory, Workspace: request.From.Workspace, ProjectName: request.From.Name},
+		To:           models.ProjectLocation{RepoRelDir: request.To.Directory, Workspace: request.To.Workspace, ProjectName: request.To.Name},
+	}
+	result, err := a.ProjectRenamer.Rename(rename)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	a.Logger.Info("renamed project in %s from dir %q workspace %q name %q to dir %q workspace %q name %q: moved %d locks, %d pull statuses and %d plan histories",
+		rename.RepoFullName, rename.From.RepoRelDir, rename.From.Workspace, rename.From.ProjectName,
+		rename.To.RepoRelDir, rename.To.Workspace, rename.To.ProjectName, result.Locks, result.PullStatuses, result.PlanHistories)
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
@@ -2,21 +2,32 @@ package controllers_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/mux"
 	. "github.com/petergtz/pegomock/v4"
 	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/db"
 	. "github.com/runatlantis/atlantis/server/core/locking/mocks"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	. "github.com/runatlantis/atlantis/server/events/mocks"
 	"github.com/runatlantis/atlantis/server/events/models"
 	. "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
+	"github.com/runatlantis/atlantis/server/scheduled"
 	. "github.com/runatlantis/atlantis/testing"
 )
 
@@ -110,6 +121,52 @@ func TestAPIController_Plan(t *testing.T) {
 	projectCommandRunner.VerifyWasCalled(Times(expectedCalls)).Plan(Any[command.ProjectContext]())
 }
 
+// fakeAutoplanSimulator records the files it simulated an autoplan with.
+type fakeAutoplanSimulator struct {
+	modifiedFiles []string
+}
+
+func (f *fakeAutoplanSimulator) SimulateAutoplan(ctx *command.Context, modifiedFiles []string) (events.AutoplanSimulation, error) {
+	f.modifiedFiles = modifiedFiles
+	ctx.Log.Info("1 projects are to be planned based on their when_modified config")
+	return events.AutoplanSimulation{
+		RepoConfigFile: "atlantis.yaml",
+		HasRepoConfig:  true,
+		Projects: []events.SimulatedProject{{
+			Dir:       "network",
+			Workspace: "default",
+			Autoplan:  true,
+			Workflow:  "default",
+			PlanSteps: []string{"init", "plan"},
+		}},
+	}, nil
+}
+
+func TestAPIController_Simulate(t *testing.T) {
+	ac, _, _ := setup(t)
+	simulator := &fakeAutoplanSimulator{}
+	ac.AutoplanSimulator = simulator
+
+	body, _ := json.Marshal(controllers.APIRequest{
+		Repository:    "Repo",
+		Ref:           "main",
+		Type:          "Gitlab",
+		ModifiedFiles: []string{"network/main.tf"},
+	})
+	req, _ := http.NewRequest("POST", "", bytes.NewBuffer(body))
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w := httptest.NewRecorder()
+	ac.Simulate(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	Equals(t, []string{"network/main.tf"}, simulator.modifiedFiles)
+
+	var response controllers.SimulateResponse
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&response))
+	Equals(t, 1, len(response.Projects))
+	Equals(t, "network", response.Projects[0].Dir)
+	Assert(t, strings.Contains(response.Log, "based on their when_modified config"), "expected the log to explain the match, got %q", response.Log)
+}
+
 func TestAPIController_Apply(t *testing.T) {
 	ac, projectCommandBuilder, projectCommandRunner := setup(t)
 
@@ -198,6 +255,305 @@ func TestAPIController_Apply(t *testing.T) {
 	projectCommandRunner.VerifyWasCalled(Times(expectedCalls)).Apply(Any[command.ProjectContext]())
 }
 
+func TestAPIController_Apply_ShadowMode(t *testing.T) {
+	ac, projectCommandBuilder, projectCommandRunner := setup(t)
+	shadowMode := true
+	ac.ShadowModeChecker = valid.GlobalCfg{
+		Repos: []valid.Repo{{IDRegex: regexp.MustCompile(".*"), ShadowMode: &shadowMode}},
+	}
+
+	body, _ := json.Marshal(controllers.APIRequest{
+		Repository: "Repo",
+		Ref:        "main",
+		Type:       "Gitlab",
+		Projects:   []string{"default"},
+	})
+	req, _ := http.NewRequest("POST", "", bytes.NewBuffer(body))
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w := httptest.NewRecorder()
+	ac.Apply(w, req)
+	ResponseContains(t, w, http.StatusForbidden, "is in shadow mode so it can only be planned")
+
+	projectCommandBuilder.VerifyWasCalled(Never()).BuildPlanCommands(Any[*command.Context](), Any[*events.CommentCommand]())
+	projectCommandRunner.VerifyWasCalled(Never()).Apply(Any[command.ProjectContext]())
+}
+
+func TestAPIController_ExplainLock(t *testing.T) {
+	ac, _, _ := setup(t)
+	backend := NewMockBackend()
+	ac.LockExplainer = &events.LockExplainer{
+		Backend:         backend,
+		WorkingDirLocks: events.NewDefaultWorkingDirLocker(),
+	}
+	project := models.NewProject("org/infra", "prod", "")
+	When(backend.GetLock(project, "default")).ThenReturn(&models.ProjectLock{
+		Project:   project,
+		Pull:      models.PullRequest{Num: 7},
+		Workspace: "default",
+	}, nil)
+
+	t.Run("missing path", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/locks/explain?repo=org/infra", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.ExplainLock(w, req)
+		ResponseContains(t, w, http.StatusBadRequest, "request must specify a repo and a path")
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/locks/explain?repo=org/infra&path=prod", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.ExplainLock(w, req)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+		var explanation events.LockExplanation
+		Ok(t, json.NewDecoder(w.Result().Body).Decode(&explanation))
+		Equals(t, true, explanation.Blocked)
+		Equals(t, 1, len(explanation.Reasons))
+		Equals(t, events.ProjectLockReason, explanation.Reasons[0].Type)
+		Equals(t, 7, explanation.Reasons[0].PullNum)
+	})
+}
+
+func TestAPIController_Drift(t *testing.T) {
+	ac, _, _ := setup(t)
+	backend := NewMockBackend()
+	ac.Backend = backend
+	infra := models.DriftStatus{
+		RepoID:           "github.com/org/infra",
+		RepoFullName:     "org/infra",
+		RepoRelDir:       "prod",
+		Workspace:        "default",
+		Drifted:          true,
+		DriftedResources: 2,
+	}
+	other := models.DriftStatus{
+		RepoID:       "github.com/org/other",
+		RepoFullName: "org/other",
+		RepoRelDir:   ".",
+		Workspace:    "default",
+	}
+	When(backend.GetDriftStatuses()).ThenReturn([]models.DriftStatus{infra, other}, nil)
+
+	t.Run("unauthorized", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/drift", nil)
+		w := httptest.NewRecorder()
+		ac.Drift(w, req)
+		ResponseContains(t, w, http.StatusUnauthorized, "did not match expected secret")
+	})
+
+	t.Run("all repos", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/drift", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.Drift(w, req)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+		var statuses []models.DriftStatus
+		Ok(t, json.NewDecoder(w.Result().Body).Decode(&statuses))
+		Equals(t, []models.DriftStatus{infra, other}, statuses)
+	})
+
+	t.Run("filtered by repo", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/drift?repository=github.com/org/infra", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.Drift(w, req)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+		var statuses []models.DriftStatus
+		Ok(t, json.NewDecoder(w.Result().Body).Decode(&statuses))
+		Equals(t, []models.DriftStatus{infra}, statuses)
+	})
+}
+
+func TestAPIController_Jobs(t *testing.T) {
+	ac, _, _ := setup(t)
+	backend := NewMockBackend()
+	ac.Backend = backend
+	older := models.JobOutput{JobID: "1", RepoFullName: "org/infra", PullNum: 1, JobStep: "plan", StartedAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), Complete: true}
+	newer := models.JobOutput{JobID: "2", RepoFullName: "org/infra", PullNum: 1, JobStep: "apply", StartedAt: time.Date(2024, 6, 1, 13, 0, 0, 0, time.UTC)}
+	When(backend.GetJobOutputs("org/infra", 1)).ThenReturn([]models.JobOutput{older, newer}, nil)
+	withLines := older
+	withLines.Lines = []string{"Plan: 1 to add, 0 to change, 0 to destroy."}
+	When(backend.GetJobOutput("1")).ThenReturn(&withLines, nil)
+
+	t.Run("list", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/jobs?repo=org/infra&pull=1", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.Jobs(w, req)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+		var jobs []models.JobOutput
+		Ok(t, json.NewDecoder(w.Result().Body).Decode(&jobs))
+		Equals(t, []models.JobOutput{newer, older}, jobs)
+	})
+
+	t.Run("list without pull", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/jobs?repo=org/infra", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.Jobs(w, req)
+		ResponseContains(t, w, http.StatusBadRequest, "request must specify a repo and a pull number")
+	})
+
+	t.Run("get", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/jobs/1", nil)
+		req = mux.SetURLVars(req, map[string]string{"job-id": "1"})
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.GetJob(w, req)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+		var job models.JobOutput
+		Ok(t, json.NewDecoder(w.Result().Body).Decode(&job))
+		Equals(t, withLines, job)
+	})
+
+	t.Run("get unknown job", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/jobs/3", nil)
+		req = mux.SetURLVars(req, map[string]string{"job-id": "3"})
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.GetJob(w, req)
+		ResponseContains(t, w, http.StatusNotFound, `no job with ID \"3\"`)
+	})
+}
+
+func TestAPIController_CancelJob(t *testing.T) {
+	ac, _, _ := setup(t)
+	ac.JobCanceler = jobs.NewJobCanceler()
+	jobCtx, done := ac.JobCanceler.Start("1")
+	defer done()
+
+	cancel := func(jobID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/jobs/"+jobID+"/cancel", nil)
+		req = mux.SetURLVars(req, map[string]string{"job-id": jobID})
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.CancelJob(w, req)
+		return w
+	}
+
+	w := cancel("1")
+	ResponseContains(t, w, http.StatusOK, `{"JobID":"1"}`)
+	Equals(t, jobs.ErrJobCanceled, context.Cause(jobCtx))
+
+	w = cancel("1")
+	ResponseContains(t, w, http.StatusNotFound, `job \"1\" isn't running or can't be canceled`)
+}
+
+func TestAPIController_RepoConfigs(t *testing.T) {
+	ac, _, _ := setup(t)
+
+	t.Run("disabled", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/repo-configs", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.RepoConfigs(w, req)
+		ResponseContains(t, w, http.StatusBadRequest, "repo config validation is disabled")
+	})
+
+	valid := models.RepoConfigStatus{RepoID: "github.com/org/infra", Branch: "main"}
+	invalid := models.RepoConfigStatus{RepoID: "github.com/org/app", Branch: "main", Invalid: true, Error: "invalid"}
+	ac.RepoConfigReport = &scheduled.RepoConfigReport{}
+
+	t.Run("not run yet", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/repo-configs", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.RepoConfigs(w, req)
+		ResponseContains(t, w, http.StatusOK, "[]")
+	})
+
+	t.Run("invalid first", func(t *testing.T) {
+		ac.RepoConfigReport.Update([]models.RepoConfigStatus{valid, invalid})
+		req, _ := http.NewRequest("GET", "/api/repo-configs", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.RepoConfigs(w, req)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+		var statuses []models.RepoConfigStatus
+		Ok(t, json.NewDecoder(w.Result().Body).Decode(&statuses))
+		Equals(t, []models.RepoConfigStatus{invalid, valid}, statuses)
+	})
+}
+
+func TestAPIController_ParallelPoolSize(t *testing.T) {
+	ac, _, _ := setup(t)
+
+	t.Run("unauthorized", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/api/parallel-pool-size", bytes.NewBufferString(`{"Size": 5}`))
+		w := httptest.NewRecorder()
+		ac.SetParallelPoolSize(w, req)
+		ResponseContains(t, w, http.StatusUnauthorized, "did not match expected secret")
+		Equals(t, 15, ac.ParallelPoolSize.Get())
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/api/parallel-pool-size", bytes.NewBufferString(`{"Size": -1}`))
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.SetParallelPoolSize(w, req)
+		ResponseContains(t, w, http.StatusBadRequest, "parallel pool size must be at least 1")
+		Equals(t, 15, ac.ParallelPoolSize.Get())
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/api/parallel-pool-size", bytes.NewBufferString(`{"Size": 5}`))
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.SetParallelPoolSize(w, req)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+		Equals(t, 5, ac.ParallelPoolSize.Get())
+
+		ac.ParallelPoolSize.SetLimit(2)
+		req, _ = http.NewRequest("GET", "/api/parallel-pool-size", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w = httptest.NewRecorder()
+		ac.GetParallelPoolSize(w, req)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+		var response controllers.ParallelPoolSizeResponse
+		Ok(t, json.NewDecoder(w.Result().Body).Decode(&response))
+		Equals(t, controllers.ParallelPoolSizeResponse{Size: 5, Limit: 2, Effective: 2}, response)
+	})
+}
+
+func TestAPIController_CompactDB(t *testing.T) {
+	ac, _, _ := setup(t)
+
+	t.Run("not boltdb", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/api/boltdb/compact", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.CompactDB(w, req)
+		ResponseContains(t, w, http.StatusBadRequest, "compaction is only supported with the boltdb locking-db-type")
+	})
+
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+	defer boltDB.Close() // nolint: errcheck
+	ac.DBCompactor = boltDB
+
+	t.Run("unauthorized", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/api/boltdb/compact", nil)
+		w := httptest.NewRecorder()
+		ac.CompactDB(w, req)
+		ResponseContains(t, w, http.StatusUnauthorized, "did not match expected secret")
+	})
+
+	t.Run("compact", func(t *testing.T) {
+		size, _, err := boltDB.FileStats()
+		Ok(t, err)
+		req, _ := http.NewRequest("POST", "/api/boltdb/compact", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.CompactDB(w, req)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+		var response controllers.DBCompactionResponse
+		Ok(t, json.NewDecoder(w.Result().Body).Decode(&response))
+		Equals(t, size, response.SizeBefore)
+		Assert(t, response.SizeAfter > 0, "expected the compacted file to have a size")
+	})
+}
+
 func setup(t *testing.T) (controllers.APIController, *MockProjectCommandBuilder, *MockProjectCommandRunner) {
 	RegisterMockTestingT(t)
 	locker := NewMockLocker()
@@ -249,6 +605,7 @@ func setup(t *testing.T) (controllers.APIController, *MockProjectCommandBuilder,
 		Logger:                         logger,
 		Scope:                          scope,
 		Parser:                         parser,
+		ParallelPoolSize:               events.NewParallelPoolSize(15),
 		ProjectCommandBuilder:          projectCommandBuilder,
 		ProjectPlanCommandRunner:       projectCommandRunner,
 		ProjectApplyCommandRunner:      projectCommandRunner,
@@ -262,3 +619,157 @@ func setup(t *testing.T) (controllers.APIController, *MockProjectCommandBuilder,
 	}
 	return ac, projectCommandBuilder, projectCommandRunner
 }
+
+func TestAPIController_GetPlan(t *testing.T) {
+	ac, _, _ := setup(t)
+	backend := NewMockBackend()
+	ac.Backend = backend
+	readTokens, err := controllers.ParseAPIPlanReadTokens("reader:github.com/org/infra, other:github.com/org/other")
+	Ok(t, err)
+	ac.PlanReadTokens = readTokens
+
+	repoDir := t.TempDir()
+	Ok(t, os.MkdirAll(filepath.Join(repoDir, "network"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "network", "default.tfplan"), []byte("plan"), 0600))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "network", "default.json"), []byte(`{"format_version":"1.2"}`), 0600))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "network", "default-show.txt"), []byte("Terraform will perform the following actions:"), 0600))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "app-default.tfplan"), []byte("app plan"), 0600))
+
+	pull := models.PullRequest{
+		Num:        1,
+		HeadCommit: "abc123",
+		BaseRepo:   models.Repo{FullName: "org/infra", VCSHost: models.VCSHost{Hostname: "github.com"}},
+	}
+	When(backend.GetPullStatuses()).ThenReturn([]models.PullStatus{{
+		Pull: pull,
+		Projects: []models.ProjectStatus{
+			{RepoRelDir: "network", Workspace: "default", Status: models.PlannedPlanStatus},
+			{RepoRelDir: ".", Workspace: "default", ProjectName: "app", Status: models.PlannedPlanStatus},
+			{RepoRelDir: "db", Workspace: "default", Status: models.ErroredPlanStatus},
+		},
+	}}, nil)
+	When(ac.WorkingDir.GetWorkingDir(Any[models.Repo](), Any[models.PullRequest](), Eq("default"))).ThenReturn(repoDir, nil)
+
+	getPlan := func(token string, project string, query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/plans/org/infra/1/"+project+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"repo": "org/infra", "pull": "1", "project": project})
+		if token != "" {
+			req.Header.Set(atlantisTokenHeader, token)
+		}
+		w := httptest.NewRecorder()
+		ac.GetPlan(w, req)
+		return w
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		ResponseContains(t, getPlan("wrong", "network", ""), http.StatusUnauthorized, "did not match expected secret")
+	})
+
+	t.Run("plan by dir", func(t *testing.T) {
+		w := getPlan(atlantisToken, "network", "")
+		ResponseContains(t, w, http.StatusOK, "plan")
+		Equals(t, "application/octet-stream", w.Result().Header.Get("Content-Type"))
+		Equals(t, "abc123", w.Result().Header.Get("X-Atlantis-Head-Commit"))
+	})
+
+	t.Run("show json", func(t *testing.T) {
+		w := getPlan(atlantisToken, "network", "?format=json")
+		ResponseContains(t, w, http.StatusOK, `{"format_version":"1.2"}`)
+		Equals(t, "application/json", w.Result().Header.Get("Content-Type"))
+	})
+
+	t.Run("show text", func(t *testing.T) {
+		w := getPlan(atlantisToken, "network", "?format=text")
+		ResponseContains(t, w, http.StatusOK, "Terraform will perform the following actions:")
+		Equals(t, "text/plain; charset=utf-8", w.Result().Header.Get("Content-Type"))
+	})
+
+	t.Run("plan by project name with read token", func(t *testing.T) {
+		ResponseContains(t, getPlan("reader", "app", ""), http.StatusOK, "app plan")
+	})
+
+	t.Run("read token of another repo", func(t *testing.T) {
+		ResponseContains(t, getPlan("other", "app", ""), http.StatusForbidden, "token is not allowed to read the plans of org/infra")
+	})
+
+	t.Run("missing show json", func(t *testing.T) {
+		ResponseContains(t, getPlan(atlantisToken, "app", "?format=json"), http.StatusNotFound, `project \"app\" has no json plan`)
+	})
+
+	t.Run("missing show text", func(t *testing.T) {
+		ResponseContains(t, getPlan(atlantisToken, "app", "?format=text"), http.StatusNotFound, `project \"app\" has no text plan`)
+	})
+
+	t.Run("errored plan", func(t *testing.T) {
+		ResponseContains(t, getPlan(atlantisToken, "db", ""), http.StatusNotFound, `project \"db\" failed to plan`)
+	})
+
+	t.Run("unknown project", func(t *testing.T) {
+		ResponseContains(t, getPlan(atlantisToken, "unknown", ""), http.StatusNotFound, `no project \"unknown\" in org/infra#1`)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		ResponseContains(t, getPlan(atlantisToken, "network", "?format=yaml"), http.StatusBadRequest, `format \"yaml\" is not one of plan, json or text`)
+	})
+}
+
+func TestParseAPIPlanReadTokens(t *testing.T) {
+	tokens, err := controllers.ParseAPIPlanReadTokens("a:github.com/org/infra,a:github.com/org/app*,b:github.com/*")
+	Ok(t, err)
+	Equals(t, 2, len(tokens))
+	Assert(t, tokens["a"].IsAllowlisted("org/app-1", "github.com"), "expected a to read org/app-1")
+	Assert(t, !tokens["a"].IsAllowlisted("org/other", "github.com"), "expected a not to read org/other")
+	Assert(t, tokens["b"].IsAllowlisted("org/other", "github.com"), "expected b to read org/other")
+
+	tokens, err = controllers.ParseAPIPlanReadTokens("")
+	Ok(t, err)
+	Equals(t, 0, len(tokens))
+
+	_, err = controllers.ParseAPIPlanReadTokens("a")
+	ErrEquals(t, `plan read token "a" must be of the form {token}:{repo}`, err)
+}
+
+func TestAPIController_RenameProject(t *testing.T) {
+	ac, _, _ := setup(t)
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+	defer boltDB.Close() // nolint: errcheck
+	ac.ProjectRenamer = &events.ProjectRenamer{Backend: boltDB}
+
+	oldProject := models.NewProject("owner/repo", "network", "")
+	_, _, err = boltDB.TryLock(models.ProjectLock{Project: oldProject, Workspace: "default", Pull: models.PullRequest{Num: 1}})
+	Ok(t, err)
+
+	t.Run("missing fields", func(t *testing.T) {
+		body, _ := json.Marshal(controllers.ProjectRenameRequest{
+			Repository: "owner/repo",
+			From:       controllers.APIProject{Directory: "network"},
+			To:         controllers.APIProject{Directory: "infra/network", Workspace: "default"},
+		})
+		req, _ := http.NewRequest("POST", "/api/projects/rename", bytes.NewBuffer(body))
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.RenameProject(w, req)
+		ResponseContains(t, w, http.StatusBadRequest, "request is missing fields")
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		body, _ := json.Marshal(controllers.ProjectRenameRequest{
+			Repository: "owner/repo",
+			From:       controllers.APIProject{Directory: "network", Workspace: "default"},
+			To:         controllers.APIProject{Directory: "infra/network", Workspace: "default"},
+		})
+		req, _ := http.NewRequest("POST", "/api/projects/rename", bytes.NewBuffer(body))
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.RenameProject(w, req)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+
+		var result events.ProjectRenameResult
+		Ok(t, json.NewDecoder(w.Result().Body).Decode(&result))
+		Equals(t, 1, result.Locks)
+		lock, err := boltDB.GetLock(models.NewProject("owner/repo", "infra/network", ""), "default")
+		Ok(t, err)
+		Assert(t, lock != nil, "expected the lock to be moved")
+	})
+}
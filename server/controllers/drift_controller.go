@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/controllers/web_templates"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// DriftController renders the results of scheduled drift detection.
+type DriftController struct {
+	AtlantisVersion     string
+	AtlantisURL         *url.URL
+	Logger              logging.SimpleLogging
+	Backend             locking.Backend
+	DriftTemplate       web_templates.TemplateWriter
+	DriftOutputTemplate web_templates.TemplateWriter
+}
+
+// GetDrift is the GET /drift route. It renders the latest drift detection
+// result for each project, drifted projects first.
+func (d *DriftController) GetDrift(w http.ResponseWriter, _ *http.Request) {
+	statuses, err := d.Backend.GetDriftStatuses()
+	if err != nil {
+		d.respond(w, logging.Error, http.StatusInternalServerError, "Failed getting drift statuses: %s", err)
+		return
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		if statuses[i].Drifted != statuses[j].Drifted {
+			return statuses[i].Drifted
+		}
+		if (statuses[i].Error != "") != (statuses[j].Error != "") {
+			return statuses[i].Error != ""
+		}
+		return statuses[i].Key() < statuses[j].Key()
+	})
+
+	var projects []web_templates.DriftProjectData
+	for _, status := range statuses {
+		projects = append(projects, newDriftProjectData(status))
+	}
+	err = d.DriftTemplate.Execute(w, web_templates.DriftIndexData{
+		Projects:        projects,
+		AtlantisVersion: d.AtlantisVersion,
+		CleanedBasePath: d.AtlantisURL.Path,
+	})
+	if err != nil {
+		d.Logger.Err(err.Error())
+	}
+}
+
+// GetDriftOutput is the GET /drift/output?id={id} route. It renders the
+// output of the refresh-only plan for a project.
+func (d *DriftController) GetDriftOutput(w http.ResponseWriter, r *http.Request) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok || id == "" {
+		d.respond(w, logging.Warn, http.StatusBadRequest, "No drift id in request")
+		return
+	}
+	idUnencoded, err := url.QueryUnescape(id)
+	if err != nil {
+		d.respond(w, logging.Warn, http.StatusBadRequest, "Invalid drift id: %s", err)
+		return
+	}
+
+	statuses, err := d.Backend.GetDriftStatuses()
+	if err != nil {
+		d.respond(w, logging.Error, http.StatusInternalServerError, "Failed getting drift statuses: %s", err)
+		return
+	}
+	for _, status := range statuses {
+		if status.Key() != idUnencoded {
+			continue
+		}
+		err = d.DriftOutputTemplate.Execute(w, web_templates.DriftOutputData{
+			DriftProjectData: newDriftProjectData(status),
+			Output:           status.Output,
+			AtlantisVersion:  d.AtlantisVersion,
+			CleanedBasePath:  d.AtlantisURL.Path,
+		})
+		if err != nil {
+			d.Logger.Err(err.Error())
+		}
+		return
+	}
+	d.respond(w, logging.Info, http.StatusNotFound, "No drift status found at id '%s'", idUnencoded)
+}
+
+func newDriftProjectData(status models.DriftStatus) web_templates.DriftProjectData {
+	return web_templates.DriftProjectData{
+		OutputPath:         fmt.Sprintf("/drift/output?id=%s", url.QueryEscape(status.Key())),
+		RepoFullName:       status.RepoFullName,
+		Branch:             status.Branch,
+		ProjectName:        status.ProjectName,
+		Path:               status.RepoRelDir,
+		Workspace:          status.Workspace,
+		Drifted:            status.Drifted,
+		DriftedResources:   status.DriftedResources,
+		Error:              status.Error,
+		CheckedAtFormatted: status.CheckedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func (d *DriftController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	response := fmt.Sprintf(format, args...)
+	d.Logger.Log(lvl, response)
+	w.WriteHeader(responseCode)
+	fmt.Fprintln(w, response)
+}
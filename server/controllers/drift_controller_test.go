@@ -0,0 +1,145 @@
+package controllers_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/controllers/web_templates"
+	tMocks "github.com/runatlantis/atlantis/server/controllers/web_templates/mocks"
+	"github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+var driftCheckedAt = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+var inSyncStatus = models.DriftStatus{
+	RepoID:       "github.com/owner/repo",
+	RepoFullName: "owner/repo",
+	Branch:       "main",
+	RepoRelDir:   "a",
+	Workspace:    "default",
+	CheckedAt:    driftCheckedAt,
+}
+
+var driftedStatus = models.DriftStatus{
+	RepoID:           "github.com/owner/repo",
+	RepoFullName:     "owner/repo",
+	Branch:           "main",
+	RepoRelDir:       "b",
+	Workspace:        "default",
+	Drifted:          true,
+	DriftedResources: 2,
+	Output:           "output",
+	CheckedAt:        driftCheckedAt,
+}
+
+func newDriftController(t *testing.T) (controllers.DriftController, *mocks.MockBackend, *tMocks.MockTemplateWriter) {
+	RegisterMockTestingT(t)
+	backend := mocks.NewMockBackend()
+	tmpl := tMocks.NewMockTemplateWriter()
+	atlantisURL, err := url.Parse("https://example.com/basepath")
+	Ok(t, err)
+	return controllers.DriftController{
+		AtlantisVersion:     "1300135",
+		AtlantisURL:         atlantisURL,
+		Logger:              logging.NewNoopLogger(t),
+		Backend:             backend,
+		DriftTemplate:       tmpl,
+		DriftOutputTemplate: tmpl,
+	}, backend, tmpl
+}
+
+func TestGetDrift_Success(t *testing.T) {
+	dc, backend, tmpl := newDriftController(t)
+	When(backend.GetDriftStatuses()).ThenReturn([]models.DriftStatus{inSyncStatus, driftedStatus}, nil)
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	dc.GetDrift(w, req)
+	tmpl.VerifyWasCalledOnce().Execute(w, web_templates.DriftIndexData{
+		Projects: []web_templates.DriftProjectData{
+			{
+				OutputPath:         "/drift/output?id=github.com%2Fowner%2Frepo%2Fb%2Fdefault%2F",
+				RepoFullName:       "owner/repo",
+				Branch:             "main",
+				Path:               "b",
+				Workspace:          "default",
+				Drifted:            true,
+				DriftedResources:   2,
+				CheckedAtFormatted: "2024-01-02 03:04:05",
+			},
+			{
+				OutputPath:         "/drift/output?id=github.com%2Fowner%2Frepo%2Fa%2Fdefault%2F",
+				RepoFullName:       "owner/repo",
+				Branch:             "main",
+				Path:               "a",
+				Workspace:          "default",
+				CheckedAtFormatted: "2024-01-02 03:04:05",
+			},
+		},
+		AtlantisVersion: "1300135",
+		CleanedBasePath: "/basepath",
+	})
+	ResponseContains(t, w, http.StatusOK, "")
+}
+
+func TestGetDrift_BackendErr(t *testing.T) {
+	dc, backend, _ := newDriftController(t)
+	When(backend.GetDriftStatuses()).ThenReturn(nil, errors.New("err"))
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	dc.GetDrift(w, req)
+	ResponseContains(t, w, http.StatusInternalServerError, "Failed getting drift statuses: err")
+}
+
+func TestGetDriftOutput_NoID(t *testing.T) {
+	dc, _, _ := newDriftController(t)
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	dc.GetDriftOutput(w, req)
+	ResponseContains(t, w, http.StatusBadRequest, "No drift id in request")
+}
+
+func TestGetDriftOutput_NotFound(t *testing.T) {
+	dc, backend, _ := newDriftController(t)
+	When(backend.GetDriftStatuses()).ThenReturn([]models.DriftStatus{inSyncStatus}, nil)
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req = mux.SetURLVars(req, map[string]string{"id": "github.com%2Fowner%2Frepo%2Fb%2Fdefault%2F"})
+	w := httptest.NewRecorder()
+	dc.GetDriftOutput(w, req)
+	ResponseContains(t, w, http.StatusNotFound, "No drift status found at id 'github.com/owner/repo/b/default/'")
+}
+
+func TestGetDriftOutput_Success(t *testing.T) {
+	dc, backend, tmpl := newDriftController(t)
+	When(backend.GetDriftStatuses()).ThenReturn([]models.DriftStatus{inSyncStatus, driftedStatus}, nil)
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req = mux.SetURLVars(req, map[string]string{"id": "github.com%2Fowner%2Frepo%2Fb%2Fdefault%2F"})
+	w := httptest.NewRecorder()
+	dc.GetDriftOutput(w, req)
+	tmpl.VerifyWasCalledOnce().Execute(w, web_templates.DriftOutputData{
+		DriftProjectData: web_templates.DriftProjectData{
+			OutputPath:         "/drift/output?id=github.com%2Fowner%2Frepo%2Fb%2Fdefault%2F",
+			RepoFullName:       "owner/repo",
+			Branch:             "main",
+			Path:               "b",
+			Workspace:          "default",
+			Drifted:            true,
+			DriftedResources:   2,
+			CheckedAtFormatted: "2024-01-02 03:04:05",
+		},
+		Output:          "output",
+		AtlantisVersion: "1300135",
+		CleanedBasePath: "/basepath",
+	})
+	ResponseContains(t, w, http.StatusOK, "")
+}
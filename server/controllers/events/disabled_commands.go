@@ -0,0 +1,39 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+// DisabledCommandsFinder looks up the comment commands disabled for
+// individual repos. It's implemented by valid.GlobalCfg.
+type DisabledCommandsFinder interface {
+	// DisabledCommands returns the commands disabled for the repo with id
+	// repoID.
+	DisabledCommands(repoID string) []string
+}
+
+// disabledCommandComment returns the comment refusing cmd if it's one of
+// disabled. It returns an empty string if cmd can be run.
+func disabledCommandComment(cmd *events.CommentCommand, disabled []string, executableName string) string {
+	if cmd == nil || len(disabled) == 0 {
+		return ""
+	}
+	if utils.SlicesContains(disabled, cmd.Name.String()) {
+		return fmt.Sprintf("```\nError: the %s command is disabled for this repo.\n```", cmd.Name.String())
+	}
+	if cmd.IsForSpecificProject() {
+		return ""
+	}
+	switch {
+	case cmd.Name == command.Plan && utils.SlicesContains(disabled, valid.PlanAllCommand),
+		cmd.Name == command.Apply && utils.SlicesContains(disabled, valid.ApplyAllCommand):
+		return fmt.Sprintf("```\nError: running %s on all projects is disabled for this repo.\nSelect the projects with -p, -d or -w, ex. '%s %s -p <project>'.\n```",
+			cmd.Name.String(), executableName, cmd.Name.String())
+	}
+	return ""
+}
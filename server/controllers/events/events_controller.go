@@ -21,12 +21,15 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v68/github"
 	"github.com/mcdafydd/go-azuredevops/azuredevops"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
@@ -46,6 +49,13 @@ const giteaEventTypeHeader = "X-Gitea-Event-Type"
 const giteaSignatureHeader = "X-Gitea-Signature"
 const giteaRequestIDHeader = "X-Gitea-Delivery"
 
+// Forgejo sends the Gitea headers too but they may be removed in a future
+// release, so its own headers are accepted as well.
+const forgejoHeader = "X-Forgejo-Event"
+const forgejoEventTypeHeader = "X-Forgejo-Event-Type"
+const forgejoSignatureHeader = "X-Forgejo-Signature"
+const forgejoRequestIDHeader = "X-Forgejo-Delivery"
+
 // bitbucketEventTypeHeader is the same in both cloud and server.
 const bitbucketEventTypeHeader = "X-Event-Key"
 const bitbucketCloudRequestIDHeader = "X-Request-UUID"
@@ -101,11 +111,31 @@ type VCSEventsController struct {
 	AzureDevopsWebhookBasicPassword []byte
 	AzureDevopsRequestValidator     AzureDevopsRequestValidator
 	GiteaWebhookSecret              []byte
+	// WebhookSecretFinder looks up secrets configured for individual repos.
+	// If the repo a webhook is for has its own secret, it's used instead of
+	// the VCS host's global secret. If nil, only the global secrets are used.
+	// Bitbucket Server and Azure DevOps webhooks always use the global
+	// secrets.
+	WebhookSecretFinder WebhookSecretFinder
+	// WebhookDeduplicator detects deliveries that were already received so
+	// retried webhooks don't trigger duplicate plans and applies. If nil, every
+	// delivery is processed.
+	WebhookDeduplicator *WebhookDeduplicator
+	// DisabledCommandsFinder looks up the comment commands disabled for
+	// individual repos. If nil, all commands allowed by the comment parser
+	// can be run.
+	DisabledCommandsFinder DisabledCommandsFinder
+	// VCSAdapter parses webhooks for the VCS host supported by the configured
+	// VCS adapter. If nil, no VCS adapter is configured.
+	VCSAdapter VCSAdapterWebhookParser
+	// EventHooks runs the hooks for comment_unrecognized when a comment is
+	// addressed to Atlantis but isn't a command it knows. It can be nil.
+	EventHooks events.WorkflowHookEventRunner
 }
 
 // Post handles POST webhook requests.
 func (e *VCSEventsController) Post(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get(giteaHeader) != "" {
+	if giteaHeaderValue(r, giteaHeader, forgejoHeader) != "" {
 		if !e.supportsHost(models.Gitea) {
 			e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request since not configured to support Gitea")
 			return
@@ -174,7 +204,8 @@ type HTTPResponse struct {
 
 func (e *VCSEventsController) handleGithubPost(w http.ResponseWriter, r *http.Request) {
 	// Validate the request against the optional webhook secret.
-	payload, err := e.GithubRequestValidator.Validate(r, e.GithubWebhookSecret)
+	secret := e.requestWebhookSecret(models.Github, r, e.GithubWebhookSecret)
+	payload, err := e.GithubRequestValidator.Validate(r, secret)
 	if err != nil {
 		e.respond(w, logging.Warn, http.StatusBadRequest, "%s", err.Error())
 		return
@@ -186,6 +217,10 @@ func (e *VCSEventsController) handleGithubPost(w http.ResponseWriter, r *http.Re
 
 	logger.Debug("request valid")
 
+	if e.isDuplicateDelivery(w, models.Github, r.Header.Get("X-Github-Delivery"), deliverySentAt(models.Github, payload), payload) {
+		return
+	}
+
 	event, _ := github.ParseWebHook(github.WebHookType(r), payload)
 
 	var resp HTTPResponse
@@ -199,6 +234,9 @@ func (e *VCSEventsController) handleGithubPost(w http.ResponseWriter, r *http.Re
 		resp = e.HandleGithubPullRequestEvent(logger, event, githubReqID)
 		scope = scope.SubScope(fmt.Sprintf("pr_%s", *event.Action))
 		scope = vcs.SetGitScopeTags(scope, event.GetRepo().GetFullName(), event.GetNumber())
+	case *github.CheckRunEvent:
+		resp = e.HandleGithubCheckRunEvent(event, githubReqID, logger)
+		scope = scope.SubScope(fmt.Sprintf("check_run_%s", event.GetAction()))
 	default:
 		resp = HTTPResponse{
 			body: fmt.Sprintf("Ignoring unsupported event %s", githubReqID),
@@ -230,12 +268,15 @@ func (e *VCSEventsController) handleBitbucketCloudPost(w http.ResponseWriter, r
 		e.respond(w, logging.Error, http.StatusBadRequest, "Unable to read body: %s %s=%s", err, bitbucketCloudRequestIDHeader, reqID)
 		return
 	}
-	if len(e.BitbucketWebhookSecret) > 0 {
-		if err := bitbucketcloud.ValidateSignature(body, sig, e.BitbucketWebhookSecret); err != nil {
+	if secret := e.repoWebhookSecret(models.BitbucketCloud, r.Header.Get("Content-Type"), body, e.BitbucketWebhookSecret); len(secret) > 0 {
+		if err := bitbucketcloud.ValidateSignature(body, sig, secret); err != nil {
 			e.respond(w, logging.Warn, http.StatusBadRequest, "%s", errors.Wrap(err, "request did not pass validation").Error())
 			return
 		}
 	}
+	if e.isDuplicateDelivery(w, models.BitbucketCloud, reqID, deliverySentAt(models.BitbucketCloud, body), body) {
+		return
+	}
 	switch eventType {
 	case bitbucketcloud.PullCreatedHeader, bitbucketcloud.PullUpdatedHeader, bitbucketcloud.PullFulfilledHeader, bitbucketcloud.PullRejectedHeader:
 		e.Logger.Debug("handling as pull request state changed event")
@@ -266,12 +307,15 @@ func (e *VCSEventsController) handleBitbucketServerPost(w http.ResponseWriter, r
 		e.respond(w, logging.Info, http.StatusOK, "Successfully received %s event %s=%s", eventType, bitbucketServerRequestIDHeader, reqID)
 		return
 	}
-	if len(e.BitbucketWebhookSecret) > 0 {
-		if err := bitbucketserver.ValidateSignature(body, sig, e.BitbucketWebhookSecret); err != nil {
+	if secret := e.bitbucketServerWebhookSecret(eventType, body, e.BitbucketWebhookSecret); len(secret) > 0 {
+		if err := bitbucketserver.ValidateSignature(body, sig, secret); err != nil {
 			e.respond(w, logging.Warn, http.StatusBadRequest, "%s", errors.Wrap(err, "request did not pass validation").Error())
 			return
 		}
 	}
+	if e.isDuplicateDelivery(w, models.BitbucketServer, reqID, deliverySentAt(models.BitbucketServer, body), body) {
+		return
+	}
 	switch eventType {
 	case bitbucketserver.PullCreatedHeader, bitbucketserver.PullFromRefUpdatedHeader, bitbucketserver.PullMergedHeader, bitbucketserver.PullDeclinedHeader, bitbucketserver.PullDeletedHeader:
 		e.Logger.Debug("handling as pull request state changed event")
@@ -295,6 +339,10 @@ func (e *VCSEventsController) handleAzureDevopsPost(w http.ResponseWriter, r *ht
 	}
 	e.Logger.Debug("request valid")
 
+	if e.isDuplicateDelivery(w, models.AzureDevops, r.Header.Get("Request-Id"), deliverySentAt(models.AzureDevops, payload), payload) {
+		return
+	}
+
 	azuredevopsReqID := "Request-Id=" + r.Header.Get("Request-Id")
 	event, err := azuredevops.ParseWebHook(payload)
 	if err != nil {
@@ -314,9 +362,9 @@ func (e *VCSEventsController) handleAzureDevopsPost(w http.ResponseWriter, r *ht
 }
 
 func (e *VCSEventsController) handleGiteaPost(w http.ResponseWriter, r *http.Request) {
-	signature := r.Header.Get(giteaSignatureHeader)
-	eventType := r.Header.Get(giteaEventTypeHeader)
-	reqID := r.Header.Get(giteaRequestIDHeader)
+	signature := giteaHeaderValue(r, giteaSignatureHeader, forgejoSignatureHeader)
+	eventType := giteaHeaderValue(r, giteaEventTypeHeader, forgejoEventTypeHeader)
+	reqID := giteaHeaderValue(r, giteaRequestIDHeader, forgejoRequestIDHeader)
 
 	defer r.Body.Close() // Ensure the request body is closed
 
@@ -326,12 +374,15 @@ func (e *VCSEventsController) handleGiteaPost(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if len(e.GiteaWebhookSecret) > 0 {
-		if err := gitea.ValidateSignature(body, signature, e.GiteaWebhookSecret); err != nil {
+	if secret := e.repoWebhookSecret(models.Gitea, r.Header.Get("Content-Type"), body, e.GiteaWebhookSecret); len(secret) > 0 {
+		if err := gitea.ValidateSignature(body, signature, secret); err != nil {
 			e.respond(w, logging.Warn, http.StatusBadRequest, "%s", errors.Wrap(err, "request did not pass validation").Error())
 			return
 		}
 	}
+	if e.isDuplicateDelivery(w, models.Gitea, reqID, deliverySentAt(models.Gitea, body), body) {
+		return
+	}
 
 	logger := e.Logger.With("gitea-request-id", reqID)
 
@@ -351,6 +402,15 @@ func (e *VCSEventsController) handleGiteaPost(w http.ResponseWriter, r *http.Req
 	}
 }
 
+// giteaHeaderValue returns the value of the Gitea header of r, or of the
+// equivalent Forgejo header if it's not set.
+func giteaHeaderValue(r *http.Request, giteaHeader string, forgejoHeader string) string {
+	if v := r.Header.Get(giteaHeader); v != "" {
+		return v
+	}
+	return r.Header.Get(forgejoHeader)
+}
+
 func (e *VCSEventsController) handleGiteaPullRequestEvent(logger logging.SimpleLogging, w http.ResponseWriter, body []byte, reqID string) {
 	logger.Debug("Entering handleGiteaPullRequestEvent")
 	// Attempt to unmarshal the incoming body into the Gitea PullRequest struct
@@ -432,6 +492,44 @@ func (e *VCSEventsController) HandleGithubCommentEvent(event *github.IssueCommen
 	return e.handleCommentEvent(logger, baseRepo, nil, nil, user, pullNum, comment.GetBody(), comment.GetID(), models.Github)
 }
 
+// HandleGithubCheckRunEvent handles the check run events of GitHub. When the
+// re-plan action of a check run created by Atlantis is requested, or the check
+// run is re-run, the command stored in its external ID is run as if it was
+// commented on the pull request by the user that requested it.
+func (e *VCSEventsController) HandleGithubCheckRunEvent(event *github.CheckRunEvent, githubReqID string, logger logging.SimpleLogging) HTTPResponse {
+	isReplan := event.GetAction() == "requested_action" && event.GetRequestedAction().Identifier == events.CheckRunReplanAction
+	if !isReplan && event.GetAction() != "rerequested" {
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring check run event since action was not a re-plan %s", githubReqID),
+		}
+	}
+	checkRun := event.GetCheckRun()
+	if checkRun.GetExternalID() == "" || len(checkRun.PullRequests) == 0 {
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring check run event since the check run was not created for a pull request %s", githubReqID),
+		}
+	}
+
+	baseRepo, err := e.Parser.ParseGithubRepo(event.GetRepo())
+	if err == nil && event.GetSender().GetLogin() == "" {
+		err = errors.New("sender.login is null")
+	}
+	if err != nil {
+		wrapped := errors.Wrapf(err, "Failed parsing event: %s", githubReqID)
+		return HTTPResponse{
+			body: wrapped.Error(),
+			err: HTTPError{
+				code: http.StatusBadRequest,
+				err:  wrapped,
+			},
+		}
+	}
+	user := models.User{Username: event.GetSender().GetLogin()}
+	pullNum := checkRun.PullRequests[0].GetNumber()
+
+	return e.handleCommentEvent(logger, baseRepo, nil, nil, user, pullNum, checkRun.GetExternalID(), -1, models.Github)
+}
+
 // HandleBitbucketCloudCommentEvent handles comment events from Bitbucket.
 func (e *VCSEventsController) HandleBitbucketCloudCommentEvent(w http.ResponseWriter, body []byte, reqID string) {
 	pull, baseRepo, headRepo, user, comment, err := e.Parser.ParseBitbucketCloudPullCommentEvent(body)
@@ -601,7 +699,7 @@ func (e *VCSEventsController) handlePullRequestEvent(logger logging.SimpleLoggin
 	case models.ClosedPullEvent:
 		// If the pull request was closed, we delete locks.
 		logger.Info("Pull request closed, cleaning up...")
-		if err := e.PullCleaner.CleanUpPull(logger, baseRepo, pull); err != nil {
+		if err := e.PullCleaner.CleanUpPull(logger, baseRepo, headRepo, pull); err != nil {
 			return HTTPResponse{
 				body: err.Error(),
 				err: HTTPError{
@@ -615,6 +713,18 @@ func (e *VCSEventsController) handlePullRequestEvent(logger logging.SimpleLoggin
 		return HTTPResponse{
 			body: "Pull request cleaned successfully",
 		}
+	case models.ApprovalLabeledPullEvent:
+		// Adding the approval label approves the commit the pull request was
+		// at, as if the user who added it commented ok-to-test.
+		cmd := &events.CommentCommand{Name: command.OkToTest, ApprovedCommit: pull.HeadCommit}
+		if !e.TestingMode {
+			go e.CommandRunner.RunCommentCommand(baseRepo, &headRepo, &pull, user, pull.Num, cmd)
+		} else {
+			e.CommandRunner.RunCommentCommand(baseRepo, &headRepo, &pull, user, pull.Num, cmd)
+		}
+		return HTTPResponse{
+			body: "Processing...",
+		}
 	case models.OtherPullEvent:
 		// Else we ignore the event.
 		return HTTPResponse{
@@ -625,13 +735,23 @@ func (e *VCSEventsController) handlePullRequestEvent(logger logging.SimpleLoggin
 }
 
 func (e *VCSEventsController) handleGitlabPost(w http.ResponseWriter, r *http.Request) {
-	event, err := e.GitlabRequestParserValidator.ParseAndValidate(r, e.GitlabWebhookSecret)
+	secret := e.requestWebhookSecret(models.Gitlab, r, e.GitlabWebhookSecret)
+	event, err := e.GitlabRequestParserValidator.ParseAndValidate(r, secret)
 	if err != nil {
 		e.respond(w, logging.Warn, http.StatusBadRequest, "%s", err.Error())
 		return
 	}
 	e.Logger.Debug("request valid")
 
+	// The parser consumes the request body so we fingerprint the parsed event
+	// instead.
+	payload, err := json.Marshal(event)
+	if err != nil {
+		e.Logger.Warn("unable to serialize GitLab event for deduplication: %s", err)
+	} else if e.isDuplicateDelivery(w, models.Gitlab, gitlabDeliveryID(r), gitlabDeliverySentAt(event), payload) {
+		return
+	}
+
 	switch event := event.(type) {
 	case gitlab.MergeCommentEvent:
 		e.Logger.Debug("handling as comment event")
@@ -670,6 +790,36 @@ func (e *VCSEventsController) HandleGitlabCommentEvent(w http.ResponseWriter, ev
 	e.respond(w, lvl, code, "%s", msg)
 }
 
+// runUnrecognizedCommentHooks runs the hooks for comment_unrecognized in the
+// background, or synchronously in testing mode.
+func (e *VCSEventsController) runUnrecognizedCommentHooks(logger logging.SimpleLogging, baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, comment string) {
+	pull := models.PullRequest{Num: pullNum, BaseRepo: baseRepo}
+	if maybePull != nil {
+		pull = *maybePull
+	}
+	hookCtx := models.WorkflowHookCommandContext{
+		BaseRepo: baseRepo,
+		Log:      logger,
+		Pull:     pull,
+		User:     user,
+		Event:    valid.UnrecognizedCommentHookEvent,
+		Comment:  comment,
+	}
+	if maybeHeadRepo != nil {
+		hookCtx.HeadRepo = *maybeHeadRepo
+	}
+	run := func() {
+		if err := e.EventHooks.RunEventHooks(hookCtx); err != nil {
+			logger.Warn("unable to run %s hooks: %s", valid.UnrecognizedCommentHookEvent, err)
+		}
+	}
+	if e.TestingMode {
+		run()
+	} else {
+		go run()
+	}
+}
+
 func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, comment string, commentID int64, vcsHost models.VCSHostType) HTTPResponse {
 	logger = logger.WithHistory(
 		"repo", baseRepo.FullName,
@@ -709,8 +859,17 @@ func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, b
 		}
 	}
 
-	// It's a comment we're going to react to so add a reaction.
-	if e.EmojiReaction != "" {
+	if e.DisabledCommandsFinder != nil {
+		disabled := e.DisabledCommandsFinder.DisabledCommands(baseRepo.ID())
+		if refusal := disabledCommandComment(parseResult.Command, disabled, e.ExecutableName); refusal != "" {
+			logger.Info("Refusing '%s' comment since it's disabled for this repo", parseResult.Command.Name)
+			parseResult = events.CommentParseResult{CommentResponse: refusal}
+		}
+	}
+
+	// It's a comment we're going to react to so add a reaction, unless the
+	// command didn't come from a comment.
+	if e.EmojiReaction != "" && commentID != -1 {
 		err := e.VCSClient.ReactToComment(logger, baseRepo, pullNum, commentID, e.EmojiReaction)
 		if err != nil {
 			logger.Warn("Failed to react to comment: %s", err)
@@ -725,6 +884,9 @@ func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, b
 		if err := e.VCSClient.CreateComment(logger, baseRepo, pullNum, parseResult.CommentResponse, ""); err != nil {
 			logger.Err("Unable to comment on pull request: %s", err)
 		}
+		if parseResult.Unrecognized && e.EventHooks != nil {
+			e.runUnrecognizedCommentHooks(logger, baseRepo, maybeHeadRepo, maybePull, user, pullNum, comment)
+		}
 		return HTTPResponse{
 			body: "Commenting back on pull request",
 		}
@@ -902,6 +1064,45 @@ func (e *VCSEventsController) respond(w http.ResponseWriter, lvl logging.LogLeve
 	fmt.Fprintln(w, response)
 }
 
+// isDuplicateDelivery returns true and responds to the request if the delivery
+// was already received or was sent at sentAt, longer ago than the
+// deduplication window. If the check fails we process the delivery anyway
+// since dropping a legitimate event is worse than running it twice.
+func (e *VCSEventsController) isDuplicateDelivery(w http.ResponseWriter, vcsHost models.VCSHostType, deliveryID string, sentAt time.Time, payload []byte) bool {
+	if e.WebhookDeduplicator.IsStale(sentAt) {
+		e.respond(w, logging.Info, http.StatusOK, "Ignoring stale %s delivery %q sent at %s", vcsHost.String(), deliveryID, sentAt.Format(time.RFC3339))
+		return true
+	}
+	duplicate, err := e.WebhookDeduplicator.IsDuplicate(vcsHost, deliveryID, payload)
+	if err != nil {
+		e.Logger.Warn("unable to check if delivery %q was already received: %s", deliveryID, err)
+		return false
+	}
+	if duplicate {
+		e.respond(w, logging.Info, http.StatusOK, "Ignoring duplicate %s delivery %q", vcsHost.String(), deliveryID)
+	}
+	return duplicate
+}
+
+// gitlabDeliverySentAt returns when the event of a GitLab delivery happened,
+// or the zero time if it's not a comment.
+func gitlabDeliverySentAt(event interface{}) time.Time {
+	if comment, ok := event.(gitlab.MergeCommentEvent); ok {
+		return parseDeliveryTime(comment.ObjectAttributes.CreatedAt)
+	}
+	return time.Time{}
+}
+
+// gitlabDeliveryID returns the ID GitLab uses to identify a webhook delivery.
+// Retried deliveries keep the same Idempotency-Key, older GitLab versions only
+// send the event UUID.
+func gitlabDeliveryID(r *http.Request) string {
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		return key
+	}
+	return r.Header.Get("X-Gitlab-Event-UUID")
+}
+
 // commentNotAllowlisted comments on the pull request that the repo is not
 // allowlisted unless allowlist error comments are disabled.
 func (e *VCSEventsController) commentNotAllowlisted(baseRepo models.Repo, pullNum int) {
@@ -1339,7 +1339,7 @@ func setupE2E(t *testing.T, repoDir string, opt setupOption) (events_controllers
 	mockDownloader := terraform_mocks.NewMockDownloader()
 	distribution := terraform.NewDistributionTerraformWithDownloader(mockDownloader)
 
-	terraformClient, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "", "default-tf-version", "https://releases.hashicorp.com", true, false, projectCmdOutputHandler)
+	terraformClient, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "", "default-tf-version", "https://releases.hashicorp.com", "", true, false, projectCmdOutputHandler, nil)
 	Ok(t, err)
 	boltdb, err := db.New(dataDir)
 	Ok(t, err)
@@ -1391,7 +1391,7 @@ func setupE2E(t *testing.T, repoDir string, opt setupOption) (events_controllers
 	}
 	drainer := &events.Drainer{}
 
-	parallelPoolSize := 1
+	parallelPoolSize := events.NewParallelPoolSize(1)
 	silenceNoProjects := false
 
 	disableUnlockLabel := "do-not-unlock"
@@ -1435,6 +1435,7 @@ func setupE2E(t *testing.T, repoDir string, opt setupOption) (events_controllers
 		globalCfg,
 		&events.DefaultPendingPlanFinder{},
 		commentParser,
+		nil,
 		false,
 		false,
 		false,
@@ -1454,7 +1455,7 @@ func setupE2E(t *testing.T, repoDir string, opt setupOption) (events_controllers
 
 	Ok(t, err)
 
-	conftextExec := policy.NewConfTestExecutorWorkflow(logger, binDir, mock_policy.NewMockDownloader())
+	conftextExec := policy.NewConfTestExecutorWorkflow(logger, binDir, t.TempDir(), mock_policy.NewMockDownloader())
 
 	// swapping out version cache to something that always returns local conftest
 	// binary
@@ -1619,6 +1620,9 @@ func setupE2E(t *testing.T, repoDir string, opt setupOption) (events_controllers
 		pullUpdater,
 		projectCommandBuilder,
 		projectCommandRunner,
+		&events.StateAuditLog{Path: filepath.Join(dataDir, events.StateAuditLogFileName)},
+		nil,
+		nil,
 	)
 
 	commentCommandRunnerByCmd := map[command.Name]events.CommentCommandRunner{
@@ -24,12 +24,15 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/v68/github"
 	"github.com/mcdafydd/go-azuredevops/azuredevops"
 	. "github.com/petergtz/pegomock/v4"
 	events_controllers "github.com/runatlantis/atlantis/server/controllers/events"
 	"github.com/runatlantis/atlantis/server/controllers/events/mocks"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/db"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	emocks "github.com/runatlantis/atlantis/server/events/mocks"
@@ -147,6 +150,29 @@ func TestPost_UnsupportedGiteaEvent(t *testing.T) {
 	ResponseContains(t, w, http.StatusOK, "Ignoring unsupported Gitea event")
 }
 
+func TestPost_UnsupportedForgejoEvent(t *testing.T) {
+	t.Log("when the event type is an unsupported forgejo event we ignore it")
+	e, _, _, _, _, _, _, _, _ := setup(t)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer([]byte(`{"not an event": ""}`)))
+	req.Header.Set("X-Forgejo-Event", "push")
+	req.Header.Set("X-Forgejo-Event-Type", "push")
+	e.GiteaWebhookSecret = nil
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Ignoring unsupported Gitea event type: push")
+}
+
+func TestPost_InvalidForgejoSecret(t *testing.T) {
+	t.Log("when the forgejo payload can't be validated a 400 is returned")
+	e, _, _, _, _, _, _, _, _ := setup(t)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set("X-Forgejo-Event", "pull_request")
+	req.Header.Set("X-Forgejo-Signature", "invalid")
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusBadRequest, "request did not pass validation")
+}
+
 func TestPost_UnsupportedGitlabEvent(t *testing.T) {
 	t.Log("when the event type is an unsupported gitlab event we ignore it")
 	e, _, gl, _, _, _, _, _, _ := setup(t)
@@ -391,6 +417,30 @@ func TestPost_GithubCommentResponse(t *testing.T) {
 	ResponseContains(t, w, http.StatusOK, "Commenting back on pull request")
 }
 
+func TestPost_GithubCommentUnrecognized(t *testing.T) {
+	t.Log("when the event is a github comment atlantis doesn't recognize we comment back and run the event hooks")
+	e, v, _, _, p, _, _, vcsClient, cp := setup(t)
+	eventHooks := emocks.NewMockWorkflowHookEventRunner()
+	e.EventHooks = eventHooks
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "issue_comment")
+	event := `{"action": "created", "comment": {"body": "atlantis paln"}}`
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+	baseRepo := models.Repo{FullName: "owner/repo"}
+	user := models.User{Username: "alice"}
+	When(p.ParseGithubIssueCommentEvent(Any[logging.SimpleLogging](), Any[*github.IssueCommentEvent]())).ThenReturn(baseRepo, user, 1, nil)
+	When(cp.Parse("atlantis paln", models.Github)).ThenReturn(events.CommentParseResult{CommentResponse: "unknown command", Unrecognized: true})
+	w := httptest.NewRecorder()
+
+	e.Post(w, req)
+	vcsClient.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Eq(baseRepo), Eq(1), Eq("unknown command"), Eq(""))
+	hookCtx := eventHooks.VerifyWasCalledOnce().RunEventHooks(Any[models.WorkflowHookCommandContext]()).GetCapturedArguments()
+	Equals(t, "comment_unrecognized", hookCtx.Event)
+	Equals(t, "atlantis paln", hookCtx.Comment)
+	Equals(t, models.PullRequest{Num: 1, BaseRepo: baseRepo}, hookCtx.Pull)
+	Equals(t, user, hookCtx.User)
+}
+
 func TestPost_GitlabCommentSuccess(t *testing.T) {
 	t.Log("when the event is a gitlab comment with a valid command we call the command handler")
 	e, _, gl, _, _, cr, _, _, cp := setup(t)
@@ -425,6 +475,135 @@ func TestPost_GithubCommentSuccess(t *testing.T) {
 	cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, nil, nil, user, 1, &cmd)
 }
 
+func TestPost_GithubPullRequestApprovalLabeled(t *testing.T) {
+	t.Log("when the approval label is added to a pull request we run ok-to-test for the labeled commit")
+	e, v, _, _, p, cr, _, _, _ := setup(t)
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "pull_request")
+	event := `{"action": "labeled"}`
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+	baseRepo := models.Repo{}
+	headRepo := models.Repo{Owner: "fork"}
+	pull := models.PullRequest{Num: 1, HeadCommit: "abc123"}
+	user := models.User{Username: "maintainer"}
+	When(p.ParseGithubPullEvent(Any[logging.SimpleLogging](), Any[*github.PullRequestEvent]())).ThenReturn(pull, models.ApprovalLabeledPullEvent, baseRepo, headRepo, user, nil)
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Processing...")
+
+	cmd := events.CommentCommand{Name: command.OkToTest, ApprovedCommit: "abc123"}
+	cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, &headRepo, &pull, user, 1, &cmd)
+}
+
+func TestPost_GithubCommentDisabledCommand(t *testing.T) {
+	t.Log("when the command is disabled for the repo we comment back instead of running it")
+	baseRepo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.com"}}
+	globalCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{ID: "github.com/owner/repo", DisabledCommands: []string{valid.StateCommand, valid.ApplyAllCommand}},
+		},
+	}
+
+	cases := map[string]struct {
+		cmd        events.CommentCommand
+		expComment string
+	}{
+		"disabled command": {
+			cmd:        events.CommentCommand{Name: command.State, SubName: "rm"},
+			expComment: "```\nError: the state command is disabled for this repo.\n```",
+		},
+		"apply without a project": {
+			cmd:        events.CommentCommand{Name: command.Apply},
+			expComment: "```\nError: running apply on all projects is disabled for this repo.\nSelect the projects with -p, -d or -w, ex. 'atlantis apply -p <project>'.\n```",
+		},
+		"apply of a project": {
+			cmd: events.CommentCommand{Name: command.Apply, ProjectName: "network"},
+		},
+		"command that isn't disabled": {
+			cmd: events.CommentCommand{Name: command.Unlock},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			e, v, _, _, p, cr, _, vcsClient, cp := setup(t)
+			e.DisabledCommandsFinder = globalCfg
+			req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+			req.Header.Set(githubHeader, "issue_comment")
+			event := `{"action": "created"}`
+			When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+			user := models.User{}
+			cmd := c.cmd
+			When(p.ParseGithubIssueCommentEvent(Any[logging.SimpleLogging](), Any[*github.IssueCommentEvent]())).ThenReturn(baseRepo, user, 1, nil)
+			When(cp.Parse("", models.Github)).ThenReturn(events.CommentParseResult{Command: &cmd})
+			w := httptest.NewRecorder()
+			e.Post(w, req)
+
+			if c.expComment != "" {
+				ResponseContains(t, w, http.StatusOK, "Commenting back on pull request")
+				vcsClient.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Eq(baseRepo), Eq(1), Eq(c.expComment), Eq(""))
+				cr.VerifyWasCalled(Never()).RunCommentCommand(Any[models.Repo](), Any[*models.Repo](), Any[*models.PullRequest](), Any[models.User](), Any[int](), Any[*events.CommentCommand]())
+			} else {
+				ResponseContains(t, w, http.StatusOK, "Processing...")
+				cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, nil, nil, user, 1, &cmd)
+			}
+		})
+	}
+}
+
+func TestPost_GithubCommentDuplicateDelivery(t *testing.T) {
+	t.Log("when the same github delivery is received twice we only run the command once")
+	e, v, _, _, p, cr, _, _, cp := setup(t)
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+	e.WebhookDeduplicator = &events_controllers.WebhookDeduplicator{
+		Backend: boltDB,
+		Window:  time.Hour,
+	}
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "issue_comment")
+	req.Header.Set("X-Github-Delivery", "delivery-id")
+	event := `{"action": "created"}`
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+	baseRepo := models.Repo{}
+	user := models.User{}
+	cmd := events.CommentCommand{}
+	When(p.ParseGithubIssueCommentEvent(Any[logging.SimpleLogging](), Any[*github.IssueCommentEvent]())).ThenReturn(baseRepo, user, 1, nil)
+	When(cp.Parse("", models.Github)).ThenReturn(events.CommentParseResult{Command: &cmd})
+
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Processing...")
+
+	w = httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Ignoring duplicate Github delivery")
+
+	cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, nil, nil, user, 1, &cmd)
+}
+
+func TestPost_GithubCommentStaleDelivery(t *testing.T) {
+	t.Log("when a github comment delivery is older than the window we ignore it")
+	e, v, _, _, _, cr, _, _, _ := setup(t)
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+	e.WebhookDeduplicator = &events_controllers.WebhookDeduplicator{
+		Backend: boltDB,
+		Window:  time.Hour,
+	}
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "issue_comment")
+	req.Header.Set("X-Github-Delivery", "delivery-id")
+	event := fmt.Sprintf(`{"action": "created", "comment": {"created_at": %q}}`, time.Now().Add(-2*time.Hour).Format(time.RFC3339))
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Ignoring stale Github delivery")
+
+	cr.VerifyWasCalled(Never()).RunCommentCommand(Any[models.Repo](), Any[*models.Repo](), Any[*models.PullRequest](), Any[models.User](), Any[int](), Any[*events.CommentCommand]())
+}
+
 func TestPost_GithubCommentReaction(t *testing.T) {
 	t.Log("when the event is a github comment with a valid command we call the ReactToComment handler")
 	e, v, _, _, p, _, _, vcsClient, cp := setup(t)
@@ -445,6 +624,38 @@ func TestPost_GithubCommentReaction(t *testing.T) {
 	vcsClient.VerifyWasCalledOnce().ReactToComment(Any[logging.SimpleLogging](), Eq(baseRepo), Eq(1), Eq(int64(1)), Eq("eyes"))
 }
 
+func TestPost_GithubCheckRunReplan(t *testing.T) {
+	t.Log("when the re-plan action of a check run is requested we run its command")
+	e, v, _, _, p, cr, _, vcsClient, cp := setup(t)
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "check_run")
+	event := `{"action": "requested_action", "requested_action": {"identifier": "replan"}, "sender": {"login": "user"}, "check_run": {"external_id": "atlantis plan -d dir", "pull_requests": [{"number": 1}]}}`
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+	baseRepo := models.Repo{FullName: "owner/repo"}
+	user := models.User{Username: "user"}
+	cmd := events.CommentCommand{Name: command.Plan, RepoRelDir: "dir"}
+	When(p.ParseGithubRepo(Any[*github.Repository]())).ThenReturn(baseRepo, nil)
+	When(cp.Parse("atlantis plan -d dir", models.Github)).ThenReturn(events.CommentParseResult{Command: &cmd})
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Processing...")
+
+	cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, nil, nil, user, 1, &cmd)
+	vcsClient.VerifyWasCalled(Never()).ReactToComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[int64](), Any[string]())
+}
+
+func TestPost_GithubCheckRunIgnored(t *testing.T) {
+	t.Log("when a check run event isn't a re-plan it's ignored")
+	e, v, _, _, _, _, _, _, _ := setup(t)
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "check_run")
+	event := `{"action": "completed", "check_run": {"external_id": "atlantis plan -d dir", "pull_requests": [{"number": 1}]}}`
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Ignoring check run event")
+}
+
 func TestPost_GilabCommentReaction(t *testing.T) {
 	t.Log("when the event is a gitlab comment with a valid command we call the ReactToComment handler")
 	e, _, gl, _, _, _, _, vcsClient, cp := setup(t)
@@ -788,7 +999,7 @@ func TestPost_GithubPullRequestClosedErrCleaningPull(t *testing.T) {
 	repo := models.Repo{}
 	pull := models.PullRequest{State: models.ClosedPullState}
 	When(p.ParseGithubPullEvent(Any[logging.SimpleLogging](), Any[*github.PullRequestEvent]())).ThenReturn(pull, models.OpenedPullEvent, repo, repo, models.User{}, nil)
-	When(c.CleanUpPull(Any[logging.SimpleLogging](), repo, pull)).ThenReturn(errors.New("cleanup err"))
+	When(c.CleanUpPull(Any[logging.SimpleLogging](), repo, repo, pull)).ThenReturn(errors.New("cleanup err"))
 	w := httptest.NewRecorder()
 	e.Post(w, req)
 	ResponseContains(t, w, http.StatusInternalServerError, "Error cleaning pull request: cleanup err")
@@ -806,7 +1017,7 @@ func TestPost_GitlabMergeRequestClosedErrCleaningPull(t *testing.T) {
 	repo := models.Repo{}
 	pullRequest := models.PullRequest{State: models.ClosedPullState}
 	When(p.ParseGitlabMergeRequestEvent(event)).ThenReturn(pullRequest, models.OpenedPullEvent, repo, repo, models.User{}, nil)
-	When(c.CleanUpPull(Any[logging.SimpleLogging](), repo, pullRequest)).ThenReturn(errors.New("err"))
+	When(c.CleanUpPull(Any[logging.SimpleLogging](), repo, repo, pullRequest)).ThenReturn(errors.New("err"))
 	w := httptest.NewRecorder()
 	e.Post(w, req)
 	ResponseContains(t, w, http.StatusInternalServerError, "Error cleaning pull request: err")
@@ -824,7 +1035,7 @@ func TestPost_GithubClosedPullRequestSuccess(t *testing.T) {
 	repo := models.Repo{}
 	pull := models.PullRequest{State: models.ClosedPullState}
 	When(p.ParseGithubPullEvent(Any[logging.SimpleLogging](), Any[*github.PullRequestEvent]())).ThenReturn(pull, models.OpenedPullEvent, repo, repo, models.User{}, nil)
-	When(c.CleanUpPull(Any[logging.SimpleLogging](), repo, pull)).ThenReturn(nil)
+	When(c.CleanUpPull(Any[logging.SimpleLogging](), repo, repo, pull)).ThenReturn(nil)
 	w := httptest.NewRecorder()
 	e.Post(w, req)
 	ResponseContains(t, w, http.StatusOK, "Pull request cleaned successfully")
@@ -914,7 +1125,7 @@ func TestPost_BBServerPullClosed(t *testing.T) {
 			}
 			pullCleaner.VerifyWasCalledOnce().CleanUpPull(
 				logger,
-				expRepo, models.PullRequest{
+				expRepo, expRepo, models.PullRequest{
 					Num:        10,
 					HeadCommit: "2d9fb6b9a46eafb1dcef7b008d1a429d45ca742c",
 					URL:        "https://bbserver.com/projects/PROJ/repos/repository/pull-requests/10",
@@ -0,0 +1,52 @@
+package events
+
+import (
+	"net/http"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/adapter"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// VCSAdapterWebhookParser parses webhooks sent to /events/vcs-adapter. It's
+// implemented by adapter.Client.
+type VCSAdapterWebhookParser interface {
+	// ParseWebhook validates and parses the webhook request r.
+	ParseWebhook(r *http.Request) (adapter.Event, error)
+}
+
+// PostVCSAdapter handles POST webhook requests for the VCS host supported by
+// the configured VCS adapter.
+func (e *VCSEventsController) PostVCSAdapter(w http.ResponseWriter, r *http.Request) {
+	if e.VCSAdapter == nil || !e.supportsHost(models.VCSAdapter) {
+		e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request since not configured with a VCS adapter")
+		return
+	}
+	event, err := e.VCSAdapter.ParseWebhook(r)
+	if err != nil {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "Error parsing webhook: %s", err)
+		return
+	}
+	e.Logger.Debug("request valid")
+
+	var resp HTTPResponse
+	switch event.Type {
+	case adapter.CommentEvent:
+		resp = e.handleCommentEvent(e.Logger, event.BaseRepo, &event.HeadRepo, &event.Pull, event.User, event.Pull.Num, event.Comment, event.CommentID, models.VCSAdapter)
+	case adapter.PullEvent:
+		resp = e.handlePullRequestEvent(e.Logger, event.BaseRepo, event.HeadRepo, event.Pull, event.User, event.PullEventType)
+	default:
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring event: %s", event.IgnoreReason)
+		return
+	}
+
+	lvl := logging.Debug
+	code := http.StatusOK
+	msg := resp.body
+	if resp.err.code != 0 {
+		lvl = logging.Error
+		code = resp.err.code
+		msg = resp.err.err.Error()
+	}
+	e.respond(w, lvl, code, "%s", msg)
+}
@@ -0,0 +1,103 @@
+package events_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/adapter"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeVCSAdapter returns event or err from ParseWebhook.
+type fakeVCSAdapter struct {
+	event adapter.Event
+	err   error
+}
+
+func (f fakeVCSAdapter) ParseWebhook(_ *http.Request) (adapter.Event, error) {
+	return f.event, f.err
+}
+
+func TestPostVCSAdapter_NotConfigured(t *testing.T) {
+	t.Log("when no VCS adapter is configured we return a 400")
+	e, _, _, _, _, _, _, _, _ := setup(t)
+	req, _ := http.NewRequest("POST", "/events/vcs-adapter", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	e.PostVCSAdapter(w, req)
+	ResponseContains(t, w, http.StatusBadRequest, "Ignoring request since not configured with a VCS adapter")
+}
+
+func TestPostVCSAdapter_InvalidWebhook(t *testing.T) {
+	t.Log("when the adapter can't parse the webhook we return a 400")
+	e, _, _, _, _, _, _, _, _ := setup(t)
+	e.SupportedVCSHosts = append(e.SupportedVCSHosts, models.VCSAdapter)
+	e.VCSAdapter = fakeVCSAdapter{err: errors.New("invalid signature")}
+	req, _ := http.NewRequest("POST", "/events/vcs-adapter", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	e.PostVCSAdapter(w, req)
+	ResponseContains(t, w, http.StatusBadRequest, "Error parsing webhook: invalid signature")
+}
+
+func TestPostVCSAdapter_IgnoredEvent(t *testing.T) {
+	t.Log("when the adapter ignores the webhook we return a 200 with the reason")
+	e, _, _, _, _, _, _, _, _ := setup(t)
+	e.SupportedVCSHosts = append(e.SupportedVCSHosts, models.VCSAdapter)
+	e.VCSAdapter = fakeVCSAdapter{event: adapter.Event{IgnoreReason: "not a pull request comment"}}
+	req, _ := http.NewRequest("POST", "/events/vcs-adapter", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	e.PostVCSAdapter(w, req)
+	ResponseContains(t, w, http.StatusOK, "Ignoring event: not a pull request comment")
+}
+
+func TestPostVCSAdapter_CommentEvent(t *testing.T) {
+	t.Log("when the adapter parses a comment we run the command")
+	e, _, _, _, _, cr, _, _, cp := setup(t)
+	e.SupportedVCSHosts = append(e.SupportedVCSHosts, models.VCSAdapter)
+	baseRepo := models.Repo{FullName: "myorg/infra", VCSHost: models.VCSHost{Hostname: "vcs.example.com", Type: models.VCSAdapter}}
+	pull := models.PullRequest{Num: 7, BaseRepo: baseRepo}
+	user := models.User{Username: "alice"}
+	e.VCSAdapter = fakeVCSAdapter{event: adapter.Event{
+		Type:     adapter.CommentEvent,
+		BaseRepo: baseRepo,
+		HeadRepo: baseRepo,
+		Pull:     pull,
+		User:     user,
+		Comment:  "atlantis plan",
+	}}
+	cmd := events.CommentCommand{Name: command.Plan}
+	When(cp.Parse("atlantis plan", models.VCSAdapter)).ThenReturn(events.CommentParseResult{Command: &cmd})
+	req, _ := http.NewRequest("POST", "/events/vcs-adapter", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	e.PostVCSAdapter(w, req)
+	ResponseContains(t, w, http.StatusOK, "Processing...")
+	cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, &baseRepo, &pull, user, 7, &cmd)
+}
+
+func TestPostVCSAdapter_PullEvent(t *testing.T) {
+	t.Log("when the adapter parses an opened pull request we autoplan")
+	e, _, _, _, _, cr, _, _, _ := setup(t)
+	e.SupportedVCSHosts = append(e.SupportedVCSHosts, models.VCSAdapter)
+	baseRepo := models.Repo{FullName: "myorg/infra", VCSHost: models.VCSHost{Hostname: "vcs.example.com", Type: models.VCSAdapter}}
+	pull := models.PullRequest{Num: 7, BaseRepo: baseRepo}
+	user := models.User{Username: "alice"}
+	e.VCSAdapter = fakeVCSAdapter{event: adapter.Event{
+		Type:          adapter.PullEvent,
+		BaseRepo:      baseRepo,
+		HeadRepo:      baseRepo,
+		Pull:          pull,
+		User:          user,
+		PullEventType: models.OpenedPullEvent,
+	}}
+	req, _ := http.NewRequest("POST", "/events/vcs-adapter", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	e.PostVCSAdapter(w, req)
+	ResponseContains(t, w, http.StatusOK, "Processing...")
+	cr.VerifyWasCalledOnce().RunAutoplanCommand(baseRepo, baseRepo, pull, user)
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// WebhookDeduplicator detects webhook deliveries that have already been
+// received. VCS hosts re-send deliveries when Atlantis doesn't respond in
+// time, which would otherwise trigger duplicate plans and applies.
+type WebhookDeduplicator struct {
+	// Backend is where received deliveries are recorded.
+	Backend locking.Backend
+	// Window is how long a delivery is remembered. Deliveries sent longer ago
+	// than Window are rejected as stale since they can no longer be compared
+	// with the recorded ones. If zero, deduplication is disabled.
+	Window time.Duration
+}
+
+// IsStale returns true if a delivery sent at sentAt is older than the window.
+// sentAt may be zero if the VCS host doesn't send when the event happened, in
+// which case the delivery is never stale.
+func (d *WebhookDeduplicator) IsStale(sentAt time.Time) bool {
+	if d == nil || d.Backend == nil || d.Window <= 0 || sentAt.IsZero() {
+		return false
+	}
+	return time.Since(sentAt) > d.Window
+}
+
+// IsDuplicate records the delivery and returns true if a delivery with the
+// same ID or the same payload was already received within the window.
+// deliveryID may be empty if the VCS host doesn't send one, in which case only
+// the payload is compared.
+func (d *WebhookDeduplicator) IsDuplicate(vcsHost models.VCSHostType, deliveryID string, payload []byte) (bool, error) {
+	if d == nil || d.Backend == nil || d.Window <= 0 {
+		return false, nil
+	}
+
+	now := time.Now()
+	sum := sha256.Sum256(payload)
+	keys := []string{fmt.Sprintf("%s/payload/%s", vcsHost, hex.EncodeToString(sum[:]))}
+	if deliveryID != "" {
+		keys = append(keys, fmt.Sprintf("%s/id/%s", vcsHost, deliveryID))
+	}
+
+	// Every key is recorded, even once a duplicate has been found, so that a
+	// retry with a new ID but the same payload (or vice versa) is caught too.
+	duplicate := false
+	for _, key := range keys {
+		recorded, err := d.Backend.RecordDelivery(key, now, d.Window)
+		if err != nil {
+			return false, err
+		}
+		if !recorded {
+			duplicate = true
+		}
+	}
+	return duplicate, nil
+}
+
+// deliveryTimeLayouts are the layouts of the times VCS hosts send in their
+// payloads.
+var deliveryTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05 -0700",
+}
+
+// deliverySentAt returns when the event of a delivery happened, or the zero
+// time if payload doesn't say. For GitHub, Gitea and Bitbucket Cloud only
+// comments have a time that can't change between deliveries of the same
+// event.
+func deliverySentAt(vcsHost models.VCSHostType, payload []byte) time.Time {
+	var fields struct {
+		Date        string `json:"date"`
+		CreatedDate string `json:"createdDate"`
+		Comment     struct {
+			CreatedAt string `json:"created_at"`
+			CreatedOn string `json:"created_on"`
+		} `json:"comment"`
+	}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return time.Time{}
+	}
+	switch vcsHost {
+	case models.Github, models.Gitea:
+		return parseDeliveryTime(fields.Comment.CreatedAt)
+	case models.BitbucketCloud:
+		return parseDeliveryTime(fields.Comment.CreatedOn)
+	case models.BitbucketServer:
+		return parseDeliveryTime(fields.Date)
+	case models.AzureDevops:
+		return parseDeliveryTime(fields.CreatedDate)
+	}
+	return time.Time{}
+}
+
+// parseDeliveryTime parses a time sent by a VCS host, returning the zero time
+// if it's empty or in an unknown layout.
+func parseDeliveryTime(value string) time.Time {
+	for _, layout := range deliveryTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
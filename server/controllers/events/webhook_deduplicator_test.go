@@ -0,0 +1,87 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	events_controllers "github.com/runatlantis/atlantis/server/controllers/events"
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestWebhookDeduplicator_IsDuplicate(t *testing.T) {
+	d := newTestDeduplicator(t, time.Hour)
+
+	dup, err := d.IsDuplicate(models.Github, "id-1", []byte(`{"action":"created"}`))
+	Ok(t, err)
+	Equals(t, false, dup)
+
+	t.Log("same delivery ID is a duplicate")
+	dup, err = d.IsDuplicate(models.Github, "id-1", []byte(`{"action":"edited"}`))
+	Ok(t, err)
+	Equals(t, true, dup)
+
+	t.Log("same payload with a new delivery ID is a duplicate")
+	dup, err = d.IsDuplicate(models.Github, "id-2", []byte(`{"action":"created"}`))
+	Ok(t, err)
+	Equals(t, true, dup)
+
+	t.Log("same delivery ID from another VCS host is not a duplicate")
+	dup, err = d.IsDuplicate(models.Gitlab, "id-1", []byte(`{"object_kind":"note"}`))
+	Ok(t, err)
+	Equals(t, false, dup)
+}
+
+func TestWebhookDeduplicator_NoDeliveryID(t *testing.T) {
+	d := newTestDeduplicator(t, time.Hour)
+
+	dup, err := d.IsDuplicate(models.BitbucketServer, "", []byte("payload"))
+	Ok(t, err)
+	Equals(t, false, dup)
+
+	dup, err = d.IsDuplicate(models.BitbucketServer, "", []byte("other payload"))
+	Ok(t, err)
+	Equals(t, false, dup)
+
+	dup, err = d.IsDuplicate(models.BitbucketServer, "", []byte("payload"))
+	Ok(t, err)
+	Equals(t, true, dup)
+}
+
+func TestWebhookDeduplicator_Disabled(t *testing.T) {
+	t.Log("a zero window or nil deduplicator never reports duplicates")
+	d := newTestDeduplicator(t, 0)
+	for i := 0; i < 2; i++ {
+		dup, err := d.IsDuplicate(models.Github, "id", []byte("payload"))
+		Ok(t, err)
+		Equals(t, false, dup)
+	}
+
+	var nilDeduplicator *events_controllers.WebhookDeduplicator
+	dup, err := nilDeduplicator.IsDuplicate(models.Github, "id", []byte("payload"))
+	Ok(t, err)
+	Equals(t, false, dup)
+}
+
+func TestWebhookDeduplicator_IsStale(t *testing.T) {
+	d := newTestDeduplicator(t, time.Hour)
+	Equals(t, false, d.IsStale(time.Now().Add(-time.Minute)))
+	Equals(t, true, d.IsStale(time.Now().Add(-2*time.Hour)))
+
+	t.Log("deliveries without a time are never stale")
+	Equals(t, false, d.IsStale(time.Time{}))
+
+	t.Log("nothing is stale when deduplication is disabled")
+	Equals(t, false, newTestDeduplicator(t, 0).IsStale(time.Now().Add(-2*time.Hour)))
+}
+
+func newTestDeduplicator(t *testing.T, window time.Duration) *events_controllers.WebhookDeduplicator {
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+	t.Cleanup(func() { boltDB.Close() }) // nolint: errcheck
+	return &events_controllers.WebhookDeduplicator{
+		Backend: boltDB,
+		Window:  window,
+	}
+}
@@ -0,0 +1,146 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
+)
+
+// WebhookSecretFinder looks up webhook secrets configured for individual
+// repos. It's implemented by valid.GlobalCfg.
+type WebhookSecretFinder interface {
+	// WebhookSecret returns the secret configured for the repo with id
+	// repoID or an empty string if the repo doesn't have its own secret.
+	WebhookSecret(repoID string) string
+}
+
+// webhookRepo holds the fields of a repository in a webhook payload that we
+// need to compute its ID. GitHub and Gitea use clone_url, Bitbucket Cloud
+// uses links.html.href.
+type webhookRepo struct {
+	FullName string `json:"full_name"`
+	CloneURL string `json:"clone_url"`
+	Links    struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+// webhookRepoPayload is the subset of the webhook payloads from each VCS host
+// used to determine which repo the event is for.
+type webhookRepoPayload struct {
+	PullRequest *struct {
+		Base *struct {
+			Repo *webhookRepo `json:"repo"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository *webhookRepo `json:"repository"`
+	Project    *struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		GitHTTPURL        string `json:"git_http_url"`
+	} `json:"project"`
+}
+
+// repoWebhookSecret returns the secret configured for the repo the payload
+// belongs to or fallback if that repo doesn't have its own secret.
+// The repo is determined the same way the event parser determines the base
+// repo so that a payload can't be validated with one repo's secret while
+// Atlantis acts on another repo.
+func (e *VCSEventsController) repoWebhookSecret(vcsHost models.VCSHostType, contentType string, body []byte, fallback []byte) []byte {
+	if e.WebhookSecretFinder == nil {
+		return fallback
+	}
+	repoID, ok := webhookRepoID(vcsHost, contentType, body)
+	if !ok {
+		return fallback
+	}
+	if secret := e.WebhookSecretFinder.WebhookSecret(repoID); secret != "" {
+		return []byte(secret)
+	}
+	return fallback
+}
+
+// requestWebhookSecret is like repoWebhookSecret but reads the payload from
+// r. The body is restored afterwards so it can be read again when the
+// request is validated.
+func (e *VCSEventsController) requestWebhookSecret(vcsHost models.VCSHostType, r *http.Request, fallback []byte) []byte {
+	if e.WebhookSecretFinder == nil || r.Body == nil {
+		return fallback
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close() // nolint: errcheck
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return fallback
+	}
+	return e.repoWebhookSecret(vcsHost, r.Header.Get("Content-Type"), body, fallback)
+}
+
+// bitbucketServerWebhookSecret is like repoWebhookSecret for Bitbucket Server
+// payloads of eventType. Their repos don't have a clone URL, which is built
+// from the Bitbucket Server URL, so the base repo is determined by the event
+// parser.
+func (e *VCSEventsController) bitbucketServerWebhookSecret(eventType string, body []byte, fallback []byte) []byte {
+	if e.WebhookSecretFinder == nil {
+		return fallback
+	}
+	var baseRepo models.Repo
+	var err error
+	if eventType == bitbucketserver.PullCommentCreatedHeader {
+		_, baseRepo, _, _, _, err = e.Parser.ParseBitbucketServerPullCommentEvent(body)
+	} else {
+		_, baseRepo, _, _, err = e.Parser.ParseBitbucketServerPullEvent(body)
+	}
+	if err != nil || baseRepo.FullName == "" {
+		return fallback
+	}
+	if secret := e.WebhookSecretFinder.WebhookSecret(baseRepo.ID()); secret != "" {
+		return []byte(secret)
+	}
+	return fallback
+}
+
+// webhookRepoID returns the ID of the base repo of the webhook payload. ok is
+// false if the payload doesn't contain a repo we can identify.
+func webhookRepoID(vcsHost models.VCSHostType, contentType string, body []byte) (repoID string, ok bool) {
+	// GitHub can send its payload as a form value.
+	if contentType == "application/x-www-form-urlencoded" {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "", false
+		}
+		body = []byte(values.Get("payload"))
+	}
+
+	var payload webhookRepoPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", false
+	}
+
+	var fullName, cloneURL string
+	switch {
+	case payload.PullRequest != nil && payload.PullRequest.Base != nil && payload.PullRequest.Base.Repo != nil:
+		fullName, cloneURL = payload.PullRequest.Base.Repo.FullName, payload.PullRequest.Base.Repo.CloneURL
+	case payload.Project != nil:
+		fullName, cloneURL = payload.Project.PathWithNamespace, payload.Project.GitHTTPURL
+	case payload.Repository != nil:
+		fullName, cloneURL = payload.Repository.FullName, payload.Repository.CloneURL
+		if vcsHost == models.BitbucketCloud {
+			cloneURL = payload.Repository.Links.HTML.Href
+		}
+	default:
+		return "", false
+	}
+
+	repo, err := models.NewRepo(vcsHost, fullName, cloneURL, "", "")
+	if err != nil {
+		return "", false
+	}
+	return repo.ID(), true
+}
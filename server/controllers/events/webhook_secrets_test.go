@@ -0,0 +1,150 @@
+package events_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestPost_GiteaRepoWebhookSecret(t *testing.T) {
+	payload := []byte(`{"repository": {"full_name": "owner/repo", "clone_url": "https://gitea.com/owner/repo.git"}}`)
+	otherPayload := []byte(`{"repository": {"full_name": "other/repo", "clone_url": "https://gitea.com/other/repo.git"}}`)
+	globalCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{IDRegex: regexp.MustCompile(".*")},
+			{ID: "gitea.com/owner/repo", WebhookSecret: "repo-secret"},
+		},
+	}
+
+	cases := map[string]struct {
+		payload []byte
+		signKey string
+		expCode int
+		expBody string
+	}{
+		"signed with the repo secret": {
+			payload: payload,
+			signKey: "repo-secret",
+			expCode: http.StatusOK,
+			expBody: "Ignoring unsupported Gitea event type",
+		},
+		"signed with the global secret when the repo has its own": {
+			payload: payload,
+			signKey: string(secret),
+			expCode: http.StatusBadRequest,
+			expBody: "request did not pass validation",
+		},
+		"repo without its own secret uses the global secret": {
+			payload: otherPayload,
+			signKey: string(secret),
+			expCode: http.StatusOK,
+			expBody: "Ignoring unsupported Gitea event type",
+		},
+		"repo without its own secret can't use another repo's secret": {
+			payload: otherPayload,
+			signKey: "repo-secret",
+			expCode: http.StatusBadRequest,
+			expBody: "request did not pass validation",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			e, _, _, _, _, _, _, _, _ := setup(t)
+			e.WebhookSecretFinder = globalCfg
+
+			mac := hmac.New(sha256.New, []byte(c.signKey))
+			mac.Write(c.payload) // nolint: errcheck
+			req, _ := http.NewRequest("POST", "", bytes.NewBuffer(c.payload))
+			req.Header.Set(giteaHeader, "value")
+			req.Header.Set("X-Gitea-Event-Type", "push")
+			req.Header.Set("X-Gitea-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+			w := httptest.NewRecorder()
+			e.Post(w, req)
+			ResponseContains(t, w, c.expCode, c.expBody)
+		})
+	}
+}
+
+func TestPost_BitbucketServerRepoWebhookSecret(t *testing.T) {
+	payload := []byte(`{"eventKey": "pr:modified"}`)
+	globalCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{IDRegex: regexp.MustCompile(".*")},
+			{ID: "bbserver.com/project/repo", WebhookSecret: "repo-secret"},
+		},
+	}
+	repo := models.Repo{
+		FullName: "project/repo",
+		VCSHost:  models.VCSHost{Hostname: "bbserver.com", Type: models.BitbucketServer},
+	}
+	otherRepo := models.Repo{
+		FullName: "project/other",
+		VCSHost:  models.VCSHost{Hostname: "bbserver.com", Type: models.BitbucketServer},
+	}
+
+	cases := map[string]struct {
+		repo    models.Repo
+		signKey string
+		expCode int
+		expBody string
+	}{
+		"signed with the repo secret": {
+			repo:    repo,
+			signKey: "repo-secret",
+			expCode: http.StatusOK,
+			expBody: "Ignoring unsupported event type",
+		},
+		"signed with the global secret when the repo has its own": {
+			repo:    repo,
+			signKey: string(secret),
+			expCode: http.StatusBadRequest,
+			expBody: "request did not pass validation",
+		},
+		"repo without its own secret uses the global secret": {
+			repo:    otherRepo,
+			signKey: string(secret),
+			expCode: http.StatusOK,
+			expBody: "Ignoring unsupported event type",
+		},
+		"repo without its own secret can't use another repo's secret": {
+			repo:    otherRepo,
+			signKey: "repo-secret",
+			expCode: http.StatusBadRequest,
+			expBody: "request did not pass validation",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			e, _, _, _, p, _, _, _, _ := setup(t)
+			e.SupportedVCSHosts = []models.VCSHostType{models.BitbucketServer}
+			e.BitbucketWebhookSecret = secret
+			e.WebhookSecretFinder = globalCfg
+			When(p.ParseBitbucketServerPullEvent(Eq(payload))).
+				ThenReturn(models.PullRequest{}, c.repo, c.repo, models.User{}, nil)
+
+			mac := hmac.New(sha256.New, []byte(c.signKey))
+			mac.Write(payload) // nolint: errcheck
+			req, _ := http.NewRequest("POST", "", bytes.NewBuffer(payload))
+			req.Header.Set("X-Event-Key", "pr:modified")
+			req.Header.Set("X-Request-ID", "request-id")
+			req.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+			w := httptest.NewRecorder()
+			e.Post(w, req)
+			ResponseContains(t, w, c.expCode, c.expBody)
+		})
+	}
+}
@@ -1,14 +1,18 @@
 package controllers
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/runatlantis/atlantis/server/controllers/web_templates"
 	"github.com/runatlantis/atlantis/server/controllers/websocket"
 	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
 	tally "github.com/uber-go/tally/v4"
@@ -35,6 +39,131 @@ type JobsController struct {
 	WsMux                    *websocket.Multiplexor
 	KeyGenerator             JobIDKeyGenerator
 	StatsScope               tally.Scope
+	// JobsTemplate renders the jobs dashboard.
+	JobsTemplate            web_templates.TemplateWriter
+	ProjectCmdOutputHandler jobs.ProjectCommandOutputHandler
+	// JobCanceler cancels running jobs. If nil, jobs can't be canceled.
+	JobCanceler *jobs.JobCanceler
+	// WebAuthentication is true if the UI is protected by basic auth, in
+	// which case jobs can be canceled from the jobs dashboard.
+	WebAuthentication bool
+	// APISecret is the API secret that requests can set in the
+	// X-Atlantis-Token header to cancel jobs without basic auth. If empty,
+	// the header isn't accepted.
+	APISecret []byte
+}
+
+// Statuses of jobs in the jobs dashboard.
+const (
+	jobStatusRunning  = "running"
+	jobStatusComplete = "complete"
+)
+
+// GetJobs is the GET /jobs route. It renders the jobs of all pull requests
+// since Atlantis started, running jobs first and then newest first. They can
+// be filtered by the repo, project and status query params.
+func (j *JobsController) GetJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	repo := query.Get("repo")
+	project := query.Get("project")
+	status := query.Get("status")
+	if status != "" && status != jobStatusRunning && status != jobStatusComplete {
+		j.respond(w, logging.Warn, http.StatusBadRequest, "Invalid status %q, must be %s or %s", status, jobStatusRunning, jobStatusComplete)
+		return
+	}
+
+	type jobWithTime struct {
+		web_templates.JobData
+		updatedAt time.Time
+	}
+	var matching []jobWithTime
+	for _, mapping := range j.ProjectCmdOutputHandler.GetPullToJobMapping() {
+		pull := mapping.Pull
+		if repo != "" && pull.RepoFullName != repo {
+			continue
+		}
+		if project != "" && pull.ProjectName != project && pull.Path != project {
+			continue
+		}
+		for _, info := range mapping.JobIDInfos {
+			jobStatus := jobStatusRunning
+			if info.Complete {
+				jobStatus = jobStatusComplete
+			}
+			if status != "" && jobStatus != status {
+				continue
+			}
+			matching = append(matching, jobWithTime{
+				JobData: web_templates.JobData{
+					JobID:            info.JobID,
+					JobPath:          fmt.Sprintf("/jobs/%s", url.PathEscape(info.JobID)),
+					RepoFullName:     pull.RepoFullName,
+					PullNum:          pull.PullNum,
+					ProjectName:      pull.ProjectName,
+					Path:             pull.Path,
+					Workspace:        pull.Workspace,
+					JobStep:          info.JobStep,
+					JobDescription:   info.JobDescription,
+					UpdatedFormatted: info.Time.Format("2006-01-02 15:04:05"),
+					Status:           jobStatus,
+					Cancelable:       j.WebAuthentication && !info.Complete && j.JobCanceler != nil && j.JobCanceler.IsRunning(info.JobID),
+				},
+				updatedAt: info.Time,
+			})
+		}
+	}
+	sort.SliceStable(matching, func(x, y int) bool {
+		if matching[x].Status != matching[y].Status {
+			return matching[x].Status == jobStatusRunning
+		}
+		return matching[x].updatedAt.After(matching[y].updatedAt)
+	})
+
+	var jobData []web_templates.JobData
+	for _, job := range matching {
+		jobData = append(jobData, job.JobData)
+	}
+	err := j.JobsTemplate.Execute(w, web_templates.JobsIndexData{
+		Jobs:            jobData,
+		Repo:            repo,
+		Project:         project,
+		Status:          status,
+		AtlantisVersion: j.AtlantisVersion,
+		CleanedBasePath: j.AtlantisURL.Path,
+	})
+	if err != nil {
+		j.Logger.Err(err.Error())
+	}
+}
+
+// CancelJob is the POST /jobs/{job-id}/cancel route. It cancels a running
+// job, which terminates the process group of the step it's running. Since
+// anyone who can reach the UI can list the jobs, the request must either have
+// passed basic auth or have the API secret.
+func (j *JobsController) CancelJob(w http.ResponseWriter, r *http.Request) {
+	if !j.canCancel(r) {
+		j.respond(w, logging.Warn, http.StatusUnauthorized, "Canceling jobs requires --web-basic-auth or the %s header", atlantisTokenHeader)
+		return
+	}
+	jobID, err := j.KeyGenerator.Generate(r)
+	if err != nil {
+		j.respond(w, logging.Error, http.StatusBadRequest, "%s", err.Error())
+		return
+	}
+	if j.JobCanceler == nil || !j.JobCanceler.Cancel(jobID) {
+		j.respond(w, logging.Info, http.StatusNotFound, "Job %s isn't running or can't be canceled", jobID)
+		return
+	}
+	j.respond(w, logging.Info, http.StatusOK, "Canceled job %s", jobID)
+}
+
+// canCancel returns true if r is allowed to cancel jobs.
+func (j *JobsController) canCancel(r *http.Request) bool {
+	if j.WebAuthentication {
+		return true
+	}
+	token := r.Header.Get(atlantisTokenHeader)
+	return len(j.APISecret) > 0 && subtle.ConstantTimeCompare([]byte(token), j.APISecret) == 1
 }
 
 func (j *JobsController) getProjectJobs(w http.ResponseWriter, r *http.Request) error {
@@ -0,0 +1,228 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/controllers/web_templates"
+	tMocks "github.com/runatlantis/atlantis/server/controllers/web_templates/mocks"
+	"github.com/runatlantis/atlantis/server/jobs"
+	jobmocks "github.com/runatlantis/atlantis/server/jobs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+var jobsUpdatedAt = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+var infraPull = jobs.PullInfoWithJobIDs{
+	Pull: jobs.PullInfo{PullNum: 1, RepoFullName: "org/infra", ProjectName: "vpc", Path: "vpc", Workspace: "default"},
+	JobIDInfos: []jobs.JobIDInfo{
+		{JobID: "plan", JobStep: "plan", Time: jobsUpdatedAt, Complete: true},
+		{JobID: "apply", JobStep: "apply", Time: jobsUpdatedAt.Add(-time.Hour)},
+	},
+}
+
+var appPull = jobs.PullInfoWithJobIDs{
+	Pull: jobs.PullInfo{PullNum: 2, RepoFullName: "org/app", Path: "terraform", Workspace: "staging"},
+	JobIDInfos: []jobs.JobIDInfo{
+		{JobID: "app-plan", JobStep: "plan", Time: jobsUpdatedAt.Add(time.Hour), Complete: true},
+	},
+}
+
+var infraApplyJob = web_templates.JobData{
+	JobID:            "apply",
+	JobPath:          "/jobs/apply",
+	RepoFullName:     "org/infra",
+	PullNum:          1,
+	ProjectName:      "vpc",
+	Path:             "vpc",
+	Workspace:        "default",
+	JobStep:          "apply",
+	UpdatedFormatted: "2024-01-02 02:04:05",
+	Status:           "running",
+	Cancelable:       true,
+}
+
+var infraPlanJob = web_templates.JobData{
+	JobID:            "plan",
+	JobPath:          "/jobs/plan",
+	RepoFullName:     "org/infra",
+	PullNum:          1,
+	ProjectName:      "vpc",
+	Path:             "vpc",
+	Workspace:        "default",
+	JobStep:          "plan",
+	UpdatedFormatted: "2024-01-02 03:04:05",
+	Status:           "complete",
+}
+
+var appPlanJob = web_templates.JobData{
+	JobID:            "app-plan",
+	JobPath:          "/jobs/app-plan",
+	RepoFullName:     "org/app",
+	PullNum:          2,
+	Path:             "terraform",
+	Workspace:        "staging",
+	JobStep:          "plan",
+	UpdatedFormatted: "2024-01-02 04:04:05",
+	Status:           "complete",
+}
+
+func newJobsController(t *testing.T) (controllers.JobsController, *tMocks.MockTemplateWriter) {
+	RegisterMockTestingT(t)
+	handler := jobmocks.NewMockProjectCommandOutputHandler()
+	When(handler.GetPullToJobMapping()).ThenReturn([]jobs.PullInfoWithJobIDs{infraPull, appPull})
+	canceler := jobs.NewJobCanceler()
+	_, done := canceler.Start("apply")
+	t.Cleanup(done)
+	tmpl := tMocks.NewMockTemplateWriter()
+	atlantisURL, err := url.Parse("https://example.com/basepath")
+	Ok(t, err)
+	return controllers.JobsController{
+		AtlantisVersion:         "1300135",
+		AtlantisURL:             atlantisURL,
+		Logger:                  logging.NewNoopLogger(t),
+		JobsTemplate:            tmpl,
+		ProjectCmdOutputHandler: handler,
+		JobCanceler:             canceler,
+		WebAuthentication:       true,
+	}, tmpl
+}
+
+func TestGetJobs(t *testing.T) {
+	cases := []struct {
+		description string
+		query       string
+		exp         web_templates.JobsIndexData
+	}{
+		{
+			"all jobs, running first and then newest first",
+			"",
+			web_templates.JobsIndexData{
+				Jobs: []web_templates.JobData{infraApplyJob, appPlanJob, infraPlanJob},
+			},
+		},
+		{
+			"by repo",
+			"?repo=org/infra",
+			web_templates.JobsIndexData{
+				Jobs: []web_templates.JobData{infraApplyJob, infraPlanJob},
+				Repo: "org/infra",
+			},
+		},
+		{
+			"by project name",
+			"?project=vpc",
+			web_templates.JobsIndexData{
+				Jobs:    []web_templates.JobData{infraApplyJob, infraPlanJob},
+				Project: "vpc",
+			},
+		},
+		{
+			"by project path",
+			"?project=terraform",
+			web_templates.JobsIndexData{
+				Jobs:    []web_templates.JobData{appPlanJob},
+				Project: "terraform",
+			},
+		},
+		{
+			"by status",
+			"?status=complete",
+			web_templates.JobsIndexData{
+				Jobs:   []web_templates.JobData{appPlanJob, infraPlanJob},
+				Status: "complete",
+			},
+		},
+		{
+			"no matches",
+			"?repo=org/app&status=running",
+			web_templates.JobsIndexData{
+				Repo:   "org/app",
+				Status: "running",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			jc, tmpl := newJobsController(t)
+			req, _ := http.NewRequest("GET", "/jobs"+c.query, nil)
+			w := httptest.NewRecorder()
+			jc.GetJobs(w, req)
+			c.exp.AtlantisVersion = "1300135"
+			c.exp.CleanedBasePath = "/basepath"
+			tmpl.VerifyWasCalledOnce().Execute(w, c.exp)
+		})
+	}
+}
+
+func TestGetJobs_NotCancelableWithoutWebAuthentication(t *testing.T) {
+	jc, tmpl := newJobsController(t)
+	jc.WebAuthentication = false
+	req, _ := http.NewRequest("GET", "/jobs?status=running", nil)
+	w := httptest.NewRecorder()
+	jc.GetJobs(w, req)
+	applyJob := infraApplyJob
+	applyJob.Cancelable = false
+	tmpl.VerifyWasCalledOnce().Execute(w, web_templates.JobsIndexData{
+		Jobs:            []web_templates.JobData{applyJob},
+		Status:          "running",
+		AtlantisVersion: "1300135",
+		CleanedBasePath: "/basepath",
+	})
+}
+
+func TestGetJobs_InvalidStatus(t *testing.T) {
+	jc, _ := newJobsController(t)
+	req, _ := http.NewRequest("GET", "/jobs?status=failed", nil)
+	w := httptest.NewRecorder()
+	jc.GetJobs(w, req)
+	ResponseContains(t, w, http.StatusBadRequest, `Invalid status "failed", must be running or complete`)
+}
+
+func TestCancelJob(t *testing.T) {
+	jc, _ := newJobsController(t)
+	cancel := func(jobID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/jobs/"+jobID+"/cancel", nil)
+		req = mux.SetURLVars(req, map[string]string{"job-id": jobID})
+		w := httptest.NewRecorder()
+		jc.CancelJob(w, req)
+		return w
+	}
+
+	ResponseContains(t, cancel("apply"), http.StatusOK, "Canceled job apply")
+	Assert(t, !jc.JobCanceler.IsRunning("apply"), "exp job to be canceled")
+	ResponseContains(t, cancel("apply"), http.StatusNotFound, "Job apply isn't running or can't be canceled")
+	ResponseContains(t, cancel("plan"), http.StatusNotFound, "Job plan isn't running or can't be canceled")
+}
+
+func TestCancelJob_WithoutWebAuthentication(t *testing.T) {
+	jc, _ := newJobsController(t)
+	jc.WebAuthentication = false
+	jc.APISecret = []byte("secret")
+	cancel := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/jobs/apply/cancel", nil)
+		req = mux.SetURLVars(req, map[string]string{"job-id": "apply"})
+		if token != "" {
+			req.Header.Set("X-Atlantis-Token", token)
+		}
+		w := httptest.NewRecorder()
+		jc.CancelJob(w, req)
+		return w
+	}
+
+	ResponseContains(t, cancel(""), http.StatusUnauthorized, "Canceling jobs requires --web-basic-auth or the X-Atlantis-Token header")
+	ResponseContains(t, cancel("wrong"), http.StatusUnauthorized, "Canceling jobs requires --web-basic-auth or the X-Atlantis-Token header")
+	Assert(t, jc.JobCanceler.IsRunning("apply"), "exp job to still be running")
+	ResponseContains(t, cancel("secret"), http.StatusOK, "Canceled job apply")
+
+	// Without an API secret, the header isn't accepted.
+	jc.APISecret = nil
+	ResponseContains(t, cancel("secret"), http.StatusUnauthorized, "Canceling jobs requires --web-basic-auth or the X-Atlantis-Token header")
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/runatlantis/atlantis/server/controllers/web_templates"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// PlanHistoryController renders the plan history of pull requests.
+type PlanHistoryController struct {
+	AtlantisVersion     string
+	AtlantisURL         *url.URL
+	Logger              logging.SimpleLogging
+	PlanHistory         *events.PlanHistory
+	PlanHistoryTemplate web_templates.TemplateWriter
+}
+
+// GetPlanHistory is the GET /plan-history?repo={repo}&pull={pull} route. It
+// renders the plans of each project of a pull request, newest first, with
+// how the resources each plan changes differ from the plan before it.
+func (p *PlanHistoryController) GetPlanHistory(w http.ResponseWriter, r *http.Request) {
+	if p.PlanHistory == nil {
+		p.respond(w, logging.Info, http.StatusNotFound, "Plan history is not enabled")
+		return
+	}
+	repo := r.URL.Query().Get("repo")
+	pullNum, err := strconv.Atoi(r.URL.Query().Get("pull"))
+	if repo == "" || err != nil {
+		p.respond(w, logging.Warn, http.StatusBadRequest, "Request must specify a repo and a pull number")
+		return
+	}
+
+	histories, err := p.PlanHistory.Get(repo, pullNum)
+	if err != nil {
+		p.respond(w, logging.Error, http.StatusInternalServerError, "Failed getting plan history: %s", err)
+		return
+	}
+
+	var projects []web_templates.PlanHistoryProjectData
+	for _, history := range histories {
+		project := web_templates.PlanHistoryProjectData{
+			ProjectName: history.ProjectName,
+			Path:        history.RepoRelDir,
+			Workspace:   history.Workspace,
+		}
+		for i := len(history.Plans) - 1; i >= 0; i-- {
+			plan := history.Plans[i]
+			data := web_templates.PlanHistoryPlanData{
+				HeadCommit:         plan.HeadCommit,
+				PlannedAtFormatted: plan.PlannedAt.Format("2006-01-02 15:04:05"),
+				Changes:            len(plan.Changes),
+			}
+			if i > 0 {
+				previous := history.Plans[i-1]
+				diff := models.NewPlanChangesetDiff(previous.HeadCommit, previous.Changes, plan.Changes)
+				data.Diff = &diff
+			}
+			project.Plans = append(project.Plans, data)
+		}
+		projects = append(projects, project)
+	}
+
+	err = p.PlanHistoryTemplate.Execute(w, web_templates.PlanHistoryData{
+		RepoFullName:    repo,
+		PullNum:         pullNum,
+		Projects:        projects,
+		AtlantisVersion: p.AtlantisVersion,
+		CleanedBasePath: p.AtlantisURL.Path,
+	})
+	if err != nil {
+		p.Logger.Err(err.Error())
+	}
+}
+
+func (p *PlanHistoryController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	response := fmt.Sprintf(format, args...)
+	p.Logger.Log(lvl, response)
+	w.WriteHeader(responseCode)
+	fmt.Fprintln(w, response)
+}
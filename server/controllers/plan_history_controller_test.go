@@ -0,0 +1,65 @@
+package controllers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/controllers/web_templates"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func newPlanHistoryController(t *testing.T, history *events.PlanHistory) controllers.PlanHistoryController {
+	atlantisURL, err := url.Parse("https://example.com/basepath")
+	Ok(t, err)
+	return controllers.PlanHistoryController{
+		AtlantisVersion:     "1300135",
+		AtlantisURL:         atlantisURL,
+		Logger:              logging.NewNoopLogger(t),
+		PlanHistory:         history,
+		PlanHistoryTemplate: web_templates.PlanHistoryTemplate,
+	}
+}
+
+func TestGetPlanHistory_Success(t *testing.T) {
+	history := &events.PlanHistory{DataDir: t.TempDir()}
+	ctx := command.ProjectContext{
+		Pull:       models.PullRequest{BaseRepo: models.Repo{FullName: "owner/repo"}, Num: 1, HeadCommit: "commit1"},
+		RepoRelDir: "network",
+		Workspace:  "default",
+	}
+	_, err := history.Add(ctx, "  # aws_subnet.a will be created")
+	Ok(t, err)
+	ctx.Pull.HeadCommit = "commit2"
+	_, err = history.Add(ctx, "  # aws_subnet.b will be created")
+	Ok(t, err)
+
+	pc := newPlanHistoryController(t, history)
+	req, _ := http.NewRequest("GET", "/plan-history?repo=owner/repo&pull=1", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	pc.GetPlanHistory(w, req)
+	ResponseContains(t, w, http.StatusOK, "+ aws_subnet.b will be created\n- aws_subnet.a will be created")
+}
+
+func TestGetPlanHistory_BadRequest(t *testing.T) {
+	pc := newPlanHistoryController(t, &events.PlanHistory{DataDir: t.TempDir()})
+	req, _ := http.NewRequest("GET", "/plan-history?repo=owner/repo", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	pc.GetPlanHistory(w, req)
+	ResponseContains(t, w, http.StatusBadRequest, "Request must specify a repo and a pull number")
+}
+
+func TestGetPlanHistory_Disabled(t *testing.T) {
+	pc := newPlanHistoryController(t, nil)
+	req, _ := http.NewRequest("GET", "/plan-history?repo=owner/repo&pull=1", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	pc.GetPlanHistory(w, req)
+	ResponseContains(t, w, http.StatusNotFound, "Plan history is not enabled")
+}
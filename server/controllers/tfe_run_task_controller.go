@@ -0,0 +1,355 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+const (
+	// tfeRunTaskSignatureHeader is the header of the HMAC-SHA512 signature of
+	// run task requests.
+	tfeRunTaskSignatureHeader = "X-TFC-Task-Signature"
+	// tfeRunTaskTestToken is the access token of the request Terraform Cloud
+	// sends to verify the endpoint when the run task is created.
+	tfeRunTaskTestToken = "test-token"
+	// tfeRunTaskTimeout is how long checking a run can take. Terraform Cloud
+	// waits up to 10 minutes for the callback.
+	tfeRunTaskTimeout = 10 * time.Minute
+)
+
+// TFERunTaskController implements the callback protocol of Terraform Cloud
+// and Enterprise run tasks. Runs of workspaces that have Atlantis as a run
+// task call its endpoint after planning and Atlantis checks their plans
+// against the server's policy sets, so that runs that haven't moved to
+// Atlantis yet are held to the same policies.
+type TFERunTaskController struct {
+	Logger logging.SimpleLogging
+	// HMACKey is the key run task requests are signed with.
+	HMACKey []byte
+	// TFEHostname is the hostname of Terraform Cloud or Enterprise. Plans are
+	// only downloaded from and results only sent to it.
+	TFEHostname string
+	// PolicySets are the policy sets plans are checked against.
+	PolicySets valid.PolicySets
+	// PolicyExecutor runs the policy sets against plans.
+	PolicyExecutor runtime.VersionedExecutorWorkflow
+	HTTPClient     *http.Client
+	// Drainer tracks the runs being checked so that they finish before
+	// Atlantis shuts down.
+	Drainer *events.Drainer
+}
+
+// TFERunTaskRequest is the payload of a run task request.
+type TFERunTaskRequest struct {
+	PayloadVersion int    `json:"payload_version"`
+	Stage          string `json:"stage"`
+	AccessToken    string `json:"access_token"`
+	Capabilities   struct {
+		Outcomes bool `json:"outcomes"`
+	} `json:"capabilities"`
+	OrganizationName      string `json:"organization_name"`
+	PlanJSONAPIURL        string `json:"plan_json_api_url"`
+	RunAppURL             string `json:"run_app_url"`
+	RunID                 string `json:"run_id"`
+	TaskResultCallbackURL string `json:"task_result_callback_url"`
+	TaskResultID          string `json:"task_result_id"`
+	WorkspaceID           string `json:"workspace_id"`
+	WorkspaceName         string `json:"workspace_name"`
+}
+
+// tfeTaskResult is the JSON:API body of a run task callback.
+type tfeTaskResult struct {
+	Data tfeTaskResultData `json:"data"`
+}
+
+type tfeTaskResultData struct {
+	Type          string                  `json:"type"`
+	Attributes    tfeTaskResultAttributes `json:"attributes"`
+	Relationships *tfeTaskResultRelations `json:"relationships,omitempty"`
+}
+
+type tfeTaskResultAttributes struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+type tfeTaskResultRelations struct {
+	Outcomes struct {
+		Data []tfeTaskResultOutcome `json:"data"`
+	} `json:"outcomes"`
+}
+
+type tfeTaskResultOutcome struct {
+	Type       string                         `json:"type"`
+	Attributes tfeTaskResultOutcomeAttributes `json:"attributes"`
+}
+
+type tfeTaskResultOutcomeAttributes struct {
+	OutcomeID   string                     `json:"outcome-id"`
+	Description string                     `json:"description"`
+	Body        string                     `json:"body"`
+	Tags        map[string][]tfeOutcomeTag `json:"tags"`
+}
+
+type tfeOutcomeTag struct {
+	Label string `json:"label"`
+	Level string `json:"level"`
+}
+
+// Post is the POST /tfe/run-task route. It verifies the request, responds
+// right away as the protocol requires and checks the run in the background,
+// sending the result to the request's callback URL.
+func (t *TFERunTaskController) Post(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.respond(w, logging.Warn, http.StatusBadRequest, "Failed reading run task request: %s", err)
+		return
+	}
+	if !t.validSignature(body, r.Header.Get(tfeRunTaskSignatureHeader)) {
+		t.respond(w, logging.Warn, http.StatusUnauthorized, "Run task request has an invalid %s header", tfeRunTaskSignatureHeader)
+		return
+	}
+	var req TFERunTaskRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.respond(w, logging.Warn, http.StatusBadRequest, "Failed parsing run task request: %s", err)
+		return
+	}
+	if req.AccessToken == tfeRunTaskTestToken {
+		t.respond(w, logging.Info, http.StatusOK, "Run task endpoint verified")
+		return
+	}
+	if err := t.validURL(req.TaskResultCallbackURL); err != nil {
+		t.respond(w, logging.Warn, http.StatusBadRequest, "Invalid task_result_callback_url: %s", err)
+		return
+	}
+	if req.PlanJSONAPIURL != "" {
+		if err := t.validURL(req.PlanJSONAPIURL); err != nil {
+			t.respond(w, logging.Warn, http.StatusBadRequest, "Invalid plan_json_api_url: %s", err)
+			return
+		}
+	}
+
+	if !t.Drainer.StartOp() {
+		t.respond(w, logging.Warn, http.StatusServiceUnavailable, "Atlantis is shutting down, cannot check run %s", req.RunID)
+		return
+	}
+	t.respond(w, logging.Info, http.StatusOK, "Checking run %s of workspace %s/%s", req.RunID, req.OrganizationName, req.WorkspaceName)
+	go func() {
+		defer t.Drainer.OpDone()
+		t.checkRun(req)
+	}()
+}
+
+// checkRun checks the plan of the run and sends the result to Terraform
+// Cloud.
+func (t *TFERunTaskController) checkRun(req TFERunTaskRequest) {
+	log := t.Logger.With("run", req.RunID, "workspace", req.WorkspaceName)
+	result := t.checkPlan(log, req)
+	if err := t.sendResult(req, result); err != nil {
+		log.Err("sending run task result: %s", err)
+		return
+	}
+	log.Info("run task %s", result.Data.Attributes.Status)
+}
+
+// checkPlan runs the policy sets against the plan of the run. Stages without
+// a plan pass since there's nothing to check.
+func (t *TFERunTaskController) checkPlan(log logging.SimpleLogging, req TFERunTaskRequest) tfeTaskResult {
+	if req.PlanJSONAPIURL == "" {
+		return newTFETaskResult("passed", fmt.Sprintf("Nothing to check in the %s stage.", req.Stage))
+	}
+	if len(t.PolicySets.PolicySets) == 0 {
+		return newTFETaskResult("passed", "No policies are configured.")
+	}
+
+	workdir, err := os.MkdirTemp("", "atlantis-run-task")
+	if err != nil {
+		return newTFETaskResult("failed", fmt.Sprintf("Creating a working directory failed: %s", err))
+	}
+	defer os.RemoveAll(workdir) // nolint: errcheck
+
+	ctx := command.ProjectContext{
+		Log:         log,
+		ProjectName: req.WorkspaceName,
+		Workspace:   "default",
+		PolicySets:  t.PolicySets,
+	}
+	if err := t.downloadPlan(req, filepath.Join(workdir, ctx.GetShowResultFileName())); err != nil {
+		return newTFETaskResult("failed", fmt.Sprintf("Downloading the plan failed: %s", err))
+	}
+	executable, err := t.PolicyExecutor.EnsureExecutorVersion(log, t.PolicySets.Version)
+	if err != nil {
+		return newTFETaskResult("failed", fmt.Sprintf("Ensuring the policy executor version failed: %s", err))
+	}
+	output, err := t.PolicyExecutor.Run(ctx, executable, map[string]string{}, workdir, nil)
+
+	var policySetResults []models.PolicySetResult
+	if output == "" || json.Unmarshal([]byte(output), &policySetResults) != nil {
+		// Conftest failed to run rather than reporting policy failures.
+		msg := "Running the policy checks failed."
+		if err != nil {
+			msg = fmt.Sprintf("Running the policy checks failed: %s", err)
+		}
+		return newTFETaskResult("failed", msg)
+	}
+
+	var failed []string
+	for _, p := range policySetResults {
+		if !p.Passed {
+			failed = append(failed, p.PolicySetName)
+		}
+	}
+	var result tfeTaskResult
+	switch {
+	case len(failed) > 0:
+		result = newTFETaskResult("failed", fmt.Sprintf("Policy sets failed: %s.", strings.Join(failed, ", ")))
+	case err != nil:
+		result = newTFETaskResult("failed", fmt.Sprintf("Policy checks failed: %s", err))
+	default:
+		result = newTFETaskResult("passed", fmt.Sprintf("%d policy sets passed.", len(policySetResults)))
+	}
+	if req.Capabilities.Outcomes {
+		result.Data.Relationships = newTFEPolicyOutcomes(policySetResults)
+	}
+	return result
+}
+
+// downloadPlan downloads the JSON plan of the run to path.
+func (t *TFERunTaskController) downloadPlan(req TFERunTaskRequest, path string) error {
+	httpReq, err := http.NewRequest(http.MethodGet, req.PlanJSONAPIURL, nil)
+	if err != nil {
+		return err
+	}
+	// The plan is redirected to a temporary URL on another host, which the
+	// http client doesn't forward the token to.
+	httpReq.Header.Set("Authorization", "Bearer "+req.AccessToken)
+	resp, err := t.httpClient().Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", req.PlanJSONAPIURL, resp.StatusCode)
+	}
+	plan, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, plan, 0600)
+}
+
+// sendResult sends the result of the run task to its callback URL.
+func (t *TFERunTaskController) sendResult(req TFERunTaskRequest, result tfeTaskResult) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequest(http.MethodPatch, req.TaskResultCallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+req.AccessToken)
+	httpReq.Header.Set("Content-Type", "application/vnd.api+json")
+	resp, err := t.httpClient().Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("PATCH %s returned %d: %s", req.TaskResultCallbackURL, resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// validSignature returns true if signature is the HMAC-SHA512 of body.
+func (t *TFERunTaskController) validSignature(body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+	mac := hmac.New(sha512.New, t.HMACKey)
+	mac.Write(body) // nolint: errcheck
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// validURL returns an error unless rawURL is an https URL of TFEHostname, so
+// that the access token of the run is only sent to Terraform Cloud.
+func (t *TFERunTaskController) validURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" || u.Host != t.TFEHostname {
+		return errors.Errorf("must be an https URL of %s", t.TFEHostname)
+	}
+	return nil
+}
+
+func (t *TFERunTaskController) httpClient() *http.Client {
+	if t.HTTPClient != nil {
+		return t.HTTPClient
+	}
+	return &http.Client{Timeout: tfeRunTaskTimeout}
+}
+
+func (t *TFERunTaskController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	response := fmt.Sprintf(format, args...)
+	t.Logger.Log(lvl, response)
+	w.WriteHeader(responseCode)
+	fmt.Fprintln(w, response)
+}
+
+func newTFETaskResult(status string, message string) tfeTaskResult {
+	return tfeTaskResult{
+		Data: tfeTaskResultData{
+			Type: "task-results",
+			Attributes: tfeTaskResultAttributes{
+				Status:  status,
+				Message: message,
+			},
+		},
+	}
+}
+
+// newTFEPolicyOutcomes returns an outcome with the output of each policy set.
+func newTFEPolicyOutcomes(policySetResults []models.PolicySetResult) *tfeTaskResultRelations {
+	relations := &tfeTaskResultRelations{}
+	for _, p := range policySetResults {
+		description := fmt.Sprintf("Policy set %s passed", p.PolicySetName)
+		tag := tfeOutcomeTag{Label: "Passed", Level: "info"}
+		if !p.Passed {
+			description = fmt.Sprintf("Policy set %s failed", p.PolicySetName)
+			tag = tfeOutcomeTag{Label: "Failed", Level: "error"}
+		}
+		relations.Outcomes.Data = append(relations.Outcomes.Data, tfeTaskResultOutcome{
+			Type: "task-result-outcomes",
+			Attributes: tfeTaskResultOutcomeAttributes{
+				OutcomeID:   p.PolicySetName,
+				Description: description,
+				Body:        fmt.Sprintf("```\n%s\n```", p.PolicyOutput),
+				Tags:        map[string][]tfeOutcomeTag{"Status": {tag}},
+			},
+		})
+	}
+	return relations
+}
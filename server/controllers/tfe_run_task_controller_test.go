@@ -0,0 +1,210 @@
+package controllers_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const tfeRunTaskHMACKey = "hmac-key"
+
+// fakePolicyExecutor fails the policy sets in failing and records the plan
+// it was run against.
+type fakePolicyExecutor struct {
+	failing []string
+	plan    string
+}
+
+func (f *fakePolicyExecutor) EnsureExecutorVersion(_ logging.SimpleLogging, _ *version.Version) (string, error) {
+	return "conftest", nil
+}
+
+func (f *fakePolicyExecutor) Run(ctx command.ProjectContext, _ string, _ map[string]string, workdir string, _ []string) (string, error) {
+	plan, err := os.ReadFile(filepath.Join(workdir, ctx.GetShowResultFileName()))
+	if err != nil {
+		return "", err
+	}
+	f.plan = string(plan)
+	var results []map[string]interface{}
+	var combinedErr error
+	for _, p := range ctx.PolicySets.PolicySets {
+		passed := true
+		for _, failing := range f.failing {
+			if p.Name == failing {
+				passed = false
+				combinedErr = errors.Join(combinedErr, fmt.Errorf("policy_set: %s: conftest: some policies failed", p.Name))
+			}
+		}
+		results = append(results, map[string]interface{}{"PolicySetName": p.Name, "PolicyOutput": "output of " + p.Name, "Passed": passed})
+	}
+	output, err := json.Marshal(results)
+	if err != nil {
+		return "", err
+	}
+	return string(output), combinedErr
+}
+
+// newTestTFERunTaskServer returns a fake Terraform Cloud that serves a plan
+// and sends the body of the run task callback to the returned channel.
+func newTestTFERunTaskServer(t *testing.T) (*httptest.Server, chan map[string]interface{}) {
+	callbacks := make(chan map[string]interface{}, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "Bearer run-token", r.Header.Get("Authorization"))
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v2/plans/plan-1/json-output":
+			w.Write([]byte(`{"resource_changes": []}`)) // nolint: errcheck
+		case "PATCH /api/v2/task-results/taskrs-1/callback":
+			Equals(t, "application/vnd.api+json", r.Header.Get("Content-Type"))
+			var body map[string]interface{}
+			Ok(t, json.NewDecoder(r.Body).Decode(&body))
+			callbacks <- body
+		default:
+			t.Errorf("got unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server, callbacks
+}
+
+func newTestTFERunTaskController(t *testing.T, server *httptest.Server, executor *fakePolicyExecutor, policySets ...string) *controllers.TFERunTaskController {
+	serverURL, err := url.Parse(server.URL)
+	Ok(t, err)
+	c := &controllers.TFERunTaskController{
+		Logger:         logging.NewNoopLogger(t),
+		HMACKey:        []byte(tfeRunTaskHMACKey),
+		TFEHostname:    serverURL.Host,
+		PolicyExecutor: executor,
+		HTTPClient:     server.Client(),
+		Drainer:        &events.Drainer{},
+	}
+	for _, name := range policySets {
+		c.PolicySets.PolicySets = append(c.PolicySets.PolicySets, valid.PolicySet{Name: name})
+	}
+	return c
+}
+
+func postTFERunTask(c *controllers.TFERunTaskController, body string, signature string) *httptest.ResponseRecorder {
+	if signature == "" {
+		mac := hmac.New(sha512.New, []byte(tfeRunTaskHMACKey))
+		mac.Write([]byte(body)) // nolint: errcheck
+		signature = hex.EncodeToString(mac.Sum(nil))
+	}
+	req := httptest.NewRequest("POST", "/tfe/run-task", bytes.NewBufferString(body))
+	req.Header.Set("X-TFC-Task-Signature", signature)
+	w := httptest.NewRecorder()
+	c.Post(w, req)
+	return w
+}
+
+func tfeRunTaskBody(serverURL string) string {
+	return fmt.Sprintf(`{
+  "payload_version": 1,
+  "stage": "post_plan",
+  "access_token": "run-token",
+  "capabilities": {"outcomes": true},
+  "organization_name": "acme",
+  "plan_json_api_url": "%[1]s/api/v2/plans/plan-1/json-output",
+  "run_id": "run-1",
+  "task_result_callback_url": "%[1]s/api/v2/task-results/taskrs-1/callback",
+  "task_result_id": "taskrs-1",
+  "workspace_name": "network"
+}`, serverURL)
+}
+
+func receiveTFERunTaskCallback(t *testing.T, callbacks chan map[string]interface{}) map[string]interface{} {
+	select {
+	case body := <-callbacks:
+		return body["data"].(map[string]interface{})
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the run task callback")
+		return nil
+	}
+}
+
+func TestTFERunTaskController_Post(t *testing.T) {
+	server, callbacks := newTestTFERunTaskServer(t)
+	defer server.Close()
+	executor := &fakePolicyExecutor{failing: []string{"cost"}}
+	c := newTestTFERunTaskController(t, server, executor, "security", "cost")
+
+	w := postTFERunTask(c, tfeRunTaskBody(server.URL), "")
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+
+	data := receiveTFERunTaskCallback(t, callbacks)
+	c.Drainer.ShutdownBlocking()
+	Equals(t, `{"resource_changes": []}`, executor.plan)
+	Equals(t, map[string]interface{}{"status": "failed", "message": "Policy sets failed: cost."}, data["attributes"])
+	outcomes := data["relationships"].(map[string]interface{})["outcomes"].(map[string]interface{})["data"].([]interface{})
+	Equals(t, 2, len(outcomes))
+	cost := outcomes[1].(map[string]interface{})["attributes"].(map[string]interface{})
+	Equals(t, "cost", cost["outcome-id"])
+	Equals(t, "Policy set cost failed", cost["description"])
+	Equals(t, "```\noutput of cost\n```", cost["body"])
+}
+
+func TestTFERunTaskController_PostPassed(t *testing.T) {
+	server, callbacks := newTestTFERunTaskServer(t)
+	defer server.Close()
+	c := newTestTFERunTaskController(t, server, &fakePolicyExecutor{}, "security")
+
+	w := postTFERunTask(c, tfeRunTaskBody(server.URL), "")
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+
+	data := receiveTFERunTaskCallback(t, callbacks)
+	c.Drainer.ShutdownBlocking()
+	Equals(t, map[string]interface{}{"status": "passed", "message": "1 policy sets passed."}, data["attributes"])
+}
+
+func TestTFERunTaskController_PostInvalidSignature(t *testing.T) {
+	server, _ := newTestTFERunTaskServer(t)
+	defer server.Close()
+	c := newTestTFERunTaskController(t, server, &fakePolicyExecutor{})
+
+	w := postTFERunTask(c, tfeRunTaskBody(server.URL), hex.EncodeToString([]byte("wrong")))
+	Equals(t, http.StatusUnauthorized, w.Result().StatusCode)
+}
+
+// The request Terraform Cloud sends when the run task is created is only
+// acknowledged.
+func TestTFERunTaskController_PostVerification(t *testing.T) {
+	server, _ := newTestTFERunTaskServer(t)
+	defer server.Close()
+	c := newTestTFERunTaskController(t, server, &fakePolicyExecutor{})
+
+	w := postTFERunTask(c, `{"payload_version": 1, "access_token": "test-token", "task_result_callback_url": "https://example.com/callback"}`, "")
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+}
+
+// The access token of the run is never sent to other hosts.
+func TestTFERunTaskController_PostOtherHost(t *testing.T) {
+	server, _ := newTestTFERunTaskServer(t)
+	defer server.Close()
+	c := newTestTFERunTaskController(t, server, &fakePolicyExecutor{})
+
+	body := fmt.Sprintf(`{"payload_version": 1, "access_token": "run-token", "plan_json_api_url": "%s/api/v2/plans/plan-1/json-output", "task_result_callback_url": "https://example.com/callback"}`, server.URL)
+	w := postTFERunTask(c, body, "")
+	Equals(t, http.StatusBadRequest, w.Result().StatusCode)
+	respBody, err := io.ReadAll(w.Result().Body)
+	Ok(t, err)
+	Assert(t, bytes.Contains(respBody, []byte("Invalid task_result_callback_url")), "got %s", string(respBody))
+}
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/Masterminds/sprig/v3"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/jobs"
 )
 
@@ -37,6 +38,10 @@ var templateFileNames = map[string]string{
 	"project-jobs":       "project-jobs.html.tmpl",
 	"project-jobs-error": "project-jobs-error.html.tmpl",
 	"github-app":         "github-app.html.tmpl",
+	"drift":              "drift.html.tmpl",
+	"drift-output":       "drift-output.html.tmpl",
+	"plan-history":       "plan-history.html.tmpl",
+	"jobs":               "jobs.html.tmpl",
 }
 
 // TemplateWriter is an interface over html/template that's used to enable
@@ -71,6 +76,8 @@ type ApplyLockData struct {
 type IndexData struct {
 	Locks            []LockIndexData
 	PullToJobMapping []jobs.PullInfoWithJobIDs
+	// ApplyQueue is nil if applies aren't queued.
+	ApplyQueue *ApplyQueueData
 
 	ApplyLock       ApplyLockData
 	AtlantisVersion string
@@ -80,6 +87,28 @@ type IndexData struct {
 	CleanedBasePath string
 }
 
+// ApplyQueueData holds the applies of the apply queue.
+type ApplyQueueData struct {
+	// Applies are the running applies followed by the queued ones.
+	Applies []ApplyQueueEntryData
+}
+
+// ApplyQueueEntryData holds the fields needed to display an apply of the
+// apply queue.
+type ApplyQueueEntryData struct {
+	RepoFullName string
+	PullNum      int
+	ProjectName  string
+	Path         string
+	Workspace    string
+	// Status is "Running" or the position in the queue, ex. "Queued #2".
+	Status string
+	// TimeFormatted is when the apply was queued.
+	TimeFormatted string
+	// StartedFormatted is empty while the apply is queued.
+	StartedFormatted string
+}
+
 var IndexTemplate = templates.Lookup(templateFileNames["index"])
 
 // LockDetailData holds the fields needed to display the lock detail view.
@@ -129,3 +158,107 @@ type GithubSetupData struct {
 }
 
 var GithubAppSetupTemplate = templates.Lookup(templateFileNames["github-app"])
+
+// DriftProjectData holds the fields needed to display a project in the drift
+// view.
+type DriftProjectData struct {
+	// OutputPath is the path to the view with the project's plan output.
+	OutputPath         string
+	RepoFullName       string
+	Branch             string
+	ProjectName        string
+	Path               string
+	Workspace          string
+	Drifted            bool
+	DriftedResources   int
+	Error              string
+	CheckedAtFormatted string
+}
+
+// DriftIndexData holds the data for rendering the drift page.
+type DriftIndexData struct {
+	Projects        []DriftProjectData
+	AtlantisVersion string
+	// CleanedBasePath is the path Atlantis is accessible at externally. If
+	// not using a path-based proxy, this will be an empty string. Never ends
+	// in a '/' (hence "cleaned").
+	CleanedBasePath string
+}
+
+var DriftTemplate = templates.Lookup(templateFileNames["drift"])
+
+// DriftOutputData holds the data for rendering a project's drift plan output.
+type DriftOutputData struct {
+	DriftProjectData
+	Output          string
+	AtlantisVersion string
+	CleanedBasePath string
+}
+
+var DriftOutputTemplate = templates.Lookup(templateFileNames["drift-output"])
+
+// PlanHistoryData holds the data for rendering the plan history of a pull
+// request.
+type PlanHistoryData struct {
+	RepoFullName    string
+	PullNum         int
+	Projects        []PlanHistoryProjectData
+	AtlantisVersion string
+	CleanedBasePath string
+}
+
+// PlanHistoryProjectData holds the plans of a project in the plan history
+// view.
+type PlanHistoryProjectData struct {
+	ProjectName string
+	Path        string
+	Workspace   string
+	// Plans are the plans of the project, newest first.
+	Plans []PlanHistoryPlanData
+}
+
+// PlanHistoryPlanData holds a plan in the plan history view.
+type PlanHistoryPlanData struct {
+	HeadCommit         string
+	PlannedAtFormatted string
+	// Changes is the number of resources the plan changes.
+	Changes int
+	// Diff is how the plan differs from the previous plan. It's nil for the
+	// first plan.
+	Diff *models.PlanChangesetDiff
+}
+
+var PlanHistoryTemplate = templates.Lookup(templateFileNames["plan-history"])
+
+// JobsIndexData holds the data for rendering the jobs dashboard.
+type JobsIndexData struct {
+	Jobs []JobData
+	// Repo, Project and Status are the filters the jobs were listed with.
+	// They're empty if the jobs weren't filtered by them.
+	Repo            string
+	Project         string
+	Status          string
+	AtlantisVersion string
+	CleanedBasePath string
+}
+
+// JobData holds the fields needed to display a job in the jobs dashboard.
+type JobData struct {
+	JobID string
+	// JobPath is the path to the view with the job's output.
+	JobPath          string
+	RepoFullName     string
+	PullNum          int
+	ProjectName      string
+	Path             string
+	Workspace        string
+	JobStep          string
+	JobDescription   string
+	UpdatedFormatted string
+	// Status is running or complete.
+	Status string
+	// Cancelable is true if the job can be canceled.
+	Cancelable bool
+}
+
+var JobsTemplate = templates.Lookup(templateFileNames["jobs"])
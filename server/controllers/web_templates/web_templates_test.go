@@ -1,10 +1,13 @@
 package web_templates
 
 import (
+	"bytes"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/jobs"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -48,6 +51,25 @@ func TestIndexTemplate(t *testing.T) {
 	Ok(t, err)
 }
 
+func TestIndexTemplate_ApplyQueue(t *testing.T) {
+	var buf bytes.Buffer
+	err := IndexTemplate.Execute(&buf, IndexData{
+		ApplyQueue: &ApplyQueueData{
+			Applies: []ApplyQueueEntryData{
+				{RepoFullName: "owner/repo", PullNum: 1, Path: "vpc", Workspace: "default", Status: "Running", TimeFormatted: "2006-01-02 15:04:05", StartedFormatted: "2006-01-02 15:04:06"},
+				{RepoFullName: "owner/repo", PullNum: 2, ProjectName: "db", Workspace: "default", Status: "Queued #1", TimeFormatted: "2006-01-02 15:04:07"},
+			},
+		},
+	})
+	Ok(t, err)
+	Assert(t, strings.Contains(buf.String(), "Apply Queue"), "expected the apply queue to be rendered")
+	Assert(t, strings.Contains(buf.String(), "Queued #1"), "expected the queued apply to be rendered")
+
+	buf.Reset()
+	Ok(t, IndexTemplate.Execute(&buf, IndexData{}))
+	Assert(t, !strings.Contains(buf.String(), "Apply Queue"), "expected no apply queue without one")
+}
+
 func TestLockTemplate(t *testing.T) {
 	err := LockTemplate.Execute(io.Discard, LockDetailData{
 		LockKeyEncoded:  "lock key encoded",
@@ -93,3 +115,105 @@ func TestGithubAppSetupTemplate(t *testing.T) {
 	})
 	Ok(t, err)
 }
+
+func TestDriftTemplate(t *testing.T) {
+	err := DriftTemplate.Execute(io.Discard, DriftIndexData{
+		Projects: []DriftProjectData{
+			{
+				OutputPath:         "/drift/output?id=id",
+				RepoFullName:       "repo full name",
+				Branch:             "main",
+				Path:               "path",
+				Workspace:          "workspace",
+				Drifted:            true,
+				DriftedResources:   2,
+				CheckedAtFormatted: "2006-01-02 15:04:05",
+			},
+		},
+		AtlantisVersion: "v0.0.0",
+		CleanedBasePath: "/path",
+	})
+	Ok(t, err)
+}
+
+func TestDriftOutputTemplate(t *testing.T) {
+	err := DriftOutputTemplate.Execute(io.Discard, DriftOutputData{
+		DriftProjectData: DriftProjectData{
+			RepoFullName:       "repo full name",
+			Branch:             "main",
+			ProjectName:        "project name",
+			Path:               "path",
+			Workspace:          "workspace",
+			Drifted:            true,
+			DriftedResources:   2,
+			CheckedAtFormatted: "2006-01-02 15:04:05",
+		},
+		Output:          "output",
+		AtlantisVersion: "v0.0.0",
+		CleanedBasePath: "/path",
+	})
+	Ok(t, err)
+}
+
+func TestPlanHistoryTemplate(t *testing.T) {
+	var out bytes.Buffer
+	err := PlanHistoryTemplate.Execute(&out, PlanHistoryData{
+		RepoFullName: "owner/repo",
+		PullNum:      1,
+		Projects: []PlanHistoryProjectData{
+			{
+				Path:      "path",
+				Workspace: "workspace",
+				Plans: []PlanHistoryPlanData{
+					{
+						HeadCommit:         "commit2",
+						PlannedAtFormatted: "2006-01-02 15:04:05",
+						Changes:            1,
+						Diff: &models.PlanChangesetDiff{
+							PreviousCommit: "commit1",
+							Added:          []models.PlanResourceChange{{Address: "aws_subnet.b", Action: "will be created"}},
+							Removed:        []models.PlanResourceChange{{Address: "aws_subnet.a", Action: "will be created"}},
+						},
+					},
+					{
+						HeadCommit:         "commit1",
+						PlannedAtFormatted: "2006-01-02 15:04:05",
+						Changes:            1,
+					},
+				},
+			},
+		},
+		AtlantisVersion: "v0.0.0",
+		CleanedBasePath: "/path",
+	})
+	Ok(t, err)
+	Assert(t, strings.Contains(out.String(), "+ aws_subnet.b will be created\n- aws_subnet.a will be created\n"), "unexpected output: %s", out.String())
+	Assert(t, strings.Contains(out.String(), "First plan of this project."), "unexpected output: %s", out.String())
+}
+
+func TestJobsTemplate(t *testing.T) {
+	var out bytes.Buffer
+	err := JobsTemplate.Execute(&out, JobsIndexData{
+		Jobs: []JobData{
+			{
+				JobID:            "job-id",
+				JobPath:          "/jobs/job-id",
+				RepoFullName:     "repo full name",
+				PullNum:          1,
+				Path:             "path",
+				Workspace:        "workspace",
+				JobStep:          "plan",
+				UpdatedFormatted: "2006-01-02 15:04:05",
+				Status:           "running",
+				Cancelable:       true,
+			},
+		},
+		Repo:            "repo full name",
+		Status:          "running",
+		AtlantisVersion: "v0.0.0",
+		CleanedBasePath: "/path",
+	})
+	Ok(t, err)
+	Assert(t, strings.Contains(out.String(), `data-job-id="job-id"`), "exp cancel button in %s", out.String())
+	Assert(t, strings.Contains(out.String(), `http-equiv="refresh"`), "exp running jobs to refresh the page in %s", out.String())
+}
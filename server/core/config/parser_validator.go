@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	validation "github.com/go-ozzo/ozzo-validation"
@@ -41,13 +42,19 @@ func (p *ParserValidator) HasRepoCfg(absRepoDir, repoConfigFile string) (bool, e
 }
 
 // ParseRepoCfg returns the parsed and validated atlantis.yaml config for the
-// repo at absRepoDir.
-// If there was no config file, it will return an os.IsNotExist(error).
+// repo at absRepoDir, merged with the repo config of the server-side repo
+// config.
+// If there was no config file, it will return the repo config of the
+// server-side repo config if there is one and an os.IsNotExist(error)
+// otherwise.
 func (p *ParserValidator) ParseRepoCfg(absRepoDir string, globalCfg valid.GlobalCfg, repoID string, branch string) (valid.RepoCfg, error) {
 	repoConfigFile := globalCfg.RepoConfigFile(repoID)
 	configFile := p.repoCfgPath(absRepoDir, repoConfigFile)
 	configData, err := os.ReadFile(configFile) // nolint: gosec
 
+	if errors.Is(err, os.ErrNotExist) && globalCfg.RepoCfgOverlay(repoID) != nil {
+		return p.ParseServerSideRepoCfg(globalCfg, repoID, branch)
+	}
 	if err != nil {
 		return valid.RepoCfg{}, fmt.Errorf("unable to read %s file: %w", repoConfigFile, err)
 	}
@@ -79,14 +86,7 @@ func (p *ParserValidator) ParseRepoCfgData(repoCfgData []byte, globalCfg valid.G
 	//   - Have no branch regex defined at all (i.e. match all branches), or
 	//   - Those that have branch regex matching the PR's base branch.
 	//
-	i := 0
-	for _, p := range validConfig.Projects {
-		if branch == "" || p.BranchRegex == nil || p.BranchRegex.MatchString(branch) {
-			validConfig.Projects[i] = p
-			i++
-		}
-	}
-	validConfig.Projects = validConfig.Projects[:i]
+	validConfig.Projects = p.filterProjectsByBranch(validConfig.Projects, branch)
 
 	// We do the project name validation after we get the valid config because
 	// we need the defaults of dir and workspace to be populated.
@@ -101,8 +101,50 @@ func (p *ParserValidator) ParseRepoCfgData(repoCfgData []byte, globalCfg valid.G
 		}
 	}
 
-	err = globalCfg.ValidateRepoCfg(validConfig, repoID)
-	return validConfig, err
+	if err := globalCfg.ValidateRepoCfg(validConfig, repoID); err != nil {
+		return validConfig, err
+	}
+	// Projects can depend on the projects of the server-side repo config so
+	// we validate depends_on after merging them.
+	return p.mergeRepoCfgOverlay(validConfig, globalCfg, repoID, branch)
+}
+
+// ParseServerSideRepoCfg returns the repo config of the server-side repo
+// config for repos without an atlantis.yaml file. It's empty if the
+// server-side repo config doesn't define one for repoID.
+func (p *ParserValidator) ParseServerSideRepoCfg(globalCfg valid.GlobalCfg, repoID string, branch string) (valid.RepoCfg, error) {
+	version := 3
+	return p.mergeRepoCfgOverlay(raw.RepoCfg{Version: &version}.ToValid(), globalCfg, repoID, branch)
+}
+
+// mergeRepoCfgOverlay adds the projects of the repo config of the server-side
+// repo config for repoID to repoCfg, unless repoCfg defines the same project,
+// and validates the depends_on of the merged projects.
+func (p *ParserValidator) mergeRepoCfgOverlay(repoCfg valid.RepoCfg, globalCfg valid.GlobalCfg, repoID string, branch string) (valid.RepoCfg, error) {
+	if overlay := globalCfg.RepoCfgOverlay(repoID); overlay != nil {
+		overlay.Projects = p.filterProjectsByBranch(overlay.Projects, branch)
+		repoCfg = overlay.Merge(repoCfg)
+		if err := p.validateProjectNames(repoCfg); err != nil {
+			return valid.RepoCfg{}, fmt.Errorf("merging server-side repo_config: %w", err)
+		}
+	}
+	if err := p.validateDependsOn(repoCfg); err != nil {
+		return valid.RepoCfg{}, err
+	}
+	return repoCfg, nil
+}
+
+// filterProjectsByBranch filters projects in place, keeping the projects
+// without a branch regex and those whose branch regex matches branch.
+func (p *ParserValidator) filterProjectsByBranch(projects []valid.Project, branch string) []valid.Project {
+	i := 0
+	for _, project := range projects {
+		if branch == "" || project.BranchRegex == nil || project.BranchRegex.MatchString(branch) {
+			projects[i] = project
+			i++
+		}
+	}
+	return projects[:i]
 }
 
 // ParseGlobalCfg returns the parsed and validated global repo config file at
@@ -175,6 +217,11 @@ func (p *ParserValidator) validateProjectNames(config valid.RepoCfg) error {
 	// that project.
 	dirWorkspaceToNames := make(map[string][]string)
 	for _, project := range config.Projects {
+		// The workspaces of projects with workspaces aren't known until
+		// they're expanded.
+		if len(project.Workspaces) > 0 {
+			continue
+		}
 		key := fmt.Sprintf("%s/%s", project.Dir, project.Workspace)
 		names := dirWorkspaceToNames[key]
 
@@ -193,6 +240,56 @@ func (p *ParserValidator) validateProjectNames(config valid.RepoCfg) error {
 	return nil
 }
 
+// validateDependsOn validates that the depends_on of the projects don't form
+// a cycle, since the projects of a cycle can't be ordered.
+func (p *ParserValidator) validateDependsOn(config valid.RepoCfg) error {
+	dependsOn := make(map[string][]string)
+	for _, project := range config.Projects {
+		if project.Name != nil {
+			dependsOn[*project.Name] = project.DependsOn
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			cycle := append(path[slices.Index(path, name):], name)
+			return fmt.Errorf("projects can't depend on each other in a cycle but depends_on has one: %s", strings.Join(cycle, " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range dependsOn[name] {
+			if _, ok := dependsOn[dep]; !ok {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, project := range config.Projects {
+		if project.Name == nil {
+			continue
+		}
+		if err := visit(*project.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // applyLegacyShellParsing changes any custom run commands in cfg to use the old
 // parsing method with shlex.Split().
 func (p *ParserValidator) applyLegacyShellParsing(cfg *valid.RepoCfg) error {
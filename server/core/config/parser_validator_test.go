@@ -14,6 +14,7 @@ import (
 	"github.com/runatlantis/atlantis/server/core/config"
 	"github.com/runatlantis/atlantis/server/core/config/raw"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 )
 
@@ -717,6 +718,22 @@ projects:
   workspace: workspace`,
 			expErr: "found two or more projects with name \"myname\"; project names must be unique",
 		},
+		{
+			description: "projects that depend on each other in a cycle",
+			input: `
+version: 3
+projects:
+- name: network
+  dir: network
+  depends_on: [app]
+- name: cluster
+  dir: cluster
+  depends_on: [network]
+- name: app
+  dir: app
+  depends_on: [cluster, unknown]`,
+			expErr: "projects can't depend on each other in a cycle but depends_on has one: network -> app -> cluster -> network",
+		},
 		{
 			description: "two projects with same dir/workspace with different names",
 			input: `
@@ -1291,6 +1308,23 @@ func TestParseGlobalCfg(t *testing.T) {
   branch: /?/`,
 			expErr: "repos: (0: (branch: parsing: /?/: error parsing regexp: missing argument to repetition operator: `?`.).).",
 		},
+		"drift detection on regex id": {
+			input: `repos:
+- id: /.*/
+  drift_detection:
+    projects:
+    - name: prod`,
+			expErr: "repos: (0: (drift_detection: can only be set for repos with an exact id.).).",
+		},
+		"scheduled applies on regex id": {
+			input: `repos:
+- id: /.*/
+  scheduled_applies:
+  - schedule: "@daily"
+    projects:
+    - name: certs`,
+			expErr: "repos: (0: (scheduled_applies: can only be set for repos with an exact id.).).",
+		},
 		"invalid repo_config_file which starts with a slash": {
 			input: `repos:
 - id: /.*/
@@ -1309,11 +1343,138 @@ func TestParseGlobalCfg(t *testing.T) {
   workflow: notdefined`,
 			expErr: "workflow \"notdefined\" is not defined",
 		},
+		"custom command defined twice": {
+			input: `custom_commands:
+- name: docs
+  workflow: docs
+- name: docs
+  workflow: docs
+workflows:
+  docs:
+    custom:
+      steps:
+      - run: terraform-docs .`,
+			expErr: "custom command \"docs\" is defined more than once",
+		},
+		"custom command workflow doesn't exist": {
+			input: `custom_commands:
+- name: docs
+  workflow: notdefined`,
+			expErr: "workflow \"notdefined\" of custom command \"docs\" is not defined",
+		},
+		"custom command workflow without custom steps": {
+			input: `custom_commands:
+- name: docs
+  workflow: docs
+workflows:
+  docs:
+    plan:
+      steps: [init, plan]`,
+			expErr: "workflow \"docs\" of custom command \"docs\" doesn't define any custom steps",
+		},
+		"custom command with a built-in name": {
+			input: `custom_commands:
+- name: apply
+  workflow: docs`,
+			expErr: "custom_commands: (0: (name: \"apply\" is a built-in command, apply, approve_policies, help, import, ok-to-test, plan, policy_check, state, unlock, version can't be used.).).",
+		},
 		"invalid allowed_override": {
 			input: `repos:
 - id: /.*/
   allowed_overrides: [invalid]`,
-			expErr: "repos: (0: (allowed_overrides: \"invalid\" is not a valid override, only \"plan_requirements\", \"apply_requirements\", \"import_requirements\", \"workflow\", \"delete_source_branch_on_merge\", \"repo_locking\", \"repo_locks\", \"policy_check\", \"custom_policy_check\", and \"silence_pr_comments\" are supported.).).",
+			expErr: "repos: (0: (allowed_overrides: \"invalid\" is not a valid override, only \"plan_requirements\", \"apply_requirements\", \"import_requirements\", \"workflow\", \"delete_source_branch_on_merge\", \"repo_locking\", \"repo_locks\", \"policy_check\", \"custom_policy_check\", \"silence_pr_comments\", \"preview_environment\", \"destroy_on_close\", and \"comment_templates\" are supported.).).",
+		},
+		"invalid disabled_commands": {
+			input: `repos:
+- id: /.*/
+  disabled_commands: [plan]`,
+			expErr: "repos: (0: (disabled_commands: \"plan\" is not a command that can be disabled, only approve_policies, import, state, unlock, version, plan_all, apply_all are supported.).).",
+		},
+		"invalid gitlab_ci commands": {
+			input: `repos:
+- id: /.*/
+  gitlab_ci:
+    wait_for_jobs: [lint]
+    commands: [plan, import]`,
+			expErr: "repos: (0: (gitlab_ci: (commands: \"import\" is not a command that can wait for GitLab CI jobs, only plan, apply are supported.).).).",
+		},
+		"github_environments without environment": {
+			input: `repos:
+- id: /.*/
+  github_environments:
+  - projects:
+    - name: network`,
+			expErr: "repos: (0: (github_environments: (0: (environment: cannot be blank.).).).).",
+		},
+		"invalid stacked_pulls": {
+			input: `repos:
+- id: /.*/
+  stacked_pulls: fail`,
+			expErr: "repos: (0: (stacked_pulls: \"fail\" is not a valid mode, only disabled, warn, block are supported.).).",
+		},
+		"negative command_timeouts": {
+			input: `repos:
+- id: /.*/
+  command_timeouts:
+    apply_seconds: -1`,
+			expErr: "repos: (0: (command_timeouts: (apply_seconds: must not be negative.).).).",
+		},
+		"invalid pre_workflow_hooks output": {
+			input: `repos:
+- id: /.*/
+  pre_workflow_hooks:
+  - run: ./list-projects.sh
+    output: log`,
+			expErr: "repos: (0: (pre_workflow_hooks: \"log\" is not a valid output, only comment, command_comment are supported.).).",
+		},
+		"post_workflow_hooks output": {
+			input: `repos:
+- id: /.*/
+  post_workflow_hooks:
+  - run: ./cleanup.sh
+    output: comment`,
+			expErr: "repos: (0: (post_workflow_hooks: output is only supported for pre_workflow_hooks.).).",
+		},
+		"invalid post_workflow_hooks on": {
+			input: `repos:
+- id: /.*/
+  post_workflow_hooks:
+  - run: ./notify.sh
+    on: [apply_success, apply_partial]`,
+			expErr: "repos: (0: (post_workflow_hooks: \"apply_partial\" is not a valid on value, only plan_success, plan_failure, apply_success, apply_failure, policy_success, policy_failure, lock_created, lock_released, pull_closed, comment_unrecognized are supported.).).",
+		},
+		"pre_workflow_hooks on": {
+			input: `repos:
+- id: /.*/
+  pre_workflow_hooks:
+  - run: ./notify.sh
+    on: plan_success`,
+			expErr: "repos: (0: (pre_workflow_hooks: on is only supported for post_workflow_hooks.).).",
+		},
+		"post_workflow_hooks on": {
+			input: `repos:
+- id: /.*/
+  post_workflow_hooks:
+  - run: ./notify.sh
+    on: [apply_success, apply_failure]
+  - run: ./cleanup.sh
+    on: plan_failure, policy_failure`,
+			exp: valid.GlobalCfg{
+				Repos: []valid.Repo{
+					defaultCfg.Repos[0],
+					{
+						IDRegex: regexp.MustCompile(".*"),
+						PostWorkflowHooks: []*valid.WorkflowHook{
+							{StepName: "run", RunCommand: "./notify.sh", On: []string{"apply_success", "apply_failure"}},
+							{StepName: "run", RunCommand: "./cleanup.sh", On: []string{"plan_failure", "policy_failure"}},
+						},
+					},
+				},
+				Workflows: defaultCfg.Workflows,
+				TeamAuthz: valid.TeamAuthz{
+					Args: make([]string, 0),
+				},
+			},
 		},
 		"invalid plan_requirement": {
 			input: `repos:
@@ -1465,6 +1626,10 @@ repos:
     mode: enabled
   repo_locks:
     mode: on_apply
+  webhook_secret: repo-secret
+  drift_detection:
+    projects:
+    - name: prod
 - id: /.*/
   branch: /(master|main)/
   pre_workflow_hooks:
@@ -1524,6 +1689,10 @@ policies:
 						PolicyCheck:          Bool(true),
 						AutoDiscover:         &valid.AutoDiscover{Mode: valid.AutoDiscoverEnabledMode},
 						RepoLocks:            &valid.RepoLocks{Mode: valid.RepoLocksOnApplyMode},
+						WebhookSecret:        "repo-secret",
+						DriftDetection: &valid.DriftDetection{
+							Projects: []valid.DriftDetectionProject{{Name: "prod"}},
+						},
 					},
 					{
 						IDRegex:           regexp.MustCompile(".*"),
@@ -1653,6 +1822,7 @@ workflows:
 						PolicyCheck:               Bool(false),
 						CustomPolicyCheck:         Bool(false),
 						AutoDiscover:              raw.DefaultAutoDiscover(),
+						CommandTimeouts:           defaultCfg.Repos[0].CommandTimeouts,
 					},
 				},
 				Workflows: map[string]valid.Workflow{
@@ -1921,6 +2091,145 @@ func TestParserValidator_ParseGlobalCfgJSON(t *testing.T) {
 	}
 }
 
+func TestParseRepoCfg_RepoCfgOverlay(t *testing.T) {
+	globalCfgDir := t.TempDir()
+	globalCfgFile := filepath.Join(globalCfgDir, "repos.yaml")
+	err := os.WriteFile(globalCfgFile, []byte(`
+repos:
+- id: /github.com/org/.*/
+  allowed_overrides: [workflow]
+  repo_config:
+    workflows:
+      platform:
+        plan:
+          steps: [init, plan]
+    projects:
+    - name: network
+      dir: network
+      workflow: platform
+      apply_requirements: [approved]
+    - name: staging
+      dir: envs/staging
+      branch: /staging/
+- id: github.com/org/app
+  repo_config:
+    projects:
+    - name: network
+      dir: infra/network
+`), 0600)
+	Ok(t, err)
+	r := config.ParserValidator{}
+	globalCfg, err := r.ParseGlobalCfg(globalCfgFile, valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}))
+	Ok(t, err)
+
+	projectNames := func(repoCfg valid.RepoCfg) []string {
+		var names []string
+		for _, p := range repoCfg.Projects {
+			names = append(names, fmt.Sprintf("%s:%s:%t", p.GetName(), p.Dir, p.ServerSide))
+		}
+		return names
+	}
+
+	t.Run("without repo config file", func(t *testing.T) {
+		repoCfg, err := r.ParseRepoCfg(t.TempDir(), globalCfg, "github.com/org/infra", "main")
+		Ok(t, err)
+		Equals(t, []string{"network:network:true"}, projectNames(repoCfg))
+		Equals(t, []string{"approved"}, repoCfg.Projects[0].ApplyRequirements)
+
+		merged := globalCfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/org/infra", repoCfg.Projects[0], repoCfg)
+		Equals(t, "platform", merged.Workflow.Name)
+		Equals(t, []string{"approved"}, merged.ApplyRequirements)
+	})
+
+	t.Run("with branch", func(t *testing.T) {
+		repoCfg, err := r.ParseRepoCfg(t.TempDir(), globalCfg, "github.com/org/infra", "staging")
+		Ok(t, err)
+		Equals(t, []string{"network:network:true", "staging:envs/staging:true"}, projectNames(repoCfg))
+	})
+
+	t.Run("later repos take precedence", func(t *testing.T) {
+		repoCfg, err := r.ParseRepoCfg(t.TempDir(), globalCfg, "github.com/org/app", "main")
+		Ok(t, err)
+		Equals(t, []string{"network:infra/network:true"}, projectNames(repoCfg))
+	})
+
+	t.Run("repo config file takes precedence", func(t *testing.T) {
+		repoDir := t.TempDir()
+		err := os.WriteFile(filepath.Join(repoDir, "atlantis.yaml"), []byte(`
+version: 3
+projects:
+- name: network
+  dir: modules/network
+  workflow: platform
+- dir: app
+`), 0600)
+		Ok(t, err)
+		repoCfg, err := r.ParseRepoCfg(repoDir, globalCfg, "github.com/org/infra", "main")
+		Ok(t, err)
+		Equals(t, []string{"network:modules/network:false", ":app:false"}, projectNames(repoCfg))
+
+		merged := globalCfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/org/infra", repoCfg.Projects[0], repoCfg)
+		Equals(t, "platform", merged.Workflow.Name)
+	})
+
+	t.Run("depends on server-side project", func(t *testing.T) {
+		repoDir := t.TempDir()
+		err := os.WriteFile(filepath.Join(repoDir, "atlantis.yaml"), []byte(`
+version: 3
+projects:
+- name: app
+  dir: app
+  depends_on: [network]
+`), 0600)
+		Ok(t, err)
+		repoCfg, err := r.ParseRepoCfg(repoDir, globalCfg, "github.com/org/infra", "main")
+		Ok(t, err)
+		Equals(t, []string{"app:app:false", "network:network:true"}, projectNames(repoCfg))
+	})
+
+	t.Run("not matching", func(t *testing.T) {
+		_, err := r.ParseRepoCfg(t.TempDir(), globalCfg, "github.com/other/repo", "main")
+		Assert(t, errors.Is(err, fs.ErrNotExist), "exp not exist err")
+	})
+}
+
+func TestParseRepoCfg_RepoCfgOverlayDuplicateProjectNames(t *testing.T) {
+	globalCfgFile := filepath.Join(t.TempDir(), "repos.yaml")
+	err := os.WriteFile(globalCfgFile, []byte(`
+repos:
+- id: /.*/
+  repo_config:
+    projects:
+    - name: network
+      dir: network
+    - name: network
+      dir: network
+      workspace: staging
+`), 0600)
+	Ok(t, err)
+	r := config.ParserValidator{}
+	globalCfg, err := r.ParseGlobalCfg(globalCfgFile, valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}))
+	Ok(t, err)
+	_, err = r.ParseRepoCfg(t.TempDir(), globalCfg, "github.com/org/infra", "main")
+	ErrContains(t, "merging server-side repo_config: found two or more projects with name \"network\"", err)
+}
+
+func TestParseGlobalCfg_RepoCfgOverlayWorkflowNotDefined(t *testing.T) {
+	globalCfgFile := filepath.Join(t.TempDir(), "repos.yaml")
+	err := os.WriteFile(globalCfgFile, []byte(`
+repos:
+- id: /.*/
+  repo_config:
+    projects:
+    - dir: .
+      workflow: missing
+`), 0600)
+	Ok(t, err)
+	r := config.ParserValidator{}
+	_, err = r.ParseGlobalCfg(globalCfgFile, valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}))
+	ErrContains(t, `workflow "missing" of repo_config project is not defined`, err)
+}
+
 // Test legacy shell parsing vs v3 parsing.
 func TestParseRepoCfg_V2ShellParsing(t *testing.T) {
 	cases := []struct {
@@ -0,0 +1,44 @@
+package raw
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// ApplyApprovers is the raw schema for the approvals a pull request needs
+// before it can be applied.
+type ApplyApprovers struct {
+	Users         []string `yaml:"users,omitempty" json:"users,omitempty"`
+	Teams         []string `yaml:"teams,omitempty" json:"teams,omitempty"`
+	Orgs          []string `yaml:"orgs,omitempty" json:"orgs,omitempty"`
+	Count         int      `yaml:"count,omitempty" json:"count,omitempty"`
+	ExcludeAuthor bool     `yaml:"exclude_author,omitempty" json:"exclude_author,omitempty"`
+}
+
+func (a ApplyApprovers) Validate() error {
+	countValid := func(value interface{}) error {
+		if value.(int) < 0 {
+			return errors.New("must be at least 1")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.Count, validation.By(countValid)),
+	)
+}
+
+func (a ApplyApprovers) ToValid() *valid.ApplyApprovers {
+	v := valid.ApplyApprovers{
+		Users:         a.Users,
+		Teams:         a.Teams,
+		Orgs:          a.Orgs,
+		Count:         a.Count,
+		ExcludeAuthor: a.ExcludeAuthor,
+	}
+	if v.Count == 0 {
+		v.Count = 1
+	}
+	return &v
+}
@@ -0,0 +1,33 @@
+package raw_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestApplyApprovers_UnmarshalYAML(t *testing.T) {
+	input := `
+teams: [platform, security]
+orgs: [acme]
+users: [alice]
+count: 2
+exclude_author: true
+`
+	var a raw.ApplyApprovers
+	Ok(t, unmarshalString(input, &a))
+	Equals(t, raw.ApplyApprovers{Users: []string{"alice"}, Teams: []string{"platform", "security"}, Orgs: []string{"acme"}, Count: 2, ExcludeAuthor: true}, a)
+	Equals(t, &valid.ApplyApprovers{Users: []string{"alice"}, Teams: []string{"platform", "security"}, Orgs: []string{"acme"}, Count: 2, ExcludeAuthor: true}, a.ToValid())
+}
+
+func TestApplyApprovers_ToValidDefaults(t *testing.T) {
+	a := raw.ApplyApprovers{Teams: []string{"platform"}}
+	Equals(t, &valid.ApplyApprovers{Teams: []string{"platform"}, Count: 1}, a.ToValid())
+}
+
+func TestApplyApprovers_Validate(t *testing.T) {
+	Ok(t, raw.ApplyApprovers{Count: 2}.Validate())
+	ErrContains(t, "count: must be at least 1", raw.ApplyApprovers{Count: -1}.Validate())
+}
@@ -13,8 +13,8 @@ var DefaultAutoPlanWhenModified = []string{
 }
 
 type Autoplan struct {
-	WhenModified []string `yaml:"when_modified,omitempty"`
-	Enabled      *bool    `yaml:"enabled,omitempty"`
+	WhenModified []string `yaml:"when_modified,omitempty" json:"when_modified,omitempty"`
+	Enabled      *bool    `yaml:"enabled,omitempty" json:"enabled,omitempty"`
 }
 
 func (a Autoplan) ToValid() valid.Autoplan {
@@ -0,0 +1,51 @@
+package raw
+
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// CommandTimeouts is the raw schema for how long commands run on a repo's pull
+// requests can run before they're canceled.
+type CommandTimeouts struct {
+	PlanSeconds        *int `yaml:"plan_seconds,omitempty" json:"plan_seconds,omitempty"`
+	ApplySeconds       *int `yaml:"apply_seconds,omitempty" json:"apply_seconds,omitempty"`
+	PolicyCheckSeconds *int `yaml:"policy_check_seconds,omitempty" json:"policy_check_seconds,omitempty"`
+	CustomSeconds      *int `yaml:"custom_seconds,omitempty" json:"custom_seconds,omitempty"`
+}
+
+func (c CommandTimeouts) Validate() error {
+	timeoutValid := func(value interface{}) error {
+		timeout := value.(*int)
+		if timeout != nil && *timeout < 0 {
+			return errors.New("must not be negative")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.PlanSeconds, validation.By(timeoutValid)),
+		validation.Field(&c.ApplySeconds, validation.By(timeoutValid)),
+		validation.Field(&c.PolicyCheckSeconds, validation.By(timeoutValid)),
+		validation.Field(&c.CustomSeconds, validation.By(timeoutValid)),
+	)
+}
+
+func (c CommandTimeouts) ToValid() *valid.RepoCommandTimeouts {
+	return &valid.RepoCommandTimeouts{
+		Plan:        secondsToDuration(c.PlanSeconds),
+		Apply:       secondsToDuration(c.ApplySeconds),
+		PolicyCheck: secondsToDuration(c.PolicyCheckSeconds),
+		Custom:      secondsToDuration(c.CustomSeconds),
+	}
+}
+
+func secondsToDuration(seconds *int) *time.Duration {
+	if seconds == nil {
+		return nil
+	}
+	d := time.Duration(*seconds) * time.Second
+	return &d
+}
@@ -0,0 +1,40 @@
+package raw
+
+import (
+	"fmt"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// CommentArgs is the raw schema for the default extra args of the plans and
+// applies of a project that are run by comments.
+type CommentArgs struct {
+	Plan  []string `yaml:"plan,omitempty" json:"plan,omitempty"`
+	Apply []string `yaml:"apply,omitempty" json:"apply,omitempty"`
+}
+
+func (c CommentArgs) Validate() error {
+	// Each arg must be a whole flag so that it can be overridden by the
+	// same flag in a comment.
+	flagsValid := func(value interface{}) error {
+		for _, arg := range value.([]string) {
+			if !strings.HasPrefix(arg, "-") || strings.Trim(arg, "-") == "" {
+				return fmt.Errorf("%q is not a flag, args must be flags like -parallelism=30", arg)
+			}
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Plan, validation.By(flagsValid)),
+		validation.Field(&c.Apply, validation.By(flagsValid)),
+	)
+}
+
+func (c CommentArgs) ToValid() valid.CommentArgs {
+	return valid.CommentArgs{
+		Plan:  c.Plan,
+		Apply: c.Apply,
+	}
+}
@@ -0,0 +1,62 @@
+package raw_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCommentArgs_UnmarshalYAML(t *testing.T) {
+	input := `
+plan: [-parallelism=30, -var-file=prod.tfvars]
+apply: [-parallelism=30]
+`
+	var c raw.CommentArgs
+	Ok(t, unmarshalString(input, &c))
+	Equals(t, raw.CommentArgs{
+		Plan:  []string{"-parallelism=30", "-var-file=prod.tfvars"},
+		Apply: []string{"-parallelism=30"},
+	}, c)
+	Equals(t, valid.CommentArgs{
+		Plan:  []string{"-parallelism=30", "-var-file=prod.tfvars"},
+		Apply: []string{"-parallelism=30"},
+	}, c.ToValid())
+}
+
+func TestCommentArgs_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.CommentArgs
+		errContains *string
+	}{
+		{
+			description: "empty",
+			input:       raw.CommentArgs{},
+		},
+		{
+			description: "flags",
+			input:       raw.CommentArgs{Plan: []string{"-parallelism=30", "--var-file=prod.tfvars"}, Apply: []string{"-lock-timeout=5m"}},
+		},
+		{
+			description: "value without flag",
+			input:       raw.CommentArgs{Plan: []string{"-parallelism", "30"}},
+			errContains: String(`plan: "30" is not a flag`),
+		},
+		{
+			description: "only dashes",
+			input:       raw.CommentArgs{Apply: []string{"--"}},
+			errContains: String(`apply: "--" is not a flag`),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if c.errContains == nil {
+				Ok(t, c.input.Validate())
+			} else {
+				ErrContains(t, *c.errContains, c.input.Validate())
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package raw
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// CommentTemplates is the raw schema for the template files that override
+// how comments of the repo's commands are rendered.
+type CommentTemplates struct {
+	Plan        *string `yaml:"plan,omitempty" json:"plan,omitempty"`
+	Apply       *string `yaml:"apply,omitempty" json:"apply,omitempty"`
+	PolicyCheck *string `yaml:"policy_check,omitempty" json:"policy_check,omitempty"`
+	Error       *string `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+func (c CommentTemplates) Validate() error {
+	// Templates are read from the repo's clone so they must be in it.
+	pathValid := func(value interface{}) error {
+		strPtr := value.(*string)
+		if strPtr == nil {
+			return nil
+		}
+		if filepath.IsAbs(*strPtr) {
+			return fmt.Errorf("%q must be a relative path in the repo", *strPtr)
+		}
+		cleaned := filepath.Clean(*strPtr)
+		if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return fmt.Errorf("%q must be a file in the repo", *strPtr)
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Plan, validation.By(pathValid)),
+		validation.Field(&c.Apply, validation.By(pathValid)),
+		validation.Field(&c.PolicyCheck, validation.By(pathValid)),
+		validation.Field(&c.Error, validation.By(pathValid)),
+	)
+}
+
+func (c CommentTemplates) ToValid() *valid.CommentTemplates {
+	v := valid.CommentTemplates{}
+	for _, f := range []struct {
+		raw   *string
+		valid *string
+	}{
+		{c.Plan, &v.Plan},
+		{c.Apply, &v.Apply},
+		{c.PolicyCheck, &v.PolicyCheck},
+		{c.Error, &v.Error},
+	} {
+		if f.raw != nil {
+			*f.valid = filepath.Clean(*f.raw)
+		}
+	}
+	return &v
+}
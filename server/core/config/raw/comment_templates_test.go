@@ -0,0 +1,67 @@
+package raw_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCommentTemplates_UnmarshalYAML(t *testing.T) {
+	input := `
+plan: .atlantis/plan.tmpl
+error: ./.atlantis/error.tmpl
+`
+	var c raw.CommentTemplates
+	Ok(t, unmarshalString(input, &c))
+	Equals(t, raw.CommentTemplates{
+		Plan:  String(".atlantis/plan.tmpl"),
+		Error: String("./.atlantis/error.tmpl"),
+	}, c)
+	Equals(t, &valid.CommentTemplates{
+		Plan:  ".atlantis/plan.tmpl",
+		Error: ".atlantis/error.tmpl",
+	}, c.ToValid())
+}
+
+func TestCommentTemplates_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.CommentTemplates
+		errContains *string
+	}{
+		{
+			description: "empty",
+			input:       raw.CommentTemplates{},
+		},
+		{
+			description: "relative paths",
+			input:       raw.CommentTemplates{Plan: String("plan.tmpl"), Apply: String("templates/../apply.tmpl")},
+		},
+		{
+			description: "absolute path",
+			input:       raw.CommentTemplates{Apply: String("/etc/apply.tmpl")},
+			errContains: String(`apply: "/etc/apply.tmpl" must be a relative path in the repo`),
+		},
+		{
+			description: "path outside the repo",
+			input:       raw.CommentTemplates{PolicyCheck: String("templates/../../policy.tmpl")},
+			errContains: String(`policy_check: "templates/../../policy.tmpl" must be a file in the repo`),
+		},
+		{
+			description: "repo root",
+			input:       raw.CommentTemplates{Error: String(".")},
+			errContains: String(`error: "." must be a file in the repo`),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if c.errContains == nil {
+				Ok(t, c.input.Validate())
+			} else {
+				ErrContains(t, *c.errContains, c.input.Validate())
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package raw
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+// customCommandNameRegex matches the names custom commands can use. They're
+// lowercase since comment commands are lowercased before they're matched.
+var customCommandNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// CustomCommand is the raw schema for a comment command defined in the
+// server-side repo config.
+type CustomCommand struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Workflow    string `yaml:"workflow" json:"workflow"`
+}
+
+func (c CustomCommand) Validate() error {
+	nameValid := func(value interface{}) error {
+		name := value.(string)
+		if !customCommandNameRegex.MatchString(name) {
+			return fmt.Errorf("%q must start with a lowercase letter and only contain lowercase letters, digits, '-' and '_'", name)
+		}
+		if utils.SlicesContains(valid.ReservedCommandNames, name) {
+			return fmt.Errorf("%q is a built-in command, %s can't be used", name, strings.Join(valid.ReservedCommandNames, ", "))
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Name, validation.Required, validation.By(nameValid)),
+		validation.Field(&c.Workflow, validation.Required),
+	)
+}
+
+func (c CustomCommand) ToValid() valid.CustomCommand {
+	return valid.CustomCommand{
+		Name:        c.Name,
+		Description: c.Description,
+		Workflow:    c.Workflow,
+	}
+}
@@ -0,0 +1,71 @@
+package raw_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCustomCommand_UnmarshalYAML(t *testing.T) {
+	input := `
+name: docs
+description: Generate the module docs
+workflow: docs
+`
+	var c raw.CustomCommand
+	Ok(t, unmarshalString(input, &c))
+	Equals(t, raw.CustomCommand{
+		Name:        "docs",
+		Description: "Generate the module docs",
+		Workflow:    "docs",
+	}, c)
+	Equals(t, valid.CustomCommand{
+		Name:        "docs",
+		Description: "Generate the module docs",
+		Workflow:    "docs",
+	}, c.ToValid())
+}
+
+func TestCustomCommand_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.CustomCommand
+		errContains *string
+	}{
+		{
+			description: "valid",
+			input:       raw.CustomCommand{Name: "infracost-breakdown", Workflow: "docs"},
+		},
+		{
+			description: "no name",
+			input:       raw.CustomCommand{Workflow: "docs"},
+			errContains: String("name: cannot be blank"),
+		},
+		{
+			description: "no workflow",
+			input:       raw.CustomCommand{Name: "docs"},
+			errContains: String("workflow: cannot be blank"),
+		},
+		{
+			description: "uppercase name",
+			input:       raw.CustomCommand{Name: "Docs", Workflow: "docs"},
+			errContains: String(`"Docs" must start with a lowercase letter`),
+		},
+		{
+			description: "built-in name",
+			input:       raw.CustomCommand{Name: "plan", Workflow: "docs"},
+			errContains: String(`"plan" is a built-in command`),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if c.errContains == nil {
+				Ok(t, c.input.Validate())
+			} else {
+				ErrContains(t, *c.errContains, c.input.Validate())
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package raw
+
+import (
+	"errors"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// DriftDetection is the raw schema for a repo's drift detection config in the
+// server-side repo config.
+type DriftDetection struct {
+	Branch   string                  `yaml:"branch,omitempty" json:"branch,omitempty"`
+	Projects []DriftDetectionProject `yaml:"projects" json:"projects"`
+}
+
+// DriftDetectionProject is the raw schema for a project that is checked for
+// drift.
+type DriftDetectionProject struct {
+	Name      string   `yaml:"name,omitempty" json:"name,omitempty"`
+	Dir       string   `yaml:"dir,omitempty" json:"dir,omitempty"`
+	Workspace string   `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+	OnDrift   *OnDrift `yaml:"on_drift,omitempty" json:"on_drift,omitempty"`
+}
+
+// OnDrift is the raw schema for what to do when drift is detected in a
+// project.
+type OnDrift struct {
+	Action      valid.OnDriftAction `yaml:"action" json:"action"`
+	PullRequest int                 `yaml:"pull_request,omitempty" json:"pull_request,omitempty"`
+}
+
+// branchNameValid checks that value is a branch name rather than a branch
+// regex.
+func branchNameValid(value interface{}) error {
+	branch := value.(string)
+	if strings.HasPrefix(branch, "/") && strings.HasSuffix(branch, "/") {
+		return errors.New("must be a branch name, not a regex")
+	}
+	return nil
+}
+
+// projectDirValid checks that value is a dir inside the repo.
+func projectDirValid(value interface{}) error {
+	dir := value.(string)
+	if strings.HasPrefix(dir, "/") {
+		return errors.New("must be relative to the repo root")
+	}
+	if strings.Contains(dir, "..") {
+		return errors.New("must not contain '..'")
+	}
+	return nil
+}
+
+func (d DriftDetection) Validate() error {
+	return validation.ValidateStruct(&d,
+		validation.Field(&d.Branch, validation.By(branchNameValid)),
+		validation.Field(&d.Projects, validation.Required),
+	)
+}
+
+func (p DriftDetectionProject) Validate() error {
+	hasNameOrDir := func(value interface{}) error {
+		if p.Name == "" && p.Dir == "" {
+			return errors.New("name or dir must be set")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Name, validation.By(hasNameOrDir)),
+		validation.Field(&p.Dir, validation.By(projectDirValid)),
+		validation.Field(&p.OnDrift),
+	)
+}
+
+func (o OnDrift) Validate() error {
+	pullRequestValid := func(value interface{}) error {
+		pullRequest := value.(int)
+		if o.Action == valid.OnDriftCommentAction && pullRequest <= 0 {
+			return errors.New("must be set when action is comment")
+		}
+		if o.Action != valid.OnDriftCommentAction && pullRequest != 0 {
+			return errors.New("can only be set when action is comment")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&o,
+		validation.Field(&o.Action, validation.Required, validation.In(valid.OnDriftIssueAction, valid.OnDriftCommentAction)),
+		validation.Field(&o.PullRequest, validation.By(pullRequestValid)),
+	)
+}
+
+func (d DriftDetection) ToValid() *valid.DriftDetection {
+	v := valid.DriftDetection{
+		Branch: d.Branch,
+	}
+	for _, p := range d.Projects {
+		v.Projects = append(v.Projects, valid.DriftDetectionProject{
+			Name:      p.Name,
+			Dir:       strings.TrimRight(p.Dir, "/"),
+			Workspace: p.Workspace,
+			OnDrift:   p.OnDrift.ToValid(),
+		})
+	}
+	return &v
+}
+
+func (o *OnDrift) ToValid() *valid.OnDrift {
+	if o == nil {
+		return nil
+	}
+	return &valid.OnDrift{
+		Action:      o.Action,
+		PullRequest: o.PullRequest,
+	}
+}
@@ -0,0 +1,133 @@
+package raw_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestDriftDetection_UnmarshalYAML(t *testing.T) {
+	input := `
+branch: main
+projects:
+- name: prod
+- dir: staging
+  workspace: blue
+  on_drift:
+    action: comment
+    pull_request: 42
+`
+	var d raw.DriftDetection
+	Ok(t, unmarshalString(input, &d))
+	Equals(t, raw.DriftDetection{
+		Branch: "main",
+		Projects: []raw.DriftDetectionProject{
+			{Name: "prod"},
+			{Dir: "staging", Workspace: "blue", OnDrift: &raw.OnDrift{Action: valid.OnDriftCommentAction, PullRequest: 42}},
+		},
+	}, d)
+}
+
+func TestDriftDetection_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.DriftDetection
+		errContains *string
+	}{
+		{
+			description: "project by name",
+			input: raw.DriftDetection{
+				Projects: []raw.DriftDetectionProject{{Name: "prod"}},
+			},
+		},
+		{
+			description: "project by dir",
+			input: raw.DriftDetection{
+				Branch:   "main",
+				Projects: []raw.DriftDetectionProject{{Dir: "staging", Workspace: "blue"}},
+			},
+		},
+		{
+			description: "no projects",
+			input:       raw.DriftDetection{},
+			errContains: String("projects: cannot be blank"),
+		},
+		{
+			description: "project without name or dir",
+			input: raw.DriftDetection{
+				Projects: []raw.DriftDetectionProject{{Workspace: "blue"}},
+			},
+			errContains: String("name or dir must be set"),
+		},
+		{
+			description: "dir outside repo",
+			input: raw.DriftDetection{
+				Projects: []raw.DriftDetectionProject{{Dir: "../other"}},
+			},
+			errContains: String("must not contain '..'"),
+		},
+		{
+			description: "open issue on drift",
+			input: raw.DriftDetection{
+				Projects: []raw.DriftDetectionProject{{Name: "prod", OnDrift: &raw.OnDrift{Action: valid.OnDriftIssueAction}}},
+			},
+		},
+		{
+			description: "unknown on drift action",
+			input: raw.DriftDetection{
+				Projects: []raw.DriftDetectionProject{{Name: "prod", OnDrift: &raw.OnDrift{Action: "email"}}},
+			},
+			errContains: String("action: must be a valid value"),
+		},
+		{
+			description: "comment without pull request",
+			input: raw.DriftDetection{
+				Projects: []raw.DriftDetectionProject{{Name: "prod", OnDrift: &raw.OnDrift{Action: valid.OnDriftCommentAction}}},
+			},
+			errContains: String("pull_request: must be set when action is comment"),
+		},
+		{
+			description: "issue with pull request",
+			input: raw.DriftDetection{
+				Projects: []raw.DriftDetectionProject{{Name: "prod", OnDrift: &raw.OnDrift{Action: valid.OnDriftIssueAction, PullRequest: 1}}},
+			},
+			errContains: String("pull_request: can only be set when action is comment"),
+		},
+		{
+			description: "branch regex",
+			input: raw.DriftDetection{
+				Branch:   "/main/",
+				Projects: []raw.DriftDetectionProject{{Name: "prod"}},
+			},
+			errContains: String("must be a branch name, not a regex"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if c.errContains == nil {
+				Ok(t, c.input.Validate())
+			} else {
+				ErrContains(t, *c.errContains, c.input.Validate())
+			}
+		})
+	}
+}
+
+func TestDriftDetection_ToValid(t *testing.T) {
+	input := raw.DriftDetection{
+		Branch: "main",
+		Projects: []raw.DriftDetectionProject{
+			{Name: "prod"},
+			{Dir: "staging/", Workspace: "blue", OnDrift: &raw.OnDrift{Action: valid.OnDriftIssueAction}},
+		},
+	}
+	Equals(t, &valid.DriftDetection{
+		Branch: "main",
+		Projects: []valid.DriftDetectionProject{
+			{Name: "prod"},
+			{Dir: "staging", Workspace: "blue", OnDrift: &valid.OnDrift{Action: valid.OnDriftIssueAction}},
+		},
+	}, input.ToValid())
+}
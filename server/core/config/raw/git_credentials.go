@@ -0,0 +1,63 @@
+package raw
+
+import (
+	"errors"
+	"path/filepath"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// GitCredentials is the raw schema for the credentials a repo is cloned with.
+type GitCredentials struct {
+	SSHKeyFile              string `yaml:"ssh_key_file,omitempty" json:"ssh_key_file,omitempty"`
+	TokenFile               string `yaml:"token_file,omitempty" json:"token_file,omitempty"`
+	TokenUser               string `yaml:"token_user,omitempty" json:"token_user,omitempty"`
+	GithubAppInstallationID int64  `yaml:"github_app_installation_id,omitempty" json:"github_app_installation_id,omitempty"`
+}
+
+func (g GitCredentials) Validate() error {
+	oneSource := func(value interface{}) error {
+		sources := 0
+		for _, set := range []bool{g.SSHKeyFile != "", g.TokenFile != "", g.GithubAppInstallationID != 0} {
+			if set {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return errors.New("exactly one of ssh_key_file, token_file and github_app_installation_id must be set")
+		}
+		return nil
+	}
+	absPath := func(value interface{}) error {
+		path := value.(string)
+		if path != "" && !filepath.IsAbs(path) {
+			return errors.New("must be an absolute path")
+		}
+		return nil
+	}
+	tokenUserValid := func(value interface{}) error {
+		if value.(string) != "" && g.TokenFile == "" {
+			return errors.New("can only be set with token_file")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&g,
+		validation.Field(&g.SSHKeyFile, validation.By(oneSource), validation.By(absPath)),
+		validation.Field(&g.TokenFile, validation.By(absPath)),
+		validation.Field(&g.TokenUser, validation.By(tokenUserValid)),
+	)
+}
+
+func (g GitCredentials) ToValid() *valid.GitCredentials {
+	v := valid.GitCredentials{
+		SSHKeyFile:              g.SSHKeyFile,
+		TokenFile:               g.TokenFile,
+		TokenUser:               g.TokenUser,
+		GithubAppInstallationID: g.GithubAppInstallationID,
+	}
+	if v.TokenFile != "" && v.TokenUser == "" {
+		v.TokenUser = valid.DefaultGitTokenUser
+	}
+	return &v
+}
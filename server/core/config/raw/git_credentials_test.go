@@ -0,0 +1,71 @@
+package raw_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGitCredentials_UnmarshalYAML(t *testing.T) {
+	input := `
+token_file: /secrets/org-a-token
+token_user: oauth2
+`
+	var g raw.GitCredentials
+	Ok(t, unmarshalString(input, &g))
+	Equals(t, raw.GitCredentials{TokenFile: "/secrets/org-a-token", TokenUser: "oauth2"}, g)
+	Equals(t, &valid.GitCredentials{TokenFile: "/secrets/org-a-token", TokenUser: "oauth2"}, g.ToValid())
+}
+
+func TestGitCredentials_ToValidDefaults(t *testing.T) {
+	g := raw.GitCredentials{TokenFile: "/secrets/org-a-token"}
+	Equals(t, &valid.GitCredentials{TokenFile: "/secrets/org-a-token", TokenUser: valid.DefaultGitTokenUser}, g.ToValid())
+}
+
+func TestGitCredentials_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.GitCredentials
+		errContains *string
+	}{
+		{
+			description: "ssh key",
+			input:       raw.GitCredentials{SSHKeyFile: "/keys/org-a"},
+		},
+		{
+			description: "github app installation",
+			input:       raw.GitCredentials{GithubAppInstallationID: 1234},
+		},
+		{
+			description: "no source",
+			input:       raw.GitCredentials{},
+			errContains: String("exactly one of ssh_key_file, token_file and github_app_installation_id must be set"),
+		},
+		{
+			description: "several sources",
+			input:       raw.GitCredentials{SSHKeyFile: "/keys/org-a", TokenFile: "/secrets/org-a-token"},
+			errContains: String("exactly one of ssh_key_file, token_file and github_app_installation_id must be set"),
+		},
+		{
+			description: "relative path",
+			input:       raw.GitCredentials{TokenFile: "secrets/org-a-token"},
+			errContains: String("token_file: must be an absolute path"),
+		},
+		{
+			description: "token user without token file",
+			input:       raw.GitCredentials{SSHKeyFile: "/keys/org-a", TokenUser: "oauth2"},
+			errContains: String("token_user: can only be set with token_file"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if c.errContains == nil {
+				Ok(t, c.input.Validate())
+			} else {
+				ErrContains(t, *c.errContains, c.input.Validate())
+			}
+		})
+	}
+}
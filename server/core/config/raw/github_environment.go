@@ -0,0 +1,72 @@
+package raw
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// GithubEnvironment is the raw schema for the projects of a repo that are
+// deployed to a GitHub environment.
+type GithubEnvironment struct {
+	Environment    string                     `yaml:"environment" json:"environment"`
+	Projects       []GithubEnvironmentProject `yaml:"projects,omitempty" json:"projects,omitempty"`
+	TimeoutSeconds *int                       `yaml:"timeout_seconds,omitempty" json:"timeout_seconds,omitempty"`
+}
+
+// GithubEnvironmentProject is the raw schema for a project deployed to a
+// GitHub environment.
+type GithubEnvironmentProject struct {
+	Name      string `yaml:"name,omitempty" json:"name,omitempty"`
+	Dir       string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	Workspace string `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+}
+
+func (e GithubEnvironment) Validate() error {
+	timeoutValid := func(value interface{}) error {
+		timeout := value.(*int)
+		if timeout != nil && *timeout <= 0 {
+			return errors.New("must be greater than 0")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&e,
+		validation.Field(&e.Environment, validation.Required),
+		validation.Field(&e.Projects),
+		validation.Field(&e.TimeoutSeconds, validation.By(timeoutValid)),
+	)
+}
+
+func (p GithubEnvironmentProject) Validate() error {
+	hasNameOrDir := func(value interface{}) error {
+		if p.Name == "" && p.Dir == "" {
+			return errors.New("name or dir must be set")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Name, validation.By(hasNameOrDir)),
+		validation.Field(&p.Dir, validation.By(projectDirValid)),
+	)
+}
+
+func (e GithubEnvironment) ToValid() valid.GithubEnvironment {
+	v := valid.GithubEnvironment{
+		Name:    e.Environment,
+		Timeout: valid.DefaultGithubEnvironmentTimeout,
+	}
+	if e.TimeoutSeconds != nil {
+		v.Timeout = time.Duration(*e.TimeoutSeconds) * time.Second
+	}
+	for _, p := range e.Projects {
+		v.Projects = append(v.Projects, valid.GithubEnvironmentProject{
+			Name:      p.Name,
+			Dir:       strings.TrimRight(p.Dir, "/"),
+			Workspace: p.Workspace,
+		})
+	}
+	return v
+}
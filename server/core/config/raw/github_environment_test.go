@@ -0,0 +1,90 @@
+package raw_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGithubEnvironment_UnmarshalYAML(t *testing.T) {
+	input := `
+environment: production
+projects:
+- name: network
+- dir: prod/
+  workspace: default
+timeout_seconds: 1800
+`
+	var e raw.GithubEnvironment
+	Ok(t, unmarshalString(input, &e))
+	timeout := 1800
+	Equals(t, raw.GithubEnvironment{
+		Environment: "production",
+		Projects: []raw.GithubEnvironmentProject{
+			{Name: "network"},
+			{Dir: "prod/", Workspace: "default"},
+		},
+		TimeoutSeconds: &timeout,
+	}, e)
+	Equals(t, valid.GithubEnvironment{
+		Name: "production",
+		Projects: []valid.GithubEnvironmentProject{
+			{Name: "network"},
+			{Dir: "prod", Workspace: "default"},
+		},
+		Timeout: 30 * time.Minute,
+	}, e.ToValid())
+}
+
+func TestGithubEnvironment_ToValidDefaults(t *testing.T) {
+	e := raw.GithubEnvironment{Environment: "production"}
+	Equals(t, valid.GithubEnvironment{
+		Name:    "production",
+		Timeout: valid.DefaultGithubEnvironmentTimeout,
+	}, e.ToValid())
+}
+
+func TestGithubEnvironment_Validate(t *testing.T) {
+	zero := 0
+	cases := []struct {
+		description string
+		input       raw.GithubEnvironment
+		errContains *string
+	}{
+		{
+			description: "every project",
+			input:       raw.GithubEnvironment{Environment: "production"},
+		},
+		{
+			description: "projects",
+			input:       raw.GithubEnvironment{Environment: "production", Projects: []raw.GithubEnvironmentProject{{Name: "network"}, {Dir: "prod"}}},
+		},
+		{
+			description: "no environment",
+			input:       raw.GithubEnvironment{Projects: []raw.GithubEnvironmentProject{{Name: "network"}}},
+			errContains: String("environment: cannot be blank"),
+		},
+		{
+			description: "project without name or dir",
+			input:       raw.GithubEnvironment{Environment: "production", Projects: []raw.GithubEnvironmentProject{{Workspace: "default"}}},
+			errContains: String("name or dir must be set"),
+		},
+		{
+			description: "zero timeout",
+			input:       raw.GithubEnvironment{Environment: "production", TimeoutSeconds: &zero},
+			errContains: String("timeout_seconds: must be greater than 0"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if c.errContains == nil {
+				Ok(t, c.input.Validate())
+			} else {
+				ErrContains(t, *c.errContains, c.input.Validate())
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package raw
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+// GitlabCI is the raw schema for the GitLab CI jobs that must succeed before
+// commands are run on a repo's merge requests.
+type GitlabCI struct {
+	WaitForJobs    []string `yaml:"wait_for_jobs,omitempty" json:"wait_for_jobs,omitempty"`
+	TriggerJobs    []string `yaml:"trigger_jobs,omitempty" json:"trigger_jobs,omitempty"`
+	Commands       []string `yaml:"commands,omitempty" json:"commands,omitempty"`
+	TimeoutSeconds *int     `yaml:"timeout_seconds,omitempty" json:"timeout_seconds,omitempty"`
+}
+
+func (g GitlabCI) Validate() error {
+	hasJobs := func(value interface{}) error {
+		if len(g.WaitForJobs) == 0 && len(g.TriggerJobs) == 0 {
+			return errors.New("wait_for_jobs or trigger_jobs must be set")
+		}
+		return nil
+	}
+	commandsValid := func(value interface{}) error {
+		for _, c := range value.([]string) {
+			if !utils.SlicesContains(valid.GitlabCICommands, c) {
+				return fmt.Errorf("%q is not a command that can wait for GitLab CI jobs, only %s are supported", c, strings.Join(valid.GitlabCICommands, ", "))
+			}
+		}
+		return nil
+	}
+	timeoutValid := func(value interface{}) error {
+		timeout := value.(*int)
+		if timeout != nil && *timeout <= 0 {
+			return errors.New("must be greater than 0")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&g,
+		validation.Field(&g.WaitForJobs, validation.By(hasJobs)),
+		validation.Field(&g.Commands, validation.By(commandsValid)),
+		validation.Field(&g.TimeoutSeconds, validation.By(timeoutValid)),
+	)
+}
+
+func (g GitlabCI) ToValid() *valid.GitlabCI {
+	v := valid.GitlabCI{
+		WaitForJobs: g.WaitForJobs,
+		TriggerJobs: g.TriggerJobs,
+		Commands:    g.Commands,
+		Timeout:     valid.DefaultGitlabCITimeout,
+	}
+	if len(v.Commands) == 0 {
+		v.Commands = valid.GitlabCICommands
+	}
+	if g.TimeoutSeconds != nil {
+		v.Timeout = time.Duration(*g.TimeoutSeconds) * time.Second
+	}
+	return &v
+}
@@ -0,0 +1,85 @@
+package raw_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGitlabCI_UnmarshalYAML(t *testing.T) {
+	input := `
+wait_for_jobs: [lint, unit-tests]
+trigger_jobs: [security-scan]
+commands: [apply]
+timeout_seconds: 300
+`
+	var g raw.GitlabCI
+	Ok(t, unmarshalString(input, &g))
+	timeout := 300
+	Equals(t, raw.GitlabCI{
+		WaitForJobs:    []string{"lint", "unit-tests"},
+		TriggerJobs:    []string{"security-scan"},
+		Commands:       []string{"apply"},
+		TimeoutSeconds: &timeout,
+	}, g)
+	Equals(t, &valid.GitlabCI{
+		WaitForJobs: []string{"lint", "unit-tests"},
+		TriggerJobs: []string{"security-scan"},
+		Commands:    []string{"apply"},
+		Timeout:     5 * time.Minute,
+	}, g.ToValid())
+}
+
+func TestGitlabCI_ToValidDefaults(t *testing.T) {
+	g := raw.GitlabCI{WaitForJobs: []string{"lint"}}
+	Equals(t, &valid.GitlabCI{
+		WaitForJobs: []string{"lint"},
+		Commands:    []string{"plan", "apply"},
+		Timeout:     valid.DefaultGitlabCITimeout,
+	}, g.ToValid())
+}
+
+func TestGitlabCI_Validate(t *testing.T) {
+	zero := 0
+	cases := []struct {
+		description string
+		input       raw.GitlabCI
+		errContains *string
+	}{
+		{
+			description: "wait for jobs",
+			input:       raw.GitlabCI{WaitForJobs: []string{"lint"}},
+		},
+		{
+			description: "trigger jobs",
+			input:       raw.GitlabCI{TriggerJobs: []string{"scan"}, Commands: []string{"apply"}},
+		},
+		{
+			description: "no jobs",
+			input:       raw.GitlabCI{Commands: []string{"plan"}},
+			errContains: String("wait_for_jobs or trigger_jobs must be set"),
+		},
+		{
+			description: "unsupported command",
+			input:       raw.GitlabCI{WaitForJobs: []string{"lint"}, Commands: []string{"unlock"}},
+			errContains: String(`"unlock" is not a command that can wait for GitLab CI jobs`),
+		},
+		{
+			description: "zero timeout",
+			input:       raw.GitlabCI{WaitForJobs: []string{"lint"}, TimeoutSeconds: &zero},
+			errContains: String("timeout_seconds: must be greater than 0"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if c.errContains == nil {
+				Ok(t, c.input.Validate())
+			} else {
+				ErrContains(t, *c.errContains, c.input.Validate())
+			}
+		})
+	}
+}
@@ -13,34 +13,51 @@ import (
 
 // GlobalCfg is the raw schema for server-side repo config.
 type GlobalCfg struct {
-	Repos      []Repo              `yaml:"repos" json:"repos"`
-	Workflows  map[string]Workflow `yaml:"workflows" json:"workflows"`
-	PolicySets PolicySets          `yaml:"policies" json:"policies"`
-	Metrics    Metrics             `yaml:"metrics" json:"metrics"`
-	TeamAuthz  TeamAuthz           `yaml:"team_authz" json:"team_authz"`
+	Repos          []Repo              `yaml:"repos" json:"repos"`
+	Workflows      map[string]Workflow `yaml:"workflows" json:"workflows"`
+	PolicySets     PolicySets          `yaml:"policies" json:"policies"`
+	Metrics        Metrics             `yaml:"metrics" json:"metrics"`
+	TeamAuthz      TeamAuthz           `yaml:"team_authz" json:"team_authz"`
+	CustomCommands []CustomCommand     `yaml:"custom_commands,omitempty" json:"custom_commands,omitempty"`
 }
 
 // Repo is the raw schema for repos in the server-side repo config.
 type Repo struct {
-	ID                        string         `yaml:"id" json:"id"`
-	Branch                    string         `yaml:"branch" json:"branch"`
-	RepoConfigFile            string         `yaml:"repo_config_file" json:"repo_config_file"`
-	PlanRequirements          []string       `yaml:"plan_requirements" json:"plan_requirements"`
-	ApplyRequirements         []string       `yaml:"apply_requirements" json:"apply_requirements"`
-	ImportRequirements        []string       `yaml:"import_requirements" json:"import_requirements"`
-	PreWorkflowHooks          []WorkflowHook `yaml:"pre_workflow_hooks" json:"pre_workflow_hooks"`
-	Workflow                  *string        `yaml:"workflow,omitempty" json:"workflow,omitempty"`
-	PostWorkflowHooks         []WorkflowHook `yaml:"post_workflow_hooks" json:"post_workflow_hooks"`
-	AllowedWorkflows          []string       `yaml:"allowed_workflows,omitempty" json:"allowed_workflows,omitempty"`
-	AllowedOverrides          []string       `yaml:"allowed_overrides" json:"allowed_overrides"`
-	AllowCustomWorkflows      *bool          `yaml:"allow_custom_workflows,omitempty" json:"allow_custom_workflows,omitempty"`
-	DeleteSourceBranchOnMerge *bool          `yaml:"delete_source_branch_on_merge,omitempty" json:"delete_source_branch_on_merge,omitempty"`
-	RepoLocking               *bool          `yaml:"repo_locking,omitempty" json:"repo_locking,omitempty"`
-	RepoLocks                 *RepoLocks     `yaml:"repo_locks,omitempty" json:"repo_locks,omitempty"`
-	PolicyCheck               *bool          `yaml:"policy_check,omitempty" json:"policy_check,omitempty"`
-	CustomPolicyCheck         *bool          `yaml:"custom_policy_check,omitempty" json:"custom_policy_check,omitempty"`
-	AutoDiscover              *AutoDiscover  `yaml:"autodiscover,omitempty" json:"autodiscover,omitempty"`
-	SilencePRComments         []string       `yaml:"silence_pr_comments,omitempty" json:"silence_pr_comments,omitempty"`
+	ID                        string              `yaml:"id" json:"id"`
+	Branch                    string              `yaml:"branch" json:"branch"`
+	RepoConfigFile            string              `yaml:"repo_config_file" json:"repo_config_file"`
+	PlanRequirements          []string            `yaml:"plan_requirements" json:"plan_requirements"`
+	ApplyRequirements         []string            `yaml:"apply_requirements" json:"apply_requirements"`
+	ImportRequirements        []string            `yaml:"import_requirements" json:"import_requirements"`
+	PreWorkflowHooks          []WorkflowHook      `yaml:"pre_workflow_hooks" json:"pre_workflow_hooks"`
+	Workflow                  *string             `yaml:"workflow,omitempty" json:"workflow,omitempty"`
+	PostWorkflowHooks         []WorkflowHook      `yaml:"post_workflow_hooks" json:"post_workflow_hooks"`
+	AllowedWorkflows          []string            `yaml:"allowed_workflows,omitempty" json:"allowed_workflows,omitempty"`
+	AllowedOverrides          []string            `yaml:"allowed_overrides" json:"allowed_overrides"`
+	AllowCustomWorkflows      *bool               `yaml:"allow_custom_workflows,omitempty" json:"allow_custom_workflows,omitempty"`
+	DeleteSourceBranchOnMerge *bool               `yaml:"delete_source_branch_on_merge,omitempty" json:"delete_source_branch_on_merge,omitempty"`
+	RepoLocking               *bool               `yaml:"repo_locking,omitempty" json:"repo_locking,omitempty"`
+	RepoLocks                 *RepoLocks          `yaml:"repo_locks,omitempty" json:"repo_locks,omitempty"`
+	PolicyCheck               *bool               `yaml:"policy_check,omitempty" json:"policy_check,omitempty"`
+	CustomPolicyCheck         *bool               `yaml:"custom_policy_check,omitempty" json:"custom_policy_check,omitempty"`
+	AutoDiscover              *AutoDiscover       `yaml:"autodiscover,omitempty" json:"autodiscover,omitempty"`
+	SilencePRComments         []string            `yaml:"silence_pr_comments,omitempty" json:"silence_pr_comments,omitempty"`
+	WebhookSecret             string              `yaml:"webhook_secret,omitempty" json:"webhook_secret,omitempty"`
+	DriftDetection            *DriftDetection     `yaml:"drift_detection,omitempty" json:"drift_detection,omitempty"`
+	ScheduledApplies          []ScheduledApply    `yaml:"scheduled_applies,omitempty" json:"scheduled_applies,omitempty"`
+	AutoApplyDestroyOnClose   *bool               `yaml:"auto_apply_destroy_on_close,omitempty" json:"auto_apply_destroy_on_close,omitempty"`
+	ShadowMode                *bool               `yaml:"shadow_mode,omitempty" json:"shadow_mode,omitempty"`
+	ManualTrigger             *ManualTrigger      `yaml:"manual_trigger,omitempty" json:"manual_trigger,omitempty"`
+	DisabledCommands          []string            `yaml:"disabled_commands,omitempty" json:"disabled_commands,omitempty"`
+	GitlabCI                  *GitlabCI           `yaml:"gitlab_ci,omitempty" json:"gitlab_ci,omitempty"`
+	GithubEnvironments        []GithubEnvironment `yaml:"github_environments,omitempty" json:"github_environments,omitempty"`
+	StackedPulls              *string             `yaml:"stacked_pulls,omitempty" json:"stacked_pulls,omitempty"`
+	CommandTimeouts           *CommandTimeouts    `yaml:"command_timeouts,omitempty" json:"command_timeouts,omitempty"`
+	StateRmAllowlist          []string            `yaml:"state_rm_allowlist,omitempty" json:"state_rm_allowlist,omitempty"`
+	MaintenanceWindows        []MaintenanceWindow `yaml:"maintenance_windows,omitempty" json:"maintenance_windows,omitempty"`
+	GitCredentials            *GitCredentials     `yaml:"git_credentials,omitempty" json:"git_credentials,omitempty"`
+	ApplyApprovers            *ApplyApprovers     `yaml:"apply_approvers,omitempty" json:"apply_approvers,omitempty"`
+	RepoCfgOverlay            *RepoCfgOverlay     `yaml:"repo_config,omitempty" json:"repo_config,omitempty"`
 }
 
 func (g GlobalCfg) Validate() error {
@@ -48,11 +65,29 @@ func (g GlobalCfg) Validate() error {
 		validation.Field(&g.Repos),
 		validation.Field(&g.Workflows),
 		validation.Field(&g.Metrics),
+		validation.Field(&g.CustomCommands),
 	)
 	if err != nil {
 		return err
 	}
 
+	// Check that custom commands are unique and that their workflows define
+	// the custom stage they run.
+	customCommandNames := make(map[string]bool)
+	for _, c := range g.CustomCommands {
+		if customCommandNames[c.Name] {
+			return fmt.Errorf("custom command %q is defined more than once", c.Name)
+		}
+		customCommandNames[c.Name] = true
+		w, ok := g.Workflows[c.Workflow]
+		if !ok {
+			return fmt.Errorf("workflow %q of custom command %q is not defined", c.Workflow, c.Name)
+		}
+		if w.Custom == nil || len(w.Custom.Steps) == 0 {
+			return fmt.Errorf("workflow %q of custom command %q doesn't define any custom steps", c.Workflow, c.Name)
+		}
+	}
+
 	// Check that all workflows referenced by repos are actually defined.
 	for _, repo := range g.Repos {
 		if repo.Workflow == nil {
@@ -63,7 +98,7 @@ func (g GlobalCfg) Validate() error {
 			// The 'default' workflow will always be defined.
 			continue
 		}
-		found := false
+		found := repo.RepoCfgOverlay.workflowDefined(name)
 		for w := range g.Workflows {
 			if w == name {
 				found = true
@@ -75,6 +110,22 @@ func (g GlobalCfg) Validate() error {
 		}
 	}
 
+	// Check that the workflows of the projects of repo configs are defined
+	// by the repo config or globally.
+	for _, repo := range g.Repos {
+		if repo.RepoCfgOverlay == nil {
+			continue
+		}
+		for _, p := range repo.RepoCfgOverlay.Projects {
+			if p.Workflow == nil || *p.Workflow == valid.DefaultWorkflowName || repo.RepoCfgOverlay.workflowDefined(*p.Workflow) {
+				continue
+			}
+			if _, ok := g.Workflows[*p.Workflow]; !ok {
+				return fmt.Errorf("workflow %q of repo_config project is not defined", *p.Workflow)
+			}
+		}
+	}
+
 	// Check that all allowed workflows are defined
 	for _, repo := range g.Repos {
 		if repo.AllowedWorkflows == nil {
@@ -157,12 +208,18 @@ func (g GlobalCfg) ToValid(defaultCfg valid.GlobalCfg) valid.GlobalCfg {
 	}
 	repos = append(defaultCfg.Repos, repos...)
 
+	var customCommands []valid.CustomCommand
+	for _, c := range g.CustomCommands {
+		customCommands = append(customCommands, c.ToValid())
+	}
+
 	return valid.GlobalCfg{
-		Repos:      repos,
-		Workflows:  workflows,
-		PolicySets: g.PolicySets.ToValid(),
-		Metrics:    g.Metrics.ToValid(),
-		TeamAuthz:  g.TeamAuthz.ToValid(),
+		Repos:          repos,
+		Workflows:      workflows,
+		PolicySets:     g.PolicySets.ToValid(),
+		Metrics:        g.Metrics.ToValid(),
+		TeamAuthz:      g.TeamAuthz.ToValid(),
+		CustomCommands: customCommands,
 	}
 }
 
@@ -223,8 +280,8 @@ func (r Repo) Validate() error {
 	overridesValid := func(value interface{}) error {
 		overrides := value.([]string)
 		for _, o := range overrides {
-			if o != valid.PlanRequirementsKey && o != valid.ApplyRequirementsKey && o != valid.ImportRequirementsKey && o != valid.WorkflowKey && o != valid.DeleteSourceBranchOnMergeKey && o != valid.RepoLockingKey && o != valid.RepoLocksKey && o != valid.PolicyCheckKey && o != valid.CustomPolicyCheckKey && o != valid.SilencePRCommentsKey {
-				return fmt.Errorf("%q is not a valid override, only %q, %q, %q, %q, %q, %q, %q, %q, %q, and %q are supported", o, valid.PlanRequirementsKey, valid.ApplyRequirementsKey, valid.ImportRequirementsKey, valid.WorkflowKey, valid.DeleteSourceBranchOnMergeKey, valid.RepoLockingKey, valid.RepoLocksKey, valid.PolicyCheckKey, valid.CustomPolicyCheckKey, valid.SilencePRCommentsKey)
+			if o != valid.PlanRequirementsKey && o != valid.ApplyRequirementsKey && o != valid.ImportRequirementsKey && o != valid.WorkflowKey && o != valid.DeleteSourceBranchOnMergeKey && o != valid.RepoLockingKey && o != valid.RepoLocksKey && o != valid.PolicyCheckKey && o != valid.CustomPolicyCheckKey && o != valid.SilencePRCommentsKey && o != valid.PreviewEnvironmentKey && o != valid.DestroyOnCloseKey && o != valid.CommentTemplatesKey {
+				return fmt.Errorf("%q is not a valid override, only %q, %q, %q, %q, %q, %q, %q, %q, %q, %q, %q, %q, and %q are supported", o, valid.PlanRequirementsKey, valid.ApplyRequirementsKey, valid.ImportRequirementsKey, valid.WorkflowKey, valid.DeleteSourceBranchOnMergeKey, valid.RepoLockingKey, valid.RepoLocksKey, valid.PolicyCheckKey, valid.CustomPolicyCheckKey, valid.SilencePRCommentsKey, valid.PreviewEnvironmentKey, valid.DestroyOnCloseKey, valid.CommentTemplatesKey)
 			}
 		}
 		return nil
@@ -257,6 +314,131 @@ func (r Repo) Validate() error {
 		return nil
 	}
 
+	driftDetectionValid := func(value interface{}) error {
+		driftDetection := value.(*DriftDetection)
+		if driftDetection == nil {
+			return nil
+		}
+		// We need to know exactly which repo to clone.
+		if r.HasRegexID() {
+			return errors.New("can only be set for repos with an exact id")
+		}
+		return driftDetection.Validate()
+	}
+
+	manualTriggerValid := func(value interface{}) error {
+		manualTrigger := value.(*ManualTrigger)
+		if manualTrigger == nil {
+			return nil
+		}
+		return manualTrigger.Validate()
+	}
+
+	disabledCommandsValid := func(value interface{}) error {
+		disabledCommands := value.([]string)
+		for _, c := range disabledCommands {
+			if !utils.SlicesContains(valid.DisableableCommands, c) {
+				return fmt.Errorf("%q is not a command that can be disabled, only %s are supported", c, strings.Join(valid.DisableableCommands, ", "))
+			}
+		}
+		return nil
+	}
+
+	stateRmAllowlistValid := func(value interface{}) error {
+		for _, pattern := range value.([]string) {
+			if strings.TrimSpace(pattern) == "" {
+				return errors.New("resource address patterns can't be empty")
+			}
+		}
+		return nil
+	}
+
+	preWorkflowHooksValid := func(value interface{}) error {
+		for _, hook := range value.([]WorkflowHook) {
+			if output := hook.StringVal["output"]; output != "" && !utils.SlicesContains(valid.WorkflowHookOutputs, output) {
+				return fmt.Errorf("%q is not a valid output, only %s are supported", output, strings.Join(valid.WorkflowHookOutputs, ", "))
+			}
+			if len(hook.on()) > 0 {
+				return errors.New("on is only supported for post_workflow_hooks")
+			}
+		}
+		return nil
+	}
+
+	postWorkflowHooksValid := func(value interface{}) error {
+		for _, hook := range value.([]WorkflowHook) {
+			if _, ok := hook.StringVal["output"]; ok {
+				return errors.New("output is only supported for pre_workflow_hooks")
+			}
+			for _, event := range hook.on() {
+				if !utils.SlicesContains(valid.WorkflowHookEvents, event) {
+					return fmt.Errorf("%q is not a valid on value, only %s are supported", event, strings.Join(valid.WorkflowHookEvents, ", "))
+				}
+			}
+		}
+		return nil
+	}
+
+	scheduledAppliesValid := func(value interface{}) error {
+		scheduledApplies := value.([]ScheduledApply)
+		if len(scheduledApplies) == 0 {
+			return nil
+		}
+		// We need to know exactly which repo to clone.
+		if r.HasRegexID() {
+			return errors.New("can only be set for repos with an exact id")
+		}
+		return validation.Validate(scheduledApplies)
+	}
+
+	gitCredentialsValid := func(value interface{}) error {
+		gitCredentials := value.(*GitCredentials)
+		if gitCredentials == nil {
+			return nil
+		}
+		return gitCredentials.Validate()
+	}
+
+	applyApproversValid := func(value interface{}) error {
+		applyApprovers := value.(*ApplyApprovers)
+		if applyApprovers == nil {
+			return nil
+		}
+		return applyApprovers.Validate()
+	}
+
+	gitlabCIValid := func(value interface{}) error {
+		gitlabCI := value.(*GitlabCI)
+		if gitlabCI == nil {
+			return nil
+		}
+		return gitlabCI.Validate()
+	}
+
+	stackedPullsValid := func(value interface{}) error {
+		stackedPulls := value.(*string)
+		if stackedPulls != nil && !utils.SlicesContains(valid.StackedPullsModes, *stackedPulls) {
+			return fmt.Errorf("%q is not a valid mode, only %s are supported", *stackedPulls, strings.Join(valid.StackedPullsModes, ", "))
+		}
+		return nil
+	}
+
+	commandTimeoutsValid := func(value interface{}) error {
+		commandTimeouts := value.(*CommandTimeouts)
+		if commandTimeouts == nil {
+			return nil
+		}
+		return commandTimeouts.Validate()
+	}
+
+	githubEnvironmentsValid := func(value interface{}) error {
+		githubEnvironments := value.([]GithubEnvironment)
+		if len(githubEnvironments) == 0 {
+			return nil
+		}
+		return validation.Validate(githubEnvironments)
+	}
+
 	return validation.ValidateStruct(&r,
 		validation.Field(&r.ID, validation.Required, validation.By(idValid)),
 		validation.Field(&r.Branch, validation.By(branchValid)),
@@ -269,6 +451,21 @@ func (r Repo) Validate() error {
 		validation.Field(&r.DeleteSourceBranchOnMerge, validation.By(deleteSourceBranchOnMergeValid)),
 		validation.Field(&r.AutoDiscover, validation.By(autoDiscoverValid)),
 		validation.Field(&r.RepoLocks, validation.By(repoLocksValid)),
+		validation.Field(&r.DriftDetection, validation.By(driftDetectionValid)),
+		validation.Field(&r.ScheduledApplies, validation.By(scheduledAppliesValid)),
+		validation.Field(&r.ManualTrigger, validation.By(manualTriggerValid)),
+		validation.Field(&r.DisabledCommands, validation.By(disabledCommandsValid)),
+		validation.Field(&r.GitlabCI, validation.By(gitlabCIValid)),
+		validation.Field(&r.GithubEnvironments, validation.By(githubEnvironmentsValid)),
+		validation.Field(&r.StackedPulls, validation.By(stackedPullsValid)),
+		validation.Field(&r.CommandTimeouts, validation.By(commandTimeoutsValid)),
+		validation.Field(&r.StateRmAllowlist, validation.By(stateRmAllowlistValid)),
+		validation.Field(&r.MaintenanceWindows),
+		validation.Field(&r.GitCredentials, validation.By(gitCredentialsValid)),
+		validation.Field(&r.ApplyApprovers, validation.By(applyApproversValid)),
+		validation.Field(&r.RepoCfgOverlay),
+		validation.Field(&r.PreWorkflowHooks, validation.By(preWorkflowHooksValid)),
+		validation.Field(&r.PostWorkflowHooks, validation.By(postWorkflowHooksValid)),
 	)
 }
 
@@ -290,11 +487,22 @@ func (r Repo) ToValid(workflows map[string]valid.Workflow, globalPlanReqs []stri
 		branchRegex = regexp.MustCompile(withoutSlashes)
 	}
 
+	var repoCfgOverlay *valid.RepoCfgOverlay
+	if r.RepoCfgOverlay != nil {
+		repoCfgOverlay = r.RepoCfgOverlay.ToValid()
+	}
+
 	var workflow *valid.Workflow
 	if r.Workflow != nil {
 		// This key is guaranteed to exist because we test for it in
-		// ParserValidator.validateRepoWorkflows.
+		// ParserValidator.validateRepoWorkflows. The workflows of the repo
+		// config take precedence over the global ones.
 		ptr := workflows[*r.Workflow]
+		if repoCfgOverlay != nil {
+			if overlayWorkflow, ok := repoCfgOverlay.Workflows[*r.Workflow]; ok {
+				ptr = overlayWorkflow
+			}
+		}
 		workflow = &ptr
 	}
 
@@ -373,6 +581,57 @@ OuterGlobalImportReqs:
 		repoLocks = r.RepoLocks.ToValid()
 	}
 
+	var driftDetection *valid.DriftDetection
+	if r.DriftDetection != nil {
+		driftDetection = r.DriftDetection.ToValid()
+	}
+
+	var manualTrigger *valid.ManualTrigger
+	if r.ManualTrigger != nil {
+		manualTrigger = r.ManualTrigger.ToValid()
+	}
+
+	var gitlabCI *valid.GitlabCI
+	if r.GitlabCI != nil {
+		gitlabCI = r.GitlabCI.ToValid()
+	}
+
+	var gitCredentials *valid.GitCredentials
+	if r.GitCredentials != nil {
+		gitCredentials = r.GitCredentials.ToValid()
+	}
+
+	var applyApprovers *valid.ApplyApprovers
+	if r.ApplyApprovers != nil {
+		applyApprovers = r.ApplyApprovers.ToValid()
+	}
+
+	var scheduledApplies []valid.ScheduledApply
+	for _, s := range r.ScheduledApplies {
+		scheduledApplies = append(scheduledApplies, s.ToValid())
+	}
+
+	var maintenanceWindows []valid.MaintenanceWindow
+	for _, m := range r.MaintenanceWindows {
+		maintenanceWindows = append(maintenanceWindows, m.ToValid())
+	}
+
+	var stackedPulls *valid.StackedPullsMode
+	if r.StackedPulls != nil {
+		mode := valid.StackedPullsMode(*r.StackedPulls)
+		stackedPulls = &mode
+	}
+
+	var commandTimeouts *valid.RepoCommandTimeouts
+	if r.CommandTimeouts != nil {
+		commandTimeouts = r.CommandTimeouts.ToValid()
+	}
+
+	var githubEnvironments []valid.GithubEnvironment
+	for _, e := range r.GithubEnvironments {
+		githubEnvironments = append(githubEnvironments, e.ToValid())
+	}
+
 	return valid.Repo{
 		ID:                        id,
 		IDRegex:                   idRegex,
@@ -394,5 +653,21 @@ OuterGlobalImportReqs:
 		CustomPolicyCheck:         r.CustomPolicyCheck,
 		AutoDiscover:              autoDiscover,
 		SilencePRComments:         r.SilencePRComments,
+		WebhookSecret:             r.WebhookSecret,
+		DriftDetection:            driftDetection,
+		ScheduledApplies:          scheduledApplies,
+		AutoApplyDestroyOnClose:   r.AutoApplyDestroyOnClose,
+		ShadowMode:                r.ShadowMode,
+		ManualTrigger:             manualTrigger,
+		DisabledCommands:          r.DisabledCommands,
+		GitlabCI:                  gitlabCI,
+		GithubEnvironments:        githubEnvironments,
+		StackedPulls:              stackedPulls,
+		CommandTimeouts:           commandTimeouts,
+		StateRmAllowlist:          r.StateRmAllowlist,
+		MaintenanceWindows:        maintenanceWindows,
+		GitCredentials:            gitCredentials,
+		ApplyApprovers:            applyApprovers,
+		RepoCfgOverlay:            repoCfgOverlay,
 	}
 }
@@ -0,0 +1,49 @@
+package raw
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// MaintenanceWindow is the raw schema for a recurring time window during which
+// applies of a repo are disabled.
+type MaintenanceWindow struct {
+	Schedule        string `yaml:"schedule" json:"schedule"`
+	DurationMinutes int    `yaml:"duration_minutes" json:"duration_minutes"`
+	Description     string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+func (m MaintenanceWindow) Validate() error {
+	scheduleValid := func(value interface{}) error {
+		_, err := valid.ParseCronSchedule(value.(string))
+		return err
+	}
+	durationValid := func(value interface{}) error {
+		minutes := value.(int)
+		if minutes <= 0 {
+			return errors.New("must be greater than 0")
+		}
+		if time.Duration(minutes)*time.Minute > valid.MaxMaintenanceWindowDuration {
+			return fmt.Errorf("must be at most %d", int(valid.MaxMaintenanceWindowDuration.Minutes()))
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Schedule, validation.Required, validation.By(scheduleValid)),
+		validation.Field(&m.DurationMinutes, validation.By(durationValid)),
+	)
+}
+
+func (m MaintenanceWindow) ToValid() valid.MaintenanceWindow {
+	// Safe to ignore the error because we test it in Validate().
+	schedule, _ := valid.ParseCronSchedule(m.Schedule)
+	return valid.MaintenanceWindow{
+		Schedule:    schedule,
+		Duration:    time.Duration(m.DurationMinutes) * time.Minute,
+		Description: m.Description,
+	}
+}
@@ -0,0 +1,75 @@
+package raw_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestMaintenanceWindow_UnmarshalYAML(t *testing.T) {
+	input := `
+schedule: 0 22 * * 5
+duration_minutes: 120
+description: Weekly release freeze.
+`
+	var m raw.MaintenanceWindow
+	Ok(t, unmarshalString(input, &m))
+	Equals(t, raw.MaintenanceWindow{
+		Schedule:        "0 22 * * 5",
+		DurationMinutes: 120,
+		Description:     "Weekly release freeze.",
+	}, m)
+}
+
+func TestMaintenanceWindow_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.MaintenanceWindow
+		errContains *string
+	}{
+		{
+			description: "valid",
+			input:       raw.MaintenanceWindow{Schedule: "0 22 * * 5", DurationMinutes: 120},
+		},
+		{
+			description: "no schedule",
+			input:       raw.MaintenanceWindow{DurationMinutes: 120},
+			errContains: String("schedule: cannot be blank"),
+		},
+		{
+			description: "invalid schedule",
+			input:       raw.MaintenanceWindow{Schedule: "0 25 * * *", DurationMinutes: 120},
+			errContains: String(`schedule: invalid value "25" in hour field`),
+		},
+		{
+			description: "no duration",
+			input:       raw.MaintenanceWindow{Schedule: "0 22 * * 5"},
+			errContains: String("duration_minutes: must be greater than 0"),
+		},
+		{
+			description: "duration too long",
+			input:       raw.MaintenanceWindow{Schedule: "0 22 * * 5", DurationMinutes: 7*24*60 + 1},
+			errContains: String("duration_minutes: must be at most 10080"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			err := c.input.Validate()
+			if c.errContains == nil {
+				Ok(t, err)
+			} else {
+				ErrContains(t, *c.errContains, err)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindow_ToValid(t *testing.T) {
+	m := raw.MaintenanceWindow{Schedule: "0 22 * * 5", DurationMinutes: 120, Description: "freeze"}
+	v := m.ToValid()
+	Equals(t, 2*time.Hour, v.Duration)
+	Equals(t, "freeze", v.Description)
+	Assert(t, v.Schedule.Matches(time.Date(2024, 1, 5, 22, 0, 0, 0, time.UTC)), "expected schedule to match")
+}
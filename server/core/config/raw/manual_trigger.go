@@ -0,0 +1,58 @@
+package raw
+
+import (
+	"errors"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// ManualTrigger is the raw schema for the projects of a repo that are only
+// planned and applied when one of its operators runs a command.
+type ManualTrigger struct {
+	Operators []string               `yaml:"operators" json:"operators"`
+	Projects  []ManualTriggerProject `yaml:"projects,omitempty" json:"projects,omitempty"`
+}
+
+// ManualTriggerProject is the raw schema for a project in manual trigger
+// mode.
+type ManualTriggerProject struct {
+	Name      string `yaml:"name,omitempty" json:"name,omitempty"`
+	Dir       string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	Workspace string `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+}
+
+func (m ManualTrigger) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Operators, validation.Required),
+		validation.Field(&m.Projects),
+	)
+}
+
+func (p ManualTriggerProject) Validate() error {
+	hasNameOrDir := func(value interface{}) error {
+		if p.Name == "" && p.Dir == "" {
+			return errors.New("name or dir must be set")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Name, validation.By(hasNameOrDir)),
+		validation.Field(&p.Dir, validation.By(projectDirValid)),
+	)
+}
+
+func (m ManualTrigger) ToValid() *valid.ManualTrigger {
+	v := valid.ManualTrigger{
+		Operators: m.Operators,
+	}
+	for _, p := range m.Projects {
+		v.Projects = append(v.Projects, valid.ManualTriggerProject{
+			Name:      p.Name,
+			Dir:       strings.TrimRight(p.Dir, "/"),
+			Workspace: p.Workspace,
+		})
+	}
+	return &v
+}
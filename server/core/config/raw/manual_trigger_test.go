@@ -0,0 +1,80 @@
+package raw_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestManualTrigger_UnmarshalYAML(t *testing.T) {
+	input := `
+operators: [alice, infra-admins]
+projects:
+- name: prod
+- dir: network/
+  workspace: blue
+`
+	var m raw.ManualTrigger
+	Ok(t, unmarshalString(input, &m))
+	Equals(t, raw.ManualTrigger{
+		Operators: []string{"alice", "infra-admins"},
+		Projects: []raw.ManualTriggerProject{
+			{Name: "prod"},
+			{Dir: "network/", Workspace: "blue"},
+		},
+	}, m)
+	Equals(t, &valid.ManualTrigger{
+		Operators: []string{"alice", "infra-admins"},
+		Projects: []valid.ManualTriggerProject{
+			{Name: "prod"},
+			{Dir: "network", Workspace: "blue"},
+		},
+	}, m.ToValid())
+}
+
+func TestManualTrigger_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.ManualTrigger
+		errContains *string
+	}{
+		{
+			description: "every project",
+			input:       raw.ManualTrigger{Operators: []string{"alice"}},
+		},
+		{
+			description: "no operators",
+			input: raw.ManualTrigger{
+				Projects: []raw.ManualTriggerProject{{Name: "prod"}},
+			},
+			errContains: String("operators: cannot be blank"),
+		},
+		{
+			description: "project without name or dir",
+			input: raw.ManualTrigger{
+				Operators: []string{"alice"},
+				Projects:  []raw.ManualTriggerProject{{Workspace: "blue"}},
+			},
+			errContains: String("name or dir must be set"),
+		},
+		{
+			description: "dir outside repo",
+			input: raw.ManualTrigger{
+				Operators: []string{"alice"},
+				Projects:  []raw.ManualTriggerProject{{Dir: "../other"}},
+			},
+			errContains: String("must not contain '..'"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if c.errContains == nil {
+				Ok(t, c.input.Validate())
+			} else {
+				ErrContains(t, *c.errContains, c.input.Validate())
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package raw
 
 import (
+	"errors"
+	"fmt"
+	"path"
+
 	validation "github.com/go-ozzo/ozzo-validation"
 	version "github.com/hashicorp/go-version"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -54,6 +58,7 @@ func (p PolicySets) ToValid() valid.PolicySets {
 type PolicyOwners struct {
 	Users []string `yaml:"users,omitempty" json:"users,omitempty"`
 	Teams []string `yaml:"teams,omitempty" json:"teams,omitempty"`
+	Orgs  []string `yaml:"orgs,omitempty" json:"orgs,omitempty"`
 }
 
 func (o PolicyOwners) ToValid() valid.PolicyOwners {
@@ -66,6 +71,10 @@ func (o PolicyOwners) ToValid() valid.PolicyOwners {
 	if len(o.Teams) > 0 {
 		policyOwners.Teams = o.Teams
 	}
+
+	if len(o.Orgs) > 0 {
+		policyOwners.Orgs = o.Orgs
+	}
 	return policyOwners
 }
 
@@ -76,6 +85,8 @@ type PolicySet struct {
 	Owners             PolicyOwners `yaml:"owners,omitempty" json:"owners,omitempty"`
 	ApproveCount       int          `yaml:"approve_count,omitempty" json:"approve_count,omitempty"`
 	PreventSelfApprove bool         `yaml:"prevent_self_approve,omitempty" json:"prevent_self_approve,omitempty"`
+	VerificationKey    string       `yaml:"verification_key,omitempty" json:"verification_key,omitempty"`
+	Projects           []string     `yaml:"projects,omitempty" json:"projects,omitempty"`
 }
 
 func (p PolicySet) Validate() error {
@@ -84,7 +95,14 @@ func (p PolicySet) Validate() error {
 		validation.Field(&p.Owners),
 		validation.Field(&p.ApproveCount),
 		validation.Field(&p.Path, validation.Required.Error("is required")),
-		validation.Field(&p.Source, validation.In(valid.LocalPolicySet, valid.GithubPolicySet).Error("only 'local' and 'github' source types are supported")),
+		validation.Field(&p.Source, validation.In(valid.LocalPolicySet, valid.GithubPolicySet, valid.BundlePolicySet).Error("only 'local', 'github' and 'bundle' source types are supported")),
+		validation.Field(&p.VerificationKey, validation.By(func(value interface{}) error {
+			if value.(string) != "" && p.Source != valid.BundlePolicySet {
+				return errors.New("is only supported by the 'bundle' source type")
+			}
+			return nil
+		})),
+		validation.Field(&p.Projects, validation.By(policySetProjectsValidator)),
 	)
 }
 
@@ -97,6 +115,18 @@ func (p PolicySet) ToValid() valid.PolicySet {
 	policySet.ApproveCount = p.ApproveCount
 	policySet.PreventSelfApprove = p.PreventSelfApprove
 	policySet.Owners = p.Owners.ToValid()
+	policySet.VerificationKey = p.VerificationKey
+	policySet.Projects = p.Projects
 
 	return policySet
 }
+
+func policySetProjectsValidator(value interface{}) error {
+	projects := value.([]string)
+	for _, project := range projects {
+		if _, err := path.Match(project, ""); err != nil {
+			return fmt.Errorf("%q is not a valid pattern: %w", project, err)
+		}
+	}
+	return nil
+}
@@ -94,7 +94,51 @@ func TestPolicySets_Validate(t *testing.T) {
 			expErr: "",
 		},
 
+		{
+			description: "signed bundle for some projects",
+			input: raw.PolicySets{
+				PolicySets: []raw.PolicySet{
+					{
+						Name:            "policy-name-1",
+						Path:            "https://example.com/bundle.tar.gz",
+						Source:          valid.BundlePolicySet,
+						VerificationKey: "/etc/atlantis/bundle.pem",
+						Projects:        []string{"network", "envs/*"},
+					},
+				},
+			},
+			expErr: "",
+		},
+
 		// Invalid inputs.
+		{
+			description: "verification key of a local policy set",
+			input: raw.PolicySets{
+				PolicySets: []raw.PolicySet{
+					{
+						Name:            "policy-name-1",
+						Path:            "rel/path/to/source",
+						Source:          valid.LocalPolicySet,
+						VerificationKey: "/etc/atlantis/bundle.pem",
+					},
+				},
+			},
+			expErr: "policy_sets: (0: (verification_key: is only supported by the 'bundle' source type.).).",
+		},
+		{
+			description: "invalid project pattern",
+			input: raw.PolicySets{
+				PolicySets: []raw.PolicySet{
+					{
+						Name:     "policy-name-1",
+						Path:     "rel/path/to/source",
+						Source:   valid.LocalPolicySet,
+						Projects: []string{"envs/["},
+					},
+				},
+			},
+			expErr: "policy_sets: (0: (projects: \"envs/[\" is not a valid pattern: syntax error in pattern.).).",
+		},
 		{
 			description: "empty elem",
 			input:       raw.PolicySets{},
@@ -121,7 +165,7 @@ func TestPolicySets_Validate(t *testing.T) {
 					},
 				},
 			},
-			expErr: "policy_sets: (0: (source: only 'local' and 'github' source types are supported.).).",
+			expErr: "policy_sets: (0: (source: only 'local', 'github' and 'bundle' source types are supported.).).",
 		},
 		{
 			description: "empty string version",
@@ -183,6 +227,9 @@ func TestPolicySets_ToValid(t *testing.T) {
 					Teams: []string{
 						"testteam",
 					},
+					Orgs: []string{
+						"testorg",
+					},
 				},
 				PolicySets: []raw.PolicySet{
 					{
@@ -204,6 +251,7 @@ func TestPolicySets_ToValid(t *testing.T) {
 				Owners: valid.PolicyOwners{
 					Users: []string{"test"},
 					Teams: []string{"testteam"},
+					Orgs:  []string{"testorg"},
 				},
 				PolicySets: []valid.PolicySet{
 					{
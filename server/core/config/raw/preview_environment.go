@@ -0,0 +1,53 @@
+package raw
+
+import (
+	"fmt"
+	"regexp"
+	"text/template"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// terraformVarNameRegex matches valid Terraform variable names.
+var terraformVarNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// PreviewEnvironment is the raw schema for a project that is planned and
+// applied in a workspace dedicated to each pull request.
+type PreviewEnvironment struct {
+	Workspace string            `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+	Vars      map[string]string `yaml:"vars,omitempty" json:"vars,omitempty"`
+}
+
+func (p PreviewEnvironment) Validate() error {
+	templateValid := func(value interface{}) error {
+		_, err := template.New("").Option("missingkey=error").Parse(value.(string))
+		return err
+	}
+	varsValid := func(value interface{}) error {
+		for name, tmpl := range value.(map[string]string) {
+			if !terraformVarNameRegex.MatchString(name) {
+				return fmt.Errorf("%q is not a valid variable name", name)
+			}
+			if err := templateValid(tmpl); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Workspace, validation.By(templateValid)),
+		validation.Field(&p.Vars, validation.By(varsValid)),
+	)
+}
+
+func (p PreviewEnvironment) ToValid() *valid.PreviewEnvironment {
+	workspace := p.Workspace
+	if workspace == "" {
+		workspace = valid.DefaultPreviewWorkspace
+	}
+	return &valid.PreviewEnvironment{
+		Workspace: workspace,
+		Vars:      p.Vars,
+	}
+}
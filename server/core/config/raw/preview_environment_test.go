@@ -0,0 +1,79 @@
+package raw_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestPreviewEnvironment_UnmarshalYAML(t *testing.T) {
+	input := `
+workspace: review-{{ .PullNum }}
+vars:
+  pr_number: "{{ .PullNum }}"
+`
+	var p raw.PreviewEnvironment
+	Ok(t, unmarshalString(input, &p))
+	Equals(t, raw.PreviewEnvironment{
+		Workspace: "review-{{ .PullNum }}",
+		Vars:      map[string]string{"pr_number": "{{ .PullNum }}"},
+	}, p)
+}
+
+func TestPreviewEnvironment_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.PreviewEnvironment
+		errContains *string
+	}{
+		{
+			description: "empty",
+			input:       raw.PreviewEnvironment{},
+		},
+		{
+			description: "valid",
+			input: raw.PreviewEnvironment{
+				Workspace: "pr-{{ .PullNum }}",
+				Vars:      map[string]string{"pr_number": "{{ .PullNum }}", "branch": "{{ .HeadBranch }}"},
+			},
+		},
+		{
+			description: "invalid workspace template",
+			input:       raw.PreviewEnvironment{Workspace: "pr-{{ .PullNum"},
+			errContains: String("template: :1: unclosed action"),
+		},
+		{
+			description: "invalid var template",
+			input:       raw.PreviewEnvironment{Vars: map[string]string{"pr_number": "{{ .PullNum"}},
+			errContains: String("pr_number: template: :1: unclosed action"),
+		},
+		{
+			description: "invalid var name",
+			input:       raw.PreviewEnvironment{Vars: map[string]string{"pr number": "{{ .PullNum }}"}},
+			errContains: String(`"pr number" is not a valid variable name`),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if c.errContains == nil {
+				Ok(t, c.input.Validate())
+			} else {
+				ErrContains(t, *c.errContains, c.input.Validate())
+			}
+		})
+	}
+}
+
+func TestPreviewEnvironment_ToValid(t *testing.T) {
+	Equals(t, &valid.PreviewEnvironment{
+		Workspace: valid.DefaultPreviewWorkspace,
+	}, raw.PreviewEnvironment{}.ToValid())
+
+	vars := map[string]string{"pr_number": "{{ .PullNum }}"}
+	Equals(t, &valid.PreviewEnvironment{
+		Workspace: "review-{{ .PullNum }}",
+		Vars:      vars,
+	}, raw.PreviewEnvironment{Workspace: "review-{{ .PullNum }}", Vars: vars}.ToValid())
+}
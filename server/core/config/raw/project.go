@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -21,25 +22,32 @@ const (
 )
 
 type Project struct {
-	Name                      *string    `yaml:"name,omitempty"`
-	Branch                    *string    `yaml:"branch,omitempty"`
-	Dir                       *string    `yaml:"dir,omitempty"`
-	Workspace                 *string    `yaml:"workspace,omitempty"`
-	Workflow                  *string    `yaml:"workflow,omitempty"`
-	TerraformDistribution     *string    `yaml:"terraform_distribution,omitempty"`
-	TerraformVersion          *string    `yaml:"terraform_version,omitempty"`
-	Autoplan                  *Autoplan  `yaml:"autoplan,omitempty"`
-	PlanRequirements          []string   `yaml:"plan_requirements,omitempty"`
-	ApplyRequirements         []string   `yaml:"apply_requirements,omitempty"`
-	ImportRequirements        []string   `yaml:"import_requirements,omitempty"`
-	DependsOn                 []string   `yaml:"depends_on,omitempty"`
-	DeleteSourceBranchOnMerge *bool      `yaml:"delete_source_branch_on_merge,omitempty"`
-	RepoLocking               *bool      `yaml:"repo_locking,omitempty"`
-	RepoLocks                 *RepoLocks `yaml:"repo_locks,omitempty"`
-	ExecutionOrderGroup       *int       `yaml:"execution_order_group,omitempty"`
-	PolicyCheck               *bool      `yaml:"policy_check,omitempty"`
-	CustomPolicyCheck         *bool      `yaml:"custom_policy_check,omitempty"`
-	SilencePRComments         []string   `yaml:"silence_pr_comments,omitempty"`
+	Name                      *string             `yaml:"name,omitempty" json:"name,omitempty"`
+	Branch                    *string             `yaml:"branch,omitempty" json:"branch,omitempty"`
+	Dir                       *string             `yaml:"dir,omitempty" json:"dir,omitempty"`
+	Workspace                 *string             `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+	Workflow                  *string             `yaml:"workflow,omitempty" json:"workflow,omitempty"`
+	TerraformDistribution     *string             `yaml:"terraform_distribution,omitempty" json:"terraform_distribution,omitempty"`
+	TerraformVersion          *string             `yaml:"terraform_version,omitempty" json:"terraform_version,omitempty"`
+	Autoplan                  *Autoplan           `yaml:"autoplan,omitempty" json:"autoplan,omitempty"`
+	PlanRequirements          []string            `yaml:"plan_requirements,omitempty" json:"plan_requirements,omitempty"`
+	ApplyRequirements         []string            `yaml:"apply_requirements,omitempty" json:"apply_requirements,omitempty"`
+	ImportRequirements        []string            `yaml:"import_requirements,omitempty" json:"import_requirements,omitempty"`
+	DependsOn                 []string            `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
+	DeleteSourceBranchOnMerge *bool               `yaml:"delete_source_branch_on_merge,omitempty" json:"delete_source_branch_on_merge,omitempty"`
+	RepoLocking               *bool               `yaml:"repo_locking,omitempty" json:"repo_locking,omitempty"`
+	RepoLocks                 *RepoLocks          `yaml:"repo_locks,omitempty" json:"repo_locks,omitempty"`
+	ExecutionOrderGroup       *int                `yaml:"execution_order_group,omitempty" json:"execution_order_group,omitempty"`
+	PolicyCheck               *bool               `yaml:"policy_check,omitempty" json:"policy_check,omitempty"`
+	CustomPolicyCheck         *bool               `yaml:"custom_policy_check,omitempty" json:"custom_policy_check,omitempty"`
+	SilencePRComments         []string            `yaml:"silence_pr_comments,omitempty" json:"silence_pr_comments,omitempty"`
+	PreviewEnvironment        *PreviewEnvironment `yaml:"preview_environment,omitempty" json:"preview_environment,omitempty"`
+	DestroyOnClose            *bool               `yaml:"destroy_on_close,omitempty" json:"destroy_on_close,omitempty"`
+	PreWorkflowHooks          []WorkflowHook      `yaml:"pre_workflow_hooks,omitempty" json:"pre_workflow_hooks,omitempty"`
+	PostWorkflowHooks         []WorkflowHook      `yaml:"post_workflow_hooks,omitempty" json:"post_workflow_hooks,omitempty"`
+	CommentArgs               *CommentArgs        `yaml:"comment_args,omitempty" json:"comment_args,omitempty"`
+	SuppressedChanges         []SuppressedChange  `yaml:"suppressed_changes,omitempty" json:"suppressed_changes,omitempty"`
+	Workspaces                []string            `yaml:"workspaces,omitempty" json:"workspaces,omitempty"`
 }
 
 func (p Project) Validate() error {
@@ -85,6 +93,60 @@ func (p Project) Validate() error {
 		return nil
 	}
 
+	previewEnvironmentValid := func(value interface{}) error {
+		previewEnvironment := value.(*PreviewEnvironment)
+		if previewEnvironment == nil {
+			return nil
+		}
+		// The workspace is named after the pull request.
+		if p.Workspace != nil {
+			return errors.New("cannot be set with workspace")
+		}
+		return previewEnvironment.Validate()
+	}
+
+	destroyOnCloseValid := func(value interface{}) error {
+		destroyOnClose := value.(*bool)
+		if destroyOnClose == nil || !*destroyOnClose {
+			return nil
+		}
+		// Destroying the default workspace would destroy what the
+		// project's main branch applied.
+		if p.Workspace == nil || *p.Workspace == DefaultWorkspace {
+			return errors.New("cannot be set for the default workspace")
+		}
+		return nil
+	}
+
+	workspacesValid := func(value interface{}) error {
+		workspaces := value.([]string)
+		if len(workspaces) == 0 {
+			return nil
+		}
+		// The project is expanded into one project per workspace.
+		if p.Workspace != nil {
+			return errors.New("cannot be set with workspace")
+		}
+		if p.Name != nil {
+			return errors.New("cannot be set with name")
+		}
+		if p.PreviewEnvironment != nil {
+			return errors.New("cannot be set with preview_environment")
+		}
+		for _, workspace := range workspaces {
+			if workspace == "" {
+				return errors.New("cannot contain empty workspaces")
+			}
+			if strings.Contains(workspace, "/") {
+				return fmt.Errorf("%q cannot contain '/'", workspace)
+			}
+			if _, err := path.Match(workspace, ""); err != nil {
+				return fmt.Errorf("%q is not a valid glob: %w", workspace, err)
+			}
+		}
+		return nil
+	}
+
 	return validation.ValidateStruct(&p,
 		validation.Field(&p.Dir, validation.Required, validation.By(hasDotDot)),
 		validation.Field(&p.PlanRequirements, validation.By(validPlanReq)),
@@ -95,6 +157,13 @@ func (p Project) Validate() error {
 		validation.Field(&p.DependsOn, validation.By(DependsOn)),
 		validation.Field(&p.Name, validation.By(validName)),
 		validation.Field(&p.Branch, validation.By(branchValid)),
+		validation.Field(&p.PreviewEnvironment, validation.By(previewEnvironmentValid)),
+		validation.Field(&p.DestroyOnClose, validation.By(destroyOnCloseValid)),
+		validation.Field(&p.PreWorkflowHooks, validation.By(projectWorkflowHooksValid)),
+		validation.Field(&p.PostWorkflowHooks, validation.By(projectWorkflowHooksValid)),
+		validation.Field(&p.CommentArgs),
+		validation.Field(&p.SuppressedChanges),
+		validation.Field(&p.Workspaces, validation.By(workspacesValid)),
 	)
 }
 
@@ -169,9 +238,49 @@ func (p Project) ToValid() valid.Project {
 		v.SilencePRComments = p.SilencePRComments
 	}
 
+	if p.PreviewEnvironment != nil {
+		v.PreviewEnvironment = p.PreviewEnvironment.ToValid()
+	}
+
+	if p.DestroyOnClose != nil {
+		v.DestroyOnClose = p.DestroyOnClose
+	}
+
+	for _, hook := range p.PreWorkflowHooks {
+		v.PreWorkflowHooks = append(v.PreWorkflowHooks, hook.ToValid())
+	}
+	for _, hook := range p.PostWorkflowHooks {
+		v.PostWorkflowHooks = append(v.PostWorkflowHooks, hook.ToValid())
+	}
+
+	if p.CommentArgs != nil {
+		v.CommentArgs = p.CommentArgs.ToValid()
+	}
+
+	for _, s := range p.SuppressedChanges {
+		v.SuppressedChanges = append(v.SuppressedChanges, s.ToValid())
+	}
+
+	v.Workspaces = p.Workspaces
+
 	return v
 }
 
+// projectWorkflowHooksValid validates the workflow hooks of a project. They
+// run with the project's steps so their output isn't posted on its own and
+// they can't run on lifecycle events.
+func projectWorkflowHooksValid(value interface{}) error {
+	for _, hook := range value.([]WorkflowHook) {
+		if _, ok := hook.StringVal["output"]; ok {
+			return errors.New("output is not supported for project workflow hooks")
+		}
+		if _, ok := hook.StringVal["on"]; ok || len(hook.On) > 0 {
+			return errors.New("on is not supported for project workflow hooks")
+		}
+	}
+	return nil
+}
+
 // validProjectName returns true if the project name is valid.
 // Since the name might be used in URLs and definitely in files we don't
 // support any characters that must be url escaped *except* for '/' because
@@ -315,6 +315,98 @@ func TestProject_Validate(t *testing.T) {
 			},
 			expErr: `name: "namewith\\" is not allowed: must contain only URL safe characters.`,
 		},
+		{
+			description: "preview environment",
+			input: raw.Project{
+				Dir:                String("."),
+				PreviewEnvironment: &raw.PreviewEnvironment{Vars: map[string]string{"pr_number": "{{ .PullNum }}"}},
+			},
+			expErr: "",
+		},
+		{
+			description: "preview environment with workspace",
+			input: raw.Project{
+				Dir:                String("."),
+				Workspace:          String("staging"),
+				PreviewEnvironment: &raw.PreviewEnvironment{},
+			},
+			expErr: "preview_environment: cannot be set with workspace.",
+		},
+		{
+			description: "workspaces",
+			input: raw.Project{
+				Dir:        String("."),
+				Workspaces: []string{"staging", "prod-*"},
+			},
+			expErr: "",
+		},
+		{
+			description: "workspaces with workspace",
+			input: raw.Project{
+				Dir:        String("."),
+				Workspace:  String("staging"),
+				Workspaces: []string{"prod-*"},
+			},
+			expErr: "workspaces: cannot be set with workspace.",
+		},
+		{
+			description: "workspaces with invalid glob",
+			input: raw.Project{
+				Dir:        String("."),
+				Workspaces: []string{"prod-["},
+			},
+			expErr: "workspaces: \"prod-[\" is not a valid glob: syntax error in pattern.",
+		},
+		{
+			description: "destroy on close",
+			input: raw.Project{
+				Dir:            String("."),
+				Workspace:      String("ephemeral"),
+				DestroyOnClose: Bool(true),
+			},
+			expErr: "",
+		},
+		{
+			description: "destroy on close for default workspace",
+			input: raw.Project{
+				Dir:            String("."),
+				DestroyOnClose: Bool(true),
+			},
+			expErr: "destroy_on_close: cannot be set for the default workspace.",
+		},
+		{
+			description: "workflow hooks",
+			input: raw.Project{
+				Dir:               String("."),
+				PreWorkflowHooks:  []raw.WorkflowHook{{StringVal: map[string]string{"run": "./setup.sh", "commands": "plan"}}},
+				PostWorkflowHooks: []raw.WorkflowHook{{StringVal: map[string]string{"run": "./teardown.sh"}}},
+			},
+			expErr: "",
+		},
+		{
+			description: "workflow hook with output",
+			input: raw.Project{
+				Dir:              String("."),
+				PreWorkflowHooks: []raw.WorkflowHook{{StringVal: map[string]string{"run": "./setup.sh", "output": "comment"}}},
+			},
+			expErr: "pre_workflow_hooks: output is not supported for project workflow hooks.",
+		},
+		{
+			description: "workflow hook with on",
+			input: raw.Project{
+				Dir:               String("."),
+				PostWorkflowHooks: []raw.WorkflowHook{{StringVal: map[string]string{"run": "./teardown.sh"}, On: []string{"apply_success"}}},
+			},
+			expErr: "post_workflow_hooks: on is not supported for project workflow hooks.",
+		},
+		{
+			description: "invalid workflow hook",
+			input: raw.Project{
+				Dir:              String("."),
+				PreWorkflowHooks: []raw.WorkflowHook{{StringVal: map[string]string{"init": "./setup.sh"}}},
+			},
+			expErr: "pre_workflow_hooks: (0: \"init\" is not a valid step type.).",
+		},
 	}
 	validation.ErrorTag = "yaml"
 	for _, c := range cases {
@@ -523,6 +615,28 @@ func TestProject_ToValid(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "workflow hooks",
+			input: raw.Project{
+				Dir:               String("."),
+				PreWorkflowHooks:  []raw.WorkflowHook{{StringVal: map[string]string{"run": "./setup.sh", "commands": "plan,apply"}}},
+				PostWorkflowHooks: []raw.WorkflowHook{{StringVal: map[string]string{"run": "./teardown.sh", "description": "teardown"}}},
+			},
+			exp: valid.Project{
+				Dir:       ".",
+				Workspace: "default",
+				Autoplan: valid.Autoplan{
+					WhenModified: raw.DefaultAutoPlanWhenModified,
+					Enabled:      true,
+				},
+				PreWorkflowHooks: []*valid.WorkflowHook{
+					{StepName: "run", RunCommand: "./setup.sh", Commands: "plan,apply"},
+				},
+				PostWorkflowHooks: []*valid.WorkflowHook{
+					{StepName: "run", RunCommand: "./teardown.sh", StepDescription: "teardown"},
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
@@ -0,0 +1,70 @@
+package raw
+
+import (
+	"errors"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// Promotion is the raw schema for a pipeline that promotes changes through
+// the projects of a repo, ex. the dev, staging and prod projects of an app.
+type Promotion struct {
+	Name   string   `yaml:"name" json:"name"`
+	Stages []string `yaml:"stages" json:"stages"`
+}
+
+func (p Promotion) Validate() error {
+	stagesValid := func(value interface{}) error {
+		stages := value.([]string)
+		if len(stages) < 2 {
+			return errors.New("must have at least 2 stages")
+		}
+		seen := make(map[string]bool)
+		for _, stage := range stages {
+			if seen[stage] {
+				return fmt.Errorf("project %q is a stage more than once", stage)
+			}
+			seen[stage] = true
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Name, validation.Required),
+		validation.Field(&p.Stages, validation.By(stagesValid)),
+	)
+}
+
+func (p Promotion) ToValid() valid.Promotion {
+	return valid.Promotion{
+		Name:   p.Name,
+		Stages: p.Stages,
+	}
+}
+
+// promotionsValid validates that the stages of promotions are the names of
+// projects and that each project is in a single promotion.
+func promotionsValid(projects []Project) validation.RuleFunc {
+	return func(value interface{}) error {
+		names := make(map[string]bool)
+		for _, project := range projects {
+			if project.Name != nil {
+				names[*project.Name] = true
+			}
+		}
+		promotionOf := make(map[string]string)
+		for _, promotion := range value.([]Promotion) {
+			for _, stage := range promotion.Stages {
+				if !names[stage] {
+					return fmt.Errorf("promotion %q: stage %q is not the name of a project", promotion.Name, stage)
+				}
+				if other, ok := promotionOf[stage]; ok && other != promotion.Name {
+					return fmt.Errorf("project %q is in promotions %q and %q, projects can only be in one promotion", stage, other, promotion.Name)
+				}
+				promotionOf[stage] = promotion.Name
+			}
+		}
+		return nil
+	}
+}
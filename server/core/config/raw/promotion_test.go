@@ -0,0 +1,79 @@
+package raw_test
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestPromotion_UnmarshalYAML(t *testing.T) {
+	input := `
+name: app
+stages: [app-dev, app-staging, app-prod]
+`
+	var p raw.Promotion
+	Ok(t, unmarshalString(input, &p))
+	Equals(t, raw.Promotion{Name: "app", Stages: []string{"app-dev", "app-staging", "app-prod"}}, p)
+	Equals(t, valid.Promotion{Name: "app", Stages: []string{"app-dev", "app-staging", "app-prod"}}, p.ToValid())
+}
+
+func TestPromotion_Validate(t *testing.T) {
+	projects := []raw.Project{
+		{Dir: String("dev"), Name: String("app-dev")},
+		{Dir: String("staging"), Name: String("app-staging")},
+		{Dir: String("prod"), Name: String("app-prod")},
+		{Dir: String("db")},
+	}
+	cases := []struct {
+		description string
+		promotions  []raw.Promotion
+		errContains *string
+	}{
+		{
+			description: "valid",
+			promotions:  []raw.Promotion{{Name: "app", Stages: []string{"app-dev", "app-staging", "app-prod"}}},
+		},
+		{
+			description: "no name",
+			promotions:  []raw.Promotion{{Stages: []string{"app-dev", "app-prod"}}},
+			errContains: String("name: cannot be blank"),
+		},
+		{
+			description: "single stage",
+			promotions:  []raw.Promotion{{Name: "app", Stages: []string{"app-dev"}}},
+			errContains: String("stages: must have at least 2 stages"),
+		},
+		{
+			description: "duplicate stage",
+			promotions:  []raw.Promotion{{Name: "app", Stages: []string{"app-dev", "app-prod", "app-dev"}}},
+			errContains: String(`project "app-dev" is a stage more than once`),
+		},
+		{
+			description: "unknown project",
+			promotions:  []raw.Promotion{{Name: "app", Stages: []string{"app-dev", "app-qa"}}},
+			errContains: String(`promotion "app": stage "app-qa" is not the name of a project`),
+		},
+		{
+			description: "project in two promotions",
+			promotions: []raw.Promotion{
+				{Name: "app", Stages: []string{"app-dev", "app-prod"}},
+				{Name: "hotfix", Stages: []string{"app-staging", "app-prod"}},
+			},
+			errContains: String(`project "app-prod" is in promotions "app" and "hotfix"`),
+		},
+	}
+	validation.ErrorTag = "yaml"
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			cfg := raw.RepoCfg{Version: Int(3), Projects: projects, Promotions: c.promotions}
+			if c.errContains == nil {
+				Ok(t, cfg.Validate())
+			} else {
+				ErrContains(t, *c.errContains, cfg.Validate())
+			}
+		})
+	}
+}
@@ -13,6 +13,10 @@ const DefaultEmojiReaction = ""
 // DefaultAbortOnExecutionOrderFail being false is the default setting for abort on execution group failures
 const DefaultAbortOnExecutionOrderFail = false
 
+// DefaultParallelApplyGroups being false is the default setting for applying
+// independent execution order groups in parallel
+const DefaultParallelApplyGroups = false
+
 // RepoCfg is the raw schema for repo-level atlantis.yaml config.
 type RepoCfg struct {
 	Version                   *int                `yaml:"version,omitempty"`
@@ -22,6 +26,7 @@ type RepoCfg struct {
 	AutoDiscover              *AutoDiscover       `yaml:"autodiscover,omitempty"`
 	Automerge                 *bool               `yaml:"automerge,omitempty"`
 	ParallelApply             *bool               `yaml:"parallel_apply,omitempty"`
+	ParallelApplyGroups       *bool               `yaml:"parallel_apply_groups,omitempty"`
 	ParallelPlan              *bool               `yaml:"parallel_plan,omitempty"`
 	DeleteSourceBranchOnMerge *bool               `yaml:"delete_source_branch_on_merge,omitempty"`
 	EmojiReaction             *string             `yaml:"emoji_reaction,omitempty"`
@@ -29,6 +34,9 @@ type RepoCfg struct {
 	AbortOnExecutionOrderFail *bool               `yaml:"abort_on_execution_order_fail,omitempty"`
 	RepoLocks                 *RepoLocks          `yaml:"repo_locks,omitempty"`
 	SilencePRComments         []string            `yaml:"silence_pr_comments,omitempty"`
+	Promotions                []Promotion         `yaml:"promotions,omitempty"`
+	SuppressedChanges         []SuppressedChange  `yaml:"suppressed_changes,omitempty"`
+	CommentTemplates          *CommentTemplates   `yaml:"comment_templates,omitempty"`
 }
 
 func (r RepoCfg) Validate() error {
@@ -46,6 +54,9 @@ func (r RepoCfg) Validate() error {
 		validation.Field(&r.Version, validation.By(equals2)),
 		validation.Field(&r.Projects),
 		validation.Field(&r.Workflows),
+		validation.Field(&r.SuppressedChanges),
+		validation.Field(&r.CommentTemplates),
+		validation.Field(&r.Promotions, validation.By(promotionsValid(r.Projects))),
 	)
 }
 
@@ -74,15 +85,35 @@ func (r RepoCfg) ToValid() valid.RepoCfg {
 		abortOnExecutionOrderFail = *r.AbortOnExecutionOrderFail
 	}
 
+	parallelApplyGroups := DefaultParallelApplyGroups
+	if r.ParallelApplyGroups != nil {
+		parallelApplyGroups = *r.ParallelApplyGroups
+	}
+
 	var autoDiscover *valid.AutoDiscover
 	if r.AutoDiscover != nil {
 		autoDiscover = r.AutoDiscover.ToValid()
 	}
 
+	var promotions []valid.Promotion
+	for _, p := range r.Promotions {
+		promotions = append(promotions, p.ToValid())
+	}
+
 	var repoLocks *valid.RepoLocks
 	if r.RepoLocks != nil {
 		repoLocks = r.RepoLocks.ToValid()
 	}
+
+	var suppressedChanges []valid.SuppressedChange
+	for _, s := range r.SuppressedChanges {
+		suppressedChanges = append(suppressedChanges, s.ToValid())
+	}
+
+	var commentTemplates *valid.CommentTemplates
+	if r.CommentTemplates != nil {
+		commentTemplates = r.CommentTemplates.ToValid()
+	}
 	return valid.RepoCfg{
 		Version:                   *r.Version,
 		Projects:                  validProjects,
@@ -90,6 +121,7 @@ func (r RepoCfg) ToValid() valid.RepoCfg {
 		AutoDiscover:              autoDiscover,
 		Automerge:                 automerge,
 		ParallelApply:             parallelApply,
+		ParallelApplyGroups:       parallelApplyGroups,
 		ParallelPlan:              parallelPlan,
 		ParallelPolicyCheck:       parallelPlan,
 		DeleteSourceBranchOnMerge: r.DeleteSourceBranchOnMerge,
@@ -98,5 +130,8 @@ func (r RepoCfg) ToValid() valid.RepoCfg {
 		AbortOnExecutionOrderFail: abortOnExecutionOrderFail,
 		RepoLocks:                 repoLocks,
 		SilencePRComments:         r.SilencePRComments,
+		Promotions:                promotions,
+		SuppressedChanges:         suppressedChanges,
+		CommentTemplates:          commentTemplates,
 	}
 }
@@ -0,0 +1,44 @@
+package raw
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// RepoCfgOverlay is the raw schema for the repo config that the server-side
+// repo config adds to the repo config of the repos it matches.
+type RepoCfgOverlay struct {
+	Projects  []Project           `yaml:"projects,omitempty" json:"projects,omitempty"`
+	Workflows map[string]Workflow `yaml:"workflows,omitempty" json:"workflows,omitempty"`
+}
+
+func (o RepoCfgOverlay) Validate() error {
+	return validation.ValidateStruct(&o,
+		validation.Field(&o.Projects),
+		validation.Field(&o.Workflows),
+	)
+}
+
+func (o RepoCfgOverlay) ToValid() *valid.RepoCfgOverlay {
+	v := valid.RepoCfgOverlay{
+		Workflows: make(map[string]valid.Workflow),
+	}
+	for name, w := range o.Workflows {
+		v.Workflows[name] = w.ToValid(name)
+	}
+	for _, p := range o.Projects {
+		project := p.ToValid()
+		project.ServerSide = true
+		v.Projects = append(v.Projects, project)
+	}
+	return &v
+}
+
+// workflowDefined returns true if the overlay defines the workflow with name.
+func (o *RepoCfgOverlay) workflowDefined(name string) bool {
+	if o == nil {
+		return false
+	}
+	_, ok := o.Workflows[name]
+	return ok
+}
@@ -0,0 +1,43 @@
+package raw_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestRepoCfgOverlay_UnmarshalYAML(t *testing.T) {
+	input := `
+workflows:
+  platform:
+    plan:
+      steps: [init, plan]
+projects:
+- name: network
+  dir: network
+  workflow: platform
+  terraform_version: v1.9.0
+`
+	var o raw.RepoCfgOverlay
+	Ok(t, unmarshalString(input, &o))
+	Ok(t, o.Validate())
+	v := o.ToValid()
+	Equals(t, 1, len(v.Projects))
+	Equals(t, "network", *v.Projects[0].Name)
+	Equals(t, "platform", *v.Projects[0].WorkflowName)
+	Assert(t, v.Projects[0].ServerSide, "exp project to be server-side")
+	Equals(t, "platform", v.Workflows["platform"].Name)
+}
+
+func TestRepoCfgOverlay_UnmarshalJSON(t *testing.T) {
+	var o raw.RepoCfgOverlay
+	Ok(t, json.Unmarshal([]byte(`{"projects": [{"dir": "network", "terraform_version": "v1.9.0"}]}`), &o))
+	Equals(t, "v1.9.0", *o.Projects[0].TerraformVersion)
+}
+
+func TestRepoCfgOverlay_Validate(t *testing.T) {
+	o := raw.RepoCfgOverlay{Projects: []raw.Project{{}}}
+	ErrContains(t, "dir: cannot be blank", o.Validate())
+}
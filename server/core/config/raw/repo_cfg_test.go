@@ -325,6 +325,18 @@ func TestConfig_ToValid(t *testing.T) {
 				Workflows:                 map[string]valid.Workflow{},
 			},
 		},
+		{
+			description: "parallel_apply_groups true",
+			input: raw.RepoCfg{
+				Version:             Int(2),
+				ParallelApplyGroups: Bool(true),
+			},
+			exp: valid.RepoCfg{
+				Version:             2,
+				ParallelApplyGroups: true,
+				Workflows:           map[string]valid.Workflow{},
+			},
+		},
 		{
 			description: "autodiscover omitted",
 			input: raw.RepoCfg{
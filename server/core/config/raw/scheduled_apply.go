@@ -0,0 +1,67 @@
+package raw
+
+import (
+	"errors"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// ScheduledApply is the raw schema for a schedule on which projects in a repo
+// are re-planned and applied.
+type ScheduledApply struct {
+	Schedule string                  `yaml:"schedule" json:"schedule"`
+	Branch   string                  `yaml:"branch,omitempty" json:"branch,omitempty"`
+	Projects []ScheduledApplyProject `yaml:"projects" json:"projects"`
+}
+
+// ScheduledApplyProject is the raw schema for a project that is applied on a
+// schedule.
+type ScheduledApplyProject struct {
+	Name      string `yaml:"name,omitempty" json:"name,omitempty"`
+	Dir       string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	Workspace string `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+}
+
+func (s ScheduledApply) Validate() error {
+	scheduleValid := func(value interface{}) error {
+		_, err := valid.ParseCronSchedule(value.(string))
+		return err
+	}
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Schedule, validation.Required, validation.By(scheduleValid)),
+		validation.Field(&s.Branch, validation.By(branchNameValid)),
+		validation.Field(&s.Projects, validation.Required),
+	)
+}
+
+func (p ScheduledApplyProject) Validate() error {
+	hasNameOrDir := func(value interface{}) error {
+		if p.Name == "" && p.Dir == "" {
+			return errors.New("name or dir must be set")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Name, validation.By(hasNameOrDir)),
+		validation.Field(&p.Dir, validation.By(projectDirValid)),
+	)
+}
+
+func (s ScheduledApply) ToValid() valid.ScheduledApply {
+	// Safe to ignore the error because we test it in Validate().
+	schedule, _ := valid.ParseCronSchedule(s.Schedule)
+	v := valid.ScheduledApply{
+		Schedule: schedule,
+		Branch:   s.Branch,
+	}
+	for _, p := range s.Projects {
+		v.Projects = append(v.Projects, valid.ScheduledApplyProject{
+			Name:      p.Name,
+			Dir:       strings.TrimRight(p.Dir, "/"),
+			Workspace: p.Workspace,
+		})
+	}
+	return v
+}
@@ -0,0 +1,121 @@
+package raw_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestScheduledApply_UnmarshalYAML(t *testing.T) {
+	input := `
+schedule: 0 3 * * *
+branch: main
+projects:
+- name: certs
+- dir: rotation
+  workspace: prod
+`
+	var s raw.ScheduledApply
+	Ok(t, unmarshalString(input, &s))
+	Equals(t, raw.ScheduledApply{
+		Schedule: "0 3 * * *",
+		Branch:   "main",
+		Projects: []raw.ScheduledApplyProject{
+			{Name: "certs"},
+			{Dir: "rotation", Workspace: "prod"},
+		},
+	}, s)
+}
+
+func TestScheduledApply_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.ScheduledApply
+		errContains *string
+	}{
+		{
+			description: "valid",
+			input: raw.ScheduledApply{
+				Schedule: "@daily",
+				Projects: []raw.ScheduledApplyProject{{Name: "certs"}, {Dir: "rotation"}},
+			},
+		},
+		{
+			description: "no schedule",
+			input: raw.ScheduledApply{
+				Projects: []raw.ScheduledApplyProject{{Name: "certs"}},
+			},
+			errContains: String("schedule: cannot be blank"),
+		},
+		{
+			description: "invalid schedule",
+			input: raw.ScheduledApply{
+				Schedule: "0 25 * * *",
+				Projects: []raw.ScheduledApplyProject{{Name: "certs"}},
+			},
+			errContains: String(`schedule: invalid value "25" in hour field`),
+		},
+		{
+			description: "no projects",
+			input:       raw.ScheduledApply{Schedule: "@daily"},
+			errContains: String("projects: cannot be blank"),
+		},
+		{
+			description: "project without name or dir",
+			input: raw.ScheduledApply{
+				Schedule: "@daily",
+				Projects: []raw.ScheduledApplyProject{{Workspace: "prod"}},
+			},
+			errContains: String("name or dir must be set"),
+		},
+		{
+			description: "absolute dir",
+			input: raw.ScheduledApply{
+				Schedule: "@daily",
+				Projects: []raw.ScheduledApplyProject{{Dir: "/rotation"}},
+			},
+			errContains: String("must be relative to the repo root"),
+		},
+		{
+			description: "branch regex",
+			input: raw.ScheduledApply{
+				Schedule: "@daily",
+				Branch:   "/main/",
+				Projects: []raw.ScheduledApplyProject{{Name: "certs"}},
+			},
+			errContains: String("must be a branch name, not a regex"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if c.errContains == nil {
+				Ok(t, c.input.Validate())
+			} else {
+				ErrContains(t, *c.errContains, c.input.Validate())
+			}
+		})
+	}
+}
+
+func TestScheduledApply_ToValid(t *testing.T) {
+	input := raw.ScheduledApply{
+		Schedule: "0 3 * * *",
+		Branch:   "main",
+		Projects: []raw.ScheduledApplyProject{
+			{Name: "certs"},
+			{Dir: "rotation/", Workspace: "prod"},
+		},
+	}
+	schedule, err := valid.ParseCronSchedule("0 3 * * *")
+	Ok(t, err)
+	Equals(t, valid.ScheduledApply{
+		Schedule: schedule,
+		Branch:   "main",
+		Projects: []valid.ScheduledApplyProject{
+			{Name: "certs"},
+			{Dir: "rotation", Workspace: "prod"},
+		},
+	}, input.ToValid())
+}
@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/expr"
 	"github.com/runatlantis/atlantis/server/utils"
 )
 
@@ -18,6 +21,9 @@ const (
 	CommandArgKey       = "command"
 	ValueArgKey         = "value"
 	OutputArgKey        = "output"
+	WhenArgKey          = "when"
+	TimeoutArgKey       = "timeout_seconds"
+	RetryArgKey         = "retry"
 	RunStepName         = "run"
 	PlanStepName        = "plan"
 	ShowStepName        = "show"
@@ -28,8 +34,27 @@ const (
 	MultiEnvStepName    = "multienv"
 	ImportStepName      = "import"
 	StateRmStepName     = "state_rm"
+	PluginStepName      = "plugin"
 	ShellArgKey         = "shell"
 	ShellArgsArgKey     = "shellArgs"
+
+	// Helper steps for common workflow boilerplate.
+	AssumeRoleStepName       = "assume_role"
+	WorkspaceSelectStepName  = "workspace_select"
+	VarFileStepName          = "var_file"
+	FormatCheckStepName      = "format_check"
+	TerragruntStepName       = "terragrunt"
+	RunAllArgKey             = "run_all"
+	CostEstimateStepName     = "cost_estimate"
+	MaxMonthlyIncreaseArgKey = "max_monthly_increase"
+	MaxPercentIncreaseArgKey = "max_percent_increase"
+	ValueFromArgKey          = "valueFrom"
+	RoleARNArgKey            = "role_arn"
+	SessionNameArgKey        = "session_name"
+	DurationSecondsArgKey    = "duration_seconds"
+	MaxAttemptsArgKey        = "max_attempts"
+	BackoffSecondsArgKey     = "backoff_seconds"
+	ErrorsArgKey             = "errors"
 )
 
 /*
@@ -38,14 +63,20 @@ Step represents a single action/command to perform. In YAML, it can be set as
   - init
   - plan
   - policy_check
+  - var_file
 
-2. A map for an env step with name and command or value, or a run step with a command and output config
+2. A map for an env step with name and command or value, a run step with a command and output config,
+or a plugin step with the name of the step plugin and extra_args
   - env:
     name: test_command
     command: echo 312
   - env:
     name: test_value
     value: value
+  - env:
+    name: test_secret
+    valueFrom:
+    vault: secret/data/db#password
   - env:
     name: test_bash_command
     command: echo ${test_value::7}
@@ -59,11 +90,40 @@ Step represents a single action/command to perform. In YAML, it can be set as
   - run:
     command: my custom command
     output: hide
+  - plugin:
+    name: pulumi
+    extra_args: [preview]
+  - assume_role:
+    role_arn: arn:aws:iam::123456789012:role/atlantis
+    session_name: atlantis
+    duration_seconds: 3600
+  - terragrunt:
+    run_all: true
+    extra_args: [-lock-timeout=5m]
+  - cost_estimate:
+    max_monthly_increase: 500
+    max_percent_increase: 20
 
 3. A map for a built-in command and extra_args:
   - plan:
     extra_args: [-var-file=staging.tfvars]
 
+Steps in the map forms can also have a when expression that must be true for
+them to run, and a timeout_seconds after which they're canceled. Built-in steps
+with a when expression or a timeout are parsed as in case #2:
+  - apply:
+    when: project.workspace != "production"
+  - plan:
+    timeout_seconds: 1800
+
+The init, plan and apply steps can also have a retry policy for transient
+errors:
+  - init:
+    retry:
+    max_attempts: 3
+    backoff_seconds: 10
+    errors: ["429", "connection reset by peer"]
+
 4. A map for a custom run command:
   - run: my custom command
 
@@ -113,7 +173,12 @@ func (s Step) validStepName(stepName string) bool {
 		stepName == ShowStepName ||
 		stepName == PolicyCheckStepName ||
 		stepName == ImportStepName ||
-		stepName == StateRmStepName
+		stepName == StateRmStepName ||
+		stepName == WorkspaceSelectStepName ||
+		stepName == VarFileStepName ||
+		stepName == FormatCheckStepName ||
+		stepName == TerragruntStepName ||
+		stepName == CostEstimateStepName
 }
 
 func (s Step) Validate() error {
@@ -184,13 +249,48 @@ func (s Step) Validate() error {
 		stepName := keys[0]
 		args := elem[keys[0]]
 
+		// Every step can have a when expression so it's validated here and
+		// excluded from the keys validated per step type.
+		if when, ok := args[WhenArgKey]; ok {
+			str, ok := when.(string)
+			if !ok {
+				return fmt.Errorf("%q step %q option must be a string, found %v", stepName, WhenArgKey, when)
+			}
+			if _, err := expr.Parse(str); err != nil {
+				return fmt.Errorf("%q step %q option is invalid: %w", stepName, WhenArgKey, err)
+			}
+		}
+
+		// Every step can have a timeout too.
+		if timeout, ok := args[TimeoutArgKey]; ok {
+			if seconds, ok := durationSeconds(timeout); !ok || seconds <= 0 {
+				return fmt.Errorf("%q step %q option must be a positive number of seconds, found %v", stepName, TimeoutArgKey, timeout)
+			}
+		}
+
+		// The steps that download providers and call their APIs can be
+		// retried.
+		if retry, ok := args[RetryArgKey]; ok {
+			if stepName != InitStepName && stepName != PlanStepName && stepName != ApplyStepName {
+				return fmt.Errorf("%q step doesn't support the %q option, only the %q, %q and %q steps do",
+					stepName, RetryArgKey, InitStepName, PlanStepName, ApplyStepName)
+			}
+			if _, err := stepRetry(retry); err != nil {
+				return fmt.Errorf("%q step %q option is invalid: %w", stepName, RetryArgKey, err)
+			}
+		}
+
 		var argKeys []string
 		for k := range args {
-			argKeys = append(argKeys, k)
+			if k != WhenArgKey && k != TimeoutArgKey && k != RetryArgKey {
+				argKeys = append(argKeys, k)
+			}
 		}
 		argMap := make(map[string]interface{})
 		for k, v := range args {
-			argMap[k] = v
+			if k != WhenArgKey && k != TimeoutArgKey && k != RetryArgKey {
+				argMap[k] = v
+			}
 		}
 		// Sort so tests can be deterministic.
 		sort.Strings(argKeys)
@@ -227,9 +327,9 @@ func (s Step) Validate() error {
 		case EnvStepName:
 			foundNameKey := false
 			for _, k := range argKeys {
-				if k != NameArgKey && k != CommandArgKey && k != ValueArgKey && k != ShellArgKey && k != ShellArgsArgKey {
-					return fmt.Errorf("env steps only support keys %q, %q, %q, %q and %q, found key %q",
-						NameArgKey, ValueArgKey, CommandArgKey, ShellArgKey, ShellArgsArgKey, k)
+				if k != NameArgKey && k != CommandArgKey && k != ValueArgKey && k != ValueFromArgKey && k != ShellArgKey && k != ShellArgsArgKey {
+					return fmt.Errorf("env steps only support keys %q, %q, %q, %q, %q and %q, found key %q",
+						NameArgKey, ValueArgKey, ValueFromArgKey, CommandArgKey, ShellArgKey, ShellArgsArgKey, k)
 				}
 				if k == NameArgKey {
 					foundNameKey = true
@@ -245,6 +345,16 @@ func (s Step) Validate() error {
 					ValueArgKey, CommandArgKey)
 			}
 			delete(argMap, ValueArgKey)
+			if valueFrom, ok := argMap[ValueFromArgKey]; ok {
+				if utils.SlicesContains(argKeys, ValueArgKey) || utils.SlicesContains(argKeys, CommandArgKey) {
+					return fmt.Errorf("env steps with a %q key can't have a %q or %q key",
+						ValueFromArgKey, ValueArgKey, CommandArgKey)
+				}
+				if _, err := secretRef(valueFrom); err != nil {
+					return fmt.Errorf("env step %q option is invalid: %w", ValueFromArgKey, err)
+				}
+			}
+			delete(argMap, ValueFromArgKey)
 		case RunStepName, MultiEnvStepName:
 			if _, ok := argMap[CommandArgKey].(string); !ok {
 				return fmt.Errorf("%q step must have a %q key set", stepName, CommandArgKey)
@@ -252,10 +362,11 @@ func (s Step) Validate() error {
 			delete(argMap, CommandArgKey)
 			if v, ok := argMap[OutputArgKey].(string); ok {
 				if stepName == RunStepName && !(v == valid.PostProcessRunOutputShow ||
-					v == valid.PostProcessRunOutputHide || v == valid.PostProcessRunOutputStripRefreshing) {
-					return fmt.Errorf("run step %q option must be one of %q, %q, or %q",
+					v == valid.PostProcessRunOutputHide || v == valid.PostProcessRunOutputStripRefreshing ||
+					v == valid.PostProcessRunOutputJSON) {
+					return fmt.Errorf("run step %q option must be one of %q, %q, %q, or %q",
 						OutputArgKey, valid.PostProcessRunOutputShow, valid.PostProcessRunOutputHide,
-						valid.PostProcessRunOutputStripRefreshing)
+						valid.PostProcessRunOutputStripRefreshing, valid.PostProcessRunOutputJSON)
 				} else if stepName == MultiEnvStepName && !(v == valid.PostProcessRunOutputShow ||
 					v == valid.PostProcessRunOutputHide) {
 					return fmt.Errorf("multienv step %q option must be %q or %q",
@@ -263,6 +374,135 @@ func (s Step) Validate() error {
 				}
 			}
 			delete(argMap, OutputArgKey)
+		case PluginStepName:
+			for _, k := range argKeys {
+				if k != NameArgKey && k != ExtraArgsKey {
+					return fmt.Errorf("plugin steps only support keys %q and %q, found key %q",
+						NameArgKey, ExtraArgsKey, k)
+				}
+			}
+			if name, ok := argMap[NameArgKey].(string); !ok || name == "" {
+				return fmt.Errorf("plugin steps must have a %q key set", NameArgKey)
+			}
+			delete(argMap, NameArgKey)
+			switch t := argMap[ExtraArgsKey].(type) {
+			case nil:
+			case []interface{}:
+				for _, e := range t {
+					if _, ok := e.(string); !ok {
+						return fmt.Errorf("plugin step %q option must contain only strings, found %v",
+							ExtraArgsKey, e)
+					}
+				}
+			default:
+				return fmt.Errorf("plugin step %q option must be a list of strings, found %v",
+					ExtraArgsKey, t)
+			}
+			delete(argMap, ExtraArgsKey)
+		case AssumeRoleStepName:
+			for _, k := range argKeys {
+				if k != RoleARNArgKey && k != SessionNameArgKey && k != DurationSecondsArgKey {
+					return fmt.Errorf("assume_role steps only support keys %q, %q and %q, found key %q",
+						RoleARNArgKey, SessionNameArgKey, DurationSecondsArgKey, k)
+				}
+			}
+			if roleARN, ok := argMap[RoleARNArgKey].(string); !ok || roleARN == "" {
+				return fmt.Errorf("assume_role steps must have a %q key set", RoleARNArgKey)
+			}
+			delete(argMap, RoleARNArgKey)
+			if _, ok := argMap[SessionNameArgKey]; ok {
+				if _, ok := argMap[SessionNameArgKey].(string); !ok {
+					return fmt.Errorf("assume_role step %q option must be a string", SessionNameArgKey)
+				}
+			}
+			delete(argMap, SessionNameArgKey)
+			if d, ok := argMap[DurationSecondsArgKey]; ok {
+				if seconds, ok := durationSeconds(d); !ok || seconds <= 0 {
+					return fmt.Errorf("assume_role step %q option must be a positive number of seconds, found %v",
+						DurationSecondsArgKey, d)
+				}
+			}
+			delete(argMap, DurationSecondsArgKey)
+		case TerragruntStepName:
+			for _, k := range argKeys {
+				if k != ExtraArgsKey && k != RunAllArgKey {
+					return fmt.Errorf("terragrunt steps only support keys %q, %q and %q, found key %q",
+						ExtraArgsKey, RunAllArgKey, WhenArgKey, k)
+				}
+			}
+			if runAll, ok := argMap[RunAllArgKey]; ok {
+				if _, ok := runAll.(bool); !ok {
+					return fmt.Errorf("terragrunt step %q option must be a boolean, found %v", RunAllArgKey, runAll)
+				}
+			}
+			delete(argMap, RunAllArgKey)
+			switch t := argMap[ExtraArgsKey].(type) {
+			case nil:
+			case []interface{}:
+				for _, e := range t {
+					if _, ok := e.(string); !ok {
+						return fmt.Errorf("terragrunt step %q option must contain only strings, found %v",
+							ExtraArgsKey, e)
+					}
+				}
+			default:
+				return fmt.Errorf("terragrunt step %q option must be a list of strings, found %v",
+					ExtraArgsKey, t)
+			}
+			delete(argMap, ExtraArgsKey)
+		case CostEstimateStepName:
+			for _, k := range argKeys {
+				if k != ExtraArgsKey && k != MaxMonthlyIncreaseArgKey && k != MaxPercentIncreaseArgKey {
+					return fmt.Errorf("cost_estimate steps only support keys %q, %q and %q, found key %q",
+						ExtraArgsKey, MaxMonthlyIncreaseArgKey, MaxPercentIncreaseArgKey, k)
+				}
+			}
+			for _, k := range []string{MaxMonthlyIncreaseArgKey, MaxPercentIncreaseArgKey} {
+				if v, ok := argMap[k]; ok {
+					if threshold, ok := costThreshold(v); !ok || threshold <= 0 {
+						return fmt.Errorf("cost_estimate step %q option must be a positive number, found %v", k, v)
+					}
+				}
+				delete(argMap, k)
+			}
+			switch t := argMap[ExtraArgsKey].(type) {
+			case nil:
+			case []interface{}:
+				for _, e := range t {
+					if _, ok := e.(string); !ok {
+						return fmt.Errorf("cost_estimate step %q option must contain only strings, found %v",
+							ExtraArgsKey, e)
+					}
+				}
+			default:
+				return fmt.Errorf("cost_estimate step %q option must be a list of strings, found %v",
+					ExtraArgsKey, t)
+			}
+			delete(argMap, ExtraArgsKey)
+		case InitStepName, PlanStepName, ShowStepName, PolicyCheckStepName, ApplyStepName, ImportStepName,
+			StateRmStepName, WorkspaceSelectStepName, VarFileStepName, FormatCheckStepName:
+			// Built-in steps are only parsed as a command step if they have
+			// a when expression or a timeout.
+			for _, k := range argKeys {
+				if k != ExtraArgsKey {
+					return fmt.Errorf("built-in steps only support %q, %q and %q keys, found %q in step %s",
+						ExtraArgsKey, WhenArgKey, TimeoutArgKey, k, stepName)
+				}
+			}
+			switch t := argMap[ExtraArgsKey].(type) {
+			case nil:
+			case []interface{}:
+				for _, e := range t {
+					if _, ok := e.(string); !ok {
+						return fmt.Errorf("%q step %q option must contain only strings, found %v",
+							stepName, ExtraArgsKey, e)
+					}
+				}
+			default:
+				return fmt.Errorf("%q step %q option must be a list of strings, found %v",
+					stepName, ExtraArgsKey, t)
+			}
+			delete(argMap, ExtraArgsKey)
 		default:
 			return fmt.Errorf("%q is not a valid step type", stepName)
 		}
@@ -330,7 +570,46 @@ func (s Step) ToValid() valid.Step {
 		// After validation we assume there's only one key and it's a valid
 		// step name so we just use the first one.
 		for stepName, stepArgs := range s.CommandMap {
-			step := valid.Step{StepName: stepName}
+			when, _ := stepArgs[WhenArgKey].(string)
+			timeoutSeconds, _ := durationSeconds(stepArgs[TimeoutArgKey])
+			timeout := time.Duration(timeoutSeconds) * time.Second
+			if stepName != EnvStepName && stepName != MultiEnvStepName && s.validStepName(stepName) {
+				step := valid.Step{StepName: stepName, When: when, Timeout: timeout}
+				if retry, ok := stepArgs[RetryArgKey]; ok {
+					step.Retry, _ = stepRetry(retry)
+				}
+				if stepName == TerragruntStepName {
+					step.TerragruntRunAll, _ = stepArgs[RunAllArgKey].(bool)
+				}
+				if stepName == CostEstimateStepName {
+					step.CostMaxMonthlyIncrease, _ = costThreshold(stepArgs[MaxMonthlyIncreaseArgKey])
+					step.CostMaxPercentIncrease, _ = costThreshold(stepArgs[MaxPercentIncreaseArgKey])
+				}
+				if extraArgs, ok := stepArgs[ExtraArgsKey].([]interface{}); ok {
+					for _, e := range extraArgs {
+						step.ExtraArgs = append(step.ExtraArgs, e.(string))
+					}
+				}
+				return step
+			}
+			if stepName == PluginStepName {
+				step := valid.Step{StepName: stepName, When: when, Timeout: timeout}
+				step.PluginName, _ = stepArgs[NameArgKey].(string)
+				if extraArgs, ok := stepArgs[ExtraArgsKey].([]interface{}); ok {
+					for _, e := range extraArgs {
+						step.ExtraArgs = append(step.ExtraArgs, e.(string))
+					}
+				}
+				return step
+			}
+			if stepName == AssumeRoleStepName {
+				step := valid.Step{StepName: stepName, When: when, Timeout: timeout}
+				step.RoleARN, _ = stepArgs[RoleARNArgKey].(string)
+				step.RoleSessionName, _ = stepArgs[SessionNameArgKey].(string)
+				step.RoleDurationSeconds, _ = durationSeconds(stepArgs[DurationSecondsArgKey])
+				return step
+			}
+			step := valid.Step{StepName: stepName, When: when, Timeout: timeout}
 			if name, ok := stepArgs[NameArgKey].(string); ok {
 				step.EnvVarName = name
 			}
@@ -340,6 +619,9 @@ func (s Step) ToValid() valid.Step {
 			if value, ok := stepArgs[ValueArgKey].(string); ok {
 				step.EnvVarValue = value
 			}
+			if valueFrom, ok := stepArgs[ValueFromArgKey]; ok {
+				step.EnvVarValueFrom, _ = secretRef(valueFrom)
+			}
 			if output, ok := stepArgs[OutputArgKey].(string); ok {
 				step.Output = valid.PostProcessRunOutputOption(output)
 			}
@@ -395,6 +677,125 @@ func (s Step) ToValid() valid.Step {
 	panic("step was not valid. This is a bug!")
 }
 
+// durationSeconds returns d as a whole number of seconds. d is an int when
+// it's unmarshalled from YAML and a float64 when it's unmarshalled from JSON.
+func durationSeconds(d interface{}) (int, bool) {
+	switch t := d.(type) {
+	case int:
+		return t, true
+	case float64:
+		if t != float64(int(t)) {
+			return 0, false
+		}
+		return int(t), true
+	}
+	return 0, false
+}
+
+// stepRetry returns the retry policy of the retry option of a step, a map of
+// its max attempts, backoff and the regexes of the errors it's retried on,
+// ex. {"max_attempts": 5, "errors": ["429"]}. Options that aren't set get
+// their defaults.
+func stepRetry(retry interface{}) (*valid.StepRetry, error) {
+	m, ok := retry.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be a map of %q, %q and %q, found %v",
+			MaxAttemptsArgKey, BackoffSecondsArgKey, ErrorsArgKey, retry)
+	}
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	// Sort so errors are deterministic.
+	sort.Strings(keys)
+
+	policy := &valid.StepRetry{
+		MaxAttempts: valid.DefaultStepRetryMaxAttempts,
+		Backoff:     valid.DefaultStepRetryBackoff,
+	}
+	errs := valid.DefaultStepRetryErrors
+	for _, k := range keys {
+		v := m[k]
+		switch k {
+		case MaxAttemptsArgKey:
+			attempts, ok := durationSeconds(v)
+			if !ok || attempts < 1 {
+				return nil, fmt.Errorf("%q must be a positive number, found %v", k, v)
+			}
+			policy.MaxAttempts = attempts
+		case BackoffSecondsArgKey:
+			seconds, ok := durationSeconds(v)
+			if !ok || seconds < 0 {
+				return nil, fmt.Errorf("%q must be a number of seconds, found %v", k, v)
+			}
+			policy.Backoff = time.Duration(seconds) * time.Second
+		case ErrorsArgKey:
+			list, ok := v.([]interface{})
+			if !ok || len(list) == 0 {
+				return nil, fmt.Errorf("%q must be a list of regexes, found %v", k, v)
+			}
+			errs = nil
+			for _, e := range list {
+				str, ok := e.(string)
+				if !ok {
+					return nil, fmt.Errorf("%q must contain only strings, found %v", k, e)
+				}
+				errs = append(errs, str)
+			}
+		default:
+			return nil, fmt.Errorf("only keys %q, %q and %q are supported, found key %q",
+				MaxAttemptsArgKey, BackoffSecondsArgKey, ErrorsArgKey, k)
+		}
+	}
+	for _, e := range errs {
+		re, err := regexp.Compile(e)
+		if err != nil {
+			return nil, fmt.Errorf("%q regex %q is invalid: %w", ErrorsArgKey, e, err)
+		}
+		policy.Errors = append(policy.Errors, re)
+	}
+	return policy, nil
+}
+
+// secretRef returns the secret of the valueFrom option of an env step, a map
+// of a secret provider to the name of the secret, ex.
+// {"vault": "secret/data/db#password"}.
+func secretRef(valueFrom interface{}) (*valid.SecretRef, error) {
+	m, ok := valueFrom.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return nil, fmt.Errorf("must be a map of one of %q, %q or %q to the name of the secret",
+			valid.VaultSecretProvider, valid.AWSSecretsManagerSecretProvider, valid.GCPSecretManagerSecretProvider)
+	}
+	for provider, name := range m {
+		if provider != valid.VaultSecretProvider && provider != valid.AWSSecretsManagerSecretProvider &&
+			provider != valid.GCPSecretManagerSecretProvider {
+			return nil, fmt.Errorf("%q is not one of the secret providers %q, %q or %q", provider,
+				valid.VaultSecretProvider, valid.AWSSecretsManagerSecretProvider, valid.GCPSecretManagerSecretProvider)
+		}
+		str, ok := name.(string)
+		if !ok || str == "" || strings.HasPrefix(str, "#") {
+			return nil, fmt.Errorf("%q must be the name of a secret, found %v", provider, name)
+		}
+		if provider == valid.VaultSecretProvider && !strings.Contains(str, "#") {
+			return nil, fmt.Errorf("%q must be the path of a secret followed by # and a key, ex. secret/data/db#password, found %q", provider, str)
+		}
+		return &valid.SecretRef{Provider: provider, Name: str}, nil
+	}
+	return nil, nil
+}
+
+// costThreshold returns the cost threshold t, which YAML and JSON parse as
+// an int or a float64.
+func costThreshold(t interface{}) (float64, bool) {
+	switch v := t.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
 // unmarshalGeneric is used by UnmarshalJSON and UnmarshalYAML to unmarshal
 // a step into one of its three forms. We need to implement a custom unmarshal
 // function because steps can either be:
@@ -440,7 +841,7 @@ func (s *Step) unmarshalGeneric(unmarshal func(interface{}) error) error {
 		return nil
 	}
 
-	// This represents a command steps env, run, and multienv, ex:
+	// This represents a command steps env, run, multienv and plugin, ex:
 	// steps:
 	//   - env:
 	//       name: k
@@ -1,7 +1,9 @@
 package raw_test
 
 import (
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/core/config/raw"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -104,6 +106,65 @@ env:
 				},
 			},
 		},
+		{
+			description: "env step valueFrom",
+			input: `
+env:
+  name: test
+  valueFrom:
+    vault: secret/data/db#password`,
+			exp: raw.Step{
+				CommandMap: EnvType{
+					"env": {
+						"name":      "test",
+						"valueFrom": map[string]interface{}{"vault": "secret/data/db#password"},
+					},
+				},
+			},
+		},
+		{
+			description: "plugin step",
+			input: `
+plugin:
+  name: pulumi
+  extra_args: [preview]`,
+			exp: raw.Step{
+				CommandMap: PluginType{
+					"plugin": {
+						"name":       "pulumi",
+						"extra_args": []interface{}{"preview"},
+					},
+				},
+			},
+		},
+		{
+			description: "built-in step with when",
+			input: `
+apply:
+  when: project.workspace != "production"`,
+			exp: raw.Step{
+				CommandMap: BuiltInType{
+					"apply": {
+						"when": `project.workspace != "production"`,
+					},
+				},
+			},
+		},
+		{
+			description: "assume_role step",
+			input: `
+assume_role:
+  role_arn: arn:aws:iam::123456789012:role/atlantis
+  duration_seconds: 900`,
+			exp: raw.Step{
+				CommandMap: AssumeRoleType{
+					"assume_role": {
+						"role_arn":         "arn:aws:iam::123456789012:role/atlantis",
+						"duration_seconds": 900,
+					},
+				},
+			},
+		},
 
 		// Run-step style
 		{
@@ -412,7 +473,70 @@ func TestStep_Validate(t *testing.T) {
 					},
 				},
 			},
-			expErr: "env steps only support keys \"name\", \"value\", \"command\", \"shell\" and \"shellArgs\", found key \"abc\"",
+			expErr: "env steps only support keys \"name\", \"value\", \"valueFrom\", \"command\", \"shell\" and \"shellArgs\", found key \"abc\"",
+		},
+		{
+			description: "env step with valueFrom",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"env": {
+						"name":      "name",
+						"valueFrom": map[string]interface{}{"aws_secrets_manager": "prod/db#password"},
+					},
+				},
+			},
+		},
+		{
+			description: "env step with valueFrom and value set",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"env": {
+						"name":      "name",
+						"value":     "value",
+						"valueFrom": map[string]interface{}{"aws_secrets_manager": "prod/db"},
+					},
+				},
+			},
+			expErr: "env steps with a \"valueFrom\" key can't have a \"value\" or \"command\" key",
+		},
+		{
+			description: "env step with unknown valueFrom provider",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"env": {
+						"name":      "name",
+						"valueFrom": map[string]interface{}{"azure_key_vault": "db"},
+					},
+				},
+			},
+			expErr: "env step \"valueFrom\" option is invalid: \"azure_key_vault\" is not one of the secret providers \"vault\", \"aws_secrets_manager\" or \"gcp_secret_manager\"",
+		},
+		{
+			description: "env step with valueFrom of several providers",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"env": {
+						"name": "name",
+						"valueFrom": map[string]interface{}{
+							"aws_secrets_manager": "prod/db",
+							"gcp_secret_manager":  "projects/p/secrets/db",
+						},
+					},
+				},
+			},
+			expErr: "env step \"valueFrom\" option is invalid: must be a map of one of \"vault\", \"aws_secrets_manager\" or \"gcp_secret_manager\" to the name of the secret",
+		},
+		{
+			description: "env step with vault valueFrom without key",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"env": {
+						"name":      "name",
+						"valueFrom": map[string]interface{}{"vault": "secret/data/db"},
+					},
+				},
+			},
+			expErr: "env step \"valueFrom\" option is invalid: \"vault\" must be the path of a secret followed by # and a key, ex. secret/data/db#password, found \"secret/data/db\"",
 		},
 		{
 			description: "env step with both command and value set",
@@ -479,6 +603,361 @@ func TestStep_Validate(t *testing.T) {
 			},
 			expErr: "\"run\" step \"shellArgs\" option must contain only strings, found 42\n",
 		},
+		{
+			description: "plugin step",
+			input: raw.Step{
+				CommandMap: PluginType{
+					"plugin": {
+						"name":       "pulumi",
+						"extra_args": []interface{}{"preview"},
+					},
+				},
+			},
+		},
+		{
+			description: "plugin step without name",
+			input: raw.Step{
+				CommandMap: PluginType{
+					"plugin": {
+						"extra_args": []interface{}{"preview"},
+					},
+				},
+			},
+			expErr: "plugin steps must have a \"name\" key set",
+		},
+		{
+			description: "plugin step with extra_args that aren't strings",
+			input: raw.Step{
+				CommandMap: PluginType{
+					"plugin": {
+						"name":       "pulumi",
+						"extra_args": []interface{}{"preview", 42},
+					},
+				},
+			},
+			expErr: "plugin step \"extra_args\" option must contain only strings, found 42",
+		},
+		{
+			description: "plugin step with a command",
+			input: raw.Step{
+				CommandMap: PluginType{
+					"plugin": {
+						"name":    "pulumi",
+						"command": "pulumi preview",
+					},
+				},
+			},
+			expErr: "plugin steps only support keys \"name\" and \"extra_args\", found key \"command\"",
+		},
+		{
+			description: "built-in step with when",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"apply": {
+						"extra_args": []interface{}{"-refresh=false"},
+						"when":       `project.workspace != "production"`,
+					},
+				},
+			},
+		},
+		{
+			description: "run step with json output",
+			input: raw.Step{
+				CommandMap: RunType{
+					"run": {
+						"command": "terraform plan -json",
+						"output":  "json",
+					},
+				},
+			},
+		},
+		{
+			description: "run step with invalid output",
+			input: raw.Step{
+				CommandMap: RunType{
+					"run": {
+						"command": "terraform plan",
+						"output":  "yaml",
+					},
+				},
+			},
+			expErr: "run step \"output\" option must be one of \"show\", \"hide\", \"strip_refreshing\", or \"json\"",
+		},
+		{
+			description: "run step with when",
+			input: raw.Step{
+				CommandMap: RunType{
+					"run": {
+						"command": "./notify.sh",
+						"when":    `command == "apply"`,
+					},
+				},
+			},
+		},
+		{
+			description: "built-in step with timeout",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"plan": {
+						"timeout_seconds": 1800,
+					},
+				},
+			},
+		},
+		{
+			description: "run step with invalid timeout",
+			input: raw.Step{
+				CommandMap: RunType{
+					"run": {
+						"command":         "./slow.sh",
+						"timeout_seconds": "10m",
+					},
+				},
+			},
+			expErr: "\"run\" step \"timeout_seconds\" option must be a positive number of seconds, found 10m",
+		},
+		{
+			description: "init step with retry",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"init": {
+						"retry": map[string]interface{}{
+							"max_attempts":    5,
+							"backoff_seconds": 0,
+							"errors":          []interface{}{"429", "(?i)connection reset"},
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "run step with retry",
+			input: raw.Step{
+				CommandMap: RunType{
+					"run": {
+						"command": "./flaky.sh",
+						"retry":   map[string]interface{}{},
+					},
+				},
+			},
+			expErr: "\"run\" step doesn't support the \"retry\" option, only the \"init\", \"plan\" and \"apply\" steps do",
+		},
+		{
+			description: "plan step with invalid retry max attempts",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"plan": {
+						"retry": map[string]interface{}{
+							"max_attempts": 0,
+						},
+					},
+				},
+			},
+			expErr: "\"plan\" step \"retry\" option is invalid: \"max_attempts\" must be a positive number, found 0",
+		},
+		{
+			description: "plan step with invalid retry error regex",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"plan": {
+						"retry": map[string]interface{}{
+							"errors": []interface{}{"(429"},
+						},
+					},
+				},
+			},
+			expErr: "\"plan\" step \"retry\" option is invalid: \"errors\" regex \"(429\" is invalid: error parsing regexp: missing closing ): `(429`",
+		},
+		{
+			description: "apply step with unknown retry key",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"apply": {
+						"retry": map[string]interface{}{
+							"attempts": 3,
+						},
+					},
+				},
+			},
+			expErr: "\"apply\" step \"retry\" option is invalid: only keys \"max_attempts\", \"backoff_seconds\" and \"errors\" are supported, found key \"attempts\"",
+		},
+		{
+			description: "built-in step with when and a command",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"plan": {
+						"command": "terraform plan",
+						"when":    `command == "plan"`,
+					},
+				},
+			},
+			expErr: "built-in steps only support \"extra_args\", \"when\" and \"timeout_seconds\" keys, found \"command\" in step plan",
+		},
+		{
+			description: "step with invalid when",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"init": {
+						"when": `command ==`,
+					},
+				},
+			},
+			expErr: "\"init\" step \"when\" option is invalid: parsing \"command ==\": Missing expression: Expected the start of an expression, but found the end of the file.",
+		},
+		{
+			description: "step with when that isn't a string",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"init": {
+						"when": true,
+					},
+				},
+			},
+			expErr: "\"init\" step \"when\" option must be a string, found true",
+		},
+		{
+			description: "helper steps",
+			input: raw.Step{
+				Key: String("var_file"),
+			},
+		},
+		{
+			description: "format_check with extra_args",
+			input: raw.Step{
+				Map: MapType{
+					"format_check": {
+						"extra_args": {"-recursive"},
+					},
+				},
+			},
+		},
+		{
+			description: "assume_role step",
+			input: raw.Step{
+				CommandMap: AssumeRoleType{
+					"assume_role": {
+						"role_arn":         "arn:aws:iam::123456789012:role/atlantis",
+						"session_name":     "atlantis",
+						"duration_seconds": 900,
+					},
+				},
+			},
+		},
+		{
+			description: "assume_role step without role_arn",
+			input: raw.Step{
+				CommandMap: AssumeRoleType{
+					"assume_role": {
+						"session_name": "atlantis",
+					},
+				},
+			},
+			expErr: "assume_role steps must have a \"role_arn\" key set",
+		},
+		{
+			description: "assume_role step with invalid duration",
+			input: raw.Step{
+				CommandMap: AssumeRoleType{
+					"assume_role": {
+						"role_arn":         "arn:aws:iam::123456789012:role/atlantis",
+						"duration_seconds": "1h",
+					},
+				},
+			},
+			expErr: "assume_role step \"duration_seconds\" option must be a positive number of seconds, found 1h",
+		},
+		{
+			description: "assume_role step with unknown key",
+			input: raw.Step{
+				CommandMap: AssumeRoleType{
+					"assume_role": {
+						"role_arn": "arn:aws:iam::123456789012:role/atlantis",
+						"profile":  "prod",
+					},
+				},
+			},
+			expErr: "assume_role steps only support keys \"role_arn\", \"session_name\" and \"duration_seconds\", found key \"profile\"",
+		},
+		{
+			description: "terragrunt step",
+			input: raw.Step{
+				Key: String("terragrunt"),
+			},
+		},
+		{
+			description: "terragrunt step with run_all",
+			input: raw.Step{
+				CommandMap: TerragruntType{
+					"terragrunt": {
+						"run_all":    true,
+						"extra_args": []interface{}{"-lock-timeout=5m"},
+					},
+				},
+			},
+		},
+		{
+			description: "terragrunt step with invalid run_all",
+			input: raw.Step{
+				CommandMap: TerragruntType{
+					"terragrunt": {
+						"run_all": "yes",
+					},
+				},
+			},
+			expErr: "terragrunt step \"run_all\" option must be a boolean, found yes",
+		},
+		{
+			description: "terragrunt step with unknown key",
+			input: raw.Step{
+				CommandMap: TerragruntType{
+					"terragrunt": {
+						"run_all": true,
+						"command": "plan",
+					},
+				},
+			},
+			expErr: "terragrunt steps only support keys \"extra_args\", \"run_all\" and \"when\", found key \"command\"",
+		},
+		{
+			description: "cost_estimate step",
+			input: raw.Step{
+				Key: String("cost_estimate"),
+			},
+		},
+		{
+			description: "cost_estimate step with thresholds",
+			input: raw.Step{
+				CommandMap: CostEstimateType{
+					"cost_estimate": {
+						"max_monthly_increase": 500,
+						"max_percent_increase": 12.5,
+						"extra_args":           []interface{}{"--usage-file", "usage.yml"},
+					},
+				},
+			},
+		},
+		{
+			description: "cost_estimate step with negative threshold",
+			input: raw.Step{
+				CommandMap: CostEstimateType{
+					"cost_estimate": {
+						"max_monthly_increase": -1,
+					},
+				},
+			},
+			expErr: "cost_estimate step \"max_monthly_increase\" option must be a positive number, found -1",
+		},
+		{
+			description: "cost_estimate step with unknown key",
+			input: raw.Step{
+				CommandMap: CostEstimateType{
+					"cost_estimate": {
+						"currency": "EUR",
+					},
+				},
+			},
+			expErr: "cost_estimate steps only support keys \"extra_args\", \"max_monthly_increase\" and \"max_percent_increase\", found key \"currency\"",
+		},
 		{
 			// For atlantis.yaml v2, this wouldn't parse, but now there should
 			// be no error.
@@ -560,6 +1039,25 @@ func TestStep_ToValid(t *testing.T) {
 				EnvVarName: "test",
 			},
 		},
+		{
+			description: "env step with valueFrom",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"env": {
+						"name":      "test",
+						"valueFrom": map[string]interface{}{"gcp_secret_manager": "projects/p/secrets/db/versions/3"},
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:   "env",
+				EnvVarName: "test",
+				EnvVarValueFrom: &valid.SecretRef{
+					Provider: "gcp_secret_manager",
+					Name:     "projects/p/secrets/db/versions/3",
+				},
+			},
+		},
 		{
 			description: "import step",
 			input: raw.Step{
@@ -695,6 +1193,181 @@ func TestStep_ToValid(t *testing.T) {
 				Output:     "hide",
 			},
 		},
+		{
+			description: "plugin step",
+			input: raw.Step{
+				CommandMap: PluginType{
+					"plugin": {
+						"name":       "pulumi",
+						"extra_args": []interface{}{"preview", "--diff"},
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:   "plugin",
+				PluginName: "pulumi",
+				ExtraArgs:  []string{"preview", "--diff"},
+			},
+		},
+		{
+			description: "built-in step with when",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"plan": {
+						"extra_args": []interface{}{"-lock=false"},
+						"when":       `pull.base_branch == "main"`,
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:  "plan",
+				ExtraArgs: []string{"-lock=false"},
+				When:      `pull.base_branch == "main"`,
+			},
+		},
+		{
+			description: "run step with when",
+			input: raw.Step{
+				CommandMap: RunType{
+					"run": {
+						"command": "./notify.sh",
+						"when":    `command == "apply"`,
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:   "run",
+				RunCommand: "./notify.sh",
+				Output:     "show",
+				When:       `command == "apply"`,
+			},
+		},
+		{
+			description: "assume_role step",
+			input: raw.Step{
+				CommandMap: AssumeRoleType{
+					"assume_role": {
+						"role_arn":         "arn:aws:iam::123456789012:role/atlantis",
+						"session_name":     "atlantis",
+						"duration_seconds": float64(900),
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:            "assume_role",
+				RoleARN:             "arn:aws:iam::123456789012:role/atlantis",
+				RoleSessionName:     "atlantis",
+				RoleDurationSeconds: 900,
+			},
+		},
+		{
+			description: "run step with timeout",
+			input: raw.Step{
+				CommandMap: RunType{
+					"run": {
+						"command":         "./slow.sh",
+						"timeout_seconds": 600,
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:   "run",
+				RunCommand: "./slow.sh",
+				Output:     "show",
+				Timeout:    10 * time.Minute,
+			},
+		},
+		{
+			description: "built-in step with timeout",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"apply": {
+						"extra_args":      []interface{}{"-lock-timeout=5m"},
+						"timeout_seconds": float64(1800),
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:  "apply",
+				ExtraArgs: []string{"-lock-timeout=5m"},
+				Timeout:   30 * time.Minute,
+			},
+		},
+		{
+			description: "init step with retry",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"init": {
+						"retry": map[string]interface{}{
+							"max_attempts": 5,
+							"errors":       []interface{}{"429"},
+						},
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName: "init",
+				Retry: &valid.StepRetry{
+					MaxAttempts: 5,
+					Backoff:     valid.DefaultStepRetryBackoff,
+					Errors:      []*regexp.Regexp{regexp.MustCompile("429")},
+				},
+			},
+		},
+		{
+			description: "plan step with default retry",
+			input: raw.Step{
+				CommandMap: BuiltInType{
+					"plan": {
+						"retry": map[string]interface{}{
+							"backoff_seconds": float64(30),
+						},
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName: "plan",
+				Retry: &valid.StepRetry{
+					MaxAttempts: valid.DefaultStepRetryMaxAttempts,
+					Backoff:     30 * time.Second,
+					Errors:      defaultStepRetryErrors(),
+				},
+			},
+		},
+		{
+			description: "terragrunt step with run_all",
+			input: raw.Step{
+				CommandMap: TerragruntType{
+					"terragrunt": {
+						"run_all":    true,
+						"extra_args": []interface{}{"-lock-timeout=5m"},
+						"when":       `project.workspace == "default"`,
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:         "terragrunt",
+				ExtraArgs:        []string{"-lock-timeout=5m"},
+				When:             `project.workspace == "default"`,
+				TerragruntRunAll: true,
+			},
+		},
+		{
+			description: "cost_estimate step",
+			input: raw.Step{
+				CommandMap: CostEstimateType{
+					"cost_estimate": {
+						"max_monthly_increase": 500,
+						"max_percent_increase": 12.5,
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:               "cost_estimate",
+				CostMaxMonthlyIncrease: 500,
+				CostMaxPercentIncrease: 12.5,
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
@@ -707,3 +1380,16 @@ type MapType map[string]map[string][]string
 type EnvType map[string]map[string]interface{}
 type RunType map[string]map[string]interface{}
 type MultiEnvType map[string]map[string]interface{}
+type PluginType map[string]map[string]interface{}
+type AssumeRoleType map[string]map[string]interface{}
+type TerragruntType map[string]map[string]interface{}
+type CostEstimateType map[string]map[string]interface{}
+type BuiltInType map[string]map[string]interface{}
+
+func defaultStepRetryErrors() []*regexp.Regexp {
+	var errs []*regexp.Regexp
+	for _, e := range valid.DefaultStepRetryErrors {
+		errs = append(errs, regexp.MustCompile(e))
+	}
+	return errs
+}
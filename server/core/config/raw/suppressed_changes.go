@@ -0,0 +1,67 @@
+package raw
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// SuppressedChange is the raw schema for a kind of noisy plan change that's
+// collapsed into the suppressed changes section of plan comments.
+type SuppressedChange struct {
+	Description *string `yaml:"description,omitempty" json:"description,omitempty"`
+	Address     *string `yaml:"address,omitempty" json:"address,omitempty"`
+	Attributes  *string `yaml:"attributes,omitempty" json:"attributes,omitempty"`
+	JQ          *string `yaml:"jq,omitempty" json:"jq,omitempty"`
+}
+
+func (s SuppressedChange) Validate() error {
+	regexValid := func(value interface{}) error {
+		strPtr := value.(*string)
+		if strPtr == nil {
+			return nil
+		}
+		if _, err := regexp.Compile(*strPtr); err != nil {
+			return fmt.Errorf("%q is not a valid regex: %s", *strPtr, err)
+		}
+		return nil
+	}
+	// A change must be matched by what it changes, otherwise every update of
+	// the matching resources would be suppressed.
+	matcherSet := func(value interface{}) error {
+		if s.Attributes == nil && s.JQ == nil {
+			return errors.New("must be set if jq isn't")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Address, validation.By(regexValid)),
+		validation.Field(&s.Attributes, validation.By(matcherSet), validation.By(regexValid)),
+		validation.Field(&s.JQ, validation.NilOrNotEmpty),
+	)
+}
+
+func (s SuppressedChange) ToValid() valid.SuppressedChange {
+	var v valid.SuppressedChange
+	// Safe to use MustCompile because we test it in Validate().
+	if s.Address != nil {
+		v.AddressRegex = regexp.MustCompile(*s.Address)
+	}
+	if s.Attributes != nil {
+		v.AttributesRegex = regexp.MustCompile(*s.Attributes)
+	}
+	if s.JQ != nil {
+		v.JQ = *s.JQ
+	}
+	if s.Description != nil {
+		v.Description = *s.Description
+	} else if s.Attributes != nil {
+		v.Description = fmt.Sprintf("only attributes matching %q changed", *s.Attributes)
+	} else {
+		v.Description = fmt.Sprintf("matches %q", v.JQ)
+	}
+	return v
+}
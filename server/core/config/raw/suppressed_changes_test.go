@@ -0,0 +1,81 @@
+package raw_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestSuppressedChange_UnmarshalYAML(t *testing.T) {
+	input := `
+description: tag-only changes
+address: ^aws_
+attributes: ^tags(_all)?(\.|$)
+`
+	var s raw.SuppressedChange
+	Ok(t, unmarshalString(input, &s))
+	Equals(t, raw.SuppressedChange{
+		Description: String("tag-only changes"),
+		Address:     String("^aws_"),
+		Attributes:  String(`^tags(_all)?(\.|$)`),
+	}, s)
+	Equals(t, valid.SuppressedChange{
+		Description:     "tag-only changes",
+		AddressRegex:    regexp.MustCompile("^aws_"),
+		AttributesRegex: regexp.MustCompile(`^tags(_all)?(\.|$)`),
+	}, s.ToValid())
+}
+
+func TestSuppressedChange_ToValid_DefaultDescription(t *testing.T) {
+	Equals(t, `only attributes matching "^tags\\."`+` changed`, raw.SuppressedChange{Attributes: String(`^tags\.`)}.ToValid().Description)
+	Equals(t, `matches ".type == \"kubernetes_manifest\""`, raw.SuppressedChange{JQ: String(`.type == "kubernetes_manifest"`)}.ToValid().Description)
+}
+
+func TestSuppressedChange_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.SuppressedChange
+		errContains *string
+	}{
+		{
+			description: "attributes",
+			input:       raw.SuppressedChange{Attributes: String(`^tags\.`)},
+		},
+		{
+			description: "jq",
+			input:       raw.SuppressedChange{Address: String("^kubernetes_"), JQ: String(".change.after.metadata[0].generation != null")},
+		},
+		{
+			description: "neither attributes nor jq",
+			input:       raw.SuppressedChange{Address: String("^aws_")},
+			errContains: String("attributes: must be set if jq isn't"),
+		},
+		{
+			description: "invalid address regex",
+			input:       raw.SuppressedChange{Address: String("aws_("), Attributes: String(`^tags\.`)},
+			errContains: String(`address: "aws_(" is not a valid regex`),
+		},
+		{
+			description: "invalid attributes regex",
+			input:       raw.SuppressedChange{Attributes: String("tags[")},
+			errContains: String(`attributes: "tags[" is not a valid regex`),
+		},
+		{
+			description: "empty jq",
+			input:       raw.SuppressedChange{JQ: String("")},
+			errContains: String("jq: cannot be blank"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if c.errContains == nil {
+				Ok(t, c.input.Validate())
+			} else {
+				ErrContains(t, *c.errContains, c.input.Validate())
+			}
+		})
+	}
+}
@@ -9,14 +9,26 @@ import (
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/expr"
+	"github.com/runatlantis/atlantis/server/utils"
 )
 
 // WorkflowHook represents a single action/command to perform. In YAML,
 // it can be set as
 // A map for a custom run commands:
 //   - run: my custom command
+//
+// Or a map with an expression that must be true for the hook to run:
+//   - run: my custom command
+//     when: command == "plan" && pull.base_branch == "main"
+//
+// Or a map with a list of the command results the hook runs on:
+//   - run: my custom command
+//     on: [apply_success, apply_failure]
 type WorkflowHook struct {
 	StringVal map[string]string
+	// On are the command results the hook runs on if they're set as a list.
+	On []string
 }
 
 func (s *WorkflowHook) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -41,6 +53,9 @@ func (s *WorkflowHook) MarshalJSON() ([]byte, error) {
 	return json.Marshal(out)
 }
 
+// workflowHookRunOptions are the keys that can be set next to run.
+var workflowHookRunOptions = []string{"description", "shell", "shellArgs", "commands", "when", "output", "on"}
+
 func (s WorkflowHook) Validate() error {
 	runStep := func(value interface{}) error {
 		elem := value.(map[string]string)
@@ -51,13 +66,21 @@ func (s WorkflowHook) Validate() error {
 		// Sort so tests can be deterministic.
 		sort.Strings(keys)
 
-		if len(keys) > 1 {
-			return fmt.Errorf("step element can only contain a single key, found %d: %s",
-				len(keys), strings.Join(keys, ","))
+		if _, ok := elem[RunStepName]; !ok {
+			if len(keys) > 1 {
+				return fmt.Errorf("step element can only contain a single key, found %d: %s",
+					len(keys), strings.Join(keys, ","))
+			}
+			return fmt.Errorf("%q is not a valid step type", keys[0])
+		}
+		for _, key := range keys {
+			if key != RunStepName && !utils.SlicesContains(workflowHookRunOptions, key) {
+				return fmt.Errorf("%q is not a valid option of a run step, only %s are supported", key, strings.Join(workflowHookRunOptions, ", "))
+			}
 		}
-		for stepName := range elem {
-			if stepName != RunStepName {
-				return fmt.Errorf("%q is not a valid step type", stepName)
+		if when, ok := elem[WhenArgKey]; ok {
+			if _, err := expr.Parse(when); err != nil {
+				return fmt.Errorf("invalid %q option: %w", WhenArgKey, err)
 			}
 		}
 		return nil
@@ -79,6 +102,9 @@ func (s WorkflowHook) ToValid() *valid.WorkflowHook {
 			Shell:           s.StringVal["shell"],
 			ShellArgs:       s.StringVal["shellArgs"],
 			Commands:        s.StringVal["commands"],
+			When:            s.StringVal[WhenArgKey],
+			Output:          s.StringVal["output"],
+			On:              s.on(),
 		}
 	}
 
@@ -101,10 +127,62 @@ func (s *WorkflowHook) unmarshalGeneric(unmarshal func(interface{}) error) error
 		return nil
 	}
 
-	return err
+	// Try to unmarshal as a run step with a list of results it runs on, ex.
+	// - run: my command
+	//   on: [apply_success]
+	var hook map[string]interface{}
+	if unmarshal(&hook) != nil {
+		return err
+	}
+	on, ok := hook["on"].([]interface{})
+	if !ok {
+		return err
+	}
+	stringVal := make(map[string]string)
+	for key, value := range hook {
+		if key == "on" {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return err
+		}
+		stringVal[key] = str
+	}
+	for _, value := range on {
+		str, ok := value.(string)
+		if !ok {
+			return err
+		}
+		s.On = append(s.On, str)
+	}
+	s.StringVal = stringVal
+	return nil
+}
+
+// on returns the command results the hook runs on. They can be set as a list
+// or a comma separated string.
+func (s WorkflowHook) on() []string {
+	if len(s.On) > 0 {
+		return s.On
+	}
+	var on []string
+	for _, value := range strings.Split(s.StringVal["on"], ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			on = append(on, value)
+		}
+	}
+	return on
 }
 
 func (s WorkflowHook) marshalGeneric() (interface{}, error) {
+	if len(s.On) > 0 {
+		hook := map[string]interface{}{"on": s.On}
+		for key, value := range s.StringVal {
+			hook[key] = value
+		}
+		return hook, nil
+	}
 	if len(s.StringVal) != 0 {
 		return s.StringVal, nil
 	}
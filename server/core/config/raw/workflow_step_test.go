@@ -39,8 +39,28 @@ key: value`,
 				},
 			},
 		},
+		{
+			description: "run step with on list",
+			input: `
+run: my command
+on: [apply_success, apply_failure]`,
+			exp: raw.WorkflowHook{
+				StringVal: map[string]string{
+					"run": "my command",
+				},
+				On: []string{"apply_success", "apply_failure"},
+			},
+		},
 
 		// Errors
+		{
+			description: "on list of maps",
+			input: `
+run: my command
+on:
+- apply: success`,
+			expErr: "yaml: unmarshal errors:\n  line 4: cannot unmarshal !!seq into string",
+		},
 		{
 			description: "extra args style no slice strings",
 			input: `
@@ -97,6 +117,45 @@ func TestGlobalConfigStep_Validate(t *testing.T) {
 			},
 			expErr: "\"invalid\" is not a valid step type",
 		},
+		{
+			description: "run step with options",
+			input: raw.WorkflowHook{
+				StringVal: map[string]string{
+					"run":         "my command",
+					"description": "my description",
+					"on":          "apply_success",
+				},
+			},
+		},
+		{
+			description: "invalid option of run step",
+			input: raw.WorkflowHook{
+				StringVal: map[string]string{
+					"run":     "my command",
+					"invalid": "",
+				},
+			},
+			expErr: "\"invalid\" is not a valid option of a run step, only description, shell, shellArgs, commands, when, output, on are supported",
+		},
+		{
+			description: "run step with when",
+			input: raw.WorkflowHook{
+				StringVal: map[string]string{
+					"run":  "my command",
+					"when": `command == "plan" && pull.base_branch == "main"`,
+				},
+			},
+		},
+		{
+			description: "run step with invalid when",
+			input: raw.WorkflowHook{
+				StringVal: map[string]string{
+					"run":  "my command",
+					"when": `branch == "main"`,
+				},
+			},
+			expErr: "invalid \"when\" option: parsing \"branch == \\\"main\\\"\": unknown variable \"branch\", only command, result, event, repo, user, pull, project are supported",
+		},
 		{
 			// For atlantis.yaml v2, this wouldn't parse, but now there should
 			// be no error.
@@ -138,6 +197,48 @@ func TestWorkflowHook_ToValid(t *testing.T) {
 				RunCommand: "my 'run command'",
 			},
 		},
+		{
+			description: "run step with output",
+			input: raw.WorkflowHook{
+				StringVal: map[string]string{
+					"run":    "./list-projects.sh",
+					"output": "comment",
+				},
+			},
+			exp: &valid.WorkflowHook{
+				StepName:   "run",
+				RunCommand: "./list-projects.sh",
+				Output:     "comment",
+			},
+		},
+		{
+			description: "run step with on",
+			input: raw.WorkflowHook{
+				StringVal: map[string]string{
+					"run": "./notify.sh",
+					"on":  "apply_success, apply_failure",
+				},
+			},
+			exp: &valid.WorkflowHook{
+				StepName:   "run",
+				RunCommand: "./notify.sh",
+				On:         []string{"apply_success", "apply_failure"},
+			},
+		},
+		{
+			description: "run step with when",
+			input: raw.WorkflowHook{
+				StringVal: map[string]string{
+					"run":  "./notify.sh",
+					"when": `result == "failure"`,
+				},
+			},
+			exp: &valid.WorkflowHook{
+				StepName:   "run",
+				RunCommand: "./notify.sh",
+				When:       `result == "failure"`,
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
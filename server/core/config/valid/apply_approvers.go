@@ -0,0 +1,41 @@
+package valid
+
+// ApplyApprovers are the approvals a pull request needs before it can be
+// applied, on top of the apply requirements.
+type ApplyApprovers struct {
+	// Users, Teams and Orgs are the users, and the teams and organizations
+	// whose members, whose approvals count. If all are empty, the approvals
+	// of anyone count.
+	Users []string
+	Teams []string
+	Orgs  []string
+	// Count is how many approvals are needed.
+	Count int
+	// ExcludeAuthor is true if the approval of the author of the pull
+	// request doesn't count.
+	ExcludeAuthor bool
+}
+
+// ApplyApprovers returns the approvals that pull requests of the repo with
+// repoID need before they can be applied. Later matching repos take
+// precedence. It returns nil if none are configured.
+func (g GlobalCfg) ApplyApprovers(repoID string) *ApplyApprovers {
+	var applyApprovers *ApplyApprovers
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.ApplyApprovers != nil {
+			applyApprovers = repo.ApplyApprovers
+		}
+	}
+	return applyApprovers
+}
+
+// ApplyApproversTeams returns the teams of the apply_approvers of every repo.
+func (g GlobalCfg) ApplyApproversTeams() []string {
+	var teams []string
+	for _, repo := range g.Repos {
+		if repo.ApplyApprovers != nil {
+			teams = append(teams, repo.ApplyApprovers.Teams...)
+		}
+	}
+	return teams
+}
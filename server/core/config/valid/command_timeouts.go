@@ -0,0 +1,71 @@
+package valid
+
+import "time"
+
+// CommandTimeouts are how long commands can run before they're canceled. A
+// timeout of 0 means the command is never canceled.
+type CommandTimeouts struct {
+	Plan        time.Duration
+	Apply       time.Duration
+	PolicyCheck time.Duration
+	// Custom is the timeout of the custom commands defined in the server-side
+	// repo config.
+	Custom time.Duration
+}
+
+// RepoCommandTimeouts override the command timeouts of a repo. Timeouts that
+// are nil aren't overridden.
+type RepoCommandTimeouts struct {
+	Plan        *time.Duration
+	Apply       *time.Duration
+	PolicyCheck *time.Duration
+	Custom      *time.Duration
+}
+
+// For returns the timeout of the command named cmdName, ex. "plan". Commands
+// without a timeout return 0.
+func (c CommandTimeouts) For(cmdName string) time.Duration {
+	switch cmdName {
+	case "plan":
+		return c.Plan
+	case "apply":
+		return c.Apply
+	case "policy_check":
+		return c.PolicyCheck
+	case "custom":
+		return c.Custom
+	}
+	return 0
+}
+
+// Override returns the timeouts with the ones set in o overridden.
+func (c CommandTimeouts) Override(o *RepoCommandTimeouts) CommandTimeouts {
+	if o == nil {
+		return c
+	}
+	if o.Plan != nil {
+		c.Plan = *o.Plan
+	}
+	if o.Apply != nil {
+		c.Apply = *o.Apply
+	}
+	if o.PolicyCheck != nil {
+		c.PolicyCheck = *o.PolicyCheck
+	}
+	if o.Custom != nil {
+		c.Custom = *o.Custom
+	}
+	return c
+}
+
+// CommandTimeouts returns the command timeouts of the repo with repoID. Each
+// timeout is set by the last matching repo that sets it.
+func (g GlobalCfg) CommandTimeouts(repoID string) CommandTimeouts {
+	var timeouts CommandTimeouts
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) {
+			timeouts = timeouts.Override(repo.CommandTimeouts)
+		}
+	}
+	return timeouts
+}
@@ -0,0 +1,48 @@
+package valid
+
+import "strings"
+
+// CommentArgs are the default extra args of the plans and applies of a
+// project that are run by comments.
+type CommentArgs struct {
+	Plan  []string
+	Apply []string
+}
+
+// Merge returns the args of the command named cmdName, ex. "plan", with the
+// default args merged into the args of the comment. A default arg is dropped
+// if the comment sets the same flag, ex. -parallelism=10 overrides
+// -parallelism=30, and the others come before the comment's args.
+func (c CommentArgs) Merge(cmdName string, commentArgs []string) []string {
+	var defaults []string
+	switch cmdName {
+	case "plan":
+		defaults = c.Plan
+	case "apply":
+		defaults = c.Apply
+	}
+	if len(defaults) == 0 {
+		return commentArgs
+	}
+
+	commentFlags := make(map[string]bool)
+	for _, arg := range commentArgs {
+		if strings.HasPrefix(arg, "-") {
+			commentFlags[commentArgFlag(arg)] = true
+		}
+	}
+	var args []string
+	for _, arg := range defaults {
+		if !commentFlags[commentArgFlag(arg)] {
+			args = append(args, arg)
+		}
+	}
+	return append(args, commentArgs...)
+}
+
+// commentArgFlag returns the name of the flag set by arg, ex. "var-file" for
+// --var-file=prod.tfvars.
+func commentArgFlag(arg string) string {
+	name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	return name
+}
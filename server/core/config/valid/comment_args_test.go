@@ -0,0 +1,57 @@
+package valid_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCommentArgs_Merge(t *testing.T) {
+	args := valid.CommentArgs{
+		Plan:  []string{"-parallelism=30", "-var-file=prod.tfvars"},
+		Apply: []string{"-parallelism=30"},
+	}
+	cases := []struct {
+		description string
+		cmdName     string
+		commentArgs []string
+		exp         []string
+	}{
+		{
+			description: "no comment args",
+			cmdName:     "plan",
+			exp:         []string{"-parallelism=30", "-var-file=prod.tfvars"},
+		},
+		{
+			description: "defaults come first",
+			cmdName:     "plan",
+			commentArgs: []string{"-target=module.db"},
+			exp:         []string{"-parallelism=30", "-var-file=prod.tfvars", "-target=module.db"},
+		},
+		{
+			description: "comment overrides a default",
+			cmdName:     "plan",
+			commentArgs: []string{"--var-file=dev.tfvars"},
+			exp:         []string{"-parallelism=30", "--var-file=dev.tfvars"},
+		},
+		{
+			description: "comment overrides a default with a separate value",
+			cmdName:     "apply",
+			commentArgs: []string{"-parallelism", "10"},
+			exp:         []string{"-parallelism", "10"},
+		},
+		{
+			description: "command without defaults",
+			cmdName:     "import",
+			commentArgs: []string{"-var=a=b"},
+			exp:         []string{"-var=a=b"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			Equals(t, c.exp, args.Merge(c.cmdName, c.commentArgs))
+		})
+	}
+	Equals(t, []string(nil), valid.CommentArgs{}.Merge("plan", nil))
+}
@@ -0,0 +1,16 @@
+package valid
+
+// CommentTemplates are the paths in the repo of the template files that
+// override how the comments of the repo's commands are rendered. Empty paths
+// use the default templates.
+type CommentTemplates struct {
+	// Plan renders the comments of plans.
+	Plan string
+	// Apply renders the comments of applies.
+	Apply string
+	// PolicyCheck renders the comments of policy checks.
+	PolicyCheck string
+	// Error renders the comments of commands that failed before any project
+	// was run.
+	Error string
+}
@@ -0,0 +1,154 @@
+package valid
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronDescriptors are the shorthands supported in place of the five fields
+// of a cron expression.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField describes the allowed values of a field of a cron expression.
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	// 7 is also Sunday.
+	{name: "day of week", min: 0, max: 7},
+}
+
+// CronSchedule is a parsed cron expression in the standard five field format:
+// minute, hour, day of month, month and day of week.
+type CronSchedule struct {
+	// Spec is the cron expression, ex. 0 3 * * *.
+	Spec string
+
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	// domStar and dowStar are true if the day of month or day of week fields
+	// start with *, ex. * or */2. As in cron, if both are restricted a time
+	// matches if either matches.
+	domStar bool
+	dowStar bool
+}
+
+// ParseCronSchedule parses spec, ex. 30 2 * * 1-5 or @daily. Fields support
+// *, lists (1,15), ranges (1-5) and steps (*/15, 0-30/10).
+func ParseCronSchedule(spec string) (*CronSchedule, error) {
+	expr := strings.TrimSpace(spec)
+	if descriptor, ok := cronDescriptors[expr]; ok {
+		expr = descriptor
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("expected %d fields but got %d", len(cronFields), len(fields))
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+	// Treat 7 as Sunday.
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	return &CronSchedule{
+		Spec:       spec,
+		minute:     sets[0],
+		hour:       sets[1],
+		dayOfMonth: sets[2],
+		month:      sets[3],
+		dayOfWeek:  sets[4],
+		domStar:    strings.HasPrefix(fields[2], "*"),
+		dowStar:    strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// Matches returns true if the schedule is due at the minute of t.
+func (c *CronSchedule) Matches(t time.Time) bool {
+	if c.minute&(1<<uint(t.Minute())) == 0 ||
+		c.hour&(1<<uint(t.Hour())) == 0 ||
+		c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatch := c.dayOfMonth&(1<<uint(t.Day())) != 0
+	dowMatch := c.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parseCronField returns the set of values matched by expr as a bitset.
+func parseCronField(expr string, field cronField) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepExpr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepExpr, field.name)
+			}
+		}
+
+		start, end := field.min, field.max
+		if rangeExpr != "*" {
+			startExpr, endExpr, isRange := strings.Cut(rangeExpr, "-")
+			var err error
+			if start, err = parseCronValue(startExpr, field); err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				if end, err = parseCronValue(endExpr, field); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// 5/15 means every 15 starting at 5.
+				end = field.max
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangeExpr, field.name)
+			}
+		}
+		for v := start; v <= end; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func parseCronValue(expr string, field cronField) (int, error) {
+	v, err := strconv.Atoi(expr)
+	if err != nil || v < field.min || v > field.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, must be between %d and %d", expr, field.name, field.min, field.max)
+	}
+	return v, nil
+}
@@ -0,0 +1,74 @@
+package valid_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestParseCronSchedule_Errors(t *testing.T) {
+	cases := map[string]string{
+		"":              "expected 5 fields but got 0",
+		"* * * *":       "expected 5 fields but got 4",
+		"60 * * * *":    `invalid value "60" in minute field, must be between 0 and 59`,
+		"* 24 * * *":    `invalid value "24" in hour field, must be between 0 and 23`,
+		"* * 0 * *":     `invalid value "0" in day of month field, must be between 1 and 31`,
+		"* * * 13 *":    `invalid value "13" in month field, must be between 1 and 12`,
+		"* * * * 8":     `invalid value "8" in day of week field, must be between 0 and 7`,
+		"*/0 * * * *":   `invalid step "0" in minute field`,
+		"30-10 * * * *": `invalid range "30-10" in minute field`,
+		"@often":        "expected 5 fields but got 1",
+	}
+	for spec, expErr := range cases {
+		t.Run(spec, func(t *testing.T) {
+			_, err := valid.ParseCronSchedule(spec)
+			ErrEquals(t, expErr, err)
+		})
+	}
+}
+
+func TestCronSchedule_Matches(t *testing.T) {
+	// 2024-01-01 was a Monday.
+	monday := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		spec string
+		time time.Time
+		exp  bool
+	}{
+		{"* * * * *", monday(13, 37), true},
+		{"0 3 * * *", monday(3, 0), true},
+		{"0 3 * * *", monday(3, 1), false},
+		{"@daily", monday(0, 0), true},
+		{"@hourly", monday(5, 0), true},
+		{"@hourly", monday(5, 30), false},
+		{"*/15 * * * *", monday(5, 45), true},
+		{"*/15 * * * *", monday(5, 50), false},
+		{"5/20 * * * *", monday(5, 25), true},
+		{"0-30/10 * * * *", monday(5, 40), false},
+		{"0 9-17 * * 1-5", monday(12, 0), true},
+		{"0 9-17 * * 1-5", monday(18, 0), false},
+		{"0 0 * * 0", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 * * 7", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 1,15 * *", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 1 6 *", monday(0, 0), false},
+		// When both day of month and day of week are set either can match.
+		{"0 0 15 * 1", monday(0, 0), true},
+		{"0 0 15 * 2", monday(0, 0), false},
+		{"0 0 1 * 2", monday(0, 0), true},
+		// A field starting with * isn't restricted so both must match.
+		{"0 0 */2 * 1", monday(0, 0), true},
+		{"0 0 */2 * 1", time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), false},
+		{"0 0 1 * */2", monday(0, 0), false},
+	}
+	for _, c := range cases {
+		t.Run(c.spec, func(t *testing.T) {
+			schedule, err := valid.ParseCronSchedule(c.spec)
+			Ok(t, err)
+			Equals(t, c.exp, schedule.Matches(c.time))
+		})
+	}
+}
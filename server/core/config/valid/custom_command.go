@@ -0,0 +1,40 @@
+package valid
+
+// CustomCommand is a comment command defined in the server-side repo config,
+// ex. `atlantis docs`. It runs the custom stage of its workflow for each
+// project.
+type CustomCommand struct {
+	// Name is what the command is run with, ex. "docs".
+	Name string
+	// Description is shown in the help comment.
+	Description string
+	// Workflow is the name of the workflow whose custom stage the command
+	// runs.
+	Workflow string
+}
+
+// ReservedCommandNames are the names of the built-in comment commands, which
+// custom commands can't use.
+var ReservedCommandNames = []string{
+	"apply",
+	"approve_policies",
+	"help",
+	"import",
+	"ok-to-test",
+	"plan",
+	"policy_check",
+	"state",
+	"unlock",
+	"version",
+}
+
+// CustomCommand returns the custom command named name and true, or false if
+// there isn't one.
+func (g GlobalCfg) CustomCommand(name string) (CustomCommand, bool) {
+	for _, c := range g.CustomCommands {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return CustomCommand{}, false
+}
@@ -0,0 +1,38 @@
+package valid
+
+// Commands that can be disabled per repo with disabled_commands.
+// PlanAllCommand and ApplyAllCommand are plan and apply comments that don't
+// select projects with -p, -d or -w.
+const (
+	ApprovePoliciesCommand = "approve_policies"
+	ImportCommand          = "import"
+	StateCommand           = "state"
+	UnlockCommand          = "unlock"
+	VersionCommand         = "version"
+	PlanAllCommand         = "plan_all"
+	ApplyAllCommand        = "apply_all"
+)
+
+// DisableableCommands are the commands that can be disabled per repo.
+var DisableableCommands = []string{
+	ApprovePoliciesCommand,
+	ImportCommand,
+	StateCommand,
+	UnlockCommand,
+	VersionCommand,
+	PlanAllCommand,
+	ApplyAllCommand,
+}
+
+// DisabledCommands returns the commands that are disabled for the repo with
+// repoID. Later matching repos take precedence so an empty list re-enables
+// the commands disabled by an earlier match.
+func (g GlobalCfg) DisabledCommands(repoID string) []string {
+	var disabled []string
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.DisabledCommands != nil {
+			disabled = repo.DisabledCommands
+		}
+	}
+	return disabled
+}
@@ -0,0 +1,48 @@
+package valid
+
+// DriftDetection is the drift detection config for a repo.
+type DriftDetection struct {
+	// Branch is the branch that is checked for drift. If empty, the repo's
+	// default branch is used.
+	Branch string
+	// Projects are the projects that are checked for drift.
+	Projects []DriftDetectionProject
+}
+
+// DriftDetectionProject identifies a project to check for drift, either by
+// name or by dir and workspace.
+type DriftDetectionProject struct {
+	Name      string
+	Dir       string
+	Workspace string
+	// OnDrift is what to do when drift is detected. If nil, drift is only
+	// recorded.
+	OnDrift *OnDrift
+}
+
+// OnDriftAction enum
+type OnDriftAction string
+
+const (
+	// OnDriftIssueAction opens an issue in the repo.
+	OnDriftIssueAction OnDriftAction = "issue"
+	// OnDriftCommentAction comments on a pull request in the repo.
+	OnDriftCommentAction OnDriftAction = "comment"
+)
+
+// OnDrift configures how users are notified when drift is detected.
+type OnDrift struct {
+	Action OnDriftAction
+	// PullRequest is the pull request to comment on when Action is
+	// OnDriftCommentAction.
+	PullRequest int
+}
+
+// Matches returns true if this is the project with the given name, dir and
+// workspace.
+func (p DriftDetectionProject) Matches(name string, dir string, workspace string) bool {
+	if p.Name != "" {
+		return p.Name == name
+	}
+	return p.Dir == dir && (p.Workspace == "" || p.Workspace == workspace)
+}
@@ -0,0 +1,31 @@
+package valid_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestDriftDetectionProject_Matches(t *testing.T) {
+	cases := []struct {
+		description string
+		project     valid.DriftDetectionProject
+		name        string
+		dir         string
+		workspace   string
+		exp         bool
+	}{
+		{"by name", valid.DriftDetectionProject{Name: "prod"}, "prod", "prod", "default", true},
+		{"different name", valid.DriftDetectionProject{Name: "prod"}, "staging", "prod", "default", false},
+		{"by dir in any workspace", valid.DriftDetectionProject{Dir: "prod"}, "", "prod", "blue", true},
+		{"by dir and workspace", valid.DriftDetectionProject{Dir: "prod", Workspace: "blue"}, "", "prod", "blue", true},
+		{"different workspace", valid.DriftDetectionProject{Dir: "prod", Workspace: "blue"}, "", "prod", "green", false},
+		{"different dir", valid.DriftDetectionProject{Dir: "prod"}, "", "staging", "default", false},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			Equals(t, c.exp, c.project.Matches(c.name, c.dir, c.workspace))
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package valid
+
+// DefaultGitTokenUser is the user that tokens read from token files are
+// passed with, which GitHub, GitLab and Gitea accept for any token.
+const DefaultGitTokenUser = "x-access-token"
+
+// GitCredentials are the credentials a repo is cloned with instead of the
+// ones of the VCS host's user. Exactly one of their sources is set.
+type GitCredentials struct {
+	// SSHKeyFile is the path to the private key the repo is cloned with over
+	// SSH, ex. a deploy key of the repo.
+	SSHKeyFile string
+	// TokenFile is the path to the file with the token the repo is cloned
+	// with over HTTPS, as TokenUser.
+	TokenFile string
+	TokenUser string
+	// GithubAppInstallationID is the installation of the server's GitHub App
+	// whose tokens the repo is cloned with, ex. the one of the repo's org.
+	GithubAppInstallationID int64
+}
+
+// GitCredentials returns the credentials the repo with repoID is cloned with.
+// Later matching repos take precedence. It returns nil if none are
+// configured.
+func (g GlobalCfg) GitCredentials(repoID string) *GitCredentials {
+	var gitCredentials *GitCredentials
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.GitCredentials != nil {
+			gitCredentials = repo.GitCredentials
+		}
+	}
+	return gitCredentials
+}
@@ -0,0 +1,50 @@
+package valid
+
+import "time"
+
+// DefaultGithubEnvironmentTimeout is how long Atlantis waits for the
+// protection rules of a GitHub environment if no timeout is configured.
+const DefaultGithubEnvironmentTimeout = time.Hour
+
+// GithubEnvironment is the config for the projects of a repo that are
+// deployed to a GitHub environment. Before they're applied, a deployment to
+// the environment is created and its protection rules, ex. required
+// reviewers and wait timers, must pass.
+type GithubEnvironment struct {
+	// Name is the name of the GitHub environment.
+	Name string
+	// Projects are the projects deployed to the environment. If empty, every
+	// project of the repo is.
+	Projects []GithubEnvironmentProject
+	// Timeout is how long to wait for the protection rules to pass before
+	// failing the apply.
+	Timeout time.Duration
+}
+
+// GithubEnvironmentProject identifies a project deployed to a GitHub
+// environment, either by name or by dir and workspace.
+type GithubEnvironmentProject struct {
+	Name      string
+	Dir       string
+	Workspace string
+}
+
+// Matches returns true if the project named name at dir and workspace is
+// deployed to the environment.
+func (e GithubEnvironment) Matches(name string, dir string, workspace string) bool {
+	if len(e.Projects) == 0 {
+		return true
+	}
+	for _, p := range e.Projects {
+		if p.Name != "" {
+			if p.Name == name {
+				return true
+			}
+			continue
+		}
+		if p.Dir == dir && (p.Workspace == "" || p.Workspace == workspace) {
+			return true
+		}
+	}
+	return false
+}
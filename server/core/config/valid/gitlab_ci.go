@@ -0,0 +1,48 @@
+package valid
+
+import (
+	"time"
+
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+// DefaultGitlabCITimeout is how long Atlantis waits for GitLab CI jobs if
+// no timeout is configured.
+const DefaultGitlabCITimeout = 10 * time.Minute
+
+// GitlabCICommands are the commands that can wait for GitLab CI jobs.
+var GitlabCICommands = []string{"plan", "apply"}
+
+// GitlabCI is the config for the GitLab CI jobs of a repo's merge requests
+// that must succeed before commands are run on them, so that Atlantis and
+// GitLab CI don't race on the same merge request.
+type GitlabCI struct {
+	// WaitForJobs are the names of the jobs that must succeed.
+	WaitForJobs []string
+	// TriggerJobs are the names of the manual jobs that are started and must
+	// succeed.
+	TriggerJobs []string
+	// Commands are the commands that wait for the jobs, ex. plan and apply.
+	// Autoplans wait if plan is one of them.
+	Commands []string
+	// Timeout is how long to wait for the jobs before failing the command.
+	Timeout time.Duration
+}
+
+// AppliesTo returns true if the command named cmdName waits for the jobs.
+func (g GitlabCI) AppliesTo(cmdName string) bool {
+	return utils.SlicesContains(g.Commands, cmdName)
+}
+
+// Jobs returns the names of every job that must succeed, the jobs that are
+// waited for followed by the jobs that are triggered.
+func (g GitlabCI) Jobs() []string {
+	var jobs []string
+	jobs = append(jobs, g.WaitForJobs...)
+	for _, job := range g.TriggerJobs {
+		if !utils.SlicesContains(jobs, job) {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
@@ -3,6 +3,7 @@ package valid
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	version "github.com/hashicorp/go-version"
@@ -28,6 +29,9 @@ const PolicyCheckKey = "policy_check"
 const CustomPolicyCheckKey = "custom_policy_check"
 const AutoDiscoverKey = "autodiscover"
 const SilencePRCommentsKey = "silence_pr_comments"
+const PreviewEnvironmentKey = "preview_environment"
+const DestroyOnCloseKey = "destroy_on_close"
+const CommentTemplatesKey = "comment_templates"
 
 var AllowedSilencePRComments = []string{"plan", "apply"}
 
@@ -48,6 +52,9 @@ type GlobalCfg struct {
 	PolicySets PolicySets
 	Metrics    Metrics
 	TeamAuthz  TeamAuthz
+	// CustomCommands are the comment commands defined in addition to the
+	// built-in ones.
+	CustomCommands []CustomCommand
 }
 
 type Metrics struct {
@@ -90,6 +97,63 @@ type Repo struct {
 	CustomPolicyCheck         *bool
 	AutoDiscover              *AutoDiscover
 	SilencePRComments         []string
+	// WebhookSecret overrides the VCS host's global webhook secret for
+	// webhooks from this repo. If empty, the global secret is used.
+	WebhookSecret string
+	// DriftDetection configures which projects in this repo are periodically
+	// checked for drift. If nil, the repo isn't checked.
+	DriftDetection *DriftDetection
+	// ScheduledApplies configures which projects in this repo are re-planned
+	// and applied on a schedule.
+	ScheduledApplies []ScheduledApply
+	// AutoApplyDestroyOnClose is true if the destroy plans of projects with
+	// destroy_on_close are applied when a pull request is closed. Otherwise
+	// they're only commented on the pull request.
+	AutoApplyDestroyOnClose *bool
+	// ShadowMode is true if plans of this repo are run without commenting on
+	// or setting statuses of its pull requests, ex. to validate a new repo or
+	// server version against real pull requests.
+	ShadowMode *bool
+	// ManualTrigger configures which projects of this repo are only planned
+	// and applied by its operators. If nil, no project is.
+	ManualTrigger *ManualTrigger
+	// DisabledCommands are the comment commands that can't be run on pull
+	// requests of this repo. If nil, the setting of an earlier matching repo
+	// is used.
+	DisabledCommands []string
+	// GitlabCI configures the GitLab CI jobs that must succeed before plan
+	// and apply are run on merge requests of this repo. If nil, Atlantis
+	// doesn't wait for any.
+	GitlabCI *GitlabCI
+	// GithubEnvironments configure which projects of this repo are deployed
+	// to GitHub environments whose protection rules must pass before they're
+	// applied. If nil, the setting of an earlier matching repo is used.
+	GithubEnvironments []GithubEnvironment
+	// StackedPulls is how pull requests of this repo that are stacked on
+	// another open pull request are handled. If nil, the setting of an
+	// earlier matching repo is used.
+	StackedPulls *StackedPullsMode
+	// CommandTimeouts override how long commands run on pull requests of
+	// this repo can run before they're canceled.
+	CommandTimeouts *RepoCommandTimeouts
+	// StateRmAllowlist are the patterns of the resource addresses that can
+	// be removed from state with state rm. If nil, the setting of an earlier
+	// matching repo is used.
+	StateRmAllowlist []string
+	// MaintenanceWindows are the recurring time windows during which applies
+	// of this repo are disabled. If nil, the setting of an earlier matching
+	// repo is used.
+	MaintenanceWindows []MaintenanceWindow
+	// GitCredentials are the credentials this repo is cloned with. If nil,
+	// the setting of an earlier matching repo is used.
+	GitCredentials *GitCredentials
+	// ApplyApprovers are the approvals pull requests of this repo need
+	// before they can be applied. If nil, the setting of an earlier matching
+	// repo is used.
+	ApplyApprovers *ApplyApprovers
+	// RepoCfgOverlay is the repo config added to the repo config of this
+	// repo. The overlays of all matching repos are merged.
+	RepoCfgOverlay *RepoCfgOverlay
 }
 
 type MergedProjectCfg struct {
@@ -115,6 +179,37 @@ type MergedProjectCfg struct {
 	PolicyCheck               bool
 	CustomPolicyCheck         bool
 	SilencePRComments         []string
+	// TerraformVars are set with -var when planning, ex. the vars of a
+	// preview environment.
+	TerraformVars map[string]string
+	// DestroyOnClose is true if the project's resources are destroyed when
+	// the pull request is closed.
+	DestroyOnClose bool
+	// AutoApplyDestroyOnClose is true if the destroy is applied rather than
+	// only planned.
+	AutoApplyDestroyOnClose bool
+	// ManualTriggerOperators are set if the project is in manual trigger
+	// mode. Only they can plan and apply it.
+	ManualTriggerOperators []string
+	// GithubEnvironment is set if the project is deployed to a GitHub
+	// environment whose protection rules must pass before it's applied.
+	GithubEnvironment *GithubEnvironment
+	// CommandTimeouts are how long commands run for the project can run
+	// before they're canceled.
+	CommandTimeouts CommandTimeouts
+	// PreWorkflowHooks and PostWorkflowHooks are the project's workflow
+	// hooks from the repo config.
+	PreWorkflowHooks  []*WorkflowHook
+	PostWorkflowHooks []*WorkflowHook
+	// CommentArgs are the default extra args of the project's plans and
+	// applies that are run by comments.
+	CommentArgs CommentArgs
+	// PromoteTo is the name of the project that is planned once this one is
+	// applied since it's the next stage of its promotion.
+	PromoteTo string
+	// SuppressedChanges are the kinds of noisy changes that are suppressed
+	// from the project's plan comments.
+	SuppressedChanges []SuppressedChange
 }
 
 // WorkflowHook is a map of custom run commands to run before or after workflows.
@@ -125,6 +220,61 @@ type WorkflowHook struct {
 	Shell           string
 	ShellArgs       string
 	Commands        string
+	// When is an expression that must evaluate to true for the hook to run,
+	// ex. `pull.base_branch == "main"`. If empty, the hook always runs.
+	When string
+	// Output is where the output of a pre workflow hook is posted, if
+	// anywhere. If set, it's one of WorkflowHookOutputs.
+	Output string
+	// On are the command results or lifecycle events a post workflow hook
+	// runs on, ex. "apply_success" or "pull_closed". If empty, it runs after
+	// every command.
+	On []string
+}
+
+// Values of WorkflowHook.Output.
+const (
+	// CommentWorkflowHookOutput posts the hook's output as its own comment.
+	CommentWorkflowHookOutput = "comment"
+	// CommandCommentWorkflowHookOutput prepends the hook's output to the
+	// comment of the command it ran before, ex. the plan comment.
+	CommandCommentWorkflowHookOutput = "command_comment"
+)
+
+// WorkflowHookOutputs are the supported values of WorkflowHook.Output.
+var WorkflowHookOutputs = []string{CommentWorkflowHookOutput, CommandCommentWorkflowHookOutput}
+
+// Command results post workflow hooks can run on.
+const (
+	PlanSuccessHookEvent   = "plan_success"
+	PlanFailureHookEvent   = "plan_failure"
+	ApplySuccessHookEvent  = "apply_success"
+	ApplyFailureHookEvent  = "apply_failure"
+	PolicySuccessHookEvent = "policy_success"
+	PolicyFailureHookEvent = "policy_failure"
+)
+
+// Lifecycle events post workflow hooks can run on. Unlike command results,
+// they're not tied to a command.
+const (
+	LockCreatedHookEvent         = "lock_created"
+	LockReleasedHookEvent        = "lock_released"
+	PullClosedHookEvent          = "pull_closed"
+	UnrecognizedCommentHookEvent = "comment_unrecognized"
+)
+
+// WorkflowHookEvents are the supported values of WorkflowHook.On.
+var WorkflowHookEvents = []string{
+	PlanSuccessHookEvent,
+	PlanFailureHookEvent,
+	ApplySuccessHookEvent,
+	ApplyFailureHookEvent,
+	PolicySuccessHookEvent,
+	PolicyFailureHookEvent,
+	LockCreatedHookEvent,
+	LockReleasedHookEvent,
+	PullClosedHookEvent,
+	UnrecognizedCommentHookEvent,
 }
 
 // DefaultApplyStage is the Atlantis default apply stage.
@@ -193,6 +343,9 @@ type GlobalCfgArgs struct {
 	PolicyCheckEnabled   bool
 	PreWorkflowHooks     []*WorkflowHook
 	PostWorkflowHooks    []*WorkflowHook
+	// CommandTimeouts are the server's command timeouts, which repos can
+	// override.
+	CommandTimeouts CommandTimeouts
 }
 
 func NewGlobalCfgFromArgs(args GlobalCfgArgs) GlobalCfg {
@@ -221,6 +374,12 @@ func NewGlobalCfgFromArgs(args GlobalCfgArgs) GlobalCfg {
 	customPolicyCheck := false
 	autoDiscover := AutoDiscover{Mode: AutoDiscoverAutoMode}
 	var silencePRComments []string
+	commandTimeouts := RepoCommandTimeouts{
+		Plan:        &args.CommandTimeouts.Plan,
+		Apply:       &args.CommandTimeouts.Apply,
+		PolicyCheck: &args.CommandTimeouts.PolicyCheck,
+		Custom:      &args.CommandTimeouts.Custom,
+	}
 	if args.AllowAllRepoSettings {
 		allowedOverrides = []string{PlanRequirementsKey, ApplyRequirementsKey, ImportRequirementsKey, WorkflowKey, DeleteSourceBranchOnMergeKey, RepoLockingKey, RepoLocksKey, PolicyCheckKey, SilencePRCommentsKey}
 		allowCustomWorkflows = true
@@ -247,6 +406,7 @@ func NewGlobalCfgFromArgs(args GlobalCfgArgs) GlobalCfg {
 				CustomPolicyCheck:         &customPolicyCheck,
 				AutoDiscover:              &autoDiscover,
 				SilencePRComments:         silencePRComments,
+				CommandTimeouts:           &commandTimeouts,
 			},
 		},
 		Workflows: map[string]Workflow{
@@ -287,6 +447,16 @@ func (r Repo) IDString() string {
 func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, proj Project, rCfg RepoCfg) MergedProjectCfg {
 	log.Debug("MergeProjectCfg started")
 	planReqs, applyReqs, importReqs, workflow, allowedOverrides, allowCustomWorkflows, deleteSourceBranchOnMerge, repoLocks, policyCheck, customPolicyCheck, _, silencePRComments := g.getMatchingCfg(log, repoID)
+	// Projects of the server-side repo config can set any key.
+	if proj.ServerSide {
+		allowedOverrides = slices.Clone(allowedOverrides)
+		for _, key := range proj.serverSideOverrides() {
+			if !slices.Contains(allowedOverrides, key) {
+				allowedOverrides = append(allowedOverrides, key)
+			}
+		}
+	}
+	overlay := g.RepoCfgOverlay(repoID)
 	// If repos are allowed to override certain keys then override them.
 	for _, key := range allowedOverrides {
 		switch key {
@@ -312,8 +482,9 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 			}
 		case WorkflowKey:
 			if proj.WorkflowName != nil {
-				// We iterate over the global workflows first and the repo
-				// workflows second so that repo workflows override. This is
+				// We iterate over the global workflows first, the workflows
+				// of the server-side repo config second and the repo
+				// workflows last so that repo workflows override. This is
 				// safe because at this point we know if a repo is allowed to
 				// define its own workflow. We also know that a workflow will
 				// exist with this name due to earlier validation.
@@ -323,7 +494,12 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 						workflow = v
 					}
 				}
-				if allowCustomWorkflows {
+				if overlay != nil {
+					if v, ok := overlay.Workflows[name]; ok {
+						workflow = v
+					}
+				}
+				if allowCustomWorkflows && !proj.ServerSide {
 					for k, v := range rCfg.Workflows {
 						if k == name {
 							workflow = v
@@ -391,6 +567,42 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 		log.Debug("MergeProjectCfg completed")
 	}
 
+	var terraformVars map[string]string
+	destroyOnClose := proj.DestroyOnClose != nil && *proj.DestroyOnClose
+	autoApplyDestroyOnClose := g.autoApplyDestroyOnClose(repoID)
+	// Preview environments are always destroyed.
+	if proj.PreviewEnvironment != nil {
+		terraformVars = proj.PreviewEnvironment.Vars
+		destroyOnClose = true
+		autoApplyDestroyOnClose = true
+	}
+	// Nothing is applied in repos in shadow mode.
+	if g.ShadowMode(repoID) {
+		autoApplyDestroyOnClose = false
+	}
+	autoplanEnabled := proj.Autoplan.Enabled
+	// The stages of a promotion after the first one are planned when the
+	// stage before them is applied and can't be applied before it.
+	dependsOn := proj.DependsOn
+	prevStage, nextStage := rCfg.PromotionStages(proj.GetName())
+	if prevStage != "" {
+		log.Debug("project is planned when %q is applied since it's the next stage of its promotion", prevStage)
+		autoplanEnabled = false
+		if !slices.Contains(dependsOn, prevStage) {
+			dependsOn = append(slices.Clone(dependsOn), prevStage)
+		}
+	}
+	manualTriggerOperators := g.manualTriggerOperators(repoID, proj.GetName(), proj.Dir, proj.Workspace)
+	if manualTriggerOperators != nil {
+		log.Debug("project is in manual trigger mode so it's only run by its operators: [%s]", strings.Join(manualTriggerOperators, ","))
+		autoplanEnabled = false
+		autoApplyDestroyOnClose = false
+	}
+	// The repo's suppressed changes apply to all its projects.
+	var suppressedChanges []SuppressedChange
+	suppressedChanges = append(suppressedChanges, rCfg.SuppressedChanges...)
+	suppressedChanges = append(suppressedChanges, proj.SuppressedChanges...)
+
 	log.Debug("final settings: %s: [%s], %s: [%s], %s: [%s], %s: %s, %s: %t, %s: %s, %s: %t, %s: %t, %s: [%s]",
 		PlanRequirementsKey, strings.Join(planReqs, ","),
 		ApplyRequirementsKey, strings.Join(applyReqs, ","),
@@ -410,19 +622,30 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 		Workflow:                  workflow,
 		RepoRelDir:                proj.Dir,
 		Workspace:                 proj.Workspace,
-		DependsOn:                 proj.DependsOn,
+		DependsOn:                 dependsOn,
 		Name:                      proj.GetName(),
-		AutoplanEnabled:           proj.Autoplan.Enabled,
+		AutoplanEnabled:           autoplanEnabled,
 		TerraformDistribution:     proj.TerraformDistribution,
 		TerraformVersion:          proj.TerraformVersion,
 		RepoCfgVersion:            rCfg.Version,
-		PolicySets:                g.PolicySets,
+		PolicySets:                g.PolicySets.ForProject(proj.GetName(), proj.Dir),
 		DeleteSourceBranchOnMerge: deleteSourceBranchOnMerge,
 		ExecutionOrderGroup:       proj.ExecutionOrderGroup,
 		RepoLocks:                 repoLocks,
 		PolicyCheck:               policyCheck,
 		CustomPolicyCheck:         customPolicyCheck,
 		SilencePRComments:         silencePRComments,
+		TerraformVars:             terraformVars,
+		DestroyOnClose:            destroyOnClose,
+		AutoApplyDestroyOnClose:   autoApplyDestroyOnClose,
+		ManualTriggerOperators:    manualTriggerOperators,
+		GithubEnvironment:         g.githubEnvironment(repoID, proj.GetName(), proj.Dir, proj.Workspace),
+		CommandTimeouts:           g.CommandTimeouts(repoID),
+		PreWorkflowHooks:          proj.PreWorkflowHooks,
+		PostWorkflowHooks:         proj.PostWorkflowHooks,
+		CommentArgs:               proj.CommentArgs,
+		PromoteTo:                 nextStage,
+		SuppressedChanges:         suppressedChanges,
 	}
 }
 
@@ -431,6 +654,7 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 func (g GlobalCfg) DefaultProjCfg(log logging.SimpleLogging, repoID string, repoRelDir string, workspace string) MergedProjectCfg {
 	log.Debug("building config based on server-side config")
 	planReqs, applyReqs, importReqs, workflow, _, _, deleteSourceBranchOnMerge, repoLocks, policyCheck, customPolicyCheck, _, silencePRComments := g.getMatchingCfg(log, repoID)
+	manualTriggerOperators := g.manualTriggerOperators(repoID, "", repoRelDir, workspace)
 	return MergedProjectCfg{
 		PlanRequirements:          planReqs,
 		ApplyRequirements:         applyReqs,
@@ -439,15 +663,18 @@ func (g GlobalCfg) DefaultProjCfg(log logging.SimpleLogging, repoID string, repo
 		RepoRelDir:                repoRelDir,
 		Workspace:                 workspace,
 		Name:                      "",
-		AutoplanEnabled:           DefaultAutoPlanEnabled,
+		AutoplanEnabled:           DefaultAutoPlanEnabled && manualTriggerOperators == nil,
 		TerraformDistribution:     nil,
 		TerraformVersion:          nil,
-		PolicySets:                g.PolicySets,
+		PolicySets:                g.PolicySets.ForProject("", repoRelDir),
 		DeleteSourceBranchOnMerge: deleteSourceBranchOnMerge,
 		RepoLocks:                 repoLocks,
 		PolicyCheck:               policyCheck,
 		CustomPolicyCheck:         customPolicyCheck,
 		SilencePRComments:         silencePRComments,
+		ManualTriggerOperators:    manualTriggerOperators,
+		GithubEnvironment:         g.githubEnvironment(repoID, "", repoRelDir, workspace),
+		CommandTimeouts:           g.CommandTimeouts(repoID),
 	}
 }
 
@@ -508,6 +735,12 @@ func (g GlobalCfg) ValidateRepoCfg(rCfg RepoCfg, repoID string) error {
 		if p.CustomPolicyCheck != nil && !utils.SlicesContains(allowedOverrides, CustomPolicyCheckKey) {
 			return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", CustomPolicyCheckKey, AllowedOverridesKey, CustomPolicyCheckKey)
 		}
+		if p.PreviewEnvironment != nil && !utils.SlicesContains(allowedOverrides, PreviewEnvironmentKey) {
+			return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", PreviewEnvironmentKey, AllowedOverridesKey, PreviewEnvironmentKey)
+		}
+		if p.DestroyOnClose != nil && !utils.SlicesContains(allowedOverrides, DestroyOnCloseKey) {
+			return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", DestroyOnCloseKey, AllowedOverridesKey, DestroyOnCloseKey)
+		}
 		if p.SilencePRComments != nil {
 			if !utils.SlicesContains(allowedOverrides, SilencePRCommentsKey) {
 				return fmt.Errorf(
@@ -530,6 +763,12 @@ func (g GlobalCfg) ValidateRepoCfg(rCfg RepoCfg, repoID string) error {
 		}
 	}
 
+	// Comment templates are read from the pull request's branch so they let
+	// its author change how their plans are shown to reviewers.
+	if rCfg.CommentTemplates != nil && !utils.SlicesContains(allowedOverrides, CommentTemplatesKey) {
+		return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", CommentTemplatesKey, AllowedOverridesKey, CommentTemplatesKey)
+	}
+
 	// Check custom workflows.
 	var allowCustomWorkflows bool
 	for _, repo := range g.Repos {
@@ -544,11 +783,19 @@ func (g GlobalCfg) ValidateRepoCfg(rCfg RepoCfg, repoID string) error {
 		return fmt.Errorf("repo config not allowed to define custom workflows: server-side config needs '%s: true'", AllowCustomWorkflowsKey)
 	}
 
+	// Project workflow hooks run arbitrary commands like custom workflows.
+	for _, p := range rCfg.Projects {
+		if (len(p.PreWorkflowHooks) > 0 || len(p.PostWorkflowHooks) > 0) && !allowCustomWorkflows {
+			return fmt.Errorf("repo config not allowed to define project workflow hooks: server-side config needs '%s: true'", AllowCustomWorkflowsKey)
+		}
+	}
+
 	// Check if the repo has set a workflow name that doesn't exist.
+	overlay := g.RepoCfgOverlay(repoID)
 	for _, p := range rCfg.Projects {
 		if p.WorkflowName != nil {
 			name := *p.WorkflowName
-			if !mapContainsF(rCfg.Workflows, name) && !mapContainsF(g.Workflows, name) {
+			if !mapContainsF(rCfg.Workflows, name) && !mapContainsF(g.Workflows, name) && (overlay == nil || !mapContainsF(overlay.Workflows, name)) {
 				return fmt.Errorf("workflow %q is not defined anywhere", name)
 			}
 		}
@@ -708,6 +955,164 @@ func (g GlobalCfg) MatchingRepo(repoID string) *Repo {
 	return nil
 }
 
+// WebhookSecret returns the webhook secret configured for the repo with id
+// repoID. Unlike MatchingRepo, repos that don't set a secret are skipped so a
+// catch-all repo config doesn't hide a more specific one. If no matching repo
+// has a secret this returns an empty string.
+func (g GlobalCfg) WebhookSecret(repoID string) string {
+	for i := len(g.Repos) - 1; i >= 0; i-- {
+		repo := g.Repos[i]
+		if repo.WebhookSecret != "" && repo.IDMatches(repoID) {
+			return repo.WebhookSecret
+		}
+	}
+	return ""
+}
+
+// DriftDetectionRepos returns the repos that have drift detection configured.
+func (g GlobalCfg) DriftDetectionRepos() []Repo {
+	var repos []Repo
+	for _, repo := range g.Repos {
+		if repo.DriftDetection != nil && repo.ID != "" {
+			repos = append(repos, repo)
+		}
+	}
+	return repos
+}
+
+// RepoIDs returns the IDs of the repos that are configured by ID rather than
+// by regex, ex. github.com/runatlantis/atlantis.
+func (g GlobalCfg) RepoIDs() []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, repo := range g.Repos {
+		if repo.ID != "" && !seen[repo.ID] {
+			seen[repo.ID] = true
+			ids = append(ids, repo.ID)
+		}
+	}
+	return ids
+}
+
+// ScheduledApplyRepos returns the repos that have scheduled applies
+// configured, except those in shadow mode.
+func (g GlobalCfg) ScheduledApplyRepos() []Repo {
+	var repos []Repo
+	for _, repo := range g.Repos {
+		if len(repo.ScheduledApplies) > 0 && repo.ID != "" && !g.ShadowMode(repo.ID) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos
+}
+
+// DestroyOnCloseAllowed returns true if any repo is allowed to configure
+// projects that are destroyed when pull requests are closed, either with
+// preview environments or destroy_on_close.
+func (g GlobalCfg) DestroyOnCloseAllowed() bool {
+	for _, repo := range g.Repos {
+		if utils.SlicesContains(repo.AllowedOverrides, PreviewEnvironmentKey) ||
+			utils.SlicesContains(repo.AllowedOverrides, DestroyOnCloseKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// autoApplyDestroyOnClose returns whether destroy plans are applied for
+// repoID. Later matching repos take precedence.
+func (g GlobalCfg) autoApplyDestroyOnClose(repoID string) bool {
+	autoApply := false
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.AutoApplyDestroyOnClose != nil {
+			autoApply = *repo.AutoApplyDestroyOnClose
+		}
+	}
+	return autoApply
+}
+
+// ShadowMode returns true if repoID is in shadow mode. Later matching repos
+// take precedence.
+func (g GlobalCfg) ShadowMode(repoID string) bool {
+	shadowMode := false
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.ShadowMode != nil {
+			shadowMode = *repo.ShadowMode
+		}
+	}
+	return shadowMode
+}
+
+// HasManualTrigger returns true if projects of the repo with repoID can be in
+// manual trigger mode.
+func (g GlobalCfg) HasManualTrigger(repoID string) bool {
+	hasManualTrigger := false
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.ManualTrigger != nil {
+			hasManualTrigger = true
+		}
+	}
+	return hasManualTrigger
+}
+
+// manualTriggerOperators returns the operators of the project named name at
+// dir and workspace of the repo with repoID if it's in manual trigger mode.
+// It returns nil otherwise.
+func (g GlobalCfg) manualTriggerOperators(repoID string, name string, dir string, workspace string) []string {
+	var manualTrigger *ManualTrigger
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.ManualTrigger != nil {
+			manualTrigger = repo.ManualTrigger
+		}
+	}
+	if manualTrigger == nil || !manualTrigger.Matches(name, dir, workspace) {
+		return nil
+	}
+	return manualTrigger.Operators
+}
+
+// GitlabCI returns the GitLab CI jobs config of the repo with repoID. Later
+// matching repos take precedence. It returns nil if none is configured.
+func (g GlobalCfg) GitlabCI(repoID string) *GitlabCI {
+	var gitlabCI *GitlabCI
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.GitlabCI != nil {
+			gitlabCI = repo.GitlabCI
+		}
+	}
+	return gitlabCI
+}
+
+// githubEnvironment returns the GitHub environment the project named name at
+// dir and workspace of the repo with repoID is deployed to. Later matching
+// repos take precedence and the first environment matching the project is
+// returned. It returns nil if the project isn't deployed to one.
+func (g GlobalCfg) githubEnvironment(repoID string, name string, dir string, workspace string) *GithubEnvironment {
+	var environments []GithubEnvironment
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.GithubEnvironments != nil {
+			environments = repo.GithubEnvironments
+		}
+	}
+	for _, e := range environments {
+		if e.Matches(name, dir, workspace) {
+			return &e
+		}
+	}
+	return nil
+}
+
+// HasPreWorkflowHooks returns true if any repo matching repoID has pre
+// workflow hooks. Pre workflow hooks of all matching repos are run.
+func (g GlobalCfg) HasPreWorkflowHooks(repoID string) bool {
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && len(repo.PreWorkflowHooks) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // RepoConfigFile returns a repository specific file path
 // If not defined, return atlantis.yaml as default
 func (g GlobalCfg) RepoConfigFile(repoID string) string {
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	"github.com/mohae/deepcopy"
@@ -84,6 +85,12 @@ func TestNewGlobalCfg(t *testing.T) {
 				PolicyCheck:               Bool(false),
 				CustomPolicyCheck:         Bool(false),
 				AutoDiscover:              raw.DefaultAutoDiscover(),
+				CommandTimeouts: &valid.RepoCommandTimeouts{
+					Plan:        new(time.Duration),
+					Apply:       new(time.Duration),
+					PolicyCheck: new(time.Duration),
+					Custom:      new(time.Duration),
+				},
 			},
 		},
 		Workflows: map[string]valid.Workflow{
@@ -491,6 +498,93 @@ func TestGlobalCfg_ValidateRepoCfg(t *testing.T) {
 			repoID: "github.com/owner/repo",
 			expErr: "repo config not allowed to set 'import_requirements' key: server-side config needs 'allowed_overrides: [import_requirements]'",
 		},
+		"repo config not allowed to set preview_environment": {
+			gCfg: valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{
+				AllowAllRepoSettings: false,
+			}),
+			rCfg: valid.RepoCfg{
+				Projects: []valid.Project{
+					{
+						Dir:                ".",
+						Workspace:          "default",
+						PreviewEnvironment: &valid.PreviewEnvironment{Workspace: valid.DefaultPreviewWorkspace},
+					},
+				},
+			},
+			repoID: "github.com/owner/repo",
+			expErr: "repo config not allowed to set 'preview_environment' key: server-side config needs 'allowed_overrides: [preview_environment]'",
+		},
+		"repo config not allowed to set destroy_on_close": {
+			gCfg: valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{
+				AllowAllRepoSettings: true,
+			}),
+			rCfg: valid.RepoCfg{
+				Projects: []valid.Project{
+					{
+						Dir:            ".",
+						Workspace:      "ephemeral",
+						DestroyOnClose: Bool(true),
+					},
+				},
+			},
+			repoID: "github.com/owner/repo",
+			expErr: "repo config not allowed to set 'destroy_on_close' key: server-side config needs 'allowed_overrides: [destroy_on_close]'",
+		},
+		"repo config not allowed to set comment_templates": {
+			gCfg: valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{
+				AllowAllRepoSettings: true,
+			}),
+			rCfg: valid.RepoCfg{
+				CommentTemplates: &valid.CommentTemplates{Plan: "plan.tmpl"},
+			},
+			repoID: "github.com/owner/repo",
+			expErr: "repo config not allowed to set 'comment_templates' key: server-side config needs 'allowed_overrides: [comment_templates]'",
+		},
+		"comment_templates allowed": {
+			gCfg: valid.GlobalCfg{
+				Repos: []valid.Repo{
+					{
+						IDRegex:          regexp.MustCompile(".*"),
+						AllowedOverrides: []string{valid.CommentTemplatesKey},
+					},
+				},
+			},
+			rCfg: valid.RepoCfg{
+				CommentTemplates: &valid.CommentTemplates{Plan: "plan.tmpl"},
+			},
+			repoID: "github.com/owner/repo",
+			expErr: "",
+		},
+		"project workflow hooks without custom workflows": {
+			gCfg: valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}),
+			rCfg: valid.RepoCfg{
+				Projects: []valid.Project{
+					{
+						Dir:              ".",
+						Workspace:        "default",
+						PreWorkflowHooks: []*valid.WorkflowHook{{StepName: "run", RunCommand: "./setup.sh"}},
+					},
+				},
+			},
+			repoID: "github.com/owner/repo",
+			expErr: "repo config not allowed to define project workflow hooks: server-side config needs 'allow_custom_workflows: true'",
+		},
+		"project workflow hooks with custom workflows": {
+			gCfg: valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{
+				AllowAllRepoSettings: true,
+			}),
+			rCfg: valid.RepoCfg{
+				Projects: []valid.Project{
+					{
+						Dir:               ".",
+						Workspace:         "default",
+						PostWorkflowHooks: []*valid.WorkflowHook{{StepName: "run", RunCommand: "./teardown.sh"}},
+					},
+				},
+			},
+			repoID: "github.com/owner/repo",
+			expErr: "",
+		},
 		"repo workflow doesn't exist": {
 			gCfg: valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{
 				AllowAllRepoSettings: true,
@@ -1152,6 +1246,194 @@ func TestGlobalCfg_MatchingRepo(t *testing.T) {
 	}
 }
 
+func TestGlobalCfg_WebhookSecret(t *testing.T) {
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex:       regexp.MustCompile("^github.com/org/.*"),
+				WebhookSecret: "org-secret",
+			},
+			{
+				ID:            "github.com/org/special",
+				WebhookSecret: "repo-secret",
+			},
+			{
+				IDRegex: regexp.MustCompile(".*"),
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		repoID string
+		exp    string
+	}{
+		"no matching secret": {
+			repoID: "github.com/other/repo",
+			exp:    "",
+		},
+		"matches org secret": {
+			repoID: "github.com/org/repo",
+			exp:    "org-secret",
+		},
+		"repo secret takes precedence": {
+			repoID: "github.com/org/special",
+			exp:    "repo-secret",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			Equals(t, c.exp, gCfg.WebhookSecret(c.repoID))
+		})
+	}
+}
+
+func TestGlobalCfg_ShadowMode(t *testing.T) {
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex:    regexp.MustCompile("^github.com/org/.*"),
+				ShadowMode: Bool(true),
+			},
+			{
+				ID:         "github.com/org/visible",
+				ShadowMode: Bool(false),
+			},
+			{
+				IDRegex: regexp.MustCompile(".*"),
+			},
+		},
+	}
+
+	Equals(t, false, gCfg.ShadowMode("github.com/other/repo"))
+	Equals(t, true, gCfg.ShadowMode("github.com/org/repo"))
+	Equals(t, false, gCfg.ShadowMode("github.com/org/visible"))
+
+	// Nothing is applied on a schedule in repos in shadow mode.
+	gCfg.Repos = append(gCfg.Repos,
+		valid.Repo{ID: "github.com/org/repo", ScheduledApplies: []valid.ScheduledApply{{Branch: "main"}}},
+		valid.Repo{ID: "github.com/org/visible", ScheduledApplies: []valid.ScheduledApply{{Branch: "main"}}},
+	)
+	repos := gCfg.ScheduledApplyRepos()
+	Equals(t, 1, len(repos))
+	Equals(t, "github.com/org/visible", repos[0].ID)
+}
+
+func TestGlobalCfg_ManualTrigger(t *testing.T) {
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	gCfg.Repos = append(gCfg.Repos, valid.Repo{
+		ID: "github.com/org/repo",
+		ManualTrigger: &valid.ManualTrigger{
+			Operators: []string{"infra-admins"},
+			Projects:  []valid.ManualTriggerProject{{Dir: "prod"}},
+		},
+	})
+	logger := logging.NewNoopLogger(t)
+	autoplan := valid.Autoplan{Enabled: true}
+	destroyOnClose := true
+
+	Equals(t, false, gCfg.HasManualTrigger("github.com/org/other"))
+	Equals(t, true, gCfg.HasManualTrigger("github.com/org/repo"))
+
+	prod := gCfg.MergeProjectCfg(logger, "github.com/org/repo", valid.Project{Dir: "prod", Workspace: "default", Autoplan: autoplan, DestroyOnClose: &destroyOnClose}, valid.RepoCfg{})
+	Equals(t, false, prod.AutoplanEnabled)
+	Equals(t, []string{"infra-admins"}, prod.ManualTriggerOperators)
+
+	staging := gCfg.MergeProjectCfg(logger, "github.com/org/repo", valid.Project{Dir: "staging", Workspace: "default", Autoplan: autoplan}, valid.RepoCfg{})
+	Equals(t, true, staging.AutoplanEnabled)
+	Assert(t, staging.ManualTriggerOperators == nil, "expected staging not to be in manual trigger mode")
+
+	discovered := gCfg.DefaultProjCfg(logger, "github.com/org/repo", "prod", "default")
+	Equals(t, false, discovered.AutoplanEnabled)
+	Equals(t, []string{"infra-admins"}, discovered.ManualTriggerOperators)
+}
+
+func TestGlobalCfg_DisabledCommands(t *testing.T) {
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	gCfg.Repos = append(gCfg.Repos,
+		valid.Repo{IDRegex: regexp.MustCompile("github.com/org/.*"), DisabledCommands: []string{valid.StateCommand, valid.ImportCommand}},
+		valid.Repo{ID: "github.com/org/infra", DisabledCommands: []string{}},
+		valid.Repo{ID: "github.com/org/legacy"},
+	)
+
+	Assert(t, gCfg.DisabledCommands("github.com/other/repo") == nil, "expected no commands to be disabled")
+	Equals(t, []string{valid.StateCommand, valid.ImportCommand}, gCfg.DisabledCommands("github.com/org/repo"))
+	Equals(t, []string{}, gCfg.DisabledCommands("github.com/org/infra"))
+	Equals(t, []string{valid.StateCommand, valid.ImportCommand}, gCfg.DisabledCommands("github.com/org/legacy"))
+}
+
+func TestGlobalCfg_GitlabCI(t *testing.T) {
+	lint := &valid.GitlabCI{WaitForJobs: []string{"lint"}, Commands: []string{"plan", "apply"}, Timeout: valid.DefaultGitlabCITimeout}
+	scan := &valid.GitlabCI{TriggerJobs: []string{"scan"}, Commands: []string{"apply"}, Timeout: 2 * valid.DefaultGitlabCITimeout}
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	gCfg.Repos = append(gCfg.Repos,
+		valid.Repo{IDRegex: regexp.MustCompile("gitlab.com/org/.*"), GitlabCI: lint},
+		valid.Repo{ID: "gitlab.com/org/infra", GitlabCI: scan},
+		valid.Repo{ID: "gitlab.com/org/legacy"},
+	)
+
+	Assert(t, gCfg.GitlabCI("gitlab.com/other/repo") == nil, "expected no GitLab CI jobs")
+	Equals(t, lint, gCfg.GitlabCI("gitlab.com/org/legacy"))
+	Equals(t, scan, gCfg.GitlabCI("gitlab.com/org/infra"))
+	Equals(t, false, scan.AppliesTo("plan"))
+	Equals(t, true, scan.AppliesTo("apply"))
+}
+
+func TestGitlabCI_Jobs(t *testing.T) {
+	gitlabCI := valid.GitlabCI{WaitForJobs: []string{"lint", "test"}, TriggerJobs: []string{"test", "scan"}}
+	Equals(t, []string{"lint", "test", "scan"}, gitlabCI.Jobs())
+}
+
+func TestGlobalCfg_GithubEnvironment(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	staging := valid.GithubEnvironment{Name: "staging", Timeout: valid.DefaultGithubEnvironmentTimeout}
+	production := valid.GithubEnvironment{
+		Name:     "production",
+		Projects: []valid.GithubEnvironmentProject{{Dir: "prod"}},
+		Timeout:  valid.DefaultGithubEnvironmentTimeout,
+	}
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	gCfg.Repos = append(gCfg.Repos,
+		valid.Repo{IDRegex: regexp.MustCompile("github.com/org/.*"), GithubEnvironments: []valid.GithubEnvironment{staging}},
+		valid.Repo{ID: "github.com/org/infra", GithubEnvironments: []valid.GithubEnvironment{production, staging}},
+	)
+
+	Assert(t, gCfg.DefaultProjCfg(logger, "github.com/other/repo", "prod", "default").GithubEnvironment == nil, "expected no GitHub environment")
+	Equals(t, &staging, gCfg.DefaultProjCfg(logger, "github.com/org/app", "prod", "default").GithubEnvironment)
+	// The first environment matching the project is used.
+	Equals(t, &production, gCfg.DefaultProjCfg(logger, "github.com/org/infra", "prod", "default").GithubEnvironment)
+	Equals(t, &staging, gCfg.DefaultProjCfg(logger, "github.com/org/infra", "staging", "default").GithubEnvironment)
+}
+
+func TestGithubEnvironment_Matches(t *testing.T) {
+	environment := valid.GithubEnvironment{
+		Name: "production",
+		Projects: []valid.GithubEnvironmentProject{
+			{Name: "network"},
+			{Dir: "prod", Workspace: "default"},
+		},
+	}
+	Equals(t, true, environment.Matches("network", "network", "default"))
+	Equals(t, true, environment.Matches("", "prod", "default"))
+	Equals(t, false, environment.Matches("", "prod", "staging"))
+	Equals(t, false, environment.Matches("app", "app", "default"))
+}
+
+func TestGlobalCfg_StackedPulls(t *testing.T) {
+	block := valid.StackedPullsBlockMode
+	disabled := valid.StackedPullsDisabledMode
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	gCfg.Repos = append(gCfg.Repos,
+		valid.Repo{IDRegex: regexp.MustCompile("github.com/org/.*"), StackedPulls: &block},
+		valid.Repo{ID: "github.com/org/legacy", StackedPulls: &disabled},
+		valid.Repo{ID: "github.com/org/infra"},
+	)
+
+	Equals(t, valid.StackedPullsDisabledMode, gCfg.StackedPulls("github.com/other/repo"))
+	Equals(t, valid.StackedPullsBlockMode, gCfg.StackedPulls("github.com/org/infra"))
+	Equals(t, valid.StackedPullsDisabledMode, gCfg.StackedPulls("github.com/org/legacy"))
+}
+
 func TestGlobalCfg_PolicyCheckOverride(t *testing.T) {
 	var emptyPolicySets valid.PolicySets
 
@@ -1403,3 +1685,185 @@ func String(v string) *string { return &v }
 // Bool is a helper routine that allocates a new bool value
 // to store v and returns a pointer to it.
 func Bool(v bool) *bool { return &v }
+
+func TestGlobalCfg_DriftDetectionRepos(t *testing.T) {
+	driftDetection := &valid.DriftDetection{
+		Projects: []valid.DriftDetectionProject{{Name: "prod"}},
+	}
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex: regexp.MustCompile(".*"),
+			},
+			{
+				ID:             "github.com/org/infra",
+				DriftDetection: driftDetection,
+			},
+			{
+				ID: "github.com/org/app",
+			},
+		},
+	}
+	Equals(t, []valid.Repo{gCfg.Repos[1]}, gCfg.DriftDetectionRepos())
+}
+
+func TestGlobalCfg_RepoIDs(t *testing.T) {
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{IDRegex: regexp.MustCompile(".*")},
+			{ID: "github.com/org/infra"},
+			{ID: "github.com/org/app"},
+			{ID: "github.com/org/infra"},
+		},
+	}
+	Equals(t, []string{"github.com/org/infra", "github.com/org/app"}, gCfg.RepoIDs())
+}
+
+func TestGlobalCfg_MergeProjectCfg_PreviewEnvironment(t *testing.T) {
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{AllowAllRepoSettings: true})
+	vars := map[string]string{"pr_number": "12"}
+	merged := gCfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/owner/repo", valid.Project{
+		Dir:                ".",
+		Workspace:          "pr-12",
+		PreviewEnvironment: &valid.PreviewEnvironment{Workspace: "pr-12", Vars: vars},
+	}, valid.RepoCfg{})
+	Equals(t, "pr-12", merged.Workspace)
+	Equals(t, vars, merged.TerraformVars)
+	Equals(t, true, merged.DestroyOnClose)
+	Equals(t, true, merged.AutoApplyDestroyOnClose)
+
+	merged = gCfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/owner/repo", valid.Project{
+		Dir:       ".",
+		Workspace: "default",
+	}, valid.RepoCfg{})
+	Equals(t, map[string]string(nil), merged.TerraformVars)
+	Equals(t, false, merged.DestroyOnClose)
+}
+
+func TestGlobalCfg_MergeProjectCfg_DestroyOnClose(t *testing.T) {
+	autoApply := true
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{AllowAllRepoSettings: true})
+	gCfg.Repos = append(gCfg.Repos, valid.Repo{
+		ID:                      "github.com/owner/auto-apply",
+		AutoApplyDestroyOnClose: &autoApply,
+	})
+	destroyOnClose := true
+	proj := valid.Project{
+		Dir:            ".",
+		Workspace:      "ephemeral",
+		DestroyOnClose: &destroyOnClose,
+	}
+
+	merged := gCfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/owner/repo", proj, valid.RepoCfg{})
+	Equals(t, true, merged.DestroyOnClose)
+	Equals(t, false, merged.AutoApplyDestroyOnClose)
+
+	merged = gCfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/owner/auto-apply", proj, valid.RepoCfg{})
+	Equals(t, true, merged.DestroyOnClose)
+	Equals(t, true, merged.AutoApplyDestroyOnClose)
+}
+
+func TestGlobalCfg_MergeProjectCfg_WorkflowHooks(t *testing.T) {
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{AllowAllRepoSettings: true})
+	preHooks := []*valid.WorkflowHook{{StepName: "run", RunCommand: "./setup.sh"}}
+	postHooks := []*valid.WorkflowHook{{StepName: "run", RunCommand: "./teardown.sh"}}
+	proj := valid.Project{
+		Dir:               ".",
+		Workspace:         "default",
+		PreWorkflowHooks:  preHooks,
+		PostWorkflowHooks: postHooks,
+	}
+
+	merged := gCfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/owner/repo", proj, valid.RepoCfg{})
+	Equals(t, preHooks, merged.PreWorkflowHooks)
+	Equals(t, postHooks, merged.PostWorkflowHooks)
+}
+
+func TestGlobalCfg_MergeProjectCfg_Promotion(t *testing.T) {
+	gCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{AllowAllRepoSettings: true})
+	rCfg := valid.RepoCfg{
+		Promotions: []valid.Promotion{{Name: "app", Stages: []string{"app-dev", "app-staging", "app-prod"}}},
+	}
+	project := func(name string, dependsOn ...string) valid.Project {
+		return valid.Project{
+			Dir:       name,
+			Workspace: "default",
+			Name:      String(name),
+			Autoplan:  valid.Autoplan{Enabled: true},
+			DependsOn: dependsOn,
+		}
+	}
+
+	merged := gCfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/owner/repo", project("app-dev"), rCfg)
+	Equals(t, "app-staging", merged.PromoteTo)
+	Equals(t, true, merged.AutoplanEnabled)
+	Equals(t, []string(nil), merged.DependsOn)
+
+	merged = gCfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/owner/repo", project("app-staging", "network"), rCfg)
+	Equals(t, "app-prod", merged.PromoteTo)
+	Equals(t, false, merged.AutoplanEnabled)
+	Equals(t, []string{"network", "app-dev"}, merged.DependsOn)
+
+	merged = gCfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/owner/repo", project("app-prod", "app-staging"), rCfg)
+	Equals(t, "", merged.PromoteTo)
+	Equals(t, []string{"app-staging"}, merged.DependsOn)
+
+	merged = gCfg.MergeProjectCfg(logging.NewNoopLogger(t), "github.com/owner/repo", project("db"), rCfg)
+	Equals(t, "", merged.PromoteTo)
+	Equals(t, true, merged.AutoplanEnabled)
+}
+
+func TestGlobalCfg_DestroyOnCloseAllowed(t *testing.T) {
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex:          regexp.MustCompile(".*"),
+				AllowedOverrides: []string{valid.WorkflowKey},
+			},
+		},
+	}
+	Equals(t, false, gCfg.DestroyOnCloseAllowed())
+
+	gCfg.Repos = append(gCfg.Repos, valid.Repo{
+		ID:               "github.com/org/app",
+		AllowedOverrides: []string{valid.PreviewEnvironmentKey},
+	})
+	Equals(t, true, gCfg.DestroyOnCloseAllowed())
+
+	gCfg.Repos[1].AllowedOverrides = []string{valid.DestroyOnCloseKey}
+	Equals(t, true, gCfg.DestroyOnCloseAllowed())
+}
+
+func TestGlobalCfg_HasPreWorkflowHooks(t *testing.T) {
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{IDRegex: regexp.MustCompile(".*")},
+			{
+				ID:               "github.com/org/infra",
+				PreWorkflowHooks: []*valid.WorkflowHook{{RunCommand: "terragrunt-atlantis-config generate"}},
+			},
+		},
+	}
+	Equals(t, true, gCfg.HasPreWorkflowHooks("github.com/org/infra"))
+	Equals(t, false, gCfg.HasPreWorkflowHooks("github.com/org/app"))
+}
+
+func TestGlobalCfg_CommandTimeouts(t *testing.T) {
+	hour := time.Hour
+	none := time.Duration(0)
+	globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{
+		CommandTimeouts: valid.CommandTimeouts{Plan: 30 * time.Minute, Apply: 2 * time.Hour},
+	})
+	globalCfg.Repos = append(globalCfg.Repos,
+		valid.Repo{IDRegex: regexp.MustCompile("github.com/org/.*"), CommandTimeouts: &valid.RepoCommandTimeouts{Apply: &hour, PolicyCheck: &hour}},
+		valid.Repo{ID: "github.com/org/slow", CommandTimeouts: &valid.RepoCommandTimeouts{Plan: &none}},
+	)
+
+	Equals(t, valid.CommandTimeouts{Plan: 30 * time.Minute, Apply: 2 * time.Hour}, globalCfg.CommandTimeouts("github.com/other/repo"))
+	Equals(t, valid.CommandTimeouts{Plan: 30 * time.Minute, Apply: time.Hour, PolicyCheck: time.Hour}, globalCfg.CommandTimeouts("github.com/org/repo"))
+	timeouts := globalCfg.CommandTimeouts("github.com/org/slow")
+	Equals(t, valid.CommandTimeouts{Apply: time.Hour, PolicyCheck: time.Hour}, timeouts)
+	Equals(t, time.Hour, timeouts.For("apply"))
+	Equals(t, time.Duration(0), timeouts.For("plan"))
+	Equals(t, time.Duration(0), timeouts.For("version"))
+}
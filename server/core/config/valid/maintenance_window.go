@@ -0,0 +1,72 @@
+package valid
+
+import "time"
+
+// MaxMaintenanceWindowDuration is the longest a maintenance window can last.
+const MaxMaintenanceWindowDuration = 7 * 24 * time.Hour
+
+// MaintenanceWindow is a recurring time window during which applies of a repo
+// are disabled, ex. while its cloud provider is being upgraded.
+type MaintenanceWindow struct {
+	// Schedule is when the window starts.
+	Schedule *CronSchedule
+	// Duration is how long the window lasts.
+	Duration time.Duration
+	// Description is shown to users whose applies are refused, ex. the
+	// reason for the window.
+	Description string
+}
+
+// EndsAt returns when the window that t is in ends. It returns false if t
+// isn't in the window. If windows started by the schedule overlap, the one
+// that ends last is used.
+func (w MaintenanceWindow) EndsAt(t time.Time) (time.Time, bool) {
+	minute := t.Truncate(time.Minute)
+	var end time.Time
+	for start := minute; start.After(minute.Add(-w.Duration)); start = start.Add(-time.Minute) {
+		if w.Schedule.Matches(start) && start.Add(w.Duration).After(end) {
+			end = start.Add(w.Duration)
+		}
+	}
+	return end, end.After(t)
+}
+
+// maxChainedMaintenanceWindows is how many overlapping or back to back
+// maintenance windows are followed to find when applies are allowed again.
+const maxChainedMaintenanceWindows = 100
+
+// ActiveMaintenanceWindow returns the maintenance window of the repo with
+// repoID that t is in, and when applies are allowed again, which may be after
+// further windows that start before it ends. The last matching repo with
+// maintenance windows takes precedence. It returns nil if t isn't in a window.
+// The time is zero if the windows never end.
+func (g GlobalCfg) ActiveMaintenanceWindow(repoID string, t time.Time) (*MaintenanceWindow, time.Time) {
+	var windows []MaintenanceWindow
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.MaintenanceWindows != nil {
+			windows = repo.MaintenanceWindows
+		}
+	}
+
+	var active *MaintenanceWindow
+	end := t
+	for i := 0; i < maxChainedMaintenanceWindows; i++ {
+		extended := false
+		for j, w := range windows {
+			if windowEnd, ok := w.EndsAt(end); ok {
+				if active == nil {
+					active = &windows[j]
+				}
+				end = windowEnd
+				extended = true
+			}
+		}
+		if !extended {
+			if active == nil {
+				return nil, time.Time{}
+			}
+			return active, end
+		}
+	}
+	return active, time.Time{}
+}
@@ -0,0 +1,120 @@
+package valid_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func mustParseCronSchedule(t *testing.T, spec string) *valid.CronSchedule {
+	s, err := valid.ParseCronSchedule(spec)
+	Ok(t, err)
+	return s
+}
+
+func TestMaintenanceWindow_EndsAt(t *testing.T) {
+	w := valid.MaintenanceWindow{
+		Schedule: mustParseCronSchedule(t, "0 22 * * 5"),
+		Duration: 2 * time.Hour,
+	}
+	cases := []struct {
+		description string
+		t           time.Time
+		expEnd      time.Time
+		expOk       bool
+	}{
+		{"at start", time.Date(2024, 1, 5, 22, 0, 0, 0, time.UTC), time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC), true},
+		{"during", time.Date(2024, 1, 5, 23, 59, 30, 0, time.UTC), time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC), true},
+		{"at end", time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC), time.Time{}, false},
+		{"before", time.Date(2024, 1, 5, 21, 59, 0, 0, time.UTC), time.Time{}, false},
+		{"other day", time.Date(2024, 1, 4, 22, 30, 0, 0, time.UTC), time.Time{}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			end, ok := w.EndsAt(c.t)
+			Equals(t, c.expOk, ok)
+			if ok {
+				Equals(t, c.expEnd, end)
+			}
+		})
+	}
+}
+
+func TestGlobalCfg_ActiveMaintenanceWindow(t *testing.T) {
+	friday := valid.MaintenanceWindow{
+		Schedule:    mustParseCronSchedule(t, "0 22 * * 5"),
+		Duration:    2 * time.Hour,
+		Description: "Weekly release freeze.",
+	}
+	saturday := valid.MaintenanceWindow{
+		Schedule: mustParseCronSchedule(t, "0 0 * * 6"),
+		Duration: time.Hour,
+	}
+	always := valid.MaintenanceWindow{
+		Schedule: mustParseCronSchedule(t, "* * * * *"),
+		Duration: time.Minute,
+	}
+	inFriday := time.Date(2024, 1, 5, 23, 0, 0, 0, time.UTC)
+	cases := []struct {
+		description string
+		repos       []valid.Repo
+		t           time.Time
+		expWindow   *valid.MaintenanceWindow
+		expEnd      time.Time
+	}{
+		{
+			description: "no windows",
+			repos:       []valid.Repo{{IDRegex: regexp.MustCompile(".*")}},
+			t:           inFriday,
+		},
+		{
+			description: "not in a window",
+			repos:       []valid.Repo{{IDRegex: regexp.MustCompile(".*"), MaintenanceWindows: []valid.MaintenanceWindow{friday}}},
+			t:           time.Date(2024, 1, 5, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			description: "in a window",
+			repos:       []valid.Repo{{IDRegex: regexp.MustCompile(".*"), MaintenanceWindows: []valid.MaintenanceWindow{friday}}},
+			t:           inFriday,
+			expWindow:   &friday,
+			expEnd:      time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			description: "back to back windows",
+			repos:       []valid.Repo{{IDRegex: regexp.MustCompile(".*"), MaintenanceWindows: []valid.MaintenanceWindow{saturday, friday}}},
+			t:           inFriday,
+			expWindow:   &friday,
+			expEnd:      time.Date(2024, 1, 6, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			description: "last matching repo takes precedence",
+			repos: []valid.Repo{
+				{IDRegex: regexp.MustCompile(".*"), MaintenanceWindows: []valid.MaintenanceWindow{friday}},
+				{ID: "github.com/owner/repo", MaintenanceWindows: []valid.MaintenanceWindow{}},
+			},
+			t: inFriday,
+		},
+		{
+			description: "other repo",
+			repos:       []valid.Repo{{ID: "github.com/owner/other", MaintenanceWindows: []valid.MaintenanceWindow{friday}}},
+			t:           inFriday,
+		},
+		{
+			description: "never ends",
+			repos:       []valid.Repo{{IDRegex: regexp.MustCompile(".*"), MaintenanceWindows: []valid.MaintenanceWindow{always}}},
+			t:           inFriday,
+			expWindow:   &always,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			g := valid.GlobalCfg{Repos: c.repos}
+			window, end := g.ActiveMaintenanceWindow("github.com/owner/repo", c.t)
+			Equals(t, c.expWindow, window)
+			Equals(t, c.expEnd, end)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package valid
+
+// ManualTrigger is the config for the projects of a repo that are never run
+// on pull request activity. They're not autoplanned and only its operators
+// can plan and apply them with comments.
+type ManualTrigger struct {
+	// Operators are the usernames and team names of who can plan and apply
+	// the projects.
+	Operators []string
+	// Projects are the projects in manual trigger mode. If empty, every
+	// project of the repo is.
+	Projects []ManualTriggerProject
+}
+
+// ManualTriggerProject identifies a project in manual trigger mode, either by
+// name or by dir and workspace.
+type ManualTriggerProject struct {
+	Name      string
+	Dir       string
+	Workspace string
+}
+
+// Matches returns true if the project named name at dir and workspace is in
+// manual trigger mode.
+func (m ManualTrigger) Matches(name string, dir string, workspace string) bool {
+	if len(m.Projects) == 0 {
+		return true
+	}
+	for _, p := range m.Projects {
+		if p.Name != "" {
+			if p.Name == name {
+				return true
+			}
+			continue
+		}
+		if p.Dir == dir && (p.Workspace == "" || p.Workspace == workspace) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOperator returns true if the user with username who's a member of teams
+// is one of the operators.
+func (m ManualTrigger) IsOperator(username string, teams []string) bool {
+	for _, operator := range m.Operators {
+		if operator == username {
+			return true
+		}
+		for _, team := range teams {
+			if operator == team {
+				return true
+			}
+		}
+	}
+	return false
+}
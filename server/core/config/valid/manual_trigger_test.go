@@ -0,0 +1,39 @@
+package valid_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestManualTrigger_Matches(t *testing.T) {
+	cases := []struct {
+		description string
+		projects    []valid.ManualTriggerProject
+		name        string
+		dir         string
+		workspace   string
+		exp         bool
+	}{
+		{"every project", nil, "", "network", "default", true},
+		{"by name", []valid.ManualTriggerProject{{Name: "prod"}}, "prod", "prod", "default", true},
+		{"different name", []valid.ManualTriggerProject{{Name: "prod"}}, "staging", "prod", "default", false},
+		{"by dir in any workspace", []valid.ManualTriggerProject{{Dir: "prod"}}, "", "prod", "blue", true},
+		{"different workspace", []valid.ManualTriggerProject{{Dir: "prod", Workspace: "blue"}}, "", "prod", "green", false},
+		{"second project", []valid.ManualTriggerProject{{Name: "prod"}, {Dir: "network"}}, "", "network", "default", true},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			m := valid.ManualTrigger{Operators: []string{"alice"}, Projects: c.projects}
+			Equals(t, c.exp, m.Matches(c.name, c.dir, c.workspace))
+		})
+	}
+}
+
+func TestManualTrigger_IsOperator(t *testing.T) {
+	m := valid.ManualTrigger{Operators: []string{"alice", "infra-admins"}}
+	Assert(t, m.IsOperator("alice", nil), "expected alice to be an operator")
+	Assert(t, m.IsOperator("bob", []string{"devs", "infra-admins"}), "expected members of infra-admins to be operators")
+	Assert(t, !m.IsOperator("bob", []string{"devs"}), "expected bob not to be an operator")
+}
@@ -1,6 +1,7 @@
 package valid
 
 import (
+	"path"
 	"slices"
 	"strings"
 
@@ -10,6 +11,8 @@ import (
 const (
 	LocalPolicySet  string = "local"
 	GithubPolicySet string = "github"
+	// BundlePolicySet is an OPA bundle downloaded from a URL.
+	BundlePolicySet string = "bundle"
 )
 
 // PolicySets defines version of policy checker binary(conftest) and a list of
@@ -25,6 +28,9 @@ type PolicySets struct {
 type PolicyOwners struct {
 	Users []string
 	Teams []string
+	// Orgs are the GitHub organizations and GitLab groups whose members are
+	// owners.
+	Orgs []string
 }
 
 type PolicySet struct {
@@ -34,6 +40,46 @@ type PolicySet struct {
 	ApproveCount       int
 	Owners             PolicyOwners
 	PreventSelfApprove bool
+	// VerificationKey is the path to the PEM encoded public key that the
+	// signature of a bundle policy set is verified with. If empty, the
+	// signature isn't verified.
+	VerificationKey string
+	// Projects are the names or dir patterns of the projects the policy set
+	// applies to. If empty, it applies to all projects.
+	Projects []string
+}
+
+// AppliesTo returns true if the policy set applies to the project with name
+// projectName in repoRelDir.
+func (p PolicySet) AppliesTo(projectName string, repoRelDir string) bool {
+	if len(p.Projects) == 0 {
+		return true
+	}
+	for _, project := range p.Projects {
+		if projectName != "" && project == projectName {
+			return true
+		}
+		if matched, _ := path.Match(project, repoRelDir); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ForProject returns the policy sets that apply to the project with name
+// projectName in repoRelDir.
+func (p PolicySets) ForProject(projectName string, repoRelDir string) PolicySets {
+	var policySets []PolicySet
+	for _, policySet := range p.PolicySets {
+		if policySet.AppliesTo(projectName, repoRelDir) {
+			policySets = append(policySets, policySet)
+		}
+	}
+	if len(policySets) == len(p.PolicySets) {
+		return p
+	}
+	p.PolicySets = policySets
+	return p
 }
 
 func (p *PolicySets) HasPolicies() bool {
@@ -51,6 +97,8 @@ func (p *PolicySets) HasTeamOwners() bool {
 	return hasTeamOwners
 }
 
+// IsOwner returns true if username or one of userTeams are owners. Orgs aren't
+// checked since their members have to be looked up on the VCS host.
 func (o *PolicyOwners) IsOwner(username string, userTeams []string) bool {
 	for _, uname := range o.Users {
 		if strings.EqualFold(uname, username) {
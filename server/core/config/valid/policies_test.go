@@ -183,3 +183,26 @@ func TestPoliciesConfig_AllTeams(t *testing.T) {
 		})
 	}
 }
+
+func TestPolicySets_ForProject(t *testing.T) {
+	policySets := valid.PolicySets{
+		ApproveCount: 1,
+		PolicySets: []valid.PolicySet{
+			{Name: "all"},
+			{Name: "network", Projects: []string{"network"}},
+			{Name: "envs", Projects: []string{"envs/*"}},
+		},
+	}
+	names := func(p valid.PolicySets) []string {
+		var names []string
+		for _, policySet := range p.PolicySets {
+			names = append(names, policySet.Name)
+		}
+		return names
+	}
+
+	Equals(t, []string{"all", "network"}, names(policySets.ForProject("network", "modules/network")))
+	Equals(t, []string{"all", "envs"}, names(policySets.ForProject("", "envs/prod")))
+	Equals(t, []string{"all"}, names(policySets.ForProject("app", "envs/prod/app")))
+	Equals(t, 1, policySets.ForProject("app", "app").ApproveCount)
+}
@@ -0,0 +1,16 @@
+package valid
+
+// DefaultPreviewWorkspace is the template for the workspace of a preview
+// environment if none is configured.
+const DefaultPreviewWorkspace = "pr-{{ .PullNum }}"
+
+// PreviewEnvironment configures a project to be planned and applied in a
+// workspace dedicated to each pull request. The workspace and vars are Go
+// templates that are rendered for the pull request, ex. pr-{{ .PullNum }}.
+type PreviewEnvironment struct {
+	// Workspace is the template for the name of the pull request's workspace.
+	Workspace string
+	// Vars are the templates for Terraform variables that are set when
+	// planning, ex. pr_number: "{{ .PullNum }}".
+	Vars map[string]string
+}
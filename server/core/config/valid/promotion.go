@@ -0,0 +1,35 @@
+package valid
+
+// Promotion is a pipeline that promotes changes through the projects of a
+// repo. Applying the project of a stage plans the project of the next stage,
+// which can't be applied until the project before it was applied.
+type Promotion struct {
+	Name string
+	// Stages are the names of the projects of the promotion, in the order
+	// changes are promoted through them.
+	Stages []string
+}
+
+// PromotionStages returns the names of the projects before and after the
+// project named projectName in its promotion. They're empty if the project
+// is the first or last stage or isn't in a promotion.
+func (r RepoCfg) PromotionStages(projectName string) (prev string, next string) {
+	if projectName == "" {
+		return "", ""
+	}
+	for _, promotion := range r.Promotions {
+		for i, stage := range promotion.Stages {
+			if stage != projectName {
+				continue
+			}
+			if i > 0 {
+				prev = promotion.Stages[i-1]
+			}
+			if i < len(promotion.Stages)-1 {
+				next = promotion.Stages[i+1]
+			}
+			return prev, next
+		}
+	}
+	return "", ""
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
 	version "github.com/hashicorp/go-version"
@@ -22,6 +23,7 @@ type RepoCfg struct {
 	Automerge                 *bool
 	AutoDiscover              *AutoDiscover
 	ParallelApply             *bool
+	ParallelApplyGroups       bool
 	ParallelPlan              *bool
 	ParallelPolicyCheck       *bool
 	DeleteSourceBranchOnMerge *bool
@@ -31,6 +33,15 @@ type RepoCfg struct {
 	AllowedRegexpPrefixes     []string
 	AbortOnExecutionOrderFail bool
 	SilencePRComments         []string
+	// Promotions are the pipelines that promote changes through the repo's
+	// projects.
+	Promotions []Promotion
+	// SuppressedChanges are the kinds of noisy changes that are suppressed
+	// from the plan comments of all the repo's projects.
+	SuppressedChanges []SuppressedChange
+	// CommentTemplates override how the comments of the repo's commands are
+	// rendered. Nil uses the default templates.
+	CommentTemplates *CommentTemplates
 }
 
 func (r RepoCfg) FindProjectsByDirWorkspace(repoRelDir string, workspace string) []Project {
@@ -177,6 +188,31 @@ type Project struct {
 	PolicyCheck               *bool
 	CustomPolicyCheck         *bool
 	SilencePRComments         []string
+	// PreviewEnvironment is set if the project is planned and applied in a
+	// workspace dedicated to each pull request.
+	PreviewEnvironment *PreviewEnvironment
+	// DestroyOnClose is true if the project's workspace is destroyed when the
+	// pull request is closed.
+	DestroyOnClose *bool
+	// PreWorkflowHooks are run in the project's directory before its steps.
+	PreWorkflowHooks []*WorkflowHook
+	// PostWorkflowHooks are run in the project's directory after its steps,
+	// even if they failed.
+	PostWorkflowHooks []*WorkflowHook
+	// CommentArgs are the default extra args of the project's plans and
+	// applies that are run by comments.
+	CommentArgs CommentArgs
+	// SuppressedChanges are the kinds of noisy changes that are suppressed
+	// from the project's plan comments, on top of the repo's.
+	SuppressedChanges []SuppressedChange
+	// Workspaces are the names of, and globs matching, the workspaces the
+	// project is planned in. If set, the project is expanded into one project
+	// per workspace before it's used.
+	Workspaces []string
+	// ServerSide is true if the project is defined in the repo_config of the
+	// server-side repo config rather than in the repo's own config, so it can
+	// set keys the repo's config isn't allowed to override.
+	ServerSide bool
 }
 
 // GetName returns the name of the project or an empty string if there is no
@@ -200,8 +236,26 @@ const (
 	PostProcessRunOutputShow            = "show"
 	PostProcessRunOutputHide            = "hide"
 	PostProcessRunOutputStripRefreshing = "strip_refreshing"
+	PostProcessRunOutputJSON            = "json"
 )
 
+// Secret providers that env steps can read their value from.
+const (
+	VaultSecretProvider             = "vault"
+	AWSSecretsManagerSecretProvider = "aws_secrets_manager"
+	GCPSecretManagerSecretProvider  = "gcp_secret_manager"
+)
+
+// SecretRef is a secret in a secret provider.
+type SecretRef struct {
+	// Provider is one of the secret providers.
+	Provider string
+	// Name is the path, ID or resource name of the secret in the provider,
+	// optionally followed by # and the key of the value in the secret, ex.
+	// secret/data/db#password.
+	Name string
+}
+
 type Stage struct {
 	Steps []Step
 }
@@ -229,8 +283,41 @@ type Step struct {
 	EnvVarName string
 	// EnvVarValue is the value to set EnvVarName to.
 	EnvVarValue string
+	// EnvVarValueFrom is the secret to set EnvVarName to, if it's read from
+	// a secret provider.
+	EnvVarValueFrom *SecretRef
 	// The Shell to use for RunCommand execution.
 	RunShell *CommandShell
+	// PluginName is the name of the step plugin that runs a plugin step.
+	PluginName string
+	// When is an expression that must evaluate to true for the step to run.
+	// If empty, the step always runs.
+	When string
+	// Timeout is how long the step can run before it's canceled. If 0, it's
+	// only canceled if the project's steps time out.
+	Timeout time.Duration
+	// Retry is how the step is retried when it fails with a transient error.
+	// If nil, it isn't retried.
+	Retry *StepRetry
+	// RoleARN is the ARN of the AWS IAM role an assume_role step assumes.
+	RoleARN string
+	// RoleSessionName is the session name of the assumed role. If empty, a
+	// name is generated from the pull request.
+	RoleSessionName string
+	// RoleDurationSeconds is how long the credentials of the assumed role
+	// are valid for. If 0, the default of the role is used.
+	RoleDurationSeconds int
+	// TerragruntRunAll is true if a terragrunt step runs in every module
+	// under the project dir, in the order of their dependencies.
+	TerragruntRunAll bool
+	// CostMaxMonthlyIncrease is how much a cost_estimate step lets a plan
+	// increase the monthly cost of the project by before it fails the plan.
+	// If 0, there's no limit.
+	CostMaxMonthlyIncrease float64
+	// CostMaxPercentIncrease is how much a cost_estimate step lets a plan
+	// increase the monthly cost of the project by, in percent, before it
+	// fails the plan. If 0, there's no limit.
+	CostMaxPercentIncrease float64
 }
 
 type Workflow struct {
@@ -240,4 +327,6 @@ type Workflow struct {
 	PolicyCheck Stage
 	Import      Stage
 	StateRm     Stage
+	// Custom is the stage run by the custom commands that use this workflow.
+	Custom Stage
 }
@@ -0,0 +1,104 @@
+package valid
+
+import "slices"
+
+// RepoCfgOverlay is the repo config that the server-side repo config adds to
+// the repo config of the repos it matches, so that workflows and projects
+// can be rolled out to many repos without changing each of them.
+type RepoCfgOverlay struct {
+	// Projects are added to the projects of the repo config, unless it
+	// defines the same project. Repos without a repo config file only have
+	// these projects.
+	Projects []Project
+	// Workflows can be used by the projects of the repo config and of the
+	// overlay, and by the workflow key of the server-side repo config. They
+	// take precedence over the global workflows with the same name.
+	Workflows map[string]Workflow
+}
+
+// RepoCfgOverlay returns the repo config overlay of the repo with repoID.
+// The overlays of all matching repos are merged, and the workflows and
+// projects of later matching repos take precedence. It returns nil if no
+// matching repo has one.
+func (g GlobalCfg) RepoCfgOverlay(repoID string) *RepoCfgOverlay {
+	var overlay *RepoCfgOverlay
+	for _, repo := range g.Repos {
+		if !repo.IDMatches(repoID) || repo.RepoCfgOverlay == nil {
+			continue
+		}
+		if overlay == nil {
+			overlay = &RepoCfgOverlay{Workflows: map[string]Workflow{}}
+		}
+		for name, w := range repo.RepoCfgOverlay.Workflows {
+			overlay.Workflows[name] = w
+		}
+		overlay.Projects = mergeProjects(overlay.Projects, repo.RepoCfgOverlay.Projects)
+	}
+	return overlay
+}
+
+// Merge returns rCfg with the projects of the overlay that it doesn't define
+// itself. The projects of rCfg come first.
+func (o RepoCfgOverlay) Merge(rCfg RepoCfg) RepoCfg {
+	rCfg.Projects = mergeProjects(o.Projects, rCfg.Projects)
+	return rCfg
+}
+
+// mergeProjects returns the projects in base that aren't the same project as
+// one of overrides, followed by overrides.
+func mergeProjects(base []Project, overrides []Project) []Project {
+	merged := slices.Clone(overrides)
+	var kept []Project
+	for _, b := range base {
+		if !slices.ContainsFunc(overrides, func(o Project) bool { return sameProject(b, o) }) {
+			kept = append(kept, b)
+		}
+	}
+	return append(merged, kept...)
+}
+
+// sameProject returns true if a and b define the same project: they have the
+// same name or, if either of them isn't named, the same dir and workspace.
+func sameProject(a Project, b Project) bool {
+	if a.Name != nil && b.Name != nil {
+		return *a.Name == *b.Name
+	}
+	return a.Dir == b.Dir && a.Workspace == b.Workspace
+}
+
+// serverSideOverrides returns the keys that p, a project of the server-side
+// repo config, overrides.
+func (p Project) serverSideOverrides() []string {
+	var keys []string
+	if p.PlanRequirements != nil {
+		keys = append(keys, PlanRequirementsKey)
+	}
+	if p.ApplyRequirements != nil {
+		keys = append(keys, ApplyRequirementsKey)
+	}
+	if p.ImportRequirements != nil {
+		keys = append(keys, ImportRequirementsKey)
+	}
+	if p.WorkflowName != nil {
+		keys = append(keys, WorkflowKey)
+	}
+	if p.DeleteSourceBranchOnMerge != nil {
+		keys = append(keys, DeleteSourceBranchOnMergeKey)
+	}
+	if p.RepoLocking != nil {
+		keys = append(keys, RepoLockingKey)
+	}
+	if p.RepoLocks != nil {
+		keys = append(keys, RepoLocksKey)
+	}
+	if p.PolicyCheck != nil {
+		keys = append(keys, PolicyCheckKey)
+	}
+	if p.CustomPolicyCheck != nil {
+		keys = append(keys, CustomPolicyCheckKey)
+	}
+	if p.SilencePRComments != nil {
+		keys = append(keys, SilencePRCommentsKey)
+	}
+	return keys
+}
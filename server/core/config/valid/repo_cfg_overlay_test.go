@@ -0,0 +1,57 @@
+package valid_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGlobalCfg_RepoCfgOverlay(t *testing.T) {
+	name := func(s string) *string { return &s }
+	g := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{IDRegex: regexp.MustCompile(".*")},
+			{
+				IDRegex: regexp.MustCompile("github.com/org/.*"),
+				RepoCfgOverlay: &valid.RepoCfgOverlay{
+					Workflows: map[string]valid.Workflow{"platform": {Name: "platform"}},
+					Projects: []valid.Project{
+						{Name: name("network"), Dir: "network", Workspace: "default", ServerSide: true},
+						{Dir: "dns", Workspace: "default", ServerSide: true},
+					},
+				},
+			},
+			{
+				ID: "github.com/org/app",
+				RepoCfgOverlay: &valid.RepoCfgOverlay{
+					Projects: []valid.Project{
+						{Name: name("network"), Dir: "infra/network", Workspace: "default", ServerSide: true},
+					},
+				},
+			},
+		},
+	}
+
+	Assert(t, g.RepoCfgOverlay("github.com/other/repo") == nil, "exp no overlay")
+	Equals(t, &valid.RepoCfgOverlay{
+		Workflows: map[string]valid.Workflow{"platform": {Name: "platform"}},
+		Projects: []valid.Project{
+			{Name: name("network"), Dir: "infra/network", Workspace: "default", ServerSide: true},
+			{Dir: "dns", Workspace: "default", ServerSide: true},
+		},
+	}, g.RepoCfgOverlay("github.com/org/app"))
+
+	rCfg := valid.RepoCfg{
+		Projects: []valid.Project{
+			{Name: name("dns"), Dir: "dns", Workspace: "default"},
+			{Dir: "app", Workspace: "default"},
+		},
+	}
+	Equals(t, []valid.Project{
+		{Name: name("dns"), Dir: "dns", Workspace: "default"},
+		{Dir: "app", Workspace: "default"},
+		{Name: name("network"), Dir: "network", Workspace: "default", ServerSide: true},
+	}, g.RepoCfgOverlay("github.com/org/infra").Merge(rCfg).Projects)
+}
@@ -0,0 +1,21 @@
+package valid
+
+// ScheduledApply is a schedule on which projects in a repo are re-planned and
+// applied from a branch, ex. to rotate certificates every night.
+type ScheduledApply struct {
+	// Schedule is when the projects are applied.
+	Schedule *CronSchedule
+	// Branch is the branch that is applied. If empty, the repo's default
+	// branch is used.
+	Branch string
+	// Projects are the projects that are applied.
+	Projects []ScheduledApplyProject
+}
+
+// ScheduledApplyProject identifies a project to apply on a schedule, either by
+// name or by dir and workspace.
+type ScheduledApplyProject struct {
+	Name      string
+	Dir       string
+	Workspace string
+}
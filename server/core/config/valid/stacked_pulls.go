@@ -0,0 +1,35 @@
+package valid
+
+// StackedPullsMode is how Atlantis handles pull requests whose base branch is
+// the head branch of another open pull request.
+type StackedPullsMode string
+
+const (
+	// StackedPullsDisabledMode doesn't check if pull requests are stacked.
+	StackedPullsDisabledMode StackedPullsMode = "disabled"
+	// StackedPullsWarnMode comments a warning on plans and applies of stacked
+	// pull requests.
+	StackedPullsWarnMode StackedPullsMode = "warn"
+	// StackedPullsBlockMode also blocks applies of stacked pull requests until
+	// the pull request they're stacked on is merged.
+	StackedPullsBlockMode StackedPullsMode = "block"
+)
+
+// StackedPullsModes are the valid values of stacked_pulls.
+var StackedPullsModes = []string{
+	string(StackedPullsDisabledMode),
+	string(StackedPullsWarnMode),
+	string(StackedPullsBlockMode),
+}
+
+// StackedPulls returns how stacked pull requests of the repo with repoID are
+// handled. Later matching repos take precedence.
+func (g GlobalCfg) StackedPulls(repoID string) StackedPullsMode {
+	mode := StackedPullsDisabledMode
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.StackedPulls != nil {
+			mode = *repo.StackedPulls
+		}
+	}
+	return mode
+}
@@ -0,0 +1,39 @@
+package valid
+
+import (
+	"regexp"
+	"strings"
+)
+
+// StateRmAllowlist returns the patterns of the resource addresses that can
+// be removed from state on the repo with repoID, and false if no matching
+// repo sets state_rm_allowlist so any address can be removed. Later matching
+// repos take precedence.
+func (g GlobalCfg) StateRmAllowlist(repoID string) ([]string, bool) {
+	var allowlist []string
+	set := false
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.StateRmAllowlist != nil {
+			allowlist = repo.StateRmAllowlist
+			set = true
+		}
+	}
+	return allowlist, set
+}
+
+// StateRmAddressAllowed returns true if address matches one of the patterns
+// of allowlist. A * in a pattern matches any characters, ex.
+// module.cache.aws_instance.* matches module.cache.aws_instance.web["a"].
+// Other characters, including [ and ], match themselves.
+func StateRmAddressAllowed(allowlist []string, address string) bool {
+	for _, pattern := range allowlist {
+		parts := strings.Split(pattern, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		if regexp.MustCompile("^" + strings.Join(parts, ".*") + "$").MatchString(address) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package valid_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestStateRmAddressAllowed(t *testing.T) {
+	cases := []struct {
+		description string
+		allowlist   []string
+		address     string
+		exp         bool
+	}{
+		{"empty allowlist", []string{}, "aws_instance.web", false},
+		{"exact", []string{"aws_instance.web"}, "aws_instance.web", true},
+		{"different address", []string{"aws_instance.web"}, "aws_instance.db", false},
+		{"prefix only", []string{"aws_instance.web"}, "aws_instance.web2", false},
+		{"wildcard", []string{"module.cache.*"}, `module.cache.aws_instance.web["a"]`, true},
+		{"wildcard in the middle", []string{"module.*.aws_instance.web"}, "module.cache.aws_instance.web", true},
+		{"brackets match themselves", []string{`aws_instance.web["a"]`}, `aws_instance.web["a"]`, true},
+		{"dots match themselves", []string{"aws_instance.web"}, "aws_instanceXweb", false},
+		{"second pattern", []string{"aws_instance.web", "random_id.*"}, "random_id.suffix", true},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			Equals(t, c.exp, valid.StateRmAddressAllowed(c.allowlist, c.address))
+		})
+	}
+}
+
+func TestGlobalCfg_StateRmAllowlist(t *testing.T) {
+	g := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{IDRegex: regexp.MustCompile(".*"), StateRmAllowlist: []string{"random_id.*"}},
+			{ID: "github.com/owner/locked", StateRmAllowlist: []string{}},
+			{ID: "github.com/owner/other"},
+		},
+	}
+
+	allowlist, ok := g.StateRmAllowlist("github.com/owner/other")
+	Assert(t, ok, "expected the allowlist of the first repo to be used")
+	Equals(t, []string{"random_id.*"}, allowlist)
+
+	allowlist, ok = g.StateRmAllowlist("github.com/owner/locked")
+	Assert(t, ok, "expected the empty allowlist to be set")
+	Equals(t, []string{}, allowlist)
+
+	_, ok = (valid.GlobalCfg{}).StateRmAllowlist("github.com/owner/other")
+	Assert(t, !ok, "expected no allowlist")
+}
@@ -0,0 +1,52 @@
+package valid
+
+import (
+	"regexp"
+	"time"
+)
+
+// DefaultStepRetryErrors are the errors a step with a retry policy is
+// retried on if its policy doesn't list any. They're the transient errors
+// of provider and module downloads, and of the APIs providers call.
+var DefaultStepRetryErrors = []string{
+	`\b429\b`,
+	`(?i)too many requests`,
+	`(?i)50[234] (bad gateway|service unavailable|gateway timeout)`,
+	`(?i)connection reset by peer`,
+	`(?i)connection refused`,
+	`(?i)i/o timeout`,
+	`(?i)TLS handshake timeout`,
+	`(?i)Client\.Timeout exceeded`,
+	`(?i)unexpected EOF`,
+}
+
+// DefaultStepRetryMaxAttempts and DefaultStepRetryBackoff are used when a
+// retry policy doesn't set its max attempts or backoff.
+const (
+	DefaultStepRetryMaxAttempts = 3
+	DefaultStepRetryBackoff     = 10 * time.Second
+)
+
+// StepRetry is how a step that fails with a transient error is retried.
+type StepRetry struct {
+	// MaxAttempts is how many times the step runs at most, including its
+	// first run.
+	MaxAttempts int
+	// Backoff is how long to wait before retrying the step the first time.
+	// It doubles before each following retry.
+	Backoff time.Duration
+	// Errors match the output or error of the failed step if it can be
+	// retried.
+	Errors []*regexp.Regexp
+}
+
+// Retryable returns true if the step that failed with output and err can be
+// retried.
+func (r StepRetry) Retryable(output string, err error) bool {
+	for _, e := range r.Errors {
+		if e.MatchString(err.Error()) || e.MatchString(output) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package valid
+
+import "regexp"
+
+// SuppressedChange is a kind of noisy change, ex. to tags only, that's
+// collapsed into the suppressed changes section of plan comments rather than
+// shown in the plan output. Only resources updated in-place are suppressed.
+type SuppressedChange struct {
+	// Description says why the changes are suppressed, ex. "tag-only
+	// changes".
+	Description string
+	// AddressRegex matches the addresses of the resources whose changes can
+	// be suppressed. If nil, changes to any resource can be.
+	AddressRegex *regexp.Regexp
+	// AttributesRegex matches the paths of changed attributes, ex.
+	// "tags.Owner" or "metadata.0.generation". A change is suppressed if it
+	// only changes attributes that match. If nil, attributes aren't checked.
+	AttributesRegex *regexp.Regexp
+	// JQ is a jq filter that's true for the resource_changes of the JSON plan
+	// to suppress. If empty, it isn't checked.
+	JQ string
+}
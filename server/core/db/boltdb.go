@@ -3,11 +3,14 @@ package db
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -18,6 +21,8 @@ import (
 
 // BoltDB is a database using BoltDB
 type BoltDB struct {
+	// mutex guards db which is replaced when the database is compacted.
+	mutex                 sync.RWMutex
 	db                    *bolt.DB
 	locksBucketName       []byte
 	pullsBucketName       []byte
@@ -28,7 +33,16 @@ const (
 	locksBucketName       = "runLocks"
 	pullsBucketName       = "pulls"
 	globalLocksBucketName = "globalLocks"
+	deliveriesBucketName  = "webhookDeliveries"
+	driftBucketName       = "driftStatuses"
+	jobOutputsBucketName  = "jobOutputs"
 	pullKeySeparator      = "::"
+	// compactTxMaxSize is the number of bytes copied per transaction when
+	// compacting.
+	compactTxMaxSize = 64 * 1024
+	// deliveriesByTimeBucketName indexes the deliveries by the time they were
+	// received so that expired ones can be pruned without a full scan.
+	deliveriesByTimeBucketName = "webhookDeliveriesByTime"
 )
 
 // New returns a valid locker. We need to be able to write to dataDir
@@ -56,6 +70,9 @@ func New(dataDir string) (*BoltDB, error) {
 		if _, err = tx.CreateBucketIfNotExists([]byte(globalLocksBucketName)); err != nil {
 			return errors.Wrapf(err, "creating bucket %q", globalLocksBucketName)
 		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(driftBucketName)); err != nil {
+			return errors.Wrapf(err, "creating bucket %q", driftBucketName)
+		}
 		return nil
 	})
 	if err != nil {
@@ -89,7 +106,7 @@ func (b *BoltDB) TryLock(newLock models.ProjectLock) (bool, models.ProjectLock,
 	var currLock models.ProjectLock
 	key := b.lockKey(newLock.Project, newLock.Workspace)
 	newLockSerialized, _ := json.Marshal(newLock)
-	transactionErr := b.db.Update(func(tx *bolt.Tx) error {
+	transactionErr := b.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.locksBucketName)
 
 		// if there is no run at that key then we're free to create the lock
@@ -125,7 +142,7 @@ func (b *BoltDB) Unlock(p models.Project, workspace string) (*models.ProjectLock
 	var lock models.ProjectLock
 	foundLock := false
 	key := b.lockKey(p, workspace)
-	err := b.db.Update(func(tx *bolt.Tx) error {
+	err := b.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.locksBucketName)
 		serialized := bucket.Get([]byte(key))
 		if serialized != nil {
@@ -147,7 +164,7 @@ func (b *BoltDB) Unlock(p models.Project, workspace string) (*models.ProjectLock
 func (b *BoltDB) List() ([]models.ProjectLock, error) {
 	var locks []models.ProjectLock
 	var locksBytes [][]byte
-	err := b.db.View(func(tx *bolt.Tx) error {
+	err := b.view(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.locksBucketName)
 		c := bucket.Cursor()
 		for k, v := c.First(); k != nil; k, v = c.Next() {
@@ -183,7 +200,7 @@ func (b *BoltDB) LockCommand(cmdName command.Name, lockTime time.Time) (*command
 	}
 
 	newLockSerialized, _ := json.Marshal(lock)
-	transactionErr := b.db.Update(func(tx *bolt.Tx) error {
+	transactionErr := b.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.globalLocksBucketName)
 
 		currLockSerialized := bucket.Get([]byte(b.commandLockKey(cmdName)))
@@ -206,7 +223,7 @@ func (b *BoltDB) LockCommand(cmdName command.Name, lockTime time.Time) (*command
 // UnlockCommand removes CommandName lock if present.
 // If there are no lock it returns an error.
 func (b *BoltDB) UnlockCommand(cmdName command.Name) error {
-	transactionErr := b.db.Update(func(tx *bolt.Tx) error {
+	transactionErr := b.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.globalLocksBucketName)
 
 		if l := bucket.Get([]byte(b.commandLockKey(cmdName))); l == nil {
@@ -230,7 +247,7 @@ func (b *BoltDB) CheckCommandLock(cmdName command.Name) (*command.Lock, error) {
 
 	found := false
 
-	err := b.db.View(func(tx *bolt.Tx) error {
+	err := b.view(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.globalLocksBucketName)
 
 		serializedLock := bucket.Get([]byte(b.commandLockKey(cmdName)))
@@ -255,7 +272,7 @@ func (b *BoltDB) CheckCommandLock(cmdName command.Name) (*command.Lock, error) {
 // UnlockByPull deletes all locks associated with that pull request and returns them.
 func (b *BoltDB) UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error) {
 	var locks []models.ProjectLock
-	err := b.db.View(func(tx *bolt.Tx) error {
+	err := b.view(func(tx *bolt.Tx) error {
 		c := tx.Bucket(b.locksBucketName).Cursor()
 
 		// we can use the repoFullName as a prefix search since that's the first part of the key
@@ -288,7 +305,7 @@ func (b *BoltDB) UnlockByPull(repoFullName string, pullNum int) ([]models.Projec
 func (b *BoltDB) GetLock(p models.Project, workspace string) (*models.ProjectLock, error) {
 	key := b.lockKey(p, workspace)
 	var lockBytes []byte
-	err := b.db.View(func(tx *bolt.Tx) error {
+	err := b.view(func(tx *bolt.Tx) error {
 		b := tx.Bucket(b.locksBucketName)
 		lockBytes = b.Get([]byte(key))
 		return nil
@@ -320,7 +337,7 @@ func (b *BoltDB) UpdatePullWithResults(pull models.PullRequest, newResults []com
 	}
 
 	var newStatus models.PullStatus
-	err = b.db.Update(func(tx *bolt.Tx) error {
+	err = b.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.pullsBucketName)
 		currStatus, err := b.getPullFromBucket(bucket, key)
 		if err != nil {
@@ -390,6 +407,29 @@ func (b *BoltDB) UpdatePullWithResults(pull models.PullRequest, newResults []com
 	return newStatus, errors.Wrap(err, "DB transaction failed")
 }
 
+// UpdateForkApproval records that pull was approved to run commands even
+// though it's from a fork. If the stored status is for an older commit it's
+// replaced, the same way UpdatePullWithResults does.
+func (b *BoltDB) UpdateForkApproval(pull models.PullRequest, approval models.ForkApproval) error {
+	key, err := b.pullKey(pull)
+	if err != nil {
+		return err
+	}
+	err = b.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.pullsBucketName)
+		currStatus, err := b.getPullFromBucket(bucket, key)
+		if err != nil {
+			return err
+		}
+		if currStatus == nil || currStatus.Pull.HeadCommit != pull.HeadCommit {
+			currStatus = &models.PullStatus{Pull: pull}
+		}
+		currStatus.ForkApproval = &approval
+		return b.writePullToBucket(bucket, key, *currStatus)
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
 // GetPullStatus returns the status for pull.
 // If there is no status, returns a nil pointer.
 func (b *BoltDB) GetPullStatus(pull models.PullRequest) (*models.PullStatus, error) {
@@ -398,7 +438,7 @@ func (b *BoltDB) GetPullStatus(pull models.PullRequest) (*models.PullStatus, err
 		return nil, err
 	}
 	var s *models.PullStatus
-	err = b.db.View(func(tx *bolt.Tx) error {
+	err = b.view(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.pullsBucketName)
 		var txErr error
 		s, txErr = b.getPullFromBucket(bucket, key)
@@ -407,26 +447,87 @@ func (b *BoltDB) GetPullStatus(pull models.PullRequest) (*models.PullStatus, err
 	return s, errors.Wrap(err, "DB transaction failed")
 }
 
+// GetPullStatuses returns the status of every pull that has one.
+func (b *BoltDB) GetPullStatuses() ([]models.PullStatus, error) {
+	var statuses []models.PullStatus
+	err := b.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.pullsBucketName)
+		return bucket.ForEach(func(k, v []byte) error {
+			var status models.PullStatus
+			if err := json.Unmarshal(v, &status); err != nil {
+				return errors.Wrapf(err, "deserializing pull at %q", string(k))
+			}
+			statuses = append(statuses, status)
+			return nil
+		})
+	})
+	return statuses, errors.Wrap(err, "DB transaction failed")
+}
+
+// PutPullStatus stores status as is, replacing any status of its pull.
+func (b *BoltDB) PutPullStatus(status models.PullStatus) error {
+	key, err := b.pullKey(status.Pull)
+	if err != nil {
+		return err
+	}
+	err = b.update(func(tx *bolt.Tx) error {
+		return b.writePullToBucket(tx.Bucket(b.pullsBucketName), key, status)
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
 // DeletePullStatus deletes the status for pull.
 func (b *BoltDB) DeletePullStatus(pull models.PullRequest) error {
 	key, err := b.pullKey(pull)
 	if err != nil {
 		return err
 	}
-	err = b.db.Update(func(tx *bolt.Tx) error {
+	err = b.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.pullsBucketName)
 		return bucket.Delete(key)
 	})
 	return errors.Wrap(err, "DB transaction failed")
 }
 
+// RenameProjectStatuses changes the project being renamed in the pull
+// statuses of its repo. It returns how many pull statuses were changed.
+func (b *BoltDB) RenameProjectStatuses(rename models.ProjectRename) (int, error) {
+	renamed := 0
+	err := b.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.pullsBucketName)
+		updated := make(map[string]models.PullStatus)
+		err := bucket.ForEach(func(k, v []byte) error {
+			var status models.PullStatus
+			if err := json.Unmarshal(v, &status); err != nil {
+				return errors.Wrapf(err, "deserializing pull at %q", string(k))
+			}
+			if status.RenameProject(rename) {
+				updated[string(k)] = status
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Buckets can't be modified while iterating over them.
+		for k, status := range updated {
+			if err := b.writePullToBucket(bucket, []byte(k), status); err != nil {
+				return err
+			}
+		}
+		renamed = len(updated)
+		return nil
+	})
+	return renamed, errors.Wrap(err, "DB transaction failed")
+}
+
 // UpdateProjectStatus updates project status.
 func (b *BoltDB) UpdateProjectStatus(pull models.PullRequest, workspace string, repoRelDir string, newStatus models.ProjectPlanStatus) error {
 	key, err := b.pullKey(pull)
 	if err != nil {
 		return err
 	}
-	err = b.db.Update(func(tx *bolt.Tx) error {
+	err = b.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.pullsBucketName)
 		currStatusPtr, err := b.getPullFromBucket(bucket, key)
 		if err != nil {
@@ -452,6 +553,258 @@ func (b *BoltDB) UpdateProjectStatus(pull models.PullRequest, workspace string,
 	return errors.Wrap(err, "DB transaction failed")
 }
 
+// RecordDelivery records the webhook delivery identified by key. It returns
+// false if the key was already recorded within window. Expired deliveries are
+// pruned in received order using an index keyed by the time they were received.
+func (b *BoltDB) RecordDelivery(key string, receivedAt time.Time, window time.Duration) (bool, error) {
+	var recorded bool
+	err := b.update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(deliveriesBucketName))
+		if err != nil {
+			return errors.Wrapf(err, "creating bucket %q", deliveriesBucketName)
+		}
+		index := tx.Bucket([]byte(deliveriesByTimeBucketName))
+		if index == nil {
+			if index, err = createDeliveriesIndex(tx, bucket); err != nil {
+				return err
+			}
+		}
+
+		cutoff := receivedAt.Add(-window).Unix()
+		var expired [][]byte
+		c := index.Cursor()
+		for k, v := c.First(); k != nil && int64(binary.BigEndian.Uint64(k[:8])) < cutoff; k, v = c.Next() { // nolint: gosec
+			expired = append(expired, k)
+			// The delivery may have been recorded again after this index
+			// entry was written, in which case it's not expired.
+			if seenAt, err := strconv.ParseInt(string(bucket.Get(v)), 10, 64); err != nil || seenAt < cutoff {
+				if err := bucket.Delete(v); err != nil {
+					return err
+				}
+			}
+		}
+		for _, k := range expired {
+			if err := index.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		if bucket.Get([]byte(key)) != nil {
+			return nil
+		}
+		recorded = true
+		if err := index.Put(deliveryIndexKey(receivedAt.Unix(), key), []byte(key)); err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), []byte(strconv.FormatInt(receivedAt.Unix(), 10)))
+	})
+	return recorded, errors.Wrap(err, "DB transaction failed")
+}
+
+// createDeliveriesIndex creates the index of the deliveries by received time
+// and indexes the deliveries recorded before it existed.
+func createDeliveriesIndex(tx *bolt.Tx, deliveries *bolt.Bucket) (*bolt.Bucket, error) {
+	index, err := tx.CreateBucket([]byte(deliveriesByTimeBucketName))
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating bucket %q", deliveriesByTimeBucketName)
+	}
+	err = deliveries.ForEach(func(k, v []byte) error {
+		// Unparseable entries get the zero time so they're pruned first.
+		seenAt, _ := strconv.ParseInt(string(v), 10, 64)
+		return index.Put(deliveryIndexKey(seenAt, string(k)), k)
+	})
+	return index, err
+}
+
+// deliveryIndexKey returns the key of a delivery in the received time index.
+// The time is big endian so keys sort in received order.
+func deliveryIndexKey(receivedAt int64, key string) []byte {
+	k := make([]byte, 8, 8+len(key))
+	binary.BigEndian.PutUint64(k, uint64(receivedAt)) // nolint: gosec
+	return append(k, key...)
+}
+
+// UpdateDriftStatus stores the latest drift detection result for a project.
+func (b *BoltDB) UpdateDriftStatus(status models.DriftStatus) error {
+	serialized, err := json.Marshal(status)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	err = b.update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(driftBucketName))
+		if err != nil {
+			return errors.Wrapf(err, "creating bucket %q", driftBucketName)
+		}
+		return bucket.Put([]byte(status.Key()), serialized)
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// GetDriftStatuses returns the latest drift detection result of every project
+// that has been checked.
+func (b *BoltDB) GetDriftStatuses() ([]models.DriftStatus, error) {
+	var statuses []models.DriftStatus
+	err := b.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(driftBucketName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var status models.DriftStatus
+			if err := json.Unmarshal(v, &status); err != nil {
+				return errors.Wrapf(err, "failed to deserialize drift status at key %q", string(k))
+			}
+			statuses = append(statuses, status)
+			return nil
+		})
+	})
+	return statuses, errors.Wrap(err, "DB transaction failed")
+}
+
+// AppendJobOutput stores job and appends lines to its output. Each job has
+// its own bucket holding the job and a bucket of its lines keyed by their
+// sequence number.
+func (b *BoltDB) AppendJobOutput(job models.JobOutput, lines []string) error {
+	job.Lines = nil
+	serialized, err := json.Marshal(job)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	err = b.update(func(tx *bolt.Tx) error {
+		jobs, err := tx.CreateBucketIfNotExists([]byte(jobOutputsBucketName))
+		if err != nil {
+			return errors.Wrapf(err, "creating bucket %q", jobOutputsBucketName)
+		}
+		jobBucket, err := jobs.CreateBucketIfNotExists([]byte(job.JobID))
+		if err != nil {
+			return errors.Wrapf(err, "creating bucket for job %q", job.JobID)
+		}
+		if err := jobBucket.Put([]byte("job"), serialized); err != nil {
+			return err
+		}
+		linesBucket, err := jobBucket.CreateBucketIfNotExists([]byte("lines"))
+		if err != nil {
+			return errors.Wrapf(err, "creating lines bucket for job %q", job.JobID)
+		}
+		for _, line := range lines {
+			seq, err := linesBucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			if err := linesBucket.Put(sequenceKey(seq), []byte(line)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// GetJobOutput returns the job with jobID and its lines, or nil if there
+// isn't one.
+func (b *BoltDB) GetJobOutput(jobID string) (*models.JobOutput, error) {
+	var job *models.JobOutput
+	err := b.view(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket([]byte(jobOutputsBucketName))
+		if jobs == nil {
+			return nil
+		}
+		jobBucket := jobs.Bucket([]byte(jobID))
+		if jobBucket == nil {
+			return nil
+		}
+		var err error
+		if job, err = getJobFromBucket(jobBucket); err != nil {
+			return err
+		}
+		job.Lines = []string{}
+		if linesBucket := jobBucket.Bucket([]byte("lines")); linesBucket != nil {
+			return linesBucket.ForEach(func(_, v []byte) error {
+				job.Lines = append(job.Lines, string(v))
+				return nil
+			})
+		}
+		return nil
+	})
+	return job, errors.Wrap(err, "DB transaction failed")
+}
+
+// GetJobOutputs returns the jobs of a pull request without their lines.
+func (b *BoltDB) GetJobOutputs(repoFullName string, pullNum int) ([]models.JobOutput, error) {
+	var result []models.JobOutput
+	err := b.view(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket([]byte(jobOutputsBucketName))
+		if jobs == nil {
+			return nil
+		}
+		return jobs.ForEach(func(k, _ []byte) error {
+			job, err := getJobFromBucket(jobs.Bucket(k))
+			if err != nil {
+				return err
+			}
+			if job.RepoFullName == repoFullName && job.PullNum == pullNum {
+				result = append(result, *job)
+			}
+			return nil
+		})
+	})
+	return result, errors.Wrap(err, "DB transaction failed")
+}
+
+// DeleteJobOutputs deletes the jobs last updated before cutoff and returns
+// how many were deleted.
+func (b *BoltDB) DeleteJobOutputs(cutoff time.Time) (int, error) {
+	deleted := 0
+	err := b.update(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket([]byte(jobOutputsBucketName))
+		if jobs == nil {
+			return nil
+		}
+		var expired [][]byte
+		err := jobs.ForEach(func(k, _ []byte) error {
+			job, err := getJobFromBucket(jobs.Bucket(k))
+			if err != nil {
+				return err
+			}
+			if job.UpdatedAt.Before(cutoff) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Buckets can't be modified while iterating over them.
+		for _, k := range expired {
+			if err := jobs.DeleteBucket(k); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, errors.Wrap(err, "DB transaction failed")
+}
+
+func getJobFromBucket(jobBucket *bolt.Bucket) (*models.JobOutput, error) {
+	var job models.JobOutput
+	if jobBucket == nil {
+		return nil, errors.New("job output isn't a bucket")
+	}
+	v := jobBucket.Get([]byte("job"))
+	if err := json.Unmarshal(v, &job); err != nil {
+		return nil, errors.Wrapf(err, "failed to deserialize job output %q", string(v))
+	}
+	return &job, nil
+}
+
+// sequenceKey returns a key that sorts in the order of seq.
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
 func (b *BoltDB) pullKey(pull models.PullRequest) ([]byte, error) {
 	hostname := pull.BaseRepo.VCSHost.Hostname
 	if strings.Contains(hostname, pullKeySeparator) {
@@ -506,5 +859,120 @@ func (b *BoltDB) projectResultToProject(p command.ProjectResult) models.ProjectS
 }
 
 func (b *BoltDB) Close() error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
 	return b.db.Close()
 }
+
+func (b *BoltDB) update(fn func(*bolt.Tx) error) error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.db.Update(fn)
+}
+
+func (b *BoltDB) view(fn func(*bolt.Tx) error) error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.db.View(fn)
+}
+
+// FileStats returns the size of the database file and how many bytes of it
+// are free pages, i.e. space left by deleted data that's only reclaimed by
+// compacting.
+func (b *BoltDB) FileStats() (size int64, free int64, err error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	info, err := os.Stat(b.db.Path())
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "reading database file size")
+	}
+	return info.Size(), int64(b.db.Stats().FreeAlloc), nil
+}
+
+// Compact rewrites the database file without its free pages. BoltDB never
+// shrinks its file, so on long-lived installs it grows with every lock and
+// pull status that was ever written. Reads and writes wait until compaction
+// is done. It returns the size of the file before and after compacting.
+func (b *BoltDB) Compact() (sizeBefore int64, sizeAfter int64, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	dbPath := b.db.Path()
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "reading database file size")
+	}
+	sizeBefore = info.Size()
+
+	compactPath := dbPath + ".compact"
+	// Remove what's left of a compaction that was interrupted.
+	if err := os.Remove(compactPath); err != nil && !os.IsNotExist(err) {
+		return sizeBefore, 0, errors.Wrapf(err, "removing %s", compactPath)
+	}
+	dst, err := bolt.Open(compactPath, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return sizeBefore, 0, errors.Wrap(err, "creating compacted database")
+	}
+	if err := bolt.Compact(dst, b.db, compactTxMaxSize); err != nil {
+		dst.Close()            // nolint: errcheck
+		os.Remove(compactPath) // nolint: errcheck
+		return sizeBefore, 0, errors.Wrap(err, "compacting database")
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(compactPath) // nolint: errcheck
+		return sizeBefore, 0, errors.Wrap(err, "closing compacted database")
+	}
+
+	// The original file is kept as a hard link until the compacted one is
+	// opened so that we can go back to it if that fails. Since both are only
+	// ever renamed into place, there's always a database at dbPath even if
+	// Atlantis is stopped while compacting.
+	backupPath := dbPath + ".precompact"
+	if err := os.Remove(backupPath); err != nil && !os.IsNotExist(err) {
+		os.Remove(compactPath) // nolint: errcheck
+		return sizeBefore, 0, errors.Wrapf(err, "removing %s", backupPath)
+	}
+	if err := os.Link(dbPath, backupPath); err != nil {
+		os.Remove(compactPath) // nolint: errcheck
+		return sizeBefore, 0, errors.Wrap(err, "backing up database")
+	}
+
+	if err := b.db.Close(); err != nil {
+		os.Remove(compactPath) // nolint: errcheck
+		os.Remove(backupPath)  // nolint: errcheck
+		return sizeBefore, 0, errors.Wrap(err, "closing database")
+	}
+	if err := os.Rename(compactPath, dbPath); err != nil {
+		os.Remove(compactPath) // nolint: errcheck
+		os.Remove(backupPath)  // nolint: errcheck
+		return sizeBefore, 0, b.reopen(dbPath, errors.Wrap(err, "replacing database with compacted database"))
+	}
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		err = errors.Wrap(err, "opening compacted database")
+		if restoreErr := os.Rename(backupPath, dbPath); restoreErr != nil {
+			return sizeBefore, 0, errors.Wrapf(restoreErr, "restoring database from %s after %s, Atlantis must be restarted once it's restored", backupPath, err)
+		}
+		return sizeBefore, 0, b.reopen(dbPath, err)
+	}
+	b.db = db
+	os.Remove(backupPath) // nolint: errcheck
+
+	info, err = os.Stat(dbPath)
+	if err != nil {
+		return sizeBefore, 0, errors.Wrap(err, "reading database file size")
+	}
+	return sizeBefore, info.Size(), nil
+}
+
+// reopen opens the database at dbPath again after compacting it failed with
+// cause, which is returned. If it can't be opened, the database stays closed
+// and Atlantis has to be restarted.
+func (b *BoltDB) reopen(dbPath string, cause error) error {
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return errors.Wrapf(err, "reopening database after %s, Atlantis must be restarted", cause)
+	}
+	b.db = db
+	return cause
+}
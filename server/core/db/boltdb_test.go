@@ -15,6 +15,8 @@ package db_test
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -492,6 +494,97 @@ func TestPullStatus_UpdateGet(t *testing.T) {
 	b.Close()
 }
 
+func TestPullStatus_GetAll(t *testing.T) {
+	b := newTestDB2(t)
+
+	statuses, err := b.GetPullStatuses()
+	Ok(t, err)
+	Equals(t, 0, len(statuses))
+
+	repo := models.Repo{
+		FullName: "runatlantis/atlantis",
+		VCSHost: models.VCSHost{
+			Hostname: "github.com",
+			Type:     models.Github,
+		},
+	}
+	for _, num := range []int{1, 2} {
+		_, err := b.UpdatePullWithResults(
+			models.PullRequest{Num: num, BaseRepo: repo},
+			[]command.ProjectResult{
+				{
+					Command:     command.Plan,
+					RepoRelDir:  ".",
+					Workspace:   "default",
+					PlanSuccess: &models.PlanSuccess{},
+				},
+			})
+		Ok(t, err)
+	}
+	Ok(t, b.UpdateDriftStatus(models.DriftStatus{RepoID: "github.com/runatlantis/atlantis", RepoRelDir: ".", Workspace: "default"}))
+
+	statuses, err = b.GetPullStatuses()
+	Ok(t, err)
+	Equals(t, 2, len(statuses))
+	nums := map[int]bool{}
+	for _, s := range statuses {
+		nums[s.Pull.Num] = true
+		Equals(t, models.PlannedPlanStatus, s.Projects[0].Status)
+	}
+	Equals(t, map[int]bool{1: true, 2: true}, nums)
+	b.Close()
+}
+
+func TestPullStatus_RenameProject(t *testing.T) {
+	b := newTestDB2(t)
+	repo := models.Repo{
+		FullName: "runatlantis/atlantis",
+		VCSHost: models.VCSHost{
+			Hostname: "github.com",
+			Type:     models.Github,
+		},
+	}
+	otherRepo := repo
+	otherRepo.FullName = "runatlantis/other"
+	for _, pull := range []models.PullRequest{{Num: 1, BaseRepo: repo}, {Num: 2, BaseRepo: otherRepo}} {
+		_, err := b.UpdatePullWithResults(pull, []command.ProjectResult{
+			{
+				Command:     command.Plan,
+				RepoRelDir:  "network",
+				Workspace:   "default",
+				PlanSuccess: &models.PlanSuccess{},
+			},
+			{
+				Command:     command.Plan,
+				RepoRelDir:  "app",
+				Workspace:   "default",
+				PlanSuccess: &models.PlanSuccess{},
+			},
+		})
+		Ok(t, err)
+	}
+
+	renamed, err := b.RenameProjectStatuses(models.ProjectRename{
+		RepoFullName: repo.FullName,
+		From:         models.ProjectLocation{RepoRelDir: "network", Workspace: "default"},
+		To:           models.ProjectLocation{RepoRelDir: "infra/network", Workspace: "default", ProjectName: "network"},
+	})
+	Ok(t, err)
+	Equals(t, 1, renamed)
+
+	status, err := b.GetPullStatus(models.PullRequest{Num: 1, BaseRepo: repo})
+	Ok(t, err)
+	Equals(t, "infra/network", status.Projects[0].RepoRelDir)
+	Equals(t, "network", status.Projects[0].ProjectName)
+	Equals(t, models.PlannedPlanStatus, status.Projects[0].Status)
+	Equals(t, "app", status.Projects[1].RepoRelDir)
+
+	status, err = b.GetPullStatus(models.PullRequest{Num: 2, BaseRepo: otherRepo})
+	Ok(t, err)
+	Equals(t, "network", status.Projects[0].RepoRelDir)
+	b.Close()
+}
+
 // Test we can create a status, delete it, and then we shouldn't be able to getCommandLock
 // it.
 func TestPullStatus_UpdateDeleteGet(t *testing.T) {
@@ -602,6 +695,55 @@ func TestPullStatus_UpdateProject(t *testing.T) {
 	b.Close()
 }
 
+// Test that fork approvals are kept while results for the same commit are
+// added and dropped once there's a new commit.
+func TestPullStatus_UpdateForkApproval(t *testing.T) {
+	b := newTestDB2(t)
+
+	pull := models.PullRequest{
+		Num:        1,
+		HeadCommit: "sha",
+		State:      models.OpenPullState,
+		BaseRepo: models.Repo{
+			FullName: "runatlantis/atlantis",
+			VCSHost: models.VCSHost{
+				Hostname: "github.com",
+				Type:     models.Github,
+			},
+		},
+	}
+	approval := models.ForkApproval{
+		HeadCommit: "sha",
+		Approver:   "maintainer",
+	}
+	err := b.UpdateForkApproval(pull, approval)
+	Ok(t, err)
+
+	status, err := b.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			RepoRelDir: ".",
+			Workspace:  "default",
+			Failure:    "failure",
+		},
+	})
+	Ok(t, err)
+	Equals(t, &approval, status.ForkApproval)
+	Assert(t, status.IsForkApproved("sha"), "exp approved")
+
+	pull.HeadCommit = "newsha"
+	status, err = b.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			RepoRelDir: ".",
+			Workspace:  "default",
+			Failure:    "failure",
+		},
+	})
+	Ok(t, err)
+	Assert(t, status.ForkApproval == nil, "exp approval to be dropped for new commit")
+	Assert(t, !status.IsForkApproved("newsha"), "exp not approved")
+	b.Close()
+}
+
 // Test that if we update an existing pull status and our new status is for a
 // different HeadSHA, that we just overwrite the old status.
 func TestPullStatus_UpdateNewCommit(t *testing.T) {
@@ -873,6 +1015,7 @@ func TestPullStatus_UpdateMerge_ApprovePolicies(t *testing.T) {
 					{
 						PolicySetName: "policy1",
 						Approvals:     1,
+						ReqApprovals:  1,
 					},
 				},
 			},
@@ -885,6 +1028,7 @@ func TestPullStatus_UpdateMerge_ApprovePolicies(t *testing.T) {
 					{
 						PolicySetName: "policy1",
 						Approvals:     0,
+						ReqApprovals:  1,
 					},
 				},
 			},
@@ -893,6 +1037,87 @@ func TestPullStatus_UpdateMerge_ApprovePolicies(t *testing.T) {
 	b.Close()
 }
 
+func TestRecordDelivery(t *testing.T) {
+	b := newTestDB2(t)
+	now := time.Now()
+
+	recorded, err := b.RecordDelivery("github/delivery-1", now, time.Hour)
+	Ok(t, err)
+	Equals(t, true, recorded)
+
+	t.Log("the same delivery within the window is a duplicate")
+	recorded, err = b.RecordDelivery("github/delivery-1", now.Add(time.Minute), time.Hour)
+	Ok(t, err)
+	Equals(t, false, recorded)
+
+	t.Log("a different delivery is recorded")
+	recorded, err = b.RecordDelivery("github/delivery-2", now.Add(time.Minute), time.Hour)
+	Ok(t, err)
+	Equals(t, true, recorded)
+
+	t.Log("the delivery can be recorded again once the window has passed")
+	recorded, err = b.RecordDelivery("github/delivery-1", now.Add(2*time.Hour), time.Hour)
+	Ok(t, err)
+	Equals(t, true, recorded)
+}
+
+func TestRecordDelivery_NoBucket(t *testing.T) {
+	t.Log("recording a delivery should work on databases created before the bucket existed")
+	db, b := newTestDB()
+	defer cleanupDB(db)
+	recorded, err := b.RecordDelivery("gitlab/uuid", time.Now(), time.Hour)
+	Ok(t, err)
+	Equals(t, true, recorded)
+}
+
+func TestRecordDelivery_Prune(t *testing.T) {
+	boltDB, b := newTestDB()
+	defer cleanupDB(boltDB)
+	now := time.Now()
+
+	t.Log("deliveries recorded before the index existed are indexed and pruned")
+	err := boltDB.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket([]byte("webhookDeliveries"))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte("github/old"), []byte(strconv.FormatInt(now.Add(-2*time.Hour).Unix(), 10)))
+	})
+	Ok(t, err)
+
+	_, err = b.RecordDelivery("github/delivery-1", now, time.Hour)
+	Ok(t, err)
+	_, err = b.RecordDelivery("github/delivery-2", now.Add(30*time.Minute), time.Hour)
+	Ok(t, err)
+	Equals(t, []string{"github/delivery-1", "github/delivery-2"}, recordedDeliveries(t, boltDB))
+
+	t.Log("only the deliveries older than the window are pruned")
+	_, err = b.RecordDelivery("github/delivery-3", now.Add(90*time.Minute), time.Hour)
+	Ok(t, err)
+	Equals(t, []string{"github/delivery-2", "github/delivery-3"}, recordedDeliveries(t, boltDB))
+}
+
+// recordedDeliveries returns the deliveries recorded in boltDB, checking that
+// the index has an entry for each of them.
+func recordedDeliveries(t *testing.T, boltDB *bolt.DB) []string {
+	var deliveries, indexed []string
+	err := boltDB.View(func(tx *bolt.Tx) error {
+		if err := tx.Bucket([]byte("webhookDeliveries")).ForEach(func(k, _ []byte) error {
+			deliveries = append(deliveries, string(k))
+			return nil
+		}); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("webhookDeliveriesByTime")).ForEach(func(_, v []byte) error {
+			indexed = append(indexed, string(v))
+			return nil
+		})
+	})
+	Ok(t, err)
+	Equals(t, deliveries, indexed)
+	return deliveries
+}
+
 // newTestDB returns a TestDB using a temporary path.
 func newTestDB() (*bolt.DB, *db.BoltDB) {
 	// Retrieve a temporary path.
@@ -934,3 +1159,137 @@ func cleanupDB(db *bolt.DB) {
 	db.Close()           // nolint: errcheck
 	os.Remove(db.Path()) // nolint: errcheck
 }
+
+func TestDriftStatus_UpdateAndGet(t *testing.T) {
+	b := newTestDB2(t)
+
+	statuses, err := b.GetDriftStatuses()
+	Ok(t, err)
+	Equals(t, 0, len(statuses))
+
+	status := models.DriftStatus{
+		RepoID:           "github.com/runatlantis/atlantis",
+		RepoFullName:     "runatlantis/atlantis",
+		Branch:           "main",
+		RepoRelDir:       ".",
+		Workspace:        "default",
+		Drifted:          true,
+		DriftedResources: 2,
+		CheckedAt:        time.Now().UTC().Truncate(time.Second),
+	}
+	Ok(t, b.UpdateDriftStatus(status))
+
+	// A newer result replaces the old one.
+	status.Drifted = false
+	status.DriftedResources = 0
+	Ok(t, b.UpdateDriftStatus(status))
+
+	other := status
+	other.RepoRelDir = "other"
+	other.Error = "error"
+	Ok(t, b.UpdateDriftStatus(other))
+
+	statuses, err = b.GetDriftStatuses()
+	Ok(t, err)
+	Equals(t, 2, len(statuses))
+	for _, s := range statuses {
+		if s.RepoRelDir == "." {
+			Equals(t, status, s)
+		} else {
+			Equals(t, other, s)
+		}
+	}
+}
+
+func TestJobOutput_AppendGetAndDelete(t *testing.T) {
+	b := newTestDB2(t)
+
+	job, err := b.GetJobOutput("1")
+	Ok(t, err)
+	Assert(t, job == nil, "expected no job")
+
+	started := time.Now().UTC().Truncate(time.Second)
+	plan := models.JobOutput{
+		JobID:        "1",
+		RepoFullName: "runatlantis/atlantis",
+		PullNum:      1,
+		RepoRelDir:   ".",
+		Workspace:    "default",
+		JobStep:      "plan",
+		StartedAt:    started,
+		UpdatedAt:    started,
+	}
+	Ok(t, b.AppendJobOutput(plan, []string{"line 1", "line 2"}))
+	plan.Complete = true
+	plan.UpdatedAt = started.Add(time.Minute)
+	Ok(t, b.AppendJobOutput(plan, []string{"line 3"}))
+	otherPull := plan
+	otherPull.JobID = "2"
+	otherPull.PullNum = 2
+	otherPull.UpdatedAt = started
+	Ok(t, b.AppendJobOutput(otherPull, nil))
+
+	job, err = b.GetJobOutput("1")
+	Ok(t, err)
+	withLines := plan
+	withLines.Lines = []string{"line 1", "line 2", "line 3"}
+	Equals(t, withLines, *job)
+
+	jobs, err := b.GetJobOutputs("runatlantis/atlantis", 1)
+	Ok(t, err)
+	Equals(t, []models.JobOutput{plan}, jobs)
+
+	t.Log("only the jobs last updated before the cutoff are deleted")
+	deleted, err := b.DeleteJobOutputs(started.Add(30 * time.Second))
+	Ok(t, err)
+	Equals(t, 1, deleted)
+	job, err = b.GetJobOutput("2")
+	Ok(t, err)
+	Assert(t, job == nil, "expected job 2 to be deleted")
+	job, err = b.GetJobOutput("1")
+	Ok(t, err)
+	Assert(t, job != nil, "expected job 1 to be kept")
+}
+
+func TestCompact(t *testing.T) {
+	tmp := t.TempDir()
+	b, err := db.New(tmp)
+	Ok(t, err)
+	pull := models.PullRequest{
+		Num:        1,
+		HeadCommit: "sha",
+		BaseRepo:   models.Repo{FullName: "runatlantis/atlantis"},
+	}
+	for i := 0; i < 200; i++ {
+		pull.Num = i
+		_, err := b.UpdatePullWithResults(pull, []command.ProjectResult{
+			{RepoRelDir: strings.Repeat("dir", 100), Workspace: "default"},
+		})
+		Ok(t, err)
+	}
+	for i := 1; i < 200; i++ {
+		pull.Num = i
+		Ok(t, b.DeletePullStatus(pull))
+	}
+	size, free, err := b.FileStats()
+	Ok(t, err)
+	Assert(t, free > 0, "expected free pages after deleting pull statuses")
+
+	sizeBefore, sizeAfter, err := b.Compact()
+	Ok(t, err)
+	Equals(t, size, sizeBefore)
+	Assert(t, sizeAfter < sizeBefore, "expected compacting to shrink the file from %d bytes, got %d", sizeBefore, sizeAfter)
+	// The compacted file and the backup of the original are gone.
+	entries, err := os.ReadDir(tmp)
+	Ok(t, err)
+	Equals(t, 1, len(entries))
+
+	t.Log("the database still works after compacting")
+	pull.Num = 0
+	status, err := b.GetPullStatus(pull)
+	Ok(t, err)
+	Assert(t, status != nil, "expected the pull status to be kept")
+	pull.Num = 1
+	_, err = b.UpdatePullWithResults(pull, []command.ProjectResult{{RepoRelDir: ".", Workspace: "default"}})
+	Ok(t, err)
+}
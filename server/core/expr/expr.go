@@ -0,0 +1,202 @@
+// Package expr implements the filter expressions of workflow hooks, steps and
+// webhooks, ex. `command == "apply" && startswith(project.dir, "prod/")`.
+// Expressions use the HCL expression syntax and are evaluated against the
+// context of the command that's being run.
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/runatlantis/atlantis/server/utils"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// Vars are the values expressions are evaluated against. Values that don't
+// apply where an expression is evaluated are empty, ex. project in a pre
+// workflow hook.
+type Vars struct {
+	// Command is the name of the command, ex. "plan".
+	Command string
+	// Result is "success" or "failure" once the command has run.
+	Result string
+	// Event is the lifecycle event a post workflow hook runs on, ex.
+	// "pull_closed".
+	Event string
+	// Repo is the full name of the base repo, ex. "runatlantis/atlantis".
+	Repo string
+	// User is the username of the user that ran the command.
+	User    string
+	Pull    PullVars
+	Project ProjectVars
+}
+
+// PullVars describe the pull request of the command.
+type PullVars struct {
+	Num        int
+	Author     string
+	BaseBranch string
+	HeadBranch string
+}
+
+// ProjectVars describe the project of the command.
+type ProjectVars struct {
+	Name      string
+	Dir       string
+	Workspace string
+}
+
+// variables are the top-level names expressions can refer to.
+var variables = []string{"command", "result", "event", "repo", "user", "pull", "project"}
+
+func (v Vars) ctyValues() map[string]cty.Value {
+	return map[string]cty.Value{
+		"command": cty.StringVal(v.Command),
+		"result":  cty.StringVal(v.Result),
+		"event":   cty.StringVal(v.Event),
+		"repo":    cty.StringVal(v.Repo),
+		"user":    cty.StringVal(v.User),
+		"pull": cty.ObjectVal(map[string]cty.Value{
+			"num":         cty.NumberIntVal(int64(v.Pull.Num)),
+			"author":      cty.StringVal(v.Pull.Author),
+			"base_branch": cty.StringVal(v.Pull.BaseBranch),
+			"head_branch": cty.StringVal(v.Pull.HeadBranch),
+		}),
+		"project": cty.ObjectVal(map[string]cty.Value{
+			"name":      cty.StringVal(v.Project.Name),
+			"dir":       cty.StringVal(v.Project.Dir),
+			"workspace": cty.StringVal(v.Project.Workspace),
+		}),
+	}
+}
+
+// functions are the functions expressions can call.
+var functions = map[string]function.Function{
+	"contains": stdlib.ContainsFunc,
+	"lower":    stdlib.LowerFunc,
+	"upper":    stdlib.UpperFunc,
+	"startswith": stringPredicate(func(s string, prefix string) (bool, error) {
+		return strings.HasPrefix(s, prefix), nil
+	}),
+	"endswith": stringPredicate(func(s string, suffix string) (bool, error) {
+		return strings.HasSuffix(s, suffix), nil
+	}),
+	"matches": stringPredicate(func(s string, pattern string) (bool, error) {
+		return regexp.MatchString(pattern, s)
+	}),
+}
+
+// stringPredicate returns a function that calls f with its two string
+// arguments.
+func stringPredicate(f func(s string, arg string) (bool, error)) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "str", Type: cty.String},
+			{Name: "arg", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.Bool),
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			ok, err := f(args[0].AsString(), args[1].AsString())
+			if err != nil {
+				return cty.UnknownVal(cty.Bool), err
+			}
+			return cty.BoolVal(ok), nil
+		},
+	})
+}
+
+// Expr is a parsed expression.
+type Expr struct {
+	src  string
+	expr hclsyntax.Expression
+}
+
+// Parse parses src. It returns an error if src isn't a valid expression or
+// refers to variables or functions that don't exist.
+func Parse(src string) (*Expr, error) {
+	e, diags := hclsyntax.ParseExpression([]byte(src), "expression", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing %q: %s", src, diagsError(diags))
+	}
+	for _, traversal := range e.Variables() {
+		if name := traversal.RootName(); !utils.SlicesContains(variables, name) {
+			return nil, fmt.Errorf("parsing %q: unknown variable %q, only %s are supported", src, name, strings.Join(variables, ", "))
+		}
+	}
+	var err error
+	hclsyntax.VisitAll(e, func(node hclsyntax.Node) hcl.Diagnostics { // nolint: errcheck
+		if call, ok := node.(*hclsyntax.FunctionCallExpr); ok && err == nil {
+			if _, ok := functions[call.Name]; !ok {
+				err = fmt.Errorf("parsing %q: unknown function %q, only %s are supported", src, call.Name, strings.Join(functionNames(), ", "))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{src: src, expr: e}, nil
+}
+
+// Eval evaluates e against vars. Expressions must evaluate to a bool.
+func (e *Expr) Eval(vars Vars) (bool, error) {
+	val, diags := e.expr.Value(&hcl.EvalContext{
+		Variables: vars.ctyValues(),
+		Functions: functions,
+	})
+	if diags.HasErrors() {
+		return false, fmt.Errorf("evaluating %q: %s", e.src, diagsError(diags))
+	}
+	if val.IsNull() || !val.Type().Equals(cty.Bool) {
+		return false, fmt.Errorf("evaluating %q: must evaluate to true or false, got %s", e.src, val.Type().FriendlyName())
+	}
+	return val.True(), nil
+}
+
+// String returns the source of e.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval parses and evaluates src against vars. An empty src evaluates to true.
+func Eval(src string, vars Vars) (bool, error) {
+	if src == "" {
+		return true, nil
+	}
+	e, err := Parse(src)
+	if err != nil {
+		return false, err
+	}
+	return e.Eval(vars)
+}
+
+// diagsError joins the summaries and details of the errors in diags.
+func diagsError(diags hcl.Diagnostics) string {
+	var errs []string
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagError {
+			continue
+		}
+		msg := diag.Summary
+		if diag.Detail != "" {
+			msg += ": " + diag.Detail
+		}
+		errs = append(errs, msg)
+	}
+	return strings.Join(errs, "; ")
+}
+
+func functionNames() []string {
+	var names []string
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,76 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/expr"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestParse_Errors(t *testing.T) {
+	cases := map[string]string{
+		`command ==`:                   `parsing "command ==": Missing expression`,
+		`workspace == "prod"`:          `parsing "workspace == \"prod\"": unknown variable "workspace", only command, result, event, repo, user, pull, project are supported`,
+		`exists(project.dir)`:          `parsing "exists(project.dir)": unknown function "exists", only contains, endswith, lower, matches, startswith, upper are supported`,
+		`lower(command) == "x" || nop`: `unknown variable "nop"`,
+	}
+	for src, expErr := range cases {
+		t.Run(src, func(t *testing.T) {
+			_, err := expr.Parse(src)
+			ErrContains(t, expErr, err)
+		})
+	}
+}
+
+func TestExpr_Eval(t *testing.T) {
+	vars := expr.Vars{
+		Command: "apply",
+		Result:  "failure",
+		Repo:    "runatlantis/atlantis",
+		User:    "alice",
+		Pull:    expr.PullVars{Num: 7, Author: "bob", BaseBranch: "main", HeadBranch: "feature"},
+		Project: expr.ProjectVars{Name: "network", Dir: "prod/network", Workspace: "default"},
+	}
+	cases := map[string]bool{
+		`command == "apply"`:                               true,
+		`command == "plan" || result == "failure"`:         true,
+		`startswith(project.dir, "prod/") && pull.num > 5`: true,
+		`endswith(project.dir, "/network")`:                true,
+		`matches(pull.head_branch, "^feat")`:               true,
+		`contains(["alice", "carol"], user)`:               true,
+		`upper(pull.base_branch) == "MAIN"`:                true,
+		`project.name != "network"`:                        false,
+		`event == "" && !(repo == "runatlantis/atlantis")`: false,
+		`lower(user) == user && pull.author != "alice"`:    true,
+	}
+	for src, exp := range cases {
+		t.Run(src, func(t *testing.T) {
+			e, err := expr.Parse(src)
+			Ok(t, err)
+			act, err := e.Eval(vars)
+			Ok(t, err)
+			Equals(t, exp, act)
+		})
+	}
+}
+
+func TestExpr_EvalErrors(t *testing.T) {
+	cases := map[string]string{
+		`project.dir`:                  `evaluating "project.dir": must evaluate to true or false, got string`,
+		`project.region == "eu"`:       `Unsupported attribute`,
+		`matches(project.dir, "(")`:    `error parsing regexp`,
+		`startswith(project.dir, [1])`: `Invalid function argument`,
+	}
+	for src, expErr := range cases {
+		t.Run(src, func(t *testing.T) {
+			_, err := expr.Eval(src, expr.Vars{})
+			ErrContains(t, expErr, err)
+		})
+	}
+}
+
+func TestEval_Empty(t *testing.T) {
+	ok, err := expr.Eval("", expr.Vars{})
+	Ok(t, err)
+	Equals(t, true, ok)
+}
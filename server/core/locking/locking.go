@@ -37,12 +37,43 @@ type Backend interface {
 	UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error)
 	UpdateProjectStatus(pull models.PullRequest, workspace string, repoRelDir string, newStatus models.ProjectPlanStatus) error
 	GetPullStatus(pull models.PullRequest) (*models.PullStatus, error)
+	// GetPullStatuses returns the status of every pull that has one.
+	GetPullStatuses() ([]models.PullStatus, error)
 	DeletePullStatus(pull models.PullRequest) error
+	// RenameProjectStatuses changes the project being renamed in the pull
+	// statuses of its repo to its new dir, workspace and name. It returns
+	// how many pull statuses were changed.
+	RenameProjectStatuses(rename models.ProjectRename) (int, error)
 	UpdatePullWithResults(pull models.PullRequest, newResults []command.ProjectResult) (models.PullStatus, error)
+	UpdateForkApproval(pull models.PullRequest, approval models.ForkApproval) error
 
 	LockCommand(cmdName command.Name, lockTime time.Time) (*command.Lock, error)
 	UnlockCommand(cmdName command.Name) error
 	CheckCommandLock(cmdName command.Name) (*command.Lock, error)
+
+	// RecordDelivery records that a webhook delivery identified by key was
+	// received at receivedAt. It returns false if the same key was already
+	// recorded within window, meaning the delivery is a duplicate.
+	RecordDelivery(key string, receivedAt time.Time, window time.Duration) (bool, error)
+
+	// UpdateDriftStatus stores the latest drift detection result for a
+	// project, replacing any previous result.
+	UpdateDriftStatus(status models.DriftStatus) error
+	// GetDriftStatuses returns the latest drift detection result of every
+	// project that has been checked.
+	GetDriftStatuses() ([]models.DriftStatus, error)
+
+	// AppendJobOutput stores job, replacing what was stored for it
+	// except for its lines, and appends lines to its output.
+	AppendJobOutput(job models.JobOutput, lines []string) error
+	// GetJobOutput returns the job with jobID and its lines, or nil if there
+	// isn't one.
+	GetJobOutput(jobID string) (*models.JobOutput, error)
+	// GetJobOutputs returns the jobs of a pull request without their lines.
+	GetJobOutputs(repoFullName string, pullNum int) ([]models.JobOutput, error)
+	// DeleteJobOutputs deletes the jobs last updated before cutoff and
+	// returns how many were deleted.
+	DeleteJobOutputs(cutoff time.Time) (int, error)
 }
 
 // TryLockResponse results from an attempted lock.
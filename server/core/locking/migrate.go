@@ -0,0 +1,158 @@
+package locking
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// MigrationTarget is a Backend that the state of another Backend can be
+// copied into.
+type MigrationTarget interface {
+	Backend
+	// PutPullStatus stores status as is, replacing any status of its pull.
+	PutPullStatus(status models.PullStatus) error
+}
+
+// MigrationResult is what was copied by Migrate.
+type MigrationResult struct {
+	Locks        int
+	PullStatuses int
+	DriftResults int
+	CommandLocks int
+}
+
+// Migrate copies the locks, pull statuses, drift detection results and
+// command locks of from to to, then reads them back from to to verify that
+// they're the same. Webhook deliveries aren't copied since they're only kept
+// for a few minutes. It's safe to run again if it fails part way, but it
+// errors if to has a lock on the same project and workspace held by another
+// pull request.
+func Migrate(from Backend, to MigrationTarget) (MigrationResult, error) {
+	var result MigrationResult
+
+	locks, err := from.List()
+	if err != nil {
+		return result, fmt.Errorf("listing locks: %w", err)
+	}
+	for _, lock := range locks {
+		acquired, currLock, err := to.TryLock(lock)
+		if err != nil {
+			return result, fmt.Errorf("copying lock of %s: %w", lockDesc(lock), err)
+		}
+		if !acquired && currLock.Pull.Num != lock.Pull.Num {
+			return result, fmt.Errorf("copying lock of %s: already locked by pull request #%d", lockDesc(lock), currLock.Pull.Num)
+		}
+		result.Locks++
+	}
+
+	statuses, err := from.GetPullStatuses()
+	if err != nil {
+		return result, fmt.Errorf("getting pull statuses: %w", err)
+	}
+	for _, status := range statuses {
+		if err := to.PutPullStatus(status); err != nil {
+			return result, fmt.Errorf("copying pull status of %s#%d: %w", status.Pull.BaseRepo.FullName, status.Pull.Num, err)
+		}
+		result.PullStatuses++
+	}
+
+	driftStatuses, err := from.GetDriftStatuses()
+	if err != nil {
+		return result, fmt.Errorf("getting drift detection results: %w", err)
+	}
+	for _, status := range driftStatuses {
+		if err := to.UpdateDriftStatus(status); err != nil {
+			return result, fmt.Errorf("copying drift detection result of %s dir %q workspace %q: %w", status.RepoFullName, status.RepoRelDir, status.Workspace, err)
+		}
+		result.DriftResults++
+	}
+
+	// Apply is the only command that can be locked.
+	cmdLock, err := from.CheckCommandLock(command.Apply)
+	if err != nil {
+		return result, fmt.Errorf("getting %s command lock: %w", command.Apply, err)
+	}
+	if cmdLock != nil && cmdLock.IsLocked() {
+		currLock, err := to.CheckCommandLock(command.Apply)
+		if err != nil {
+			return result, fmt.Errorf("getting %s command lock of target: %w", command.Apply, err)
+		}
+		if currLock == nil || !currLock.IsLocked() {
+			if _, err := to.LockCommand(command.Apply, cmdLock.LockTime()); err != nil {
+				return result, fmt.Errorf("copying %s command lock: %w", command.Apply, err)
+			}
+		}
+		result.CommandLocks++
+	}
+
+	return result, verifyMigration(locks, statuses, driftStatuses, cmdLock, to)
+}
+
+// verifyMigration checks that to has the same locks, pull statuses, drift
+// detection results and command lock as were read from the source.
+func verifyMigration(locks []models.ProjectLock, statuses []models.PullStatus, driftStatuses []models.DriftStatus, cmdLock *command.Lock, to Backend) error {
+	for _, lock := range locks {
+		got, err := to.GetLock(lock.Project, lock.Workspace)
+		if err != nil {
+			return fmt.Errorf("verifying lock of %s: %w", lockDesc(lock), err)
+		}
+		if got == nil || got.Pull.Num != lock.Pull.Num {
+			return fmt.Errorf("verifying lock of %s: lock wasn't copied", lockDesc(lock))
+		}
+	}
+
+	for _, status := range statuses {
+		got, err := to.GetPullStatus(status.Pull)
+		if err != nil {
+			return fmt.Errorf("verifying pull status of %s#%d: %w", status.Pull.BaseRepo.FullName, status.Pull.Num, err)
+		}
+		if got == nil || !sameJSON(status, *got) {
+			return fmt.Errorf("verifying pull status of %s#%d: copied pull status is different", status.Pull.BaseRepo.FullName, status.Pull.Num)
+		}
+	}
+
+	copiedDrift, err := to.GetDriftStatuses()
+	if err != nil {
+		return fmt.Errorf("verifying drift detection results: %w", err)
+	}
+	for _, status := range driftStatuses {
+		found := false
+		for _, copied := range copiedDrift {
+			if sameJSON(status, copied) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("verifying drift detection result of %s dir %q workspace %q: result wasn't copied", status.RepoFullName, status.RepoRelDir, status.Workspace)
+		}
+	}
+
+	if cmdLock != nil && cmdLock.IsLocked() {
+		got, err := to.CheckCommandLock(command.Apply)
+		if err != nil {
+			return fmt.Errorf("verifying %s command lock: %w", command.Apply, err)
+		}
+		if got == nil || !got.IsLocked() {
+			return fmt.Errorf("verifying %s command lock: lock wasn't copied", command.Apply)
+		}
+	}
+	return nil
+}
+
+func lockDesc(lock models.ProjectLock) string {
+	return fmt.Sprintf("%s dir %q workspace %q", lock.Project.RepoFullName, lock.Project.Path, lock.Workspace)
+}
+
+// sameJSON returns true if a and b serialize to the same JSON. Both backends
+// store JSON, so this compares what was stored rather than in-memory details
+// like time zones.
+func sameJSON(a interface{}, b interface{}) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aJSON, bJSON)
+}
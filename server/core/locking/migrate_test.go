@@ -0,0 +1,73 @@
+package locking_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/core/redis"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestMigrate_BoltDBToRedis(t *testing.T) {
+	from, err := db.New(t.TempDir())
+	Ok(t, err)
+	s := miniredis.RunT(t)
+	to, err := redis.New(s.Host(), s.Server().Addr().Port, "", false, false, 0)
+	Ok(t, err)
+
+	migratePull := models.PullRequest{
+		Num:        2,
+		HeadCommit: "sha",
+		BaseRepo:   models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.com"}},
+	}
+	lock := models.ProjectLock{Project: project, Pull: migratePull, Workspace: workspace, Time: time.Now()}
+	_, _, err = from.TryLock(lock)
+	Ok(t, err)
+	status := models.PullStatus{
+		Pull: migratePull,
+		Projects: []models.ProjectStatus{
+			{Workspace: workspace, RepoRelDir: "path", Status: models.PlannedPlanStatus},
+		},
+	}
+	Ok(t, from.PutPullStatus(status))
+	Ok(t, from.UpdateDriftStatus(models.DriftStatus{RepoFullName: "owner/repo", RepoRelDir: "path", Workspace: workspace, Drifted: true}))
+	_, err = from.LockCommand(command.Apply, time.Now())
+	Ok(t, err)
+
+	result, err := locking.Migrate(from, to)
+	Ok(t, err)
+	Equals(t, locking.MigrationResult{Locks: 1, PullStatuses: 1, DriftResults: 1, CommandLocks: 1}, result)
+
+	gotLock, err := to.GetLock(project, workspace)
+	Ok(t, err)
+	Equals(t, 2, gotLock.Pull.Num)
+	gotStatus, err := to.GetPullStatus(migratePull)
+	Ok(t, err)
+	Equals(t, status.Projects, gotStatus.Projects)
+	cmdLock, err := to.CheckCommandLock(command.Apply)
+	Ok(t, err)
+	Assert(t, cmdLock.IsLocked(), "expected apply command lock to be copied")
+
+	// Migrating again is a no-op.
+	_, err = locking.Migrate(from, to)
+	Ok(t, err)
+}
+
+func TestMigrate_LockConflict(t *testing.T) {
+	from, err := db.New(t.TempDir())
+	Ok(t, err)
+	to, err := db.New(t.TempDir())
+	Ok(t, err)
+	_, _, err = from.TryLock(models.ProjectLock{Project: project, Pull: models.PullRequest{Num: 1}, Workspace: workspace, Time: time.Now()})
+	Ok(t, err)
+	_, _, err = to.TryLock(models.ProjectLock{Project: project, Pull: models.PullRequest{Num: 3}, Workspace: workspace, Time: time.Now()})
+	Ok(t, err)
+
+	_, err = locking.Migrate(from, to)
+	ErrEquals(t, `copying lock of owner/repo dir "path" workspace "workspace": already locked by pull request #3`, err)
+}
@@ -26,6 +26,21 @@ func NewMockBackend(options ...pegomock.Option) *MockBackend {
 func (mock *MockBackend) SetFailHandler(fh pegomock.FailHandler) { mock.fail = fh }
 func (mock *MockBackend) FailHandler() pegomock.FailHandler      { return mock.fail }
 
+func (mock *MockBackend) AppendJobOutput(job models.JobOutput, lines []string) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{job, lines}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("AppendJobOutput", _params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(error)
+		}
+	}
+	return _ret0
+}
+
 func (mock *MockBackend) CheckCommandLock(cmdName command.Name) (*command.Lock, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -45,6 +60,25 @@ func (mock *MockBackend) CheckCommandLock(cmdName command.Name) (*command.Lock,
 	return _ret0, _ret1
 }
 
+func (mock *MockBackend) DeleteJobOutputs(cutoff time.Time) (int, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{cutoff}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("DeleteJobOutputs", _params, []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 int
+	var _ret1 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(int)
+		}
+		if _result[1] != nil {
+			_ret1 = _result[1].(error)
+		}
+	}
+	return _ret0, _ret1
+}
+
 func (mock *MockBackend) DeletePullStatus(pull models.PullRequest) error {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -60,6 +94,63 @@ func (mock *MockBackend) DeletePullStatus(pull models.PullRequest) error {
 	return _ret0
 }
 
+func (mock *MockBackend) GetDriftStatuses() ([]models.DriftStatus, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("GetDriftStatuses", _params, []reflect.Type{reflect.TypeOf((*[]models.DriftStatus)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 []models.DriftStatus
+	var _ret1 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].([]models.DriftStatus)
+		}
+		if _result[1] != nil {
+			_ret1 = _result[1].(error)
+		}
+	}
+	return _ret0, _ret1
+}
+
+func (mock *MockBackend) GetJobOutput(jobID string) (*models.JobOutput, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{jobID}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("GetJobOutput", _params, []reflect.Type{reflect.TypeOf((**models.JobOutput)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 *models.JobOutput
+	var _ret1 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(*models.JobOutput)
+		}
+		if _result[1] != nil {
+			_ret1 = _result[1].(error)
+		}
+	}
+	return _ret0, _ret1
+}
+
+func (mock *MockBackend) GetJobOutputs(repoFullName string, pullNum int) ([]models.JobOutput, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{repoFullName, pullNum}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("GetJobOutputs", _params, []reflect.Type{reflect.TypeOf((*[]models.JobOutput)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 []models.JobOutput
+	var _ret1 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].([]models.JobOutput)
+		}
+		if _result[1] != nil {
+			_ret1 = _result[1].(error)
+		}
+	}
+	return _ret0, _ret1
+}
+
 func (mock *MockBackend) GetLock(project models.Project, workspace string) (*models.ProjectLock, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -98,6 +189,25 @@ func (mock *MockBackend) GetPullStatus(pull models.PullRequest) (*models.PullSta
 	return _ret0, _ret1
 }
 
+func (mock *MockBackend) GetPullStatuses() ([]models.PullStatus, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("GetPullStatuses", _params, []reflect.Type{reflect.TypeOf((*[]models.PullStatus)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 []models.PullStatus
+	var _ret1 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].([]models.PullStatus)
+		}
+		if _result[1] != nil {
+			_ret1 = _result[1].(error)
+		}
+	}
+	return _ret0, _ret1
+}
+
 func (mock *MockBackend) List() ([]models.ProjectLock, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -136,6 +246,44 @@ func (mock *MockBackend) LockCommand(cmdName command.Name, lockTime time.Time) (
 	return _ret0, _ret1
 }
 
+func (mock *MockBackend) RecordDelivery(key string, receivedAt time.Time, window time.Duration) (bool, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{key, receivedAt, window}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("RecordDelivery", _params, []reflect.Type{reflect.TypeOf((*bool)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 bool
+	var _ret1 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(bool)
+		}
+		if _result[1] != nil {
+			_ret1 = _result[1].(error)
+		}
+	}
+	return _ret0, _ret1
+}
+
+func (mock *MockBackend) RenameProjectStatuses(rename models.ProjectRename) (int, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{rename}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("RenameProjectStatuses", _params, []reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 int
+	var _ret1 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(int)
+		}
+		if _result[1] != nil {
+			_ret1 = _result[1].(error)
+		}
+	}
+	return _ret0, _ret1
+}
+
 func (mock *MockBackend) TryLock(lock models.ProjectLock) (bool, models.ProjectLock, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -212,6 +360,36 @@ func (mock *MockBackend) UnlockCommand(cmdName command.Name) error {
 	return _ret0
 }
 
+func (mock *MockBackend) UpdateDriftStatus(status models.DriftStatus) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{status}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("UpdateDriftStatus", _params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(error)
+		}
+	}
+	return _ret0
+}
+
+func (mock *MockBackend) UpdateForkApproval(pull models.PullRequest, approval models.ForkApproval) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{pull, approval}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("UpdateForkApproval", _params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(error)
+		}
+	}
+	return _ret0
+}
+
 func (mock *MockBackend) UpdateProjectStatus(pull models.PullRequest, workspace string, repoRelDir string, newStatus models.ProjectPlanStatus) error {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -283,6 +461,41 @@ type VerifierMockBackend struct {
 	timeout                time.Duration
 }
 
+func (verifier *VerifierMockBackend) AppendJobOutput(job models.JobOutput, lines []string) *MockBackend_AppendJobOutput_OngoingVerification {
+	_params := []pegomock.Param{job, lines}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "AppendJobOutput", _params, verifier.timeout)
+	return &MockBackend_AppendJobOutput_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_AppendJobOutput_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_AppendJobOutput_OngoingVerification) GetCapturedArguments() (models.JobOutput, []string) {
+	job, lines := c.GetAllCapturedArguments()
+	return job[len(job)-1], lines[len(lines)-1]
+}
+
+func (c *MockBackend_AppendJobOutput_OngoingVerification) GetAllCapturedArguments() (_param0 []models.JobOutput, _param1 [][]string) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]models.JobOutput, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(models.JobOutput)
+			}
+		}
+		if len(_params) > 1 {
+			_param1 = make([][]string, len(c.methodInvocations))
+			for u, param := range _params[1] {
+				_param1[u] = param.([]string)
+			}
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockBackend) CheckCommandLock(cmdName command.Name) *MockBackend_CheckCommandLock_OngoingVerification {
 	_params := []pegomock.Param{cmdName}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "CheckCommandLock", _params, verifier.timeout)
@@ -312,6 +525,35 @@ func (c *MockBackend_CheckCommandLock_OngoingVerification) GetAllCapturedArgumen
 	return
 }
 
+func (verifier *VerifierMockBackend) DeleteJobOutputs(cutoff time.Time) *MockBackend_DeleteJobOutputs_OngoingVerification {
+	_params := []pegomock.Param{cutoff}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeleteJobOutputs", _params, verifier.timeout)
+	return &MockBackend_DeleteJobOutputs_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_DeleteJobOutputs_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_DeleteJobOutputs_OngoingVerification) GetCapturedArguments() time.Time {
+	cutoff := c.GetAllCapturedArguments()
+	return cutoff[len(cutoff)-1]
+}
+
+func (c *MockBackend_DeleteJobOutputs_OngoingVerification) GetAllCapturedArguments() (_param0 []time.Time) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]time.Time, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(time.Time)
+			}
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockBackend) DeletePullStatus(pull models.PullRequest) *MockBackend_DeletePullStatus_OngoingVerification {
 	_params := []pegomock.Param{pull}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeletePullStatus", _params, verifier.timeout)
@@ -341,6 +583,87 @@ func (c *MockBackend_DeletePullStatus_OngoingVerification) GetAllCapturedArgumen
 	return
 }
 
+func (verifier *VerifierMockBackend) GetDriftStatuses() *MockBackend_GetDriftStatuses_OngoingVerification {
+	_params := []pegomock.Param{}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetDriftStatuses", _params, verifier.timeout)
+	return &MockBackend_GetDriftStatuses_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_GetDriftStatuses_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_GetDriftStatuses_OngoingVerification) GetCapturedArguments() {
+}
+
+func (c *MockBackend_GetDriftStatuses_OngoingVerification) GetAllCapturedArguments() {
+}
+
+func (verifier *VerifierMockBackend) GetJobOutput(jobID string) *MockBackend_GetJobOutput_OngoingVerification {
+	_params := []pegomock.Param{jobID}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetJobOutput", _params, verifier.timeout)
+	return &MockBackend_GetJobOutput_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_GetJobOutput_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_GetJobOutput_OngoingVerification) GetCapturedArguments() string {
+	jobID := c.GetAllCapturedArguments()
+	return jobID[len(jobID)-1]
+}
+
+func (c *MockBackend_GetJobOutput_OngoingVerification) GetAllCapturedArguments() (_param0 []string) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(string)
+			}
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) GetJobOutputs(repoFullName string, pullNum int) *MockBackend_GetJobOutputs_OngoingVerification {
+	_params := []pegomock.Param{repoFullName, pullNum}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetJobOutputs", _params, verifier.timeout)
+	return &MockBackend_GetJobOutputs_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_GetJobOutputs_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_GetJobOutputs_OngoingVerification) GetCapturedArguments() (string, int) {
+	repoFullName, pullNum := c.GetAllCapturedArguments()
+	return repoFullName[len(repoFullName)-1], pullNum[len(pullNum)-1]
+}
+
+func (c *MockBackend_GetJobOutputs_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []int) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(string)
+			}
+		}
+		if len(_params) > 1 {
+			_param1 = make([]int, len(c.methodInvocations))
+			for u, param := range _params[1] {
+				_param1[u] = param.(int)
+			}
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockBackend) GetLock(project models.Project, workspace string) *MockBackend_GetLock_OngoingVerification {
 	_params := []pegomock.Param{project, workspace}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetLock", _params, verifier.timeout)
@@ -405,6 +728,23 @@ func (c *MockBackend_GetPullStatus_OngoingVerification) GetAllCapturedArguments(
 	return
 }
 
+func (verifier *VerifierMockBackend) GetPullStatuses() *MockBackend_GetPullStatuses_OngoingVerification {
+	_params := []pegomock.Param{}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetPullStatuses", _params, verifier.timeout)
+	return &MockBackend_GetPullStatuses_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_GetPullStatuses_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_GetPullStatuses_OngoingVerification) GetCapturedArguments() {
+}
+
+func (c *MockBackend_GetPullStatuses_OngoingVerification) GetAllCapturedArguments() {
+}
+
 func (verifier *VerifierMockBackend) List() *MockBackend_List_OngoingVerification {
 	_params := []pegomock.Param{}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "List", _params, verifier.timeout)
@@ -457,6 +797,76 @@ func (c *MockBackend_LockCommand_OngoingVerification) GetAllCapturedArguments()
 	return
 }
 
+func (verifier *VerifierMockBackend) RecordDelivery(key string, receivedAt time.Time, window time.Duration) *MockBackend_RecordDelivery_OngoingVerification {
+	_params := []pegomock.Param{key, receivedAt, window}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "RecordDelivery", _params, verifier.timeout)
+	return &MockBackend_RecordDelivery_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_RecordDelivery_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_RecordDelivery_OngoingVerification) GetCapturedArguments() (string, time.Time, time.Duration) {
+	key, receivedAt, window := c.GetAllCapturedArguments()
+	return key[len(key)-1], receivedAt[len(receivedAt)-1], window[len(window)-1]
+}
+
+func (c *MockBackend_RecordDelivery_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []time.Time, _param2 []time.Duration) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(string)
+			}
+		}
+		if len(_params) > 1 {
+			_param1 = make([]time.Time, len(c.methodInvocations))
+			for u, param := range _params[1] {
+				_param1[u] = param.(time.Time)
+			}
+		}
+		if len(_params) > 2 {
+			_param2 = make([]time.Duration, len(c.methodInvocations))
+			for u, param := range _params[2] {
+				_param2[u] = param.(time.Duration)
+			}
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) RenameProjectStatuses(rename models.ProjectRename) *MockBackend_RenameProjectStatuses_OngoingVerification {
+	_params := []pegomock.Param{rename}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "RenameProjectStatuses", _params, verifier.timeout)
+	return &MockBackend_RenameProjectStatuses_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_RenameProjectStatuses_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_RenameProjectStatuses_OngoingVerification) GetCapturedArguments() models.ProjectRename {
+	rename := c.GetAllCapturedArguments()
+	return rename[len(rename)-1]
+}
+
+func (c *MockBackend_RenameProjectStatuses_OngoingVerification) GetAllCapturedArguments() (_param0 []models.ProjectRename) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]models.ProjectRename, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(models.ProjectRename)
+			}
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockBackend) TryLock(lock models.ProjectLock) *MockBackend_TryLock_OngoingVerification {
 	_params := []pegomock.Param{lock}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "TryLock", _params, verifier.timeout)
@@ -585,6 +995,70 @@ func (c *MockBackend_UnlockCommand_OngoingVerification) GetAllCapturedArguments(
 	return
 }
 
+func (verifier *VerifierMockBackend) UpdateDriftStatus(status models.DriftStatus) *MockBackend_UpdateDriftStatus_OngoingVerification {
+	_params := []pegomock.Param{status}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateDriftStatus", _params, verifier.timeout)
+	return &MockBackend_UpdateDriftStatus_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_UpdateDriftStatus_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_UpdateDriftStatus_OngoingVerification) GetCapturedArguments() models.DriftStatus {
+	status := c.GetAllCapturedArguments()
+	return status[len(status)-1]
+}
+
+func (c *MockBackend_UpdateDriftStatus_OngoingVerification) GetAllCapturedArguments() (_param0 []models.DriftStatus) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]models.DriftStatus, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(models.DriftStatus)
+			}
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) UpdateForkApproval(pull models.PullRequest, approval models.ForkApproval) *MockBackend_UpdateForkApproval_OngoingVerification {
+	_params := []pegomock.Param{pull, approval}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateForkApproval", _params, verifier.timeout)
+	return &MockBackend_UpdateForkApproval_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_UpdateForkApproval_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_UpdateForkApproval_OngoingVerification) GetCapturedArguments() (models.PullRequest, models.ForkApproval) {
+	pull, approval := c.GetAllCapturedArguments()
+	return pull[len(pull)-1], approval[len(approval)-1]
+}
+
+func (c *MockBackend_UpdateForkApproval_OngoingVerification) GetAllCapturedArguments() (_param0 []models.PullRequest, _param1 []models.ForkApproval) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]models.PullRequest, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(models.PullRequest)
+			}
+		}
+		if len(_params) > 1 {
+			_param1 = make([]models.ForkApproval, len(c.methodInvocations))
+			for u, param := range _params[1] {
+				_param1[u] = param.(models.ForkApproval)
+			}
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockBackend) UpdateProjectStatus(pull models.PullRequest, workspace string, repoRelDir string, newStatus models.ProjectPlanStatus) *MockBackend_UpdateProjectStatus_OngoingVerification {
 	_params := []pegomock.Param{pull, workspace, repoRelDir, newStatus}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateProjectStatus", _params, verifier.timeout)
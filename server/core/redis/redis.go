@@ -238,6 +238,30 @@ func (r *RedisDB) CheckCommandLock(cmdName command.Name) (*command.Lock, error)
 	return &cmdLock, err
 }
 
+// RenameProjectStatuses changes the project being renamed in the pull
+// statuses of its repo. It returns how many pull statuses were changed.
+func (r *RedisDB) RenameProjectStatuses(rename models.ProjectRename) (int, error) {
+	statuses, err := r.GetPullStatuses()
+	if err != nil {
+		return 0, err
+	}
+	renamed := 0
+	for _, status := range statuses {
+		if !status.RenameProject(rename) {
+			continue
+		}
+		key, err := r.pullKey(status.Pull)
+		if err != nil {
+			return renamed, err
+		}
+		if err := r.writePull(key, status); err != nil {
+			return renamed, err
+		}
+		renamed++
+	}
+	return renamed, nil
+}
+
 // UpdateProjectStatus updates pull's status with the latest project results.
 // It returns the new PullStatus object.
 func (r *RedisDB) UpdateProjectStatus(pull models.PullRequest, workspace string, repoRelDir string, newStatus models.ProjectPlanStatus) error {
@@ -270,6 +294,27 @@ func (r *RedisDB) UpdateProjectStatus(pull models.PullRequest, workspace string,
 	return errors.Wrap(err, "db transaction failed")
 }
 
+// UpdateForkApproval records that pull was approved to run commands even
+// though it's from a fork. If the stored status is for an older commit it's
+// replaced, the same way UpdatePullWithResults does.
+func (r *RedisDB) UpdateForkApproval(pull models.PullRequest, approval models.ForkApproval) error {
+	key, err := r.pullKey(pull)
+	if err != nil {
+		return err
+	}
+
+	currStatus, err := r.getPull(key)
+	if err != nil {
+		return err
+	}
+	if currStatus == nil || currStatus.Pull.HeadCommit != pull.HeadCommit {
+		currStatus = &models.PullStatus{Pull: pull}
+	}
+	currStatus.ForkApproval = &approval
+
+	return r.writePull(key, *currStatus)
+}
+
 func (r *RedisDB) GetPullStatus(pull models.PullRequest) (*models.PullStatus, error) {
 	key, err := r.pullKey(pull)
 	if err != nil {
@@ -281,6 +326,42 @@ func (r *RedisDB) GetPullStatus(pull models.PullRequest) (*models.PullStatus, er
 	return pullStatus, errors.Wrap(err, "db transaction failed")
 }
 
+// GetPullStatuses returns the status of every pull that has one.
+func (r *RedisDB) GetPullStatuses() ([]models.PullStatus, error) {
+	var statuses []models.PullStatus
+	iter := r.client.Scan(ctx, 0, fmt.Sprintf("*%s*%s*", pullKeySeparator, pullKeySeparator), 0).Iterator()
+	for iter.Next(ctx) {
+		// Pull keys have no prefix so skip the other keys in case they
+		// contain the separator.
+		key := iter.Val()
+		if strings.HasPrefix(key, "pr/") || strings.HasPrefix(key, "global/") ||
+			strings.HasPrefix(key, "webhookDelivery/") || strings.HasPrefix(key, "drift/") ||
+			strings.HasPrefix(key, "jobOutput/") || strings.HasPrefix(key, "jobOutputLines/") {
+			continue
+		}
+		status, err := r.getPull(key)
+		if err != nil {
+			return statuses, err
+		}
+		if status != nil {
+			statuses = append(statuses, *status)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return statuses, errors.Wrap(err, "db transaction failed")
+	}
+	return statuses, nil
+}
+
+// PutPullStatus stores status as is, replacing any status of its pull.
+func (r *RedisDB) PutPullStatus(status models.PullStatus) error {
+	key, err := r.pullKey(status.Pull)
+	if err != nil {
+		return err
+	}
+	return r.writePull(key, status)
+}
+
 func (r *RedisDB) DeletePullStatus(pull models.PullRequest) error {
 	key, err := r.pullKey(pull)
 	if err != nil {
@@ -362,6 +443,141 @@ func (r *RedisDB) UpdatePullWithResults(pull models.PullRequest, newResults []co
 	return newStatus, errors.Wrap(r.writePull(key, newStatus), "db transaction failed")
 }
 
+// RecordDelivery records the webhook delivery identified by key. It returns
+// false if the key was already recorded within window. Keys expire on their
+// own once the window has passed.
+func (r *RedisDB) RecordDelivery(key string, receivedAt time.Time, window time.Duration) (bool, error) {
+	recorded, err := r.client.SetNX(ctx, r.deliveryKey(key), receivedAt.Unix(), window).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "db transaction failed")
+	}
+	return recorded, nil
+}
+
+// UpdateDriftStatus stores the latest drift detection result for a project.
+func (r *RedisDB) UpdateDriftStatus(status models.DriftStatus) error {
+	serialized, err := json.Marshal(status)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	err = r.client.Set(ctx, r.driftKey(status), serialized, 0).Err()
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// GetDriftStatuses returns the latest drift detection result of every project
+// that has been checked.
+func (r *RedisDB) GetDriftStatuses() ([]models.DriftStatus, error) {
+	var statuses []models.DriftStatus
+	iter := r.client.Scan(ctx, 0, "drift/*", 0).Iterator()
+	for iter.Next(ctx) {
+		val, err := r.client.Get(ctx, iter.Val()).Result()
+		if err != nil {
+			return nil, errors.Wrap(err, "db transaction failed")
+		}
+		var status models.DriftStatus
+		if err := json.Unmarshal([]byte(val), &status); err != nil {
+			return statuses, errors.Wrapf(err, "failed to deserialize drift status at key %q", iter.Val())
+		}
+		statuses = append(statuses, status)
+	}
+	if err := iter.Err(); err != nil {
+		return statuses, errors.Wrap(err, "db transaction failed")
+	}
+	return statuses, nil
+}
+
+// AppendJobOutput stores job and appends lines to the list of its output.
+func (r *RedisDB) AppendJobOutput(job models.JobOutput, lines []string) error {
+	job.Lines = nil
+	serialized, err := json.Marshal(job)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, r.jobOutputKey(job.JobID), serialized, 0)
+	if len(lines) > 0 {
+		values := make([]interface{}, len(lines))
+		for i, line := range lines {
+			values[i] = line
+		}
+		pipe.RPush(ctx, r.jobOutputLinesKey(job.JobID), values...)
+	}
+	_, err = pipe.Exec(ctx)
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// GetJobOutput returns the job with jobID and its lines, or nil if there
+// isn't one.
+func (r *RedisDB) GetJobOutput(jobID string) (*models.JobOutput, error) {
+	job, err := r.getJobOutput(r.jobOutputKey(jobID))
+	if err != nil || job == nil {
+		return job, err
+	}
+	lines, err := r.client.LRange(ctx, r.jobOutputLinesKey(jobID), 0, -1).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	job.Lines = lines
+	return job, nil
+}
+
+// GetJobOutputs returns the jobs of a pull request without their lines.
+func (r *RedisDB) GetJobOutputs(repoFullName string, pullNum int) ([]models.JobOutput, error) {
+	var jobs []models.JobOutput
+	iter := r.client.Scan(ctx, 0, "jobOutput/*", 0).Iterator()
+	for iter.Next(ctx) {
+		job, err := r.getJobOutput(iter.Val())
+		if err != nil {
+			return nil, err
+		}
+		if job != nil && job.RepoFullName == repoFullName && job.PullNum == pullNum {
+			jobs = append(jobs, *job)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return jobs, errors.Wrap(err, "db transaction failed")
+	}
+	return jobs, nil
+}
+
+// DeleteJobOutputs deletes the jobs last updated before cutoff and returns
+// how many were deleted.
+func (r *RedisDB) DeleteJobOutputs(cutoff time.Time) (int, error) {
+	deleted := 0
+	iter := r.client.Scan(ctx, 0, "jobOutput/*", 0).Iterator()
+	for iter.Next(ctx) {
+		job, err := r.getJobOutput(iter.Val())
+		if err != nil {
+			return deleted, err
+		}
+		if job == nil || !job.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		if err := r.client.Del(ctx, iter.Val(), r.jobOutputLinesKey(job.JobID)).Err(); err != nil {
+			return deleted, errors.Wrap(err, "db transaction failed")
+		}
+		deleted++
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, errors.Wrap(err, "db transaction failed")
+	}
+	return deleted, nil
+}
+
+func (r *RedisDB) getJobOutput(key string) (*models.JobOutput, error) {
+	val, err := r.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	var job models.JobOutput
+	if err := json.Unmarshal([]byte(val), &job); err != nil {
+		return nil, errors.Wrapf(err, "failed to deserialize job output at key %q", key)
+	}
+	return &job, nil
+}
+
 func (r *RedisDB) getPull(key string) (*models.PullStatus, error) {
 	val, err := r.client.Get(ctx, key).Result()
 	if err == redis.Nil {
@@ -399,6 +615,22 @@ func (r *RedisDB) commandLockKey(cmdName command.Name) string {
 	return fmt.Sprintf("global/%s/lock", cmdName)
 }
 
+func (r *RedisDB) deliveryKey(key string) string {
+	return fmt.Sprintf("webhookDelivery/%s", key)
+}
+
+func (r *RedisDB) driftKey(status models.DriftStatus) string {
+	return fmt.Sprintf("drift/%s", status.Key())
+}
+
+func (r *RedisDB) jobOutputKey(jobID string) string {
+	return fmt.Sprintf("jobOutput/%s", jobID)
+}
+
+func (r *RedisDB) jobOutputLinesKey(jobID string) string {
+	return fmt.Sprintf("jobOutputLines/%s", jobID)
+}
+
 func (r *RedisDB) pullKey(pull models.PullRequest) (string, error) {
 	hostname := pull.BaseRepo.VCSHost.Hostname
 	if strings.Contains(hostname, pullKeySeparator) {
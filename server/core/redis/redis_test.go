@@ -529,6 +529,83 @@ func TestPullStatus_UpdateGet(t *testing.T) {
 	}, status.Projects)
 }
 
+func TestPullStatus_GetAll(t *testing.T) {
+	s := miniredis.RunT(t)
+	rdb := newTestRedis(s)
+
+	statuses, err := rdb.GetPullStatuses()
+	Ok(t, err)
+	Equals(t, 0, len(statuses))
+
+	repo := models.Repo{
+		FullName: "runatlantis/atlantis",
+		VCSHost: models.VCSHost{
+			Hostname: "github.com",
+			Type:     models.Github,
+		},
+	}
+	for _, num := range []int{1, 2} {
+		_, err := rdb.UpdatePullWithResults(
+			models.PullRequest{Num: num, BaseRepo: repo},
+			[]command.ProjectResult{
+				{
+					Command:     command.Plan,
+					RepoRelDir:  ".",
+					Workspace:   "default",
+					PlanSuccess: &models.PlanSuccess{},
+				},
+			})
+		Ok(t, err)
+	}
+	Ok(t, rdb.UpdateDriftStatus(models.DriftStatus{RepoID: "github.com/runatlantis/atlantis", RepoRelDir: ".", Workspace: "default"}))
+	Ok(t, rdb.AppendJobOutput(models.JobOutput{JobID: "1"}, []string{"line"}))
+
+	statuses, err = rdb.GetPullStatuses()
+	Ok(t, err)
+	Equals(t, 2, len(statuses))
+	nums := map[int]bool{}
+	for _, s := range statuses {
+		nums[s.Pull.Num] = true
+		Equals(t, models.PlannedPlanStatus, s.Projects[0].Status)
+	}
+	Equals(t, map[int]bool{1: true, 2: true}, nums)
+}
+
+func TestPullStatus_RenameProject(t *testing.T) {
+	s := miniredis.RunT(t)
+	rdb := newTestRedis(s)
+	repo := models.Repo{
+		FullName: "runatlantis/atlantis",
+		VCSHost: models.VCSHost{
+			Hostname: "github.com",
+			Type:     models.Github,
+		},
+	}
+	pull := models.PullRequest{Num: 1, BaseRepo: repo}
+	_, err := rdb.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			Command:     command.Plan,
+			RepoRelDir:  "network",
+			Workspace:   "default",
+			PlanSuccess: &models.PlanSuccess{},
+		},
+	})
+	Ok(t, err)
+
+	renamed, err := rdb.RenameProjectStatuses(models.ProjectRename{
+		RepoFullName: repo.FullName,
+		From:         models.ProjectLocation{RepoRelDir: "network", Workspace: "default"},
+		To:           models.ProjectLocation{RepoRelDir: "network", Workspace: "production"},
+	})
+	Ok(t, err)
+	Equals(t, 1, renamed)
+
+	status, err := rdb.GetPullStatus(pull)
+	Ok(t, err)
+	Equals(t, "production", status.Projects[0].Workspace)
+	Equals(t, models.PlannedPlanStatus, status.Projects[0].Status)
+}
+
 // Test we can create a status, delete it, and then we shouldn't be able to getCommandLock
 // it.
 func TestPullStatus_UpdateDeleteGet(t *testing.T) {
@@ -639,6 +716,55 @@ func TestPullStatus_UpdateProject(t *testing.T) {
 	}, status.Projects) // nolint: staticcheck
 }
 
+// Test that fork approvals are kept while results for the same commit are
+// added and dropped once there's a new commit.
+func TestPullStatus_UpdateForkApproval(t *testing.T) {
+	s := miniredis.RunT(t)
+	b := newTestRedis(s)
+
+	pull := models.PullRequest{
+		Num:        1,
+		HeadCommit: "sha",
+		State:      models.OpenPullState,
+		BaseRepo: models.Repo{
+			FullName: "runatlantis/atlantis",
+			VCSHost: models.VCSHost{
+				Hostname: "github.com",
+				Type:     models.Github,
+			},
+		},
+	}
+	approval := models.ForkApproval{
+		HeadCommit: "sha",
+		Approver:   "maintainer",
+	}
+	err := b.UpdateForkApproval(pull, approval)
+	Ok(t, err)
+
+	status, err := b.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			RepoRelDir: ".",
+			Workspace:  "default",
+			Failure:    "failure",
+		},
+	})
+	Ok(t, err)
+	Equals(t, &approval, status.ForkApproval)
+	Assert(t, status.IsForkApproved("sha"), "exp approved")
+
+	pull.HeadCommit = "newsha"
+	status, err = b.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			RepoRelDir: ".",
+			Workspace:  "default",
+			Failure:    "failure",
+		},
+	})
+	Ok(t, err)
+	Assert(t, status.ForkApproval == nil, "exp approval to be dropped for new commit")
+	Assert(t, !status.IsForkApproved("newsha"), "exp not approved")
+}
+
 // Test that if we update an existing pull status and our new status is for a
 // different HeadSHA, that we just overwrite the old status.
 func TestPullStatus_UpdateNewCommit(t *testing.T) {
@@ -911,6 +1037,7 @@ func TestPullStatus_UpdateMerge_ApprovePolicies(t *testing.T) {
 					{
 						PolicySetName: "policy1",
 						Approvals:     1,
+						ReqApprovals:  1,
 					},
 				},
 			},
@@ -923,6 +1050,7 @@ func TestPullStatus_UpdateMerge_ApprovePolicies(t *testing.T) {
 					{
 						PolicySetName: "policy1",
 						Approvals:     0,
+						ReqApprovals:  1,
 					},
 				},
 			},
@@ -930,6 +1058,32 @@ func TestPullStatus_UpdateMerge_ApprovePolicies(t *testing.T) {
 	}
 }
 
+func TestRecordDelivery(t *testing.T) {
+	s := miniredis.RunT(t)
+	r := newTestRedis(s)
+	now := time.Now()
+
+	recorded, err := r.RecordDelivery("github/delivery-1", now, time.Hour)
+	Ok(t, err)
+	Equals(t, true, recorded)
+
+	t.Log("the same delivery within the window is a duplicate")
+	recorded, err = r.RecordDelivery("github/delivery-1", now, time.Hour)
+	Ok(t, err)
+	Equals(t, false, recorded)
+
+	t.Log("recorded deliveries should not show up as locks")
+	locks, err := r.List()
+	Ok(t, err)
+	Equals(t, 0, len(locks))
+
+	t.Log("the delivery can be recorded again once the window has passed")
+	s.FastForward(2 * time.Hour)
+	recorded, err = r.RecordDelivery("github/delivery-1", now.Add(2*time.Hour), time.Hour)
+	Ok(t, err)
+	Equals(t, true, recorded)
+}
+
 func newTestRedis(mr *miniredis.Miniredis) *redis.RedisDB {
 	r, err := redis.New(mr.Host(), mr.Server().Addr().Port, "", false, false, 0)
 	if err != nil {
@@ -981,3 +1135,96 @@ func generateLocalhostCert() ([]byte, []byte, error) {
 	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
 	return certBytes, keyBytes, err
 }
+
+func TestDriftStatus_UpdateAndGet(t *testing.T) {
+	s := miniredis.RunT(t)
+	b := newTestRedis(s)
+
+	statuses, err := b.GetDriftStatuses()
+	Ok(t, err)
+	Equals(t, 0, len(statuses))
+
+	status := models.DriftStatus{
+		RepoID:           "github.com/runatlantis/atlantis",
+		RepoFullName:     "runatlantis/atlantis",
+		Branch:           "main",
+		RepoRelDir:       ".",
+		Workspace:        "default",
+		Drifted:          true,
+		DriftedResources: 2,
+		CheckedAt:        time.Now().UTC().Truncate(time.Second),
+	}
+	Ok(t, b.UpdateDriftStatus(status))
+
+	// A newer result replaces the old one.
+	status.Drifted = false
+	status.DriftedResources = 0
+	Ok(t, b.UpdateDriftStatus(status))
+
+	other := status
+	other.RepoRelDir = "other"
+	other.Error = "error"
+	Ok(t, b.UpdateDriftStatus(other))
+
+	statuses, err = b.GetDriftStatuses()
+	Ok(t, err)
+	Equals(t, 2, len(statuses))
+	for _, s := range statuses {
+		if s.RepoRelDir == "." {
+			Equals(t, status, s)
+		} else {
+			Equals(t, other, s)
+		}
+	}
+}
+
+func TestJobOutput_AppendGetAndDelete(t *testing.T) {
+	s := miniredis.RunT(t)
+	b := newTestRedis(s)
+
+	job, err := b.GetJobOutput("1")
+	Ok(t, err)
+	Assert(t, job == nil, "expected no job")
+
+	started := time.Now().UTC().Truncate(time.Second)
+	plan := models.JobOutput{
+		JobID:        "1",
+		RepoFullName: "runatlantis/atlantis",
+		PullNum:      1,
+		RepoRelDir:   ".",
+		Workspace:    "default",
+		JobStep:      "plan",
+		StartedAt:    started,
+		UpdatedAt:    started,
+	}
+	Ok(t, b.AppendJobOutput(plan, []string{"line 1", "line 2"}))
+	plan.Complete = true
+	plan.UpdatedAt = started.Add(time.Minute)
+	Ok(t, b.AppendJobOutput(plan, []string{"line 3"}))
+	otherPull := plan
+	otherPull.JobID = "2"
+	otherPull.PullNum = 2
+	otherPull.UpdatedAt = started
+	Ok(t, b.AppendJobOutput(otherPull, nil))
+
+	job, err = b.GetJobOutput("1")
+	Ok(t, err)
+	withLines := plan
+	withLines.Lines = []string{"line 1", "line 2", "line 3"}
+	Equals(t, withLines, *job)
+
+	jobs, err := b.GetJobOutputs("runatlantis/atlantis", 1)
+	Ok(t, err)
+	Equals(t, []models.JobOutput{plan}, jobs)
+
+	t.Log("only the jobs last updated before the cutoff are deleted")
+	deleted, err := b.DeleteJobOutputs(started.Add(30 * time.Second))
+	Ok(t, err)
+	Equals(t, 1, deleted)
+	job, err = b.GetJobOutput("2")
+	Ok(t, err)
+	Assert(t, job == nil, "expected job 2 to be deleted")
+	job, err = b.GetJobOutput("1")
+	Ok(t, err)
+	Assert(t, job != nil, "expected job 1 to be kept")
+}
@@ -26,8 +26,20 @@ type ApplyStepRunner struct {
 }
 
 func (a *ApplyStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
-	if a.hasTargetFlag(ctx, extraArgs) {
-		return "", errors.New("cannot run apply with -target because we are applying an already generated plan. Instead, run -target with atlantis plan")
+	tfDistribution := a.DefaultTFDistribution
+	if ctx.TerraformDistribution != nil {
+		tfDistribution = a.TerraformExecutor.Distributions().NewDistribution(*ctx.TerraformDistribution)
+	}
+	tfVersion := a.DefaultTFVersion
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+	args := append(append([]string{}, extraArgs...), ctx.EscapedCommentArgs...)
+	if err := validateDistributionArgs(tfDistribution, tfVersion, args, envs); err != nil {
+		return "", err
+	}
+	if flag := findPlanOnlyFlag(args); flag != "" {
+		return "", fmt.Errorf("cannot run apply with %s because we are applying an already generated plan. Instead, run %s with atlantis plan", flag, flag)
 	}
 
 	planPath := filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName))
@@ -41,14 +53,6 @@ func (a *ApplyStepRunner) Run(ctx command.ProjectContext, extraArgs []string, pa
 
 	ctx.Log.Info("starting apply")
 	var out string
-	tfDistribution := a.DefaultTFDistribution
-	if ctx.TerraformDistribution != nil {
-		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
-	}
-	tfVersion := a.DefaultTFVersion
-	if ctx.TerraformVersion != nil {
-		tfVersion = ctx.TerraformVersion
-	}
 
 	// TODO: Leverage PlanTypeStepRunnerDelegate here
 	if IsRemotePlan(contents) {
@@ -74,28 +78,6 @@ func (a *ApplyStepRunner) Run(ctx command.ProjectContext, extraArgs []string, pa
 	return out, err
 }
 
-func (a *ApplyStepRunner) hasTargetFlag(ctx command.ProjectContext, extraArgs []string) bool {
-	isTargetFlag := func(s string) bool {
-		if s == "-target" {
-			return true
-		}
-		split := strings.Split(s, "=")
-		return split[0] == "-target"
-	}
-
-	for _, arg := range ctx.EscapedCommentArgs {
-		if isTargetFlag(arg) {
-			return true
-		}
-	}
-	for _, arg := range extraArgs {
-		if isTargetFlag(arg) {
-			return true
-		}
-	}
-	return false
-}
-
 // cleanRemoteApplyOutput removes unneeded output like the refresh and plan
 // phases to make the final comment cleaner.
 func (a *ApplyStepRunner) cleanRemoteApplyOutput(out string) string {
@@ -263,6 +263,48 @@ func TestRun_UsingTarget(t *testing.T) {
 	}
 }
 
+// Apply with -exclude is rejected like -target with OpenTofu, and as an
+// unsupported flag with Terraform.
+func TestRun_UsingExclude(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	cases := []struct {
+		tfDistribution string
+		expErr         string
+	}{
+		{
+			tfDistribution: "opentofu",
+			expErr:         "cannot run apply with -exclude because we are applying an already generated plan. Instead, run -exclude with atlantis plan",
+		},
+		{
+			tfDistribution: "terraform",
+			expErr:         "-exclude is only supported by OpenTofu but this project runs Terraform; set terraform_distribution: opentofu to use it",
+		},
+	}
+
+	RegisterMockTestingT(t)
+
+	for _, c := range cases {
+		t.Run(c.tfDistribution, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			Ok(t, os.WriteFile(filepath.Join(tmpDir, "workspace.tfplan"), nil, 0600))
+			terraform := tfclientmocks.NewMockClient()
+			step := runtime.ApplyStepRunner{
+				TerraformExecutor: terraform,
+			}
+
+			output, err := step.Run(command.ProjectContext{
+				Log:                   logger,
+				Workspace:             "workspace",
+				RepoRelDir:            ".",
+				EscapedCommentArgs:    []string{`\-\e\x\c\l\u\d\e`, `\m\o\d\u\l\e\.\a`},
+				TerraformDistribution: &c.tfDistribution,
+			}, nil, tmpDir, map[string]string(nil))
+			Equals(t, "", output)
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
 // Test that apply works for remote applies.
 func TestRun_RemoteApply_Success(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -0,0 +1,75 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// AssumeRoleStepRunner runs assume_role steps. They assume an AWS IAM role
+// with the AWS CLI and set its credentials in the environment of the
+// following steps.
+type AssumeRoleStepRunner struct {
+	// AWSCLI is the path to the AWS CLI. If empty, aws is looked up in the
+	// PATH.
+	AWSCLI string
+}
+
+// assumeRoleOutput is the output of aws sts assume-role.
+type assumeRoleOutput struct {
+	Credentials struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		SessionToken    string `json:"SessionToken"`
+	} `json:"Credentials"`
+}
+
+// Run assumes roleARN with the credentials in envs and replaces them with the
+// credentials of the role. If sessionName is empty, it's generated from the
+// pull request. If durationSeconds is 0, the default of the role is used.
+func (r *AssumeRoleStepRunner) Run(ctx command.ProjectContext, roleARN string, sessionName string, durationSeconds int, path string, envs map[string]string) (string, error) {
+	awsCLI := r.AWSCLI
+	if awsCLI == "" {
+		awsCLI = "aws"
+	}
+	if sessionName == "" {
+		sessionName = fmt.Sprintf("atlantis-pr-%d", ctx.Pull.Num)
+	}
+	args := []string{"sts", "assume-role", "--role-arn", roleARN, "--role-session-name", sessionName, "--output", "json"}
+	if durationSeconds > 0 {
+		args = append(args, "--duration-seconds", strconv.Itoa(durationSeconds))
+	}
+
+	cmd := exec.Command(awsCLI, args...) // #nosec
+	cmd.Dir = path
+	cmd.Env = os.Environ()
+	for key, val := range envs {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, val))
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("assuming role %s: %s: %s", roleARN, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var output assumeRoleOutput
+	if err := json.Unmarshal(out, &output); err != nil {
+		return "", errors.Wrapf(err, "parsing credentials of role %s", roleARN)
+	}
+	if output.Credentials.AccessKeyID == "" {
+		return "", fmt.Errorf("assuming role %s returned no credentials", roleARN)
+	}
+	envs["AWS_ACCESS_KEY_ID"] = output.Credentials.AccessKeyID
+	envs["AWS_SECRET_ACCESS_KEY"] = output.Credentials.SecretAccessKey
+	envs["AWS_SESSION_TOKEN"] = output.Credentials.SessionToken
+	ctx.Log.Info("assumed role %s with session name %s", roleARN, sessionName)
+	return "", nil
+}
@@ -0,0 +1,55 @@
+package runtime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeAWSCLI writes a script that records its args and prints output like aws
+// sts assume-role.
+func fakeAWSCLI(t *testing.T, output string, exitCode string) (string, string) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := filepath.Join(dir, "aws")
+	contents := "#!/bin/sh\necho \"$@ $AWS_PROFILE\" > " + argsFile + "\necho '" + output + "'\nexit " + exitCode + "\n"
+	Ok(t, os.WriteFile(script, []byte(contents), 0700)) // nolint: gosec
+	return script, argsFile
+}
+
+func TestAssumeRoleStepRunner_Run(t *testing.T) {
+	awsCLI, argsFile := fakeAWSCLI(t, `{"Credentials": {"AccessKeyId": "AKIA", "SecretAccessKey": "secret", "SessionToken": "token"}}`, "0")
+	r := runtime.AssumeRoleStepRunner{AWSCLI: awsCLI}
+	ctx := command.ProjectContext{
+		Log:  logging.NewNoopLogger(t),
+		Pull: models.PullRequest{Num: 2},
+	}
+	envs := map[string]string{"AWS_PROFILE": "ci"}
+
+	out, err := r.Run(ctx, "arn:aws:iam::123456789012:role/atlantis", "", 900, t.TempDir(), envs)
+	Ok(t, err)
+	Equals(t, "", out)
+	Equals(t, map[string]string{
+		"AWS_PROFILE":           "ci",
+		"AWS_ACCESS_KEY_ID":     "AKIA",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+		"AWS_SESSION_TOKEN":     "token",
+	}, envs)
+	args, err := os.ReadFile(argsFile)
+	Ok(t, err)
+	Equals(t, "sts assume-role --role-arn arn:aws:iam::123456789012:role/atlantis --role-session-name atlantis-pr-2 --output json --duration-seconds 900 ci\n", string(args))
+}
+
+func TestAssumeRoleStepRunner_RunError(t *testing.T) {
+	awsCLI, _ := fakeAWSCLI(t, "", "255")
+	r := runtime.AssumeRoleStepRunner{AWSCLI: awsCLI}
+	ctx := command.ProjectContext{Log: logging.NewNoopLogger(t)}
+	_, err := r.Run(ctx, "arn:aws:iam::123456789012:role/atlantis", "atlantis", 0, t.TempDir(), map[string]string{})
+	ErrContains(t, "assuming role arn:aws:iam::123456789012:role/atlantis: exit status 255", err)
+}
@@ -0,0 +1,222 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/runtime/cache"
+	runtimemodels "github.com/runatlantis/atlantis/server/core/runtime/models"
+	"github.com/runatlantis/atlantis/server/core/runtime/policy"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+const (
+	// DefaultInfracostVersion is the version of Infracost that's downloaded
+	// if it isn't installed.
+	DefaultInfracostVersion     = "0.10.39"
+	infracostBinaryName         = "infracost"
+	infracostDownloadURLPrefix  = "https://github.com/infracost/infracost/releases/download/v"
+	infracostNoPlanErrorMessage = "no plan to estimate the cost of, the cost_estimate step must run after the plan step"
+)
+
+// CostEstimateStepRunner runs cost_estimate steps. They run Infracost on the
+// JSON output of terraform show for the project's plan, running show first if
+// the workflow didn't, and save the estimate to the project's cost estimate
+// file so it's added to the plan comment. If the plan increases the monthly
+// cost by more than the step's thresholds, the plan is deleted so it can't be
+// applied and the step fails.
+//
+// Infracost is run from the PATH if it's installed. Otherwise
+// DefaultInfracostVersion is downloaded once to the bin dir and reused.
+// Infracost reads its API key from the INFRACOST_API_KEY env var.
+type CostEstimateStepRunner struct {
+	ShowStepRunner Runner
+	VersionCache   cache.ExecutionVersionCache
+	Version        *version.Version
+	Exec           runtimemodels.Exec
+}
+
+// NewCostEstimateStepRunner returns a CostEstimateStepRunner that downloads
+// Infracost to binDir with downloader.
+func NewCostEstimateStepRunner(binDir string, showStepRunner Runner, downloader policy.Downloader) *CostEstimateStepRunner {
+	return &CostEstimateStepRunner{
+		ShowStepRunner: showStepRunner,
+		VersionCache: cache.NewExecutionVersionLayeredLoadingCache(
+			infracostBinaryName,
+			binDir,
+			func(v *version.Version, destPath string) (runtimemodels.FilePath, error) {
+				return downloadInfracost(downloader, v, destPath)
+			},
+		),
+		Version: version.Must(version.NewVersion(DefaultInfracostVersion)),
+		Exec:    runtimemodels.LocalExec{},
+	}
+}
+
+func (r *CostEstimateStepRunner) Run(ctx command.ProjectContext, extraArgs []string, maxMonthlyIncrease float64, maxPercentIncrease float64, path string, envs map[string]string) (string, error) {
+	planFile := filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	planInfo, err := os.Stat(planFile)
+	if os.IsNotExist(err) {
+		return "", errors.New(infracostNoPlanErrorMessage)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Infracost reads the JSON output of terraform show rather than the
+	// planfile, so show is run unless the workflow already ran it on this
+	// plan.
+	showFile := filepath.Join(path, ctx.GetShowResultFileName())
+	if showInfo, err := os.Stat(showFile); err != nil || showInfo.ModTime().Before(planInfo.ModTime()) {
+		if _, err := r.ShowStepRunner.Run(ctx, nil, path, envs); err != nil {
+			return "", errors.Wrap(err, "running terraform show")
+		}
+	}
+
+	binPath, err := r.infracostPath()
+	if err != nil {
+		return "", errors.Wrap(err, "getting infracost")
+	}
+	costFile := filepath.Join(path, ctx.GetCostEstimateFileName())
+	args := append([]string{
+		binPath, "breakdown",
+		"--path", filepath.Clean(showFile),
+		"--format", "json",
+		"--out-file", filepath.Clean(costFile),
+		"--no-color",
+	}, extraArgs...)
+	if out, err := r.Exec.CombinedOutput(args, envs, path); err != nil {
+		return out, errors.Wrap(err, "running infracost")
+	}
+
+	estimate, err := ReadCostEstimate(costFile)
+	if err != nil {
+		return "", err
+	}
+	diff := estimate.DiffMonthlyCost()
+	var exceeded error
+	switch {
+	case maxMonthlyIncrease > 0 && diff > maxMonthlyIncrease:
+		exceeded = fmt.Errorf("the plan increases the monthly cost by %.2f %s, more than the max_monthly_increase of %.2f %s",
+			diff, estimate.Currency, maxMonthlyIncrease, estimate.Currency)
+	case maxPercentIncrease > 0 && diff > 0 && estimate.DiffPercent() > maxPercentIncrease:
+		exceeded = fmt.Errorf("the plan increases the monthly cost by %s, more than the max_percent_increase of %s",
+			formatPercent(estimate.DiffPercent()), formatPercent(maxPercentIncrease))
+	}
+	if exceeded != nil {
+		if err := os.Remove(planFile); err != nil {
+			return "", errors.Wrapf(exceeded, "deleting plan: %s", err)
+		}
+		return "", exceeded
+	}
+	return "", nil
+}
+
+func (r *CostEstimateStepRunner) infracostPath() (string, error) {
+	if path, err := r.Exec.LookPath(infracostBinaryName); err == nil {
+		return path, nil
+	}
+	return r.VersionCache.Get(r.Version)
+}
+
+func downloadInfracost(downloader policy.Downloader, v *version.Version, destPath string) (runtimemodels.FilePath, error) {
+	platform := fmt.Sprintf("%s-%s", runtime.GOOS, runtime.GOARCH)
+	binURL := fmt.Sprintf("%s%s/infracost-%s.tar.gz", infracostDownloadURLPrefix, v.Original(), platform)
+	// go-getter checks the archive against the checksum file released next
+	// to it.
+	fullSrcURL := fmt.Sprintf("%s?checksum=file:%s.sha256", binURL, binURL)
+	if err := downloader.GetAny(destPath, fullSrcURL); err != nil {
+		return runtimemodels.LocalFilePath(""), errors.Wrapf(err, "downloading infracost version %s at %q", v.String(), fullSrcURL)
+	}
+	return runtimemodels.LocalFilePath(filepath.Join(destPath, fmt.Sprintf("infracost-%s", platform))), nil
+}
+
+func formatPercent(percent float64) string {
+	if math.IsInf(percent, 1) {
+		return "more than 100%"
+	}
+	return fmt.Sprintf("%.1f%%", percent)
+}
+
+// infracostOutput is the part of the JSON output of infracost breakdown that
+// cost estimates are read from.
+type infracostOutput struct {
+	Currency             string        `json:"currency"`
+	PastTotalMonthlyCost infracostCost `json:"pastTotalMonthlyCost"`
+	TotalMonthlyCost     infracostCost `json:"totalMonthlyCost"`
+	Projects             []struct {
+		Diff *struct {
+			Resources []struct {
+				Name        string        `json:"name"`
+				MonthlyCost infracostCost `json:"monthlyCost"`
+			} `json:"resources"`
+		} `json:"diff"`
+	} `json:"projects"`
+}
+
+// infracostCost is a cost in the JSON output of Infracost, which is a
+// decimal string or null if the cost is unknown.
+type infracostCost float64
+
+func (c *infracostCost) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		*c = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(*s, 64)
+	if err != nil {
+		return err
+	}
+	*c = infracostCost(f)
+	return nil
+}
+
+// ReadCostEstimate reads the cost estimate saved by a cost_estimate step to
+// file.
+func ReadCostEstimate(file string) (*models.CostEstimate, error) {
+	content, err := os.ReadFile(file) // nolint: gosec
+	if err != nil {
+		return nil, err
+	}
+	var output infracostOutput
+	if err := json.Unmarshal(content, &output); err != nil {
+		return nil, errors.Wrap(err, "parsing infracost output")
+	}
+
+	estimate := &models.CostEstimate{
+		Currency:        output.Currency,
+		PastMonthlyCost: float64(output.PastTotalMonthlyCost),
+		MonthlyCost:     float64(output.TotalMonthlyCost),
+	}
+	for _, project := range output.Projects {
+		if project.Diff == nil {
+			continue
+		}
+		for _, resource := range project.Diff.Resources {
+			if resource.MonthlyCost == 0 {
+				continue
+			}
+			estimate.Resources = append(estimate.Resources, models.ResourceCostChange{
+				Address:         resource.Name,
+				DiffMonthlyCost: float64(resource.MonthlyCost),
+			})
+		}
+	}
+	sort.SliceStable(estimate.Resources, func(i, j int) bool {
+		return math.Abs(estimate.Resources[i].DiffMonthlyCost) > math.Abs(estimate.Resources[j].DiffMonthlyCost)
+	})
+	return estimate, nil
+}
@@ -0,0 +1,139 @@
+package runtime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	cachemocks "github.com/runatlantis/atlantis/server/core/runtime/cache/mocks"
+	"github.com/runatlantis/atlantis/server/core/runtime/mocks"
+	runtimemocks "github.com/runatlantis/atlantis/server/core/runtime/models/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const infracostOutput = `{
+  "currency": "USD",
+  "pastTotalMonthlyCost": "100",
+  "totalMonthlyCost": "130.5",
+  "projects": [
+    {
+      "diff": {
+        "resources": [
+          {"name": "aws_eip.old", "monthlyCost": "-10"},
+          {"name": "aws_s3_bucket.logs", "monthlyCost": null},
+          {"name": "aws_instance.web", "monthlyCost": "40.5"}
+        ]
+      }
+    },
+    {"diff": null}
+  ]
+}`
+
+func TestReadCostEstimate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "infracost.json")
+	Ok(t, os.WriteFile(file, []byte(infracostOutput), 0600))
+
+	estimate, err := runtime.ReadCostEstimate(file)
+	Ok(t, err)
+	Equals(t, &models.CostEstimate{
+		Currency:        "USD",
+		PastMonthlyCost: 100,
+		MonthlyCost:     130.5,
+		Resources: []models.ResourceCostChange{
+			{Address: "aws_instance.web", DiffMonthlyCost: 40.5},
+			{Address: "aws_eip.old", DiffMonthlyCost: -10},
+		},
+	}, estimate)
+	Equals(t, 30.5, estimate.DiffMonthlyCost())
+	Equals(t, 30.5, estimate.DiffPercent())
+}
+
+func TestCostEstimateStepRunner_Run(t *testing.T) {
+	cases := []struct {
+		description        string
+		maxMonthlyIncrease float64
+		maxPercentIncrease float64
+		expErr             string
+	}{
+		{
+			description: "no thresholds",
+		},
+		{
+			description:        "under the thresholds",
+			maxMonthlyIncrease: 50,
+			maxPercentIncrease: 50,
+		},
+		{
+			description:        "over max_monthly_increase",
+			maxMonthlyIncrease: 30,
+			expErr:             "the plan increases the monthly cost by 30.50 USD, more than the max_monthly_increase of 30.00 USD",
+		},
+		{
+			description:        "over max_percent_increase",
+			maxPercentIncrease: 25,
+			expErr:             "the plan increases the monthly cost by 30.5%, more than the max_percent_increase of 25.0%",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			path := t.TempDir()
+			ctx := command.ProjectContext{Log: logging.NewNoopLogger(t), Workspace: "default", ProjectName: "network"}
+			planFile := filepath.Join(path, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+			Ok(t, os.WriteFile(planFile, []byte("plan"), 0600))
+			costFile := filepath.Join(path, ctx.GetCostEstimateFileName())
+
+			showRunner := mocks.NewMockRunner()
+			When(showRunner.Run(ctx, nil, path, map[string]string(nil))).Then(func([]Param) ReturnValues {
+				Ok(t, os.WriteFile(filepath.Join(path, ctx.GetShowResultFileName()), []byte("{}"), 0600))
+				return []ReturnValue{"", nil}
+			})
+			exec := runtimemocks.NewMockExec()
+			When(exec.LookPath("infracost")).ThenReturn("/usr/local/bin/infracost", nil)
+			When(exec.CombinedOutput(Any[[]string](), Any[map[string]string](), Eq(path))).Then(func([]Param) ReturnValues {
+				Ok(t, os.WriteFile(costFile, []byte(infracostOutput), 0600))
+				return []ReturnValue{"", nil}
+			})
+			runner := &runtime.CostEstimateStepRunner{
+				ShowStepRunner: showRunner,
+				VersionCache:   cachemocks.NewMockExecutionVersionCache(),
+				Exec:           exec,
+			}
+
+			_, err := runner.Run(ctx, []string{"--usage-file", "usage.yml"}, c.maxMonthlyIncrease, c.maxPercentIncrease, path, nil)
+			showRunner.VerifyWasCalledOnce().Run(ctx, nil, path, nil)
+			exec.VerifyWasCalledOnce().CombinedOutput([]string{
+				"/usr/local/bin/infracost", "breakdown",
+				"--path", filepath.Join(path, "network-default.json"),
+				"--format", "json",
+				"--out-file", costFile,
+				"--no-color",
+				"--usage-file", "usage.yml",
+			}, nil, path)
+			_, statErr := os.Stat(planFile)
+			if c.expErr == "" {
+				Ok(t, err)
+				Ok(t, statErr)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+			Assert(t, os.IsNotExist(statErr), "expected the plan to be deleted")
+		})
+	}
+}
+
+func TestCostEstimateStepRunner_Run_NoPlan(t *testing.T) {
+	RegisterMockTestingT(t)
+	runner := &runtime.CostEstimateStepRunner{
+		ShowStepRunner: mocks.NewMockRunner(),
+		Exec:           runtimemocks.NewMockExec(),
+	}
+	ctx := command.ProjectContext{Log: logging.NewNoopLogger(t), Workspace: "default"}
+	_, err := runner.Run(ctx, nil, 0, 0, t.TempDir(), nil)
+	ErrEquals(t, "no plan to estimate the cost of, the cost_estimate step must run after the plan step", err)
+}
@@ -0,0 +1,103 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+)
+
+// stateEncryptionEnvVar is the env var that configures OpenTofu's state and
+// plan encryption.
+const stateEncryptionEnvVar = "TF_ENCRYPTION"
+
+// openTofuOnlyFlags are the flags of plan and apply that only OpenTofu
+// supports, by the first version of OpenTofu that supports them.
+var openTofuOnlyFlags = map[string]string{
+	"-exclude":      "1.9.0",
+	"-exclude-file": "1.10.0",
+	"-target-file":  "1.10.0",
+}
+
+// stateEncryptionMinVersion is the first version of OpenTofu that supports
+// state encryption.
+const stateEncryptionMinVersion = "1.7.0"
+
+// planOnlyFlags are the flags that select the resources to plan, which apply
+// ignores when it applies a planfile.
+var planOnlyFlags = []string{"-target", "-target-file", "-exclude", "-exclude-file"}
+
+// validateDistributionArgs returns an error if args or envs use a feature
+// that the distribution and version of the project don't support, so that
+// users get a clear error instead of the cryptic one of the binary.
+// Terraform, for example, rejects -exclude as an unknown flag and fails to
+// read state that OpenTofu encrypted.
+func validateDistributionArgs(tfDistribution terraform.Distribution, tfVersion *version.Version, args []string, envs map[string]string) error {
+	isOpenTofu := tfDistribution != nil && tfDistribution.BinName() == "tofu"
+	for _, arg := range args {
+		flag := flagName(arg)
+		minVersion, ok := openTofuOnlyFlags[flag]
+		if !ok {
+			continue
+		}
+		if !isOpenTofu {
+			return fmt.Errorf("%s is only supported by OpenTofu but this project runs Terraform; set terraform_distribution: opentofu to use it", flag)
+		}
+		if err := checkOpenTofuVersion(tfVersion, minVersion, flag); err != nil {
+			return err
+		}
+	}
+
+	if envs[stateEncryptionEnvVar] == "" {
+		return nil
+	}
+	if !isOpenTofu {
+		return fmt.Errorf("state encryption (%s) is only supported by OpenTofu but this project runs Terraform; set terraform_distribution: opentofu to use it", stateEncryptionEnvVar)
+	}
+	return checkOpenTofuVersion(tfVersion, stateEncryptionMinVersion, fmt.Sprintf("state encryption (%s)", stateEncryptionEnvVar))
+}
+
+// checkOpenTofuVersion returns an error if tfVersion is older than minVersion,
+// the first version of OpenTofu that supports feature.
+func checkOpenTofuVersion(tfVersion *version.Version, minVersion string, feature string) error {
+	if tfVersion == nil {
+		return nil
+	}
+	if tfVersion.Core().LessThan(version.Must(version.NewVersion(minVersion))) {
+		return fmt.Errorf("%s requires OpenTofu %s or later but this project runs %s", feature, minVersion, tfVersion)
+	}
+	return nil
+}
+
+// findPlanOnlyFlag returns the first flag in args that only applies to plans,
+// or an empty string if there's none.
+func findPlanOnlyFlag(args []string) string {
+	for _, arg := range args {
+		flag := flagName(arg)
+		for _, planOnly := range planOnlyFlags {
+			if flag == planOnly {
+				return flag
+			}
+		}
+	}
+	return ""
+}
+
+// flagName returns the name of the flag arg, ex. -exclude for
+// -exclude=aws_instance.web. Comment args are escaped character by character
+// so they're unescaped first.
+func flagName(arg string) string {
+	if strings.HasPrefix(arg, `\`) {
+		var unescaped strings.Builder
+		for i := 0; i < len(arg); i++ {
+			if arg[i] == '\\' && i+1 < len(arg) {
+				i++
+			}
+			unescaped.WriteByte(arg[i])
+		}
+		arg = unescaped.String()
+	}
+	name, _, _ := strings.Cut(arg, "=")
+	return name
+}
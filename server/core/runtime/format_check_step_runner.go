@@ -0,0 +1,37 @@
+package runtime
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// FormatCheckStepRunner runs format_check steps. They fail if terraform fmt
+// would change any file of the project. extra_args are passed to terraform
+// fmt, ex. -recursive.
+type FormatCheckStepRunner struct {
+	TerraformExecutor     TerraformExec
+	DefaultTFDistribution terraform.Distribution
+	DefaultTFVersion      *version.Version
+}
+
+func (r *FormatCheckStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfDistribution := r.DefaultTFDistribution
+	tfVersion := r.DefaultTFVersion
+	if ctx.TerraformDistribution != nil {
+		tfDistribution = r.TerraformExecutor.Distributions().NewDistribution(*ctx.TerraformDistribution)
+	}
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	fmtCmd := append([]string{"fmt", "-check", "-diff"}, extraArgs...)
+	out, err := r.TerraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), fmtCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
+	if err != nil {
+		return "", fmt.Errorf("files aren't formatted, run terraform fmt to fix them: %s\n%s", err, out)
+	}
+	return "", nil
+}
@@ -0,0 +1,49 @@
+package runtime_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	tf "github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestFormatCheckStepRunner_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("1.5.0")
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader())
+	r := runtime.FormatCheckStepRunner{
+		TerraformExecutor:     terraform,
+		DefaultTFDistribution: tfDistribution,
+		DefaultTFVersion:      tfVersion,
+	}
+	ctx := command.ProjectContext{
+		Log:       logging.NewNoopLogger(t),
+		Workspace: "default",
+	}
+	tmpDir := t.TempDir()
+	args := []string{"fmt", "-check", "-diff", "-recursive"}
+
+	t.Run("formatted", func(t *testing.T) {
+		When(terraform.RunCommandWithVersion(ctx, tmpDir, args, map[string]string(nil), tfDistribution, tfVersion, "default")).
+			ThenReturn("", nil)
+		out, err := r.Run(ctx, []string{"-recursive"}, tmpDir, map[string]string(nil))
+		Ok(t, err)
+		Equals(t, "", out)
+	})
+
+	t.Run("not formatted", func(t *testing.T) {
+		When(terraform.RunCommandWithVersion(ctx, tmpDir, args, map[string]string(nil), tfDistribution, tfVersion, "default")).
+			ThenReturn("main.tf\n", errors.New("exit status 3"))
+		_, err := r.Run(ctx, []string{"-recursive"}, tmpDir, map[string]string(nil))
+		ErrEquals(t, "files aren't formatted, run terraform fmt to fix them: exit status 3\nmain.tf\n", err)
+	})
+}
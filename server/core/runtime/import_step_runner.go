@@ -29,7 +29,7 @@ func (p *importStepRunner) Run(ctx command.ProjectContext, extraArgs []string, p
 	tfDistribution := p.defaultTFDistribution
 	tfVersion := p.defaultTFVersion
 	if ctx.TerraformDistribution != nil {
-		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+		tfDistribution = p.terraformExecutor.Distributions().NewDistribution(*ctx.TerraformDistribution)
 	}
 	if ctx.TerraformVersion != nil {
 		tfVersion = ctx.TerraformVersion
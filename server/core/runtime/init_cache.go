@@ -0,0 +1,238 @@
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+)
+
+// DefaultInitCacheMaxAge is how long an init cache entry that isn't used is
+// kept for.
+const DefaultInitCacheMaxAge = 7 * 24 * time.Hour
+
+// initCacheSubDirs are the dirs inside .terraform that are cached. Modules
+// aren't cached since init doesn't verify the modules it finds installed,
+// while it verifies providers against the hashes in the lock file.
+var initCacheSubDirs = []string{"providers"}
+
+// InitCache caches the providers that `terraform init` downloads into
+// .terraform. Entries are scoped to a repo and keyed by the hash of
+// .terraform.lock.hcl, so the projects of a repo that pin the same providers
+// share an entry and only the first of them downloads them. The cached dirs
+// are copied into .terraform before init, which then finds the providers
+// already installed.
+type InitCache struct {
+	// Dir is where the entries are stored.
+	Dir string
+	// MaxAge is how long an entry that isn't used is kept for.
+	MaxAge time.Duration
+}
+
+// Key returns the key of the entry for the project in path, or "" if the
+// project has no lock file and so can't be cached.
+func (c *InitCache) Key(path string, tfDistribution terraform.Distribution, tfVersion *version.Version) (string, error) {
+	lockFile, err := os.ReadFile(filepath.Join(path, ".terraform.lock.hcl"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", tfDistribution.BinName(), tfVersion) // nolint: errcheck
+	hash.Write(lockFile)                                              // nolint: errcheck
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// repoDir returns the dir of the entries of the repo repoFullName, so that a
+// repo can't put entries in the cache that other repos use.
+func (c *InitCache) repoDir(repoFullName string) string {
+	return filepath.Join(c.Dir, url.PathEscape(repoFullName))
+}
+
+// Restore copies the entry for key of the repo repoFullName into the
+// .terraform dir of the project in path. It returns false if there's no entry
+// for key.
+func (c *InitCache) Restore(repoFullName string, path string, key string) (bool, error) {
+	entryDir := filepath.Join(c.repoDir(repoFullName), key)
+	if _, err := os.Stat(entryDir); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	for _, subDir := range initCacheSubDirs {
+		src := filepath.Join(entryDir, subDir)
+		dst := filepath.Join(path, ".terraform", subDir)
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+		// Anything that's already installed is left for init to check.
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		if err := copyDir(src, dst); err != nil {
+			return false, errors.Wrapf(err, "restoring %s", subDir)
+		}
+	}
+	now := time.Now()
+	if err := os.Chtimes(entryDir, now, now); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Save stores the .terraform dir of the project in path as the entry for key
+// of the repo repoFullName, unless there's already an entry for key. It must
+// only be called if init created .terraform, since init doesn't replace
+// everything that was already in it. Entries that haven't been used for
+// MaxAge are removed.
+func (c *InitCache) Save(repoFullName string, path string, key string) error {
+	repoDir := c.repoDir(repoFullName)
+	entryDir := filepath.Join(repoDir, key)
+	if _, err := os.Stat(entryDir); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(repoDir, 0700); err != nil {
+		return err
+	}
+
+	// The entry is written to a temporary dir and renamed into place so that
+	// projects that run at the same time never see a partial entry.
+	tmpDir, err := os.MkdirTemp(repoDir, key+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+	for _, subDir := range initCacheSubDirs {
+		src := filepath.Join(path, ".terraform", subDir)
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+		if err := copyDir(src, filepath.Join(tmpDir, subDir)); err != nil {
+			return errors.Wrapf(err, "saving %s", subDir)
+		}
+	}
+	if err := os.Rename(tmpDir, entryDir); err != nil {
+		// Another project saved the same entry first.
+		if _, statErr := os.Stat(entryDir); statErr == nil {
+			return nil
+		}
+		return err
+	}
+	return c.evict()
+}
+
+// evict removes the entries that haven't been used for MaxAge, and the dirs
+// of repos that have no entries left.
+func (c *InitCache) evict() error {
+	maxAge := c.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultInitCacheMaxAge
+	}
+	repoDirs, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return err
+	}
+	for _, repoDir := range repoDirs {
+		repoPath := filepath.Join(c.Dir, repoDir.Name())
+		// Entries saved before the cache was scoped to repos are removed.
+		if !repoDir.IsDir() || isInitCacheEntry(repoPath) {
+			if err := os.RemoveAll(repoPath); err != nil {
+				return err
+			}
+			continue
+		}
+		entries, err := os.ReadDir(repoPath)
+		if err != nil {
+			return err
+		}
+		removed := 0
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			if time.Since(info.ModTime()) > maxAge {
+				if err := os.RemoveAll(filepath.Join(repoPath, entry.Name())); err != nil {
+					return err
+				}
+				removed++
+			}
+		}
+		if removed == len(entries) {
+			os.Remove(repoPath) // nolint: errcheck
+		}
+	}
+	return nil
+}
+
+// isInitCacheEntry returns whether dir is an entry rather than the dir of a
+// repo's entries.
+func isInitCacheEntry(dir string) bool {
+	for _, subDir := range []string{"providers", "modules"} {
+		if _, err := os.Stat(filepath.Join(dir, subDir)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// copyDir copies the dir src to dst. Symlinks are copied as symlinks, ex. the
+// providers that init links from the plugin cache.
+func copyDir(src string, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src string, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint: errcheck
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close() // nolint: errcheck
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,101 @@
+package runtime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	tf "github.com/runatlantis/atlantis/server/core/terraform"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func writeInitCacheProject(t *testing.T, dir string, lockFile string, moduleVersion string) {
+	t.Helper()
+	Ok(t, os.WriteFile(filepath.Join(dir, ".terraform.lock.hcl"), []byte(lockFile), 0600))
+	Ok(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "`+moduleVersion+`"
+}
+`), 0600))
+}
+
+func TestInitCache_Key(t *testing.T) {
+	tfVersion, _ := version.NewVersion("1.5.0")
+	tfDistribution := tf.NewDistributionTerraform()
+	cache := &runtime.InitCache{Dir: t.TempDir()}
+
+	// Projects without a lock file aren't cached.
+	key, err := cache.Key(t.TempDir(), tfDistribution, tfVersion)
+	Ok(t, err)
+	Equals(t, "", key)
+
+	dirA, dirB, dirC, dirD := t.TempDir(), t.TempDir(), t.TempDir(), t.TempDir()
+	writeInitCacheProject(t, dirA, "lock", "5.0.0")
+	writeInitCacheProject(t, dirB, "lock", "5.0.0")
+	writeInitCacheProject(t, dirC, "other lock", "5.0.0")
+	// Modules aren't cached so they don't change the key.
+	writeInitCacheProject(t, dirD, "lock", "5.1.0")
+
+	keyA, err := cache.Key(dirA, tfDistribution, tfVersion)
+	Ok(t, err)
+	Assert(t, keyA != "", "expected a key")
+	keyB, err := cache.Key(dirB, tfDistribution, tfVersion)
+	Ok(t, err)
+	Equals(t, keyA, keyB)
+	keyC, err := cache.Key(dirC, tfDistribution, tfVersion)
+	Ok(t, err)
+	Assert(t, keyA != keyC, "expected lock file to change the key")
+	keyD, err := cache.Key(dirD, tfDistribution, tfVersion)
+	Ok(t, err)
+	Equals(t, keyA, keyD)
+	otherVersion, _ := version.NewVersion("1.6.0")
+	keyVersion, err := cache.Key(dirA, tfDistribution, otherVersion)
+	Ok(t, err)
+	Assert(t, keyA != keyVersion, "expected terraform version to change the key")
+}
+
+func TestInitCache_SaveRestore(t *testing.T) {
+	cache := &runtime.InitCache{Dir: t.TempDir()}
+
+	src := t.TempDir()
+	providerDir := filepath.Join(src, ".terraform", "providers", "registry.terraform.io", "hashicorp", "null")
+	Ok(t, os.MkdirAll(providerDir, 0700))
+	Ok(t, os.WriteFile(filepath.Join(providerDir, "terraform-provider-null"), []byte("binary"), 0700))
+	Ok(t, os.Symlink("/plugin-cache/aws", filepath.Join(src, ".terraform", "providers", "aws")))
+	Ok(t, os.MkdirAll(filepath.Join(src, ".terraform", "modules"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(src, ".terraform", "modules", "modules.json"), []byte("{}"), 0600))
+	// The state isn't cached.
+	Ok(t, os.WriteFile(filepath.Join(src, ".terraform", "terraform.tfstate"), []byte("state"), 0600))
+
+	restored, err := cache.Restore("org/app", src, "key")
+	Ok(t, err)
+	Equals(t, false, restored)
+	Ok(t, cache.Save("org/app", src, "key"))
+	// Saving the same key again keeps the existing entry.
+	Ok(t, cache.Save("org/app", t.TempDir(), "key"))
+
+	// Entries are only restored for the repo that saved them.
+	restored, err = cache.Restore("org/other", t.TempDir(), "key")
+	Ok(t, err)
+	Equals(t, false, restored)
+
+	dst := t.TempDir()
+	restored, err = cache.Restore("org/app", dst, "key")
+	Ok(t, err)
+	Equals(t, true, restored)
+
+	binary, err := os.ReadFile(filepath.Join(dst, ".terraform", "providers", "registry.terraform.io", "hashicorp", "null", "terraform-provider-null"))
+	Ok(t, err)
+	Equals(t, "binary", string(binary))
+	link, err := os.Readlink(filepath.Join(dst, ".terraform", "providers", "aws"))
+	Ok(t, err)
+	Equals(t, "/plugin-cache/aws", link)
+	// Modules and the state aren't cached.
+	_, err = os.Stat(filepath.Join(dst, ".terraform", "modules"))
+	Assert(t, os.IsNotExist(err), "expected modules not to be restored")
+	_, err = os.Stat(filepath.Join(dst, ".terraform", "terraform.tfstate"))
+	Assert(t, os.IsNotExist(err), "expected state not to be restored")
+}
@@ -15,6 +15,8 @@ type InitStepRunner struct {
 	TerraformExecutor     TerraformExec
 	DefaultTFDistribution terraform.Distribution
 	DefaultTFVersion      *version.Version
+	// Cache, if set, caches the providers that init downloads.
+	Cache *InitCache
 }
 
 func (i *InitStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
@@ -37,7 +39,7 @@ func (i *InitStepRunner) Run(ctx command.ProjectContext, extraArgs []string, pat
 
 	tfDistribution := i.DefaultTFDistribution
 	if ctx.TerraformDistribution != nil {
-		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+		tfDistribution = i.TerraformExecutor.Distributions().NewDistribution(*ctx.TerraformDistribution)
 	}
 
 	tfVersion := i.DefaultTFVersion
@@ -63,11 +65,37 @@ func (i *InitStepRunner) Run(ctx command.ProjectContext, extraArgs []string, pat
 
 	terraformInitCmd := append(terraformInitVerb, finalArgs...)
 
+	// Init can only be cached when the lock file pins the providers, since
+	// otherwise it upgrades them. A .terraform dir that already exists, ex.
+	// committed to the repo, is never saved since init doesn't replace
+	// everything in it.
+	var cacheKey string
+	restored := false
+	if i.Cache != nil && MustConstraint(">= 0.14.0").Check(tfVersion) && common.FileExists(terraformLockfilePath) &&
+		!common.FileExists(filepath.Join(path, ".terraform")) {
+		cacheKey, err = i.Cache.Key(path, tfDistribution, tfVersion)
+		if err != nil {
+			ctx.Log.Warn("unable to compute init cache key: %s", err)
+		} else if cacheKey != "" {
+			restored, err = i.Cache.Restore(ctx.BaseRepo.FullName, path, cacheKey)
+			if err != nil {
+				ctx.Log.Warn("unable to restore init cache: %s", err)
+			} else if restored {
+				ctx.Log.Debug("restored .terraform from init cache entry %s", cacheKey)
+			}
+		}
+	}
+
 	out, err := i.TerraformExecutor.RunCommandWithVersion(ctx, path, terraformInitCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
 	// Only include the init output if there was an error. Otherwise it's
 	// unnecessary and lengthens the comment.
 	if err != nil {
 		return out, err
 	}
+	if cacheKey != "" && !restored {
+		if err := i.Cache.Save(ctx.BaseRepo.FullName, path, cacheKey); err != nil {
+			ctx.Log.Warn("unable to save init cache: %s", err)
+		}
+	}
 	return "", nil
 }
@@ -408,3 +408,56 @@ func initRepo(t *testing.T) string {
 	runCmd(t, repoDir, "git", "branch", "branch")
 	return repoDir
 }
+
+func TestRun_InitCacheSkipsExistingTerraformDir(t *testing.T) {
+	RegisterMockTestingT(t)
+	tfVersion, _ := version.NewVersion("1.5.0")
+	cacheDir := t.TempDir()
+	terraform := tfclientmocks.NewMockClient()
+	iso := runtime.InitStepRunner{
+		TerraformExecutor:     terraform,
+		DefaultTFDistribution: tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader()),
+		DefaultTFVersion:      tfVersion,
+		Cache:                 &runtime.InitCache{Dir: cacheDir},
+	}
+	// Init installs a provider.
+	When(terraform.RunCommandWithVersion(Any[command.ProjectContext](), Any[string](), Any[[]string](), Any[map[string]string](), Any[tf.Distribution](), Any[*version.Version](), Any[string]())).
+		Then(func(params []Param) ReturnValues {
+			providerDir := filepath.Join(params[1].(string), ".terraform", "providers")
+			Ok(t, os.MkdirAll(providerDir, 0700))
+			Ok(t, os.WriteFile(filepath.Join(providerDir, "terraform-provider-null"), []byte("binary"), 0700))
+			return []ReturnValue{"", nil}
+		})
+	ctx := command.ProjectContext{
+		Workspace:  "default",
+		RepoRelDir: ".",
+		Log:        logging.NewNoopLogger(t),
+	}
+	ctx.BaseRepo.FullName = "org/app"
+
+	// newProject returns a repo with a tracked lock file.
+	newProject := func() string {
+		repoDir := initRepo(t)
+		Ok(t, os.WriteFile(filepath.Join(repoDir, ".terraform.lock.hcl"), []byte("lock"), 0600))
+		runCmd(t, repoDir, "git", "add", ".terraform.lock.hcl")
+		runCmd(t, repoDir, "git", "commit", "-m", "add .terraform.lock.hcl")
+		return repoDir
+	}
+
+	// A .terraform dir committed to the repo isn't saved.
+	committed := newProject()
+	Ok(t, os.MkdirAll(filepath.Join(committed, ".terraform", "modules"), 0700))
+	_, err := iso.Run(ctx, nil, committed, nil)
+	Ok(t, err)
+	entries, err := os.ReadDir(cacheDir)
+	Ok(t, err)
+	Equals(t, 0, len(entries))
+
+	// A .terraform dir created by init is saved in the repo's dir.
+	clean := newProject()
+	_, err = iso.Run(ctx, nil, clean, nil)
+	Ok(t, err)
+	entries, err = os.ReadDir(filepath.Join(cacheDir, "org%2Fapp"))
+	Ok(t, err)
+	Equals(t, 1, len(entries))
+}
@@ -0,0 +1,202 @@
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PlanChanges are the resource changes of a plan parsed from its JSON
+// output. See ParseJSONPlanOutput.
+type PlanChanges struct {
+	Import, Add, Change, Remove int
+	// Resources are the resources the plan changes, in the order of the
+	// output.
+	Resources []ResourceChange
+	// ChangesOutside is true if objects changed outside of Terraform.
+	ChangesOutside bool
+	// Diagnostics are the errors and warnings of the plan, ex.
+	// "Warning: Deprecated attribute".
+	Diagnostics []string
+}
+
+// ResourceChange is a change to a resource of a plan.
+type ResourceChange struct {
+	Address string
+	// Action is one of create, update, delete, replace, read, import, move
+	// or remove.
+	Action string
+}
+
+// uiMessage is a message of the machine-readable UI of terraform plan -json.
+type uiMessage struct {
+	Type   string `json:"type"`
+	Change struct {
+		Resource struct {
+			Addr string `json:"addr"`
+		} `json:"resource"`
+		Action string `json:"action"`
+	} `json:"change"`
+	Changes *struct {
+		Add    int `json:"add"`
+		Change int `json:"change"`
+		Import int `json:"import"`
+		Remove int `json:"remove"`
+	} `json:"changes"`
+	Diagnostic struct {
+		Severity string `json:"severity"`
+		Summary  string `json:"summary"`
+	} `json:"diagnostic"`
+}
+
+// jsonPlan is the JSON representation of a plan output by terraform show
+// -json.
+type jsonPlan struct {
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Change  struct {
+			Actions   []string        `json:"actions"`
+			Importing json.RawMessage `json:"importing"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+	ResourceDrift []json.RawMessage `json:"resource_drift"`
+}
+
+// ParseJSONPlanOutput parses the output of terraform plan -json, or of
+// terraform show -json with a plan. Lines of the output that aren't JSON, ex.
+// the output of a script that runs terraform, are ignored.
+func ParseJSONPlanOutput(output string) (PlanChanges, error) {
+	trimmed := strings.TrimSpace(output)
+	if strings.HasPrefix(trimmed, "{") && strings.Contains(trimmed, `"resource_changes"`) {
+		var plan jsonPlan
+		if err := json.Unmarshal([]byte(trimmed), &plan); err == nil {
+			return planChangesFromJSONPlan(plan), nil
+		}
+	}
+
+	var changes PlanChanges
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var msg uiMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case "planned_change":
+			if msg.Change.Action != "noop" {
+				changes.Resources = append(changes.Resources, ResourceChange{Address: msg.Change.Resource.Addr, Action: msg.Change.Action})
+			}
+		case "resource_drift":
+			changes.ChangesOutside = true
+		case "change_summary":
+			if msg.Changes != nil {
+				changes.Import = msg.Changes.Import
+				changes.Add = msg.Changes.Add
+				changes.Change = msg.Changes.Change
+				changes.Remove = msg.Changes.Remove
+				found = true
+			}
+		case "diagnostic":
+			changes.Diagnostics = append(changes.Diagnostics, fmt.Sprintf("%s: %s", capitalize(msg.Diagnostic.Severity), msg.Diagnostic.Summary))
+		}
+	}
+	if !found {
+		return PlanChanges{}, errors.New("no change summary found in the output, it must be the output of terraform plan -json or terraform show -json")
+	}
+	return changes, nil
+}
+
+func planChangesFromJSONPlan(plan jsonPlan) PlanChanges {
+	changes := PlanChanges{ChangesOutside: len(plan.ResourceDrift) > 0}
+	for _, rc := range plan.ResourceChanges {
+		action := strings.Join(rc.Change.Actions, ",")
+		switch action {
+		case "create":
+			changes.Add++
+		case "update":
+			changes.Change++
+		case "delete":
+			changes.Remove++
+		case "delete,create", "create,delete":
+			changes.Add++
+			changes.Remove++
+			action = "replace"
+		case "no-op":
+			action = ""
+		}
+		if len(rc.Change.Importing) > 0 && string(rc.Change.Importing) != "null" {
+			changes.Import++
+			if action == "" {
+				action = "import"
+			}
+		}
+		if action != "" {
+			changes.Resources = append(changes.Resources, ResourceChange{Address: rc.Address, Action: action})
+		}
+	}
+	return changes
+}
+
+// String renders the changes like the summary of terraform plan, with a line
+// per changed resource that starts with the symbol of its action, ex.
+// "+ aws_instance.web", so the comment highlights it like a diff.
+func (c PlanChanges) String() string {
+	var out strings.Builder
+	if c.ChangesOutside {
+		out.WriteString("Note: Objects have changed outside of Terraform\n\n")
+	}
+	for _, d := range c.Diagnostics {
+		out.WriteString(d + "\n")
+	}
+	if len(c.Diagnostics) > 0 {
+		out.WriteString("\n")
+	}
+	for _, r := range c.Resources {
+		switch r.Action {
+		case "create":
+			fmt.Fprintf(&out, "+ %s\n", r.Address)
+		case "update":
+			fmt.Fprintf(&out, "~ %s\n", r.Address)
+		case "delete":
+			fmt.Fprintf(&out, "- %s\n", r.Address)
+		case "replace":
+			fmt.Fprintf(&out, "-/+ %s\n", r.Address)
+		case "read":
+			fmt.Fprintf(&out, "<= %s\n", r.Address)
+		case "import":
+			fmt.Fprintf(&out, "  %s will be imported\n", r.Address)
+		case "move":
+			fmt.Fprintf(&out, "  %s has moved\n", r.Address)
+		case "remove":
+			fmt.Fprintf(&out, "  %s will be removed from the state\n", r.Address)
+		default:
+			fmt.Fprintf(&out, "  %s: %s\n", r.Address, r.Action)
+		}
+	}
+	if len(c.Resources) > 0 {
+		out.WriteString("\n")
+	}
+
+	if c.Import+c.Add+c.Change+c.Remove == 0 {
+		out.WriteString("No changes. Your infrastructure matches the configuration.\n")
+		return out.String()
+	}
+	out.WriteString("Plan: ")
+	if c.Import > 0 {
+		fmt.Fprintf(&out, "%d to import, ", c.Import)
+	}
+	fmt.Fprintf(&out, "%d to add, %d to change, %d to destroy.\n", c.Add, c.Change, c.Remove)
+	return out.String()
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
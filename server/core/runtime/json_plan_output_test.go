@@ -0,0 +1,100 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestParseJSONPlanOutput(t *testing.T) {
+	cases := []struct {
+		description string
+		output      string
+		exp         runtime.PlanChanges
+		expString   string
+	}{
+		{
+			description: "terraform plan -json",
+			output: `Running terraform plan
+{"@level":"info","@message":"Terraform 1.9.0","type":"version","terraform":"1.9.0","ui":"1.2"}
+{"@level":"warn","@message":"Warning: Deprecated attribute","type":"diagnostic","diagnostic":{"severity":"warning","summary":"Deprecated attribute"}}
+{"@level":"info","@message":"random_id.old: Drift detected (delete)","type":"resource_drift","change":{"resource":{"addr":"random_id.old"},"action":"delete"}}
+{"@level":"info","@message":"aws_instance.web: Plan to create","type":"planned_change","change":{"resource":{"addr":"aws_instance.web"},"action":"create"}}
+{"@level":"info","@message":"aws_instance.db: Plan to replace","type":"planned_change","change":{"resource":{"addr":"aws_instance.db"},"action":"replace"}}
+{"@level":"info","@message":"aws_s3_bucket.logs: Plan to import","type":"planned_change","change":{"resource":{"addr":"aws_s3_bucket.logs"},"action":"import"}}
+{"@level":"info","@message":"Plan: 1 to import, 2 to add, 0 to change, 1 to destroy.","type":"change_summary","changes":{"add":2,"change":0,"import":1,"remove":1,"operation":"plan"}}
+`,
+			exp: runtime.PlanChanges{
+				Import: 1,
+				Add:    2,
+				Remove: 1,
+				Resources: []runtime.ResourceChange{
+					{Address: "aws_instance.web", Action: "create"},
+					{Address: "aws_instance.db", Action: "replace"},
+					{Address: "aws_s3_bucket.logs", Action: "import"},
+				},
+				ChangesOutside: true,
+				Diagnostics:    []string{"Warning: Deprecated attribute"},
+			},
+			expString: `Note: Objects have changed outside of Terraform
+
+Warning: Deprecated attribute
+
++ aws_instance.web
+-/+ aws_instance.db
+  aws_s3_bucket.logs will be imported
+
+Plan: 1 to import, 2 to add, 0 to change, 1 to destroy.
+`,
+		},
+		{
+			description: "terraform plan -json without changes",
+			output:      `{"@level":"info","type":"change_summary","changes":{"add":0,"change":0,"import":0,"remove":0,"operation":"plan"}}`,
+			exp:         runtime.PlanChanges{},
+			expString:   "No changes. Your infrastructure matches the configuration.\n",
+		},
+		{
+			description: "terraform show -json",
+			output: `{"format_version":"1.2","resource_changes":[
+{"address":"aws_instance.web","change":{"actions":["create"]}},
+{"address":"aws_instance.db","change":{"actions":["delete","create"]}},
+{"address":"aws_s3_bucket.logs","change":{"actions":["update"]}},
+{"address":"aws_iam_role.ci","change":{"actions":["no-op"],"importing":{"id":"ci"}}},
+{"address":"random_id.id","change":{"actions":["no-op"]}}
+]}`,
+			exp: runtime.PlanChanges{
+				Import: 1,
+				Add:    2,
+				Change: 1,
+				Remove: 1,
+				Resources: []runtime.ResourceChange{
+					{Address: "aws_instance.web", Action: "create"},
+					{Address: "aws_instance.db", Action: "replace"},
+					{Address: "aws_s3_bucket.logs", Action: "update"},
+					{Address: "aws_iam_role.ci", Action: "import"},
+				},
+			},
+			expString: `+ aws_instance.web
+-/+ aws_instance.db
+~ aws_s3_bucket.logs
+  aws_iam_role.ci will be imported
+
+Plan: 1 to import, 2 to add, 1 to change, 1 to destroy.
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			changes, err := runtime.ParseJSONPlanOutput(c.output)
+			Ok(t, err)
+			Equals(t, c.exp, changes)
+			Equals(t, c.expString, changes.String())
+		})
+	}
+}
+
+func TestParseJSONPlanOutput_NotJSON(t *testing.T) {
+	_, err := runtime.ParseJSONPlanOutput("Plan: 1 to add, 0 to change, 0 to destroy.\n")
+	ErrEquals(t, "no change summary found in the output, it must be the output of terraform plan -json or terraform show -json", err)
+}
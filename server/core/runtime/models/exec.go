@@ -5,8 +5,16 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
 )
 
+// CommandInterruptDelay is how long a command that timed out has to exit
+// after it's interrupted before it's killed, ex. so that terraform can
+// release the state lock.
+const CommandInterruptDelay = time.Minute
+
 //go:generate pegomock generate --package mocks -o mocks/mock_exec.go Exec
 
 type Exec interface {
@@ -46,3 +54,51 @@ func (e LocalExec) CombinedOutput(args []string, envs map[string]string, workdir
 
 	return string(output), err
 }
+
+// Command is a command created by NewCommand.
+type Command struct {
+	*exec.Cmd
+	// stopKill stops the command's processes from being killed once it's
+	// exited so that a process that reuses their process group isn't killed.
+	stopKill func()
+}
+
+// Wait waits for the command to exit like exec.Cmd.Wait.
+func (c *Command) Wait() error {
+	defer c.stopKill()
+	return c.Cmd.Wait()
+}
+
+// Run starts the command and waits for it to exit like exec.Cmd.Run.
+func (c *Command) Run() error {
+	defer c.stopKill()
+	return c.Cmd.Run()
+}
+
+// Output runs the command and returns its stdout like exec.Cmd.Output.
+func (c *Command) Output() ([]byte, error) {
+	defer c.stopKill()
+	return c.Cmd.Output()
+}
+
+// CombinedOutput runs the command and returns its stdout and stderr like
+// exec.Cmd.CombinedOutput.
+func (c *Command) CombinedOutput() ([]byte, error) {
+	defer c.stopKill()
+	return c.Cmd.CombinedOutput()
+}
+
+// NewCommand returns a command that runs name with args for the project of
+// ctx. If the project's steps time out while it's running, it's interrupted,
+// along with the processes it started, and then killed if it doesn't exit
+// within CommandInterruptDelay. If the project's job is canceled, it's
+// terminated instead.
+func NewCommand(ctx command.ProjectContext, name string, args ...string) *Command {
+	if ctx.TimeoutCtx == nil {
+		return &Command{Cmd: exec.Command(name, args...), stopKill: func() {}} // #nosec
+	}
+	cmd := exec.CommandContext(ctx.TimeoutCtx, name, args...) // #nosec
+	stopKill := setInterrupt(cmd, ctx.TimeoutCtx)
+	cmd.WaitDelay = CommandInterruptDelay
+	return &Command{Cmd: cmd, stopKill: stopKill}
+}
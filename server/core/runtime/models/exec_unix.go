@@ -0,0 +1,63 @@
+//go:build !windows
+
+package models
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/jobs"
+)
+
+// setInterrupt runs cmd in its own process group so that when ctx is done,
+// the processes it started, ex. terraform started by sh -c, are interrupted
+// too, or terminated if their job was canceled, and killed if they haven't
+// exited within CommandInterruptDelay. stopKill must be called once cmd has
+// been waited for so that the process group isn't killed after its ID could
+// have been reused.
+func setInterrupt(cmd *exec.Cmd, ctx context.Context) (stopKill func()) {
+	var mu sync.Mutex
+	var killTimer *time.Timer
+	exited := false
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		if exited {
+			return os.ErrProcessDone
+		}
+		pgid := cmd.Process.Pid
+		signal := syscall.SIGINT
+		if errors.Is(context.Cause(ctx), jobs.ErrJobCanceled) {
+			signal = syscall.SIGTERM
+		}
+		if err := syscall.Kill(-pgid, signal); err != nil {
+			if errors.Is(err, syscall.ESRCH) {
+				return os.ErrProcessDone
+			}
+			return err
+		}
+		killTimer = time.AfterFunc(CommandInterruptDelay, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if !exited {
+				syscall.Kill(-pgid, syscall.SIGKILL) // nolint: errcheck
+			}
+		})
+		return nil
+	}
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		exited = true
+		if killTimer != nil {
+			killTimer.Stop()
+		}
+	}
+}
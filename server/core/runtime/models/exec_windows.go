@@ -0,0 +1,17 @@
+//go:build windows
+
+package models
+
+import (
+	"context"
+	"os/exec"
+)
+
+// setInterrupt kills cmd when it's canceled since Windows processes can't be
+// interrupted. Nothing is left to stop once cmd has exited.
+func setInterrupt(cmd *exec.Cmd, _ context.Context) (stopKill func()) {
+	cmd.Cancel = func() error {
+		return cmd.Process.Kill()
+	}
+	return func() {}
+}
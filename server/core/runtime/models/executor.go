@@ -0,0 +1,92 @@
+package models
+
+import (
+	"io"
+	"sync"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// Executor creates the commands that run the shell commands of steps, ex.
+// terraform plan or the command of a run step.
+type Executor interface {
+	// Command returns a command that runs shellCmd with shell in dir with env
+	// for the project of ctx. It's started and waited for like an exec.Cmd.
+	Command(ctx command.ProjectContext, shell *valid.CommandShell, shellCmd string, dir string, env []string) Cmd
+}
+
+// Cmd is a command created by an Executor. *exec.Cmd and *Command implement
+// it.
+type Cmd interface {
+	StdoutPipe() (io.ReadCloser, error)
+	StderrPipe() (io.ReadCloser, error)
+	StdinPipe() (io.WriteCloser, error)
+	Start() error
+	Wait() error
+}
+
+// LocalExecutor runs commands on the Atlantis server.
+type LocalExecutor struct{}
+
+// Command returns a command that runs on the Atlantis server. See NewCommand.
+func (LocalExecutor) Command(ctx command.ProjectContext, shell *valid.CommandShell, shellCmd string, dir string, env []string) Cmd {
+	if shell == nil {
+		shell = defaultShell()
+	}
+	var args []string
+	args = append(args, shell.ShellArgs...)
+	args = append(args, shellCmd)
+	cmd := NewCommand(ctx, shell.Shell, args...)
+	cmd.Env = env
+	cmd.Dir = dir
+	return cmd
+}
+
+// RunCombined runs cmd, writing its stdout and stderr to out, and waits for
+// it to exit.
+func RunCombined(cmd Cmd, out io.Writer) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Both are copied at the same time so that neither blocks the command.
+	syncOut := &syncWriter{w: out}
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+	for _, r := range []io.Reader{stdout, stderr} {
+		go func(r io.Reader) {
+			io.Copy(syncOut, r) // nolint: errcheck
+			wg.Done()
+		}(r)
+	}
+	wg.Wait()
+	return cmd.Wait()
+}
+
+func defaultShell() *valid.CommandShell {
+	return &valid.CommandShell{
+		Shell:     "sh",
+		ShellArgs: []string{"-c"},
+	}
+}
+
+// syncWriter serializes writes to w.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
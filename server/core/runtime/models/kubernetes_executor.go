@@ -0,0 +1,392 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"gopkg.in/yaml.v3"
+)
+
+// kubernetesDataVolume is the name of the volume that the Atlantis data dir
+// is mounted from in the pods of the Jobs.
+const kubernetesDataVolume = "atlantis-data"
+
+// kubernetesJobTTL is how long finished Jobs are kept if Atlantis can't
+// delete them, ex. because it restarted while they ran.
+const kubernetesJobTTL = 10 * 60
+
+// kubernetesPodStartFailures are the reasons a container is waiting for that
+// it won't recover from on its own.
+var kubernetesPodStartFailures = []string{"ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerConfigError", "CreateContainerError"}
+
+// KubernetesExecutor runs commands in ephemeral Kubernetes Jobs with kubectl
+// rather than on the Atlantis server, so that the credentials they need can
+// be given to the Jobs' pods and never live on the server. The output of the
+// pods is streamed back like the output of local commands.
+type KubernetesExecutor struct {
+	// Kubectl is the kubectl binary. It's configured like any kubectl, ex.
+	// with KUBECONFIG or the service account of the Atlantis pod.
+	Kubectl string
+	// Namespace is the namespace the Jobs are created in. If empty, the
+	// namespace of kubectl's current context is used.
+	Namespace string
+	// PodTemplate is the template of the Jobs' pods, ex. with their image,
+	// service account and env vars from secrets. The command, working dir and
+	// env of its first container are set for each command.
+	PodTemplate map[string]interface{}
+	// DataDir is the Atlantis data dir. It's mounted at the same path in the
+	// pods from the persistent volume claim DataDirClaim, so they see the same
+	// working dirs, binaries and plugin cache as the server.
+	DataDir      string
+	DataDirClaim string
+	// PollInterval is how often the pods' status is checked.
+	PollInterval time.Duration
+}
+
+// NewKubernetesExecutor returns an executor that creates Jobs with the pod
+// template in YAML at podTemplateFile in namespace.
+func NewKubernetesExecutor(podTemplateFile string, namespace string, dataDir string, dataDirClaim string) (*KubernetesExecutor, error) {
+	raw, err := os.ReadFile(podTemplateFile) // nolint: gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "reading pod template")
+	}
+	var podTemplate map[string]interface{}
+	if err := yaml.Unmarshal(raw, &podTemplate); err != nil {
+		return nil, errors.Wrapf(err, "parsing pod template %s", podTemplateFile)
+	}
+	e := &KubernetesExecutor{
+		Kubectl:      "kubectl",
+		Namespace:    namespace,
+		PodTemplate:  podTemplate,
+		DataDir:      dataDir,
+		DataDirClaim: dataDirClaim,
+		PollInterval: 2 * time.Second,
+	}
+	// Check the template now rather than when the first command runs.
+	if _, err := e.jobManifest(command.ProjectContext{}, "atlantis-check", defaultShell(), "true", dataDir, nil); err != nil {
+		return nil, errors.Wrapf(err, "pod template %s", podTemplateFile)
+	}
+	return e, nil
+}
+
+// Command returns a command that runs in a Kubernetes Job. The env vars that
+// the Atlantis server itself has aren't passed to the Job, only the ones set
+// for the command, ex. WORKSPACE. It doesn't read stdin.
+func (e *KubernetesExecutor) Command(ctx command.ProjectContext, shell *valid.CommandShell, shellCmd string, dir string, env []string) Cmd {
+	if shell == nil {
+		shell = defaultShell()
+	}
+	return &kubernetesCmd{
+		executor: e,
+		ctx:      ctx,
+		shell:    shell,
+		shellCmd: shellCmd,
+		dir:      dir,
+		env:      env,
+		done:     make(chan struct{}),
+	}
+}
+
+// jobManifest returns the Job that runs shellCmd with shell in dir with env.
+func (e *KubernetesExecutor) jobManifest(ctx command.ProjectContext, name string, shell *valid.CommandShell, shellCmd string, dir string, env []string) ([]byte, error) {
+	// The template is copied since it's changed for each Job.
+	raw, err := json.Marshal(e.PodTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var podTemplate map[string]interface{}
+	if err := json.Unmarshal(raw, &podTemplate); err != nil {
+		return nil, err
+	}
+	spec, ok := podTemplate["spec"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("spec must be set")
+	}
+	containers, _ := spec["containers"].([]interface{})
+	if len(containers) == 0 {
+		return nil, errors.New("spec.containers must have a container")
+	}
+	container, ok := containers[0].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("spec.containers must have a container")
+	}
+
+	cmd := []interface{}{shell.Shell}
+	for _, arg := range shell.ShellArgs {
+		cmd = append(cmd, arg)
+	}
+	container["command"] = append(cmd, shellCmd)
+	delete(container, "args")
+	container["workingDir"] = dir
+	containerEnv, _ := container["env"].([]interface{})
+	for _, v := range jobEnv(env) {
+		containerEnv = append(containerEnv, v)
+	}
+	container["env"] = containerEnv
+	mounts, _ := container["volumeMounts"].([]interface{})
+	container["volumeMounts"] = append(mounts, map[string]interface{}{
+		"name":      kubernetesDataVolume,
+		"mountPath": e.DataDir,
+	})
+	volumes, _ := spec["volumes"].([]interface{})
+	spec["volumes"] = append(volumes, map[string]interface{}{
+		"name": kubernetesDataVolume,
+		"persistentVolumeClaim": map[string]interface{}{
+			"claimName": e.DataDirClaim,
+		},
+	})
+	spec["restartPolicy"] = "Never"
+
+	return json.Marshal(map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]interface{}{
+			"name": name,
+			"labels": map[string]interface{}{
+				"app.kubernetes.io/managed-by": "atlantis",
+			},
+			"annotations": map[string]interface{}{
+				"atlantis.runatlantis.io/repo":      ctx.BaseRepo.FullName,
+				"atlantis.runatlantis.io/pull":      strconv.Itoa(ctx.Pull.Num),
+				"atlantis.runatlantis.io/dir":       ctx.RepoRelDir,
+				"atlantis.runatlantis.io/workspace": ctx.Workspace,
+			},
+		},
+		"spec": map[string]interface{}{
+			"backoffLimit":            0,
+			"ttlSecondsAfterFinished": kubernetesJobTTL,
+			"template":                podTemplate,
+		},
+	})
+}
+
+// jobEnv returns the env vars of env in the format of a container's env,
+// leaving out the ones that the Atlantis server itself has so its
+// credentials aren't passed to the Jobs. Later values of a var replace
+// earlier ones.
+func jobEnv(env []string) []interface{} {
+	serverEnv := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		serverEnv[kv] = true
+	}
+	var names []string
+	values := make(map[string]string)
+	for _, kv := range env {
+		if serverEnv[kv] {
+			continue
+		}
+		name, value, _ := strings.Cut(kv, "=")
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = value
+	}
+	var vars []interface{}
+	for _, name := range names {
+		vars = append(vars, map[string]interface{}{"name": name, "value": values[name]})
+	}
+	return vars
+}
+
+// kubernetesCmd is a command that runs in a Kubernetes Job.
+type kubernetesCmd struct {
+	executor *KubernetesExecutor
+	ctx      command.ProjectContext
+	shell    *valid.CommandShell
+	shellCmd string
+	dir      string
+	env      []string
+
+	name    string
+	stdoutR *io.PipeReader
+	stdoutW *io.PipeWriter
+	stderrR *io.PipeReader
+	stderrW *io.PipeWriter
+	once    sync.Once
+	done    chan struct{}
+	err     error
+}
+
+func (c *kubernetesCmd) pipes() {
+	c.once.Do(func() {
+		c.stdoutR, c.stdoutW = io.Pipe()
+		c.stderrR, c.stderrW = io.Pipe()
+	})
+}
+
+func (c *kubernetesCmd) StdoutPipe() (io.ReadCloser, error) {
+	c.pipes()
+	return c.stdoutR, nil
+}
+
+func (c *kubernetesCmd) StderrPipe() (io.ReadCloser, error) {
+	c.pipes()
+	return c.stderrR, nil
+}
+
+func (c *kubernetesCmd) StdinPipe() (io.WriteCloser, error) {
+	return nopWriteCloser{io.Discard}, nil
+}
+
+// Start creates the Job and starts streaming the logs of its pod.
+func (c *kubernetesCmd) Start() error {
+	c.pipes()
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	c.name = "atlantis-" + hex.EncodeToString(suffix)
+	manifest, err := c.executor.jobManifest(c.ctx, c.name, c.shell, c.shellCmd, c.dir, c.env)
+	if err != nil {
+		return errors.Wrap(err, "building Kubernetes job")
+	}
+
+	create := c.kubectl(c.context(), "create", "-f", "-")
+	create.Stdin = bytes.NewReader(manifest)
+	if out, err := create.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "creating Kubernetes job: %s", strings.TrimSpace(string(out)))
+	}
+	c.ctx.Log.Debug("created Kubernetes job %s", c.name)
+	go c.run()
+	return nil
+}
+
+// Wait waits for the Job to finish. It returns an error if it failed.
+func (c *kubernetesCmd) Wait() error {
+	<-c.done
+	return c.err
+}
+
+func (c *kubernetesCmd) run() {
+	defer close(c.done)
+	defer c.stderrW.Close() // nolint: errcheck
+	defer c.stdoutW.Close() // nolint: errcheck
+	defer c.deleteJob()
+
+	ctx := c.context()
+	if err := c.waitForPod(ctx); err != nil {
+		c.err = err
+		return
+	}
+	logs := c.kubectl(ctx, "logs", "--follow", "job/"+c.name)
+	logs.Stdout = c.stdoutW
+	logs.Stderr = c.stderrW
+	if err := logs.Run(); err != nil {
+		c.ctx.Log.Warn("streaming logs of Kubernetes job %s: %s", c.name, err)
+	}
+	c.err = c.waitForExit(ctx)
+}
+
+// waitForPod waits for the Job's pod to start.
+func (c *kubernetesCmd) waitForPod(ctx context.Context) error {
+	for {
+		phase, detail, err := c.podStatus(ctx)
+		if err != nil {
+			return err
+		}
+		switch phase {
+		case "Running", "Succeeded", "Failed":
+			return nil
+		}
+		for _, failure := range kubernetesPodStartFailures {
+			if detail == failure {
+				return fmt.Errorf("pod of Kubernetes job %s can't start: %s", c.name, detail)
+			}
+		}
+		if err := c.sleep(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// waitForExit waits for the Job's pod to exit.
+func (c *kubernetesCmd) waitForExit(ctx context.Context) error {
+	for {
+		phase, detail, err := c.podStatus(ctx)
+		if err != nil {
+			return err
+		}
+		switch phase {
+		case "Succeeded":
+			return nil
+		case "Failed":
+			return fmt.Errorf("Kubernetes job %s failed with exit code %s", c.name, detail)
+		}
+		if err := c.sleep(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// podStatus returns the phase of the Job's pod and the reason its container
+// is waiting or its exit code, ex. "Pending" and "ErrImagePull". The phase is
+// empty if the pod hasn't been created yet.
+func (c *kubernetesCmd) podStatus(ctx context.Context) (string, string, error) {
+	out, err := c.kubectl(ctx, "get", "pods", "--selector", "job-name="+c.name, "--output",
+		`jsonpath={range .items[*]}{.status.phase} {.status.containerStatuses[0].state.waiting.reason}{.status.containerStatuses[0].state.terminated.exitCode}{"\n"}{end}`).Output()
+	if err != nil {
+		return "", "", errors.Wrapf(err, "getting pod of Kubernetes job %s", c.name)
+	}
+	fields := strings.Fields(strings.SplitN(string(out), "\n", 2)[0])
+	phase, detail := "", ""
+	if len(fields) > 0 {
+		phase = fields[0]
+	}
+	if len(fields) > 1 {
+		detail = fields[1]
+	}
+	return phase, detail, nil
+}
+
+func (c *kubernetesCmd) sleep(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "waiting for Kubernetes job %s", c.name)
+	case <-time.After(c.executor.PollInterval):
+		return nil
+	}
+}
+
+// deleteJob deletes the Job and its pod, even if the command timed out.
+func (c *kubernetesCmd) deleteJob() {
+	if out, err := c.kubectl(context.Background(), "delete", "job", c.name, "--ignore-not-found", "--wait=false", "--cascade=background").CombinedOutput(); err != nil {
+		c.ctx.Log.Warn("deleting Kubernetes job %s: %s: %s", c.name, err, strings.TrimSpace(string(out)))
+	}
+}
+
+// context is canceled when the project's steps time out.
+func (c *kubernetesCmd) context() context.Context {
+	if c.ctx.TimeoutCtx != nil {
+		return c.ctx.TimeoutCtx
+	}
+	return context.Background()
+}
+
+func (c *kubernetesCmd) kubectl(ctx context.Context, args ...string) *exec.Cmd {
+	if c.executor.Namespace != "" {
+		args = append([]string{"--namespace", c.executor.Namespace}, args...)
+	}
+	return exec.CommandContext(ctx, c.executor.Kubectl, args...) // #nosec
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,150 @@
+package models_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/runtime/models"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	"github.com/runatlantis/atlantis/server/jobs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const podTemplate = `
+metadata:
+  labels:
+    team: infra
+spec:
+  serviceAccountName: terraform
+  containers:
+  - name: terraform
+    image: hashicorp/terraform:1.9
+    args: [plan]
+    env:
+    - name: AWS_SECRET_ACCESS_KEY
+      valueFrom:
+        secretKeyRef: {name: aws, key: secret}
+`
+
+// fakeKubectl writes a kubectl that saves the Job it creates to dir and
+// reports that its pod exited with status podStatus after printing two lines.
+func fakeKubectl(t *testing.T, dir string, podStatus string) string {
+	kubectl := filepath.Join(dir, "kubectl")
+	script := fmt.Sprintf(`#!/bin/sh
+echo "$@" >> %[1]s/calls
+case "$*" in
+  *create*) cat > %[1]s/job.json ;;
+  *"get pods"*) echo "%[2]s" ;;
+  *logs*) echo "Plan: 1 to add"; >&2 echo "warning" ;;
+esac
+`, dir, podStatus)
+	Ok(t, os.WriteFile(kubectl, []byte(script), 0700)) // #nosec G306
+	return kubectl
+}
+
+func newKubernetesExecutor(t *testing.T, dir string, podStatus string) *models.KubernetesExecutor {
+	templateFile := filepath.Join(dir, "pod.yaml")
+	Ok(t, os.WriteFile(templateFile, []byte(podTemplate), 0600))
+	e, err := models.NewKubernetesExecutor(templateFile, "atlantis-jobs", "/atlantis-data", "atlantis-data")
+	Ok(t, err)
+	e.Kubectl = fakeKubectl(t, dir, podStatus)
+	e.PollInterval = time.Millisecond
+	return e
+}
+
+func TestKubernetesExecutor(t *testing.T) {
+	dir := t.TempDir()
+	e := newKubernetesExecutor(t, dir, "Succeeded 0")
+	t.Setenv("ATLANTIS_GH_TOKEN", "server-secret")
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		BaseRepo:   testdata.GithubRepo,
+		Pull:       testdata.Pull,
+		RepoRelDir: "network",
+		Workspace:  "default",
+	}
+
+	env := append([]string{"WORKSPACE=default", "TF_IN_AUTOMATION=true"}, os.Environ()...)
+	runner := models.NewShellCommandRunner(nil, "terraform plan", env, "/atlantis-data/repos/network", true, mocks.NewMockProjectCommandOutputHandler(), e)
+	out, err := runner.Run(ctx)
+	Ok(t, err)
+	Assert(t, strings.Contains(out, "Plan: 1 to add\n"), "expected the pod's logs in the output, got %q", out)
+	Assert(t, strings.Contains(out, "warning\n"), "expected the pod's stderr in the output, got %q", out)
+
+	calls, err := os.ReadFile(filepath.Join(dir, "calls")) // nolint: gosec
+	Ok(t, err)
+	Assert(t, strings.HasPrefix(string(calls), "--namespace atlantis-jobs create -f -\n"), "unexpected kubectl calls %q", calls)
+	Assert(t, strings.Contains(string(calls), "--namespace atlantis-jobs delete job atlantis-"), "expected the job to be deleted, got %q", calls)
+
+	raw, err := os.ReadFile(filepath.Join(dir, "job.json")) // nolint: gosec
+	Ok(t, err)
+	var job struct {
+		Kind string
+		Spec struct {
+			BackoffLimit int
+			Template     struct {
+				Metadata struct {
+					Labels map[string]string
+				}
+				Spec struct {
+					ServiceAccountName string
+					RestartPolicy      string
+					Containers         []struct {
+						Command      []string
+						Args         []string
+						WorkingDir   string
+						Env          []map[string]interface{}
+						VolumeMounts []map[string]string
+					}
+					Volumes []map[string]interface{}
+				}
+			}
+		}
+	}
+	Ok(t, json.Unmarshal(raw, &job))
+	Equals(t, "Job", job.Kind)
+	Equals(t, 0, job.Spec.BackoffLimit)
+	pod := job.Spec.Template
+	Equals(t, "infra", pod.Metadata.Labels["team"])
+	Equals(t, "terraform", pod.Spec.ServiceAccountName)
+	Equals(t, "Never", pod.Spec.RestartPolicy)
+	container := pod.Spec.Containers[0]
+	Equals(t, []string{"sh", "-c", "terraform plan"}, container.Command)
+	Equals(t, 0, len(container.Args))
+	Equals(t, "/atlantis-data/repos/network", container.WorkingDir)
+	var envNames []string
+	for _, v := range container.Env {
+		envNames = append(envNames, v["name"].(string))
+	}
+	Equals(t, []string{"AWS_SECRET_ACCESS_KEY", "WORKSPACE", "TF_IN_AUTOMATION"}, envNames)
+	Equals(t, []map[string]string{{"name": "atlantis-data", "mountPath": "/atlantis-data"}}, container.VolumeMounts)
+	Equals(t, "atlantis-data", pod.Spec.Volumes[0]["persistentVolumeClaim"].(map[string]interface{})["claimName"])
+}
+
+func TestKubernetesExecutor_Failed(t *testing.T) {
+	e := newKubernetesExecutor(t, t.TempDir(), "Failed 3")
+	runner := models.NewShellCommandRunner(nil, "terraform apply", nil, "/atlantis-data/repos", false, mocks.NewMockProjectCommandOutputHandler(), e)
+	_, err := runner.Run(command.ProjectContext{Log: logging.NewNoopLogger(t)})
+	ErrContains(t, "failed with exit code 3", err)
+}
+
+func TestKubernetesExecutor_PodCantStart(t *testing.T) {
+	e := newKubernetesExecutor(t, t.TempDir(), "Pending ImagePullBackOff")
+	runner := models.NewShellCommandRunner(nil, "terraform apply", nil, "/atlantis-data/repos", false, mocks.NewMockProjectCommandOutputHandler(), e)
+	_, err := runner.Run(command.ProjectContext{Log: logging.NewNoopLogger(t)})
+	ErrContains(t, "can't start: ImagePullBackOff", err)
+}
+
+func TestNewKubernetesExecutor_NoContainers(t *testing.T) {
+	templateFile := filepath.Join(t.TempDir(), "pod.yaml")
+	Ok(t, os.WriteFile(templateFile, []byte("spec:\n  serviceAccountName: terraform\n"), 0600))
+	_, err := models.NewKubernetesExecutor(templateFile, "", "/atlantis-data", "atlantis-data")
+	ErrContains(t, "spec.containers must have a container", err)
+}
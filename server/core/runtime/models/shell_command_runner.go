@@ -3,7 +3,6 @@ package models
 import (
 	"bufio"
 	"io"
-	"os/exec"
 	"strings"
 	"sync"
 	"time"
@@ -26,17 +25,20 @@ type Line struct {
 	Err error
 }
 
-// ShellCommandRunner runs a command via `exec.Command` and streams output to the
-// `ProjectCommandOutputHandler`.
+// ShellCommandRunner runs a command with an Executor and streams output to
+// the `ProjectCommandOutputHandler`.
 type ShellCommandRunner struct {
 	command       string
 	workingDir    string
 	outputHandler jobs.ProjectCommandOutputHandler
 	streamOutput  bool
-	cmd           *exec.Cmd
+	environ       []string
 	shell         *valid.CommandShell
+	executor      Executor
 }
 
+// NewShellCommandRunner returns a runner of command. If executor is nil, the
+// command runs on the Atlantis server.
 func NewShellCommandRunner(
 	shell *valid.CommandShell,
 	command string,
@@ -44,33 +46,30 @@ func NewShellCommandRunner(
 	workingDir string,
 	streamOutput bool,
 	outputHandler jobs.ProjectCommandOutputHandler,
+	executor Executor,
 ) *ShellCommandRunner {
 	if shell == nil {
-		shell = &valid.CommandShell{
-			Shell:     "sh",
-			ShellArgs: []string{"-c"},
-		}
+		shell = defaultShell()
+	}
+	if executor == nil {
+		executor = LocalExecutor{}
 	}
-	var args []string
-	args = append(args, shell.ShellArgs...)
-	args = append(args, command)
-	cmd := exec.Command(shell.Shell, args...) // #nosec
-	cmd.Env = environ
-	cmd.Dir = workingDir
-
 	return &ShellCommandRunner{
 		command:       command,
 		workingDir:    workingDir,
 		outputHandler: outputHandler,
 		streamOutput:  streamOutput,
-		cmd:           cmd,
+		environ:       environ,
 		shell:         shell,
+		executor:      executor,
 	}
 }
 
 func (s *ShellCommandRunner) Run(ctx command.ProjectContext) (string, error) {
 	_, outCh := s.RunCommandAsync(ctx)
 
+	// sanitize output by stripping out any ansi characters a line at a time
+	// so that the output isn't copied once it's all been read.
 	outbuf := new(strings.Builder)
 	var err error
 	for line := range outCh {
@@ -78,13 +77,10 @@ func (s *ShellCommandRunner) Run(ctx command.ProjectContext) (string, error) {
 			err = line.Err
 			break
 		}
-		outbuf.WriteString(line.Line)
+		outbuf.WriteString(ansi.Strip(line.Line))
 		outbuf.WriteString("\n")
 	}
-
-	// sanitize output by stripping out any ansi characters.
-	output := ansi.Strip(outbuf.String())
-	return output, err
+	return outbuf.String(), err
 }
 
 // RunCommandAsync runs terraform with args. It immediately returns an
@@ -107,12 +103,14 @@ func (s *ShellCommandRunner) RunCommandAsync(ctx command.ProjectContext) (chan<-
 			close(inCh)
 		}()
 
-		stdout, _ := s.cmd.StdoutPipe()
-		stderr, _ := s.cmd.StderrPipe()
-		stdin, _ := s.cmd.StdinPipe()
+		cmd := s.executor.Command(ctx, s.shell, s.command, s.workingDir, s.environ)
+
+		stdout, _ := cmd.StdoutPipe()
+		stderr, _ := cmd.StderrPipe()
+		stdin, _ := cmd.StdinPipe()
 
 		ctx.Log.Debug("starting '%s %q' in '%s'", s.shell.String(), s.command, s.workingDir)
-		err := s.cmd.Start()
+		err := cmd.Start()
 		if err != nil {
 			err = errors.Wrapf(err, "running '%s %q' in '%s'", s.shell.String(), s.command, s.workingDir)
 			ctx.Log.Err(err.Error())
@@ -166,7 +164,7 @@ func (s *ShellCommandRunner) RunCommandAsync(ctx command.ProjectContext) (chan<-
 		wg.Wait()
 
 		// Wait for the command to complete.
-		err = s.cmd.Wait()
+		err = cmd.Wait()
 
 		dur := time.Since(start)
 		log := ctx.Log.With("duration", dur)
@@ -1,15 +1,19 @@
 package models_test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/petergtz/pegomock/v4"
 	"github.com/runatlantis/atlantis/server/core/runtime/models"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/jobs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
 	logmocks "github.com/runatlantis/atlantis/server/logging/mocks"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -54,7 +58,7 @@ func TestShellCommandRunner_Run(t *testing.T) {
 			expectedOutput := fmt.Sprintf("%s\n", strings.Join(c.ExpLines, "\n"))
 
 			// Run once with streaming enabled
-			runner := models.NewShellCommandRunner(nil, c.Command, environ, cwd, true, projectCmdOutputHandler)
+			runner := models.NewShellCommandRunner(nil, c.Command, environ, cwd, true, projectCmdOutputHandler, nil)
 			output, err := runner.Run(ctx)
 			Ok(t, err)
 			Equals(t, expectedOutput, output)
@@ -68,7 +72,7 @@ func TestShellCommandRunner_Run(t *testing.T) {
 			// command output handler should not have received anything
 
 			projectCmdOutputHandler = mocks.NewMockProjectCommandOutputHandler()
-			runner = models.NewShellCommandRunner(nil, c.Command, environ, cwd, false, projectCmdOutputHandler)
+			runner = models.NewShellCommandRunner(nil, c.Command, environ, cwd, false, projectCmdOutputHandler, nil)
 			output, err = runner.Run(ctx)
 			Ok(t, err)
 			Equals(t, expectedOutput, output)
@@ -78,3 +82,50 @@ func TestShellCommandRunner_Run(t *testing.T) {
 		})
 	}
 }
+
+// Commands that are still running when the project's steps time out are
+// interrupted, along with the processes they started.
+func TestShellCommandRunner_RunTimeout(t *testing.T) {
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "default",
+		RepoRelDir: ".",
+		TimeoutCtx: timeoutCtx,
+	}
+	cwd, err := os.Getwd()
+	Ok(t, err)
+
+	start := time.Now()
+	runner := models.NewShellCommandRunner(nil, "echo started; sleep 30; echo done", nil, cwd, false, mocks.NewMockProjectCommandOutputHandler(), nil)
+	output, err := runner.Run(ctx)
+	Assert(t, err != nil, "expected an error")
+	Equals(t, "started\n", output)
+	Assert(t, time.Since(start) < 10*time.Second, "command wasn't interrupted, ran for %s", time.Since(start))
+}
+
+// Commands that are still running when the project's job is canceled are
+// terminated, along with the processes they started.
+func TestShellCommandRunner_RunCanceled(t *testing.T) {
+	canceler := jobs.NewJobCanceler()
+	jobCtx, done := canceler.Start("job")
+	defer done()
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "default",
+		RepoRelDir: ".",
+		JobID:      "job",
+		TimeoutCtx: jobCtx,
+	}
+	cwd, err := os.Getwd()
+	Ok(t, err)
+
+	time.AfterFunc(200*time.Millisecond, func() { canceler.Cancel("job") })
+	start := time.Now()
+	runner := models.NewShellCommandRunner(nil, "trap 'echo terminated; exit 1' TERM; echo started; sleep 30 & wait", nil, cwd, false, mocks.NewMockProjectCommandOutputHandler(), nil)
+	output, err := runner.Run(ctx)
+	Assert(t, err != nil, "expected an error")
+	Equals(t, "started\nterminated\n", output)
+	Assert(t, time.Since(start) < 10*time.Second, "command wasn't terminated, ran for %s", time.Since(start))
+}
@@ -0,0 +1,257 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// updatedInPlaceSuffix ends the line of terraform plan's output that starts
+// the block of a resource that's updated in-place, ex.
+// "  # aws_instance.web will be updated in-place".
+const updatedInPlaceSuffix = " will be updated in-place"
+
+// PlanChangeSuppressor removes the noisy changes of a plan, ex. to tags only
+// or to the generation of a Kubernetes object, from its output so that the
+// changes that matter stand out. Which changes are noisy is decided from the
+// JSON output of terraform show for the plan.
+type PlanChangeSuppressor struct {
+	// ShowStepRunner runs terraform show if the workflow didn't already run
+	// it on the plan.
+	ShowStepRunner Runner
+	// JQ is the path to jq, which runs the jq filters of suppressed changes.
+	// If empty, jq is looked up in the PATH.
+	JQ string
+}
+
+// Suppress removes the blocks of the resources whose changes match the
+// project's suppressed changes from output and returns them. Only resources
+// that are updated in-place are suppressed so resources are never created or
+// destroyed without being shown.
+func (s *PlanChangeSuppressor) Suppress(ctx command.ProjectContext, output string, path string, envs map[string]string) (string, []models.SuppressedChange, error) {
+	if len(ctx.SuppressedChanges) == 0 {
+		return output, nil, nil
+	}
+	showFile := filepath.Join(path, ctx.GetShowResultFileName())
+	planInfo, err := os.Stat(filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName)))
+	if err != nil {
+		return output, nil, errors.Wrap(err, "reading planfile")
+	}
+	if showInfo, err := os.Stat(showFile); err != nil || showInfo.ModTime().Before(planInfo.ModTime()) {
+		if _, err := s.ShowStepRunner.Run(ctx, nil, path, envs); err != nil {
+			return output, nil, errors.Wrap(err, "running terraform show")
+		}
+	}
+	content, err := os.ReadFile(showFile) // nolint: gosec
+	if err != nil {
+		return output, nil, errors.Wrap(err, "reading terraform show output")
+	}
+	var plan suppressiblePlan
+	if err := json.Unmarshal(content, &plan); err != nil {
+		return output, nil, errors.Wrap(err, "parsing terraform show output")
+	}
+
+	// jq is run once per filter on the whole plan rather than once per
+	// resource.
+	jqMatches := make([]map[string]bool, len(ctx.SuppressedChanges))
+	for i, sc := range ctx.SuppressedChanges {
+		if sc.JQ == "" {
+			continue
+		}
+		if jqMatches[i], err = s.jqAddresses(ctx, sc.JQ, showFile, path, envs); err != nil {
+			return output, nil, err
+		}
+	}
+
+	var suppressed []models.SuppressedChange
+	addresses := make(map[string]bool)
+	for _, rc := range plan.ResourceChanges {
+		if strings.Join(rc.Change.Actions, ",") != "update" {
+			continue
+		}
+		for i, sc := range ctx.SuppressedChanges {
+			if sc.AddressRegex != nil && !sc.AddressRegex.MatchString(rc.Address) {
+				continue
+			}
+			if sc.JQ != "" && !jqMatches[i][rc.Address] {
+				continue
+			}
+			if sc.AttributesRegex != nil && !onlyChangesAttributes(rc.Change.Before, rc.Change.After, rc.Change.AfterUnknown, sc.AttributesRegex) {
+				continue
+			}
+			suppressed = append(suppressed, models.SuppressedChange{Address: rc.Address, Description: sc.Description})
+			addresses[rc.Address] = true
+			break
+		}
+	}
+	if len(suppressed) == 0 {
+		return output, nil, nil
+	}
+	ctx.Log.Info("suppressed %d noisy changes from the plan output", len(suppressed))
+	return removeResourceBlocks(output, addresses), suppressed, nil
+}
+
+// jqAddresses returns the addresses of the resource changes of the JSON plan
+// in showFile that filter is true for.
+func (s *PlanChangeSuppressor) jqAddresses(ctx command.ProjectContext, filter string, showFile string, path string, envs map[string]string) (map[string]bool, error) {
+	jq := s.JQ
+	if jq == "" {
+		jq = "jq"
+	}
+	program := fmt.Sprintf("[.resource_changes[]? | select(%s) | .address]", filter)
+	out, err := runCLI(ctx, path, envs, jq, "--compact-output", program, showFile)
+	if err != nil {
+		return nil, fmt.Errorf("running jq filter %q: %s", filter, err)
+	}
+	var addresses []string
+	if err := json.Unmarshal([]byte(out), &addresses); err != nil {
+		return nil, errors.Wrapf(err, "parsing the output of jq filter %q", filter)
+	}
+	matches := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		matches[address] = true
+	}
+	return matches, nil
+}
+
+// suppressiblePlan is the part of the JSON output of terraform show that
+// changes are suppressed from.
+type suppressiblePlan struct {
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Change  struct {
+			Actions      []string    `json:"actions"`
+			Before       interface{} `json:"before"`
+			After        interface{} `json:"after"`
+			AfterUnknown interface{} `json:"after_unknown"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+// onlyChangesAttributes returns true if the change from before to after
+// changes at least one attribute and the paths of all the attributes it
+// changes match attributes.
+func onlyChangesAttributes(before interface{}, after interface{}, afterUnknown interface{}, attributes *regexp.Regexp) bool {
+	var paths []string
+	changedAttributes("", before, after, &paths)
+	unknownAttributes("", afterUnknown, &paths)
+	if len(paths) == 0 {
+		return false
+	}
+	for _, path := range paths {
+		if !attributes.MatchString(path) {
+			return false
+		}
+	}
+	return true
+}
+
+// changedAttributes appends the paths of the attributes that differ between
+// before and after to paths, ex. "tags.Owner" or "metadata.0.generation".
+func changedAttributes(prefix string, before interface{}, after interface{}, paths *[]string) {
+	switch b := before.(type) {
+	case map[string]interface{}:
+		if a, ok := after.(map[string]interface{}); ok {
+			for key, value := range b {
+				changedAttributes(attributePath(prefix, key), value, a[key], paths)
+			}
+			for key, value := range a {
+				if _, ok := b[key]; !ok {
+					changedAttributes(attributePath(prefix, key), nil, value, paths)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if a, ok := after.([]interface{}); ok && len(a) == len(b) {
+			for i := range b {
+				changedAttributes(attributePath(prefix, strconv.Itoa(i)), b[i], a[i], paths)
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(before, after) {
+		*paths = append(*paths, prefix)
+	}
+}
+
+// unknownAttributes appends the paths of the attributes that are only known
+// after apply, which after_unknown marks with true, to paths.
+func unknownAttributes(prefix string, afterUnknown interface{}, paths *[]string) {
+	switch u := afterUnknown.(type) {
+	case bool:
+		if u {
+			*paths = append(*paths, prefix)
+		}
+	case map[string]interface{}:
+		for key, value := range u {
+			unknownAttributes(attributePath(prefix, key), value, paths)
+		}
+	case []interface{}:
+		for i, value := range u {
+			unknownAttributes(attributePath(prefix, strconv.Itoa(i)), value, paths)
+		}
+	}
+}
+
+func attributePath(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// removeResourceBlocks removes the blocks of the resources at addresses that
+// are updated in-place from the output of terraform plan. A block starts with
+// the "# <address> will be updated in-place" line and ends with the line that
+// closes the resource, which is indented by at most 4 spaces while the lines
+// that close its nested blocks are indented further.
+func removeResourceBlocks(output string, addresses map[string]bool) string {
+	var out strings.Builder
+	out.Grow(len(output))
+	inBlock := false
+	skipBlank := false
+	for output != "" {
+		line, rest, found := strings.Cut(output, "\n")
+		output = rest
+		trimmed := strings.TrimSpace(line)
+		if inBlock {
+			if trimmed == "}" && len(line)-len(strings.TrimLeft(line, " ")) <= 4 {
+				inBlock = false
+				skipBlank = true
+				continue
+			}
+			// The block wasn't closed as expected so the rest of the output
+			// is kept.
+			if !strings.HasPrefix(trimmed, "# ") && !strings.HasPrefix(trimmed, "Plan: ") {
+				continue
+			}
+			inBlock = false
+		}
+		if skipBlank {
+			skipBlank = false
+			if trimmed == "" {
+				continue
+			}
+		}
+		if strings.HasPrefix(trimmed, "# ") && strings.HasSuffix(trimmed, updatedInPlaceSuffix) &&
+			addresses[strings.TrimSuffix(strings.TrimPrefix(trimmed, "# "), updatedInPlaceSuffix)] {
+			inBlock = true
+			continue
+		}
+		out.WriteString(line)
+		if found {
+			out.WriteByte('\n')
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,124 @@
+package runtime_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const suppressiblePlanJSON = `{
+  "resource_changes": [
+    {
+      "address": "aws_instance.web",
+      "change": {
+        "actions": ["update"],
+        "before": {"ami": "ami-1", "tags": {"Owner": "a"}},
+        "after": {"ami": "ami-1", "tags": {"Owner": "b"}},
+        "after_unknown": {}
+      }
+    },
+    {
+      "address": "aws_instance.db",
+      "change": {
+        "actions": ["update"],
+        "before": {"ami": "ami-1", "tags": {"Owner": "a"}},
+        "after": {"ami": "ami-2", "tags": {"Owner": "b"}},
+        "after_unknown": {}
+      }
+    },
+    {
+      "address": "aws_s3_bucket.logs",
+      "change": {
+        "actions": ["create"],
+        "before": null,
+        "after": {"tags": {"Owner": "b"}},
+        "after_unknown": {}
+      }
+    }
+  ]
+}`
+
+const suppressiblePlanOutput = `Terraform will perform the following actions:
+
+  # aws_instance.db will be updated in-place
+  ~ resource "aws_instance" "db" {
+      ~ ami  = "ami-1" -> "ami-2"
+      ~ tags = {
+          ~ "Owner" = "a" -> "b"
+        }
+    }
+
+  # aws_instance.web will be updated in-place
+  ~ resource "aws_instance" "web" {
+      ~ tags = {
+          ~ "Owner" = "a" -> "b"
+        }
+    }
+
+  # aws_s3_bucket.logs will be created
+  + resource "aws_s3_bucket" "logs" {
+      + tags = {
+          + "Owner" = "b"
+        }
+    }
+
+Plan: 1 to add, 2 to change, 0 to destroy.
+`
+
+func TestPlanChangeSuppressor_Suppress(t *testing.T) {
+	path := t.TempDir()
+	ctx := command.ProjectContext{
+		Log:       logging.NewNoopLogger(t),
+		Workspace: "default",
+		SuppressedChanges: []valid.SuppressedChange{
+			{
+				Description:     "tag-only changes",
+				AttributesRegex: regexp.MustCompile(`^tags(\.|$)`),
+			},
+		},
+	}
+	Ok(t, os.WriteFile(filepath.Join(path, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName)), nil, 0600))
+	Ok(t, os.WriteFile(filepath.Join(path, ctx.GetShowResultFileName()), []byte(suppressiblePlanJSON), 0600))
+
+	s := &runtime.PlanChangeSuppressor{}
+	output, suppressed, err := s.Suppress(ctx, suppressiblePlanOutput, path, nil)
+	Ok(t, err)
+	Equals(t, []models.SuppressedChange{
+		{Address: "aws_instance.web", Description: "tag-only changes"},
+	}, suppressed)
+	Equals(t, `Terraform will perform the following actions:
+
+  # aws_instance.db will be updated in-place
+  ~ resource "aws_instance" "db" {
+      ~ ami  = "ami-1" -> "ami-2"
+      ~ tags = {
+          ~ "Owner" = "a" -> "b"
+        }
+    }
+
+  # aws_s3_bucket.logs will be created
+  + resource "aws_s3_bucket" "logs" {
+      + tags = {
+          + "Owner" = "b"
+        }
+    }
+
+Plan: 1 to add, 2 to change, 0 to destroy.
+`, output)
+}
+
+func TestPlanChangeSuppressor_Suppress_NoSuppressedChanges(t *testing.T) {
+	s := &runtime.PlanChangeSuppressor{}
+	output, suppressed, err := s.Suppress(command.ProjectContext{}, suppressiblePlanOutput, t.TempDir(), nil)
+	Ok(t, err)
+	Equals(t, 0, len(suppressed))
+	Equals(t, suppressiblePlanOutput, output)
+}
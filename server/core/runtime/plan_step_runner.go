@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 
 	version "github.com/hashicorp/go-version"
@@ -20,12 +20,6 @@ const (
 	refreshSeparator = "------------------------------------------------------------------------\n"
 )
 
-var (
-	plusDiffRegex  = regexp.MustCompile(`(?m)^ {2}\+`)
-	tildeDiffRegex = regexp.MustCompile(`(?m)^ {2}~`)
-	minusDiffRegex = regexp.MustCompile(`(?m)^ {2}-`)
-)
-
 type planStepRunner struct {
 	TerraformExecutor     TerraformExec
 	DefaultTFDistribution terraform.Distribution
@@ -49,11 +43,14 @@ func (p *planStepRunner) Run(ctx command.ProjectContext, extraArgs []string, pat
 	tfDistribution := p.DefaultTFDistribution
 	tfVersion := p.DefaultTFVersion
 	if ctx.TerraformDistribution != nil {
-		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+		tfDistribution = p.TerraformExecutor.Distributions().NewDistribution(*ctx.TerraformDistribution)
 	}
 	if ctx.TerraformVersion != nil {
 		tfVersion = ctx.TerraformVersion
 	}
+	if err := validateDistributionArgs(tfDistribution, tfVersion, append(append([]string{}, extraArgs...), ctx.EscapedCommentArgs...), envs); err != nil {
+		return "", err
+	}
 
 	planFile := filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName))
 	planCmd := p.buildPlanCmd(ctx, extraArgs, path, tfVersion, planFile)
@@ -65,7 +62,7 @@ func (p *planStepRunner) Run(ctx command.ProjectContext, extraArgs []string, pat
 	if err != nil {
 		return output, err
 	}
-	return p.fmtPlanOutput(output, tfVersion), nil
+	return fmtPlanOutput(output, tfVersion, ctx.Verbose), nil
 }
 
 // isRemoteOpsErr returns true if there was an error caused due to this
@@ -82,6 +79,7 @@ func (p *planStepRunner) isRemoteOpsErr(output string, err error) bool {
 func (p *planStepRunner) remotePlan(ctx command.ProjectContext, extraArgs []string, path string, tfDistribution terraform.Distribution, tfVersion *version.Version, planFile string, envs map[string]string) (string, error) {
 	argList := [][]string{
 		{"plan", "-input=false", "-refresh", "-no-color"},
+		projectVarArgs(ctx),
 		extraArgs,
 		ctx.EscapedCommentArgs,
 	}
@@ -108,7 +106,7 @@ func (p *planStepRunner) remotePlan(ctx command.ProjectContext, extraArgs []stri
 		return output, errors.Wrap(err, "unable to create planfile for remote ops")
 	}
 
-	return p.fmtPlanOutput(output, tfVersion), nil
+	return fmtPlanOutput(output, tfVersion, ctx.Verbose), nil
 }
 
 func (p *planStepRunner) buildPlanCmd(ctx command.ProjectContext, extraArgs []string, path string, tfVersion *version.Version, planFile string) []string {
@@ -129,6 +127,7 @@ func (p *planStepRunner) buildPlanCmd(ctx command.ProjectContext, extraArgs []st
 		// have spaces in its repo owner names.
 		{"plan", "-input=false", "-refresh", "-out", fmt.Sprintf("%q", planFile)},
 		tfVars,
+		projectVarArgs(ctx),
 		extraArgs,
 		ctx.EscapedCommentArgs,
 		envFileArgs,
@@ -168,6 +167,27 @@ func (p *planStepRunner) tfVars(ctx command.ProjectContext, tfVersion *version.V
 	}
 }
 
+// projectVarArgs returns a list of "-var", "key=value" pairs for the
+// project's TerraformVars, sorted by name. Each character is escaped like
+// comment args since the values can come from the pull request.
+func projectVarArgs(ctx command.ProjectContext) []string {
+	names := make([]string, 0, len(ctx.TerraformVars))
+	for name := range ctx.TerraformVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var args []string
+	for _, name := range names {
+		var escaped strings.Builder
+		for _, c := range name + "=" + ctx.TerraformVars[name] {
+			escaped.WriteString("\\" + string(c))
+		}
+		args = append(args, "-var", escaped.String())
+	}
+	return args
+}
+
 func (p *planStepRunner) flatten(slices [][]string) []string {
 	var flattened []string
 	for _, v := range slices {
@@ -176,17 +196,33 @@ func (p *planStepRunner) flatten(slices [][]string) []string {
 	return flattened
 }
 
-// fmtPlanOutput uses regex's to remove any leading whitespace in front of the
-// terraform output so that the diff syntax highlighting works. Example:
+// fmtPlanOutput removes any leading whitespace in front of the terraform
+// output so that the diff syntax highlighting works. Example:
 // "  - aws_security_group_rule.allow_all" =>
 // "- aws_security_group_rule.allow_all"
 // We do it for +, ~ and -.
-// It also removes the "Refreshing..." preamble.
-func (p *planStepRunner) fmtPlanOutput(output string, tfVersion *version.Version) string {
-	output = StripRefreshingFromPlanOutput(output, tfVersion)
-	output = plusDiffRegex.ReplaceAllString(output, "+")
-	output = tildeDiffRegex.ReplaceAllString(output, "~")
-	return minusDiffRegex.ReplaceAllString(output, "-")
+// It also removes the "Refreshing..." preamble unless the user asked for
+// verbose output.
+// Plans can be hundreds of megabytes so the output is formatted in a single
+// pass rather than copied once per replacement.
+func fmtPlanOutput(output string, tfVersion *version.Version, verbose bool) string {
+	if !verbose {
+		output = StripRefreshingFromPlanOutput(output, tfVersion)
+	}
+	var formatted strings.Builder
+	formatted.Grow(len(output))
+	for output != "" {
+		line, rest, found := strings.Cut(output, "\n")
+		if len(line) >= 3 && line[:2] == "  " && (line[2] == '+' || line[2] == '~' || line[2] == '-') {
+			line = line[2:]
+		}
+		formatted.WriteString(line)
+		if found {
+			formatted.WriteByte('\n')
+		}
+		output = rest
+	}
+	return formatted.String()
 }
 
 // runRemotePlan runs a terraform command that utilizes the remote operations
@@ -214,7 +250,8 @@ func (p *planStepRunner) runRemotePlan(
 	// Start the async command execution.
 	ctx.Log.Debug("starting async tf remote operation")
 	_, outCh := p.AsyncTFExec.RunCommandAsync(ctx, filepath.Clean(path), cmdArgs, envs, tfDistribution, tfVersion, ctx.Workspace)
-	var lines []string
+	var output strings.Builder
+	numLines := 0
 	nextLineIsRunURL := false
 	var runURL string
 	var err error
@@ -224,7 +261,11 @@ func (p *planStepRunner) runRemotePlan(
 			err = line.Err
 			break
 		}
-		lines = append(lines, line.Line)
+		if numLines > 0 {
+			output.WriteByte('\n')
+		}
+		output.WriteString(line.Line)
+		numLines++
 
 		// Here we're checking for the run url and updating the status
 		// if found.
@@ -239,29 +280,29 @@ func (p *planStepRunner) runRemotePlan(
 	}
 
 	ctx.Log.Debug("async tf remote operation complete")
-	output := strings.Join(lines, "\n")
 	if err != nil {
 		updateStatusF(models.FailedCommitStatus, runURL)
 	} else {
 		updateStatusF(models.SuccessCommitStatus, runURL)
 	}
-	return output, err
+	return output.String(), err
 }
 
 func StripRefreshingFromPlanOutput(output string, tfVersion *version.Version) string {
 	if tfVersion.GreaterThanOrEqual(version.Must(version.NewVersion("0.14.0"))) {
-		// Plan output contains a lot of "Refreshing..." lines, remove it
-		lines := strings.Split(output, "\n")
-		finalIndex := 0
-		for i, line := range lines {
-			if strings.Contains(line, refreshKeyword) {
-				finalIndex = i
+		// Plan output contains a lot of "Refreshing..." lines, remove
+		// everything up to the last one. The output is sliced rather than
+		// split into lines to avoid copying it.
+		idx := strings.LastIndex(output, refreshKeyword)
+		// If the only refresh line is the first line, it's kept.
+		if idx > -1 && strings.LastIndexByte(output[:idx], '\n') > -1 {
+			nl := strings.IndexByte(output[idx:], '\n')
+			if nl < 0 {
+				output = ""
+			} else {
+				output = output[idx+nl+1:]
 			}
 		}
-
-		if finalIndex != 0 {
-			output = strings.Join(lines[finalIndex+1:], "\n")
-		}
 	} else {
 		// Plan output contains a lot of "Refreshing..." lines followed by a
 		// separator. We want to remove everything before that separator.
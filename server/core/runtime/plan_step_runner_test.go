@@ -227,6 +227,11 @@ Terraform will perform the following actions:
 
 - aws_security_group_rule.allow_all
 `, actOutput)
+
+	// With verbose output the refresh preamble is kept.
+	actOutput, err = s.Run(command.ProjectContext{Workspace: "default", Verbose: true}, nil, "", map[string]string(nil))
+	Ok(t, err)
+	Assert(t, strings.HasPrefix(actOutput, "Refreshing Terraform state in-memory prior to plan..."), "expected the refresh output to be kept, got %q", actOutput)
 }
 
 // Test that even if there's an error, we get the returned output.
@@ -340,6 +345,55 @@ func TestRun_NoOptionalVarsIn012(t *testing.T) {
 
 }
 
+func TestRun_AddsTerraformVars(t *testing.T) {
+	// Test that the project's TerraformVars are set with -var, sorted by name
+	// and escaped.
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	commitStatusUpdater := runtimemocks.NewMockStatusUpdater()
+	asyncTfExec := runtimemocks.NewMockAsyncTFExec()
+	When(terraform.RunCommandWithVersion(
+		Any[command.ProjectContext](),
+		Any[string](),
+		Any[[]string](),
+		Any[map[string]string](),
+		Any[tf.Distribution](),
+		Any[*version.Version](),
+		Any[string]())).ThenReturn("output", nil)
+
+	mockDownloader := mocks.NewMockDownloader()
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mockDownloader)
+	tfVersion, _ := version.NewVersion("1.5.0")
+	s := runtime.NewPlanStepRunner(terraform, tfDistribution, tfVersion, commitStatusUpdater, asyncTfExec)
+	ctx := command.ProjectContext{
+		Workspace:  "default",
+		RepoRelDir: ".",
+		TerraformVars: map[string]string{
+			"pr_number": "12",
+			"branch":    "fix $x",
+		},
+	}
+
+	output, err := s.Run(ctx, []string{"extra", "args"}, "/path", map[string]string(nil))
+	Ok(t, err)
+	Equals(t, "output", output)
+
+	expPlanArgs := []string{
+		"plan",
+		"-input=false",
+		"-refresh",
+		"-out",
+		fmt.Sprintf("%q", "/path/default.tfplan"),
+		"-var",
+		`\b\r\a\n\c\h\=\f\i\x\ \$\x`,
+		"-var",
+		`\p\r\_\n\u\m\b\e\r\=\1\2`,
+		"extra",
+		"args",
+	}
+	terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx, "/path", expPlanArgs, map[string]string(nil), tfDistribution, tfVersion, "default")
+}
+
 // Test plans if using remote ops.
 func TestRun_RemoteOps(t *testing.T) {
 	cases := []struct {
@@ -630,6 +684,93 @@ func TestPlanStepRunner_TestRun_UsesConfiguredDistribution(t *testing.T) {
 
 }
 
+// OpenTofu-only flags and state encryption are passed to OpenTofu and give a
+// clear error with Terraform.
+func TestPlanStepRunner_Run_OpenTofuOnlyArgs(t *testing.T) {
+	RegisterMockTestingT(t)
+
+	cases := []struct {
+		name           string
+		tfDistribution string
+		tfVersion      string
+		commentArgs    []string
+		envs           map[string]string
+		expErr         string
+	}{
+		{
+			name:           "exclude with opentofu",
+			tfDistribution: "opentofu",
+			tfVersion:      "1.9.0",
+			commentArgs:    []string{`\-\e\x\c\l\u\d\e\=\m\o\d\u\l\e\.\a`},
+		},
+		{
+			name:           "exclude with terraform",
+			tfDistribution: "terraform",
+			tfVersion:      "1.9.0",
+			commentArgs:    []string{`\-\e\x\c\l\u\d\e\=\m\o\d\u\l\e\.\a`},
+			expErr:         "-exclude is only supported by OpenTofu but this project runs Terraform; set terraform_distribution: opentofu to use it",
+		},
+		{
+			name:           "exclude with old opentofu",
+			tfDistribution: "opentofu",
+			tfVersion:      "1.8.5",
+			commentArgs:    []string{`\-\e\x\c\l\u\d\e`, `\m\o\d\u\l\e\.\a`},
+			expErr:         "-exclude requires OpenTofu 1.9.0 or later but this project runs 1.8.5",
+		},
+		{
+			name:           "state encryption with opentofu",
+			tfDistribution: "opentofu",
+			tfVersion:      "1.7.0",
+			envs:           map[string]string{"TF_ENCRYPTION": "key_provider {}"},
+		},
+		{
+			name:           "state encryption with terraform",
+			tfDistribution: "terraform",
+			tfVersion:      "1.7.0",
+			envs:           map[string]string{"TF_ENCRYPTION": "key_provider {}"},
+			expErr:         "state encryption (TF_ENCRYPTION) is only supported by OpenTofu but this project runs Terraform; set terraform_distribution: opentofu to use it",
+		},
+		{
+			name:           "similar flag with terraform",
+			tfDistribution: "terraform",
+			tfVersion:      "1.9.0",
+			commentArgs:    []string{`\-\e\x\c\l\u\d\e\d`},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			terraform := tfclientmocks.NewMockClient()
+			When(terraform.RunCommandWithVersion(
+				Any[command.ProjectContext](),
+				Any[string](),
+				Any[[]string](),
+				Any[map[string]string](),
+				Any[tf.Distribution](),
+				Any[*version.Version](),
+				Any[string]())).ThenReturn("output", nil)
+
+			tfVersion, _ := version.NewVersion(c.tfVersion)
+			s := runtime.NewPlanStepRunner(terraform, tf.NewDistributionTerraform(), tfVersion, runtimemocks.NewMockStatusUpdater(), runtimemocks.NewMockAsyncTFExec())
+			ctx := command.ProjectContext{
+				Log:                   logging.NewNoopLogger(t),
+				Workspace:             "default",
+				RepoRelDir:            ".",
+				EscapedCommentArgs:    c.commentArgs,
+				TerraformDistribution: &c.tfDistribution,
+			}
+
+			output, err := s.Run(ctx, nil, "/path", c.envs)
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+			Equals(t, "output", output)
+		})
+	}
+}
+
 type remotePlanMock struct {
 	// LinesToSend will be sent on the channel.
 	LinesToSend string
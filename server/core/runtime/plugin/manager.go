@@ -0,0 +1,157 @@
+package plugin
+
+import (
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// startTimeout is how long plugins have to complete their handshake after
+// they're started.
+const startTimeout = time.Minute
+
+// Manager starts step plugins when they're first used and keeps them
+// running. Plugins that exit are started again the next time they're used.
+type Manager struct {
+	// paths are the paths to the plugin binaries by plugin name.
+	paths     map[string]string
+	logger    logging.SimpleLogging
+	mu        sync.Mutex
+	processes map[string]*process
+}
+
+// process is a running step plugin.
+type process struct {
+	client *goplugin.Client
+	plugin StepPlugin
+}
+
+// NewManager returns a Manager for the plugin binaries at paths, keyed by
+// plugin name.
+func NewManager(paths map[string]string, logger logging.SimpleLogging) *Manager {
+	return &Manager{
+		paths:     paths,
+		logger:    logger,
+		processes: make(map[string]*process),
+	}
+}
+
+// ParsePaths parses plugin paths in the format of --step-plugins, ex.
+// "pulumi=/usr/local/bin/atlantis-pulumi,cdktf=/usr/local/bin/atlantis-cdktf".
+func ParsePaths(value string) (map[string]string, error) {
+	paths := make(map[string]string)
+	if value == "" {
+		return paths, nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		name, path, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || name == "" || path == "" {
+			return nil, errors.Errorf("%q is not in the format <name>=<path>", entry)
+		}
+		if _, ok := paths[name]; ok {
+			return nil, errors.Errorf("plugin %q is configured more than once", name)
+		}
+		paths[name] = path
+	}
+	return paths, nil
+}
+
+// Names returns the names of the configured plugins.
+func (m *Manager) Names() []string {
+	var names []string
+	for name := range m.paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the plugin named name, starting it if it isn't running.
+func (m *Manager) Get(name string) (StepPlugin, error) {
+	path, ok := m.paths[name]
+	if !ok {
+		return nil, errors.Errorf("no step plugin named %q is configured, configured plugins are: %s", name, strings.Join(m.Names(), ", "))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p, ok := m.processes[name]; ok {
+		if !p.client.Exited() {
+			return p.plugin, nil
+		}
+		m.logger.Warn("step plugin %q exited, starting it again", name)
+		p.client.Kill()
+	}
+
+	p, err := m.start(name, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "starting step plugin %q", name)
+	}
+	m.processes[name] = p
+	return p.plugin, nil
+}
+
+// Kill stops every running plugin.
+func (m *Manager) Kill() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, p := range m.processes {
+		p.kill()
+		delete(m.processes, name)
+	}
+}
+
+// start starts the plugin binary at path. The plugin's stderr is logged at
+// the debug level.
+func (m *Manager) start(name string, path string) (*process, error) {
+	client := goplugin.NewClient(&goplugin.ClientConfig{
+		HandshakeConfig:  handshake,
+		VersionedPlugins: versionedPlugins(nil),
+		Cmd:              exec.Command(path), // #nosec
+		AllowedProtocols: []goplugin.Protocol{goplugin.ProtocolNetRPC},
+		AutoMTLS:         true,
+		StartTimeout:     startTimeout,
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Name:        "plugin." + name,
+			Level:       hclog.Debug,
+			Output:      &logWriter{logger: m.logger},
+			DisableTime: true,
+		}),
+	})
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, err
+	}
+	raw, err := rpcClient.Dispense(stepPluginName)
+	if err != nil {
+		client.Kill()
+		return nil, err
+	}
+	m.logger.Info("started step plugin %q from %s using protocol version %d", name, path, client.NegotiatedVersion())
+	return &process{client: client, plugin: raw.(StepPlugin)}, nil
+}
+
+// kill kills the plugin.
+func (p *process) kill() {
+	p.client.Kill()
+}
+
+// logWriter logs the lines go-plugin logs at the debug level.
+type logWriter struct {
+	logger logging.SimpleLogging
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		w.logger.Debug("%s", line)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,100 @@
+// Package plugin runs step plugins: external binaries that implement custom
+// workflow step types, ex. to run pulumi or cdktf, without forking Atlantis.
+//
+// Step plugins are served with HashiCorp's go-plugin over its net/rpc
+// protocol. go-plugin does the handshake, negotiates the protocol version
+// and secures the connection with mTLS. Plugins written in Go only need to
+// implement StepPlugin and call Serve from their main function.
+package plugin
+
+import (
+	goplugin "github.com/hashicorp/go-plugin"
+)
+
+// ProtocolVersion is the version of the protocol between Atlantis and step
+// plugins. It's increased on incompatible changes.
+const ProtocolVersion = 1
+
+// MagicCookieKey and MagicCookieValue are set in the environment of step
+// plugins.
+const (
+	MagicCookieKey   = "ATLANTIS_STEP_PLUGIN_MAGIC_COOKIE"
+	MagicCookieValue = "d3a1c6d8e0b84a38a0d8bd6e4ff3b5f7"
+)
+
+// handshake is the go-plugin handshake of step plugins. The protocol version
+// is negotiated from the versions in versionedPlugins.
+var handshake = goplugin.HandshakeConfig{
+	MagicCookieKey:   MagicCookieKey,
+	MagicCookieValue: MagicCookieValue,
+}
+
+// stepPluginName is the name step plugins are dispensed under.
+const stepPluginName = "step"
+
+// versionedPlugins are the plugins served by step plugins by protocol
+// version. impl is nil on the Atlantis side.
+func versionedPlugins(impl StepPlugin) map[int]goplugin.PluginSet {
+	return map[int]goplugin.PluginSet{
+		ProtocolVersion: {stepPluginName: &StepRPCPlugin{Impl: impl}},
+	}
+}
+
+// StepPlugin is implemented by step plugins.
+type StepPlugin interface {
+	// Run runs the step described by req. Lines passed to output are
+	// streamed to the project's job output as they're produced. The
+	// returned string is the step's output that's commented on the pull
+	// request.
+	Run(req StepRequest, output func(line string)) (string, error)
+}
+
+// StepRequest is what a step plugin is called with.
+type StepRequest struct {
+	// ExtraArgs are the step's extra_args.
+	ExtraArgs []string
+	// Path is the absolute path to the project's dir.
+	Path string
+	// Envs are the environment variables set by earlier env and multienv
+	// steps.
+	Envs map[string]string
+	// Project is the project the step is run for.
+	Project Project
+}
+
+// Project describes the project a step plugin is run for.
+type Project struct {
+	// CommandName is the command being run, ex. "plan" or "apply".
+	CommandName        string
+	BaseRepoFullName   string
+	HeadRepoFullName   string
+	PullNum            int
+	PullAuthor         string
+	PullURL            string
+	BaseBranch         string
+	HeadBranch         string
+	HeadCommit         string
+	RepoRelDir         string
+	Workspace          string
+	ProjectName        string
+	User               string
+	TerraformVersion   string
+	EscapedCommentArgs []string
+	Verbose            bool
+}
+
+// RunArgs are the arguments of Plugin.Run.
+type RunArgs struct {
+	Request StepRequest
+	// OutputID is the go-plugin broker ID of the connection that output
+	// lines are sent to.
+	OutputID uint32
+}
+
+// RunReply is the reply to Plugin.Run.
+type RunReply struct {
+	Output string
+	// Error is the error returned by the plugin, if any. It's in the reply
+	// rather than returned so that the output is kept.
+	Error string
+}
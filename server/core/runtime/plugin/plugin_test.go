@@ -0,0 +1,106 @@
+package plugin_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/runatlantis/atlantis/server/core/runtime/plugin"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// echoPlugin outputs its extra args one per line.
+type echoPlugin struct{}
+
+func (echoPlugin) Run(req plugin.StepRequest, output func(line string)) (string, error) {
+	if len(req.ExtraArgs) > 0 && req.ExtraArgs[0] == "fail" {
+		return "", errors.New("failed on purpose")
+	}
+	for _, arg := range req.ExtraArgs {
+		output(arg)
+	}
+	return req.Project.ProjectName + ": " + strings.Join(req.ExtraArgs, " "), nil
+}
+
+// TestMain runs the test binary as the echo plugin when it's started by a
+// plugin.Manager.
+func TestMain(m *testing.M) {
+	if os.Getenv(plugin.MagicCookieKey) == plugin.MagicCookieValue {
+		plugin.Serve(echoPlugin{})
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func TestStepRPCPlugin_Run(t *testing.T) {
+	client, _ := goplugin.TestPluginRPCConn(t, map[string]goplugin.Plugin{
+		"step": &plugin.StepRPCPlugin{Impl: echoPlugin{}},
+	}, nil)
+	defer client.Close() // nolint: errcheck
+	raw, err := client.Dispense("step")
+	Ok(t, err)
+	p := raw.(plugin.StepPlugin)
+
+	var lines []string
+	out, err := p.Run(plugin.StepRequest{
+		ExtraArgs: []string{"preview", "--diff"},
+		Project:   plugin.Project{ProjectName: "network"},
+	}, func(line string) {
+		lines = append(lines, line)
+	})
+	Ok(t, err)
+	Equals(t, "network: preview --diff", out)
+	Equals(t, []string{"preview", "--diff"}, lines)
+
+	_, err = p.Run(plugin.StepRequest{ExtraArgs: []string{"fail"}}, func(string) {})
+	ErrEquals(t, "failed on purpose", err)
+}
+
+func TestManager_Get(t *testing.T) {
+	executable, err := os.Executable()
+	Ok(t, err)
+	manager := plugin.NewManager(map[string]string{"echo": executable}, logging.NewNoopLogger(t))
+	defer manager.Kill()
+
+	p, err := manager.Get("echo")
+	Ok(t, err)
+	var lines []string
+	out, err := p.Run(plugin.StepRequest{ExtraArgs: []string{"up"}, Project: plugin.Project{ProjectName: "app"}}, func(line string) {
+		lines = append(lines, line)
+	})
+	Ok(t, err)
+	Equals(t, "app: up", out)
+	Equals(t, []string{"up"}, lines)
+
+	_, err = manager.Get("pulumi")
+	ErrEquals(t, `no step plugin named "pulumi" is configured, configured plugins are: echo`, err)
+}
+
+func TestManager_GetInvalidHandshake(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "plugin.sh")
+	Ok(t, os.WriteFile(script, []byte("#!/bin/sh\necho '1|2|unix|/tmp/plugin.sock|netrpc'\nexec sleep 5\n"), 0700)) // nolint: gosec
+	manager := plugin.NewManager(map[string]string{"old": script}, logging.NewNoopLogger(t))
+	defer manager.Kill()
+
+	_, err := manager.Get("old")
+	ErrContains(t, `starting step plugin "old": Incompatible API version with plugin. Plugin version: 2, Client versions: [1]`, err)
+}
+
+func TestParsePaths(t *testing.T) {
+	paths, err := plugin.ParsePaths("pulumi=/bin/atlantis-pulumi, cdktf=/bin/atlantis-cdktf")
+	Ok(t, err)
+	Equals(t, map[string]string{"pulumi": "/bin/atlantis-pulumi", "cdktf": "/bin/atlantis-cdktf"}, paths)
+
+	paths, err = plugin.ParsePaths("")
+	Ok(t, err)
+	Equals(t, 0, len(paths))
+
+	_, err = plugin.ParsePaths("pulumi")
+	ErrEquals(t, `"pulumi" is not in the format <name>=<path>`, err)
+	_, err = plugin.ParsePaths("pulumi=/a,pulumi=/b")
+	ErrEquals(t, `plugin "pulumi" is configured more than once`, err)
+}
@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// handshakeTimeout is how long plugins have to write their handshake line
+// after they're started.
+const handshakeTimeout = time.Minute
+
+// Handshake is what Atlantis and a kind of plugin that isn't served with
+// go-plugin, ex. VCS adapters, agree on before talking to each other.
+type Handshake struct {
+	// ProtocolVersion is the version of the protocol the plugin has to
+	// speak.
+	ProtocolVersion int
+	// MagicCookieKey and MagicCookieValue are set in the environment of the
+	// plugin. They're not a security measure, they only stop plugin binaries
+	// from being run by hand.
+	MagicCookieKey   string
+	MagicCookieValue string
+}
+
+// Process is a running plugin process.
+type Process struct {
+	// Network and Address are where the plugin is listening, from its
+	// handshake.
+	Network string
+	Address string
+	cmd     *exec.Cmd
+	// exited is closed once the process exited.
+	exited chan struct{}
+}
+
+// StartProcess starts the plugin binary at path and waits for its handshake.
+// name is only used in log messages. The plugin's stderr and the lines it
+// writes to stdout after its handshake are logged at the debug level.
+func StartProcess(logger logging.SimpleLogging, name string, path string, handshake Handshake) (*Process, error) {
+	cmd := exec.Command(path) // #nosec
+	cmd.Env = append(os.Environ(), handshake.MagicCookieKey+"="+handshake.MagicCookieValue)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// The pipes have to be read until the plugin closes them before waiting
+	// for it to exit.
+	var readers sync.WaitGroup
+	readers.Add(2)
+	handshakeLine := make(chan string, 1)
+	go func() {
+		defer readers.Done()
+		scanner := bufio.NewScanner(stdout)
+		if scanner.Scan() {
+			handshakeLine <- scanner.Text()
+		}
+		// Keep reading so the plugin doesn't block on writes to stdout.
+		for scanner.Scan() {
+			logger.Debug("plugin %q: %s", name, scanner.Text())
+		}
+	}()
+	go func() {
+		defer readers.Done()
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			logger.Debug("plugin %q: %s", name, scanner.Text())
+		}
+	}()
+	exited := make(chan struct{})
+	go func() {
+		readers.Wait()
+		err := cmd.Wait()
+		logger.Debug("plugin %q exited: %v", name, err)
+		close(exited)
+	}()
+	p := &Process{cmd: cmd, exited: exited}
+
+	var line string
+	select {
+	case line = <-handshakeLine:
+	case <-exited:
+		return nil, errors.New("plugin exited before writing its handshake")
+	case <-time.After(handshakeTimeout):
+		p.Kill()
+		return nil, errors.Errorf("plugin didn't write its handshake within %s", handshakeTimeout)
+	}
+
+	if p.Network, p.Address, err = parseHandshake(line, handshake.ProtocolVersion); err != nil {
+		p.Kill()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Exited is closed once the process exited.
+func (p *Process) Exited() <-chan struct{} {
+	return p.exited
+}
+
+// Kill kills the process and waits until it exited.
+func (p *Process) Kill() {
+	p.cmd.Process.Kill() // nolint: errcheck
+	<-p.exited
+}
+
+// parseHandshake parses the handshake line written by plugins, ex.
+// "1|unix|/tmp/plugin/plugin.sock".
+func parseHandshake(line string, protocolVersion int) (network string, address string, err error) {
+	parts := strings.SplitN(strings.TrimSpace(line), "|", 3)
+	if len(parts) != 3 {
+		return "", "", errors.Errorf("invalid handshake %q, expected <protocol version>|<network>|<address>", line)
+	}
+	version, err := strconv.Atoi(parts[0])
+	if err != nil || version != protocolVersion {
+		return "", "", errors.Errorf("plugin uses protocol version %q but Atlantis uses version %d", parts[0], protocolVersion)
+	}
+	return parts[1], parts[2], nil
+}
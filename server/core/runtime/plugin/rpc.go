@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"net/rpc"
+
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/pkg/errors"
+)
+
+// StepRPCPlugin is the go-plugin plugin of step plugins. Impl is only set in
+// the plugin binary.
+type StepRPCPlugin struct {
+	Impl StepPlugin
+}
+
+// Server returns the RPC service served by the plugin binary.
+func (p *StepRPCPlugin) Server(broker *goplugin.MuxBroker) (interface{}, error) {
+	return &rpcServer{impl: p.Impl, broker: broker}, nil
+}
+
+// Client returns the StepPlugin that calls the plugin binary over client.
+func (p *StepRPCPlugin) Client(broker *goplugin.MuxBroker, client *rpc.Client) (interface{}, error) {
+	return &rpcClient{client: client, broker: broker}, nil
+}
+
+// rpcClient calls a step plugin over RPC. It implements StepPlugin.
+type rpcClient struct {
+	client *rpc.Client
+	broker *goplugin.MuxBroker
+}
+
+// Run runs the step on the plugin. Since net/rpc can't stream, the plugin
+// sends the output lines to an outputServer that's served on a connection of
+// the go-plugin broker for the duration of the run.
+func (c *rpcClient) Run(req StepRequest, output func(line string)) (string, error) {
+	id := c.broker.NextId()
+	go c.broker.AcceptAndServe(id, &outputServer{output: output})
+
+	var reply RunReply
+	if err := c.client.Call("Plugin.Run", RunArgs{Request: req, OutputID: id}, &reply); err != nil {
+		return "", errors.Wrap(err, "running step")
+	}
+	if reply.Error != "" {
+		return reply.Output, errors.New(reply.Error)
+	}
+	return reply.Output, nil
+}
+
+// rpcServer is the RPC service of step plugins.
+type rpcServer struct {
+	impl   StepPlugin
+	broker *goplugin.MuxBroker
+}
+
+// Run runs the step described by args.Request and sends its output lines to
+// the outputServer at args.OutputID.
+func (s *rpcServer) Run(args RunArgs, reply *RunReply) error {
+	conn, err := s.broker.Dial(args.OutputID)
+	if err != nil {
+		return errors.Wrap(err, "connecting to output")
+	}
+	outputClient := rpc.NewClient(conn)
+	defer outputClient.Close() // nolint: errcheck
+
+	output, err := s.impl.Run(args.Request, func(line string) {
+		outputClient.Call("Plugin.Line", line, &struct{}{}) // nolint: errcheck
+	})
+	reply.Output = output
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	return nil
+}
+
+// outputServer receives the output lines of a run.
+type outputServer struct {
+	output func(line string)
+}
+
+// Line passes line to the run's output func.
+func (s *outputServer) Line(line string, _ *struct{}) error {
+	s.output(line)
+	return nil
+}
@@ -0,0 +1,15 @@
+package plugin
+
+import (
+	goplugin "github.com/hashicorp/go-plugin"
+)
+
+// Serve serves impl to Atlantis. It's called from the main function of step
+// plugins and never returns. It exits if the plugin wasn't started by
+// Atlantis.
+func Serve(impl StepPlugin) {
+	goplugin.Serve(&goplugin.ServeConfig{
+		HandshakeConfig:  handshake,
+		VersionedPlugins: versionedPlugins(impl),
+	})
+}
@@ -0,0 +1,65 @@
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/core/runtime/plugin"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/jobs"
+)
+
+// StepPlugins finds the step plugins configured with --step-plugins.
+type StepPlugins interface {
+	// Get returns the plugin named name.
+	Get(name string) (plugin.StepPlugin, error)
+}
+
+// PluginStepRunner runs plugin steps, which are implemented by external
+// binaries, ex. to run pulumi or cdktf.
+type PluginStepRunner struct {
+	Plugins                 StepPlugins
+	ProjectCmdOutputHandler jobs.ProjectCommandOutputHandler
+}
+
+func (r *PluginStepRunner) Run(ctx command.ProjectContext, pluginName string, extraArgs []string, path string, envs map[string]string) (string, error) {
+	p, err := r.Plugins.Get(pluginName)
+	if err != nil {
+		return "", err
+	}
+
+	req := plugin.StepRequest{
+		ExtraArgs: extraArgs,
+		Path:      path,
+		Envs:      envs,
+		Project: plugin.Project{
+			CommandName:        ctx.CommandName.String(),
+			BaseRepoFullName:   ctx.BaseRepo.FullName,
+			HeadRepoFullName:   ctx.HeadRepo.FullName,
+			PullNum:            ctx.Pull.Num,
+			PullAuthor:         ctx.Pull.Author,
+			PullURL:            ctx.Pull.URL,
+			BaseBranch:         ctx.Pull.BaseBranch,
+			HeadBranch:         ctx.Pull.HeadBranch,
+			HeadCommit:         ctx.Pull.HeadCommit,
+			RepoRelDir:         ctx.RepoRelDir,
+			Workspace:          ctx.Workspace,
+			ProjectName:        ctx.ProjectName,
+			User:               ctx.User.Username,
+			EscapedCommentArgs: ctx.EscapedCommentArgs,
+			Verbose:            ctx.Verbose,
+		},
+	}
+	if ctx.TerraformVersion != nil {
+		req.Project.TerraformVersion = ctx.TerraformVersion.String()
+	}
+
+	out, err := p.Run(req, func(line string) {
+		r.ProjectCmdOutputHandler.Send(ctx, line, false)
+	})
+	if err != nil {
+		err = fmt.Errorf("%s: running step plugin %q in %q: \n%s", err, pluginName, path, out)
+		ctx.Log.Debug("error: %s", err)
+		return "", err
+	}
+	return out, nil
+}
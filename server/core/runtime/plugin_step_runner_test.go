@@ -0,0 +1,76 @@
+package runtime_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/core/runtime/plugin"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	jobmocks "github.com/runatlantis/atlantis/server/jobs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeStepPlugins are step plugins that record their requests.
+type fakeStepPlugins struct {
+	requests []plugin.StepRequest
+}
+
+func (f *fakeStepPlugins) Get(name string) (plugin.StepPlugin, error) {
+	if name != "pulumi" {
+		return nil, errors.New("not configured")
+	}
+	return f, nil
+}
+
+func (f *fakeStepPlugins) Run(req plugin.StepRequest, output func(line string)) (string, error) {
+	f.requests = append(f.requests, req)
+	output("Previewing update (dev)")
+	return "Resources: 2 to create", nil
+}
+
+func TestPluginStepRunner_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	plugins := &fakeStepPlugins{}
+	projectCmdOutputHandler := jobmocks.NewMockProjectCommandOutputHandler()
+	runner := runtime.PluginStepRunner{
+		Plugins:                 plugins,
+		ProjectCmdOutputHandler: projectCmdOutputHandler,
+	}
+	ctx := command.ProjectContext{
+		CommandName: command.Plan,
+		BaseRepo:    models.Repo{FullName: "owner/repo"},
+		Pull:        models.PullRequest{Num: 2, HeadCommit: "abc"},
+		User:        models.User{Username: "acme-user"},
+		Log:         logging.NewNoopLogger(t),
+		Workspace:   "dev",
+		RepoRelDir:  "app",
+		ProjectName: "app",
+	}
+
+	out, err := runner.Run(ctx, "pulumi", []string{"preview"}, "/repo/app", map[string]string{"STACK": "dev"})
+	Ok(t, err)
+	Equals(t, "Resources: 2 to create", out)
+	Equals(t, []plugin.StepRequest{{
+		ExtraArgs: []string{"preview"},
+		Path:      "/repo/app",
+		Envs:      map[string]string{"STACK": "dev"},
+		Project: plugin.Project{
+			CommandName:      "plan",
+			BaseRepoFullName: "owner/repo",
+			PullNum:          2,
+			HeadCommit:       "abc",
+			RepoRelDir:       "app",
+			Workspace:        "dev",
+			ProjectName:      "app",
+			User:             "acme-user",
+		},
+	}}, plugins.requests)
+	projectCmdOutputHandler.VerifyWasCalledOnce().Send(ctx, "Previewing update (dev)", false)
+
+	_, err = runner.Run(ctx, "cdktf", nil, "/repo/app", nil)
+	ErrEquals(t, "not configured", err)
+}
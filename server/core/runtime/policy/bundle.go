@@ -0,0 +1,219 @@
+package policy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// bundleSignaturesFile is the file of an OPA bundle that holds its
+// signatures, see https://www.openpolicyagent.org/docs/latest/management-bundles/#signing.
+const bundleSignaturesFile = ".signatures.json"
+
+// BundleSourceResolver resolves a bundle policy set by downloading the OPA
+// bundle at its path, ex. https://example.com/bundle.tar.gz, and verifying its
+// signature if the policy set has a verification key. Conftest evaluates the
+// Rego policies of the bundle like those of a local policy set.
+type BundleSourceResolver struct {
+	// Downloader downloads and extracts the bundles.
+	Downloader Downloader
+	// BundleDir is the dir the bundles are downloaded to, with a dir per
+	// policy set.
+	BundleDir string
+
+	// mutex prevents two policy checks from downloading the same bundle at
+	// the same time.
+	mutex sync.Mutex
+}
+
+var unsafeDirChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+func (b *BundleSourceResolver) Resolve(policySet valid.PolicySet) (string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// The bundle is downloaded on each policy check so changes to it are
+	// picked up right away.
+	dir := filepath.Join(b.BundleDir, unsafeDirChars.ReplaceAllString(policySet.Name, "_"))
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("deleting previous bundle: %w", err)
+	}
+	if err := b.Downloader.GetAny(dir, policySet.Path); err != nil {
+		return "", fmt.Errorf("downloading bundle %q: %w", policySet.Path, err)
+	}
+	if policySet.VerificationKey == "" {
+		return dir, nil
+	}
+
+	key, err := os.ReadFile(policySet.VerificationKey)
+	if err != nil {
+		return "", fmt.Errorf("reading verification key: %w", err)
+	}
+	if err := VerifyBundle(dir, key); err != nil {
+		// Don't leave an unverified bundle around.
+		os.RemoveAll(dir) // nolint: errcheck
+		return "", fmt.Errorf("verifying bundle %q: %w", policySet.Path, err)
+	}
+	return dir, nil
+}
+
+// bundleSignatures is the content of the signatures file of a bundle.
+type bundleSignatures struct {
+	Signatures []string `json:"signatures"`
+}
+
+// bundleFile is a file listed in the signature of a bundle.
+type bundleFile struct {
+	Name      string `json:"name"`
+	Hash      string `json:"hash"`
+	Algorithm string `json:"algorithm"`
+}
+
+// bundleClaims are the claims of the signature of a bundle.
+type bundleClaims struct {
+	Files []bundleFile `json:"files"`
+	jwt.RegisteredClaims
+}
+
+// VerifyBundle verifies the signature of the OPA bundle extracted to dir with
+// the PEM encoded public key. The signature must be valid and list every file
+// of the bundle with its hash, like the signatures made by opa sign.
+func VerifyBundle(dir string, key []byte) error {
+	publicKey, err := parsePublicKey(key)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, bundleSignaturesFile)) // nolint: gosec
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("bundle isn't signed, it has no %s file", bundleSignaturesFile)
+		}
+		return err
+	}
+	var signatures bundleSignatures
+	if err := json.Unmarshal(content, &signatures); err != nil {
+		return fmt.Errorf("parsing %s: %w", bundleSignaturesFile, err)
+	}
+	if len(signatures.Signatures) != 1 {
+		return fmt.Errorf("bundle must have exactly one signature, got %d", len(signatures.Signatures))
+	}
+
+	var claims bundleClaims
+	_, err = jwt.ParseWithClaims(signatures.Signatures[0], &claims, func(*jwt.Token) (any, error) {
+		return publicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}))
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	signed := make(map[string]bundleFile)
+	for _, file := range claims.Files {
+		signed[strings.TrimPrefix(filepath.ToSlash(file.Name), "/")] = file
+	}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		if name == bundleSignaturesFile {
+			return nil
+		}
+		file, ok := signed[name]
+		if !ok {
+			return fmt.Errorf("file %s isn't signed", name)
+		}
+		delete(signed, name)
+		return verifyBundleFile(path, file)
+	})
+	if err != nil {
+		return err
+	}
+	if len(signed) > 0 {
+		missing := slices.Sorted(maps.Keys(signed))
+		return fmt.Errorf("signed file %s is missing", missing[0])
+	}
+	return nil
+}
+
+func verifyBundleFile(path string, file bundleFile) error {
+	var h hash.Hash
+	switch strings.ToUpper(file.Algorithm) {
+	case "", "SHA-256":
+		h = sha256.New()
+	case "SHA-384":
+		h = sha512.New384()
+	case "SHA-512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("file %s: unsupported hash algorithm %q", file.Name, file.Algorithm)
+	}
+
+	content, err := os.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return err
+	}
+	// OPA hashes data files in their canonical JSON encoding.
+	if filepath.Ext(path) == ".json" {
+		if content, err = canonicalJSON(content); err != nil {
+			return fmt.Errorf("file %s: %w", file.Name, err)
+		}
+	}
+	h.Write(content)
+	if hex.EncodeToString(h.Sum(nil)) != strings.ToLower(file.Hash) {
+		return fmt.Errorf("file %s doesn't match its signed hash", file.Name)
+	}
+	return nil
+}
+
+// canonicalJSON returns the JSON encoding of content with sorted keys and no
+// whitespace.
+func canonicalJSON(content []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func parsePublicKey(key []byte) (any, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, errors.New("verification key isn't PEM encoded")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing verification key: %w", err)
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,181 @@
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	conftest_mocks "github.com/runatlantis/atlantis/server/core/runtime/policy/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+var bundleFiles = map[string]string{
+	".manifest":          `{"revision": "1", "roots": ["main"]}`,
+	"main/policy.rego":   "package main\n\ndeny[msg] {\n  msg := \"denied\"\n}\n",
+	"main/data.json":     `{"b": 1, "a": "<tag>"}`,
+	"main/lib/util.rego": "package main.lib\n",
+}
+
+// writeBundle writes files to dir and signs them with key. The hashes of
+// the files in signed override their real hashes.
+func writeBundle(t *testing.T, dir string, files map[string]string, key *ecdsa.PrivateKey, signed map[string]string) {
+	t.Helper()
+	var claimFiles []bundleFile
+	for name, content := range files {
+		Ok(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0700))
+		Ok(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+		hashed := []byte(content)
+		if filepath.Ext(name) == ".json" {
+			var err error
+			hashed, err = canonicalJSON(hashed)
+			Ok(t, err)
+		}
+		sum := sha256.Sum256(hashed)
+		claimFiles = append(claimFiles, bundleFile{Name: name, Hash: hex.EncodeToString(sum[:]), Algorithm: "SHA-256"})
+	}
+	for name, hash := range signed {
+		claimFiles = append(claimFiles, bundleFile{Name: name, Hash: hash, Algorithm: "SHA-256"})
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, bundleClaims{Files: claimFiles}).SignedString(key)
+	Ok(t, err)
+	signatures, err := json.Marshal(bundleSignatures{Signatures: []string{token}})
+	Ok(t, err)
+	Ok(t, os.WriteFile(filepath.Join(dir, bundleSignaturesFile), signatures, 0600))
+}
+
+func newBundleKey(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Ok(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	Ok(t, err)
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestVerifyBundle(t *testing.T) {
+	key, publicKey := newBundleKey(t)
+	otherKey, _ := newBundleKey(t)
+
+	cases := []struct {
+		description string
+		write       func(t *testing.T, dir string)
+		expErr      string
+	}{
+		{
+			description: "valid signature",
+			write: func(t *testing.T, dir string) {
+				writeBundle(t, dir, bundleFiles, key, nil)
+			},
+		},
+		{
+			description: "not signed",
+			write: func(t *testing.T, dir string) {
+				Ok(t, os.WriteFile(filepath.Join(dir, "policy.rego"), []byte("package main"), 0600))
+			},
+			expErr: "bundle isn't signed, it has no .signatures.json file",
+		},
+		{
+			description: "signed with another key",
+			write: func(t *testing.T, dir string) {
+				writeBundle(t, dir, bundleFiles, otherKey, nil)
+			},
+			expErr: "invalid signature: token signature is invalid: crypto/ecdsa: verification error",
+		},
+		{
+			description: "file changed after signing",
+			write: func(t *testing.T, dir string) {
+				writeBundle(t, dir, bundleFiles, key, nil)
+				Ok(t, os.WriteFile(filepath.Join(dir, "main/policy.rego"), []byte("package main\n"), 0600))
+			},
+			expErr: "file main/policy.rego doesn't match its signed hash",
+		},
+		{
+			description: "file added after signing",
+			write: func(t *testing.T, dir string) {
+				writeBundle(t, dir, bundleFiles, key, nil)
+				Ok(t, os.WriteFile(filepath.Join(dir, "main/extra.rego"), []byte("package main\n"), 0600))
+			},
+			expErr: "file main/extra.rego isn't signed",
+		},
+		{
+			description: "signed file missing",
+			write: func(t *testing.T, dir string) {
+				writeBundle(t, dir, bundleFiles, key, map[string]string{"main/removed.rego": "00"})
+			},
+			expErr: "signed file main/removed.rego is missing",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			dir := t.TempDir()
+			c.write(t, dir)
+			err := VerifyBundle(dir, publicKey)
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+
+	ErrEquals(t, "verification key isn't PEM encoded", VerifyBundle(t.TempDir(), []byte("not a key")))
+}
+
+func TestBundleSourceResolver_Resolve(t *testing.T) {
+	RegisterMockTestingT(t)
+	key, publicKey := newBundleKey(t)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	Ok(t, os.WriteFile(keyFile, publicKey, 0600))
+
+	bundleDir := t.TempDir()
+	downloader := conftest_mocks.NewMockDownloader()
+	resolver := &BundleSourceResolver{Downloader: downloader, BundleDir: bundleDir}
+	policySet := valid.PolicySet{
+		Name:            "security/iam",
+		Source:          valid.BundlePolicySet,
+		Path:            "https://example.com/bundle.tar.gz",
+		VerificationKey: keyFile,
+	}
+	expDir := filepath.Join(bundleDir, "security_iam")
+
+	t.Run("verified", func(t *testing.T) {
+		When(downloader.GetAny(expDir, policySet.Path)).Then(func([]Param) ReturnValues {
+			Ok(t, os.MkdirAll(expDir, 0700))
+			writeBundle(t, expDir, bundleFiles, key, nil)
+			return []ReturnValue{nil}
+		})
+		dir, err := resolver.Resolve(policySet)
+		Ok(t, err)
+		Equals(t, expDir, dir)
+	})
+
+	t.Run("verification failed", func(t *testing.T) {
+		When(downloader.GetAny(expDir, policySet.Path)).Then(func([]Param) ReturnValues {
+			Ok(t, os.MkdirAll(expDir, 0700))
+			Ok(t, os.WriteFile(filepath.Join(expDir, "policy.rego"), []byte("package main"), 0600))
+			return []ReturnValue{nil}
+		})
+		_, err := resolver.Resolve(policySet)
+		ErrEquals(t, `verifying bundle "https://example.com/bundle.tar.gz": bundle isn't signed, it has no .signatures.json file`, err)
+		_, err = os.Stat(expDir)
+		Assert(t, os.IsNotExist(err), "expected the unverified bundle to be deleted")
+	})
+
+	t.Run("download failed", func(t *testing.T) {
+		When(downloader.GetAny(expDir, policySet.Path)).ThenReturn(errors.New("404 Not Found"))
+		_, err := resolver.Resolve(policySet)
+		ErrEquals(t, `downloading bundle "https://example.com/bundle.tar.gz": 404 Not Found`, err)
+	})
+}
@@ -93,13 +93,16 @@ func (p *LocalSourceResolver) Resolve(policySet valid.PolicySet) (string, error)
 
 // SourceResolverProxy proxies to underlying source resolvers dynamically
 type SourceResolverProxy struct {
-	localSourceResolver SourceResolver
+	localSourceResolver  SourceResolver
+	bundleSourceResolver SourceResolver
 }
 
 func (p *SourceResolverProxy) Resolve(policySet valid.PolicySet) (string, error) {
 	switch source := policySet.Source; source {
 	case valid.LocalPolicySet:
 		return p.localSourceResolver.Resolve(policySet)
+	case valid.BundlePolicySet:
+		return p.bundleSourceResolver.Resolve(policySet)
 	default:
 		return "", fmt.Errorf("unable to resolve policy set source %s", source)
 	}
@@ -157,7 +160,10 @@ type ConfTestExecutorWorkflow struct {
 	Exec                   runtime_models.Exec
 }
 
-func NewConfTestExecutorWorkflow(log logging.SimpleLogging, versionRootDir string, conftestDownloder Downloader) *ConfTestExecutorWorkflow {
+// NewConfTestExecutorWorkflow returns a workflow that downloads conftest
+// versions to versionRootDir and the bundles of bundle policy sets to
+// bundleDir.
+func NewConfTestExecutorWorkflow(log logging.SimpleLogging, versionRootDir string, bundleDir string, conftestDownloder Downloader) *ConfTestExecutorWorkflow {
 	downloader := ConfTestVersionDownloader{
 		downloader: conftestDownloder,
 	}
@@ -179,6 +185,10 @@ func NewConfTestExecutorWorkflow(log logging.SimpleLogging, versionRootDir strin
 		DefaultConftestVersion: version,
 		SourceResolver: &SourceResolverProxy{
 			localSourceResolver: &LocalSourceResolver{},
+			bundleSourceResolver: &BundleSourceResolver{
+				Downloader: conftestDownloder,
+				BundleDir:  bundleDir,
+			},
 		},
 		Exec: runtime_models.LocalExec{},
 	}
@@ -192,6 +202,12 @@ func (c *ConfTestExecutorWorkflow) Run(ctx command.ProjectContext, executablePat
 	var combinedErr error
 
 	for _, policySet := range ctx.PolicySets.PolicySets {
+		// Conftest is run for each policy set, so the remaining ones are
+		// skipped once the policy check times out.
+		if ctx.TimeoutCtx != nil && ctx.TimeoutCtx.Err() != nil {
+			combinedErr = errors.Join(combinedErr, fmt.Errorf("policy_set: %s: not run: %w", policySet.Name, ctx.TimeoutCtx.Err()))
+			continue
+		}
 		path, resolveErr := c.SourceResolver.Resolve(policySet)
 
 		// Let's not fail the whole step because of a single failure. Log and fail silently
@@ -223,12 +239,17 @@ func (c *ConfTestExecutorWorkflow) Run(ctx command.ProjectContext, executablePat
 		if hasFailures(cmdOutput) {
 			passed = false
 		}
+		failures, isJSON := parseConftestFailures(cmdOutput)
+		if isJSON {
+			passed = len(failures) == 0
+		}
 
 		policySetResults = append(policySetResults, models.PolicySetResult{
 			PolicySetName: policySet.Name,
 			PolicyOutput:  cmdOutput,
 			Passed:        passed,
-			ReqApprovals:  policySet.ApproveCount,
+			ReqApprovals:  requiredApprovals(policySet.ApproveCount, failures),
+			Failures:      failures,
 		})
 	}
 
@@ -316,6 +337,39 @@ func isValidConftestOutput(output string) bool {
 	return false
 }
 
+// conftestResult is the result of a file in the JSON output of conftest, i.e.
+// with --output json.
+type conftestResult struct {
+	Failures []models.PolicyFailure `json:"failures"`
+}
+
+// parseConftestFailures returns the failures of the JSON output of conftest
+// and whether output is JSON.
+func parseConftestFailures(output string) ([]models.PolicyFailure, bool) {
+	var results []conftestResult
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		return nil, false
+	}
+	var failures []models.PolicyFailure
+	for _, result := range results {
+		failures = append(failures, result.Failures...)
+	}
+	return failures, true
+}
+
+// requiredApprovals returns the number of approvals a policy set with
+// approveCount requires given its failures. The approve_count of the metadata
+// of a failure raises it, so a deny rule can require more approvals for a
+// riskier change, ex. deny[{"msg": msg, "approve_count": 2}].
+func requiredApprovals(approveCount int, failures []models.PolicyFailure) int {
+	for _, failure := range failures {
+		if count, ok := failure.Metadata["approve_count"].(float64); ok && int(count) > approveCount {
+			approveCount = int(count)
+		}
+	}
+	return approveCount
+}
+
 // hasFailures checks whether any conftest policies have failed
 func hasFailures(output string) bool {
 	r := regexp.MustCompile(`([1-9]([0-9]?)* failure|failures": \[)`)
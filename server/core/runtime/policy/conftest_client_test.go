@@ -1,6 +1,7 @@
 package policy
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 	models_mocks "github.com/runatlantis/atlantis/server/core/runtime/models/mocks"
 	conftest_mocks "github.com/runatlantis/atlantis/server/core/runtime/policy/mocks"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -233,6 +235,33 @@ func TestRun(t *testing.T) {
 
 	})
 
+	t.Run("json output with deny metadata", func(t *testing.T) {
+		extraArgs := []string{"--output", "json"}
+
+		expectedArgsPolicy1 := []string{executablePath, "test", "-p", localPolicySetPath1, filepath.Join(workdir, "testproj-default.json"), "--no-color", "--output", "json"}
+		expectedArgsPolicy2 := []string{executablePath, "test", "-p", localPolicySetPath2, filepath.Join(workdir, "testproj-default.json"), "--no-color", "--output", "json"}
+
+		When(mockResolver.Resolve(policySet1)).ThenReturn(localPolicySetPath1, nil)
+		When(mockResolver.Resolve(policySet2)).ThenReturn(localPolicySetPath2, nil)
+
+		output1 := `[{"filename":"plan.json","namespace":"main","successes":1,"failures":[{"msg":"IAM changes need a security review","metadata":{"approve_count":2}}]}]`
+		output2 := `[{"filename":"plan.json","namespace":"main","successes":2}]`
+		When(mockExec.CombinedOutput(expectedArgsPolicy1, envs, workdir)).ThenReturn(output1, errors.New("exit status 1"))
+		When(mockExec.CombinedOutput(expectedArgsPolicy2, envs, workdir)).ThenReturn(output2, nil)
+
+		result, err := subject.Run(ctx, executablePath, envs, workdir, extraArgs)
+		ErrContains(t, "policy_set: policy1: conftest: some policies failed", err)
+
+		var results []models.PolicySetResult
+		Ok(t, json.Unmarshal([]byte(result), &results))
+		Equals(t, 2, len(results))
+		Equals(t, false, results[0].Passed)
+		Equals(t, 2, results[0].ReqApprovals)
+		Equals(t, []models.PolicyFailure{{Msg: "IAM changes need a security review", Metadata: map[string]any{"approve_count": float64(2)}}}, results[0].Failures)
+		Equals(t, true, results[1].Passed)
+		Equals(t, 0, results[1].ReqApprovals)
+	})
+
 	t.Run("error resolving one policy source", func(t *testing.T) {
 		var extraArgs []string
 
@@ -44,6 +44,15 @@ func (wh DefaultPostWorkflowHookRunner) Run(ctx models.WorkflowHookCommandContex
 		"USER_NAME":          ctx.User.Username,
 		"OUTPUT_STATUS_FILE": outputFilePath,
 		"COMMAND_NAME":       ctx.CommandName,
+		"COMMAND_RESULT":     ctx.CommandResult,
+		"COMMAND_ERROR":      ctx.CommandError,
+		"COMMAND_OUTCOMES":   strings.Join(ctx.CommandOutcomes, ","),
+		"PROJECT_RESULTS":    ctx.ProjectResults,
+		"EVENT_NAME":         ctx.Event,
+		"PROJECT_NAME":       ctx.ProjectName,
+		"REPO_REL_DIR":       ctx.RepoRelDir,
+		"WORKSPACE":          ctx.Workspace,
+		"COMMENT":            ctx.Comment,
 	}
 
 	finalEnvVars := baseEnvVars
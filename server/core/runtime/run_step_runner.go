@@ -22,6 +22,8 @@ type RunStepRunner struct {
 	// TerraformBinDir is the directory where Atlantis downloads Terraform binaries.
 	TerraformBinDir         string
 	ProjectCmdOutputHandler jobs.ProjectCommandOutputHandler
+	// Executor runs the commands. If nil, they run on the Atlantis server.
+	Executor models.Executor
 }
 
 func (r *RunStepRunner) Run(
@@ -36,7 +38,7 @@ func (r *RunStepRunner) Run(
 	tfDistribution := r.DefaultTFDistribution
 	tfVersion := r.DefaultTFVersion
 	if ctx.TerraformDistribution != nil {
-		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+		tfDistribution = r.TerraformExecutor.Distributions().NewDistribution(*ctx.TerraformDistribution)
 	}
 	if ctx.TerraformVersion != nil {
 		tfVersion = ctx.TerraformVersion
@@ -83,7 +85,7 @@ func (r *RunStepRunner) Run(
 		finalEnvVars = append(finalEnvVars, fmt.Sprintf("%s=%s", key, val))
 	}
 
-	runner := models.NewShellCommandRunner(shell, command, finalEnvVars, path, streamOutput, r.ProjectCmdOutputHandler)
+	runner := models.NewShellCommandRunner(shell, command, finalEnvVars, path, streamOutput, r.ProjectCmdOutputHandler, r.Executor)
 	output, err := runner.Run(ctx)
 
 	if postProcessOutput == valid.PostProcessRunOutputStripRefreshing {
@@ -106,6 +108,14 @@ func (r *RunStepRunner) Run(
 		return "", nil
 	case valid.PostProcessRunOutputStripRefreshing:
 		return output, nil
+	case valid.PostProcessRunOutputJSON:
+		changes, err := ParseJSONPlanOutput(output)
+		if err != nil {
+			err = fmt.Errorf("%s: parsing the output of %q in %q", err, command, path)
+			ctx.Log.Debug("error: %s", err)
+			return "", err
+		}
+		return changes.String(), nil
 	case valid.PostProcessRunOutputShow:
 		return output, nil
 	default:
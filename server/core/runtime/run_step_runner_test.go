@@ -187,3 +187,31 @@ func TestRunStepRunner_Run(t *testing.T) {
 		}
 	}
 }
+
+func TestRunStepRunner_Run_JSONOutput(t *testing.T) {
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	When(terraform.EnsureVersion(Any[logging.SimpleLogging](), Any[tf.Distribution](), Any[*version.Version]())).
+		ThenReturn(nil)
+	defaultVersion, _ := version.NewVersion("1.9.0")
+	r := runtime.RunStepRunner{
+		TerraformExecutor:       terraform,
+		DefaultTFDistribution:   tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader()),
+		DefaultTFVersion:        defaultVersion,
+		ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+	}
+	ctx := command.ProjectContext{
+		Log:       logging.NewNoopLogger(t),
+		Workspace: "default",
+	}
+
+	summary := `{"type":"planned_change","change":{"resource":{"addr":"aws_instance.web"},"action":"create"}}
+{"type":"change_summary","changes":{"add":1,"change":0,"import":0,"remove":0,"operation":"plan"}}`
+	out, err := r.Run(ctx, nil, fmt.Sprintf("echo '%s'", summary), t.TempDir(), nil, true, valid.PostProcessRunOutputJSON)
+	Ok(t, err)
+	Equals(t, "+ aws_instance.web\n\nPlan: 1 to add, 0 to change, 0 to destroy.\n", out)
+	Equals(t, models.PlanSuccessStats{Add: 1, Changes: true}, models.NewPlanSuccessStats(out))
+
+	_, err = r.Run(ctx, nil, "echo hi", t.TempDir(), nil, true, valid.PostProcessRunOutputJSON)
+	ErrContains(t, "no change summary found in the output", err)
+}
@@ -29,6 +29,7 @@ const (
 type TerraformExec interface {
 	RunCommandWithVersion(ctx command.ProjectContext, path string, args []string, envs map[string]string, d terraform.Distribution, v *version.Version, workspace string) (string, error)
 	EnsureVersion(log logging.SimpleLogging, d terraform.Distribution, v *version.Version) error
+	Distributions() terraform.DistributionFactory
 }
 
 // AsyncTFExec brings the interface from TerraformClient into this package
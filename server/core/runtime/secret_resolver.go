@@ -0,0 +1,194 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	runtimemodels "github.com/runatlantis/atlantis/server/core/runtime/models"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// SecretResolver reads the values of env steps from secret providers. The
+// name of a secret can be followed by # and a key, ex. prod/db#password, to
+// read the value of the key of a secret that's a JSON object.
+//
+//   - vault secrets are read from the KV secrets engine, version 1 or 2, of
+//     the Vault server at VAULT_ADDR with VAULT_TOKEN. Their key is required.
+//   - aws_secrets_manager secrets are read with the AWS CLI.
+//   - gcp_secret_manager secrets are read with the gcloud CLI. Their name is
+//     a secret or a secret version, ex. projects/my-project/secrets/db or
+//     projects/my-project/secrets/db/versions/3. The latest version of a
+//     secret is read.
+//
+// The environment of the step is used, so the credentials set by an earlier
+// assume_role step are used for AWS.
+type SecretResolver struct {
+	// AWSCLI is the path to the AWS CLI. If empty, aws is looked up in the
+	// PATH.
+	AWSCLI string
+	// GCloudCLI is the path to the gcloud CLI. If empty, gcloud is looked up
+	// in the PATH.
+	GCloudCLI string
+	// HTTPClient is used to read Vault secrets. If nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+func (r *SecretResolver) Resolve(ctx command.ProjectContext, secret valid.SecretRef, path string, envs map[string]string) (string, error) {
+	name, key, _ := strings.Cut(secret.Name, "#")
+	var value string
+	var err error
+	switch secret.Provider {
+	case valid.VaultSecretProvider:
+		return r.resolveVault(name, key, envs)
+	case valid.AWSSecretsManagerSecretProvider:
+		value, err = r.resolveAWS(ctx, name, path, envs)
+	case valid.GCPSecretManagerSecretProvider:
+		value, err = r.resolveGCP(ctx, name, path, envs)
+	default:
+		return "", fmt.Errorf("unknown secret provider %q", secret.Provider)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "reading secret %q from %s", name, secret.Provider)
+	}
+	if key == "" {
+		return value, nil
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &object); err != nil {
+		return "", fmt.Errorf("reading key %q of secret %q from %s: secret isn't a JSON object", key, name, secret.Provider)
+	}
+	return secretKey(object, key, name, secret.Provider)
+}
+
+// resolveVault reads the key of the secret at path from Vault.
+func (r *SecretResolver) resolveVault(path string, key string, envs map[string]string) (string, error) {
+	addr := secretEnv(envs, "VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("reading secret %q from vault: VAULT_ADDR isn't set", path)
+	}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(addr, "/"), strings.TrimPrefix(path, "/")), nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading secret %q from vault", path)
+	}
+	req.Header.Set("X-Vault-Token", secretEnv(envs, "VAULT_TOKEN"))
+	if namespace := secretEnv(envs, "VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading secret %q from vault", path)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading secret %q from vault", path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("reading secret %q from vault: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", errors.Wrapf(err, "parsing secret %q from vault", path)
+	}
+	// The KV secrets engine version 2 nests the secret under data and adds
+	// its metadata.
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	return secretKey(data, key, path, valid.VaultSecretProvider)
+}
+
+func (r *SecretResolver) resolveAWS(ctx command.ProjectContext, secretID string, path string, envs map[string]string) (string, error) {
+	awsCLI := r.AWSCLI
+	if awsCLI == "" {
+		awsCLI = "aws"
+	}
+	out, err := runCLI(ctx, path, envs, awsCLI, "secretsmanager", "get-secret-value",
+		"--secret-id", secretID, "--query", "SecretString", "--output", "text")
+	return strings.TrimSuffix(out, "\n"), err
+}
+
+func (r *SecretResolver) resolveGCP(ctx command.ProjectContext, name string, path string, envs map[string]string) (string, error) {
+	gcloudCLI := r.GCloudCLI
+	if gcloudCLI == "" {
+		gcloudCLI = "gcloud"
+	}
+	secretName, version := name, "latest"
+	var project string
+	parts := strings.Split(name, "/")
+	if len(parts) >= 4 && parts[0] == "projects" && parts[2] == "secrets" {
+		project, secretName = parts[1], parts[3]
+		if len(parts) == 6 && parts[4] == "versions" {
+			version = parts[5]
+		} else if len(parts) != 4 {
+			return "", fmt.Errorf("%q isn't a secret or a secret version", name)
+		}
+	}
+	args := []string{"secrets", "versions", "access", version, "--secret", secretName}
+	if project != "" {
+		args = append(args, "--project", project)
+	}
+	return runCLI(ctx, path, envs, gcloudCLI, args...)
+}
+
+// runCLI runs cli with args in path and returns its output.
+func runCLI(ctx command.ProjectContext, path string, envs map[string]string, cli string, args ...string) (string, error) {
+	cmd := runtimemodels.NewCommand(ctx, cli, args...)
+	cmd.Dir = path
+	cmd.Env = os.Environ()
+	for key, val := range envs {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, val))
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// secretKey returns the value of key in the secret name. Values that aren't
+// strings are returned as JSON.
+func secretKey(secret map[string]interface{}, key string, name string, provider string) (string, error) {
+	value, ok := secret[key]
+	if !ok {
+		return "", fmt.Errorf("reading key %q of secret %q from %s: secret has no such key", key, name, provider)
+	}
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading key %q of secret %q from %s", key, name, provider)
+	}
+	return string(encoded), nil
+}
+
+// secretEnv returns the env var key of the step, or of Atlantis if the step
+// doesn't set it.
+func secretEnv(envs map[string]string, key string) string {
+	if value, ok := envs[key]; ok {
+		return value
+	}
+	return os.Getenv(key)
+}
@@ -0,0 +1,185 @@
+package runtime_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestSecretResolver_Resolve_Vault(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/db":
+			fmt.Fprint(w, `{"data":{"data":{"password":"hunter22","port":5432},"metadata":{"version":3}}}`)
+		case "/v1/kv/db":
+			fmt.Fprint(w, `{"data":{"password":"hunter23"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[]}`)
+		}
+	}))
+	defer vault.Close()
+
+	cases := []struct {
+		description string
+		name        string
+		token       string
+		exp         string
+		expErr      string
+	}{
+		{
+			description: "kv version 2",
+			name:        "secret/data/db#password",
+			token:       "token",
+			exp:         "hunter22",
+		},
+		{
+			description: "kv version 2 value that isn't a string",
+			name:        "secret/data/db#port",
+			token:       "token",
+			exp:         "5432",
+		},
+		{
+			description: "kv version 1",
+			name:        "kv/db#password",
+			token:       "token",
+			exp:         "hunter23",
+		},
+		{
+			description: "missing key",
+			name:        "kv/db#user",
+			token:       "token",
+			expErr:      `reading key "user" of secret "kv/db" from vault: secret has no such key`,
+		},
+		{
+			description: "missing secret",
+			name:        "kv/missing#password",
+			token:       "token",
+			expErr:      `reading secret "kv/missing" from vault: 404 Not Found: {"errors":[]}`,
+		},
+		{
+			description: "permission denied",
+			name:        "kv/db#password",
+			token:       "other",
+			expErr:      `reading secret "kv/db" from vault: 403 Forbidden: {"errors":["permission denied"]}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r := &runtime.SecretResolver{}
+			envs := map[string]string{"VAULT_ADDR": vault.URL, "VAULT_TOKEN": c.token}
+			value, err := r.Resolve(command.ProjectContext{Log: logging.NewNoopLogger(t)}, valid.SecretRef{Provider: "vault", Name: c.name}, t.TempDir(), envs)
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+			Equals(t, c.exp, value)
+		})
+	}
+}
+
+// writeSecretCLI writes a CLI that records its args in the returned file and
+// prints output.
+func writeSecretCLI(t *testing.T, output string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	cli := filepath.Join(dir, "cli")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s\nprintf '%%s' '%s'\n", argsFile, output)
+	Ok(t, os.WriteFile(cli, []byte(script), 0700)) // #nosec G306
+	return cli, argsFile
+}
+
+func TestSecretResolver_Resolve_CLIs(t *testing.T) {
+	cases := []struct {
+		description string
+		secret      valid.SecretRef
+		output      string
+		exp         string
+		expArgs     string
+		expErr      string
+	}{
+		{
+			description: "aws secret",
+			secret:      valid.SecretRef{Provider: "aws_secrets_manager", Name: "prod/db"},
+			output:      "hunter22\n",
+			exp:         "hunter22",
+			expArgs:     "secretsmanager get-secret-value --secret-id prod/db --query SecretString --output text\n",
+		},
+		{
+			description: "aws secret key",
+			secret:      valid.SecretRef{Provider: "aws_secrets_manager", Name: "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db#password"},
+			output:      `{"username":"atlantis","password":"hunter22"}`,
+			exp:         "hunter22",
+			expArgs:     "secretsmanager get-secret-value --secret-id arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db --query SecretString --output text\n",
+		},
+		{
+			description: "aws secret key of a secret that isn't JSON",
+			secret:      valid.SecretRef{Provider: "aws_secrets_manager", Name: "prod/db#password"},
+			output:      "hunter22",
+			expErr:      `reading key "password" of secret "prod/db" from aws_secrets_manager: secret isn't a JSON object`,
+		},
+		{
+			description: "gcp secret",
+			secret:      valid.SecretRef{Provider: "gcp_secret_manager", Name: "projects/my-project/secrets/db"},
+			output:      "hunter22",
+			exp:         "hunter22",
+			expArgs:     "secrets versions access latest --secret db --project my-project\n",
+		},
+		{
+			description: "gcp secret version",
+			secret:      valid.SecretRef{Provider: "gcp_secret_manager", Name: "projects/my-project/secrets/db/versions/3#password"},
+			output:      `{"password":"hunter22"}`,
+			exp:         "hunter22",
+			expArgs:     "secrets versions access 3 --secret db --project my-project\n",
+		},
+		{
+			description: "gcp secret of the default project",
+			secret:      valid.SecretRef{Provider: "gcp_secret_manager", Name: "db"},
+			output:      "hunter22",
+			exp:         "hunter22",
+			expArgs:     "secrets versions access latest --secret db\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			cli, argsFile := writeSecretCLI(t, c.output)
+			r := &runtime.SecretResolver{AWSCLI: cli, GCloudCLI: cli}
+			value, err := r.Resolve(command.ProjectContext{Log: logging.NewNoopLogger(t)}, c.secret, t.TempDir(), nil)
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+			Equals(t, c.exp, value)
+			args, err := os.ReadFile(argsFile)
+			Ok(t, err)
+			Equals(t, c.expArgs, string(args))
+		})
+	}
+}
+
+func TestSecretResolver_Resolve_CLIError(t *testing.T) {
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "aws")
+	Ok(t, os.WriteFile(cli, []byte("#!/bin/sh\necho 'ResourceNotFoundException' >&2\nexit 254\n"), 0700)) // #nosec G306
+
+	r := &runtime.SecretResolver{AWSCLI: cli}
+	_, err := r.Resolve(command.ProjectContext{Log: logging.NewNoopLogger(t)}, valid.SecretRef{Provider: "aws_secrets_manager", Name: "prod/db"}, dir, nil)
+	ErrEquals(t, `reading secret "prod/db" from aws_secrets_manager: exit status 254: ResourceNotFoundException`, err)
+}
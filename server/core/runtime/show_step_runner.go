@@ -23,7 +23,8 @@ func NewShowStepRunner(executor TerraformExec, defaultTfDistribution terraform.D
 	return NewMinimumVersionStepRunnerDelegate(minimumShowTfVersion, defaultTFVersion, runner)
 }
 
-// showStepRunner runs terraform show on an existing plan file and outputs it to a json file
+// showStepRunner runs terraform show on an existing plan file and outputs it
+// to a json file and, without -json, to a text file
 type showStepRunner struct {
 	terraformExecutor     TerraformExec
 	defaultTfDistribution terraform.Distribution
@@ -34,7 +35,7 @@ func (p *showStepRunner) Run(ctx command.ProjectContext, _ []string, path string
 	tfDistribution := p.defaultTfDistribution
 	tfVersion := p.defaultTFVersion
 	if ctx.TerraformDistribution != nil {
-		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+		tfDistribution = p.terraformExecutor.Distributions().NewDistribution(*ctx.TerraformDistribution)
 	}
 	if ctx.TerraformVersion != nil {
 		tfVersion = ctx.TerraformVersion
@@ -42,6 +43,7 @@ func (p *showStepRunner) Run(ctx command.ProjectContext, _ []string, path string
 
 	planFile := filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName))
 	showResultFile := filepath.Join(path, ctx.GetShowResultFileName())
+	showTextFile := filepath.Join(path, ctx.GetShowTextFileName())
 
 	output, err := p.terraformExecutor.RunCommandWithVersion(
 		ctx,
@@ -57,9 +59,41 @@ func (p *showStepRunner) Run(ctx command.ProjectContext, _ []string, path string
 		return "", errors.Wrap(err, "running terraform show")
 	}
 
-	if err := os.WriteFile(showResultFile, []byte(output), 0600); err != nil {
+	if err := writeStringFile(showResultFile, output); err != nil {
 		return "", errors.Wrap(err, "writing terraform show result")
 	}
 
+	textOutput, err := p.terraformExecutor.RunCommandWithVersion(
+		ctx,
+		path,
+		[]string{"show", "-no-color", filepath.Clean(planFile)},
+		envs,
+		tfDistribution,
+		tfVersion,
+		ctx.Workspace,
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "running terraform show")
+	}
+
+	if err := writeStringFile(showTextFile, textOutput); err != nil {
+		return "", errors.Wrap(err, "writing terraform show text")
+	}
+
 	return output, nil
 }
+
+// writeStringFile writes data to name like os.WriteFile without copying it to
+// a byte slice first, since the JSON of large plans can be hundreds of
+// megabytes.
+func writeStringFile(name string, data string) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(data); err != nil {
+		f.Close() // nolint: errcheck
+		return err
+	}
+	return f.Close()
+}
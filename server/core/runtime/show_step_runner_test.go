@@ -21,6 +21,7 @@ func TestShowStepRunnner(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	path := t.TempDir()
 	resultPath := filepath.Join(path, "test-default.json")
+	textPath := filepath.Join(path, "test-default-show.txt")
 	envs := map[string]string{"key": "val"}
 	mockDownloader := mocks.NewMockDownloader()
 	tfDistribution := tf.NewDistributionTerraformWithDownloader(mockDownloader)
@@ -46,6 +47,9 @@ func TestShowStepRunnner(t *testing.T) {
 		When(mockExecutor.RunCommandWithVersion(
 			context, path, []string{"show", "-json", filepath.Join(path, "test-default.tfplan")}, envs, tfDistribution, tfVersion, context.Workspace,
 		)).ThenReturn("success", nil)
+		When(mockExecutor.RunCommandWithVersion(
+			context, path, []string{"show", "-no-color", filepath.Join(path, "test-default.tfplan")}, envs, tfDistribution, tfVersion, context.Workspace,
+		)).ThenReturn("text success", nil)
 
 		r, err := subject.Run(context, []string{}, path, envs)
 
@@ -57,6 +61,9 @@ func TestShowStepRunnner(t *testing.T) {
 		Assert(t, actualStr == "success", fmt.Sprintf("expected '%s' to be success", actualStr))
 		Assert(t, r == "success", fmt.Sprintf("expected '%s' to be success", r))
 
+		actualText, _ := os.ReadFile(textPath)
+		Equals(t, "text success", string(actualText))
+
 	})
 
 	t.Run("success w/ version override", func(t *testing.T) {
@@ -0,0 +1,138 @@
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+type statePullStepRunner struct {
+	terraformExecutor     TerraformExec
+	defaultTFDistribution terraform.Distribution
+	defaultTFVersion      *version.Version
+}
+
+// NewStatePullStepRunner returns a runner that runs terraform state pull and
+// outputs the metadata of the state. The state itself is never output since
+// it's posted on the pull request and can contain secrets.
+func NewStatePullStepRunner(terraformExecutor TerraformExec, defaultTfDistribution terraform.Distribution, defaultTfVersion *version.Version) Runner {
+	runner := &statePullStepRunner{
+		terraformExecutor:     terraformExecutor,
+		defaultTFDistribution: defaultTfDistribution,
+		defaultTFVersion:      defaultTfVersion,
+	}
+	return NewWorkspaceStepRunnerDelegate(terraformExecutor, defaultTfDistribution, defaultTfVersion, runner)
+}
+
+func (p *statePullStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfDistribution := p.defaultTFDistribution
+	tfVersion := p.defaultTFVersion
+	if ctx.TerraformDistribution != nil {
+		tfDistribution = p.terraformExecutor.Distributions().NewDistribution(*ctx.TerraformDistribution)
+	}
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	statePullCmd := append([]string{"state", "pull"}, extraArgs...)
+	out, err := p.terraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), statePullCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
+	// Only what's printed before the state is kept since the output of a
+	// failed pull can still contain part of it.
+	beforeState, _, _ := strings.Cut(out, "{")
+	if err != nil {
+		return "", fmt.Errorf("%s\n%s", err, strings.TrimSpace(beforeState))
+	}
+	return SummarizeState([]byte(out[len(beforeState):]))
+}
+
+// stateFile is the part of a v4 state file that SummarizeState reads. The
+// attributes of resources and the values of outputs are left out so that they
+// can't end up in the summary.
+type stateFile struct {
+	Version          int    `json:"version"`
+	TerraformVersion string `json:"terraform_version"`
+	Serial           int64  `json:"serial"`
+	Lineage          string `json:"lineage"`
+	Outputs          map[string]struct {
+		Sensitive bool `json:"sensitive"`
+	} `json:"outputs"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey interface{} `json:"index_key"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// SummarizeState returns the metadata of the state file in state: its
+// version, serial, lineage and checksum, and the addresses of its resources
+// and names of its outputs. It never contains values from the state.
+func SummarizeState(state []byte) (string, error) {
+	if len(strings.TrimSpace(string(state))) == 0 {
+		return "No state.", nil
+	}
+	var parsed stateFile
+	if err := json.Unmarshal(state, &parsed); err != nil {
+		// The error isn't wrapped since it can quote the state.
+		return "", errors.New("parsing state: not a valid state file")
+	}
+
+	var addresses []string
+	for _, resource := range parsed.Resources {
+		address := resource.Type + "." + resource.Name
+		if resource.Mode == "data" {
+			address = "data." + address
+		}
+		if resource.Module != "" {
+			address = resource.Module + "." + address
+		}
+		for _, instance := range resource.Instances {
+			switch key := instance.IndexKey.(type) {
+			case nil:
+				addresses = append(addresses, address)
+			case string:
+				addresses = append(addresses, fmt.Sprintf("%s[%q]", address, key))
+			default:
+				addresses = append(addresses, fmt.Sprintf("%s[%v]", address, key))
+			}
+		}
+	}
+	sort.Strings(addresses)
+	var outputs []string
+	for name, output := range parsed.Outputs {
+		if output.Sensitive {
+			name += " (sensitive)"
+		}
+		outputs = append(outputs, name)
+	}
+	sort.Strings(outputs)
+
+	checksum := sha256.Sum256(state)
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "State version: %d\n", parsed.Version)
+	fmt.Fprintf(&summary, "Terraform version: %s\n", parsed.TerraformVersion)
+	fmt.Fprintf(&summary, "Serial: %d\n", parsed.Serial)
+	fmt.Fprintf(&summary, "Lineage: %s\n", parsed.Lineage)
+	fmt.Fprintf(&summary, "Checksum (SHA-256): %s\n", hex.EncodeToString(checksum[:]))
+	fmt.Fprintf(&summary, "\nResources (%d):\n", len(addresses))
+	for _, address := range addresses {
+		fmt.Fprintf(&summary, "  %s\n", address)
+	}
+	fmt.Fprintf(&summary, "\nOutputs (%d):\n", len(outputs))
+	for _, output := range outputs {
+		fmt.Fprintf(&summary, "  %s\n", output)
+	}
+	return summary.String(), nil
+}
@@ -0,0 +1,87 @@
+package runtime
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock/v4"
+	tf "github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const testState = `{
+  "version": 4,
+  "terraform_version": "1.5.7",
+  "serial": 12,
+  "lineage": "3f2a1b4c-0000-0000-0000-000000000000",
+  "outputs": {
+    "vpc_id": {"value": "vpc-123", "type": "string"},
+    "db_password": {"value": "hunter2", "type": "string", "sensitive": true}
+  },
+  "resources": [
+    {"mode": "managed", "type": "aws_vpc", "name": "main", "instances": [{"attributes": {"id": "vpc-123"}}]},
+    {"module": "module.db", "mode": "managed", "type": "aws_db_instance", "name": "main", "instances": [{"index_key": 0, "attributes": {"password": "hunter2"}}]},
+    {"mode": "data", "type": "aws_ami", "name": "ubuntu", "instances": [{"index_key": "a", "attributes": {}}]}
+  ]
+}`
+
+func TestSummarizeState(t *testing.T) {
+	summary, err := SummarizeState([]byte(testState))
+	Ok(t, err)
+	Assert(t, !strings.Contains(summary, "hunter2"), "summary must not contain values from the state")
+	Assert(t, !strings.Contains(summary, "vpc-123"), "summary must not contain values from the state")
+	Assert(t, strings.HasPrefix(summary, `State version: 4
+Terraform version: 1.5.7
+Serial: 12
+Lineage: 3f2a1b4c-0000-0000-0000-000000000000
+Checksum (SHA-256): `), "unexpected summary %q", summary)
+	Assert(t, strings.HasSuffix(summary, `
+Resources (3):
+  aws_vpc.main
+  data.aws_ami.ubuntu["a"]
+  module.db.aws_db_instance.main[0]
+
+Outputs (2):
+  db_password (sensitive)
+  vpc_id
+`), "unexpected summary %q", summary)
+
+	summary, err = SummarizeState(nil)
+	Ok(t, err)
+	Equals(t, "No state.", summary)
+
+	_, err = SummarizeState([]byte(`{"password": "hunter2"`))
+	ErrEquals(t, "parsing state: not a valid state file", err)
+}
+
+func TestStatePullStepRunner_Run(t *testing.T) {
+	context := command.ProjectContext{
+		Log:       logging.NewNoopLogger(t),
+		Workspace: "default",
+	}
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("0.15.0")
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader())
+	s := NewStatePullStepRunner(terraform, tfDistribution, tfVersion)
+	tmpDir := t.TempDir()
+
+	When(terraform.RunCommandWithVersion(Any[command.ProjectContext](), Any[string](), Any[[]string](), Any[map[string]string](), Any[tf.Distribution](), Any[*version.Version](), Any[string]())).
+		ThenReturn(testState, nil)
+	output, err := s.Run(context, []string{}, tmpDir, map[string]string(nil))
+	Ok(t, err)
+	Assert(t, strings.Contains(output, "Serial: 12"), "expected the summary, got %q", output)
+	Assert(t, !strings.Contains(output, "hunter2"), "output must not contain values from the state")
+	terraform.VerifyWasCalledOnce().RunCommandWithVersion(context, tmpDir, []string{"state", "pull"}, map[string]string(nil), tfDistribution, tfVersion, "default")
+
+	When(terraform.RunCommandWithVersion(Any[command.ProjectContext](), Any[string](), Eq([]string{"state", "pull"}), Any[map[string]string](), Any[tf.Distribution](), Any[*version.Version](), Any[string]())).
+		ThenReturn("Error refreshing state\n"+testState, errors.New("exit status 1"))
+	_, err = s.Run(context, []string{}, tmpDir, map[string]string(nil))
+	ErrEquals(t, "exit status 1\nError refreshing state", err)
+}
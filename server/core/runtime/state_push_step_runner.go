@@ -0,0 +1,57 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+type statePushStepRunner struct {
+	terraformExecutor     TerraformExec
+	defaultTFDistribution terraform.Distribution
+	defaultTFVersion      *version.Version
+}
+
+// NewStatePushStepRunner returns a runner that runs terraform state push for
+// the state file passed in the comment.
+func NewStatePushStepRunner(terraformExecutor TerraformExec, defaultTfDistribution terraform.Distribution, defaultTfVersion *version.Version) Runner {
+	runner := &statePushStepRunner{
+		terraformExecutor:     terraformExecutor,
+		defaultTFDistribution: defaultTfDistribution,
+		defaultTFVersion:      defaultTfVersion,
+	}
+	return NewWorkspaceStepRunnerDelegate(terraformExecutor, defaultTfDistribution, defaultTfVersion, runner)
+}
+
+func (p *statePushStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfDistribution := p.defaultTFDistribution
+	tfVersion := p.defaultTFVersion
+	if ctx.TerraformDistribution != nil {
+		tfDistribution = p.terraformExecutor.Distributions().NewDistribution(*ctx.TerraformDistribution)
+	}
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	statePushCmd := []string{"state", "push"}
+	statePushCmd = append(statePushCmd, extraArgs...)
+	statePushCmd = append(statePushCmd, ctx.EscapedCommentArgs...)
+	out, err := p.terraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), statePushCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
+
+	// If the state push was successful and a plan file exists, delete the plan
+	// since it was made against the state that was replaced.
+	planPath := filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	if err == nil {
+		if _, planPathErr := os.Stat(planPath); !os.IsNotExist(planPathErr) {
+			ctx.Log.Info("state push successful, deleting planfile")
+			if removeErr := utils.RemoveIgnoreNonExistent(planPath); removeErr != nil {
+				ctx.Log.Warn("failed to delete planfile after successful state push: %s", removeErr)
+			}
+		}
+	}
+	return out, err
+}
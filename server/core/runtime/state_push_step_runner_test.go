@@ -0,0 +1,41 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock/v4"
+	tf "github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestStatePushStepRunner_Run_Success(t *testing.T) {
+	tmpDir := t.TempDir()
+	planPath := filepath.Join(tmpDir, "default.tfplan")
+	Ok(t, os.WriteFile(planPath, nil, 0600))
+
+	context := command.ProjectContext{
+		Log:                logging.NewNoopLogger(t),
+		EscapedCommentArgs: []string{"-force", "backup.tfstate"},
+		Workspace:          "default",
+	}
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("0.15.0")
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader())
+	s := NewStatePushStepRunner(terraform, tfDistribution, tfVersion)
+
+	When(terraform.RunCommandWithVersion(Any[command.ProjectContext](), Any[string](), Any[[]string](), Any[map[string]string](), Any[tf.Distribution](), Any[*version.Version](), Any[string]())).
+		ThenReturn("", nil)
+	_, err := s.Run(context, []string{}, tmpDir, map[string]string(nil))
+	Ok(t, err)
+	terraform.VerifyWasCalledOnce().RunCommandWithVersion(context, tmpDir, []string{"state", "push", "-force", "backup.tfstate"}, map[string]string(nil), tfDistribution, tfVersion, "default")
+	_, err = os.Stat(planPath)
+	Assert(t, os.IsNotExist(err), "planfile should be deleted")
+}
@@ -0,0 +1,71 @@
+package runtime
+
+import (
+	"errors"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	tally "github.com/uber-go/tally/v4"
+)
+
+const (
+	// StepDurationMetric is the histogram of how long steps take to run.
+	StepDurationMetric = "step_duration"
+	// StepExitCodeMetric counts the steps that were run by their exit code.
+	StepExitCodeMetric = "step_exit_code"
+	// PlanResourcesScope is the scope of the gauges of how many resources
+	// the last plan of a project changes.
+	PlanResourcesScope = "plan_resources"
+)
+
+// stepDurationBuckets go from a second to about an hour, doubling each time.
+var stepDurationBuckets = tally.MustMakeExponentialDurationBuckets(time.Second, 2, 13)
+
+// reExitStatus matches the exit status of commands whose errors were
+// formatted rather than wrapped.
+var reExitStatus = regexp.MustCompile(`exit status (\d+)`)
+
+// EmitStepMetrics records the duration and exit code of a step that ran for
+// ctx, tagged by its project and the step. If the step is a successful plan,
+// the resources its output changes are recorded too. It does nothing if ctx
+// has no scope.
+func EmitStepMetrics(ctx command.ProjectContext, stepName string, duration time.Duration, output string, err error) {
+	if ctx.Scope == nil {
+		return
+	}
+	// The scope is tagged by project already unless policy checks are
+	// enabled, so tag it again to always have the same tags.
+	projectScope := ctx.SetProjectScopeTags(ctx.Scope)
+	scope := projectScope.Tagged(map[string]string{"step": stepName})
+	scope.Histogram(StepDurationMetric, stepDurationBuckets).RecordDuration(duration)
+	scope.Tagged(map[string]string{"exit_code": stepExitCode(err)}).Counter(StepExitCodeMetric).Inc(1)
+
+	if stepName == "plan" && err == nil {
+		stats := models.NewPlanSuccessStats(output)
+		planScope := projectScope.SubScope(PlanResourcesScope)
+		planScope.Gauge("import").Update(float64(stats.Import))
+		planScope.Gauge("add").Update(float64(stats.Add))
+		planScope.Gauge("change").Update(float64(stats.Change))
+		planScope.Gauge("destroy").Update(float64(stats.Destroy))
+	}
+}
+
+// stepExitCode returns the exit code of a step that returned err: 0 if it
+// succeeded and "unknown" if it failed without running a command that exited.
+func stepExitCode(err error) string {
+	if err == nil {
+		return "0"
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return strconv.Itoa(exitErr.ExitCode())
+	}
+	if m := reExitStatus.FindStringSubmatch(err.Error()); m != nil {
+		return m[1]
+	}
+	return "unknown"
+}
@@ -0,0 +1,65 @@
+package runtime_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestEmitStepMetrics(t *testing.T) {
+	scope := tally.NewTestScope("atlantis", nil)
+	ctx := command.ProjectContext{
+		Scope:       scope,
+		BaseRepo:    models.Repo{FullName: "owner/repo"},
+		ProjectName: "network",
+		RepoRelDir:  "network",
+		Workspace:   "default",
+	}
+
+	runtime.EmitStepMetrics(ctx, "plan", 3*time.Second, "Plan: 1 to add, 2 to change, 3 to destroy.", nil)
+	runtime.EmitStepMetrics(ctx, "run", time.Second, "", fmt.Errorf("%s: running %q in %q", errors.New("exit status 2"), "make", "network"))
+	runtime.EmitStepMetrics(ctx, "init", time.Second, "", errors.New("downloading terraform"))
+
+	snapshot := scope.Snapshot()
+	exitCodes := make(map[string]string)
+	for _, c := range snapshot.Counters() {
+		if c.Name() == "atlantis.step_exit_code" {
+			Equals(t, "network", c.Tags()["project"])
+			Equals(t, "default", c.Tags()["workspace"])
+			Equals(t, int64(1), c.Value())
+			exitCodes[c.Tags()["step"]] = c.Tags()["exit_code"]
+		}
+	}
+	Equals(t, map[string]string{"plan": "0", "run": "2", "init": "unknown"}, exitCodes)
+
+	planDurations := make(map[time.Duration]int64)
+	for _, h := range snapshot.Histograms() {
+		if h.Name() == "atlantis.step_duration" && h.Tags()["step"] == "plan" {
+			planDurations = h.Durations()
+		}
+	}
+	Equals(t, int64(1), planDurations[4*time.Second])
+
+	resources := make(map[string]float64)
+	for _, g := range snapshot.Gauges() {
+		Equals(t, "owner/repo", g.Tags()["base_repo"])
+		resources[g.Name()] = g.Value()
+	}
+	Equals(t, map[string]float64{
+		"atlantis.plan_resources.import":  0,
+		"atlantis.plan_resources.add":     1,
+		"atlantis.plan_resources.change":  2,
+		"atlantis.plan_resources.destroy": 3,
+	}, resources)
+}
+
+func TestEmitStepMetrics_NoScope(t *testing.T) {
+	runtime.EmitStepMetrics(command.ProjectContext{}, "plan", time.Second, "", nil)
+}
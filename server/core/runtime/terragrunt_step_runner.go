@@ -0,0 +1,383 @@
+package runtime
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+const (
+	terragruntConfigFile = "terragrunt.hcl"
+	// terragruntRunAllHeader is the first line of the planfile of a project
+	// planned with run_all. The planfile lists the modules that were planned,
+	// in the order they're applied, rather than holding a plan itself.
+	terragruntRunAllHeader = "atlantis terragrunt run-all\n"
+	// terragruntModulePlanExt is the extension of the plans of the modules of
+	// a run_all project. It's not .tfplan so they aren't mistaken for the
+	// plans of projects.
+	terragruntModulePlanExt = ".tgplan"
+)
+
+var terragruntConfigSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "dependency",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type: "dependencies",
+		},
+	},
+}
+
+var terragruntDependencySchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "config_path",
+		},
+	},
+}
+
+var terragruntDependenciesSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "paths",
+		},
+	},
+}
+
+// TerragruntStepRunner runs terragrunt steps. In the plan stage they run
+// terragrunt plan and save the plan to the project's planfile, and in the
+// apply stage they apply it. TERRAGRUNT_TFPATH is set to the project's
+// Terraform distribution and version.
+//
+// With run_all, they run in every module under the project dir like
+// terragrunt run-all, one module at a time in the order of the dependency
+// and dependencies blocks of the modules' terragrunt.hcl. Each module's plan
+// is saved next to its terragrunt.hcl and the project's planfile lists the
+// modules so apply runs them in the same order.
+type TerragruntStepRunner struct {
+	DefaultTFDistribution terraform.Distribution
+	DefaultTFVersion      *version.Version
+	RunStepRunner         *RunStepRunner
+}
+
+func (r *TerragruntStepRunner) Run(ctx command.ProjectContext, extraArgs []string, runAll bool, path string, envs map[string]string) (string, error) {
+	tfDistribution := r.DefaultTFDistribution
+	tfVersion := r.DefaultTFVersion
+	if ctx.TerraformDistribution != nil {
+		tfDistribution = r.RunStepRunner.TerraformExecutor.Distributions().NewDistribution(*ctx.TerraformDistribution)
+	}
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	tgEnvs := make(map[string]string, len(envs)+8)
+	for name, value := range envs {
+		tgEnvs[name] = value
+	}
+	// The binaries that Atlantis downloads are named after their version
+	// and their dir is in the PATH of the commands of steps.
+	tfPath := tfDistribution.BinName() + tfVersion.String()
+	for name, value := range map[string]string{
+		"TERRAGRUNT_TFPATH":          tfPath,
+		"TG_TF_PATH":                 tfPath,
+		"TERRAGRUNT_NON_INTERACTIVE": "true",
+		"TG_NON_INTERACTIVE":         "true",
+		"TERRAGRUNT_NO_COLOR":        "true",
+		"TG_NO_COLOR":                "true",
+		"TF_IN_AUTOMATION":           "true",
+	} {
+		if _, ok := tgEnvs[name]; !ok {
+			tgEnvs[name] = value
+		}
+	}
+	if ctx.Workspace != defaultWorkspace {
+		tgEnvs["TF_WORKSPACE"] = ctx.Workspace
+	}
+
+	planFile := filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	switch ctx.CommandName {
+	case command.Plan, command.Autoplan:
+		if runAll {
+			return r.planAll(ctx, extraArgs, path, planFile, tfVersion, tgEnvs)
+		}
+		out, err := r.plan(ctx, extraArgs, path, planFile, tgEnvs)
+		if err != nil {
+			return "", err
+		}
+		return fmtPlanOutput(out, tfVersion, ctx.Verbose), nil
+	case command.Apply:
+		if runAll {
+			return r.applyAll(ctx, extraArgs, path, planFile, tgEnvs)
+		}
+		if _, err := os.Stat(planFile); os.IsNotExist(err) {
+			return "", fmt.Errorf("no plan found at path %q and workspace %q–did you run plan?", ctx.RepoRelDir, ctx.Workspace)
+		}
+		return r.apply(ctx, extraArgs, path, planFile, tgEnvs)
+	default:
+		return "", fmt.Errorf("terragrunt steps can only be used in the plan and apply stages, not %s", ctx.CommandName)
+	}
+}
+
+// plan runs terragrunt plan in dir and saves the plan to planFile.
+func (r *TerragruntStepRunner) plan(ctx command.ProjectContext, extraArgs []string, dir string, planFile string, envs map[string]string) (string, error) {
+	// NOTE: the plan file is quoted because Bitbucket Server can have spaces
+	// in its repo owner names.
+	args := [][]string{
+		{"terragrunt", "plan", "-input=false", "-no-color", "-out", fmt.Sprintf("%q", planFile)},
+		projectVarArgs(ctx),
+		extraArgs,
+		ctx.EscapedCommentArgs,
+	}
+	return r.run(ctx, args, dir, envs)
+}
+
+// apply runs terragrunt apply in dir with the plan at planFile and deletes it
+// if the apply succeeds.
+func (r *TerragruntStepRunner) apply(ctx command.ProjectContext, extraArgs []string, dir string, planFile string, envs map[string]string) (string, error) {
+	args := [][]string{
+		{"terragrunt", "apply", "-input=false", "-no-color"},
+		extraArgs,
+		ctx.EscapedCommentArgs,
+		{fmt.Sprintf("%q", planFile)},
+	}
+	out, err := r.run(ctx, args, dir, envs)
+	if err != nil {
+		return "", err
+	}
+	if removeErr := utils.RemoveIgnoreNonExistent(planFile); removeErr != nil {
+		ctx.Log.Warn("failed to delete planfile after successful apply: %s", removeErr)
+	}
+	return out, nil
+}
+
+func (r *TerragruntStepRunner) planAll(ctx command.ProjectContext, extraArgs []string, path string, planFile string, tfVersion *version.Version, envs map[string]string) (string, error) {
+	modules, err := terragruntModules(path)
+	if err != nil {
+		return "", err
+	}
+	if len(modules) == 0 {
+		return "", fmt.Errorf("no %s files found under %q", terragruntConfigFile, ctx.RepoRelDir)
+	}
+	// Like terragrunt run-all destroy, destroy plans are made and applied in
+	// the reverse order of the dependencies. Comment args are escaped
+	// character by character.
+	if utils.SlicesContains(extraArgs, "-destroy") || utils.SlicesContains(ctx.EscapedCommentArgs, "\\-\\d\\e\\s\\t\\r\\o\\y") {
+		for i, j := 0, len(modules)-1; i < j; i, j = i+1, j-1 {
+			modules[i], modules[j] = modules[j], modules[i]
+		}
+	}
+
+	// The old plans are deleted first so a failed plan can't be applied.
+	if err := utils.RemoveIgnoreNonExistent(planFile); err != nil {
+		return "", errors.Wrap(err, "deleting old planfile")
+	}
+	modulePlanFile := strings.TrimSuffix(filepath.Base(planFile), ".tfplan") + terragruntModulePlanExt
+	var outputs []string
+	manifest := terragruntRunAllHeader
+	for _, module := range modules {
+		out, err := r.plan(ctx, extraArgs, filepath.Join(path, module), filepath.Join(path, module, modulePlanFile), envs)
+		if err != nil {
+			return "", errors.Wrapf(err, "module %s", module)
+		}
+		outputs = append(outputs, fmt.Sprintf("Module %s:\n%s", module, fmtPlanOutput(out, tfVersion, ctx.Verbose)))
+		manifest += module + "\n"
+	}
+	if err := os.WriteFile(planFile, []byte(manifest), 0600); err != nil {
+		return "", errors.Wrap(err, "writing planfile")
+	}
+	return strings.Join(outputs, "\n"), nil
+}
+
+func (r *TerragruntStepRunner) applyAll(ctx command.ProjectContext, extraArgs []string, path string, planFile string, envs map[string]string) (string, error) {
+	manifest, err := os.ReadFile(planFile) // nolint: gosec
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("no plan found at path %q and workspace %q–did you run plan?", ctx.RepoRelDir, ctx.Workspace)
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "unable to read planfile")
+	}
+	if !strings.HasPrefix(string(manifest), terragruntRunAllHeader) {
+		return "", fmt.Errorf("the plan of %q wasn't made by a terragrunt step with run_all", ctx.RepoRelDir)
+	}
+
+	modulePlanFile := strings.TrimSuffix(filepath.Base(planFile), ".tfplan") + terragruntModulePlanExt
+	modules := strings.Split(strings.TrimSpace(strings.TrimPrefix(string(manifest), terragruntRunAllHeader)), "\n")
+	var outputs []string
+	for _, module := range modules {
+		if module == "" {
+			continue
+		}
+		moduleDir := filepath.Join(path, module)
+		// Modules whose plans were already applied, ex. before another
+		// module failed, are skipped.
+		if _, err := os.Stat(filepath.Join(moduleDir, modulePlanFile)); os.IsNotExist(err) {
+			continue
+		}
+		out, err := r.apply(ctx, extraArgs, moduleDir, filepath.Join(moduleDir, modulePlanFile), envs)
+		if err != nil {
+			return strings.Join(outputs, "\n"), errors.Wrapf(err, "module %s", module)
+		}
+		outputs = append(outputs, fmt.Sprintf("Module %s:\n%s", module, out))
+	}
+	if removeErr := utils.RemoveIgnoreNonExistent(planFile); removeErr != nil {
+		ctx.Log.Warn("failed to delete planfile after successful apply: %s", removeErr)
+	}
+	return strings.Join(outputs, "\n"), nil
+}
+
+func (r *TerragruntStepRunner) run(ctx command.ProjectContext, args [][]string, dir string, envs map[string]string) (string, error) {
+	var cmd []string
+	for _, a := range args {
+		cmd = append(cmd, a...)
+	}
+	return r.RunStepRunner.Run(ctx, nil, strings.Join(cmd, " "), dir, envs, true, valid.PostProcessRunOutputShow)
+}
+
+// terragruntModules returns the dirs relative to path that have a
+// terragrunt.hcl file, including path itself, in the order they must be run
+// in so that each module runs after the modules it depends on. Modules
+// without dependencies between them are in lexical order.
+func terragruntModules(path string) ([]string, error) {
+	var modules []string
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", ".terraform", ".terragrunt-cache":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == terragruntConfigFile {
+			rel, err := filepath.Rel(path, filepath.Dir(p))
+			if err != nil {
+				return err
+			}
+			modules = append(modules, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding %s files", terragruntConfigFile)
+	}
+
+	deps := make(map[string][]string, len(modules))
+	for _, module := range modules {
+		moduleDeps, err := terragruntDependencies(filepath.Join(path, module, terragruntConfigFile))
+		if err != nil {
+			return nil, errors.Wrapf(err, "module %s", module)
+		}
+		for _, dep := range moduleDeps {
+			if !filepath.IsAbs(dep) {
+				dep = filepath.Join(path, module, dep)
+			}
+			// Dependencies outside of path aren't run so they're ignored.
+			rel, err := filepath.Rel(path, dep)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+				continue
+			}
+			deps[module] = append(deps[module], rel)
+		}
+	}
+
+	var ordered []string
+	done := make(map[string]bool, len(modules))
+	for len(ordered) < len(modules) {
+		progress := false
+		for _, module := range modules {
+			if done[module] {
+				continue
+			}
+			ready := true
+			for _, dep := range deps[module] {
+				if !done[dep] && utils.SlicesContains(modules, dep) {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				ordered = append(ordered, module)
+				done[module] = true
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			var cycle []string
+			for _, module := range modules {
+				if !done[module] {
+					cycle = append(cycle, module)
+				}
+			}
+			sort.Strings(cycle)
+			return nil, fmt.Errorf("dependency cycle between modules %s", strings.Join(cycle, ", "))
+		}
+	}
+	return ordered, nil
+}
+
+// terragruntDependencies returns the config_path of the dependency blocks and
+// the paths of the dependencies block of the terragrunt.hcl at file. They must
+// be literal strings because functions and locals aren't evaluated.
+func terragruntDependencies(file string) ([]string, error) {
+	f, diags := hclparse.NewParser().ParseHCLFile(file)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	content, _, diags := f.Body.PartialContent(terragruntConfigSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var deps []string
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "dependency":
+			attrs, _, diags := block.Body.PartialContent(terragruntDependencySchema)
+			if diags.HasErrors() {
+				return nil, diags
+			}
+			attr, ok := attrs.Attributes["config_path"]
+			if !ok {
+				continue
+			}
+			var dep string
+			if diags := gohcl.DecodeExpression(attr.Expr, nil, &dep); diags.HasErrors() {
+				return nil, fmt.Errorf("config_path of dependency %q must be a literal path: %s", block.Labels[0], diags)
+			}
+			deps = append(deps, dep)
+		case "dependencies":
+			attrs, _, diags := block.Body.PartialContent(terragruntDependenciesSchema)
+			if diags.HasErrors() {
+				return nil, diags
+			}
+			attr, ok := attrs.Attributes["paths"]
+			if !ok {
+				continue
+			}
+			var paths []string
+			if diags := gohcl.DecodeExpression(attr.Expr, nil, &paths); diags.HasErrors() {
+				return nil, fmt.Errorf("paths of dependencies must be a list of literal paths: %s", diags)
+			}
+			deps = append(deps, paths...)
+		}
+	}
+	return deps, nil
+}
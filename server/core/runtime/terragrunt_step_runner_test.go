@@ -0,0 +1,210 @@
+package runtime_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	tf "github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	jobmocks "github.com/runatlantis/atlantis/server/jobs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// newTerragruntStepRunner returns a runner whose terragrunt binary records
+// the dir, command and TERRAGRUNT_TFPATH of each call in the returned file and
+// creates the -out file of plans.
+func newTerragruntStepRunner(t *testing.T) (*runtime.TerragruntStepRunner, string) {
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	When(terraform.EnsureVersion(Any[logging.SimpleLogging](), Any[tf.Distribution](), Any[*version.Version]())).
+		ThenReturn(nil)
+
+	binDir := t.TempDir()
+	calls := filepath.Join(binDir, "calls")
+	script := fmt.Sprintf(`#!/bin/sh
+echo "$(basename "$PWD") $1 $TERRAGRUNT_TFPATH" >> %s
+case "$1" in
+  plan)
+    while [ $# -gt 0 ]; do
+      if [ "$1" = "-out" ]; then touch "$2"; fi
+      shift
+    done
+    echo "  + random_id.id"
+    ;;
+  apply) echo "Apply complete!" ;;
+esac
+`, calls)
+	Ok(t, os.WriteFile(filepath.Join(binDir, "terragrunt"), []byte(script), 0700)) // #nosec G306
+	t.Setenv("PATH", binDir+":"+os.Getenv("PATH"))
+
+	tfVersion, _ := version.NewVersion("1.9.0")
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader())
+	return &runtime.TerragruntStepRunner{
+		DefaultTFDistribution: tfDistribution,
+		DefaultTFVersion:      tfVersion,
+		RunStepRunner: &runtime.RunStepRunner{
+			TerraformExecutor:       terraform,
+			DefaultTFDistribution:   tfDistribution,
+			DefaultTFVersion:        tfVersion,
+			TerraformBinDir:         binDir,
+			ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+		},
+	}, calls
+}
+
+func writeTerragruntModules(t *testing.T, modules map[string]string) string {
+	dir := t.TempDir()
+	for module, config := range modules {
+		Ok(t, os.MkdirAll(filepath.Join(dir, module), 0700))
+		Ok(t, os.WriteFile(filepath.Join(dir, module, "terragrunt.hcl"), []byte(config), 0600))
+	}
+	return dir
+}
+
+func TestTerragruntStepRunner_Run(t *testing.T) {
+	r, calls := newTerragruntStepRunner(t)
+	dir := writeTerragruntModules(t, map[string]string{".": ""})
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		Workspace:   "default",
+		RepoRelDir:  ".",
+		CommandName: command.Plan,
+	}
+
+	out, err := r.Run(ctx, nil, false, dir, map[string]string{})
+	Ok(t, err)
+	Equals(t, "+ random_id.id\n", out)
+	_, err = os.Stat(filepath.Join(dir, "default.tfplan"))
+	Ok(t, err)
+
+	ctx.CommandName = command.Apply
+	out, err = r.Run(ctx, nil, false, dir, map[string]string{})
+	Ok(t, err)
+	Equals(t, "Apply complete!\n", out)
+	_, err = os.Stat(filepath.Join(dir, "default.tfplan"))
+	Assert(t, os.IsNotExist(err), "expected the planfile to be deleted after the apply")
+
+	called, err := os.ReadFile(calls) // nolint: gosec
+	Ok(t, err)
+	base := filepath.Base(dir)
+	Equals(t, fmt.Sprintf("%[1]s plan terraform1.9.0\n%[1]s apply terraform1.9.0\n", base), string(called))
+}
+
+func TestTerragruntStepRunner_Run_NoPlan(t *testing.T) {
+	r, _ := newTerragruntStepRunner(t)
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		Workspace:   "default",
+		RepoRelDir:  "live",
+		CommandName: command.Apply,
+	}
+	_, err := r.Run(ctx, nil, false, t.TempDir(), map[string]string{})
+	ErrEquals(t, "no plan found at path \"live\" and workspace \"default\"–did you run plan?", err)
+}
+
+func TestTerragruntStepRunner_Run_RunAll(t *testing.T) {
+	r, calls := newTerragruntStepRunner(t)
+	dir := writeTerragruntModules(t, map[string]string{
+		"app": `
+dependency "vpc" {
+  config_path = "../vpc"
+}
+dependency "db" {
+  config_path = "../db"
+}`,
+		"db": `
+dependencies {
+  paths = ["../vpc", "../../shared"]
+}`,
+		"vpc": "",
+	})
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		Workspace:   "default",
+		RepoRelDir:  "live",
+		CommandName: command.Plan,
+	}
+
+	out, err := r.Run(ctx, nil, true, dir, map[string]string{})
+	Ok(t, err)
+	Equals(t, "Module vpc:\n+ random_id.id\n\nModule db:\n+ random_id.id\n\nModule app:\n+ random_id.id\n", out)
+	planfile, err := os.ReadFile(filepath.Join(dir, "default.tfplan")) // nolint: gosec
+	Ok(t, err)
+	Equals(t, "atlantis terragrunt run-all\nvpc\ndb\napp\n", string(planfile))
+	_, err = os.Stat(filepath.Join(dir, "app", "default.tgplan"))
+	Ok(t, err)
+
+	ctx.CommandName = command.Apply
+	out, err = r.Run(ctx, nil, true, dir, map[string]string{})
+	Ok(t, err)
+	Equals(t, "Module vpc:\nApply complete!\n\nModule db:\nApply complete!\n\nModule app:\nApply complete!\n", out)
+	for _, plan := range []string{"default.tfplan", "app/default.tgplan", "db/default.tgplan", "vpc/default.tgplan"} {
+		_, err = os.Stat(filepath.Join(dir, plan))
+		Assert(t, os.IsNotExist(err), "expected %s to be deleted after the apply", plan)
+	}
+
+	called, err := os.ReadFile(calls) // nolint: gosec
+	Ok(t, err)
+	var order []string
+	for _, call := range strings.Split(strings.TrimSpace(string(called)), "\n") {
+		order = append(order, strings.Join(strings.Fields(call)[:2], " "))
+	}
+	Equals(t, []string{"vpc plan", "db plan", "app plan", "vpc apply", "db apply", "app apply"}, order)
+}
+
+func TestTerragruntStepRunner_Run_RunAllErrors(t *testing.T) {
+	cases := []struct {
+		description string
+		modules     map[string]string
+		expErr      string
+	}{
+		{
+			"cycle",
+			map[string]string{
+				"a": `dependency "b" { config_path = "../b" }`,
+				"b": `dependencies { paths = ["../a"] }`,
+				"c": "",
+			},
+			"dependency cycle between modules a, b",
+		},
+		{
+			"config_path isn't a literal",
+			map[string]string{
+				"a": `dependency "b" { config_path = find_in_parent_folders("b") }`,
+			},
+			"module a: config_path of dependency \"b\" must be a literal path",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r, _ := newTerragruntStepRunner(t)
+			ctx := command.ProjectContext{
+				Log:         logging.NewNoopLogger(t),
+				Workspace:   "default",
+				CommandName: command.Plan,
+			}
+			_, err := r.Run(ctx, nil, true, writeTerragruntModules(t, c.modules), map[string]string{})
+			ErrContains(t, c.expErr, err)
+		})
+	}
+}
+
+func TestTerragruntStepRunner_Run_OtherStage(t *testing.T) {
+	r, _ := newTerragruntStepRunner(t)
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		Workspace:   "default",
+		CommandName: command.Import,
+	}
+	_, err := r.Run(ctx, nil, false, t.TempDir(), map[string]string{})
+	ErrEquals(t, "terragrunt steps can only be used in the plan and apply stages, not import", err)
+}
@@ -0,0 +1,51 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// varFileCommands are the terraform commands var files are passed to.
+var varFileCommands = []string{"plan", "import"}
+
+// VarFileStepRunner runs var_file steps. They look for a <workspace>.tfvars
+// file in the project dir, or in the dirs relative to it set in extra_args,
+// and pass the first one found to terraform plan and import with the
+// TF_CLI_ARGS_plan and TF_CLI_ARGS_import environment variables.
+type VarFileStepRunner struct{}
+
+func (r *VarFileStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	dirs := extraArgs
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	name := ctx.Workspace + ".tfvars"
+	for _, dir := range dirs {
+		varFile := filepath.Join(dir, name)
+		if _, err := os.Stat(filepath.Join(path, varFile)); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", errors.Wrapf(err, "checking for var file %s", varFile)
+		}
+
+		arg := "-var-file=" + filepath.ToSlash(varFile)
+		for _, cmd := range varFileCommands {
+			// Keep the args that are already set for the command.
+			key := "TF_CLI_ARGS_" + cmd
+			existing, ok := envs[key]
+			if !ok {
+				existing = os.Getenv(key)
+			}
+			envs[key] = strings.TrimSpace(existing + " " + arg)
+		}
+		ctx.Log.Info("using var file %s", varFile)
+		return "", nil
+	}
+	ctx.Log.Info("no var file %s found, not using one", name)
+	return "", nil
+}
@@ -0,0 +1,75 @@
+package runtime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestVarFileStepRunner_Run(t *testing.T) {
+	tmpDir := t.TempDir()
+	Ok(t, os.MkdirAll(filepath.Join(tmpDir, "vars"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(tmpDir, "staging.tfvars"), nil, 0600))
+	Ok(t, os.WriteFile(filepath.Join(tmpDir, "vars", "production.tfvars"), nil, 0600))
+
+	cases := []struct {
+		description string
+		workspace   string
+		extraArgs   []string
+		envs        map[string]string
+		expEnvs     map[string]string
+	}{
+		{
+			description: "var file in project dir",
+			workspace:   "staging",
+			envs:        map[string]string{},
+			expEnvs: map[string]string{
+				"TF_CLI_ARGS_plan":   "-var-file=staging.tfvars",
+				"TF_CLI_ARGS_import": "-var-file=staging.tfvars",
+			},
+		},
+		{
+			description: "var file in extra_args dir",
+			workspace:   "production",
+			extraArgs:   []string{".", "vars"},
+			envs:        map[string]string{},
+			expEnvs: map[string]string{
+				"TF_CLI_ARGS_plan":   "-var-file=vars/production.tfvars",
+				"TF_CLI_ARGS_import": "-var-file=vars/production.tfvars",
+			},
+		},
+		{
+			description: "keeps existing args",
+			workspace:   "staging",
+			envs:        map[string]string{"TF_CLI_ARGS_plan": "-lock-timeout=5m"},
+			expEnvs: map[string]string{
+				"TF_CLI_ARGS_plan":   "-lock-timeout=5m -var-file=staging.tfvars",
+				"TF_CLI_ARGS_import": "-var-file=staging.tfvars",
+			},
+		},
+		{
+			description: "no var file",
+			workspace:   "default",
+			envs:        map[string]string{},
+			expEnvs:     map[string]string{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			ctx := command.ProjectContext{
+				Log:       logging.NewNoopLogger(t),
+				Workspace: c.workspace,
+			}
+			r := runtime.VarFileStepRunner{}
+			out, err := r.Run(ctx, c.extraArgs, tmpDir, c.envs)
+			Ok(t, err)
+			Equals(t, "", out)
+			Equals(t, c.expEnvs, c.envs)
+		})
+	}
+}
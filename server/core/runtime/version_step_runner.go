@@ -1,18 +1,49 @@
 package runtime
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/runatlantis/atlantis/server/core/terraform"
 	"github.com/runatlantis/atlantis/server/events/command"
 )
 
+// lockFileName is the dependency lock file that terraform init writes the
+// selected provider versions to.
+const lockFileName = ".terraform.lock.hcl"
+
+var lockFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "provider",
+			LabelNames: []string{"source"},
+		},
+	},
+}
+
+var lockFileProviderSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "version",
+		},
+	},
+}
+
 // VersionStepRunner runs a version command given a ctx
 type VersionStepRunner struct {
 	TerraformExecutor     TerraformExec
 	DefaultTFDistribution terraform.Distribution
 	DefaultTFVersion      *version.Version
+	// DefaultConftestVersion is the version of conftest that policy checks
+	// use if the policies don't set one. It's nil if it isn't configured.
+	DefaultConftestVersion *version.Version
 }
 
 // Run ensures a given version for the executable, builds the args from the project context and then runs executable returning the result
@@ -20,12 +51,96 @@ func (v *VersionStepRunner) Run(ctx command.ProjectContext, _ []string, path str
 	tfDistribution := v.DefaultTFDistribution
 	tfVersion := v.DefaultTFVersion
 	if ctx.TerraformDistribution != nil {
-		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+		tfDistribution = v.TerraformExecutor.Distributions().NewDistribution(*ctx.TerraformDistribution)
 	}
 	if ctx.TerraformVersion != nil {
 		tfVersion = ctx.TerraformVersion
 	}
 
 	versionCmd := []string{"version"}
-	return v.TerraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), versionCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
+	out, err := v.TerraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), versionCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
+	if err != nil {
+		return out, err
+	}
+	return strings.TrimRight(out, "\n") + "\n\n" + v.environmentReport(ctx, path, tfDistribution, tfVersion), nil
+}
+
+// environmentReport describes the versions Atlantis uses for the project so
+// that they can be compared with the ones used locally.
+func (v *VersionStepRunner) environmentReport(ctx command.ProjectContext, path string, tfDistribution terraform.Distribution, tfVersion *version.Version) string {
+	var report strings.Builder
+	report.WriteString("Atlantis environment:\n")
+
+	binName := "terraform"
+	if tfDistribution != nil {
+		binName = tfDistribution.BinName()
+	}
+	fmt.Fprintf(&report, "  Distribution: %s\n", binName)
+	switch {
+	case ctx.TerraformVersion != nil:
+		fmt.Fprintf(&report, "  Version: %s (set by the project)\n", tfVersion)
+	case tfVersion != nil:
+		fmt.Fprintf(&report, "  Version: %s (server default)\n", tfVersion)
+	default:
+		fmt.Fprintf(&report, "  Version: the %s binary in the server's $PATH\n", binName)
+	}
+
+	providers, err := lockFileProviders(filepath.Join(path, lockFileName))
+	switch {
+	case err != nil:
+		fmt.Fprintf(&report, "  Providers: unable to read %s: %s\n", lockFileName, err)
+	case len(providers) == 0:
+		fmt.Fprintf(&report, "  Providers: no %s, run plan first\n", lockFileName)
+	default:
+		fmt.Fprintf(&report, "  Providers (from %s):\n", lockFileName)
+		for _, provider := range providers {
+			fmt.Fprintf(&report, "    %s\n", provider)
+		}
+	}
+
+	if len(ctx.PolicySets.PolicySets) > 0 {
+		switch {
+		case ctx.PolicySets.Version != nil:
+			fmt.Fprintf(&report, "  Policy engine: conftest %s (set by the policies)\n", ctx.PolicySets.Version)
+		case v.DefaultConftestVersion != nil:
+			fmt.Fprintf(&report, "  Policy engine: conftest %s (server default)\n", v.DefaultConftestVersion)
+		default:
+			report.WriteString("  Policy engine: the conftest binary in the server's $PATH\n")
+		}
+	}
+	return report.String()
+}
+
+// lockFileProviders returns the sources and versions of the providers in the
+// lock file at path, ex. "registry.terraform.io/hashicorp/aws 5.1.0", sorted
+// by source. It returns nil if there's no lock file.
+func lockFileProviders(path string) ([]string, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	file, diags := hclparse.NewParser().ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	content, _, diags := file.Body.PartialContent(lockFileSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var providers []string
+	for _, block := range content.Blocks {
+		attrs, _, diags := block.Body.PartialContent(lockFileProviderSchema)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		providerVersion := "unknown"
+		if attr, ok := attrs.Attributes["version"]; ok {
+			if diags := gohcl.DecodeExpression(attr.Expr, nil, &providerVersion); diags.HasErrors() {
+				return nil, diags
+			}
+		}
+		providers = append(providers, block.Labels[0]+" "+providerVersion)
+	}
+	sort.Strings(providers)
+	return providers, nil
 }
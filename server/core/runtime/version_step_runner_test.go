@@ -1,10 +1,13 @@
 package runtime
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hashicorp/go-version"
 	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	tf "github.com/runatlantis/atlantis/server/core/terraform"
 	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
 	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
@@ -94,3 +97,52 @@ func TestVersionStepRunner_Run_UsesConfiguredDistribution(t *testing.T) {
 		Ok(t, err)
 	})
 }
+
+func TestVersionStepRunner_Run_EnvironmentReport(t *testing.T) {
+	RegisterMockTestingT(t)
+	tfVersion, _ := version.NewVersion("1.5.7")
+	conftestVersion, _ := version.NewVersion("0.46.0")
+	context := command.ProjectContext{
+		Log:       logging.NewNoopLogger(t),
+		Workspace: "default",
+		PolicySets: valid.PolicySets{
+			PolicySets: []valid.PolicySet{{Name: "policies"}},
+		},
+	}
+	tmpDir := t.TempDir()
+	lockFile := `provider "registry.terraform.io/hashicorp/random" {
+  version = "3.5.1"
+  hashes = ["h1:abc"]
+}
+
+provider "registry.terraform.io/hashicorp/aws" {
+  version     = "5.1.0"
+  constraints = "~> 5.0"
+}
+`
+	Ok(t, os.WriteFile(filepath.Join(tmpDir, ".terraform.lock.hcl"), []byte(lockFile), 0600))
+
+	terraform := tfclientmocks.NewMockClient()
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader())
+	When(terraform.RunCommandWithVersion(Any[command.ProjectContext](), Any[string](), Any[[]string](), Any[map[string]string](), Any[tf.Distribution](), Any[*version.Version](), Any[string]())).
+		ThenReturn("Terraform v1.5.7\n", nil)
+	s := &VersionStepRunner{
+		TerraformExecutor:      terraform,
+		DefaultTFDistribution:  tfDistribution,
+		DefaultTFVersion:       tfVersion,
+		DefaultConftestVersion: conftestVersion,
+	}
+
+	out, err := s.Run(context, nil, tmpDir, nil)
+	Ok(t, err)
+	Equals(t, `Terraform v1.5.7
+
+Atlantis environment:
+  Distribution: terraform
+  Version: 1.5.7 (server default)
+  Providers (from .terraform.lock.hcl):
+    registry.terraform.io/hashicorp/aws 5.1.0
+    registry.terraform.io/hashicorp/random 3.5.1
+  Policy engine: conftest 0.46.0 (server default)
+`, out)
+}
@@ -0,0 +1,13 @@
+package runtime
+
+import (
+	"github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+)
+
+// NewWorkspaceSelectStepRunner returns the runner of workspace_select steps.
+// They select the project's workspace and create it if it doesn't exist, ex.
+// for run steps that call terraform themselves.
+func NewWorkspaceSelectStepRunner(terraformExecutor TerraformExec, defaultTfDistribution terraform.Distribution, defaultTfVersion *version.Version) Runner {
+	return NewWorkspaceStepRunnerDelegate(terraformExecutor, defaultTfDistribution, defaultTfVersion, NullRunner{})
+}
@@ -1,6 +1,8 @@
 package ansi
 
 import (
+	"bytes"
+	"io"
 	"regexp"
 )
 
@@ -11,3 +13,43 @@ var re = regexp.MustCompile(ansi)
 func Strip(str string) string {
 	return re.ReplaceAllString(str, "")
 }
+
+// StripWriter strips ANSI escape sequences from what's written to it and
+// writes the result to the underlying writer. Escape sequences never span
+// lines so output is stripped a line at a time and only the current line is
+// buffered, unlike Strip which needs the whole output in memory.
+type StripWriter struct {
+	w    io.Writer
+	line []byte
+}
+
+func NewStripWriter(w io.Writer) *StripWriter {
+	return &StripWriter{w: w}
+}
+
+func (s *StripWriter) Write(p []byte) (int, error) {
+	s.line = append(s.line, p...)
+	rest := s.line
+	for {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			break
+		}
+		if _, err := s.w.Write(re.ReplaceAll(rest[:i+1], nil)); err != nil {
+			return 0, err
+		}
+		rest = rest[i+1:]
+	}
+	s.line = append(s.line[:0], rest...)
+	return len(p), nil
+}
+
+// Flush writes the last line if it didn't end with a newline.
+func (s *StripWriter) Flush() error {
+	if len(s.line) == 0 {
+		return nil
+	}
+	_, err := s.w.Write(re.ReplaceAll(s.line, nil))
+	s.line = s.line[:0]
+	return err
+}
@@ -1,6 +1,9 @@
 package ansi
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestStrip(t *testing.T) {
 	tests := []struct {
@@ -28,3 +31,21 @@ Plan: 3 to add, 0 to change, 0 to destroy.
 		})
 	}
 }
+
+func TestStripWriter(t *testing.T) {
+	var out strings.Builder
+	w := NewStripWriter(&out)
+	// Escape sequences and lines are split across writes.
+	for _, p := range []string{"\x1b[32", "m+\x1b[0m create\n\x1b[0m\x1b[1mPlan:", "\x1b[0m 3 to add\n", "\x1b[1mdone\x1b[0m"} {
+		if _, err := w.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "+ create\nPlan: 3 to add\ndone"
+	if got := out.String(); got != want {
+		t.Errorf("StripWriter wrote %q, want %q", got, want)
+	}
+}
@@ -18,16 +18,34 @@ type Distribution interface {
 	ResolveConstraint(context.Context, string) (*version.Version, error)
 }
 
+// NewDistribution returns the distribution named distribution that resolves
+// its versions from the official releases.
 func NewDistribution(distribution string) Distribution {
-	tfDistribution := NewDistributionTerraform()
+	return DistributionFactory{}.NewDistribution(distribution)
+}
+
+// DistributionFactory creates the distributions that projects configure so
+// that they resolve their versions from the same mirror as the default one.
+// The zero value uses the official releases.
+type DistributionFactory struct {
+	// TofuDownloadURL is the mirror of the OpenTofu releases, see
+	// NewDistributionOpenTofuWithDownloadURL.
+	TofuDownloadURL string
+}
+
+// NewDistribution returns the distribution named distribution.
+func (f DistributionFactory) NewDistribution(distribution string) Distribution {
 	if distribution == "opentofu" {
-		tfDistribution = NewDistributionOpenTofu()
+		return NewDistributionOpenTofuWithDownloadURL(f.TofuDownloadURL)
 	}
-	return tfDistribution
+	return NewDistributionTerraform()
 }
 
 type DistributionOpenTofu struct {
 	downloader Downloader
+	// downloadURL is the mirror that versions are resolved from, see
+	// TofuDownloader. The official releases are used if it's empty.
+	downloadURL string
 }
 
 func NewDistributionOpenTofu() Distribution {
@@ -42,6 +60,15 @@ func NewDistributionOpenTofuWithDownloader(downloader Downloader) Distribution {
 	}
 }
 
+// NewDistributionOpenTofuWithDownloadURL returns the OpenTofu distribution
+// that resolves versions from the mirror at downloadURL.
+func NewDistributionOpenTofuWithDownloadURL(downloadURL string) Distribution {
+	return &DistributionOpenTofu{
+		downloader:  &TofuDownloader{},
+		downloadURL: downloadURL,
+	}
+}
+
 func (*DistributionOpenTofu) BinName() string {
 	return "tofu"
 }
@@ -50,8 +77,8 @@ func (d *DistributionOpenTofu) Downloader() Downloader {
 	return d.downloader
 }
 
-func (*DistributionOpenTofu) ResolveConstraint(ctx context.Context, constraintStr string) (*version.Version, error) {
-	dl, err := tofudl.New()
+func (d *DistributionOpenTofu) ResolveConstraint(ctx context.Context, constraintStr string) (*version.Version, error) {
+	dl, err := tofudl.New(tofuDownloadOpts(d.downloadURL)...)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,8 @@ package terraform_test
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/runatlantis/atlantis/server/core/terraform"
@@ -20,6 +22,36 @@ func TestResolveOpenTofuVersions(t *testing.T) {
 	Equals(t, version.String(), "1.8.0")
 }
 
+func TestResolveOpenTofuVersions_DownloadURL(t *testing.T) {
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "/tofu/api.json", r.URL.Path)
+		w.Write([]byte(`{"versions": [{"id": "1.8.1", "files": []}, {"id": "1.7.3", "files": []}]}`)) // nolint: errcheck
+	}))
+	defer mirror.Close()
+
+	d := terraform.NewDistributionOpenTofuWithDownloadURL(mirror.URL + "/tofu/")
+	version, err := d.ResolveConstraint(context.Background(), "< 1.8.0")
+	Ok(t, err)
+	Equals(t, version.String(), "1.7.3")
+}
+
+func TestDistributionFactory_OpenTofuDownloadURL(t *testing.T) {
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "/tofu/api.json", r.URL.Path)
+		w.Write([]byte(`{"versions": [{"id": "1.8.1", "files": []}, {"id": "1.7.3", "files": []}]}`)) // nolint: errcheck
+	}))
+	defer mirror.Close()
+
+	f := terraform.DistributionFactory{TofuDownloadURL: mirror.URL + "/tofu/"}
+	d := f.NewDistribution("opentofu")
+	Equals(t, "tofu", d.BinName())
+	version, err := d.ResolveConstraint(context.Background(), "< 1.8.0")
+	Ok(t, err)
+	Equals(t, version.String(), "1.7.3")
+
+	Equals(t, "terraform", f.NewDistribution("terraform").BinName())
+}
+
 func TestTerraformBinName(t *testing.T) {
 	d := terraform.NewDistributionTerraform()
 	Equals(t, d.BinName(), "terraform")
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/go-version"
 	install "github.com/hashicorp/hc-install"
@@ -20,11 +21,15 @@ type Downloader interface {
 	Install(ctx context.Context, dir string, downloadURL string, v *version.Version) (string, error)
 }
 
+// TofuDownloader downloads OpenTofu from downloadURL, or from the official
+// OpenTofu releases if it's empty. The checksums of the release are verified
+// with the OpenTofu signing key bundled in tofudl, so downloads from a mirror
+// work in air-gapped environments and can't be tampered with.
 type TofuDownloader struct{}
 
-func (d *TofuDownloader) Install(ctx context.Context, dir string, _downloadURL string, v *version.Version) (string, error) {
+func (d *TofuDownloader) Install(ctx context.Context, dir string, downloadURL string, v *version.Version) (string, error) {
 	// Initialize the downloader:
-	dl, err := tofudl.New()
+	dl, err := tofudl.New(tofuDownloadOpts(downloadURL)...)
 	if err != nil {
 		return "", err
 	}
@@ -68,3 +73,20 @@ func (d *TerraformDownloader) Install(ctx context.Context, dir string, downloadU
 
 	return newPath, nil
 }
+
+// tofuDownloadOpts returns the tofudl options to download OpenTofu from the
+// mirror at downloadURL. The mirror serves the releases API at
+// <downloadURL>/api.json and the release files at
+// <downloadURL>/v<version>/<file>, like the mirrors created by tofudl do.
+// It returns no options, so the official releases are used, if downloadURL
+// is empty.
+func tofuDownloadOpts(downloadURL string) []tofudl.ConfigOpt {
+	if downloadURL == "" {
+		return nil
+	}
+	downloadURL = strings.TrimSuffix(downloadURL, "/")
+	return []tofudl.ConfigOpt{
+		tofudl.ConfigAPIURL(downloadURL + "/api.json"),
+		tofudl.ConfigDownloadMirrorURLTemplate(downloadURL + "/v{{ .Version }}/{{ .Artifact }}"),
+	}
+}
@@ -35,7 +35,7 @@ func TestOpenTofuInstall(t *testing.T) {
 
 	v, _ := version.NewVersion("1.8.0")
 
-	newPath, err := d.Install(context.Background(), binDir, cmd.DefaultTFDownloadURL, v)
+	newPath, err := d.Install(context.Background(), binDir, "", v)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -43,6 +43,21 @@ func (mock *MockClient) DetectVersion(log logging.SimpleLogging, projectDirector
 	return _ret0
 }
 
+func (mock *MockClient) Distributions() terraform.DistributionFactory {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockClient().")
+	}
+	_params := []pegomock.Param{}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("Distributions", _params, []reflect.Type{reflect.TypeOf((*terraform.DistributionFactory)(nil)).Elem()})
+	var _ret0 terraform.DistributionFactory
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(terraform.DistributionFactory)
+		}
+	}
+	return _ret0
+}
+
 func (mock *MockClient) EnsureVersion(log logging.SimpleLogging, d terraform.Distribution, v *go_version.Version) error {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockClient().")
@@ -149,6 +164,23 @@ func (c *MockClient_DetectVersion_OngoingVerification) GetAllCapturedArguments()
 	return
 }
 
+func (verifier *VerifierMockClient) Distributions() *MockClient_Distributions_OngoingVerification {
+	_params := []pegomock.Param{}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Distributions", _params, verifier.timeout)
+	return &MockClient_Distributions_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockClient_Distributions_OngoingVerification struct {
+	mock              *MockClient
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockClient_Distributions_OngoingVerification) GetCapturedArguments() {
+}
+
+func (c *MockClient_Distributions_OngoingVerification) GetAllCapturedArguments() {
+}
+
 func (verifier *VerifierMockClient) EnsureVersion(log logging.SimpleLogging, d terraform.Distribution, v *go_version.Version) *MockClient_EnsureVersion_OngoingVerification {
 	_params := []pegomock.Param{log, d, v}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "EnsureVersion", _params, verifier.timeout)
@@ -40,14 +40,15 @@ import (
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
-var LogStreamingValidCmds = [...]string{"init", "plan", "apply"}
+var LogStreamingValidCmds = [...]string{"init", "plan", "apply", "import"}
 
 //go:generate pegomock generate --package mocks -o mocks/mock_terraform_client.go Client
 
 type Client interface {
-	// RunCommandWithVersion executes terraform with args in path. If v is nil,
-	// it will use the default Terraform version. workspace is the Terraform
-	// workspace which should be set as an environment variable.
+	// RunCommandWithVersion executes terraform with args in path. If d or v
+	// is nil, it will use the default distribution or Terraform version.
+	// workspace is the Terraform workspace which should be set as an
+	// environment variable.
 	RunCommandWithVersion(ctx command.ProjectContext, path string, args []string, envs map[string]string, d terraform.Distribution, v *version.Version, workspace string) (string, error)
 
 	// EnsureVersion makes sure that terraform version `v` is available to use
@@ -55,6 +56,9 @@ type Client interface {
 
 	// DetectVersion Extracts required_version from Terraform configuration in the specified project directory. Returns nil if unable to determine the version.
 	DetectVersion(log logging.SimpleLogging, projectDirectory string) *version.Version
+
+	// Distributions creates the distributions that projects configure.
+	Distributions() terraform.DistributionFactory
 }
 
 type DefaultClient struct {
@@ -73,6 +77,10 @@ type DefaultClient struct {
 	overrideTF string
 	// settings for the downloader.
 	downloadBaseURL string
+	// tofuDownloadURL is the mirror to download OpenTofu from, or empty to
+	// download it from the official releases. downloadBaseURL is only used
+	// for Terraform.
+	tofuDownloadURL string
 	downloadAllowed bool
 	// versions maps from the string representation of a tf version (ex. 0.11.10)
 	// to the absolute path of that binary on disk (if it exists).
@@ -86,6 +94,10 @@ type DefaultClient struct {
 	usePluginCache bool
 
 	projectCmdOutputHandler jobs.ProjectCommandOutputHandler
+
+	// executor runs terraform, ex. on the Atlantis server or in Kubernetes
+	// Jobs.
+	executor models.Executor
 }
 
 // versionRegex extracts the version from `terraform version` output.
@@ -111,10 +123,12 @@ func NewClientWithDefaultVersion(
 	defaultVersionStr string,
 	defaultVersionFlagName string,
 	tfDownloadURL string,
+	tofuDownloadURL string,
 	tfDownloadAllowed bool,
 	usePluginCache bool,
 	fetchAsync bool,
 	projectCmdOutputHandler jobs.ProjectCommandOutputHandler,
+	executor models.Executor,
 ) (*DefaultClient, error) {
 	var finalDefaultVersion *version.Version
 	var localVersion *version.Version
@@ -149,7 +163,7 @@ func NewClientWithDefaultVersion(
 			// Since ensureVersion might end up downloading terraform,
 			// we call it asynchronously so as to not delay server startup.
 			versionsLock.Lock()
-			_, err := ensureVersion(log, distribution, versions, defaultVersion, binDir, tfDownloadURL, tfDownloadAllowed)
+			_, err := ensureVersion(log, distribution, versions, defaultVersion, binDir, distributionDownloadURL(distribution, tfDownloadURL, tofuDownloadURL), tfDownloadAllowed)
 			versionsLock.Unlock()
 			if err != nil {
 				log.Err("could not download %s %s: %s", distribution.BinName(), defaultVersion.String(), err)
@@ -179,11 +193,13 @@ func NewClientWithDefaultVersion(
 		terraformPluginCacheDir: cacheDir,
 		binDir:                  binDir,
 		downloadBaseURL:         tfDownloadURL,
+		tofuDownloadURL:         tofuDownloadURL,
 		downloadAllowed:         tfDownloadAllowed,
 		versionsLock:            &versionsLock,
 		versions:                versions,
 		usePluginCache:          usePluginCache,
 		projectCmdOutputHandler: projectCmdOutputHandler,
+		executor:                executor,
 	}, nil
 
 }
@@ -198,9 +214,11 @@ func NewTestClient(
 	defaultVersionStr string,
 	defaultVersionFlagName string,
 	tfDownloadURL string,
+	tofuDownloadURL string,
 	tfDownloadAllowed bool,
 	usePluginCache bool,
 	projectCmdOutputHandler jobs.ProjectCommandOutputHandler,
+	executor models.Executor,
 ) (*DefaultClient, error) {
 	return NewClientWithDefaultVersion(
 		log,
@@ -212,10 +230,12 @@ func NewTestClient(
 		defaultVersionStr,
 		defaultVersionFlagName,
 		tfDownloadURL,
+		tofuDownloadURL,
 		tfDownloadAllowed,
 		usePluginCache,
 		false,
 		projectCmdOutputHandler,
+		executor,
 	)
 }
 
@@ -236,9 +256,11 @@ func NewClient(
 	defaultVersionStr string,
 	defaultVersionFlagName string,
 	tfDownloadURL string,
+	tofuDownloadURL string,
 	tfDownloadAllowed bool,
 	usePluginCache bool,
 	projectCmdOutputHandler jobs.ProjectCommandOutputHandler,
+	executor models.Executor,
 ) (*DefaultClient, error) {
 	return NewClientWithDefaultVersion(
 		log,
@@ -250,10 +272,12 @@ func NewClient(
 		defaultVersionStr,
 		defaultVersionFlagName,
 		tfDownloadURL,
+		tofuDownloadURL,
 		tfDownloadAllowed,
 		usePluginCache,
 		true,
 		projectCmdOutputHandler,
+		executor,
 	)
 }
 
@@ -261,6 +285,12 @@ func (c *DefaultClient) DefaultDistribution() terraform.Distribution {
 	return c.distribution
 }
 
+// Distributions creates the distributions that projects configure, which
+// resolve OpenTofu versions from the same mirror as the default distribution.
+func (c *DefaultClient) Distributions() terraform.DistributionFactory {
+	return terraform.DistributionFactory{TofuDownloadURL: c.tofuDownloadURL}
+}
+
 // Version returns the default version of Terraform we use if no other version
 // is defined.
 func (c *DefaultClient) DefaultVersion() *version.Version {
@@ -290,23 +320,35 @@ func (c *DefaultClient) ExtractExactRegex(log logging.SimpleLogging, version str
 }
 
 // DetectVersion extracts required_version from Terraform configuration in the specified project directory. Returns nil if unable to determine the version.
-// It will also try to evaluate non-exact matches by passing the Constraints to the hc-install Releases API, which will return a list of available versions.
-// It will then select the highest version that satisfies the constraint.
+// A version pinned in a tfenv-style .terraform-version file in the directory takes precedence.
+// Otherwise the required_version settings of all the files are combined and the highest version that satisfies them is selected:
+// from the versions available from the hc-install Releases API if downloads are allowed, or from the installed versions otherwise.
 func (c *DefaultClient) DetectVersion(log logging.SimpleLogging, projectDirectory string) *version.Version {
+	if pinned := c.detectVersionFile(log, projectDirectory); pinned != nil {
+		return pinned
+	}
+
 	module, diags := tfconfig.LoadModule(projectDirectory)
 	if diags.HasErrors() {
 		log.Err("trying to detect required version: %s", diags.Error())
 	}
 
-	if len(module.RequiredCore) != 1 {
-		log.Info("cannot determine which version to use from terraform configuration, detected %d possibilities.", len(module.RequiredCore))
+	if len(module.RequiredCore) == 0 {
+		log.Info("cannot determine which version to use from terraform configuration, detected 0 possibilities.")
 		return nil
 	}
-	requiredVersionSetting := module.RequiredCore[0]
+	// Each file can have its own required_version and all of them must be
+	// satisfied.
+	requiredVersionSetting := strings.Join(module.RequiredCore, ", ")
 	log.Debug("Found required_version setting of %q", requiredVersionSetting)
 
 	if !c.downloadAllowed {
 		log.Debug("terraform downloads disabled.")
+		if installed := c.highestInstalledVersion(log, requiredVersionSetting); installed != nil {
+			return installed
+		}
+		// An exact version is still used so that the error says which
+		// version is missing.
 		matched := c.ExtractExactRegex(log, requiredVersionSetting)
 		if len(matched) == 0 {
 			log.Debug("did not specify exact version in terraform configuration, found %q", requiredVersionSetting)
@@ -330,6 +372,74 @@ func (c *DefaultClient) DetectVersion(log logging.SimpleLogging, projectDirector
 	return downloadVersion
 }
 
+// terraformVersionFile is the file tfenv reads the version of a directory
+// from.
+const terraformVersionFile = ".terraform-version"
+
+// detectVersionFile returns the version pinned in the .terraform-version file
+// of projectDirectory, or nil if there's none. Only exact versions are
+// supported. tfenv's keywords, ex. latest-allowed, fall back to
+// required_version.
+func (c *DefaultClient) detectVersionFile(log logging.SimpleLogging, projectDirectory string) *version.Version {
+	content, err := os.ReadFile(filepath.Join(projectDirectory, terraformVersionFile)) // nolint: gosec
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Err("reading %s: %s", terraformVersionFile, err)
+		}
+		return nil
+	}
+	pin, _, _ := strings.Cut(strings.TrimSpace(string(content)), "\n")
+	pin = strings.TrimSpace(pin)
+	pinned, err := version.NewVersion(pin)
+	if err != nil {
+		log.Info("ignoring %s since %q isn't an exact version", terraformVersionFile, pin)
+		return nil
+	}
+	log.Debug("found version %s in %s", pinned, terraformVersionFile)
+	return pinned
+}
+
+// highestInstalledVersion returns the highest version of the distribution that
+// satisfies constraint and is in the versions map or the bin dir, or nil if
+// there's none.
+func (c *DefaultClient) highestInstalledVersion(log logging.SimpleLogging, constraint string) *version.Version {
+	constraints, err := version.NewConstraint(constraint)
+	if err != nil {
+		log.Err("error parsing constraint string: %s", err)
+		return nil
+	}
+
+	installed := make(map[string]bool)
+	c.versionsLock.Lock()
+	for v := range c.versions {
+		installed[v] = true
+	}
+	c.versionsLock.Unlock()
+	binName := c.distribution.BinName()
+	if entries, err := os.ReadDir(c.binDir); err == nil {
+		for _, entry := range entries {
+			if v, ok := strings.CutPrefix(entry.Name(), binName); ok && v != "" {
+				installed[v] = true
+			}
+		}
+	}
+
+	var highest *version.Version
+	for v := range installed {
+		parsed, err := version.NewVersion(v)
+		if err != nil || !constraints.Check(parsed) {
+			continue
+		}
+		if highest == nil || parsed.GreaterThan(highest) {
+			highest = parsed
+		}
+	}
+	if highest != nil {
+		log.Debug("selected installed version %s for constraint %q", highest, constraint)
+	}
+	return highest
+}
+
 // See Client.EnsureVersion.
 func (c *DefaultClient) EnsureVersion(log logging.SimpleLogging, d terraform.Distribution, v *version.Version) error {
 	if v == nil {
@@ -338,7 +448,7 @@ func (c *DefaultClient) EnsureVersion(log logging.SimpleLogging, d terraform.Dis
 
 	var err error
 	c.versionsLock.Lock()
-	_, err = ensureVersion(log, d, c.versions, v, c.binDir, c.downloadBaseURL, c.downloadAllowed)
+	_, err = ensureVersion(log, d, c.versions, v, c.binDir, distributionDownloadURL(d, c.downloadBaseURL, c.tofuDownloadURL), c.downloadAllowed)
 	c.versionsLock.Unlock()
 	if err != nil {
 		return err
@@ -352,62 +462,73 @@ func (c *DefaultClient) RunCommandWithVersion(ctx command.ProjectContext, path s
 	if isAsyncEligibleCommand(args[0]) {
 		_, outCh := c.RunCommandAsync(ctx, path, args, customEnvVars, d, v, workspace)
 
-		var lines []string
+		// The output is sanitized by stripping out any ansi characters a line
+		// at a time as it's read, so that only one copy of it is kept in
+		// memory.
+		var output strings.Builder
 		var err error
 		for line := range outCh {
 			if line.Err != nil {
 				err = line.Err
 				break
 			}
-			lines = append(lines, line.Line)
+			output.WriteString(ansi.Strip(line.Line))
+			output.WriteByte('\n')
 		}
-		output := strings.Join(lines, "\n")
-
-		// sanitize output by stripping out any ansi characters.
-		output = ansi.Strip(output)
-		return fmt.Sprintf("%s\n", output), err
+		if output.Len() == 0 {
+			output.WriteByte('\n')
+		}
+		return output.String(), err
 	}
-	tfCmd, cmd, err := c.prepExecCmd(ctx.Log, d, v, workspace, path, args)
+	tfCmd, cmd, err := c.prepExecCmd(ctx, d, v, workspace, path, args, customEnvVars)
 	if err != nil {
 		return "", err
 	}
-	envVars := cmd.Env
-	for key, val := range customEnvVars {
-		envVars = append(envVars, fmt.Sprintf("%s=%s", key, val))
-	}
-	cmd.Env = envVars
+	// Output is stripped of ansi characters as it's written rather than
+	// buffered and stripped at the end, ex. terraform show -json can print
+	// hundreds of megabytes.
+	var out strings.Builder
+	stripper := ansi.NewStripWriter(&out)
 	start := time.Now()
-	out, err := cmd.CombinedOutput()
+	err = models.RunCombined(cmd, stripper)
+	stripper.Flush() // nolint: errcheck
 	dur := time.Since(start)
 	log := ctx.Log.With("duration", dur)
 	if err != nil {
 		err = errors.Wrapf(err, "running '%s' in '%s'", tfCmd, path)
 		log.Err(err.Error())
-		return ansi.Strip(string(out)), err
+		return out.String(), err
 	}
 	log.Info("Successfully ran '%s' in '%s'", tfCmd, path)
 
-	return ansi.Strip(string(out)), nil
+	return out.String(), nil
 }
 
 // prepExecCmd builds a ready to execute command based on the version of terraform
 // v, and args. It returns a printable representation of the command that will
 // be run and the actual command.
-func (c *DefaultClient) prepExecCmd(log logging.SimpleLogging, d terraform.Distribution, v *version.Version, workspace string, path string, args []string) (string, *exec.Cmd, error) {
-	tfCmd, envVars, err := c.prepCmd(log, d, v, workspace, path, args)
+func (c *DefaultClient) prepExecCmd(ctx command.ProjectContext, d terraform.Distribution, v *version.Version, workspace string, path string, args []string, customEnvVars map[string]string) (string, models.Cmd, error) {
+	tfCmd, envVars, err := c.prepCmd(ctx.Log, d, v, workspace, path, args)
 	if err != nil {
 		return "", nil, err
 	}
-	cmd := exec.Command("sh", "-c", tfCmd)
-	cmd.Dir = path
-	cmd.Env = envVars
-	return tfCmd, cmd, nil
+	for key, val := range customEnvVars {
+		envVars = append(envVars, fmt.Sprintf("%s=%s", key, val))
+	}
+	var executor models.Executor = models.LocalExecutor{}
+	if c.executor != nil {
+		executor = c.executor
+	}
+	return tfCmd, executor.Command(ctx, nil, tfCmd, path, envVars), nil
 }
 
 // prepCmd prepares a shell command (to be interpreted with `sh -c <cmd>`) and set of environment
 // variables for running terraform.
 func (c *DefaultClient) prepCmd(log logging.SimpleLogging, d terraform.Distribution, v *version.Version, workspace string, path string, args []string) (string, []string, error) {
 
+	if d == nil {
+		d = c.distribution
+	}
 	if v == nil {
 		v = c.defaultVersion
 	}
@@ -419,7 +540,7 @@ func (c *DefaultClient) prepCmd(log logging.SimpleLogging, d terraform.Distribut
 	} else {
 		var err error
 		c.versionsLock.Lock()
-		binPath, err = ensureVersion(log, d, c.versions, v, c.binDir, c.downloadBaseURL, c.downloadAllowed)
+		binPath, err = ensureVersion(log, d, c.versions, v, c.binDir, distributionDownloadURL(d, c.downloadBaseURL, c.tofuDownloadURL), c.downloadAllowed)
 		c.versionsLock.Unlock()
 		if err != nil {
 			return "", nil, err
@@ -472,7 +593,7 @@ func (c *DefaultClient) RunCommandAsync(ctx command.ProjectContext, path string,
 		envVars = append(envVars, fmt.Sprintf("%s=%s", key, val))
 	}
 
-	runner := models.NewShellCommandRunner(nil, cmd, envVars, path, true, c.projectCmdOutputHandler)
+	runner := models.NewShellCommandRunner(nil, cmd, envVars, path, true, c.projectCmdOutputHandler, c.executor)
 	inCh, outCh := runner.RunCommandAsync(ctx)
 	return inCh, outCh
 }
@@ -543,6 +664,15 @@ func ensureVersion(
 	return execPath, nil
 }
 
+// distributionDownloadURL returns the URL to download d from: tofuDownloadURL
+// for OpenTofu and tfDownloadURL for Terraform.
+func distributionDownloadURL(d terraform.Distribution, tfDownloadURL string, tofuDownloadURL string) string {
+	if _, ok := d.(*terraform.DistributionOpenTofu); ok {
+		return tofuDownloadURL
+	}
+	return tfDownloadURL
+}
+
 // generateRCFile generates a .terraformrc file containing config for tfeToken
 // and hostname tfeHostname.
 // It will create the file in home/.terraformrc.
@@ -81,7 +81,7 @@ is 0.11.13. You can update by downloading from developer.hashicorp.com/terraform
 	mockDownloader := mocks.NewMockDownloader()
 	distribution := terraform.NewDistributionTerraformWithDownloader(mockDownloader)
 
-	c, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, true, true, projectCmdOutputHandler)
+	c, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, "", true, true, projectCmdOutputHandler, nil)
 	Ok(t, err)
 
 	Ok(t, err)
@@ -118,7 +118,7 @@ is 0.11.13. You can update by downloading from developer.hashicorp.com/terraform
 	mockDownloader := mocks.NewMockDownloader()
 	distribution := terraform.NewDistributionTerraformWithDownloader(mockDownloader)
 
-	c, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, true, true, projectCmdOutputHandler)
+	c, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, "", true, true, projectCmdOutputHandler, nil)
 	Ok(t, err)
 
 	Ok(t, err)
@@ -142,7 +142,7 @@ func TestNewClient_NoTF(t *testing.T) {
 	mockDownloader := mocks.NewMockDownloader()
 	distribution := terraform.NewDistributionTerraformWithDownloader(mockDownloader)
 
-	_, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, true, true, projectCmdOutputHandler)
+	_, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, "", true, true, projectCmdOutputHandler, nil)
 	ErrEquals(t, "terraform not found in $PATH. Set --default-tf-version or download terraform from https://developer.hashicorp.com/terraform/downloads", err)
 }
 
@@ -168,7 +168,7 @@ func TestNewClient_DefaultTFFlagInPath(t *testing.T) {
 	mockDownloader := mocks.NewMockDownloader()
 	distribution := terraform.NewDistributionTerraformWithDownloader(mockDownloader)
 
-	c, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, false, true, projectCmdOutputHandler)
+	c, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, "", false, true, projectCmdOutputHandler, nil)
 	Ok(t, err)
 
 	Ok(t, err)
@@ -199,7 +199,7 @@ func TestNewClient_DefaultTFFlagInBinDir(t *testing.T) {
 	mockDownloader := mocks.NewMockDownloader()
 	distribution := terraform.NewDistributionTerraformWithDownloader(mockDownloader)
 
-	c, err := tfclient.NewClient(logging.NewNoopLogger(t), distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, true, true, projectCmdOutputHandler)
+	c, err := tfclient.NewClient(logging.NewNoopLogger(t), distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, "", true, true, projectCmdOutputHandler, nil)
 	Ok(t, err)
 
 	Ok(t, err)
@@ -233,7 +233,7 @@ func TestNewClient_DefaultTFFlagDownload(t *testing.T) {
 		return []ReturnValue{binPath, err}
 	})
 	distribution := terraform.NewDistributionTerraformWithDownloader(mockDownloader)
-	c, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, true, true, projectCmdOutputHandler)
+	c, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, "", true, true, projectCmdOutputHandler, nil)
 	Ok(t, err)
 
 	Ok(t, err)
@@ -256,7 +256,7 @@ func TestNewClient_BadVersion(t *testing.T) {
 	projectCmdOutputHandler := jobmocks.NewMockProjectCommandOutputHandler()
 	mockDownloader := mocks.NewMockDownloader()
 	distribution := terraform.NewDistributionTerraformWithDownloader(mockDownloader)
-	_, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "malformed", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, true, true, projectCmdOutputHandler)
+	_, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "malformed", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, "", true, true, projectCmdOutputHandler, nil)
 	ErrEquals(t, "Malformed version: malformed", err)
 }
 
@@ -284,7 +284,7 @@ func TestRunCommandWithVersion_DLsTF(t *testing.T) {
 		return []ReturnValue{binPath, err}
 	})
 
-	c, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, true, true, projectCmdOutputHandler)
+	c, err := tfclient.NewClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, "", true, true, projectCmdOutputHandler, nil)
 	Ok(t, err)
 	Equals(t, "0.11.10", c.DefaultVersion().String())
 
@@ -305,7 +305,7 @@ func TestEnsureVersion_downloaded(t *testing.T) {
 	distribution := terraform.NewDistributionTerraformWithDownloader(mockDownloader)
 
 	downloadsAllowed := true
-	c, err := tfclient.NewTestClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, downloadsAllowed, true, projectCmdOutputHandler)
+	c, err := tfclient.NewTestClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, "", downloadsAllowed, true, projectCmdOutputHandler, nil)
 	Ok(t, err)
 
 	Equals(t, "0.11.10", c.DefaultVersion().String())
@@ -338,7 +338,7 @@ func TestEnsureVersion_downloaded_customURL(t *testing.T) {
 	downloadsAllowed := true
 	customURL := "http://releases.example.com"
 
-	c, err := tfclient.NewTestClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, customURL, downloadsAllowed, true, projectCmdOutputHandler)
+	c, err := tfclient.NewTestClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, customURL, "", downloadsAllowed, true, projectCmdOutputHandler, nil)
 	Ok(t, err)
 
 	Equals(t, "0.11.10", c.DefaultVersion().String())
@@ -359,6 +359,36 @@ func TestEnsureVersion_downloaded_customURL(t *testing.T) {
 	mockDownloader.VerifyWasCalledEventually(Once(), 2*time.Second).Install(context.Background(), binDir, customURL, v)
 }
 
+func TestEnsureVersion_downloaded_tofuCustomURL(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+	_, binDir, cacheDir := mkSubDirs(t)
+	projectCmdOutputHandler := jobmocks.NewMockProjectCommandOutputHandler()
+
+	mockDownloader := mocks.NewMockDownloader()
+	distribution := terraform.NewDistributionOpenTofuWithDownloader(mockDownloader)
+	downloadsAllowed := true
+	tofuURL := "http://tofu-mirror.example.com"
+
+	c, err := tfclient.NewTestClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, tofuURL, downloadsAllowed, true, projectCmdOutputHandler, nil)
+	Ok(t, err)
+
+	v, err := version.NewVersion("99.99.99")
+	Ok(t, err)
+
+	When(mockDownloader.Install(context.Background(), binDir, tofuURL, v)).Then(func(params []Param) ReturnValues {
+		binPath := filepath.Join(params[1].(string), "tofu99.99.99")
+		err := os.WriteFile(binPath, []byte("#!/bin/sh\necho '\nOpenTofu v99.99.99\n'"), 0700) // #nosec G306
+		return []ReturnValue{binPath, err}
+	})
+
+	err = c.EnsureVersion(logger, distribution, v)
+
+	Ok(t, err)
+
+	mockDownloader.VerifyWasCalledEventually(Once(), 2*time.Second).Install(context.Background(), binDir, tofuURL, v)
+}
+
 // Test that EnsureVersion throws an error when downloads are disabled
 func TestEnsureVersion_downloaded_downloadingDisabled(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
@@ -370,7 +400,7 @@ func TestEnsureVersion_downloaded_downloadingDisabled(t *testing.T) {
 	distribution := terraform.NewDistributionTerraformWithDownloader(mockDownloader)
 
 	downloadsAllowed := false
-	c, err := tfclient.NewTestClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, downloadsAllowed, true, projectCmdOutputHandler)
+	c, err := tfclient.NewTestClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, "", downloadsAllowed, true, projectCmdOutputHandler, nil)
 	Ok(t, err)
 
 	Equals(t, "0.11.10", c.DefaultVersion().String())
@@ -512,9 +542,11 @@ terraform {
 				"",
 				cmd.DefaultTFVersionFlag,
 				cmd.DefaultTFDownloadURL,
+				"",
 				downloadsAllowed,
 				true,
-				projectCmdOutputHandler)
+				projectCmdOutputHandler,
+				nil)
 			Ok(t, err)
 
 			tmpDir := DirStructure(t, testCase.DirStructure)
@@ -549,7 +581,7 @@ func TestExtractExactRegex(t *testing.T) {
 	mockDownloader := mocks.NewMockDownloader()
 	distribution := terraform.NewDistributionTerraformWithDownloader(mockDownloader)
 
-	c, err := tfclient.NewTestClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, true, true, projectCmdOutputHandler)
+	c, err := tfclient.NewTestClient(logger, distribution, binDir, cacheDir, "", "", "0.11.10", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, "", true, true, projectCmdOutputHandler, nil)
 	Ok(t, err)
 
 	tests := []struct {
@@ -575,3 +607,45 @@ func TestExtractExactRegex(t *testing.T) {
 		})
 	}
 }
+
+// Test that the version is resolved from .terraform-version pins and the
+// installed versions that satisfy all required_version settings when
+// downloads are disabled.
+func TestDefaultClient_DetectVersion_Installed(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+	_, binDir, cacheDir := mkSubDirs(t)
+	for _, v := range []string{"1.4.6", "1.5.0", "1.5.7", "1.6.2"} {
+		Ok(t, os.WriteFile(filepath.Join(binDir, "terraform"+v), nil, 0700)) // #nosec G306
+	}
+	distribution := terraform.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader())
+	c, err := tfclient.NewTestClient(logger, distribution, binDir, cacheDir, "", "", "1.5.0", cmd.DefaultTFVersionFlag, cmd.DefaultTFDownloadURL, "", false, true, jobmocks.NewMockProjectCommandOutputHandler(), nil)
+	Ok(t, err)
+
+	tmpDir := DirStructure(t, map[string]interface{}{
+		"constraint": map[string]interface{}{
+			"main.tf": `terraform { required_version = ">= 1.5, < 1.6" }`,
+		},
+		"multiple-files": map[string]interface{}{
+			"main.tf":     `terraform { required_version = ">= 1.4" }`,
+			"versions.tf": `terraform { required_version = "~> 1.5.0" }`,
+		},
+		"pinned": map[string]interface{}{
+			".terraform-version": "1.4.2\n",
+			"main.tf":            `terraform { required_version = ">= 1.5" }`,
+		},
+		"keyword": map[string]interface{}{
+			".terraform-version": "latest-allowed\n",
+			"main.tf":            `terraform { required_version = "< 1.5" }`,
+		},
+		"unsatisfiable": map[string]interface{}{
+			"main.tf": `terraform { required_version = ">= 2.0" }`,
+		},
+	})
+
+	Equals(t, "1.5.7", c.DetectVersion(logger, filepath.Join(tmpDir, "constraint")).String())
+	Equals(t, "1.5.7", c.DetectVersion(logger, filepath.Join(tmpDir, "multiple-files")).String())
+	Equals(t, "1.4.2", c.DetectVersion(logger, filepath.Join(tmpDir, "pinned")).String())
+	Equals(t, "1.4.6", c.DetectVersion(logger, filepath.Join(tmpDir, "keyword")).String())
+	Assert(t, c.DetectVersion(logger, filepath.Join(tmpDir, "unsatisfiable")) == nil, "exp no version")
+}
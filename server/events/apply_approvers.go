@@ -0,0 +1,88 @@
+package events
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// ApplyApproversGetter returns the approvals that pull requests of a repo need
+// before they can be applied. It's implemented by valid.GlobalCfg.
+type ApplyApproversGetter interface {
+	// ApplyApprovers returns the approvals that pull requests of the repo
+	// with repoID need, or nil if they don't need any.
+	ApplyApprovers(repoID string) *valid.ApplyApprovers
+}
+
+// applyApproversFailure describes why the pull request of ctx can't be
+// applied with its approvals. It returns an empty string if it can. The
+// approvals must have been fetched into ctx.PullRequestStatus. The teams and
+// organizations of approvers are looked up with memberships, or with
+// vcsClient if it's nil.
+func applyApproversFailure(getter ApplyApproversGetter, memberships *UserMemberships, vcsClient vcs.Client, ctx *command.Context) (string, error) {
+	if getter == nil {
+		return "", nil
+	}
+	approvers := getter.ApplyApprovers(ctx.Pull.BaseRepo.ID())
+	if approvers == nil {
+		return "", nil
+	}
+	if memberships == nil {
+		memberships = &UserMemberships{TeamNamesGetter: vcsClient}
+	}
+	allowlist := &UserAllowlist{
+		Users:       approvers.Users,
+		Teams:       approvers.Teams,
+		Orgs:        approvers.Orgs,
+		Memberships: memberships,
+	}
+
+	var counted []string
+	for _, approver := range ctx.PullRequestStatus.ApprovalStatus.Approvers {
+		if approvers.ExcludeAuthor && strings.EqualFold(approver, ctx.Pull.Author) {
+			continue
+		}
+		counts := allowlist.IsEmpty()
+		if !counts {
+			var err error
+			counts, err = allowlist.IsAllowed(ctx.Log, ctx.Pull.BaseRepo, models.User{Username: approver})
+			if err != nil {
+				return "", errors.Wrapf(err, "checking the teams of approver %s", approver)
+			}
+		}
+		if counts && !slices.Contains(counted, approver) {
+			counted = append(counted, approver)
+		}
+	}
+	if len(counted) >= approvers.Count {
+		return "", nil
+	}
+
+	var who []string
+	if len(approvers.Users) > 0 {
+		who = append(who, fmt.Sprintf("users [%s]", strings.Join(approvers.Users, ", ")))
+	}
+	if len(approvers.Teams) > 0 {
+		who = append(who, fmt.Sprintf("members of teams [%s]", strings.Join(approvers.Teams, ", ")))
+	}
+	if len(approvers.Orgs) > 0 {
+		who = append(who, fmt.Sprintf("members of organizations [%s]", strings.Join(approvers.Orgs, ", ")))
+	}
+	if len(who) == 0 {
+		who = append(who, "anyone")
+	}
+	if approvers.ExcludeAuthor {
+		who[len(who)-1] += " other than its author"
+	}
+	failure := fmt.Sprintf("Pull request must be approved by %d of %s before it can be applied, but has %d such approvals", approvers.Count, strings.Join(who, " or "), len(counted))
+	if len(counted) > 0 {
+		failure += fmt.Sprintf(": [%s]", strings.Join(counted, ", "))
+	}
+	return failure + ".", nil
+}
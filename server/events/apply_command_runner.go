@@ -1,6 +1,9 @@
 package events
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -18,7 +21,7 @@ func NewApplyCommandRunner(
 	pullUpdater *PullUpdater,
 	dbUpdater *DBUpdater,
 	backend locking.Backend,
-	parallelPoolSize int,
+	parallelPoolSize *ParallelPoolSize,
 	SilenceNoProjects bool,
 	silenceVCSStatusNoProjects bool,
 	pullReqStatusFetcher vcs.PullReqStatusFetcher,
@@ -52,7 +55,7 @@ type ApplyCommandRunner struct {
 	autoMerger           *AutoMerger
 	pullUpdater          *PullUpdater
 	dbUpdater            *DBUpdater
-	parallelPoolSize     int
+	parallelPoolSize     *ParallelPoolSize
 	pullReqStatusFetcher vcs.PullReqStatusFetcher
 	// SilenceNoProjects is whether Atlantis should respond to PRs if no projects
 	// are found
@@ -61,6 +64,22 @@ type ApplyCommandRunner struct {
 	// are found
 	silenceVCSStatusNoProjects bool
 	SilencePRComments          []string
+	// PromotionPlanner plans the next stages of the promotions of the
+	// projects that were applied. If nil, they aren't planned.
+	PromotionPlanner CommentCommandRunner
+	// DependentsPlanner re-plans the projects whose plans were made before
+	// the projects they depend on were applied. If nil, they aren't
+	// re-planned.
+	DependentsPlanner CommentCommandRunner
+	// MaintenanceWindows disables applies during the maintenance windows of
+	// repos. If nil, there are no maintenance windows.
+	MaintenanceWindows MaintenanceWindowChecker
+	// ApplyApprovers gets the approvals pull requests need before they can be
+	// applied. If nil, they don't need any on top of the apply requirements.
+	ApplyApprovers ApplyApproversGetter
+	// UserMemberships looks up and caches the teams and organizations of
+	// approvers. If nil, teams are looked up with the VCS client every time.
+	UserMemberships *UserMemberships
 }
 
 func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
@@ -85,6 +104,15 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 		return
 	}
 
+	if msg := maintenanceWindowMessage(a.MaintenanceWindows, baseRepo.ID(), time.Now()); msg != "" {
+		ctx.Log.Info("ignoring apply command since it's during a maintenance window")
+		if err := a.vcsClient.CreateComment(ctx.Log, baseRepo, pull.Num, "**Error:** "+msg, command.Apply.String()); err != nil {
+			ctx.Log.Err("unable to comment on pull request: %s", err)
+		}
+
+		return
+	}
+
 	if a.DisableApplyAll && !cmd.IsForSpecificProject() {
 		ctx.Log.Info("ignoring apply command without flags since apply all is disabled")
 		if err := a.vcsClient.CreateComment(ctx.Log, baseRepo, pull.Num, applyAllDisabledComment, command.Apply.String()); err != nil {
@@ -119,12 +147,22 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 		return
 	}
 
+	// Destroy plans are only applied once they're confirmed.
+	for _, projectCmd := range projectCmds {
+		if destroyPlanned(ctx.PullStatus, projectCmd) {
+			ctx.Log.Info("ignoring apply command since dir %q workspace %q has a destroy plan", projectCmd.RepoRelDir, projectCmd.Workspace)
+			a.pullUpdater.updatePull(ctx, cmd, command.Result{Failure: fmt.Sprintf(applyDestroyPlannedComment, projectCmd.RepoRelDir, projectCmd.Workspace)})
+			return
+		}
+	}
+
 	// If there are no projects to apply, don't respond to the PR and ignore
 	if len(projectCmds) == 0 && a.SilenceNoProjects {
 		ctx.Log.Info("determined there was no project to run plan in")
 		if !a.silenceVCSStatusNoProjects {
-			if cmd.IsForSpecificProject() {
-				// With a specific apply, just reset the status so it's not stuck in pending state
+			if cmd.IsForSpecificProject() || cmd.Failed {
+				// With a specific apply, or when no projects failed, just
+				// reset the status so it's not stuck in pending state
 				pullStatus, err := a.Backend.GetPullStatus(pull)
 				if err != nil {
 					ctx.Log.Warn("unable to fetch pull status: %s", err)
@@ -154,13 +192,31 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 		return
 	}
 
+	if len(projectCmds) > 0 {
+		failure, err := applyApproversFailure(a.ApplyApprovers, a.UserMemberships, a.vcsClient, ctx)
+		if err != nil {
+			a.pullUpdater.updatePull(ctx, cmd, command.Result{Error: err})
+			return
+		}
+		if failure != "" {
+			ctx.Log.Info("ignoring apply command since the pull request doesn't have the approvals of its apply approvers")
+			a.pullUpdater.updatePull(ctx, cmd, command.Result{Failure: failure})
+			return
+		}
+	}
+
+	applyFunc := a.prjCmdRunner.Apply
+
 	// Only run commands in parallel if enabled
 	var result command.Result
 	if a.isParallelEnabled(projectCmds) {
 		ctx.Log.Info("Running applies in parallel")
-		result = runProjectCmdsParallelGroups(ctx, projectCmds, a.prjCmdRunner.Apply, a.parallelPoolSize)
+		result = runProjectCmdsParallelGroups(ctx, projectCmds, applyFunc, a.parallelPoolSize.Get())
+	} else if a.isParallelGroupsEnabled(projectCmds) {
+		ctx.Log.Info("Running applies of independent execution order groups in parallel")
+		result = runProjectCmdsParallelIndependentGroups(ctx, projectCmds, applyFunc, a.parallelPoolSize.Get())
 	} else {
-		result = runProjectCmds(projectCmds, a.prjCmdRunner.Apply)
+		result = runProjectCmdsInOrder(projectCmds, applyFunc)
 	}
 
 	a.pullUpdater.updatePull(
@@ -179,6 +235,103 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 	if a.autoMerger.automergeEnabled(projectCmds) && !cmd.AutoMergeDisabled {
 		a.autoMerger.automerge(ctx, pullStatus, a.autoMerger.deleteSourceBranchOnMergeEnabled(projectCmds), cmd.AutoMergeMethod)
 	}
+
+	promoted := a.planPromotions(ctx, projectCmds, result, pullStatus)
+	a.replanDependents(ctx, projectCmds, result, pullStatus, promoted)
+}
+
+// planPromotions plans the next stages of the promotions of the projects that
+// were applied successfully.
+func (a *ApplyCommandRunner) planPromotions(ctx *command.Context, projectCmds []command.ProjectContext, result command.Result, pullStatus models.PullStatus) map[string]bool {
+	promoted := make(map[string]bool)
+	if a.PromotionPlanner == nil {
+		return promoted
+	}
+	applied := make(map[string]bool)
+	for _, projectResult := range result.ProjectResults {
+		if projectResult.ApplySuccess != "" {
+			applied[projectResult.ProjectName] = true
+		}
+	}
+	ctx.PullStatus = &pullStatus
+	for _, projectCmd := range projectCmds {
+		if projectCmd.PromoteTo == "" || projectCmd.ProjectName == "" || !applied[projectCmd.ProjectName] {
+			continue
+		}
+		ctx.Log.Info("planning %q since it's the next stage of the promotion of %q", projectCmd.PromoteTo, projectCmd.ProjectName)
+		a.PromotionPlanner.Run(ctx, &CommentCommand{
+			Name:        command.Plan,
+			ProjectName: projectCmd.PromoteTo,
+		})
+		promoted[projectCmd.PromoteTo] = true
+	}
+	return promoted
+}
+
+// replanDependents re-plans the projects of the pull request that still have
+// a plan and that either depend on a project that was applied or are in a
+// later execution order group than one, since the plans were made before
+// the project was applied and may be out of date. Projects in promoted were
+// already planned.
+func (a *ApplyCommandRunner) replanDependents(ctx *command.Context, projectCmds []command.ProjectContext, result command.Result, pullStatus models.PullStatus, promoted map[string]bool) {
+	if a.DependentsPlanner == nil {
+		return
+	}
+	// The results aren't in the order of projectCmds when applies are run in
+	// parallel.
+	groups := make(map[string]int)
+	for _, projectCmd := range projectCmds {
+		groups[projectCmd.RepoRelDir+"/"+projectCmd.Workspace+"/"+projectCmd.ProjectName] = projectCmd.ExecutionOrderGroup
+	}
+	appliedNames := make(map[string]bool)
+	appliedGroup := -1
+	for _, projectResult := range result.ProjectResults {
+		if projectResult.ApplySuccess == "" {
+			continue
+		}
+		if projectResult.ProjectName != "" {
+			appliedNames[projectResult.ProjectName] = true
+		}
+		group, ok := groups[projectResult.RepoRelDir+"/"+projectResult.Workspace+"/"+projectResult.ProjectName]
+		if ok && (appliedGroup == -1 || group < appliedGroup) {
+			appliedGroup = group
+		}
+	}
+	if appliedGroup == -1 {
+		return
+	}
+
+	// The projects that still have a plan are the ones that apply would run.
+	planned, err := a.prjCmdBuilder.BuildApplyCommands(ctx, &CommentCommand{Name: command.Apply})
+	if err != nil {
+		ctx.Log.Warn("unable to find the projects to re-plan after apply: %s", err)
+		return
+	}
+	ctx.PullStatus = &pullStatus
+	for _, projectCmd := range planned {
+		if promoted[projectCmd.ProjectName] || !a.dependsOnApplied(projectCmd, appliedNames, appliedGroup) {
+			continue
+		}
+		ctx.Log.Info("re-planning dir %q workspace %q since a project it depends on was applied", projectCmd.RepoRelDir, projectCmd.Workspace)
+		a.DependentsPlanner.Run(ctx, &CommentCommand{
+			Name:        command.Plan,
+			RepoRelDir:  projectCmd.RepoRelDir,
+			Workspace:   projectCmd.Workspace,
+			ProjectName: projectCmd.ProjectName,
+		})
+	}
+}
+
+func (a *ApplyCommandRunner) dependsOnApplied(projectCmd command.ProjectContext, appliedNames map[string]bool, appliedGroup int) bool {
+	if projectCmd.ExecutionOrderGroup > appliedGroup {
+		return true
+	}
+	for _, dep := range projectCmd.DependsOn {
+		if appliedNames[dep] {
+			return true
+		}
+	}
+	return false
 }
 
 func (a *ApplyCommandRunner) IsLocked() (bool, error) {
@@ -191,6 +344,10 @@ func (a *ApplyCommandRunner) isParallelEnabled(projectCmds []command.ProjectCont
 	return len(projectCmds) > 0 && projectCmds[0].ParallelApplyEnabled
 }
 
+func (a *ApplyCommandRunner) isParallelGroupsEnabled(projectCmds []command.ProjectContext) bool {
+	return len(projectCmds) > 0 && projectCmds[0].ParallelApplyGroupsEnabled
+}
+
 func (a *ApplyCommandRunner) updateCommitStatus(ctx *command.Context, pullStatus models.PullStatus) {
 	var numSuccess int
 	var numErrored int
@@ -227,3 +384,8 @@ var applyAllDisabledComment = "**Error:** Running `atlantis apply` without flags
 
 // applyDisabledComment is posted when apply commands are disabled globally and an apply command is issued.
 var applyDisabledComment = "**Error:** Running `atlantis apply` is disabled."
+
+// applyDestroyPlannedComment is the failure of applies of projects whose plan
+// was made by atlantis destroy.
+var applyDestroyPlannedComment = "The plan of dir `%s` workspace `%s` destroys it and can only be applied with `atlantis destroy --confirm`." +
+	" Run `atlantis plan` for the project to replace it with a regular plan."
@@ -3,15 +3,18 @@ package events_test
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/v68/github"
 	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/db"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
 	. "github.com/runatlantis/atlantis/testing"
@@ -439,6 +442,52 @@ func TestApplyCommandRunner_ExecutionOrder(t *testing.T) {
 				"1. dir: `` workspace: ``\n1. dir: `` workspace: ``\n---\n\n### 1. dir: `` workspace: ``\n**Apply Error**\n```\nshabang\n```\n\n---\n### " +
 				"2. dir: `` workspace: ``\n```diff\nGreat success!\n```\n\n---\n### Apply Summary\n\n2 projects, 1 successful, 0 failed, 1 errored",
 		},
+		{
+			Description: "When a group fails, independent groups still run",
+			ProjectContexts: []command.ProjectContext{
+				{
+					ExecutionOrderGroup:        0,
+					ProjectName:                "First",
+					ParallelApplyGroupsEnabled: true,
+					AbortOnExecutionOrderFail:  true,
+				},
+				{
+					ExecutionOrderGroup:        1,
+					ProjectName:                "Second",
+					DependsOn:                  []string{"First"},
+					ParallelApplyGroupsEnabled: true,
+					AbortOnExecutionOrderFail:  true,
+				},
+				{
+					ExecutionOrderGroup:        2,
+					ProjectName:                "Third",
+					ParallelApplyGroupsEnabled: true,
+					AbortOnExecutionOrderFail:  true,
+				},
+			},
+			ProjectResults: []command.ProjectResult{
+				{
+					Command: command.Apply,
+					Error:   errors.New("shabang"),
+				},
+				{
+					Command:      command.Apply,
+					ApplySuccess: "Great success!",
+				},
+				{
+					Command:      command.Apply,
+					ApplySuccess: "Great success!",
+				},
+			},
+			RunnerInvokeMatch: []*EqMatcher{
+				Once(),
+				Never(),
+				Once(),
+			},
+			ExpComment: "Ran Apply for 2 projects:\n\n" +
+				"1. dir: `` workspace: ``\n1. dir: `` workspace: ``\n---\n\n### 1. dir: `` workspace: ``\n**Apply Error**\n```\nshabang\n```\n\n---\n### " +
+				"2. dir: `` workspace: ``\n```diff\nGreat success!\n```\n\n---\n### Apply Summary\n\n2 projects, 1 successful, 0 failed, 1 errored",
+		},
 		{
 			Description: "Don't block when abortOnExecutionOrderFail is not set",
 			ProjectContexts: []command.ProjectContext{
@@ -514,3 +563,245 @@ func TestApplyCommandRunner_ExecutionOrder(t *testing.T) {
 		})
 	}
 }
+
+type fakePromotionPlanner struct {
+	cmds []*events.CommentCommand
+}
+
+func (f *fakePromotionPlanner) Run(_ *command.Context, cmd *events.CommentCommand) {
+	f.cmds = append(f.cmds, cmd)
+}
+
+func TestApplyCommandRunner_PlansPromotions(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+	setup(t)
+	planner := &fakePromotionPlanner{}
+	applyCommandRunner.PromotionPlanner = planner
+
+	scopeNull, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+	cmd := &events.CommentCommand{Name: command.Apply}
+	ctx := &command.Context{
+		User:     testdata.User,
+		Log:      logger,
+		Scope:    scopeNull,
+		Pull:     modelPull,
+		HeadRepo: testdata.GithubRepo,
+		Trigger:  command.CommentTrigger,
+	}
+	projectCmds := []command.ProjectContext{
+		{ProjectName: "app-dev", PromoteTo: "app-staging"},
+		{ProjectName: "db-dev", PromoteTo: "db-staging"},
+		{ProjectName: "app-prod"},
+	}
+	When(projectCommandBuilder.BuildApplyCommands(ctx, cmd)).ThenReturn(projectCmds, nil)
+	When(projectCommandRunner.Apply(projectCmds[0])).ThenReturn(command.ProjectResult{Command: command.Apply, ProjectName: "app-dev", ApplySuccess: "applied"})
+	When(projectCommandRunner.Apply(projectCmds[1])).ThenReturn(command.ProjectResult{Command: command.Apply, ProjectName: "db-dev", Error: errors.New("failed")})
+	When(projectCommandRunner.Apply(projectCmds[2])).ThenReturn(command.ProjectResult{Command: command.Apply, ProjectName: "app-prod", ApplySuccess: "applied"})
+
+	applyCommandRunner.Run(ctx, cmd)
+
+	Equals(t, []*events.CommentCommand{{Name: command.Plan, ProjectName: "app-staging"}}, planner.cmds)
+}
+
+func TestApplyCommandRunner_ReplansDependents(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+	setup(t)
+	planner := &fakePromotionPlanner{}
+	applyCommandRunner.DependentsPlanner = planner
+
+	scopeNull, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+	cmd := &events.CommentCommand{Name: command.Apply, ProjectName: "network"}
+	ctx := &command.Context{
+		User:     testdata.User,
+		Log:      logger,
+		Scope:    scopeNull,
+		Pull:     modelPull,
+		HeadRepo: testdata.GithubRepo,
+		Trigger:  command.CommentTrigger,
+	}
+	network := command.ProjectContext{ProjectName: "network", RepoRelDir: "network", Workspace: "default", ExecutionOrderGroup: 1}
+	When(projectCommandBuilder.BuildApplyCommands(ctx, cmd)).ThenReturn([]command.ProjectContext{network}, nil)
+	When(projectCommandRunner.Apply(network)).ThenReturn(command.ProjectResult{Command: command.Apply, ProjectName: "network", RepoRelDir: "network", Workspace: "default", ApplySuccess: "applied"})
+	When(projectCommandBuilder.BuildApplyCommands(ctx, &events.CommentCommand{Name: command.Apply})).ThenReturn([]command.ProjectContext{
+		{ProjectName: "app", RepoRelDir: "app", Workspace: "default", ExecutionOrderGroup: 1, DependsOn: []string{"network"}},
+		{ProjectName: "dns", RepoRelDir: "dns", Workspace: "default", ExecutionOrderGroup: 2},
+		{ProjectName: "other", RepoRelDir: "other", Workspace: "default", ExecutionOrderGroup: 1},
+		{ProjectName: "bootstrap", RepoRelDir: "bootstrap", Workspace: "default"},
+	}, nil)
+
+	applyCommandRunner.Run(ctx, cmd)
+
+	Equals(t, []*events.CommentCommand{
+		{Name: command.Plan, ProjectName: "app", RepoRelDir: "app", Workspace: "default"},
+		{Name: command.Plan, ProjectName: "dns", RepoRelDir: "dns", Workspace: "default"},
+	}, planner.cmds)
+}
+
+type fakeMaintenanceWindowChecker struct {
+	window *valid.MaintenanceWindow
+	end    time.Time
+}
+
+func (f fakeMaintenanceWindowChecker) ActiveMaintenanceWindow(_ string, _ time.Time) (*valid.MaintenanceWindow, time.Time) {
+	return f.window, f.end
+}
+
+func TestApplyCommandRunner_MaintenanceWindow(t *testing.T) {
+	end := time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		description string
+		checker     fakeMaintenanceWindowChecker
+		expComment  string
+	}{
+		{
+			description: "not in a window",
+			checker:     fakeMaintenanceWindowChecker{},
+			expComment:  "Ran Apply for 0 projects:",
+		},
+		{
+			description: "in a window",
+			checker:     fakeMaintenanceWindowChecker{window: &valid.MaintenanceWindow{Description: "Weekly release freeze."}, end: end},
+			expComment:  "**Error:** Running `atlantis apply` is disabled during a maintenance window until Sat, 06 Jan 2024 00:00:00 UTC. Weekly release freeze.",
+		},
+		{
+			description: "in windows that never end",
+			checker:     fakeMaintenanceWindowChecker{window: &valid.MaintenanceWindow{}},
+			expComment:  "**Error:** Running `atlantis apply` is disabled during a maintenance window.",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			logger := logging.NewNoopLogger(t)
+			vcsClient := setup(t)
+			applyCommandRunner.MaintenanceWindows = c.checker
+
+			scopeNull, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+			modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+			ctx := &command.Context{
+				User:     testdata.User,
+				Log:      logger,
+				Scope:    scopeNull,
+				Pull:     modelPull,
+				HeadRepo: testdata.GithubRepo,
+				Trigger:  command.CommentTrigger,
+			}
+			applyCommandRunner.Run(ctx, &events.CommentCommand{Name: command.Apply})
+
+			vcsClient.VerifyWasCalledOnce().CreateComment(
+				Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq(c.expComment), Eq("apply"))
+		})
+	}
+}
+
+type fakeApplyApproversGetter struct {
+	approvers *valid.ApplyApprovers
+}
+
+func (f fakeApplyApproversGetter) ApplyApprovers(_ string) *valid.ApplyApprovers {
+	return f.approvers
+}
+
+func TestApplyCommandRunner_ApplyApprovers(t *testing.T) {
+	cases := []struct {
+		description string
+		approvers   *valid.ApplyApprovers
+		approvedBy  []string
+		expFailure  string
+	}{
+		{
+			description: "no approvers",
+			approvedBy:  []string{"alice"},
+		},
+		{
+			description: "enough approvals",
+			approvers:   &valid.ApplyApprovers{Count: 2, ExcludeAuthor: true},
+			approvedBy:  []string{"alice", "bob"},
+		},
+		{
+			description: "author's approval excluded",
+			approvers:   &valid.ApplyApprovers{Count: 2, ExcludeAuthor: true},
+			approvedBy:  []string{"alice", testdata.Pull.Author},
+			expFailure:  "Pull request must be approved by 2 of anyone other than its author before it can be applied, but has 1 such approvals: [alice].",
+		},
+		{
+			description: "approvals of users and team members",
+			approvers:   &valid.ApplyApprovers{Users: []string{"Alice"}, Teams: []string{"platform"}, Count: 2},
+			approvedBy:  []string{"alice", "bob", "carol"},
+		},
+		{
+			description: "approval of a user that isn't an approver",
+			approvers:   &valid.ApplyApprovers{Users: []string{"Alice"}, Teams: []string{"platform"}, Count: 2},
+			approvedBy:  []string{"alice", "bob"},
+			expFailure:  "Pull request must be approved by 2 of users [Alice] or members of teams [platform] before it can be applied, but has 1 such approvals: [alice].",
+		},
+		{
+			description: "no approvals",
+			approvers:   &valid.ApplyApprovers{Teams: []string{"platform"}, Count: 1},
+			expFailure:  "Pull request must be approved by 1 of members of teams [platform] before it can be applied, but has 0 such approvals.",
+		},
+		{
+			description: "approval of an organization member",
+			approvers:   &valid.ApplyApprovers{Orgs: []string{"acme"}, Count: 1},
+			approvedBy:  []string{"dave"},
+		},
+		{
+			description: "approval of a member of another organization",
+			approvers:   &valid.ApplyApprovers{Orgs: []string{"other"}, Count: 1},
+			approvedBy:  []string{"dave"},
+			expFailure:  "Pull request must be approved by 1 of members of organizations [other] before it can be applied, but has 0 such approvals.",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			logger := logging.NewNoopLogger(t)
+			vcsClient := setup(t)
+			applyCommandRunner.ApplyApprovers = fakeApplyApproversGetter{approvers: c.approvers}
+			applyCommandRunner.UserMemberships = &events.UserMemberships{
+				TeamNamesGetter:       vcsClient,
+				OrgMembershipCheckers: map[models.VCSHostType]vcs.OrgMembershipChecker{models.Github: &fakeMembership{orgs: []string{"acme"}}},
+			}
+			defer func() {
+				applyCommandRunner.ApplyApprovers = nil
+				applyCommandRunner.UserMemberships = nil
+			}()
+
+			modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num, Author: testdata.Pull.Author}
+			ctx := &command.Context{
+				User:     testdata.User,
+				Log:      logger,
+				Pull:     modelPull,
+				HeadRepo: testdata.GithubRepo,
+				Trigger:  command.CommentTrigger,
+			}
+			cmd := &events.CommentCommand{Name: command.Apply}
+			projectCmd := command.ProjectContext{CommandName: command.Apply, ProjectName: "network", RepoRelDir: "network", Workspace: "default"}
+			When(pullReqStatusFetcher.FetchPullStatus(Any[logging.SimpleLogging](), Eq(modelPull))).ThenReturn(models.PullReqStatus{
+				ApprovalStatus: models.ApprovalStatus{IsApproved: len(c.approvedBy) > 0, Approvers: c.approvedBy},
+			}, nil)
+			When(vcsClient.GetTeamNamesForUser(Any[logging.SimpleLogging](), Any[models.Repo](), Eq(models.User{Username: "bob"}))).ThenReturn([]string{"developers"}, nil)
+			When(vcsClient.GetTeamNamesForUser(Any[logging.SimpleLogging](), Any[models.Repo](), Eq(models.User{Username: "carol"}))).ThenReturn([]string{"Platform"}, nil)
+			When(projectCommandBuilder.BuildApplyCommands(ctx, cmd)).ThenReturn([]command.ProjectContext{projectCmd}, nil)
+			When(projectCommandRunner.Apply(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{
+				Command:      command.Apply,
+				ProjectName:  "network",
+				RepoRelDir:   "network",
+				Workspace:    "default",
+				ApplySuccess: "Apply complete!",
+			})
+
+			applyCommandRunner.Run(ctx, cmd)
+
+			if c.expFailure == "" {
+				projectCommandRunner.VerifyWasCalledOnce().Apply(Any[command.ProjectContext]())
+				return
+			}
+			projectCommandRunner.VerifyWasCalled(Never()).Apply(Any[command.ProjectContext]())
+			vcsClient.VerifyWasCalledOnce().CreateComment(
+				Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq("**Apply Failed**: "+c.expFailure), Eq("apply"))
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// ApplyQueue limits how many projects are applied at the same time, per
+// repository and across all repositories, so applies of many projects don't
+// all contend for the same state locks. Applies over a limit wait in a first
+// in, first out queue. An apply that's only held back by the limit of its
+// repository doesn't hold back the applies of other repositories.
+type ApplyQueue struct {
+	// maxPerRepo and maxGlobal are the limits. 0 means there's no limit.
+	maxPerRepo int
+	maxGlobal  int
+
+	mutex        sync.Mutex
+	runningRepos map[string]int
+	running      []*applyQueueEntry
+	waiting      []*applyQueueEntry
+}
+
+// ApplyQueueEntry is an apply of a project that's running or waiting in the
+// queue.
+type ApplyQueueEntry struct {
+	RepoFullName string
+	PullNum      int
+	ProjectName  string
+	RepoRelDir   string
+	Workspace    string
+	EnqueuedAt   time.Time
+	// StartedAt is zero while the apply is waiting.
+	StartedAt time.Time
+	// Position is the 1-based position of the apply in the queue, or 0 if
+	// it's running.
+	Position int
+}
+
+type applyQueueEntry struct {
+	ApplyQueueEntry
+	ready chan struct{}
+}
+
+// NewApplyQueue returns a queue that runs up to maxPerRepo applies per
+// repository and maxGlobal applies in total at the same time. A limit of 0
+// means there's no limit.
+func NewApplyQueue(maxPerRepo int, maxGlobal int) *ApplyQueue {
+	return &ApplyQueue{
+		maxPerRepo:   maxPerRepo,
+		maxGlobal:    maxGlobal,
+		runningRepos: make(map[string]int),
+	}
+}
+
+// Acquire blocks until entry can be applied and returns the func to call
+// once the apply is done. If entry has to wait, onQueued is called with its
+// position in the queue before waiting.
+func (q *ApplyQueue) Acquire(entry ApplyQueueEntry, onQueued func(position int)) (release func()) {
+	e := &applyQueueEntry{ApplyQueueEntry: entry, ready: make(chan struct{})}
+	e.EnqueuedAt = time.Now()
+
+	q.mutex.Lock()
+	q.waiting = append(q.waiting, e)
+	q.dispatch()
+	position := q.position(e)
+	q.mutex.Unlock()
+
+	if position > 0 {
+		if onQueued != nil {
+			onQueued(position)
+		}
+		<-e.ready
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { q.release(e) })
+	}
+}
+
+// List returns the running applies, in the order they started, followed by
+// the waiting applies, in the order they'll start.
+func (q *ApplyQueue) List() []ApplyQueueEntry {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	entries := make([]ApplyQueueEntry, 0, len(q.running)+len(q.waiting))
+	for _, e := range q.running {
+		entries = append(entries, e.ApplyQueueEntry)
+	}
+	for i, e := range q.waiting {
+		entry := e.ApplyQueueEntry
+		entry.Position = i + 1
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (q *ApplyQueue) release(e *applyQueueEntry) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for i, running := range q.running {
+		if running == e {
+			q.running = append(q.running[:i], q.running[i+1:]...)
+			break
+		}
+	}
+	q.runningRepos[e.RepoFullName]--
+	if q.runningRepos[e.RepoFullName] <= 0 {
+		delete(q.runningRepos, e.RepoFullName)
+	}
+	q.dispatch()
+}
+
+// dispatch starts the waiting applies that fit within the limits. It must be
+// called with the mutex held.
+func (q *ApplyQueue) dispatch() {
+	waiting := q.waiting[:0]
+	for _, e := range q.waiting {
+		if (q.maxGlobal > 0 && len(q.running) >= q.maxGlobal) ||
+			(q.maxPerRepo > 0 && q.runningRepos[e.RepoFullName] >= q.maxPerRepo) {
+			waiting = append(waiting, e)
+			continue
+		}
+		e.StartedAt = time.Now()
+		q.running = append(q.running, e)
+		q.runningRepos[e.RepoFullName]++
+		close(e.ready)
+	}
+	q.waiting = waiting
+}
+
+// position returns the 1-based position of e in the queue, or 0 if it's
+// running. It must be called with the mutex held.
+func (q *ApplyQueue) position(e *applyQueueEntry) int {
+	for i, waiting := range q.waiting {
+		if waiting == e {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// ApplyQueueProjectCommandRunner makes each project apply wait for its turn
+// in an ApplyQueue, whether it's applied by a comment, the API, a scheduled
+// apply or when its pull request closes.
+type ApplyQueueProjectCommandRunner struct {
+	ProjectCommandRunner
+	ApplyQueue *ApplyQueue
+	// VCSClient comments the position of applies that have to wait on their
+	// pull request.
+	VCSClient vcs.Client
+}
+
+func (p *ApplyQueueProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
+	entry := ApplyQueueEntry{
+		RepoFullName: ctx.BaseRepo.FullName,
+		PullNum:      ctx.Pull.Num,
+		ProjectName:  ctx.ProjectName,
+		RepoRelDir:   ctx.RepoRelDir,
+		Workspace:    ctx.Workspace,
+	}
+	release := p.ApplyQueue.Acquire(entry, func(position int) {
+		ctx.Log.Info("apply of dir %q workspace %q is queued at position %d", ctx.RepoRelDir, ctx.Workspace, position)
+		// Applies that aren't for a pull request, ex. scheduled applies,
+		// have nowhere to comment.
+		if ctx.Pull.Num <= 0 {
+			return
+		}
+		comment := fmt.Sprintf(applyQueuedComment, ctx.RepoRelDir, ctx.Workspace, position)
+		if ctx.ProjectName != "" {
+			comment = fmt.Sprintf(applyQueuedProjectComment, ctx.ProjectName, position)
+		}
+		if err := p.VCSClient.CreateComment(ctx.Log, ctx.BaseRepo, ctx.Pull.Num, comment, command.Apply.String()); err != nil {
+			ctx.Log.Err("unable to comment on pull request: %s", err)
+		}
+	})
+	defer release()
+	return p.ProjectCommandRunner.Apply(ctx)
+}
+
+// applyQueuedComment is posted when the apply of a project has to wait for
+// other applies to finish.
+var applyQueuedComment = "Apply of dir `%s` workspace `%s` is queued at position %d." +
+	" It will start once earlier applies finish."
+
+// applyQueuedProjectComment is applyQueuedComment for projects with a name.
+var applyQueuedProjectComment = "Apply of project `%s` is queued at position %d." +
+	" It will start once earlier applies finish."
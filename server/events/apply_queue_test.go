@@ -0,0 +1,151 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// acquireAsync acquires entry in a goroutine and returns a channel that
+// receives the release func once it's acquired, and one that receives the
+// position it was queued at.
+func acquireAsync(q *events.ApplyQueue, entry events.ApplyQueueEntry) (chan func(), chan int) {
+	acquired := make(chan func(), 1)
+	queued := make(chan int, 1)
+	go func() {
+		acquired <- q.Acquire(entry, func(position int) { queued <- position })
+	}()
+	return acquired, queued
+}
+
+func TestApplyQueue_PerRepoLimit(t *testing.T) {
+	q := events.NewApplyQueue(1, 0)
+	repoA := events.ApplyQueueEntry{RepoFullName: "owner/a", RepoRelDir: "vpc", Workspace: "default"}
+	repoB := events.ApplyQueueEntry{RepoFullName: "owner/b", RepoRelDir: "vpc", Workspace: "default"}
+
+	releaseA := q.Acquire(repoA, func(int) { t.Error("expected the first apply not to be queued") })
+
+	// A second apply of the same repo waits, an apply of another repo doesn't.
+	acquiredA, queuedA := acquireAsync(q, repoA)
+	Equals(t, 1, <-queuedA)
+	releaseB := q.Acquire(repoB, func(int) { t.Error("expected the apply of another repo not to be queued") })
+
+	entries := q.List()
+	Equals(t, 3, len(entries))
+	Equals(t, 0, entries[0].Position)
+	Equals(t, "owner/b", entries[1].RepoFullName)
+	Equals(t, 0, entries[1].Position)
+	Equals(t, "owner/a", entries[2].RepoFullName)
+	Equals(t, 1, entries[2].Position)
+	Assert(t, entries[2].StartedAt.IsZero(), "expected the queued apply not to be started")
+
+	select {
+	case <-acquiredA:
+		t.Fatal("expected the second apply to wait for the first")
+	case <-time.After(50 * time.Millisecond):
+	}
+	releaseA()
+	(<-acquiredA)()
+	releaseB()
+	Equals(t, 0, len(q.List()))
+}
+
+func TestApplyQueue_GlobalLimit(t *testing.T) {
+	q := events.NewApplyQueue(0, 2)
+	entry := func(repo string) events.ApplyQueueEntry {
+		return events.ApplyQueueEntry{RepoFullName: repo, RepoRelDir: ".", Workspace: "default"}
+	}
+
+	release1 := q.Acquire(entry("owner/a"), nil)
+	release2 := q.Acquire(entry("owner/b"), nil)
+	acquired3, queued3 := acquireAsync(q, entry("owner/c"))
+	Equals(t, 1, <-queued3)
+	acquired4, queued4 := acquireAsync(q, entry("owner/d"))
+	Equals(t, 2, <-queued4)
+
+	// The queue is first in, first out.
+	release2()
+	release3 := <-acquired3
+	entries := q.List()
+	Equals(t, 3, len(entries))
+	Equals(t, "owner/d", entries[2].RepoFullName)
+	Equals(t, 1, entries[2].Position)
+
+	// Releasing twice doesn't free two slots.
+	release1()
+	release1()
+	release4 := <-acquired4
+	Equals(t, 2, len(q.List()))
+	release3()
+	release4()
+	Equals(t, 0, len(q.List()))
+}
+
+func TestApplyQueueProjectCommandRunner_Apply(t *testing.T) {
+	cases := []struct {
+		description string
+		pullNum     int
+		expComment  bool
+	}{
+		{"pull request", 1, true},
+		// API, scheduled and drift detection applies have no pull request
+		// to comment on but still wait for their turn.
+		{"API", 0, false},
+		{"scheduled", -1, false},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			queue := events.NewApplyQueue(1, 0)
+			projectCommandRunner := mocks.NewMockProjectCommandRunner()
+			vcsClient := vcsmocks.NewMockClient()
+			runner := &events.ApplyQueueProjectCommandRunner{
+				ProjectCommandRunner: projectCommandRunner,
+				ApplyQueue:           queue,
+				VCSClient:            vcsClient,
+			}
+			repo := models.Repo{FullName: "owner/repo"}
+			ctx := command.ProjectContext{
+				Log:         logging.NewNoopLogger(t),
+				BaseRepo:    repo,
+				Pull:        models.PullRequest{Num: c.pullNum, BaseRepo: repo},
+				ProjectName: "network",
+				RepoRelDir:  "network",
+				Workspace:   "default",
+			}
+			When(projectCommandRunner.Apply(Eq(ctx))).ThenReturn(command.ProjectResult{ApplySuccess: "applied"})
+
+			// Another apply of the repo is running, so this one has to wait.
+			release := queue.Acquire(events.ApplyQueueEntry{RepoFullName: "owner/repo"}, nil)
+			done := make(chan command.ProjectResult)
+			go func() {
+				done <- runner.Apply(ctx)
+			}()
+			for len(queue.List()) < 2 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			projectCommandRunner.VerifyWasCalled(Never()).Apply(Eq(ctx))
+
+			release()
+			Equals(t, "applied", (<-done).ApplySuccess)
+			projectCommandRunner.VerifyWasCalledOnce().Apply(Eq(ctx))
+			Equals(t, 0, len(queue.List()))
+			if c.expComment {
+				vcsClient.VerifyWasCalledOnce().CreateComment(
+					Any[logging.SimpleLogging](), Eq(repo), Eq(c.pullNum),
+					Eq("Apply of project `network` is queued at position 1. It will start once earlier applies finish."), Eq("apply"),
+				)
+			} else {
+				vcsClient.VerifyWasCalled(Never()).CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Package audit records the commands that Atlantis runs as structured events
+// and ships them to sinks, ex. a file or Kafka, so that there's a record of
+// who ran what that's separate from pull request comments.
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+const (
+	FileKind       = "file"
+	HttpKind       = "http"
+	KafkaKind      = "kafka"
+	CloudWatchKind = "cloudwatch"
+)
+
+// The results of commands.
+const (
+	SuccessResult = "success"
+	// FailureResult is a command that didn't run, ex. because its apply
+	// requirements weren't met.
+	FailureResult = "failure"
+	ErrorResult   = "error"
+)
+
+// Event is a command that was run on a project.
+type Event struct {
+	Time time.Time `json:"time"`
+	// User is the user that ran the command.
+	User      string `json:"user"`
+	Repo      string `json:"repo"`
+	Pull      int    `json:"pull"`
+	Project   string `json:"project,omitempty"`
+	Dir       string `json:"dir"`
+	Workspace string `json:"workspace"`
+	// Command is the name of the command, ex. apply.
+	Command string `json:"command"`
+	// Result is one of SuccessResult, FailureResult or ErrorResult.
+	Result string `json:"result"`
+	// Error is the error or failure of the command if it didn't succeed.
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Sink ships audit events.
+type Sink interface {
+	// Send ships event.
+	Send(log logging.SimpleLogging, event Event) error
+}
+
+// Config configures a sink.
+type Config struct {
+	// Kind is the type of sink, ex. file.
+	Kind string
+	// Path is the file that file sinks append events to.
+	Path string
+	// URL is where http sinks post events, and the URL of the Kafka REST
+	// proxy of kafka sinks.
+	URL string
+	// Topic is the Kafka topic of kafka sinks.
+	Topic string
+	// LogGroup, LogStream and Region are the CloudWatch Logs stream that
+	// cloudwatch sinks put events in. If Region is empty, the default region
+	// of the AWS CLI is used.
+	LogGroup  string
+	LogStream string
+	Region    string
+}
+
+// Clients are used by the sinks to ship events.
+type Clients struct {
+	Http *http.Client
+	// Headers are added to the requests of http sinks.
+	Headers map[string][]string
+	// AWSCLI is the path to the AWS CLI that cloudwatch sinks use. If empty,
+	// aws is looked up in the PATH.
+	AWSCLI string
+}
+
+// MultiSink sends events to each of its sinks.
+type MultiSink struct {
+	Sinks []Sink
+}
+
+// NewMultiSink returns a MultiSink with a sink for each of configs.
+func NewMultiSink(configs []Config, clients Clients) (*MultiSink, error) {
+	var sinks []Sink
+	for _, c := range configs {
+		var sink Sink
+		switch c.Kind {
+		case FileKind:
+			if c.Path == "" {
+				return nil, errors.New("must specify \"path\" if using an audit sink of \"kind: file\"")
+			}
+			sink = &FileSink{Path: c.Path}
+		case HttpKind:
+			if c.URL == "" {
+				return nil, errors.New("must specify \"url\" if using an audit sink of \"kind: http\"")
+			}
+			sink = &HttpSink{Client: clients.Http, Headers: clients.Headers, URL: c.URL}
+		case KafkaKind:
+			if c.URL == "" || c.Topic == "" {
+				return nil, errors.New("must specify \"url\" and \"topic\" if using an audit sink of \"kind: kafka\"")
+			}
+			sink = &KafkaSink{Client: clients.Http, URL: c.URL, Topic: c.Topic}
+		case CloudWatchKind:
+			if c.LogGroup == "" || c.LogStream == "" {
+				return nil, errors.New("must specify \"log-group\" and \"log-stream\" if using an audit sink of \"kind: cloudwatch\"")
+			}
+			sink = &CloudWatchSink{AWSCLI: clients.AWSCLI, LogGroup: c.LogGroup, LogStream: c.LogStream, Region: c.Region}
+		default:
+			return nil, fmt.Errorf("\"kind: %s\" not supported. Only \"kind: %s\", \"kind: %s\", \"kind: %s\" and \"kind: %s\" are supported right now",
+				c.Kind, FileKind, HttpKind, KafkaKind, CloudWatchKind)
+		}
+		sinks = append(sinks, sink)
+	}
+	return &MultiSink{Sinks: sinks}, nil
+}
+
+// Send sends event to each of the sinks. Errors are logged so that a sink
+// that's down doesn't stop the others.
+func (m *MultiSink) Send(log logging.SimpleLogging, event Event) error {
+	for _, s := range m.Sinks {
+		if err := s.Send(log, event); err != nil {
+			log.Err("error sending audit event: %s", err)
+		}
+	}
+	return nil
+}
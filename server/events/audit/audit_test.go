@@ -0,0 +1,135 @@
+package audit_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/audit"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+var event = audit.Event{
+	Time:       time.Date(2024, 1, 5, 22, 0, 0, 0, time.UTC),
+	User:       "lkysow",
+	Repo:       "runatlantis/atlantis",
+	Pull:       1,
+	Dir:        "network",
+	Workspace:  "default",
+	Command:    "apply",
+	Result:     audit.SuccessResult,
+	DurationMs: 1500,
+}
+
+func TestNewMultiSink(t *testing.T) {
+	cases := []struct {
+		description string
+		config      audit.Config
+		expErr      string
+	}{
+		{"file", audit.Config{Kind: audit.FileKind, Path: "audit.log"}, ""},
+		{"file without path", audit.Config{Kind: audit.FileKind}, "must specify \"path\" if using an audit sink of \"kind: file\""},
+		{"http", audit.Config{Kind: audit.HttpKind, URL: "https://example.com"}, ""},
+		{"http without url", audit.Config{Kind: audit.HttpKind}, "must specify \"url\" if using an audit sink of \"kind: http\""},
+		{"kafka", audit.Config{Kind: audit.KafkaKind, URL: "https://example.com", Topic: "audit"}, ""},
+		{"kafka without topic", audit.Config{Kind: audit.KafkaKind, URL: "https://example.com"}, "must specify \"url\" and \"topic\" if using an audit sink of \"kind: kafka\""},
+		{"cloudwatch", audit.Config{Kind: audit.CloudWatchKind, LogGroup: "atlantis", LogStream: "audit"}, ""},
+		{"cloudwatch without stream", audit.Config{Kind: audit.CloudWatchKind, LogGroup: "atlantis"}, "must specify \"log-group\" and \"log-stream\" if using an audit sink of \"kind: cloudwatch\""},
+		{"unknown kind", audit.Config{Kind: "syslog"}, "\"kind: syslog\" not supported. Only \"kind: file\", \"kind: http\", \"kind: kafka\" and \"kind: cloudwatch\" are supported right now"},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			sink, err := audit.NewMultiSink([]audit.Config{c.config}, audit.Clients{})
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+			Equals(t, 1, len(sink.Sinks))
+		})
+	}
+}
+
+func TestFileSink_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink := &audit.FileSink{Path: path}
+	Ok(t, sink.Send(logging.NewNoopLogger(t), event))
+	Ok(t, sink.Send(logging.NewNoopLogger(t), event))
+
+	contents, err := os.ReadFile(path)
+	Ok(t, err)
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	Equals(t, 2, len(lines))
+	Equals(t, `{"time":"2024-01-05T22:00:00Z","user":"lkysow","repo":"runatlantis/atlantis","pull":1,"dir":"network","workspace":"default","command":"apply","result":"success","duration_ms":1500}`, lines[0])
+}
+
+func TestHttpSink_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "application/json", r.Header.Get("Content-Type"))
+		Equals(t, "Bearer token", r.Header.Get("Authorization"))
+		var received audit.Event
+		Ok(t, json.NewDecoder(r.Body).Decode(&received))
+		Equals(t, event, received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := &audit.HttpSink{Client: http.DefaultClient, Headers: map[string][]string{"Authorization": {"Bearer token"}}, URL: server.URL}
+	Ok(t, sink.Send(logging.NewNoopLogger(t), event))
+}
+
+func TestHttpSink_Send_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("down")) // nolint: errcheck
+	}))
+	defer server.Close()
+
+	sink := &audit.HttpSink{Client: http.DefaultClient, URL: server.URL}
+	ErrContains(t, "returned status code 500 with response \"down\"", sink.Send(logging.NewNoopLogger(t), event))
+}
+
+func TestKafkaSink_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "/topics/atlantis-audit", r.URL.Path)
+		Equals(t, "application/vnd.kafka.json.v2+json", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		Ok(t, err)
+		Assert(t, strings.HasPrefix(string(body), `{"records":[{"key":"runatlantis/atlantis","value":{"time":"2024-01-05T22:00:00Z"`), "unexpected body %s", body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := &audit.KafkaSink{Client: http.DefaultClient, URL: server.URL, Topic: "atlantis-audit"}
+	Ok(t, sink.Send(logging.NewNoopLogger(t), event))
+}
+
+func TestCloudWatchSink_Send(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	awsCLI := filepath.Join(dir, "aws")
+	Ok(t, os.WriteFile(awsCLI, []byte("#!/bin/sh\necho \"$@\" > "+argsFile+"\n"), 0700)) // nolint: gosec
+
+	sink := &audit.CloudWatchSink{AWSCLI: awsCLI, LogGroup: "atlantis", LogStream: "audit", Region: "us-east-1"}
+	Ok(t, sink.Send(logging.NewNoopLogger(t), event))
+
+	args, err := os.ReadFile(argsFile)
+	Ok(t, err)
+	Assert(t, strings.HasPrefix(string(args), `logs put-log-events --log-group-name atlantis --log-stream-name audit --log-events [{"timestamp":1704492000000,"message":"{\"time\":`), "unexpected args %s", args)
+	Assert(t, strings.HasSuffix(strings.TrimSpace(string(args)), "--region us-east-1"), "unexpected args %s", args)
+}
+
+func TestCloudWatchSink_Send_Error(t *testing.T) {
+	awsCLI := filepath.Join(t.TempDir(), "aws")
+	Ok(t, os.WriteFile(awsCLI, []byte("#!/bin/sh\necho 'ResourceNotFoundException' >&2\nexit 254\n"), 0700)) // nolint: gosec
+
+	sink := &audit.CloudWatchSink{AWSCLI: awsCLI, LogGroup: "atlantis", LogStream: "audit"}
+	ErrContains(t, "putting audit event in CloudWatch Logs stream atlantis/audit: exit status 254: ResourceNotFoundException", sink.Send(logging.NewNoopLogger(t), event))
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// CloudWatchSink puts events in a CloudWatch Logs stream with the AWS CLI,
+// which uses the credentials of the Atlantis server. The log group and stream
+// must exist.
+type CloudWatchSink struct {
+	// AWSCLI is the path to the AWS CLI. If empty, aws is looked up in the
+	// PATH.
+	AWSCLI    string
+	LogGroup  string
+	LogStream string
+	Region    string
+
+	// mu keeps events in order since CloudWatch Logs rejects events that are
+	// older than the ones already in the stream by more than a few hours.
+	mu sync.Mutex
+}
+
+// cloudWatchLogEvent is an event of aws logs put-log-events.
+type cloudWatchLogEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// Send puts event in the log stream.
+func (c *CloudWatchSink) Send(_ logging.SimpleLogging, event Event) error {
+	message, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	logEvents, err := json.Marshal([]cloudWatchLogEvent{{Timestamp: event.Time.UnixMilli(), Message: string(message)}})
+	if err != nil {
+		return err
+	}
+
+	awsCLI := c.AWSCLI
+	if awsCLI == "" {
+		awsCLI = "aws"
+	}
+	args := []string{"logs", "put-log-events", "--log-group-name", c.LogGroup, "--log-stream-name", c.LogStream, "--log-events", string(logEvents)}
+	if c.Region != "" {
+		args = append(args, "--region", c.Region)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cmd := exec.Command(awsCLI, args...) // #nosec
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("putting audit event in CloudWatch Logs stream %s/%s: %s: %s", c.LogGroup, c.LogStream, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// FileSink appends events to a file, one JSON object per line. Events are
+// only ever appended so the file can be shipped by a log collector.
+type FileSink struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Send appends event to the file, creating it if it doesn't exist.
+func (f *FileSink) Send(_ logging.SimpleLogging, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304
+	if err != nil {
+		return errors.Wrapf(err, "opening audit log %q", f.Path)
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close() // nolint: errcheck
+		return errors.Wrapf(err, "writing to audit log %q", f.Path)
+	}
+	return file.Close()
+}
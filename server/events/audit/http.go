@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// HttpSink posts events as JSON to a URL.
+type HttpSink struct {
+	Client *http.Client
+	// Headers are added to each request, ex. for authentication.
+	Headers map[string][]string
+	URL     string
+}
+
+// Send posts event to URL.
+func (h *HttpSink) Send(_ logging.SimpleLogging, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if err := post(h.Client, h.URL, "application/json", h.Headers, body); err != nil {
+		return errors.Wrapf(err, "sending audit event to %q", h.URL)
+	}
+	return nil
+}
+
+// KafkaSink produces events to a Kafka topic through a Kafka REST proxy, ex.
+// the Confluent REST Proxy. Events are keyed by repo so that the events of a
+// repo stay in order.
+type KafkaSink struct {
+	Client *http.Client
+	// URL is the URL of the REST proxy.
+	URL   string
+	Topic string
+}
+
+// kafkaRecords is the body of a request to produce records with the v2 API
+// of the REST proxy.
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value Event  `json:"value"`
+}
+
+// Send produces event to Topic.
+func (k *KafkaSink) Send(_ logging.SimpleLogging, event Event) error {
+	body, err := json.Marshal(kafkaRecords{Records: []kafkaRecord{{Key: event.Repo, Value: event}}})
+	if err != nil {
+		return err
+	}
+	topicURL, err := url.JoinPath(k.URL, "topics", k.Topic)
+	if err != nil {
+		return err
+	}
+	if err := post(k.Client, topicURL, "application/vnd.kafka.json.v2+json", nil, body); err != nil {
+		return errors.Wrapf(err, "producing audit event to Kafka topic %q", k.Topic)
+	}
+	return nil
+}
+
+// post posts body to url and returns an error unless it succeeds.
+func post(client *http.Client, url string, contentType string, headers map[string][]string, body []byte) error {
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for header, values := range headers {
+		for _, value := range values {
+			req.Header.Add(header, value)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("returned status code %d with response %q", resp.StatusCode, respBody)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/audit"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// AuditProjectCommandRunner sends an audit event for each project command
+// that it runs.
+type AuditProjectCommandRunner struct {
+	ProjectCommandRunner
+	Sink audit.Sink
+}
+
+func (p *AuditProjectCommandRunner) Plan(ctx command.ProjectContext) command.ProjectResult {
+	return p.audit(ctx, p.ProjectCommandRunner.Plan)
+}
+
+func (p *AuditProjectCommandRunner) PolicyCheck(ctx command.ProjectContext) command.ProjectResult {
+	return p.audit(ctx, p.ProjectCommandRunner.PolicyCheck)
+}
+
+func (p *AuditProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
+	return p.audit(ctx, p.ProjectCommandRunner.Apply)
+}
+
+func (p *AuditProjectCommandRunner) ApprovePolicies(ctx command.ProjectContext) command.ProjectResult {
+	return p.audit(ctx, p.ProjectCommandRunner.ApprovePolicies)
+}
+
+func (p *AuditProjectCommandRunner) Version(ctx command.ProjectContext) command.ProjectResult {
+	return p.audit(ctx, p.ProjectCommandRunner.Version)
+}
+
+func (p *AuditProjectCommandRunner) Import(ctx command.ProjectContext) command.ProjectResult {
+	return p.audit(ctx, p.ProjectCommandRunner.Import)
+}
+
+func (p *AuditProjectCommandRunner) StateRm(ctx command.ProjectContext) command.ProjectResult {
+	return p.audit(ctx, p.ProjectCommandRunner.StateRm)
+}
+
+func (p *AuditProjectCommandRunner) StatePull(ctx command.ProjectContext) command.ProjectResult {
+	return p.audit(ctx, p.ProjectCommandRunner.StatePull)
+}
+
+func (p *AuditProjectCommandRunner) StatePush(ctx command.ProjectContext) command.ProjectResult {
+	return p.audit(ctx, p.ProjectCommandRunner.StatePush)
+}
+
+func (p *AuditProjectCommandRunner) Custom(ctx command.ProjectContext) command.ProjectResult {
+	return p.audit(ctx, p.ProjectCommandRunner.Custom)
+}
+
+func (p *AuditProjectCommandRunner) audit(ctx command.ProjectContext, execute func(ctx command.ProjectContext) command.ProjectResult) command.ProjectResult {
+	start := time.Now()
+	result := execute(ctx)
+
+	event := audit.Event{
+		Time:       start.UTC(),
+		User:       ctx.User.Username,
+		Repo:       ctx.BaseRepo.FullName,
+		Pull:       ctx.Pull.Num,
+		Project:    ctx.ProjectName,
+		Dir:        ctx.RepoRelDir,
+		Workspace:  ctx.Workspace,
+		Command:    ctx.CommandName.String(),
+		Result:     audit.SuccessResult,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if result.Error != nil {
+		event.Result = audit.ErrorResult
+		event.Error = result.Error.Error()
+	} else if result.Failure != "" {
+		event.Result = audit.FailureResult
+		event.Error = result.Failure
+	}
+	if err := p.Sink.Send(ctx.Log, event); err != nil {
+		ctx.Log.Err("error sending audit event: %s", err)
+	}
+	return result
+}
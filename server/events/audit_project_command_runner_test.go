@@ -0,0 +1,66 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/audit"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeAuditSink struct {
+	events []audit.Event
+}
+
+func (f *fakeAuditSink) Send(_ logging.SimpleLogging, event audit.Event) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func TestAuditProjectCommandRunner(t *testing.T) {
+	RegisterMockTestingT(t)
+	runner := mocks.NewMockProjectCommandRunner()
+	sink := &fakeAuditSink{}
+	auditRunner := &events.AuditProjectCommandRunner{ProjectCommandRunner: runner, Sink: sink}
+
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		CommandName: command.Apply,
+		User:        models.User{Username: "lkysow"},
+		BaseRepo:    models.Repo{FullName: "runatlantis/atlantis"},
+		Pull:        models.PullRequest{Num: 1},
+		ProjectName: "network",
+		RepoRelDir:  "network",
+		Workspace:   "default",
+	}
+	When(runner.Apply(ctx)).ThenReturn(command.ProjectResult{ApplySuccess: "applied"})
+	When(runner.Plan(ctx)).ThenReturn(command.ProjectResult{Error: errors.New("exit status 1")})
+	When(runner.Import(ctx)).ThenReturn(command.ProjectResult{Failure: "Pull request must be approved"})
+
+	Equals(t, "applied", auditRunner.Apply(ctx).ApplySuccess)
+	auditRunner.Plan(ctx)
+	auditRunner.Import(ctx)
+
+	Equals(t, 3, len(sink.events))
+	for _, event := range sink.events {
+		Equals(t, "lkysow", event.User)
+		Equals(t, "runatlantis/atlantis", event.Repo)
+		Equals(t, 1, event.Pull)
+		Equals(t, "network", event.Project)
+		Equals(t, "network", event.Dir)
+		Equals(t, "default", event.Workspace)
+		Assert(t, !event.Time.IsZero(), "expected time to be set")
+	}
+	Equals(t, audit.SuccessResult, sink.events[0].Result)
+	Equals(t, "", sink.events[0].Error)
+	Equals(t, audit.ErrorResult, sink.events[1].Result)
+	Equals(t, "exit status 1", sink.events[1].Error)
+	Equals(t, audit.FailureResult, sink.events[2].Result)
+	Equals(t, "Pull request must be approved", sink.events[2].Error)
+}
@@ -0,0 +1,150 @@
+package events
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// AutoplanSimulator works out what Atlantis would do for a set of modified
+// files without running anything.
+type AutoplanSimulator interface {
+	// SimulateAutoplan returns the projects that modifiedFiles would match in
+	// the repo at ctx.Pull and the config each of them would be run with.
+	SimulateAutoplan(ctx *command.Context, modifiedFiles []string) (AutoplanSimulation, error)
+}
+
+// AutoplanSimulation is the result of simulating an autoplan.
+type AutoplanSimulation struct {
+	// RepoConfigFile is the name of the repo config file that was looked for.
+	RepoConfigFile string
+	// HasRepoConfig is true if the repo has a repo config file.
+	HasRepoConfig bool
+	// AutoDiscover is true if projects are discovered automatically.
+	AutoDiscover bool
+	// Projects are the projects that the modified files match, whether they
+	// would be autoplanned or not.
+	Projects []SimulatedProject
+}
+
+// SimulatedProject is a project matched by a simulated autoplan with its
+// config after the repo config was merged with the server-side config.
+type SimulatedProject struct {
+	Name      string
+	Dir       string
+	Workspace string
+	// Autoplan is true if the project would be planned automatically.
+	Autoplan bool
+	// Reason is why the project was matched and whether it would be
+	// autoplanned.
+	Reason             string
+	Workflow           string
+	PlanSteps          []string
+	ApplySteps         []string
+	PlanRequirements   []string
+	ApplyRequirements  []string
+	ImportRequirements []string
+	TerraformVersion   string
+	PolicyCheck        bool
+	DependsOn          []string
+}
+
+// SimulateAutoplan clones the repo and matches modifiedFiles against its
+// projects the same way an autoplan would.
+func (p *DefaultProjectCommandBuilder) SimulateAutoplan(ctx *command.Context, modifiedFiles []string) (AutoplanSimulation, error) {
+	simulation := AutoplanSimulation{
+		RepoConfigFile: p.GlobalCfg.RepoConfigFile(ctx.Pull.BaseRepo.ID()),
+	}
+
+	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, DefaultWorkspace, DefaultRepoRelDir)
+	if err != nil {
+		ctx.Log.Warn("workspace was locked")
+		return simulation, err
+	}
+	ctx.Log.Debug("got workspace lock")
+	defer unlockFn()
+
+	repoDir, _, err := p.WorkingDir.Clone(ctx.Log, ctx.HeadRepo, ctx.Pull, DefaultWorkspace)
+	if err != nil {
+		return simulation, err
+	}
+
+	repoCfg, hasRepoCfg, err := p.parseRepoCfg(ctx, repoDir)
+	if err != nil {
+		return simulation, err
+	}
+	simulation.HasRepoConfig = hasRepoCfg
+	simulation.AutoDiscover = p.autoDiscoverModeEnabled(ctx, repoCfg)
+
+	mergedCfgs, err := p.getMergedProjectCfgs(ctx, repoDir, modifiedFiles, repoCfg)
+	if err != nil {
+		return simulation, err
+	}
+
+	configuredDirs := make(map[string]bool)
+	for _, proj := range repoCfg.Projects {
+		configuredDirs[filepath.Clean(proj.Dir)] = true
+	}
+	for _, mergedCfg := range mergedCfgs {
+		simulation.Projects = append(simulation.Projects, newSimulatedProject(mergedCfg, configuredDirs[filepath.Clean(mergedCfg.RepoRelDir)]))
+	}
+	return simulation, nil
+}
+
+func newSimulatedProject(mergedCfg valid.MergedProjectCfg, configured bool) SimulatedProject {
+	reason := "automatically discovered since files in its dir were modified"
+	if configured {
+		reason = "configured in the repo config and its when_modified patterns matched"
+	}
+	if len(mergedCfg.ManualTriggerOperators) > 0 {
+		reason += fmt.Sprintf("; it's in manual trigger mode so it can only be planned by its operators: %s", strings.Join(mergedCfg.ManualTriggerOperators, ", "))
+	} else if mergedCfg.AutoplanEnabled {
+		reason += "; autoplan is enabled"
+	} else {
+		reason += "; autoplan is disabled so it would only be planned with a comment"
+	}
+
+	proj := SimulatedProject{
+		Name:               mergedCfg.Name,
+		Dir:                mergedCfg.RepoRelDir,
+		Workspace:          mergedCfg.Workspace,
+		Autoplan:           mergedCfg.AutoplanEnabled,
+		Reason:             reason,
+		Workflow:           mergedCfg.Workflow.Name,
+		PlanSteps:          describeSteps(mergedCfg.Workflow.Plan.Steps),
+		ApplySteps:         describeSteps(mergedCfg.Workflow.Apply.Steps),
+		PlanRequirements:   mergedCfg.PlanRequirements,
+		ApplyRequirements:  mergedCfg.ApplyRequirements,
+		ImportRequirements: mergedCfg.ImportRequirements,
+		PolicyCheck:        mergedCfg.PolicyCheck,
+		DependsOn:          mergedCfg.DependsOn,
+	}
+	if mergedCfg.TerraformVersion != nil {
+		proj.TerraformVersion = mergedCfg.TerraformVersion.String()
+	}
+	return proj
+}
+
+// describeSteps returns steps the way they'd be written in a workflow, ex.
+// "init -upgrade" or "run: make plan".
+func describeSteps(steps []valid.Step) []string {
+	var descriptions []string
+	for _, step := range steps {
+		switch step.StepName {
+		case "run", "multienv":
+			descriptions = append(descriptions, fmt.Sprintf("%s: %s", step.StepName, step.RunCommand))
+		case "env":
+			value := step.EnvVarValue
+			if step.RunCommand != "" {
+				value = fmt.Sprintf("$(%s)", step.RunCommand)
+			}
+			descriptions = append(descriptions, fmt.Sprintf("env: %s=%s", step.EnvVarName, value))
+		default:
+			descriptions = append(descriptions, strings.TrimSpace(step.StepName+" "+strings.Join(step.ExtraArgs, " ")))
+		}
+	}
+	return descriptions
+}
@@ -0,0 +1,274 @@
+package events
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	tally "github.com/uber-go/tally/v4"
+)
+
+const (
+	// CircuitBreakerOpenedMetric counts how many times the circuit of a pull
+	// request was opened.
+	CircuitBreakerOpenedMetric = "opened"
+	// CircuitBreakerRejectedMetric counts the commands that weren't run
+	// because the circuit of their pull request was open.
+	CircuitBreakerRejectedMetric = "rejected"
+)
+
+// circuitMaxIdle is how long after its last failure a closed circuit is
+// forgotten, so that the circuits of closed pull requests don't pile up.
+const circuitMaxIdle = 24 * time.Hour
+
+// circuitBreakerErrRegexes match the errors that running a command again
+// won't fix until someone fixes a credential or the provider recovers, ex.
+// an expired token or a provider API returning 5xx errors.
+var circuitBreakerErrRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b401 unauthorized\b`),
+	regexp.MustCompile(`(?i)\b403 forbidden\b`),
+	regexp.MustCompile(`(?i)\bstatus ?code:? (401|403|5\d\d)\b`),
+	regexp.MustCompile(`(?i)\b5\d\d (internal server error|bad gateway|service unavailable|gateway timeout)\b`),
+	regexp.MustCompile(`\b(InvalidClientTokenId|ExpiredToken|UnrecognizedClientException|SignatureDoesNotMatch|AuthFailure)\b`),
+	regexp.MustCompile(`(?i)\binvalid_grant\b`),
+	regexp.MustCompile(`(?i)\bauthentication failed\b`),
+	regexp.MustCompile(`(?i)no valid credential sources`),
+	regexp.MustCompile(`Permission denied \(publickey`),
+	regexp.MustCompile(`could not read Username`),
+}
+
+// maxCircuitBreakerErrLen is how much of the error that opened a circuit is
+// kept to show in comments.
+const maxCircuitBreakerErrLen = 500
+
+// CircuitBreaker stops running commands on pull requests whose last commands
+// all failed with credential or provider errors, so that a broken credential
+// doesn't use up API quota and workers until someone notices. Once a pull
+// request's circuit is open, commands aren't run on it until the cooldown is
+// over. The next command is then run and, if it fails the same way, the
+// circuit opens again. Circuits are per pull request rather than per repo
+// since the output that's checked for errors can be controlled by whoever
+// opened the pull request, who mustn't be able to stop commands on the
+// other pull requests of the repo.
+type CircuitBreaker struct {
+	// Threshold is how many consecutive projects of a pull request must fail
+	// with a credential or provider error for its circuit to open.
+	Threshold int
+	// Cooldown is how long an open circuit stops commands from running.
+	Cooldown time.Duration
+	scope    tally.Scope
+	now      func() time.Time
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	pullNum   int
+	failures  int
+	failedAt  time.Time
+	openUntil time.Time
+	lastErr   string
+}
+
+// OpenCircuit describes why commands aren't run on a pull request.
+type OpenCircuit struct {
+	// PullNum is the number of the pull request.
+	PullNum int
+	// Until is when commands are run on the repo again.
+	Until time.Time
+	// Failures is how many consecutive projects failed.
+	Failures int
+	// LastErr is the error the last project failed with.
+	LastErr string
+}
+
+// NewCircuitBreaker returns a CircuitBreaker that opens the circuit of a pull
+// request after threshold consecutive failures for cooldown.
+func NewCircuitBreaker(threshold int, cooldown time.Duration, scope tally.Scope) *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		scope:     scope.SubScope("circuit_breaker"),
+		now:       time.Now,
+		circuits:  make(map[string]*circuit),
+	}
+}
+
+// Record updates the circuit of the project's pull request with the result
+// of running a command on it. Successes close the circuit, credential and
+// provider errors count towards opening it and other failures, like invalid
+// Terraform, are ignored.
+func (c *CircuitBreaker) Record(ctx command.ProjectContext, result command.ProjectResult) {
+	errMsg := result.Failure
+	if result.Error != nil {
+		errMsg = result.Error.Error()
+	}
+	key := circuitKey(ctx.BaseRepo, ctx.Pull.Num)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if errMsg == "" {
+		delete(c.circuits, key)
+		return
+	}
+	errLine := circuitBreakerErr(errMsg)
+	if errLine == "" {
+		return
+	}
+	now := c.now()
+	circ, ok := c.circuits[key]
+	if !ok {
+		c.forgetIdleCircuits(now)
+		circ = &circuit{pullNum: ctx.Pull.Num}
+		c.circuits[key] = circ
+	}
+	circ.failures++
+	circ.failedAt = now
+	circ.lastErr = errLine
+	if circ.failures < c.Threshold || now.Before(circ.openUntil) {
+		return
+	}
+	circ.openUntil = now.Add(c.Cooldown)
+	ctx.Log.Warn("not running commands on %s#%d until %s since its last %d projects failed with credential or provider errors, the last one was: %s",
+		ctx.BaseRepo.FullName, ctx.Pull.Num, circ.openUntil.Format(time.RFC3339), circ.failures, errLine)
+	c.repoScope(ctx.BaseRepo).Counter(CircuitBreakerOpenedMetric).Inc(1)
+}
+
+// Check returns why commands can't be run on pull request pullNum of repo
+// and true if its circuit is open.
+func (c *CircuitBreaker) Check(repo models.Repo, pullNum int) (OpenCircuit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	open, ok := c.openCircuit(circuitKey(repo, pullNum))
+	if ok {
+		c.repoScope(repo).Counter(CircuitBreakerRejectedMetric).Inc(1)
+	}
+	return open, ok
+}
+
+// Status returns the open circuits of the pull requests of the repo named
+// repoFullName on any VCS host, ordered by pull request number. Unlike
+// Check, it doesn't count as a rejected command.
+func (c *CircuitBreaker) Status(repoFullName string) []OpenCircuit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var opens []OpenCircuit
+	for key := range c.circuits {
+		repoID, _, _ := strings.Cut(key, "#")
+		if !strings.HasSuffix(repoID, "/"+repoFullName) {
+			continue
+		}
+		if open, ok := c.openCircuit(key); ok {
+			opens = append(opens, open)
+		}
+	}
+	sort.Slice(opens, func(i, j int) bool { return opens[i].PullNum < opens[j].PullNum })
+	return opens
+}
+
+func (c *CircuitBreaker) openCircuit(key string) (OpenCircuit, bool) {
+	circ, ok := c.circuits[key]
+	if !ok || !c.now().Before(circ.openUntil) {
+		return OpenCircuit{}, false
+	}
+	return OpenCircuit{
+		PullNum:  circ.pullNum,
+		Until:    circ.openUntil,
+		Failures: circ.failures,
+		LastErr:  circ.lastErr,
+	}, true
+}
+
+// forgetIdleCircuits deletes the closed circuits that haven't failed in
+// circuitMaxIdle.
+func (c *CircuitBreaker) forgetIdleCircuits(now time.Time) {
+	for key, circ := range c.circuits {
+		if !now.Before(circ.openUntil) && now.Sub(circ.failedAt) > circuitMaxIdle {
+			delete(c.circuits, key)
+		}
+	}
+}
+
+func circuitKey(repo models.Repo, pullNum int) string {
+	return fmt.Sprintf("%s#%d", repo.ID(), pullNum)
+}
+
+func (c *CircuitBreaker) repoScope(repo models.Repo) tally.Scope {
+	return c.scope.Tagged(map[string]string{"base_repo": repo.FullName})
+}
+
+// Comment returns the comment explaining why the command named cmdName
+// wasn't run.
+func (o OpenCircuit) Comment(cmdName string) string {
+	return fmt.Sprintf("**Error**: not running %s: the last %d projects run on this pull request failed with credential or provider errors, "+
+		"so no commands are run on it until %s. Fix the credentials or wait for the provider to recover, then run the command again. "+
+		"The last error was:\n```\n%s\n```", cmdName, o.Failures, o.Until.UTC().Format("2006-01-02 15:04 MST"), o.LastErr)
+}
+
+// circuitBreakerErr returns the line of errMsg with a credential or provider
+// error, or an empty string if it doesn't have one.
+func circuitBreakerErr(errMsg string) string {
+	for _, line := range strings.Split(errMsg, "\n") {
+		for _, r := range circuitBreakerErrRegexes {
+			if !r.MatchString(line) {
+				continue
+			}
+			line = strings.TrimSpace(line)
+			if len(line) > maxCircuitBreakerErrLen {
+				line = line[:maxCircuitBreakerErrLen] + "..."
+			}
+			return line
+		}
+	}
+	return ""
+}
+
+// CircuitBreakerProjectCommandRunner records the results of the project
+// commands that use credentials in a CircuitBreaker.
+type CircuitBreakerProjectCommandRunner struct {
+	ProjectCommandRunner
+	CircuitBreaker *CircuitBreaker
+}
+
+func (p *CircuitBreakerProjectCommandRunner) Plan(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.Plan)
+}
+
+func (p *CircuitBreakerProjectCommandRunner) PolicyCheck(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.PolicyCheck)
+}
+
+func (p *CircuitBreakerProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.Apply)
+}
+
+func (p *CircuitBreakerProjectCommandRunner) Import(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.Import)
+}
+
+func (p *CircuitBreakerProjectCommandRunner) StateRm(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.StateRm)
+}
+
+func (p *CircuitBreakerProjectCommandRunner) StatePull(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.StatePull)
+}
+
+func (p *CircuitBreakerProjectCommandRunner) StatePush(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.StatePush)
+}
+
+func (p *CircuitBreakerProjectCommandRunner) Custom(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.Custom)
+}
+
+func (p *CircuitBreakerProjectCommandRunner) record(ctx command.ProjectContext, execute func(ctx command.ProjectContext) command.ProjectResult) command.ProjectResult {
+	result := execute(ctx)
+	p.CircuitBreaker.Record(ctx, result)
+	return result
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestCircuitBreakerErr(t *testing.T) {
+	cases := []struct {
+		errMsg string
+		exp    string
+	}{
+		{"Error: Invalid reference\n\non main.tf line 3", ""},
+		{"exit status 1: Error: retrieving account details: operation error STS: GetCallerIdentity, https response error StatusCode: 403, RequestID: abc, api error ExpiredToken: The security token included in the request is expired", "exit status 1: Error: retrieving account details: operation error STS: GetCallerIdentity, https response error StatusCode: 403, RequestID: abc, api error ExpiredToken: The security token included in the request is expired"},
+		{"running git clone:\n  remote: HTTP Basic: Access denied\n  fatal: Authentication failed for 'https://gitlab.com/org/repo.git/'", "fatal: Authentication failed for 'https://gitlab.com/org/repo.git/'"},
+		{"Error: Failed to query available provider packages\n\n  502 Bad Gateway", "502 Bad Gateway"},
+		{"oauth2: cannot fetch token: 400 Bad Request\nResponse: {\"error\":\"invalid_grant\"}", "Response: {\"error\":\"invalid_grant\"}"},
+		{"Error: creating EC2 Instance: InvalidAMIID.NotFound", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.errMsg, func(t *testing.T) {
+			Equals(t, c.exp, circuitBreakerErr(c.errMsg))
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	scope := tally.NewTestScope("atlantis", nil)
+	breaker := NewCircuitBreaker(2, 10*time.Minute, scope)
+	now := time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+
+	repo := models.Repo{FullName: "org/infra", VCSHost: models.VCSHost{Hostname: "github.com", Type: models.Github}}
+	otherRepo := models.Repo{FullName: "org/other", VCSHost: models.VCSHost{Hostname: "github.com", Type: models.Github}}
+	pull := models.PullRequest{Num: 1, BaseRepo: repo}
+	ctx := command.ProjectContext{Log: logging.NewNoopLogger(t), BaseRepo: repo, Pull: pull}
+	authErr := command.ProjectResult{Error: errors.New("exit status 1: Error: 401 Unauthorized")}
+	tfFailure := command.ProjectResult{Failure: "Error: Unsupported argument"}
+
+	// Failures that aren't credential or provider errors don't count.
+	breaker.Record(ctx, authErr)
+	breaker.Record(ctx, tfFailure)
+	_, open := breaker.Check(repo, 1)
+	Assert(t, !open, "expected the circuit to be closed after 1 failure")
+
+	breaker.Record(ctx, authErr)
+	circ, open := breaker.Check(repo, 1)
+	Assert(t, open, "expected the circuit to be open after 2 failures")
+	Equals(t, OpenCircuit{PullNum: 1, Until: now.Add(10 * time.Minute), Failures: 2, LastErr: "exit status 1: Error: 401 Unauthorized"}, circ)
+	Equals(t, []OpenCircuit{circ}, breaker.Status("org/infra"))
+	_, open = breaker.Check(otherRepo, 1)
+	Assert(t, !open, "expected the circuit of other repos to be closed")
+	// The errors of a pull request can come from its own code so they don't
+	// stop commands on the other pull requests of the repo.
+	_, open = breaker.Check(repo, 2)
+	Assert(t, !open, "expected the circuit of other pull requests to be closed")
+
+	// Once the cooldown is over, a single failure opens it again.
+	now = now.Add(10 * time.Minute)
+	_, open = breaker.Check(repo, 1)
+	Assert(t, !open, "expected the circuit to be closed after the cooldown")
+	breaker.Record(ctx, authErr)
+	circ, open = breaker.Check(repo, 1)
+	Assert(t, open, "expected the circuit to open again")
+	Equals(t, now.Add(10*time.Minute), circ.Until)
+
+	// A success closes it.
+	now = now.Add(10 * time.Minute)
+	breaker.Record(ctx, command.ProjectResult{PlanSuccess: &models.PlanSuccess{}})
+	breaker.Record(ctx, authErr)
+	_, open = breaker.Check(repo, 1)
+	Assert(t, !open, "expected the circuit to be closed after a success")
+	Equals(t, 0, len(breaker.Status("org/infra")))
+
+	// Closed circuits that haven't failed in a while are forgotten.
+	now = now.Add(circuitMaxIdle + time.Minute)
+	breaker.Record(command.ProjectContext{Log: ctx.Log, BaseRepo: repo, Pull: models.PullRequest{Num: 2}}, authErr)
+	_, ok := breaker.circuits[circuitKey(repo, 1)]
+	Assert(t, !ok, "expected the idle circuit to be forgotten")
+
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(2), counters["atlantis.circuit_breaker.opened+base_repo=org/infra"].Value())
+	Equals(t, int64(2), counters["atlantis.circuit_breaker.rejected+base_repo=org/infra"].Value())
+}
@@ -42,11 +42,30 @@ type Context struct {
 	// ClearPolicyApproval is true if approval should be cleared on specified policies.
 	ClearPolicyApproval bool
 
+	// ForcePlan is true if plans should be run even if the plan from the last
+	// run can be reused.
+	ForcePlan bool
+
 	Trigger Trigger
 
 	// API is true if plan/apply by API endpoints
 	API bool
 
+	// PreWorkflowHookOutputs are the rendered outputs of pre workflow hooks
+	// with output: command_comment. They're prepended to the command's
+	// comment.
+	PreWorkflowHookOutputs []string
+
+	// Results are the results of the commands that were run for this context,
+	// ex. the plan and policy check of an autoplan. They're used by post
+	// workflow hooks.
+	Results map[Name]Result
+
+	// CommentTemplates are the contents of the repo's comment_templates
+	// files keyed by the kind of comment they render, ex. plan. They're
+	// loaded with the repo config.
+	CommentTemplates map[string]string
+
 	// TeamAllowlistChecker is used to check authorization on a project-level
 	TeamAllowlistChecker TeamAllowlistChecker
 }
@@ -28,8 +28,17 @@ const (
 	Version
 	// Import is a command to run terraform import
 	Import
-	// State is a command to run terraform state rm
+	// State is a command to run terraform state rm, pull or push
 	State
+	// OkToTest is a command to allow Atlantis to run commands on a pull
+	// request from a fork.
+	OkToTest
+	// Custom is a command defined in the server-side repo config. Its name
+	// is the sub command, ex. "docs" for atlantis docs.
+	Custom
+	// Destroy is a command to plan the destruction of a project and, once
+	// confirmed, apply it.
+	Destroy
 	// Adding more? Don't forget to update String() below
 )
 
@@ -47,6 +56,8 @@ var AllCommentCommands = []Name{
 	ApprovePolicies,
 	Import,
 	State,
+	OkToTest,
+	Destroy,
 }
 
 // TitleString returns the string representation in title form.
@@ -74,6 +85,12 @@ func (c Name) String() string {
 		return "import"
 	case State:
 		return "state"
+	case OkToTest:
+		return "ok-to-test"
+	case Custom:
+		return "custom"
+	case Destroy:
+		return "destroy"
 	}
 	return ""
 }
@@ -84,7 +101,7 @@ func (c Name) DefaultUsage() string {
 	case Import:
 		return "import ADDRESS ID"
 	case State:
-		return "state [rm ADDRESS...]"
+		return "state [rm ADDRESS...|pull|push PATH]"
 	default:
 		return c.String()
 	}
@@ -94,7 +111,7 @@ func (c Name) DefaultUsage() string {
 func (c Name) SubCommands() []string {
 	switch c {
 	case State:
-		return []string{"rm"}
+		return []string{"rm", "pull", "push"}
 	default:
 		return nil
 	}
@@ -106,8 +123,13 @@ func (c Name) CommandArgCount(subCommand string) (*ArgCount, error) {
 	case Import:
 		return &ArgCount{2, 2}, nil // "atlantis import ADDRESS ID"
 	case State:
-		if subCommand == "rm" {
+		switch subCommand {
+		case "rm":
 			return &ArgCount{1, -1}, nil // "atlantis state rm ADDRESS..."
+		case "pull":
+			return &ArgCount{0, 0}, nil // "atlantis state pull"
+		case "push":
+			return &ArgCount{1, 1}, nil // "atlantis state push PATH"
 		}
 		return nil, fmt.Errorf("command arg count unknown sub command: %s", subCommand)
 	default:
@@ -149,6 +171,10 @@ func ParseCommandName(name string) (Name, error) {
 		return Import, nil
 	case "state":
 		return State, nil
+	case "ok-to-test":
+		return OkToTest, nil
+	case "destroy":
+		return Destroy, nil
 	}
 	return -1, fmt.Errorf("unknown command name: %s", name)
 }
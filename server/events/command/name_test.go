@@ -41,6 +41,9 @@ func TestName_String(t *testing.T) {
 		{command.Version, "version"},
 		{command.Import, "import"},
 		{command.State, "state"},
+		{command.OkToTest, "ok-to-test"},
+		{command.Custom, "custom"},
+		{command.Destroy, "destroy"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.want, func(t *testing.T) {
@@ -63,7 +66,7 @@ func TestName_DefaultUsage(t *testing.T) {
 		{command.ApprovePolicies, "approve_policies"},
 		{command.Version, "version"},
 		{command.Import, "import ADDRESS ID"},
-		{command.State, "state [rm ADDRESS...]"},
+		{command.State, "state [rm ADDRESS...|pull|push PATH]"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.c.String(), func(t *testing.T) {
@@ -86,7 +89,7 @@ func TestName_SubCommands(t *testing.T) {
 		{c: command.ApprovePolicies},
 		{c: command.Version},
 		{c: command.Import},
-		{c: command.State, want: []string{"rm"}},
+		{c: command.State, want: []string{"rm", "pull", "push"}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.c.String(), func(t *testing.T) {
@@ -112,6 +115,8 @@ func TestName_CommandArgCount(t *testing.T) {
 		{c: command.Version, want: &command.ArgCount{}},
 		{c: command.Import, want: &command.ArgCount{Min: 2, Max: 2}},
 		{c: command.State, subCommand: "rm", want: &command.ArgCount{Min: 1, Max: -1}},
+		{c: command.State, subCommand: "pull", want: &command.ArgCount{Min: 0, Max: 0}},
+		{c: command.State, subCommand: "push", want: &command.ArgCount{Min: 1, Max: 1}},
 		{c: command.State, subCommand: "unknown", wantErr: true},
 	}
 	for _, tt := range tests {
@@ -179,6 +184,8 @@ func TestParseCommandName(t *testing.T) {
 		{command.Version, "version"},
 		{command.Import, "import"},
 		{command.State, "state"},
+		{command.OkToTest, "ok-to-test"},
+		{command.Destroy, "destroy"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1,9 +1,11 @@
 package command
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -64,6 +66,9 @@ type ProjectContext struct {
 	// orders and to warn the user if they're applying a project that
 	// depends on other projects.
 	DependsOn []string
+	// PromoteTo is the name of the project that is planned once this one is
+	// applied since it's the next stage of its promotion.
+	PromoteTo string
 	// Log is a logger that's been set up for this context.
 	Log logging.SimpleLogging
 	// Scope is the scope for reporting stats setup for this context
@@ -88,6 +93,9 @@ type ProjectContext struct {
 	// RePlanCmd is the command that users should run to re-plan this project.
 	// If this is an apply then this will be empty.
 	RePlanCmd string
+	// UnlockCmd is the command that users should run to release this
+	// project's lock and discard its plan.
+	UnlockCmd string
 	// RepoRelDir is the directory of this project relative to the repo root.
 	RepoRelDir string
 	// Steps are the sequence of commands we need to run for this project and this
@@ -101,6 +109,44 @@ type ProjectContext struct {
 	// commands for this project. This can be set to nil in which case we will
 	// use the default Atlantis terraform version.
 	TerraformVersion *version.Version
+	// TerraformVars are passed to terraform plan as -var flags. They're set
+	// for preview environments.
+	TerraformVars map[string]string
+	// Envs are set in the environment of every step, ex. by the project
+	// command hook.
+	Envs map[string]string
+	// DestroyOnClose is true if the project's resources should be destroyed
+	// when the pull request is closed.
+	DestroyOnClose bool
+	// AutoApplyDestroyOnClose is true if the destroy is applied rather than
+	// only planned.
+	AutoApplyDestroyOnClose bool
+	// ManualTriggerOperators are set if the project is in manual trigger mode.
+	// Only they can plan and apply it.
+	ManualTriggerOperators []string
+	// GithubEnvironment is set if the project is deployed to a GitHub
+	// environment whose protection rules must pass before it's applied.
+	GithubEnvironment *valid.GithubEnvironment
+	// Timeout is how long the project's steps can run before they're
+	// canceled. If 0, they're never canceled.
+	Timeout time.Duration
+	// TimeoutCtx is done once the project's steps have run for Timeout or
+	// its job is canceled. The processes they run are interrupted when it's
+	// done, or terminated if the job was canceled. It's nil until the steps
+	// start running, and if they can be neither timed out nor canceled.
+	TimeoutCtx context.Context
+	// Secrets are the values the project's env steps read from secret
+	// providers, which are redacted from its output. It's nil until the
+	// steps start running.
+	Secrets *SecretRedactor
+	// PreWorkflowHooks are run in the project's directory before its steps.
+	PreWorkflowHooks []*valid.WorkflowHook
+	// PostWorkflowHooks are run in the project's directory after its steps,
+	// even if they failed.
+	PostWorkflowHooks []*valid.WorkflowHook
+	// SuppressedChanges are the kinds of noisy changes that are suppressed
+	// from the plan output and listed in their own section of the comment.
+	SuppressedChanges []valid.SuppressedChange
 	// Configuration metadata for a given project.
 	User models.User
 	// Verbose is true when the user would like verbose output.
@@ -115,6 +161,9 @@ type ProjectContext struct {
 	PolicySetTarget string
 	// ClearPolicyApproval determines whether policy counts will be incremented or cleared.
 	ClearPolicyApproval bool
+	// ForcePlan is true if the plan should be run even if the plan from the
+	// last run can be reused.
+	ForcePlan bool
 	// DeleteSourceBranchOnMerge will attempt to allow a branch to be deleted when merged (AzureDevOps & GitLab Support Only)
 	DeleteSourceBranchOnMerge bool
 	// Repo locks mode: disabled, on plan or on apply
@@ -127,6 +176,9 @@ type ProjectContext struct {
 	ExecutionOrderGroup int
 	// If plans/applies should be aborted if any prior plan/apply fails
 	AbortOnExecutionOrderFail bool
+	// ParallelApplyGroupsEnabled is true if execution order groups that don't
+	// depend on each other should be applied in parallel.
+	ParallelApplyGroupsEnabled bool
 	// Allows custom policy check tools outside of Conftest to run in checks
 	CustomPolicyCheck bool
 	SilencePRComments []string
@@ -163,6 +215,26 @@ func (p ProjectContext) GetShowResultFileName() string {
 	return fmt.Sprintf("%s-%s.json", projName, p.Workspace)
 }
 
+// GetShowTextFileName returns the filename (not the path) to store the text
+// output of tf show
+func (p ProjectContext) GetShowTextFileName() string {
+	if p.ProjectName == "" {
+		return fmt.Sprintf("%s-show.txt", p.Workspace)
+	}
+	projName := strings.Replace(p.ProjectName, "/", planfileSlashReplace, -1)
+	return fmt.Sprintf("%s-%s-show.txt", projName, p.Workspace)
+}
+
+// GetCostEstimateFileName returns the filename (not the path) to store the
+// Infracost output of the cost_estimate step in.
+func (p ProjectContext) GetCostEstimateFileName() string {
+	if p.ProjectName == "" {
+		return fmt.Sprintf("%s-infracost.json", p.Workspace)
+	}
+	projName := strings.Replace(p.ProjectName, "/", planfileSlashReplace, -1)
+	return fmt.Sprintf("%s-%s-infracost.json", projName, p.Workspace)
+}
+
 // GetPolicyCheckResultFileName returns the filename (not the path) to store the result from conftest_client.
 func (p ProjectContext) GetPolicyCheckResultFileName() string {
 	if p.ProjectName == "" {
@@ -204,7 +276,7 @@ func (p ProjectContext) PolicyCleared() bool {
 		}
 		for _, psCfg := range p.PolicySets.PolicySets {
 			if psStatus.PolicySetName == psCfg.Name {
-				if psStatus.Approvals != psCfg.ApproveCount {
+				if psStatus.Approvals != psStatus.RequiredApprovals(psCfg.ApproveCount) {
 					passing = false
 				}
 			}
@@ -1,6 +1,8 @@
 package command
 
 import (
+	"time"
+
 	"github.com/runatlantis/atlantis/server/events/models"
 )
 
@@ -18,8 +20,16 @@ type ProjectResult struct {
 	VersionSuccess     string
 	ImportSuccess      *models.ImportSuccess
 	StateRmSuccess     *models.StateRmSuccess
+	StatePullSuccess   *models.StatePullSuccess
+	StatePushSuccess   *models.StatePushSuccess
+	CustomSuccess      string
 	ProjectName        string
 	SilencePRComments  []string
+	// JobURL is the url to the full output of the command, if it's streamed
+	// to a job.
+	JobURL string
+	// Duration is how long the command took to run.
+	Duration time.Duration
 }
 
 // CommitStatus returns the vcs commit status of this project result.
@@ -42,6 +52,7 @@ func (p ProjectResult) PolicyStatus() []models.PolicySetStatus {
 				PolicySetName: policySet.PolicySetName,
 				Passed:        policySet.Passed,
 				Approvals:     policySet.CurApprovals,
+				ReqApprovals:  policySet.ReqApprovals,
 			}
 			policyStatuses = append(policyStatuses, policyStatus)
 		}
@@ -76,6 +87,13 @@ func (p ProjectResult) PlanStatus() models.ProjectPlanStatus {
 			return models.ErroredApplyStatus
 		}
 		return models.AppliedPlanStatus
+	case Destroy:
+		if p.Error != nil {
+			return models.ErroredPlanStatus
+		} else if p.Failure != "" {
+			return models.ErroredPlanStatus
+		}
+		return models.PlannedDestroyPlanStatus
 	}
 
 	panic("PlanStatus() missing a combination")
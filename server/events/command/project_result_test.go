@@ -137,6 +137,20 @@ func TestProjectResult_PlanStatus(t *testing.T) {
 			},
 			expStatus: models.ErroredPolicyCheckStatus,
 		},
+		{
+			p: command.ProjectResult{
+				Command:     command.Destroy,
+				PlanSuccess: &models.PlanSuccess{},
+			},
+			expStatus: models.PlannedDestroyPlanStatus,
+		},
+		{
+			p: command.ProjectResult{
+				Command: command.Destroy,
+				Error:   errors.New("err"),
+			},
+			expStatus: models.ErroredPlanStatus,
+		},
 	}
 
 	for _, c := range cases {
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// secretRedaction replaces secrets in redacted output.
+const secretRedaction = "<redacted>"
+
+// minSecretLength is the length of the shortest value that's redacted.
+// Shorter values would mostly redact unrelated output.
+const minSecretLength = 4
+
+// SecretRedactor redacts secrets from output.
+type SecretRedactor struct {
+	mutex   sync.RWMutex
+	secrets []string
+}
+
+// Add adds secret to the redacted values. Each line of a multiline secret is
+// also redacted on its own since output is often streamed line by line.
+func (r *SecretRedactor) Add(secret string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, s := range append([]string{secret}, strings.Split(secret, "\n")...) {
+		s = strings.TrimSpace(s)
+		if len(s) < minSecretLength {
+			continue
+		}
+		r.secrets = append(r.secrets, s)
+	}
+	// Longer secrets are redacted first so a secret that contains another
+	// isn't partially left.
+	sort.SliceStable(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+}
+
+// Redact returns output with the secrets replaced by <redacted>.
+func (r *SecretRedactor) Redact(output string) string {
+	if r == nil {
+		return output
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, s := range r.secrets {
+		output = strings.ReplaceAll(output, s, secretRedaction)
+	}
+	return output
+}
@@ -0,0 +1,24 @@
+package command_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestSecretRedactor_Redact(t *testing.T) {
+	var nilRedactor *command.SecretRedactor
+	Equals(t, "password: hunter22", nilRedactor.Redact("password: hunter22"))
+
+	r := &command.SecretRedactor{}
+	r.Add("hunter22")
+	r.Add("abc")
+	r.Add("-----BEGIN KEY-----\nMIIEvQIBADANBg\n-----END KEY-----")
+	r.Add("hunter2222")
+
+	Equals(t, "password: <redacted> abc", r.Redact("password: hunter22 abc"))
+	Equals(t, "password: <redacted>", r.Redact("password: hunter2222"))
+	Equals(t, "key: <redacted>", r.Redact("key: -----BEGIN KEY-----\nMIIEvQIBADANBg\n-----END KEY-----"))
+	Equals(t, "  <redacted>", r.Redact("  MIIEvQIBADANBg"))
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -9,6 +10,11 @@ import (
 // Wildcard matches all teams and all commands
 const wildcard = "*"
 
+// OrgTeamPrefix prefixes the teams of allowlists that are the members of an
+// organization, ex. org:acme. Users get these teams for the organizations they
+// are members of.
+const OrgTeamPrefix = "org:"
+
 // mapOfStrings is an alias for map[string]string
 type mapOfStrings map[string]string
 
@@ -32,15 +38,19 @@ type DefaultTeamAllowlistChecker struct {
 	rules []mapOfStrings
 }
 
-// NewTeamAllowlistChecker constructs a new checker
+// NewTeamAllowlistChecker constructs a new checker. Entries are
+// <team>:<command>, or org:<org>:<command> for the members of an organization.
 func NewTeamAllowlistChecker(allowlist string) (*DefaultTeamAllowlistChecker, error) {
 	var rules []mapOfStrings
 	pairs := strings.Split(allowlist, ",")
 	if pairs[0] != "" {
 		for _, pair := range pairs {
-			values := strings.Split(pair, ":")
-			team := strings.TrimSpace(values[0])
-			command := strings.TrimSpace(values[1])
+			i := strings.LastIndex(pair, ":")
+			if i < 0 {
+				return nil, fmt.Errorf("invalid team allowlist entry %q, must be <team>:<command> or %s<org>:<command>", strings.TrimSpace(pair), OrgTeamPrefix)
+			}
+			team := strings.TrimSpace(pair[:i])
+			command := strings.TrimSpace(pair[i+1:])
 			m := mapOfStrings{team: command}
 			rules = append(rules, m)
 		}
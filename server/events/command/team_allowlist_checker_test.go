@@ -15,6 +15,20 @@ func TestNewTeamAllowListChecker(t *testing.T) {
 	Ok(t, err)
 }
 
+func TestNewTeamAllowListCheckerInvalid(t *testing.T) {
+	_, err := command.NewTeamAllowlistChecker(`bob:plan, dave`)
+	ErrEquals(t, `invalid team allowlist entry "dave", must be <team>:<command> or org:<org>:<command>`, err)
+}
+
+func TestIsCommandAllowedForOrg(t *testing.T) {
+	checker, err := command.NewTeamAllowlistChecker(`org:acme:plan, bob:apply`)
+	Ok(t, err)
+	Equals(t, []string{"org:acme", "bob"}, checker.AllTeams())
+	Equals(t, true, checker.IsCommandAllowedForAnyTeam(models.TeamAllowlistCheckerContext{}, []string{"org:acme"}, "plan"))
+	Equals(t, false, checker.IsCommandAllowedForAnyTeam(models.TeamAllowlistCheckerContext{}, []string{"acme"}, "plan"))
+	Equals(t, false, checker.IsCommandAllowedForAnyTeam(models.TeamAllowlistCheckerContext{}, []string{"org:acme"}, "apply"))
+}
+
 func TestNewTeamAllowListCheckerEmpty(t *testing.T) {
 	allowlist := ``
 	checker, err := command.NewTeamAllowlistChecker(allowlist)
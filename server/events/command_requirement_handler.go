@@ -2,6 +2,7 @@ package events
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/runatlantis/atlantis/server/core/config/raw"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -15,6 +16,8 @@ type CommandRequirementHandler interface {
 	ValidatePlanProject(repoDir string, ctx command.ProjectContext) (string, error)
 	ValidateApplyProject(repoDir string, ctx command.ProjectContext) (string, error)
 	ValidateImportProject(repoDir string, ctx command.ProjectContext) (string, error)
+	ValidateStateProject(repoDir string, ctx command.ProjectContext) (string, error)
+	ValidateCustomProject(repoDir string, ctx command.ProjectContext) (string, error)
 }
 
 type DefaultCommandRequirementHandler struct {
@@ -22,6 +25,9 @@ type DefaultCommandRequirementHandler struct {
 }
 
 func (a *DefaultCommandRequirementHandler) ValidatePlanProject(repoDir string, ctx command.ProjectContext) (failure string, err error) {
+	if failure := validateManualTriggerOperator(ctx, "planned"); failure != "" {
+		return failure, nil
+	}
 	for _, req := range ctx.PlanRequirements {
 		switch req {
 		case raw.ApprovedRequirement:
@@ -43,6 +49,9 @@ func (a *DefaultCommandRequirementHandler) ValidatePlanProject(repoDir string, c
 }
 
 func (a *DefaultCommandRequirementHandler) ValidateApplyProject(repoDir string, ctx command.ProjectContext) (failure string, err error) {
+	if failure := validateManualTriggerOperator(ctx, "applied"); failure != "" {
+		return failure, nil
+	}
 	for _, req := range ctx.ApplyRequirements {
 		switch req {
 		case raw.ApprovedRequirement:
@@ -84,6 +93,9 @@ func (a *DefaultCommandRequirementHandler) ValidateProjectDependencies(ctx comma
 }
 
 func (a *DefaultCommandRequirementHandler) ValidateImportProject(repoDir string, ctx command.ProjectContext) (failure string, err error) {
+	if failure := validateManualTriggerOperator(ctx, "imported into"); failure != "" {
+		return failure, nil
+	}
 	for _, req := range ctx.ImportRequirements {
 		switch req {
 		case raw.ApprovedRequirement:
@@ -103,3 +115,28 @@ func (a *DefaultCommandRequirementHandler) ValidateImportProject(repoDir string,
 	// Passed all import requirements configured.
 	return "", nil
 }
+
+// ValidateStateProject checks that the state of the project can be pulled,
+// pushed or have resources removed from it by the user.
+func (a *DefaultCommandRequirementHandler) ValidateStateProject(_ string, ctx command.ProjectContext) (failure string, err error) {
+	return validateManualTriggerOperator(ctx, "used with state commands"), nil
+}
+
+// ValidateCustomProject checks that custom commands can be run on the project
+// by the user.
+func (a *DefaultCommandRequirementHandler) ValidateCustomProject(_ string, ctx command.ProjectContext) (failure string, err error) {
+	return validateManualTriggerOperator(ctx, "used with custom commands"), nil
+}
+
+// validateManualTriggerOperator returns a failure if the project is in manual
+// trigger mode and the user isn't one of its operators.
+func validateManualTriggerOperator(ctx command.ProjectContext, verb string) string {
+	if len(ctx.ManualTriggerOperators) == 0 {
+		return ""
+	}
+	manualTrigger := valid.ManualTrigger{Operators: ctx.ManualTriggerOperators}
+	if manualTrigger.IsOperator(ctx.User.Username, ctx.User.Teams) {
+		return ""
+	}
+	return fmt.Sprintf("This project is in manual trigger mode and can only be %s by its operators: %s.", verb, strings.Join(ctx.ManualTriggerOperators, ", "))
+}
@@ -71,6 +71,23 @@ func TestAggregateApplyRequirements_ValidatePlanProject(t *testing.T) {
 			wantFailure: "Pull request must be mergeable before running plan.",
 			wantErr:     assert.NoError,
 		},
+		{
+			name: "pass by manual trigger operator team",
+			ctx: command.ProjectContext{
+				ManualTriggerOperators: []string{"alice", "infra-admins"},
+				User:                   models.User{Username: "bob", Teams: []string{"infra-admins"}},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "fail by not manual trigger operator",
+			ctx: command.ProjectContext{
+				ManualTriggerOperators: []string{"alice", "infra-admins"},
+				User:                   models.User{Username: "bob"},
+			},
+			wantFailure: "This project is in manual trigger mode and can only be planned by its operators: alice, infra-admins.",
+			wantErr:     assert.NoError,
+		},
 		{
 			name: "fail by diverged",
 			ctx: command.ProjectContext{
@@ -416,3 +433,46 @@ func TestAggregateApplyRequirements_ValidateImportProject(t *testing.T) {
 		})
 	}
 }
+
+func TestRequirements_ValidateStateAndCustomProject(t *testing.T) {
+	repoDir := "repoDir"
+	operators := []string{"alice", "infra-admins"}
+	tests := []struct {
+		name              string
+		ctx               command.ProjectContext
+		wantStateFailure  string
+		wantCustomFailure string
+	}{
+		{
+			name: "pass without manual trigger mode",
+			ctx:  command.ProjectContext{User: models.User{Username: "bob"}},
+		},
+		{
+			name: "pass by manual trigger operator team",
+			ctx: command.ProjectContext{
+				ManualTriggerOperators: operators,
+				User:                   models.User{Username: "bob", Teams: []string{"infra-admins"}},
+			},
+		},
+		{
+			name: "fail by not manual trigger operator",
+			ctx: command.ProjectContext{
+				ManualTriggerOperators: operators,
+				User:                   models.User{Username: "bob"},
+			},
+			wantStateFailure:  "This project is in manual trigger mode and can only be used with state commands by its operators: alice, infra-admins.",
+			wantCustomFailure: "This project is in manual trigger mode and can only be used with custom commands by its operators: alice, infra-admins.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &events.DefaultCommandRequirementHandler{}
+			failure, err := a.ValidateStateProject(repoDir, tt.ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStateFailure, failure)
+			failure, err = a.ValidateCustomProject(repoDir, tt.ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCustomFailure, failure)
+		})
+	}
+}
@@ -15,7 +15,10 @@ package events
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v68/github"
 	"github.com/mcdafydd/go-azuredevops/azuredevops"
@@ -122,7 +125,17 @@ type DefaultCommandRunner struct {
 	// SilenceForkPRErrorsFlag is the name of the flag that controls fork PR's. We use
 	// this in our error message back to the user on a forked PR so they know
 	// how to disable error comment
-	SilenceForkPRErrorsFlag        string
+	SilenceForkPRErrorsFlag string
+	// User config option: requires a maintainer to approve pull requests from
+	// forks before any command is run on them.
+	ForkPRRequireApproval bool
+	// ForkPRApprovalLabel is the pull request label that approves a fork pull
+	// request. If empty, fork pull requests can only be approved with the
+	// ok-to-test command.
+	ForkPRApprovalLabel string
+	// ExecutableName is the name used to run Atlantis commands in comments. We
+	// use this in our message on unapproved fork PR's.
+	ExecutableName                 string
 	CommentCommandRunnerByCmd      map[command.Name]CommentCommandRunner
 	Drainer                        *Drainer
 	PreWorkflowHooksCommandRunner  PreWorkflowHooksCommandRunner
@@ -131,6 +144,20 @@ type DefaultCommandRunner struct {
 	TeamAllowlistChecker           command.TeamAllowlistChecker
 	VarFileAllowlistChecker        *VarFileAllowlistChecker
 	CommitStatusUpdater            CommitStatusUpdater
+	// UserMemberships looks up and caches the teams and organizations of
+	// users. If nil, teams are looked up with VCSClient every time.
+	UserMemberships *UserMemberships
+	// GitlabCIJobWaiter waits for the GitLab CI jobs that must succeed before
+	// plan and apply are run on merge requests. It's nil if GitLab isn't
+	// configured.
+	GitlabCIJobWaiter *GitlabCIJobWaiter
+	// StackedPullChecker detects pull requests stacked on another open pull
+	// request. If nil, pull requests aren't checked.
+	StackedPullChecker *StackedPullChecker
+	// CircuitBreaker stops running commands on pull requests whose commands
+	// keep failing with credential or provider errors. If nil, commands are always
+	// run.
+	CircuitBreaker *CircuitBreaker
 }
 
 // RunAutoplanCommand runs plan and policy_checks when a pull request is opened or updated.
@@ -198,6 +225,10 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 		}
 	}
 
+	if !c.checkCircuitBreaker(ctx, command.Plan) {
+		return
+	}
+
 	ctx.Log.Info("Running autoplan...")
 	cmd := &CommentCommand{
 		Name: command.Autoplan,
@@ -232,6 +263,14 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 		ctx.Log.Err("'fail-on-pre-workflow-hook-error' not set so running %s command.", command.Plan)
 	}
 
+	if !c.waitForGitlabCIJobs(ctx, command.Plan) {
+		return
+	}
+
+	if !c.checkStackedPull(ctx, command.Plan) {
+		return
+	}
+
 	autoPlanRunner := buildCommentCommandRunner(c, command.Plan)
 
 	autoPlanRunner.Run(ctx, nil)
@@ -239,10 +278,104 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 	c.PostWorkflowHooksCommandRunner.RunPostHooks(ctx, cmd) // nolint: errcheck
 }
 
+// waitForGitlabCIJobs waits for the GitLab CI jobs that must succeed before
+// the command named cmdName is run on the merge request. If they don't, it
+// comments why, fails the command's commit status and returns false.
+func (c *DefaultCommandRunner) waitForGitlabCIJobs(ctx *command.Context, cmdName command.Name) bool {
+	if c.GitlabCIJobWaiter == nil {
+		return true
+	}
+	err := c.GitlabCIJobWaiter.Wait(ctx, cmdName)
+	if err == nil {
+		return true
+	}
+	ctx.Log.Err("not running %s command: %s", cmdName, err)
+	errMsg := fmt.Sprintf("```\nError: not running %s: %s\n```", cmdName, err)
+	if commentErr := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, errMsg, cmdName.String()); commentErr != nil {
+		ctx.Log.Err("unable to comment on pull request: %s", commentErr)
+	}
+	if statusErr := c.CommitStatusUpdater.UpdateCombined(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, models.FailedCommitStatus, cmdName); statusErr != nil {
+		ctx.Log.Warn("unable to update %s commit status: %s", cmdName, statusErr)
+	}
+	return false
+}
+
+// checkCircuitBreaker returns false if the command named cmdName can't be
+// run since the pull request's circuit is open, after commenting why and failing the
+// command's commit status. Commands that don't use credentials are always run.
+func (c *DefaultCommandRunner) checkCircuitBreaker(ctx *command.Context, cmdName command.Name) bool {
+	if c.CircuitBreaker == nil {
+		return true
+	}
+	switch cmdName {
+	case command.Unlock, command.ApprovePolicies, command.Version:
+		return true
+	}
+	open, ok := c.CircuitBreaker.Check(ctx.Pull.BaseRepo, ctx.Pull.Num)
+	if !ok {
+		return true
+	}
+	ctx.Log.Info("not running %s command since the circuit of %s#%d is open until %s", cmdName, ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, open.Until.Format(time.RFC3339))
+	if commentErr := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, open.Comment(cmdName.String()), cmdName.String()); commentErr != nil {
+		ctx.Log.Err("unable to comment on pull request: %s", commentErr)
+	}
+	if cmdName == command.Plan || cmdName == command.Apply {
+		if statusErr := c.CommitStatusUpdater.UpdateCombined(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, models.FailedCommitStatus, cmdName); statusErr != nil {
+			ctx.Log.Warn("unable to update %s commit status: %s", cmdName, statusErr)
+		}
+	}
+	return false
+}
+
+// checkStackedPull warns on plans and applies of pull requests that are
+// stacked on another open pull request, hiding the previous warning so that
+// only the latest one is shown. If the repo blocks applies of stacked pull
+// requests, it comments why, fails the apply's commit status and returns
+// false.
+func (c *DefaultCommandRunner) checkStackedPull(ctx *command.Context, cmdName command.Name) bool {
+	if c.StackedPullChecker == nil || (cmdName != command.Plan && cmdName != command.Apply) {
+		return true
+	}
+	stackedPull, err := c.StackedPullChecker.Check(ctx)
+	var comment string
+	var blocked bool
+	switch {
+	case err != nil:
+		ctx.Log.Warn("unable to check if the pull request is stacked: %s", err)
+		// Plans are still run but applies that might be blocked aren't.
+		if stackedPull.Mode != valid.StackedPullsBlockMode || cmdName != command.Apply {
+			return true
+		}
+		comment = fmt.Sprintf("```\nError: not running apply: %s\n```", err)
+		blocked = true
+	case stackedPull.ParentNum == 0:
+		return true
+	default:
+		comment = stackedPull.Comment(cmdName, ctx.Pull.BaseBranch)
+		blocked = stackedPull.Blocks(cmdName)
+		if !blocked {
+			if hideErr := c.VCSClient.HidePrevCommandComments(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, stackedPullCommentMarker, ""); hideErr != nil {
+				ctx.Log.Warn("unable to hide the previous stacked pull request warnings: %s", hideErr)
+			}
+		}
+	}
+	if commentErr := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, comment, cmdName.String()); commentErr != nil {
+		ctx.Log.Err("unable to comment on pull request: %s", commentErr)
+	}
+	if !blocked {
+		return true
+	}
+	ctx.Log.Info("not running %s command since the pull request is stacked", cmdName)
+	if statusErr := c.CommitStatusUpdater.UpdateCombined(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, models.FailedCommitStatus, cmdName); statusErr != nil {
+		ctx.Log.Warn("unable to update %s commit status: %s", cmdName, statusErr)
+	}
+	return false
+}
+
 // commentUserDoesNotHavePermissions comments on the pull request that the user
 // is not allowed to execute the command.
 func (c *DefaultCommandRunner) commentUserDoesNotHavePermissions(baseRepo models.Repo, pullNum int, user models.User, cmd *CommentCommand) {
-	errMsg := fmt.Sprintf("```\nError: User @%s does not have permissions to execute '%s' command.\n```", user.Username, cmd.Name.String())
+	errMsg := fmt.Sprintf("```\nError: User @%s does not have permissions to execute '%s' command.\n```", user.Username, cmd.DisplayName())
 	if err := c.VCSClient.CreateComment(c.Logger, baseRepo, pullNum, errMsg, ""); err != nil {
 		c.Logger.Err("unable to comment on pull request: %s", err)
 	}
@@ -296,10 +429,17 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 	log := c.buildLogger(baseRepo.FullName, pullNum)
 	defer c.logPanics(baseRepo, pullNum, log)
 
+	// Repos in shadow mode only run plans since anything else, ex. an apply,
+	// would change them without anyone seeing it.
+	if cmd != nil && cmd.Name != command.Plan && c.GlobalCfg.ShadowMode(baseRepo.ID()) {
+		log.Info("ignoring %s command since %s is in shadow mode", cmd.DisplayName(), baseRepo.FullName)
+		return
+	}
+
 	scope := c.StatsScope.SubScope("comment")
 
 	if cmd != nil {
-		scope = scope.SubScope(cmd.Name.String())
+		scope = scope.SubScope(cmd.DisplayName())
 	}
 	timer := scope.Timer(metrics.ExecutionTimeMetric).Start()
 	defer timer.Stop()
@@ -312,7 +452,7 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 			return
 		}
 
-		ok, err := c.checkUserPermissions(baseRepo, user, cmd.Name.String())
+		ok, err := c.checkUserPermissions(baseRepo, user, cmd.DisplayName())
 		if err != nil {
 			c.Logger.Err("Unable to check user permissions: %s", err)
 			return
@@ -321,6 +461,11 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 			c.commentUserDoesNotHavePermissions(baseRepo, pullNum, user, cmd)
 			return
 		}
+	} else if c.GlobalCfg.HasManualTrigger(baseRepo.ID()) {
+		// Operators of projects in manual trigger mode can be teams.
+		if err := c.fetchUserTeams(log, baseRepo, &user); err != nil {
+			log.Warn("unable to fetch user teams, only usernames will match manual trigger operators: %s", err)
+		}
 	}
 
 	// Check if the provided var files in a 'plan' command are allowlisted
@@ -353,6 +498,7 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		Trigger:              command.CommentTrigger,
 		PolicySet:            cmd.PolicySet,
 		ClearPolicyApproval:  cmd.ClearPolicyApproval,
+		ForcePlan:            cmd.Force,
 		TeamAllowlistChecker: c.TeamAllowlistChecker,
 	}
 
@@ -360,6 +506,17 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		return
 	}
 
+	// Approving a fork pull request doesn't touch the repo so we don't run the
+	// workflow hooks, which could run code from the fork.
+	if cmd.Name == command.OkToTest {
+		buildCommentCommandRunner(c, cmd.CommandName()).Run(ctx, cmd)
+		return
+	}
+
+	if !c.checkCircuitBreaker(ctx, cmd.Name) {
+		return
+	}
+
 	// Update the combined plan or apply commit status to pending
 	switch cmd.Name {
 	case command.Plan:
@@ -376,7 +533,7 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 
 	if err != nil {
 		if c.FailOnPreWorkflowHookError {
-			ctx.Log.Err("'fail-on-pre-workflow-hook-error' set, so not running %s command.", cmd.Name.String())
+			ctx.Log.Err("'fail-on-pre-workflow-hook-error' set, so not running %s command.", cmd.DisplayName())
 
 			// Update the plan or apply commit status to failed
 			switch cmd.Name {
@@ -393,7 +550,15 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 			return
 		}
 
-		ctx.Log.Err("'fail-on-pre-workflow-hook-error' not set so running %s command.", cmd.Name.String())
+		ctx.Log.Err("'fail-on-pre-workflow-hook-error' not set so running %s command.", cmd.DisplayName())
+	}
+
+	if !c.waitForGitlabCIJobs(ctx, cmd.Name) {
+		return
+	}
+
+	if !c.checkStackedPull(ctx, cmd.Name) {
+		return
 	}
 
 	cmdRunner := buildCommentCommandRunner(c, cmd.CommandName())
@@ -485,7 +650,7 @@ func (c *DefaultCommandRunner) ensureValidRepoMetadata(
 		pull, headRepo, err = c.getGithubData(log, baseRepo, pullNum)
 	case models.Gitlab:
 		pull, err = c.getGitlabData(log, baseRepo, pullNum)
-	case models.BitbucketCloud, models.BitbucketServer:
+	case models.BitbucketCloud, models.BitbucketServer, models.VCSAdapter:
 		if maybePull == nil {
 			err = errors.New("pull request should not be nil–this is a bug")
 			break
@@ -509,11 +674,34 @@ func (c *DefaultCommandRunner) ensureValidRepoMetadata(
 	return
 }
 
+// fetchUserTeams sets the teams of user. They include org:<org> for the
+// organizations of the team allowlist that user is a member of.
 func (c *DefaultCommandRunner) fetchUserTeams(logger logging.SimpleLogging, repo models.Repo, user *models.User) error {
-	teams, err := c.VCSClient.GetTeamNamesForUser(logger, repo, *user)
+	memberships := c.UserMemberships
+	if memberships == nil {
+		memberships = &UserMemberships{TeamNamesGetter: c.VCSClient}
+	}
+	teams, err := memberships.UserTeams(logger, repo, *user)
 	if err != nil {
 		return err
 	}
+	teams = slices.Clone(teams)
+
+	if c.TeamAllowlistChecker != nil {
+		for _, team := range c.TeamAllowlistChecker.AllTeams() {
+			org, ok := strings.CutPrefix(team, command.OrgTeamPrefix)
+			if !ok || slices.Contains(teams, team) {
+				continue
+			}
+			isMember, err := memberships.IsOrgMember(logger, repo, *user, org)
+			if err != nil {
+				return err
+			}
+			if isMember {
+				teams = append(teams, team)
+			}
+		}
+	}
 
 	user.Teams = teams
 	return nil
@@ -531,6 +719,18 @@ func (c *DefaultCommandRunner) validateCtxAndComment(ctx *command.Context, comma
 		return false
 	}
 
+	if c.ForkPRRequireApproval && ctx.HeadRepo.Owner != ctx.Pull.BaseRepo.Owner &&
+		commandName != command.OkToTest && commandName != command.Unlock && !c.isForkPRApproved(ctx) {
+		ctx.Log.Info("command was run on a fork pull request which hasn't been approved")
+		if c.SilenceForkPRErrors {
+			return false
+		}
+		if err := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, c.forkPRApprovalComment(), ""); err != nil {
+			ctx.Log.Err("unable to comment: %s", err)
+		}
+		return false
+	}
+
 	if ctx.Pull.State != models.OpenPullState && commandName != command.Unlock {
 		ctx.Log.Info("command was run on closed pull request")
 		if err := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, "Atlantis commands can't be run on closed pull requests", ""); err != nil {
@@ -548,6 +748,23 @@ func (c *DefaultCommandRunner) validateCtxAndComment(ctx *command.Context, comma
 	return true
 }
 
+// isForkPRApproved returns true if a maintainer approved the current commit of
+// the fork pull request, with the ok-to-test command or by adding the approval
+// label. Approvals don't carry over to new commits.
+func (c *DefaultCommandRunner) isForkPRApproved(ctx *command.Context) bool {
+	return ctx.PullStatus != nil && ctx.PullStatus.IsForkApproved(ctx.Pull.HeadCommit)
+}
+
+// forkPRApprovalComment returns the comment explaining how to approve a fork
+// pull request.
+func (c *DefaultCommandRunner) forkPRApprovalComment() string {
+	howTo := fmt.Sprintf("comment `%s %s`", c.ExecutableName, command.OkToTest)
+	if c.ForkPRApprovalLabel != "" {
+		howTo = fmt.Sprintf("add the `%s` label, removing it first if it's already there, or %s", c.ForkPRApprovalLabel, howTo)
+	}
+	return fmt.Sprintf("Atlantis commands can't be run on this fork pull request until it's approved. To approve, a maintainer must %s.", howTo)
+}
+
 // logPanics logs and creates a comment on the pull request for panics.
 func (c *DefaultCommandRunner) logPanics(baseRepo models.Repo, pullNum int, logger logging.SimpleLogging) {
 	if err := recover(); err != nil {
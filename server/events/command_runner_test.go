@@ -34,6 +34,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/mocks"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -65,6 +66,7 @@ var applyLockChecker *lockingmocks.MockApplyLockChecker
 var lockingLocker *lockingmocks.MockLocker
 var applyCommandRunner *events.ApplyCommandRunner
 var unlockCommandRunner *events.UnlockCommandRunner
+var okToTestCommandRunner *events.OkToTestCommandRunner
 var importCommandRunner *events.ImportCommandRunner
 var preWorkflowHooksCommandRunner events.PreWorkflowHooksCommandRunner
 var postWorkflowHooksCommandRunner events.PostWorkflowHooksCommandRunner
@@ -142,7 +144,7 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		pullUpdater,
 		commitUpdater,
 		projectCommandRunner,
-		testConfig.parallelPoolSize,
+		events.NewParallelPoolSize(testConfig.parallelPoolSize),
 		testConfig.silenceVCSStatusNoProjects,
 		false,
 	)
@@ -160,7 +162,7 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		pullUpdater,
 		policyCheckCommandRunner,
 		autoMerger,
-		testConfig.parallelPoolSize,
+		events.NewParallelPoolSize(testConfig.parallelPoolSize),
 		testConfig.SilenceNoProjects,
 		testConfig.backend,
 		lockingLocker,
@@ -179,7 +181,7 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		pullUpdater,
 		dbUpdater,
 		testConfig.backend,
-		testConfig.parallelPoolSize,
+		events.NewParallelPoolSize(testConfig.parallelPoolSize),
 		testConfig.SilenceNoProjects,
 		testConfig.silenceVCSStatusNoProjects,
 		pullReqStatusFetcher,
@@ -207,7 +209,7 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		pullUpdater,
 		projectCommandBuilder,
 		projectCommandRunner,
-		testConfig.parallelPoolSize,
+		events.NewParallelPoolSize(testConfig.parallelPoolSize),
 		testConfig.SilenceNoProjects,
 	)
 
@@ -219,6 +221,12 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		testConfig.SilenceNoProjects,
 	)
 
+	okToTestCommandRunner = events.NewOkToTestCommandRunner(
+		vcsClient,
+		testConfig.backend,
+		nil,
+	)
+
 	commentCommandRunnerByCmd := map[command.Name]events.CommentCommandRunner{
 		command.Plan:            planCommandRunner,
 		command.Apply:           applyCommandRunner,
@@ -226,6 +234,7 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		command.Unlock:          unlockCommandRunner,
 		command.Version:         versionCommandRunner,
 		command.Import:          importCommandRunner,
+		command.OkToTest:        okToTestCommandRunner,
 	}
 
 	preWorkflowHooksCommandRunner = mocks.NewMockPreWorkflowHooksCommandRunner()
@@ -339,6 +348,36 @@ func TestRunCommentCommand_TeamAllowListChecker(t *testing.T) {
 		vcsClient.VerifyWasCalledOnce().CreateComment(
 			Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq("Ran Plan for 0 projects:"), Eq("plan"))
 	})
+
+	t.Run("organization rules", func(t *testing.T) {
+		vcsClient := setup(t)
+		checker, err := command.NewTeamAllowlistChecker("org:runatlantis:plan")
+		Ok(t, err)
+		ch.TeamAllowlistChecker = checker
+		membership := &fakeMembership{orgs: []string{"runatlantis"}}
+		ch.UserMemberships = &events.UserMemberships{
+			TeamNamesGetter:       membership,
+			OrgMembershipCheckers: map[models.VCSHostType]vcs.OrgMembershipChecker{models.Github: membership},
+		}
+		defer func() { ch.UserMemberships = nil }()
+		var pull github.PullRequest
+		modelPull := models.PullRequest{
+			BaseRepo: testdata.GithubRepo,
+			State:    models.OpenPullState,
+		}
+		When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num))).ThenReturn(&pull, nil)
+		When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(&pull))).ThenReturn(modelPull, modelPull.BaseRepo, testdata.GithubRepo, nil)
+
+		ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Plan})
+		vcsClient.VerifyWasCalledOnce().CreateComment(
+			Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq("Ran Plan for 0 projects:"), Eq("plan"))
+
+		ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Apply})
+		vcsClient.VerifyWasCalledOnce().CreateComment(
+			Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num), Eq("```\nError: User @lkysow does not have permissions to execute 'apply' command.\n```"), Eq(""))
+		Equals(t, 1, membership.teamLookups)
+		Equals(t, 1, membership.orgLookups)
+	})
 }
 
 func TestRunCommentCommand_ForkPRDisabled(t *testing.T) {
@@ -385,6 +424,153 @@ func TestRunCommentCommand_ForkPRDisabled_SilenceEnabled(t *testing.T) {
 		Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
 }
 
+func TestRunCommentCommand_ForkPRNotApproved(t *testing.T) {
+	t.Log("if a command is run on a forked pull request that hasn't been approved atlantis should" +
+		" comment saying how to approve it")
+	vcsClient := setup(t)
+	ch.AllowForkPRs = true
+	ch.ForkPRRequireApproval = true
+	ch.ForkPRApprovalLabel = "ok-to-test"
+	ch.ExecutableName = "atlantis"
+	var pull github.PullRequest
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, HeadCommit: "abc123"}
+	When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num))).ThenReturn(&pull, nil)
+
+	headRepo := testdata.GithubRepo
+	headRepo.FullName = "forkrepo/atlantis"
+	headRepo.Owner = "forkrepo"
+	When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(&pull))).ThenReturn(modelPull, modelPull.BaseRepo, headRepo, nil)
+
+	ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Plan})
+	commentMessage := "Atlantis commands can't be run on this fork pull request until it's approved. To approve, a maintainer must add the `ok-to-test` label, removing it first if it's already there, or comment `atlantis ok-to-test`."
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq(commentMessage), Eq(""))
+	projectCommandBuilder.VerifyWasCalled(Never()).BuildPlanCommands(Any[*command.Context](), Any[*events.CommentCommand]())
+}
+
+func TestRunCommentCommand_ForkPRApprovedByLabel(t *testing.T) {
+	t.Log("if the approval label is added to a forked pull request atlantis should run commands" +
+		" on the labeled commit but not on later ones, even though the label is still there")
+	vcsClient := setup(t)
+	ch.AllowForkPRs = true
+	ch.ForkPRRequireApproval = true
+	ch.ForkPRApprovalLabel = "ok-to-test"
+	ch.ExecutableName = "atlantis"
+	var pull github.PullRequest
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num, HeadCommit: "abc123"}
+	When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num))).ThenReturn(&pull, nil)
+
+	headRepo := testdata.GithubRepo
+	headRepo.FullName = "forkrepo/atlantis"
+	headRepo.Owner = "forkrepo"
+	When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(&pull))).ThenReturn(modelPull, modelPull.BaseRepo, headRepo, nil)
+	When(vcsClient.GetPullLabels(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest]())).ThenReturn([]string{"ok-to-test"}, nil)
+
+	ch.RunCommentCommand(testdata.GithubRepo, &headRepo, &modelPull, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.OkToTest, ApprovedCommit: "abc123"})
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num),
+		Eq("Atlantis commands can now be run on commit abc123. New commits will need to be approved again."), Eq("ok-to-test"))
+
+	ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Plan})
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq("Ran Plan for 0 projects:"), Eq("plan"))
+
+	newPull := modelPull
+	newPull.HeadCommit = "def456"
+	When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(&pull))).ThenReturn(newPull, newPull.BaseRepo, headRepo, nil)
+	ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Plan})
+	commentMessage := "Atlantis commands can't be run on this fork pull request until it's approved. To approve, a maintainer must add the `ok-to-test` label, removing it first if it's already there, or comment `atlantis ok-to-test`."
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq(commentMessage), Eq(""))
+}
+
+func TestRunCommentCommand_ForkPRApprovalLabelStale(t *testing.T) {
+	t.Log("if new commits were pushed to a forked pull request after the approval label was added" +
+		" atlantis should not approve them")
+	vcsClient := setup(t)
+	ch.AllowForkPRs = true
+	ch.ForkPRRequireApproval = true
+	ch.ForkPRApprovalLabel = "ok-to-test"
+	var pull github.PullRequest
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num, HeadCommit: "def456"}
+	When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num))).ThenReturn(&pull, nil)
+
+	headRepo := testdata.GithubRepo
+	headRepo.FullName = "forkrepo/atlantis"
+	headRepo.Owner = "forkrepo"
+	When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(&pull))).ThenReturn(modelPull, modelPull.BaseRepo, headRepo, nil)
+
+	ch.RunCommentCommand(testdata.GithubRepo, &headRepo, &modelPull, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.OkToTest, ApprovedCommit: "abc123"})
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num),
+		Eq("New commits were pushed after this pull request was approved at commit abc123, so it needs to be approved again"), Eq("ok-to-test"))
+
+	status, err := ch.PullStatusFetcher.GetPullStatus(modelPull)
+	Ok(t, err)
+	Assert(t, status == nil || !status.IsForkApproved(modelPull.HeadCommit), "expected pull request not to be approved")
+}
+
+func TestRunCommentCommand_ForkPRApprovedByOkToTest(t *testing.T) {
+	t.Log("if a maintainer comments ok-to-test on a forked pull request atlantis should run commands" +
+		" on that commit but not on later ones")
+	vcsClient := setup(t)
+	ch.AllowForkPRs = true
+	ch.ForkPRRequireApproval = true
+	ch.ExecutableName = "atlantis"
+	okToTestCommandRunner.Approvers = &events.UserAllowlist{Users: []string{testdata.User.Username}}
+	var pull github.PullRequest
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num, HeadCommit: "abc123"}
+	When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num))).ThenReturn(&pull, nil)
+
+	headRepo := testdata.GithubRepo
+	headRepo.FullName = "forkrepo/atlantis"
+	headRepo.Owner = "forkrepo"
+	When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(&pull))).ThenReturn(modelPull, modelPull.BaseRepo, headRepo, nil)
+
+	ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.OkToTest})
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num),
+		Eq("Atlantis commands can now be run on commit abc123. New commits will need to be approved again."), Eq("ok-to-test"))
+	preWorkflowHooksCommandRunner.(*mocks.MockPreWorkflowHooksCommandRunner).VerifyWasCalled(Never()).RunPreHooks(Any[*command.Context](), Any[*events.CommentCommand]())
+
+	ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Plan})
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq("Ran Plan for 0 projects:"), Eq("plan"))
+
+	newPull := modelPull
+	newPull.HeadCommit = "def456"
+	When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(&pull))).ThenReturn(newPull, newPull.BaseRepo, headRepo, nil)
+	ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Plan})
+	commentMessage := "Atlantis commands can't be run on this fork pull request until it's approved. To approve, a maintainer must comment `atlantis ok-to-test`."
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq(commentMessage), Eq(""))
+}
+
+func TestRunCommentCommand_ForkPROkToTestNotApprover(t *testing.T) {
+	t.Log("if a user that isn't an approver comments ok-to-test atlantis should not approve the pull request")
+	vcsClient := setup(t)
+	ch.AllowForkPRs = true
+	ch.ForkPRRequireApproval = true
+	okToTestCommandRunner.Approvers = &events.UserAllowlist{Users: []string{"maintainer"}}
+	var pull github.PullRequest
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num, HeadCommit: "abc123"}
+	When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num))).ThenReturn(&pull, nil)
+
+	headRepo := testdata.GithubRepo
+	headRepo.FullName = "forkrepo/atlantis"
+	headRepo.Owner = "forkrepo"
+	When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(&pull))).ThenReturn(modelPull, modelPull.BaseRepo, headRepo, nil)
+
+	ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.OkToTest})
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num),
+		Eq(fmt.Sprintf("User @%s is not allowed to approve pull requests from forks", testdata.User.Username)), Eq("ok-to-test"))
+
+	status, err := ch.PullStatusFetcher.GetPullStatus(modelPull)
+	Ok(t, err)
+	Assert(t, status == nil || !status.IsForkApproved(modelPull.HeadCommit), "expected pull request not to be approved")
+}
+
 func TestRunCommentCommandPlan_NoProjects_SilenceEnabled(t *testing.T) {
 	t.Log("if a plan command is run on a pull request and SilenceNoProjects is enabled and we are silencing all comments if the modified files don't have a matching project")
 	vcsClient := setup(t)
@@ -630,6 +816,21 @@ func TestRunCommentCommand_UnmatchedBranch(t *testing.T) {
 	vcsClient.VerifyWasCalled(Never()).CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
 }
 
+func TestRunCommentCommand_ShadowModeIgnoresApply(t *testing.T) {
+	t.Log("if a command other than plan is run on a repo in shadow mode it's ignored")
+	vcsClient := setup(t)
+
+	shadowMode := true
+	ch.GlobalCfg.Repos = append(ch.GlobalCfg.Repos, valid.Repo{
+		IDRegex:    regexp.MustCompile(".*"),
+		ShadowMode: &shadowMode,
+	})
+
+	ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Apply})
+	githubGetter.VerifyWasCalled(Never()).GetPullRequest(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int]())
+	vcsClient.VerifyWasCalled(Never()).CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+}
+
 func TestRunUnlockCommand_VCSComment(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -696,6 +897,59 @@ func TestRunUnlockCommandFail_VCSComment(t *testing.T) {
 		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num), Eq("Failed to delete PR locks"), Eq("unlock"))
 }
 
+func TestRunUnlockCommand_Project(t *testing.T) {
+	t.Log("if unlock is run for a specific project, atlantis should only delete" +
+		" the locks of that project and list them in the PR comment")
+
+	vcsClient := setup(t)
+	pull := &github.PullRequest{
+		State: github.Ptr("open"),
+	}
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+	When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo),
+		Eq(testdata.Pull.Num))).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(pull))).ThenReturn(modelPull, modelPull.BaseRepo,
+		testdata.GithubRepo, nil)
+	When(deleteLockCommand.DeleteLocksByProject(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo.FullName),
+		Eq(testdata.Pull.Num), Eq(""), Eq("staging"), Eq("vpc"))).ThenReturn([]models.ProjectLock{
+		{
+			Pull:      modelPull,
+			Workspace: "staging",
+			Project:   models.Project{Path: "vpc", RepoFullName: testdata.GithubRepo.FullName, ProjectName: "vpc"},
+		},
+	}, nil)
+
+	ch.RunCommentCommand(testdata.GithubRepo, &testdata.GithubRepo, nil, testdata.User, testdata.Pull.Num,
+		&events.CommentCommand{Name: command.Unlock, ProjectName: "vpc", Workspace: "staging"})
+
+	deleteLockCommand.VerifyWasCalled(Never()).DeleteLocksByPull(Any[logging.SimpleLogging](), Any[string](), Any[int]())
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num),
+		Eq("The Atlantis locks of the following projects have been unlocked and their plans discarded:\n\n"+
+			"* project: `vpc` dir: `vpc` workspace: `staging`"), Eq("unlock"))
+}
+
+func TestRunUnlockCommand_ProjectNoLocks(t *testing.T) {
+	t.Log("if unlock is run for a project without locks, atlantis should say so")
+
+	vcsClient := setup(t)
+	pull := &github.PullRequest{
+		State: github.Ptr("open"),
+	}
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+	When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo),
+		Eq(testdata.Pull.Num))).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(pull))).ThenReturn(modelPull, modelPull.BaseRepo,
+		testdata.GithubRepo, nil)
+
+	ch.RunCommentCommand(testdata.GithubRepo, &testdata.GithubRepo, nil, testdata.User, testdata.Pull.Num,
+		&events.CommentCommand{Name: command.Unlock, RepoRelDir: "network"})
+
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num),
+		Eq("No Atlantis locks for this PR match dir: `network`"), Eq("unlock"))
+}
+
 func TestRunUnlockCommandFail_DisableUnlockLabel(t *testing.T) {
 	t.Log("if PR has label equal to disable-unlock-label unlock should fail")
 
@@ -802,6 +1056,38 @@ func TestRunAutoplanCommand_DeletePlans(t *testing.T) {
 	lockingLocker.VerifyWasCalledOnce().UnlockByPull(testdata.Pull.BaseRepo.FullName, testdata.Pull.Num)
 }
 
+func TestRunAutoplanCommand_StackedPullWarning(t *testing.T) {
+	t.Log("the warning of a stacked pull request replaces the previous one")
+	vcsClient := setup(t)
+	tmp := t.TempDir()
+	boltDB, err := db.New(tmp)
+	t.Cleanup(func() {
+		boltDB.Close()
+	})
+	Ok(t, err)
+	dbUpdater.Backend = boltDB
+	applyCommandRunner.Backend = boltDB
+	warn := valid.StackedPullsWarnMode
+	globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	globalCfg.Repos = append(globalCfg.Repos, valid.Repo{ID: testdata.GithubRepo.ID(), StackedPulls: &warn})
+	ch.StackedPullChecker = &events.StackedPullChecker{
+		GlobalCfg:         globalCfg,
+		ParentPullFinders: map[models.VCSHostType]vcs.ParentPullFinder{models.Github: &fakeParentPullFinder{parents: map[string]int{"feature-a": 2}}},
+	}
+	defer func() { ch.StackedPullChecker = nil }()
+
+	When(projectCommandBuilder.BuildAutoplanCommands(Any[*command.Context]())).ThenReturn([]command.ProjectContext{}, nil)
+	When(workingDir.GetPullDir(Any[models.Repo](), Any[models.PullRequest]())).ThenReturn(tmp, nil)
+	pull := testdata.Pull
+	pull.BaseRepo = testdata.GithubRepo
+	pull.BaseBranch = "feature-a"
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, pull, testdata.User)
+
+	warning := events.StackedPull{ParentNum: 2, Mode: warn}.Comment(command.Plan, "feature-a")
+	vcsClient.VerifyWasCalledOnce().HidePrevCommandComments(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(pull.Num), Eq("<!-- atlantis:stacked-pull -->"), Eq(""))
+	vcsClient.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(pull.Num), Eq(warning), Eq("plan"))
+}
+
 func TestRunAutoplanCommand_FailedPreWorkflowHook_FailOnPreWorkflowHookError_False(t *testing.T) {
 	setup(t)
 	tmp := t.TempDir()
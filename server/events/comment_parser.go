@@ -24,6 +24,7 @@ import (
 	"text/template"
 
 	"github.com/google/shlex"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/utils"
@@ -45,8 +46,16 @@ const (
 	autoMergeMethodFlagShort     = ""
 	verboseFlagLong              = "verbose"
 	verboseFlagShort             = ""
+	quietFlagLong                = "quiet"
+	quietFlagShort               = ""
 	clearPolicyApprovalFlagLong  = "clear-policy-approval"
 	clearPolicyApprovalFlagShort = ""
+	forceFlagLong                = "force"
+	forceFlagShort               = ""
+	failedFlagLong               = "failed"
+	failedFlagShort              = ""
+	confirmFlagLong              = "confirm"
+	confirmFlagShort             = ""
 )
 
 // multiLineRegex is used to ignore multi-line comments since those aren't valid
@@ -75,6 +84,11 @@ type CommentBuilder interface {
 	BuildApplyComment(repoRelDir string, workspace string, project string, autoMergeDisabled bool, autoMergeMethod string) string
 	// BuildApprovePoliciesComment builds an approve_policies comment for the specified args.
 	BuildApprovePoliciesComment(repoRelDir string, workspace string, project string) string
+	// BuildUnlockComment builds an unlock comment for the specified args.
+	BuildUnlockComment(repoRelDir string, workspace string, project string) string
+	// BuildDestroyComment builds a destroy comment for the specified args,
+	// which applies the destroy plan if confirm is true.
+	BuildDestroyComment(repoRelDir string, workspace string, project string, confirm bool) string
 }
 
 // CommentParser implements CommentParsing
@@ -84,8 +98,13 @@ type CommentParser struct {
 	GiteaUser       string
 	BitbucketUser   string
 	AzureDevopsUser string
-	ExecutableName  string
-	AllowCommands   []command.Name
+	// VCSAdapterUser is the user of the VCS adapter, if one is configured.
+	VCSAdapterUser string
+	ExecutableName string
+	AllowCommands  []command.Name
+	// CustomCommands are the commands defined in the server-side repo config.
+	// They can be run in addition to AllowCommands.
+	CustomCommands []valid.CustomCommand
 }
 
 // NewCommentParser returns a CommentParser
@@ -121,6 +140,10 @@ type CommentParseResult struct {
 	CommentResponse string
 	// Ignore is set to true when we should just ignore this comment.
 	Ignore bool
+	// Unrecognized is set to true with CommentResponse when the comment is
+	// addressed to Atlantis but isn't a command it knows, ex. a misspelled
+	// command.
+	Unrecognized bool
 }
 
 // Parse parses the comment as an Atlantis command.
@@ -129,7 +152,7 @@ type CommentParseResult struct {
 //   - The initial "executable" name, 'run' or 'atlantis' or '@GithubUser'
 //     where GithubUser is the API user Atlantis is running as.
 //   - Then a command: 'plan', 'apply', 'unlock', 'version, 'approve_policies',
-//     or 'help'.
+//     'help' or a custom command defined in the server-side repo config.
 //   - Then optional flags, then an optional separator '--' followed by optional
 //     extra flags to be appended to the terraform plan/apply command.
 //
@@ -139,6 +162,8 @@ type CommentParseResult struct {
 // - @GithubUser plan -w staging
 // - atlantis plan -w staging -d dir --verbose
 // - atlantis plan --verbose -- -key=value -key2 value2
+// - atlantis plan -d dir --force
+// - atlantis plan --quiet
 // - atlantis unlock
 // - atlantis version
 // - atlantis approve_policies
@@ -163,12 +188,12 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 
 	// Helpfully warn the user if they're using "terraform" instead of "atlantis"
 	if executableName == "terraform" && e.ExecutableName != "terraform" {
-		return CommentParseResult{CommentResponse: fmt.Sprintf(DidYouMeanAtlantisComment, e.ExecutableName, "terraform")}
+		return CommentParseResult{CommentResponse: fmt.Sprintf(DidYouMeanAtlantisComment, e.ExecutableName, "terraform"), Unrecognized: true}
 	}
 
 	// Helpfully warn the user that the command might be misspelled
 	if utils.IsSimilarWord(executableName, e.ExecutableName) {
-		return CommentParseResult{CommentResponse: fmt.Sprintf(DidYouMeanAtlantisComment, e.ExecutableName, args[0])}
+		return CommentParseResult{CommentResponse: fmt.Sprintf(DidYouMeanAtlantisComment, e.ExecutableName, args[0]), Unrecognized: true}
 	}
 
 	// Atlantis can be invoked using the name of the VCS host user we're
@@ -185,6 +210,8 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		vcsUser = e.BitbucketUser
 	case models.AzureDevops:
 		vcsUser = e.AzureDevopsUser
+	case models.VCSAdapter:
+		vcsUser = e.VCSAdapterUser
 	}
 	executableNames := []string{"run", e.ExecutableName, "@" + vcsUser}
 	if !e.stringInSlice(executableName, executableNames) {
@@ -195,7 +222,7 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	// parser.
 	args, err := shlex.Split(comment)
 	if err != nil {
-		return CommentParseResult{CommentResponse: fmt.Sprintf("```\nError parsing command: %s\n```", err)}
+		return CommentParseResult{CommentResponse: fmt.Sprintf("```\nError parsing command: %s\n```", err), Unrecognized: true}
 	}
 	if len(args) < 1 {
 		return CommentParseResult{Ignore: true}
@@ -216,12 +243,19 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	}
 
 	// Need to have allow commands at this point.
-	if !e.isAllowedCommand(cmd) {
+	_, isCustom := e.customCommand(cmd)
+	if !isCustom && !e.isAllowedCommand(cmd) {
 		var allowCommandList []string
 		for _, allowCommand := range e.AllowCommands {
 			allowCommandList = append(allowCommandList, allowCommand.String())
 		}
-		return CommentParseResult{CommentResponse: fmt.Sprintf("```\nError: unknown command %q.\nRun '%s --help' for usage.\nAvailable commands(--allow-commands): %s\n```", cmd, e.ExecutableName, strings.Join(allowCommandList, ", "))}
+		for _, customCommand := range e.CustomCommands {
+			allowCommandList = append(allowCommandList, customCommand.Name)
+		}
+		return CommentParseResult{
+			CommentResponse: fmt.Sprintf("```\nError: unknown command %q.\nRun '%s --help' for usage.\nAvailable commands(--allow-commands): %s\n```", cmd, e.ExecutableName, strings.Join(allowCommandList, ", ")),
+			Unrecognized:    true,
+		}
 	}
 
 	var workspace string
@@ -230,6 +264,10 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	var policySet string
 	var clearPolicyApproval bool
 	var verbose bool
+	var quiet bool
+	var force bool
+	var failed bool
+	var confirm bool
 	var autoMergeDisabled bool
 	var autoMergeMethod string
 	var flagSet *pflag.FlagSet
@@ -244,7 +282,10 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Switch to this Terraform workspace before planning.")
 		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Which directory to run plan in relative to root of repo, ex. 'child/dir'.")
 		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Which project to run plan for. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.")
-		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log, refresh output and how long each project took to comment.")
+		flagSet.BoolVarP(&quiet, quietFlagLong, quietFlagShort, false, "Only comment the summary of each project, with a link to its full output. Cannot be used at same time as verbose flag.")
+		flagSet.BoolVarP(&force, forceFlagLong, forceFlagShort, false, "Run plan even if the plan from the last run can be reused.")
+		flagSet.BoolVarP(&failed, failedFlagLong, failedFlagShort, false, "Only plan the projects whose plan failed in the last run. Cannot be used at same time as project, workspace or dir flags.")
 	case command.Apply.String():
 		name = command.Apply
 		flagSet = pflag.NewFlagSet(command.Apply.String(), pflag.ContinueOnError)
@@ -254,7 +295,9 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Apply the plan for this project. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.")
 		flagSet.BoolVarP(&autoMergeDisabled, autoMergeDisabledFlagLong, autoMergeDisabledFlagShort, false, "Disable automerge after apply.")
 		flagSet.StringVarP(&autoMergeMethod, autoMergeMethodFlagLong, autoMergeMethodFlagShort, "", "Specifies the merge method for the VCS if automerge is enabled. (Currently only implemented for GitHub)")
-		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+		flagSet.BoolVarP(&failed, failedFlagLong, failedFlagShort, false, "Only apply the projects whose apply failed in the last run. Cannot be used at same time as project, workspace or dir flags.")
+		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log and how long each project took to comment.")
+		flagSet.BoolVarP(&quiet, quietFlagLong, quietFlagShort, false, "Only comment the summary of each project, with a link to its full output. Cannot be used at same time as verbose flag.")
 	case command.ApprovePolicies.String():
 		name = command.ApprovePolicies
 		flagSet = pflag.NewFlagSet(command.ApprovePolicies.String(), pflag.ContinueOnError)
@@ -269,6 +312,13 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		name = command.Unlock
 		flagSet = pflag.NewFlagSet(command.Unlock.String(), pflag.ContinueOnError)
 		flagSet.SetOutput(io.Discard)
+		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Only unlock the projects in this Terraform workspace.")
+		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Only unlock the projects in this directory, relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Only unlock this project. Refers to the name of the project configured in a repo config file.")
+	case command.OkToTest.String():
+		name = command.OkToTest
+		flagSet = pflag.NewFlagSet(command.OkToTest.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
 	case command.Version.String():
 		name = command.Version
 		flagSet = pflag.NewFlagSet(command.Version.String(), pflag.ContinueOnError)
@@ -292,14 +342,36 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Which directory to run state command in relative to root of repo, ex. 'child/dir'.")
 		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Which project to run state command for. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.")
 		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+	case command.Destroy.String():
+		name = command.Destroy
+		flagSet = pflag.NewFlagSet(command.Destroy.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Plan the destruction of this Terraform workspace.")
+		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Plan the destruction of this directory, relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Plan the destruction of this project. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.")
+		flagSet.BoolVarP(&confirm, confirmFlagLong, confirmFlagShort, false, "Apply the destroy plans of this pull request instead. To only apply a specific destroy plan, use the -d, -w and -p flags.")
+		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log and how long each project took to comment.")
 	default:
-		return CommentParseResult{CommentResponse: fmt.Sprintf("Error: unknown command %q – this is a bug", cmd)}
+		if !isCustom {
+			return CommentParseResult{CommentResponse: fmt.Sprintf("Error: unknown command %q – this is a bug", cmd)}
+		}
+		name = command.Custom
+		flagSet = pflag.NewFlagSet(cmd, pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", fmt.Sprintf("Switch to this Terraform workspace before running %s.", cmd))
+		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", fmt.Sprintf("Which directory to run %s in relative to root of repo, ex. 'child/dir'.", cmd))
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Which project to run %s for. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.", cmd))
+		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
 	}
 
 	subName, extraArgs, errResult := e.parseArgs(name, args, flagSet)
 	if errResult != "" {
 		return CommentParseResult{CommentResponse: errResult}
 	}
+	if name == command.Custom {
+		// The custom command is run as the sub command of command.Custom.
+		subName = cmd
+	}
 
 	dir, err = e.validateDir(dir)
 	if err != nil {
@@ -318,11 +390,37 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	// to the default or didn't set the flag so there is an edge case here we
 	// don't detect, ex. atlantis plan -p project -d . -w default won't cause
 	// an error.
-	if project != "" && (workspace != "" || dir != "") {
+	// Unlock matches the locks of projects by all the flags it's given, so a
+	// project's lock in a single workspace can be released.
+	if project != "" && (workspace != "" || dir != "") && name != command.Unlock {
 		err := fmt.Sprintf("cannot use -%s/--%s at same time as -%s/--%s or -%s/--%s", projectFlagShort, projectFlagLong, dirFlagShort, dirFlagLong, workspaceFlagShort, workspaceFlagLong)
 		return CommentParseResult{CommentResponse: e.errMarkdown(err, cmd, flagSet)}
 	}
 
+	if failed && (project != "" || workspace != "" || dir != "") {
+		err := fmt.Sprintf("cannot use --%s at same time as -%s/--%s, -%s/--%s or -%s/--%s", failedFlagLong, projectFlagShort, projectFlagLong, dirFlagShort, dirFlagLong, workspaceFlagShort, workspaceFlagLong)
+		return CommentParseResult{CommentResponse: e.errMarkdown(err, cmd, flagSet)}
+	}
+
+	if name == command.Destroy && !confirm && project == "" && workspace == "" && dir == "" {
+		err := fmt.Sprintf("must specify the project to destroy with -%s/--%s, -%s/--%s or -%s/--%s", projectFlagShort, projectFlagLong, dirFlagShort, dirFlagLong, workspaceFlagShort, workspaceFlagLong)
+		return CommentParseResult{CommentResponse: e.errMarkdown(err, cmd, flagSet)}
+	}
+
+	if name == command.Unlock && len(extraArgs) > 0 {
+		return CommentParseResult{CommentResponse: fmt.Sprintf(UnlockUsage, e.ExecutableName)}
+	}
+
+	if confirm && len(extraArgs) > 0 {
+		err := fmt.Sprintf("cannot pass extra arguments with --%s since the destroy plan is applied as it is", confirmFlagLong)
+		return CommentParseResult{CommentResponse: e.errMarkdown(err, cmd, flagSet)}
+	}
+
+	if quiet && verbose {
+		err := fmt.Sprintf("cannot use --%s at same time as --%s", quietFlagLong, verboseFlagLong)
+		return CommentParseResult{CommentResponse: e.errMarkdown(err, cmd, flagSet)}
+	}
+
 	if autoMergeMethod != "" {
 		if autoMergeDisabled {
 			err := fmt.Sprintf("cannot use --%s at the same time as --%s", autoMergeMethodFlagLong, autoMergeDisabledFlagLong)
@@ -336,22 +434,30 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	}
 
 	return CommentParseResult{
-		Command: NewCommentCommand(dir, extraArgs, name, subName, verbose, autoMergeDisabled, autoMergeMethod, workspace, project, policySet, clearPolicyApproval),
+		Command: NewCommentCommand(dir, extraArgs, name, subName, verbose, quiet, autoMergeDisabled, autoMergeMethod, workspace, project, policySet, clearPolicyApproval, force, failed, confirm),
 	}
 }
 
 func (e *CommentParser) parseArgs(name command.Name, args []string, flagSet *pflag.FlagSet) (string, []string, string) {
+	cmdName, usage := name.String(), name.DefaultUsage()
+	if name == command.Custom {
+		// Custom commands are named after what was typed, which is safe to
+		// use since we know args[1] is a custom command.
+		cmdName = strings.ToLower(args[1])
+		usage = cmdName
+	}
+
 	// Now parse the flags.
 	// It's safe to use [2:] because we know there's at least 2 elements in args.
 	err := flagSet.Parse(args[2:])
 	if err == pflag.ErrHelp {
-		return "", nil, fmt.Sprintf("```\nUsage of %s:\n%s\n```", name.DefaultUsage(), flagSet.FlagUsagesWrapped(usagesCols))
+		return "", nil, fmt.Sprintf("```\nUsage of %s:\n%s\n```", usage, flagSet.FlagUsagesWrapped(usagesCols))
 	}
 	if err != nil {
 		if name == command.Unlock {
 			return "", nil, fmt.Sprintf(UnlockUsage, e.ExecutableName)
 		}
-		return "", nil, e.errMarkdown(err.Error(), name.String(), flagSet)
+		return "", nil, e.errMarkdown(err.Error(), cmdName, flagSet)
 	}
 
 	var commandArgs []string // commandArgs are the arguments that are passed before `--` without any parameter flags.
@@ -366,23 +472,23 @@ func (e *CommentParser) parseArgs(name command.Name, args []string, flagSet *pfl
 	availableSubCommands := name.SubCommands()
 	if len(availableSubCommands) > 0 { // command requires a subcommand
 		if len(commandArgs) < 1 {
-			return "", nil, e.errMarkdown("subcommand required", name.String(), flagSet)
+			return "", nil, e.errMarkdown("subcommand required", cmdName, flagSet)
 		}
 		subCommand, commandArgs = commandArgs[0], commandArgs[1:]
 		isAvailableSubCommand := utils.SlicesContains(availableSubCommands, subCommand)
 		if !isAvailableSubCommand {
 			errMsg := fmt.Sprintf("invalid subcommand %s (not %s)", subCommand, strings.Join(availableSubCommands, ", "))
-			return "", nil, e.errMarkdown(errMsg, name.String(), flagSet)
+			return "", nil, e.errMarkdown(errMsg, cmdName, flagSet)
 		}
 	}
 
 	// check command args count requirements
 	commandArgCount, err := name.CommandArgCount(subCommand)
 	if err != nil {
-		return "", nil, e.errMarkdown(err.Error(), name.String(), flagSet)
+		return "", nil, e.errMarkdown(err.Error(), cmdName, flagSet)
 	}
 	if !commandArgCount.IsMatchCount(len(commandArgs)) {
-		return "", nil, e.errMarkdown(fmt.Sprintf("unknown argument(s) – %s", strings.Join(commandArgs, " ")), name.DefaultUsage(), flagSet)
+		return "", nil, e.errMarkdown(fmt.Sprintf("unknown argument(s) – %s", strings.Join(commandArgs, " ")), usage, flagSet)
 	}
 
 	var extraArgs []string // command extra_args
@@ -431,6 +537,21 @@ func (e *CommentParser) BuildApprovePoliciesComment(repoRelDir string, workspace
 	return fmt.Sprintf("%s %s%s", e.ExecutableName, command.ApprovePolicies.String(), flags)
 }
 
+// BuildUnlockComment builds an unlock comment for the specified args.
+func (e *CommentParser) BuildUnlockComment(repoRelDir string, workspace string, project string) string {
+	flags := e.buildFlags(repoRelDir, workspace, project, false, "")
+	return fmt.Sprintf("%s %s%s", e.ExecutableName, command.Unlock.String(), flags)
+}
+
+// BuildDestroyComment builds a destroy comment for the specified args.
+func (e *CommentParser) BuildDestroyComment(repoRelDir string, workspace string, project string, confirm bool) string {
+	flags := e.buildFlags(repoRelDir, workspace, project, false, "")
+	if confirm {
+		flags = fmt.Sprintf(" --%s%s", confirmFlagLong, flags)
+	}
+	return fmt.Sprintf("%s %s%s", e.ExecutableName, command.Destroy.String(), flags)
+}
+
 func (e *CommentParser) buildFlags(repoRelDir string, workspace string, project string, autoMergeDisabled bool, autoMergeMethod string) string {
 	// Add quotes if dir has spaces.
 	if strings.Contains(repoRelDir, " ") {
@@ -501,6 +622,17 @@ func (e *CommentParser) isAllowedCommand(cmd string) bool {
 	return false
 }
 
+// customCommand returns the custom command named cmd and true, or false if
+// there isn't one.
+func (e *CommentParser) customCommand(cmd string) (valid.CustomCommand, bool) {
+	for _, c := range e.CustomCommands {
+		if c.Name == cmd {
+			return c, true
+		}
+	}
+	return valid.CustomCommand{}, false
+}
+
 func (e *CommentParser) errMarkdown(errMsg string, cmd string, flagSet *pflag.FlagSet) string {
 	return fmt.Sprintf("```\nError: %s.\nUsage of %s:\n%s```", errMsg, cmd, flagSet.FlagUsagesWrapped(usagesCols))
 }
@@ -517,6 +649,9 @@ func (e *CommentParser) HelpComment() string {
 		AllowApprovePolicies bool
 		AllowImport          bool
 		AllowState           bool
+		AllowOkToTest        bool
+		AllowDestroy         bool
+		CustomCommands       []valid.CustomCommand
 	}{
 		ExecutableName:       e.ExecutableName,
 		AllowVersion:         e.isAllowedCommand(command.Version.String()),
@@ -526,6 +661,9 @@ func (e *CommentParser) HelpComment() string {
 		AllowApprovePolicies: e.isAllowedCommand(command.ApprovePolicies.String()),
 		AllowImport:          e.isAllowedCommand(command.Import.String()),
 		AllowState:           e.isAllowedCommand(command.State.String()),
+		AllowOkToTest:        e.isAllowedCommand(command.OkToTest.String()),
+		AllowDestroy:         e.isAllowedCommand(command.Destroy.String()),
+		CustomCommands:       e.CustomCommands,
 	}); err != nil {
 		return fmt.Sprintf("Failed to render template, this is a bug: %v", err)
 	}
@@ -567,7 +705,7 @@ Commands:
 {{- end }}
 {{- if .AllowUnlock }}
   unlock   Removes all atlantis locks and discards all plans for this PR.
-           To unlock a specific plan you can use the Atlantis UI.
+           To only unlock a specific project, use the -d, -w and -p flags.
 {{- end }}
 {{- if .AllowApprovePolicies }}
   approve_policies
@@ -585,6 +723,28 @@ Commands:
   state rm ADDRESS...
            Runs 'terraform state rm' for the passed address resource.
            To remove a specific project resource, use the -d, -w and -p flags.
+  state pull
+           Shows the metadata of the state, without any values.
+  state push PATH
+           Runs 'terraform state push' for the state file at PATH.
+           Only allowed for admins and for a single project.
+{{- end }}
+{{- if .AllowOkToTest }}
+  ok-to-test
+           Allows Atlantis to run commands on this pull request from a fork.
+           Must be run again after new commits are pushed.
+{{- end }}
+{{- if .AllowDestroy }}
+  destroy  Runs 'terraform plan -destroy' for a specific project, which must be
+           given with the -d, -w or -p flags.
+  destroy --confirm
+           Applies the destroy plans from this pull request.
+{{- end }}
+{{- range .CustomCommands }}
+  {{ .Name }}
+{{- if .Description }}
+           {{ .Description }}
+{{- end }}
 {{- end }}
   help     View help.
 
@@ -599,12 +759,12 @@ Use "{{ .ExecutableName }} [command] --help" for more information about a comman
 var DidYouMeanAtlantisComment = "Did you mean to use `%s` instead of `%s`?"
 
 // UnlockUsage is the comment we add to the pull request when someone runs
-// `atlantis unlock` with flags.
+// `atlantis unlock` with invalid flags or arguments.
 
 var UnlockUsage = "`Usage of unlock:`\n\n ```cmake\n" +
-	`%s unlock
+	`%s unlock [-p project] [-d dir] [-w workspace]
 
   Unlocks the entire PR and discards all plans in this PR.
-  Arguments or flags are not supported at the moment.
-  If you need to unlock a specific project please use the atlantis UI.` +
+  To only unlock the projects that match the -p, -d and -w flags
+  and discard their plans, use the flags.` +
 	"\n```"
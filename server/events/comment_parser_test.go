@@ -18,6 +18,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -139,6 +140,7 @@ func TestParse_HelpResponse(t *testing.T) {
 				}
 				r := commentParser.Parse(c, models.Github)
 				Equals(t, commentParser.HelpComment(), r.CommentResponse)
+				Equals(t, false, r.Unrecognized)
 			})
 		}
 	}
@@ -260,7 +262,7 @@ func TestParse_UnusedArguments(t *testing.T) {
 }
 
 func TestParse_UnknownShorthandFlag(t *testing.T) {
-	comment := "atlantis unlock -d ."
+	comment := "atlantis unlock -x ."
 	r := commentParser.Parse(comment, models.Github)
 
 	Equals(t, UnlockUsage, r.CommentResponse)
@@ -282,6 +284,7 @@ func TestParse_DidYouMeanAtlantis(t *testing.T) {
 		r := commentParser.Parse(c, models.Github)
 		Assert(t, r.CommentResponse == fmt.Sprintf(events.DidYouMeanAtlantisComment, "atlantis", "terraform"),
 			"For comment %q expected CommentResponse==%q but got %q", c, events.DidYouMeanAtlantisComment, r.CommentResponse)
+		Assert(t, r.Unrecognized, "For comment %q expected Unrecognized to be set", c)
 	}
 }
 
@@ -311,6 +314,7 @@ func TestParse_InvalidCommand(t *testing.T) {
 		r := cp.Parse(c, models.Github)
 		exp := fmt.Sprintf("```\nError: unknown command %q.\nRun 'atlantis --help' for usage.\nAvailable commands(--allow-commands): version, plan, apply, unlock\n```", strings.Fields(c)[1])
 		Equals(t, exp, r.CommentResponse)
+		Equals(t, true, r.Unrecognized)
 	}
 }
 
@@ -329,8 +333,8 @@ func TestParse_SubcommandUsage(t *testing.T) {
 		{"atlantis approve_policies --help", "approve_policies"},
 		{"atlantis import -h", "import ADDRESS ID"},
 		{"atlantis import --help", "import ADDRESS ID"},
-		{"atlantis state -h", "state [rm ADDRESS...]"},
-		{"atlantis state --help", "state [rm ADDRESS...]"},
+		{"atlantis state -h", "state [rm ADDRESS...|pull|push PATH]"},
+		{"atlantis state --help", "state [rm ADDRESS...|pull|push PATH]"},
 	}
 	for _, c := range tests {
 		r := commentParser.Parse(c.input, models.Github)
@@ -443,6 +447,138 @@ func TestParse_ValidCommand(t *testing.T) {
 	}
 }
 
+func TestParse_OkToTest(t *testing.T) {
+	r := commentParser.Parse("atlantis ok-to-test", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, &events.CommentCommand{Name: command.OkToTest}, r.Command)
+
+	r = commentParser.Parse("atlantis ok-to-test -d .", models.Github)
+	Assert(t, r.CommentResponse != "", "expected an error response for unknown flags")
+}
+
+func TestParse_Force(t *testing.T) {
+	r := commentParser.Parse("atlantis plan -d dir --force", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Assert(t, r.Command.Force, "expected force to be set")
+
+	r = commentParser.Parse("atlantis plan -d dir", models.Github)
+	Assert(t, !r.Command.Force, "expected force not to be set")
+
+	r = commentParser.Parse("atlantis apply --force", models.Github)
+	Assert(t, r.CommentResponse != "", "expected an error response for unknown flags")
+}
+
+func TestParse_Failed(t *testing.T) {
+	r := commentParser.Parse("atlantis plan --failed", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Assert(t, r.Command.Failed, "expected failed to be set")
+
+	r = commentParser.Parse("atlantis apply --failed", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Assert(t, r.Command.Failed, "expected failed to be set")
+
+	r = commentParser.Parse("atlantis plan", models.Github)
+	Assert(t, !r.Command.Failed, "expected failed not to be set")
+
+	r = commentParser.Parse("atlantis plan --failed -p project", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "cannot use --failed at same time as"), "expected an error response, got %q", r.CommentResponse)
+
+	r = commentParser.Parse("atlantis import --failed", models.Github)
+	Assert(t, r.CommentResponse != "", "expected an error response for unknown flags")
+}
+
+func TestParse_Quiet(t *testing.T) {
+	r := commentParser.Parse("atlantis plan --quiet", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Assert(t, r.Command.Quiet, "expected quiet to be set")
+
+	r = commentParser.Parse("atlantis apply -p project --quiet", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Assert(t, r.Command.Quiet, "expected quiet to be set")
+
+	r = commentParser.Parse("atlantis plan", models.Github)
+	Assert(t, !r.Command.Quiet, "expected quiet not to be set")
+
+	r = commentParser.Parse("atlantis plan --quiet --verbose", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "cannot use --quiet at same time as --verbose"), "expected an error response, got %q", r.CommentResponse)
+
+	r = commentParser.Parse("atlantis version --quiet", models.Github)
+	Assert(t, r.CommentResponse != "", "expected an error response for unknown flags")
+}
+
+func TestParse_StatePullPush(t *testing.T) {
+	r := commentParser.Parse("atlantis state pull -p project", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.State, r.Command.Name)
+	Equals(t, "pull", r.Command.SubName)
+	Equals(t, "project", r.Command.ProjectName)
+	Equals(t, 0, len(r.Command.Flags))
+
+	r = commentParser.Parse("atlantis state -d network push backup.tfstate -- -force", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "push", r.Command.SubName)
+	Equals(t, "network", r.Command.RepoRelDir)
+	Equals(t, []string{"-force", "backup.tfstate"}, r.Command.Flags)
+
+	r = commentParser.Parse("atlantis state pull address", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown argument(s) – address"), "expected an error response, got %q", r.CommentResponse)
+
+	r = commentParser.Parse("atlantis state push", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown argument(s)"), "expected an error response, got %q", r.CommentResponse)
+
+	r = commentParser.Parse("atlantis state mv a b", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "invalid subcommand mv (not rm, pull, push)"), "expected an error response, got %q", r.CommentResponse)
+}
+
+func TestParse_Destroy(t *testing.T) {
+	r := commentParser.Parse("atlantis destroy -p network -- -target=module.vpc", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.Destroy, r.Command.Name)
+	Equals(t, "network", r.Command.ProjectName)
+	Equals(t, []string{"-target=module.vpc"}, r.Command.Flags)
+	Assert(t, !r.Command.Confirm, "expected confirm not to be set")
+
+	r = commentParser.Parse("atlantis destroy --confirm", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Assert(t, r.Command.Confirm, "expected confirm to be set")
+
+	r = commentParser.Parse("atlantis destroy", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "must specify the project to destroy"), "expected an error response, got %q", r.CommentResponse)
+
+	r = commentParser.Parse("atlantis destroy --confirm -- -target=module.vpc", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "cannot pass extra arguments with --confirm"), "expected an error response, got %q", r.CommentResponse)
+
+	Equals(t, "atlantis destroy --confirm -p network", commentParser.BuildDestroyComment("network", "default", "network", true))
+}
+
+func TestParse_CustomCommand(t *testing.T) {
+	cp := events.CommentParser{
+		ExecutableName: "atlantis",
+		AllowCommands:  []command.Name{command.Plan},
+		CustomCommands: []valid.CustomCommand{{Name: "docs", Description: "Regenerates the module docs.", Workflow: "docs"}},
+	}
+
+	r := cp.Parse("atlantis Docs -p network -- --sort", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.Custom, r.Command.Name)
+	Equals(t, "docs", r.Command.SubName)
+	Equals(t, "docs", r.Command.DisplayName())
+	Equals(t, "network", r.Command.ProjectName)
+	Equals(t, []string{"--sort"}, r.Command.Flags)
+
+	r = cp.Parse("atlantis docs extra", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "Error: unknown argument(s) – extra.\nUsage of docs:"), "expected an error response, got %q", r.CommentResponse)
+
+	r = cp.Parse("atlantis docs --help", models.Github)
+	Assert(t, strings.HasPrefix(r.CommentResponse, "```\nUsage of docs:\n"), "expected the usage, got %q", r.CommentResponse)
+
+	r = cp.Parse("atlantis lint", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "Available commands(--allow-commands): plan, docs"), "expected an error response, got %q", r.CommentResponse)
+
+	r = cp.Parse("atlantis help", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "  docs\n           Regenerates the module docs.\n  help     View help."), "expected docs in the help, got %q", r.CommentResponse)
+}
+
 func TestParse_InvalidWorkspace(t *testing.T) {
 	t.Log("if -w is used with '..' or '/', should return an error")
 	comments := []string{
@@ -471,6 +607,29 @@ func TestParse_InvalidWorkspace(t *testing.T) {
 	}
 }
 
+func TestParse_Unlock(t *testing.T) {
+	r := commentParser.Parse("atlantis unlock", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.Unlock, r.Command.Name)
+	Assert(t, !r.Command.IsForSpecificProject(), "expected the whole PR to be unlocked")
+
+	// Unlike other commands, unlock can match projects by name and workspace.
+	r = commentParser.Parse("atlantis unlock -p network -w staging", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "network", r.Command.ProjectName)
+	Equals(t, "staging", r.Command.Workspace)
+
+	r = commentParser.Parse("atlantis unlock -d ./modules/../vpc", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "vpc", r.Command.RepoRelDir)
+
+	r = commentParser.Parse("atlantis unlock -p network -- -target=module.vpc", models.Github)
+	Equals(t, UnlockUsage, r.CommentResponse)
+
+	Equals(t, "atlantis unlock -p network", commentParser.BuildUnlockComment("network", "staging", "network"))
+	Equals(t, "atlantis unlock -d network -w staging", commentParser.BuildUnlockComment("network", "staging", ""))
+}
+
 func TestParse_UsingProjectAtSameTimeAsWorkspaceOrDir(t *testing.T) {
 	cases := []string{
 		"atlantis plan -w workspace -p project",
@@ -915,7 +1074,7 @@ Commands:
   apply    Runs 'terraform apply' on all unapplied plans from this pull request.
            To only apply a specific plan, use the -d, -w and -p flags.
   unlock   Removes all atlantis locks and discards all plans for this PR.
-           To unlock a specific plan you can use the Atlantis UI.
+           To only unlock a specific project, use the -d, -w and -p flags.
   approve_policies
            Approves all current policy checking failures for the PR.
   version  Print the output of 'terraform version'
@@ -925,6 +1084,18 @@ Commands:
   state rm ADDRESS...
            Runs 'terraform state rm' for the passed address resource.
            To remove a specific project resource, use the -d, -w and -p flags.
+  state pull
+           Shows the metadata of the state, without any values.
+  state push PATH
+           Runs 'terraform state push' for the state file at PATH.
+           Only allowed for admins and for a single project.
+  ok-to-test
+           Allows Atlantis to run commands on this pull request from a fork.
+           Must be run again after new commits are pushed.
+  destroy  Runs 'terraform plan -destroy' for a specific project, which must be
+           given with the -d, -w or -p flags.
+  destroy --confirm
+           Applies the destroy plans from this pull request.
   help     View help.
 
 Flags:
@@ -983,7 +1154,7 @@ Commands:
   apply    Runs 'terraform apply' on all unapplied plans from this pull request.
            To only apply a specific plan, use the -d, -w and -p flags.
   unlock   Removes all atlantis locks and discards all plans for this PR.
-           To unlock a specific plan you can use the Atlantis UI.
+           To only unlock a specific project, use the -d, -w and -p flags.
   help     View help.
 
 Flags:
@@ -1050,10 +1221,17 @@ func TestParse_VCSUsername(t *testing.T) {
 var PlanUsage = `Usage of plan:
   -d, --dir string         Which directory to run plan in relative to root of repo,
                            ex. 'child/dir'.
+      --failed             Only plan the projects whose plan failed in the last run.
+                           Cannot be used at same time as project, workspace or dir
+                           flags.
+      --force              Run plan even if the plan from the last run can be reused.
   -p, --project string     Which project to run plan for. Refers to the name of the
                            project configured in a repo config file. Cannot be used
                            at same time as workspace or dir flags.
-      --verbose            Append Atlantis log to comment.
+      --quiet              Only comment the summary of each project, with a link to
+                           its full output. Cannot be used at same time as verbose flag.
+      --verbose            Append Atlantis log, refresh output and how long each
+                           project took to comment.
   -w, --workspace string   Switch to this Terraform workspace before planning.
 `
 
@@ -1064,11 +1242,18 @@ var ApplyUsage = `Usage of apply:
                                    for GitHub)
   -d, --dir string                 Apply the plan for this directory, relative to
                                    root of repo, ex. 'child/dir'.
+      --failed                     Only apply the projects whose apply failed in the
+                                   last run. Cannot be used at same time as project,
+                                   workspace or dir flags.
   -p, --project string             Apply the plan for this project. Refers to the
                                    name of the project configured in a repo config
                                    file. Cannot be used at same time as workspace or
                                    dir flags.
-      --verbose                    Append Atlantis log to comment.
+      --quiet                      Only comment the summary of each project, with a
+                                   link to its full output. Cannot be used at same
+                                   time as verbose flag.
+      --verbose                    Append Atlantis log and how long each project
+                                   took to comment.
   -w, --workspace string           Apply the plan for this Terraform workspace.
 `
 
@@ -1089,11 +1274,11 @@ var ApprovePolicyUsage = `Usage of approve_policies:
 `
 
 var UnlockUsage = "`Usage of unlock:`\n\n ```cmake\n" +
-	`atlantis unlock
+	`atlantis unlock [-p project] [-d dir] [-w workspace]
 
   Unlocks the entire PR and discards all plans in this PR.
-  Arguments or flags are not supported at the moment.
-  If you need to unlock a specific project please use the atlantis UI.` +
+  To only unlock the projects that match the -p, -d and -w flags
+  and discard their plans, use the flags.` +
 	"\n```"
 
 var ImportUsage = `Usage of import ADDRESS ID:
@@ -0,0 +1,333 @@
+package events
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// The kinds of comments that can be rendered with the repo's
+// comment_templates.
+const (
+	planCommentTemplate        = "plan"
+	applyCommentTemplate       = "apply"
+	policyCheckCommentTemplate = "policy_check"
+	errorCommentTemplate       = "error"
+)
+
+// Limits of comment_templates so that a template can't use up the server's
+// memory or CPU.
+const (
+	// maxCommentTemplateSize is the max size of a comment_templates file.
+	maxCommentTemplateSize = 64 * 1024
+	// maxCommentTemplateOutput is the max size of a rendered comment.
+	maxCommentTemplateOutput = 1024 * 1024
+	// checkDeadlineFunc is the func that's called at the start of every
+	// range iteration and template to stop rendering after
+	// commentTemplateTimeout.
+	checkDeadlineFunc = "atlantisCheckDeadline"
+)
+
+// commentTemplateTimeout is how long a comment can take to render.
+var commentTemplateTimeout = 2 * time.Second
+
+// deniedCommentTemplateFuncs are the sprig functions comment_templates can't
+// use because they read the server's environment or network, or because a
+// single call can allocate an unbounded amount of memory or CPU.
+var deniedCommentTemplateFuncs = []string{
+	"env", "expandenv", "getHostByName",
+	"until", "untilStep", "seq", "repeat", "indent", "nindent",
+	"randAlpha", "randAlphaNum", "randAscii", "randNumeric", "randBytes",
+	"bcrypt", "htpasswd", "derivePassword", "genPrivateKey", "buildCustomCert",
+	"genCA", "genCAWithKey", "genSelfSignedCert", "genSelfSignedCertWithKey",
+	"genSignedCert", "genSignedCertWithKey",
+}
+
+// CommentTemplateData is the data comment_templates are rendered with.
+type CommentTemplateData struct {
+	// Command is the command that was run, ex. plan.
+	Command string
+	// Repo is the full name of the repo, ex. runatlantis/atlantis.
+	Repo string
+	// PullNum is the number of the pull request.
+	PullNum int
+	// PullURL is the URL of the pull request.
+	PullURL string
+	// User is the username of the user that ran the command.
+	User string
+	// VcsRequestType is "Pull Request" or "Merge Request" on GitLab.
+	VcsRequestType string
+	// ExecutableName is the name of the comment command, ex. atlantis.
+	ExecutableName string
+	// Error is the error or failure of the command if it failed before any
+	// project was run.
+	Error string
+	// Projects are the results of the projects the command was run for.
+	Projects []CommentTemplateProject
+	// NumSuccesses is the number of projects that succeeded.
+	NumSuccesses int
+	// NumFailures is the number of projects that errored or failed.
+	NumFailures int
+	// NumPlansWithChanges is the number of plans with changes.
+	NumPlansWithChanges int
+	// Totals are the resource counts of all the plans.
+	Totals models.PlanSuccessStats
+	// Default is the comment rendered with the default templates.
+	Default string
+}
+
+// CommentTemplateProject is the result of a project in a CommentTemplateData.
+type CommentTemplateProject struct {
+	// Name is the name of the project, empty if it has none.
+	Name string
+	// Dir is the dir of the project relative to the repo root.
+	Dir string
+	// Workspace is the Terraform workspace of the project.
+	Workspace string
+	// TerraformVersion is the version of Terraform the project was planned
+	// with.
+	TerraformVersion string
+	// JobURL is the URL of the project's job output.
+	JobURL string
+	// Successful is true if the project didn't error or fail.
+	Successful bool
+	// NoChanges is true if the project's plan has no changes.
+	NoChanges bool
+	// Stats are the resource counts of the project's plan.
+	Stats models.PlanSuccessStats
+	// Summary is the one line summary of the project's plan, apply or
+	// policy check.
+	Summary string
+	// Output is the project's Terraform or policy check output.
+	Output string
+	// Error is the project's error or failure.
+	Error string
+}
+
+// commentTemplateFuncs are the sprig functions without
+// deniedCommentTemplateFuncs, and the func that stops rendering once deadline
+// has passed.
+func commentTemplateFuncs(deadline time.Time) template.FuncMap {
+	funcs := sprig.TxtFuncMap()
+	for _, f := range deniedCommentTemplateFuncs {
+		delete(funcs, f)
+	}
+	funcs[checkDeadlineFunc] = func() (string, error) {
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("took longer than %s to render", commentTemplateTimeout)
+		}
+		return "", nil
+	}
+	return funcs
+}
+
+// commentTemplateKind returns the kind of the comment_templates that renders
+// the comment of res, or "" if it's rendered with the default templates.
+func commentTemplateKind(res command.Result, cmd PullCommand) string {
+	if res.Error != nil || res.Failure != "" {
+		return errorCommentTemplate
+	}
+	switch cmd.CommandName() {
+	case command.Plan, command.Autoplan:
+		return planCommentTemplate
+	case command.Apply:
+		return applyCommentTemplate
+	case command.PolicyCheck:
+		return policyCheckCommentTemplate
+	}
+	return ""
+}
+
+// newCommentTemplateData returns the data comment_templates render res with.
+func newCommentTemplateData(ctx *command.Context, res command.Result, cmd PullCommand, common commonData, rendered string) CommentTemplateData {
+	data := CommentTemplateData{
+		Command:        cmd.CommandName().String(),
+		Repo:           ctx.Pull.BaseRepo.FullName,
+		PullNum:        ctx.Pull.Num,
+		PullURL:        ctx.Pull.URL,
+		User:           ctx.User.Username,
+		VcsRequestType: common.VcsRequestType,
+		ExecutableName: common.ExecutableName,
+		Default:        rendered,
+	}
+	if res.Error != nil {
+		data.Error = res.Error.Error()
+	} else if res.Failure != "" {
+		data.Error = res.Failure
+	}
+	for _, result := range res.ProjectResults {
+		project := CommentTemplateProject{
+			Name:       result.ProjectName,
+			Dir:        result.RepoRelDir,
+			Workspace:  result.Workspace,
+			JobURL:     result.JobURL,
+			Successful: result.IsSuccessful(),
+		}
+		switch {
+		case result.PlanSuccess != nil:
+			project.TerraformVersion = result.PlanSuccess.TerraformVersion
+			project.NoChanges = result.PlanSuccess.NoChanges()
+			project.Stats = result.PlanSuccess.Stats()
+			project.Summary = result.PlanSuccess.DiffSummary()
+			project.Output = strings.TrimSpace(result.PlanSuccess.TerraformOutput)
+			data.Totals.Import += project.Stats.Import
+			data.Totals.Add += project.Stats.Add
+			data.Totals.Change += project.Stats.Change
+			data.Totals.Destroy += project.Stats.Destroy
+			data.Totals.Changes = data.Totals.Changes || project.Stats.Changes
+			data.Totals.ChangesOutside = data.Totals.ChangesOutside || project.Stats.ChangesOutside
+			if !project.NoChanges {
+				data.NumPlansWithChanges++
+			}
+		case result.PolicyCheckResults != nil:
+			project.Summary = result.PolicyCheckResults.Summary()
+			project.Output = strings.TrimSpace(result.PolicyCheckResults.CombinedOutput())
+		case result.ApplySuccess != "":
+			project.Output = strings.TrimSpace(result.ApplySuccess)
+			project.Summary = reApplyComplete.FindString(project.Output)
+		}
+		if result.Error != nil {
+			project.Error = result.Error.Error()
+		} else if result.Failure != "" {
+			project.Error = result.Failure
+		}
+		if project.Successful {
+			data.NumSuccesses++
+		} else {
+			data.NumFailures++
+		}
+		data.Projects = append(data.Projects, project)
+	}
+	return data
+}
+
+// renderCommentTemplate renders data with the comment_templates text. It
+// returns an error if text isn't a valid template, can't render data, or goes
+// over the time or output limits.
+func renderCommentTemplate(kind string, text string, data CommentTemplateData) (string, error) {
+	tmpl, err := template.New(kind).Funcs(commentTemplateFuncs(time.Now().Add(commentTemplateTimeout))).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	// Loops and recursive templates can run for a long time without
+	// writing anything so they check the deadline as they go.
+	for _, t := range tmpl.Templates() {
+		addDeadlineChecks(t.Tree)
+	}
+	buf := &limitedBuffer{limit: maxCommentTemplateOutput}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// addDeadlineChecks calls checkDeadlineFunc at the start of tree and of every
+// range iteration in it.
+func addDeadlineChecks(tree *parse.Tree) {
+	if tree == nil || tree.Root == nil {
+		return
+	}
+	check := func(pos parse.Pos) parse.Node {
+		ident := parse.NewIdentifier(checkDeadlineFunc).SetTree(tree).SetPos(pos)
+		return &parse.ActionNode{
+			NodeType: parse.NodeAction,
+			Pos:      pos,
+			Pipe: &parse.PipeNode{
+				NodeType: parse.NodePipe,
+				Pos:      pos,
+				Cmds:     []*parse.CommandNode{{NodeType: parse.NodeCommand, Pos: pos, Args: []parse.Node{ident}}},
+			},
+		}
+	}
+	var walk func(list *parse.ListNode)
+	walk = func(list *parse.ListNode) {
+		if list == nil {
+			return
+		}
+		for _, node := range list.Nodes {
+			switch node := node.(type) {
+			case *parse.RangeNode:
+				walk(node.List)
+				walk(node.ElseList)
+				node.List.Nodes = append([]parse.Node{check(node.Position())}, node.List.Nodes...)
+			case *parse.IfNode:
+				walk(node.List)
+				walk(node.ElseList)
+			case *parse.WithNode:
+				walk(node.List)
+				walk(node.ElseList)
+			case *parse.ListNode:
+				walk(node)
+			}
+		}
+	}
+	walk(tree.Root)
+	tree.Root.Nodes = append([]parse.Node{check(tree.Root.Position())}, tree.Root.Nodes...)
+}
+
+// limitedBuffer is a buffer that returns an error once more than limit bytes
+// are written to it.
+type limitedBuffer struct {
+	strings.Builder
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, fmt.Errorf("rendered comment is larger than %d bytes", b.limit)
+	}
+	return b.Builder.Write(p)
+}
+
+// readCommentTemplates reads the comment_templates files of the repo cloned
+// at repoDir. Files must be in the repo, even through symlinks.
+func readCommentTemplates(repoDir string, cfg *valid.CommentTemplates) (map[string]string, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	absRepoDir, err := filepath.EvalSymlinks(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	templates := make(map[string]string)
+	for kind, path := range map[string]string{
+		planCommentTemplate:        cfg.Plan,
+		applyCommentTemplate:       cfg.Apply,
+		policyCheckCommentTemplate: cfg.PolicyCheck,
+		errorCommentTemplate:       cfg.Error,
+	} {
+		if path == "" {
+			continue
+		}
+		absPath, err := filepath.EvalSymlinks(filepath.Join(repoDir, path))
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s comment template", kind)
+		}
+		if rel, err := filepath.Rel(absRepoDir, absPath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s comment template %q is not in the repo", kind, path)
+		}
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s comment template", kind)
+		}
+		if info.Size() > maxCommentTemplateSize {
+			return nil, fmt.Errorf("%s comment template %q is larger than %d bytes", kind, path, maxCommentTemplateSize)
+		}
+		content, err := os.ReadFile(absPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s comment template", kind)
+		}
+		templates[kind] = string(content)
+	}
+	return templates, nil
+}
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestReadCommentTemplates(t *testing.T) {
+	repoDir := t.TempDir()
+	Ok(t, os.MkdirAll(filepath.Join(repoDir, ".atlantis"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, ".atlantis", "plan.tmpl"), []byte("plan"), 0600))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "error.tmpl"), []byte("error"), 0600))
+
+	templates, err := readCommentTemplates(repoDir, &valid.CommentTemplates{
+		Plan:  ".atlantis/plan.tmpl",
+		Error: "error.tmpl",
+	})
+	Ok(t, err)
+	Equals(t, map[string]string{"plan": "plan", "error": "error"}, templates)
+
+	templates, err = readCommentTemplates(repoDir, nil)
+	Ok(t, err)
+	Equals(t, 0, len(templates))
+}
+
+func TestReadCommentTemplates_Missing(t *testing.T) {
+	_, err := readCommentTemplates(t.TempDir(), &valid.CommentTemplates{Apply: "apply.tmpl"})
+	ErrContains(t, "reading apply comment template", err)
+}
+
+func TestReadCommentTemplates_SymlinkOutsideRepo(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret")
+	Ok(t, os.WriteFile(secret, []byte("secret"), 0600))
+	repoDir := t.TempDir()
+	Ok(t, os.Symlink(secret, filepath.Join(repoDir, "plan.tmpl")))
+
+	_, err := readCommentTemplates(repoDir, &valid.CommentTemplates{Plan: "plan.tmpl"})
+	ErrEquals(t, `plan comment template "plan.tmpl" is not in the repo`, err)
+}
+
+func TestRenderCommentTemplate_Limits(t *testing.T) {
+	defer func(timeout time.Duration) { commentTemplateTimeout = timeout }(commentTemplateTimeout)
+	commentTemplateTimeout = 100 * time.Millisecond
+
+	cases := []struct {
+		description string
+		text        string
+		expErr      string
+	}{
+		{
+			description: "long loop",
+			text:        `{{ range 1000000000 }}{{ end }}`,
+			expErr:      "took longer than 100ms to render",
+		},
+		{
+			description: "nested loops over data",
+			text:        `{{ range $.PullNum }}{{ range $.PullNum }}{{ range $.PullNum }}{{ end }}{{ end }}{{ end }}`,
+			expErr:      "took longer than 100ms to render",
+		},
+		{
+			description: "recursive template",
+			text:        `{{ define "a" }}{{ template "a" . }}{{ template "a" . }}{{ end }}{{ template "a" . }}`,
+			expErr:      "took longer than 100ms to render",
+		},
+		{
+			description: "large output",
+			text:        `{{ range 2000 }}{{ printf "%01000d" 0 }}{{ end }}`,
+			expErr:      "rendered comment is larger than 1048576 bytes",
+		},
+		{
+			description: "denied func",
+			text:        `{{ until 1000000000 }}`,
+			expErr:      `function "until" not defined`,
+		},
+		{
+			description: "env",
+			text:        `{{ env "HOME" }}`,
+			expErr:      `function "env" not defined`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			start := time.Now()
+			_, err := renderCommentTemplate("plan", c.text, CommentTemplateData{PullNum: 100000})
+			ErrContains(t, c.expErr, err)
+			Assert(t, time.Since(start) < 5*time.Second, "exp rendering to stop early, took %s", time.Since(start))
+		})
+	}
+
+	// The deadline checks don't change what's rendered.
+	out, err := renderCommentTemplate("plan", `{{ range .Projects }}{{ if .NoChanges }}{{ $dir := .Dir }}{{ range 2 }}{{ $dir }}{{ end }}{{ end }}{{ end }}`, CommentTemplateData{
+		Projects: []CommentTemplateProject{{Dir: "a", NoChanges: true}, {Dir: "b"}},
+	})
+	Ok(t, err)
+	Equals(t, "aa", out)
+}
+
+func TestReadCommentTemplates_TooLarge(t *testing.T) {
+	repoDir := t.TempDir()
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "plan.tmpl"), []byte(strings.Repeat("x", maxCommentTemplateSize+1)), 0600))
+	_, err := readCommentTemplates(repoDir, &valid.CommentTemplates{Plan: "plan.tmpl"})
+	ErrEquals(t, `plan comment template "plan.tmpl" is larger than 65536 bytes`, err)
+}
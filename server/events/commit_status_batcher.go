@@ -0,0 +1,151 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// commitStatusWrittenTTL is how long a written status is remembered for so
+// that writing the same status again can be skipped.
+const commitStatusWrittenTTL = time.Hour
+
+// CommitStatusBatcher coalesces commit status updates. Updates are queued for
+// Debounce and then written one at a time. If a status is updated more than
+// once while it's queued, ex. a project's plan goes from pending to success
+// within Debounce, only the last update is written, and updates that don't
+// change the status that was last written aren't written at all. This cuts the
+// number of calls to the VCS host when many projects finish at about the same
+// time and writing one at a time avoids the secondary rate limits that hosts
+// like GitHub apply to concurrent requests.
+type CommitStatusBatcher struct {
+	Client   vcs.Client
+	Debounce time.Duration
+	Scope    tally.Scope
+
+	mutex   sync.Mutex
+	queued  map[commitStatusKey]*commitStatusUpdate
+	order   []commitStatusKey
+	timer   *time.Timer
+	flushMu sync.Mutex
+	// written is only accessed while flushMu is held.
+	written map[commitStatusKey]writtenCommitStatus
+}
+
+// commitStatusKey identifies a status, i.e. a context on a commit.
+type commitStatusKey struct {
+	repo   string
+	pull   int
+	commit string
+	src    string
+}
+
+type commitStatusUpdate struct {
+	logger      logging.SimpleLogging
+	repo        models.Repo
+	pull        models.PullRequest
+	state       models.CommitStatus
+	description string
+	url         string
+}
+
+type writtenCommitStatus struct {
+	state       models.CommitStatus
+	description string
+	url         string
+	writtenAt   time.Time
+}
+
+// UpdateStatus queues an update of the status src of the head commit of pull.
+// Errors writing the status are logged to logger since they happen after
+// UpdateStatus returns.
+func (b *CommitStatusBatcher) UpdateStatus(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, url string) {
+	key := commitStatusKey{repo: repo.FullName, pull: pull.Num, commit: pull.HeadCommit, src: src}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.queued == nil {
+		b.queued = make(map[commitStatusKey]*commitStatusUpdate)
+	}
+	if _, ok := b.queued[key]; ok {
+		b.scope().Counter("coalesced").Inc(1)
+	} else {
+		b.order = append(b.order, key)
+	}
+	b.queued[key] = &commitStatusUpdate{
+		logger:      logger,
+		repo:        repo,
+		pull:        pull,
+		state:       state,
+		description: description,
+		url:         url,
+	}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.Debounce, b.flush)
+	}
+}
+
+// Flush writes the queued updates now. It's called on shutdown so that queued
+// updates aren't lost.
+func (b *CommitStatusBatcher) Flush() {
+	b.mutex.Lock()
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.mutex.Unlock()
+	b.flush()
+}
+
+func (b *CommitStatusBatcher) flush() {
+	// Flushes are serialized so that the updates of a status are written in
+	// the order they were made.
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mutex.Lock()
+	order, queued := b.order, b.queued
+	b.order, b.queued, b.timer = nil, nil, nil
+	b.mutex.Unlock()
+
+	if b.written == nil {
+		b.written = make(map[commitStatusKey]writtenCommitStatus)
+	}
+	now := time.Now()
+	for key, written := range b.written {
+		if now.Sub(written.writtenAt) > commitStatusWrittenTTL {
+			delete(b.written, key)
+		}
+	}
+
+	scope := b.scope()
+	for _, key := range order {
+		update := queued[key]
+		if last, ok := b.written[key]; ok && last.state == update.state && last.description == update.description && last.url == update.url {
+			scope.Counter("coalesced").Inc(1)
+			continue
+		}
+		if err := b.Client.UpdateStatus(update.logger, update.repo, update.pull, update.state, key.src, update.description, update.url); err != nil {
+			update.logger.Warn("unable to update commit status: %s", err)
+			scope.Counter("errors").Inc(1)
+			delete(b.written, key)
+			continue
+		}
+		scope.Counter("written").Inc(1)
+		b.written[key] = writtenCommitStatus{
+			state:       update.state,
+			description: update.description,
+			url:         update.url,
+			writtenAt:   now,
+		}
+	}
+}
+
+func (b *CommitStatusBatcher) scope() tally.Scope {
+	if b.Scope == nil {
+		return tally.NoopScope
+	}
+	return b.Scope
+}
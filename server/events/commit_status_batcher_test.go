@@ -0,0 +1,69 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestCommitStatusBatcher_Coalesces(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	client := mocks.NewMockClient()
+	scope := tally.NewTestScope("commit_status", nil)
+	// The debounce is long enough that only Flush writes the statuses.
+	batcher := &events.CommitStatusBatcher{Client: client, Debounce: time.Hour, Scope: scope}
+	repo := models.Repo{FullName: "owner/repo"}
+	pull := models.PullRequest{Num: 1, HeadCommit: "abc", BaseRepo: repo}
+
+	batcher.UpdateStatus(logger, repo, pull, models.PendingCommitStatus, "atlantis/plan: project1", "Plan in progress...", "url1")
+	batcher.UpdateStatus(logger, repo, pull, models.PendingCommitStatus, "atlantis/plan: project2", "Plan in progress...", "url2")
+	batcher.UpdateStatus(logger, repo, pull, models.SuccessCommitStatus, "atlantis/plan: project1", "No changes.", "url1")
+	client.VerifyWasCalled(Never()).UpdateStatus(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[models.CommitStatus](), Any[string](), Any[string](), Any[string]())
+
+	batcher.Flush()
+	client.VerifyWasCalled(Never()).UpdateStatus(Any[logging.SimpleLogging](), Eq(repo), Eq(pull), Eq(models.PendingCommitStatus),
+		Eq("atlantis/plan: project1"), Any[string](), Any[string]())
+	client.VerifyWasCalledOnce().UpdateStatus(Any[logging.SimpleLogging](), Eq(repo), Eq(pull), Eq(models.SuccessCommitStatus),
+		Eq("atlantis/plan: project1"), Eq("No changes."), Eq("url1"))
+	client.VerifyWasCalledOnce().UpdateStatus(Any[logging.SimpleLogging](), Eq(repo), Eq(pull), Eq(models.PendingCommitStatus),
+		Eq("atlantis/plan: project2"), Eq("Plan in progress..."), Eq("url2"))
+
+	// Writing a status that's already been written is skipped.
+	batcher.UpdateStatus(logger, repo, pull, models.SuccessCommitStatus, "atlantis/plan: project1", "No changes.", "url1")
+	batcher.Flush()
+	client.VerifyWasCalled(Times(2)).UpdateStatus(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[models.CommitStatus](), Any[string](), Any[string](), Any[string]())
+
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(2), counters["commit_status.written+"].Value())
+	Equals(t, int64(2), counters["commit_status.coalesced+"].Value())
+}
+
+func TestCommitStatusBatcher_Debounce(t *testing.T) {
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClient()
+	s := events.DefaultCommitStatusUpdater{
+		Client:     client,
+		StatusName: "atlantis",
+		Batcher:    &events.CommitStatusBatcher{Client: client, Debounce: 10 * time.Millisecond},
+	}
+	err := s.UpdateProject(command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		RepoRelDir: ".",
+		Workspace:  "default",
+	}, command.Apply, models.SuccessCommitStatus, "url", nil)
+	Ok(t, err)
+
+	client.VerifyWasCalledEventually(Once(), 5*time.Second).UpdateStatus(Any[logging.SimpleLogging](), Eq(models.Repo{}), Eq(models.PullRequest{}),
+		Eq(models.SuccessCommitStatus), Eq("atlantis/apply: ./default"), Eq("Apply succeeded."), Eq("url"))
+}
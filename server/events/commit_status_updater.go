@@ -15,6 +15,7 @@ package events
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/runatlantis/atlantis/server/core/runtime"
 	"github.com/runatlantis/atlantis/server/events/command"
@@ -27,6 +28,10 @@ import (
 
 //go:generate pegomock generate github.com/runatlantis/atlantis/server/events --package mocks -o mocks/mock_commit_status_updater.go CommitStatusUpdater
 
+// CheckRunReplanAction identifies the action of the check runs of projects
+// that re-plans the project.
+const CheckRunReplanAction = "replan"
+
 // CommitStatusUpdater updates the status of a commit with the VCS host. We set
 // the status to signify whether the plan/apply succeeds.
 type CommitStatusUpdater interface {
@@ -46,6 +51,12 @@ type DefaultCommitStatusUpdater struct {
 	Client vcs.Client
 	// StatusName is the name used to identify Atlantis when creating PR statuses.
 	StatusName string
+	// Batcher, if set, coalesces the updates of project statuses instead of
+	// writing each one right away.
+	Batcher *CommitStatusBatcher
+	// ChecksClient, if set, reports the status of each project of GitHub
+	// repos as a check run instead of a commit status.
+	ChecksClient vcs.ChecksClient
 }
 
 // ensure DefaultCommitStatusUpdater implements runtime.StatusUpdater interface
@@ -101,9 +112,73 @@ func (d *DefaultCommitStatusUpdater) UpdateProject(ctx command.ProjectContext, c
 			descripWords = genProjectStatusDescription(cmdName.String(), "succeeded.")
 		}
 	}
+	if d.ChecksClient != nil && ctx.BaseRepo.VCSHost.Type == models.Github {
+		return d.ChecksClient.UpdateCheckRun(ctx.Log, ctx.BaseRepo, ctx.Pull, projectCheckRun(ctx, cmdName, status, src, descripWords, url, result))
+	}
+	if d.Batcher != nil {
+		d.Batcher.UpdateStatus(ctx.Log, ctx.BaseRepo, ctx.Pull, status, src, descripWords, url)
+		return nil
+	}
 	return d.Client.UpdateStatus(ctx.Log, ctx.BaseRepo, ctx.Pull, status, src, descripWords, url)
 }
 
+// projectCheckRun returns the check run reporting the status of the project
+// of ctx. It contains the output of the command, annotations for the failed
+// policies and an action to re-plan the project.
+func projectCheckRun(ctx command.ProjectContext, cmdName command.Name, status models.CommitStatus, name string, title string, url string, result *command.ProjectResult) vcs.CheckRunOptions {
+	opts := vcs.CheckRunOptions{
+		Name:       name,
+		Status:     status,
+		Title:      title,
+		Summary:    title,
+		DetailsURL: url,
+		ExternalID: ctx.RePlanCmd,
+	}
+	if ctx.RePlanCmd != "" {
+		opts.Actions = []vcs.CheckRunAction{{
+			Label:       "Re-plan",
+			Description: "Run the plan of this project again.",
+			Identifier:  CheckRunReplanAction,
+		}}
+	}
+	if result == nil {
+		return opts
+	}
+	switch {
+	case result.Error != nil:
+		opts.Summary = fmt.Sprintf("```\n%s\n```", result.Error)
+	case result.Failure != "":
+		opts.Summary = result.Failure
+	case result.PlanSuccess != nil:
+		opts.Summary = fmt.Sprintf("```diff\n%s\n```", strings.TrimSpace(result.PlanSuccess.TerraformOutput))
+	case result.ApplySuccess != "":
+		opts.Summary = fmt.Sprintf("```diff\n%s\n```", strings.TrimSpace(result.ApplySuccess))
+	}
+	if result.PolicyCheckResults != nil {
+		for _, policySet := range result.PolicyCheckResults.PolicySetResults {
+			if policySet.Passed {
+				continue
+			}
+			if len(policySet.Failures) == 0 {
+				opts.Annotations = append(opts.Annotations, vcs.CheckRunAnnotation{
+					Path:    ctx.RepoRelDir,
+					Title:   fmt.Sprintf("Policy set %s failed", policySet.PolicySetName),
+					Message: policySet.PolicyOutput,
+				})
+				continue
+			}
+			for _, failure := range policySet.Failures {
+				opts.Annotations = append(opts.Annotations, vcs.CheckRunAnnotation{
+					Path:    ctx.RepoRelDir,
+					Title:   fmt.Sprintf("Policy set %s failed", policySet.PolicySetName),
+					Message: failure.Msg,
+				})
+			}
+		}
+	}
+	return opts
+}
+
 func genProjectStatusDescription(cmdName, description string) string {
 	return fmt.Sprintf("%s %s", cases.Title(language.English).String(cmdName), description)
 }
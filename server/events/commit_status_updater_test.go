@@ -21,6 +21,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
@@ -264,3 +265,58 @@ func TestDefaultCommitStatusUpdater_UpdateProjectCustomStatusName(t *testing.T)
 	client.VerifyWasCalledOnce().UpdateStatus(Any[logging.SimpleLogging](), Eq(models.Repo{}), Eq(models.PullRequest{}),
 		Eq(models.SuccessCommitStatus), Eq("custom/apply: ./default"), Eq("Apply succeeded."), Eq("url"))
 }
+
+// checkRunRecorder is a vcs.ChecksClient that records the check runs it's
+// asked to update.
+type checkRunRecorder struct {
+	checkRuns []vcs.CheckRunOptions
+}
+
+func (c *checkRunRecorder) UpdateCheckRun(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest, opts vcs.CheckRunOptions) error {
+	c.checkRuns = append(c.checkRuns, opts)
+	return nil
+}
+
+func TestDefaultCommitStatusUpdater_UpdateProjectCheckRun(t *testing.T) {
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClient()
+	checks := &checkRunRecorder{}
+	s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis", ChecksClient: checks}
+	ctx := command.ProjectContext{
+		BaseRepo:   models.Repo{VCSHost: models.VCSHost{Type: models.Github}},
+		RepoRelDir: "dir",
+		Workspace:  "default",
+		RePlanCmd:  "atlantis plan -d dir",
+	}
+	err := s.UpdateProject(ctx, command.Plan, models.FailedCommitStatus, "url", &command.ProjectResult{
+		PlanSuccess: &models.PlanSuccess{
+			TerraformOutput: "Plan: 1 to add, 0 to change, 0 to destroy.\n",
+		},
+		PolicyCheckResults: &models.PolicyCheckResults{
+			PolicySetResults: []models.PolicySetResult{
+				{PolicySetName: "passing", Passed: true},
+				{PolicySetName: "tags", Failures: []models.PolicyFailure{{Msg: "missing owner tag"}}},
+			},
+		},
+	})
+	Ok(t, err)
+	client.VerifyWasCalled(Never()).UpdateStatus(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[models.CommitStatus](), Any[string](), Any[string](), Any[string]())
+	Equals(t, []vcs.CheckRunOptions{{
+		Name:        "atlantis/plan: dir/default",
+		Status:      models.FailedCommitStatus,
+		Title:       "Plan failed.",
+		Summary:     "```diff\nPlan: 1 to add, 0 to change, 0 to destroy.\n```",
+		DetailsURL:  "url",
+		ExternalID:  "atlantis plan -d dir",
+		Annotations: []vcs.CheckRunAnnotation{{Path: "dir", Title: "Policy set tags failed", Message: "missing owner tag"}},
+		Actions:     []vcs.CheckRunAction{{Label: "Re-plan", Description: "Run the plan of this project again.", Identifier: "replan"}},
+	}}, checks.checkRuns)
+
+	// Check runs are only supported by GitHub.
+	ctx.BaseRepo.VCSHost.Type = models.Gitlab
+	Ok(t, s.UpdateProject(ctx, command.Plan, models.PendingCommitStatus, "url", nil))
+	Equals(t, 1, len(checks.checkRuns))
+	client.VerifyWasCalledOnce().UpdateStatus(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Eq(models.PendingCommitStatus), Eq("atlantis/plan: dir/default"), Eq("Plan in progress..."), Eq("url"))
+}
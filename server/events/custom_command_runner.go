@@ -0,0 +1,47 @@
+package events
+
+import (
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+func NewCustomCommandRunner(
+	pullUpdater *PullUpdater,
+	prjCmdBuilder ProjectCustomCommandBuilder,
+	prjCmdRunner ProjectCustomCommandRunner,
+	silenceNoProjects bool,
+) *CustomCommandRunner {
+	return &CustomCommandRunner{
+		pullUpdater:       pullUpdater,
+		prjCmdBuilder:     prjCmdBuilder,
+		prjCmdRunner:      prjCmdRunner,
+		silenceNoProjects: silenceNoProjects,
+	}
+}
+
+// CustomCommandRunner runs the custom commands defined in the server-side
+// repo config, ex. atlantis docs. The name of the custom command is the sub
+// command of the comment.
+type CustomCommandRunner struct {
+	pullUpdater   *PullUpdater
+	prjCmdBuilder ProjectCustomCommandBuilder
+	prjCmdRunner  ProjectCustomCommandRunner
+	// silenceNoProjects is whether to skip commenting if there are no
+	// projects to run the command in.
+	silenceNoProjects bool
+}
+
+func (c *CustomCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	projectCmds, err := c.prjCmdBuilder.BuildCustomCommands(ctx, cmd)
+	if err != nil {
+		c.pullUpdater.updatePull(ctx, cmd, command.Result{Error: err})
+		return
+	}
+
+	if len(projectCmds) == 0 && c.silenceNoProjects {
+		ctx.Log.Info("determined there was no project to run %s in", cmd.SubName)
+		return
+	}
+
+	result := runProjectCmds(projectCmds, c.prjCmdRunner.Custom)
+	c.pullUpdater.updatePull(ctx, cmd, result)
+}
@@ -1,6 +1,9 @@
 package events
 
 import (
+	"sort"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -12,6 +15,7 @@ import (
 type DeleteLockCommand interface {
 	DeleteLock(logger logging.SimpleLogging, id string) (*models.ProjectLock, error)
 	DeleteLocksByPull(logger logging.SimpleLogging, repoFullName string, pullNum int) (int, error)
+	DeleteLocksByProject(logger logging.SimpleLogging, repoFullName string, pullNum int, repoRelDir string, workspace string, projectName string) ([]models.ProjectLock, error)
 }
 
 // DefaultDeleteLockCommand deletes a specific lock after a request from the LocksController.
@@ -20,6 +24,12 @@ type DefaultDeleteLockCommand struct {
 	WorkingDir       WorkingDir
 	WorkingDirLocker WorkingDirLocker
 	Backend          locking.Backend
+	// EventHooks, if set, runs the hooks for lock_released for every lock
+	// that's deleted.
+	EventHooks WorkflowHookEventRunner
+	// PlanStore, if set, holds the stored plans that are deleted along with
+	// the plans of the locks.
+	PlanStore PlanStore
 }
 
 // DeleteLock handles deleting the lock at id
@@ -32,11 +42,12 @@ func (l *DefaultDeleteLockCommand) DeleteLock(logger logging.SimpleLogging, id s
 		return nil, nil
 	}
 
-	removeErr := l.WorkingDir.DeletePlan(logger, lock.Pull.BaseRepo, lock.Pull, lock.Workspace, lock.Project.Path, lock.Project.ProjectName)
+	removeErr := l.deletePlan(logger, *lock)
 	if removeErr != nil {
 		logger.Warn("Failed to delete plan: %s", removeErr)
 		return nil, removeErr
 	}
+	l.runLockReleasedHooks(logger, *lock)
 
 	return lock, nil
 }
@@ -56,12 +67,76 @@ func (l *DefaultDeleteLockCommand) DeleteLocksByPull(logger logging.SimpleLoggin
 	for i := 0; i < numLocks; i++ {
 		lock := locks[i]
 
-		err := l.WorkingDir.DeletePlan(logger, lock.Pull.BaseRepo, lock.Pull, lock.Workspace, lock.Project.Path, lock.Project.ProjectName)
+		err := l.deletePlan(logger, lock)
 		if err != nil {
 			logger.Warn("Failed to delete plan: %s", err)
 			return numLocks, err
 		}
+		l.runLockReleasedHooks(logger, lock)
 	}
 
 	return numLocks, nil
 }
+
+// DeleteLocksByProject handles deleting the locks of the pull request's
+// projects in repoRelDir and workspace named projectName, and returns the
+// deleted locks. Empty args match any project.
+func (l *DefaultDeleteLockCommand) DeleteLocksByProject(logger logging.SimpleLogging, repoFullName string, pullNum int, repoRelDir string, workspace string, projectName string) ([]models.ProjectLock, error) {
+	locks, err := l.Locker.List()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for id, lock := range locks {
+		if lock.Pull.BaseRepo.FullName != repoFullName || lock.Pull.Num != pullNum {
+			continue
+		}
+		if (repoRelDir != "" && lock.Project.Path != repoRelDir) ||
+			(workspace != "" && lock.Workspace != workspace) ||
+			(projectName != "" && lock.Project.ProjectName != projectName) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var deleted []models.ProjectLock
+	for _, id := range ids {
+		lock, err := l.DeleteLock(logger, id)
+		if err != nil {
+			return deleted, err
+		}
+		if lock != nil {
+			deleted = append(deleted, *lock)
+		}
+	}
+	if len(deleted) == 0 {
+		logger.Debug("No locks found for repo '%v', pull request: %v, dir: %q, workspace: %q, project: %q", repoFullName, pullNum, repoRelDir, workspace, projectName)
+	}
+	return deleted, nil
+}
+
+// deletePlan deletes the plan of the project of lock from its working dir and
+// the plan store.
+func (l *DefaultDeleteLockCommand) deletePlan(logger logging.SimpleLogging, lock models.ProjectLock) error {
+	if err := l.WorkingDir.DeletePlan(logger, lock.Pull.BaseRepo, lock.Pull, lock.Workspace, lock.Project.Path, lock.Project.ProjectName); err != nil {
+		return err
+	}
+	if l.PlanStore != nil {
+		return l.PlanStore.DeleteForProject(lock.Pull, lock.Workspace, lock.Project.Path, lock.Project.ProjectName)
+	}
+	return nil
+}
+
+func (l *DefaultDeleteLockCommand) runLockReleasedHooks(logger logging.SimpleLogging, lock models.ProjectLock) {
+	if l.EventHooks == nil {
+		return
+	}
+	hookCtx := newEventHookContext(logger, valid.LockReleasedHookEvent, lock.Pull, lock.User)
+	hookCtx.ProjectName = lock.Project.ProjectName
+	hookCtx.RepoRelDir = lock.Project.Path
+	hookCtx.Workspace = lock.Workspace
+	if err := l.EventHooks.RunEventHooks(hookCtx); err != nil {
+		logger.Warn("unable to run %s hooks: %s", valid.LockReleasedHookEvent, err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/runatlantis/atlantis/server/core/db"
 	lockmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/mocks"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
@@ -198,3 +199,106 @@ func TestDeleteLocksByPull_MultipleSuccess(t *testing.T) {
 	workingDir.VerifyWasCalled(Once()).DeletePlan(logger, pull.BaseRepo, pull, workspace, path1, projectName)
 	workingDir.VerifyWasCalled(Once()).DeletePlan(logger, pull.BaseRepo, pull, workspace, path2, projectName)
 }
+
+func TestDeleteLock_RunsEventHooks(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+	l := lockmocks.NewMockLocker()
+	eventHooks := mocks.NewMockWorkflowHookEventRunner()
+	lock := models.ProjectLock{
+		Pull:      models.PullRequest{Num: 2, BaseRepo: models.Repo{FullName: "owner/repo"}},
+		User:      models.User{Username: "alice"},
+		Workspace: "default",
+		Project:   models.Project{ProjectName: "staging", Path: "staging", RepoFullName: "owner/repo"},
+	}
+	When(l.Unlock("id")).ThenReturn(&lock, nil)
+	dlc := events.DefaultDeleteLockCommand{
+		Locker:     l,
+		WorkingDir: events.NewMockWorkingDir(),
+		EventHooks: eventHooks,
+	}
+	_, err := dlc.DeleteLock(logger, "id")
+	Ok(t, err)
+	hookCtx := eventHooks.VerifyWasCalledOnce().RunEventHooks(Any[models.WorkflowHookCommandContext]()).GetCapturedArguments()
+	Equals(t, "lock_released", hookCtx.Event)
+	Equals(t, lock.Pull, hookCtx.Pull)
+	Equals(t, lock.User, hookCtx.User)
+	Equals(t, "staging", hookCtx.ProjectName)
+	Equals(t, "default", hookCtx.Workspace)
+}
+
+func TestDeleteLocksByProject_Success(t *testing.T) {
+	t.Log("Only the locks of the matching projects of the pull request are deleted")
+	logger := logging.NewNoopLogger(t)
+	repoName := "reponame"
+	pullNum := 2
+	RegisterMockTestingT(t)
+	l := lockmocks.NewMockLocker()
+	workingDir := events.NewMockWorkingDir()
+	pull := models.PullRequest{
+		BaseRepo: models.Repo{FullName: repoName},
+		Num:      pullNum,
+	}
+	otherPull := models.PullRequest{
+		BaseRepo: models.Repo{FullName: repoName},
+		Num:      pullNum + 1,
+	}
+	vpcStaging := models.ProjectLock{
+		Pull:      pull,
+		Workspace: "staging",
+		Project:   models.Project{Path: "vpc", RepoFullName: repoName, ProjectName: "vpc-staging"},
+	}
+	vpcProd := models.ProjectLock{
+		Pull:      pull,
+		Workspace: "prod",
+		Project:   models.Project{Path: "vpc", RepoFullName: repoName, ProjectName: "vpc-prod"},
+	}
+	otherPullVpc := models.ProjectLock{
+		Pull:      otherPull,
+		Workspace: "staging",
+		Project:   models.Project{Path: "vpc", RepoFullName: repoName, ProjectName: "vpc-staging"},
+	}
+	When(l.List()).ThenReturn(map[string]models.ProjectLock{
+		"vpc-staging":       vpcStaging,
+		"vpc-prod":          vpcProd,
+		"other-vpc-staging": otherPullVpc,
+	}, nil)
+	When(l.Unlock("vpc-staging")).ThenReturn(&vpcStaging, nil)
+	dlc := events.DefaultDeleteLockCommand{
+		Locker:     l,
+		WorkingDir: workingDir,
+	}
+	locks, err := dlc.DeleteLocksByProject(logger, repoName, pullNum, "vpc", "staging", "")
+	Ok(t, err)
+	Equals(t, []models.ProjectLock{vpcStaging}, locks)
+	l.VerifyWasCalledOnce().Unlock("vpc-staging")
+	l.VerifyWasCalled(Never()).Unlock("vpc-prod")
+	l.VerifyWasCalled(Never()).Unlock("other-vpc-staging")
+	workingDir.VerifyWasCalledOnce().DeletePlan(Any[logging.SimpleLogging](), Eq(pull.BaseRepo), Eq(pull), Eq("staging"),
+		Eq("vpc"), Eq("vpc-staging"))
+}
+
+func TestDeleteLocksByProject_None(t *testing.T) {
+	t.Log("If no lock matches the project nothing is deleted")
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+	l := lockmocks.NewMockLocker()
+	workingDir := events.NewMockWorkingDir()
+	When(l.List()).ThenReturn(map[string]models.ProjectLock{
+		"vpc": {
+			Pull:      models.PullRequest{BaseRepo: models.Repo{FullName: "reponame"}, Num: 2},
+			Workspace: "default",
+			Project:   models.Project{Path: "vpc", RepoFullName: "reponame", ProjectName: "vpc"},
+		},
+	}, nil)
+	dlc := events.DefaultDeleteLockCommand{
+		Locker:     l,
+		WorkingDir: workingDir,
+	}
+	locks, err := dlc.DeleteLocksByProject(logger, "reponame", 2, "", "", "network")
+	Ok(t, err)
+	Equals(t, 0, len(locks))
+	l.VerifyWasCalled(Never()).Unlock(Any[string]())
+	workingDir.VerifyWasCalled(Never()).DeletePlan(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[string](), Any[string](), Any[string]())
+}
@@ -0,0 +1,188 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// ProjectDestroyCommandRunner runs the plans and applies of destroy.
+type ProjectDestroyCommandRunner interface {
+	ProjectPlanCommandRunner
+	ProjectApplyCommandRunner
+}
+
+func NewDestroyCommandRunner(
+	vcsClient vcs.Client,
+	pullUpdater *PullUpdater,
+	dbUpdater *DBUpdater,
+	pullReqStatusFetcher vcs.PullReqStatusFetcher,
+	prjCmdBuilder ProjectDestroyCommandBuilder,
+	prjCmdRunner ProjectDestroyCommandRunner,
+	planCommandRunner *PlanCommandRunner,
+	applyCommandRunner *ApplyCommandRunner,
+	destroyApprovers *UserAllowlist,
+) *DestroyCommandRunner {
+	return &DestroyCommandRunner{
+		vcsClient:            vcsClient,
+		pullUpdater:          pullUpdater,
+		dbUpdater:            dbUpdater,
+		pullReqStatusFetcher: pullReqStatusFetcher,
+		prjCmdBuilder:        prjCmdBuilder,
+		prjCmdRunner:         prjCmdRunner,
+		planCommandRunner:    planCommandRunner,
+		applyCommandRunner:   applyCommandRunner,
+		destroyApprovers:     destroyApprovers,
+	}
+}
+
+// DestroyCommandRunner runs atlantis destroy. Without --confirm, it plans the
+// destruction of a single project. The plan is only applied once a second
+// comment confirms it with --confirm, and regular applies refuse to apply it.
+type DestroyCommandRunner struct {
+	vcsClient            vcs.Client
+	pullUpdater          *PullUpdater
+	dbUpdater            *DBUpdater
+	pullReqStatusFetcher vcs.PullReqStatusFetcher
+	prjCmdBuilder        ProjectDestroyCommandBuilder
+	prjCmdRunner         ProjectDestroyCommandRunner
+	// planCommandRunner and applyCommandRunner update the commit statuses and
+	// apply the same restrictions as for regular plans and applies.
+	planCommandRunner  *PlanCommandRunner
+	applyCommandRunner *ApplyCommandRunner
+	// destroyApprovers are the users that can confirm destroys. If empty,
+	// anyone that can apply can.
+	destroyApprovers *UserAllowlist
+}
+
+func (d *DestroyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	if cmd.Confirm {
+		d.runConfirm(ctx, cmd)
+	} else {
+		d.runPlan(ctx, cmd)
+	}
+}
+
+// runPlan plans the destruction of the project of cmd.
+func (d *DestroyCommandRunner) runPlan(ctx *command.Context, cmd *CommentCommand) {
+	projectCmds, err := d.prjCmdBuilder.BuildDestroyCommands(ctx, cmd)
+	if err != nil {
+		d.pullUpdater.updatePull(ctx, cmd, command.Result{Error: err})
+		return
+	}
+	if len(projectCmds) != 1 {
+		d.pullUpdater.updatePull(ctx, cmd, command.Result{
+			Failure: fmt.Sprintf("Destroy must be run for a single project but %d projects matched, use the -p flag.", len(projectCmds)),
+		})
+		return
+	}
+
+	result := runProjectCmds(projectCmds, d.prjCmdRunner.Plan)
+	// The results are stored as destroy plans so that only a confirmation
+	// applies them.
+	for i := range result.ProjectResults {
+		result.ProjectResults[i].Command = command.Destroy
+	}
+	d.pullUpdater.updatePull(ctx, cmd, result)
+
+	pullStatus, err := d.dbUpdater.updateDB(ctx, ctx.Pull, result.ProjectResults)
+	if err != nil {
+		ctx.Log.Err("writing results: %s", err)
+		return
+	}
+	d.planCommandRunner.updateCommitStatus(ctx, pullStatus, command.Plan)
+	d.planCommandRunner.updateCommitStatus(ctx, pullStatus, command.Apply)
+}
+
+// runConfirm applies the destroy plans of the pull request.
+func (d *DestroyCommandRunner) runConfirm(ctx *command.Context, cmd *CommentCommand) {
+	// The output is the one of an apply.
+	applyCmd := *cmd
+	applyCmd.Name = command.Apply
+
+	locked, err := d.applyCommandRunner.IsLocked()
+	if err != nil {
+		ctx.Log.Warn("checking global apply lock: %s", err)
+	}
+	if locked {
+		ctx.Log.Info("ignoring destroy confirmation since apply disabled globally")
+		d.pullUpdater.updatePull(ctx, &applyCmd, command.Result{Failure: "Running `atlantis apply` is disabled."})
+		return
+	}
+	if msg := maintenanceWindowMessage(d.applyCommandRunner.MaintenanceWindows, ctx.Pull.BaseRepo.ID(), time.Now()); msg != "" {
+		ctx.Log.Info("ignoring destroy confirmation since it's during a maintenance window")
+		d.pullUpdater.updatePull(ctx, &applyCmd, command.Result{Failure: msg})
+		return
+	}
+
+	if !d.destroyApprovers.IsEmpty() {
+		isApprover, err := d.destroyApprovers.IsAllowed(ctx.Log, ctx.Pull.BaseRepo, ctx.User)
+		if err != nil {
+			d.pullUpdater.updatePull(ctx, &applyCmd, command.Result{Error: errors.Wrap(err, "checking if user is a destroy approver")})
+			return
+		}
+		if !isApprover {
+			d.pullUpdater.updatePull(ctx, &applyCmd, command.Result{
+				Failure: fmt.Sprintf("User @%s is not allowed to confirm destroys, only the users of --destroy-approvers are.", ctx.User.Username),
+			})
+			return
+		}
+	}
+
+	// See ApplyCommandRunner.Run.
+	ctx.PullRequestStatus, err = d.pullReqStatusFetcher.FetchPullStatus(ctx.Log, ctx.Pull)
+	if err != nil {
+		ctx.Log.Warn("unable to get pull request status: %s. Continuing with mergeable and approved assumed false", err)
+	}
+
+	projectCmds, err := d.prjCmdBuilder.BuildDestroyCommands(ctx, cmd)
+	if err != nil {
+		d.pullUpdater.updatePull(ctx, &applyCmd, command.Result{Error: err})
+		return
+	}
+	if len(projectCmds) == 0 {
+		d.pullUpdater.updatePull(ctx, &applyCmd, command.Result{
+			Failure: "There are no destroy plans to confirm, run `atlantis destroy` for the project first.",
+		})
+		return
+	}
+	failure, err := applyApproversFailure(d.applyCommandRunner.ApplyApprovers, d.applyCommandRunner.UserMemberships, d.vcsClient, ctx)
+	if err != nil {
+		d.pullUpdater.updatePull(ctx, &applyCmd, command.Result{Error: err})
+		return
+	}
+	if failure != "" {
+		d.pullUpdater.updatePull(ctx, &applyCmd, command.Result{Failure: failure})
+		return
+	}
+
+	result := runProjectCmds(projectCmds, d.prjCmdRunner.Apply)
+	d.pullUpdater.updatePull(ctx, &applyCmd, result)
+
+	pullStatus, err := d.dbUpdater.updateDB(ctx, ctx.Pull, result.ProjectResults)
+	if err != nil {
+		ctx.Log.Err("writing results: %s", err)
+		return
+	}
+	d.applyCommandRunner.updateCommitStatus(ctx, pullStatus)
+}
+
+// destroyPlanned returns true if the plan of the project of projCtx was made
+// by atlantis destroy and hasn't been applied yet.
+func destroyPlanned(pullStatus *models.PullStatus, projCtx command.ProjectContext) bool {
+	if pullStatus == nil {
+		return false
+	}
+	for _, project := range pullStatus.Projects {
+		if project.RepoRelDir == projCtx.RepoRelDir &&
+			project.Workspace == projCtx.Workspace &&
+			project.ProjectName == projCtx.ProjectName {
+			return project.Status == models.PlannedDestroyPlanStatus
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package events_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func setupDestroy(t *testing.T, approvers string) (*vcsmocks.MockClient, *events.DestroyCommandRunner, *command.Context) {
+	vcsClient := setup(t)
+	destroyApprovers, err := events.NewUserAllowlist(approvers)
+	Ok(t, err)
+	destroyRunner := events.NewDestroyCommandRunner(
+		vcsClient,
+		pullUpdater,
+		dbUpdater,
+		pullReqStatusFetcher,
+		projectCommandBuilder,
+		projectCommandRunner,
+		planCommandRunner,
+		applyCommandRunner,
+		destroyApprovers,
+	)
+	ctx := &command.Context{
+		User:    testdata.User,
+		Log:     logging.NewNoopLogger(t),
+		Pull:    models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num},
+		Trigger: command.CommentTrigger,
+	}
+	return vcsClient, destroyRunner, ctx
+}
+
+func capturedComment(t *testing.T, vcsClient *vcsmocks.MockClient) string {
+	_, _, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]()).GetCapturedArguments()
+	return comment
+}
+
+func TestDestroyCommandRunner_PlanAndConfirm(t *testing.T) {
+	vcsClient, destroyRunner, ctx := setupDestroy(t, "")
+	projectCmd := command.ProjectContext{ProjectName: "network", RepoRelDir: "network", Workspace: "default"}
+
+	plan := &events.CommentCommand{Name: command.Destroy, ProjectName: "network"}
+	When(projectCommandBuilder.BuildDestroyCommands(ctx, plan)).ThenReturn([]command.ProjectContext{projectCmd}, nil)
+	When(projectCommandRunner.Plan(projectCmd)).ThenReturn(command.ProjectResult{
+		Command:     command.Plan,
+		ProjectName: "network",
+		RepoRelDir:  "network",
+		Workspace:   "default",
+		PlanSuccess: &models.PlanSuccess{TerraformOutput: "Plan: 0 to add, 0 to change, 3 to destroy."},
+	})
+	destroyRunner.Run(ctx, plan)
+
+	pullStatus, err := dbUpdater.Backend.GetPullStatus(ctx.Pull)
+	Ok(t, err)
+	Equals(t, models.PlannedDestroyPlanStatus, pullStatus.Projects[0].Status)
+	comment := capturedComment(t, vcsClient)
+	Assert(t, strings.Contains(comment, "Ran Plan to **destroy** project: `network`"), "expected the destroy plan, got %q", comment)
+
+	// A regular apply refuses to apply the destroy plan.
+	ctx.PullStatus = pullStatus
+	apply := &events.CommentCommand{Name: command.Apply}
+	When(projectCommandBuilder.BuildApplyCommands(ctx, apply)).ThenReturn([]command.ProjectContext{projectCmd}, nil)
+	applyCommandRunner.Run(ctx, apply)
+	projectCommandRunner.VerifyWasCalled(Never()).Apply(Any[command.ProjectContext]())
+
+	confirm := &events.CommentCommand{Name: command.Destroy, Confirm: true}
+	When(projectCommandBuilder.BuildDestroyCommands(ctx, confirm)).ThenReturn([]command.ProjectContext{projectCmd}, nil)
+	When(projectCommandRunner.Apply(projectCmd)).ThenReturn(command.ProjectResult{
+		Command:      command.Apply,
+		ProjectName:  "network",
+		RepoRelDir:   "network",
+		Workspace:    "default",
+		ApplySuccess: "Destroy complete! Resources: 3 destroyed.",
+	})
+	destroyRunner.Run(ctx, confirm)
+
+	projectCommandRunner.VerifyWasCalledOnce().Apply(projectCmd)
+	pullStatus, err = dbUpdater.Backend.GetPullStatus(ctx.Pull)
+	Ok(t, err)
+	Equals(t, models.AppliedPlanStatus, pullStatus.Projects[0].Status)
+}
+
+func TestDestroyCommandRunner_MultipleProjects(t *testing.T) {
+	vcsClient, destroyRunner, ctx := setupDestroy(t, "")
+	cmd := &events.CommentCommand{Name: command.Destroy, RepoRelDir: "."}
+	When(projectCommandBuilder.BuildDestroyCommands(ctx, cmd)).ThenReturn([]command.ProjectContext{
+		{ProjectName: "network", RepoRelDir: ".", Workspace: "default"},
+		{ProjectName: "dns", RepoRelDir: ".", Workspace: "default"},
+	}, nil)
+
+	destroyRunner.Run(ctx, cmd)
+
+	projectCommandRunner.VerifyWasCalled(Never()).Plan(Any[command.ProjectContext]())
+	comment := capturedComment(t, vcsClient)
+	Assert(t, strings.Contains(comment, "Destroy must be run for a single project but 2 projects matched"), "expected a failure, got %q", comment)
+}
+
+func TestDestroyCommandRunner_ConfirmNotApprover(t *testing.T) {
+	vcsClient, destroyRunner, ctx := setupDestroy(t, "someone-else")
+
+	destroyRunner.Run(ctx, &events.CommentCommand{Name: command.Destroy, Confirm: true})
+
+	projectCommandRunner.VerifyWasCalled(Never()).Apply(Any[command.ProjectContext]())
+	comment := capturedComment(t, vcsClient)
+	Assert(t, strings.Contains(comment, "is not allowed to confirm destroys"), "expected a failure, got %q", comment)
+}
@@ -0,0 +1,165 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	tally "github.com/uber-go/tally/v4"
+)
+
+//go:generate pegomock generate --package mocks -o mocks/mock_destroy_on_close_runner.go DestroyOnCloseRunner
+
+// DestroyOnCloseRunner destroys the workspaces of a closed pull request.
+type DestroyOnCloseRunner interface {
+	// DestroyOnClose destroys the projects in pullStatus that were applied
+	// and are configured to be destroyed when the pull request is closed,
+	// ex. preview environments. headRepo is the repo the pull request's
+	// branch is in.
+	DestroyOnClose(logger logging.SimpleLogging, repo models.Repo, headRepo models.Repo, pull models.PullRequest, pullStatus models.PullStatus) error
+}
+
+// DefaultDestroyOnCloseRunner plans a destroy for each project that was
+// applied and has destroy_on_close set. If the destroy is auto-applied, it's
+// applied without checking the project's apply requirements since the pull
+// request is already closed, and the results are commented like an apply.
+// Otherwise the destroy plan is commented so the resources that are left
+// behind can be cleaned up by hand. Destroys of fork pull requests are only
+// applied if a maintainer approved their latest commit, since it could have
+// been pushed after the last reviewed apply.
+type DefaultDestroyOnCloseRunner struct {
+	ProjectCommandBuilder     ProjectCommandBuilder
+	ProjectPlanCommandRunner  ProjectPlanCommandRunner
+	ProjectApplyCommandRunner ProjectApplyCommandRunner
+	PullUpdater               *PullUpdater
+	Scope                     tally.Scope
+	VCSClient                 vcs.Client
+}
+
+// DestroyOnClose destroys the projects in pullStatus that were applied and
+// are configured to be destroyed when the pull request is closed.
+func (d *DefaultDestroyOnCloseRunner) DestroyOnClose(logger logging.SimpleLogging, repo models.Repo, headRepo models.Repo, pull models.PullRequest, pullStatus models.PullStatus) error {
+	ctx := &command.Context{
+		User:       models.User{Username: pull.Author},
+		Log:        logger,
+		Scope:      d.Scope.SubScope("destroy_on_close"),
+		Pull:       pull,
+		HeadRepo:   headRepo,
+		PullStatus: &pullStatus,
+	}
+	unapprovedFork := headRepo.Owner != repo.Owner && !pullStatus.IsForkApproved(pull.HeadCommit)
+	if unapprovedFork {
+		logger.Info("not applying destroys since the latest commit of the fork pull request isn't approved")
+	}
+
+	var applied, planned []command.ProjectResult
+	for _, project := range pullStatus.Projects {
+		if project.Status != models.AppliedPlanStatus && project.Status != models.ErroredApplyStatus {
+			continue
+		}
+		projectApplied, projectPlanned := d.destroy(ctx, project, !unapprovedFork)
+		applied = append(applied, projectApplied...)
+		planned = append(planned, projectPlanned...)
+	}
+
+	if len(applied) > 0 {
+		d.PullUpdater.updatePull(ctx, &CommentCommand{Name: command.Apply}, command.Result{ProjectResults: applied})
+	}
+	if len(planned) > 0 {
+		if err := d.VCSClient.CreateComment(logger, repo, pull.Num, destroyPlanComment(planned, unapprovedFork), command.Plan.String()); err != nil {
+			return errors.Wrap(err, "commenting destroy plans")
+		}
+	}
+	return nil
+}
+
+// destroy plans a destroy for project if it has destroy_on_close set and
+// applies it if it's auto-applied and autoApply is true. It returns the results to comment as an
+// apply, which includes errors, and the destroy plans that weren't applied.
+func (d *DefaultDestroyOnCloseRunner) destroy(ctx *command.Context, project models.ProjectStatus, autoApply bool) (applied []command.ProjectResult, planned []command.ProjectResult) {
+	cmds, err := d.ProjectCommandBuilder.BuildPlanCommands(ctx, &CommentCommand{
+		Name:        command.Plan,
+		ProjectName: project.ProjectName,
+		RepoRelDir:  project.RepoRelDir,
+		Workspace:   project.Workspace,
+		Flags:       []string{"-destroy"},
+	})
+	if err != nil {
+		return []command.ProjectResult{{
+			Command:     command.Plan,
+			ProjectName: project.ProjectName,
+			RepoRelDir:  project.RepoRelDir,
+			Workspace:   project.Workspace,
+			Error:       errors.Wrap(err, "building destroy plan command"),
+		}}, nil
+	}
+
+	for _, cmd := range cmds {
+		if cmd.CommandName != command.Plan || !cmd.DestroyOnClose {
+			continue
+		}
+		// Config validation prevents this but the default workspace is
+		// shared with the main branch so we never destroy it.
+		if cmd.Workspace == DefaultWorkspace {
+			ctx.Log.Warn("not destroying dir '%s' since it's in the default workspace", cmd.RepoRelDir)
+			continue
+		}
+		ctx.Log.Info("planning destroy of dir '%s' workspace '%s'", cmd.RepoRelDir, cmd.Workspace)
+		planResult := d.ProjectPlanCommandRunner.Plan(cmd)
+		if planResult.Error != nil || planResult.Failure != "" {
+			applied = append(applied, planResult)
+			continue
+		}
+		if !cmd.AutoApplyDestroyOnClose || !autoApply {
+			planned = append(planned, planResult)
+			continue
+		}
+
+		applyCmds, err := d.ProjectCommandBuilder.BuildApplyCommands(ctx, &CommentCommand{
+			Name:        command.Apply,
+			ProjectName: cmd.ProjectName,
+			RepoRelDir:  cmd.RepoRelDir,
+			Workspace:   cmd.Workspace,
+		})
+		if err != nil {
+			planResult.Command = command.Apply
+			planResult.PlanSuccess = nil
+			planResult.Error = errors.Wrap(err, "building destroy apply command")
+			applied = append(applied, planResult)
+			continue
+		}
+		for _, applyCmd := range applyCmds {
+			applyCmd.ApplyRequirements = nil
+			applied = append(applied, d.ProjectApplyCommandRunner.Apply(applyCmd))
+		}
+	}
+	return applied, planned
+}
+
+// destroyPlanComment returns the markdown comment for destroy plans that
+// weren't applied. unapprovedFork is true if they weren't applied because the
+// pull request is from a fork and its latest commit isn't approved.
+func destroyPlanComment(results []command.ProjectResult, unapprovedFork bool) string {
+	var b strings.Builder
+	b.WriteString("Atlantis planned a destroy of the following projects when this pull request was closed but didn't apply it. " +
+		"Their resources still exist and need to be destroyed by hand.\n")
+	if unapprovedFork {
+		b.WriteString("\nThe destroy wasn't applied since this pull request is from a fork and its latest commit wasn't approved.\n")
+	}
+	for _, result := range results {
+		project := fmt.Sprintf("dir: `%s` workspace: `%s`", result.RepoRelDir, result.Workspace)
+		if result.ProjectName != "" {
+			project = fmt.Sprintf("project: `%s` %s", result.ProjectName, project)
+		}
+		output := ""
+		if result.PlanSuccess != nil {
+			output = strings.TrimSpace(result.PlanSuccess.TerraformOutput)
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n<details><summary>Show Output</summary>\n\n```diff\n%s\n```\n</details>\n", project, output)
+	}
+	return b.String()
+}
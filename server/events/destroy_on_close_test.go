@@ -0,0 +1,213 @@
+package events_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestDefaultDestroyOnCloseRunner_DestroyOnClose(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	vcsClient := vcsmocks.NewMockClient()
+	projectCommandBuilder := mocks.NewMockProjectCommandBuilder()
+	projectCommandRunner := mocks.NewMockProjectCommandRunner()
+	runner := &events.DefaultDestroyOnCloseRunner{
+		ProjectCommandBuilder:     projectCommandBuilder,
+		ProjectPlanCommandRunner:  projectCommandRunner,
+		ProjectApplyCommandRunner: projectCommandRunner,
+		PullUpdater: &events.PullUpdater{
+			VCSClient:        vcsClient,
+			MarkdownRenderer: events.NewMarkdownRenderer(false, false, false, false, false, false, "", "atlantis", false, false),
+		},
+		Scope:     tally.NewTestScope("test", nil),
+		VCSClient: vcsClient,
+	}
+
+	repo := models.Repo{FullName: "org/app", Owner: "org"}
+	pull := models.PullRequest{Num: 1, Author: "author", BaseRepo: repo}
+	pullStatus := models.PullStatus{
+		Pull: pull,
+		Projects: []models.ProjectStatus{
+			{RepoRelDir: "preview", Workspace: "pr-1", Status: models.AppliedPlanStatus},
+			{RepoRelDir: "ephemeral", Workspace: "ephemeral", Status: models.AppliedPlanStatus},
+			{RepoRelDir: "unapplied", Workspace: "ephemeral", Status: models.PlannedPlanStatus},
+		},
+	}
+
+	previewPlan := command.ProjectContext{
+		CommandName:             command.Plan,
+		RepoRelDir:              "preview",
+		Workspace:               "pr-1",
+		DestroyOnClose:          true,
+		AutoApplyDestroyOnClose: true,
+	}
+	ephemeralPlan := command.ProjectContext{
+		CommandName:    command.Plan,
+		RepoRelDir:     "ephemeral",
+		Workspace:      "ephemeral",
+		DestroyOnClose: true,
+	}
+	previewApply := command.ProjectContext{
+		CommandName:       command.Apply,
+		RepoRelDir:        "preview",
+		Workspace:         "pr-1",
+		ApplyRequirements: []string{"approved"},
+	}
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Eq(&events.CommentCommand{
+		Name:       command.Plan,
+		RepoRelDir: "preview",
+		Workspace:  "pr-1",
+		Flags:      []string{"-destroy"},
+	}))).ThenReturn([]command.ProjectContext{previewPlan}, nil)
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Eq(&events.CommentCommand{
+		Name:       command.Plan,
+		RepoRelDir: "ephemeral",
+		Workspace:  "ephemeral",
+		Flags:      []string{"-destroy"},
+	}))).ThenReturn([]command.ProjectContext{ephemeralPlan}, nil)
+	When(projectCommandBuilder.BuildApplyCommands(Any[*command.Context](), Eq(&events.CommentCommand{
+		Name:       command.Apply,
+		RepoRelDir: "preview",
+		Workspace:  "pr-1",
+	}))).ThenReturn([]command.ProjectContext{previewApply}, nil)
+	When(projectCommandRunner.Plan(Eq(previewPlan))).ThenReturn(command.ProjectResult{
+		Command:     command.Plan,
+		RepoRelDir:  "preview",
+		Workspace:   "pr-1",
+		PlanSuccess: &models.PlanSuccess{TerraformOutput: "Plan: 0 to add, 0 to change, 2 to destroy."},
+	})
+	When(projectCommandRunner.Plan(Eq(ephemeralPlan))).ThenReturn(command.ProjectResult{
+		Command:     command.Plan,
+		RepoRelDir:  "ephemeral",
+		Workspace:   "ephemeral",
+		PlanSuccess: &models.PlanSuccess{TerraformOutput: "Plan: 0 to add, 0 to change, 3 to destroy."},
+	})
+	When(projectCommandRunner.Apply(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{
+		Command:      command.Apply,
+		RepoRelDir:   "preview",
+		Workspace:    "pr-1",
+		ApplySuccess: "Destroy complete! Resources: 2 destroyed.",
+	})
+
+	Ok(t, runner.DestroyOnClose(logger, repo, repo, pull, pullStatus))
+
+	// The destroy is applied without the project's apply requirements.
+	applyCtx := projectCommandRunner.VerifyWasCalledOnce().Apply(Any[command.ProjectContext]()).GetCapturedArguments()
+	Equals(t, []string(nil), applyCtx.ApplyRequirements)
+	projectCommandBuilder.VerifyWasCalled(Never()).BuildPlanCommands(Any[*command.Context](), Eq(&events.CommentCommand{
+		Name:       command.Plan,
+		RepoRelDir: "unapplied",
+		Workspace:  "ephemeral",
+		Flags:      []string{"-destroy"},
+	}))
+
+	_, _, _, applyComment, _ := vcsClient.VerifyWasCalledOnce().
+		CreateComment(Any[logging.SimpleLogging](), Eq(repo), Eq(1), Any[string](), Eq("apply")).
+		GetCapturedArguments()
+	Assert(t, strings.Contains(applyComment, "Destroy complete! Resources: 2 destroyed."), "expected apply output in %q", applyComment)
+	_, _, _, planComment, _ := vcsClient.VerifyWasCalledOnce().
+		CreateComment(Any[logging.SimpleLogging](), Eq(repo), Eq(1), Any[string](), Eq("plan")).
+		GetCapturedArguments()
+	Assert(t, strings.Contains(planComment, "### dir: `ephemeral` workspace: `ephemeral`"), "expected ephemeral project in %q", planComment)
+	Assert(t, strings.Contains(planComment, "Plan: 0 to add, 0 to change, 3 to destroy."), "expected plan output in %q", planComment)
+	Assert(t, !strings.Contains(planComment, "pr-1"), "expected no applied project in %q", planComment)
+}
+
+func TestDefaultDestroyOnCloseRunner_DestroyOnCloseFork(t *testing.T) {
+	repo := models.Repo{FullName: "org/app", Owner: "org"}
+	fork := models.Repo{FullName: "someone/app", Owner: "someone"}
+	pull := models.PullRequest{Num: 1, Author: "someone", BaseRepo: repo, HeadCommit: "new"}
+
+	cases := []struct {
+		description string
+		approval    *models.ForkApproval
+		expApplied  bool
+	}{
+		{
+			description: "latest commit approved",
+			approval:    &models.ForkApproval{HeadCommit: "new", Approver: "maintainer"},
+			expApplied:  true,
+		},
+		{
+			description: "commits pushed after the approval",
+			approval:    &models.ForkApproval{HeadCommit: "old", Approver: "maintainer"},
+		},
+		{
+			description: "not approved",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			vcsClient := vcsmocks.NewMockClient()
+			projectCommandBuilder := mocks.NewMockProjectCommandBuilder()
+			projectCommandRunner := mocks.NewMockProjectCommandRunner()
+			runner := &events.DefaultDestroyOnCloseRunner{
+				ProjectCommandBuilder:     projectCommandBuilder,
+				ProjectPlanCommandRunner:  projectCommandRunner,
+				ProjectApplyCommandRunner: projectCommandRunner,
+				PullUpdater: &events.PullUpdater{
+					VCSClient:        vcsClient,
+					MarkdownRenderer: events.NewMarkdownRenderer(false, false, false, false, false, false, "", "atlantis", false, false),
+				},
+				Scope:     tally.NewTestScope("test", nil),
+				VCSClient: vcsClient,
+			}
+			pullStatus := models.PullStatus{
+				Pull:         pull,
+				Projects:     []models.ProjectStatus{{RepoRelDir: "preview", Workspace: "pr-1", Status: models.AppliedPlanStatus}},
+				ForkApproval: c.approval,
+			}
+			previewPlan := command.ProjectContext{
+				CommandName:             command.Plan,
+				RepoRelDir:              "preview",
+				Workspace:               "pr-1",
+				DestroyOnClose:          true,
+				AutoApplyDestroyOnClose: true,
+			}
+			When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Any[*events.CommentCommand]())).
+				ThenReturn([]command.ProjectContext{previewPlan}, nil)
+			When(projectCommandBuilder.BuildApplyCommands(Any[*command.Context](), Any[*events.CommentCommand]())).
+				ThenReturn([]command.ProjectContext{{CommandName: command.Apply, RepoRelDir: "preview", Workspace: "pr-1"}}, nil)
+			When(projectCommandRunner.Plan(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{
+				Command:     command.Plan,
+				RepoRelDir:  "preview",
+				Workspace:   "pr-1",
+				PlanSuccess: &models.PlanSuccess{TerraformOutput: "Plan: 0 to add, 0 to change, 2 to destroy."},
+			})
+			When(projectCommandRunner.Apply(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{
+				Command:      command.Apply,
+				RepoRelDir:   "preview",
+				Workspace:    "pr-1",
+				ApplySuccess: "Destroy complete! Resources: 2 destroyed.",
+			})
+
+			Ok(t, runner.DestroyOnClose(logging.NewNoopLogger(t), repo, fork, pull, pullStatus))
+
+			// The pull request's branch is cloned from the fork.
+			ctx, _ := projectCommandBuilder.VerifyWasCalledOnce().
+				BuildPlanCommands(Any[*command.Context](), Any[*events.CommentCommand]()).
+				GetCapturedArguments()
+			Equals(t, fork, ctx.HeadRepo)
+			if c.expApplied {
+				projectCommandRunner.VerifyWasCalledOnce().Apply(Any[command.ProjectContext]())
+				return
+			}
+			projectCommandRunner.VerifyWasCalled(Never()).Apply(Any[command.ProjectContext]())
+			_, _, _, planComment, _ := vcsClient.VerifyWasCalledOnce().
+				CreateComment(Any[logging.SimpleLogging](), Eq(repo), Eq(1), Any[string](), Eq("plan")).
+				GetCapturedArguments()
+			Assert(t, strings.Contains(planComment, "this pull request is from a fork and its latest commit wasn't approved"), "expected fork note in %q", planComment)
+		})
+	}
+}
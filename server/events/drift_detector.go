@@ -0,0 +1,254 @@
+package events
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// refreshOnlyFlag is passed to terraform plan so that it only reports
+// changes made outside of Terraform.
+const refreshOnlyFlag = "-refresh-only"
+
+// DefaultDriftDetector runs refresh-only plans on a repo's default branch to
+// detect changes made outside of Atlantis.
+// Each run plans as if it were for its own pseudo pull request, see
+// newBranchContext, so a project that is locked by a pull request will be
+// reported as an error.
+type DefaultDriftDetector struct {
+	Locker                   locking.Locker
+	Logger                   logging.SimpleLogging
+	Parser                   EventParsing
+	ProjectCommandBuilder    ProjectCommandBuilder
+	ProjectPlanCommandRunner ProjectPlanCommandRunner
+	Scope                    tally.Scope
+	VCSClient                vcs.Client
+	// VCSHostTypes maps the hostnames of the configured VCS hosts to their
+	// type, ex. github.com => Github.
+	VCSHostTypes     map[string]models.VCSHostType
+	WorkingDir       WorkingDir
+	WorkingDirLocker WorkingDirLocker
+	// PlanCache, PlanHistory and PlanStore are cleaned up after each run
+	// since plans are saved in them under the run's pseudo pull request.
+	// They can be nil.
+	PlanCache   PlanCache
+	PlanHistory *PlanHistory
+	PlanStore   PlanStore
+}
+
+// DetectDrift checks each of repo's drift detection projects for drift.
+func (d *DefaultDriftDetector) DetectDrift(repo valid.Repo) ([]models.DriftStatus, error) {
+	if repo.DriftDetection == nil {
+		return nil, nil
+	}
+	baseRepo, err := resolveRepo(d.Logger, d.VCSClient, d.Parser, d.VCSHostTypes, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	branch := repo.DriftDetection.Branch
+	if branch == "" {
+		if branch, err = remoteDefaultBranch(baseRepo); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := newBranchContext(d.Logger.With("repo", repo.ID), d.Scope, baseRepo, branch)
+	defer cleanUpBranchRun(ctx, d.WorkingDir, d.Locker, d.PlanCache, d.PlanHistory, d.PlanStore)
+	if err := cloneBranch(d.WorkingDir, d.WorkingDirLocker, ctx); err != nil {
+		return nil, errors.Wrap(err, "cloning repo")
+	}
+
+	var statuses []models.DriftStatus
+	for _, project := range repo.DriftDetection.Projects {
+		cmds, err := d.ProjectCommandBuilder.BuildPlanCommands(ctx, &CommentCommand{
+			Name:        command.Plan,
+			ProjectName: project.Name,
+			RepoRelDir:  project.Dir,
+			Workspace:   project.Workspace,
+			Flags:       []string{refreshOnlyFlag},
+		})
+		if err != nil {
+			status := newDriftStatus(repo, baseRepo, branch, command.ProjectResult{
+				ProjectName: project.Name,
+				RepoRelDir:  project.Dir,
+				Workspace:   project.Workspace,
+			})
+			status.Error = fmt.Sprintf("building plan command: %s", err)
+			statuses = append(statuses, status)
+			continue
+		}
+		for _, cmd := range cmds {
+			statuses = append(statuses, newDriftStatus(repo, baseRepo, branch, d.ProjectPlanCommandRunner.Plan(cmd)))
+		}
+	}
+	return statuses, nil
+}
+
+// resolveRepo looks up the repo with the given ID, ex.
+// github.com/runatlantis/atlantis, on its VCS host. It's used by jobs that
+// aren't triggered by a VCS event.
+func resolveRepo(logger logging.SimpleLogging, vcsClient vcs.Client, parser EventParsing, vcsHostTypes map[string]models.VCSHostType, repoID string) (models.Repo, error) {
+	hostname, fullName, ok := strings.Cut(repoID, "/")
+	if !ok {
+		return models.Repo{}, fmt.Errorf("invalid repo id %q", repoID)
+	}
+	hostType, ok := vcsHostTypes[hostname]
+	if !ok {
+		return models.Repo{}, fmt.Errorf("no VCS host configured for %q", hostname)
+	}
+	cloneURL, err := vcsClient.GetCloneURL(logger, hostType, fullName)
+	if err != nil {
+		return models.Repo{}, errors.Wrap(err, "getting clone url")
+	}
+	baseRepo, err := parser.ParseAPIPlanRequest(hostType, fullName, cloneURL)
+	if err != nil {
+		return models.Repo{}, errors.Wrap(err, "parsing repo")
+	}
+	return baseRepo, nil
+}
+
+// lastBranchPullNum is the pseudo pull request number last handed out by
+// newBranchContext.
+var lastBranchPullNum atomic.Int64
+
+// newBranchContext returns the context for running commands against branch
+// of repo rather than a pull request. Each context has its own negative
+// pseudo pull request number so that runs never share a working dir or locks
+// with each other, with pull requests or with API requests, which use 0.
+// The head commit is set to the commit of branch by cloneBranch. Everything
+// that's stored under the pseudo pull request has to be cleaned up with
+// cleanUpBranchRun once the run is done.
+func newBranchContext(log logging.SimpleLogging, scope tally.Scope, repo models.Repo, branch string) *command.Context {
+	return &command.Context{
+		HeadRepo: repo,
+		Pull: models.PullRequest{
+			Num:        int(lastBranchPullNum.Add(-1)),
+			BaseBranch: branch,
+			HeadBranch: branch,
+			BaseRepo:   repo,
+		},
+		Scope: scope,
+		Log:   log,
+		API:   true,
+	}
+}
+
+// cloneBranch clones the branch of ctx into a fresh working directory and sets
+// the head commit of ctx to the commit that was cloned.
+func cloneBranch(workingDir WorkingDir, workingDirLocker WorkingDirLocker, ctx *command.Context) error {
+	unlockFn, err := workingDirLocker.TryLock(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, DefaultWorkspace, DefaultRepoRelDir)
+	if err != nil {
+		return err
+	}
+	defer unlockFn()
+
+	// Clone reuses an existing checkout if it's at the right commit but we
+	// only know the branch name so we always start from scratch.
+	if err := workingDir.Delete(ctx.Log, ctx.HeadRepo, ctx.Pull); err != nil {
+		return err
+	}
+	cloneDir, _, err := workingDir.Clone(ctx.Log, ctx.HeadRepo, ctx.Pull, DefaultWorkspace)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("git", "rev-parse", "HEAD") // #nosec
+	cmd.Dir = cloneDir
+	out, err := cmd.Output()
+	if err != nil {
+		return errors.Wrap(err, "getting cloned commit")
+	}
+	ctx.Pull.HeadCommit = strings.TrimSpace(string(out))
+	return nil
+}
+
+// cleanUpBranchRun deletes the working dir, locks, cached and stored plans and
+// plan history of the pseudo pull request of ctx, see newBranchContext. The
+// stores can be nil.
+func cleanUpBranchRun(ctx *command.Context, workingDir WorkingDir, locker locking.Locker, planCache PlanCache, planHistory *PlanHistory, planStore PlanStore) {
+	repo := ctx.Pull.BaseRepo
+	if err := workingDir.Delete(ctx.Log, repo, ctx.Pull); err != nil {
+		ctx.Log.Warn("deleting working dir: %s", err)
+	}
+	if planCache != nil {
+		if err := planCache.Delete(repo, ctx.Pull); err != nil {
+			ctx.Log.Warn("deleting cached plans: %s", err)
+		}
+	}
+	if planHistory != nil {
+		if err := planHistory.Delete(repo, ctx.Pull); err != nil {
+			ctx.Log.Warn("deleting plan history: %s", err)
+		}
+	}
+	if planStore != nil {
+		if err := planStore.Delete(ctx.Pull); err != nil {
+			ctx.Log.Warn("deleting stored plans: %s", err)
+		}
+	}
+	if _, err := locker.UnlockByPull(repo.FullName, ctx.Pull.Num); err != nil {
+		ctx.Log.Warn("deleting locks: %s", err)
+	}
+}
+
+// remoteDefaultBranch returns the branch that the repo's HEAD points to.
+func remoteDefaultBranch(repo models.Repo) (string, error) {
+	cmd := exec.Command("git", "ls-remote", "--symref", repo.CloneURL, "HEAD") // #nosec
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		sanitizedOutput := strings.ReplaceAll(string(out), repo.CloneURL, repo.SanitizedCloneURL)
+		return "", fmt.Errorf("getting default branch of %s: %s: %s", repo.FullName, err, sanitizedOutput)
+	}
+	return parseSymrefHead(string(out))
+}
+
+// parseSymrefHead parses the branch name from the output of
+// git ls-remote --symref <url> HEAD, ex.
+//
+//	ref: refs/heads/main	HEAD
+//	6d6d8e4e6a2f4b5c...	HEAD
+func parseSymrefHead(output string) (string, error) {
+	for _, line := range strings.Split(output, "\n") {
+		ref, found := strings.CutPrefix(line, "ref: refs/heads/")
+		if !found {
+			continue
+		}
+		if branch, _, ok := strings.Cut(ref, "\t"); ok {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("unable to determine default branch from %q", output)
+}
+
+func newDriftStatus(repo valid.Repo, baseRepo models.Repo, branch string, result command.ProjectResult) models.DriftStatus {
+	status := models.DriftStatus{
+		RepoID:       repo.ID,
+		RepoFullName: baseRepo.FullName,
+		Branch:       branch,
+		ProjectName:  result.ProjectName,
+		RepoRelDir:   result.RepoRelDir,
+		Workspace:    result.Workspace,
+		CheckedAt:    time.Now(),
+	}
+	switch {
+	case result.Error != nil:
+		status.Error = result.Error.Error()
+	case result.Failure != "":
+		status.Error = result.Failure
+	case result.PlanSuccess != nil:
+		status.Output = result.PlanSuccess.TerraformOutput
+		status.DriftedResources = models.CountDriftedResources(result.PlanSuccess.TerraformOutput)
+		status.Drifted = status.DriftedResources > 0 || result.PlanSuccess.Stats().ChangesOutside
+	}
+	return status
+}
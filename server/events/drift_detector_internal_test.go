@@ -0,0 +1,16 @@
+package events
+
+import (
+	"testing"
+
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestParseSymrefHead(t *testing.T) {
+	branch, err := parseSymrefHead("ref: refs/heads/main\tHEAD\n6d6d8e4e6a2f4b5c0d7e3f0a9b8c7d6e5f4a3b2c\tHEAD\n")
+	Ok(t, err)
+	Equals(t, "main", branch)
+
+	_, err = parseSymrefHead("6d6d8e4e6a2f4b5c0d7e3f0a9b8c7d6e5f4a3b2c\tHEAD\n")
+	ErrEquals(t, `unable to determine default branch from "6d6d8e4e6a2f4b5c0d7e3f0a9b8c7d6e5f4a3b2c\tHEAD\n"`, err)
+}
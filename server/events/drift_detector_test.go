@@ -0,0 +1,187 @@
+package events_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	lockingmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestDefaultDriftDetector_DetectDrift(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	vcsClient := vcsmocks.NewMockClient()
+	parser := mocks.NewMockEventParsing()
+	workingDir := mocks.NewMockWorkingDir()
+	workingDirLocker := mocks.NewMockWorkingDirLocker()
+	projectCommandBuilder := mocks.NewMockProjectCommandBuilder()
+	projectCommandRunner := mocks.NewMockProjectCommandRunner()
+	locker := lockingmocks.NewMockLocker()
+	planCache := mocks.NewMockPlanCache()
+
+	baseRepo := models.Repo{FullName: "org/infra", CloneURL: "https://github.com/org/infra.git"}
+	repoDir := initRepo(t)
+	sha := strings.TrimSpace(runCmd(t, repoDir, "git", "rev-parse", "HEAD"))
+	When(workingDir.Clone(Any[logging.SimpleLogging](), Eq(baseRepo), Any[models.PullRequest](), Eq(events.DefaultWorkspace))).
+		ThenReturn(repoDir, false, nil)
+	When(vcsClient.GetCloneURL(Any[logging.SimpleLogging](), Eq(models.Github), Eq("org/infra"))).
+		ThenReturn(baseRepo.CloneURL, nil)
+	When(parser.ParseAPIPlanRequest(Eq(models.Github), Eq("org/infra"), Eq(baseRepo.CloneURL))).ThenReturn(baseRepo, nil)
+	When(workingDirLocker.TryLock(Eq("org/infra"), Any[int](), Eq(events.DefaultWorkspace), Eq(events.DefaultRepoRelDir))).
+		ThenReturn(func() {}, nil)
+
+	prod := command.ProjectContext{RepoRelDir: "prod", Workspace: "default"}
+	staging := command.ProjectContext{RepoRelDir: "staging", Workspace: "default"}
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Eq(&events.CommentCommand{
+		Name:       command.Plan,
+		RepoRelDir: "prod",
+		Flags:      []string{"-refresh-only"},
+	}))).ThenReturn([]command.ProjectContext{prod}, nil)
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Eq(&events.CommentCommand{
+		Name:       command.Plan,
+		RepoRelDir: "staging",
+		Flags:      []string{"-refresh-only"},
+	}))).ThenReturn([]command.ProjectContext{staging}, nil)
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Eq(&events.CommentCommand{
+		Name:       command.Plan,
+		RepoRelDir: "missing",
+		Flags:      []string{"-refresh-only"},
+	}))).ThenReturn(nil, errors.New("no project found"))
+
+	driftOutput := `
+  # aws_instance.web has changed
+  # aws_s3_bucket.logs has been deleted
+
+Note: Objects have changed outside of Terraform
+`
+	When(projectCommandRunner.Plan(Eq(prod))).ThenReturn(command.ProjectResult{
+		RepoRelDir:  "prod",
+		Workspace:   "default",
+		PlanSuccess: &models.PlanSuccess{TerraformOutput: driftOutput},
+	})
+	When(projectCommandRunner.Plan(Eq(staging))).ThenReturn(command.ProjectResult{
+		RepoRelDir: "staging",
+		Workspace:  "default",
+		Error:      errors.New("locked"),
+	})
+
+	detector := events.DefaultDriftDetector{
+		Locker:                   locker,
+		Logger:                   logger,
+		Parser:                   parser,
+		ProjectCommandBuilder:    projectCommandBuilder,
+		ProjectPlanCommandRunner: projectCommandRunner,
+		Scope:                    tally.NewTestScope("atlantis", nil),
+		VCSClient:                vcsClient,
+		VCSHostTypes:             map[string]models.VCSHostType{"github.com": models.Github},
+		WorkingDir:               workingDir,
+		WorkingDirLocker:         workingDirLocker,
+		PlanCache:                planCache,
+	}
+	statuses, err := detector.DetectDrift(valid.Repo{
+		ID: "github.com/org/infra",
+		DriftDetection: &valid.DriftDetection{
+			Branch: "main",
+			Projects: []valid.DriftDetectionProject{
+				{Dir: "prod"},
+				{Dir: "staging"},
+				{Dir: "missing"},
+			},
+		},
+	})
+	Ok(t, err)
+	Equals(t, 3, len(statuses))
+
+	Equals(t, "github.com/org/infra", statuses[0].RepoID)
+	Equals(t, "org/infra", statuses[0].RepoFullName)
+	Equals(t, "main", statuses[0].Branch)
+	Equals(t, "prod", statuses[0].RepoRelDir)
+	Equals(t, true, statuses[0].Drifted)
+	Equals(t, 2, statuses[0].DriftedResources)
+	Equals(t, driftOutput, statuses[0].Output)
+	Equals(t, "", statuses[0].Error)
+
+	Equals(t, "staging", statuses[1].RepoRelDir)
+	Equals(t, false, statuses[1].Drifted)
+	Equals(t, "locked", statuses[1].Error)
+
+	Equals(t, "missing", statuses[2].RepoRelDir)
+	Equals(t, "building plan command: no project found", statuses[2].Error)
+
+	_, _, pull, _ := workingDir.VerifyWasCalledOnce().Clone(Any[logging.SimpleLogging](), Eq(baseRepo), Any[models.PullRequest](), Eq(events.DefaultWorkspace)).GetCapturedArguments()
+	// Each run has its own pseudo pull request so it doesn't share its
+	// working dir or locks with pull 0.
+	Assert(t, pull.Num < 0, "expected a negative pull number, got %d", pull.Num)
+	Equals(t, models.PullRequest{
+		Num:        pull.Num,
+		BaseBranch: "main",
+		HeadBranch: "main",
+		BaseRepo:   baseRepo,
+	}, pull)
+	// The clone starts from scratch and everything stored under the pseudo
+	// pull request is cleaned up afterwards.
+	clonedPull := pull
+	clonedPull.HeadCommit = sha
+	workingDir.VerifyWasCalledOnce().Delete(Any[logging.SimpleLogging](), Eq(baseRepo), Eq(pull))
+	workingDir.VerifyWasCalledOnce().Delete(Any[logging.SimpleLogging](), Eq(baseRepo), Eq(clonedPull))
+	planCache.VerifyWasCalledOnce().Delete(Eq(baseRepo), Eq(clonedPull))
+	locker.VerifyWasCalledOnce().UnlockByPull("org/infra", pull.Num)
+	locker.VerifyWasCalled(Never()).UnlockByPull("org/infra", 0)
+}
+
+func TestDefaultDriftDetector_DetectDrift_SeparatePulls(t *testing.T) {
+	RegisterMockTestingT(t)
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[string]())).
+		ThenReturn(initRepo(t), false, nil)
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.GetCloneURL(Any[logging.SimpleLogging](), Any[models.VCSHostType](), Any[string]())).ThenReturn("https://github.com/org/infra.git", nil)
+	parser := mocks.NewMockEventParsing()
+	When(parser.ParseAPIPlanRequest(Any[models.VCSHostType](), Any[string](), Any[string]())).ThenReturn(models.Repo{FullName: "org/infra"}, nil)
+	detector := events.DefaultDriftDetector{
+		Locker:                   lockingmocks.NewMockLocker(),
+		Logger:                   logging.NewNoopLogger(t),
+		Parser:                   parser,
+		ProjectCommandBuilder:    mocks.NewMockProjectCommandBuilder(),
+		ProjectPlanCommandRunner: mocks.NewMockProjectCommandRunner(),
+		Scope:                    tally.NewTestScope("atlantis", nil),
+		VCSClient:                vcsClient,
+		VCSHostTypes:             map[string]models.VCSHostType{"github.com": models.Github},
+		WorkingDir:               workingDir,
+		WorkingDirLocker:         events.NewDefaultWorkingDirLocker(),
+	}
+	repo := valid.Repo{
+		ID:             "github.com/org/infra",
+		DriftDetection: &valid.DriftDetection{Branch: "main"},
+	}
+	_, err := detector.DetectDrift(repo)
+	Ok(t, err)
+	_, err = detector.DetectDrift(repo)
+	Ok(t, err)
+
+	_, _, pulls, _ := workingDir.VerifyWasCalled(Times(2)).Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[string]()).GetAllCapturedArguments()
+	Assert(t, pulls[0].Num != pulls[1].Num, "expected runs to have separate pull numbers, got %d twice", pulls[0].Num)
+}
+
+func TestDefaultDriftDetector_DetectDrift_UnknownHost(t *testing.T) {
+	detector := events.DefaultDriftDetector{
+		Logger:       logging.NewNoopLogger(t),
+		VCSHostTypes: map[string]models.VCSHostType{"github.com": models.Github},
+	}
+	_, err := detector.DetectDrift(valid.Repo{
+		ID:             "gitlab.com/org/infra",
+		DriftDetection: &valid.DriftDetection{Projects: []valid.DriftDetectionProject{{Dir: "."}}},
+	})
+	ErrEquals(t, `no VCS host configured for "gitlab.com"`, err)
+}
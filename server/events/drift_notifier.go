@@ -0,0 +1,106 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// maxDriftOutputLen is the maximum number of characters of plan output
+// included in drift notifications. VCS hosts limit the size of issues and
+// comments, ex. GitHub allows 65536 characters.
+const maxDriftOutputLen = 50000
+
+// DefaultDriftNotifier opens an issue or comments on a pull request when drift
+// is detected in a project, depending on the project's on_drift config.
+type DefaultDriftNotifier struct {
+	// ExecutableName is the name used in the pre-filled plan command, ex.
+	// atlantis.
+	ExecutableName string
+	Logger         logging.SimpleLogging
+	Parser         EventParsing
+	VCSClient      vcs.Client
+	// VCSHostTypes maps the hostnames of the configured VCS hosts to their
+	// type, ex. github.com => Github.
+	VCSHostTypes map[string]models.VCSHostType
+}
+
+// NotifyDrift notifies users of the drift in status.
+func (n *DefaultDriftNotifier) NotifyDrift(repo valid.Repo, status models.DriftStatus) error {
+	if repo.DriftDetection == nil {
+		return nil
+	}
+	var onDrift *valid.OnDrift
+	for _, project := range repo.DriftDetection.Projects {
+		if project.Matches(status.ProjectName, status.RepoRelDir, status.Workspace) {
+			onDrift = project.OnDrift
+			break
+		}
+	}
+	if onDrift == nil {
+		return nil
+	}
+
+	baseRepo, err := resolveRepo(n.Logger, n.VCSClient, n.Parser, n.VCSHostTypes, repo.ID)
+	if err != nil {
+		return err
+	}
+	body := n.driftMessage(status)
+	switch onDrift.Action {
+	case valid.OnDriftIssueAction:
+		project := fmt.Sprintf("%s (workspace %s)", status.RepoRelDir, status.Workspace)
+		if status.ProjectName != "" {
+			project = status.ProjectName
+		}
+		title := fmt.Sprintf("Drift detected in %s: %s", status.RepoFullName, project)
+		return n.VCSClient.CreateIssue(n.Logger, baseRepo, title, body)
+	case valid.OnDriftCommentAction:
+		return n.VCSClient.CreateComment(n.Logger, baseRepo, onDrift.PullRequest, body, "")
+	default:
+		return fmt.Errorf("unknown on_drift action %q", onDrift.Action)
+	}
+}
+
+// driftMessage returns the markdown body of a drift notification.
+func (n *DefaultDriftNotifier) driftMessage(status models.DriftStatus) string {
+	planCmd := fmt.Sprintf("%s plan -d %s -w %s", n.ExecutableName, status.RepoRelDir, status.Workspace)
+	if status.ProjectName != "" {
+		planCmd = fmt.Sprintf("%s plan -p %s", n.ExecutableName, status.ProjectName)
+	}
+	output := status.Output
+	if len(output) > maxDriftOutputLen {
+		// Cut on a rune boundary so multi-byte characters aren't split.
+		end := maxDriftOutputLen
+		for end > 0 && !utf8.RuneStart(output[end]) {
+			end--
+		}
+		output = output[:end] + "\n...\n(output truncated)"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Atlantis detected drift in %s on branch `%s`", driftProjectDescription(status), status.Branch)
+	if status.DriftedResources > 0 {
+		fmt.Fprintf(&b, ": %d resource(s) changed outside of Terraform", status.DriftedResources)
+	}
+	b.WriteString(".\n\n")
+	b.WriteString("<details><summary>Show Output</summary>\n\n```diff\n")
+	b.WriteString(strings.TrimSpace(output))
+	b.WriteString("\n```\n</details>\n\n")
+	b.WriteString("To bring the project back in line with its configuration, open a pull request and comment:\n\n")
+	fmt.Fprintf(&b, "```\n%s\n```\n", planCmd)
+	return b.String()
+}
+
+// driftProjectDescription describes the project of status, ex.
+// `prod` (workspace `default`) in runatlantis/atlantis.
+func driftProjectDescription(status models.DriftStatus) string {
+	if status.ProjectName != "" {
+		return fmt.Sprintf("project `%s` in %s", status.ProjectName, status.RepoFullName)
+	}
+	return fmt.Sprintf("`%s` (workspace `%s`) in %s", status.RepoRelDir, status.Workspace, status.RepoFullName)
+}
@@ -0,0 +1,122 @@
+package events_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func newTestDriftNotifier(t *testing.T) (*events.DefaultDriftNotifier, *vcsmocks.MockClient, models.Repo) {
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	parser := mocks.NewMockEventParsing()
+	baseRepo := models.Repo{FullName: "org/infra", CloneURL: "https://github.com/org/infra.git"}
+	When(vcsClient.GetCloneURL(Any[logging.SimpleLogging](), Eq(models.Github), Eq("org/infra"))).
+		ThenReturn(baseRepo.CloneURL, nil)
+	When(parser.ParseAPIPlanRequest(Eq(models.Github), Eq("org/infra"), Eq(baseRepo.CloneURL))).ThenReturn(baseRepo, nil)
+	return &events.DefaultDriftNotifier{
+		ExecutableName: "atlantis",
+		Logger:         logging.NewNoopLogger(t),
+		Parser:         parser,
+		VCSClient:      vcsClient,
+		VCSHostTypes:   map[string]models.VCSHostType{"github.com": models.Github},
+	}, vcsClient, baseRepo
+}
+
+var driftNotifierStatus = models.DriftStatus{
+	RepoID:           "github.com/org/infra",
+	RepoFullName:     "org/infra",
+	Branch:           "main",
+	RepoRelDir:       "prod",
+	Workspace:        "default",
+	Drifted:          true,
+	DriftedResources: 1,
+	Output:           "  # aws_instance.web has changed\n",
+}
+
+func TestDefaultDriftNotifier_NotifyDrift_Issue(t *testing.T) {
+	notifier, vcsClient, baseRepo := newTestDriftNotifier(t)
+	err := notifier.NotifyDrift(valid.Repo{
+		ID: "github.com/org/infra",
+		DriftDetection: &valid.DriftDetection{
+			Projects: []valid.DriftDetectionProject{
+				{Dir: "prod", OnDrift: &valid.OnDrift{Action: valid.OnDriftIssueAction}},
+			},
+		},
+	}, driftNotifierStatus)
+	Ok(t, err)
+
+	expBody := "Atlantis detected drift in `prod` (workspace `default`) in org/infra on branch `main`: 1 resource(s) changed outside of Terraform.\n\n" +
+		"<details><summary>Show Output</summary>\n\n```diff\n# aws_instance.web has changed\n```\n</details>\n\n" +
+		"To bring the project back in line with its configuration, open a pull request and comment:\n\n" +
+		"```\natlantis plan -d prod -w default\n```\n"
+	vcsClient.VerifyWasCalledOnce().CreateIssue(Any[logging.SimpleLogging](), Eq(baseRepo),
+		Eq("Drift detected in org/infra: prod (workspace default)"), Eq(expBody))
+}
+
+func TestDefaultDriftNotifier_NotifyDrift_Comment(t *testing.T) {
+	notifier, vcsClient, baseRepo := newTestDriftNotifier(t)
+	status := driftNotifierStatus
+	status.ProjectName = "prod"
+	err := notifier.NotifyDrift(valid.Repo{
+		ID: "github.com/org/infra",
+		DriftDetection: &valid.DriftDetection{
+			Projects: []valid.DriftDetectionProject{
+				{Name: "prod", OnDrift: &valid.OnDrift{Action: valid.OnDriftCommentAction, PullRequest: 42}},
+			},
+		},
+	}, status)
+	Ok(t, err)
+
+	_, _, _, body, _ := vcsClient.VerifyWasCalledOnce().
+		CreateComment(Any[logging.SimpleLogging](), Eq(baseRepo), Eq(42), Any[string](), Eq("")).
+		GetCapturedArguments()
+	Assert(t, strings.Contains(body, "Atlantis detected drift in project `prod` in org/infra"), "expected project in %q", body)
+	Assert(t, strings.Contains(body, "```\natlantis plan -p prod\n```"), "expected pre-filled plan command in %q", body)
+	vcsClient.VerifyWasCalled(Never()).CreateIssue(Any[logging.SimpleLogging](), Any[models.Repo](), Any[string](), Any[string]())
+}
+
+func TestDefaultDriftNotifier_NotifyDrift_TruncatesOutput(t *testing.T) {
+	notifier, vcsClient, baseRepo := newTestDriftNotifier(t)
+	status := driftNotifierStatus
+	// Each é is two bytes so the output can't be cut at a fixed byte offset
+	// without splitting one.
+	status.Output = "a" + strings.Repeat("é", 30000)
+	err := notifier.NotifyDrift(valid.Repo{
+		ID: "github.com/org/infra",
+		DriftDetection: &valid.DriftDetection{
+			Projects: []valid.DriftDetectionProject{
+				{Dir: "prod", OnDrift: &valid.OnDrift{Action: valid.OnDriftIssueAction}},
+			},
+		},
+	}, status)
+	Ok(t, err)
+
+	_, _, _, body := vcsClient.VerifyWasCalledOnce().
+		CreateIssue(Any[logging.SimpleLogging](), Eq(baseRepo), Any[string](), Any[string]()).
+		GetCapturedArguments()
+	Assert(t, utf8.ValidString(body), "expected valid UTF-8 in %q", body)
+	Assert(t, strings.Contains(body, "é\n...\n(output truncated)"), "expected truncated output in %q", body)
+}
+
+func TestDefaultDriftNotifier_NotifyDrift_NotConfigured(t *testing.T) {
+	notifier, vcsClient, _ := newTestDriftNotifier(t)
+	err := notifier.NotifyDrift(valid.Repo{
+		ID: "github.com/org/infra",
+		DriftDetection: &valid.DriftDetection{
+			Projects: []valid.DriftDetectionProject{{Dir: "prod"}},
+		},
+	}, driftNotifierStatus)
+	Ok(t, err)
+	vcsClient.VerifyWasCalled(Never()).CreateIssue(Any[logging.SimpleLogging](), Any[models.Repo](), Any[string](), Any[string]())
+	vcsClient.VerifyWasCalled(Never()).CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+}
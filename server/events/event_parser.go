@@ -30,6 +30,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
 	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
@@ -53,6 +54,9 @@ type PullCommand interface {
 	SubCommandName() string
 	// IsVerbose is true if the output of this command should be verbose.
 	IsVerbose() bool
+	// IsQuiet is true if the output of this command should only be a summary
+	// with links to the full output.
+	IsQuiet() bool
 	// IsAutoplan is true if this is an autoplan command vs. a comment command.
 	IsAutoplan() bool
 }
@@ -81,6 +85,11 @@ func (c PolicyCheckCommand) IsVerbose() bool {
 	return false
 }
 
+// IsQuiet is false for policy_check commands.
+func (c PolicyCheckCommand) IsQuiet() bool {
+	return false
+}
+
 // IsAutoplan is true for policy_check commands.
 func (c PolicyCheckCommand) IsAutoplan() bool {
 	return false
@@ -110,6 +119,11 @@ func (c AutoplanCommand) IsVerbose() bool {
 	return false
 }
 
+// IsQuiet is false for autoplan commands.
+func (c AutoplanCommand) IsQuiet() bool {
+	return false
+}
+
 // IsAutoplan is true for autoplan commands (obviously).
 func (c AutoplanCommand) IsAutoplan() bool {
 	return true
@@ -133,6 +147,9 @@ type CommentCommand struct {
 	AutoMergeMethod string
 	// Verbose is true if the command should output verbosely.
 	Verbose bool
+	// Quiet is true if the command should only output a summary of each
+	// project with a link to its full output.
+	Quiet bool
 	// Workspace is the name of the Terraform workspace to run the command in.
 	// If empty then the comment specified no workspace.
 	Workspace string
@@ -144,6 +161,20 @@ type CommentCommand struct {
 	PolicySet string
 	// ClearPolicyApproval is true if approvals should be cleared out for specified policies.
 	ClearPolicyApproval bool
+	// Force is true if plans should be run even if the plan from the last run
+	// can be reused.
+	Force bool
+	// Failed is true if only the projects that failed the last time the
+	// command was run on the pull request should be run again.
+	Failed bool
+	// Confirm is true if the destroy plans of the pull request should be
+	// applied rather than a new one planned.
+	Confirm bool
+	// ApprovedCommit is the commit a fork pull request was at when the
+	// approval label was added to it. It's only set for the ok-to-test
+	// command run for the label, which fails if new commits have been pushed
+	// since.
+	ApprovedCommit string
 }
 
 // IsForSpecificProject returns true if the command is for a specific dir, workspace
@@ -163,6 +194,15 @@ func (c CommentCommand) CommandName() command.Name {
 	return c.Name
 }
 
+// DisplayName returns the name the command was run with, ex. "plan", which
+// is the name of the custom command for custom commands.
+func (c CommentCommand) DisplayName() string {
+	if c.Name == command.Custom {
+		return c.SubName
+	}
+	return c.Name.String()
+}
+
 // SubCommandName returns the name of this subcommand.
 func (c CommentCommand) SubCommandName() string {
 	return c.SubName
@@ -173,6 +213,11 @@ func (c CommentCommand) IsVerbose() bool {
 	return c.Verbose
 }
 
+// IsQuiet is true if the command should only give a summary of its output.
+func (c CommentCommand) IsQuiet() bool {
+	return c.Quiet
+}
+
 // IsAutoplan will be false for comment commands.
 func (c CommentCommand) IsAutoplan() bool {
 	return false
@@ -180,11 +225,11 @@ func (c CommentCommand) IsAutoplan() bool {
 
 // String returns a string representation of the command.
 func (c CommentCommand) String() string {
-	return fmt.Sprintf("command=%q, verbose=%t, dir=%q, workspace=%q, project=%q, policyset=%q, auto-merge-disabled=%t, auto-merge-method=%s, clear-policy-approval=%t, flags=%q", c.Name.String(), c.Verbose, c.RepoRelDir, c.Workspace, c.ProjectName, c.PolicySet, c.AutoMergeDisabled, c.AutoMergeMethod, c.ClearPolicyApproval, strings.Join(c.Flags, ","))
+	return fmt.Sprintf("command=%q, verbose=%t, quiet=%t, dir=%q, workspace=%q, project=%q, policyset=%q, auto-merge-disabled=%t, auto-merge-method=%s, clear-policy-approval=%t, failed=%t, flags=%q", c.Name.String(), c.Verbose, c.Quiet, c.RepoRelDir, c.Workspace, c.ProjectName, c.PolicySet, c.AutoMergeDisabled, c.AutoMergeMethod, c.ClearPolicyApproval, c.Failed, strings.Join(c.Flags, ","))
 }
 
 // NewCommentCommand constructs a CommentCommand, setting all missing fields to defaults.
-func NewCommentCommand(repoRelDir string, flags []string, name command.Name, subName string, verbose, autoMergeDisabled bool, autoMergeMethod string, workspace string, project string, policySet string, clearPolicyApproval bool) *CommentCommand {
+func NewCommentCommand(repoRelDir string, flags []string, name command.Name, subName string, verbose, quiet, autoMergeDisabled bool, autoMergeMethod string, workspace string, project string, policySet string, clearPolicyApproval bool, force bool, failed bool, confirm bool) *CommentCommand {
 	// If repoRelDir was empty we want to keep it that way to indicate that it
 	// wasn't specified in the comment.
 	if repoRelDir != "" {
@@ -199,12 +244,16 @@ func NewCommentCommand(repoRelDir string, flags []string, name command.Name, sub
 		Name:                name,
 		SubName:             subName,
 		Verbose:             verbose,
+		Quiet:               quiet,
 		Workspace:           workspace,
 		AutoMergeDisabled:   autoMergeDisabled,
 		AutoMergeMethod:     autoMergeMethod,
 		ProjectName:         project,
 		PolicySet:           policySet,
 		ClearPolicyApproval: clearPolicyApproval,
+		Force:               force,
+		Failed:              failed,
+		Confirm:             confirm,
 	}
 }
 
@@ -369,6 +418,30 @@ type EventParser struct {
 	BitbucketServerURL string
 	AzureDevopsToken   string
 	AzureDevopsUser    string
+	// AzureDevopsServicePrincipal is set when Atlantis authenticates to Azure
+	// DevOps as a service principal. Clone URLs then use its access token
+	// instead of AzureDevopsToken.
+	AzureDevopsServicePrincipal *vcs.AzureDevopsServicePrincipal
+	// BitbucketCloudTokens is set when Atlantis authenticates to Bitbucket
+	// Cloud with an access token or as an OAuth consumer. Clone URLs then use
+	// its token instead of BitbucketUser and BitbucketToken.
+	BitbucketCloudTokens bitbucketcloud.TokenSource
+	// ForkPRApprovalLabel is the label that approves fork pull requests. Pull
+	// request events that add it are parsed as ApprovalLabeledPullEvent.
+	ForkPRApprovalLabel string
+}
+
+// bitbucketCloudCloneCreds returns the user and token the clone URLs of
+// Bitbucket Cloud repos authenticate with.
+func (e *EventParser) bitbucketCloudCloneCreds() (string, string, error) {
+	if e.BitbucketCloudTokens == nil {
+		return e.BitbucketUser, e.BitbucketToken, nil
+	}
+	token, err := e.BitbucketCloudTokens.GetToken()
+	if err != nil {
+		return "", "", err
+	}
+	return bitbucketcloud.AccessTokenGitUser, token, nil
 }
 
 func (e *EventParser) ParseAPIPlanRequest(vcsHostType models.VCSHostType, repoFullName string, cloneURL string) (models.Repo, error) {
@@ -446,12 +519,16 @@ func (e *EventParser) parseCommonBitbucketCloudEventData(event bitbucketcloud.Co
 		return
 	}
 
+	cloneUser, cloneToken, err := e.bitbucketCloudCloneCreds()
+	if err != nil {
+		return
+	}
 	headRepo, err = models.NewRepo(
 		models.BitbucketCloud,
 		*event.PullRequest.Source.Repository.FullName,
 		*event.PullRequest.Source.Repository.Links.HTML.HREF,
-		e.BitbucketUser,
-		e.BitbucketToken)
+		cloneUser,
+		cloneToken)
 	if err != nil {
 		return
 	}
@@ -459,8 +536,8 @@ func (e *EventParser) parseCommonBitbucketCloudEventData(event bitbucketcloud.Co
 		models.BitbucketCloud,
 		*event.Repository.FullName,
 		*event.Repository.Links.HTML.HREF,
-		e.BitbucketUser,
-		e.BitbucketToken)
+		cloneUser,
+		cloneToken)
 	if err != nil {
 		return
 	}
@@ -546,8 +623,9 @@ func (e *EventParser) ParseGithubPullEvent(logger logging.SimpleLogging, pullEve
 	// If it's a draft PR we ignore it for auto-planning if configured to do so
 	// however it's still possible for users to run plan on it manually via a
 	// comment so if any draft PR is closed we still need to check if we need
-	// to delete its locks.
-	if pullEvent.GetPullRequest().GetDraft() && pullEvent.GetAction() != "closed" && !e.AllowDraftPRs {
+	// to delete its locks. Draft pull requests from forks can still be
+	// approved with the approval label.
+	if pullEvent.GetPullRequest().GetDraft() && pullEvent.GetAction() != "closed" && pullEvent.GetAction() != "labeled" && !e.AllowDraftPRs {
 		action = "other"
 	}
 
@@ -562,6 +640,11 @@ func (e *EventParser) ParseGithubPullEvent(logger logging.SimpleLogging, pullEve
 		pullEventType = models.UpdatedPullEvent
 	case "closed":
 		pullEventType = models.ClosedPullEvent
+	case "labeled":
+		pullEventType = models.OtherPullEvent
+		if e.ForkPRApprovalLabel != "" && pullEvent.GetLabel().GetName() == e.ForkPRApprovalLabel {
+			pullEventType = models.ApprovalLabeledPullEvent
+		}
 	default:
 		pullEventType = models.OtherPullEvent
 	}
@@ -663,9 +746,29 @@ func (e *EventParser) ParseGitlabMergeRequestUpdateEvent(event gitlab.MergeEvent
 		(strings.HasPrefix(event.Changes.Title.Previous, "Draft:") && !strings.HasPrefix(event.Changes.Title.Current, "Draft:")) {
 		return models.UpdatedPullEvent
 	}
+	if e.isApprovalLabelAdded(event) {
+		return models.ApprovalLabeledPullEvent
+	}
 	return models.OtherPullEvent
 }
 
+// isApprovalLabelAdded returns true if the GitLab merge request update event
+// added the ForkPRApprovalLabel label.
+func (e *EventParser) isApprovalLabelAdded(event gitlab.MergeEvent) bool {
+	if e.ForkPRApprovalLabel == "" {
+		return false
+	}
+	hasLabel := func(labels []*gitlab.EventLabel) bool {
+		for _, label := range labels {
+			if label != nil && label.Title == e.ForkPRApprovalLabel {
+				return true
+			}
+		}
+		return false
+	}
+	return hasLabel(event.Changes.Labels.Current) && !hasLabel(event.Changes.Labels.Previous)
+}
+
 // ParseGitlabMergeRequestEvent parses GitLab merge request events.
 // pull is the parsed merge request.
 // See EventParsing for return value docs.
@@ -703,6 +806,10 @@ func (e *EventParser) ParseGitlabMergeRequestEvent(event gitlab.MergeEvent) (pul
 	// to delete its locks.
 	if event.ObjectAttributes.WorkInProgress && event.ObjectAttributes.Action != "close" && !e.AllowDraftPRs {
 		eventType = models.OtherPullEvent
+		// Draft merge requests from forks can still be approved.
+		if event.ObjectAttributes.Action == "update" && e.isApprovalLabelAdded(event) {
+			eventType = models.ApprovalLabeledPullEvent
+		}
 	} else {
 		switch event.ObjectAttributes.Action {
 		case "open":
@@ -1052,7 +1159,14 @@ func (e *EventParser) ParseAzureDevopsRepo(adRepo *azuredevops.GitRepository) (m
 	}
 	fmt.Println("%", cloneURL)
 	fullName := fmt.Sprintf("%s/%s/%s", owner, project, repo)
-	return models.NewRepo(models.AzureDevops, fullName, cloneURL, e.AzureDevopsUser, e.AzureDevopsToken)
+	token := e.AzureDevopsToken
+	if e.AzureDevopsServicePrincipal != nil {
+		token, err = e.AzureDevopsServicePrincipal.GetToken()
+		if err != nil {
+			return models.Repo{}, err
+		}
+	}
+	return models.NewRepo(models.AzureDevops, fullName, cloneURL, e.AzureDevopsUser, token)
 }
 
 func (e *EventParser) ParseGiteaPullRequestEvent(event giteasdk.PullRequest) (models.PullRequest, models.PullRequestEventType, models.Repo, models.Repo, models.User, error) {
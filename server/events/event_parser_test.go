@@ -280,6 +280,33 @@ func TestParseGithubPullEvent_EventType(t *testing.T) {
 	}
 }
 
+func TestParseGithubPullEvent_ApprovalLabel(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	labelParser := parser
+	labelParser.ForkPRApprovalLabel = "ok-to-test"
+	cases := []struct {
+		label    string
+		draft    bool
+		expEvent models.PullRequestEventType
+	}{
+		{"ok-to-test", false, models.ApprovalLabeledPullEvent},
+		{"ok-to-test", true, models.ApprovalLabeledPullEvent},
+		{"other", false, models.OtherPullEvent},
+	}
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s draft=%t", c.label, c.draft), func(t *testing.T) {
+			event := deepcopy.Copy(PullEvent).(github.PullRequestEvent)
+			event.Action = github.Ptr("labeled")
+			event.Label = &github.Label{Name: github.Ptr(c.label)}
+			event.PullRequest.Draft = github.Ptr(c.draft)
+			_, evType, _, _, actUser, err := labelParser.ParseGithubPullEvent(logger, &event)
+			Ok(t, err)
+			Equals(t, c.expEvent, evType)
+			Equals(t, models.User{Username: "user"}, actUser)
+		})
+	}
+}
+
 func TestParseGithubPull(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	testPull := deepcopy.Copy(Pull).(github.PullRequest)
@@ -532,6 +559,33 @@ func TestParseGitlabMergeEvent_Update_ActionType(t *testing.T) {
 	}
 }
 
+func TestParseGitlabMergeEvent_ApprovalLabel(t *testing.T) {
+	path := filepath.Join("testdata", "gitlab-merge-request-event-update-labels.json")
+	bytes, err := os.ReadFile(path)
+	Ok(t, err)
+	var event *gitlab.MergeEvent
+	err = json.Unmarshal(bytes, &event)
+	Ok(t, err)
+
+	labelParser := parser
+	labelParser.ForkPRApprovalLabel = "aaaa"
+	_, evType, _, _, _, err := labelParser.ParseGitlabMergeRequestEvent(*event)
+	Ok(t, err)
+	Equals(t, models.ApprovalLabeledPullEvent, evType)
+
+	// Only adding the label approves the merge request.
+	event.Changes.Labels.Previous = event.Changes.Labels.Current
+	_, evType, _, _, _, err = labelParser.ParseGitlabMergeRequestEvent(*event)
+	Ok(t, err)
+	Equals(t, models.OtherPullEvent, evType)
+
+	labelParser.ForkPRApprovalLabel = "other"
+	event.Changes.Labels.Previous = nil
+	_, evType, _, _, _, err = labelParser.ParseGitlabMergeRequestEvent(*event)
+	Ok(t, err)
+	Equals(t, models.OtherPullEvent, evType)
+}
+
 func TestParseGitlabMergeEvent_ActionType(t *testing.T) {
 	cases := []struct {
 		action string
@@ -751,14 +805,14 @@ func TestNewCommand_CleansDir(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.RepoRelDir, func(t *testing.T) {
-			cmd := events.NewCommentCommand(c.RepoRelDir, nil, command.Plan, "", false, false, "", "workspace", "", "", false)
+			cmd := events.NewCommentCommand(c.RepoRelDir, nil, command.Plan, "", false, false, false, "", "workspace", "", "", false, false, false, false)
 			Equals(t, c.ExpDir, cmd.RepoRelDir)
 		})
 	}
 }
 
 func TestNewCommand_EmptyDirWorkspaceProject(t *testing.T) {
-	cmd := events.NewCommentCommand("", nil, command.Plan, "", false, false, "", "", "", "", false)
+	cmd := events.NewCommentCommand("", nil, command.Plan, "", false, false, false, "", "", "", "", false, false, false, false)
 	Equals(t, events.CommentCommand{
 		RepoRelDir:  "",
 		Flags:       nil,
@@ -770,7 +824,7 @@ func TestNewCommand_EmptyDirWorkspaceProject(t *testing.T) {
 }
 
 func TestNewCommand_AllFieldsSet(t *testing.T) {
-	cmd := events.NewCommentCommand("dir", []string{"a", "b"}, command.Plan, "", true, false, "", "workspace", "project", "policyset", false)
+	cmd := events.NewCommentCommand("dir", []string{"a", "b"}, command.Plan, "", true, false, false, "", "workspace", "project", "policyset", false, false, false, false)
 	Equals(t, events.CommentCommand{
 		Workspace:   "workspace",
 		RepoRelDir:  "dir",
@@ -817,7 +871,7 @@ func TestCommentCommand_IsAutoplan(t *testing.T) {
 }
 
 func TestCommentCommand_String(t *testing.T) {
-	exp := `command="plan", verbose=true, dir="mydir", workspace="myworkspace", project="myproject", policyset="", auto-merge-disabled=false, auto-merge-method=, clear-policy-approval=false, flags="flag1,flag2"`
+	exp := `command="plan", verbose=true, quiet=false, dir="mydir", workspace="myworkspace", project="myproject", policyset="", auto-merge-disabled=false, auto-merge-method=, clear-policy-approval=false, failed=false, flags="flag1,flag2"`
 	Equals(t, exp, (events.CommentCommand{
 		RepoRelDir:  "mydir",
 		Flags:       []string{"flag1", "flag2"},
@@ -0,0 +1,347 @@
+package events
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+// DefaultGarbageCollector deletes the plans, working dirs and database entries
+// that are left behind when Atlantis misses a pull request being closed.
+type DefaultGarbageCollector struct {
+	Backend locking.Backend
+	// DataDir is the Atlantis data dir that the working dirs are cloned
+	// under.
+	DataDir           string
+	Logger            logging.SimpleLogging
+	PendingPlanFinder PendingPlanFinder
+	WorkingDirLocker  WorkingDirLocker
+	// VCSClient is used to check that pull requests were closed before their
+	// database entries are deleted.
+	VCSClient vcs.Client
+	// PlanCache, PlanHistory and PlanStore hold the plans of pull requests
+	// and are cleaned up along with their database entries. They can be nil.
+	PlanCache   PlanCache
+	PlanHistory *PlanHistory
+	PlanStore   PlanStore
+	// PlanMaxAge is how long after it was generated a plan is deleted. If 0,
+	// plans aren't deleted on their own.
+	PlanMaxAge time.Duration
+	// WorkingDirMaxAge is how long after it was last used a pull request's
+	// working dir is deleted. If 0, working dirs aren't deleted.
+	WorkingDirMaxAge time.Duration
+	// CollectOrphanedEntries is true if the pull statuses, locks and plans of
+	// closed pull requests without a working dir should be deleted. It should
+	// be false if the database is shared by servers with different data dirs.
+	CollectOrphanedEntries bool
+	// DryRun is true if what would be deleted should only be logged.
+	DryRun bool
+}
+
+// gcPullDir is the working dir of a pull request on disk.
+type gcPullDir struct {
+	path         string
+	repoFullName string
+	pullNum      int
+}
+
+// CollectGarbage deletes plans older than PlanMaxAge, working dirs that
+// haven't been used in WorkingDirMaxAge and, if CollectOrphanedEntries is
+// set, the pull statuses, locks and plans of closed pull requests whose
+// working dir no longer exists. Pull requests that are running a command are
+// skipped.
+func (g *DefaultGarbageCollector) CollectGarbage() (models.GarbageCollectionResult, error) {
+	result := models.GarbageCollectionResult{DryRun: g.DryRun}
+	pullDirs, err := findPullDirs(g.DataDir)
+	if err != nil {
+		return result, errors.Wrap(err, "finding working dirs")
+	}
+	statuses, err := g.Backend.GetPullStatuses()
+	if err != nil {
+		return result, errors.Wrap(err, "getting pull statuses")
+	}
+	pulls := make(map[string]models.PullRequest)
+	for _, status := range statuses {
+		pulls[g.pullKey(status.Pull.BaseRepo.FullName, status.Pull.Num)] = status.Pull
+	}
+
+	// removed holds the pulls whose working dir was deleted, or would have
+	// been if this is a dry run.
+	removed := make(map[string]bool)
+	for _, pullDir := range pullDirs {
+		key := g.pullKey(pullDir.repoFullName, pullDir.pullNum)
+		pull, hasStatus := pulls[key]
+		deleted, err := g.collectPullDir(pullDir, pull, hasStatus, &result)
+		if err != nil {
+			g.Logger.Err("collecting garbage in working dir '%s': %s", pullDir.path, err)
+			continue
+		}
+		removed[key] = deleted
+	}
+
+	if g.CollectOrphanedEntries {
+		if err := g.collectOrphanedEntries(statuses, removed, &result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// collectPullDir deletes pullDir if it's stale and otherwise deletes its
+// stale plans. It returns true if pullDir was deleted.
+func (g *DefaultGarbageCollector) collectPullDir(pullDir gcPullDir, pull models.PullRequest, hasStatus bool, result *models.GarbageCollectionResult) (bool, error) {
+	unlock, err := g.WorkingDirLocker.TryLockPull(pullDir.repoFullName, pullDir.pullNum)
+	if err != nil {
+		g.Logger.Debug("skipping working dir '%s' since a command is running", pullDir.path)
+		return false, nil
+	}
+	defer unlock()
+
+	plans, err := g.PendingPlanFinder.Find(pullDir.path)
+	if err != nil {
+		return false, errors.Wrap(err, "finding plans")
+	}
+	planTimes := make([]time.Time, len(plans))
+	lastUsed := time.Time{}
+	for i, plan := range plans {
+		info, err := os.Stat(pendingPlanPath(plan))
+		if err != nil {
+			return false, errors.Wrap(err, "getting plan age")
+		}
+		planTimes[i] = info.ModTime()
+		if planTimes[i].After(lastUsed) {
+			lastUsed = planTimes[i]
+		}
+	}
+	// Every git command run in a clone writes to its .git dir so it tells us
+	// when the working dir was last used.
+	workspaceDirs, err := os.ReadDir(pullDir.path)
+	if err != nil {
+		return false, err
+	}
+	for _, workspaceDir := range workspaceDirs {
+		info, err := os.Stat(filepath.Join(pullDir.path, workspaceDir.Name(), ".git"))
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(lastUsed) {
+			lastUsed = info.ModTime()
+		}
+	}
+
+	if g.WorkingDirMaxAge > 0 && time.Since(lastUsed) > g.WorkingDirMaxAge {
+		g.Logger.Info("%sdeleting working dir '%s' last used at %s", g.dryRunPrefix(), pullDir.path, lastUsed.Format(time.RFC3339))
+		if !g.DryRun {
+			if err := os.RemoveAll(pullDir.path); err != nil {
+				return false, errors.Wrap(err, "deleting working dir")
+			}
+		}
+		result.WorkingDirs++
+		return true, nil
+	}
+
+	if g.PlanMaxAge == 0 {
+		return false, nil
+	}
+	for i, plan := range plans {
+		if time.Since(planTimes[i]) <= g.PlanMaxAge {
+			continue
+		}
+		path := pendingPlanPath(plan)
+		g.Logger.Info("%sdeleting plan '%s' generated at %s", g.dryRunPrefix(), path, planTimes[i].Format(time.RFC3339))
+		if !g.DryRun {
+			if err := utils.RemoveIgnoreNonExistent(path); err != nil {
+				return false, errors.Wrap(err, "deleting plan")
+			}
+			if hasStatus {
+				if err := g.Backend.UpdateProjectStatus(pull, plan.Workspace, plan.RepoRelDir, models.DiscardedPlanStatus); err != nil {
+					return false, errors.Wrapf(err, "discarding plan status of dir '%s' workspace '%s'", plan.RepoRelDir, plan.Workspace)
+				}
+			}
+		}
+		result.Plans++
+	}
+	return false, nil
+}
+
+// collectOrphanedEntries deletes the pull statuses, locks and plans of the
+// pull requests that don't have a working dir, either because they were
+// never deleted when the pull request was closed or because it's in removed,
+// and that the VCS host reports as closed. The working dir of an open pull
+// request that's in removed was only deleted since it wasn't used for a while
+// so its entries are kept. Pull requests with a number of 0 or less aren't
+// VCS pull requests, ex. those of API requests, so they're collected once
+// they don't have a working dir.
+func (g *DefaultGarbageCollector) collectOrphanedEntries(statuses []models.PullStatus, removed map[string]bool, result *models.GarbageCollectionResult) error {
+	locks, err := g.Backend.List()
+	if err != nil {
+		return errors.Wrap(err, "listing locks")
+	}
+	pulls := make(map[string]models.PullRequest)
+	hasStatus := make(map[string]bool)
+	for _, status := range statuses {
+		key := g.pullKey(status.Pull.BaseRepo.FullName, status.Pull.Num)
+		pulls[key] = status.Pull
+		hasStatus[key] = true
+	}
+	lockCounts := make(map[string]int)
+	for _, lock := range locks {
+		key := g.pullKey(lock.Pull.BaseRepo.FullName, lock.Pull.Num)
+		if _, ok := pulls[key]; !ok {
+			pulls[key] = lock.Pull
+		}
+		lockCounts[key]++
+	}
+
+	for key, pull := range pulls {
+		if !removed[key] {
+			_, err := os.Stat(g.pullDirPath(pull.BaseRepo.FullName, pull.Num))
+			if err == nil {
+				continue
+			}
+			if !os.IsNotExist(err) {
+				g.Logger.Err("checking working dir of %s: %s", key, err)
+				continue
+			}
+		}
+		if pull.Num > 0 {
+			state, err := g.VCSClient.GetPullState(g.Logger, pull.BaseRepo, pull)
+			if err != nil {
+				g.Logger.Err("getting state of %s: %s", key, err)
+				continue
+			}
+			if state == models.OpenPullState {
+				continue
+			}
+		}
+		if err := g.collectOrphanedPull(key, pull, hasStatus[key], lockCounts[key], result); err != nil {
+			g.Logger.Err("deleting database entries of %s: %s", key, err)
+		}
+	}
+	return nil
+}
+
+func (g *DefaultGarbageCollector) collectOrphanedPull(key string, pull models.PullRequest, hasStatus bool, lockCount int, result *models.GarbageCollectionResult) error {
+	unlock, err := g.WorkingDirLocker.TryLockPull(pull.BaseRepo.FullName, pull.Num)
+	if err != nil {
+		g.Logger.Debug("skipping database entries of %s since a command is running", key)
+		return nil
+	}
+	defer unlock()
+
+	g.Logger.Info("%sdeleting pull status, plans and %d lock(s) of %s since it was closed and has no working dir", g.dryRunPrefix(), lockCount, key)
+	if !g.DryRun {
+		if g.PlanCache != nil {
+			if err := g.PlanCache.Delete(pull.BaseRepo, pull); err != nil {
+				return errors.Wrap(err, "deleting cached plans")
+			}
+		}
+		if g.PlanHistory != nil {
+			if err := g.PlanHistory.Delete(pull.BaseRepo, pull); err != nil {
+				return errors.Wrap(err, "deleting plan history")
+			}
+		}
+		if g.PlanStore != nil {
+			if err := g.PlanStore.Delete(pull); err != nil {
+				return errors.Wrap(err, "deleting stored plans")
+			}
+		}
+		if lockCount > 0 {
+			if _, err := g.Backend.UnlockByPull(pull.BaseRepo.FullName, pull.Num); err != nil {
+				return errors.Wrap(err, "deleting locks")
+			}
+		}
+		if hasStatus {
+			if err := g.Backend.DeletePullStatus(pull); err != nil {
+				return errors.Wrap(err, "deleting pull status")
+			}
+		}
+	}
+	result.Locks += lockCount
+	if hasStatus {
+		result.PullStatuses++
+	}
+	return nil
+}
+
+// findPullDirs returns the working dirs of every pull request on disk. Repo
+// names can contain slashes, ex. GitLab subgroups, so we find them by looking
+// for dirs whose workspace dirs are git clones.
+func findPullDirs(dataDir string) ([]gcPullDir, error) {
+	root := filepath.Join(dataDir, workingDirPrefix)
+	var pullDirs []gcPullDir
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() || path == root {
+			return nil
+		}
+		pullNum, err := strconv.Atoi(d.Name())
+		if err != nil || !isPullDir(path) {
+			return nil
+		}
+		repoDir, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		pullDirs = append(pullDirs, gcPullDir{
+			path:         path,
+			repoFullName: filepath.ToSlash(repoDir),
+			pullNum:      pullNum,
+		})
+		return filepath.SkipDir
+	})
+	return pullDirs, err
+}
+
+// isPullDir returns true if one of path's dirs is a git clone.
+func isPullDir(path string) bool {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(path, entry.Name(), ".git")); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *DefaultGarbageCollector) pullDirPath(repoFullName string, pullNum int) string {
+	return filepath.Join(g.DataDir, workingDirPrefix, repoFullName, strconv.Itoa(pullNum))
+}
+
+// pendingPlanPath returns the path to the plan file of plan.
+func pendingPlanPath(plan PendingPlan) string {
+	return filepath.Join(plan.RepoDir, plan.RepoRelDir, runtime.GetPlanFilename(plan.Workspace, plan.ProjectName))
+}
+
+func (g *DefaultGarbageCollector) pullKey(repoFullName string, pullNum int) string {
+	return fmt.Sprintf("%s#%d", repoFullName, pullNum)
+}
+
+func (g *DefaultGarbageCollector) dryRunPrefix() string {
+	if g.DryRun {
+		return "[dry run] "
+	}
+	return ""
+}
@@ -0,0 +1,184 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	lockmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// initGCWorkingDir creates a working dir for pullNum with a plan in dir1 and
+// sets the time the working dir and plan were last used.
+func initGCWorkingDir(t *testing.T, dataDir string, pullNum string, lastUsed time.Time, planTime time.Time) string {
+	t.Helper()
+	cloneDir := filepath.Join(dataDir, "repos", "org", "app", pullNum, "default")
+	Ok(t, os.MkdirAll(filepath.Join(cloneDir, "dir1"), 0700))
+	runCmd(t, cloneDir, "git", "init")
+	plan := filepath.Join(cloneDir, "dir1", "default.tfplan")
+	Ok(t, os.WriteFile(plan, nil, 0600))
+	Ok(t, os.Chtimes(plan, planTime, planTime))
+	Ok(t, os.Chtimes(filepath.Join(cloneDir, ".git"), lastUsed, lastUsed))
+	return plan
+}
+
+func TestDefaultGarbageCollector_CollectGarbage(t *testing.T) {
+	RegisterMockTestingT(t)
+	dataDir := t.TempDir()
+	backend := lockmocks.NewMockBackend()
+	vcsClient := vcsmocks.NewMockClient()
+	planCache := mocks.NewMockPlanCache()
+	planHistory := &events.PlanHistory{DataDir: t.TempDir()}
+	repo := models.Repo{FullName: "org/app"}
+	stalePull := models.PullRequest{Num: 1, BaseRepo: repo}
+	activePull := models.PullRequest{Num: 2, BaseRepo: repo}
+	closedPull := models.PullRequest{Num: 3, BaseRepo: repo}
+	staleClosedPull := models.PullRequest{Num: 4, BaseRepo: repo}
+	When(backend.GetPullStatuses()).ThenReturn([]models.PullStatus{
+		{Pull: stalePull},
+		{Pull: activePull},
+		{Pull: closedPull},
+		{Pull: staleClosedPull},
+	}, nil)
+	When(backend.List()).ThenReturn([]models.ProjectLock{
+		{Pull: stalePull, Workspace: "default"},
+		{Pull: closedPull, Workspace: "default"},
+		{Pull: closedPull, Workspace: "staging"},
+		{Pull: activePull, Workspace: "default"},
+	}, nil)
+	When(vcsClient.GetPullState(Any[logging.SimpleLogging](), Eq(repo), Eq(stalePull))).ThenReturn(models.OpenPullState, nil)
+	When(vcsClient.GetPullState(Any[logging.SimpleLogging](), Eq(repo), Eq(closedPull))).ThenReturn(models.ClosedPullState, nil)
+	When(vcsClient.GetPullState(Any[logging.SimpleLogging](), Eq(repo), Eq(staleClosedPull))).ThenReturn(models.ClosedPullState, nil)
+
+	now := time.Now()
+	initGCWorkingDir(t, dataDir, "1", now.Add(-40*24*time.Hour), now.Add(-40*24*time.Hour))
+	stalePlan := initGCWorkingDir(t, dataDir, "2", now, now.Add(-10*24*time.Hour))
+	initGCWorkingDir(t, dataDir, "4", now.Add(-40*24*time.Hour), now.Add(-40*24*time.Hour))
+	_, err := planHistory.Add(command.ProjectContext{Pull: closedPull, RepoRelDir: "dir1", Workspace: "default"}, "")
+	Ok(t, err)
+
+	gc := &events.DefaultGarbageCollector{
+		Backend:                backend,
+		DataDir:                dataDir,
+		Logger:                 logging.NewNoopLogger(t),
+		PendingPlanFinder:      &events.DefaultPendingPlanFinder{},
+		WorkingDirLocker:       events.NewDefaultWorkingDirLocker(),
+		VCSClient:              vcsClient,
+		PlanCache:              planCache,
+		PlanHistory:            planHistory,
+		PlanMaxAge:             7 * 24 * time.Hour,
+		WorkingDirMaxAge:       30 * 24 * time.Hour,
+		CollectOrphanedEntries: true,
+	}
+	result, err := gc.CollectGarbage()
+	Ok(t, err)
+	Equals(t, models.GarbageCollectionResult{
+		Plans:        1,
+		WorkingDirs:  2,
+		PullStatuses: 2,
+		Locks:        2,
+	}, result)
+
+	_, err = os.Stat(filepath.Join(dataDir, "repos", "org", "app", "1"))
+	Assert(t, os.IsNotExist(err), "expected stale working dir to be deleted")
+	_, err = os.Stat(stalePlan)
+	Assert(t, os.IsNotExist(err), "expected stale plan to be deleted")
+	_, err = os.Stat(filepath.Join(dataDir, "repos", "org", "app", "2", "default"))
+	Ok(t, err)
+
+	backend.VerifyWasCalledOnce().UpdateProjectStatus(Eq(activePull), Eq("default"), Eq("dir1"), Eq(models.DiscardedPlanStatus))
+	// The working dir of stalePull was deleted since it wasn't used but the
+	// pull request is still open so its entries are kept.
+	backend.VerifyWasCalled(Never()).DeletePullStatus(Eq(stalePull))
+	backend.VerifyWasCalled(Never()).UnlockByPull(Eq("org/app"), Eq(1))
+	planCache.VerifyWasCalled(Never()).Delete(Eq(repo), Eq(stalePull))
+	backend.VerifyWasCalledOnce().DeletePullStatus(Eq(closedPull))
+	backend.VerifyWasCalledOnce().DeletePullStatus(Eq(staleClosedPull))
+	backend.VerifyWasCalled(Never()).DeletePullStatus(Eq(activePull))
+	backend.VerifyWasCalledOnce().UnlockByPull(Eq("org/app"), Eq(3))
+	backend.VerifyWasCalled(Never()).UnlockByPull(Eq("org/app"), Eq(2))
+	planCache.VerifyWasCalledOnce().Delete(Eq(repo), Eq(closedPull))
+	planCache.VerifyWasCalledOnce().Delete(Eq(repo), Eq(staleClosedPull))
+	history, err := planHistory.Get("org/app", 3)
+	Ok(t, err)
+	Equals(t, 0, len(history))
+	// The state of the pull request with a working dir isn't checked.
+	vcsClient.VerifyWasCalled(Never()).GetPullState(Any[logging.SimpleLogging](), Any[models.Repo](), Eq(activePull))
+}
+
+func TestDefaultGarbageCollector_CollectGarbage_DryRun(t *testing.T) {
+	RegisterMockTestingT(t)
+	dataDir := t.TempDir()
+	backend := lockmocks.NewMockBackend()
+	vcsClient := vcsmocks.NewMockClient()
+	stalePull := models.PullRequest{Num: 1, BaseRepo: models.Repo{FullName: "org/app"}}
+	When(backend.GetPullStatuses()).ThenReturn([]models.PullStatus{{Pull: stalePull}}, nil)
+	When(vcsClient.GetPullState(Any[logging.SimpleLogging](), Any[models.Repo](), Eq(stalePull))).ThenReturn(models.ClosedPullState, nil)
+	When(backend.List()).ThenReturn([]models.ProjectLock{{Pull: stalePull, Workspace: "default"}}, nil)
+
+	old := time.Now().Add(-40 * 24 * time.Hour)
+	plan := initGCWorkingDir(t, dataDir, "1", old, old)
+
+	gc := &events.DefaultGarbageCollector{
+		Backend:                backend,
+		DataDir:                dataDir,
+		Logger:                 logging.NewNoopLogger(t),
+		PendingPlanFinder:      &events.DefaultPendingPlanFinder{},
+		WorkingDirLocker:       events.NewDefaultWorkingDirLocker(),
+		VCSClient:              vcsClient,
+		WorkingDirMaxAge:       30 * 24 * time.Hour,
+		CollectOrphanedEntries: true,
+		DryRun:                 true,
+	}
+	result, err := gc.CollectGarbage()
+	Ok(t, err)
+	Equals(t, models.GarbageCollectionResult{
+		WorkingDirs:  1,
+		PullStatuses: 1,
+		Locks:        1,
+		DryRun:       true,
+	}, result)
+
+	_, err = os.Stat(plan)
+	Ok(t, err)
+	backend.VerifyWasCalled(Never()).DeletePullStatus(Any[models.PullRequest]())
+	backend.VerifyWasCalled(Never()).UnlockByPull(Any[string](), Any[int]())
+}
+
+func TestDefaultGarbageCollector_CollectGarbage_SkipsRunningCommands(t *testing.T) {
+	RegisterMockTestingT(t)
+	dataDir := t.TempDir()
+	backend := lockmocks.NewMockBackend()
+	When(backend.GetPullStatuses()).ThenReturn(nil, nil)
+	old := time.Now().Add(-40 * 24 * time.Hour)
+	initGCWorkingDir(t, dataDir, "1", old, old)
+
+	workingDirLocker := events.NewDefaultWorkingDirLocker()
+	unlock, err := workingDirLocker.TryLock("org/app", 1, "default", "dir1")
+	Ok(t, err)
+	defer unlock()
+
+	gc := &events.DefaultGarbageCollector{
+		Backend:           backend,
+		DataDir:           dataDir,
+		Logger:            logging.NewNoopLogger(t),
+		PendingPlanFinder: &events.DefaultPendingPlanFinder{},
+		WorkingDirLocker:  workingDirLocker,
+		PlanMaxAge:        7 * 24 * time.Hour,
+		WorkingDirMaxAge:  30 * 24 * time.Hour,
+	}
+	result, err := gc.CollectGarbage()
+	Ok(t, err)
+	Equals(t, models.GarbageCollectionResult{}, result)
+	_, err = os.Stat(filepath.Join(dataDir, "repos", "org", "app", "1"))
+	Ok(t, err)
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// GithubAppInstallationTokens mints the tokens of the installations of a
+// GitHub App.
+type GithubAppInstallationTokens interface {
+	// Token returns a token of the installation with installationID that
+	// isn't about to expire.
+	Token(installationID int64) (string, error)
+}
+
+// GitCredentialsResolver sets the clone URLs of repos to use the credentials
+// configured for them in the server-side repo config, so that repos of
+// different orgs can be cloned without a single token that can read all of
+// them. Credentials are resolved for every clone, so rotated keys and tokens
+// are used without restarting.
+type GitCredentialsResolver struct {
+	GlobalCfg valid.GlobalCfg
+	// GithubAppTokens mints the tokens of the installations of the server's
+	// GitHub App. If nil, repos can't be cloned with them.
+	GithubAppTokens GithubAppInstallationTokens
+
+	mu sync.Mutex
+	// tokenFiles caches the tokens read from token files by path until the
+	// files change.
+	tokenFiles map[string]cachedTokenFile
+}
+
+type cachedTokenFile struct {
+	modTime time.Time
+	size    int64
+	token   string
+}
+
+// Resolve returns repo with its clone URLs set to use its credentials, and
+// the SSH command to clone it with. The SSH command is empty unless the repo
+// is cloned with an SSH key. If no credentials are configured for the repo,
+// it's returned as it is.
+func (r *GitCredentialsResolver) Resolve(repo models.Repo) (models.Repo, string, error) {
+	creds := r.GlobalCfg.GitCredentials(repo.ID())
+	if creds == nil || repo.CloneURL == "" {
+		return repo, "", nil
+	}
+	cloneURL, err := url.Parse(repo.CloneURL)
+	if err != nil {
+		return repo, "", errors.Wrapf(err, "parsing clone URL of %s", repo.FullName)
+	}
+
+	if creds.SSHKeyFile != "" {
+		// The port of the HTTPS URL isn't the one of SSH.
+		repo.CloneURL = fmt.Sprintf("git@%s:%s", cloneURL.Hostname(), strings.TrimPrefix(cloneURL.Path, "/"))
+		repo.SanitizedCloneURL = repo.CloneURL
+		return repo, fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes", shellQuote(creds.SSHKeyFile)), nil
+	}
+
+	var user, token string
+	if creds.TokenFile != "" {
+		user = creds.TokenUser
+		token, err = r.readTokenFile(creds.TokenFile)
+	} else {
+		if r.GithubAppTokens == nil {
+			return repo, "", fmt.Errorf("cloning %s with github_app_installation_id requires a GitHub App", repo.FullName)
+		}
+		user = "x-access-token"
+		token, err = r.GithubAppTokens.Token(creds.GithubAppInstallationID)
+	}
+	if err != nil {
+		return repo, "", errors.Wrapf(err, "getting git credentials of %s", repo.FullName)
+	}
+
+	cloneURL.User = nil
+	scheme, rest, _ := strings.Cut(cloneURL.String(), "://")
+	repo.CloneURL = fmt.Sprintf("%s://%s:%s@%s", scheme, url.QueryEscape(user), url.QueryEscape(token), rest)
+	repo.SanitizedCloneURL = fmt.Sprintf("%s://%s:<redacted>@%s", scheme, url.QueryEscape(user), rest)
+	return repo, "", nil
+}
+
+// readTokenFile returns the token in the file at path, which is only read
+// again once it changes.
+func (r *GitCredentialsResolver) readTokenFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cached, ok := r.tokenFiles[path]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.token, nil
+	}
+	content, err := os.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", path)
+	}
+	if r.tokenFiles == nil {
+		r.tokenFiles = make(map[string]cachedTokenFile)
+	}
+	r.tokenFiles[path] = cachedTokenFile{modTime: info.ModTime(), size: info.Size(), token: token}
+	return token, nil
+}
+
+// shellQuote quotes s to be a single word for the shell git runs SSH commands
+// with.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// defaultGithubEnvironmentPollInterval is how often the deployment is polled
+// if GithubEnvironmentGate.PollInterval isn't set.
+const defaultGithubEnvironmentPollInterval = 10 * time.Second
+
+// GithubDeploymentClient makes the GitHub API calls to deploy to environments.
+type GithubDeploymentClient interface {
+	// GetEnvironmentProtectionRules returns the types of the protection rules
+	// of the environment of repo.
+	GetEnvironmentProtectionRules(logger logging.SimpleLogging, repo models.Repo, environment string) ([]string, error)
+	// CreateDeployment creates a deployment of ref to the environment of repo
+	// and returns its ID.
+	CreateDeployment(logger logging.SimpleLogging, repo models.Repo, ref string, environment string, description string) (int64, error)
+	// GetDeploymentState returns the state of the latest status of the
+	// deployment, or an empty string if it has none.
+	GetDeploymentState(logger logging.SimpleLogging, repo models.Repo, deploymentID int64) (string, error)
+	// UpdateDeploymentStatus sets the status of the deployment.
+	UpdateDeploymentStatus(logger logging.SimpleLogging, repo models.Repo, deploymentID int64, state string, description string) error
+}
+
+// GithubEnvironmentGate gates the apply of projects deployed to a GitHub
+// environment on the environment's protection rules. It creates a
+// deployment to the environment and waits until GitHub approves it, ex. once
+// the required reviewers approved it and the wait timer elapsed.
+type GithubEnvironmentGate struct {
+	Client GithubDeploymentClient
+	// PollInterval is the time between polls of the deployment. If 0,
+	// defaultGithubEnvironmentPollInterval is used.
+	PollInterval time.Duration
+}
+
+// Wait creates a deployment of the pull request's head commit to the GitHub
+// environment of the project and waits until its protection rules pass. It
+// returns the ID of the deployment, which must be finished with Finish once
+// the project is applied, or a failure if the deployment was rejected or
+// wasn't approved before the timeout.
+func (g *GithubEnvironmentGate) Wait(ctx command.ProjectContext) (deploymentID int64, failure string, err error) {
+	environment := ctx.GithubEnvironment
+	repo := ctx.Pull.BaseRepo
+	rules, err := g.Client.GetEnvironmentProtectionRules(ctx.Log, repo, environment.Name)
+	if err != nil {
+		return 0, "", errors.Wrapf(err, "getting protection rules of GitHub environment %q", environment.Name)
+	}
+	description := fmt.Sprintf("Atlantis apply of %s for pull request #%d by %s", g.projectDescription(ctx), ctx.Pull.Num, ctx.User.Username)
+	deploymentID, err = g.Client.CreateDeployment(ctx.Log, repo, ctx.Pull.HeadCommit, environment.Name, description)
+	if err != nil {
+		return 0, "", errors.Wrapf(err, "creating deployment to GitHub environment %q", environment.Name)
+	}
+	ctx.Log.Info("created deployment %d to GitHub environment %q", deploymentID, environment.Name)
+
+	if len(rules) > 0 {
+		pollInterval := g.PollInterval
+		if pollInterval == 0 {
+			pollInterval = defaultGithubEnvironmentPollInterval
+		}
+		deadline := time.Now().Add(environment.Timeout)
+		approved := false
+		for !approved {
+			state, err := g.Client.GetDeploymentState(ctx.Log, repo, deploymentID)
+			if err != nil {
+				return 0, "", errors.Wrapf(err, "getting status of deployment to GitHub environment %q", environment.Name)
+			}
+			switch state {
+			case "queued", "in_progress", "success":
+				approved = true
+			case "failure", "error", "inactive":
+				return 0, fmt.Sprintf("The deployment to GitHub environment %q was rejected by its protection rules.", environment.Name), nil
+			default:
+				if time.Now().After(deadline) {
+					g.updateStatus(ctx, deploymentID, "error", "Timed out waiting for the protection rules to pass")
+					return 0, fmt.Sprintf("Timed out after %s waiting for the protection rules of GitHub environment %q to pass.", environment.Timeout, environment.Name), nil
+				}
+				ctx.Log.Debug("waiting for protection rules of GitHub environment %q, deployment is %q", environment.Name, state)
+				time.Sleep(pollInterval)
+			}
+		}
+		ctx.Log.Info("deployment to GitHub environment %q was approved", environment.Name)
+	}
+	g.updateStatus(ctx, deploymentID, "in_progress", "Applying")
+	return deploymentID, "", nil
+}
+
+// Finish sets the status of the deployment with deploymentID to whether the
+// project was applied successfully.
+func (g *GithubEnvironmentGate) Finish(ctx command.ProjectContext, deploymentID int64, applyErr error) {
+	if applyErr != nil {
+		g.updateStatus(ctx, deploymentID, "failure", "Apply failed")
+		return
+	}
+	g.updateStatus(ctx, deploymentID, "success", "Applied")
+}
+
+// updateStatus sets the status of the deployment, only logging errors since
+// the deployment's status doesn't change the outcome of the apply.
+func (g *GithubEnvironmentGate) updateStatus(ctx command.ProjectContext, deploymentID int64, state string, description string) {
+	if err := g.Client.UpdateDeploymentStatus(ctx.Log, ctx.Pull.BaseRepo, deploymentID, state, description); err != nil {
+		ctx.Log.Warn("unable to set status of deployment %d to %q: %s", deploymentID, state, err)
+	}
+}
+
+func (g *GithubEnvironmentGate) projectDescription(ctx command.ProjectContext) string {
+	if ctx.ProjectName != "" {
+		return fmt.Sprintf("project %s", ctx.ProjectName)
+	}
+	return fmt.Sprintf("dir %s workspace %s", ctx.RepoRelDir, ctx.Workspace)
+}
@@ -0,0 +1,109 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeGithubDeploymentClient returns the next deployment state on every poll,
+// repeating the last one.
+type fakeGithubDeploymentClient struct {
+	rules    []string
+	states   []string
+	statuses []string
+}
+
+func (f *fakeGithubDeploymentClient) GetEnvironmentProtectionRules(_ logging.SimpleLogging, _ models.Repo, _ string) ([]string, error) {
+	return f.rules, nil
+}
+
+func (f *fakeGithubDeploymentClient) CreateDeployment(_ logging.SimpleLogging, _ models.Repo, _ string, _ string, _ string) (int64, error) {
+	return 42, nil
+}
+
+func (f *fakeGithubDeploymentClient) GetDeploymentState(_ logging.SimpleLogging, _ models.Repo, _ int64) (string, error) {
+	state := f.states[0]
+	if len(f.states) > 1 {
+		f.states = f.states[1:]
+	}
+	return state, nil
+}
+
+func (f *fakeGithubDeploymentClient) UpdateDeploymentStatus(_ logging.SimpleLogging, _ models.Repo, _ int64, state string, _ string) error {
+	f.statuses = append(f.statuses, state)
+	return nil
+}
+
+func newTestGithubEnvironmentGate(t *testing.T, client *fakeGithubDeploymentClient, timeout time.Duration) (*events.GithubEnvironmentGate, command.ProjectContext) {
+	gate := &events.GithubEnvironmentGate{
+		Client:       client,
+		PollInterval: time.Millisecond,
+	}
+	ctx := command.ProjectContext{
+		Log:               logging.NewNoopLogger(t),
+		Pull:              models.PullRequest{Num: 1, HeadCommit: "sha", BaseRepo: models.Repo{FullName: "org/infra"}},
+		ProjectName:       "network",
+		GithubEnvironment: &valid.GithubEnvironment{Name: "production", Timeout: timeout},
+	}
+	return gate, ctx
+}
+
+func TestGithubEnvironmentGate_Wait(t *testing.T) {
+	client := &fakeGithubDeploymentClient{
+		rules:  []string{"required_reviewers"},
+		states: []string{"", "waiting", "queued"},
+	}
+	gate, ctx := newTestGithubEnvironmentGate(t, client, time.Minute)
+	deploymentID, failure, err := gate.Wait(ctx)
+	Ok(t, err)
+	Equals(t, "", failure)
+	Equals(t, int64(42), deploymentID)
+
+	gate.Finish(ctx, deploymentID, nil)
+	Equals(t, []string{"in_progress", "success"}, client.statuses)
+}
+
+// Without protection rules the deployment isn't waited for.
+func TestGithubEnvironmentGate_WaitNoProtectionRules(t *testing.T) {
+	client := &fakeGithubDeploymentClient{}
+	gate, ctx := newTestGithubEnvironmentGate(t, client, time.Minute)
+	deploymentID, failure, err := gate.Wait(ctx)
+	Ok(t, err)
+	Equals(t, "", failure)
+	Equals(t, int64(42), deploymentID)
+
+	gate.Finish(ctx, deploymentID, errors.New("apply failed"))
+	Equals(t, []string{"in_progress", "failure"}, client.statuses)
+}
+
+func TestGithubEnvironmentGate_WaitRejected(t *testing.T) {
+	client := &fakeGithubDeploymentClient{
+		rules:  []string{"required_reviewers"},
+		states: []string{"waiting", "failure"},
+	}
+	gate, ctx := newTestGithubEnvironmentGate(t, client, time.Minute)
+	_, failure, err := gate.Wait(ctx)
+	Ok(t, err)
+	Equals(t, `The deployment to GitHub environment "production" was rejected by its protection rules.`, failure)
+	Equals(t, 0, len(client.statuses))
+}
+
+func TestGithubEnvironmentGate_WaitTimeout(t *testing.T) {
+	client := &fakeGithubDeploymentClient{
+		rules:  []string{"wait_timer"},
+		states: []string{"waiting"},
+	}
+	gate, ctx := newTestGithubEnvironmentGate(t, client, 5*time.Millisecond)
+	_, failure, err := gate.Wait(ctx)
+	Ok(t, err)
+	Equals(t, `Timed out after 5ms waiting for the protection rules of GitHub environment "production" to pass.`, failure)
+	Equals(t, []string{"error"}, client.statuses)
+}
@@ -0,0 +1,110 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/utils"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// defaultGitlabCIPollInterval is how often the jobs are polled if
+// GitlabCIJobWaiter.PollInterval isn't set.
+const defaultGitlabCIPollInterval = 10 * time.Second
+
+// GitlabCIClient makes the GitLab API calls to wait for and trigger CI jobs.
+type GitlabCIClient interface {
+	// GetPipelineJobs returns the jobs of the latest pipeline for the commit
+	// sha of repo.
+	GetPipelineJobs(logger logging.SimpleLogging, repo models.Repo, sha string) ([]*gitlab.Job, error)
+	// PlayJob starts the manual job with jobID of repo.
+	PlayJob(logger logging.SimpleLogging, repo models.Repo, jobID int) error
+}
+
+// GitlabCIJobWaiter waits for the GitLab CI jobs that must succeed before a
+// command is run on a merge request, starting the manual ones it's configured
+// to trigger.
+type GitlabCIJobWaiter struct {
+	Client    GitlabCIClient
+	GlobalCfg valid.GlobalCfg
+	// PollInterval is the time between polls of the jobs. If 0,
+	// defaultGitlabCIPollInterval is used.
+	PollInterval time.Duration
+}
+
+// Wait waits until the jobs configured in the GitLab CI config of the merge
+// request's repo have succeeded. It returns an error if one of them fails or
+// if they don't finish before the timeout. It returns right away if the
+// command named cmdName doesn't wait for jobs.
+func (w *GitlabCIJobWaiter) Wait(ctx *command.Context, cmdName command.Name) error {
+	if ctx.Pull.BaseRepo.VCSHost.Type != models.Gitlab {
+		return nil
+	}
+	gitlabCI := w.GlobalCfg.GitlabCI(ctx.Pull.BaseRepo.ID())
+	if gitlabCI == nil || !gitlabCI.AppliesTo(cmdName.String()) {
+		return nil
+	}
+	pollInterval := w.PollInterval
+	if pollInterval == 0 {
+		pollInterval = defaultGitlabCIPollInterval
+	}
+
+	ctx.Log.Info("waiting for GitLab CI jobs %s", strings.Join(gitlabCI.Jobs(), ", "))
+	deadline := time.Now().Add(gitlabCI.Timeout)
+	played := make(map[int]bool)
+	for {
+		jobs, err := w.Client.GetPipelineJobs(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.HeadCommit)
+		if err != nil {
+			return errors.Wrap(err, "getting GitLab CI jobs")
+		}
+		jobsByName := make(map[string]*gitlab.Job)
+		for _, job := range jobs {
+			jobsByName[job.Name] = job
+		}
+
+		for _, name := range gitlabCI.TriggerJobs {
+			job, ok := jobsByName[name]
+			if !ok || job.Status != "manual" || played[job.ID] {
+				continue
+			}
+			ctx.Log.Info("triggering GitLab CI job %q", name)
+			if err := w.Client.PlayJob(ctx.Log, ctx.Pull.BaseRepo, job.ID); err != nil {
+				return errors.Wrapf(err, "triggering GitLab CI job %q", name)
+			}
+			played[job.ID] = true
+		}
+
+		var unfinished []string
+		for _, name := range gitlabCI.Jobs() {
+			job, ok := jobsByName[name]
+			if !ok {
+				unfinished = append(unfinished, fmt.Sprintf("%s (not created)", name))
+				continue
+			}
+			switch {
+			case job.Status == "success", job.Status == "failed" && job.AllowFailure:
+			case job.Status == "failed", job.Status == "canceled", job.Status == "skipped":
+				return fmt.Errorf("GitLab CI job %q %s: %s", name, job.Status, job.WebURL)
+			case job.Status == "manual" && !utils.SlicesContains(gitlabCI.TriggerJobs, name):
+				return fmt.Errorf("GitLab CI job %q is a manual job, add it to trigger_jobs to start it", name)
+			default:
+				unfinished = append(unfinished, fmt.Sprintf("%s (%s)", name, job.Status))
+			}
+		}
+		if len(unfinished) == 0 {
+			ctx.Log.Info("GitLab CI jobs succeeded")
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for GitLab CI jobs: %s", gitlabCI.Timeout, strings.Join(unfinished, ", "))
+		}
+		ctx.Log.Debug("waiting for GitLab CI jobs %s", strings.Join(unfinished, ", "))
+		time.Sleep(pollInterval)
+	}
+}
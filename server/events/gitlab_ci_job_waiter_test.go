@@ -0,0 +1,118 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// fakeGitlabCIClient returns the next set of jobs on every poll, repeating
+// the last one.
+type fakeGitlabCIClient struct {
+	polls  [][]*gitlab.Job
+	played []int
+}
+
+func (f *fakeGitlabCIClient) GetPipelineJobs(_ logging.SimpleLogging, _ models.Repo, _ string) ([]*gitlab.Job, error) {
+	jobs := f.polls[0]
+	if len(f.polls) > 1 {
+		f.polls = f.polls[1:]
+	}
+	return jobs, nil
+}
+
+func (f *fakeGitlabCIClient) PlayJob(_ logging.SimpleLogging, _ models.Repo, jobID int) error {
+	f.played = append(f.played, jobID)
+	return nil
+}
+
+func newTestGitlabCIJobWaiter(t *testing.T, gitlabCI *valid.GitlabCI, polls ...[]*gitlab.Job) (*events.GitlabCIJobWaiter, *fakeGitlabCIClient, *command.Context) {
+	client := &fakeGitlabCIClient{polls: polls}
+	globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	globalCfg.Repos = append(globalCfg.Repos, valid.Repo{ID: "gitlab.com/org/infra", GitlabCI: gitlabCI})
+	repo := models.Repo{
+		FullName: "org/infra",
+		VCSHost:  models.VCSHost{Hostname: "gitlab.com", Type: models.Gitlab},
+	}
+	ctx := &command.Context{
+		Log:  logging.NewNoopLogger(t),
+		Pull: models.PullRequest{BaseRepo: repo, HeadCommit: "sha"},
+	}
+	waiter := &events.GitlabCIJobWaiter{
+		Client:       client,
+		GlobalCfg:    globalCfg,
+		PollInterval: time.Millisecond,
+	}
+	return waiter, client, ctx
+}
+
+func TestGitlabCIJobWaiter_Wait(t *testing.T) {
+	gitlabCI := &valid.GitlabCI{
+		WaitForJobs: []string{"lint"},
+		TriggerJobs: []string{"scan"},
+		Commands:    []string{"plan", "apply"},
+		Timeout:     time.Minute,
+	}
+	waiter, client, ctx := newTestGitlabCIJobWaiter(t, gitlabCI,
+		nil,
+		[]*gitlab.Job{{ID: 1, Name: "lint", Status: "running"}, {ID: 2, Name: "scan", Status: "manual"}},
+		[]*gitlab.Job{{ID: 1, Name: "lint", Status: "success"}, {ID: 2, Name: "scan", Status: "manual"}},
+		[]*gitlab.Job{{ID: 1, Name: "lint", Status: "success"}, {ID: 2, Name: "scan", Status: "running"}},
+		[]*gitlab.Job{{ID: 1, Name: "lint", Status: "success"}, {ID: 2, Name: "scan", Status: "success"}},
+	)
+	Ok(t, waiter.Wait(ctx, command.Plan))
+	// The manual job is only started once.
+	Equals(t, []int{2}, client.played)
+}
+
+func TestGitlabCIJobWaiter_WaitAllowedFailure(t *testing.T) {
+	gitlabCI := &valid.GitlabCI{WaitForJobs: []string{"lint"}, Commands: []string{"plan"}, Timeout: time.Minute}
+	waiter, _, ctx := newTestGitlabCIJobWaiter(t, gitlabCI,
+		[]*gitlab.Job{{ID: 1, Name: "lint", Status: "failed", AllowFailure: true}},
+	)
+	Ok(t, waiter.Wait(ctx, command.Plan))
+}
+
+func TestGitlabCIJobWaiter_WaitFailed(t *testing.T) {
+	gitlabCI := &valid.GitlabCI{WaitForJobs: []string{"lint"}, Commands: []string{"plan"}, Timeout: time.Minute}
+	waiter, _, ctx := newTestGitlabCIJobWaiter(t, gitlabCI,
+		[]*gitlab.Job{{ID: 1, Name: "lint", Status: "failed", WebURL: "https://gitlab.com/org/infra/-/jobs/1"}},
+	)
+	ErrEquals(t, `GitLab CI job "lint" failed: https://gitlab.com/org/infra/-/jobs/1`, waiter.Wait(ctx, command.Plan))
+}
+
+func TestGitlabCIJobWaiter_WaitManualJob(t *testing.T) {
+	gitlabCI := &valid.GitlabCI{WaitForJobs: []string{"deploy"}, Commands: []string{"apply"}, Timeout: time.Minute}
+	waiter, client, ctx := newTestGitlabCIJobWaiter(t, gitlabCI,
+		[]*gitlab.Job{{ID: 1, Name: "deploy", Status: "manual"}},
+	)
+	ErrEquals(t, `GitLab CI job "deploy" is a manual job, add it to trigger_jobs to start it`, waiter.Wait(ctx, command.Apply))
+	Equals(t, 0, len(client.played))
+}
+
+func TestGitlabCIJobWaiter_WaitTimeout(t *testing.T) {
+	gitlabCI := &valid.GitlabCI{WaitForJobs: []string{"lint", "test"}, Commands: []string{"plan"}, Timeout: 10 * time.Millisecond}
+	waiter, _, ctx := newTestGitlabCIJobWaiter(t, gitlabCI,
+		[]*gitlab.Job{{ID: 1, Name: "lint", Status: "pending"}},
+	)
+	ErrEquals(t, "timed out after 10ms waiting for GitLab CI jobs: lint (pending), test (not created)", waiter.Wait(ctx, command.Plan))
+}
+
+// Commands that don't wait for jobs and other VCS hosts return right away.
+func TestGitlabCIJobWaiter_WaitNotConfigured(t *testing.T) {
+	gitlabCI := &valid.GitlabCI{WaitForJobs: []string{"lint"}, Commands: []string{"apply"}, Timeout: time.Minute}
+	waiter, _, ctx := newTestGitlabCIJobWaiter(t, gitlabCI,
+		[]*gitlab.Job{{ID: 1, Name: "lint", Status: "failed"}},
+	)
+	Ok(t, waiter.Wait(ctx, command.Plan))
+
+	ctx.Pull.BaseRepo.VCSHost.Type = models.Github
+	Ok(t, waiter.Wait(ctx, command.Apply))
+}
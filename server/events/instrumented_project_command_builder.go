@@ -1,6 +1,8 @@
 package events
 
 import (
+	"fmt"
+
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
@@ -49,15 +51,42 @@ func (b *InstrumentedProjectCommandBuilder) BuildImportCommands(ctx *command.Con
 	)
 }
 
-func (b *InstrumentedProjectCommandBuilder) BuildStateRmCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+func (b *InstrumentedProjectCommandBuilder) BuildStateCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
 	return b.buildAndEmitStats(
 		"state rm",
 		func() ([]command.ProjectContext, error) {
-			return b.ProjectCommandBuilder.BuildStateRmCommands(ctx, comment)
+			return b.ProjectCommandBuilder.BuildStateCommands(ctx, comment)
+		},
+	)
+}
+
+func (b *InstrumentedProjectCommandBuilder) BuildCustomCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+	return b.buildAndEmitStats(
+		"custom",
+		func() ([]command.ProjectContext, error) {
+			return b.ProjectCommandBuilder.BuildCustomCommands(ctx, comment)
+		},
+	)
+}
+
+func (b *InstrumentedProjectCommandBuilder) BuildDestroyCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+	return b.buildAndEmitStats(
+		"destroy",
+		func() ([]command.ProjectContext, error) {
+			return b.ProjectCommandBuilder.BuildDestroyCommands(ctx, comment)
 		},
 	)
 }
 
+// SimulateAutoplan simulates an autoplan if the wrapped builder supports it.
+func (b *InstrumentedProjectCommandBuilder) SimulateAutoplan(ctx *command.Context, modifiedFiles []string) (AutoplanSimulation, error) {
+	simulator, ok := b.ProjectCommandBuilder.(AutoplanSimulator)
+	if !ok {
+		return AutoplanSimulation{}, fmt.Errorf("simulating autoplans is not supported")
+	}
+	return simulator.SimulateAutoplan(ctx, modifiedFiles)
+}
+
 func (b *InstrumentedProjectCommandBuilder) buildAndEmitStats(
 	command string,
 	execute func() ([]command.ProjectContext, error),
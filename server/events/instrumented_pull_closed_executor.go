@@ -29,7 +29,7 @@ func NewInstrumentedPullClosedExecutor(
 	}
 }
 
-func (e *InstrumentedPullClosedExecutor) CleanUpPull(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) error {
+func (e *InstrumentedPullClosedExecutor) CleanUpPull(logger logging.SimpleLogging, repo models.Repo, headRepo models.Repo, pull models.PullRequest) error {
 
 	executionSuccess := e.scope.Counter(metrics.ExecutionSuccessMetric)
 	executionError := e.scope.Counter(metrics.ExecutionErrorMetric)
@@ -38,7 +38,7 @@ func (e *InstrumentedPullClosedExecutor) CleanUpPull(logger logging.SimpleLoggin
 
 	logger.Info("Initiating cleanup of pull data.")
 
-	err := e.cleaner.CleanUpPull(logger, repo, pull)
+	err := e.cleaner.CleanUpPull(logger, repo, headRepo, pull)
 
 	if err != nil {
 		executionError.Inc(1)
@@ -0,0 +1,156 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// The types of LockReason.
+const (
+	// ProjectLockReason is a project lock held by another pull request.
+	ProjectLockReason = "project_lock"
+	// RunningCommandReason is a command that's running on the project.
+	RunningCommandReason = "running_command"
+	// ApplyLockReason is the global apply lock or apply being disabled.
+	ApplyLockReason = "apply_lock"
+	// CircuitBreakerReason is the circuit of a pull request being open.
+	CircuitBreakerReason = "circuit_breaker"
+)
+
+// LockExplanation is everything that stops commands from running on a
+// project right now.
+type LockExplanation struct {
+	Repository string
+	Path       string
+	Workspace  string
+	// Blocked is true if anything stops commands from running.
+	Blocked bool
+	// Reasons is what stops commands from running. It's empty if nothing
+	// does.
+	Reasons []LockReason
+}
+
+// LockReason is one thing that stops commands from running on a project.
+type LockReason struct {
+	// Type is one of project_lock, running_command, apply_lock or
+	// circuit_breaker.
+	Type string
+	// Message explains the reason.
+	Message string
+	// Commands are the commands it stops, ex. plan and apply.
+	Commands []string
+	// PullNum is the pull request that holds the lock, if any.
+	PullNum int `json:",omitempty"`
+	// PullURL is the URL of the pull request that holds the lock, if known.
+	PullURL string `json:",omitempty"`
+	// User is who ran the command that took the lock, if known.
+	User string `json:",omitempty"`
+	// Since is when the lock was taken, if known.
+	Since *time.Time `json:",omitempty"`
+	// Until is when the reason goes away on its own, if it does.
+	Until *time.Time `json:",omitempty"`
+}
+
+// LockExplainer explains why commands can't run on a project by gathering
+// the project locks in the database, the working dir locks of running
+// commands, the global apply lock and the circuit breaker.
+type LockExplainer struct {
+	Backend          locking.Backend
+	WorkingDirLocks  WorkingDirLockLister
+	ApplyLockChecker locking.ApplyLockChecker
+	// CircuitBreaker is nil if it's disabled.
+	CircuitBreaker *CircuitBreaker
+}
+
+// Explain returns what stops commands from running on the project at path
+// in the repo named repoFullName in workspace.
+func (l *LockExplainer) Explain(repoFullName string, path string, workspace string) (LockExplanation, error) {
+	explanation := LockExplanation{
+		Repository: repoFullName,
+		Path:       path,
+		Workspace:  workspace,
+		Reasons:    []LockReason{},
+	}
+
+	lock, err := l.Backend.GetLock(models.NewProject(repoFullName, path, ""), workspace)
+	if err != nil {
+		return explanation, errors.Wrap(err, "getting project lock")
+	}
+	lockPull := 0
+	if lock != nil {
+		lockPull = lock.Pull.Num
+		since := lock.Time
+		explanation.Reasons = append(explanation.Reasons, LockReason{
+			Type: ProjectLockReason,
+			Message: fmt.Sprintf("locked by pull request #%d since %s, other pull requests can't plan or apply it until it's applied, unlocked or closed",
+				lock.Pull.Num, lock.Time.Format(time.RFC3339)),
+			Commands: []string{"plan", "apply"},
+			PullNum:  lock.Pull.Num,
+			PullURL:  lock.Pull.URL,
+			User:     lock.User.Username,
+			Since:    &since,
+		})
+	}
+
+	if l.WorkingDirLocks != nil {
+		for _, dirLock := range l.WorkingDirLocks.Locks(repoFullName) {
+			switch {
+			case dirLock.Workspace == workspace && dirLock.Path == path:
+				explanation.Reasons = append(explanation.Reasons, LockReason{
+					Type:     RunningCommandReason,
+					Message:  fmt.Sprintf("pull request #%d is running a command on it, other commands of that pull request can't run on it until it's done", dirLock.PullNum),
+					Commands: []string{"all"},
+					PullNum:  dirLock.PullNum,
+				})
+			case dirLock.Workspace == "" && dirLock.PullNum == lockPull:
+				explanation.Reasons = append(explanation.Reasons, LockReason{
+					Type:     RunningCommandReason,
+					Message:  fmt.Sprintf("pull request #%d is running a command on all of its projects, other commands of that pull request can't run until it's done", dirLock.PullNum),
+					Commands: []string{"all"},
+					PullNum:  dirLock.PullNum,
+				})
+			}
+		}
+	}
+
+	if l.ApplyLockChecker != nil {
+		applyLock, err := l.ApplyLockChecker.CheckApplyLock()
+		if err != nil {
+			return explanation, errors.Wrap(err, "checking apply lock")
+		}
+		if applyLock.Locked {
+			reason := LockReason{
+				Type:     ApplyLockReason,
+				Message:  "apply is disabled on this Atlantis server",
+				Commands: []string{"apply"},
+			}
+			if !applyLock.Time.IsZero() {
+				since := applyLock.Time
+				reason.Message = fmt.Sprintf("apply has been locked for all repos since %s, it can be unlocked on the Atlantis UI", since.Format(time.RFC3339))
+				reason.Since = &since
+			}
+			explanation.Reasons = append(explanation.Reasons, reason)
+		}
+	}
+
+	if l.CircuitBreaker != nil {
+		for _, open := range l.CircuitBreaker.Status(repoFullName) {
+			until := open.Until
+			explanation.Reasons = append(explanation.Reasons, LockReason{
+				Type: CircuitBreakerReason,
+				Message: fmt.Sprintf("commands aren't run on pull request #%d until %s since its last %d projects failed with credential or provider errors, the last one was: %s",
+					open.PullNum, until.Format(time.RFC3339), open.Failures, open.LastErr),
+				Commands: []string{"all"},
+				PullNum:  open.PullNum,
+				Until:    &until,
+			})
+		}
+	}
+
+	explanation.Blocked = len(explanation.Reasons) > 0
+	return explanation, nil
+}
@@ -0,0 +1,84 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	lockmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestLockExplainer_Explain(t *testing.T) {
+	RegisterMockTestingT(t)
+	backend := lockmocks.NewMockBackend()
+	applyLockChecker := lockmocks.NewMockApplyLockChecker()
+	lockTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	project := models.NewProject("owner/repo", "dir", "")
+	When(backend.GetLock(project, "default")).ThenReturn(&models.ProjectLock{
+		Project:   project,
+		Pull:      models.PullRequest{Num: 1, URL: "https://github.com/owner/repo/pull/1"},
+		User:      models.User{Username: "user"},
+		Workspace: "default",
+		Time:      lockTime,
+	}, nil)
+	When(applyLockChecker.CheckApplyLock()).ThenReturn(locking.ApplyCommandLock{Locked: true, GlobalApplyLockEnabled: true, Time: lockTime}, nil)
+	workingDirLocker := events.NewDefaultWorkingDirLocker()
+	_, err := workingDirLocker.TryLock("owner/repo", 2, "default", "dir")
+	Ok(t, err)
+	_, err = workingDirLocker.TryLock("owner/repo", 3, "default", "other")
+	Ok(t, err)
+
+	explainer := &events.LockExplainer{
+		Backend:          backend,
+		WorkingDirLocks:  workingDirLocker,
+		ApplyLockChecker: applyLockChecker,
+	}
+	explanation, err := explainer.Explain("owner/repo", "dir", "default")
+	Ok(t, err)
+	Equals(t, events.LockExplanation{
+		Repository: "owner/repo",
+		Path:       "dir",
+		Workspace:  "default",
+		Blocked:    true,
+		Reasons: []events.LockReason{
+			{
+				Type:     events.ProjectLockReason,
+				Message:  "locked by pull request #1 since 2024-01-02T03:04:05Z, other pull requests can't plan or apply it until it's applied, unlocked or closed",
+				Commands: []string{"plan", "apply"},
+				PullNum:  1,
+				PullURL:  "https://github.com/owner/repo/pull/1",
+				User:     "user",
+				Since:    &lockTime,
+			},
+			{
+				Type:     events.RunningCommandReason,
+				Message:  "pull request #2 is running a command on it, other commands of that pull request can't run on it until it's done",
+				Commands: []string{"all"},
+				PullNum:  2,
+			},
+			{
+				Type:     events.ApplyLockReason,
+				Message:  "apply has been locked for all repos since 2024-01-02T03:04:05Z, it can be unlocked on the Atlantis UI",
+				Commands: []string{"apply"},
+				Since:    &lockTime,
+			},
+		},
+	}, explanation)
+}
+
+func TestLockExplainer_Explain_NotBlocked(t *testing.T) {
+	RegisterMockTestingT(t)
+	backend := lockmocks.NewMockBackend()
+	explainer := &events.LockExplainer{
+		Backend:         backend,
+		WorkingDirLocks: events.NewDefaultWorkingDirLocker(),
+	}
+	explanation, err := explainer.Explain("owner/repo", "dir", "default")
+	Ok(t, err)
+	Equals(t, false, explanation.Blocked)
+	Equals(t, []events.LockReason{}, explanation.Reasons)
+}
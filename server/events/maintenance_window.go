@@ -0,0 +1,40 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// MaintenanceWindowChecker returns the maintenance window of a repo that t
+// is in. It's implemented by valid.GlobalCfg.
+type MaintenanceWindowChecker interface {
+	// ActiveMaintenanceWindow returns the maintenance window of the repo with
+	// repoID that t is in and when applies are allowed again, which is zero
+	// if never. It returns nil if t isn't in a window.
+	ActiveMaintenanceWindow(repoID string, t time.Time) (*valid.MaintenanceWindow, time.Time)
+}
+
+// maintenanceWindowMessage describes the maintenance window that applies of
+// the repo with repoID are disabled by at t. It returns an empty string if
+// applies are allowed.
+func maintenanceWindowMessage(checker MaintenanceWindowChecker, repoID string, t time.Time) string {
+	if checker == nil {
+		return ""
+	}
+	window, end := checker.ActiveMaintenanceWindow(repoID, t)
+	if window == nil {
+		return ""
+	}
+	msg := "Running `atlantis apply` is disabled during a maintenance window"
+	if end.IsZero() {
+		msg += "."
+	} else {
+		msg += fmt.Sprintf(" until %s.", end.UTC().Format(time.RFC1123))
+	}
+	if window.Description != "" {
+		msg += " " + window.Description
+	}
+	return msg
+}
@@ -17,8 +17,10 @@ import (
 	"bytes"
 	"embed"
 	"fmt"
+	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/runatlantis/atlantis/server/events/command"
@@ -35,9 +37,14 @@ var (
 	versionCommandTitle         = command.Version.TitleString()
 	importCommandTitle          = command.Import.TitleString()
 	stateCommandTitle           = command.State.TitleString()
+	customCommandTitle          = command.Custom.TitleString()
+	destroyCommandTitle         = command.Destroy.TitleString()
 	// maxUnwrappedLines is the maximum number of lines the Terraform output
 	// can be before we wrap it in an expandable template.
 	maxUnwrappedLines = 12
+	// reApplyComplete matches the one line summary of the changes an apply
+	// made.
+	reApplyComplete = regexp.MustCompile(`Apply complete! Resources: .*`)
 
 	//go:embed templates/*
 	templatesFS embed.FS
@@ -65,6 +72,7 @@ type commonData struct {
 	Command                   string
 	SubCommand                string
 	Verbose                   bool
+	Quiet                     bool
 	Log                       string
 	PlansDeleted              bool
 	DisableApplyAll           bool
@@ -120,6 +128,14 @@ type planSuccessData struct {
 	DisableRepoLocking       bool
 	EnableDiffMarkdownFormat bool
 	PlanStats                models.PlanSuccessStats
+	JobURL                   string
+}
+
+// applyQuietSuccessData is the data of a successful apply rendered with
+// --quiet.
+type applyQuietSuccessData struct {
+	Summary string
+	JobURL  string
 }
 
 type policyCheckResultsData struct {
@@ -133,12 +149,13 @@ type policyCheckResultsData struct {
 }
 
 type projectResultTmplData struct {
-	Workspace    string
-	RepoRelDir   string
-	ProjectName  string
-	Rendered     string
-	NoChanges    bool
-	IsSuccessful bool
+	Workspace        string
+	RepoRelDir       string
+	ProjectName      string
+	TerraformVersion string
+	Rendered         string
+	NoChanges        bool
+	IsSuccessful     bool
 }
 
 // Initialize templates
@@ -189,6 +206,7 @@ func (m *MarkdownRenderer) Render(ctx *command.Context, res command.Result, cmd
 		Command:                   commandStr,
 		SubCommand:                cmd.SubCommandName(),
 		Verbose:                   cmd.IsVerbose(),
+		Quiet:                     cmd.IsQuiet(),
 		Log:                       ctx.Log.GetHistory(),
 		PlansDeleted:              res.PlansDeleted,
 		DisableApplyAll:           m.disableApplyAll || m.disableApply,
@@ -201,6 +219,22 @@ func (m *MarkdownRenderer) Render(ctx *command.Context, res command.Result, cmd
 		VcsRequestType:            vcsRequestType,
 	}
 
+	rendered := m.renderDefault(ctx, res, common)
+	kind := commentTemplateKind(res, cmd)
+	text, ok := ctx.CommentTemplates[kind]
+	if !ok {
+		return rendered
+	}
+	custom, err := renderCommentTemplate(kind, text, newCommentTemplateData(ctx, res, cmd, common, rendered))
+	if err != nil {
+		ctx.Log.Warn("rendering %s comment template, using the default: %s", kind, err)
+		return rendered
+	}
+	return custom
+}
+
+// renderDefault renders res with the default templates.
+func (m *MarkdownRenderer) renderDefault(ctx *command.Context, res command.Result, common commonData) string {
 	templates := m.markdownTemplates
 
 	if res.Error != nil {
@@ -237,6 +271,7 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 		}
 		if result.PlanSuccess != nil {
 			result.PlanSuccess.TerraformOutput = strings.TrimSpace(result.PlanSuccess.TerraformOutput)
+			resultData.TerraformVersion = result.PlanSuccess.TerraformVersion
 			data := planSuccessData{
 				PlanSuccess:              *result.PlanSuccess,
 				PlanWasDeleted:           common.PlansDeleted,
@@ -244,8 +279,12 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 				DisableRepoLocking:       common.DisableRepoLocking,
 				EnableDiffMarkdownFormat: common.EnableDiffMarkdownFormat,
 				PlanStats:                result.PlanSuccess.Stats(),
+				JobURL:                   result.JobURL,
 			}
-			if m.shouldUseWrappedTmpl(vcsHost, result.PlanSuccess.TerraformOutput) {
+			if common.Quiet {
+				data.PlanSummary = result.PlanSuccess.Summary()
+				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("planSuccessQuiet"), data)
+			} else if m.shouldUseWrappedTmpl(vcsHost, result.PlanSuccess.TerraformOutput) {
 				data.PlanSummary = result.PlanSuccess.Summary()
 				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("planSuccessWrapped"), data)
 			} else {
@@ -294,7 +333,10 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 			}
 		} else if result.ApplySuccess != "" {
 			output := strings.TrimSpace(result.ApplySuccess)
-			if m.shouldUseWrappedTmpl(vcsHost, result.ApplySuccess) {
+			if common.Quiet {
+				data := applyQuietSuccessData{Summary: reApplyComplete.FindString(output), JobURL: result.JobURL}
+				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("applyQuietSuccess"), data)
+			} else if m.shouldUseWrappedTmpl(vcsHost, result.ApplySuccess) {
 				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("applyWrappedSuccess"), struct{ Output string }{output})
 			} else {
 				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("applyUnwrappedSuccess"), struct{ Output string }{output})
@@ -322,6 +364,27 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 			} else {
 				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("stateRmSuccessUnwrapped"), result.StateRmSuccess)
 			}
+		} else if result.StatePullSuccess != nil {
+			result.StatePullSuccess.Output = strings.TrimSpace(result.StatePullSuccess.Output)
+			if m.shouldUseWrappedTmpl(vcsHost, result.StatePullSuccess.Output) {
+				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("statePullSuccessWrapped"), result.StatePullSuccess)
+			} else {
+				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("statePullSuccessUnwrapped"), result.StatePullSuccess)
+			}
+		} else if result.StatePushSuccess != nil {
+			result.StatePushSuccess.Output = strings.TrimSpace(result.StatePushSuccess.Output)
+			if m.shouldUseWrappedTmpl(vcsHost, result.StatePushSuccess.Output) {
+				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("statePushSuccessWrapped"), result.StatePushSuccess)
+			} else {
+				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("statePushSuccessUnwrapped"), result.StatePushSuccess)
+			}
+		} else if result.Command == command.Custom && result.Error == nil && result.Failure == "" {
+			output := strings.TrimSpace(result.CustomSuccess)
+			if m.shouldUseWrappedTmpl(vcsHost, output) {
+				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("customSuccessWrapped"), struct{ Output string }{output})
+			} else {
+				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("customSuccessUnwrapped"), struct{ Output string }{output})
+			}
 			// Error out if no template was found, only if there are no errors or failures.
 			// This is because some errors and failures rely on additional context rendered by templates, but not all errors or failures.
 		} else if !(result.Error != nil || result.Failure != "") {
@@ -343,6 +406,9 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 				numApplyFailures++
 			}
 		}
+		if common.Verbose && result.Duration > 0 {
+			resultData.Rendered += "\n\n" + m.renderTemplateTrimSpace(templates.Lookup("projectDuration"), result.Duration.Round(time.Second))
+		}
 		resultsTmplData = append(resultsTmplData, resultData)
 	}
 
@@ -366,11 +432,16 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 		tmpl = templates.Lookup("singleProjectImport")
 	case len(resultsTmplData) == 1 && common.Command == stateCommandTitle:
 		switch common.SubCommand {
-		case "rm":
+		// The state rm templates fit all state subcommands.
+		case "rm", "pull", "push":
 			tmpl = templates.Lookup("singleProjectStateRm")
 		default:
 			return fmt.Sprintf("no template matched–this is a bug: command=%s, subcommand=%s", common.Command, common.SubCommand)
 		}
+	case len(resultsTmplData) == 1 && common.Command == customCommandTitle:
+		tmpl = templates.Lookup("singleProjectCustom")
+	case len(resultsTmplData) == 1 && common.Command == destroyCommandTitle:
+		tmpl = templates.Lookup("singleProjectDestroy")
 	case common.Command == planCommandTitle:
 		tmpl = templates.Lookup("multiProjectPlan")
 	case common.Command == policyCheckCommandTitle:
@@ -391,9 +462,13 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 		tmpl = templates.Lookup("multiProjectVersion")
 	case common.Command == importCommandTitle:
 		tmpl = templates.Lookup("multiProjectImport")
+	case common.Command == customCommandTitle:
+		tmpl = templates.Lookup("multiProjectCustom")
+	case common.Command == destroyCommandTitle:
+		tmpl = templates.Lookup("multiProjectDestroy")
 	case common.Command == stateCommandTitle:
 		switch common.SubCommand {
-		case "rm":
+		case "rm", "pull", "push":
 			tmpl = templates.Lookup("multiProjectStateRm")
 		default:
 			return fmt.Sprintf("no template matched–this is a bug: command=%s, subcommand=%s", common.Command, common.SubCommand)
@@ -417,6 +492,12 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 // load. Some VCS providers or versions of VCS providers don't support this
 // syntax.
 func (m *MarkdownRenderer) shouldUseWrappedTmpl(vcsHost models.VCSHostType, output string) bool {
+	return m.supportsFolding(vcsHost) && strings.Count(output, "\n") > maxUnwrappedLines
+}
+
+// supportsFolding returns true if output can be collapsed in comments on
+// vcsHost with the <details> syntax.
+func (m *MarkdownRenderer) supportsFolding(vcsHost models.VCSHostType) bool {
 	if m.disableMarkdownFolding {
 		return false
 	}
@@ -426,11 +507,7 @@ func (m *MarkdownRenderer) shouldUseWrappedTmpl(vcsHost models.VCSHostType, outp
 		return false
 	}
 
-	if vcsHost == models.Gitlab && !m.gitlabSupportsCommonMark {
-		return false
-	}
-
-	return strings.Count(output, "\n") > maxUnwrappedLines
+	return vcsHost != models.Gitlab || m.gitlabSupportsCommonMark
 }
 
 func (m *MarkdownRenderer) renderTemplateTrimSpace(tmpl *template.Template, data interface{}) string {
@@ -19,6 +19,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
@@ -272,6 +273,55 @@ $$$
   atlantis plan -d path -w workspace
   $$$
 
+---
+* :fast_forward: To **apply** all unapplied plans from this Pull Request, comment:
+  $$$shell
+  atlantis apply
+  $$$
+* :put_litter_in_its_place: To **delete** all plans and locks from this Pull Request, comment:
+  $$$shell
+  atlantis unlock
+  $$$
+`,
+		},
+		{
+			"single successful plan with unlock command",
+			command.Plan,
+			"",
+			[]command.ProjectResult{
+				{
+					PlanSuccess: &models.PlanSuccess{
+						TerraformOutput: "terraform-output",
+						LockURL:         "lock-url",
+						RePlanCmd:       "atlantis plan -d path -w workspace",
+						ApplyCmd:        "atlantis apply -d path -w workspace",
+						UnlockCmd:       "atlantis unlock -d path -w workspace",
+					},
+					Workspace:  "workspace",
+					RepoRelDir: "path",
+				},
+			},
+			models.Github,
+			`
+Ran Plan for dir: $path$ workspace: $workspace$
+
+$$$diff
+terraform-output
+$$$
+
+* :arrow_forward: To **apply** this plan, comment:
+  $$$shell
+  atlantis apply -d path -w workspace
+  $$$
+* :put_litter_in_its_place: To **delete** this plan and lock, click [here](lock-url) or comment:
+  $$$shell
+  atlantis unlock -d path -w workspace
+  $$$
+* :repeat: To **plan** this project again, comment:
+  $$$shell
+  atlantis plan -d path -w workspace
+  $$$
+
 ---
 * :fast_forward: To **apply** all unapplied plans from this Pull Request, comment:
   $$$shell
@@ -319,6 +369,272 @@ $$$
   $$$
 :twisted_rightwards_arrows: Upstream was modified, a new merge was performed.
 
+---
+* :fast_forward: To **apply** all unapplied plans from this Pull Request, comment:
+  $$$shell
+  atlantis apply
+  $$$
+* :put_litter_in_its_place: To **delete** all plans and locks from this Pull Request, comment:
+  $$$shell
+  atlantis unlock
+  $$$
+`,
+		},
+		{
+			"single successful plan that was reused",
+			command.Plan,
+			"",
+			[]command.ProjectResult{
+				{
+					PlanSuccess: &models.PlanSuccess{
+						TerraformOutput: "terraform-output",
+						LockURL:         "lock-url",
+						RePlanCmd:       "atlantis plan -d path -w workspace",
+						ApplyCmd:        "atlantis apply -d path -w workspace",
+						Cached:          true,
+					},
+					Workspace:  "workspace",
+					RepoRelDir: "path",
+				},
+			},
+			models.Github,
+			`
+Ran Plan for dir: $path$ workspace: $workspace$
+
+$$$diff
+terraform-output
+$$$
+
+* :arrow_forward: To **apply** this plan, comment:
+  $$$shell
+  atlantis apply -d path -w workspace
+  $$$
+* :put_litter_in_its_place: To **delete** this plan and lock, click [here](lock-url)
+* :repeat: To **plan** this project again, comment:
+  $$$shell
+  atlantis plan -d path -w workspace
+  $$$
+:recycle: This project hasn't changed since it was last planned so that plan was reused. To plan it again anyway, add $--force$ to the plan command.
+
+---
+* :fast_forward: To **apply** all unapplied plans from this Pull Request, comment:
+  $$$shell
+  atlantis apply
+  $$$
+* :put_litter_in_its_place: To **delete** all plans and locks from this Pull Request, comment:
+  $$$shell
+  atlantis unlock
+  $$$
+`,
+		},
+		{
+			"single successful plan with changeset diff",
+			command.Plan,
+			"",
+			[]command.ProjectResult{
+				{
+					PlanSuccess: &models.PlanSuccess{
+						TerraformOutput: "terraform-output",
+						LockURL:         "lock-url",
+						RePlanCmd:       "atlantis plan -d path -w workspace",
+						ApplyCmd:        "atlantis apply -d path -w workspace",
+						ChangesetDiff: &models.PlanChangesetDiff{
+							PreviousCommit: "0123456789",
+							Added:          []models.PlanResourceChange{{Address: "aws_instance.web", Action: "will be created"}},
+							Removed:        []models.PlanResourceChange{{Address: "aws_s3_bucket.logs", Action: "will be destroyed"}},
+							Changed: []models.PlanResourceActionChange{
+								{Address: "aws_iam_role.app", PreviousAction: "will be updated in-place", Action: "must be replaced"},
+							},
+						},
+					},
+					Workspace:  "workspace",
+					RepoRelDir: "path",
+				},
+			},
+			models.Github,
+			`
+Ran Plan for dir: $path$ workspace: $workspace$
+
+$$$diff
+terraform-output
+$$$
+
+* :arrow_forward: To **apply** this plan, comment:
+  $$$shell
+  atlantis apply -d path -w workspace
+  $$$
+* :put_litter_in_its_place: To **delete** this plan and lock, click [here](lock-url)
+* :repeat: To **plan** this project again, comment:
+  $$$shell
+  atlantis plan -d path -w workspace
+  $$$
+:mag: Resource changes added to ($+$), removed from ($-$) or changed in ($!$) this plan since the previous plan ($0123456$):
+$$$diff
++ aws_instance.web will be created
+- aws_s3_bucket.logs will be destroyed
+! aws_iam_role.app will be updated in-place -> must be replaced
+$$$
+
+---
+* :fast_forward: To **apply** all unapplied plans from this Pull Request, comment:
+  $$$shell
+  atlantis apply
+  $$$
+* :put_litter_in_its_place: To **delete** all plans and locks from this Pull Request, comment:
+  $$$shell
+  atlantis unlock
+  $$$
+`,
+		},
+		{
+			"single successful plan with cost estimate",
+			command.Plan,
+			"",
+			[]command.ProjectResult{
+				{
+					PlanSuccess: &models.PlanSuccess{
+						TerraformOutput: "terraform-output",
+						LockURL:         "lock-url",
+						RePlanCmd:       "atlantis plan -d path -w workspace",
+						ApplyCmd:        "atlantis apply -d path -w workspace",
+						CostEstimate: &models.CostEstimate{
+							Currency:        "USD",
+							PastMonthlyCost: 100,
+							MonthlyCost:     130.5,
+							Resources: []models.ResourceCostChange{
+								{Address: "aws_instance.web", DiffMonthlyCost: 40.5},
+								{Address: "aws_eip.old", DiffMonthlyCost: -10},
+							},
+						},
+					},
+					Workspace:  "workspace",
+					RepoRelDir: "path",
+				},
+			},
+			models.Github,
+			`
+Ran Plan for dir: $path$ workspace: $workspace$
+
+$$$diff
+terraform-output
+$$$
+
+* :arrow_forward: To **apply** this plan, comment:
+  $$$shell
+  atlantis apply -d path -w workspace
+  $$$
+* :put_litter_in_its_place: To **delete** this plan and lock, click [here](lock-url)
+* :repeat: To **plan** this project again, comment:
+  $$$shell
+  atlantis plan -d path -w workspace
+  $$$
+:moneybag: This plan changes the estimated monthly cost by **+30.50 USD**, from 100.00 to 130.50 USD.
+
+| Resource | Monthly cost change |
+| -------- | ------------------: |
+| $aws_instance.web$ | +40.50 USD |
+| $aws_eip.old$ | -10.00 USD |
+
+---
+* :fast_forward: To **apply** all unapplied plans from this Pull Request, comment:
+  $$$shell
+  atlantis apply
+  $$$
+* :put_litter_in_its_place: To **delete** all plans and locks from this Pull Request, comment:
+  $$$shell
+  atlantis unlock
+  $$$
+`,
+		},
+		{
+			"single successful plan with resolved terraform version",
+			command.Plan,
+			"",
+			[]command.ProjectResult{
+				{
+					PlanSuccess: &models.PlanSuccess{
+						TerraformOutput:  "terraform-output",
+						LockURL:          "lock-url",
+						RePlanCmd:        "atlantis plan -d path -w workspace",
+						ApplyCmd:         "atlantis apply -d path -w workspace",
+						TerraformVersion: "1.5.7",
+					},
+					Workspace:  "workspace",
+					RepoRelDir: "path",
+				},
+			},
+			models.Github,
+			`
+Ran Plan for dir: $path$ workspace: $workspace$ version: $1.5.7$
+
+$$$diff
+terraform-output
+$$$
+
+* :arrow_forward: To **apply** this plan, comment:
+  $$$shell
+  atlantis apply -d path -w workspace
+  $$$
+* :put_litter_in_its_place: To **delete** this plan and lock, click [here](lock-url)
+* :repeat: To **plan** this project again, comment:
+  $$$shell
+  atlantis plan -d path -w workspace
+  $$$
+
+---
+* :fast_forward: To **apply** all unapplied plans from this Pull Request, comment:
+  $$$shell
+  atlantis apply
+  $$$
+* :put_litter_in_its_place: To **delete** all plans and locks from this Pull Request, comment:
+  $$$shell
+  atlantis unlock
+  $$$
+`,
+		},
+		{
+			"single successful plan with suppressed changes",
+			command.Plan,
+			"",
+			[]command.ProjectResult{
+				{
+					PlanSuccess: &models.PlanSuccess{
+						TerraformOutput: "terraform-output",
+						LockURL:         "lock-url",
+						RePlanCmd:       "atlantis plan -d path -w workspace",
+						ApplyCmd:        "atlantis apply -d path -w workspace",
+						SuppressedChanges: []models.SuppressedChange{
+							{Address: "aws_instance.web", Description: "tag-only changes"},
+							{Address: "kubernetes_manifest.app", Description: "generation churn"},
+						},
+					},
+					Workspace:  "workspace",
+					RepoRelDir: "path",
+				},
+			},
+			models.Github,
+			`
+Ran Plan for dir: $path$ workspace: $workspace$
+
+$$$diff
+terraform-output
+$$$
+
+* :arrow_forward: To **apply** this plan, comment:
+  $$$shell
+  atlantis apply -d path -w workspace
+  $$$
+* :put_litter_in_its_place: To **delete** this plan and lock, click [here](lock-url)
+* :repeat: To **plan** this project again, comment:
+  $$$shell
+  atlantis plan -d path -w workspace
+  $$$
+<details><summary>:mute: 2 suppressed changes</summary>
+
+* $aws_instance.web$: tag-only changes
+* $kubernetes_manifest.app$: generation churn
+</details>
+
 ---
 * :fast_forward: To **apply** all unapplied plans from this Pull Request, comment:
   $$$shell
@@ -597,6 +913,29 @@ $$$
   $$$shell
   atlantis plan -d path -w workspace
   $$$
+`,
+		},
+		{
+			"single successful state pull",
+			command.State,
+			"pull",
+			[]command.ProjectResult{
+				{
+					StatePullSuccess: &models.StatePullSuccess{
+						Output: "Serial: 12",
+					},
+					Workspace:   "workspace",
+					RepoRelDir:  "path",
+					ProjectName: "projectname",
+				},
+			},
+			models.Github,
+			`
+Ran State $pull$ for project: $projectname$ dir: $path$ workspace: $workspace$
+
+$$$
+Serial: 12
+$$$
 `,
 		},
 		{
@@ -4275,3 +4614,199 @@ Ran Plan for 3 projects:
 		})
 	}
 }
+
+func TestRenderProjectResults_QuietAndVerbose(t *testing.T) {
+	mr := events.NewMarkdownRenderer(
+		false,      // gitlabSupportsCommonMark
+		false,      // disableApplyAll
+		false,      // disableApply
+		false,      // disableMarkdownFolding
+		false,      // disableRepoLocking
+		false,      // enableDiffMarkdownFormat
+		"",         // markdownTemplateOverridesDir
+		"atlantis", // executableName
+		false,      // hideUnchangedPlanComments
+		false,      // quietPolicyChecks
+	)
+	logger := logging.NewNoopLogger(t).WithHistory()
+	logger.Info("log")
+	ctx := &command.Context{
+		Log: logger,
+		Pull: models.PullRequest{
+			BaseRepo: models.Repo{
+				VCSHost: models.VCSHost{
+					Type: models.Github,
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		Description string
+		Command     *events.CommentCommand
+		Result      command.ProjectResult
+		Expected    string
+	}{
+		{
+			"quiet plan",
+			&events.CommentCommand{Name: command.Plan, Quiet: true},
+			command.ProjectResult{
+				RepoRelDir: ".",
+				Workspace:  "default",
+				PlanSuccess: &models.PlanSuccess{
+					TerraformOutput: "terraform-output\nPlan: 1 to add, 0 to change, 0 to destroy.",
+					LockURL:         "lock-url",
+					RePlanCmd:       "atlantis plan -d .",
+					ApplyCmd:        "atlantis apply -d .",
+				},
+				JobURL:   "https://atlantis.example.com/jobs/1",
+				Duration: 65 * time.Second,
+			},
+			`
+Ran Plan for dir: $.$ workspace: $default$
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+* :page_facing_up: To see the full output, click [here](https://atlantis.example.com/jobs/1)
+
+---
+* :fast_forward: To **apply** all unapplied plans from this Pull Request, comment:
+  $$$shell
+  atlantis apply
+  $$$
+* :put_litter_in_its_place: To **delete** all plans and locks from this Pull Request, comment:
+  $$$shell
+  atlantis unlock
+  $$$
+`,
+		},
+		{
+			"quiet apply",
+			&events.CommentCommand{Name: command.Apply, Quiet: true},
+			command.ProjectResult{
+				RepoRelDir:   ".",
+				Workspace:    "default",
+				ApplySuccess: "null_resource.a: Creating...\nApply complete! Resources: 1 added, 0 changed, 0 destroyed.\n",
+				JobURL:       "https://atlantis.example.com/jobs/1",
+			},
+			`
+Ran Apply for dir: $.$ workspace: $default$
+
+Apply complete! Resources: 1 added, 0 changed, 0 destroyed.
+* :page_facing_up: To see the full output, click [here](https://atlantis.example.com/jobs/1)
+`,
+		},
+		{
+			"verbose apply",
+			&events.CommentCommand{Name: command.Apply, Verbose: true},
+			command.ProjectResult{
+				RepoRelDir:   ".",
+				Workspace:    "default",
+				ApplySuccess: "success",
+				Duration:     65*time.Second + 300*time.Millisecond,
+			},
+			`
+Ran Apply for dir: $.$ workspace: $default$
+
+$$$diff
+success
+$$$
+
+:stopwatch: Took $1m5s$.
+<details><summary>Log</summary>
+<p>
+
+$$$
+[INFO] log
+$$$
+</p></details>
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Description, func(t *testing.T) {
+			res := command.Result{ProjectResults: []command.ProjectResult{c.Result}}
+			rendered := mr.Render(ctx, res, c.Command)
+			Equals(t, normalize(c.Expected), normalize(rendered))
+		})
+	}
+}
+
+func TestRender_CommentTemplates(t *testing.T) {
+	mr := events.NewMarkdownRenderer(
+		false,      // gitlabSupportsCommonMark
+		false,      // disableApplyAll
+		false,      // disableApply
+		false,      // disableMarkdownFolding
+		false,      // disableRepoLocking
+		false,      // enableDiffMarkdownFormat
+		"",         // markdownTemplateOverridesDir
+		"atlantis", // executableName
+		false,      // hideUnchangedPlanComments
+		false,      // quietPolicyChecks
+	)
+	ctx := &command.Context{
+		Log:  logging.NewNoopLogger(t),
+		User: models.User{Username: "user"},
+		Pull: models.PullRequest{
+			Num: 1,
+			BaseRepo: models.Repo{
+				FullName: "owner/repo",
+				VCSHost: models.VCSHost{
+					Type: models.Github,
+				},
+			},
+		},
+		CommentTemplates: map[string]string{
+			"plan": `{{ .User }} planned {{ .Repo }}#{{ .PullNum }}: +{{ .Totals.Add }} ~{{ .Totals.Change }} -{{ .Totals.Destroy }}
+{{ range .Projects }}- {{ .Name }} {{ .Dir }}/{{ .Workspace }} {{ .Summary }} {{ .JobURL }}
+{{ end }}`,
+			"apply": `{{ .Default | upper }}`,
+			"error": `{{ env "HOME" }}`,
+		},
+	}
+	planRes := command.Result{ProjectResults: []command.ProjectResult{
+		{
+			ProjectName: "a",
+			RepoRelDir:  "a",
+			Workspace:   "default",
+			PlanSuccess: &models.PlanSuccess{TerraformOutput: "Plan: 1 to add, 2 to change, 0 to destroy."},
+			JobURL:      "https://atlantis.example.com/jobs/1",
+		},
+		{
+			ProjectName: "b",
+			RepoRelDir:  "b",
+			Workspace:   "staging",
+			PlanSuccess: &models.PlanSuccess{TerraformOutput: "Plan: 2 to add, 0 to change, 1 to destroy."},
+			JobURL:      "https://atlantis.example.com/jobs/2",
+		},
+	}}
+
+	t.Run("plan", func(t *testing.T) {
+		Equals(t, `user planned owner/repo#1: +3 ~2 -1
+- a a/default Plan: 1 to add, 2 to change, 0 to destroy. https://atlantis.example.com/jobs/1
+- b b/staging Plan: 2 to add, 0 to change, 1 to destroy. https://atlantis.example.com/jobs/2`,
+			mr.Render(ctx, planRes, &events.CommentCommand{Name: command.Plan}))
+	})
+
+	t.Run("default comment", func(t *testing.T) {
+		res := command.Result{ProjectResults: []command.ProjectResult{{RepoRelDir: ".", Workspace: "default", ApplySuccess: "success"}}}
+		Equals(t, "RAN APPLY FOR DIR: `.` WORKSPACE: `DEFAULT`\n\n```DIFF\nSUCCESS\n```",
+			mr.Render(ctx, res, &events.CommentCommand{Name: command.Apply}))
+	})
+
+	t.Run("no template", func(t *testing.T) {
+		res := command.Result{ProjectResults: []command.ProjectResult{{RepoRelDir: ".", Workspace: "default", PolicyCheckResults: &models.PolicyCheckResults{}}}}
+		withoutTemplates := *ctx
+		withoutTemplates.CommentTemplates = nil
+		Equals(t, mr.Render(&withoutTemplates, res, &events.CommentCommand{Name: command.PolicyCheck}),
+			mr.Render(ctx, res, &events.CommentCommand{Name: command.PolicyCheck}))
+	})
+
+	t.Run("invalid template falls back to the default", func(t *testing.T) {
+		res := command.Result{Error: errors.New("error")}
+		withoutTemplates := *ctx
+		withoutTemplates.CommentTemplates = nil
+		Equals(t, mr.Render(&withoutTemplates, res, &events.CommentCommand{Name: command.Plan}),
+			mr.Render(ctx, res, &events.CommentCommand{Name: command.Plan}))
+	})
+}
@@ -54,6 +54,36 @@ func (mock *MockCommentBuilder) BuildApprovePoliciesComment(repoRelDir string, w
 	return _ret0
 }
 
+func (mock *MockCommentBuilder) BuildUnlockComment(repoRelDir string, workspace string, project string) string {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockCommentBuilder().")
+	}
+	_params := []pegomock.Param{repoRelDir, workspace, project}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("BuildUnlockComment", _params, []reflect.Type{reflect.TypeOf((*string)(nil)).Elem()})
+	var _ret0 string
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(string)
+		}
+	}
+	return _ret0
+}
+
+func (mock *MockCommentBuilder) BuildDestroyComment(repoRelDir string, workspace string, project string, confirm bool) string {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockCommentBuilder().")
+	}
+	_params := []pegomock.Param{repoRelDir, workspace, project, confirm}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("BuildDestroyComment", _params, []reflect.Type{reflect.TypeOf((*string)(nil)).Elem()})
+	var _ret0 string
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(string)
+		}
+	}
+	return _ret0
+}
+
 func (mock *MockCommentBuilder) BuildPlanComment(repoRelDir string, workspace string, project string, commentArgs []string) string {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockCommentBuilder().")
@@ -200,6 +230,94 @@ func (c *MockCommentBuilder_BuildApprovePoliciesComment_OngoingVerification) Get
 	return
 }
 
+func (verifier *VerifierMockCommentBuilder) BuildUnlockComment(repoRelDir string, workspace string, project string) *MockCommentBuilder_BuildUnlockComment_OngoingVerification {
+	_params := []pegomock.Param{repoRelDir, workspace, project}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "BuildUnlockComment", _params, verifier.timeout)
+	return &MockCommentBuilder_BuildUnlockComment_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockCommentBuilder_BuildUnlockComment_OngoingVerification struct {
+	mock              *MockCommentBuilder
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockCommentBuilder_BuildUnlockComment_OngoingVerification) GetCapturedArguments() (string, string, string) {
+	repoRelDir, workspace, project := c.GetAllCapturedArguments()
+	return repoRelDir[len(repoRelDir)-1], workspace[len(workspace)-1], project[len(project)-1]
+}
+
+func (c *MockCommentBuilder_BuildUnlockComment_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []string) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(string)
+			}
+		}
+		if len(_params) > 1 {
+			_param1 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[1] {
+				_param1[u] = param.(string)
+			}
+		}
+		if len(_params) > 2 {
+			_param2 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[2] {
+				_param2[u] = param.(string)
+			}
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockCommentBuilder) BuildDestroyComment(repoRelDir string, workspace string, project string, confirm bool) *MockCommentBuilder_BuildDestroyComment_OngoingVerification {
+	_params := []pegomock.Param{repoRelDir, workspace, project, confirm}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "BuildDestroyComment", _params, verifier.timeout)
+	return &MockCommentBuilder_BuildDestroyComment_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockCommentBuilder_BuildDestroyComment_OngoingVerification struct {
+	mock              *MockCommentBuilder
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockCommentBuilder_BuildDestroyComment_OngoingVerification) GetCapturedArguments() (string, string, string, bool) {
+	repoRelDir, workspace, project, confirm := c.GetAllCapturedArguments()
+	return repoRelDir[len(repoRelDir)-1], workspace[len(workspace)-1], project[len(project)-1], confirm[len(confirm)-1]
+}
+
+func (c *MockCommentBuilder_BuildDestroyComment_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []string, _param3 []bool) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(string)
+			}
+		}
+		if len(_params) > 1 {
+			_param1 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[1] {
+				_param1[u] = param.(string)
+			}
+		}
+		if len(_params) > 2 {
+			_param2 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[2] {
+				_param2[u] = param.(string)
+			}
+		}
+		if len(_params) > 3 {
+			_param3 = make([]bool, len(c.methodInvocations))
+			for u, param := range _params[3] {
+				_param3[u] = param.(bool)
+			}
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockCommentBuilder) BuildPlanComment(repoRelDir string, workspace string, project string, commentArgs []string) *MockCommentBuilder_BuildPlanComment_OngoingVerification {
 	_params := []pegomock.Param{repoRelDir, workspace, project, commentArgs}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "BuildPlanComment", _params, verifier.timeout)
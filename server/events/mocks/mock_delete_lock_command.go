@@ -64,6 +64,25 @@ func (mock *MockDeleteLockCommand) DeleteLocksByPull(logger logging.SimpleLoggin
 	return _ret0, _ret1
 }
 
+func (mock *MockDeleteLockCommand) DeleteLocksByProject(logger logging.SimpleLogging, repoFullName string, pullNum int, repoRelDir string, workspace string, projectName string) ([]models.ProjectLock, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockDeleteLockCommand().")
+	}
+	_params := []pegomock.Param{logger, repoFullName, pullNum, repoRelDir, workspace, projectName}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("DeleteLocksByProject", _params, []reflect.Type{reflect.TypeOf((*[]models.ProjectLock)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 []models.ProjectLock
+	var _ret1 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].([]models.ProjectLock)
+		}
+		if _result[1] != nil {
+			_ret1 = _result[1].(error)
+		}
+	}
+	return _ret0, _ret1
+}
+
 func (mock *MockDeleteLockCommand) VerifyWasCalledOnce() *VerifierMockDeleteLockCommand {
 	return &VerifierMockDeleteLockCommand{
 		mock:                   mock,
@@ -176,3 +195,62 @@ func (c *MockDeleteLockCommand_DeleteLocksByPull_OngoingVerification) GetAllCapt
 	}
 	return
 }
+
+func (verifier *VerifierMockDeleteLockCommand) DeleteLocksByProject(logger logging.SimpleLogging, repoFullName string, pullNum int, repoRelDir string, workspace string, projectName string) *MockDeleteLockCommand_DeleteLocksByProject_OngoingVerification {
+	_params := []pegomock.Param{logger, repoFullName, pullNum, repoRelDir, workspace, projectName}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeleteLocksByProject", _params, verifier.timeout)
+	return &MockDeleteLockCommand_DeleteLocksByProject_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockDeleteLockCommand_DeleteLocksByProject_OngoingVerification struct {
+	mock              *MockDeleteLockCommand
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockDeleteLockCommand_DeleteLocksByProject_OngoingVerification) GetCapturedArguments() (logging.SimpleLogging, string, int, string, string, string) {
+	logger, repoFullName, pullNum, repoRelDir, workspace, projectName := c.GetAllCapturedArguments()
+	return logger[len(logger)-1], repoFullName[len(repoFullName)-1], pullNum[len(pullNum)-1], repoRelDir[len(repoRelDir)-1], workspace[len(workspace)-1], projectName[len(projectName)-1]
+}
+
+func (c *MockDeleteLockCommand_DeleteLocksByProject_OngoingVerification) GetAllCapturedArguments() (_param0 []logging.SimpleLogging, _param1 []string, _param2 []int, _param3 []string, _param4 []string, _param5 []string) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]logging.SimpleLogging, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(logging.SimpleLogging)
+			}
+		}
+		if len(_params) > 1 {
+			_param1 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[1] {
+				_param1[u] = param.(string)
+			}
+		}
+		if len(_params) > 2 {
+			_param2 = make([]int, len(c.methodInvocations))
+			for u, param := range _params[2] {
+				_param2[u] = param.(int)
+			}
+		}
+		if len(_params) > 3 {
+			_param3 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[3] {
+				_param3[u] = param.(string)
+			}
+		}
+		if len(_params) > 4 {
+			_param4 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[4] {
+				_param4[u] = param.(string)
+			}
+		}
+		if len(_params) > 5 {
+			_param5 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[5] {
+				_param5[u] = param.(string)
+			}
+		}
+	}
+	return
+}
@@ -55,6 +55,21 @@ func (mock *MockProjectCommandRunner) ApprovePolicies(ctx command.ProjectContext
 	return _ret0
 }
 
+func (mock *MockProjectCommandRunner) Custom(ctx command.ProjectContext) command.ProjectResult {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockProjectCommandRunner().")
+	}
+	_params := []pegomock.Param{ctx}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("Custom", _params, []reflect.Type{reflect.TypeOf((*command.ProjectResult)(nil)).Elem()})
+	var _ret0 command.ProjectResult
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(command.ProjectResult)
+		}
+	}
+	return _ret0
+}
+
 func (mock *MockProjectCommandRunner) Import(ctx command.ProjectContext) command.ProjectResult {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockProjectCommandRunner().")
@@ -115,6 +130,36 @@ func (mock *MockProjectCommandRunner) StateRm(ctx command.ProjectContext) comman
 	return _ret0
 }
 
+func (mock *MockProjectCommandRunner) StatePull(ctx command.ProjectContext) command.ProjectResult {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockProjectCommandRunner().")
+	}
+	_params := []pegomock.Param{ctx}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("StatePull", _params, []reflect.Type{reflect.TypeOf((*command.ProjectResult)(nil)).Elem()})
+	var _ret0 command.ProjectResult
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(command.ProjectResult)
+		}
+	}
+	return _ret0
+}
+
+func (mock *MockProjectCommandRunner) StatePush(ctx command.ProjectContext) command.ProjectResult {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockProjectCommandRunner().")
+	}
+	_params := []pegomock.Param{ctx}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("StatePush", _params, []reflect.Type{reflect.TypeOf((*command.ProjectResult)(nil)).Elem()})
+	var _ret0 command.ProjectResult
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(command.ProjectResult)
+		}
+	}
+	return _ret0
+}
+
 func (mock *MockProjectCommandRunner) Version(ctx command.ProjectContext) command.ProjectResult {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockProjectCommandRunner().")
@@ -225,12 +270,41 @@ func (c *MockProjectCommandRunner_ApprovePolicies_OngoingVerification) GetAllCap
 	return
 }
 
+func (verifier *VerifierMockProjectCommandRunner) Custom(ctx command.ProjectContext) *MockProjectCommandRunner_Custom_OngoingVerification {
+	_params := []pegomock.Param{ctx}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Custom", _params, verifier.timeout)
+	return &MockProjectCommandRunner_Custom_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
 func (verifier *VerifierMockProjectCommandRunner) Import(ctx command.ProjectContext) *MockProjectCommandRunner_Import_OngoingVerification {
 	_params := []pegomock.Param{ctx}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Import", _params, verifier.timeout)
 	return &MockProjectCommandRunner_Import_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
 
+type MockProjectCommandRunner_Custom_OngoingVerification struct {
+	mock              *MockProjectCommandRunner
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockProjectCommandRunner_Custom_OngoingVerification) GetCapturedArguments() command.ProjectContext {
+	ctx := c.GetAllCapturedArguments()
+	return ctx[len(ctx)-1]
+}
+
+func (c *MockProjectCommandRunner_Custom_OngoingVerification) GetAllCapturedArguments() (_param0 []command.ProjectContext) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]command.ProjectContext, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(command.ProjectContext)
+			}
+		}
+	}
+	return
+}
+
 type MockProjectCommandRunner_Import_OngoingVerification struct {
 	mock              *MockProjectCommandRunner
 	methodInvocations []pegomock.MethodInvocation
@@ -318,6 +392,18 @@ func (verifier *VerifierMockProjectCommandRunner) StateRm(ctx command.ProjectCon
 	return &MockProjectCommandRunner_StateRm_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
 
+func (verifier *VerifierMockProjectCommandRunner) StatePull(ctx command.ProjectContext) *MockProjectCommandRunner_StatePull_OngoingVerification {
+	_params := []pegomock.Param{ctx}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "StatePull", _params, verifier.timeout)
+	return &MockProjectCommandRunner_StatePull_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+func (verifier *VerifierMockProjectCommandRunner) StatePush(ctx command.ProjectContext) *MockProjectCommandRunner_StatePush_OngoingVerification {
+	_params := []pegomock.Param{ctx}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "StatePush", _params, verifier.timeout)
+	return &MockProjectCommandRunner_StatePush_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
 type MockProjectCommandRunner_StateRm_OngoingVerification struct {
 	mock              *MockProjectCommandRunner
 	methodInvocations []pegomock.MethodInvocation
@@ -341,6 +427,52 @@ func (c *MockProjectCommandRunner_StateRm_OngoingVerification) GetAllCapturedArg
 	return
 }
 
+type MockProjectCommandRunner_StatePull_OngoingVerification struct {
+	mock              *MockProjectCommandRunner
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockProjectCommandRunner_StatePull_OngoingVerification) GetCapturedArguments() command.ProjectContext {
+	ctx := c.GetAllCapturedArguments()
+	return ctx[len(ctx)-1]
+}
+
+func (c *MockProjectCommandRunner_StatePull_OngoingVerification) GetAllCapturedArguments() (_param0 []command.ProjectContext) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]command.ProjectContext, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(command.ProjectContext)
+			}
+		}
+	}
+	return
+}
+
+type MockProjectCommandRunner_StatePush_OngoingVerification struct {
+	mock              *MockProjectCommandRunner
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockProjectCommandRunner_StatePush_OngoingVerification) GetCapturedArguments() command.ProjectContext {
+	ctx := c.GetAllCapturedArguments()
+	return ctx[len(ctx)-1]
+}
+
+func (c *MockProjectCommandRunner_StatePush_OngoingVerification) GetAllCapturedArguments() (_param0 []command.ProjectContext) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]command.ProjectContext, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(command.ProjectContext)
+			}
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockProjectCommandRunner) Version(ctx command.ProjectContext) *MockProjectCommandRunner_Version_OngoingVerification {
 	_params := []pegomock.Param{ctx}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Version", _params, verifier.timeout)
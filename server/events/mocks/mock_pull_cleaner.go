@@ -26,11 +26,11 @@ func NewMockPullCleaner(options ...pegomock.Option) *MockPullCleaner {
 func (mock *MockPullCleaner) SetFailHandler(fh pegomock.FailHandler) { mock.fail = fh }
 func (mock *MockPullCleaner) FailHandler() pegomock.FailHandler      { return mock.fail }
 
-func (mock *MockPullCleaner) CleanUpPull(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) error {
+func (mock *MockPullCleaner) CleanUpPull(logger logging.SimpleLogging, repo models.Repo, headRepo models.Repo, pull models.PullRequest) error {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockPullCleaner().")
 	}
-	_params := []pegomock.Param{logger, repo, pull}
+	_params := []pegomock.Param{logger, repo, headRepo, pull}
 	_result := pegomock.GetGenericMockFrom(mock).Invoke("CleanUpPull", _params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
 	var _ret0 error
 	if len(_result) != 0 {
@@ -78,8 +78,8 @@ type VerifierMockPullCleaner struct {
 	timeout                time.Duration
 }
 
-func (verifier *VerifierMockPullCleaner) CleanUpPull(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) *MockPullCleaner_CleanUpPull_OngoingVerification {
-	_params := []pegomock.Param{logger, repo, pull}
+func (verifier *VerifierMockPullCleaner) CleanUpPull(logger logging.SimpleLogging, repo models.Repo, headRepo models.Repo, pull models.PullRequest) *MockPullCleaner_CleanUpPull_OngoingVerification {
+	_params := []pegomock.Param{logger, repo, headRepo, pull}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "CleanUpPull", _params, verifier.timeout)
 	return &MockPullCleaner_CleanUpPull_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
@@ -89,12 +89,12 @@ type MockPullCleaner_CleanUpPull_OngoingVerification struct {
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *MockPullCleaner_CleanUpPull_OngoingVerification) GetCapturedArguments() (logging.SimpleLogging, models.Repo, models.PullRequest) {
-	logger, repo, pull := c.GetAllCapturedArguments()
-	return logger[len(logger)-1], repo[len(repo)-1], pull[len(pull)-1]
+func (c *MockPullCleaner_CleanUpPull_OngoingVerification) GetCapturedArguments() (logging.SimpleLogging, models.Repo, models.Repo, models.PullRequest) {
+	logger, repo, headRepo, pull := c.GetAllCapturedArguments()
+	return logger[len(logger)-1], repo[len(repo)-1], headRepo[len(headRepo)-1], pull[len(pull)-1]
 }
 
-func (c *MockPullCleaner_CleanUpPull_OngoingVerification) GetAllCapturedArguments() (_param0 []logging.SimpleLogging, _param1 []models.Repo, _param2 []models.PullRequest) {
+func (c *MockPullCleaner_CleanUpPull_OngoingVerification) GetAllCapturedArguments() (_param0 []logging.SimpleLogging, _param1 []models.Repo, _param2 []models.Repo, _param3 []models.PullRequest) {
 	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(_params) > 0 {
 		if len(_params) > 0 {
@@ -110,9 +110,15 @@ func (c *MockPullCleaner_CleanUpPull_OngoingVerification) GetAllCapturedArgument
 			}
 		}
 		if len(_params) > 2 {
-			_param2 = make([]models.PullRequest, len(c.methodInvocations))
+			_param2 = make([]models.Repo, len(c.methodInvocations))
 			for u, param := range _params[2] {
-				_param2[u] = param.(models.PullRequest)
+				_param2[u] = param.(models.Repo)
+			}
+		}
+		if len(_params) > 3 {
+			_param3 = make([]models.PullRequest, len(c.methodInvocations))
+			for u, param := range _params[3] {
+				_param3[u] = param.(models.PullRequest)
 			}
 		}
 	}
@@ -20,6 +20,7 @@ package models
 
 import (
 	"fmt"
+	"math"
 	"net/url"
 	paths "path"
 	"regexp"
@@ -149,6 +150,9 @@ type ApprovalStatus struct {
 	IsApproved bool
 	ApprovedBy string
 	Date       time.Time
+	// Approvers are the users whose approvals of the pull request stand, if
+	// the VCS host reports them.
+	Approvers []string
 }
 
 // PullRequest is a VCS pull request.
@@ -204,6 +208,9 @@ const (
 	UpdatedPullEvent
 	ClosedPullEvent
 	OtherPullEvent
+	// ApprovalLabeledPullEvent is when the --fork-pr-approval-label label is
+	// added to a pull request.
+	ApprovalLabeledPullEvent
 )
 
 func (p PullRequestEventType) String() string {
@@ -216,6 +223,8 @@ func (p PullRequestEventType) String() string {
 		return "closed"
 	case OtherPullEvent:
 		return "other"
+	case ApprovalLabeledPullEvent:
+		return "approval_labeled"
 	}
 	return "<missing String() implementation>"
 }
@@ -266,6 +275,39 @@ func (p Project) String() string {
 	return fmt.Sprintf("repofullname=%s path=%s", p.RepoFullName, p.Path)
 }
 
+// ProjectRename is a change to the dir, workspace or name of a project of a
+// repo. It's used to move what's stored for the project under its old dir,
+// workspace and name to the new ones.
+type ProjectRename struct {
+	RepoFullName string
+	From         ProjectLocation
+	To           ProjectLocation
+}
+
+// ProjectLocation is what identifies a project of a repo.
+type ProjectLocation struct {
+	RepoRelDir  string
+	Workspace   string
+	ProjectName string
+}
+
+// Matches returns true if the project at repoRelDir and workspace named
+// projectName is the project being renamed.
+func (r ProjectRename) Matches(repoRelDir string, workspace string, projectName string) bool {
+	return cleanRepoRelDir(repoRelDir) == cleanRepoRelDir(r.From.RepoRelDir) &&
+		workspace == r.From.Workspace &&
+		projectName == r.From.ProjectName
+}
+
+// cleanRepoRelDir cleans dir the same way NewProject cleans paths.
+func cleanRepoRelDir(dir string) string {
+	dir = paths.Clean(dir)
+	if dir == "/" {
+		return "."
+	}
+	return dir
+}
+
 // Plan is the result of running an Atlantis plan command.
 // This model is used to represent a plan on disk.
 type Plan struct {
@@ -309,6 +351,9 @@ const (
 	BitbucketServer
 	AzureDevops
 	Gitea
+	// VCSAdapter is a VCS host supported by an external adapter process
+	// configured with --vcs-adapter.
+	VCSAdapter
 )
 
 func (h VCSHostType) String() string {
@@ -325,6 +370,8 @@ func (h VCSHostType) String() string {
 		return "AzureDevops"
 	case Gitea:
 		return "Gitea"
+	case VCSAdapter:
+		return "VCSAdapter"
 	}
 	return "<missing String() implementation>"
 }
@@ -343,6 +390,8 @@ func NewVCSHostType(t string) (VCSHostType, error) {
 		return AzureDevops, nil
 	case "Gitea":
 		return Gitea, nil
+	case "VCSAdapter":
+		return VCSAdapter, nil
 	}
 
 	return -1, fmt.Errorf("%q is not a valid type", t)
@@ -374,10 +423,30 @@ type PlanSuccess struct {
 	RePlanCmd string
 	// ApplyCmd is the command that users should run to apply this plan.
 	ApplyCmd string
+	// UnlockCmd is the command that users should run to delete this plan and
+	// release its lock.
+	UnlockCmd string
 	// MergedAgain is true if we're using the checkout merge strategy and the
 	// branch we're merging into had been updated, and we had to merge again
 	// before planning
 	MergedAgain bool
+	// Cached is true if the project hadn't changed since it was last planned
+	// so the plan from that run was reused instead of running plan again.
+	Cached bool
+	// ChangesetDiff is how the resources changed by this plan differ from the
+	// previous plan of the project. It's nil if the diff isn't shown or there
+	// was no previous plan.
+	ChangesetDiff *PlanChangesetDiff
+	// CostEstimate is how the plan changes the monthly cost of the project,
+	// if its workflow has a cost_estimate step.
+	CostEstimate *CostEstimate
+	// SuppressedChanges are the noisy changes that were removed from
+	// TerraformOutput, if the project suppresses changes.
+	SuppressedChanges []SuppressedChange
+	// TerraformVersion is the version of Terraform the project was planned
+	// with if it was pinned or resolved from required_version, or empty if
+	// the server's default version was used.
+	TerraformVersion string
 }
 
 type PolicySetResult struct {
@@ -386,6 +455,17 @@ type PolicySetResult struct {
 	Passed        bool
 	ReqApprovals  int
 	CurApprovals  int
+	// Failures are the failed policies of the policy set with their
+	// metadata. They're only known when conftest outputs JSON.
+	Failures []PolicyFailure `json:",omitempty"`
+}
+
+// PolicyFailure is a failed policy.
+type PolicyFailure struct {
+	Msg string `json:"msg"`
+	// Metadata are the other fields of the deny rule's result, ex.
+	// {"approve_count": 2}.
+	Metadata map[string]any `json:"metadata,omitempty"`
 }
 
 // PolicySetApproval tracks the number of approvals a given policy set has.
@@ -393,6 +473,17 @@ type PolicySetStatus struct {
 	PolicySetName string
 	Passed        bool
 	Approvals     int
+	// ReqApprovals is the number of approvals the policy set required when
+	// it was checked, which is more than the approve_count of its config if
+	// its failures raised it. It's 0 for statuses stored before it was
+	// recorded.
+	ReqApprovals int `json:",omitempty"`
+}
+
+// RequiredApprovals returns the number of approvals the policy set requires,
+// given the approveCount of its config.
+func (p PolicySetStatus) RequiredApprovals(approveCount int) int {
+	return max(approveCount, p.ReqApprovals)
 }
 
 // Summary regexes
@@ -459,6 +550,9 @@ type PolicyCheckResults struct {
 	ApplyCmd string
 	// ApprovePoliciesCmd is the command that users should run to approve policies for this plan.
 	ApprovePoliciesCmd string
+	// UnlockCmd is the command that users should run to delete this plan and
+	// release its lock.
+	UnlockCmd string
 	// HasDiverged is true if we're using the checkout merge strategy and the
 	// branch we're merging into has been updated since we cloned and merged
 	// it.
@@ -481,6 +575,21 @@ type StateRmSuccess struct {
 	RePlanCmd string
 }
 
+// StatePullSuccess is the result of a successful state pull run.
+type StatePullSuccess struct {
+	// Output is the metadata of the state. It never contains values from the
+	// state since it's posted on the pull request.
+	Output string
+}
+
+// StatePushSuccess is the result of a successful state push run.
+type StatePushSuccess struct {
+	// Output is the output from terraform state push
+	Output string
+	// RePlanCmd is the command that users should run to re-plan this project.
+	RePlanCmd string
+}
+
 func (p *PolicyCheckResults) CombinedOutput() string {
 	combinedOutput := ""
 	for _, psResult := range p.PolicySetResults {
@@ -540,6 +649,47 @@ type PullStatus struct {
 	Projects []ProjectStatus
 	// Pull is the original pull request model.
 	Pull PullRequest
+	// ForkApproval is set once a maintainer has allowed Atlantis to run
+	// commands on this pull request from a fork.
+	ForkApproval *ForkApproval
+}
+
+// ForkApproval records that a maintainer allowed Atlantis to run commands on
+// a pull request from a fork.
+type ForkApproval struct {
+	// HeadCommit is the commit that was approved. Once new commits are pushed
+	// the pull request needs to be approved again.
+	HeadCommit string
+	// Approver is the username of the user that approved the pull request.
+	Approver string
+	// Time is when the pull request was approved.
+	Time time.Time
+}
+
+// IsForkApproved returns true if a maintainer approved headCommit to run
+// commands on this pull request from a fork.
+func (p PullStatus) IsForkApproved(headCommit string) bool {
+	return p.ForkApproval != nil && headCommit != "" && p.ForkApproval.HeadCommit == headCommit
+}
+
+// RenameProject changes the project being renamed to its new dir, workspace
+// and name. It returns false if the pull is for another repo or doesn't have
+// the project.
+func (p *PullStatus) RenameProject(rename ProjectRename) bool {
+	if p.Pull.BaseRepo.FullName != rename.RepoFullName {
+		return false
+	}
+	found := false
+	for i := range p.Projects {
+		proj := &p.Projects[i]
+		if rename.Matches(proj.RepoRelDir, proj.Workspace, proj.ProjectName) {
+			proj.RepoRelDir = rename.To.RepoRelDir
+			proj.Workspace = rename.To.Workspace
+			proj.ProjectName = rename.To.ProjectName
+			found = true
+		}
+	}
+	return found
 }
 
 // StatusCount returns the number of projects that have status.
@@ -593,6 +743,9 @@ const (
 	// PassedPolicyCheckStatus means that there was an unapplied plan that was
 	// discarded due to a project being unlocked
 	PassedPolicyCheckStatus
+	// PlannedDestroyPlanStatus means that a plan to destroy the project has
+	// been generated by atlantis destroy and not yet confirmed.
+	PlannedDestroyPlanStatus
 )
 
 // String returns a string representation of the status.
@@ -614,11 +767,180 @@ func (p ProjectPlanStatus) String() string {
 		return "policy_check_errored"
 	case PassedPolicyCheckStatus:
 		return "policy_check_passed"
+	case PlannedDestroyPlanStatus:
+		return "destroy_planned"
 	default:
 		panic("missing String() impl for ProjectPlanStatus")
 	}
 }
 
+// DriftStatus is the result of checking a project for drift, i.e. changes
+// made to its infrastructure outside of Atlantis, using a refresh-only plan.
+type DriftStatus struct {
+	// RepoID is the ID of the repo, ex. github.com/runatlantis/atlantis.
+	RepoID string
+	// RepoFullName is the owner and repo name, ex. runatlantis/atlantis.
+	RepoFullName string
+	// Branch is the branch that was checked.
+	Branch      string
+	ProjectName string
+	RepoRelDir  string
+	Workspace   string
+	// Drifted is true if the refresh-only plan found changes.
+	Drifted bool
+	// DriftedResources is the number of resources that changed outside of
+	// Atlantis.
+	DriftedResources int
+	// Output is the output of the refresh-only plan.
+	Output string
+	// Error is set if the project couldn't be checked.
+	Error string
+	// CheckedAt is when the project was checked.
+	CheckedAt time.Time
+}
+
+// Key uniquely identifies the project this status is for.
+func (d DriftStatus) Key() string {
+	return fmt.Sprintf("%s/%s/%s/%s", d.RepoID, d.RepoRelDir, d.Workspace, d.ProjectName)
+}
+
+var reDriftedResource = regexp.MustCompile(`(?m)^\s*# \S+ has (been deleted|changed)$`)
+
+// CountDriftedResources returns the number of resources reported as changed
+// outside of Terraform in the output of a refresh-only plan.
+func CountDriftedResources(terraformOutput string) int {
+	return len(reDriftedResource.FindAllString(terraformOutput, -1))
+}
+
+var rePlanResourceChange = regexp.MustCompile(`(?m)^\s*# (.+?) ((?:will be|must be|has moved to) .+)$`)
+
+// PlanResourceChange is a change that a plan makes to a resource.
+type PlanResourceChange struct {
+	// Address is the address of the resource, ex. aws_instance.web.
+	Address string
+	// Action is what the plan does to the resource, ex. "will be created".
+	Action string
+}
+
+// ParsePlanResourceChanges returns the changes to resources in the output of
+// a plan, in the order they're listed.
+func ParsePlanResourceChanges(terraformOutput string) []PlanResourceChange {
+	var changes []PlanResourceChange
+	for _, match := range rePlanResourceChange.FindAllStringSubmatch(terraformOutput, -1) {
+		changes = append(changes, PlanResourceChange{Address: match[1], Action: match[2]})
+	}
+	return changes
+}
+
+// PlanChangesetDiff is how the resources changed by a plan differ from the
+// ones changed by the previous plan of the same project.
+type PlanChangesetDiff struct {
+	// PreviousCommit is the head commit that the previous plan was run on.
+	PreviousCommit string
+	// Added are the changes that weren't in the previous plan.
+	Added []PlanResourceChange
+	// Removed are the changes of the previous plan that aren't in this one.
+	Removed []PlanResourceChange
+	// Changed are the resources that both plans change, but differently.
+	Changed []PlanResourceActionChange
+}
+
+// PlanResourceActionChange is a resource that two plans change differently.
+type PlanResourceActionChange struct {
+	Address        string
+	PreviousAction string
+	Action         string
+}
+
+// NewPlanChangesetDiff returns how changes differ from previous.
+func NewPlanChangesetDiff(previousCommit string, previous []PlanResourceChange, changes []PlanResourceChange) PlanChangesetDiff {
+	diff := PlanChangesetDiff{PreviousCommit: previousCommit}
+	previousActions := make(map[string]string)
+	for _, change := range previous {
+		previousActions[change.Address] = change.Action
+	}
+	actions := make(map[string]string)
+	for _, change := range changes {
+		actions[change.Address] = change.Action
+		previousAction, ok := previousActions[change.Address]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, change)
+		case previousAction != change.Action:
+			diff.Changed = append(diff.Changed, PlanResourceActionChange{
+				Address:        change.Address,
+				PreviousAction: previousAction,
+				Action:         change.Action,
+			})
+		}
+	}
+	for _, change := range previous {
+		if _, ok := actions[change.Address]; !ok {
+			diff.Removed = append(diff.Removed, change)
+		}
+	}
+	return diff
+}
+
+// Empty returns true if both plans change the same resources the same way.
+func (d PlanChangesetDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// ShortPreviousCommit returns the abbreviated PreviousCommit.
+func (d PlanChangesetDiff) ShortPreviousCommit() string {
+	if len(d.PreviousCommit) > 7 {
+		return d.PreviousCommit[:7]
+	}
+	return d.PreviousCommit
+}
+
+// CostEstimate is how a plan changes the estimated monthly cost of a
+// project, as estimated by Infracost.
+type CostEstimate struct {
+	Currency string
+	// PastMonthlyCost is the monthly cost before the plan is applied.
+	PastMonthlyCost float64
+	// MonthlyCost is the monthly cost once the plan is applied.
+	MonthlyCost float64
+	// Resources are the resources whose cost the plan changes, the largest
+	// change first.
+	Resources []ResourceCostChange
+}
+
+// ResourceCostChange is how a plan changes the monthly cost of a resource.
+type ResourceCostChange struct {
+	Address         string
+	DiffMonthlyCost float64
+}
+
+// DiffMonthlyCost returns how much the plan changes the monthly cost by.
+func (c CostEstimate) DiffMonthlyCost() float64 {
+	return c.MonthlyCost - c.PastMonthlyCost
+}
+
+// DiffPercent returns how much the plan changes the monthly cost by, in
+// percent of the past monthly cost. It's +Inf if a project that cost nothing
+// starts costing something.
+func (c CostEstimate) DiffPercent() float64 {
+	if c.PastMonthlyCost == 0 {
+		if c.MonthlyCost == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return c.DiffMonthlyCost() / c.PastMonthlyCost * 100
+}
+
+// SuppressedChange is a noisy change, ex. to tags only, that was removed from
+// the output of a plan.
+type SuppressedChange struct {
+	// Address is the address of the changed resource.
+	Address string
+	// Description says why the change was suppressed.
+	Description string
+}
+
 // TeamAllowlistCheckerContext defines the context for a TeamAllowlistChecker to verify
 // command permissions.
 type TeamAllowlistCheckerContext struct {
@@ -709,6 +1031,22 @@ type WorkflowHookCommandContext struct {
 	Workspace string
 	// API is true if plan/apply by API endpoints
 	API bool
+	// CommandResult is "success" or "failure" depending on whether the
+	// command succeeded for all projects. It's empty if the command didn't
+	// run. Only set for post workflow hooks.
+	CommandResult string
+	// CommandError is the error or failure of the command, if any.
+	CommandError string
+	// CommandOutcomes are the outcomes of the commands that were run, ex.
+	// "plan_success" and "policy_failure".
+	CommandOutcomes []string
+	// ProjectResults is a JSON array summarizing the result of each project.
+	ProjectResults string
+	// Event is the lifecycle event the hook runs on, ex. "lock_released".
+	// It's empty if the hook runs after a command.
+	Event string
+	// Comment is the comment that triggered the event, if any.
+	Comment string
 }
 
 // PlanSuccessStats holds stats for a plan.
@@ -737,3 +1075,60 @@ func NewPlanSuccessStats(output string) PlanSuccessStats {
 
 	return s
 }
+
+// GarbageCollectionResult is what a garbage collection run deleted, or would
+// have deleted if it's a dry run.
+type GarbageCollectionResult struct {
+	// Plans is the number of stale plans.
+	Plans int
+	// WorkingDirs is the number of stale pull request working dirs.
+	WorkingDirs int
+	// PullStatuses is the number of pull statuses whose working dir no
+	// longer exists.
+	PullStatuses int
+	// Locks is the number of locks whose pull request's working dir no
+	// longer exists.
+	Locks int
+	// DryRun is true if nothing was deleted.
+	DryRun bool
+}
+
+// RepoConfigStatus is the result of validating a repo's config on its default
+// branch against the current server-side config.
+type RepoConfigStatus struct {
+	// RepoID is the repo's ID, ex. github.com/runatlantis/atlantis.
+	RepoID string
+	// Branch is the branch whose config was validated.
+	Branch string
+	// Invalid is true if the repo's config doesn't pass validation.
+	Invalid bool
+	// Error is why the config is invalid, or why it couldn't be validated
+	// if Invalid is false.
+	Error     string
+	CheckedAt time.Time
+}
+
+// JobOutput is the output of a job that streams to the jobs web UI, ex. a
+// project's plan, persisted so it can be replayed after Atlantis restarts.
+type JobOutput struct {
+	JobID string
+	// RepoFullName is the owner and repo name, ex. runatlantis/atlantis.
+	RepoFullName   string
+	PullNum        int
+	ProjectName    string
+	RepoRelDir     string
+	Workspace      string
+	HeadCommit     string
+	JobStep        string
+	JobDescription string
+	// StartedAt is when the first line of the job was output.
+	StartedAt time.Time
+	// UpdatedAt is when the output was last persisted.
+	UpdatedAt time.Time
+	// Complete is false if the job was still running when its output was
+	// last persisted.
+	Complete bool
+	// Lines are the lines output so far. They're only set when getting a
+	// single job.
+	Lines []string `json:",omitempty"`
+}
@@ -213,6 +213,17 @@ func TestNewProject(t *testing.T) {
 	}
 }
 
+func TestProjectRename_Matches(t *testing.T) {
+	rename := models.ProjectRename{
+		RepoFullName: "owner/repo",
+		From:         models.ProjectLocation{RepoRelDir: "./network/", Workspace: "default", ProjectName: "network"},
+		To:           models.ProjectLocation{RepoRelDir: "infra/network", Workspace: "default", ProjectName: "network"},
+	}
+	Assert(t, rename.Matches("network", "default", "network"), "expected the dirs to match once cleaned")
+	Assert(t, !rename.Matches("network", "staging", "network"), "expected another workspace not to match")
+	Assert(t, !rename.Matches("network", "default", ""), "expected another name not to match")
+}
+
 func TestVCSHostType_ToString(t *testing.T) {
 	cases := []struct {
 		vcsType models.VCSHostType
@@ -238,6 +249,10 @@ func TestVCSHostType_ToString(t *testing.T) {
 			models.AzureDevops,
 			"AzureDevops",
 		},
+		{
+			models.VCSAdapter,
+			"VCSAdapter",
+		},
 	}
 
 	for _, c := range cases {
@@ -726,3 +741,79 @@ func TestPlanSuccessStats(t *testing.T) {
 		})
 	}
 }
+
+func TestCountDriftedResources(t *testing.T) {
+	output := `Note: Objects have changed outside of Terraform
+
+Terraform detected the following changes made outside of Terraform since the
+last "terraform apply" which may have affected this plan:
+
+  # aws_instance.web has changed
+  ~ resource "aws_instance" "web" {
+      ~ instance_type = "t2.micro" -> "t2.large"
+    }
+
+  # aws_s3_bucket.logs has been deleted
+  - resource "aws_s3_bucket" "logs" {
+    }
+
+This is a refresh-only plan, so Terraform will not take any actions to undo
+these.`
+	Equals(t, 2, models.CountDriftedResources(output))
+	Equals(t, 0, models.CountDriftedResources("No changes. Your infrastructure still matches the configuration."))
+}
+
+func TestParsePlanResourceChanges(t *testing.T) {
+	output := `Note: Objects have changed outside of Terraform
+
+  # aws_instance.drifted has changed
+  ~ resource "aws_instance" "drifted" {
+    }
+
+Terraform will perform the following actions:
+
+  # aws_instance.web will be created
+  + resource "aws_instance" "web" {
+    }
+
+  # module.vpc.aws_subnet.private["a b"] must be replaced
+-/+ resource "aws_subnet" "private" {
+    }
+
+  # aws_s3_bucket.old has moved to aws_s3_bucket.new
+    resource "aws_s3_bucket" "new" {
+    }
+
+Plan: 2 to add, 0 to change, 1 to destroy.`
+	Equals(t, []models.PlanResourceChange{
+		{Address: "aws_instance.web", Action: "will be created"},
+		{Address: `module.vpc.aws_subnet.private["a b"]`, Action: "must be replaced"},
+		{Address: "aws_s3_bucket.old", Action: "has moved to aws_s3_bucket.new"},
+	}, models.ParsePlanResourceChanges(output))
+	Equals(t, 0, len(models.ParsePlanResourceChanges("No changes. Your infrastructure matches the configuration.")))
+}
+
+func TestNewPlanChangesetDiff(t *testing.T) {
+	previous := []models.PlanResourceChange{
+		{Address: "aws_instance.web", Action: "will be created"},
+		{Address: "aws_s3_bucket.logs", Action: "will be updated in-place"},
+		{Address: "aws_iam_role.app", Action: "will be destroyed"},
+	}
+	changes := []models.PlanResourceChange{
+		{Address: "aws_instance.web", Action: "will be created"},
+		{Address: "aws_s3_bucket.logs", Action: "must be replaced"},
+		{Address: "aws_iam_role.worker", Action: "will be created"},
+	}
+	diff := models.NewPlanChangesetDiff("0123456789", previous, changes)
+	Equals(t, models.PlanChangesetDiff{
+		PreviousCommit: "0123456789",
+		Added:          []models.PlanResourceChange{{Address: "aws_iam_role.worker", Action: "will be created"}},
+		Removed:        []models.PlanResourceChange{{Address: "aws_iam_role.app", Action: "will be destroyed"}},
+		Changed: []models.PlanResourceActionChange{
+			{Address: "aws_s3_bucket.logs", PreviousAction: "will be updated in-place", Action: "must be replaced"},
+		},
+	}, diff)
+	Assert(t, !diff.Empty(), "expected diff not to be empty")
+	Equals(t, "0123456", diff.ShortPreviousCommit())
+	Assert(t, models.NewPlanChangesetDiff("abc", previous, previous).Empty(), "expected diff to be empty")
+}
@@ -5,6 +5,7 @@ import (
 	"io/fs"
 	"os"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/terraform-config-inspect/tfconfig"
@@ -117,6 +118,23 @@ func (m moduleInfo) load(files fs.FS, dir string, projects ...string) (_ *module
 	return m[dir], diags
 }
 
+// moduleDependencies returns the dirs of the local modules that the module at
+// dir depends on, directly or through other modules.
+func moduleDependencies(files fs.FS, dir string) ([]string, error) {
+	m := make(moduleInfo)
+	if _, diags := m.load(files, dir); diags.HasErrors() {
+		return nil, diags.Err()
+	}
+	var deps []string
+	for modDir := range m {
+		if modDir != dir {
+			deps = append(deps, modDir)
+		}
+	}
+	sort.Strings(deps)
+	return deps, nil
+}
+
 // FindModuleProjects returns a mapping of modules to projects that depend on them.
 func FindModuleProjects(absRepoDir string, autoplanModuleDependants string) (ModuleProjects, error) {
 	return findModuleDependants(os.DirFS(absRepoDir), autoplanModuleDependants)
@@ -70,3 +70,16 @@ func Test_findModuleDependants(t *testing.T) {
 		})
 	}
 }
+
+func Test_moduleDependencies(t *testing.T) {
+	a, err := fs.Sub(repos, "testdata/fs/repoA")
+	require.NoError(t, err)
+
+	deps, err := moduleDependencies(a, "qux/quxx")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"modules/bar", "modules/foo"}, deps)
+
+	deps, err = moduleDependencies(a, "baz")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"modules/bar"}, deps)
+}
@@ -0,0 +1,90 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+func NewOkToTestCommandRunner(
+	vcsClient vcs.Client,
+	backend locking.Backend,
+	approvers *UserAllowlist,
+) *OkToTestCommandRunner {
+	return &OkToTestCommandRunner{
+		vcsClient: vcsClient,
+		backend:   backend,
+		Approvers: approvers,
+	}
+}
+
+// OkToTestCommandRunner approves the current commit of a fork pull request so
+// that Atlantis will run commands on it.
+type OkToTestCommandRunner struct {
+	vcsClient vcs.Client
+	backend   locking.Backend
+	// Approvers are the users that are allowed to approve fork pull
+	// requests.
+	Approvers *UserAllowlist
+}
+
+func (o *OkToTestCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	baseRepo := ctx.Pull.BaseRepo
+	pullNum := ctx.Pull.Num
+	// The ok-to-test command is also run when the approval label is added.
+	labeled := cmd != nil && cmd.ApprovedCommit != ""
+
+	var vcsMessage string
+	switch {
+	case ctx.HeadRepo.Owner == baseRepo.Owner:
+		if labeled {
+			return
+		}
+		vcsMessage = "This pull request isn't from a fork so it doesn't need to be approved"
+	case labeled && cmd.ApprovedCommit != ctx.Pull.HeadCommit:
+		ctx.Log.Info("new commits were pushed after the approval label was added at commit %s", cmd.ApprovedCommit)
+		vcsMessage = fmt.Sprintf("New commits were pushed after this pull request was approved at commit %s, so it needs to be approved again", cmd.ApprovedCommit)
+	case labeled && o.Approvers.IsEmpty():
+		// Without approvers, anyone who can add labels can approve.
+		vcsMessage = o.approve(ctx)
+	case !o.isApprover(ctx):
+		ctx.Log.Info("user %s is not allowed to approve fork pull requests", ctx.User.Username)
+		vcsMessage = fmt.Sprintf("User @%s is not allowed to approve pull requests from forks", ctx.User.Username)
+	default:
+		vcsMessage = o.approve(ctx)
+	}
+
+	if commentErr := o.vcsClient.CreateComment(ctx.Log, baseRepo, pullNum, vcsMessage, command.OkToTest.String()); commentErr != nil {
+		ctx.Log.Err("unable to comment: %s", commentErr)
+	}
+}
+
+// approve records that the current commit of the pull request was approved by
+// the user of ctx and returns the comment to reply with.
+func (o *OkToTestCommandRunner) approve(ctx *command.Context) string {
+	approval := models.ForkApproval{
+		HeadCommit: ctx.Pull.HeadCommit,
+		Approver:   ctx.User.Username,
+		Time:       time.Now(),
+	}
+	if err := o.backend.UpdateForkApproval(ctx.Pull, approval); err != nil {
+		ctx.Log.Err("failed to approve fork pull request: %s", err)
+		return "Failed to approve pull request"
+	}
+	ctx.Log.Info("fork pull request approved by %s at commit %s", approval.Approver, approval.HeadCommit)
+	return fmt.Sprintf("Atlantis commands can now be run on commit %s. New commits will need to be approved again.", approval.HeadCommit)
+}
+
+// isApprover returns true if the user who commented is allowed to approve
+// fork pull requests.
+func (o *OkToTestCommandRunner) isApprover(ctx *command.Context) bool {
+	isApprover, err := o.Approvers.IsAllowed(ctx.Log, ctx.Pull.BaseRepo, ctx.User)
+	if err != nil {
+		ctx.Log.Err("unable to check if user %s can approve fork pull requests: %s", ctx.User.Username, err)
+	}
+	return isApprover
+}
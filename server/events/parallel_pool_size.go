@@ -0,0 +1,71 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ParallelPoolSize is the number of projects that are planned, applied or
+// policy checked at the same time when running in parallel. It can be changed
+// while Atlantis is running, ex. to throttle Atlantis during an incident.
+// Commands that are already running keep the size they started with.
+type ParallelPoolSize struct {
+	mutex sync.RWMutex
+	// size is the size set with --parallel-pool-size or the API.
+	size int
+	// limit caps size when the host is under pressure. It's 0 if there's no
+	// limit.
+	limit int
+}
+
+// NewParallelPoolSize returns a pool size of size.
+func NewParallelPoolSize(size int) *ParallelPoolSize {
+	return &ParallelPoolSize{size: size}
+}
+
+// Get returns the size that new commands should use.
+func (p *ParallelPoolSize) Get() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	size := p.size
+	if p.limit > 0 && p.limit < size {
+		size = p.limit
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// Size returns the size that was set, ignoring the limit.
+func (p *ParallelPoolSize) Size() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.size
+}
+
+// Set sets the size to size.
+func (p *ParallelPoolSize) Set(size int) error {
+	if size < 1 {
+		return errors.Errorf("parallel pool size must be at least 1, got %d", size)
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.size = size
+	return nil
+}
+
+// Limit returns the limit on the size, or 0 if there's no limit.
+func (p *ParallelPoolSize) Limit() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.limit
+}
+
+// SetLimit caps the size at limit. A limit of 0 removes the cap.
+func (p *ParallelPoolSize) SetLimit(limit int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.limit = limit
+}
@@ -0,0 +1,26 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestParallelPoolSize(t *testing.T) {
+	pool := events.NewParallelPoolSize(10)
+	Equals(t, 10, pool.Get())
+
+	ErrEquals(t, "parallel pool size must be at least 1, got 0", pool.Set(0))
+	Ok(t, pool.Set(4))
+	Equals(t, 4, pool.Get())
+
+	// The limit only applies while it's smaller than the size.
+	pool.SetLimit(2)
+	Equals(t, 2, pool.Get())
+	Equals(t, 4, pool.Size())
+	pool.SetLimit(8)
+	Equals(t, 4, pool.Get())
+	pool.SetLimit(0)
+	Equals(t, 4, pool.Get())
+}
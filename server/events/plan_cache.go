@@ -0,0 +1,313 @@
+package events
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+const (
+	// planCacheDir is the dir under the data dir where plans are cached.
+	planCacheDir = "plan-cache"
+	// planCacheEntryFile is the file in a cache entry that holds the key and
+	// output of the cached plan.
+	planCacheEntryFile = "entry.json"
+	// planCachePlanFile is the file in a cache entry that holds the plan.
+	planCachePlanFile = "plan"
+)
+
+//go:generate pegomock generate --package mocks -o mocks/mock_plan_cache.go PlanCache
+
+// PlanCache caches the last plan of each project of a pull request so that
+// when a project is planned again without any change to its inputs, the
+// cached plan can be reused instead of running terraform.
+type PlanCache interface {
+	// Key returns the key of the inputs of the project in ctx, cloned into
+	// repoDir. The inputs are the project's dir, the local modules and var
+	// files it uses, the commit of the base branch, the project's plan config
+	// and state, which summarizes the project's state so that the key changes
+	// whenever the state is written to.
+	Key(ctx command.ProjectContext, repoDir string, state string) (string, error)
+	// Get restores the cached plan of the project in ctx into the project's
+	// dir if it was cached with key and returns its output. It returns false
+	// if there's no such plan.
+	Get(ctx command.ProjectContext, repoDir string, key string) (string, bool, error)
+	// Put caches the plan of the project in ctx with key and output.
+	Put(ctx command.ProjectContext, repoDir string, key string, output string) error
+	// DeleteForProject deletes the cached plan of the project in ctx.
+	DeleteForProject(ctx command.ProjectContext) error
+	// Delete deletes the cached plans of pull.
+	Delete(repo models.Repo, pull models.PullRequest) error
+}
+
+// DefaultPlanCache caches plans on disk under the data dir.
+type DefaultPlanCache struct {
+	DataDir string
+}
+
+// planCacheEntry is the key and output of a cached plan.
+type planCacheEntry struct {
+	Key    string
+	Output string
+}
+
+// Key returns the key of the inputs of the project in ctx.
+func (c *DefaultPlanCache) Key(ctx command.ProjectContext, repoDir string, state string) (string, error) {
+	baseCommit, err := c.baseCommit(ctx.Pull, repoDir)
+	if err != nil {
+		return "", errors.Wrap(err, "finding the commit of the base branch")
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "workspace=%s\nproject=%s\ndir=%s\n", ctx.Workspace, ctx.ProjectName, ctx.RepoRelDir)
+	fmt.Fprintf(h, "args=%q\n", ctx.EscapedCommentArgs)
+	for _, step := range ctx.Steps {
+		fmt.Fprintf(h, "step=%s %q\n", step.StepName, step.ExtraArgs)
+	}
+	if ctx.TerraformVersion != nil {
+		fmt.Fprintf(h, "version=%s\n", ctx.TerraformVersion)
+	}
+	if ctx.TerraformDistribution != nil {
+		fmt.Fprintf(h, "distribution=%s\n", *ctx.TerraformDistribution)
+	}
+	fmt.Fprintf(h, "state=%s\n", state)
+	var vars []string
+	for name, value := range ctx.TerraformVars {
+		vars = append(vars, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(vars)
+	fmt.Fprintf(h, "vars=%q\n", vars)
+	var envs []string
+	for name, value := range ctx.Envs {
+		envs = append(envs, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(envs)
+	fmt.Fprintf(h, "envs=%q\n", envs)
+	fmt.Fprintf(h, "base=%s %s\n", ctx.Pull.BaseBranch, baseCommit)
+
+	modules, err := moduleDependencies(os.DirFS(repoDir), ctx.RepoRelDir)
+	if err != nil {
+		return "", errors.Wrap(err, "finding modules")
+	}
+	for _, dir := range append([]string{ctx.RepoRelDir}, modules...) {
+		if err := c.hashDir(h, repoDir, dir); err != nil {
+			return "", errors.Wrapf(err, "hashing dir '%s'", dir)
+		}
+	}
+	// Var files can be outside of the project's dir, ex. ../common.tfvars.
+	for _, varFile := range planVarFiles(ctx.Steps) {
+		path := varFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(repoDir, ctx.RepoRelDir, varFile)
+		}
+		if err := c.hashFile(h, path, varFile); err != nil {
+			return "", errors.Wrapf(err, "hashing var file '%s'", varFile)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Get restores the cached plan of the project in ctx if it was cached with
+// key.
+func (c *DefaultPlanCache) Get(ctx command.ProjectContext, repoDir string, key string) (string, bool, error) {
+	entryDir := c.entryDir(ctx)
+	data, err := os.ReadFile(filepath.Join(entryDir, planCacheEntryFile))
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	var entry planCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return "", false, errors.Wrap(err, "parsing cache entry")
+	}
+	if entry.Key != key {
+		return "", false, nil
+	}
+	planFile := filepath.Join(repoDir, ctx.RepoRelDir, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	if err := copyFile(filepath.Join(entryDir, planCachePlanFile), planFile); err != nil {
+		return "", false, errors.Wrap(err, "restoring plan")
+	}
+	return entry.Output, true, nil
+}
+
+// Put caches the plan of the project in ctx with key and output.
+func (c *DefaultPlanCache) Put(ctx command.ProjectContext, repoDir string, key string, output string) error {
+	entryDir := c.entryDir(ctx)
+	// Delete the old entry first so that it can't be read with the new plan.
+	if err := os.RemoveAll(entryDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(entryDir, 0700); err != nil {
+		return err
+	}
+	planFile := filepath.Join(repoDir, ctx.RepoRelDir, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	if err := copyFile(planFile, filepath.Join(entryDir, planCachePlanFile)); err != nil {
+		return errors.Wrap(err, "copying plan")
+	}
+	data, err := json.Marshal(planCacheEntry{Key: key, Output: output})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(entryDir, planCacheEntryFile), data, 0600)
+}
+
+// DeleteForProject deletes the cached plan of the project in ctx.
+func (c *DefaultPlanCache) DeleteForProject(ctx command.ProjectContext) error {
+	return os.RemoveAll(c.entryDir(ctx))
+}
+
+// Delete deletes the cached plans of pull.
+func (c *DefaultPlanCache) Delete(repo models.Repo, pull models.PullRequest) error {
+	return os.RemoveAll(c.pullDir(repo, pull))
+}
+
+// baseCommit returns the commit of the base branch of pull. It's the commit
+// that was merged into repoDir, or when the branch checkout strategy is used
+// and the base branch isn't fetched, the commit the base branch is at in the
+// base repo. Changes to the base branch can change the state of the project
+// even if they aren't planned, ex. when another pull request is applied.
+func (c *DefaultPlanCache) baseCommit(pull models.PullRequest, repoDir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", fmt.Sprintf("refs/remotes/origin/%s^{commit}", pull.BaseBranch)) // #nosec
+	cmd.Dir = repoDir
+	if out, err := cmd.Output(); err == nil {
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	cmd = exec.Command("git", "ls-remote", "--", pull.BaseRepo.CloneURL, fmt.Sprintf("refs/heads/%s", pull.BaseBranch)) // #nosec
+	cmd.Dir = repoDir
+	out, err := cmd.Output()
+	if err != nil {
+		// The error isn't wrapped since the clone URL can contain credentials.
+		return "", fmt.Errorf("running git ls-remote on the base repo: %s", err)
+	}
+	commit, _, _ := strings.Cut(string(out), "\t")
+	if commit == "" {
+		return "", fmt.Errorf("base branch %q not found", pull.BaseBranch)
+	}
+	return strings.TrimSpace(commit), nil
+}
+
+// planVarFiles returns the var files passed to the plan steps in steps with
+// -var-file.
+func planVarFiles(steps []valid.Step) []string {
+	var varFiles []string
+	for _, step := range steps {
+		if step.StepName != "plan" {
+			continue
+		}
+		for i, arg := range step.ExtraArgs {
+			// Terraform accepts flags with one or two dashes.
+			if strings.HasPrefix(arg, "--") {
+				arg = arg[1:]
+			}
+			switch {
+			case strings.HasPrefix(arg, "-var-file="):
+				varFiles = append(varFiles, strings.TrimPrefix(arg, "-var-file="))
+			case arg == "-var-file" && i+1 < len(step.ExtraArgs):
+				varFiles = append(varFiles, step.ExtraArgs[i+1])
+			}
+		}
+	}
+	return varFiles
+}
+
+// hashFile writes name and the contents of the file at path to h.
+func (c *DefaultPlanCache) hashFile(h hash.Hash, path string, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() // nolint: errcheck
+	fmt.Fprintf(h, "varfile=%s\n", filepath.ToSlash(name))
+	size, err := io.Copy(h, f)
+	fmt.Fprintf(h, "\nsize=%d\n", size)
+	return err
+}
+
+// hashDir writes the paths and contents of the files in dir to h. Files that
+// are written by Atlantis or terraform, ex. plans, are skipped.
+func (c *DefaultPlanCache) hashDir(h hash.Hash, repoDir string, dir string) error {
+	return filepath.WalkDir(filepath.Join(repoDir, dir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", ".terraform", ".terragrunt-cache":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) == ".tfplan" {
+			return nil
+		}
+		relPath, err := filepath.Rel(repoDir, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "file=%s\n", filepath.ToSlash(relPath))
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "symlink=%s\n", target)
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close() // nolint: errcheck
+		size, err := io.Copy(h, f)
+		fmt.Fprintf(h, "\nsize=%d\n", size)
+		return err
+	})
+}
+
+func (c *DefaultPlanCache) pullDir(repo models.Repo, pull models.PullRequest) string {
+	return filepath.Join(c.DataDir, planCacheDir, repo.FullName, strconv.Itoa(pull.Num))
+}
+
+// entryDir returns the dir that the plan of the project in ctx is cached in.
+// Project names and dirs can contain characters that aren't valid in paths so
+// the dir is named by a hash of them.
+func (c *DefaultPlanCache) entryDir(ctx command.ProjectContext) string {
+	project := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s", ctx.Workspace, ctx.ProjectName, ctx.RepoRelDir)))
+	return filepath.Join(c.pullDir(ctx.Pull.BaseRepo, ctx.Pull), hex.EncodeToString(project[:]))
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint: errcheck
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close() // nolint: errcheck
+		return err
+	}
+	return out.Close()
+}
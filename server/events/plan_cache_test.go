@@ -0,0 +1,156 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// initPlanCacheRepo creates a repo with a project in dir1 that uses the
+// module in modules/mod, and a project in dir2.
+func initPlanCacheRepo(t *testing.T) string {
+	t.Helper()
+	repoDir := t.TempDir()
+	files := map[string]string{
+		"dir1/main.tf":        "module \"mod\" {\n  source = \"../modules/mod\"\n}\n",
+		"modules/mod/main.tf": "resource \"null_resource\" \"mod\" {}\n",
+		"dir2/main.tf":        "resource \"null_resource\" \"dir2\" {}\n",
+	}
+	for path, contents := range files {
+		Ok(t, os.MkdirAll(filepath.Dir(filepath.Join(repoDir, path)), 0700))
+		Ok(t, os.WriteFile(filepath.Join(repoDir, path), []byte(contents), 0600))
+	}
+	// The base branch is fetched like with the merge checkout strategy.
+	runCmd(t, repoDir, "git", "init", "--initial-branch=main")
+	runCmd(t, repoDir, "git", "fetch", initRepo(t), "main:refs/remotes/origin/main")
+	return repoDir
+}
+
+func planCacheCtx() command.ProjectContext {
+	return command.ProjectContext{
+		Pull:       models.PullRequest{Num: 1, BaseRepo: models.Repo{FullName: "org/app"}, BaseBranch: "main"},
+		RepoRelDir: "dir1",
+		Workspace:  "default",
+		Steps:      []valid.Step{{StepName: "init"}, {StepName: "plan"}},
+	}
+}
+
+func TestDefaultPlanCache_Key(t *testing.T) {
+	repoDir := initPlanCacheRepo(t)
+	cache := &events.DefaultPlanCache{DataDir: t.TempDir()}
+	ctx := planCacheCtx()
+	key, err := cache.Key(ctx, repoDir, "Serial: 1")
+	Ok(t, err)
+
+	// Files that aren't inputs of the project don't change the key.
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "dir2", "main.tf"), []byte("changed"), 0600))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "dir1", "default.tfplan"), []byte("plan"), 0600))
+	Ok(t, os.MkdirAll(filepath.Join(repoDir, "dir1", ".terraform"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "dir1", ".terraform", "terraform.tfstate"), []byte("state"), 0600))
+	unchangedKey, err := cache.Key(ctx, repoDir, "Serial: 1")
+	Ok(t, err)
+	Equals(t, key, unchangedKey)
+
+	// Changing a module the project uses changes the key.
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "modules", "mod", "variables.tf"), []byte("variable \"a\" {}\n"), 0600))
+	moduleKey, err := cache.Key(ctx, repoDir, "Serial: 1")
+	Ok(t, err)
+	Assert(t, moduleKey != key, "expected the key to change when a module changes")
+
+	// So does changing the project's config.
+	ctx.EscapedCommentArgs = []string{"\\-\\d\\e\\s\\t\\r\\o\\y"}
+	argsKey, err := cache.Key(ctx, repoDir, "Serial: 1")
+	Ok(t, err)
+	Assert(t, argsKey != moduleKey, "expected the key to change when the comment args change")
+
+	// And the environment variables set by the project command hook.
+	ctx.Envs = map[string]string{"DEPLOY_WINDOW": "night"}
+	envsKey, err := cache.Key(ctx, repoDir, "Serial: 1")
+	Ok(t, err)
+	Assert(t, envsKey != argsKey, "expected the key to change when the envs change")
+
+	// And the state.
+	stateKey, err := cache.Key(ctx, repoDir, "Serial: 2")
+	Ok(t, err)
+	Assert(t, stateKey != envsKey, "expected the key to change when the state changes")
+
+	// And var files, even outside of the project's dir.
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "common.tfvars"), []byte("a = 1\n"), 0600))
+	ctx.Steps = []valid.Step{{StepName: "init"}, {StepName: "plan", ExtraArgs: []string{"-var-file", "../common.tfvars"}}}
+	varFileKey, err := cache.Key(ctx, repoDir, "Serial: 1")
+	Ok(t, err)
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "common.tfvars"), []byte("a = 2\n"), 0600))
+	changedVarFileKey, err := cache.Key(ctx, repoDir, "Serial: 1")
+	Ok(t, err)
+	Assert(t, changedVarFileKey != varFileKey, "expected the key to change when a var file changes")
+}
+
+func TestDefaultPlanCache_KeyBranchCheckout(t *testing.T) {
+	// With the branch checkout strategy the base branch isn't fetched so its
+	// commit is looked up in the base repo.
+	repoDir := t.TempDir()
+	Ok(t, os.MkdirAll(filepath.Join(repoDir, "dir1"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "dir1", "main.tf"), []byte("resource \"null_resource\" \"dir1\" {}\n"), 0600))
+	runCmd(t, repoDir, "git", "init", "--initial-branch=branch")
+	baseRepoDir := initRepo(t)
+	cache := &events.DefaultPlanCache{DataDir: t.TempDir()}
+	ctx := planCacheCtx()
+	ctx.Pull.BaseRepo.CloneURL = baseRepoDir
+
+	key, err := cache.Key(ctx, repoDir, "Serial: 1")
+	Ok(t, err)
+	runCmd(t, baseRepoDir, "git", "commit", "--allow-empty", "-m", "another commit")
+	baseKey, err := cache.Key(ctx, repoDir, "Serial: 1")
+	Ok(t, err)
+	Assert(t, baseKey != key, "expected the key to change when the base branch changes")
+
+	ctx.Pull.BaseBranch = "missing"
+	_, err = cache.Key(ctx, repoDir, "Serial: 1")
+	ErrContains(t, `base branch "missing" not found`, err)
+}
+
+func TestDefaultPlanCache_GetPut(t *testing.T) {
+	repoDir := initPlanCacheRepo(t)
+	cache := &events.DefaultPlanCache{DataDir: t.TempDir()}
+	ctx := planCacheCtx()
+	planFile := filepath.Join(repoDir, "dir1", "default.tfplan")
+
+	_, ok, err := cache.Get(ctx, repoDir, "key")
+	Ok(t, err)
+	Assert(t, !ok, "expected no cached plan")
+
+	Ok(t, os.WriteFile(planFile, []byte("plan"), 0600))
+	Ok(t, cache.Put(ctx, repoDir, "key", "Plan: 1 to add, 0 to change, 0 to destroy."))
+	Ok(t, os.Remove(planFile))
+
+	_, ok, err = cache.Get(ctx, repoDir, "other-key")
+	Ok(t, err)
+	Assert(t, !ok, "expected no cached plan for a different key")
+	_, err = os.Stat(planFile)
+	Assert(t, os.IsNotExist(err), "expected plan not to be restored")
+
+	output, ok, err := cache.Get(ctx, repoDir, "key")
+	Ok(t, err)
+	Assert(t, ok, "expected a cached plan")
+	Equals(t, "Plan: 1 to add, 0 to change, 0 to destroy.", output)
+	plan, err := os.ReadFile(planFile)
+	Ok(t, err)
+	Equals(t, "plan", string(plan))
+
+	Ok(t, cache.DeleteForProject(ctx))
+	_, ok, err = cache.Get(ctx, repoDir, "key")
+	Ok(t, err)
+	Assert(t, !ok, "expected the cached plan to be deleted")
+
+	Ok(t, cache.Put(ctx, repoDir, "key", "output"))
+	Ok(t, cache.Delete(ctx.Pull.BaseRepo, ctx.Pull))
+	_, ok, err = cache.Get(ctx, repoDir, "key")
+	Ok(t, err)
+	Assert(t, !ok, "expected the pull's cached plans to be deleted")
+}
@@ -20,7 +20,7 @@ func NewPlanCommandRunner(
 	pullUpdater *PullUpdater,
 	policyCheckCommandRunner *PolicyCheckCommandRunner,
 	autoMerger *AutoMerger,
-	parallelPoolSize int,
+	parallelPoolSize *ParallelPoolSize,
 	SilenceNoProjects bool,
 	pullStatusFetcher PullStatusFetcher,
 	lockingLocker locking.Locker,
@@ -69,7 +69,7 @@ type PlanCommandRunner struct {
 	pullUpdater                *PullUpdater
 	policyCheckCommandRunner   *PolicyCheckCommandRunner
 	autoMerger                 *AutoMerger
-	parallelPoolSize           int
+	parallelPoolSize           *ParallelPoolSize
 	pullStatusFetcher          PullStatusFetcher
 	lockingLocker              locking.Locker
 	// DiscardApprovalOnPlan controls if all already existing approvals should be removed/dismissed before executing
@@ -77,6 +77,9 @@ type PlanCommandRunner struct {
 	DiscardApprovalOnPlan bool
 	pullReqStatusFetcher  vcs.PullReqStatusFetcher
 	SilencePRComments     []string
+	// PlanStore holds the stored plans that are deleted along with the
+	// plans of the pull request. It can be nil.
+	PlanStore PlanStore
 }
 
 func (p *PlanCommandRunner) runAutoplan(ctx *command.Context) {
@@ -126,9 +129,9 @@ func (p *PlanCommandRunner) runAutoplan(ctx *command.Context) {
 	var result command.Result
 	if p.isParallelEnabled(projectCmds) {
 		ctx.Log.Info("Running plans in parallel")
-		result = runProjectCmdsParallelGroups(ctx, projectCmds, p.prjCmdRunner.Plan, p.parallelPoolSize)
+		result = runProjectCmdsParallelGroups(ctx, projectCmds, p.prjCmdRunner.Plan, p.parallelPoolSize.Get())
 	} else {
-		result = runProjectCmds(projectCmds, p.prjCmdRunner.Plan)
+		result = runProjectCmdsInOrder(projectCmds, p.prjCmdRunner.Plan)
 	}
 
 	if p.autoMerger.automergeEnabled(projectCmds) && result.HasErrors() {
@@ -195,8 +198,9 @@ func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
 	if len(projectCmds) == 0 && p.SilenceNoProjects {
 		ctx.Log.Info("determined there was no project to run plan in")
 		if !p.silenceVCSStatusNoProjects {
-			if cmd.IsForSpecificProject() {
-				// With a specific plan, just reset the status so it's not stuck in pending state
+			if cmd.IsForSpecificProject() || cmd.Failed {
+				// With a specific plan, or when no projects failed, just reset
+				// the status so it's not stuck in pending state
 				pullStatus, err := p.pullStatusFetcher.GetPullStatus(pull)
 				if err != nil {
 					ctx.Log.Warn("unable to fetch pull status: %s", err)
@@ -235,8 +239,9 @@ func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
 	projectCmds, policyCheckCmds := p.partitionProjectCmds(ctx, projectCmds)
 
 	// if the plan is generic, new plans will be generated based on changes
-	// discard previous plans that might not be relevant anymore
-	if !cmd.IsForSpecificProject() {
+	// discard previous plans that might not be relevant anymore. Re-running
+	// the failed projects keeps the plans of the others.
+	if !cmd.IsForSpecificProject() && !cmd.Failed {
 		ctx.Log.Debug("deleting previous plans and locks")
 		p.deletePlans(ctx)
 		_, err = p.lockingLocker.UnlockByPull(baseRepo.FullName, pull.Num)
@@ -249,9 +254,9 @@ func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
 	var result command.Result
 	if p.isParallelEnabled(projectCmds) {
 		ctx.Log.Info("Running plans in parallel")
-		result = runProjectCmdsParallelGroups(ctx, projectCmds, p.prjCmdRunner.Plan, p.parallelPoolSize)
+		result = runProjectCmdsParallelGroups(ctx, projectCmds, p.prjCmdRunner.Plan, p.parallelPoolSize.Get())
 	} else {
-		result = runProjectCmds(projectCmds, p.prjCmdRunner.Plan)
+		result = runProjectCmdsInOrder(projectCmds, p.prjCmdRunner.Plan)
 	}
 
 	if p.autoMerger.automergeEnabled(projectCmds) && result.HasErrors() {
@@ -280,7 +285,7 @@ func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
 		!(result.HasErrors() || result.PlansDeleted) {
 		ctx.Log.Info("Running policy check for '%s'", cmd.CommandName())
 		p.policyCheckCommandRunner.Run(ctx, policyCheckCmds)
-	} else if len(projectCmds) == 0 && !cmd.IsForSpecificProject() {
+	} else if len(projectCmds) == 0 && !cmd.IsForSpecificProject() && !cmd.Failed {
 		// If there were no projects modified, we set successful commit statuses
 		// with 0/0 projects planned/policy_checked/applied successfully because some users require
 		// the Atlantis status to be passing for all pull requests.
@@ -348,6 +353,11 @@ func (p *PlanCommandRunner) deletePlans(ctx *command.Context) {
 	if err := p.pendingPlanFinder.DeletePlans(pullDir); err != nil {
 		ctx.Log.Err("deleting pending plans: %s", err)
 	}
+	if p.PlanStore != nil {
+		if err := p.PlanStore.Delete(ctx.Pull); err != nil {
+			ctx.Log.Err("deleting stored plans: %s", err)
+		}
+	}
 }
 
 func (p *PlanCommandRunner) partitionProjectCmds(
@@ -0,0 +1,247 @@
+package events
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+const (
+	// planHistoryDir is the dir under the data dir where the plan history of
+	// pull requests is stored.
+	planHistoryDir = "plan-history"
+	// planHistoryMaxEntries is how many plans of each project are kept.
+	planHistoryMaxEntries = 10
+)
+
+// PlanHistory stores the resource changes of the successive plans of each
+// project of a pull request, so that reviewers of a pull request that's
+// iterated on can see what changed between plans.
+type PlanHistory struct {
+	DataDir string
+
+	// mutex serializes updates to the history files since the projects of a
+	// pull request can be planned in parallel.
+	mutex sync.Mutex
+}
+
+// ProjectPlanHistory is the plan history of a project of a pull request.
+type ProjectPlanHistory struct {
+	RepoRelDir  string
+	Workspace   string
+	ProjectName string
+	// Plans are the plans of the project, oldest first.
+	Plans []PlanHistoryEntry
+}
+
+// PlanHistoryEntry is a plan of a project.
+type PlanHistoryEntry struct {
+	// HeadCommit is the head commit of the pull request that was planned.
+	HeadCommit string
+	// PlannedAt is when the plan was run.
+	PlannedAt time.Time
+	// Changes are the changes the plan makes to resources.
+	Changes []models.PlanResourceChange
+}
+
+// Add adds the plan of the project in ctx with terraformOutput to the
+// history and returns how its changes differ from the project's previous
+// plan. It returns nil if there was no previous plan.
+func (h *PlanHistory) Add(ctx command.ProjectContext, terraformOutput string) (*models.PlanChangesetDiff, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	file := h.projectFile(ctx)
+	history := ProjectPlanHistory{
+		RepoRelDir:  ctx.RepoRelDir,
+		Workspace:   ctx.Workspace,
+		ProjectName: ctx.ProjectName,
+	}
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &history); err != nil {
+			return nil, errors.Wrap(err, "parsing plan history")
+		}
+	}
+
+	entry := PlanHistoryEntry{
+		HeadCommit: ctx.Pull.HeadCommit,
+		PlannedAt:  time.Now(),
+		Changes:    models.ParsePlanResourceChanges(terraformOutput),
+	}
+	var diff *models.PlanChangesetDiff
+	if len(history.Plans) > 0 {
+		previous := history.Plans[len(history.Plans)-1]
+		d := models.NewPlanChangesetDiff(previous.HeadCommit, previous.Changes, entry.Changes)
+		diff = &d
+		// Planning again without any change, ex. to refresh a stale plan,
+		// only updates when the last plan was run.
+		if d.Empty() && previous.HeadCommit == entry.HeadCommit {
+			history.Plans = history.Plans[:len(history.Plans)-1]
+		}
+	}
+	history.Plans = append(history.Plans, entry)
+	if len(history.Plans) > planHistoryMaxEntries {
+		history.Plans = history.Plans[len(history.Plans)-planHistoryMaxEntries:]
+	}
+
+	data, err = json.Marshal(history)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// Get returns the plan history of the projects of pull, sorted by dir,
+// workspace and project name.
+func (h *PlanHistory) Get(repoFullName string, pullNum int) ([]ProjectPlanHistory, error) {
+	if strings.Contains(repoFullName, "..") {
+		return nil, errors.Errorf("invalid repo %q", repoFullName)
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	dir := h.pullDir(repoFullName, pullNum)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var histories []ProjectPlanHistory
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var history ProjectPlanHistory
+		if err := json.Unmarshal(data, &history); err != nil {
+			return nil, errors.Wrapf(err, "parsing plan history %s", entry.Name())
+		}
+		histories = append(histories, history)
+	}
+	sort.Slice(histories, func(i, j int) bool {
+		a, b := histories[i], histories[j]
+		if a.RepoRelDir != b.RepoRelDir {
+			return a.RepoRelDir < b.RepoRelDir
+		}
+		if a.Workspace != b.Workspace {
+			return a.Workspace < b.Workspace
+		}
+		return a.ProjectName < b.ProjectName
+	})
+	return histories, nil
+}
+
+// Delete deletes the plan history of pull.
+func (h *PlanHistory) Delete(repo models.Repo, pull models.PullRequest) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return os.RemoveAll(h.pullDir(repo.FullName, pull.Num))
+}
+
+// Rename moves the plan history of the project being renamed in every pull
+// of its repo to its new dir, workspace and name. It returns how many
+// histories were moved.
+func (h *PlanHistory) Rename(rename models.ProjectRename) (int, error) {
+	if strings.Contains(rename.RepoFullName, "..") {
+		return 0, errors.Errorf("invalid repo %q", rename.RepoFullName)
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	repoDir := filepath.Join(h.DataDir, planHistoryDir, rename.RepoFullName)
+	pullDirs, err := os.ReadDir(repoDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	renamed := 0
+	for _, pullDir := range pullDirs {
+		if !pullDir.IsDir() {
+			continue
+		}
+		dir := filepath.Join(repoDir, pullDir.Name())
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return renamed, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+				continue
+			}
+			file := filepath.Join(dir, entry.Name())
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return renamed, err
+			}
+			var history ProjectPlanHistory
+			if err := json.Unmarshal(data, &history); err != nil {
+				return renamed, errors.Wrapf(err, "parsing plan history %s", entry.Name())
+			}
+			if !rename.Matches(history.RepoRelDir, history.Workspace, history.ProjectName) {
+				continue
+			}
+			history.RepoRelDir = rename.To.RepoRelDir
+			history.Workspace = rename.To.Workspace
+			history.ProjectName = rename.To.ProjectName
+			if data, err = json.Marshal(history); err != nil {
+				return renamed, err
+			}
+			newFile := historyFile(dir, history.RepoRelDir, history.Workspace, history.ProjectName)
+			if err := os.WriteFile(newFile, data, 0600); err != nil {
+				return renamed, err
+			}
+			if newFile != file {
+				if err := os.Remove(file); err != nil {
+					return renamed, err
+				}
+			}
+			renamed++
+		}
+	}
+	return renamed, nil
+}
+
+func (h *PlanHistory) pullDir(repoFullName string, pullNum int) string {
+	return filepath.Join(h.DataDir, planHistoryDir, repoFullName, strconv.Itoa(pullNum))
+}
+
+// projectFile returns the file that the plan history of the project in ctx
+// is stored in. Project names and dirs can contain characters that aren't
+// valid in paths so the file is named by a hash of them.
+func (h *PlanHistory) projectFile(ctx command.ProjectContext) string {
+	return historyFile(h.pullDir(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num), ctx.RepoRelDir, ctx.Workspace, ctx.ProjectName)
+}
+
+func historyFile(pullDir string, repoRelDir string, workspace string, projectName string) string {
+	project := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s", workspace, projectName, repoRelDir)))
+	return filepath.Join(pullDir, hex.EncodeToString(project[:])+".json")
+}
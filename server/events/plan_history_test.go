@@ -0,0 +1,61 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestPlanHistory(t *testing.T) {
+	history := &events.PlanHistory{DataDir: t.TempDir()}
+	repo := models.Repo{FullName: "owner/repo"}
+	ctx := command.ProjectContext{
+		Pull:       models.PullRequest{BaseRepo: repo, Num: 1, HeadCommit: "commit1"},
+		RepoRelDir: "network",
+		Workspace:  "default",
+	}
+
+	// The first plan has nothing to be compared to.
+	diff, err := history.Add(ctx, "  # aws_vpc.main will be created\n  # aws_subnet.a will be created")
+	Ok(t, err)
+	Assert(t, diff == nil, "expected no diff for the first plan")
+
+	ctx.Pull.HeadCommit = "commit2"
+	diff, err = history.Add(ctx, "  # aws_vpc.main will be created\n  # aws_subnet.b will be created")
+	Ok(t, err)
+	Equals(t, &models.PlanChangesetDiff{
+		PreviousCommit: "commit1",
+		Added:          []models.PlanResourceChange{{Address: "aws_subnet.b", Action: "will be created"}},
+		Removed:        []models.PlanResourceChange{{Address: "aws_subnet.a", Action: "will be created"}},
+	}, diff)
+
+	// Planning the same commit again without changes replaces the last plan.
+	diff, err = history.Add(ctx, "  # aws_vpc.main will be created\n  # aws_subnet.b will be created")
+	Ok(t, err)
+	Assert(t, diff.Empty(), "expected an empty diff")
+
+	other := ctx
+	other.RepoRelDir = "app"
+	_, err = history.Add(other, "")
+	Ok(t, err)
+
+	histories, err := history.Get("owner/repo", 1)
+	Ok(t, err)
+	Equals(t, 2, len(histories))
+	Equals(t, "app", histories[0].RepoRelDir)
+	Equals(t, "network", histories[1].RepoRelDir)
+	Equals(t, 2, len(histories[1].Plans))
+	Equals(t, "commit1", histories[1].Plans[0].HeadCommit)
+	Equals(t, "commit2", histories[1].Plans[1].HeadCommit)
+
+	_, err = history.Get("../owner", 1)
+	Assert(t, err != nil, "expected an error for an invalid repo")
+
+	Ok(t, history.Delete(repo, ctx.Pull))
+	histories, err = history.Get("owner/repo", 1)
+	Ok(t, err)
+	Equals(t, 0, len(histories))
+}
@@ -0,0 +1,206 @@
+package events
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+const (
+	// planStoreMetadataFile is the object of a stored plan that holds its
+	// metadata.
+	planStoreMetadataFile = "metadata.json"
+	// planStorePlanFile is the object of a stored plan that holds the plan.
+	planStorePlanFile = "plan.tfplan"
+	// planStoreLockFile is the object of a stored plan that holds the
+	// dependency lock file the project was planned with, if any.
+	planStoreLockFile = ".terraform.lock.hcl"
+)
+
+// PlanStore stores plans outside of the working dirs so that they can be
+// applied after Atlantis restarts or by another Atlantis server, without
+// planning again.
+type PlanStore interface {
+	// Save stores the plan of the project of meta in projAbsPath, along with
+	// its dependency lock file if it has one.
+	Save(meta PlanMetadata, projAbsPath string, planFilename string) error
+	// Metadata returns the metadata of the stored plan of the project, or
+	// false if there's no such plan.
+	Metadata(pull models.PullRequest, workspace string, repoRelDir string, projectName string) (PlanMetadata, bool, error)
+	// Restore writes the stored plan of the project of meta into projAbsPath,
+	// along with its dependency lock file unless projAbsPath has one.
+	Restore(meta PlanMetadata, projAbsPath string, planFilename string) error
+	// DeleteForProject deletes the stored plan of the project.
+	DeleteForProject(pull models.PullRequest, workspace string, repoRelDir string, projectName string) error
+	// Delete deletes the stored plans of pull.
+	Delete(pull models.PullRequest) error
+}
+
+// PlanMetadata describes a stored plan.
+type PlanMetadata struct {
+	RepoFullName string
+	PullNum      int
+	// HeadCommit is the commit of the pull request that was planned. Plans of
+	// other commits aren't restored.
+	HeadCommit  string
+	ProjectName string
+	Workspace   string
+	RepoRelDir  string
+	// User is the user that planned the project.
+	User string
+	// Locked is true if the project was locked by the pull request when it
+	// was planned. Applying it locks it again if the lock was lost.
+	Locked    bool
+	PlannedAt time.Time
+}
+
+// BlobPlanStore stores plans in a bucket of an object store, ex. S3, GCS or
+// Azure Blob Storage.
+type BlobPlanStore struct {
+	Bucket *blob.Bucket
+}
+
+// Save stores the plan of the project of meta.
+func (s *BlobPlanStore) Save(meta PlanMetadata, projAbsPath string, planFilename string) error {
+	ctx := context.Background()
+	dir := s.projectDir(meta.RepoFullName, meta.PullNum, meta.Workspace, meta.RepoRelDir, meta.ProjectName)
+	// The metadata is deleted first so that the old metadata can't be read
+	// with the new plan.
+	if err := s.deleteObject(ctx, path.Join(dir, planStoreMetadataFile)); err != nil {
+		return err
+	}
+	if err := s.upload(ctx, filepath.Join(projAbsPath, planFilename), path.Join(dir, planStorePlanFile)); err != nil {
+		return errors.Wrap(err, "uploading plan")
+	}
+	if err := s.upload(ctx, filepath.Join(projAbsPath, planStoreLockFile), path.Join(dir, planStoreLockFile)); os.IsNotExist(err) {
+		if err := s.deleteObject(ctx, path.Join(dir, planStoreLockFile)); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return errors.Wrap(err, "uploading dependency lock file")
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return s.Bucket.WriteAll(ctx, path.Join(dir, planStoreMetadataFile), data, nil)
+}
+
+// Metadata returns the metadata of the stored plan of the project.
+func (s *BlobPlanStore) Metadata(pull models.PullRequest, workspace string, repoRelDir string, projectName string) (PlanMetadata, bool, error) {
+	dir := s.projectDir(pull.BaseRepo.FullName, pull.Num, workspace, repoRelDir, projectName)
+	data, err := s.Bucket.ReadAll(context.Background(), path.Join(dir, planStoreMetadataFile))
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return PlanMetadata{}, false, nil
+	}
+	if err != nil {
+		return PlanMetadata{}, false, err
+	}
+	var meta PlanMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return PlanMetadata{}, false, errors.Wrap(err, "parsing plan metadata")
+	}
+	return meta, true, nil
+}
+
+// Restore writes the stored plan of the project of meta into projAbsPath.
+func (s *BlobPlanStore) Restore(meta PlanMetadata, projAbsPath string, planFilename string) error {
+	ctx := context.Background()
+	dir := s.projectDir(meta.RepoFullName, meta.PullNum, meta.Workspace, meta.RepoRelDir, meta.ProjectName)
+	if err := s.download(ctx, path.Join(dir, planStorePlanFile), filepath.Join(projAbsPath, planFilename)); err != nil {
+		return errors.Wrap(err, "downloading plan")
+	}
+	lockFile := filepath.Join(projAbsPath, planStoreLockFile)
+	if _, err := os.Stat(lockFile); err == nil {
+		return nil
+	}
+	if err := s.download(ctx, path.Join(dir, planStoreLockFile), lockFile); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return errors.Wrap(err, "downloading dependency lock file")
+	}
+	return nil
+}
+
+// DeleteForProject deletes the stored plan of the project.
+func (s *BlobPlanStore) DeleteForProject(pull models.PullRequest, workspace string, repoRelDir string, projectName string) error {
+	return s.deletePrefix(s.projectDir(pull.BaseRepo.FullName, pull.Num, workspace, repoRelDir, projectName) + "/")
+}
+
+// Delete deletes the stored plans of pull.
+func (s *BlobPlanStore) Delete(pull models.PullRequest) error {
+	return s.deletePrefix(s.pullDir(pull.BaseRepo.FullName, pull.Num) + "/")
+}
+
+func (s *BlobPlanStore) pullDir(repoFullName string, pullNum int) string {
+	return path.Join("plans", repoFullName, strconv.Itoa(pullNum))
+}
+
+// projectDir returns the prefix of the objects of the stored plan of the
+// project. Project names and dirs can contain characters that aren't valid in
+// keys so it's named by a hash of them.
+func (s *BlobPlanStore) projectDir(repoFullName string, pullNum int, workspace string, repoRelDir string, projectName string) string {
+	project := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s", workspace, projectName, repoRelDir)))
+	return path.Join(s.pullDir(repoFullName, pullNum), hex.EncodeToString(project[:]))
+}
+
+func (s *BlobPlanStore) upload(ctx context.Context, src string, key string) error {
+	in, err := os.Open(src) // nolint: gosec
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint: errcheck
+	return s.Bucket.Upload(ctx, key, in, &blob.WriterOptions{ContentType: "application/octet-stream"})
+}
+
+func (s *BlobPlanStore) download(ctx context.Context, key string, dst string) error {
+	r, err := s.Bucket.NewReader(ctx, key, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Close() // nolint: errcheck
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := r.WriteTo(out); err != nil {
+		out.Close() // nolint: errcheck
+		return err
+	}
+	return out.Close()
+}
+
+func (s *BlobPlanStore) deleteObject(ctx context.Context, key string) error {
+	if err := s.Bucket.Delete(ctx, key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return err
+	}
+	return nil
+}
+
+func (s *BlobPlanStore) deletePrefix(prefix string) error {
+	ctx := context.Background()
+	iter := s.Bucket.List(&blob.ListOptions{Prefix: prefix})
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.deleteObject(ctx, obj.Key); err != nil {
+			return err
+		}
+	}
+}
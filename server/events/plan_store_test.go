@@ -0,0 +1,90 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+	"gocloud.dev/blob/memblob"
+)
+
+func TestBlobPlanStore(t *testing.T) {
+	store := &events.BlobPlanStore{Bucket: memblob.OpenBucket(nil)}
+	pull := models.PullRequest{Num: 1, HeadCommit: "abc", BaseRepo: models.Repo{FullName: "org/app"}}
+	meta := events.PlanMetadata{
+		RepoFullName: "org/app",
+		PullNum:      1,
+		HeadCommit:   "abc",
+		ProjectName:  "app/network",
+		Workspace:    "default",
+		RepoRelDir:   "network",
+		User:         "user",
+		Locked:       true,
+		PlannedAt:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	_, ok, err := store.Metadata(pull, "default", "network", "app/network")
+	Ok(t, err)
+	Assert(t, !ok, "expected no stored plan")
+
+	planDir := t.TempDir()
+	Ok(t, os.WriteFile(filepath.Join(planDir, "app::network-default.tfplan"), []byte("plan"), 0600))
+	Ok(t, os.WriteFile(filepath.Join(planDir, ".terraform.lock.hcl"), []byte("lock"), 0600))
+	Ok(t, store.Save(meta, planDir, "app::network-default.tfplan"))
+
+	stored, ok, err := store.Metadata(pull, "default", "network", "app/network")
+	Ok(t, err)
+	Assert(t, ok, "expected a stored plan")
+	Equals(t, meta, stored)
+
+	// The plan and lock file are restored into a new clone.
+	restoreDir := t.TempDir()
+	Ok(t, store.Restore(stored, restoreDir, "app::network-default.tfplan"))
+	plan, err := os.ReadFile(filepath.Join(restoreDir, "app::network-default.tfplan"))
+	Ok(t, err)
+	Equals(t, "plan", string(plan))
+	lock, err := os.ReadFile(filepath.Join(restoreDir, ".terraform.lock.hcl"))
+	Ok(t, err)
+	Equals(t, "lock", string(lock))
+
+	// A lock file that's committed isn't overwritten.
+	committedDir := t.TempDir()
+	Ok(t, os.WriteFile(filepath.Join(committedDir, ".terraform.lock.hcl"), []byte("committed"), 0600))
+	Ok(t, store.Restore(stored, committedDir, "app::network-default.tfplan"))
+	lock, err = os.ReadFile(filepath.Join(committedDir, ".terraform.lock.hcl"))
+	Ok(t, err)
+	Equals(t, "committed", string(lock))
+
+	Ok(t, store.DeleteForProject(pull, "default", "network", "app/network"))
+	_, ok, err = store.Metadata(pull, "default", "network", "app/network")
+	Ok(t, err)
+	Assert(t, !ok, "expected the stored plan to be deleted")
+}
+
+func TestBlobPlanStore_Delete(t *testing.T) {
+	store := &events.BlobPlanStore{Bucket: memblob.OpenBucket(nil)}
+	pull := models.PullRequest{Num: 1, BaseRepo: models.Repo{FullName: "org/app"}}
+	otherPull := models.PullRequest{Num: 10, BaseRepo: models.Repo{FullName: "org/app"}}
+
+	planDir := t.TempDir()
+	Ok(t, os.WriteFile(filepath.Join(planDir, "default.tfplan"), []byte("plan"), 0600))
+	for _, p := range []models.PullRequest{pull, otherPull} {
+		for _, dir := range []string{"dir1", "dir2"} {
+			Ok(t, store.Save(events.PlanMetadata{RepoFullName: "org/app", PullNum: p.Num, Workspace: "default", RepoRelDir: dir}, planDir, "default.tfplan"))
+		}
+	}
+
+	Ok(t, store.Delete(pull))
+	for _, dir := range []string{"dir1", "dir2"} {
+		_, ok, err := store.Metadata(pull, "default", dir, "")
+		Ok(t, err)
+		Assert(t, !ok, "expected the stored plan of %s to be deleted", dir)
+		_, ok, err = store.Metadata(otherPull, "default", dir, "")
+		Ok(t, err)
+		Assert(t, ok, "expected the stored plan of %s of the other pull to be kept", dir)
+	}
+}
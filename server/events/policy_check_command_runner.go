@@ -10,7 +10,7 @@ func NewPolicyCheckCommandRunner(
 	pullUpdater *PullUpdater,
 	commitStatusUpdater CommitStatusUpdater,
 	projectCommandRunner ProjectPolicyCheckCommandRunner,
-	parallelPoolSize int,
+	parallelPoolSize *ParallelPoolSize,
 	silenceVCSStatusNoProjects bool,
 	quietPolicyChecks bool,
 ) *PolicyCheckCommandRunner {
@@ -30,7 +30,7 @@ type PolicyCheckCommandRunner struct {
 	pullUpdater         *PullUpdater
 	commitStatusUpdater CommitStatusUpdater
 	prjCmdRunner        ProjectPolicyCheckCommandRunner
-	parallelPoolSize    int
+	parallelPoolSize    *ParallelPoolSize
 	// SilenceVCSStatusNoProjects is whether any plan should set commit status if no projects
 	// are found
 	silenceVCSStatusNoProjects bool
@@ -60,7 +60,7 @@ func (p *PolicyCheckCommandRunner) Run(ctx *command.Context, cmds []command.Proj
 	var result command.Result
 	if p.isParallelEnabled(cmds) {
 		ctx.Log.Info("Running policy_checks in parallel")
-		result = runProjectCmdsParallel(cmds, p.prjCmdRunner.PolicyCheck, p.parallelPoolSize)
+		result = runProjectCmdsParallel(cmds, p.prjCmdRunner.PolicyCheck, p.parallelPoolSize.Get())
 	} else {
 		result = runProjectCmds(cmds, p.prjCmdRunner.PolicyCheck)
 	}
@@ -1,15 +1,21 @@
 package events
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/expr"
 	"github.com/runatlantis/atlantis/server/core/runtime"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/utils"
 )
 
 //go:generate pegomock generate --package mocks -o mocks/mock_post_workflow_hook_url_generator.go PostWorkflowHookURLGenerator
@@ -25,6 +31,16 @@ type PostWorkflowHooksCommandRunner interface {
 	RunPostHooks(ctx *command.Context, cmd *CommentCommand) error
 }
 
+//go:generate pegomock generate --package mocks -o mocks/mock_workflow_hook_event_runner.go WorkflowHookEventRunner
+
+// WorkflowHookEventRunner runs the post workflow hooks that are set to run on
+// a lifecycle event, ex. when a lock is released.
+type WorkflowHookEventRunner interface {
+	// RunEventHooks runs the hooks of ctx.BaseRepo that are set to run on
+	// ctx.Event.
+	RunEventHooks(ctx models.WorkflowHookCommandContext) error
+}
+
 // DefaultPostWorkflowHooksCommandRunner is the first step when processing a workflow hook commands.
 type DefaultPostWorkflowHooksCommandRunner struct {
 	VCSClient              vcs.Client
@@ -69,19 +85,38 @@ func (w *DefaultPostWorkflowHooksCommandRunner) RunPostHooks(ctx *command.Contex
 		escapedArgs = escapeArgs(cmd.Flags)
 	}
 
-	err = w.runHooks(
-		models.WorkflowHookCommandContext{
-			BaseRepo:           ctx.Pull.BaseRepo,
-			HeadRepo:           ctx.HeadRepo,
-			Log:                ctx.Log,
-			Pull:               ctx.Pull,
-			User:               ctx.User,
-			Verbose:            false,
-			EscapedCommentArgs: escapedArgs,
-			CommandName:        cmd.Name.String(),
-			API:                ctx.API,
-		},
-		postWorkflowHooks, repoDir)
+	hookCtx := models.WorkflowHookCommandContext{
+		BaseRepo:           ctx.Pull.BaseRepo,
+		HeadRepo:           ctx.HeadRepo,
+		Log:                ctx.Log,
+		Pull:               ctx.Pull,
+		User:               ctx.User,
+		Verbose:            false,
+		EscapedCommentArgs: escapedArgs,
+		CommandName:        cmd.DisplayName(),
+		API:                ctx.API,
+		CommandOutcomes:    commandOutcomes(ctx.Results),
+	}
+	resultName := cmd.Name
+	if resultName == command.Autoplan {
+		resultName = command.Plan
+	}
+	if res, ok := ctx.Results[resultName]; ok {
+		hookCtx.CommandResult = "success"
+		if res.HasErrors() {
+			hookCtx.CommandResult = "failure"
+		}
+		if res.Error != nil {
+			hookCtx.CommandError = res.Error.Error()
+		} else {
+			hookCtx.CommandError = res.Failure
+		}
+	}
+	if hookCtx.ProjectResults, err = projectResultsJSON(ctx.Results); err != nil {
+		return err
+	}
+
+	err = w.runHooks(hookCtx, postWorkflowHooks, repoDir)
 
 	if err != nil {
 		ctx.Log.Err("Error running post-workflow hooks %s.", err)
@@ -91,6 +126,74 @@ func (w *DefaultPostWorkflowHooksCommandRunner) RunPostHooks(ctx *command.Contex
 	return nil
 }
 
+// newEventHookContext returns the context of the hooks for event on pull.
+func newEventHookContext(logger logging.SimpleLogging, event string, pull models.PullRequest, user models.User) models.WorkflowHookCommandContext {
+	return models.WorkflowHookCommandContext{
+		BaseRepo: pull.BaseRepo,
+		Log:      logger,
+		Pull:     pull,
+		User:     user,
+		Event:    event,
+	}
+}
+
+// RunEventHooks runs the post_workflow_hooks that are set to run on
+// ctx.Event. They're run in the pull request's working dir if it's been cloned
+// and in an empty dir otherwise.
+func (w *DefaultPostWorkflowHooksCommandRunner) RunEventHooks(ctx models.WorkflowHookCommandContext) error {
+	var hooks []*valid.WorkflowHook
+	for _, repo := range w.GlobalCfg.Repos {
+		if !repo.IDMatches(ctx.BaseRepo.ID()) || !repo.BranchMatches(ctx.Pull.BaseBranch) {
+			continue
+		}
+		for _, hook := range repo.PostWorkflowHooks {
+			if !utils.SlicesContains(hook.On, ctx.Event) {
+				continue
+			}
+			run, err := expr.Eval(hook.When, hookExprVars(ctx))
+			if err != nil {
+				return errors.Wrapf(err, "post workflow hook %q", hook.StepDescription)
+			}
+			if run {
+				hooks = append(hooks, hook)
+			}
+		}
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	ctx.Log.Info("Running post-workflow hooks for event %s", ctx.Event)
+	dir, err := w.WorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, DefaultWorkspace)
+	if err != nil {
+		if dir, err = os.MkdirTemp("", "atlantis-hook"); err != nil {
+			return errors.Wrap(err, "creating dir for hooks")
+		}
+		defer os.RemoveAll(dir) // nolint: errcheck
+	}
+
+	for i, hook := range hooks {
+		ctx.HookDescription = hook.StepDescription
+		if ctx.HookDescription == "" {
+			ctx.HookDescription = fmt.Sprintf("Post workflow hook #%d", i)
+		}
+		ctx.HookStepName = fmt.Sprintf("%s #%d", ctx.Event, i)
+		ctx.HookID = uuid.NewString()
+		shell := hook.Shell
+		if shell == "" {
+			shell = "sh"
+		}
+		shellArgs := hook.ShellArgs
+		if shellArgs == "" {
+			shellArgs = "-c"
+		}
+		if _, _, err := w.PostWorkflowHookRunner.Run(ctx, hook.RunCommand, shell, shellArgs, dir); err != nil {
+			return errors.Wrapf(err, "running post workflow hook %q for event %s", ctx.HookDescription, ctx.Event)
+		}
+	}
+	return nil
+}
+
 func (w *DefaultPostWorkflowHooksCommandRunner) runHooks(
 	ctx models.WorkflowHookCommandContext,
 	postWorkflowHooks []*valid.WorkflowHook,
@@ -113,6 +216,21 @@ func (w *DefaultPostWorkflowHooksCommandRunner) runHooks(
 			continue
 		}
 
+		if len(hook.On) > 0 && !hookEventOccurred(hook.On, ctx.CommandOutcomes) {
+			ctx.Log.Debug("Skipping post workflow hook '%s' as none of [%s] occurred, outcomes were [%s]",
+				ctx.HookDescription, strings.Join(hook.On, ", "), strings.Join(ctx.CommandOutcomes, ", "))
+			continue
+		}
+
+		run, err := expr.Eval(hook.When, hookExprVars(ctx))
+		if err != nil {
+			return errors.Wrapf(err, "post workflow hook %q", ctx.HookDescription)
+		}
+		if !run {
+			ctx.Log.Debug("Skipping post workflow hook '%s' as %s is false", ctx.HookDescription, hook.When)
+			continue
+		}
+
 		ctx.Log.Debug("Running post workflow hook: '%s'", ctx.HookDescription)
 		ctx.HookID = uuid.NewString()
 		shell := hook.Shell
@@ -152,3 +270,91 @@ func (w *DefaultPostWorkflowHooksCommandRunner) runHooks(
 
 	return nil
 }
+
+// outcomeCommands are the commands post workflow hooks can run on the outcome
+// of, in the order they're run, and the prefix of their outcomes.
+var outcomeCommands = []struct {
+	name   command.Name
+	prefix string
+}{
+	{command.Plan, "plan"},
+	{command.PolicyCheck, "policy"},
+	{command.ApprovePolicies, "policy"},
+	{command.Apply, "apply"},
+}
+
+// commandOutcomes returns the outcomes of results, ex. "plan_success" and
+// "policy_failure", as they can be set in the on key of post workflow hooks.
+func commandOutcomes(results map[command.Name]command.Result) []string {
+	var outcomes []string
+	for _, cmd := range outcomeCommands {
+		res, ok := results[cmd.name]
+		if !ok {
+			continue
+		}
+		outcome := cmd.prefix + "_success"
+		if res.HasErrors() {
+			outcome = cmd.prefix + "_failure"
+		}
+		if !utils.SlicesContains(outcomes, outcome) {
+			outcomes = append(outcomes, outcome)
+		}
+	}
+	return outcomes
+}
+
+func hookEventOccurred(on []string, outcomes []string) bool {
+	for _, event := range on {
+		if utils.SlicesContains(outcomes, event) {
+			return true
+		}
+	}
+	return false
+}
+
+// postWorkflowHookProjectResult is the result of a project as it's passed to
+// post workflow hooks in PROJECT_RESULTS.
+type postWorkflowHookProjectResult struct {
+	Project   string `json:"project"`
+	Dir       string `json:"dir"`
+	Workspace string `json:"workspace"`
+	Command   string `json:"command"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Summary   string `json:"summary,omitempty"`
+}
+
+// projectResultsJSON returns the project results of results as a JSON array.
+func projectResultsJSON(results map[command.Name]command.Result) (string, error) {
+	projectResults := make([]postWorkflowHookProjectResult, 0)
+	for _, cmd := range outcomeCommands {
+		for _, result := range results[cmd.name].ProjectResults {
+			projectResult := postWorkflowHookProjectResult{
+				Project:   result.ProjectName,
+				Dir:       result.RepoRelDir,
+				Workspace: result.Workspace,
+				Command:   cmd.name.String(),
+				Status:    "success",
+			}
+			if !result.IsSuccessful() {
+				projectResult.Status = "failure"
+			}
+			if result.Error != nil {
+				projectResult.Error = result.Error.Error()
+			} else if result.Failure != "" {
+				projectResult.Error = result.Failure
+			}
+			if result.PlanSuccess != nil {
+				projectResult.Summary = result.PlanSuccess.Summary()
+			} else if result.PolicyCheckResults != nil {
+				projectResult.Summary = result.PolicyCheckResults.Summary()
+			}
+			projectResults = append(projectResults, projectResult)
+		}
+	}
+	out, err := json.Marshal(projectResults)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling project results")
+	}
+	return string(out), nil
+}
@@ -543,3 +543,134 @@ func TestRunPostHooks_Clone(t *testing.T) {
 		Assert(t, *unlockCalled == true, "unlock function called")
 	})
 }
+
+func TestRunPostHooks_On(t *testing.T) {
+	log := logging.NewNoopLogger(t)
+	newPull := testdata.Pull
+	newPull.BaseRepo = testdata.GithubRepo
+	repoDir := "path/to/repo"
+	autoplanCmd := &events.CommentCommand{Name: command.Autoplan}
+
+	applySuccessHook := valid.WorkflowHook{
+		StepName:   "run",
+		RunCommand: "./notify-applied.sh",
+		On:         []string{valid.ApplySuccessHookEvent},
+	}
+	planFailureHook := valid.WorkflowHook{
+		StepName:   "run",
+		RunCommand: "./notify-failed.sh",
+		On:         []string{valid.PlanFailureHookEvent, valid.PolicyFailureHookEvent},
+	}
+
+	postWorkflowHooksSetup(t)
+	postWh.GlobalCfg = valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				ID:                testdata.GithubRepo.ID(),
+				PostWorkflowHooks: []*valid.WorkflowHook{&applySuccessHook, &planFailureHook},
+			},
+		},
+	}
+	When(postWhWorkingDirLocker.TryLock(testdata.GithubRepo.FullName, newPull.Num, events.DefaultWorkspace,
+		events.DefaultRepoRelDir)).ThenReturn(func() {}, nil)
+	When(postWhWorkingDir.Clone(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(newPull),
+		Eq(events.DefaultWorkspace))).ThenReturn(repoDir, false, nil)
+	When(whPostWorkflowHookRunner.Run(Any[models.WorkflowHookCommandContext](), Any[string](),
+		Any[string](), Any[string](), Eq(repoDir))).ThenReturn("", "", nil)
+
+	ctx := &command.Context{
+		Pull:     newPull,
+		HeadRepo: testdata.GithubRepo,
+		User:     testdata.User,
+		Log:      log,
+		Results: map[command.Name]command.Result{
+			command.Plan: {
+				ProjectResults: []command.ProjectResult{
+					{
+						Command:     command.Plan,
+						ProjectName: "staging",
+						RepoRelDir:  "staging",
+						Workspace:   "default",
+						PlanSuccess: &models.PlanSuccess{TerraformOutput: "No changes. Your infrastructure matches the configuration."},
+					},
+					{
+						Command:     command.Plan,
+						ProjectName: "production",
+						RepoRelDir:  "production",
+						Workspace:   "default",
+						Error:       errors.New("exit status 1"),
+					},
+				},
+			},
+		},
+	}
+	Ok(t, postWh.RunPostHooks(ctx, autoplanCmd))
+
+	whPostWorkflowHookRunner.VerifyWasCalled(Never()).Run(Any[models.WorkflowHookCommandContext](),
+		Eq(applySuccessHook.RunCommand), Any[string](), Any[string](), Eq(repoDir))
+	hookCtx, _, _, _, _ := whPostWorkflowHookRunner.VerifyWasCalledOnce().Run(Any[models.WorkflowHookCommandContext](),
+		Eq(planFailureHook.RunCommand), Any[string](), Any[string](), Eq(repoDir)).GetCapturedArguments()
+	Equals(t, "failure", hookCtx.CommandResult)
+	Equals(t, "", hookCtx.CommandError)
+	Equals(t, []string{valid.PlanFailureHookEvent}, hookCtx.CommandOutcomes)
+	Equals(t, `[{"project":"staging","dir":"staging","workspace":"default","command":"plan","status":"success","summary":"No changes. Your infrastructure matches the configuration."},`+
+		`{"project":"production","dir":"production","workspace":"default","command":"plan","status":"failure","error":"exit status 1"}]`,
+		hookCtx.ProjectResults)
+}
+
+func TestRunEventHooks(t *testing.T) {
+	log := logging.NewNoopLogger(t)
+	newPull := testdata.Pull
+	newPull.BaseRepo = testdata.GithubRepo
+	repoDir := "path/to/repo"
+
+	lockReleasedHook := valid.WorkflowHook{
+		StepName:   "run",
+		RunCommand: "./notify-unlocked.sh",
+		On:         []string{valid.LockReleasedHookEvent},
+	}
+	pullClosedHook := valid.WorkflowHook{
+		StepName:   "run",
+		RunCommand: "./cleanup.sh",
+		On:         []string{valid.PullClosedHookEvent},
+	}
+	commandHook := valid.WorkflowHook{
+		StepName:   "run",
+		RunCommand: "./report.sh",
+	}
+
+	postWorkflowHooksSetup(t)
+	postWh.GlobalCfg = valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				ID:                testdata.GithubRepo.ID(),
+				PostWorkflowHooks: []*valid.WorkflowHook{&lockReleasedHook, &pullClosedHook, &commandHook},
+			},
+		},
+	}
+	When(postWhWorkingDir.GetWorkingDir(Eq(testdata.GithubRepo), Eq(newPull), Eq(events.DefaultWorkspace))).ThenReturn(repoDir, nil)
+	When(whPostWorkflowHookRunner.Run(Any[models.WorkflowHookCommandContext](), Any[string](),
+		Any[string](), Any[string](), Any[string]())).ThenReturn("", "", nil)
+
+	err := postWh.RunEventHooks(models.WorkflowHookCommandContext{
+		BaseRepo:  testdata.GithubRepo,
+		Log:       log,
+		Pull:      newPull,
+		User:      testdata.User,
+		Event:     valid.LockReleasedHookEvent,
+		Workspace: "default",
+	})
+	Ok(t, err)
+
+	hookCtx, _, shell, shellArgs, _ := whPostWorkflowHookRunner.VerifyWasCalledOnce().Run(Any[models.WorkflowHookCommandContext](),
+		Eq(lockReleasedHook.RunCommand), Any[string](), Any[string](), Eq(repoDir)).GetCapturedArguments()
+	Equals(t, valid.LockReleasedHookEvent, hookCtx.Event)
+	Equals(t, "default", hookCtx.Workspace)
+	Equals(t, "sh", shell)
+	Equals(t, "-c", shellArgs)
+	whPostWorkflowHookRunner.VerifyWasCalled(Never()).Run(Any[models.WorkflowHookCommandContext](),
+		Eq(pullClosedHook.RunCommand), Any[string](), Any[string](), Any[string]())
+	whPostWorkflowHookRunner.VerifyWasCalled(Never()).Run(Any[models.WorkflowHookCommandContext](),
+		Eq(commandHook.RunCommand), Any[string](), Any[string](), Any[string]())
+	postWhWorkingDir.VerifyWasCalled(Never()).Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[string]())
+}
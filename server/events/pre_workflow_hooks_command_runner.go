@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/expr"
 	"github.com/runatlantis/atlantis/server/core/runtime"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -69,7 +70,7 @@ func (w *DefaultPreWorkflowHooksCommandRunner) RunPreHooks(ctx *command.Context,
 		escapedArgs = escapeArgs(cmd.Flags)
 	}
 
-	err = w.runHooks(
+	outputs, err := w.runHooks(
 		models.WorkflowHookCommandContext{
 			BaseRepo:           ctx.Pull.BaseRepo,
 			HeadRepo:           ctx.HeadRepo,
@@ -78,11 +79,12 @@ func (w *DefaultPreWorkflowHooksCommandRunner) RunPreHooks(ctx *command.Context,
 			User:               ctx.User,
 			Verbose:            false,
 			EscapedCommentArgs: escapedArgs,
-			CommandName:        cmd.Name.String(),
+			CommandName:        cmd.DisplayName(),
 			API:                ctx.API,
 		},
 		preWorkflowHooks, repoDir)
 
+	ctx.PreWorkflowHookOutputs = append(ctx.PreWorkflowHookOutputs, outputs...)
 	if err != nil {
 		ctx.Log.Err("Error running pre-workflow hooks %s.", err)
 		return err
@@ -97,7 +99,10 @@ func (w *DefaultPreWorkflowHooksCommandRunner) runHooks(
 	ctx models.WorkflowHookCommandContext,
 	preWorkflowHooks []*valid.WorkflowHook,
 	repoDir string,
-) error {
+) ([]string, error) {
+	// commandCommentOutputs are the outputs of hooks with output:
+	// command_comment.
+	var commandCommentOutputs []string
 	for i, hook := range preWorkflowHooks {
 		ctx.HookDescription = hook.StepDescription
 		if ctx.HookDescription == "" {
@@ -114,6 +119,15 @@ func (w *DefaultPreWorkflowHooksCommandRunner) runHooks(
 			continue
 		}
 
+		run, err := expr.Eval(hook.When, hookExprVars(ctx))
+		if err != nil {
+			return commandCommentOutputs, fmt.Errorf("pre workflow hook %q: %w", ctx.HookDescription, err)
+		}
+		if !run {
+			ctx.Log.Debug("Skipping pre workflow hook '%s' as %s is false", ctx.HookDescription, hook.When)
+			continue
+		}
+
 		ctx.Log.Debug("Running pre workflow hook: '%s'", ctx.HookDescription)
 		ctx.HookID = uuid.NewString()
 		shell := hook.Shell
@@ -128,7 +142,7 @@ func (w *DefaultPreWorkflowHooksCommandRunner) runHooks(
 		}
 		url, err := w.Router.GenerateProjectWorkflowHookURL(ctx.HookID)
 		if err != nil && !ctx.API {
-			return err
+			return commandCommentOutputs, err
 		}
 
 		if err := w.CommitStatusUpdater.UpdatePreWorkflowHook(ctx.Log, ctx.Pull, models.PendingCommitStatus, ctx.HookDescription, "", url); err != nil {
@@ -136,26 +150,74 @@ func (w *DefaultPreWorkflowHooksCommandRunner) runHooks(
 			ctx.Log.Info("is api? %v", ctx.API)
 			if !ctx.API {
 				ctx.Log.Info("is api? %v", ctx.API)
-				return err
+				return commandCommentOutputs, err
 			}
 		}
 
-		_, runtimeDesc, err := w.PreWorkflowHookRunner.Run(ctx, hook.RunCommand, shell, shellArgs, repoDir)
+		out, runtimeDesc, err := w.PreWorkflowHookRunner.Run(ctx, hook.RunCommand, shell, shellArgs, repoDir)
+
+		// Commands run through the API don't comment on the pull request.
+		switch hook.Output {
+		case valid.CommentWorkflowHookOutput:
+			if comment := renderPreWorkflowHookOutput(ctx.HookDescription, out); comment != "" && !ctx.API {
+				if err := w.VCSClient.CreateComment(ctx.Log, ctx.BaseRepo, ctx.Pull.Num, comment, ""); err != nil {
+					ctx.Log.Warn("unable to comment pre workflow hook output: %s", err)
+				}
+			}
+		case valid.CommandCommentWorkflowHookOutput:
+			if comment := renderPreWorkflowHookOutput(ctx.HookDescription, out); comment != "" {
+				commandCommentOutputs = append(commandCommentOutputs, comment)
+			}
+		}
 
 		if err != nil {
 			if err := w.CommitStatusUpdater.UpdatePreWorkflowHook(ctx.Log, ctx.Pull, models.FailedCommitStatus, ctx.HookDescription, runtimeDesc, url); err != nil {
 				ctx.Log.Warn("unable to update pre workflow hook status: %s", err)
 			}
-			return err
+			return commandCommentOutputs, err
 		}
 
 		if err := w.CommitStatusUpdater.UpdatePreWorkflowHook(ctx.Log, ctx.Pull, models.SuccessCommitStatus, ctx.HookDescription, runtimeDesc, url); err != nil {
 			ctx.Log.Warn("unable to update pre workflow hook status: %s", err)
 			if !ctx.API {
-				return err
+				return commandCommentOutputs, err
 			}
 		}
 	}
 
-	return nil
+	return commandCommentOutputs, nil
+}
+
+// renderPreWorkflowHookOutput renders the output of the pre workflow hook
+// described by description for a comment. It returns an empty string if the
+// hook had no output.
+func renderPreWorkflowHookOutput(description string, output string) string {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return ""
+	}
+	return fmt.Sprintf("**%s**\n```\n%s\n```", description, output)
+}
+
+// hookExprVars returns the values the when expressions of workflow hooks are
+// evaluated against.
+func hookExprVars(ctx models.WorkflowHookCommandContext) expr.Vars {
+	return expr.Vars{
+		Command: ctx.CommandName,
+		Result:  ctx.CommandResult,
+		Event:   ctx.Event,
+		Repo:    ctx.BaseRepo.FullName,
+		User:    ctx.User.Username,
+		Pull: expr.PullVars{
+			Num:        ctx.Pull.Num,
+			Author:     ctx.Pull.Author,
+			BaseBranch: ctx.Pull.BaseBranch,
+			HeadBranch: ctx.Pull.HeadBranch,
+		},
+		Project: expr.ProjectVars{
+			Name:      ctx.ProjectName,
+			Dir:       ctx.RepoRelDir,
+			Workspace: ctx.Workspace,
+		},
+	}
 }
@@ -546,4 +546,102 @@ func TestRunPreHooks_Clone(t *testing.T) {
 			Eq(testHookWithPlanApplyCommands.RunCommand), Any[string](), Any[string](), Eq(repoDir))
 		Assert(t, *unlockCalled == true, "unlock function called")
 	})
+
+	t.Run("When set on webhook", func(t *testing.T) {
+		preWorkflowHooksSetup(t)
+
+		unlockFn := func() {}
+		applyHook := valid.WorkflowHook{
+			StepName:   "apply",
+			RunCommand: "echo apply",
+			When:       `command == "apply"`,
+		}
+		planHook := valid.WorkflowHook{
+			StepName:   "plan",
+			RunCommand: "echo plan",
+			When:       `command == "plan" && pull.num > 0`,
+		}
+		preWh.GlobalCfg = valid.GlobalCfg{
+			Repos: []valid.Repo{
+				{
+					ID:               testdata.GithubRepo.ID(),
+					PreWorkflowHooks: []*valid.WorkflowHook{&applyHook, &planHook},
+				},
+			},
+		}
+
+		When(preWhWorkingDirLocker.TryLock(testdata.GithubRepo.FullName, newPull.Num, events.DefaultWorkspace,
+			events.DefaultRepoRelDir)).ThenReturn(unlockFn, nil)
+		When(preWhWorkingDir.Clone(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(newPull),
+			Eq(events.DefaultWorkspace))).ThenReturn(repoDir, false, nil)
+		When(whPreWorkflowHookRunner.Run(Any[models.WorkflowHookCommandContext](), Any[string](),
+			Any[string](), Any[string](), Eq(repoDir))).ThenReturn(result, runtimeDesc, nil)
+
+		err := preWh.RunPreHooks(ctx, planCmd)
+
+		Ok(t, err)
+		whPreWorkflowHookRunner.VerifyWasCalled(Never()).Run(Any[models.WorkflowHookCommandContext](),
+			Eq(applyHook.RunCommand), Any[string](), Any[string](), Eq(repoDir))
+		whPreWorkflowHookRunner.VerifyWasCalledOnce().Run(Any[models.WorkflowHookCommandContext](),
+			Eq(planHook.RunCommand), Any[string](), Any[string](), Eq(repoDir))
+	})
+}
+
+func TestRunPreHooks_Output(t *testing.T) {
+	log := logging.NewNoopLogger(t)
+	newPull := testdata.Pull
+	newPull.BaseRepo = testdata.GithubRepo
+	repoDir := "path/to/repo"
+	planCmd := &events.CommentCommand{Name: command.Plan}
+
+	commentHook := valid.WorkflowHook{
+		StepName:        "run",
+		RunCommand:      "./validate.sh",
+		StepDescription: "Validation",
+		Output:          valid.CommentWorkflowHookOutput,
+	}
+	commandCommentHook := valid.WorkflowHook{
+		StepName:        "run",
+		RunCommand:      "./list-projects.sh",
+		StepDescription: "Projects",
+		Output:          valid.CommandCommentWorkflowHookOutput,
+	}
+	silentHook := valid.WorkflowHook{
+		StepName:   "run",
+		RunCommand: "./generate.sh",
+	}
+
+	preWorkflowHooksSetup(t)
+	vcsClient := vcsmocks.NewMockClient()
+	preWh.VCSClient = vcsClient
+	preWh.GlobalCfg = valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				ID:               testdata.GithubRepo.ID(),
+				PreWorkflowHooks: []*valid.WorkflowHook{&commentHook, &commandCommentHook, &silentHook},
+			},
+		},
+	}
+	When(preWhWorkingDirLocker.TryLock(testdata.GithubRepo.FullName, newPull.Num, events.DefaultWorkspace,
+		events.DefaultRepoRelDir)).ThenReturn(func() {}, nil)
+	When(preWhWorkingDir.Clone(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(newPull),
+		Eq(events.DefaultWorkspace))).ThenReturn(repoDir, false, nil)
+	When(whPreWorkflowHookRunner.Run(Any[models.WorkflowHookCommandContext](), Eq(commentHook.RunCommand),
+		Any[string](), Any[string](), Eq(repoDir))).ThenReturn("warning: no owners file\n", "", nil)
+	When(whPreWorkflowHookRunner.Run(Any[models.WorkflowHookCommandContext](), Eq(commandCommentHook.RunCommand),
+		Any[string](), Any[string](), Eq(repoDir))).ThenReturn("prod\nstaging\n", "", nil)
+	When(whPreWorkflowHookRunner.Run(Any[models.WorkflowHookCommandContext](), Eq(silentHook.RunCommand),
+		Any[string](), Any[string](), Eq(repoDir))).ThenReturn("generated\n", "", nil)
+
+	ctx := &command.Context{
+		Pull:     newPull,
+		HeadRepo: testdata.GithubRepo,
+		User:     testdata.User,
+		Log:      log,
+	}
+	Ok(t, preWh.RunPreHooks(ctx, planCmd))
+
+	vcsClient.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(newPull.Num),
+		Eq("**Validation**\n```\nwarning: no owners file\n```"), Eq(""))
+	Equals(t, []string{"**Projects**\n```\nprod\nstaging\n```"}, ctx.PreWorkflowHookOutputs)
 }
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// previewWorkspaceRegex matches the workspaces that preview environments can
+// render to. Workspaces are used in paths so we don't allow slashes or dots.
+var previewWorkspaceRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// previewTemplateData is the data available to the workspace and vars
+// templates of a preview environment.
+type previewTemplateData struct {
+	PullNum    int
+	HeadBranch string
+	BaseBranch string
+	Author     string
+	RepoOwner  string
+	RepoName   string
+}
+
+// renderPreviewEnvironments renders the workspace and vars of each project in
+// repoCfg that has a preview environment for pull. The project's workspace is
+// set to the rendered workspace.
+// Preview environments only exist for pull requests so if pull isn't one, ex.
+// during drift detection, those projects are removed.
+func renderPreviewEnvironments(pull models.PullRequest, repoCfg *valid.RepoCfg) error {
+	data := previewTemplateData{
+		PullNum:    pull.Num,
+		HeadBranch: pull.HeadBranch,
+		BaseBranch: pull.BaseBranch,
+		Author:     pull.Author,
+		RepoOwner:  pull.BaseRepo.Owner,
+		RepoName:   pull.BaseRepo.Name,
+	}
+
+	// Copy rather than filter in place since the projects can be shared with
+	// the caller.
+	projects := repoCfg.Projects[:0:0]
+	for _, project := range repoCfg.Projects {
+		if project.PreviewEnvironment == nil {
+			projects = append(projects, project)
+			continue
+		}
+		if pull.Num <= 0 {
+			continue
+		}
+
+		workspace, err := renderPreviewTemplate(project.PreviewEnvironment.Workspace, data)
+		if err != nil {
+			return errors.Wrapf(err, "rendering preview environment workspace of project at dir '%s'", project.Dir)
+		}
+		if !previewWorkspaceRegex.MatchString(workspace) {
+			return fmt.Errorf("preview environment workspace %q of project at dir '%s' can only contain letters, numbers, '-' and '_'", workspace, project.Dir)
+		}
+		vars := make(map[string]string, len(project.PreviewEnvironment.Vars))
+		for name, tmpl := range project.PreviewEnvironment.Vars {
+			if vars[name], err = renderPreviewTemplate(tmpl, data); err != nil {
+				return errors.Wrapf(err, "rendering preview environment var '%s' of project at dir '%s'", name, project.Dir)
+			}
+		}
+
+		project.Workspace = workspace
+		project.PreviewEnvironment = &valid.PreviewEnvironment{
+			Workspace: workspace,
+			Vars:      vars,
+		}
+		projects = append(projects, project)
+	}
+	repoCfg.Projects = projects
+	return nil
+}
+
+func renderPreviewTemplate(tmpl string, data previewTemplateData) (string, error) {
+	t, err := template.New("").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// findPreviewProjects returns the projects in dir that have a preview
+// environment.
+func findPreviewProjects(repoCfg *valid.RepoCfg, dir string) []valid.Project {
+	var projects []valid.Project
+	for _, project := range repoCfg.FindProjectsByDir(dir) {
+		if project.PreviewEnvironment != nil {
+			projects = append(projects, project)
+		}
+	}
+	return projects
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+var previewPull = models.PullRequest{
+	Num:        12,
+	HeadBranch: "feature",
+	BaseBranch: "main",
+	Author:     "lkysow",
+	BaseRepo:   models.Repo{Owner: "runatlantis", Name: "atlantis"},
+}
+
+func TestRenderPreviewEnvironments(t *testing.T) {
+	repoCfg := valid.RepoCfg{
+		Projects: []valid.Project{
+			{Dir: "prod", Workspace: "default"},
+			{
+				Dir:       "app",
+				Workspace: "default",
+				PreviewEnvironment: &valid.PreviewEnvironment{
+					Workspace: valid.DefaultPreviewWorkspace,
+					Vars: map[string]string{
+						"pr_number": "{{ .PullNum }}",
+						"name":      "{{ .RepoName }}-{{ .HeadBranch }}",
+					},
+				},
+			},
+		},
+	}
+	Ok(t, renderPreviewEnvironments(previewPull, &repoCfg))
+	Equals(t, []valid.Project{
+		{Dir: "prod", Workspace: "default"},
+		{
+			Dir:       "app",
+			Workspace: "pr-12",
+			PreviewEnvironment: &valid.PreviewEnvironment{
+				Workspace: "pr-12",
+				Vars: map[string]string{
+					"pr_number": "12",
+					"name":      "atlantis-feature",
+				},
+			},
+		},
+	}, repoCfg.Projects)
+}
+
+func TestRenderPreviewEnvironments_NoPull(t *testing.T) {
+	repoCfg := valid.RepoCfg{
+		Projects: []valid.Project{
+			{Dir: "prod", Workspace: "default"},
+			{
+				Dir:                "app",
+				Workspace:          "default",
+				PreviewEnvironment: &valid.PreviewEnvironment{Workspace: valid.DefaultPreviewWorkspace},
+			},
+		},
+	}
+	Ok(t, renderPreviewEnvironments(models.PullRequest{BaseBranch: "main"}, &repoCfg))
+	Equals(t, []valid.Project{{Dir: "prod", Workspace: "default"}}, repoCfg.Projects)
+}
+
+func TestRenderPreviewEnvironments_InvalidWorkspace(t *testing.T) {
+	repoCfg := valid.RepoCfg{
+		Projects: []valid.Project{
+			{
+				Dir:                "app",
+				Workspace:          "default",
+				PreviewEnvironment: &valid.PreviewEnvironment{Workspace: "pr-{{ .BaseBranch }}/{{ .PullNum }}"},
+			},
+		},
+	}
+	ErrEquals(t, `preview environment workspace "pr-main/12" of project at dir 'app' can only contain letters, numbers, '-' and '_'`,
+		renderPreviewEnvironments(previewPull, &repoCfg))
+}
+
+func TestFindPreviewProjects(t *testing.T) {
+	preview := valid.Project{
+		Dir:                "app",
+		Workspace:          "pr-12",
+		PreviewEnvironment: &valid.PreviewEnvironment{Workspace: "pr-12"},
+	}
+	repoCfg := valid.RepoCfg{
+		Projects: []valid.Project{
+			{Dir: "prod", Workspace: "default"},
+			preview,
+		},
+	}
+	Equals(t, []valid.Project{preview}, findPreviewProjects(&repoCfg, "app"))
+	Equals(t, []valid.Project(nil), findPreviewProjects(&repoCfg, "prod"))
+}
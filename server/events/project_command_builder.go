@@ -11,6 +11,8 @@ import (
 	tally "github.com/uber-go/tally/v4"
 
 	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/core/terraform"
 	"github.com/runatlantis/atlantis/server/core/terraform/tfclient"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
@@ -47,6 +49,7 @@ func NewInstrumentedProjectCommandBuilder(
 	globalCfg valid.GlobalCfg,
 	pendingPlanFinder *DefaultPendingPlanFinder,
 	commentBuilder CommentBuilder,
+	planStore PlanStore,
 	skipCloneNoChanges bool,
 	EnableRegExpCmd bool,
 	EnableAutoMerge bool,
@@ -78,6 +81,7 @@ func NewInstrumentedProjectCommandBuilder(
 			globalCfg,
 			pendingPlanFinder,
 			commentBuilder,
+			planStore,
 			skipCloneNoChanges,
 			EnableRegExpCmd,
 			EnableAutoMerge,
@@ -107,6 +111,7 @@ func NewProjectCommandBuilder(
 	globalCfg valid.GlobalCfg,
 	pendingPlanFinder *DefaultPendingPlanFinder,
 	commentBuilder CommentBuilder,
+	planStore PlanStore,
 	skipCloneNoChanges bool,
 	EnableRegExpCmd bool,
 	EnableAutoMerge bool,
@@ -145,7 +150,9 @@ func NewProjectCommandBuilder(
 			commentBuilder,
 			scope,
 		),
+		CommentBuilder:    commentBuilder,
 		TerraformExecutor: terraformClient,
+		PlanStore:         planStore,
 	}
 }
 
@@ -186,10 +193,24 @@ type ProjectImportCommandBuilder interface {
 }
 
 type ProjectStateCommandBuilder interface {
-	// BuildStateRmCommands builds project state rm commands for this ctx and comment. If
+	// BuildStateCommands builds project state rm, pull or push commands for this ctx and comment. If
 	// comment doesn't specify one project then there may be multiple commands
 	// to be run.
-	BuildStateRmCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error)
+	BuildStateCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error)
+}
+
+type ProjectDestroyCommandBuilder interface {
+	// BuildDestroyCommands builds project commands for atlantis destroy. They
+	// plan the destruction of the projects the comment is for, or if it's
+	// confirmed, apply the destroy plans of the pull request.
+	BuildDestroyCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error)
+}
+
+type ProjectCustomCommandBuilder interface {
+	// BuildCustomCommands builds project commands for the custom command of
+	// this ctx and comment. If comment doesn't specify one project then there
+	// may be multiple commands to be run.
+	BuildCustomCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error)
 }
 
 //go:generate pegomock generate github.com/runatlantis/atlantis/server/events --package mocks -o mocks/mock_project_command_builder.go ProjectCommandBuilder
@@ -202,6 +223,8 @@ type ProjectCommandBuilder interface {
 	ProjectVersionCommandBuilder
 	ProjectImportCommandBuilder
 	ProjectStateCommandBuilder
+	ProjectCustomCommandBuilder
+	ProjectDestroyCommandBuilder
 }
 
 // DefaultProjectCommandBuilder implements ProjectCommandBuilder.
@@ -224,6 +247,8 @@ type DefaultProjectCommandBuilder struct {
 	PendingPlanFinder *DefaultPendingPlanFinder
 	// Builds project command contexts for Atlantis commands.
 	ProjectCommandContextBuilder ProjectCommandContextBuilder
+	// Builds the commands that the output of destroy suggests to run.
+	CommentBuilder CommentBuilder
 	// User config option: Skip cloning the repo during autoplan if there are no changes to Terraform projects.
 	SkipCloneNoChanges bool
 	// User config option: Enable the use of regular expressions to run plan/apply commands against defined project names.
@@ -250,6 +275,9 @@ type DefaultProjectCommandBuilder struct {
 	AutoDiscoverMode string
 	// Handles the actual running of Terraform commands.
 	TerraformExecutor tfclient.Client
+	// Restores the plans that are missing from the working dirs before applies.
+	// If nil, plans are only kept in the working dirs.
+	PlanStore PlanStore
 }
 
 // See ProjectCommandBuilder.BuildAutoplanCommands.
@@ -273,7 +301,11 @@ func (p *DefaultProjectCommandBuilder) BuildAutoplanCommands(ctx *command.Contex
 func (p *DefaultProjectCommandBuilder) BuildPlanCommands(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
 	if !cmd.IsForSpecificProject() {
 		ctx.Log.Debug("Building plan command for all affected projects")
-		return p.buildAllCommandsByCfg(ctx, cmd.CommandName(), cmd.SubName, cmd.Flags, cmd.Verbose)
+		projCtxs, err := p.buildAllCommandsByCfg(ctx, cmd.CommandName(), cmd.SubName, cmd.Flags, cmd.Verbose)
+		if err != nil || !cmd.Failed {
+			return projCtxs, err
+		}
+		return filterFailedProjects(ctx, projCtxs, models.ErroredPlanStatus, models.ErroredPolicyCheckStatus), nil
 	}
 	ctx.Log.Debug("Building plan command for specific project with directory: '%v', workspace: '%v', project: '%v'",
 		cmd.RepoRelDir, cmd.Workspace, cmd.ProjectName)
@@ -282,12 +314,90 @@ func (p *DefaultProjectCommandBuilder) BuildPlanCommands(ctx *command.Context, c
 
 // See ProjectCommandBuilder.BuildApplyCommands.
 func (p *DefaultProjectCommandBuilder) BuildApplyCommands(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
+	if err := p.restorePlans(ctx); err != nil {
+		return nil, errors.Wrap(err, "restoring stored plans")
+	}
 	if !cmd.IsForSpecificProject() {
-		return p.buildAllProjectCommandsByPlan(ctx, cmd)
+		projCtxs, err := p.buildAllProjectCommandsByPlan(ctx, cmd)
+		if err != nil || !cmd.Failed {
+			return projCtxs, err
+		}
+		return filterFailedProjects(ctx, projCtxs, models.ErroredApplyStatus), nil
 	}
 	return p.buildProjectCommand(ctx, cmd)
 }
 
+// restorePlans restores the stored plans of the projects that are planned on
+// the head commit of the pull request but whose plans aren't in their working
+// dirs, ex. because Atlantis restarted without its data dir or another
+// Atlantis server planned them. The working dirs are cloned if needed.
+func (p *DefaultProjectCommandBuilder) restorePlans(ctx *command.Context) error {
+	if p.PlanStore == nil || ctx.PullStatus == nil || ctx.PullStatus.Pull.HeadCommit != ctx.Pull.HeadCommit {
+		return nil
+	}
+	unlockFn, err := p.WorkingDirLocker.TryLockPull(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num)
+	if err != nil {
+		return err
+	}
+	defer unlockFn()
+
+	for _, project := range ctx.PullStatus.Projects {
+		if project.Status != models.PlannedPlanStatus && project.Status != models.PlannedDestroyPlanStatus {
+			continue
+		}
+		planFilename := runtime.GetPlanFilename(project.Workspace, project.ProjectName)
+		repoDir, err := p.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, project.Workspace)
+		if err == nil {
+			if _, err := os.Stat(filepath.Join(repoDir, project.RepoRelDir, planFilename)); err == nil {
+				continue
+			}
+		}
+
+		meta, ok, err := p.PlanStore.Metadata(ctx.Pull, project.Workspace, project.RepoRelDir, project.ProjectName)
+		if err != nil {
+			return errors.Wrapf(err, "getting stored plan of dir '%s' workspace '%s'", project.RepoRelDir, project.Workspace)
+		}
+		if !ok || meta.HeadCommit != ctx.Pull.HeadCommit {
+			continue
+		}
+		repoDir, _, err = p.WorkingDir.Clone(ctx.Log, ctx.HeadRepo, ctx.Pull, project.Workspace)
+		if err != nil {
+			return err
+		}
+		projAbsPath := filepath.Join(repoDir, project.RepoRelDir)
+		if _, err := os.Stat(projAbsPath); err != nil {
+			return DirNotExistErr{RepoRelDir: project.RepoRelDir}
+		}
+		if err := p.PlanStore.Restore(meta, projAbsPath, planFilename); err != nil {
+			return errors.Wrapf(err, "restoring plan of dir '%s' workspace '%s'", project.RepoRelDir, project.Workspace)
+		}
+		ctx.Log.Info("restored stored plan of dir '%s' workspace '%s' planned by %s", project.RepoRelDir, project.Workspace, meta.User)
+	}
+	return nil
+}
+
+// filterFailedProjects returns the projects of projCtxs that have one of the
+// failed statuses in the pull status of the head commit, i.e. the projects
+// that failed the last time the command was run.
+func filterFailedProjects(ctx *command.Context, projCtxs []command.ProjectContext, failed ...models.ProjectPlanStatus) []command.ProjectContext {
+	if ctx.PullStatus == nil || ctx.PullStatus.Pull.HeadCommit != ctx.Pull.HeadCommit {
+		ctx.Log.Info("no previous run at commit %s, so no projects failed", ctx.Pull.HeadCommit)
+		return nil
+	}
+	var failedCtxs []command.ProjectContext
+	for _, projCtx := range projCtxs {
+		for _, status := range ctx.PullStatus.Projects {
+			if status.RepoRelDir == projCtx.RepoRelDir && status.Workspace == projCtx.Workspace && status.ProjectName == projCtx.ProjectName &&
+				slices.Contains(failed, status.Status) {
+				failedCtxs = append(failedCtxs, projCtx)
+				break
+			}
+		}
+	}
+	ctx.Log.Debug("%d of %d projects failed in the last run", len(failedCtxs), len(projCtxs))
+	return failedCtxs
+}
+
 func (p *DefaultProjectCommandBuilder) BuildApprovePoliciesCommands(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
 	if !cmd.IsForSpecificProject() {
 		return p.buildAllProjectCommandsByPlan(ctx, cmd)
@@ -310,14 +420,82 @@ func (p *DefaultProjectCommandBuilder) BuildImportCommands(ctx *command.Context,
 	return p.buildProjectCommand(ctx, cmd)
 }
 
-func (p *DefaultProjectCommandBuilder) BuildStateRmCommands(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
+func (p *DefaultProjectCommandBuilder) BuildStateCommands(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
 	if !cmd.IsForSpecificProject() {
-		// state rm discard a plan file, so use buildAllCommandsByCfg instead buildAllProjectCommandsByPlan.
+		// state commands run with or without a plan file, so use buildAllCommandsByCfg instead buildAllProjectCommandsByPlan.
 		return p.buildAllCommandsByCfg(ctx, cmd.CommandName(), cmd.SubName, cmd.Flags, cmd.Verbose)
 	}
 	return p.buildProjectCommand(ctx, cmd)
 }
 
+// See ProjectCommandBuilder.BuildDestroyCommands.
+func (p *DefaultProjectCommandBuilder) BuildDestroyCommands(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
+	if cmd.Confirm {
+		applyCmd := *cmd
+		applyCmd.Name = command.Apply
+		projCtxs, err := p.BuildApplyCommands(ctx, &applyCmd)
+		if err != nil {
+			return nil, err
+		}
+		// Only the plans made by destroy are applied.
+		var destroyCtxs []command.ProjectContext
+		for _, projCtx := range projCtxs {
+			if destroyPlanned(ctx.PullStatus, projCtx) {
+				destroyCtxs = append(destroyCtxs, projCtx)
+			}
+		}
+		return destroyCtxs, nil
+	}
+
+	planCmd := *cmd
+	planCmd.Name = command.Plan
+	planCmd.Flags = append([]string{"-destroy"}, cmd.Flags...)
+	projCtxs, err := p.BuildPlanCommands(ctx, &planCmd)
+	if err != nil {
+		return nil, err
+	}
+	var destroyCtxs []command.ProjectContext
+	for _, projCtx := range projCtxs {
+		// Policies aren't checked for destroy plans.
+		if projCtx.CommandName != command.Plan {
+			continue
+		}
+		// A plan reused from the cache wouldn't be a destroy plan.
+		projCtx.ForcePlan = true
+		projCtx.ApplyCmd = p.CommentBuilder.BuildDestroyComment(projCtx.RepoRelDir, projCtx.Workspace, projCtx.ProjectName, true)
+		projCtx.RePlanCmd = p.CommentBuilder.BuildDestroyComment(projCtx.RepoRelDir, projCtx.Workspace, projCtx.ProjectName, false)
+		destroyCtxs = append(destroyCtxs, projCtx)
+	}
+	return destroyCtxs, nil
+}
+
+func (p *DefaultProjectCommandBuilder) BuildCustomCommands(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
+	customCmd, ok := p.GlobalCfg.CustomCommand(cmd.SubName)
+	if !ok {
+		return nil, fmt.Errorf("custom command %q is not defined", cmd.SubName)
+	}
+
+	var projCtxs []command.ProjectContext
+	var err error
+	if !cmd.IsForSpecificProject() {
+		// custom commands don't need a plan, so use buildAllCommandsByCfg instead buildAllProjectCommandsByPlan.
+		projCtxs, err = p.buildAllCommandsByCfg(ctx, cmd.CommandName(), cmd.SubName, cmd.Flags, cmd.Verbose)
+	} else {
+		projCtxs, err = p.buildProjectCommand(ctx, cmd)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Custom commands run the custom stage of their own workflow rather than
+	// a stage of the project's workflow.
+	steps := p.GlobalCfg.Workflows[customCmd.Workflow].Custom.Steps
+	for i := range projCtxs {
+		projCtxs[i].Steps = steps
+	}
+	return projCtxs, nil
+}
+
 // shouldSkipClone determines whether we should skip cloning for a given context
 func (p *DefaultProjectCommandBuilder) shouldSkipClone(ctx *command.Context, modifiedFiles []string) (bool, error) {
 	// NOTE: We discard this work here and end up doing it again after
@@ -327,45 +505,56 @@ func (p *DefaultProjectCommandBuilder) shouldSkipClone(ctx *command.Context, mod
 	if !p.SkipCloneNoChanges || !p.VCSClient.SupportsSingleFileDownload(ctx.Pull.BaseRepo) {
 		return false, nil
 	}
+	// Pre workflow hooks run in the clone and can generate the repo config,
+	// ex. with terragrunt-atlantis-config, so we can't tell which projects
+	// were modified without cloning.
+	if p.GlobalCfg.HasPreWorkflowHooks(ctx.Pull.BaseRepo.ID()) {
+		ctx.Log.Debug("not skipping repo clone since pre workflow hooks are configured")
+		return false, nil
+	}
 	repoCfgFile := p.GlobalCfg.RepoConfigFile(ctx.Pull.BaseRepo.ID())
 	hasRepoCfg, repoCfgData, err := p.VCSClient.GetFileContent(ctx.Log, ctx.Pull, repoCfgFile)
 	if err != nil {
 		return false, errors.Wrapf(err, "downloading %s", repoCfgFile)
 	}
-	// We can only skip if we determine that none of the modified files belong to projects configured in a repo config
-	if !hasRepoCfg {
-		return false, nil
-	}
-	repoCfg, err := p.ParserValidator.ParseRepoCfgData(repoCfgData, p.GlobalCfg, ctx.Pull.BaseRepo.ID(), ctx.Pull.BaseBranch)
-	if err != nil {
-		return false, errors.Wrapf(err, "parsing %s", repoCfgFile)
-	}
-	ctx.Log.Info("successfully parsed remote %s file", repoCfgFile)
-
-	// If auto discover is enabled, we never want to skip cloning
-	if p.autoDiscoverModeEnabled(ctx, repoCfg) {
-		ctx.Log.Info("automatic project discovery enabled. Will resume automatic detection")
-		return false, nil
-	}
-
-	if len(repoCfg.Projects) == 0 {
-		ctx.Log.Info("no projects are defined in %s. Will resume automatic detection", repoCfgFile)
-		return false, nil
+	var repoCfg valid.RepoCfg
+	if hasRepoCfg {
+		repoCfg, err = p.ParserValidator.ParseRepoCfgData(repoCfgData, p.GlobalCfg, ctx.Pull.BaseRepo.ID(), ctx.Pull.BaseBranch)
+		if err != nil {
+			return false, errors.Wrapf(err, "parsing %s", repoCfgFile)
+		}
+		ctx.Log.Info("successfully parsed remote %s file", repoCfgFile)
+	} else if p.GlobalCfg.RepoCfgOverlay(ctx.Pull.BaseRepo.ID()) != nil {
+		repoCfg, err = p.ParserValidator.ParseServerSideRepoCfg(p.GlobalCfg, ctx.Pull.BaseRepo.ID(), ctx.Pull.BaseBranch)
+		if err != nil {
+			return false, errors.Wrap(err, "parsing server-side repo_config")
+		}
 	}
 
-	matchingProjects, err := p.ProjectFinder.DetermineProjectsViaConfig(ctx.Log, modifiedFiles, repoCfg, "", nil)
-	if err != nil {
-		return false, err
+	if len(repoCfg.Projects) > 0 {
+		matchingProjects, err := p.ProjectFinder.DetermineProjectsViaConfig(ctx.Log, modifiedFiles, repoCfg, "", nil)
+		if err != nil {
+			return false, err
+		}
+		ctx.Log.Info("%d projects are changed on MR %d based on their when_modified config", len(matchingProjects), ctx.Pull.Num)
+		if len(matchingProjects) > 0 {
+			return false, nil
+		}
 	}
 
-	ctx.Log.Info("%d projects are changed on MR %d based on their when_modified config", len(matchingProjects), ctx.Pull.Num)
-	if len(matchingProjects) == 0 {
-		ctx.Log.Info("skipping repo clone since no project was modified")
-		return true, nil
+	// Automatic project discovery needs the clone to find the projects, but
+	// it only plans projects whose files are in the autoplan file list.
+	if p.autoDiscoverModeEnabled(ctx, repoCfg) {
+		finder := &DefaultProjectFinder{}
+		autoplanFiles := finder.filterToFileList(ctx.Log, modifiedFiles, p.AutoplanFileList)
+		if len(autoplanFiles) > 0 {
+			ctx.Log.Info("automatic project discovery enabled and %d modified file(s) are in the autoplan file list. Will resume automatic detection", len(autoplanFiles))
+			return false, nil
+		}
 	}
 
-	return false, nil
-
+	ctx.Log.Info("skipping repo clone since no project was modified")
+	return true, nil
 }
 
 // autoDiscoverModeEnabled determines whether to use autodiscover
@@ -446,6 +635,67 @@ func (p *DefaultProjectCommandBuilder) getMergedProjectCfgs(ctx *command.Context
 	return mergedCfgs, nil
 }
 
+// parseRepoCfg parses the repo config file in repoDir. It returns false if
+// there's neither a repo config file nor a server-side repo_config, in which
+// case the global defaults are used.
+func (p *DefaultProjectCommandBuilder) parseRepoCfg(ctx *command.Context, repoDir string) (valid.RepoCfg, bool, error) {
+	var repoCfg valid.RepoCfg
+	repoCfgFile := p.GlobalCfg.RepoConfigFile(ctx.Pull.BaseRepo.ID())
+	hasRepoCfg, err := p.ParserValidator.HasRepoCfg(repoDir, repoCfgFile)
+	if err != nil {
+		return repoCfg, false, errors.Wrapf(err, "looking for '%s' file in '%s'", repoCfgFile, repoDir)
+	}
+	if !hasRepoCfg && p.GlobalCfg.RepoCfgOverlay(ctx.Pull.BaseRepo.ID()) == nil {
+		ctx.Log.Info("repo config file %s is absent, using global defaults", repoCfgFile)
+		return repoCfg, false, nil
+	}
+
+	// If there's a repo cfg with projects then we'll use it to figure out which projects
+	// should be planed.
+	repoCfg, err = p.ParserValidator.ParseRepoCfg(repoDir, p.GlobalCfg, ctx.Pull.BaseRepo.ID(), ctx.Pull.BaseBranch)
+	if err != nil {
+		return repoCfg, false, errors.Wrapf(err, "parsing %s", repoCfgFile)
+	}
+	if err = renderPreviewEnvironments(ctx.Pull, &repoCfg); err != nil {
+		return repoCfg, false, err
+	}
+	if err = expandWorkspaces(&repoCfg, func(project valid.Project) ([]string, error) {
+		return p.listWorkspaces(ctx, repoDir, project)
+	}); err != nil {
+		return repoCfg, false, err
+	}
+	if ctx.CommentTemplates, err = readCommentTemplates(repoDir, repoCfg.CommentTemplates); err != nil {
+		return repoCfg, false, err
+	}
+	ctx.Log.Info("successfully parsed %s file", repoCfgFile)
+	return repoCfg, true, nil
+}
+
+// listWorkspaces lists the workspaces of project by running terraform
+// workspace list in its dir of the repo cloned at repoDir. This requires the
+// project's backend to be initialized unless it uses local state.
+func (p *DefaultProjectCommandBuilder) listWorkspaces(ctx *command.Context, repoDir string, project valid.Project) ([]string, error) {
+	if p.TerraformExecutor == nil {
+		return nil, errors.New("no terraform client is configured")
+	}
+	var distribution terraform.Distribution
+	if project.TerraformDistribution != nil {
+		distribution = p.TerraformExecutor.Distributions().NewDistribution(*project.TerraformDistribution)
+	}
+	projCtx := command.ProjectContext{
+		Log:        ctx.Log,
+		Pull:       ctx.Pull,
+		BaseRepo:   ctx.Pull.BaseRepo,
+		RepoRelDir: project.Dir,
+		Workspace:  DefaultWorkspace,
+	}
+	output, err := p.TerraformExecutor.RunCommandWithVersion(projCtx, filepath.Join(repoDir, project.Dir), []string{"workspace", "list"}, nil, distribution, project.TerraformVersion, DefaultWorkspace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "running terraform workspace list: %s", strings.TrimSpace(output))
+	}
+	return parseWorkspaceList(output), nil
+}
+
 // buildAllCommandsByCfg builds init contexts for all projects we determine were
 // modified in this ctx.
 func (p *DefaultProjectCommandBuilder) buildAllCommandsByCfg(ctx *command.Context, cmdName command.Name, subCmdName string, commentFlags []string, verbose bool) ([]command.ProjectContext, error) {
@@ -494,26 +744,12 @@ func (p *DefaultProjectCommandBuilder) buildAllCommandsByCfg(ctx *command.Contex
 	}
 
 	// Parse config file if it exists.
-	repoCfgFile := p.GlobalCfg.RepoConfigFile(ctx.Pull.BaseRepo.ID())
-	hasRepoCfg, err := p.ParserValidator.HasRepoCfg(repoDir, repoCfgFile)
+	repoCfg, hasRepoCfg, err := p.parseRepoCfg(ctx, repoDir)
 	if err != nil {
-		return nil, errors.Wrapf(err, "looking for '%s' file in '%s'", repoCfgFile, repoDir)
+		return nil, err
 	}
 
 	var projCtxs []command.ProjectContext
-	var repoCfg valid.RepoCfg
-
-	if hasRepoCfg {
-		// If there's a repo cfg with projects then we'll use it to figure out which projects
-		// should be planed.
-		repoCfg, err = p.ParserValidator.ParseRepoCfg(repoDir, p.GlobalCfg, ctx.Pull.BaseRepo.ID(), ctx.Pull.BaseBranch)
-		if err != nil {
-			return nil, errors.Wrapf(err, "parsing %s", repoCfgFile)
-		}
-		ctx.Log.Info("successfully parsed %s file", repoCfgFile)
-	} else {
-		ctx.Log.Info("repo config file %s is absent, using global defaults", repoCfg)
-	}
 
 	mergedProjectCfgs, err := p.getMergedProjectCfgs(ctx, repoDir, modifiedFiles, repoCfg)
 	if err != nil {
@@ -524,6 +760,7 @@ func (p *DefaultProjectCommandBuilder) buildAllCommandsByCfg(ctx *command.Contex
 	parallelApply := p.EnableParallelApply
 	parallelPlan := p.EnableParallelPlan
 	abortOnExecutionOrderFail := DefaultAbortOnExecutionOrderFail
+	parallelApplyGroups := false
 	if hasRepoCfg {
 		if repoCfg.Automerge != nil {
 			automerge = *repoCfg.Automerge
@@ -535,6 +772,7 @@ func (p *DefaultProjectCommandBuilder) buildAllCommandsByCfg(ctx *command.Contex
 			parallelPlan = *repoCfg.ParallelPlan
 		}
 		abortOnExecutionOrderFail = repoCfg.AbortOnExecutionOrderFail
+		parallelApplyGroups = repoCfg.ParallelApplyGroups
 	}
 
 	for _, mergedProjectCfg := range mergedProjectCfgs {
@@ -551,6 +789,7 @@ func (p *DefaultProjectCommandBuilder) buildAllCommandsByCfg(ctx *command.Contex
 				parallelPlan,
 				verbose,
 				abortOnExecutionOrderFail,
+				parallelApplyGroups,
 				p.TerraformExecutor,
 			)...)
 	}
@@ -560,6 +799,7 @@ func (p *DefaultProjectCommandBuilder) buildAllCommandsByCfg(ctx *command.Contex
 	})
 
 	// Filter projects to only include ones the user is authorized for
+	allowlistName := allowlistCommandName(cmdName, subCmdName)
 	projCtxs = slices.DeleteFunc(projCtxs, func(projCtx command.ProjectContext) bool {
 		if projCtx.TeamAllowlistChecker == nil || !projCtx.TeamAllowlistChecker.HasRules() {
 			// allowlist restriction is not enabled
@@ -567,7 +807,7 @@ func (p *DefaultProjectCommandBuilder) buildAllCommandsByCfg(ctx *command.Contex
 		}
 		ctx := models.TeamAllowlistCheckerContext{
 			BaseRepo:           projCtx.BaseRepo,
-			CommandName:        projCtx.CommandName.String(),
+			CommandName:        allowlistName,
 			EscapedCommentArgs: projCtx.EscapedCommentArgs,
 			HeadRepo:           projCtx.HeadRepo,
 			Log:                projCtx.Log,
@@ -580,7 +820,7 @@ func (p *DefaultProjectCommandBuilder) buildAllCommandsByCfg(ctx *command.Contex
 			Workspace:          projCtx.Workspace,
 			API:                false,
 		}
-		return !projCtx.TeamAllowlistChecker.IsCommandAllowedForAnyTeam(ctx, projCtx.User.Teams, projCtx.CommandName.String())
+		return !projCtx.TeamAllowlistChecker.IsCommandAllowedForAnyTeam(ctx, projCtx.User.Teams, allowlistName)
 	})
 
 	return projCtxs, nil
@@ -715,7 +955,7 @@ func (p *DefaultProjectCommandBuilder) getCfg(ctx *command.Context, projectName
 		err = errors.Wrapf(err, "looking for '%s' file in '%s'", repoCfgFile, repoDir)
 		return
 	}
-	if !hasRepoCfg {
+	if !hasRepoCfg && p.GlobalCfg.RepoCfgOverlay(ctx.Pull.BaseRepo.ID()) == nil {
 		if projectName != "" {
 			err = fmt.Errorf("cannot specify a project name unless an %s file exists to configure projects", repoCfgFile)
 			return
@@ -728,6 +968,19 @@ func (p *DefaultProjectCommandBuilder) getCfg(ctx *command.Context, projectName
 	if err != nil {
 		return
 	}
+	if err = renderPreviewEnvironments(ctx.Pull, &repoConfig); err != nil {
+		return
+	}
+	// Only the project in the requested workspace is needed so there's no
+	// need to list the workspaces of projects with globs.
+	if err = expandWorkspaces(&repoConfig, func(valid.Project) ([]string, error) {
+		return []string{workspace}, nil
+	}); err != nil {
+		return
+	}
+	if ctx.CommentTemplates, err = readCommentTemplates(repoDir, repoConfig.CommentTemplates); err != nil {
+		return
+	}
 	repoCfg = &repoConfig
 
 	// If they've specified a project by name we look it up. Otherwise we
@@ -752,6 +1005,11 @@ func (p *DefaultProjectCommandBuilder) getCfg(ctx *command.Context, projectName
 	}
 
 	projCfgs := repoCfg.FindProjectsByDirWorkspace(dir, workspace)
+	if len(projCfgs) == 0 && workspace == DefaultWorkspace {
+		// A plan for a dir without a workspace runs in the pull request's
+		// preview environment if the dir has one.
+		projCfgs = findPreviewProjects(repoCfg, dir)
+	}
 	if len(projCfgs) == 0 {
 		return
 	}
@@ -871,6 +1129,7 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 	parallelApply := p.EnableParallelApply
 	parallelPlan := p.EnableParallelPlan
 	abortOnExecutionOrderFail := DefaultAbortOnExecutionOrderFail
+	parallelApplyGroups := false
 	if repoCfgPtr != nil {
 		if repoCfgPtr.Automerge != nil {
 			automerge = *repoCfgPtr.Automerge
@@ -882,6 +1141,7 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 			parallelPlan = *repoCfgPtr.ParallelPlan
 		}
 		abortOnExecutionOrderFail = repoCfgPtr.AbortOnExecutionOrderFail
+		parallelApplyGroups = repoCfgPtr.ParallelApplyGroups
 	}
 
 	if len(matchingProjects) > 0 {
@@ -907,6 +1167,7 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 					parallelPlan,
 					verbose,
 					abortOnExecutionOrderFail,
+					parallelApplyGroups,
 					p.TerraformExecutor,
 				)...)
 		}
@@ -931,6 +1192,7 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 				parallelPlan,
 				verbose,
 				abortOnExecutionOrderFail,
+				parallelApplyGroups,
 				p.TerraformExecutor,
 			)...)
 	}
@@ -940,6 +1202,7 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 	}
 
 	// Filter projects to only include ones the user is authorized for
+	allowlistName := allowlistCommandName(cmd, subCmd)
 	projCtxs = slices.DeleteFunc(projCtxs, func(projCtx command.ProjectContext) bool {
 		if projCtx.TeamAllowlistChecker == nil || !projCtx.TeamAllowlistChecker.HasRules() {
 			// allowlist restriction is not enabled
@@ -947,7 +1210,7 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 		}
 		ctx := models.TeamAllowlistCheckerContext{
 			BaseRepo:           projCtx.BaseRepo,
-			CommandName:        projCtx.CommandName.String(),
+			CommandName:        allowlistName,
 			EscapedCommentArgs: projCtx.EscapedCommentArgs,
 			HeadRepo:           projCtx.HeadRepo,
 			Log:                projCtx.Log,
@@ -960,12 +1223,21 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 			Workspace:          projCtx.Workspace,
 			API:                false,
 		}
-		return !projCtx.TeamAllowlistChecker.IsCommandAllowedForAnyTeam(ctx, projCtx.User.Teams, projCtx.CommandName.String())
+		return !projCtx.TeamAllowlistChecker.IsCommandAllowedForAnyTeam(ctx, projCtx.User.Teams, allowlistName)
 	})
 
 	return projCtxs, nil
 }
 
+// allowlistCommandName returns the command name team allowlist rules match
+// against. Custom commands are matched by their own name, ex. "docs".
+func allowlistCommandName(cmd command.Name, subCmd string) string {
+	if cmd == command.Custom {
+		return subCmd
+	}
+	return cmd.String()
+}
+
 // validateWorkspaceAllowed returns an error if repoCfg defines projects in
 // repoRelDir but none of them use workspace. We want this to be an error
 // because if users have gone to the trouble of defining projects in repoRelDir
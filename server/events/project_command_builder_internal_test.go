@@ -18,6 +18,35 @@ import (
 	. "github.com/runatlantis/atlantis/testing"
 )
 
+func TestFilterFailedProjects(t *testing.T) {
+	projCtxs := []command.ProjectContext{
+		{RepoRelDir: "network", Workspace: "default"},
+		{RepoRelDir: "database", Workspace: "default"},
+		{RepoRelDir: "database", Workspace: "staging"},
+		{RepoRelDir: "app", Workspace: "default", ProjectName: "app"},
+	}
+	ctx := &command.Context{
+		Log:  logging.NewNoopLogger(t),
+		Pull: models.PullRequest{HeadCommit: "abc"},
+		PullStatus: &models.PullStatus{
+			Pull: models.PullRequest{HeadCommit: "abc"},
+			Projects: []models.ProjectStatus{
+				{RepoRelDir: "network", Workspace: "default", Status: models.AppliedPlanStatus},
+				{RepoRelDir: "database", Workspace: "default", Status: models.ErroredApplyStatus},
+				{RepoRelDir: "database", Workspace: "staging", Status: models.ErroredPlanStatus},
+				{RepoRelDir: "app", Workspace: "default", ProjectName: "app", Status: models.ErroredPolicyCheckStatus},
+			},
+		},
+	}
+
+	Equals(t, []command.ProjectContext{projCtxs[1]}, filterFailedProjects(ctx, projCtxs, models.ErroredApplyStatus))
+	Equals(t, []command.ProjectContext{projCtxs[2], projCtxs[3]}, filterFailedProjects(ctx, projCtxs, models.ErroredPlanStatus, models.ErroredPolicyCheckStatus))
+
+	// The status of a previous commit isn't used.
+	ctx.Pull.HeadCommit = "def"
+	Equals(t, 0, len(filterFailedProjects(ctx, projCtxs, models.ErroredApplyStatus)))
+}
+
 // Test different permutations of global and repo config.
 func TestBuildProjectCmdCtx(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
@@ -79,6 +108,7 @@ workflows:
 				ApplyRequirements:  []string{},
 				ImportRequirements: []string{},
 				RePlanCmd:          "atlantis plan -d project1 -w myworkspace -- flag",
+				UnlockCmd:          "atlantis unlock -d project1 -w myworkspace",
 				RepoRelDir:         "project1",
 				User:               models.User{},
 				Verbose:            true,
@@ -137,6 +167,7 @@ projects:
 				ImportRequirements: []string{},
 				RepoConfigVersion:  3,
 				RePlanCmd:          "atlantis plan -d project1 -w myworkspace -- flag",
+				UnlockCmd:          "atlantis unlock -d project1 -w myworkspace",
 				RepoRelDir:         "project1",
 				TerraformVersion:   mustVersion("10.0"),
 				User:               models.User{},
@@ -198,6 +229,7 @@ projects:
 				ImportRequirements: []string{"approved", "mergeable"},
 				RepoConfigVersion:  3,
 				RePlanCmd:          "atlantis plan -d project1 -w myworkspace -- flag",
+				UnlockCmd:          "atlantis unlock -d project1 -w myworkspace",
 				RepoRelDir:         "project1",
 				TerraformVersion:   mustVersion("10.0"),
 				User:               models.User{},
@@ -267,6 +299,7 @@ projects:
 				ImportRequirements: []string{"approved"},
 				RepoConfigVersion:  3,
 				RePlanCmd:          "atlantis plan -d project1 -w myworkspace -- flag",
+				UnlockCmd:          "atlantis unlock -d project1 -w myworkspace",
 				RepoRelDir:         "project1",
 				TerraformVersion:   mustVersion("10.0"),
 				User:               models.User{},
@@ -423,6 +456,7 @@ workflows:
 				ImportRequirements: []string{},
 				RepoConfigVersion:  3,
 				RePlanCmd:          "atlantis plan -d project1 -w myworkspace -- flag",
+				UnlockCmd:          "atlantis unlock -d project1 -w myworkspace",
 				RepoRelDir:         "project1",
 				TerraformVersion:   mustVersion("10.0"),
 				User:               models.User{},
@@ -486,6 +520,7 @@ projects:
 				ImportRequirements: []string{},
 				RepoConfigVersion:  3,
 				RePlanCmd:          "atlantis plan -d project1 -w myworkspace -- flag",
+				UnlockCmd:          "atlantis unlock -d project1 -w myworkspace",
 				RepoRelDir:         "project1",
 				TerraformVersion:   mustVersion("10.0"),
 				User:               models.User{},
@@ -552,6 +587,7 @@ workflows:
 				ImportRequirements: []string{},
 				RepoConfigVersion:  3,
 				RePlanCmd:          "atlantis plan -d project1 -w myworkspace -- flag",
+				UnlockCmd:          "atlantis unlock -d project1 -w myworkspace",
 				RepoRelDir:         "project1",
 				TerraformVersion:   mustVersion("10.0"),
 				User:               models.User{},
@@ -604,6 +640,7 @@ projects:
 				ImportRequirements: []string{"approved"},
 				RepoConfigVersion:  3,
 				RePlanCmd:          "atlantis plan -d project1 -w myworkspace -- flag",
+				UnlockCmd:          "atlantis unlock -d project1 -w myworkspace",
 				RepoRelDir:         "project1",
 				User:               models.User{},
 				Verbose:            true,
@@ -660,6 +697,7 @@ projects:
 				globalCfg,
 				&DefaultPendingPlanFinder{},
 				&CommentParser{ExecutableName: "atlantis"},
+				nil,
 				false,
 				false,
 				false,
@@ -818,6 +856,7 @@ projects:
 				ImportRequirements: []string{},
 				RepoConfigVersion:  3,
 				RePlanCmd:          "atlantis plan -p myproject_1 -- flag",
+				UnlockCmd:          "atlantis unlock -p myproject_1",
 				RepoRelDir:         "project1",
 				TerraformVersion:   mustVersion("10.0"),
 				User:               models.User{},
@@ -877,6 +916,7 @@ projects:
 				globalCfg,
 				&DefaultPendingPlanFinder{},
 				&CommentParser{ExecutableName: "atlantis"},
+				nil,
 				false,
 				true,
 				false,
@@ -1000,6 +1040,7 @@ repos:
 				ApplyRequirements:  []string{"policies_passed"},
 				ImportRequirements: []string{"policies_passed"},
 				RePlanCmd:          "atlantis plan -d project1 -w myworkspace -- flag",
+				UnlockCmd:          "atlantis unlock -d project1 -w myworkspace",
 				RepoRelDir:         "project1",
 				User:               models.User{},
 				Verbose:            true,
@@ -1063,6 +1104,7 @@ workflows:
 				ImportRequirements: []string{"policies_passed"},
 				RepoConfigVersion:  3,
 				RePlanCmd:          "atlantis plan -d project1 -w myworkspace -- flag",
+				UnlockCmd:          "atlantis unlock -d project1 -w myworkspace",
 				RepoRelDir:         "project1",
 				TerraformVersion:   mustVersion("v10.0"),
 				User:               models.User{},
@@ -1124,6 +1166,7 @@ workflows:
 				globalCfg,
 				&DefaultPendingPlanFinder{},
 				&CommentParser{ExecutableName: "atlantis"},
+				nil,
 				false,
 				false,
 				false,
@@ -1276,6 +1319,7 @@ projects:
 				globalCfg,
 				&DefaultPendingPlanFinder{},
 				&CommentParser{ExecutableName: "atlantis"},
+				nil,
 				false,
 				false,
 				false,
@@ -1418,6 +1462,7 @@ projects:
 				globalCfg,
 				&DefaultPendingPlanFinder{},
 				&CommentParser{ExecutableName: "atlantis"},
+				nil,
 				false,
 				false,
 				false,
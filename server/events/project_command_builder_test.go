@@ -9,6 +9,7 @@ import (
 
 	"github.com/hashicorp/go-version"
 	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/terraform"
 	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
 
 	"github.com/runatlantis/atlantis/server/core/config"
@@ -21,6 +22,7 @@ import (
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
 	. "github.com/runatlantis/atlantis/testing"
+	"gocloud.dev/blob/memblob"
 )
 
 var defaultUserConfig = struct {
@@ -262,6 +264,7 @@ terraform {
 				valid.NewGlobalCfgFromArgs(globalCfgArgs),
 				&events.DefaultPendingPlanFinder{},
 				&events.CommentParser{ExecutableName: "atlantis"},
+				nil,
 				userConfig.SkipCloneNoChanges,
 				userConfig.EnableRegExpCmd,
 				userConfig.EnableAutoMerge,
@@ -628,6 +631,7 @@ projects:
 					valid.NewGlobalCfgFromArgs(globalCfgArgs),
 					&events.DefaultPendingPlanFinder{},
 					&events.CommentParser{ExecutableName: "atlantis"},
+					nil,
 					userConfig.SkipCloneNoChanges,
 					userConfig.EnableRegExpCmd,
 					c.EnableAutoMergeUserCfg,
@@ -816,6 +820,7 @@ projects:
 				valid.NewGlobalCfgFromArgs(globalCfgArgs),
 				&events.DefaultPendingPlanFinder{},
 				&events.CommentParser{ExecutableName: "atlantis"},
+				nil,
 				userConfig.SkipCloneNoChanges,
 				userConfig.EnableRegExpCmd,
 				userConfig.EnableAutoMerge,
@@ -1217,6 +1222,7 @@ projects:
 				valid.NewGlobalCfgFromArgs(globalCfgArgs),
 				&events.DefaultPendingPlanFinder{},
 				&events.CommentParser{ExecutableName: "atlantis"},
+				nil,
 				userConfig.SkipCloneNoChanges,
 				userConfig.EnableRegExpCmd,
 				userConfig.EnableAutoMerge,
@@ -1315,6 +1321,7 @@ func TestDefaultProjectCommandBuilder_BuildMultiApply(t *testing.T) {
 		valid.NewGlobalCfgFromArgs(globalCfgArgs),
 		&events.DefaultPendingPlanFinder{},
 		&events.CommentParser{ExecutableName: "atlantis"},
+		nil,
 		userConfig.SkipCloneNoChanges,
 		userConfig.EnableRegExpCmd,
 		userConfig.EnableAutoMerge,
@@ -1355,6 +1362,89 @@ func TestDefaultProjectCommandBuilder_BuildMultiApply(t *testing.T) {
 	Equals(t, "workspace2", ctxs[3].Workspace)
 }
 
+// Test that the stored plans of projects planned on the head commit are
+// restored into their working dirs before they're applied.
+func TestDefaultProjectCommandBuilder_BuildApplyCommands_RestoresStoredPlans(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir := DirStructure(t, map[string]interface{}{
+		"workspace1": map[string]interface{}{
+			"project1": map[string]interface{}{
+				"main.tf": nil,
+			},
+			"project2": map[string]interface{}{
+				"main.tf": nil,
+			},
+		},
+	})
+	runCmd(t, filepath.Join(tmpDir, "workspace1"), "git", "init")
+	repoDir := filepath.Join(tmpDir, "workspace1")
+
+	pull := models.PullRequest{Num: 1, HeadCommit: "abc", BaseRepo: models.Repo{FullName: "org/app"}}
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.GetPullDir(Any[models.Repo](), Any[models.PullRequest]())).ThenReturn(tmpDir, nil)
+	When(workingDir.GetWorkingDir(Any[models.Repo](), Any[models.PullRequest](), Eq("workspace1"))).ThenReturn(repoDir, nil)
+	When(workingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Eq("workspace1"))).ThenReturn(repoDir, false, nil)
+
+	// project1 was planned on the head commit and project2 on an older one.
+	planStore := &events.BlobPlanStore{Bucket: memblob.OpenBucket(nil)}
+	planDir := t.TempDir()
+	Ok(t, os.WriteFile(filepath.Join(planDir, "workspace1.tfplan"), []byte("plan"), 0600))
+	Ok(t, planStore.Save(events.PlanMetadata{RepoFullName: "org/app", PullNum: 1, HeadCommit: "abc", Workspace: "workspace1", RepoRelDir: "project1"}, planDir, "workspace1.tfplan"))
+	Ok(t, planStore.Save(events.PlanMetadata{RepoFullName: "org/app", PullNum: 1, HeadCommit: "old", Workspace: "workspace1", RepoRelDir: "project2"}, planDir, "workspace1.tfplan"))
+
+	logger := logging.NewNoopLogger(t)
+	scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+	userConfig := defaultUserConfig
+	builder := events.NewProjectCommandBuilder(
+		false,
+		&config.ParserValidator{},
+		&events.DefaultProjectFinder{},
+		nil,
+		workingDir,
+		events.NewDefaultWorkingDirLocker(),
+		valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}),
+		&events.DefaultPendingPlanFinder{},
+		&events.CommentParser{ExecutableName: "atlantis"},
+		planStore,
+		userConfig.SkipCloneNoChanges,
+		userConfig.EnableRegExpCmd,
+		userConfig.EnableAutoMerge,
+		userConfig.EnableParallelPlan,
+		userConfig.EnableParallelApply,
+		userConfig.AutoDetectModuleFiles,
+		userConfig.AutoplanFileList,
+		userConfig.RestrictFileList,
+		userConfig.SilenceNoProjects,
+		userConfig.IncludeGitUntrackedFiles,
+		userConfig.AutoDiscoverMode,
+		scope,
+		tfclientmocks.NewMockClient(),
+	)
+
+	ctxs, err := builder.BuildApplyCommands(
+		&command.Context{
+			Log:   logger,
+			Scope: scope,
+			Pull:  pull,
+			PullStatus: &models.PullStatus{
+				Pull: pull,
+				Projects: []models.ProjectStatus{
+					{Workspace: "workspace1", RepoRelDir: "project1", Status: models.PlannedPlanStatus},
+					{Workspace: "workspace1", RepoRelDir: "project2", Status: models.PlannedPlanStatus},
+				},
+			},
+		},
+		&events.CommentCommand{Name: command.Apply})
+	Ok(t, err)
+	Equals(t, 1, len(ctxs))
+	Equals(t, "project1", ctxs[0].RepoRelDir)
+	plan, err := os.ReadFile(filepath.Join(repoDir, "project1", "workspace1.tfplan"))
+	Ok(t, err)
+	Equals(t, "plan", string(plan))
+	_, err = os.Stat(filepath.Join(repoDir, "project2", "workspace1.tfplan"))
+	Assert(t, os.IsNotExist(err), "expected the plan of an older commit not to be restored")
+}
+
 // Test that if a directory has a list of workspaces configured then we don't
 // allow plans for other workspace names.
 func TestDefaultProjectCommandBuilder_WrongWorkspaceName(t *testing.T) {
@@ -1401,6 +1491,7 @@ projects:
 		valid.NewGlobalCfgFromArgs(globalCfgArgs),
 		&events.DefaultPendingPlanFinder{},
 		&events.CommentParser{ExecutableName: "atlantis"},
+		nil,
 		userConfig.SkipCloneNoChanges,
 		userConfig.EnableRegExpCmd,
 		userConfig.EnableAutoMerge,
@@ -1489,6 +1580,7 @@ func TestDefaultProjectCommandBuilder_EscapeArgs(t *testing.T) {
 				valid.NewGlobalCfgFromArgs(globalCfgArgs),
 				&events.DefaultPendingPlanFinder{},
 				&events.CommentParser{ExecutableName: "atlantis"},
+				nil,
 				userConfig.SkipCloneNoChanges,
 				userConfig.EnableRegExpCmd,
 				userConfig.EnableAutoMerge,
@@ -1651,6 +1743,7 @@ projects:
 				valid.NewGlobalCfgFromArgs(globalCfgArgs),
 				&events.DefaultPendingPlanFinder{},
 				&events.CommentParser{ExecutableName: "atlantis"},
+				nil,
 				userConfig.SkipCloneNoChanges,
 				userConfig.EnableRegExpCmd,
 				userConfig.EnableAutoMerge,
@@ -1700,6 +1793,7 @@ func TestDefaultProjectCommandBuilder_SkipCloneNoChanges(t *testing.T) {
 		ExpectedClones           InvocationCountMatcher
 		ModifiedFiles            []string
 		IncludeGitUntrackedFiles bool
+		PreWorkflowHooks         bool
 	}{
 		{
 			AtlantisYAML: `
@@ -1725,10 +1819,43 @@ projects:
 			AtlantisYAML: `
 version: 3
 parallel_plan: true`,
+			ExpectedCtxs:             0,
+			ExpectedClones:           Never(),
+			ModifiedFiles:            []string{"README.md"},
+			IncludeGitUntrackedFiles: false,
+		},
+		{
+			AtlantisYAML: `
+version: 3
+parallel_plan: true`,
+			ExpectedCtxs:             0,
+			ExpectedClones:           Once(),
+			ModifiedFiles:            []string{"dir2/main.tf"},
+			IncludeGitUntrackedFiles: false,
+		},
+		{
+			// No repo config.
+			AtlantisYAML:             "",
+			ExpectedCtxs:             0,
+			ExpectedClones:           Never(),
+			ModifiedFiles:            []string{"README.md", "docs/setup.md"},
+			IncludeGitUntrackedFiles: false,
+		},
+		{
+			AtlantisYAML:             "",
+			ExpectedCtxs:             0,
+			ExpectedClones:           Once(),
+			ModifiedFiles:            []string{"README.md", "dir2/terragrunt.hcl"},
+			IncludeGitUntrackedFiles: false,
+		},
+		{
+			// Pre workflow hooks can generate the repo config.
+			AtlantisYAML:             "",
 			ExpectedCtxs:             0,
 			ExpectedClones:           Once(),
 			ModifiedFiles:            []string{"README.md"},
 			IncludeGitUntrackedFiles: false,
+			PreWorkflowHooks:         true,
 		},
 		{
 			AtlantisYAML: `
@@ -1742,6 +1869,18 @@ projects:
 			ModifiedFiles:            []string{"dir2/main.tf"},
 			IncludeGitUntrackedFiles: false,
 		},
+		{
+			AtlantisYAML: `
+version: 3
+autodiscover:
+  mode: enabled
+projects:
+- dir: dir1`,
+			ExpectedCtxs:             0,
+			ExpectedClones:           Never(),
+			ModifiedFiles:            []string{"dir2/README.md"},
+			IncludeGitUntrackedFiles: false,
+		},
 	}
 
 	userConfig := defaultUserConfig
@@ -1754,7 +1893,7 @@ projects:
 			Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest]())).ThenReturn(c.ModifiedFiles, nil)
 		When(vcsClient.SupportsSingleFileDownload(Any[models.Repo]())).ThenReturn(true)
 		When(vcsClient.GetFileContent(
-			Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[string]())).ThenReturn(true, []byte(c.AtlantisYAML), nil)
+			Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[string]())).ThenReturn(c.AtlantisYAML != "", []byte(c.AtlantisYAML), nil)
 		workingDir := mocks.NewMockWorkingDir()
 
 		logger := logging.NewNoopLogger(t)
@@ -1762,6 +1901,9 @@ projects:
 		globalCfgArgs := valid.GlobalCfgArgs{
 			AllowAllRepoSettings: true,
 		}
+		if c.PreWorkflowHooks {
+			globalCfgArgs.PreWorkflowHooks = []*valid.WorkflowHook{{RunCommand: "terragrunt-atlantis-config generate"}}
+		}
 		scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
 		terraformClient := tfclientmocks.NewMockClient()
 
@@ -1775,6 +1917,7 @@ projects:
 			valid.NewGlobalCfgFromArgs(globalCfgArgs),
 			&events.DefaultPendingPlanFinder{},
 			&events.CommentParser{ExecutableName: "atlantis"},
+			nil,
 			userConfig.SkipCloneNoChanges,
 			userConfig.EnableRegExpCmd,
 			userConfig.EnableAutoMerge,
@@ -1844,6 +1987,7 @@ func TestDefaultProjectCommandBuilder_WithPolicyCheckEnabled_BuildAutoplanComman
 		globalCfg,
 		&events.DefaultPendingPlanFinder{},
 		&events.CommentParser{ExecutableName: "atlantis"},
+		nil,
 		userConfig.SkipCloneNoChanges,
 		userConfig.EnableRegExpCmd,
 		userConfig.EnableAutoMerge,
@@ -1877,6 +2021,75 @@ func TestDefaultProjectCommandBuilder_WithPolicyCheckEnabled_BuildAutoplanComman
 	Equals(t, globalCfg.Workflows["default"].PolicyCheck.Steps, policyCheckCtx.Steps)
 }
 
+// Test that projects with workspaces are planned in each of their workspaces.
+func TestDefaultProjectCommandBuilder_BuildAutoplanCommands_Workspaces(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir := DirStructure(t, map[string]interface{}{
+		"main.tf": nil,
+	})
+	repoCfg := `version: 3
+projects:
+- dir: .
+  workspaces: [staging, "prod-*"]
+`
+	Ok(t, os.WriteFile(filepath.Join(tmpDir, "atlantis.yaml"), []byte(repoCfg), 0600))
+
+	logger := logging.NewNoopLogger(t)
+	scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+	userConfig := defaultUserConfig
+
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[string]())).ThenReturn(tmpDir, false, nil)
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.GetModifiedFiles(Any[logging.SimpleLogging](), Any[models.Repo](),
+		Any[models.PullRequest]())).ThenReturn([]string{"main.tf"}, nil)
+	terraformClient := tfclientmocks.NewMockClient()
+	When(terraformClient.RunCommandWithVersion(Any[command.ProjectContext](), Eq(tmpDir), Eq([]string{"workspace", "list"}),
+		Any[map[string]string](), Any[terraform.Distribution](), Any[*version.Version](), Any[string]())).
+		ThenReturn("* default\n  dev\n  prod-eu\n  prod-us\n", nil)
+
+	builder := events.NewProjectCommandBuilder(
+		false,
+		&config.ParserValidator{},
+		&events.DefaultProjectFinder{},
+		vcsClient,
+		workingDir,
+		events.NewDefaultWorkingDirLocker(),
+		valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}),
+		&events.DefaultPendingPlanFinder{},
+		&events.CommentParser{ExecutableName: "atlantis"},
+		nil,
+		userConfig.SkipCloneNoChanges,
+		userConfig.EnableRegExpCmd,
+		userConfig.EnableAutoMerge,
+		userConfig.EnableParallelPlan,
+		userConfig.EnableParallelApply,
+		userConfig.AutoDetectModuleFiles,
+		userConfig.AutoplanFileList,
+		userConfig.RestrictFileList,
+		userConfig.SilenceNoProjects,
+		userConfig.IncludeGitUntrackedFiles,
+		userConfig.AutoDiscoverMode,
+		scope,
+		terraformClient,
+	)
+
+	ctxs, err := builder.BuildAutoplanCommands(&command.Context{
+		PullRequestStatus: models.PullReqStatus{
+			Mergeable: true,
+		},
+		Log:   logger,
+		Scope: scope,
+	})
+	Ok(t, err)
+	var workspaces []string
+	for _, ctx := range ctxs {
+		workspaces = append(workspaces, ctx.Workspace)
+	}
+	Equals(t, []string{"staging", "prod-eu", "prod-us"}, workspaces)
+}
+
 // Test building version command for multiple projects
 func TestDefaultProjectCommandBuilder_BuildVersionCommand(t *testing.T) {
 	RegisterMockTestingT(t)
@@ -1932,6 +2145,7 @@ func TestDefaultProjectCommandBuilder_BuildVersionCommand(t *testing.T) {
 		valid.NewGlobalCfgFromArgs(globalCfgArgs),
 		&events.DefaultPendingPlanFinder{},
 		&events.CommentParser{ExecutableName: "atlantis"},
+		nil,
 		userConfig.SkipCloneNoChanges,
 		userConfig.EnableRegExpCmd,
 		userConfig.EnableAutoMerge,
@@ -2062,6 +2276,7 @@ func TestDefaultProjectCommandBuilder_BuildPlanCommands_Single_With_RestrictFile
 				valid.NewGlobalCfgFromArgs(globalCfgArgs),
 				&events.DefaultPendingPlanFinder{},
 				&events.CommentParser{ExecutableName: "atlantis"},
+				nil,
 				userConfig.SkipCloneNoChanges,
 				userConfig.EnableRegExpCmd,
 				userConfig.EnableAutoMerge,
@@ -2173,6 +2388,7 @@ func TestDefaultProjectCommandBuilder_BuildPlanCommands_with_IncludeGitUntracked
 				valid.NewGlobalCfgFromArgs(globalCfgArgs),
 				&events.DefaultPendingPlanFinder{},
 				&events.CommentParser{ExecutableName: "atlantis"},
+				nil,
 				userConfig.SkipCloneNoChanges,
 				userConfig.EnableRegExpCmd,
 				userConfig.EnableAutoMerge,
@@ -38,7 +38,7 @@ type ProjectCommandContextBuilder interface {
 		prjCfg valid.MergedProjectCfg,
 		commentFlags []string,
 		repoDir string,
-		automerge, parallelApply, parallelPlan, verbose, abortOnExecutionOrderFail bool, terraformClient tfclient.Client,
+		automerge, parallelApply, parallelPlan, verbose, abortOnExecutionOrderFail, parallelApplyGroups bool, terraformClient tfclient.Client,
 	) []command.ProjectContext
 }
 
@@ -58,13 +58,13 @@ func (cb *CommandScopedStatsProjectCommandContextBuilder) BuildProjectContext(
 	prjCfg valid.MergedProjectCfg,
 	commentFlags []string,
 	repoDir string,
-	automerge, parallelApply, parallelPlan, verbose, abortOnExecutionOrderFail bool,
+	automerge, parallelApply, parallelPlan, verbose, abortOnExecutionOrderFail, parallelApplyGroups bool,
 	terraformClient tfclient.Client,
 ) (projectCmds []command.ProjectContext) {
 	cb.ProjectCounter.Inc(1)
 
 	cmds := cb.ProjectCommandContextBuilder.BuildProjectContext(
-		ctx, cmdName, subCmdName, prjCfg, commentFlags, repoDir, automerge, parallelApply, parallelPlan, verbose, abortOnExecutionOrderFail, terraformClient,
+		ctx, cmdName, subCmdName, prjCfg, commentFlags, repoDir, automerge, parallelApply, parallelPlan, verbose, abortOnExecutionOrderFail, parallelApplyGroups, terraformClient,
 	)
 
 	projectCmds = []command.ProjectContext{}
@@ -92,7 +92,7 @@ func (cb *DefaultProjectCommandContextBuilder) BuildProjectContext(
 	prjCfg valid.MergedProjectCfg,
 	commentFlags []string,
 	repoDir string,
-	automerge, parallelApply, parallelPlan, verbose, abortOnExecutionOrderFail bool,
+	automerge, parallelApply, parallelPlan, verbose, abortOnExecutionOrderFail, parallelApplyGroups bool,
 	terraformClient tfclient.Client,
 ) (projectCmds []command.ProjectContext) {
 	ctx.Log.Debug("Building project command context for %s", cmdName)
@@ -114,6 +114,12 @@ func (cb *DefaultProjectCommandContextBuilder) BuildProjectContext(
 		switch subName {
 		case "rm":
 			steps = prjCfg.Workflow.StateRm.Steps
+		case "pull", "push":
+			// State pull and push aren't workflow stages so that a repo
+			// config can't change what they run, ex. to print the state.
+			// They only reuse how the plan stage sets up the project.
+			steps = stateSetupSteps(prjCfg.Workflow.Plan.Steps)
+			steps = append(steps, valid.Step{StepName: "state_" + subName})
 		default:
 			// comment_parser prevent invalid subcommand, so not need to handle this.
 			// if comes here, state_command_runner will respond on PR, so it's enough to do log only.
@@ -127,21 +133,31 @@ func (cb *DefaultProjectCommandContextBuilder) BuildProjectContext(
 		prjCfg.TerraformVersion = terraformClient.DetectVersion(ctx.Log, filepath.Join(repoDir, prjCfg.RepoRelDir))
 	}
 
+	// The project's default comment args are only added to commands run by
+	// comments. The plan command in comments only has the comment's args
+	// since the defaults are added again when it's run.
+	commentArgs := commentFlags
+	if ctx.Trigger == command.CommentTrigger {
+		commentArgs = prjCfg.CommentArgs.Merge(cmdName.String(), commentFlags)
+	}
+
 	projectCmdContext := newProjectCommandContext(
 		ctx,
 		cmdName,
 		cb.CommentBuilder.BuildApplyComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name, prjCfg.AutoMergeDisabled, prjCfg.AutoMergeMethod),
 		cb.CommentBuilder.BuildApprovePoliciesComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name),
 		cb.CommentBuilder.BuildPlanComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name, commentFlags),
+		cb.CommentBuilder.BuildUnlockComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name),
 		prjCfg,
 		steps,
 		prjCfg.PolicySets,
-		escapeArgs(commentFlags),
+		escapeArgs(commentArgs),
 		automerge,
 		parallelApply,
 		parallelPlan,
 		verbose,
 		abortOnExecutionOrderFail,
+		parallelApplyGroups,
 		ctx.Scope,
 		ctx.PullRequestStatus,
 		ctx.PullStatus,
@@ -165,7 +181,7 @@ func (cb *PolicyCheckProjectCommandContextBuilder) BuildProjectContext(
 	prjCfg valid.MergedProjectCfg,
 	commentFlags []string,
 	repoDir string,
-	automerge, parallelApply, parallelPlan, verbose, abortOnExecutionOrderFail bool,
+	automerge, parallelApply, parallelPlan, verbose, abortOnExecutionOrderFail, parallelApplyGroups bool,
 	terraformClient tfclient.Client,
 ) (projectCmds []command.ProjectContext) {
 	if prjCfg.PolicyCheck {
@@ -193,6 +209,7 @@ func (cb *PolicyCheckProjectCommandContextBuilder) BuildProjectContext(
 		parallelPlan,
 		verbose,
 		abortOnExecutionOrderFail,
+		parallelApplyGroups,
 		terraformClient,
 	)
 
@@ -206,6 +223,7 @@ func (cb *PolicyCheckProjectCommandContextBuilder) BuildProjectContext(
 			cb.CommentBuilder.BuildApplyComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name, prjCfg.AutoMergeDisabled, prjCfg.AutoMergeMethod),
 			cb.CommentBuilder.BuildApprovePoliciesComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name),
 			cb.CommentBuilder.BuildPlanComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name, commentFlags),
+			cb.CommentBuilder.BuildUnlockComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name),
 			prjCfg,
 			steps,
 			prjCfg.PolicySets,
@@ -215,6 +233,7 @@ func (cb *PolicyCheckProjectCommandContextBuilder) BuildProjectContext(
 			parallelPlan,
 			verbose,
 			abortOnExecutionOrderFail,
+			parallelApplyGroups,
 			ctx.Scope,
 			ctx.PullRequestStatus,
 			ctx.PullStatus,
@@ -232,6 +251,7 @@ func newProjectCommandContext(ctx *command.Context,
 	applyCmd string,
 	approvePoliciesCmd string,
 	planCmd string,
+	unlockCmd string,
 	projCfg valid.MergedProjectCfg,
 	steps []valid.Step,
 	policySets valid.PolicySets,
@@ -241,6 +261,7 @@ func newProjectCommandContext(ctx *command.Context,
 	parallelPlanEnabled bool,
 	verbose bool,
 	abortOnExecutionOrderFail bool,
+	parallelApplyGroupsEnabled bool,
 	scope tally.Scope,
 	pullReqStatus models.PullReqStatus,
 	pullStatus *models.PullStatus,
@@ -282,6 +303,7 @@ func newProjectCommandContext(ctx *command.Context,
 		ParallelPlanEnabled:        parallelPlanEnabled,
 		ParallelPolicyCheckEnabled: parallelPlanEnabled,
 		DependsOn:                  projCfg.DependsOn,
+		PromoteTo:                  projCfg.PromoteTo,
 		AutoplanEnabled:            projCfg.AutoplanEnabled,
 		Steps:                      steps,
 		HeadRepo:                   ctx.HeadRepo,
@@ -295,26 +317,52 @@ func newProjectCommandContext(ctx *command.Context,
 		ApplyRequirements:          projCfg.ApplyRequirements,
 		ImportRequirements:         projCfg.ImportRequirements,
 		RePlanCmd:                  planCmd,
+		UnlockCmd:                  unlockCmd,
 		RepoRelDir:                 projCfg.RepoRelDir,
 		RepoConfigVersion:          projCfg.RepoCfgVersion,
 		TerraformDistribution:      projCfg.TerraformDistribution,
 		TerraformVersion:           projCfg.TerraformVersion,
+		TerraformVars:              projCfg.TerraformVars,
+		DestroyOnClose:             projCfg.DestroyOnClose,
+		AutoApplyDestroyOnClose:    projCfg.AutoApplyDestroyOnClose,
+		ManualTriggerOperators:     projCfg.ManualTriggerOperators,
+		GithubEnvironment:          projCfg.GithubEnvironment,
+		Timeout:                    projCfg.CommandTimeouts.For(cmd.String()),
+		PreWorkflowHooks:           projCfg.PreWorkflowHooks,
+		PostWorkflowHooks:          projCfg.PostWorkflowHooks,
+		SuppressedChanges:          projCfg.SuppressedChanges,
 		User:                       ctx.User,
 		Verbose:                    verbose,
 		Workspace:                  projCfg.Workspace,
 		PolicySets:                 policySets,
 		PolicySetTarget:            ctx.PolicySet,
 		ClearPolicyApproval:        ctx.ClearPolicyApproval,
+		ForcePlan:                  ctx.ForcePlan,
 		PullReqStatus:              pullReqStatus,
 		PullStatus:                 pullStatus,
 		JobID:                      uuid.New().String(),
 		ExecutionOrderGroup:        projCfg.ExecutionOrderGroup,
 		AbortOnExecutionOrderFail:  abortOnExecutionOrderFail,
+		ParallelApplyGroupsEnabled: parallelApplyGroupsEnabled,
 		SilencePRComments:          projCfg.SilencePRComments,
 		TeamAllowlistChecker:       teamAllowlistChecker,
 	}
 }
 
+// stateSetupSteps returns the steps of planSteps that set up the project
+// before terraform runs: the init step and the steps that set environment
+// variables.
+func stateSetupSteps(planSteps []valid.Step) []valid.Step {
+	var steps []valid.Step
+	for _, step := range planSteps {
+		switch step.StepName {
+		case "init", "env", "multienv":
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
 func escapeArgs(args []string) []string {
 	var escaped []string
 	for _, arg := range args {
@@ -61,7 +61,7 @@ func TestProjectCommandContextBuilder_PullStatus(t *testing.T) {
 			},
 		}
 
-		result := subject.BuildProjectContext(commandCtx, command.Plan, "", projCfg, []string{}, "some/dir", false, false, false, false, false, terraformClient)
+		result := subject.BuildProjectContext(commandCtx, command.Plan, "", projCfg, []string{}, "some/dir", false, false, false, false, false, false, terraformClient)
 		assert.Equal(t, models.ErroredPolicyCheckStatus, result[0].ProjectPlanStatus)
 	})
 
@@ -80,7 +80,7 @@ func TestProjectCommandContextBuilder_PullStatus(t *testing.T) {
 			},
 		}
 
-		result := subject.BuildProjectContext(commandCtx, command.Plan, "", projCfg, []string{}, "some/dir", false, false, false, false, false, terraformClient)
+		result := subject.BuildProjectContext(commandCtx, command.Plan, "", projCfg, []string{}, "some/dir", false, false, false, false, false, false, terraformClient)
 
 		assert.Equal(t, models.ErroredPolicyCheckStatus, result[0].ProjectPlanStatus)
 	})
@@ -100,7 +100,7 @@ func TestProjectCommandContextBuilder_PullStatus(t *testing.T) {
 			},
 		}
 
-		result := subject.BuildProjectContext(commandCtx, command.Plan, "", projCfg, []string{}, "some/dir", false, true, false, false, false, terraformClient)
+		result := subject.BuildProjectContext(commandCtx, command.Plan, "", projCfg, []string{}, "some/dir", false, true, false, false, false, false, terraformClient)
 
 		assert.True(t, result[0].ParallelApplyEnabled)
 		assert.False(t, result[0].ParallelPlanEnabled)
@@ -121,8 +121,37 @@ func TestProjectCommandContextBuilder_PullStatus(t *testing.T) {
 			},
 		}
 
-		result := subject.BuildProjectContext(commandCtx, command.Plan, "", projCfg, []string{}, "some/dir", false, false, false, false, true, terraformClient)
+		result := subject.BuildProjectContext(commandCtx, command.Plan, "", projCfg, []string{}, "some/dir", false, false, false, false, true, false, terraformClient)
 
 		assert.True(t, result[0].AbortOnExecutionOrderFail)
 	})
 }
+
+func TestProjectCommandContextBuilder_CommentArgs(t *testing.T) {
+	RegisterMockTestingT(t)
+	subject := events.DefaultProjectCommandContextBuilder{
+		CommentBuilder: mocks.NewMockCommentBuilder(),
+	}
+	projCfg := valid.MergedProjectCfg{
+		RepoRelDir: "dir1",
+		Workspace:  "default",
+		Workflow: valid.Workflow{
+			Name: valid.DefaultWorkflowName,
+			Plan: valid.DefaultPlanStage,
+		},
+		CommentArgs: valid.CommentArgs{Plan: []string{"-p=1", "-v=a"}},
+	}
+	terraformClient := tfclientmocks.NewMockClient()
+
+	t.Run("comment", func(t *testing.T) {
+		commandCtx := &command.Context{Log: logging.NewNoopLogger(t), Trigger: command.CommentTrigger}
+		result := subject.BuildProjectContext(commandCtx, command.Plan, "", projCfg, []string{"-v=b"}, "some/dir", false, false, false, false, false, false, terraformClient)
+		assert.Equal(t, []string{`\-\p\=\1`, `\-\v\=\b`}, result[0].EscapedCommentArgs)
+	})
+
+	t.Run("autoplan", func(t *testing.T) {
+		commandCtx := &command.Context{Log: logging.NewNoopLogger(t), Trigger: command.AutoTrigger}
+		result := subject.BuildProjectContext(commandCtx, command.Plan, "", projCfg, nil, "some/dir", false, false, false, false, false, false, terraformClient)
+		assert.Empty(t, result[0].EscapedCommentArgs)
+	})
+}
@@ -0,0 +1,237 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// ProjectCommandHook sends the project commands Atlantis built to an external
+// HTTP service that can reorder, filter and annotate them with environment
+// variables before they're run, ex. to implement org-specific scheduling.
+type ProjectCommandHook struct {
+	Client *http.Client
+	// URL is where the project commands are POSTed to.
+	URL string
+}
+
+// ProjectCommandHookRequest is the body POSTed to the hook.
+type ProjectCommandHookRequest struct {
+	// Command is the command being run, ex. "plan" or "apply".
+	Command string `json:"command"`
+	// Autoplan is true if the commands were built for an autoplan.
+	Autoplan bool                        `json:"autoplan"`
+	Repo     string                      `json:"repo"`
+	Pull     ProjectCommandHookPull      `json:"pull"`
+	User     string                      `json:"user"`
+	Projects []ProjectCommandHookProject `json:"projects"`
+}
+
+// ProjectCommandHookPull describes the pull request the commands are run for.
+type ProjectCommandHookPull struct {
+	Num        int    `json:"num"`
+	Author     string `json:"author"`
+	BaseBranch string `json:"base_branch"`
+	HeadBranch string `json:"head_branch"`
+	HeadCommit string `json:"head_commit"`
+}
+
+// ProjectCommandHookProject describes one of the project commands Atlantis
+// built.
+type ProjectCommandHookProject struct {
+	// Index is the position of the project in the list Atlantis built. The
+	// hook refers to projects by their index in its response.
+	Index               int      `json:"index"`
+	Name                string   `json:"name"`
+	Dir                 string   `json:"dir"`
+	Workspace           string   `json:"workspace"`
+	ExecutionOrderGroup int      `json:"execution_order_group"`
+	DependsOn           []string `json:"depends_on"`
+}
+
+// ProjectCommandHookResponse is the response of the hook. Projects are run in
+// the order they're listed in and projects that aren't listed aren't run.
+type ProjectCommandHookResponse struct {
+	Projects []ProjectCommandHookResult `json:"projects"`
+}
+
+// ProjectCommandHookResult is a project the hook wants to run.
+type ProjectCommandHookResult struct {
+	// Index is the index of the project in the request.
+	Index int `json:"index"`
+	// Envs are set in the environment of every step of the project.
+	Envs map[string]string `json:"envs,omitempty"`
+	// ExecutionOrderGroup replaces the project's execution order group if
+	// set.
+	ExecutionOrderGroup *int `json:"execution_order_group,omitempty"`
+}
+
+// Run sends cmds to the hook and returns the commands it wants to run.
+func (h *ProjectCommandHook) Run(ctx *command.Context, cmdName command.Name, autoplan bool, cmds []command.ProjectContext) ([]command.ProjectContext, error) {
+	if len(cmds) == 0 {
+		return cmds, nil
+	}
+	req := ProjectCommandHookRequest{
+		Command:  cmdName.String(),
+		Autoplan: autoplan,
+		Repo:     ctx.Pull.BaseRepo.FullName,
+		Pull: ProjectCommandHookPull{
+			Num:        ctx.Pull.Num,
+			Author:     ctx.Pull.Author,
+			BaseBranch: ctx.Pull.BaseBranch,
+			HeadBranch: ctx.Pull.HeadBranch,
+			HeadCommit: ctx.Pull.HeadCommit,
+		},
+		User: ctx.User.Username,
+	}
+	for i, cmd := range cmds {
+		req.Projects = append(req.Projects, ProjectCommandHookProject{
+			Index:               i,
+			Name:                cmd.ProjectName,
+			Dir:                 cmd.RepoRelDir,
+			Workspace:           cmd.Workspace,
+			ExecutionOrderGroup: cmd.ExecutionOrderGroup,
+			DependsOn:           cmd.DependsOn,
+		})
+	}
+
+	resp, err := h.post(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "calling project command hook %q", h.URL)
+	}
+
+	var processed []command.ProjectContext
+	seen := make(map[int]bool)
+	for _, result := range resp.Projects {
+		if result.Index < 0 || result.Index >= len(cmds) {
+			return nil, fmt.Errorf("project command hook returned unknown project index %d", result.Index)
+		}
+		if seen[result.Index] {
+			return nil, fmt.Errorf("project command hook returned project index %d more than once", result.Index)
+		}
+		seen[result.Index] = true
+
+		cmd := cmds[result.Index]
+		if len(result.Envs) > 0 {
+			envs := make(map[string]string, len(cmd.Envs)+len(result.Envs))
+			for name, value := range cmd.Envs {
+				envs[name] = value
+			}
+			for name, value := range result.Envs {
+				envs[name] = value
+			}
+			cmd.Envs = envs
+		}
+		if result.ExecutionOrderGroup != nil {
+			cmd.ExecutionOrderGroup = *result.ExecutionOrderGroup
+		}
+		processed = append(processed, cmd)
+	}
+	if skipped := len(cmds) - len(processed); skipped > 0 {
+		ctx.Log.Info("project command hook skipped %d of %d projects", skipped, len(cmds))
+	}
+	return processed, nil
+}
+
+func (h *ProjectCommandHook) post(req ProjectCommandHookRequest) (ProjectCommandHookResponse, error) {
+	var resp ProjectCommandHookResponse
+	body, err := json.Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+	httpResp, err := h.Client.Post(h.URL, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return resp, err
+	}
+	defer httpResp.Body.Close() // nolint: errcheck
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return resp, errors.Wrap(err, "reading response")
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return resp, fmt.Errorf("returned status code %d with response %q", httpResp.StatusCode, respBody)
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return resp, errors.Wrap(err, "parsing response")
+	}
+	return resp, nil
+}
+
+// HookedProjectCommandBuilder runs the commands built by the wrapped
+// ProjectCommandBuilder through Hook. If Hook is nil, the commands are
+// returned unchanged.
+type HookedProjectCommandBuilder struct {
+	ProjectCommandBuilder
+	Hook *ProjectCommandHook
+}
+
+func (b *HookedProjectCommandBuilder) BuildAutoplanCommands(ctx *command.Context) ([]command.ProjectContext, error) {
+	return b.run(ctx, command.Plan, true, func() ([]command.ProjectContext, error) {
+		return b.ProjectCommandBuilder.BuildAutoplanCommands(ctx)
+	})
+}
+
+func (b *HookedProjectCommandBuilder) BuildPlanCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+	return b.run(ctx, command.Plan, false, func() ([]command.ProjectContext, error) {
+		return b.ProjectCommandBuilder.BuildPlanCommands(ctx, comment)
+	})
+}
+
+func (b *HookedProjectCommandBuilder) BuildApplyCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+	return b.run(ctx, command.Apply, false, func() ([]command.ProjectContext, error) {
+		return b.ProjectCommandBuilder.BuildApplyCommands(ctx, comment)
+	})
+}
+
+func (b *HookedProjectCommandBuilder) BuildApprovePoliciesCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+	return b.run(ctx, command.ApprovePolicies, false, func() ([]command.ProjectContext, error) {
+		return b.ProjectCommandBuilder.BuildApprovePoliciesCommands(ctx, comment)
+	})
+}
+
+func (b *HookedProjectCommandBuilder) BuildVersionCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+	return b.run(ctx, command.Version, false, func() ([]command.ProjectContext, error) {
+		return b.ProjectCommandBuilder.BuildVersionCommands(ctx, comment)
+	})
+}
+
+func (b *HookedProjectCommandBuilder) BuildImportCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+	return b.run(ctx, command.Import, false, func() ([]command.ProjectContext, error) {
+		return b.ProjectCommandBuilder.BuildImportCommands(ctx, comment)
+	})
+}
+
+func (b *HookedProjectCommandBuilder) BuildStateCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+	return b.run(ctx, command.State, false, func() ([]command.ProjectContext, error) {
+		return b.ProjectCommandBuilder.BuildStateCommands(ctx, comment)
+	})
+}
+
+func (b *HookedProjectCommandBuilder) BuildDestroyCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+	return b.run(ctx, command.Destroy, false, func() ([]command.ProjectContext, error) {
+		return b.ProjectCommandBuilder.BuildDestroyCommands(ctx, comment)
+	})
+}
+
+// SimulateAutoplan simulates an autoplan if the wrapped builder supports it.
+// The hook isn't called for simulations.
+func (b *HookedProjectCommandBuilder) SimulateAutoplan(ctx *command.Context, modifiedFiles []string) (AutoplanSimulation, error) {
+	simulator, ok := b.ProjectCommandBuilder.(AutoplanSimulator)
+	if !ok {
+		return AutoplanSimulation{}, fmt.Errorf("simulating autoplans is not supported")
+	}
+	return simulator.SimulateAutoplan(ctx, modifiedFiles)
+}
+
+func (b *HookedProjectCommandBuilder) run(ctx *command.Context, cmdName command.Name, autoplan bool, build func() ([]command.ProjectContext, error)) ([]command.ProjectContext, error) {
+	cmds, err := build()
+	if err != nil || b.Hook == nil {
+		return cmds, err
+	}
+	return b.Hook.Run(ctx, cmdName, autoplan, cmds)
+}
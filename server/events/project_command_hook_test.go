@@ -0,0 +1,102 @@
+package events_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func projectCommandHookServer(t *testing.T, handler func(req events.ProjectCommandHookRequest) (int, string)) *events.ProjectCommandHook {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req events.ProjectCommandHookRequest
+		Ok(t, json.NewDecoder(r.Body).Decode(&req))
+		code, body := handler(req)
+		w.WriteHeader(code)
+		w.Write([]byte(body)) // nolint: errcheck
+	}))
+	t.Cleanup(server.Close)
+	return &events.ProjectCommandHook{Client: server.Client(), URL: server.URL}
+}
+
+func projectCommandHookCtx(t *testing.T) *command.Context {
+	return &command.Context{
+		Pull: models.PullRequest{Num: 7, Author: "alice", BaseBranch: "main", BaseRepo: models.Repo{FullName: "org/app"}},
+		User: models.User{Username: "bob"},
+		Log:  logging.NewNoopLogger(t),
+	}
+}
+
+func TestProjectCommandHook_Run(t *testing.T) {
+	var received events.ProjectCommandHookRequest
+	hook := projectCommandHookServer(t, func(req events.ProjectCommandHookRequest) (int, string) {
+		received = req
+		// Run staging before production, skip sandbox.
+		return http.StatusOK, `{"projects": [{"index": 1, "envs": {"DEPLOY_WINDOW": "night"}}, {"index": 0, "execution_order_group": 2}]}`
+	})
+	cmds := []command.ProjectContext{
+		{ProjectName: "production", RepoRelDir: "prod", Workspace: "default", Envs: map[string]string{"REGION": "eu"}},
+		{ProjectName: "staging", RepoRelDir: "staging", Workspace: "default"},
+		{ProjectName: "sandbox", RepoRelDir: "sandbox", Workspace: "default"},
+	}
+
+	processed, err := hook.Run(projectCommandHookCtx(t), command.Plan, true, cmds)
+	Ok(t, err)
+	Equals(t, events.ProjectCommandHookRequest{
+		Command:  "plan",
+		Autoplan: true,
+		Repo:     "org/app",
+		Pull:     events.ProjectCommandHookPull{Num: 7, Author: "alice", BaseBranch: "main"},
+		User:     "bob",
+		Projects: []events.ProjectCommandHookProject{
+			{Index: 0, Name: "production", Dir: "prod", Workspace: "default"},
+			{Index: 1, Name: "staging", Dir: "staging", Workspace: "default"},
+			{Index: 2, Name: "sandbox", Dir: "sandbox", Workspace: "default"},
+		},
+	}, received)
+	Equals(t, 2, len(processed))
+	Equals(t, "staging", processed[0].ProjectName)
+	Equals(t, map[string]string{"DEPLOY_WINDOW": "night"}, processed[0].Envs)
+	Equals(t, "production", processed[1].ProjectName)
+	Equals(t, 2, processed[1].ExecutionOrderGroup)
+	Equals(t, map[string]string{"REGION": "eu"}, processed[1].Envs)
+}
+
+func TestProjectCommandHook_RunErrors(t *testing.T) {
+	cases := map[string]struct {
+		code   int
+		body   string
+		expErr string
+	}{
+		"error status": {
+			code:   http.StatusInternalServerError,
+			body:   "boom",
+			expErr: `returned status code 500 with response "boom"`,
+		},
+		"unknown index": {
+			code:   http.StatusOK,
+			body:   `{"projects": [{"index": 1}]}`,
+			expErr: "project command hook returned unknown project index 1",
+		},
+		"duplicate index": {
+			code:   http.StatusOK,
+			body:   `{"projects": [{"index": 0}, {"index": 0}]}`,
+			expErr: "project command hook returned project index 0 more than once",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			hook := projectCommandHookServer(t, func(_ events.ProjectCommandHookRequest) (int, string) {
+				return c.code, c.body
+			})
+			_, err := hook.Run(projectCommandHookCtx(t), command.Apply, false, []command.ProjectContext{{RepoRelDir: "."}})
+			ErrContains(t, c.expErr, err)
+		})
+	}
+}
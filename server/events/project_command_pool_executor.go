@@ -1,11 +1,15 @@
 package events
 
 import (
+	"fmt"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/remeh/sizedwaitgroup"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
 )
 
 type prjCmdRunnerFunc func(ctx command.ProjectContext) command.ProjectResult
@@ -52,33 +56,174 @@ func runProjectCmds(
 	return command.Result{ProjectResults: results}
 }
 
-func splitByExecutionOrderGroup(cmds []command.ProjectContext) [][]command.ProjectContext {
-	groups := make(map[int][]command.ProjectContext)
-	for _, cmd := range cmds {
-		groups[cmd.ExecutionOrderGroup] = append(groups[cmd.ExecutionOrderGroup], cmd)
+// runProjectCmdsInOrder runs cmds one at a time in the order of their
+// execution order groups and dependencies, see splitByExecutionOrder.
+func runProjectCmdsInOrder(
+	cmds []command.ProjectContext,
+	runnerFunc prjCmdRunnerFunc,
+) command.Result {
+	runnerFunc = newDependencyTracker().wrap(runnerFunc)
+	var results []command.ProjectResult
+	for _, group := range splitByExecutionOrder(cmds) {
+		results = append(results, runProjectCmds(group, runnerFunc).ProjectResults...)
+	}
+	return command.Result{ProjectResults: results}
+}
+
+// splitByExecutionOrder splits cmds into the groups they must be run in, in
+// order. cmds are split by execution order group, and the projects of each
+// execution order group are split further by their depends_on so that they
+// run after the projects of the same group they depend on. The projects of a
+// group don't depend on each other so they can run in parallel.
+func splitByExecutionOrder(cmds []command.ProjectContext) [][]command.ProjectContext {
+	levels := dependencyLevels(cmds)
+	type groupKey struct {
+		executionOrderGroup int
+		level               int
+	}
+	groups := make(map[groupKey][]command.ProjectContext)
+	for i, cmd := range cmds {
+		key := groupKey{cmd.ExecutionOrderGroup, levels[i]}
+		groups[key] = append(groups[key], cmd)
 	}
 
-	var groupKeys []int
+	var groupKeys []groupKey
 	for k := range groups {
 		groupKeys = append(groupKeys, k)
 	}
-	sort.Ints(groupKeys)
+	sort.Slice(groupKeys, func(i, j int) bool {
+		if groupKeys[i].executionOrderGroup != groupKeys[j].executionOrderGroup {
+			return groupKeys[i].executionOrderGroup < groupKeys[j].executionOrderGroup
+		}
+		return groupKeys[i].level < groupKeys[j].level
+	})
 
 	var res [][]command.ProjectContext
-	for _, group := range groupKeys {
-		res = append(res, groups[group])
+	for _, key := range groupKeys {
+		res = append(res, groups[key])
 	}
 	return res
 }
 
+// dependencyLevels returns the level of each of cmds within its execution
+// order group: 0 for projects that don't depend on another project of the
+// group, and otherwise one more than the highest level of the projects of the
+// group they depend on. Dependencies on projects of other groups are ordered
+// by the groups instead. The repo config can't have dependency cycles, but
+// cmds that are in one are left at the level they had when it was found.
+func dependencyLevels(cmds []command.ProjectContext) []int {
+	byName := make(map[string][]int)
+	for i, cmd := range cmds {
+		if cmd.ProjectName != "" {
+			byName[cmd.ProjectName] = append(byName[cmd.ProjectName], i)
+		}
+	}
+
+	levels := make([]int, len(cmds))
+	visiting := make([]bool, len(cmds))
+	visited := make([]bool, len(cmds))
+	var visit func(i int) int
+	visit = func(i int) int {
+		if visited[i] || visiting[i] {
+			return levels[i]
+		}
+		visiting[i] = true
+		for _, name := range cmds[i].DependsOn {
+			for _, j := range byName[name] {
+				if cmds[j].ExecutionOrderGroup != cmds[i].ExecutionOrderGroup {
+					continue
+				}
+				if level := visit(j) + 1; level > levels[i] {
+					levels[i] = level
+				}
+			}
+		}
+		visiting[i] = false
+		visited[i] = true
+		return levels[i]
+	}
+	for i := range cmds {
+		visit(i)
+	}
+	return levels
+}
+
+// dependencyTracker tracks the results of the projects of a command so that
+// the projects that depend on them know how they went.
+type dependencyTracker struct {
+	mux     sync.Mutex
+	results map[string]command.ProjectResult
+}
+
+func newDependencyTracker() *dependencyTracker {
+	return &dependencyTracker{results: make(map[string]command.ProjectResult)}
+}
+
+// wrap returns runnerFunc wrapped so that projects whose dependencies failed
+// in this command aren't run, and so that the dependencies applied by this
+// command count as applied when the apply requirements of the projects that
+// depend on them are checked.
+func (d *dependencyTracker) wrap(runnerFunc prjCmdRunnerFunc) prjCmdRunnerFunc {
+	return func(ctx command.ProjectContext) command.ProjectResult {
+		var failed, applied []string
+		d.mux.Lock()
+		for _, dep := range ctx.DependsOn {
+			res, ok := d.results[dep]
+			switch {
+			case !ok:
+			case res.Error != nil || res.Failure != "":
+				failed = append(failed, dep)
+			case res.ApplySuccess != "":
+				applied = append(applied, dep)
+			}
+		}
+		d.mux.Unlock()
+
+		var res command.ProjectResult
+		if len(failed) > 0 {
+			res = command.ProjectResult{
+				Command:     ctx.CommandName,
+				ProjectName: ctx.ProjectName,
+				RepoRelDir:  ctx.RepoRelDir,
+				Workspace:   ctx.Workspace,
+				Failure:     fmt.Sprintf("Not run because the projects it depends on failed: [%s]", strings.Join(failed, ", ")),
+			}
+		} else {
+			if len(applied) > 0 && ctx.PullStatus != nil {
+				ctx.PullStatus = withAppliedProjects(*ctx.PullStatus, applied)
+			}
+			res = runnerFunc(ctx)
+		}
+		if ctx.ProjectName != "" {
+			d.mux.Lock()
+			d.results[ctx.ProjectName] = res
+			d.mux.Unlock()
+		}
+		return res
+	}
+}
+
+// withAppliedProjects returns a copy of pullStatus where the projects named
+// applied are applied.
+func withAppliedProjects(pullStatus models.PullStatus, applied []string) *models.PullStatus {
+	pullStatus.Projects = slices.Clone(pullStatus.Projects)
+	for i, project := range pullStatus.Projects {
+		if slices.Contains(applied, project.ProjectName) {
+			pullStatus.Projects[i].Status = models.AppliedPlanStatus
+		}
+	}
+	return &pullStatus
+}
+
 func runProjectCmdsParallelGroups(
 	ctx *command.Context,
 	cmds []command.ProjectContext,
 	runnerFunc prjCmdRunnerFunc,
 	poolSize int,
 ) command.Result {
+	runnerFunc = newDependencyTracker().wrap(runnerFunc)
 	var results []command.ProjectResult
-	groups := splitByExecutionOrderGroup(cmds)
+	groups := splitByExecutionOrder(cmds)
 	for _, group := range groups {
 		res := runProjectCmdsParallel(group, runnerFunc, poolSize)
 		results = append(results, res.ProjectResults...)
@@ -90,3 +235,108 @@ func runProjectCmdsParallelGroups(
 
 	return command.Result{ProjectResults: results}
 }
+
+// runProjectCmdsParallelIndependentGroups runs the execution order groups of
+// cmds in parallel, up to poolSize groups at a time, while the projects within
+// each group run one at a time. A group only waits for the earlier groups that
+// it shares a dependency with, i.e. where a project of one group depends on a
+// project of the other, so groups that don't depend on each other don't block
+// each other. If a group fails and aborting on failures is enabled, the groups
+// that depend on it aren't run.
+func runProjectCmdsParallelIndependentGroups(
+	ctx *command.Context,
+	cmds []command.ProjectContext,
+	runnerFunc prjCmdRunnerFunc,
+	poolSize int,
+) command.Result {
+	if poolSize < 1 {
+		poolSize = 1
+	}
+	runnerFunc = newDependencyTracker().wrap(runnerFunc)
+	groups := splitByExecutionOrder(cmds)
+	deps := groupDependencies(groups)
+
+	// results and failed of a group are written before its done channel is
+	// closed, so they can be read by the groups that wait for it.
+	results := make([][]command.ProjectResult, len(groups))
+	failed := make([]bool, len(groups))
+	done := make([]chan struct{}, len(groups))
+	for i := range groups {
+		done[i] = make(chan struct{})
+	}
+
+	sem := make(chan struct{}, poolSize)
+	var wg sync.WaitGroup
+	for i, group := range groups {
+		wg.Add(1)
+		go func(i int, group []command.ProjectContext) {
+			defer wg.Done()
+			defer close(done[i])
+			for _, dep := range deps[i] {
+				<-done[dep]
+				if failed[dep] && group[0].AbortOnExecutionOrderFail {
+					ctx.Log.Info("abort on execution order when failed")
+					failed[i] = true
+					return
+				}
+			}
+
+			sem <- struct{}{}
+			res := runProjectCmds(group, runnerFunc)
+			<-sem
+			results[i] = res.ProjectResults
+			failed[i] = res.HasErrors()
+		}(i, group)
+	}
+	wg.Wait()
+
+	var projectResults []command.ProjectResult
+	for _, res := range results {
+		projectResults = append(projectResults, res...)
+	}
+	return command.Result{ProjectResults: projectResults}
+}
+
+// groupDependencies returns the indexes of the earlier groups that each group
+// in groups must wait for. Two groups depend on each other if a project in one
+// depends on a project in the other, and the later group waits for the earlier
+// one.
+func groupDependencies(groups [][]command.ProjectContext) [][]int {
+	projectGroups := make(map[string]int)
+	for i, group := range groups {
+		for _, cmd := range group {
+			if cmd.ProjectName != "" {
+				projectGroups[cmd.ProjectName] = i
+			}
+		}
+	}
+
+	deps := make([]map[int]bool, len(groups))
+	for i := range groups {
+		deps[i] = make(map[int]bool)
+	}
+	for i, group := range groups {
+		for _, cmd := range group {
+			for _, name := range cmd.DependsOn {
+				j, ok := projectGroups[name]
+				if !ok || j == i {
+					continue
+				}
+				if j < i {
+					deps[i][j] = true
+				} else {
+					deps[j][i] = true
+				}
+			}
+		}
+	}
+
+	res := make([][]int, len(groups))
+	for i := range deps {
+		for j := range deps[i] {
+			res[i] = append(res[i], j)
+		}
+		sort.Ints(res[i])
+	}
+	return res
+}
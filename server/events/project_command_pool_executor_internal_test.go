@@ -0,0 +1,166 @@
+package events
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGroupDependencies(t *testing.T) {
+	groups := splitByExecutionOrder([]command.ProjectContext{
+		{ProjectName: "network", ExecutionOrderGroup: 0},
+		{ProjectName: "database", ExecutionOrderGroup: 1, DependsOn: []string{"network"}},
+		{ProjectName: "dns", ExecutionOrderGroup: 2},
+		// Dependencies on later groups and on projects that aren't being run
+		// still make the groups wait for each other.
+		{ProjectName: "app", ExecutionOrderGroup: 3, DependsOn: []string{"database", "unchanged"}},
+		{ProjectName: "cdn", ExecutionOrderGroup: 2, DependsOn: []string{"app"}},
+	})
+	Equals(t, [][]int{nil, {0}, nil, {1, 2}}, groupDependencies(groups))
+}
+
+func TestRunProjectCmdsParallelIndependentGroups(t *testing.T) {
+	ctx := &command.Context{Log: logging.NewNoopLogger(t)}
+	cmds := []command.ProjectContext{
+		{ProjectName: "network", ExecutionOrderGroup: 0},
+		{ProjectName: "database", ExecutionOrderGroup: 1, DependsOn: []string{"network"}},
+		{ProjectName: "dns", ExecutionOrderGroup: 2},
+	}
+
+	var mux sync.Mutex
+	var order []string
+	dnsStarted := make(chan struct{})
+	runner := func(cmd command.ProjectContext) command.ProjectResult {
+		switch cmd.ProjectName {
+		case "network":
+			// dns doesn't depend on network so it runs while network is
+			// still being applied.
+			select {
+			case <-dnsStarted:
+			case <-time.After(10 * time.Second):
+				t.Error("dns wasn't applied in parallel with network")
+			}
+		case "dns":
+			close(dnsStarted)
+		}
+		mux.Lock()
+		order = append(order, cmd.ProjectName)
+		mux.Unlock()
+		return command.ProjectResult{ProjectName: cmd.ProjectName}
+	}
+
+	result := runProjectCmdsParallelIndependentGroups(ctx, cmds, runner, 2)
+	Equals(t, []string{"dns", "network", "database"}, order)
+	// Results are in the order of the groups.
+	var names []string
+	for _, res := range result.ProjectResults {
+		names = append(names, res.ProjectName)
+	}
+	Equals(t, []string{"network", "database", "dns"}, names)
+}
+
+func TestRunProjectCmdsParallelIndependentGroups_Abort(t *testing.T) {
+	ctx := &command.Context{Log: logging.NewNoopLogger(t)}
+	cmds := []command.ProjectContext{
+		{ProjectName: "network", ExecutionOrderGroup: 0, AbortOnExecutionOrderFail: true},
+		{ProjectName: "database", ExecutionOrderGroup: 1, DependsOn: []string{"network"}, AbortOnExecutionOrderFail: true},
+		{ProjectName: "app", ExecutionOrderGroup: 2, DependsOn: []string{"database"}, AbortOnExecutionOrderFail: true},
+		{ProjectName: "dns", ExecutionOrderGroup: 3, AbortOnExecutionOrderFail: true},
+	}
+	runner := func(cmd command.ProjectContext) command.ProjectResult {
+		if cmd.ProjectName == "network" {
+			return command.ProjectResult{ProjectName: cmd.ProjectName, Error: errors.New("failed")}
+		}
+		return command.ProjectResult{ProjectName: cmd.ProjectName}
+	}
+
+	// The groups that depend on network, even through other groups, aren't
+	// run but dns is.
+	result := runProjectCmdsParallelIndependentGroups(ctx, cmds, runner, 1)
+	var names []string
+	for _, res := range result.ProjectResults {
+		names = append(names, res.ProjectName)
+	}
+	Equals(t, []string{"network", "dns"}, names)
+}
+
+func TestSplitByExecutionOrder(t *testing.T) {
+	groups := splitByExecutionOrder([]command.ProjectContext{
+		{ProjectName: "app", DependsOn: []string{"cluster"}},
+		{ProjectName: "cluster", DependsOn: []string{"network"}},
+		{ProjectName: "network"},
+		{ProjectName: "dns"},
+		// Dependencies on other execution order groups are ordered by the
+		// groups.
+		{ProjectName: "cdn", ExecutionOrderGroup: 1, DependsOn: []string{"app"}},
+	})
+	var names [][]string
+	for _, group := range groups {
+		var groupNames []string
+		for _, cmd := range group {
+			groupNames = append(groupNames, cmd.ProjectName)
+		}
+		names = append(names, groupNames)
+	}
+	Equals(t, [][]string{{"network", "dns"}, {"cluster"}, {"app"}, {"cdn"}}, names)
+}
+
+func TestRunProjectCmdsInOrder(t *testing.T) {
+	cmds := []command.ProjectContext{
+		{ProjectName: "app", DependsOn: []string{"cluster"}},
+		{ProjectName: "cluster", DependsOn: []string{"network"}},
+		{ProjectName: "network"},
+		{ProjectName: "dns"},
+	}
+	var order []string
+	runner := func(cmd command.ProjectContext) command.ProjectResult {
+		order = append(order, cmd.ProjectName)
+		if cmd.ProjectName == "network" {
+			return command.ProjectResult{ProjectName: cmd.ProjectName, Error: errors.New("failed")}
+		}
+		return command.ProjectResult{ProjectName: cmd.ProjectName}
+	}
+
+	// The projects that depend on network, even through other projects,
+	// aren't run.
+	result := runProjectCmdsInOrder(cmds, runner)
+	Equals(t, []string{"network", "dns"}, order)
+	var names []string
+	for _, res := range result.ProjectResults {
+		names = append(names, res.ProjectName)
+	}
+	Equals(t, []string{"network", "dns", "cluster", "app"}, names)
+	Equals(t, "Not run because the projects it depends on failed: [network]", result.ProjectResults[2].Failure)
+	Equals(t, "Not run because the projects it depends on failed: [cluster]", result.ProjectResults[3].Failure)
+}
+
+func TestRunProjectCmdsParallelGroups_AppliedDependencies(t *testing.T) {
+	ctx := &command.Context{Log: logging.NewNoopLogger(t)}
+	pullStatus := &models.PullStatus{Projects: []models.ProjectStatus{
+		{ProjectName: "network", Status: models.PlannedPlanStatus},
+		{ProjectName: "cluster", Status: models.PlannedPlanStatus},
+	}}
+	cmds := []command.ProjectContext{
+		{ProjectName: "cluster", DependsOn: []string{"network"}, PullStatus: pullStatus},
+		{ProjectName: "network", PullStatus: pullStatus},
+	}
+	var clusterPullStatus *models.PullStatus
+	runner := func(cmd command.ProjectContext) command.ProjectResult {
+		if cmd.ProjectName == "cluster" {
+			clusterPullStatus = cmd.PullStatus
+		}
+		return command.ProjectResult{ProjectName: cmd.ProjectName, ApplySuccess: "success"}
+	}
+
+	runProjectCmdsParallelGroups(ctx, cmds, runner, 2)
+	// network counts as applied for the apply requirements of cluster, without
+	// changing the pull status of the other projects.
+	Equals(t, models.AppliedPlanStatus, clusterPullStatus.Projects[0].Status)
+	Equals(t, models.PlannedPlanStatus, pullStatus.Projects[0].Status)
+}
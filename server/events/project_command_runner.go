@@ -14,19 +14,23 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/expr"
 	"github.com/runatlantis/atlantis/server/core/runtime"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
@@ -102,6 +106,46 @@ type MultiEnvStepRunner interface {
 	) (string, error)
 }
 
+// PluginStepRunner runs plugin steps.
+type PluginStepRunner interface {
+	// Run the step plugin named pluginName in path.
+	Run(ctx command.ProjectContext, pluginName string, extraArgs []string, path string, envs map[string]string) (string, error)
+}
+
+// TerragruntStepRunner runs terragrunt steps.
+type TerragruntStepRunner interface {
+	// Run runs terragrunt in path, or in every module under path if runAll.
+	Run(ctx command.ProjectContext, extraArgs []string, runAll bool, path string, envs map[string]string) (string, error)
+}
+
+// SecretResolver reads the values of env steps from secret providers.
+type SecretResolver interface {
+	// Resolve returns the value of secret. envs are the environment of the
+	// step, ex. with the credentials of an earlier assume_role step.
+	Resolve(ctx command.ProjectContext, secret valid.SecretRef, path string, envs map[string]string) (string, error)
+}
+
+// PlanChangeSuppressor suppresses the noisy changes of plans.
+type PlanChangeSuppressor interface {
+	// Suppress removes the changes of the plan in path that match the
+	// project's suppressed changes from output and returns them.
+	Suppress(ctx command.ProjectContext, output string, path string, envs map[string]string) (string, []models.SuppressedChange, error)
+}
+
+// CostEstimateStepRunner runs cost_estimate steps.
+type CostEstimateStepRunner interface {
+	// Run estimates how the plan in path changes the monthly cost of the
+	// project and fails if it increases it by more than maxMonthlyIncrease
+	// or maxPercentIncrease. A limit of 0 means there's no limit.
+	Run(ctx command.ProjectContext, extraArgs []string, maxMonthlyIncrease float64, maxPercentIncrease float64, path string, envs map[string]string) (string, error)
+}
+
+// AssumeRoleStepRunner runs assume_role steps.
+type AssumeRoleStepRunner interface {
+	// Run assumes the AWS IAM role roleARN and sets its credentials in envs.
+	Run(ctx command.ProjectContext, roleARN string, sessionName string, durationSeconds int, path string, envs map[string]string) (string, error)
+}
+
 //go:generate pegomock generate --package mocks -o mocks/mock_webhooks_sender.go WebhooksSender
 
 // WebhooksSender sends webhook.
@@ -145,6 +189,16 @@ type ProjectImportCommandRunner interface {
 type ProjectStateCommandRunner interface {
 	// StateRm runs terraform state rm for the project described by ctx.
 	StateRm(ctx command.ProjectContext) command.ProjectResult
+	// StatePull runs terraform state pull for the project described by ctx.
+	StatePull(ctx command.ProjectContext) command.ProjectResult
+	// StatePush runs terraform state push for the project described by ctx.
+	StatePush(ctx command.ProjectContext) command.ProjectResult
+}
+
+type ProjectCustomCommandRunner interface {
+	// Custom runs the steps of a custom command for the project described by
+	// ctx.
+	Custom(ctx command.ProjectContext) command.ProjectResult
 }
 
 // ProjectCommandRunner runs project commands. A project command is a command
@@ -157,6 +211,7 @@ type ProjectCommandRunner interface {
 	ProjectVersionCommandRunner
 	ProjectImportCommandRunner
 	ProjectStateCommandRunner
+	ProjectCustomCommandRunner
 }
 
 //go:generate pegomock generate --package mocks -o mocks/mock_job_url_setter.go JobURLSetter
@@ -179,17 +234,46 @@ type ProjectOutputWrapper struct {
 	ProjectCommandRunner
 	JobMessageSender JobMessageSender
 	JobURLSetter     JobURLSetter
+	// JobURLGenerator generates the url to the job that's added to results so
+	// that comments can link to the full output. If nil, no url is added.
+	JobURLGenerator jobs.ProjectJobURLGenerator
 }
 
 func (p *ProjectOutputWrapper) Plan(ctx command.ProjectContext) command.ProjectResult {
+	start := time.Now()
 	result := p.updateProjectPRStatus(command.Plan, ctx, p.ProjectCommandRunner.Plan)
 	p.JobMessageSender.Send(ctx, "", OperationComplete)
-	return result
+	return p.addJobDetails(ctx, result, start)
 }
 
 func (p *ProjectOutputWrapper) Apply(ctx command.ProjectContext) command.ProjectResult {
+	start := time.Now()
 	result := p.updateProjectPRStatus(command.Apply, ctx, p.ProjectCommandRunner.Apply)
 	p.JobMessageSender.Send(ctx, "", OperationComplete)
+	return p.addJobDetails(ctx, result, start)
+}
+
+// Import streams the output of import to its job like Plan and Apply. It
+// doesn't create a commit status for the project since imports aren't
+// required to merge the pull request.
+func (p *ProjectOutputWrapper) Import(ctx command.ProjectContext) command.ProjectResult {
+	start := time.Now()
+	result := p.ProjectCommandRunner.Import(ctx)
+	p.JobMessageSender.Send(ctx, "", OperationComplete)
+	return p.addJobDetails(ctx, result, start)
+}
+
+// addJobDetails adds how long the command that started at start took and the
+// url to its job to result.
+func (p *ProjectOutputWrapper) addJobDetails(ctx command.ProjectContext, result command.ProjectResult, start time.Time) command.ProjectResult {
+	result.Duration = time.Since(start)
+	if p.JobURLGenerator != nil {
+		url, err := p.JobURLGenerator.GenerateProjectJobURL(ctx)
+		if err != nil {
+			ctx.Log.Warn("generating job url: %s", err)
+		}
+		result.JobURL = url
+	}
 	return result
 }
 
@@ -221,26 +305,64 @@ func (p *ProjectOutputWrapper) updateProjectPRStatus(commandName command.Name, c
 
 // DefaultProjectCommandRunner implements ProjectCommandRunner.
 type DefaultProjectCommandRunner struct {
-	VcsClient                 vcs.Client
-	Locker                    ProjectLocker
-	LockURLGenerator          LockURLGenerator
-	Logger                    logging.SimpleLogging
-	InitStepRunner            StepRunner
-	PlanStepRunner            StepRunner
-	ShowStepRunner            StepRunner
-	ApplyStepRunner           StepRunner
-	PolicyCheckStepRunner     StepRunner
-	VersionStepRunner         StepRunner
-	ImportStepRunner          StepRunner
-	StateRmStepRunner         StepRunner
-	RunStepRunner             CustomStepRunner
-	EnvStepRunner             EnvStepRunner
-	MultiEnvStepRunner        MultiEnvStepRunner
+	VcsClient             vcs.Client
+	Locker                ProjectLocker
+	LockURLGenerator      LockURLGenerator
+	Logger                logging.SimpleLogging
+	InitStepRunner        StepRunner
+	PlanStepRunner        StepRunner
+	ShowStepRunner        StepRunner
+	ApplyStepRunner       StepRunner
+	PolicyCheckStepRunner StepRunner
+	VersionStepRunner     StepRunner
+	ImportStepRunner      StepRunner
+	StateRmStepRunner     StepRunner
+	StatePullStepRunner   StepRunner
+	StatePushStepRunner   StepRunner
+	RunStepRunner         CustomStepRunner
+	EnvStepRunner         EnvStepRunner
+	MultiEnvStepRunner    MultiEnvStepRunner
+	// PluginStepRunner runs plugin steps. If nil, no step plugins are
+	// configured.
+	PluginStepRunner          PluginStepRunner
+	WorkspaceSelectStepRunner StepRunner
+	VarFileStepRunner         StepRunner
+	FormatCheckStepRunner     StepRunner
+	AssumeRoleStepRunner      AssumeRoleStepRunner
+	TerragruntStepRunner      TerragruntStepRunner
+	CostEstimateStepRunner    CostEstimateStepRunner
+	SecretResolver            SecretResolver
+	// PlanChangeSuppressor suppresses the changes that projects configure
+	// as noisy from their plan comments. If nil, no changes are suppressed.
+	PlanChangeSuppressor      PlanChangeSuppressor
 	PullApprovedChecker       runtime.PullApprovedChecker
 	WorkingDir                WorkingDir
 	Webhooks                  WebhooksSender
 	WorkingDirLocker          WorkingDirLocker
 	CommandRequirementHandler CommandRequirementHandler
+	// PlanCache is used to reuse the last plan of projects that haven't
+	// changed since they were planned. If nil, plans aren't cached.
+	PlanCache PlanCache
+	// UserMemberships looks up and caches the teams and organizations of
+	// policy owners. If nil, teams are looked up with VcsClient every time.
+	UserMemberships *UserMemberships
+	// PlanHistory stores the resource changes of each plan. If nil, the
+	// history isn't stored.
+	PlanHistory *PlanHistory
+	// PlanDiffComments adds how the resources changed by a plan differ from
+	// the previous plan to the plan's comment.
+	PlanDiffComments bool
+	// GithubEnvironmentGate gates the apply of projects deployed to a GitHub
+	// environment on its protection rules. If nil, those projects can't be
+	// applied.
+	GithubEnvironmentGate *GithubEnvironmentGate
+	// PlanStore stores plans so that they can be applied after a restart or
+	// by another Atlantis server. If nil, plans are only kept in the working
+	// dirs.
+	PlanStore PlanStore
+	// JobCanceler lets the jobs of projects be canceled while their steps
+	// run. If nil, they can't be canceled.
+	JobCanceler *jobs.JobCanceler
 }
 
 // Plan runs terraform plan for the project described by ctx.
@@ -313,6 +435,20 @@ func (p *DefaultProjectCommandRunner) Version(ctx command.ProjectContext) comman
 	}
 }
 
+// Custom runs the steps of a custom command for the project described by ctx.
+func (p *DefaultProjectCommandRunner) Custom(ctx command.ProjectContext) command.ProjectResult {
+	out, failure, err := p.doCustom(ctx)
+	return command.ProjectResult{
+		Command:       command.Custom,
+		Failure:       failure,
+		Error:         err,
+		CustomSuccess: out,
+		RepoRelDir:    ctx.RepoRelDir,
+		Workspace:     ctx.Workspace,
+		ProjectName:   ctx.ProjectName,
+	}
+}
+
 // Import runs terraform import for the project described by ctx.
 func (p *DefaultProjectCommandRunner) Import(ctx command.ProjectContext) command.ProjectResult {
 	importSuccess, failure, err := p.doImport(ctx)
@@ -342,6 +478,36 @@ func (p *DefaultProjectCommandRunner) StateRm(ctx command.ProjectContext) comman
 	}
 }
 
+// StatePull runs terraform state pull for the project described by ctx.
+func (p *DefaultProjectCommandRunner) StatePull(ctx command.ProjectContext) command.ProjectResult {
+	statePullSuccess, failure, err := p.doStatePull(ctx)
+	return command.ProjectResult{
+		Command:          command.State,
+		SubCommand:       "pull",
+		StatePullSuccess: statePullSuccess,
+		Error:            err,
+		Failure:          failure,
+		RepoRelDir:       ctx.RepoRelDir,
+		Workspace:        ctx.Workspace,
+		ProjectName:      ctx.ProjectName,
+	}
+}
+
+// StatePush runs terraform state push for the project described by ctx.
+func (p *DefaultProjectCommandRunner) StatePush(ctx command.ProjectContext) command.ProjectResult {
+	statePushSuccess, failure, err := p.doStatePush(ctx)
+	return command.ProjectResult{
+		Command:          command.State,
+		SubCommand:       "push",
+		StatePushSuccess: statePushSuccess,
+		Error:            err,
+		Failure:          failure,
+		RepoRelDir:       ctx.RepoRelDir,
+		Workspace:        ctx.Workspace,
+		ProjectName:      ctx.ProjectName,
+	}
+}
+
 func (p *DefaultProjectCommandRunner) doApprovePolicies(ctx command.ProjectContext) (*models.PolicyCheckResults, string, error) {
 	// Acquire Atlantis lock for this repo/dir/workspace.
 	lockAttempt, err := p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.User, ctx.Workspace, models.NewProject(ctx.Pull.BaseRepo.FullName, ctx.RepoRelDir, ctx.ProjectName), ctx.RepoLocksMode == valid.RepoLocksOnPlanMode)
@@ -360,21 +526,26 @@ func (p *DefaultProjectCommandRunner) doApprovePolicies(ctx command.ProjectConte
 	}
 	defer unlockFn()
 
-	teams := []string{}
-
 	policySetCfg := ctx.PolicySets
 
-	// Only query the users team membership if any teams have been configured as owners on any policy set(s).
-	if policySetCfg.HasTeamOwners() {
-		// A convenient way to access vcsClient. Not sure if best way.
-		userTeams, err := p.VcsClient.GetTeamNamesForUser(p.Logger, ctx.Pull.BaseRepo, ctx.User)
+	// The teams and organizations of the user are only looked up if they
+	// aren't an owner by username.
+	memberships := p.UserMemberships
+	if memberships == nil {
+		memberships = &UserMemberships{TeamNamesGetter: p.VcsClient}
+	}
+	isPolicyOwner := func(owners valid.PolicyOwners) (bool, error) {
+		allowlist := &UserAllowlist{Users: owners.Users, Teams: owners.Teams, Orgs: owners.Orgs, Memberships: memberships}
+		isOwner, err := allowlist.IsAllowed(ctx.Log, ctx.Pull.BaseRepo, ctx.User)
 		if err != nil {
 			ctx.Log.Err("unable to get team membership for user: %s", err)
-			return nil, "", err
 		}
-		teams = append(teams, userTeams...)
+		return isOwner, err
+	}
+	isAdmin, err := isPolicyOwner(policySetCfg.Owners)
+	if err != nil {
+		return nil, "", err
 	}
-	isAdmin := policySetCfg.Owners.IsOwner(ctx.User.Username, teams)
 
 	var failure string
 
@@ -383,13 +554,19 @@ func (p *DefaultProjectCommandRunner) doApprovePolicies(ctx command.ProjectConte
 	var prjErr error
 	allPassed := true
 	for _, policySet := range policySetCfg.PolicySets {
-		isOwner := policySet.Owners.IsOwner(ctx.User.Username, teams) || isAdmin
+		isOwner := isAdmin
+		if !isOwner {
+			if isOwner, err = isPolicyOwner(policySet.Owners); err != nil {
+				return nil, "", err
+			}
+		}
 		prjPolicyStatus := ctx.ProjectPolicyStatus
 		for i, policyStatus := range prjPolicyStatus {
 			ignorePolicy := false
 			if policySet.Name == policyStatus.PolicySetName {
+				reqApprovals := policyStatus.RequiredApprovals(policySet.ApproveCount)
 				// Policy set either passed or has sufficient approvals. Move on.
-				if policyStatus.Passed || (policyStatus.Approvals == policySet.ApproveCount) {
+				if policyStatus.Passed || (policyStatus.Approvals == reqApprovals) {
 					if !ctx.ClearPolicyApproval {
 						ignorePolicy = true
 					}
@@ -413,7 +590,7 @@ func (p *DefaultProjectCommandRunner) doApprovePolicies(ctx command.ProjectConte
 					prjErr = errors.Join(prjErr, fmt.Errorf("policy set: %s user %s is not a policy owner - please contact policy owners to approve failing policies", policySet.Name, ctx.User.Username))
 				}
 				// Still bubble up this failure, even if policy set is not targeted.
-				if !policyStatus.Passed && (prjPolicyStatus[i].Approvals != policySet.ApproveCount) {
+				if !policyStatus.Passed && (prjPolicyStatus[i].Approvals != reqApprovals) {
 					allPassed = false
 				}
 
@@ -421,7 +598,7 @@ func (p *DefaultProjectCommandRunner) doApprovePolicies(ctx command.ProjectConte
 					PolicySetName: policySet.Name,
 					Passed:        policyStatus.Passed,
 					CurApprovals:  prjPolicyStatus[i].Approvals,
-					ReqApprovals:  policySet.ApproveCount,
+					ReqApprovals:  reqApprovals,
 				})
 			}
 		}
@@ -435,6 +612,7 @@ func (p *DefaultProjectCommandRunner) doApprovePolicies(ctx command.ProjectConte
 		ApplyCmd:           ctx.ApplyCmd,
 		RePlanCmd:          ctx.RePlanCmd,
 		ApprovePoliciesCmd: ctx.ApprovePoliciesCmd,
+		UnlockCmd:          ctx.UnlockCmd,
 	}, failure, prjErr
 }
 
@@ -491,9 +669,16 @@ func (p *DefaultProjectCommandRunner) doPolicyCheck(ctx command.ProjectContext)
 	}
 
 	var failure string
-	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	outputs, err := p.runProjectSteps(ctx, absPath, func() ([]string, error) {
+		return p.runSteps(ctx.Steps, ctx, absPath)
+	})
 	var errs error
 	if err != nil {
+		if failure = timeoutFailure(ctx, err, outputs); failure != "" {
+			return nil, failure, nil
+		}
 		for {
 			err = errors.Unwrap(err)
 			if err == nil {
@@ -549,6 +734,7 @@ func (p *DefaultProjectCommandRunner) doPolicyCheck(ctx command.ProjectContext)
 		RePlanCmd:          ctx.RePlanCmd,
 		ApplyCmd:           ctx.ApplyCmd,
 		ApprovePoliciesCmd: ctx.ApprovePoliciesCmd,
+		UnlockCmd:          ctx.UnlockCmd,
 	}
 
 	// Using this function instead of catching failed policy runs with errors, for cases when '--no-fail' is passed to conftest.
@@ -599,24 +785,276 @@ func (p *DefaultProjectCommandRunner) doPlan(ctx command.ProjectContext) (*model
 		return nil, failure, err
 	}
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
+	var cached bool
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	outputs, err := p.runProjectSteps(ctx, projAbsPath, func() (outputs []string, err error) {
+		outputs, cached, err = p.runPlanSteps(ctx, repoDir, projAbsPath)
+		return outputs, err
+	})
 
 	if err != nil {
 		if unlockErr := lockAttempt.UnlockFn(); unlockErr != nil {
 			ctx.Log.Err("error unlocking state after plan error: %v", unlockErr)
 		}
+		if failure = timeoutFailure(ctx, err, outputs); failure != "" {
+			return nil, failure, nil
+		}
 		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
 
+	p.storeShowOutputs(ctx, projAbsPath)
+	p.storePlan(ctx, projAbsPath, lockAttempt.LockAcquired && ctx.RepoLocksMode == valid.RepoLocksOnPlanMode)
+
+	output := strings.Join(outputs, "\n")
+	var changesetDiff *models.PlanChangesetDiff
+	if p.PlanHistory != nil {
+		diff, err := p.PlanHistory.Add(ctx, output)
+		if err != nil {
+			ctx.Log.Warn("adding plan to plan history: %s", err)
+		} else if p.PlanDiffComments {
+			changesetDiff = diff
+		}
+	}
+
+	// Changes are suppressed after the plan is added to the history so that
+	// it has all of them. If they can't be, the whole output is shown.
+	var suppressed []models.SuppressedChange
+	if p.PlanChangeSuppressor != nil && len(ctx.SuppressedChanges) > 0 {
+		filtered, changes, err := p.PlanChangeSuppressor.Suppress(ctx, output, projAbsPath, ctx.Envs)
+		if err != nil {
+			ctx.Log.Warn("suppressing plan changes: %s", err)
+		} else {
+			output, suppressed = filtered, changes
+		}
+	}
+
+	var tfVersion string
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion.String()
+	}
 	return &models.PlanSuccess{
-		LockURL:         p.LockURLGenerator.GenerateLockURL(lockAttempt.LockKey),
-		TerraformOutput: strings.Join(outputs, "\n"),
-		RePlanCmd:       ctx.RePlanCmd,
-		ApplyCmd:        ctx.ApplyCmd,
-		MergedAgain:     mergedAgain,
+		LockURL:           p.LockURLGenerator.GenerateLockURL(lockAttempt.LockKey),
+		TerraformOutput:   output,
+		RePlanCmd:         ctx.RePlanCmd,
+		ApplyCmd:          ctx.ApplyCmd,
+		UnlockCmd:         ctx.UnlockCmd,
+		MergedAgain:       mergedAgain,
+		Cached:            cached,
+		ChangesetDiff:     changesetDiff,
+		CostEstimate:      p.readCostEstimate(ctx, projAbsPath),
+		SuppressedChanges: suppressed,
+		TerraformVersion:  tfVersion,
 	}, "", nil
 }
 
+// readCostEstimate returns the cost estimate of the plan of the project in
+// ctx if its workflow has a cost_estimate step, or nil. An estimate that's
+// older than the plan, ex. because the step was skipped this time, isn't
+// returned.
+func (p *DefaultProjectCommandRunner) readCostEstimate(ctx command.ProjectContext, projAbsPath string) *models.CostEstimate {
+	hasStep := false
+	for _, step := range ctx.Steps {
+		if step.StepName == "cost_estimate" {
+			hasStep = true
+			break
+		}
+	}
+	if !hasStep {
+		return nil
+	}
+	costFile := filepath.Join(projAbsPath, ctx.GetCostEstimateFileName())
+	costInfo, err := os.Stat(costFile)
+	if err != nil {
+		return nil
+	}
+	planInfo, err := os.Stat(filepath.Join(projAbsPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName)))
+	if err != nil || costInfo.ModTime().Before(planInfo.ModTime()) {
+		return nil
+	}
+	estimate, err := runtime.ReadCostEstimate(costFile)
+	if err != nil {
+		ctx.Log.Warn("reading cost estimate: %s", err)
+		return nil
+	}
+	return estimate
+}
+
+// storeShowOutputs runs the show step on the plan of the project in ctx if its
+// workflow doesn't, so that the JSON and text output of terraform show are
+// stored for every plan and tools can read them without running show again.
+func (p *DefaultProjectCommandRunner) storeShowOutputs(ctx command.ProjectContext, projAbsPath string) {
+	if p.ShowStepRunner == nil {
+		return
+	}
+	for _, step := range ctx.Steps {
+		if step.StepName == "show" {
+			return
+		}
+	}
+	if _, err := p.ShowStepRunner.Run(ctx, nil, projAbsPath, ctx.Envs); err != nil {
+		ctx.Log.Warn("storing terraform show output: %s", err)
+	}
+}
+
+// storePlan stores the plan of the project in ctx in the plan store, if any.
+// locked is true if the project was locked when it was planned.
+func (p *DefaultProjectCommandRunner) storePlan(ctx command.ProjectContext, projAbsPath string, locked bool) {
+	if p.PlanStore == nil {
+		return
+	}
+	planFilename := runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName)
+	if _, err := os.Stat(filepath.Join(projAbsPath, planFilename)); err != nil {
+		// Some workflows, ex. ones that plan remotely, don't write plans.
+		return
+	}
+	meta := PlanMetadata{
+		RepoFullName: ctx.Pull.BaseRepo.FullName,
+		PullNum:      ctx.Pull.Num,
+		HeadCommit:   ctx.Pull.HeadCommit,
+		ProjectName:  ctx.ProjectName,
+		Workspace:    ctx.Workspace,
+		RepoRelDir:   ctx.RepoRelDir,
+		User:         ctx.User.Username,
+		Locked:       locked,
+		PlannedAt:    time.Now(),
+	}
+	if err := p.PlanStore.Save(meta, projAbsPath, planFilename); err != nil {
+		ctx.Log.Warn("storing plan: %s", err)
+	}
+}
+
+// storedPlan returns the metadata of the stored plan of the project in ctx if
+// it was planned on the head commit of the pull request.
+func (p *DefaultProjectCommandRunner) storedPlan(ctx command.ProjectContext) (PlanMetadata, bool) {
+	if p.PlanStore == nil {
+		return PlanMetadata{}, false
+	}
+	meta, ok, err := p.PlanStore.Metadata(ctx.Pull, ctx.Workspace, ctx.RepoRelDir, ctx.ProjectName)
+	if err != nil {
+		ctx.Log.Warn("getting stored plan: %s", err)
+		return PlanMetadata{}, false
+	}
+	return meta, ok && meta.HeadCommit == ctx.Pull.HeadCommit
+}
+
+// initRestoredProject runs terraform init in the project in ctx if it was
+// cloned to restore its plan, since applying the plan needs the providers
+// and modules that init installs.
+func (p *DefaultProjectCommandRunner) initRestoredProject(ctx command.ProjectContext, absPath string) error {
+	hasApplyStep := false
+	for _, step := range ctx.Steps {
+		if step.StepName == "apply" {
+			hasApplyStep = true
+			break
+		}
+	}
+	if !hasApplyStep {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(absPath, ".terraform")); !os.IsNotExist(err) {
+		return nil
+	}
+	ctx.Log.Info("running init before applying the restored plan")
+	if out, err := p.InitStepRunner.Run(ctx, nil, absPath, ctx.Envs); err != nil {
+		return fmt.Errorf("running init before applying the restored plan: %s\n%s", err, out)
+	}
+	return nil
+}
+
+// runPlanSteps runs the plan steps of the project in ctx. If plans are cached
+// and the project hasn't changed since it was last planned, its cached plan is
+// restored instead of running the plan step and true is returned.
+func (p *DefaultProjectCommandRunner) runPlanSteps(ctx command.ProjectContext, repoDir string, projAbsPath string) ([]string, bool, error) {
+	initSteps, planSteps, ok := splitCacheablePlanSteps(ctx.Steps)
+	if p.PlanCache == nil || ctx.ForcePlan || !ok {
+		outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
+		return outputs, false, err
+	}
+
+	// Init is run even if the plan is cached since applying the plan needs
+	// the providers and modules that it installs. The key is computed after
+	// init since it includes the lock file that init can write, and the
+	// state which can only be pulled once the backend is initialized.
+	outputs, err := p.runSteps(initSteps, ctx, projAbsPath)
+	if err != nil {
+		return outputs, false, err
+	}
+	state, err := p.pullState(ctx, projAbsPath)
+	if err != nil {
+		ctx.Log.Warn("not caching plan: pulling state: %s", err)
+		planOutputs, err := p.runSteps(planSteps, ctx, projAbsPath)
+		return append(outputs, planOutputs...), false, err
+	}
+	key, err := p.PlanCache.Key(ctx, repoDir, state)
+	if err != nil {
+		ctx.Log.Warn("not caching plan: %s", err)
+		planOutputs, err := p.runSteps(planSteps, ctx, projAbsPath)
+		return append(outputs, planOutputs...), false, err
+	}
+	cachedOutput, cached, err := p.PlanCache.Get(ctx, repoDir, key)
+	if err != nil {
+		ctx.Log.Warn("reading cached plan: %s", err)
+	}
+	if cached {
+		ctx.Log.Info("reusing the cached plan since the project hasn't changed since it was last planned")
+		return append(outputs, cachedOutput), true, nil
+	}
+
+	planOutputs, err := p.runSteps(planSteps, ctx, projAbsPath)
+	outputs = append(outputs, planOutputs...)
+	if err != nil {
+		return outputs, false, err
+	}
+	if err := p.PlanCache.Put(ctx, repoDir, key, strings.Join(planOutputs, "\n")); err != nil {
+		ctx.Log.Warn("caching plan: %s", err)
+	}
+	return outputs, false, nil
+}
+
+// pullState returns a summary of the state of the project in ctx, which
+// changes whenever the state is written to, ex. by an apply outside of
+// Atlantis.
+func (p *DefaultProjectCommandRunner) pullState(ctx command.ProjectContext, projAbsPath string) (string, error) {
+	if p.StatePullStepRunner == nil {
+		return "", errors.New("state pull isn't configured")
+	}
+	envs := make(map[string]string)
+	for name, value := range ctx.Envs {
+		envs[name] = value
+	}
+	return p.StatePullStepRunner.Run(ctx, nil, projAbsPath, envs)
+}
+
+// splitCacheablePlanSteps splits steps into its init and plan steps. It
+// returns false if steps can't be cached because they include other steps,
+// ex. custom run steps whose output can't be reused, or the init steps don't
+// all come first.
+func splitCacheablePlanSteps(steps []valid.Step) (initSteps []valid.Step, planSteps []valid.Step, ok bool) {
+	for _, step := range steps {
+		switch {
+		case step.StepName == "init" && len(planSteps) == 0:
+			initSteps = append(initSteps, step)
+		case step.StepName == "plan":
+			planSteps = append(planSteps, step)
+		default:
+			return nil, nil, false
+		}
+	}
+	return initSteps, planSteps, len(planSteps) > 0
+}
+
+// deleteCachedPlan deletes the cached plan of the project in ctx since it's
+// stale once the project's state has been changed.
+func (p *DefaultProjectCommandRunner) deleteCachedPlan(ctx command.ProjectContext) {
+	if p.PlanCache == nil {
+		return
+	}
+	if err := p.PlanCache.DeleteForProject(ctx); err != nil {
+		ctx.Log.Warn("deleting cached plan: %s", err)
+	}
+}
+
 func (p *DefaultProjectCommandRunner) doApply(ctx command.ProjectContext) (applyOut string, failure string, err error) {
 	repoDir, err := p.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace)
 	if err != nil {
@@ -640,12 +1078,29 @@ func (p *DefaultProjectCommandRunner) doApply(ctx command.ProjectContext) (apply
 		return "", failure, err
 	}
 
-	// Acquire Atlantis lock for this repo/dir/workspace.
-	lockAttempt, err := p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.User, ctx.Workspace, models.NewProject(ctx.Pull.BaseRepo.FullName, ctx.RepoRelDir, ctx.ProjectName), ctx.RepoLocksMode == valid.RepoLocksOnApplyMode)
+	var deploymentID int64
+	if ctx.GithubEnvironment != nil {
+		if p.GithubEnvironmentGate == nil || ctx.Pull.BaseRepo.VCSHost.Type != models.Github {
+			return "", fmt.Sprintf("This project is deployed to GitHub environment %q, which requires Atlantis to be configured for GitHub.", ctx.GithubEnvironment.Name), nil
+		}
+		deploymentID, failure, err = p.GithubEnvironmentGate.Wait(ctx)
+		if failure != "" || err != nil {
+			return "", failure, err
+		}
+	}
+
+	// Acquire Atlantis lock for this repo/dir/workspace. A restored plan
+	// locks the project again if it was locked when it was planned since the
+	// lock may have been lost with the plan.
+	storedPlan, hasStoredPlan := p.storedPlan(ctx)
+	repoLocking := ctx.RepoLocksMode == valid.RepoLocksOnApplyMode || (hasStoredPlan && storedPlan.Locked)
+	lockAttempt, err := p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.User, ctx.Workspace, models.NewProject(ctx.Pull.BaseRepo.FullName, ctx.RepoRelDir, ctx.ProjectName), repoLocking)
 	if err != nil {
+		p.finishDeployment(ctx, deploymentID, err)
 		return "", "", fmt.Errorf("acquiring lock: %w", err)
 	}
 	if !lockAttempt.LockAcquired {
+		p.finishDeployment(ctx, deploymentID, errors.New(lockAttempt.LockFailureReason))
 		return "", lockAttempt.LockFailureReason, nil
 	}
 	ctx.Log.Debug("acquired lock for project")
@@ -653,11 +1108,27 @@ func (p *DefaultProjectCommandRunner) doApply(ctx command.ProjectContext) (apply
 	// Acquire internal lock for the directory we're going to operate in.
 	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
 	if err != nil {
+		p.finishDeployment(ctx, deploymentID, err)
 		return "", "", err
 	}
 	defer unlockFn()
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	p.deleteCachedPlan(ctx)
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	outputs, err := p.runProjectSteps(ctx, absPath, func() ([]string, error) {
+		if hasStoredPlan {
+			if err := p.initRestoredProject(ctx, absPath); err != nil {
+				return nil, err
+			}
+		}
+		return p.runSteps(ctx.Steps, ctx, absPath)
+	})
+	if err == nil && hasStoredPlan {
+		if err := p.PlanStore.DeleteForProject(ctx.Pull, ctx.Workspace, ctx.RepoRelDir, ctx.ProjectName); err != nil {
+			ctx.Log.Warn("deleting stored plan: %s", err)
+		}
+	}
 
 	p.Webhooks.Send(ctx.Log, webhooks.ApplyResult{ // nolint: errcheck
 		Workspace:   ctx.Workspace,
@@ -668,14 +1139,27 @@ func (p *DefaultProjectCommandRunner) doApply(ctx command.ProjectContext) (apply
 		Directory:   ctx.RepoRelDir,
 		ProjectName: ctx.ProjectName,
 	})
+	p.finishDeployment(ctx, deploymentID, err)
 
 	if err != nil {
+		if failure = timeoutFailure(ctx, err, outputs); failure != "" {
+			return "", failure, nil
+		}
 		return "", "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
 
 	return strings.Join(outputs, "\n"), "", nil
 }
 
+// finishDeployment sets the status of the GitHub deployment with
+// deploymentID created to apply the project, if any.
+func (p *DefaultProjectCommandRunner) finishDeployment(ctx command.ProjectContext, deploymentID int64, applyErr error) {
+	if deploymentID == 0 {
+		return
+	}
+	p.GithubEnvironmentGate.Finish(ctx, deploymentID, applyErr)
+}
+
 func (p *DefaultProjectCommandRunner) doVersion(ctx command.ProjectContext) (versionOut string, failure string, err error) {
 	repoDir, err := p.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace)
 	if err != nil {
@@ -696,8 +1180,50 @@ func (p *DefaultProjectCommandRunner) doVersion(ctx command.ProjectContext) (ver
 	}
 	defer unlockFn()
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	outputs, err := p.runProjectSteps(ctx, absPath, func() ([]string, error) {
+		return p.runSteps(ctx.Steps, ctx, absPath)
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+	}
+
+	return strings.Join(outputs, "\n"), "", nil
+}
+
+func (p *DefaultProjectCommandRunner) doCustom(ctx command.ProjectContext) (out string, failure string, err error) {
+	repoDir, err := p.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", "", errors.New("project has not been cloned–did you run plan?")
+		}
+		return "", "", err
+	}
+	absPath := filepath.Join(repoDir, ctx.RepoRelDir)
+	if _, err = os.Stat(absPath); os.IsNotExist(err) {
+		return "", "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
+	}
+
+	failure, err = p.CommandRequirementHandler.ValidateCustomProject(repoDir, ctx)
+	if failure != "" || err != nil {
+		return "", failure, err
+	}
+
+	// Acquire internal lock for the directory we're going to operate in.
+	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
+	if err != nil {
+		return "", "", err
+	}
+	defer unlockFn()
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	outputs, err := p.runProjectSteps(ctx, absPath, func() ([]string, error) {
+		return p.runSteps(ctx.Steps, ctx, absPath)
+	})
 	if err != nil {
+		if failure = timeoutFailure(ctx, err, outputs); failure != "" {
+			return "", failure, nil
+		}
 		return "", "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
 
@@ -737,7 +1263,10 @@ func (p *DefaultProjectCommandRunner) doImport(ctx command.ProjectContext) (out
 	}
 	defer unlockFn()
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
+	p.deleteCachedPlan(ctx)
+	outputs, err := p.runProjectSteps(ctx, projAbsPath, func() ([]string, error) {
+		return p.runSteps(ctx.Steps, ctx, projAbsPath)
+	})
 	if err != nil {
 		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
@@ -761,6 +1290,11 @@ func (p *DefaultProjectCommandRunner) doStateRm(ctx command.ProjectContext) (out
 		return nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
 	}
 
+	failure, err = p.CommandRequirementHandler.ValidateStateProject(repoDir, ctx)
+	if failure != "" || err != nil {
+		return nil, failure, err
+	}
+
 	// Acquire Atlantis lock for this repo/dir/workspace.
 	lockAttempt, err := p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.User, ctx.Workspace, models.NewProject(ctx.Pull.BaseRepo.FullName, ctx.RepoRelDir, ctx.ProjectName), ctx.RepoLocksMode != valid.RepoLocksDisabledMode)
 	if err != nil {
@@ -778,7 +1312,10 @@ func (p *DefaultProjectCommandRunner) doStateRm(ctx command.ProjectContext) (out
 	}
 	defer unlockFn()
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
+	p.deleteCachedPlan(ctx)
+	outputs, err := p.runProjectSteps(ctx, projAbsPath, func() ([]string, error) {
+		return p.runSteps(ctx.Steps, ctx, projAbsPath)
+	})
 	if err != nil {
 		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
@@ -791,48 +1328,401 @@ func (p *DefaultProjectCommandRunner) doStateRm(ctx command.ProjectContext) (out
 	}, "", nil
 }
 
+func (p *DefaultProjectCommandRunner) doStatePull(ctx command.ProjectContext) (out *models.StatePullSuccess, failure string, err error) {
+	// Clone is idempotent so okay to run even if the repo was already cloned.
+	repoDir, _, cloneErr := p.WorkingDir.Clone(ctx.Log, ctx.HeadRepo, ctx.Pull, ctx.Workspace)
+	if cloneErr != nil {
+		return nil, "", cloneErr
+	}
+	projAbsPath := filepath.Join(repoDir, ctx.RepoRelDir)
+	if _, err = os.Stat(projAbsPath); os.IsNotExist(err) {
+		return nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
+	}
+
+	failure, err = p.CommandRequirementHandler.ValidateStateProject(repoDir, ctx)
+	if failure != "" || err != nil {
+		return nil, failure, err
+	}
+
+	// State pull doesn't change anything so it doesn't take the Atlantis
+	// lock, only the internal lock for the directory since init runs in it.
+	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlockFn()
+
+	outputs, err := p.runProjectSteps(ctx, projAbsPath, func() ([]string, error) {
+		return p.runSteps(ctx.Steps, ctx, projAbsPath)
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+	}
+	return &models.StatePullSuccess{
+		Output: strings.Join(outputs, "\n"),
+	}, "", nil
+}
+
+func (p *DefaultProjectCommandRunner) doStatePush(ctx command.ProjectContext) (out *models.StatePushSuccess, failure string, err error) {
+	// Clone is idempotent so okay to run even if the repo was already cloned.
+	repoDir, _, cloneErr := p.WorkingDir.Clone(ctx.Log, ctx.HeadRepo, ctx.Pull, ctx.Workspace)
+	if cloneErr != nil {
+		return nil, "", cloneErr
+	}
+	projAbsPath := filepath.Join(repoDir, ctx.RepoRelDir)
+	if _, err = os.Stat(projAbsPath); os.IsNotExist(err) {
+		return nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
+	}
+
+	failure, err = p.CommandRequirementHandler.ValidateStateProject(repoDir, ctx)
+	if failure != "" || err != nil {
+		return nil, failure, err
+	}
+
+	// Acquire Atlantis lock for this repo/dir/workspace.
+	lockAttempt, err := p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.User, ctx.Workspace, models.NewProject(ctx.Pull.BaseRepo.FullName, ctx.RepoRelDir, ctx.ProjectName), ctx.RepoLocksMode != valid.RepoLocksDisabledMode)
+	if err != nil {
+		return nil, "", fmt.Errorf("acquiring lock: %w", err)
+	}
+	if !lockAttempt.LockAcquired {
+		return nil, lockAttempt.LockFailureReason, nil
+	}
+	ctx.Log.Debug("acquired lock for project")
+
+	// Acquire internal lock for the directory we're going to operate in.
+	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlockFn()
+
+	p.deleteCachedPlan(ctx)
+	outputs, err := p.runProjectSteps(ctx, projAbsPath, func() ([]string, error) {
+		return p.runSteps(ctx.Steps, ctx, projAbsPath)
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+	}
+
+	// after state push, re-plan command is required without state push args
+	rePlanCmd := strings.TrimSpace(strings.Split(ctx.RePlanCmd, "--")[0])
+	return &models.StatePushSuccess{
+		Output:    strings.Join(outputs, "\n"),
+		RePlanCmd: rePlanCmd,
+	}, "", nil
+}
+
+// runProjectSteps runs the project's pre workflow hooks, then run and then its
+// post workflow hooks. Post workflow hooks run even if run or the pre workflow
+// hooks failed so they can clean up, and their errors are only logged.
+func (p *DefaultProjectCommandRunner) runProjectSteps(ctx command.ProjectContext, absPath string, run func() ([]string, error)) ([]string, error) {
+	var outputs []string
+	err := p.runProjectWorkflowHooks(ctx, ctx.PreWorkflowHooks, absPath, "")
+	if err != nil {
+		err = fmt.Errorf("running project pre workflow hook: %w", err)
+	} else {
+		outputs, err = run()
+	}
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	// Post workflow hooks are run even if the steps timed out.
+	ctx.TimeoutCtx = nil
+	if hookErr := p.runProjectWorkflowHooks(ctx, ctx.PostWorkflowHooks, absPath, result); hookErr != nil {
+		ctx.Log.Warn("running project post workflow hook: %s", hookErr)
+	}
+	return outputs, err
+}
+
+// withTimeout returns ctx with a TimeoutCtx that's done once the project's
+// steps have run for its timeout or its job is canceled, and the func that
+// releases it.
+func (p *DefaultProjectCommandRunner) withTimeout(ctx command.ProjectContext) (command.ProjectContext, context.CancelFunc) {
+	parent, release := context.Background(), func() {}
+	if p.JobCanceler != nil && ctx.JobID != "" {
+		parent, release = p.JobCanceler.Start(ctx.JobID)
+		ctx.TimeoutCtx = parent
+	}
+	if ctx.Timeout == 0 {
+		return ctx, release
+	}
+	timeoutCtx, cancel := context.WithTimeout(parent, ctx.Timeout)
+	ctx.TimeoutCtx = timeoutCtx
+	return ctx, func() {
+		cancel()
+		release()
+	}
+}
+
+// timeoutFailure returns the failure of a command whose steps failed with
+// err because they timed out or their job was canceled, with the output they
+// had before they were canceled, or an empty string if they weren't
+// canceled.
+func timeoutFailure(ctx command.ProjectContext, err error, outputs []string) string {
+	if ctx.TimeoutCtx == nil {
+		return ""
+	}
+	var reason string
+	switch {
+	case errors.Is(context.Cause(ctx.TimeoutCtx), jobs.ErrJobCanceled):
+		reason = "Job was canceled"
+	case errors.Is(ctx.TimeoutCtx.Err(), context.DeadlineExceeded):
+		reason = fmt.Sprintf("Timed out after %s and was canceled", ctx.Timeout)
+	default:
+		return ""
+	}
+	return fmt.Sprintf("%s. Output before it was canceled:\n```\n%s\n%s\n```", reason, err, strings.Join(outputs, "\n"))
+}
+
+// runProjectWorkflowHooks runs hooks in the project's directory like custom
+// run steps. Their output is streamed but not added to the command's output.
+// result is the result of the project's steps for post workflow hooks.
+func (p *DefaultProjectCommandRunner) runProjectWorkflowHooks(ctx command.ProjectContext, hooks []*valid.WorkflowHook, absPath string, result string) error {
+	vars := projectExprVars(ctx)
+	vars.Result = result
+	for _, hook := range hooks {
+		if hook.Commands != "" && !strings.Contains(hook.Commands, ctx.CommandName.String()) {
+			ctx.Log.Debug("skipping project workflow hook %q as command %q is not in commands [%s]", hook.RunCommand, ctx.CommandName, hook.Commands)
+			continue
+		}
+		run, err := expr.Eval(hook.When, vars)
+		if err != nil {
+			return err
+		}
+		if !run {
+			ctx.Log.Debug("skipping project workflow hook %q as %s is false", hook.RunCommand, hook.When)
+			continue
+		}
+
+		envs := make(map[string]string, len(ctx.Envs)+1)
+		for name, value := range ctx.Envs {
+			envs[name] = value
+		}
+		if result != "" {
+			envs["COMMAND_RESULT"] = result
+		}
+		var shell *valid.CommandShell
+		if hook.Shell != "" {
+			shell = &valid.CommandShell{Shell: hook.Shell, ShellArgs: []string{"-c"}}
+			if hook.ShellArgs != "" {
+				shell.ShellArgs = strings.Split(hook.ShellArgs, " ")
+			}
+		}
+
+		if _, err := p.RunStepRunner.Run(ctx, shell, hook.RunCommand, absPath, envs, true, valid.PostProcessRunOutputShow); err != nil {
+			name := hook.StepDescription
+			if name == "" {
+				name = hook.RunCommand
+			}
+			return fmt.Errorf("%q: %w", name, err)
+		}
+	}
+	return nil
+}
+
 func (p *DefaultProjectCommandRunner) runSteps(steps []valid.Step, ctx command.ProjectContext, absPath string) ([]string, error) {
 	var outputs []string
 
 	envs := make(map[string]string)
+	for name, value := range ctx.Envs {
+		envs[name] = value
+	}
+	// The values env steps read from secret providers are redacted from the
+	// output of the steps, both streamed and commented.
 	for _, step := range steps {
-		var out string
-		var err error
-		switch step.StepName {
-		case "init":
-			out, err = p.InitStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "plan":
-			out, err = p.PlanStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "show":
-			_, err = p.ShowStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "policy_check":
-			out, err = p.PolicyCheckStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "apply":
-			out, err = p.ApplyStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "version":
-			out, err = p.VersionStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "import":
-			out, err = p.ImportStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "state_rm":
-			out, err = p.StateRmStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "run":
-			out, err = p.RunStepRunner.Run(ctx, step.RunShell, step.RunCommand, absPath, envs, true, step.Output)
-		case "env":
-			out, err = p.EnvStepRunner.Run(ctx, step.RunShell, step.RunCommand, step.EnvVarValue, absPath, envs)
-			envs[step.EnvVarName] = out
-			// We reset out to the empty string because we don't want it to
-			// be printed to the PR, it's solely to set the environment variable.
-			out = ""
-		case "multienv":
-			out, err = p.MultiEnvStepRunner.Run(ctx, step.RunShell, step.RunCommand, absPath, envs, step.Output)
+		if step.EnvVarValueFrom != nil {
+			ctx.Secrets = &command.SecretRedactor{}
+			break
+		}
+	}
+	for _, step := range steps {
+		if step.When != "" {
+			run, err := expr.Eval(step.When, projectExprVars(ctx))
+			if err != nil {
+				return outputs, fmt.Errorf("%s step: %w", step.StepName, err)
+			}
+			if !run {
+				ctx.Log.Debug("skipping %s step as %s is false", step.StepName, step.When)
+				continue
+			}
 		}
 
+		out, err := p.runStepWithRetries(ctx, step, absPath, envs)
 		if out != "" {
-			outputs = append(outputs, out)
+			outputs = append(outputs, ctx.Secrets.Redact(out))
 		}
 		if err != nil {
+			if redacted := ctx.Secrets.Redact(err.Error()); redacted != err.Error() {
+				err = errors.New(redacted)
+			}
 			return outputs, err
 		}
 	}
 	return outputs, nil
 }
+
+// runStepWithRetries runs step with its timeout, and runs it again while it
+// fails with one of the errors of its retry policy.
+func (p *DefaultProjectCommandRunner) runStepWithRetries(ctx command.ProjectContext, step valid.Step, absPath string, envs map[string]string) (string, error) {
+	var backoff time.Duration
+	if step.Retry != nil {
+		backoff = step.Retry.Backoff
+	}
+	for attempt := 1; ; attempt++ {
+		stepCtx, cancel := withStepTimeout(ctx, step.Timeout)
+		start := time.Now()
+		out, err := p.runStep(stepCtx, step, absPath, envs)
+		runtime.EmitStepMetrics(ctx, step.StepName, time.Since(start), out, err)
+		if err != nil && step.Timeout > 0 && errors.Is(stepCtx.TimeoutCtx.Err(), context.DeadlineExceeded) &&
+			(ctx.TimeoutCtx == nil || ctx.TimeoutCtx.Err() == nil) {
+			err = fmt.Errorf("%s step timed out after %s and was canceled: %w", step.StepName, step.Timeout, err)
+		}
+		cancel()
+
+		if err == nil || step.Retry == nil || attempt >= step.Retry.MaxAttempts || !step.Retry.Retryable(out, err) {
+			return out, err
+		}
+		// Steps aren't retried once the project's steps timed out or its
+		// job was canceled.
+		if ctx.TimeoutCtx != nil && ctx.TimeoutCtx.Err() != nil {
+			return out, err
+		}
+		ctx.Log.Warn("%s step failed with a retryable error, retrying in %s (attempt %d of %d): %s",
+			step.StepName, backoff, attempt+1, step.Retry.MaxAttempts, ctx.Secrets.Redact(err.Error()))
+		if !waitForRetry(ctx, backoff) {
+			return out, err
+		}
+		backoff *= 2
+	}
+}
+
+// waitForRetry waits for backoff before a step is retried. It returns false
+// if the project's steps time out or its job is canceled before then.
+func waitForRetry(ctx command.ProjectContext, backoff time.Duration) bool {
+	if ctx.TimeoutCtx == nil {
+		time.Sleep(backoff)
+		return true
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.TimeoutCtx.Done():
+		return false
+	}
+}
+
+// runStep runs step in absPath. Env steps set their env var in envs.
+func (p *DefaultProjectCommandRunner) runStep(ctx command.ProjectContext, step valid.Step, absPath string, envs map[string]string) (string, error) {
+	var out string
+	var err error
+	switch step.StepName {
+	case "init":
+		out, err = p.InitStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "plan":
+		out, err = p.PlanStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "show":
+		_, err = p.ShowStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "policy_check":
+		out, err = p.PolicyCheckStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "apply":
+		out, err = p.ApplyStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "version":
+		out, err = p.VersionStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "import":
+		out, err = p.ImportStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "state_rm":
+		out, err = p.StateRmStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "state_pull":
+		out, err = p.StatePullStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "state_push":
+		out, err = p.StatePushStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "run":
+		out, err = p.RunStepRunner.Run(ctx, step.RunShell, step.RunCommand, absPath, envs, true, step.Output)
+	case "env":
+		if step.EnvVarValueFrom != nil {
+			out, err = p.resolveSecret(ctx, *step.EnvVarValueFrom, absPath, envs)
+		} else {
+			out, err = p.EnvStepRunner.Run(ctx, step.RunShell, step.RunCommand, step.EnvVarValue, absPath, envs)
+		}
+		envs[step.EnvVarName] = out
+		// We reset out to the empty string because we don't want it to
+		// be printed to the PR, it's solely to set the environment variable.
+		out = ""
+	case "multienv":
+		out, err = p.MultiEnvStepRunner.Run(ctx, step.RunShell, step.RunCommand, absPath, envs, step.Output)
+	case "plugin":
+		if p.PluginStepRunner == nil {
+			err = fmt.Errorf("no step plugins are configured, the %q step plugin can't be run", step.PluginName)
+			break
+		}
+		out, err = p.PluginStepRunner.Run(ctx, step.PluginName, step.ExtraArgs, absPath, envs)
+	case "workspace_select":
+		out, err = p.WorkspaceSelectStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "var_file":
+		out, err = p.VarFileStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "format_check":
+		out, err = p.FormatCheckStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "assume_role":
+		out, err = p.AssumeRoleStepRunner.Run(ctx, step.RoleARN, step.RoleSessionName, step.RoleDurationSeconds, absPath, envs)
+	case "terragrunt":
+		out, err = p.TerragruntStepRunner.Run(ctx, step.ExtraArgs, step.TerragruntRunAll, absPath, envs)
+	case "cost_estimate":
+		out, err = p.CostEstimateStepRunner.Run(ctx, step.ExtraArgs, step.CostMaxMonthlyIncrease, step.CostMaxPercentIncrease, absPath, envs)
+	}
+	return out, err
+}
+
+// resolveSecret returns the value of secret and adds it to the secrets that
+// are redacted from the output of the project's steps.
+func (p *DefaultProjectCommandRunner) resolveSecret(ctx command.ProjectContext, secret valid.SecretRef, absPath string, envs map[string]string) (string, error) {
+	if p.SecretResolver == nil {
+		return "", fmt.Errorf("reading secret %q from %s: secret providers aren't configured", secret.Name, secret.Provider)
+	}
+	value, err := p.SecretResolver.Resolve(ctx, secret, absPath, envs)
+	if err != nil {
+		return "", err
+	}
+	ctx.Secrets.Add(value)
+	return value, nil
+}
+
+// withStepTimeout returns ctx with a TimeoutCtx that's done once a step has
+// run for timeout, or once the project's steps time out, and the func that
+// releases it. If timeout is 0, ctx is returned as is.
+func withStepTimeout(ctx command.ProjectContext, timeout time.Duration) (command.ProjectContext, context.CancelFunc) {
+	if timeout == 0 {
+		return ctx, func() {}
+	}
+	parent := ctx.TimeoutCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	timeoutCtx, cancel := context.WithTimeout(parent, timeout)
+	ctx.TimeoutCtx = timeoutCtx
+	return ctx, cancel
+}
+
+// projectExprVars returns the values the when expressions of the project's
+// steps and workflow hooks are evaluated against.
+func projectExprVars(ctx command.ProjectContext) expr.Vars {
+	return expr.Vars{
+		Command: ctx.CommandName.String(),
+		Repo:    ctx.BaseRepo.FullName,
+		User:    ctx.User.Username,
+		Pull: expr.PullVars{
+			Num:        ctx.Pull.Num,
+			Author:     ctx.Pull.Author,
+			BaseBranch: ctx.Pull.BaseBranch,
+			HeadBranch: ctx.Pull.HeadBranch,
+		},
+		Project: expr.ProjectVars{
+			Name:      ctx.ProjectName,
+			Dir:       ctx.RepoRelDir,
+			Workspace: ctx.Workspace,
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/jobs"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestTimeoutFailure(t *testing.T) {
+	ctx, cancel := (&DefaultProjectCommandRunner{}).withTimeout(command.ProjectContext{Timeout: time.Millisecond})
+	defer cancel()
+	<-ctx.TimeoutCtx.Done()
+	Equals(t, "Timed out after 1ms and was canceled. Output before it was canceled:\n```\nsignal: interrupt\nInitializing...\n```",
+		timeoutFailure(ctx, errors.New("signal: interrupt"), []string{"Initializing..."}))
+}
+
+func TestTimeoutFailure_Canceled(t *testing.T) {
+	canceler := jobs.NewJobCanceler()
+	runner := &DefaultProjectCommandRunner{JobCanceler: canceler}
+	ctx, cancel := runner.withTimeout(command.ProjectContext{JobID: "job", Timeout: time.Hour})
+	defer cancel()
+	Assert(t, canceler.Cancel("job"), "exp job to be canceled")
+	<-ctx.TimeoutCtx.Done()
+	Equals(t, "Job was canceled. Output before it was canceled:\n```\nsignal: terminated\nInitializing...\n```",
+		timeoutFailure(ctx, errors.New("signal: terminated"), []string{"Initializing..."}))
+}
+
+// Jobs stop being cancelable once their steps are done.
+func TestWithTimeout_ReleasesJob(t *testing.T) {
+	canceler := jobs.NewJobCanceler()
+	runner := &DefaultProjectCommandRunner{JobCanceler: canceler}
+	ctx, cancel := runner.withTimeout(command.ProjectContext{JobID: "job"})
+	Assert(t, ctx.TimeoutCtx != nil, "exp job to be cancelable")
+	Assert(t, canceler.IsRunning("job"), "exp job to be running")
+	cancel()
+	Assert(t, !canceler.IsRunning("job"), "exp job not to be running")
+	Equals(t, "", timeoutFailure(ctx, errors.New("exit status 1"), nil))
+}
+
+// Steps that fail before the timeout aren't failures.
+func TestTimeoutFailure_NotTimedOut(t *testing.T) {
+	ctx, cancel := (&DefaultProjectCommandRunner{}).withTimeout(command.ProjectContext{Timeout: time.Hour})
+	defer cancel()
+	Equals(t, "", timeoutFailure(ctx, errors.New("exit status 1"), nil))
+
+	ctx, cancel = (&DefaultProjectCommandRunner{}).withTimeout(command.ProjectContext{})
+	defer cancel()
+	Assert(t, ctx.TimeoutCtx == nil, "expected no timeout")
+	Equals(t, "", timeoutFailure(ctx, errors.New("exit status 1"), nil))
+}
@@ -17,7 +17,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	. "github.com/petergtz/pegomock/v4"
@@ -31,6 +34,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/mocks"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
 	jobmocks "github.com/runatlantis/atlantis/server/jobs/mocks"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -122,6 +126,71 @@ func TestDefaultProjectCommandRunner_Plan(t *testing.T) {
 	}
 }
 
+// Test that a cached plan is reused instead of running the plan step unless
+// the plan is forced.
+func TestDefaultProjectCommandRunner_PlanCached(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockInit := mocks.NewMockStepRunner()
+	mockPlan := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	mockStatePull := mocks.NewMockStepRunner()
+	mockPlanCache := mocks.NewMockPlanCache()
+
+	runner := events.DefaultProjectCommandRunner{
+		Locker:                    mockLocker,
+		LockURLGenerator:          mockURLGenerator{},
+		InitStepRunner:            mockInit,
+		PlanStepRunner:            mockPlan,
+		StatePullStepRunner:       mockStatePull,
+		WorkingDir:                mockWorkingDir,
+		WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+		CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+		PlanCache:                 mockPlanCache,
+	}
+
+	repoDir := t.TempDir()
+	When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[string]())).ThenReturn(repoDir, false, nil)
+	When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+		Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key"}, nil)
+
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Steps:      []valid.Step{{StepName: "init"}, {StepName: "plan"}},
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+	When(mockInit.Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())).ThenReturn("init", nil)
+	When(mockPlan.Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())).ThenReturn("plan", nil)
+	When(mockStatePull.Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())).ThenReturn("Serial: 1", nil)
+	When(mockPlanCache.Key(ctx, repoDir, "Serial: 1")).ThenReturn("key", nil)
+	When(mockPlanCache.Get(ctx, repoDir, "key")).ThenReturn("cached-plan", true, nil)
+
+	res := runner.Plan(ctx)
+	Assert(t, res.PlanSuccess != nil, "exp plan success")
+	Equals(t, "init\ncached-plan", res.PlanSuccess.TerraformOutput)
+	Assert(t, res.PlanSuccess.Cached, "exp plan to be cached")
+	mockInit.VerifyWasCalledOnce().Run(ctx, nil, repoDir, map[string]string{})
+	mockPlan.VerifyWasCalled(Never()).Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())
+
+	ctx.ForcePlan = true
+	res = runner.Plan(ctx)
+	Assert(t, res.PlanSuccess != nil, "exp plan success")
+	Equals(t, "init\nplan", res.PlanSuccess.TerraformOutput)
+	Assert(t, !res.PlanSuccess.Cached, "exp plan not to be cached")
+	mockPlan.VerifyWasCalledOnce().Run(ctx, nil, repoDir, map[string]string{})
+	mockPlanCache.VerifyWasCalled(Never()).Key(ctx, repoDir, "Serial: 1")
+
+	t.Log("the plan isn't cached if the state can't be pulled")
+	ctx.ForcePlan = false
+	When(mockStatePull.Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())).ThenReturn("", errors.New("backend not initialized"))
+	res = runner.Plan(ctx)
+	Assert(t, res.PlanSuccess != nil, "exp plan success")
+	Equals(t, "init\nplan", res.PlanSuccess.TerraformOutput)
+	mockPlanCache.VerifyWasCalledOnce().Key(Any[command.ProjectContext](), Any[string](), Any[string]())
+}
+
 func TestProjectOutputWrapper(t *testing.T) {
 	RegisterMockTestingT(t)
 	ctx := command.ProjectContext{
@@ -230,6 +299,59 @@ func TestProjectOutputWrapper(t *testing.T) {
 	}
 }
 
+func TestProjectOutputWrapper_AddsJobDetails(t *testing.T) {
+	RegisterMockTestingT(t)
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "default",
+		RepoRelDir: ".",
+		JobID:      "1234",
+	}
+	mockProjectCommandRunner := mocks.NewMockProjectCommandRunner()
+	mockJobURLGenerator := jobmocks.NewMockProjectJobURLGenerator()
+	runner := &events.ProjectOutputWrapper{
+		JobURLSetter:         mocks.NewMockJobURLSetter(),
+		JobMessageSender:     mocks.NewMockJobMessageSender(),
+		JobURLGenerator:      mockJobURLGenerator,
+		ProjectCommandRunner: mockProjectCommandRunner,
+	}
+	When(mockProjectCommandRunner.Plan(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{PlanSuccess: &models.PlanSuccess{}})
+	When(mockJobURLGenerator.GenerateProjectJobURL(ctx)).ThenReturn("https://atlantis.example.com/jobs/1234", nil)
+
+	result := runner.Plan(ctx)
+	Equals(t, "https://atlantis.example.com/jobs/1234", result.JobURL)
+	Assert(t, result.Duration > 0, "expected the duration to be set")
+}
+
+func TestProjectOutputWrapper_Import(t *testing.T) {
+	RegisterMockTestingT(t)
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "default",
+		RepoRelDir: ".",
+		JobID:      "1234",
+	}
+	mockJobURLSetter := mocks.NewMockJobURLSetter()
+	mockJobMessageSender := mocks.NewMockJobMessageSender()
+	mockProjectCommandRunner := mocks.NewMockProjectCommandRunner()
+	mockJobURLGenerator := jobmocks.NewMockProjectJobURLGenerator()
+	runner := &events.ProjectOutputWrapper{
+		JobURLSetter:         mockJobURLSetter,
+		JobMessageSender:     mockJobMessageSender,
+		JobURLGenerator:      mockJobURLGenerator,
+		ProjectCommandRunner: mockProjectCommandRunner,
+	}
+	When(mockProjectCommandRunner.Import(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{ImportSuccess: &models.ImportSuccess{}})
+	When(mockJobURLGenerator.GenerateProjectJobURL(ctx)).ThenReturn("https://atlantis.example.com/jobs/1234", nil)
+
+	result := runner.Import(ctx)
+
+	Equals(t, "https://atlantis.example.com/jobs/1234", result.JobURL)
+	mockProjectCommandRunner.VerifyWasCalledOnce().Import(ctx)
+	mockJobMessageSender.VerifyWasCalledOnce().Send(ctx, "", events.OperationComplete)
+	mockJobURLSetter.VerifyWasCalled(Never()).SetJobURLWithStatus(Any[command.ProjectContext](), Any[command.Name](), Any[models.CommitStatus](), Any[*command.ProjectResult]())
+}
+
 // Test what happens if there's no working dir. This signals that the project
 // was never planned.
 func TestDefaultProjectCommandRunner_ApplyNotCloned(t *testing.T) {
@@ -624,6 +746,235 @@ func TestDefaultProjectCommandRunner_RunEnvSteps(t *testing.T) {
 	Equals(t, "var=\n\nvar=value\n\ndynamic_var=dynamic_value\n\ndynamic_var=overridden\n", res.PlanSuccess.TerraformOutput)
 }
 
+// Test that project workflow hooks run around the project's steps. We don't
+// use mocks for this test since we're not running any Terraform.
+func TestDefaultProjectCommandRunner_WorkflowHooks(t *testing.T) {
+	cases := map[string]struct {
+		preHooks  []*valid.WorkflowHook
+		expOutput string
+		expErr    string
+		expResult string
+	}{
+		"hooks succeed": {
+			preHooks: []*valid.WorkflowHook{
+				{StepName: "run", RunCommand: "echo setup > setup"},
+				{StepName: "run", RunCommand: "exit 1", Commands: "apply"},
+				{StepName: "run", RunCommand: "exit 1", When: `project.workspace != "default"`},
+			},
+			expOutput: "setup\n",
+			expResult: "success\n",
+		},
+		"pre hook fails": {
+			preHooks: []*valid.WorkflowHook{
+				{StepName: "run", RunCommand: "exit 1", StepDescription: "setup"},
+			},
+			expErr:    "running project pre workflow hook: \"setup\"",
+			expResult: "failure\n",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			tfClient := tfclientmocks.NewMockClient()
+			tfDistribution := terraform.NewDistributionTerraformWithDownloader(tmocks.NewMockDownloader())
+			tfVersion, err := version.NewVersion("0.12.0")
+			Ok(t, err)
+			run := runtime.RunStepRunner{
+				TerraformExecutor:       tfClient,
+				DefaultTFDistribution:   tfDistribution,
+				DefaultTFVersion:        tfVersion,
+				ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+			}
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+
+			runner := events.DefaultProjectCommandRunner{
+				Locker:                    mockLocker,
+				LockURLGenerator:          mockURLGenerator{},
+				RunStepRunner:             &run,
+				WorkingDir:                mockWorkingDir,
+				WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+				CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+			}
+
+			repoDir := t.TempDir()
+			When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+				Any[string]())).ThenReturn(repoDir, false, nil)
+			When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+				Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key", UnlockFn: func() error { return nil }}, nil)
+
+			ctx := command.ProjectContext{
+				Log:         logging.NewNoopLogger(t),
+				CommandName: command.Plan,
+				Steps: []valid.Step{
+					{
+						StepName:   "run",
+						RunCommand: "cat setup",
+					},
+					{
+						StepName:   "run",
+						RunCommand: "echo skipped",
+						When:       `command == "apply"`,
+					},
+				},
+				Workspace:        "default",
+				RepoRelDir:       ".",
+				PreWorkflowHooks: c.preHooks,
+				PostWorkflowHooks: []*valid.WorkflowHook{
+					{StepName: "run", RunCommand: "echo $COMMAND_RESULT > result"},
+				},
+			}
+			res := runner.Plan(ctx)
+			if c.expErr != "" {
+				ErrContains(t, c.expErr, res.Error)
+			} else {
+				Ok(t, res.Error)
+				Equals(t, c.expOutput, res.PlanSuccess.TerraformOutput)
+			}
+			result, err := os.ReadFile(filepath.Join(repoDir, "result"))
+			Ok(t, err)
+			Equals(t, c.expResult, string(result))
+		})
+	}
+}
+
+// Test that a step is canceled once it runs for its timeout. We don't use
+// mocks for this test since we're not running any Terraform.
+func TestDefaultProjectCommandRunner_StepTimeout(t *testing.T) {
+	RegisterMockTestingT(t)
+	tfClient := tfclientmocks.NewMockClient()
+	tfDistribution := terraform.NewDistributionTerraformWithDownloader(tmocks.NewMockDownloader())
+	tfVersion, err := version.NewVersion("0.12.0")
+	Ok(t, err)
+	run := runtime.RunStepRunner{
+		TerraformExecutor:       tfClient,
+		DefaultTFDistribution:   tfDistribution,
+		DefaultTFVersion:        tfVersion,
+		ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+	}
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	runner := events.DefaultProjectCommandRunner{
+		Locker:                    mockLocker,
+		LockURLGenerator:          mockURLGenerator{},
+		RunStepRunner:             &run,
+		WorkingDir:                mockWorkingDir,
+		WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+		CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+	}
+	When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[string]())).ThenReturn(t.TempDir(), false, nil)
+	When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+		Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key", UnlockFn: func() error { return nil }}, nil)
+
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		CommandName: command.Plan,
+		Steps: []valid.Step{
+			{
+				StepName:   "run",
+				RunCommand: "echo started",
+				Timeout:    time.Minute,
+			},
+			{
+				StepName:   "run",
+				RunCommand: "sleep 60",
+				Timeout:    100 * time.Millisecond,
+			},
+		},
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+	start := time.Now()
+	res := runner.Plan(ctx)
+	ErrContains(t, "run step timed out after 100ms and was canceled", res.Error)
+	Assert(t, time.Since(start) < 30*time.Second, "expected the step to be canceled")
+}
+
+func TestDefaultProjectCommandRunner_StepRetry(t *testing.T) {
+	retry := &valid.StepRetry{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		Errors:      []*regexp.Regexp{regexp.MustCompile("connection reset by peer")},
+	}
+	cases := []struct {
+		description string
+		initErrs    []error
+		expAttempts int
+		expErr      string
+	}{
+		{
+			description: "succeeds after a retryable error",
+			initErrs:    []error{errors.New("read tcp: connection reset by peer"), nil},
+			expAttempts: 2,
+		},
+		{
+			description: "fails after max attempts",
+			initErrs: []error{
+				errors.New("read tcp: connection reset by peer"),
+				errors.New("read tcp: connection reset by peer"),
+				errors.New("read tcp: connection reset by peer"),
+			},
+			expAttempts: 3,
+			expErr:      "read tcp: connection reset by peer",
+		},
+		{
+			description: "isn't retried after an error that isn't retryable",
+			initErrs:    []error{errors.New("Unsupported argument")},
+			expAttempts: 1,
+			expErr:      "Unsupported argument",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockInit := mocks.NewMockStepRunner()
+			mockPlan := mocks.NewMockStepRunner()
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			runner := events.DefaultProjectCommandRunner{
+				Locker:                    mockLocker,
+				LockURLGenerator:          mockURLGenerator{},
+				InitStepRunner:            mockInit,
+				PlanStepRunner:            mockPlan,
+				WorkingDir:                mockWorkingDir,
+				WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+				CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+			}
+			When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+				Any[string]())).ThenReturn(t.TempDir(), false, nil)
+			When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+				Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key", UnlockFn: func() error { return nil }}, nil)
+			initCall := When(mockInit.Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]()))
+			for _, err := range c.initErrs {
+				initCall = initCall.ThenReturn("init", err)
+			}
+			When(mockPlan.Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())).
+				ThenReturn("plan", nil)
+
+			ctx := command.ProjectContext{
+				Log:         logging.NewNoopLogger(t),
+				CommandName: command.Plan,
+				Steps: []valid.Step{
+					{StepName: "init", Retry: retry},
+					{StepName: "plan"},
+				},
+				Workspace:  "default",
+				RepoRelDir: ".",
+			}
+			res := runner.Plan(ctx)
+			mockInit.VerifyWasCalled(Times(c.expAttempts)).Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())
+			if c.expErr != "" {
+				ErrContains(t, c.expErr, res.Error)
+				mockPlan.VerifyWasCalled(Never()).Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())
+				return
+			}
+			Ok(t, res.Error)
+			Equals(t, "init\nplan", res.PlanSuccess.TerraformOutput)
+		})
+	}
+}
+
 // Test that it runs the expected import steps.
 func TestDefaultProjectCommandRunner_Import(t *testing.T) {
 	expEnvs := map[string]string{}
@@ -738,6 +1089,40 @@ func TestDefaultProjectCommandRunner_Import(t *testing.T) {
 	}
 }
 
+func TestDefaultProjectCommandRunner_StateAndCustomManualTrigger(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	mockRun := mocks.NewMockCustomStepRunner()
+	runner := events.DefaultProjectCommandRunner{
+		Locker:                    mockLocker,
+		WorkingDir:                mockWorkingDir,
+		WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+		RunStepRunner:             mockRun,
+		CommandRequirementHandler: &events.DefaultCommandRequirementHandler{WorkingDir: mockWorkingDir},
+	}
+	ctx := command.ProjectContext{
+		Log:                    logging.NewNoopLogger(t),
+		Steps:                  []valid.Step{{StepName: "run", RunCommand: "echo hi"}},
+		Workspace:              "default",
+		RepoRelDir:             ".",
+		ManualTriggerOperators: []string{"alice"},
+		User:                   models.User{Username: "bob"},
+	}
+	repoDir := t.TempDir()
+	When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[string]())).ThenReturn(repoDir, false, nil)
+	When(mockWorkingDir.GetWorkingDir(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).ThenReturn(repoDir, nil)
+
+	stateFailure := "This project is in manual trigger mode and can only be used with state commands by its operators: alice."
+	Equals(t, stateFailure, runner.StateRm(ctx).Failure)
+	Equals(t, stateFailure, runner.StatePush(ctx).Failure)
+	Equals(t, stateFailure, runner.StatePull(ctx).Failure)
+	Equals(t, "This project is in manual trigger mode and can only be used with custom commands by its operators: alice.", runner.Custom(ctx).Failure)
+	mockLocker.VerifyWasCalled(Never()).TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](), Any[models.Project](), AnyBool())
+	mockRun.VerifyWasCalled(Never()).Run(Any[command.ProjectContext](), Any[*valid.CommandShell](), Any[string](), Any[string](), Any[map[string]string](), AnyBool(), Any[valid.PostProcessRunOutputOption]())
+}
+
 type mockURLGenerator struct{}
 
 func (m mockURLGenerator) GenerateLockURL(lockID string) string {
@@ -752,6 +1137,7 @@ func TestDefaultProjectCommandRunner_ApprovePolicies(t *testing.T) {
 		policySetCfg        valid.PolicySets
 		policySetStatus     []models.PolicySetStatus
 		userTeams           []string // Teams the user is a member of
+		userOrgs            []string // Organizations the user is a member of
 		targetedPolicy      string   // Policy to target when running approvals
 		clearPolicyApproval bool
 
@@ -759,6 +1145,41 @@ func TestDefaultProjectCommandRunner_ApprovePolicies(t *testing.T) {
 		expFailure string
 		hasErr     bool
 	}{
+		{
+			description: "When user is a member of an owner organization, increment approval count.",
+			userOrgs:    []string{"someuserorg"},
+			policySetCfg: valid.PolicySets{
+				PolicySets: []valid.PolicySet{
+					{
+						Owners: valid.PolicyOwners{
+							Orgs: []string{"someuserorg"},
+						},
+						Name:         "policy1",
+						ApproveCount: 1,
+					},
+					{
+						Owners: valid.PolicyOwners{
+							Orgs: []string{"someotherorg"},
+						},
+						Name:         "policy2",
+						ApproveCount: 1,
+					},
+				},
+			},
+			expOut: []models.PolicySetResult{
+				{
+					PolicySetName: "policy1",
+					ReqApprovals:  1,
+					CurApprovals:  1,
+				},
+				{
+					PolicySetName: "policy2",
+					ReqApprovals:  1,
+				},
+			},
+			expFailure: "One or more policy sets require additional approval.",
+			hasErr:     true,
+		},
 		{
 			description: "When user is not an owner at any level, approve policy fails.",
 			hasErr:      true,
@@ -1249,6 +1670,10 @@ func TestDefaultProjectCommandRunner_ApprovePolicies(t *testing.T) {
 				WorkingDir:       mockWorkingDir,
 				Webhooks:         mockSender,
 				WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+				UserMemberships: &events.UserMemberships{
+					TeamNamesGetter:       mockVcsClient,
+					OrgMembershipCheckers: map[models.VCSHostType]vcs.OrgMembershipChecker{models.Github: &fakeMembership{orgs: c.userOrgs}},
+				},
 			}
 			repoDir := t.TempDir()
 			When(mockWorkingDir.GetWorkingDir(
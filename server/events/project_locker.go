@@ -16,6 +16,7 @@ package events
 import (
 	"fmt"
 
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
@@ -41,6 +42,9 @@ type DefaultProjectLocker struct {
 	Locker     locking.Locker
 	NoOpLocker locking.Locker
 	VCSClient  vcs.Client
+	// EventHooks, if set, runs the hooks for lock_created when a lock is
+	// created.
+	EventHooks WorkflowHookEventRunner
 }
 
 // TryLockResponse is the result of trying to lock a project.
@@ -84,6 +88,15 @@ func (p *DefaultProjectLocker) TryLock(log logging.SimpleLogging, pull models.Pu
 		}, nil
 	}
 	log.Info("Acquired lock with id '%s'", lockAttempt.LockKey)
+	if lockAttempt.LockAcquired && repoLocking && p.EventHooks != nil {
+		hookCtx := newEventHookContext(log, valid.LockCreatedHookEvent, pull, user)
+		hookCtx.ProjectName = project.ProjectName
+		hookCtx.RepoRelDir = project.Path
+		hookCtx.Workspace = workspace
+		if err := p.EventHooks.RunEventHooks(hookCtx); err != nil {
+			log.Warn("unable to run %s hooks: %s", valid.LockCreatedHookEvent, err)
+		}
+	}
 	return &TryLockResponse{
 		LockAcquired: true,
 		UnlockFn: func() error {
@@ -21,6 +21,7 @@ import (
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/core/locking/mocks"
 	"github.com/runatlantis/atlantis/server/events"
+	eventmocks "github.com/runatlantis/atlantis/server/events/mocks"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -210,3 +211,41 @@ func TestDefaultProjectLocker_RepoLocking(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultProjectLocker_TryLockRunsEventHooks(t *testing.T) {
+	RegisterMockTestingT(t)
+	var githubClient *vcs.GithubClient
+	mockClient := vcs.NewClientProxy(githubClient, nil, nil, nil, nil, nil)
+	mockLocker := mocks.NewMockLocker()
+	eventHooks := eventmocks.NewMockWorkflowHookEventRunner()
+	locker := events.DefaultProjectLocker{
+		Locker:     mockLocker,
+		VCSClient:  mockClient,
+		EventHooks: eventHooks,
+	}
+	expProject := models.Project{ProjectName: "staging", Path: "staging"}
+	expPull := models.PullRequest{Num: 2}
+	expUser := models.User{Username: "alice"}
+
+	t.Log("a lock held by the same pull isn't created again")
+	When(mockLocker.TryLock(expProject, "default", expPull, expUser)).ThenReturn(
+		locking.TryLockResponse{LockAcquired: false, CurrLock: models.ProjectLock{Pull: expPull}, LockKey: "key"},
+		nil,
+	)
+	_, err := locker.TryLock(logging.NewNoopLogger(t), expPull, expUser, "default", expProject, true)
+	Ok(t, err)
+	eventHooks.VerifyWasCalled(Never()).RunEventHooks(Any[models.WorkflowHookCommandContext]())
+
+	When(mockLocker.TryLock(expProject, "default", expPull, expUser)).ThenReturn(
+		locking.TryLockResponse{LockAcquired: true, CurrLock: models.ProjectLock{Pull: expPull}, LockKey: "key"},
+		nil,
+	)
+	_, err = locker.TryLock(logging.NewNoopLogger(t), expPull, expUser, "default", expProject, true)
+	Ok(t, err)
+	hookCtx := eventHooks.VerifyWasCalledOnce().RunEventHooks(Any[models.WorkflowHookCommandContext]()).GetCapturedArguments()
+	Equals(t, "lock_created", hookCtx.Event)
+	Equals(t, "staging", hookCtx.ProjectName)
+	Equals(t, "staging", hookCtx.RepoRelDir)
+	Equals(t, "default", hookCtx.Workspace)
+	Equals(t, expUser, hookCtx.User)
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// ProjectRenamer moves what's stored for a project under its old dir,
+// workspace and name to the new ones after the project was renamed or moved.
+// Otherwise the locks held under the old dir and workspace are orphaned and
+// block the project until they're deleted by hand.
+type ProjectRenamer struct {
+	Backend locking.Backend
+	// PlanHistory is nil if plan history is disabled.
+	PlanHistory *PlanHistory
+}
+
+// ProjectRenameResult is how many of each were moved by a rename.
+type ProjectRenameResult struct {
+	Locks         int
+	PullStatuses  int
+	PlanHistories int
+}
+
+// Rename moves the locks, pull statuses and plan history of the project
+// being renamed. Nothing is moved if a lock can't be moved because another
+// pull holds the lock on the new dir and workspace.
+func (r *ProjectRenamer) Rename(rename models.ProjectRename) (ProjectRenameResult, error) {
+	var result ProjectRenameResult
+	if rename.From == rename.To {
+		return result, errors.New("the project's dir, workspace and name are unchanged")
+	}
+
+	locks, err := r.Backend.List()
+	if err != nil {
+		return result, errors.Wrap(err, "listing locks")
+	}
+	newProject := models.NewProject(rename.RepoFullName, rename.To.RepoRelDir, rename.To.ProjectName)
+	var toMove []models.ProjectLock
+	for _, lock := range locks {
+		if lock.Project.RepoFullName != rename.RepoFullName ||
+			!rename.Matches(lock.Project.Path, lock.Workspace, lock.Project.ProjectName) {
+			continue
+		}
+		if lock.Project.Path != newProject.Path || lock.Workspace != rename.To.Workspace {
+			existing, err := r.Backend.GetLock(newProject, rename.To.Workspace)
+			if err != nil {
+				return result, errors.Wrap(err, "getting lock")
+			}
+			if existing != nil && existing.Pull.Num != lock.Pull.Num {
+				return result, errors.Errorf("dir %q workspace %q is already locked by pull #%d", newProject.Path, rename.To.Workspace, existing.Pull.Num)
+			}
+		}
+		toMove = append(toMove, lock)
+	}
+
+	for _, lock := range toMove {
+		if _, err := r.Backend.Unlock(lock.Project, lock.Workspace); err != nil {
+			return result, errors.Wrap(err, "deleting old lock")
+		}
+		newLock := lock
+		newLock.Project = newProject
+		newLock.Workspace = rename.To.Workspace
+		// The lock isn't acquired if the pull already holds the lock on the
+		// new dir and workspace, which is fine.
+		if _, _, err := r.Backend.TryLock(newLock); err != nil {
+			return result, errors.Wrap(err, "creating new lock")
+		}
+		result.Locks++
+	}
+
+	if result.PullStatuses, err = r.Backend.RenameProjectStatuses(rename); err != nil {
+		return result, errors.Wrap(err, "renaming project in pull statuses")
+	}
+
+	if r.PlanHistory != nil {
+		if result.PlanHistories, err = r.PlanHistory.Rename(rename); err != nil {
+			return result, errors.Wrap(err, "renaming plan history")
+		}
+	}
+	return result, nil
+}
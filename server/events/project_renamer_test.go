@@ -0,0 +1,66 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestProjectRenamer_Rename(t *testing.T) {
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+	defer boltDB.Close() // nolint: errcheck
+	history := &events.PlanHistory{DataDir: t.TempDir()}
+	renamer := events.ProjectRenamer{Backend: boltDB, PlanHistory: history}
+
+	repo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.com"}}
+	pull := models.PullRequest{Num: 1, BaseRepo: repo, HeadCommit: "commit1"}
+	oldProject := models.NewProject(repo.FullName, "network", "")
+	acquired, _, err := boltDB.TryLock(models.ProjectLock{Project: oldProject, Workspace: "default", Pull: pull})
+	Ok(t, err)
+	Assert(t, acquired, "expected the lock to be acquired")
+	_, err = boltDB.UpdatePullWithResults(pull, []command.ProjectResult{{
+		Command:     command.Plan,
+		RepoRelDir:  "network",
+		Workspace:   "default",
+		PlanSuccess: &models.PlanSuccess{},
+	}})
+	Ok(t, err)
+	_, err = history.Add(command.ProjectContext{Pull: pull, RepoRelDir: "network", Workspace: "default"}, "")
+	Ok(t, err)
+
+	rename := models.ProjectRename{
+		RepoFullName: repo.FullName,
+		From:         models.ProjectLocation{RepoRelDir: "network", Workspace: "default"},
+		To:           models.ProjectLocation{RepoRelDir: "infra/network", Workspace: "default", ProjectName: "network"},
+	}
+	result, err := renamer.Rename(rename)
+	Ok(t, err)
+	Equals(t, events.ProjectRenameResult{Locks: 1, PullStatuses: 1, PlanHistories: 1}, result)
+
+	lock, err := boltDB.GetLock(oldProject, "default")
+	Ok(t, err)
+	Assert(t, lock == nil, "expected the old lock to be deleted")
+	lock, err = boltDB.GetLock(models.NewProject(repo.FullName, "infra/network", "network"), "default")
+	Ok(t, err)
+	Assert(t, lock != nil, "expected the lock to be moved")
+	Equals(t, 1, lock.Pull.Num)
+
+	histories, err := history.Get(repo.FullName, 1)
+	Ok(t, err)
+	Equals(t, 1, len(histories))
+	Equals(t, "infra/network", histories[0].RepoRelDir)
+	Equals(t, "network", histories[0].ProjectName)
+
+	// A lock held by another pull on the new dir and workspace isn't taken
+	// over.
+	otherPull := models.PullRequest{Num: 2, BaseRepo: repo}
+	_, _, err = boltDB.TryLock(models.ProjectLock{Project: oldProject, Workspace: "default", Pull: otherPull})
+	Ok(t, err)
+	_, err = renamer.Rename(rename)
+	ErrContains(t, "already locked by pull #1", err)
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// workspaceGlobChars are the characters that make an entry of a project's
+// workspaces a glob rather than the name of a workspace.
+const workspaceGlobChars = "*?["
+
+// expandWorkspaces replaces each project in repoCfg that has workspaces with
+// one project per workspace. Names are used as is and globs are matched
+// against the workspaces that listWorkspaces returns for the project, which is
+// only called for projects with globs.
+func expandWorkspaces(repoCfg *valid.RepoCfg, listWorkspaces func(project valid.Project) ([]string, error)) error {
+	// Copy rather than expand in place since the projects can be shared with
+	// the caller.
+	projects := repoCfg.Projects[:0:0]
+	for _, project := range repoCfg.Projects {
+		if len(project.Workspaces) == 0 {
+			projects = append(projects, project)
+			continue
+		}
+
+		var workspaces []string
+		var globs []string
+		for _, workspace := range project.Workspaces {
+			if strings.ContainsAny(workspace, workspaceGlobChars) {
+				globs = append(globs, workspace)
+			} else {
+				workspaces = append(workspaces, workspace)
+			}
+		}
+		if len(globs) > 0 {
+			existing, err := listWorkspaces(project)
+			if err != nil {
+				return errors.Wrapf(err, "listing workspaces of project at dir '%s' to match %s", project.Dir, strings.Join(globs, ", "))
+			}
+			for _, workspace := range existing {
+				for _, glob := range globs {
+					// The globs are validated when the config is parsed.
+					if matched, _ := path.Match(glob, workspace); matched {
+						workspaces = append(workspaces, workspace)
+						break
+					}
+				}
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, workspace := range workspaces {
+			if seen[workspace] {
+				continue
+			}
+			seen[workspace] = true
+			expanded := project
+			expanded.Workspace = workspace
+			expanded.Workspaces = nil
+			projects = append(projects, expanded)
+		}
+	}
+	repoCfg.Projects = projects
+	return nil
+}
+
+// parseWorkspaceList parses the output of terraform workspace list, which
+// lists one workspace per line with the selected one prefixed by '*'.
+func parseWorkspaceList(output string) []string {
+	var workspaces []string
+	for _, line := range strings.Split(output, "\n") {
+		workspace := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if workspace != "" {
+			workspaces = append(workspaces, workspace)
+		}
+	}
+	return workspaces
+}
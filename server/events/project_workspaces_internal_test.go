@@ -0,0 +1,61 @@
+package events
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestExpandWorkspaces(t *testing.T) {
+	repoCfg := valid.RepoCfg{
+		Projects: []valid.Project{
+			{Dir: "prod", Workspace: "default"},
+			{Dir: "app", Workspace: "default", Workspaces: []string{"staging", "prod-*"}},
+		},
+	}
+	var listed []string
+	Ok(t, expandWorkspaces(&repoCfg, func(project valid.Project) ([]string, error) {
+		listed = append(listed, project.Dir)
+		return []string{"default", "prod-eu", "staging", "prod-us"}, nil
+	}))
+	Equals(t, []string{"app"}, listed)
+	Equals(t, []valid.Project{
+		{Dir: "prod", Workspace: "default"},
+		{Dir: "app", Workspace: "staging"},
+		{Dir: "app", Workspace: "prod-eu"},
+		{Dir: "app", Workspace: "prod-us"},
+	}, repoCfg.Projects)
+}
+
+func TestExpandWorkspaces_NoGlobs(t *testing.T) {
+	repoCfg := valid.RepoCfg{
+		Projects: []valid.Project{
+			{Dir: "app", Workspace: "default", Workspaces: []string{"staging", "prod"}},
+		},
+	}
+	Ok(t, expandWorkspaces(&repoCfg, func(valid.Project) ([]string, error) {
+		return nil, errors.New("workspaces shouldn't be listed")
+	}))
+	Equals(t, []valid.Project{
+		{Dir: "app", Workspace: "staging"},
+		{Dir: "app", Workspace: "prod"},
+	}, repoCfg.Projects)
+}
+
+func TestExpandWorkspaces_ListError(t *testing.T) {
+	repoCfg := valid.RepoCfg{
+		Projects: []valid.Project{
+			{Dir: "app", Workspace: "default", Workspaces: []string{"prod-*"}},
+		},
+	}
+	err := expandWorkspaces(&repoCfg, func(valid.Project) ([]string, error) {
+		return nil, errors.New("backend not initialized")
+	})
+	ErrEquals(t, "listing workspaces of project at dir 'app' to match prod-*: backend not initialized", err)
+}
+
+func TestParseWorkspaceList(t *testing.T) {
+	Equals(t, []string{"default", "prod-eu", "staging"}, parseWorkspaceList("  default\n* prod-eu\n  staging\n\n"))
+}
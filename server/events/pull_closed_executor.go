@@ -24,6 +24,7 @@ import (
 	"github.com/runatlantis/atlantis/server/logging"
 
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
@@ -42,7 +43,9 @@ type ResourceCleaner interface {
 type PullCleaner interface {
 	// CleanUpPull deletes the workspaces used by the pull request on disk
 	// and deletes any locks associated with this pull request for all workspaces.
-	CleanUpPull(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) error
+	// headRepo is the repo the pull request's branch is in, it's empty if
+	// it isn't known.
+	CleanUpPull(logger logging.SimpleLogging, repo models.Repo, headRepo models.Repo, pull models.PullRequest) error
 }
 
 // PullClosedExecutor executes the tasks required to clean up a closed pull
@@ -54,8 +57,39 @@ type PullClosedExecutor struct {
 	Backend                  locking.Backend
 	PullClosedTemplate       PullCleanupTemplate
 	LogStreamResourceCleaner ResourceCleaner
+	// DestroyOnCloseRunner destroys the pull request's workspaces, ex.
+	// preview environments, before it's cleaned up. It can be nil.
+	DestroyOnCloseRunner DestroyOnCloseRunner
+	// PlanCache holds the pull request's cached plans. It can be nil.
+	PlanCache PlanCache
+	// PlanHistory holds the pull request's plan history. It can be nil.
+	PlanHistory *PlanHistory
+	// PlanStore holds the pull request's stored plans. It can be nil.
+	PlanStore PlanStore
+	// EventHooks runs the hooks for pull_closed before the pull request is
+	// cleaned up. It can be nil.
+	EventHooks WorkflowHookEventRunner
+	// CommentCleanup is how the comments Atlantis made on the pull request
+	// are cleaned up.
+	CommentCleanup CommentCleanupMode
+	// CommentCleaners clean up comments on the VCS hosts that support it.
+	CommentCleaners map[models.VCSHostType]vcs.CommentCleaner
 }
 
+// CommentCleanupMode is how the comments Atlantis made on a pull request are
+// cleaned up once it's closed.
+type CommentCleanupMode string
+
+const (
+	// KeepCommentCleanupMode keeps the comments as they are.
+	KeepCommentCleanupMode CommentCleanupMode = "keep"
+	// CollapseCommentCleanupMode collapses the comments on the VCS hosts
+	// that support it and deletes them on the others.
+	CollapseCommentCleanupMode CommentCleanupMode = "collapse"
+	// DeleteCommentCleanupMode deletes the comments.
+	DeleteCommentCleanupMode CommentCleanupMode = "delete"
+)
+
 type templatedProject struct {
 	RepoRelDir string
 	Workspaces string
@@ -77,13 +111,45 @@ func (t *PullClosedEventTemplate) Execute(wr io.Writer, data interface{}) error
 }
 
 // CleanUpPull cleans up after a closed pull request.
-func (p *PullClosedExecutor) CleanUpPull(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) error {
+func (p *PullClosedExecutor) CleanUpPull(logger logging.SimpleLogging, repo models.Repo, headRepo models.Repo, pull models.PullRequest) error {
+	if p.EventHooks != nil {
+		if err := p.EventHooks.RunEventHooks(newEventHookContext(logger, valid.PullClosedHookEvent, pull, models.User{})); err != nil {
+			// Log and continue to clean up the pull request.
+			logger.Warn("unable to run %s hooks: %s", valid.PullClosedHookEvent, err)
+		}
+	}
+
 	pullStatus, err := p.Backend.GetPullStatus(pull)
 	if err != nil {
 		// Log and continue to clean up other resources.
 		logger.Err("retrieving pull status: %s", err)
 	}
 
+	// Destroying takes as long as a plan and apply so it's run in the
+	// background, and the pull request is cleaned up once it's done.
+	if p.DestroyOnCloseRunner != nil && pullStatus != nil && hasAppliedProjects(*pullStatus) {
+		// The pull request's branch can't be cloned without its head repo,
+		// ex. when it's cleaned up after being closed while Atlantis was down.
+		if headRepo.FullName == "" {
+			logger.Warn("not destroying the workspaces of the pull request since its head repo isn't known")
+			return p.cleanUp(logger, repo, pull, pullStatus)
+		}
+		go func() {
+			if err := p.DestroyOnCloseRunner.DestroyOnClose(logger, repo, headRepo, pull, *pullStatus); err != nil {
+				logger.Err("destroying workspaces: %s", err)
+			}
+			if err := p.cleanUp(logger, repo, pull, pullStatus); err != nil {
+				logger.Err("cleaning up pull request: %s", err)
+			}
+		}()
+		return nil
+	}
+	return p.cleanUp(logger, repo, pull, pullStatus)
+}
+
+// cleanUp deletes the pull request's working dirs, locks and status and
+// comments with the locks that were deleted.
+func (p *PullClosedExecutor) cleanUp(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, pullStatus *models.PullStatus) error {
 	if pullStatus != nil {
 		for _, project := range pullStatus.Projects {
 			jobContext := jobs.PullInfo{
@@ -99,6 +165,21 @@ func (p *PullClosedExecutor) CleanUpPull(logger logging.SimpleLogging, repo mode
 	if err := p.WorkingDir.Delete(logger, repo, pull); err != nil {
 		return errors.Wrap(err, "cleaning workspace")
 	}
+	if p.PlanCache != nil {
+		if err := p.PlanCache.Delete(repo, pull); err != nil {
+			return errors.Wrap(err, "deleting cached plans")
+		}
+	}
+	if p.PlanHistory != nil {
+		if err := p.PlanHistory.Delete(repo, pull); err != nil {
+			return errors.Wrap(err, "deleting plan history")
+		}
+	}
+	if p.PlanStore != nil {
+		if err := p.PlanStore.Delete(pull); err != nil {
+			return errors.Wrap(err, "deleting stored plans")
+		}
+	}
 
 	// Finally, delete locks. We do this last because when someone
 	// unlocks a project, right now we don't actually delete the plan
@@ -113,19 +194,84 @@ func (p *PullClosedExecutor) CleanUpPull(logger logging.SimpleLogging, repo mode
 		logger.Err("deleting pull from db: %s", err)
 	}
 
-	// If there are no locks then there's no need to comment.
-	if len(locks) == 0 {
+	// The comments are cleaned up before commenting so that the comment is
+	// the only one left.
+	cleanedUp := p.cleanUpComments(logger, repo, pull)
+
+	// If there are no locks and no comments were cleaned up then there's no
+	// need to comment.
+	if len(locks) == 0 && cleanedUp == 0 {
 		return nil
 	}
 
-	templateData := p.buildTemplateData(locks)
 	var buf bytes.Buffer
-	if err = pullClosedTemplate.Execute(&buf, templateData); err != nil {
-		return errors.Wrap(err, "rendering template for comment")
+	if cleanedUp > 0 {
+		buf.WriteString(p.commentCleanupSummary(cleanedUp, pullStatus))
+	}
+	if len(locks) > 0 {
+		if cleanedUp > 0 {
+			buf.WriteString("\n\n")
+		}
+		templateData := p.buildTemplateData(locks)
+		if err = pullClosedTemplate.Execute(&buf, templateData); err != nil {
+			return errors.Wrap(err, "rendering template for comment")
+		}
 	}
 	return p.VCSClient.CreateComment(logger, repo, pull.Num, buf.String(), "")
 }
 
+// cleanUpComments cleans up the comments Atlantis made on the pull request
+// and returns how many were cleaned up.
+func (p *PullClosedExecutor) cleanUpComments(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) int {
+	if p.CommentCleanup != CollapseCommentCleanupMode && p.CommentCleanup != DeleteCommentCleanupMode {
+		return 0
+	}
+	cleaner, ok := p.CommentCleaners[repo.VCSHost.Type]
+	if !ok {
+		logger.Debug("cleaning up comments isn't supported on %s", repo.VCSHost.Type.String())
+		return 0
+	}
+	cleanedUp, err := cleaner.CleanUpComments(logger, repo, pull.Num, p.CommentCleanup == CollapseCommentCleanupMode)
+	if err != nil {
+		// Log and continue to comment with what was cleaned up.
+		logger.Err("cleaning up comments: %s", err)
+	}
+	return cleanedUp
+}
+
+// commentCleanupSummary returns the summary of the pull request that replaces
+// the cleanedUp comments.
+func (p *PullClosedExecutor) commentCleanupSummary(cleanedUp int, pullStatus *models.PullStatus) string {
+	action := "deleted"
+	if p.CommentCleanup == CollapseCommentCleanupMode {
+		action = "cleaned up"
+	}
+	summary := fmt.Sprintf("Atlantis %s its %d comments on this pull request since it's closed.", action, cleanedUp)
+	if pullStatus == nil || len(pullStatus.Projects) == 0 {
+		return summary
+	}
+	summary += " The last status of its projects was:\n"
+	for _, project := range pullStatus.Projects {
+		summary += fmt.Sprintf("\n- dir: `%s` workspace: `%s`", project.RepoRelDir, project.Workspace)
+		if project.ProjectName != "" {
+			summary += fmt.Sprintf(" project: `%s`", project.ProjectName)
+		}
+		summary += fmt.Sprintf(": %s", project.Status.String())
+	}
+	return summary
+}
+
+// hasAppliedProjects returns true if any of the projects in pullStatus were
+// applied.
+func hasAppliedProjects(pullStatus models.PullStatus) bool {
+	for _, project := range pullStatus.Projects {
+		if project.Status == models.AppliedPlanStatus || project.Status == models.ErroredApplyStatus {
+			return true
+		}
+	}
+	return false
+}
+
 // buildTemplateData formats the lock data into a slice that can easily be
 // templated for the VCS comment. We organize all the workspaces by their
 // respective project paths so the comment can look like:
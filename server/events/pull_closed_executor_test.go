@@ -16,10 +16,12 @@ package events_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/core/db"
 	"github.com/runatlantis/atlantis/server/jobs"
+	jobmocks "github.com/runatlantis/atlantis/server/jobs/mocks"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/stretchr/testify/assert"
 	bolt "go.etcd.io/bbolt"
@@ -31,6 +33,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/mocks"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
 	loggermocks "github.com/runatlantis/atlantis/server/logging/mocks"
 	. "github.com/runatlantis/atlantis/testing"
@@ -54,7 +57,7 @@ func TestCleanUpPullWorkspaceErr(t *testing.T) {
 	}
 	err = errors.New("err")
 	When(w.Delete(logger, testdata.GithubRepo, testdata.Pull)).ThenReturn(err)
-	actualErr := pce.CleanUpPull(logger, testdata.GithubRepo, testdata.Pull)
+	actualErr := pce.CleanUpPull(logger, testdata.GithubRepo, testdata.GithubRepo, testdata.Pull)
 	Equals(t, "cleaning workspace: err", actualErr.Error())
 }
 
@@ -78,7 +81,7 @@ func TestCleanUpPullUnlockErr(t *testing.T) {
 	}
 	err = errors.New("err")
 	When(l.UnlockByPull(testdata.GithubRepo.FullName, testdata.Pull.Num)).ThenReturn(nil, err)
-	actualErr := pce.CleanUpPull(logger, testdata.GithubRepo, testdata.Pull)
+	actualErr := pce.CleanUpPull(logger, testdata.GithubRepo, testdata.GithubRepo, testdata.Pull)
 	Equals(t, "cleaning up locks: err", actualErr.Error())
 }
 
@@ -102,7 +105,7 @@ func TestCleanUpPullNoLocks(t *testing.T) {
 		Backend:    db,
 	}
 	When(l.UnlockByPull(testdata.GithubRepo.FullName, testdata.Pull.Num)).ThenReturn(nil, nil)
-	err = pce.CleanUpPull(logger, testdata.GithubRepo, testdata.Pull)
+	err = pce.CleanUpPull(logger, testdata.GithubRepo, testdata.GithubRepo, testdata.Pull)
 	Ok(t, err)
 	cp.VerifyWasCalled(Never()).CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
 }
@@ -203,7 +206,7 @@ func TestCleanUpPullComments(t *testing.T) {
 			}
 			t.Log("testing: " + c.Description)
 			When(l.UnlockByPull(testdata.GithubRepo.FullName, testdata.Pull.Num)).ThenReturn(c.Locks, nil)
-			err = pce.CleanUpPull(logger, testdata.GithubRepo, testdata.Pull)
+			err = pce.CleanUpPull(logger, testdata.GithubRepo, testdata.GithubRepo, testdata.Pull)
 			Ok(t, err)
 			_, _, _, comment, _ := cp.VerifyWasCalledOnce().CreateComment(
 				Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]()).GetCapturedArguments()
@@ -294,7 +297,7 @@ func TestCleanUpLogStreaming(t *testing.T) {
 		When(locker.UnlockByPull(testdata.GithubRepo.FullName, testdata.Pull.Num)).ThenReturn(locks, nil)
 
 		// Clean up.
-		err = pullClosedExecutor.CleanUpPull(logger, testdata.GithubRepo, testdata.Pull)
+		err = pullClosedExecutor.CleanUpPull(logger, testdata.GithubRepo, testdata.GithubRepo, testdata.Pull)
 		Ok(t, err)
 
 		close(prjCmdOutput)
@@ -309,3 +312,171 @@ func TestCleanUpLogStreaming(t *testing.T) {
 		assert.Empty(t, dfPrjCmdOutputHandler.GetReceiverBufferForPull(ctx.PullInfo()))
 	})
 }
+
+func TestCleanUpPullDestroysOnClose(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+	w := mocks.NewMockWorkingDir()
+	l := lockmocks.NewMockLocker()
+	destroyer := mocks.NewMockDestroyOnCloseRunner()
+	db, err := db.New(t.TempDir())
+	t.Cleanup(func() {
+		db.Close()
+	})
+	Ok(t, err)
+	pullStatus, err := db.UpdatePullWithResults(testdata.Pull, []command.ProjectResult{
+		{
+			Command:      command.Apply,
+			RepoRelDir:   ".",
+			Workspace:    "pr-1",
+			ApplySuccess: "success",
+		},
+	})
+	Ok(t, err)
+	pce := events.PullClosedExecutor{
+		Locker:                   l,
+		WorkingDir:               w,
+		Backend:                  db,
+		DestroyOnCloseRunner:     destroyer,
+		LogStreamResourceCleaner: jobmocks.NewMockProjectCommandOutputHandler(),
+	}
+	When(l.UnlockByPull(testdata.GithubRepo.FullName, testdata.Pull.Num)).ThenReturn(nil, nil)
+
+	err = pce.CleanUpPull(logger, testdata.GithubRepo, testdata.GithubRepo, testdata.Pull)
+	Ok(t, err)
+	destroyer.VerifyWasCalledEventually(Once(), 2*time.Second).
+		DestroyOnClose(logger, testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, pullStatus)
+	// The pull request is cleaned up after its workspaces are destroyed.
+	l.VerifyWasCalledEventually(Once(), 2*time.Second).UnlockByPull(testdata.GithubRepo.FullName, testdata.Pull.Num)
+	// Wait for the pull status to be deleted so the clean up is done before
+	// the db is closed.
+	for i := 0; i < 100; i++ {
+		if status, _ := db.GetPullStatus(testdata.Pull); status == nil {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("pull status was not deleted")
+}
+
+func TestCleanUpPullNoAppliedProjects(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+	w := mocks.NewMockWorkingDir()
+	l := lockmocks.NewMockLocker()
+	destroyer := mocks.NewMockDestroyOnCloseRunner()
+	db, err := db.New(t.TempDir())
+	t.Cleanup(func() {
+		db.Close()
+	})
+	Ok(t, err)
+	_, err = db.UpdatePullWithResults(testdata.Pull, []command.ProjectResult{
+		{
+			Command:     command.Plan,
+			RepoRelDir:  ".",
+			Workspace:   "pr-1",
+			PlanSuccess: &models.PlanSuccess{},
+		},
+	})
+	Ok(t, err)
+	pce := events.PullClosedExecutor{
+		Locker:                   l,
+		WorkingDir:               w,
+		Backend:                  db,
+		DestroyOnCloseRunner:     destroyer,
+		LogStreamResourceCleaner: jobmocks.NewMockProjectCommandOutputHandler(),
+	}
+	When(l.UnlockByPull(testdata.GithubRepo.FullName, testdata.Pull.Num)).ThenReturn(nil, nil)
+
+	err = pce.CleanUpPull(logger, testdata.GithubRepo, testdata.GithubRepo, testdata.Pull)
+	Ok(t, err)
+	destroyer.VerifyWasCalled(Never()).DestroyOnClose(
+		Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.Repo](), Any[models.PullRequest](), Any[models.PullStatus]())
+	l.VerifyWasCalledOnce().UnlockByPull(testdata.GithubRepo.FullName, testdata.Pull.Num)
+}
+
+// fakeCommentCleaner records how it was asked to clean up comments.
+type fakeCommentCleaner struct {
+	collapse  bool
+	cleanedUp int
+}
+
+func (f *fakeCommentCleaner) CleanUpComments(_ logging.SimpleLogging, _ models.Repo, _ int, collapse bool) (int, error) {
+	f.collapse = collapse
+	return f.cleanedUp, nil
+}
+
+func TestCleanUpPullCleansUpComments(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+	w := mocks.NewMockWorkingDir()
+	l := lockmocks.NewMockLocker()
+	cp := vcsmocks.NewMockClient()
+	db, err := db.New(t.TempDir())
+	t.Cleanup(func() {
+		db.Close()
+	})
+	Ok(t, err)
+	_, err = db.UpdatePullWithResults(testdata.Pull, []command.ProjectResult{
+		{
+			Command:     command.Plan,
+			RepoRelDir:  "path",
+			Workspace:   "default",
+			PlanSuccess: &models.PlanSuccess{},
+		},
+	})
+	Ok(t, err)
+	cleaner := &fakeCommentCleaner{cleanedUp: 3}
+	pce := events.PullClosedExecutor{
+		Locker:                   l,
+		VCSClient:                cp,
+		WorkingDir:               w,
+		Backend:                  db,
+		LogStreamResourceCleaner: jobmocks.NewMockProjectCommandOutputHandler(),
+		CommentCleanup:           events.CollapseCommentCleanupMode,
+		CommentCleaners:          map[models.VCSHostType]vcs.CommentCleaner{models.Github: cleaner},
+	}
+	When(l.UnlockByPull(testdata.GithubRepo.FullName, testdata.Pull.Num)).ThenReturn([]models.ProjectLock{
+		{
+			Project:   models.NewProject("owner/repo", "path", ""),
+			Workspace: "default",
+		},
+	}, nil)
+
+	err = pce.CleanUpPull(logger, testdata.GithubRepo, testdata.GithubRepo, testdata.Pull)
+	Ok(t, err)
+	Assert(t, cleaner.collapse, "expected the comments to be collapsed")
+	_, _, _, comment, _ := cp.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]()).GetCapturedArguments()
+	Equals(t, "Atlantis cleaned up its 3 comments on this pull request since it's closed. The last status of its projects was:\n\n"+
+		"- dir: `path` workspace: `default`: planned\n\n"+
+		"Locks and plans deleted for the projects and workspaces modified in this pull request:\n\n"+
+		"- dir: `path` workspace: `default`", comment)
+}
+
+func TestCleanUpPullKeepsComments(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+	w := mocks.NewMockWorkingDir()
+	l := lockmocks.NewMockLocker()
+	cp := vcsmocks.NewMockClient()
+	db, err := db.New(t.TempDir())
+	t.Cleanup(func() {
+		db.Close()
+	})
+	Ok(t, err)
+	cleaner := &fakeCommentCleaner{cleanedUp: 3}
+	pce := events.PullClosedExecutor{
+		Locker:          l,
+		VCSClient:       cp,
+		WorkingDir:      w,
+		Backend:         db,
+		CommentCleanup:  events.KeepCommentCleanupMode,
+		CommentCleaners: map[models.VCSHostType]vcs.CommentCleaner{models.Github: cleaner},
+	}
+	When(l.UnlockByPull(testdata.GithubRepo.FullName, testdata.Pull.Num)).ThenReturn(nil, nil)
+
+	err = pce.CleanUpPull(logger, testdata.GithubRepo, testdata.GithubRepo, testdata.Pull)
+	Ok(t, err)
+	cp.VerifyWasCalled(Never()).CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+}
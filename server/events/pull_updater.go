@@ -1,6 +1,8 @@
 package events
 
 import (
+	"strings"
+
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/utils"
@@ -10,9 +12,18 @@ type PullUpdater struct {
 	HidePrevPlanComments bool
 	VCSClient            vcs.Client
 	MarkdownRenderer     *MarkdownRenderer
+	// SummaryComments maintains a single summary comment per pull request
+	// that's edited with the results of projects instead of commenting them.
+	// If nil, results are commented.
+	SummaryComments *SummaryCommentUpdater
 }
 
 func (c *PullUpdater) updatePull(ctx *command.Context, cmd PullCommand, res command.Result) {
+	if ctx.Results == nil {
+		ctx.Results = make(map[command.Name]command.Result)
+	}
+	ctx.Results[cmd.CommandName()] = res
+
 	// Log if we got any errors or failures.
 	if res.Error != nil {
 		ctx.Log.Err(res.Error.Error())
@@ -20,6 +31,15 @@ func (c *PullUpdater) updatePull(ctx *command.Context, cmd PullCommand, res comm
 		ctx.Log.Warn(res.Failure)
 	}
 
+	// Errors and failures of the whole command are still commented since
+	// they don't belong to the section of any project.
+	if c.SummaryComments != nil && res.Error == nil && res.Failure == "" && len(res.ProjectResults) > 0 {
+		commentOnProjects := commentedProjectResults(ctx, cmd, res.ProjectResults)
+		if len(commentOnProjects) == 0 || c.SummaryComments.Update(ctx, cmd, commentOnProjects) {
+			return
+		}
+	}
+
 	// HidePrevCommandComments will hide old comments left from previous runs to reduce
 	// clutter in a pull/merge request. This will not delete the comment, since the
 	// comment trail may be useful in auditing or backtracing problems.
@@ -31,15 +51,7 @@ func (c *PullUpdater) updatePull(ctx *command.Context, cmd PullCommand, res comm
 	}
 
 	if len(res.ProjectResults) > 0 {
-		var commentOnProjects []command.ProjectResult
-		for _, result := range res.ProjectResults {
-			if utils.SlicesContains(result.SilencePRComments, cmd.CommandName().String()) {
-				ctx.Log.Debug("silenced command '%s' comment for project '%s'", cmd.CommandName().String(), result.ProjectName)
-				continue
-			}
-			commentOnProjects = append(commentOnProjects, result)
-		}
-
+		commentOnProjects := commentedProjectResults(ctx, cmd, res.ProjectResults)
 		if len(commentOnProjects) == 0 {
 			return
 		}
@@ -48,7 +60,24 @@ func (c *PullUpdater) updatePull(ctx *command.Context, cmd PullCommand, res comm
 	}
 
 	comment := c.MarkdownRenderer.Render(ctx, res, cmd)
+	if len(ctx.PreWorkflowHookOutputs) > 0 {
+		comment = strings.Join(ctx.PreWorkflowHookOutputs, "\n\n") + "\n\n" + comment
+	}
 	if err := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, comment, cmd.CommandName().String()); err != nil {
 		ctx.Log.Err("unable to comment: %s", err)
 	}
 }
+
+// commentedProjectResults returns the results that aren't silenced for the
+// command.
+func commentedProjectResults(ctx *command.Context, cmd PullCommand, results []command.ProjectResult) []command.ProjectResult {
+	var commentOnProjects []command.ProjectResult
+	for _, result := range results {
+		if utils.SlicesContains(result.SilencePRComments, cmd.CommandName().String()) {
+			ctx.Log.Debug("silenced command '%s' comment for project '%s'", cmd.CommandName().String(), result.ProjectName)
+			continue
+		}
+		commentOnProjects = append(commentOnProjects, result)
+	}
+	return commentOnProjects
+}
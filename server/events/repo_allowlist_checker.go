@@ -15,6 +15,7 @@ package events
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -29,20 +30,30 @@ type RepoAllowlistChecker struct {
 }
 
 // NewRepoAllowlistChecker constructs a new checker and validates that the
-// allowlist isn't malformed.
+// allowlist isn't malformed. Rules starting with ! exclude the repos they
+// match, ex. github.com/myorg/*,!github.com/myorg/sandbox-* allows every repo
+// of myorg except the sandbox ones.
 func NewRepoAllowlistChecker(allowlist string) (*RepoAllowlistChecker, error) {
 	includeRules := make([]string, 0)
 	omitRules := make([]string, 0)
 	for _, rule := range strings.Split(allowlist, ",") {
+		rule = strings.TrimSpace(rule)
 		if strings.Contains(rule, "://") {
 			return nil, fmt.Errorf("allowlist %q contained ://", rule)
 		}
-		if len(rule) > 1 && rule[0] == '!' {
-			omitRules = append(omitRules, rule[1:])
+		if strings.HasPrefix(rule, "!") {
+			omitRule := strings.TrimSpace(rule[1:])
+			if omitRule == "" {
+				return nil, fmt.Errorf("allowlist %q must be followed by a repo to exclude", rule)
+			}
+			omitRules = append(omitRules, omitRule)
 		} else {
 			includeRules = append(includeRules, rule)
 		}
 	}
+	if len(omitRules) > 0 && !slices.ContainsFunc(includeRules, func(rule string) bool { return rule != "" }) {
+		return nil, fmt.Errorf("allowlist %q only excludes repos, it must also include the repos to allow, ex. '*,%s'", allowlist, allowlist)
+	}
 	return &RepoAllowlistChecker{
 		includeRules: includeRules,
 		omitRules:    omitRules,
@@ -52,9 +63,15 @@ func NewRepoAllowlistChecker(allowlist string) (*RepoAllowlistChecker, error) {
 // IsAllowlisted returns true if this repo is in our allowlist and false
 // otherwise.
 func (r *RepoAllowlistChecker) IsAllowlisted(repoFullName string, vcsHostname string) bool {
-	candidate := fmt.Sprintf("%s/%s", vcsHostname, repoFullName)
-	shouldInclude := r.matchesAtLeastOneRule(r.includeRules, candidate)
-	shouldOmit := r.matchesAtLeastOneRule(r.omitRules, candidate)
+	return r.IsRepoIDAllowlisted(fmt.Sprintf("%s/%s", vcsHostname, repoFullName))
+}
+
+// IsRepoIDAllowlisted returns true if the repo with repoID, ex.
+// github.com/runatlantis/atlantis, is in our allowlist and false otherwise.
+// Repos that match an include rule and an exclude rule aren't allowlisted.
+func (r *RepoAllowlistChecker) IsRepoIDAllowlisted(repoID string) bool {
+	shouldInclude := r.matchesAtLeastOneRule(r.includeRules, repoID)
+	shouldOmit := r.matchesAtLeastOneRule(r.omitRules, repoID)
 	return shouldInclude && !shouldOmit
 }
 
@@ -72,38 +89,29 @@ func (r *RepoAllowlistChecker) matchesRule(rule string, candidate string) bool {
 	rule = strings.ToLower(rule)
 	candidate = strings.ToLower(candidate)
 
-	wildcardIdx := strings.Index(rule, Wildcard)
-	if wildcardIdx == -1 {
+	parts := strings.Split(rule, Wildcard)
+	if len(parts) == 1 {
 		// No wildcard so can do a straight up match.
 		return candidate == rule
 	}
 
-	// If the candidate length is less than where we found the wildcard
-	// then it can't be equal. For example:
-	//   rule: abc*
-	//   candidate: ab
-	if len(candidate) < wildcardIdx {
+	// The candidate must start with what's before the first wildcard and end
+	// with what's after the last one. Example:
+	//   rule: github.com/*/sandbox-*
+	//   candidate: github.com/owner/sandbox-repo
+	if !strings.HasPrefix(candidate, parts[0]) {
 		return false
 	}
+	candidate = candidate[len(parts[0]):]
 
-	// If wildcard is not the last character, substring both to compare what is after the wildcard.  Example:
-	// candidate: repo-abc
-	// rule: *-abc
-	// substr(candidate): -abc
-	// substr(rule): -abc
-	if wildcardIdx != len(rule)-1 {
-		// If the rule substring after wildcard does not exist in the candidate, then it is not a match.
-		idx := strings.LastIndex(candidate, rule[wildcardIdx+1:])
+	// What's between wildcards must be in the candidate in order. Matching
+	// each part as early as possible leaves the most room for the next ones.
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(candidate, part)
 		if idx == -1 {
 			return false
 		}
-		return candidate[idx:] == rule[wildcardIdx+1:]
+		candidate = candidate[idx+len(part):]
 	}
-
-	// If wildcard is last character, substring both so they're comparing before the wildcard. Example:
-	// candidate: abcd
-	// rule: abc*
-	// substr(candidate): abc
-	// substr(rule): abc
-	return candidate[:wildcardIdx] == rule[:wildcardIdx]
+	return strings.HasSuffix(candidate, parts[len(parts)-1])
 }
@@ -189,6 +189,48 @@ func TestRepoAllowlistChecker_IsAllowlisted(t *testing.T) {
 			"github.com",
 			true,
 		},
+		{
+			"should exclude with negative wildcard match",
+			"github.com/owner/*,!github.com/owner/sandbox-*",
+			"owner/sandbox-network",
+			"github.com",
+			false,
+		},
+		{
+			"should match if negative wildcard rule doesn't match",
+			"github.com/owner/*,!github.com/owner/sandbox-*",
+			"owner/network-sandbox",
+			"github.com",
+			true,
+		},
+		{
+			"should exclude with negative rule for any owner",
+			"*, !github.com/*/sandbox-*",
+			"otherorg/sandbox-repo",
+			"github.com",
+			false,
+		},
+		{
+			"should ignore spaces around rules",
+			"github.com/owner/repo , github.com/otherorg/repo",
+			"otherorg/repo",
+			"github.com",
+			true,
+		},
+		{
+			"should match with several wildcards",
+			"github.com/*/infra-*-live",
+			"owner/infra-network-live",
+			"github.com",
+			true,
+		},
+		{
+			"should not match with several wildcards out of order",
+			"github.com/*/infra-*-live",
+			"owner/live-network-infra",
+			"github.com",
+			false,
+		},
 	}
 
 	for _, c := range cases {
@@ -223,3 +265,27 @@ func TestRepoAllowlistChecker_ContainsSchema(t *testing.T) {
 		})
 	}
 }
+
+// If the allowlist has invalid exclude rules then we should get an error.
+func TestRepoAllowlistChecker_InvalidExcludeRules(t *testing.T) {
+	cases := []struct {
+		allowlist string
+		expErr    string
+	}{
+		{
+			"github.com/owner/*,!",
+			`allowlist "!" must be followed by a repo to exclude`,
+		},
+		{
+			"!github.com/owner/sandbox-*",
+			`allowlist "!github.com/owner/sandbox-*" only excludes repos, it must also include the repos to allow, ex. '*,!github.com/owner/sandbox-*'`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.allowlist, func(t *testing.T) {
+			_, err := events.NewRepoAllowlistChecker(c.allowlist)
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
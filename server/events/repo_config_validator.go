@@ -0,0 +1,100 @@
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// DefaultRepoConfigValidator validates the repo config on a repo's default
+// branch against the current server-side config so that configs that were
+// made invalid by a change to the server-side config are found before users
+// run into them.
+type DefaultRepoConfigValidator struct {
+	GlobalCfg       valid.GlobalCfg
+	Logger          logging.SimpleLogging
+	Parser          EventParsing
+	ParserValidator *config.ParserValidator
+	Scope           tally.Scope
+	VCSClient       vcs.Client
+	// VCSHostTypes maps the hostnames of the configured VCS hosts to their
+	// type, ex. github.com => Github.
+	VCSHostTypes     map[string]models.VCSHostType
+	WorkingDir       WorkingDir
+	WorkingDirLocker WorkingDirLocker
+}
+
+// ValidateRepoConfig validates the config of the repo with repoID, ex.
+// github.com/runatlantis/atlantis. Repos without a config are valid.
+func (d *DefaultRepoConfigValidator) ValidateRepoConfig(repoID string) models.RepoConfigStatus {
+	status := models.RepoConfigStatus{
+		RepoID:    repoID,
+		CheckedAt: time.Now(),
+	}
+	baseRepo, err := resolveRepo(d.Logger, d.VCSClient, d.Parser, d.VCSHostTypes, repoID)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if status.Branch, err = remoteDefaultBranch(baseRepo); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	ctx := newBranchContext(d.Logger.With("repo", repoID), d.Scope, baseRepo, status.Branch)
+	hasRepoCfg, repoCfgData, err := d.getRepoCfg(ctx.Log, ctx.Pull, d.GlobalCfg.RepoConfigFile(repoID))
+	if err != nil {
+		status.Error = errors.Wrap(err, "getting repo config").Error()
+		return status
+	}
+	if !hasRepoCfg {
+		return status
+	}
+	if _, err := d.ParserValidator.ParseRepoCfgData(repoCfgData, d.GlobalCfg, repoID, status.Branch); err != nil {
+		status.Invalid = true
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// getRepoCfg returns the contents of the repo config file on the branch of
+// pull. The file is downloaded on its own if the VCS host supports it and
+// otherwise the branch is cloned.
+func (d *DefaultRepoConfigValidator) getRepoCfg(logger logging.SimpleLogging, pull models.PullRequest, repoCfgFile string) (bool, []byte, error) {
+	if d.VCSClient.SupportsSingleFileDownload(pull.BaseRepo) {
+		return d.VCSClient.GetFileContent(logger, pull, repoCfgFile)
+	}
+
+	// We hold the lock until we're done reading since other jobs, ex. drift
+	// detection, clone the same branch into the same working dir.
+	unlockFn, err := d.WorkingDirLocker.TryLock(pull.BaseRepo.FullName, pull.Num, DefaultWorkspace, DefaultRepoRelDir)
+	if err != nil {
+		return false, nil, err
+	}
+	defer unlockFn()
+	if err := d.WorkingDir.Delete(logger, pull.BaseRepo, pull); err != nil {
+		return false, nil, err
+	}
+	repoDir, _, err := d.WorkingDir.Clone(logger, pull.BaseRepo, pull, DefaultWorkspace)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "cloning repo")
+	}
+	defer d.WorkingDir.Delete(logger, pull.BaseRepo, pull) // nolint: errcheck
+
+	repoCfgData, err := os.ReadFile(filepath.Join(repoDir, repoCfgFile))
+	if os.IsNotExist(err) {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	return true, repoCfgData, nil
+}
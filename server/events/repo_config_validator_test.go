@@ -0,0 +1,95 @@
+package events_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestDefaultRepoConfigValidator_ValidateRepoConfig(t *testing.T) {
+	cases := []struct {
+		description string
+		repoCfg     string
+		hasRepoCfg  bool
+		expInvalid  bool
+		expErr      string
+	}{
+		{
+			description: "no repo config",
+		},
+		{
+			description: "valid",
+			repoCfg:     "version: 3\nprojects:\n- dir: .\n",
+			hasRepoCfg:  true,
+		},
+		{
+			description: "override not allowed by server config",
+			repoCfg:     "version: 3\nprojects:\n- dir: .\n  workflow: custom\n",
+			hasRepoCfg:  true,
+			expInvalid:  true,
+			expErr:      "repo config not allowed to set 'workflow' key: server-side config needs 'allowed_overrides: [workflow]'",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			vcsClient := vcsmocks.NewMockClient()
+			parser := mocks.NewMockEventParsing()
+
+			// The default branch is looked up with git so the clone URL
+			// needs to be a real repo.
+			baseRepo := models.Repo{FullName: "org/infra", CloneURL: initRepo(t)}
+			When(vcsClient.GetCloneURL(Any[logging.SimpleLogging](), Eq(models.Github), Eq("org/infra"))).
+				ThenReturn(baseRepo.CloneURL, nil)
+			When(parser.ParseAPIPlanRequest(Eq(models.Github), Eq("org/infra"), Eq(baseRepo.CloneURL))).ThenReturn(baseRepo, nil)
+			When(vcsClient.SupportsSingleFileDownload(Eq(baseRepo))).ThenReturn(true)
+			When(vcsClient.GetFileContent(Any[logging.SimpleLogging](), Any[models.PullRequest](), Eq("atlantis.yaml"))).
+				ThenReturn(c.hasRepoCfg, []byte(c.repoCfg), nil)
+
+			validator := events.DefaultRepoConfigValidator{
+				GlobalCfg:        valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}),
+				Logger:           logging.NewNoopLogger(t),
+				Parser:           parser,
+				ParserValidator:  &config.ParserValidator{},
+				Scope:            tally.NewTestScope("atlantis", nil),
+				VCSClient:        vcsClient,
+				VCSHostTypes:     map[string]models.VCSHostType{"github.com": models.Github},
+				WorkingDir:       mocks.NewMockWorkingDir(),
+				WorkingDirLocker: mocks.NewMockWorkingDirLocker(),
+			}
+			status := validator.ValidateRepoConfig("github.com/org/infra")
+			Equals(t, "github.com/org/infra", status.RepoID)
+			Equals(t, "main", status.Branch)
+			Equals(t, c.expInvalid, status.Invalid)
+			Equals(t, c.expErr, status.Error)
+
+			_, pull, _ := vcsClient.VerifyWasCalledOnce().
+				GetFileContent(Any[logging.SimpleLogging](), Any[models.PullRequest](), Eq("atlantis.yaml")).
+				GetCapturedArguments()
+			Equals(t, "main", pull.HeadBranch)
+		})
+	}
+}
+
+func TestDefaultRepoConfigValidator_ValidateRepoConfig_UnknownHost(t *testing.T) {
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	validator := events.DefaultRepoConfigValidator{
+		GlobalCfg:    valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}),
+		Logger:       logging.NewNoopLogger(t),
+		VCSClient:    vcsClient,
+		VCSHostTypes: map[string]models.VCSHostType{"github.com": models.Github},
+	}
+	status := validator.ValidateRepoConfig("gitlab.com/org/infra")
+	Equals(t, false, status.Invalid)
+	Equals(t, `no VCS host configured for "gitlab.com"`, status.Error)
+}
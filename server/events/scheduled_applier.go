@@ -0,0 +1,229 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/logging"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// ScheduledApplyUser is the user that scheduled applies are run as. It's shown
+// in locks and apply webhooks.
+const ScheduledApplyUser = "atlantis-scheduler"
+
+// DefaultScheduledApplier re-plans and applies projects from a branch on a
+// schedule. Each project goes through the same plan, policy check and apply
+// steps as a pull request, including its apply requirements, so requirements
+// that need a pull request, ex. approved, will never pass.
+// Like drift detection, each run has its own pseudo pull request so runs don't
+// share working dirs or locks, and only the locks of the run are released when
+// it's done.
+type DefaultScheduledApplier struct {
+	Locker                          locking.Locker
+	Logger                          logging.SimpleLogging
+	Parser                          EventParsing
+	ProjectCommandBuilder           ProjectCommandBuilder
+	ProjectPlanCommandRunner        ProjectPlanCommandRunner
+	ProjectPolicyCheckCommandRunner ProjectPolicyCheckCommandRunner
+	ProjectApplyCommandRunner       ProjectApplyCommandRunner
+	Scope                           tally.Scope
+	VCSClient                       vcs.Client
+	// VCSHostTypes maps the hostnames of the configured VCS hosts to their
+	// type, ex. github.com => Github.
+	VCSHostTypes map[string]models.VCSHostType
+	// Webhooks is used to report projects that fail before they're applied.
+	// Applies report their own results.
+	Webhooks         WebhooksSender
+	WorkingDir       WorkingDir
+	WorkingDirLocker WorkingDirLocker
+	// MaintenanceWindows skips scheduled applies during the maintenance
+	// windows of repos. If nil, there are no maintenance windows.
+	MaintenanceWindows MaintenanceWindowChecker
+	// ShadowModeChecker skips scheduled applies of repos in shadow mode,
+	// which only run plans. If nil, no repos are in shadow mode.
+	ShadowModeChecker vcs.ShadowModeChecker
+	// PlanCache, PlanHistory and PlanStore are cleaned up after each run
+	// since plans are saved in them under the run's pseudo pull request.
+	// They can be nil.
+	PlanCache   PlanCache
+	PlanHistory *PlanHistory
+	PlanStore   PlanStore
+}
+
+// ScheduledApply plans, policy checks and applies each of the projects in
+// scheduledApply. It returns the result of the last command run for each
+// project.
+func (a *DefaultScheduledApplier) ScheduledApply(repo valid.Repo, scheduledApply valid.ScheduledApply) ([]command.ProjectResult, error) {
+	if a.ShadowModeChecker != nil && a.ShadowModeChecker.ShadowMode(repo.ID) {
+		return nil, fmt.Errorf("%s is in shadow mode so it can only be planned", repo.ID)
+	}
+	if msg := maintenanceWindowMessage(a.MaintenanceWindows, repo.ID, time.Now()); msg != "" {
+		return nil, errors.New(msg)
+	}
+
+	baseRepo, err := resolveRepo(a.Logger, a.VCSClient, a.Parser, a.VCSHostTypes, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	branch := scheduledApply.Branch
+	if branch == "" {
+		if branch, err = remoteDefaultBranch(baseRepo); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := newBranchContext(a.Logger.With("repo", repo.ID), a.Scope, baseRepo, branch)
+	ctx.User = models.User{Username: ScheduledApplyUser}
+	defer cleanUpBranchRun(ctx, a.WorkingDir, a.Locker, a.PlanCache, a.PlanHistory, a.PlanStore)
+	if err := cloneBranch(a.WorkingDir, a.WorkingDirLocker, ctx); err != nil {
+		return nil, errors.Wrap(err, "cloning repo")
+	}
+
+	var results []command.ProjectResult
+	for _, project := range scheduledApply.Projects {
+		results = append(results, a.applyProject(ctx, project)...)
+	}
+	return results, nil
+}
+
+// applyProject runs plan, policy check and apply for the projects matching
+// project.
+func (a *DefaultScheduledApplier) applyProject(ctx *command.Context, project valid.ScheduledApplyProject) []command.ProjectResult {
+	cmds, err := a.ProjectCommandBuilder.BuildPlanCommands(ctx, &CommentCommand{
+		Name:        command.Plan,
+		ProjectName: project.Name,
+		RepoRelDir:  project.Dir,
+		Workspace:   project.Workspace,
+	})
+	if err != nil {
+		result := command.ProjectResult{
+			Command:     command.Plan,
+			ProjectName: project.Name,
+			RepoRelDir:  project.Dir,
+			Workspace:   project.Workspace,
+			Error:       errors.Wrap(err, "building plan command"),
+		}
+		a.sendFailure(ctx, result)
+		return []command.ProjectResult{result}
+	}
+
+	// The apply requirements are checked against the pull status, ex.
+	// policies_passed, so we build it from the results as we go.
+	pullStatus := models.PullStatus{Pull: ctx.Pull}
+	var planned []command.ProjectResult
+	var policyCheckCmds []command.ProjectContext
+	for _, cmd := range cmds {
+		if cmd.CommandName == command.PolicyCheck {
+			policyCheckCmds = append(policyCheckCmds, cmd)
+			continue
+		}
+		result := a.ProjectPlanCommandRunner.Plan(cmd)
+		pullStatus.Projects = append(pullStatus.Projects, projectStatus(result))
+		planned = append(planned, result)
+	}
+	for _, cmd := range policyCheckCmds {
+		if !planSucceeded(pullStatus, cmd) {
+			continue
+		}
+		result := a.ProjectPolicyCheckCommandRunner.PolicyCheck(cmd)
+		for i, p := range pullStatus.Projects {
+			if p.RepoRelDir == result.RepoRelDir && p.Workspace == result.Workspace && p.ProjectName == result.ProjectName {
+				pullStatus.Projects[i].PolicyStatus = result.PolicyStatus()
+			}
+		}
+	}
+	// ctx is shared by the scheduled projects so each one is applied with a
+	// copy that has its own pull status.
+	projCtx := *ctx
+	projCtx.PullStatus = &pullStatus
+	ctx = &projCtx
+
+	var results []command.ProjectResult
+	for _, planResult := range planned {
+		if planResult.Error != nil || planResult.Failure != "" {
+			a.sendFailure(ctx, planResult)
+			results = append(results, planResult)
+			continue
+		}
+		if planResult.PlanSuccess == nil || planResult.PlanSuccess.NoChanges() {
+			ctx.Log.Info("no changes to apply in %s", planResult.RepoRelDir)
+			results = append(results, planResult)
+			continue
+		}
+		results = append(results, a.apply(ctx, planResult)...)
+	}
+	return results
+}
+
+// apply applies the project that was planned in planResult.
+func (a *DefaultScheduledApplier) apply(ctx *command.Context, planResult command.ProjectResult) []command.ProjectResult {
+	cmd := &CommentCommand{Name: command.Apply, ProjectName: planResult.ProjectName}
+	if planResult.ProjectName == "" {
+		cmd.RepoRelDir = planResult.RepoRelDir
+		cmd.Workspace = planResult.Workspace
+	}
+	applyCmds, err := a.ProjectCommandBuilder.BuildApplyCommands(ctx, cmd)
+	if err != nil {
+		result := planResult
+		result.Command = command.Apply
+		result.PlanSuccess = nil
+		result.Error = errors.Wrap(err, "building apply command")
+		a.sendFailure(ctx, result)
+		return []command.ProjectResult{result}
+	}
+
+	var results []command.ProjectResult
+	for _, applyCmd := range applyCmds {
+		result := a.ProjectApplyCommandRunner.Apply(applyCmd)
+		// Failed apply requirements are returned as failures before the
+		// apply runs so they haven't been reported yet.
+		if result.Failure != "" {
+			a.sendFailure(ctx, result)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// sendFailure reports a project that failed before it could be applied to the
+// apply webhooks.
+func (a *DefaultScheduledApplier) sendFailure(ctx *command.Context, result command.ProjectResult) {
+	a.Webhooks.Send(ctx.Log, webhooks.ApplyResult{ // nolint: errcheck
+		Workspace:   result.Workspace,
+		User:        ctx.User,
+		Repo:        ctx.Pull.BaseRepo,
+		Pull:        ctx.Pull,
+		Success:     false,
+		Directory:   result.RepoRelDir,
+		ProjectName: result.ProjectName,
+	})
+}
+
+func projectStatus(result command.ProjectResult) models.ProjectStatus {
+	return models.ProjectStatus{
+		Workspace:    result.Workspace,
+		RepoRelDir:   result.RepoRelDir,
+		ProjectName:  result.ProjectName,
+		PolicyStatus: result.PolicyStatus(),
+		Status:       result.PlanStatus(),
+	}
+}
+
+// planSucceeded returns true if the project of cmd was planned successfully.
+func planSucceeded(pullStatus models.PullStatus, cmd command.ProjectContext) bool {
+	for _, p := range pullStatus.Projects {
+		if p.RepoRelDir == cmd.RepoRelDir && p.Workspace == cmd.Workspace && p.ProjectName == cmd.ProjectName {
+			return p.Status == models.PlannedPlanStatus || p.Status == models.PlannedNoChangesPlanStatus
+		}
+	}
+	return false
+}
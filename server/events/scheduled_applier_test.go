@@ -0,0 +1,195 @@
+package events_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	lockingmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestDefaultScheduledApplier_ScheduledApply(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	vcsClient := vcsmocks.NewMockClient()
+	parser := mocks.NewMockEventParsing()
+	workingDir := mocks.NewMockWorkingDir()
+	workingDirLocker := mocks.NewMockWorkingDirLocker()
+	projectCommandBuilder := mocks.NewMockProjectCommandBuilder()
+	projectCommandRunner := mocks.NewMockProjectCommandRunner()
+	webhooksSender := mocks.NewMockWebhooksSender()
+	locker := lockingmocks.NewMockLocker()
+	planCache := mocks.NewMockPlanCache()
+
+	baseRepo := models.Repo{FullName: "org/infra", CloneURL: "https://github.com/org/infra.git"}
+	repoDir := initRepo(t)
+	sha := strings.TrimSpace(runCmd(t, repoDir, "git", "rev-parse", "HEAD"))
+	When(workingDir.Clone(Any[logging.SimpleLogging](), Eq(baseRepo), Any[models.PullRequest](), Eq(events.DefaultWorkspace))).
+		ThenReturn(repoDir, false, nil)
+	When(vcsClient.GetCloneURL(Any[logging.SimpleLogging](), Eq(models.Github), Eq("org/infra"))).
+		ThenReturn(baseRepo.CloneURL, nil)
+	When(parser.ParseAPIPlanRequest(Eq(models.Github), Eq("org/infra"), Eq(baseRepo.CloneURL))).ThenReturn(baseRepo, nil)
+	When(workingDirLocker.TryLock(Eq("org/infra"), Any[int](), Eq(events.DefaultWorkspace), Eq(events.DefaultRepoRelDir))).
+		ThenReturn(func() {}, nil)
+
+	certsPlan := command.ProjectContext{CommandName: command.Plan, ProjectName: "certs", RepoRelDir: "certs", Workspace: "default"}
+	certsPolicyCheck := command.ProjectContext{CommandName: command.PolicyCheck, ProjectName: "certs", RepoRelDir: "certs", Workspace: "default"}
+	certsApply := command.ProjectContext{CommandName: command.Apply, ProjectName: "certs", RepoRelDir: "certs", Workspace: "default"}
+	noop := command.ProjectContext{CommandName: command.Plan, RepoRelDir: "noop", Workspace: "default"}
+	broken := command.ProjectContext{CommandName: command.Plan, RepoRelDir: "broken", Workspace: "default"}
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Eq(&events.CommentCommand{
+		Name:        command.Plan,
+		ProjectName: "certs",
+	}))).ThenReturn([]command.ProjectContext{certsPlan, certsPolicyCheck}, nil)
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Eq(&events.CommentCommand{
+		Name:       command.Plan,
+		RepoRelDir: "noop",
+	}))).ThenReturn([]command.ProjectContext{noop}, nil)
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Eq(&events.CommentCommand{
+		Name:       command.Plan,
+		RepoRelDir: "broken",
+	}))).ThenReturn([]command.ProjectContext{broken}, nil)
+	When(projectCommandBuilder.BuildApplyCommands(Any[*command.Context](), Eq(&events.CommentCommand{
+		Name:        command.Apply,
+		ProjectName: "certs",
+	}))).ThenReturn([]command.ProjectContext{certsApply}, nil)
+
+	When(projectCommandRunner.Plan(Eq(certsPlan))).ThenReturn(command.ProjectResult{
+		Command:     command.Plan,
+		ProjectName: "certs",
+		RepoRelDir:  "certs",
+		Workspace:   "default",
+		PlanSuccess: &models.PlanSuccess{TerraformOutput: "Plan: 1 to add, 0 to change, 1 to destroy."},
+	})
+	When(projectCommandRunner.PolicyCheck(Eq(certsPolicyCheck))).ThenReturn(command.ProjectResult{
+		Command:     command.PolicyCheck,
+		ProjectName: "certs",
+		RepoRelDir:  "certs",
+		Workspace:   "default",
+		PolicyCheckResults: &models.PolicyCheckResults{
+			PolicySetResults: []models.PolicySetResult{{PolicySetName: "certs", Passed: true}},
+		},
+	})
+	When(projectCommandRunner.Apply(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{
+		Command:      command.Apply,
+		ProjectName:  "certs",
+		RepoRelDir:   "certs",
+		Workspace:    "default",
+		ApplySuccess: "Apply complete!",
+	})
+	noopResult := command.ProjectResult{
+		Command:     command.Plan,
+		RepoRelDir:  "noop",
+		Workspace:   "default",
+		PlanSuccess: &models.PlanSuccess{TerraformOutput: "No changes. Your infrastructure matches the configuration."},
+	}
+	When(projectCommandRunner.Plan(Eq(noop))).ThenReturn(noopResult)
+	brokenResult := command.ProjectResult{
+		Command:    command.Plan,
+		RepoRelDir: "broken",
+		Workspace:  "default",
+		Error:      errors.New("plan failed"),
+	}
+	When(projectCommandRunner.Plan(Eq(broken))).ThenReturn(brokenResult)
+
+	applier := events.DefaultScheduledApplier{
+		Locker:                          locker,
+		Logger:                          logger,
+		Parser:                          parser,
+		ProjectCommandBuilder:           projectCommandBuilder,
+		ProjectPlanCommandRunner:        projectCommandRunner,
+		ProjectPolicyCheckCommandRunner: projectCommandRunner,
+		ProjectApplyCommandRunner:       projectCommandRunner,
+		Scope:                           tally.NewTestScope("atlantis", nil),
+		VCSClient:                       vcsClient,
+		VCSHostTypes:                    map[string]models.VCSHostType{"github.com": models.Github},
+		Webhooks:                        webhooksSender,
+		WorkingDir:                      workingDir,
+		WorkingDirLocker:                workingDirLocker,
+		PlanCache:                       planCache,
+	}
+	schedule, err := valid.ParseCronSchedule("@daily")
+	Ok(t, err)
+	results, err := applier.ScheduledApply(valid.Repo{ID: "github.com/org/infra"}, valid.ScheduledApply{
+		Schedule: schedule,
+		Branch:   "main",
+		Projects: []valid.ScheduledApplyProject{
+			{Name: "certs"},
+			{Dir: "noop"},
+			{Dir: "broken"},
+		},
+	})
+	Ok(t, err)
+	Equals(t, 3, len(results))
+	Equals(t, "Apply complete!", results[0].ApplySuccess)
+	Equals(t, noopResult, results[1])
+	Equals(t, brokenResult, results[2])
+
+	// The apply is checked against the policy check results.
+	applyCtx, _ := projectCommandBuilder.VerifyWasCalledOnce().BuildApplyCommands(Any[*command.Context](), Any[*events.CommentCommand]()).
+		GetCapturedArguments()
+	Equals(t, models.User{Username: events.ScheduledApplyUser}, applyCtx.User)
+	Equals(t, []models.PolicySetStatus{{PolicySetName: "certs", Passed: true}}, applyCtx.PullStatus.Projects[0].PolicyStatus)
+	projectCommandRunner.VerifyWasCalledOnce().Apply(Eq(certsApply))
+
+	// Only the project that failed before it was applied is reported.
+	_, _, pull, _ := workingDir.VerifyWasCalledOnce().Clone(Any[logging.SimpleLogging](), Eq(baseRepo), Any[models.PullRequest](), Eq(events.DefaultWorkspace)).GetCapturedArguments()
+	Assert(t, pull.Num < 0, "expected a negative pull number, got %d", pull.Num)
+	webhooksSender.VerifyWasCalledOnce().Send(Any[logging.SimpleLogging](), Eq(webhooks.ApplyResult{
+		Workspace: "default",
+		User:      models.User{Username: events.ScheduledApplyUser},
+		Repo:      baseRepo,
+		Pull: models.PullRequest{
+			Num:        pull.Num,
+			BaseBranch: "main",
+			HeadBranch: "main",
+			HeadCommit: sha,
+			BaseRepo:   baseRepo,
+		},
+		Directory: "broken",
+	}))
+	webhooksSender.VerifyWasCalledOnce().Send(Any[logging.SimpleLogging](), Any[webhooks.ApplyResult]())
+	// Only the locks of this run are released, not those of API requests.
+	// The clone starts from scratch and everything stored under the pseudo
+	// pull request is cleaned up afterwards.
+	clonedPull := pull
+	clonedPull.HeadCommit = sha
+	workingDir.VerifyWasCalledOnce().Delete(Any[logging.SimpleLogging](), Eq(baseRepo), Eq(pull))
+	workingDir.VerifyWasCalledOnce().Delete(Any[logging.SimpleLogging](), Eq(baseRepo), Eq(clonedPull))
+	planCache.VerifyWasCalledOnce().Delete(Eq(baseRepo), Eq(clonedPull))
+	locker.VerifyWasCalledOnce().UnlockByPull("org/infra", pull.Num)
+	locker.VerifyWasCalled(Never()).UnlockByPull("org/infra", 0)
+}
+
+func TestDefaultScheduledApplier_ScheduledApply_ShadowMode(t *testing.T) {
+	RegisterMockTestingT(t)
+	workingDir := mocks.NewMockWorkingDir()
+	projectCommandRunner := mocks.NewMockProjectCommandRunner()
+	shadowMode := true
+	applier := events.DefaultScheduledApplier{
+		Logger:                    logging.NewNoopLogger(t),
+		ProjectApplyCommandRunner: projectCommandRunner,
+		WorkingDir:                workingDir,
+		ShadowModeChecker: valid.GlobalCfg{
+			Repos: []valid.Repo{{ID: "github.com/org/infra", ShadowMode: &shadowMode}},
+		},
+	}
+
+	_, err := applier.ScheduledApply(valid.Repo{ID: "github.com/org/infra"}, valid.ScheduledApply{
+		Projects: []valid.ScheduledApplyProject{{Name: "certs"}},
+	})
+	ErrEquals(t, "github.com/org/infra is in shadow mode so it can only be planned", err)
+	workingDir.VerifyWasCalled(Never()).Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[string]())
+	projectCommandRunner.VerifyWasCalled(Never()).Apply(Any[command.ProjectContext]())
+}
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// stackedPullCommentMarker is the first line of the warnings about stacked
+// pull requests so that the previous warnings can be hidden when a new one is
+// posted.
+const stackedPullCommentMarker = "<!-- atlantis:stacked-pull -->"
+
+// StackedPullChecker detects pull requests whose base branch is the head
+// branch of another open pull request. Their plans are run against the
+// parent's branch, so they include the parent's changes until it's applied
+// and merged, and applying them would apply the parent's changes too. Stacked
+// pull requests are only detected, they're still planned against their base
+// branch and not against the merge base with the parent's base branch.
+type StackedPullChecker struct {
+	GlobalCfg valid.GlobalCfg
+	// ParentPullFinders find parent pull requests on the VCS hosts that
+	// support it.
+	ParentPullFinders map[models.VCSHostType]vcs.ParentPullFinder
+}
+
+// StackedPull is the result of checking if a pull request is stacked.
+type StackedPull struct {
+	// ParentNum is the number of the pull request it's stacked on. It's 0 if
+	// the pull request isn't stacked.
+	ParentNum int
+	// Mode is how the stacked pull request is handled.
+	Mode valid.StackedPullsMode
+}
+
+// Check returns the pull request the pull request of ctx is stacked on, if
+// the repo's stacked_pulls setting checks for it.
+func (s *StackedPullChecker) Check(ctx *command.Context) (StackedPull, error) {
+	mode := s.GlobalCfg.StackedPulls(ctx.Pull.BaseRepo.ID())
+	finder, ok := s.ParentPullFinders[ctx.Pull.BaseRepo.VCSHost.Type]
+	if mode == valid.StackedPullsDisabledMode || !ok {
+		return StackedPull{Mode: mode}, nil
+	}
+	parentNum, err := finder.GetParentPull(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull)
+	if err != nil {
+		return StackedPull{Mode: mode}, errors.Wrap(err, "finding the pull request this one is stacked on")
+	}
+	return StackedPull{ParentNum: parentNum, Mode: mode}, nil
+}
+
+// Blocks returns true if the command named cmdName can't be run until the
+// parent pull request is merged.
+func (s StackedPull) Blocks(cmdName command.Name) bool {
+	return s.ParentNum != 0 && s.Mode == valid.StackedPullsBlockMode && cmdName == command.Apply
+}
+
+// Comment returns the comment explaining how the stacked pull request is
+// handled for the command named cmdName.
+func (s StackedPull) Comment(cmdName command.Name, baseBranch string) string {
+	if s.Blocks(cmdName) {
+		return fmt.Sprintf("```\nError: not running apply: this pull request is stacked on #%d, its base branch %s is the head branch of #%d. Apply and merge #%d first.\n```", s.ParentNum, baseBranch, s.ParentNum, s.ParentNum)
+	}
+	return fmt.Sprintf(stackedPullCommentMarker+"\n**Warning**: this pull request is stacked on #%d, its base branch `%s` is the head branch of #%d. "+
+		"Plans are run against `%s` so until #%d is applied and merged, they include its changes, and applying this pull request applies them too.",
+		s.ParentNum, baseBranch, s.ParentNum, baseBranch, s.ParentNum)
+}
@@ -0,0 +1,95 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeParentPullFinder returns the parent of pull requests by base branch.
+type fakeParentPullFinder struct {
+	parents map[string]int
+	err     error
+}
+
+func (f *fakeParentPullFinder) GetParentPull(_ logging.SimpleLogging, _ models.Repo, pull models.PullRequest) (int, error) {
+	return f.parents[pull.BaseBranch], f.err
+}
+
+func newTestStackedPullChecker(mode *valid.StackedPullsMode, finder *fakeParentPullFinder) *events.StackedPullChecker {
+	globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	globalCfg.Repos = append(globalCfg.Repos, valid.Repo{ID: "github.com/org/infra", StackedPulls: mode})
+	return &events.StackedPullChecker{
+		GlobalCfg:         globalCfg,
+		ParentPullFinders: map[models.VCSHostType]vcs.ParentPullFinder{models.Github: finder},
+	}
+}
+
+func newTestStackedPullContext(t *testing.T, baseBranch string) *command.Context {
+	return &command.Context{
+		Log: logging.NewNoopLogger(t),
+		Pull: models.PullRequest{
+			Num:        5,
+			BaseBranch: baseBranch,
+			BaseRepo: models.Repo{
+				FullName: "org/infra",
+				VCSHost:  models.VCSHost{Hostname: "github.com", Type: models.Github},
+			},
+		},
+	}
+}
+
+func TestStackedPullChecker_Check(t *testing.T) {
+	block := valid.StackedPullsBlockMode
+	checker := newTestStackedPullChecker(&block, &fakeParentPullFinder{parents: map[string]int{"feature-a": 2}})
+
+	stackedPull, err := checker.Check(newTestStackedPullContext(t, "feature-a"))
+	Ok(t, err)
+	Equals(t, events.StackedPull{ParentNum: 2, Mode: valid.StackedPullsBlockMode}, stackedPull)
+	Equals(t, false, stackedPull.Blocks(command.Plan))
+	Equals(t, true, stackedPull.Blocks(command.Apply))
+	Equals(t, "```\nError: not running apply: this pull request is stacked on #2, its base branch feature-a is the head branch of #2. Apply and merge #2 first.\n```",
+		stackedPull.Comment(command.Apply, "feature-a"))
+
+	stackedPull, err = checker.Check(newTestStackedPullContext(t, "main"))
+	Ok(t, err)
+	Equals(t, events.StackedPull{Mode: valid.StackedPullsBlockMode}, stackedPull)
+	Equals(t, false, stackedPull.Blocks(command.Apply))
+}
+
+func TestStackedPullChecker_CheckWarn(t *testing.T) {
+	warn := valid.StackedPullsWarnMode
+	checker := newTestStackedPullChecker(&warn, &fakeParentPullFinder{parents: map[string]int{"feature-a": 2}})
+
+	stackedPull, err := checker.Check(newTestStackedPullContext(t, "feature-a"))
+	Ok(t, err)
+	Equals(t, false, stackedPull.Blocks(command.Apply))
+	Equals(t, "<!-- atlantis:stacked-pull -->\n**Warning**: this pull request is stacked on #2, its base branch `feature-a` is the head branch of #2. "+
+		"Plans are run against `feature-a` so until #2 is applied and merged, they include its changes, and applying this pull request applies them too.",
+		stackedPull.Comment(command.Apply, "feature-a"))
+}
+
+// Pull requests aren't checked unless stacked_pulls is set.
+func TestStackedPullChecker_CheckDisabled(t *testing.T) {
+	checker := newTestStackedPullChecker(nil, &fakeParentPullFinder{err: errors.New("should not be called")})
+
+	stackedPull, err := checker.Check(newTestStackedPullContext(t, "feature-a"))
+	Ok(t, err)
+	Equals(t, events.StackedPull{Mode: valid.StackedPullsDisabledMode}, stackedPull)
+}
+
+func TestStackedPullChecker_CheckError(t *testing.T) {
+	block := valid.StackedPullsBlockMode
+	checker := newTestStackedPullChecker(&block, &fakeParentPullFinder{err: errors.New("rate limited")})
+
+	stackedPull, err := checker.Check(newTestStackedPullContext(t, "feature-a"))
+	ErrEquals(t, "finding the pull request this one is stacked on: rate limited", err)
+	Equals(t, valid.StackedPullsBlockMode, stackedPull.Mode)
+}
@@ -0,0 +1,245 @@
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+// StartupReconciler reconciles the working dirs and plans on disk with the
+// database and the VCS hosts when Atlantis starts, so that a crash or restart
+// in the middle of a command doesn't leave them inconsistent.
+type StartupReconciler struct {
+	Backend locking.Backend
+	// DataDir is the Atlantis data dir that the working dirs are cloned
+	// under.
+	DataDir             string
+	Logger              logging.SimpleLogging
+	VCSClient           vcs.Client
+	PendingPlanFinder   PendingPlanFinder
+	WorkingDirLocker    WorkingDirLocker
+	CommitStatusUpdater CommitStatusUpdater
+	// PullCleaner cleans up the pull requests that were closed while
+	// Atlantis was down.
+	PullCleaner PullCleaner
+	// PlanStore holds the plans that are restored before applies, so
+	// planned projects whose plan is stored aren't discarded. It can be nil.
+	PlanStore PlanStore
+}
+
+// ReconcileResult is what was fixed by a reconciliation.
+type ReconcileResult struct {
+	// ClosedPulls is the number of closed pull requests that were cleaned up.
+	ClosedPulls int
+	// OrphanedWorkingDirs is the number of working dirs of pull requests
+	// without a pull status that were deleted.
+	OrphanedWorkingDirs int
+	// OrphanedPlans is the number of plans of projects without a status
+	// that were deleted.
+	OrphanedPlans int
+	// MissingPlans is the number of planned projects without a plan whose
+	// plan was discarded.
+	MissingPlans int
+	// RestoredStatuses is the number of pull requests whose commit statuses
+	// were restored.
+	RestoredStatuses int
+}
+
+// Reconcile cleans up the pull requests that were closed while Atlantis was
+// down and the working dirs of pull requests it doesn't know about. For the
+// pull requests that are still open, it deletes the plans that aren't in
+// their pull status, discards the plans in their pull status that are
+// missing, and restores their plan and apply commit statuses from it since
+// the ones of commands that were running are left pending. Pull requests
+// that are running a command are skipped.
+func (r *StartupReconciler) Reconcile() (ReconcileResult, error) {
+	var result ReconcileResult
+	statuses, err := r.Backend.GetPullStatuses()
+	if err != nil {
+		return result, errors.Wrap(err, "getting pull statuses")
+	}
+	pullDirs, err := findPullDirs(r.DataDir)
+	if err != nil {
+		return result, errors.Wrap(err, "finding working dirs")
+	}
+
+	hasStatus := make(map[string]bool)
+	for _, status := range statuses {
+		hasStatus[r.pullKey(status.Pull.BaseRepo.FullName, status.Pull.Num)] = true
+		if err := r.reconcilePull(status, &result); err != nil {
+			r.Logger.Err("reconciling pull request %s#%d: %s", status.Pull.BaseRepo.FullName, status.Pull.Num, err)
+		}
+	}
+
+	for _, pullDir := range pullDirs {
+		if hasStatus[r.pullKey(pullDir.repoFullName, pullDir.pullNum)] {
+			continue
+		}
+		if err := r.deleteOrphanedPullDir(pullDir, &result); err != nil {
+			r.Logger.Err("deleting working dir '%s': %s", pullDir.path, err)
+		}
+	}
+	return result, nil
+}
+
+func (r *StartupReconciler) reconcilePull(status models.PullStatus, result *ReconcileResult) error {
+	pull := status.Pull
+	state, err := r.VCSClient.GetPullState(r.Logger, pull.BaseRepo, pull)
+	if err != nil {
+		return errors.Wrap(err, "getting pull request state")
+	}
+	if state != models.OpenPullState {
+		r.Logger.Info("cleaning up pull request %s#%d since it was closed while Atlantis was down", pull.BaseRepo.FullName, pull.Num)
+		// The head repo isn't known from the pull status.
+		if err := r.PullCleaner.CleanUpPull(r.Logger, pull.BaseRepo, models.Repo{}, pull); err != nil {
+			return errors.Wrap(err, "cleaning up closed pull request")
+		}
+		result.ClosedPulls++
+		return nil
+	}
+
+	unlock, err := r.WorkingDirLocker.TryLockPull(pull.BaseRepo.FullName, pull.Num)
+	if err != nil {
+		r.Logger.Debug("skipping pull request %s#%d since a command is running", pull.BaseRepo.FullName, pull.Num)
+		return nil
+	}
+	defer unlock()
+
+	if err := r.reconcilePlans(&status, result); err != nil {
+		return err
+	}
+	if r.restoreCommitStatuses(status) {
+		result.RestoredStatuses++
+	}
+	return nil
+}
+
+// reconcilePlans deletes the plans of the pull request that aren't in its
+// pull status and discards the planned projects of status without a plan.
+func (r *StartupReconciler) reconcilePlans(status *models.PullStatus, result *ReconcileResult) error {
+	pull := status.Pull
+	pullDir := filepath.Join(r.DataDir, workingDirPrefix, pull.BaseRepo.FullName, strconv.Itoa(pull.Num))
+	var plans []PendingPlan
+	if _, err := os.Stat(pullDir); err == nil {
+		plans, err = r.PendingPlanFinder.Find(pullDir)
+		if err != nil {
+			return errors.Wrap(err, "finding plans")
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	hasProject := make(map[string]bool)
+	for _, project := range status.Projects {
+		hasProject[project.Workspace+"/"+project.RepoRelDir] = true
+	}
+	hasPlan := make(map[string]bool)
+	for _, plan := range plans {
+		if hasProject[plan.Workspace+"/"+plan.RepoRelDir] {
+			hasPlan[plan.Workspace+"/"+plan.RepoRelDir] = true
+			continue
+		}
+		path := pendingPlanPath(plan)
+		r.Logger.Info("deleting plan '%s' since it isn't in the pull status of %s#%d", path, pull.BaseRepo.FullName, pull.Num)
+		if err := utils.RemoveIgnoreNonExistent(path); err != nil {
+			return errors.Wrap(err, "deleting plan")
+		}
+		result.OrphanedPlans++
+	}
+
+	for i, project := range status.Projects {
+		if project.Status != models.PlannedPlanStatus || hasPlan[project.Workspace+"/"+project.RepoRelDir] {
+			continue
+		}
+		if r.hasStoredPlan(pull, project) {
+			continue
+		}
+		r.Logger.Info("discarding the plan of dir '%s' workspace '%s' of %s#%d since it's missing", project.RepoRelDir, project.Workspace, pull.BaseRepo.FullName, pull.Num)
+		if err := r.Backend.UpdateProjectStatus(pull, project.Workspace, project.RepoRelDir, models.DiscardedPlanStatus); err != nil {
+			return errors.Wrapf(err, "discarding plan status of dir '%s' workspace '%s'", project.RepoRelDir, project.Workspace)
+		}
+		status.Projects[i].Status = models.DiscardedPlanStatus
+		result.MissingPlans++
+	}
+	return nil
+}
+
+// hasStoredPlan returns true if the plan of project, planned on the head
+// commit of pull, is in the plan store.
+func (r *StartupReconciler) hasStoredPlan(pull models.PullRequest, project models.ProjectStatus) bool {
+	if r.PlanStore == nil {
+		return false
+	}
+	meta, ok, err := r.PlanStore.Metadata(pull, project.Workspace, project.RepoRelDir, project.ProjectName)
+	if err != nil {
+		r.Logger.Warn("getting stored plan of dir '%s' workspace '%s' of %s#%d: %s", project.RepoRelDir, project.Workspace, pull.BaseRepo.FullName, pull.Num, err)
+		return false
+	}
+	return ok && meta.HeadCommit == pull.HeadCommit
+}
+
+// restoreCommitStatuses sets the plan and apply commit statuses of the pull
+// request from its pull status. It returns true if they were set.
+func (r *StartupReconciler) restoreCommitStatuses(status models.PullStatus) bool {
+	if len(status.Projects) == 0 {
+		return false
+	}
+	pull := status.Pull
+	numTotal := len(status.Projects)
+
+	planErrored := status.StatusCount(models.ErroredPlanStatus)
+	planStatus := models.SuccessCommitStatus
+	if planErrored > 0 {
+		planStatus = models.FailedCommitStatus
+	}
+	if err := r.CommitStatusUpdater.UpdateCombinedCount(r.Logger, pull.BaseRepo, pull, planStatus, command.Plan, numTotal-planErrored, numTotal); err != nil {
+		r.Logger.Warn("unable to restore plan commit status of %s#%d: %s", pull.BaseRepo.FullName, pull.Num, err)
+		return false
+	}
+
+	// The apply commit status is only set once a project was applied.
+	applied := status.StatusCount(models.AppliedPlanStatus)
+	applyErrored := status.StatusCount(models.ErroredApplyStatus)
+	if applied == 0 && applyErrored == 0 {
+		return true
+	}
+	numSuccess := applied + status.StatusCount(models.PlannedNoChangesPlanStatus)
+	applyStatus := models.PendingCommitStatus
+	if applyErrored > 0 {
+		applyStatus = models.FailedCommitStatus
+	} else if numSuccess == numTotal {
+		applyStatus = models.SuccessCommitStatus
+	}
+	if err := r.CommitStatusUpdater.UpdateCombinedCount(r.Logger, pull.BaseRepo, pull, applyStatus, command.Apply, numSuccess, numTotal); err != nil {
+		r.Logger.Warn("unable to restore apply commit status of %s#%d: %s", pull.BaseRepo.FullName, pull.Num, err)
+	}
+	return true
+}
+
+func (r *StartupReconciler) deleteOrphanedPullDir(pullDir gcPullDir, result *ReconcileResult) error {
+	unlock, err := r.WorkingDirLocker.TryLockPull(pullDir.repoFullName, pullDir.pullNum)
+	if err != nil {
+		r.Logger.Debug("skipping working dir '%s' since a command is running", pullDir.path)
+		return nil
+	}
+	defer unlock()
+
+	r.Logger.Info("deleting working dir '%s' since its pull request has no pull status", pullDir.path)
+	if err := os.RemoveAll(pullDir.path); err != nil {
+		return err
+	}
+	result.OrphanedWorkingDirs++
+	return nil
+}
+
+func (r *StartupReconciler) pullKey(repoFullName string, pullNum int) string {
+	return repoFullName + "#" + strconv.Itoa(pullNum)
+}
@@ -0,0 +1,155 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	lockmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	"gocloud.dev/blob/memblob"
+)
+
+func TestStartupReconciler_Reconcile(t *testing.T) {
+	RegisterMockTestingT(t)
+	dataDir := t.TempDir()
+	backend := lockmocks.NewMockBackend()
+	vcsClient := vcsmocks.NewMockClient()
+	commitStatusUpdater := mocks.NewMockCommitStatusUpdater()
+	pullCleaner := mocks.NewMockPullCleaner()
+	repo := models.Repo{FullName: "org/app"}
+	openPull := models.PullRequest{Num: 1, BaseRepo: repo}
+	closedPull := models.PullRequest{Num: 2, BaseRepo: repo}
+	When(backend.GetPullStatuses()).ThenReturn([]models.PullStatus{
+		{
+			Pull: openPull,
+			Projects: []models.ProjectStatus{
+				{Workspace: "default", RepoRelDir: "dir1", Status: models.PlannedPlanStatus},
+				{Workspace: "default", RepoRelDir: "dir2", Status: models.PlannedPlanStatus},
+			},
+		},
+		{Pull: closedPull},
+	}, nil)
+	When(vcsClient.GetPullState(Any[logging.SimpleLogging](), Eq(repo), Eq(openPull))).ThenReturn(models.OpenPullState, nil)
+	When(vcsClient.GetPullState(Any[logging.SimpleLogging](), Eq(repo), Eq(closedPull))).ThenReturn(models.ClosedPullState, nil)
+
+	now := time.Now()
+	plan := initGCWorkingDir(t, dataDir, "1", now, now)
+	orphanedPlan := filepath.Join(dataDir, "repos", "org", "app", "1", "default", "dir3", "default.tfplan")
+	Ok(t, os.MkdirAll(filepath.Dir(orphanedPlan), 0700))
+	Ok(t, os.WriteFile(orphanedPlan, nil, 0600))
+	initGCWorkingDir(t, dataDir, "3", now, now)
+
+	reconciler := &events.StartupReconciler{
+		Backend:             backend,
+		DataDir:             dataDir,
+		Logger:              logging.NewNoopLogger(t),
+		VCSClient:           vcsClient,
+		PendingPlanFinder:   &events.DefaultPendingPlanFinder{},
+		WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+		CommitStatusUpdater: commitStatusUpdater,
+		PullCleaner:         pullCleaner,
+	}
+	result, err := reconciler.Reconcile()
+	Ok(t, err)
+	Equals(t, events.ReconcileResult{
+		ClosedPulls:         1,
+		OrphanedWorkingDirs: 1,
+		OrphanedPlans:       1,
+		MissingPlans:        1,
+		RestoredStatuses:    1,
+	}, result)
+
+	pullCleaner.VerifyWasCalledOnce().CleanUpPull(Any[logging.SimpleLogging](), Eq(repo), Eq(models.Repo{}), Eq(closedPull))
+	pullCleaner.VerifyWasCalled(Never()).CleanUpPull(Any[logging.SimpleLogging](), Eq(repo), Any[models.Repo](), Eq(openPull))
+
+	_, err = os.Stat(plan)
+	Ok(t, err)
+	_, err = os.Stat(orphanedPlan)
+	Assert(t, os.IsNotExist(err), "expected orphaned plan to be deleted")
+	_, err = os.Stat(filepath.Join(dataDir, "repos", "org", "app", "3"))
+	Assert(t, os.IsNotExist(err), "expected orphaned working dir to be deleted")
+
+	backend.VerifyWasCalledOnce().UpdateProjectStatus(Eq(openPull), Eq("default"), Eq("dir2"), Eq(models.DiscardedPlanStatus))
+	backend.VerifyWasCalled(Never()).UpdateProjectStatus(Eq(openPull), Eq("default"), Eq("dir1"), Any[models.ProjectPlanStatus]())
+	commitStatusUpdater.VerifyWasCalledOnce().UpdateCombinedCount(Any[logging.SimpleLogging](), Eq(repo), Eq(openPull), Eq(models.SuccessCommitStatus), Eq(command.Plan), Eq(2), Eq(2))
+	commitStatusUpdater.VerifyWasCalled(Never()).UpdateCombinedCount(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[models.CommitStatus](), Eq(command.Apply), Any[int](), Any[int]())
+}
+
+func TestStartupReconciler_Reconcile_StoredPlan(t *testing.T) {
+	RegisterMockTestingT(t)
+	dataDir := t.TempDir()
+	backend := lockmocks.NewMockBackend()
+	vcsClient := vcsmocks.NewMockClient()
+	repo := models.Repo{FullName: "org/app"}
+	pull := models.PullRequest{Num: 1, HeadCommit: "abc", BaseRepo: repo}
+	When(backend.GetPullStatuses()).ThenReturn([]models.PullStatus{
+		{
+			Pull: pull,
+			Projects: []models.ProjectStatus{
+				{Workspace: "default", RepoRelDir: "dir1", Status: models.PlannedPlanStatus},
+			},
+		},
+	}, nil)
+	When(vcsClient.GetPullState(Any[logging.SimpleLogging](), Eq(repo), Eq(pull))).ThenReturn(models.OpenPullState, nil)
+
+	// The plan of dir1 isn't in the data dir but is in the plan store.
+	planStore := &events.BlobPlanStore{Bucket: memblob.OpenBucket(nil)}
+	planDir := t.TempDir()
+	Ok(t, os.WriteFile(filepath.Join(planDir, "default.tfplan"), nil, 0600))
+	Ok(t, planStore.Save(events.PlanMetadata{RepoFullName: "org/app", PullNum: 1, HeadCommit: "abc", Workspace: "default", RepoRelDir: "dir1"}, planDir, "default.tfplan"))
+
+	reconciler := &events.StartupReconciler{
+		Backend:             backend,
+		DataDir:             dataDir,
+		Logger:              logging.NewNoopLogger(t),
+		VCSClient:           vcsClient,
+		PendingPlanFinder:   &events.DefaultPendingPlanFinder{},
+		WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+		CommitStatusUpdater: mocks.NewMockCommitStatusUpdater(),
+		PullCleaner:         mocks.NewMockPullCleaner(),
+		PlanStore:           planStore,
+	}
+	result, err := reconciler.Reconcile()
+	Ok(t, err)
+	Equals(t, 0, result.MissingPlans)
+	backend.VerifyWasCalled(Never()).UpdateProjectStatus(Any[models.PullRequest](), Any[string](), Any[string](), Any[models.ProjectPlanStatus]())
+}
+
+func TestStartupReconciler_Reconcile_SkipsRunningCommands(t *testing.T) {
+	RegisterMockTestingT(t)
+	dataDir := t.TempDir()
+	backend := lockmocks.NewMockBackend()
+	When(backend.GetPullStatuses()).ThenReturn([]models.PullStatus{}, nil)
+	now := time.Now()
+	initGCWorkingDir(t, dataDir, "1", now, now)
+
+	locker := events.NewDefaultWorkingDirLocker()
+	unlock, err := locker.TryLockPull("org/app", 1)
+	Ok(t, err)
+	defer unlock()
+
+	reconciler := &events.StartupReconciler{
+		Backend:             backend,
+		DataDir:             dataDir,
+		Logger:              logging.NewNoopLogger(t),
+		VCSClient:           vcsmocks.NewMockClient(),
+		PendingPlanFinder:   &events.DefaultPendingPlanFinder{},
+		WorkingDirLocker:    locker,
+		CommitStatusUpdater: mocks.NewMockCommitStatusUpdater(),
+		PullCleaner:         mocks.NewMockPullCleaner(),
+	}
+	result, err := reconciler.Reconcile()
+	Ok(t, err)
+	Equals(t, events.ReconcileResult{}, result)
+	_, err = os.Stat(filepath.Join(dataDir, "repos", "org", "app", "1"))
+	Ok(t, err)
+}
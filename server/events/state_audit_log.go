@@ -0,0 +1,71 @@
+package events
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// StateAuditLogFileName is the name of the file in the data dir that state
+// commands are recorded in.
+const StateAuditLogFileName = "state-audit.log"
+
+const (
+	// StateAuditStarted is the status of a state command that's about to run.
+	StateAuditStarted = "started"
+	// StateAuditSucceeded is the status of a state command that succeeded.
+	StateAuditSucceeded = "succeeded"
+	// StateAuditFailed is the status of a state command that failed.
+	StateAuditFailed = "failed"
+	// StateAuditDenied is the status of a state command that the user wasn't
+	// allowed to run.
+	StateAuditDenied = "denied"
+)
+
+// StateAuditEntry records a state command run on a project.
+type StateAuditEntry struct {
+	Time        time.Time
+	User        string
+	Repo        string
+	Pull        int
+	SubCommand  string
+	Args        []string `json:",omitempty"`
+	RepoRelDir  string   `json:",omitempty"`
+	Workspace   string   `json:",omitempty"`
+	ProjectName string   `json:",omitempty"`
+	Status      string
+	Error       string `json:",omitempty"`
+}
+
+// StateAuditLog records who ran state commands on which projects and how
+// they ended, one JSON entry per line, since they change the state outside
+// of a plan and apply. It can't be turned off.
+type StateAuditLog struct {
+	Path string
+
+	mutex sync.Mutex
+}
+
+// Record appends entry to the log.
+func (l *StateAuditLog) Record(entry StateAuditEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	f, err := os.OpenFile(l.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close() // nolint: errcheck
+		return err
+	}
+	return f.Close()
+}
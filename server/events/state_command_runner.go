@@ -2,19 +2,38 @@ package events
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events/command"
 )
 
+// StateRmAllowlistFinder looks up the resource addresses that can be removed
+// from state on a repo.
+type StateRmAllowlistFinder interface {
+	// StateRmAllowlist returns the patterns of the resource addresses that
+	// can be removed from state on the repo with repoID, and false if any
+	// address can be.
+	StateRmAllowlist(repoID string) ([]string, bool)
+}
+
 func NewStateCommandRunner(
 	pullUpdater *PullUpdater,
 	prjCmdBuilder ProjectStateCommandBuilder,
 	prjCmdRunner ProjectStateCommandRunner,
+	auditLog *StateAuditLog,
+	statePushAdmins *UserAllowlist,
+	stateRmAllowlist StateRmAllowlistFinder,
 ) *StateCommandRunner {
 	return &StateCommandRunner{
-		pullUpdater:   pullUpdater,
-		prjCmdBuilder: prjCmdBuilder,
-		prjCmdRunner:  prjCmdRunner,
+		pullUpdater:      pullUpdater,
+		prjCmdBuilder:    prjCmdBuilder,
+		prjCmdRunner:     prjCmdRunner,
+		auditLog:         auditLog,
+		statePushAdmins:  statePushAdmins,
+		stateRmAllowlist: stateRmAllowlist,
 	}
 }
 
@@ -22,6 +41,14 @@ type StateCommandRunner struct {
 	pullUpdater   *PullUpdater
 	prjCmdBuilder ProjectStateCommandBuilder
 	prjCmdRunner  ProjectStateCommandRunner
+	// auditLog records every state command. Commands aren't run if they
+	// can't be recorded.
+	auditLog *StateAuditLog
+	// statePushAdmins are the users that can run state push.
+	statePushAdmins *UserAllowlist
+	// stateRmAllowlist limits the resource addresses that state rm can
+	// remove. If nil, any address can be removed.
+	stateRmAllowlist StateRmAllowlistFinder
 }
 
 func (v *StateCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
@@ -29,6 +56,10 @@ func (v *StateCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 	switch cmd.SubName {
 	case "rm":
 		result = v.runRm(ctx, cmd)
+	case "pull":
+		result = v.runPull(ctx, cmd)
+	case "push":
+		result = v.runPush(ctx, cmd)
 	default:
 		result = command.Result{
 			Failure: fmt.Sprintf("unknown state subcommand %s", cmd.SubName),
@@ -37,10 +68,127 @@ func (v *StateCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 	v.pullUpdater.updatePull(ctx, cmd, result)
 }
 
+// runRm runs state rm. The resource addresses must match the repo's
+// state_rm_allowlist if it's set. Like plan and apply, it takes the project
+// lock so it can't run while another pull request changes the project.
 func (v *StateCommandRunner) runRm(ctx *command.Context, cmd *CommentCommand) command.Result {
-	projectCmds, err := v.prjCmdBuilder.BuildStateRmCommands(ctx, cmd)
+	if v.stateRmAllowlist != nil {
+		if allowlist, ok := v.stateRmAllowlist.StateRmAllowlist(ctx.Pull.BaseRepo.ID()); ok {
+			for _, address := range stateRmAddresses(cmd.Flags) {
+				if valid.StateRmAddressAllowed(allowlist, address) {
+					continue
+				}
+				if err := v.record(ctx, cmd, command.ProjectContext{}, StateAuditDenied, ""); err != nil {
+					return command.Result{Error: err}
+				}
+				return command.Result{
+					Failure: fmt.Sprintf("Resource address %q can't be removed from state, it doesn't match the state_rm_allowlist of the repo in the server-side repo config.", address),
+				}
+			}
+		}
+	}
+
+	projectCmds, err := v.prjCmdBuilder.BuildStateCommands(ctx, cmd)
+	if err != nil {
+		ctx.Log.Warn("Error %s", err)
+	}
+	return v.runAudited(ctx, cmd, projectCmds, v.prjCmdRunner.StateRm)
+}
+
+// stateRmAddresses returns the resource addresses of the state rm args,
+// skipping its flags, ex. -lock=false.
+func stateRmAddresses(args []string) []string {
+	var addresses []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			addresses = append(addresses, arg)
+		}
+	}
+	return addresses
+}
+
+func (v *StateCommandRunner) runPull(ctx *command.Context, cmd *CommentCommand) command.Result {
+	projectCmds, err := v.prjCmdBuilder.BuildStateCommands(ctx, cmd)
 	if err != nil {
 		ctx.Log.Warn("Error %s", err)
 	}
-	return runProjectCmds(projectCmds, v.prjCmdRunner.StateRm)
+	return v.runAudited(ctx, cmd, projectCmds, v.prjCmdRunner.StatePull)
+}
+
+// runPush runs state push, which replaces the state of a project and so is
+// only allowed for the admins and for a single project at a time.
+func (v *StateCommandRunner) runPush(ctx *command.Context, cmd *CommentCommand) command.Result {
+	isAdmin, err := v.statePushAdmins.IsAllowed(ctx.Log, ctx.Pull.BaseRepo, ctx.User)
+	if err != nil {
+		return command.Result{Error: errors.Wrap(err, "checking if user is a state push admin")}
+	}
+	if !isAdmin {
+		if err := v.record(ctx, cmd, command.ProjectContext{}, StateAuditDenied, ""); err != nil {
+			return command.Result{Error: err}
+		}
+		return command.Result{
+			Failure: fmt.Sprintf("User @%s is not allowed to run state push, only the users of --state-push-admins are.", ctx.User.Username),
+		}
+	}
+	if !cmd.IsForSpecificProject() {
+		return command.Result{Failure: "State push must be run for a single project with the -p or -d flags."}
+	}
+	// The state file is the last argument, after the ones passed after --.
+	if path := cmd.Flags[len(cmd.Flags)-1]; !filepath.IsLocal(path) {
+		return command.Result{Failure: fmt.Sprintf("State file %q must be a relative path inside the project's dir.", path)}
+	}
+
+	projectCmds, err := v.prjCmdBuilder.BuildStateCommands(ctx, cmd)
+	if err != nil {
+		ctx.Log.Warn("Error %s", err)
+	}
+	if len(projectCmds) > 1 {
+		return command.Result{Failure: fmt.Sprintf("State push must be run for a single project but %d projects matched, use the -p flag.", len(projectCmds))}
+	}
+	return v.runAudited(ctx, cmd, projectCmds, v.prjCmdRunner.StatePush)
+}
+
+// runAudited runs projectCmds with runnerFunc, recording in the audit log
+// when each starts and how it ends.
+func (v *StateCommandRunner) runAudited(ctx *command.Context, cmd *CommentCommand, projectCmds []command.ProjectContext, runnerFunc prjCmdRunnerFunc) command.Result {
+	return runProjectCmds(projectCmds, func(projectCmd command.ProjectContext) command.ProjectResult {
+		if err := v.record(ctx, cmd, projectCmd, StateAuditStarted, ""); err != nil {
+			return command.ProjectResult{
+				Command:     command.State,
+				SubCommand:  cmd.SubName,
+				Error:       err,
+				RepoRelDir:  projectCmd.RepoRelDir,
+				Workspace:   projectCmd.Workspace,
+				ProjectName: projectCmd.ProjectName,
+			}
+		}
+		result := runnerFunc(projectCmd)
+		status, errMsg := StateAuditSucceeded, ""
+		if result.Error != nil {
+			status, errMsg = StateAuditFailed, result.Error.Error()
+		} else if result.Failure != "" {
+			status, errMsg = StateAuditFailed, result.Failure
+		}
+		if err := v.record(ctx, cmd, projectCmd, status, errMsg); err != nil {
+			ctx.Log.Err("%s", err)
+		}
+		return result
+	})
+}
+
+func (v *StateCommandRunner) record(ctx *command.Context, cmd *CommentCommand, projectCmd command.ProjectContext, status string, errMsg string) error {
+	ctx.Log.Info("state %s by @%s on dir %q workspace %q project %q: %s", cmd.SubName, ctx.User.Username, projectCmd.RepoRelDir, projectCmd.Workspace, projectCmd.ProjectName, status)
+	err := v.auditLog.Record(StateAuditEntry{
+		User:        ctx.User.Username,
+		Repo:        ctx.Pull.BaseRepo.FullName,
+		Pull:        ctx.Pull.Num,
+		SubCommand:  cmd.SubName,
+		Args:        cmd.Flags,
+		RepoRelDir:  projectCmd.RepoRelDir,
+		Workspace:   projectCmd.Workspace,
+		ProjectName: projectCmd.ProjectName,
+		Status:      status,
+		Error:       errMsg,
+	})
+	return errors.Wrap(err, "recording state command in the audit log")
 }
@@ -0,0 +1,185 @@
+package events_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestStateCommandRunner_Run(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	project := command.ProjectContext{RepoRelDir: "network", Workspace: "default"}
+
+	tests := []struct {
+		name             string
+		cmd              *events.CommentCommand
+		projectCmds      []command.ProjectContext
+		stateRmAllowlist []string
+		expRm            bool
+		expPull          bool
+		expPush          bool
+		expComment       string
+		expStatuses      []string
+	}{
+		{
+			name:        "rm",
+			cmd:         &events.CommentCommand{Name: command.State, SubName: "rm", Flags: []string{"aws_instance.web"}},
+			projectCmds: []command.ProjectContext{project},
+			expRm:       true,
+			expStatuses: []string{events.StateAuditStarted, events.StateAuditSucceeded},
+		},
+		{
+			name:             "rm of an allowlisted address",
+			cmd:              &events.CommentCommand{Name: command.State, SubName: "rm", Flags: []string{"-lock=false", `random_id.suffix["a"]`}},
+			projectCmds:      []command.ProjectContext{project},
+			stateRmAllowlist: []string{"random_id.*"},
+			expRm:            true,
+			expStatuses:      []string{events.StateAuditStarted, events.StateAuditSucceeded},
+		},
+		{
+			name:             "rm of an address that isn't allowlisted",
+			cmd:              &events.CommentCommand{Name: command.State, SubName: "rm", Flags: []string{"random_id.suffix", "aws_instance.web"}},
+			projectCmds:      []command.ProjectContext{project},
+			stateRmAllowlist: []string{"random_id.*"},
+			expComment:       `Resource address "aws_instance.web" can't be removed from state, it doesn't match the state_rm_allowlist`,
+			expStatuses:      []string{events.StateAuditDenied},
+		},
+		{
+			name:        "pull",
+			cmd:         &events.CommentCommand{Name: command.State, SubName: "pull"},
+			projectCmds: []command.ProjectContext{project},
+			expPull:     true,
+			expStatuses: []string{events.StateAuditStarted, events.StateAuditSucceeded},
+		},
+		{
+			name:        "push by admin",
+			cmd:         &events.CommentCommand{Name: command.State, SubName: "push", RepoRelDir: "network", Flags: []string{"backup.tfstate"}},
+			projectCmds: []command.ProjectContext{project},
+			expPush:     true,
+			expStatuses: []string{events.StateAuditStarted, events.StateAuditSucceeded},
+		},
+		{
+			name:       "push for all projects",
+			cmd:        &events.CommentCommand{Name: command.State, SubName: "push", Flags: []string{"backup.tfstate"}},
+			expComment: "State push must be run for a single project with the -p or -d flags.",
+		},
+		{
+			name:       "push of a file outside the project",
+			cmd:        &events.CommentCommand{Name: command.State, SubName: "push", RepoRelDir: "network", Flags: []string{"../backup.tfstate"}},
+			expComment: `State file "../backup.tfstate" must be a relative path inside the project's dir.`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			vcsClient := vcsmocks.NewMockClient()
+			builder := mocks.NewMockProjectCommandBuilder()
+			runner := mocks.NewMockProjectCommandRunner()
+			auditLog := &events.StateAuditLog{Path: filepath.Join(t.TempDir(), events.StateAuditLogFileName)}
+			stateCommandRunner := events.NewStateCommandRunner(
+				&events.PullUpdater{
+					VCSClient:        vcsClient,
+					MarkdownRenderer: events.NewMarkdownRenderer(false, false, false, false, false, false, "", "atlantis", false, false),
+				},
+				builder,
+				runner,
+				auditLog,
+				&events.UserAllowlist{Users: []string{"LKYSOW"}},
+				valid.GlobalCfg{Repos: []valid.Repo{{ID: testdata.GithubRepo.ID(), StateRmAllowlist: tt.stateRmAllowlist}}},
+			)
+			ctx := &command.Context{
+				User:     testdata.User,
+				Log:      logger,
+				Pull:     models.PullRequest{BaseRepo: testdata.GithubRepo, Num: testdata.Pull.Num},
+				HeadRepo: testdata.GithubRepo,
+				Trigger:  command.CommentTrigger,
+			}
+			When(builder.BuildStateCommands(ctx, tt.cmd)).ThenReturn(tt.projectCmds, nil)
+			When(runner.StateRm(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{StateRmSuccess: &models.StateRmSuccess{}})
+			When(runner.StatePull(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{StatePullSuccess: &models.StatePullSuccess{Output: "Serial: 1"}})
+			When(runner.StatePush(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{StatePushSuccess: &models.StatePushSuccess{}})
+
+			stateCommandRunner.Run(ctx, tt.cmd)
+
+			runner.VerifyWasCalled(Times(boolToTimes(tt.expRm))).StateRm(Any[command.ProjectContext]())
+			runner.VerifyWasCalled(Times(boolToTimes(tt.expPull))).StatePull(Any[command.ProjectContext]())
+			runner.VerifyWasCalled(Times(boolToTimes(tt.expPush))).StatePush(Any[command.ProjectContext]())
+			_, _, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(
+				Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]()).GetCapturedArguments()
+			Assert(t, strings.Contains(comment, tt.expComment), "expected comment %q to contain %q", comment, tt.expComment)
+			Equals(t, tt.expStatuses, readStateAuditStatuses(t, auditLog.Path))
+		})
+	}
+}
+
+func TestStateCommandRunner_Run_PushDenied(t *testing.T) {
+	RegisterMockTestingT(t)
+	vcsClient := vcsmocks.NewMockClient()
+	runner := mocks.NewMockProjectCommandRunner()
+	auditLog := &events.StateAuditLog{Path: filepath.Join(t.TempDir(), events.StateAuditLogFileName)}
+	stateCommandRunner := events.NewStateCommandRunner(
+		&events.PullUpdater{
+			VCSClient:        vcsClient,
+			MarkdownRenderer: events.NewMarkdownRenderer(false, false, false, false, false, false, "", "atlantis", false, false),
+		},
+		mocks.NewMockProjectCommandBuilder(),
+		runner,
+		auditLog,
+		&events.UserAllowlist{Users: []string{"admin"}},
+		nil,
+	)
+	ctx := &command.Context{
+		User:     testdata.User,
+		Log:      logging.NewNoopLogger(t),
+		Pull:     models.PullRequest{BaseRepo: testdata.GithubRepo, Num: testdata.Pull.Num},
+		HeadRepo: testdata.GithubRepo,
+		Trigger:  command.CommentTrigger,
+	}
+
+	stateCommandRunner.Run(ctx, &events.CommentCommand{Name: command.State, SubName: "push", ProjectName: "network", Flags: []string{"backup.tfstate"}})
+
+	runner.VerifyWasCalled(Never()).StatePush(Any[command.ProjectContext]())
+	_, _, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]()).GetCapturedArguments()
+	Assert(t, strings.Contains(comment, "User @lkysow is not allowed to run state push"), "unexpected comment %q", comment)
+	Equals(t, []string{events.StateAuditDenied}, readStateAuditStatuses(t, auditLog.Path))
+}
+
+func boolToTimes(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func readStateAuditStatuses(t *testing.T, path string) []string {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	Ok(t, err)
+	defer f.Close() // nolint: errcheck
+	var statuses []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry events.StateAuditEntry
+		Ok(t, json.Unmarshal(scanner.Bytes(), &entry))
+		Equals(t, "lkysow", entry.User)
+		statuses = append(statuses, entry.Status)
+	}
+	return statuses
+}
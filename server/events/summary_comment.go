@@ -0,0 +1,223 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// summaryCommentMarker starts each of the comments of the summary of a pull
+// request so that they can be found again.
+const summaryCommentMarker = "<!-- atlantis-summary -->"
+
+// summaryPreWorkflowHooksKey is the key of the section of the output of the
+// pre workflow hooks. It sorts before the keys of projects.
+const summaryPreWorkflowHooksKey = ""
+
+// reSummarySection matches a section of a summary. The first group is the
+// JSON of its summarySection and the second is its content.
+var reSummarySection = regexp.MustCompile(`(?s)<!-- atlantis-project (\{.*?\}) -->\n(.*?)\n<!-- /atlantis-project -->`)
+
+// SummaryCommentUpdater maintains a single summary comment per pull request
+// that's edited with the output of the latest command of each project, instead
+// of commenting for every command. The summary is split into several comments
+// if it's longer than what the VCS host accepts.
+type SummaryCommentUpdater struct {
+	// Commenters maintain the summary comments on the VCS hosts that support
+	// it. Commands on other hosts are commented as usual.
+	Commenters       map[models.VCSHostType]vcs.SummaryCommenter
+	MarkdownRenderer *MarkdownRenderer
+
+	mu sync.Mutex
+	// pulls serializes the updates of the summary of each pull request so
+	// that concurrent commands don't overwrite each other's sections.
+	pulls map[string]*sync.Mutex
+}
+
+// summarySection is the output of the latest command of a project in a
+// summary. It's stored in the summary comment so that the other sections can
+// be kept when one is updated.
+type summarySection struct {
+	Key string `json:"key"`
+	// Commit is the head commit the command ran on.
+	Commit string `json:"commit"`
+	Title  string `json:"title"`
+	Body   string `json:"-"`
+}
+
+// Update replaces the sections of the projects in results in the summary of
+// the pull request of ctx. It returns false if the summary couldn't be
+// updated, in which case the results should be commented as usual.
+func (s *SummaryCommentUpdater) Update(ctx *command.Context, cmd PullCommand, results []command.ProjectResult) bool {
+	repo := ctx.Pull.BaseRepo
+	commenter, ok := s.Commenters[repo.VCSHost.Type]
+	if !ok || len(results) == 0 {
+		return false
+	}
+
+	unlock := s.lockPull(repo.FullName, ctx.Pull.Num)
+	defer unlock()
+
+	bodies, err := commenter.GetSummaryComments(ctx.Log, repo, ctx.Pull.Num, summaryCommentMarker)
+	if err != nil {
+		ctx.Log.Err("unable to get summary comment: %s", err)
+		return false
+	}
+	sections := parseSummarySections(strings.Join(bodies, "\n"))
+
+	var updated []summarySection
+	if len(ctx.PreWorkflowHookOutputs) > 0 {
+		updated = append(updated, summarySection{
+			Key:    summaryPreWorkflowHooksKey,
+			Commit: ctx.Pull.HeadCommit,
+			Title:  "Pre workflow hooks",
+			Body:   strings.Join(ctx.PreWorkflowHookOutputs, "\n\n"),
+		})
+	}
+	for _, result := range results {
+		updated = append(updated, summarySection{
+			Key:    summaryProjectKey(result),
+			Commit: ctx.Pull.HeadCommit,
+			Title:  summaryTitle(result),
+			Body:   s.MarkdownRenderer.Render(ctx, command.Result{ProjectResults: []command.ProjectResult{result}}, cmd),
+		})
+	}
+	for _, section := range updated {
+		sections[section.Key] = section
+	}
+
+	fold := s.MarkdownRenderer.supportsFolding(repo.VCSHost.Type)
+	comments := renderSummary(sections, ctx.Pull.HeadCommit, fold, commenter.MaxCommentLength())
+	if err := commenter.SetSummaryComments(ctx.Log, repo, ctx.Pull.Num, summaryCommentMarker, comments); err != nil {
+		ctx.Log.Err("unable to update summary comment: %s", err)
+		return false
+	}
+	return true
+}
+
+// lockPull locks the summary of the pull request and returns the function
+// that unlocks it.
+func (s *SummaryCommentUpdater) lockPull(repoFullName string, pullNum int) func() {
+	key := fmt.Sprintf("%s#%d", repoFullName, pullNum)
+	s.mu.Lock()
+	if s.pulls == nil {
+		s.pulls = make(map[string]*sync.Mutex)
+	}
+	pullMu, ok := s.pulls[key]
+	if !ok {
+		pullMu = &sync.Mutex{}
+		s.pulls[key] = pullMu
+	}
+	s.mu.Unlock()
+
+	pullMu.Lock()
+	return pullMu.Unlock
+}
+
+// summaryProjectKey identifies the project of result in a summary the same way
+// comments do.
+func summaryProjectKey(result command.ProjectResult) string {
+	key := fmt.Sprintf("dir: `%s` workspace: `%s`", result.RepoRelDir, result.Workspace)
+	if result.ProjectName != "" {
+		key = fmt.Sprintf("project: `%s` %s", result.ProjectName, key)
+	}
+	return key
+}
+
+// summaryTitle is the line that's shown for result when its section is
+// collapsed.
+func summaryTitle(result command.ProjectResult) string {
+	status := ":white_check_mark:"
+	if result.Error != nil || result.Failure != "" {
+		status = ":x:"
+	}
+	title := fmt.Sprintf("%s %s %s", status, summaryProjectKey(result), result.Command.TitleString())
+	if result.Error == nil && result.Failure == "" && result.PlanSuccess != nil {
+		if diff := result.PlanSuccess.DiffSummary(); diff != "" {
+			title += ": " + diff
+		}
+	}
+	return title
+}
+
+// parseSummarySections returns the sections of summary by key.
+func parseSummarySections(summary string) map[string]summarySection {
+	sections := make(map[string]summarySection)
+	for _, match := range reSummarySection.FindAllStringSubmatch(summary, -1) {
+		var section summarySection
+		if err := json.Unmarshal([]byte(match[1]), &section); err != nil {
+			continue
+		}
+		section.Body = unwrapSummarySection(match[2])
+		sections[section.Key] = section
+	}
+	return sections
+}
+
+// wrapSummarySection renders section in a summary. If fold is true, its body
+// is collapsed. outdated is true if it ran on an older commit than the head of
+// the pull request.
+func wrapSummarySection(section summarySection, fold bool, outdated bool) string {
+	// json.Marshal escapes '<' and '>' so the JSON can't end the HTML
+	// comment.
+	meta, _ := json.Marshal(section)
+	title := section.Title
+	if outdated {
+		title += fmt.Sprintf(" (outdated, ran on `%.7s`)", section.Commit)
+	}
+	if fold {
+		return fmt.Sprintf("<!-- atlantis-project %s -->\n<details><summary>%s</summary>\n\n%s\n\n</details>\n<!-- /atlantis-project -->", meta, title, section.Body)
+	}
+	return fmt.Sprintf("<!-- atlantis-project %s -->\n#### %s\n\n%s\n<!-- /atlantis-project -->", meta, title, section.Body)
+}
+
+// unwrapSummarySection returns the body of a section rendered by
+// wrapSummarySection, whether it was folded or not.
+func unwrapSummarySection(content string) string {
+	_, body, ok := strings.Cut(content, "\n\n")
+	if !ok {
+		return ""
+	}
+	if strings.HasPrefix(content, "<details>") {
+		body = strings.TrimSuffix(body, "\n\n</details>")
+	}
+	return body
+}
+
+// renderSummary renders sections into as many comments as needed for each to
+// be at most maxLength long. Sections that are longer by themselves have their
+// body replaced by a warning.
+func renderSummary(sections map[string]summarySection, headCommit string, fold bool, maxLength int) []string {
+	var keys []string
+	for key := range sections {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	header := fmt.Sprintf("%s\n### Atlantis Summary\n\nThe output of the latest command of each project, updated for commit `%.7s`.\n", summaryCommentMarker, headCommit)
+	continuation := summaryCommentMarker + "\n*Atlantis Summary continued from the previous comment.*\n"
+
+	comments := []string{header}
+	for _, key := range keys {
+		section := sections[key]
+		rendered := wrapSummarySection(section, fold, section.Commit != headCommit)
+		if len(continuation)+len(rendered)+1 > maxLength {
+			section.Body = "**Warning**: The output is longer than the maximum comment size. See the logs of the command's job for the full output."
+			rendered = wrapSummarySection(section, fold, section.Commit != headCommit)
+		}
+		last := len(comments) - 1
+		if len(comments[last])+len(rendered)+1 > maxLength {
+			comments = append(comments, continuation)
+			last++
+		}
+		comments[last] += "\n" + rendered
+	}
+	return comments
+}
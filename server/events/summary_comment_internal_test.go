@@ -0,0 +1,136 @@
+package events
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeSummaryCommenter keeps the summary comments of a single pull request.
+type fakeSummaryCommenter struct {
+	maxLength int
+	bodies    []string
+	getErr    error
+}
+
+func (f *fakeSummaryCommenter) MaxCommentLength() int {
+	return f.maxLength
+}
+
+func (f *fakeSummaryCommenter) GetSummaryComments(_ logging.SimpleLogging, _ models.Repo, _ int, _ string) ([]string, error) {
+	return f.bodies, f.getErr
+}
+
+func (f *fakeSummaryCommenter) SetSummaryComments(_ logging.SimpleLogging, _ models.Repo, _ int, _ string, bodies []string) error {
+	f.bodies = bodies
+	return nil
+}
+
+func newSummaryTestContext(t *testing.T, headCommit string) *command.Context {
+	return &command.Context{
+		Log: logging.NewNoopLogger(t),
+		Pull: models.PullRequest{
+			Num:        1,
+			HeadCommit: headCommit,
+			BaseRepo: models.Repo{
+				FullName: "owner/repo",
+				VCSHost:  models.VCSHost{Type: models.Github},
+			},
+		},
+	}
+}
+
+func newSummaryTestUpdater(commenter *fakeSummaryCommenter) *SummaryCommentUpdater {
+	return &SummaryCommentUpdater{
+		Commenters:       map[models.VCSHostType]vcs.SummaryCommenter{models.Github: commenter},
+		MarkdownRenderer: NewMarkdownRenderer(false, false, false, false, false, false, "", "atlantis", false, false),
+	}
+}
+
+func TestSummaryCommentUpdater_Update(t *testing.T) {
+	commenter := &fakeSummaryCommenter{maxLength: 65536}
+	updater := newSummaryTestUpdater(commenter)
+	plan := &CommentCommand{Name: command.Plan}
+
+	ctx := newSummaryTestContext(t, "aaaaaaaaaa")
+	Assert(t, updater.Update(ctx, plan, []command.ProjectResult{
+		{Command: command.Plan, RepoRelDir: "dir1", Workspace: "default", Failure: "failure1"},
+		{Command: command.Plan, RepoRelDir: "dir2", Workspace: "default", Failure: "failure2"},
+	}), "expected the summary to be updated")
+	Equals(t, 1, len(commenter.bodies))
+	Assert(t, strings.HasPrefix(commenter.bodies[0], summaryCommentMarker), "expected the summary to start with the marker")
+
+	// Only the section of dir2 is replaced, and dir1 is outdated.
+	ctx = newSummaryTestContext(t, "bbbbbbbbbb")
+	Assert(t, updater.Update(ctx, plan, []command.ProjectResult{
+		{Command: command.Plan, RepoRelDir: "dir2", Workspace: "default", Failure: "failure3"},
+	}), "expected the summary to be updated")
+	Equals(t, 1, len(commenter.bodies))
+	sections := parseSummarySections(commenter.bodies[0])
+	Equals(t, 2, len(sections))
+	dir1 := sections[summaryProjectKey(command.ProjectResult{RepoRelDir: "dir1", Workspace: "default"})]
+	dir2 := sections[summaryProjectKey(command.ProjectResult{RepoRelDir: "dir2", Workspace: "default"})]
+	Equals(t, "aaaaaaaaaa", dir1.Commit)
+	Equals(t, "bbbbbbbbbb", dir2.Commit)
+	Assert(t, strings.Contains(dir1.Body, "failure1"), "expected dir1 to be kept, got %q", dir1.Body)
+	Assert(t, strings.Contains(dir2.Body, "failure3"), "expected dir2 to be replaced, got %q", dir2.Body)
+	Assert(t, strings.Contains(commenter.bodies[0], "(outdated, ran on `aaaaaaa`)"), "expected dir1 to be outdated")
+}
+
+func TestSummaryCommentUpdater_Update_Unsupported(t *testing.T) {
+	commenter := &fakeSummaryCommenter{maxLength: 65536}
+	updater := newSummaryTestUpdater(commenter)
+	ctx := newSummaryTestContext(t, "aaaaaaaaaa")
+	ctx.Pull.BaseRepo.VCSHost.Type = models.BitbucketCloud
+
+	Assert(t, !updater.Update(ctx, &CommentCommand{Name: command.Plan}, []command.ProjectResult{
+		{Command: command.Plan, RepoRelDir: "dir1", Workspace: "default"},
+	}), "expected the summary not to be updated")
+	Equals(t, 0, len(commenter.bodies))
+}
+
+func TestSummaryCommentUpdater_Update_GetErr(t *testing.T) {
+	commenter := &fakeSummaryCommenter{maxLength: 65536, getErr: errors.New("err")}
+	updater := newSummaryTestUpdater(commenter)
+
+	Assert(t, !updater.Update(newSummaryTestContext(t, "aaaaaaaaaa"), &CommentCommand{Name: command.Plan}, []command.ProjectResult{
+		{Command: command.Plan, RepoRelDir: "dir1", Workspace: "default"},
+	}), "expected the summary not to be updated")
+}
+
+func TestParseSummarySections_RoundTrip(t *testing.T) {
+	sections := map[string]summarySection{
+		"a": {Key: "a", Commit: "abc", Title: "title <a>", Body: "line1\n\nline2"},
+		"b": {Key: "b", Commit: "def", Title: "title b", Body: "```\n-->\n```"},
+	}
+	for _, fold := range []bool{true, false} {
+		comments := renderSummary(sections, "abc", fold, 65536)
+		Equals(t, 1, len(comments))
+		Equals(t, sections, parseSummarySections(comments[0]))
+	}
+}
+
+func TestRenderSummary_Split(t *testing.T) {
+	sections := map[string]summarySection{
+		"a": {Key: "a", Commit: "abc", Title: "a", Body: strings.Repeat("a", 300)},
+		"b": {Key: "b", Commit: "abc", Title: "b", Body: strings.Repeat("b", 300)},
+		"c": {Key: "c", Commit: "abc", Title: "c", Body: strings.Repeat("c", 2000)},
+	}
+	comments := renderSummary(sections, "abc", true, 600)
+	Equals(t, 3, len(comments))
+	for _, comment := range comments {
+		Assert(t, len(comment) <= 600, "expected comment to be at most 600 long, got %d", len(comment))
+		Assert(t, strings.HasPrefix(comment, summaryCommentMarker), "expected comment to start with the marker")
+	}
+
+	parsed := parseSummarySections(strings.Join(comments, "\n"))
+	Equals(t, sections["a"], parsed["a"])
+	Equals(t, sections["b"], parsed["b"])
+	Assert(t, strings.HasPrefix(parsed["c"].Body, "**Warning**"), "expected c to be replaced by a warning, got %q", parsed["c"].Body)
+}
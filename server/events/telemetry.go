@@ -0,0 +1,137 @@
+package events
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// The classes that telemetry aggregates the errors of project commands into.
+// They never include the error itself since it can name repos, resources or
+// secrets.
+const (
+	// TelemetryCredentialsOrProviderErr is an error that a CircuitBreaker
+	// counts, ex. an expired token or a provider returning 5xx errors.
+	TelemetryCredentialsOrProviderErr = "credentials_or_provider"
+	// TelemetryTimeoutErr is a command that ran for longer than its timeout.
+	TelemetryTimeoutErr = "timeout"
+	// TelemetryFailure is a command that didn't run, ex. because its apply
+	// requirements weren't met or it was locked.
+	TelemetryFailure = "failure"
+	// TelemetryOtherErr is any other error, ex. invalid Terraform.
+	TelemetryOtherErr = "other"
+)
+
+// TelemetryRecorder counts the project commands that were run and the classes
+// of their errors between telemetry reports. It only counts, so that reports
+// stay anonymous.
+type TelemetryRecorder struct {
+	mu       sync.Mutex
+	commands map[string]int64
+	errors   map[string]int64
+}
+
+// NewTelemetryRecorder returns a TelemetryRecorder with nothing recorded.
+func NewTelemetryRecorder() *TelemetryRecorder {
+	return &TelemetryRecorder{
+		commands: make(map[string]int64),
+		errors:   make(map[string]int64),
+	}
+}
+
+// Record counts the result of running the project command in ctx.
+func (t *TelemetryRecorder) Record(ctx command.ProjectContext, result command.ProjectResult) {
+	errClass := telemetryErrClass(result)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.commands[ctx.CommandName.String()]++
+	if errClass != "" {
+		t.errors[errClass]++
+	}
+}
+
+// Flush returns the counts recorded since the last flush, by command name and
+// by error class, and resets them.
+func (t *TelemetryRecorder) Flush() (commands map[string]int64, errors map[string]int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	commands, errors = t.commands, t.errors
+	t.commands = make(map[string]int64)
+	t.errors = make(map[string]int64)
+	return commands, errors
+}
+
+// telemetryErrClass returns the class of the error of result, or an empty
+// string if it succeeded.
+func telemetryErrClass(result command.ProjectResult) string {
+	errMsg := result.Failure
+	if result.Error != nil {
+		errMsg = result.Error.Error()
+	}
+	switch {
+	case errMsg == "":
+		return ""
+	case strings.HasPrefix(result.Failure, "Timed out after"):
+		return TelemetryTimeoutErr
+	case circuitBreakerErr(errMsg) != "":
+		return TelemetryCredentialsOrProviderErr
+	case result.Error == nil:
+		return TelemetryFailure
+	}
+	return TelemetryOtherErr
+}
+
+// TelemetryProjectCommandRunner records the results of project commands in a
+// TelemetryRecorder.
+type TelemetryProjectCommandRunner struct {
+	ProjectCommandRunner
+	Recorder *TelemetryRecorder
+}
+
+func (p *TelemetryProjectCommandRunner) Plan(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.Plan)
+}
+
+func (p *TelemetryProjectCommandRunner) PolicyCheck(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.PolicyCheck)
+}
+
+func (p *TelemetryProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.Apply)
+}
+
+func (p *TelemetryProjectCommandRunner) ApprovePolicies(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.ApprovePolicies)
+}
+
+func (p *TelemetryProjectCommandRunner) Version(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.Version)
+}
+
+func (p *TelemetryProjectCommandRunner) Import(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.Import)
+}
+
+func (p *TelemetryProjectCommandRunner) StateRm(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.StateRm)
+}
+
+func (p *TelemetryProjectCommandRunner) StatePull(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.StatePull)
+}
+
+func (p *TelemetryProjectCommandRunner) StatePush(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.StatePush)
+}
+
+func (p *TelemetryProjectCommandRunner) Custom(ctx command.ProjectContext) command.ProjectResult {
+	return p.record(ctx, p.ProjectCommandRunner.Custom)
+}
+
+func (p *TelemetryProjectCommandRunner) record(ctx command.ProjectContext, execute func(ctx command.ProjectContext) command.ProjectResult) command.ProjectResult {
+	result := execute(ctx)
+	p.Recorder.Record(ctx, result)
+	return result
+}
@@ -0,0 +1,40 @@
+package events
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestTelemetryErrClass(t *testing.T) {
+	cases := []struct {
+		result command.ProjectResult
+		exp    string
+	}{
+		{command.ProjectResult{}, ""},
+		{command.ProjectResult{Error: errors.New("error acquiring credentials: 401 Unauthorized")}, TelemetryCredentialsOrProviderErr},
+		{command.ProjectResult{Failure: "Timed out after 30m0s"}, TelemetryTimeoutErr},
+		{command.ProjectResult{Failure: "Pull request must be approved before running apply."}, TelemetryFailure},
+		{command.ProjectResult{Error: errors.New("exit status 1: invalid resource")}, TelemetryOtherErr},
+	}
+	for _, c := range cases {
+		Equals(t, c.exp, telemetryErrClass(c.result))
+	}
+}
+
+func TestTelemetryRecorder_Flush(t *testing.T) {
+	recorder := NewTelemetryRecorder()
+	recorder.Record(command.ProjectContext{CommandName: command.Plan}, command.ProjectResult{})
+	recorder.Record(command.ProjectContext{CommandName: command.Plan}, command.ProjectResult{Failure: "Timed out after 1m0s"})
+	recorder.Record(command.ProjectContext{CommandName: command.Apply}, command.ProjectResult{})
+
+	commands, errs := recorder.Flush()
+	Equals(t, map[string]int64{"plan": 2, "apply": 1}, commands)
+	Equals(t, map[string]int64{TelemetryTimeoutErr: 1}, errs)
+
+	commands, errs = recorder.Flush()
+	Equals(t, map[string]int64{}, commands)
+	Equals(t, map[string]int64{}, errs)
+}
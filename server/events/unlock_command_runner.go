@@ -1,9 +1,12 @@
 package events
 
 import (
+	"fmt"
 	"slices"
+	"strings"
 
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 )
 
@@ -30,13 +33,18 @@ type UnlockCommandRunner struct {
 	DisableUnlockLabel string
 }
 
-func (u *UnlockCommandRunner) Run(ctx *command.Context, _ *CommentCommand) {
+func (u *UnlockCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 	baseRepo := ctx.Pull.BaseRepo
 	pullNum := ctx.Pull.Num
 	disableUnlockLabel := u.DisableUnlockLabel
+	forProject := cmd != nil && cmd.IsForSpecificProject()
 
-	ctx.Log.Info("Unlocking all locks")
 	vcsMessage := "All Atlantis locks for this PR have been unlocked and plans discarded"
+	if forProject {
+		ctx.Log.Info("Unlocking locks of %s", describeProjectFilter(cmd))
+	} else {
+		ctx.Log.Info("Unlocking all locks")
+	}
 
 	var hasLabel bool
 	var err error
@@ -56,10 +64,24 @@ func (u *UnlockCommandRunner) Run(ctx *command.Context, _ *CommentCommand) {
 
 	var numLocks int
 	if err == nil && !hasLabel {
-		numLocks, err = u.deleteLockCommand.DeleteLocksByPull(ctx.Log, baseRepo.FullName, pullNum)
-		if err != nil {
-			vcsMessage = "Failed to delete PR locks"
-			ctx.Log.Err("failed to delete locks by pull %s", err.Error())
+		if forProject {
+			var locks []models.ProjectLock
+			locks, err = u.deleteLockCommand.DeleteLocksByProject(ctx.Log, baseRepo.FullName, pullNum, cmd.RepoRelDir, cmd.Workspace, cmd.ProjectName)
+			numLocks = len(locks)
+			if err != nil {
+				vcsMessage = "Failed to delete project locks"
+				ctx.Log.Err("failed to delete locks by project %s", err.Error())
+			} else if numLocks == 0 {
+				vcsMessage = fmt.Sprintf("No Atlantis locks for this PR match %s", describeProjectFilter(cmd))
+			} else {
+				vcsMessage = unlockedProjectsMessage(locks)
+			}
+		} else {
+			numLocks, err = u.deleteLockCommand.DeleteLocksByPull(ctx.Log, baseRepo.FullName, pullNum)
+			if err != nil {
+				vcsMessage = "Failed to delete PR locks"
+				ctx.Log.Err("failed to delete locks by pull %s", err.Error())
+			}
 		}
 	}
 
@@ -75,3 +97,34 @@ func (u *UnlockCommandRunner) Run(ctx *command.Context, _ *CommentCommand) {
 		ctx.Log.Err("unable to comment: %s", commentErr)
 	}
 }
+
+// describeProjectFilter describes the projects that cmd unlocks, ex.
+// "project: `vpc` workspace: `staging`".
+func describeProjectFilter(cmd *CommentCommand) string {
+	var filters []string
+	if cmd.ProjectName != "" {
+		filters = append(filters, fmt.Sprintf("project: `%s`", cmd.ProjectName))
+	}
+	if cmd.RepoRelDir != "" {
+		filters = append(filters, fmt.Sprintf("dir: `%s`", cmd.RepoRelDir))
+	}
+	if cmd.Workspace != "" {
+		filters = append(filters, fmt.Sprintf("workspace: `%s`", cmd.Workspace))
+	}
+	return strings.Join(filters, " ")
+}
+
+// unlockedProjectsMessage is the comment listing the projects whose locks
+// were deleted.
+func unlockedProjectsMessage(locks []models.ProjectLock) string {
+	var msg strings.Builder
+	msg.WriteString("The Atlantis locks of the following projects have been unlocked and their plans discarded:\n")
+	for _, lock := range locks {
+		msg.WriteString("\n* ")
+		if lock.Project.ProjectName != "" {
+			fmt.Fprintf(&msg, "project: `%s` ", lock.Project.ProjectName)
+		}
+		fmt.Fprintf(&msg, "dir: `%s` workspace: `%s`", lock.Project.Path, lock.Workspace)
+	}
+	return msg.String()
+}
@@ -0,0 +1,203 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+const (
+	// userAllowlistTeamPrefix prefixes allowlist entries that allow the
+	// members of a GitHub team or GitLab group.
+	userAllowlistTeamPrefix = "team:"
+	// userAllowlistOrgPrefix prefixes allowlist entries that allow the
+	// members of a GitHub organization or GitLab group.
+	userAllowlistOrgPrefix = "org:"
+	// userAllowlistCacheTTL is how long the teams and organization
+	// memberships of users are cached.
+	userAllowlistCacheTTL = 5 * time.Minute
+)
+
+// TeamNamesGetter gets the names of the teams a user is a member of.
+type TeamNamesGetter interface {
+	GetTeamNamesForUser(logger logging.SimpleLogging, repo models.Repo, user models.User) ([]string, error)
+}
+
+// UserAllowlist is a list of the users that are allowed to do something, ex.
+// approve fork pull requests. Users can be allowed by username, by team or by
+// organization so that the list doesn't have to be updated as people join and
+// leave.
+type UserAllowlist struct {
+	// Users are the allowed usernames.
+	Users []string
+	// Teams are the GitHub teams, by name or slug, and GitLab groups whose
+	// members are allowed.
+	Teams []string
+	// Orgs are the GitHub organizations and GitLab groups whose members are
+	// allowed.
+	Orgs []string
+	// Memberships looks up the teams and organizations of users. It's shared
+	// by all the allowlists so that users are only looked up once.
+	Memberships *UserMemberships
+}
+
+// UserMemberships looks up the teams and organization memberships of users
+// on the VCS hosts and caches them for userAllowlistCacheTTL.
+type UserMemberships struct {
+	// TeamNamesGetter gets the teams of users if they haven't been fetched
+	// yet.
+	TeamNamesGetter TeamNamesGetter
+	// OrgMembershipCheckers check organization memberships on each type of
+	// VCS host.
+	OrgMembershipCheckers map[models.VCSHostType]vcs.OrgMembershipChecker
+
+	mutex sync.Mutex
+	cache map[string]userAllowlistCacheEntry
+}
+
+// userAllowlistCacheEntry is the cached teams of a user or whether they're a
+// member of an organization.
+type userAllowlistCacheEntry struct {
+	teams    []string
+	isMember bool
+	storedAt time.Time
+}
+
+// NewUserAllowlist parses allowlist, a comma-separated list of usernames,
+// team:<team> and org:<org> entries.
+func NewUserAllowlist(allowlist string) (*UserAllowlist, error) {
+	a := &UserAllowlist{}
+	for _, entry := range strings.Split(allowlist, ",") {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+		case strings.HasPrefix(entry, userAllowlistTeamPrefix):
+			a.Teams = append(a.Teams, strings.TrimPrefix(entry, userAllowlistTeamPrefix))
+		case strings.HasPrefix(entry, userAllowlistOrgPrefix):
+			a.Orgs = append(a.Orgs, strings.TrimPrefix(entry, userAllowlistOrgPrefix))
+		case strings.Contains(entry, ":"):
+			return nil, fmt.Errorf("invalid allowlist entry %q, must be a username or start with %q or %q", entry, userAllowlistTeamPrefix, userAllowlistOrgPrefix)
+		default:
+			a.Users = append(a.Users, entry)
+		}
+	}
+	return a, nil
+}
+
+// IsEmpty returns true if no user is allowed.
+func (a *UserAllowlist) IsEmpty() bool {
+	return a == nil || len(a.Users)+len(a.Teams)+len(a.Orgs) == 0
+}
+
+// IsAllowed returns true if user is allowed, either by username or as a
+// member of one of the teams or organizations. Teams and organizations are
+// looked up on the VCS host of repo.
+func (a *UserAllowlist) IsAllowed(logger logging.SimpleLogging, repo models.Repo, user models.User) (bool, error) {
+	if a == nil {
+		return false, nil
+	}
+	for _, username := range a.Users {
+		if strings.EqualFold(username, user.Username) {
+			return true, nil
+		}
+	}
+
+	if len(a.Teams) > 0 {
+		teams, err := a.Memberships.UserTeams(logger, repo, user)
+		if err != nil {
+			return false, err
+		}
+		for _, team := range a.Teams {
+			for _, userTeam := range teams {
+				if strings.EqualFold(team, userTeam) {
+					return true, nil
+				}
+			}
+		}
+	}
+
+	for _, org := range a.Orgs {
+		isMember, err := a.Memberships.IsOrgMember(logger, repo, user, org)
+		if err != nil {
+			return false, err
+		}
+		if isMember {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UserTeams returns the teams of user on the VCS host of repo, fetching them
+// if they haven't been yet.
+func (m *UserMemberships) UserTeams(logger logging.SimpleLogging, repo models.Repo, user models.User) ([]string, error) {
+	if user.Teams != nil {
+		return user.Teams, nil
+	}
+	if m == nil || m.TeamNamesGetter == nil {
+		return nil, fmt.Errorf("teams can't be checked for %s", repo.VCSHost.Type)
+	}
+	key := fmt.Sprintf("teams/%s/%s", repo.ID(), user.Username)
+	if entry, ok := m.cached(key); ok {
+		return entry.teams, nil
+	}
+	teams, err := m.TeamNamesGetter.GetTeamNamesForUser(logger, repo, user)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting teams of user %s", user.Username)
+	}
+	m.store(key, userAllowlistCacheEntry{teams: teams})
+	return teams, nil
+}
+
+// IsOrgMember returns true if user is a member of org on the VCS host of
+// repo.
+func (m *UserMemberships) IsOrgMember(logger logging.SimpleLogging, repo models.Repo, user models.User, org string) (bool, error) {
+	var checker vcs.OrgMembershipChecker
+	if m != nil {
+		checker = m.OrgMembershipCheckers[repo.VCSHost.Type]
+	}
+	if checker == nil {
+		return false, fmt.Errorf("organization membership can't be checked for %s", repo.VCSHost.Type)
+	}
+	key := fmt.Sprintf("orgs/%s/%s/%s", repo.VCSHost.Hostname, org, user.Username)
+	if entry, ok := m.cached(key); ok {
+		return entry.isMember, nil
+	}
+	isMember, err := checker.IsOrgMember(logger, user, org)
+	if err != nil {
+		return false, errors.Wrapf(err, "checking if user %s is a member of %s", user.Username, org)
+	}
+	m.store(key, userAllowlistCacheEntry{isMember: isMember})
+	return isMember, nil
+}
+
+func (m *UserMemberships) cached(key string) (userAllowlistCacheEntry, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	entry, ok := m.cache[key]
+	if !ok || time.Since(entry.storedAt) >= userAllowlistCacheTTL {
+		return userAllowlistCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (m *UserMemberships) store(key string, entry userAllowlistCacheEntry) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.cache == nil {
+		m.cache = make(map[string]userAllowlistCacheEntry)
+	}
+	for k, e := range m.cache {
+		if time.Since(e.storedAt) >= userAllowlistCacheTTL {
+			delete(m.cache, k)
+		}
+	}
+	entry.storedAt = time.Now()
+	m.cache[key] = entry
+}
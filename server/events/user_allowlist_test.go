@@ -0,0 +1,150 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeMembership returns fixed teams and organizations and counts how often
+// they're looked up.
+type fakeMembership struct {
+	teams       []string
+	orgs        []string
+	err         error
+	teamLookups int
+	orgLookups  int
+}
+
+func (f *fakeMembership) GetTeamNamesForUser(_ logging.SimpleLogging, _ models.Repo, _ models.User) ([]string, error) {
+	f.teamLookups++
+	return f.teams, f.err
+}
+
+func (f *fakeMembership) IsOrgMember(_ logging.SimpleLogging, _ models.User, org string) (bool, error) {
+	f.orgLookups++
+	for _, o := range f.orgs {
+		if o == org {
+			return true, f.err
+		}
+	}
+	return false, f.err
+}
+
+func TestNewUserAllowlist(t *testing.T) {
+	allowlist, err := events.NewUserAllowlist("alice, team:infra-admins,org:acme,,bob")
+	Ok(t, err)
+	Equals(t, []string{"alice", "bob"}, allowlist.Users)
+	Equals(t, []string{"infra-admins"}, allowlist.Teams)
+	Equals(t, []string{"acme"}, allowlist.Orgs)
+	Equals(t, false, allowlist.IsEmpty())
+
+	allowlist, err = events.NewUserAllowlist("")
+	Ok(t, err)
+	Equals(t, true, allowlist.IsEmpty())
+
+	_, err = events.NewUserAllowlist("group:infra")
+	ErrEquals(t, `invalid allowlist entry "group:infra", must be a username or start with "team:" or "org:"`, err)
+}
+
+func TestUserAllowlist_IsAllowed(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	repo := models.Repo{FullName: "acme/infra", VCSHost: models.VCSHost{Hostname: "github.com", Type: models.Github}}
+	membership := &fakeMembership{teams: []string{"Infra Admins", "infra-admins"}, orgs: []string{"acme"}}
+	newAllowlist := func(allowlist string) *events.UserAllowlist {
+		a, err := events.NewUserAllowlist(allowlist)
+		Ok(t, err)
+		a.Memberships = &events.UserMemberships{
+			TeamNamesGetter:       membership,
+			OrgMembershipCheckers: map[models.VCSHostType]vcs.OrgMembershipChecker{models.Github: membership},
+		}
+		return a
+	}
+
+	cases := []struct {
+		description string
+		allowlist   string
+		expAllowed  bool
+	}{
+		{"username", "Alice", true},
+		{"other username", "bob", false},
+		{"team slug", "team:infra-admins", true},
+		{"other team", "team:developers", false},
+		{"org", "org:acme", true},
+		{"other org", "org:other", false},
+		{"empty", "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			allowed, err := newAllowlist(c.allowlist).IsAllowed(logger, repo, models.User{Username: "alice"})
+			Ok(t, err)
+			Equals(t, c.expAllowed, allowed)
+		})
+	}
+
+	var nilAllowlist *events.UserAllowlist
+	allowed, err := nilAllowlist.IsAllowed(logger, repo, models.User{Username: "alice"})
+	Ok(t, err)
+	Equals(t, false, allowed)
+}
+
+// Teams and organization memberships are looked up once and cached, even
+// across allowlists.
+func TestUserAllowlist_IsAllowedCached(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	repo := models.Repo{FullName: "acme/infra", VCSHost: models.VCSHost{Hostname: "github.com", Type: models.Github}}
+	membership := &fakeMembership{orgs: []string{"acme"}}
+	memberships := &events.UserMemberships{
+		TeamNamesGetter:       membership,
+		OrgMembershipCheckers: map[models.VCSHostType]vcs.OrgMembershipChecker{models.Github: membership},
+	}
+	allowlist, err := events.NewUserAllowlist("team:infra-admins,org:acme")
+	Ok(t, err)
+	allowlist.Memberships = memberships
+
+	for i := 0; i < 2; i++ {
+		allowed, err := allowlist.IsAllowed(logger, repo, models.User{Username: "alice"})
+		Ok(t, err)
+		Equals(t, true, allowed)
+	}
+	Equals(t, 1, membership.teamLookups)
+	Equals(t, 1, membership.orgLookups)
+
+	other, err := events.NewUserAllowlist("team:developers,org:acme")
+	Ok(t, err)
+	other.Memberships = memberships
+	allowed, err := other.IsAllowed(logger, repo, models.User{Username: "alice"})
+	Ok(t, err)
+	Equals(t, true, allowed)
+	Equals(t, 1, membership.teamLookups)
+	Equals(t, 1, membership.orgLookups)
+
+	// Teams that were already fetched aren't looked up again.
+	allowed, err = allowlist.IsAllowed(logger, repo, models.User{Username: "bob", Teams: []string{"infra-admins"}})
+	Ok(t, err)
+	Equals(t, true, allowed)
+	Equals(t, 1, membership.teamLookups)
+}
+
+func TestUserAllowlist_IsAllowedErrors(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	user := models.User{Username: "alice"}
+	allowlist, err := events.NewUserAllowlist("org:acme")
+	Ok(t, err)
+
+	bitbucketRepo := models.Repo{FullName: "acme/infra", VCSHost: models.VCSHost{Hostname: "bitbucket.org", Type: models.BitbucketCloud}}
+	_, err = allowlist.IsAllowed(logger, bitbucketRepo, user)
+	ErrEquals(t, "organization membership can't be checked for BitbucketCloud", err)
+
+	allowlist.Memberships = &events.UserMemberships{
+		OrgMembershipCheckers: map[models.VCSHostType]vcs.OrgMembershipChecker{models.Github: &fakeMembership{err: errors.New("403 Forbidden")}},
+	}
+	githubRepo := models.Repo{FullName: "acme/infra", VCSHost: models.VCSHost{Hostname: "github.com", Type: models.Github}}
+	_, err = allowlist.IsAllowed(logger, githubRepo, user)
+	ErrEquals(t, "checking if user alice is a member of acme: 403 Forbidden", err)
+}
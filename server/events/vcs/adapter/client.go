@@ -0,0 +1,306 @@
+package adapter
+
+import (
+	"io"
+	"net/http"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/runtime/plugin"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// Client is a vcs.Client that calls a VCS adapter.
+type Client struct {
+	rpcClient *rpc.Client
+	// process is nil if the client wasn't started by Atlantis.
+	process *plugin.Process
+	info    InfoReply
+}
+
+// Event is a webhook event parsed by the adapter.
+type Event struct {
+	// Type is CommentEvent, PullEvent or empty if the event is ignored.
+	Type          string
+	IgnoreReason  string
+	BaseRepo      models.Repo
+	HeadRepo      models.Repo
+	Pull          models.PullRequest
+	PullEventType models.PullRequestEventType
+	User          models.User
+	Comment       string
+	CommentID     int64
+}
+
+// Start starts the adapter binary at path and connects to it.
+func Start(logger logging.SimpleLogging, path string) (*Client, error) {
+	process, err := plugin.StartProcess(logger, "vcs-adapter", path, plugin.Handshake{
+		ProtocolVersion:  ProtocolVersion,
+		MagicCookieKey:   MagicCookieKey,
+		MagicCookieValue: MagicCookieValue,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "starting VCS adapter")
+	}
+	client, err := Dial(process.Network, process.Address)
+	if err != nil {
+		process.Kill()
+		return nil, err
+	}
+	client.process = process
+	logger.Info("started VCS adapter for %s from %s", client.info.Name, path)
+	return client, nil
+}
+
+// Dial connects to the adapter listening on address and gets its info.
+func Dial(network string, address string) (*Client, error) {
+	rpcClient, err := jsonrpc.Dial(network, address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "connecting to VCS adapter at %s", address)
+	}
+	c := &Client{rpcClient: rpcClient}
+	if err := c.call("Info", Empty{}, &c.info); err != nil {
+		rpcClient.Close() // nolint: errcheck
+		return nil, err
+	}
+	return c, nil
+}
+
+// User returns the user Atlantis acts as on the VCS host.
+func (c *Client) User() string {
+	return c.info.User
+}
+
+// Kill closes the connection to the adapter and kills it if it was started
+// by Atlantis.
+func (c *Client) Kill() {
+	c.rpcClient.Close() // nolint: errcheck
+	if c.process != nil {
+		c.process.Kill()
+	}
+}
+
+// ParseWebhook has the adapter validate and parse the webhook request r.
+func (c *Client) ParseWebhook(r *http.Request) (Event, error) {
+	defer r.Body.Close() // nolint: errcheck
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Event{}, errors.Wrap(err, "reading body")
+	}
+	var reply WebhookReply
+	if err := c.call("ParseWebhook", WebhookArgs{Headers: r.Header, Body: string(body)}, &reply); err != nil {
+		return Event{}, err
+	}
+
+	event := Event{
+		Type:         reply.Event,
+		IgnoreReason: reply.IgnoreReason,
+		User:         models.User{Username: reply.User.Username, Teams: reply.User.Teams},
+		Comment:      reply.Comment,
+		CommentID:    reply.CommentID,
+	}
+	if event.Type == "" {
+		return event, nil
+	}
+	if event.Type != CommentEvent && event.Type != PullEvent {
+		return Event{}, errors.Errorf("unknown event %q", event.Type)
+	}
+	if event.BaseRepo, err = c.toRepo(reply.BaseRepo); err != nil {
+		return Event{}, errors.Wrap(err, "parsing base repo")
+	}
+	if event.HeadRepo, err = c.toRepo(reply.HeadRepo); err != nil {
+		return Event{}, errors.Wrap(err, "parsing head repo")
+	}
+	if event.Pull, err = c.toPull(reply.Pull); err != nil {
+		return Event{}, err
+	}
+	switch reply.PullEvent {
+	case OpenedPullEvent:
+		event.PullEventType = models.OpenedPullEvent
+	case UpdatedPullEvent:
+		event.PullEventType = models.UpdatedPullEvent
+	case ClosedPullEvent:
+		event.PullEventType = models.ClosedPullEvent
+	default:
+		event.PullEventType = models.OtherPullEvent
+	}
+	return event, nil
+}
+
+func (c *Client) GetModifiedFiles(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error) {
+	var reply StringsReply
+	err := c.call("GetModifiedFiles", PullArgs{Repo: fromRepo(repo), Pull: fromPull(pull)}, &reply)
+	return reply.Values, err
+}
+
+func (c *Client) CreateComment(_ logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string) error {
+	return c.call("CreateComment", CommentArgs{Repo: fromRepo(repo), PullNum: pullNum, Comment: comment, Command: command}, &Empty{})
+}
+
+func (c *Client) ReactToComment(_ logging.SimpleLogging, repo models.Repo, pullNum int, commentID int64, reaction string) error {
+	return c.call("ReactToComment", ReactionArgs{Repo: fromRepo(repo), PullNum: pullNum, CommentID: commentID, Reaction: reaction}, &Empty{})
+}
+
+func (c *Client) HidePrevCommandComments(_ logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
+	return c.call("HidePrevCommandComments", HideCommentsArgs{Repo: fromRepo(repo), PullNum: pullNum, Command: command, Dir: dir}, &Empty{})
+}
+
+func (c *Client) PullIsApproved(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.ApprovalStatus, error) {
+	var reply ApprovalReply
+	if err := c.call("PullIsApproved", PullArgs{Repo: fromRepo(repo), Pull: fromPull(pull)}, &reply); err != nil {
+		return models.ApprovalStatus{}, err
+	}
+	return models.ApprovalStatus{IsApproved: reply.IsApproved, ApprovedBy: reply.ApprovedBy, Date: reply.Date, Approvers: reply.Approvers}, nil
+}
+
+func (c *Client) PullIsMergeable(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest, vcsstatusname string, ignoreVCSStatusNames []string) (bool, error) {
+	var reply BoolReply
+	err := c.call("PullIsMergeable", MergeableArgs{
+		Repo:                 fromRepo(repo),
+		Pull:                 fromPull(pull),
+		VCSStatusName:        vcsstatusname,
+		IgnoreVCSStatusNames: ignoreVCSStatusNames,
+	}, &reply)
+	return reply.Value, err
+}
+
+func (c *Client) UpdateStatus(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, url string) error {
+	return c.call("UpdateStatus", StatusArgs{
+		Repo:        fromRepo(repo),
+		Pull:        fromPull(pull),
+		State:       state.String(),
+		Src:         src,
+		Description: description,
+		URL:         url,
+	}, &Empty{})
+}
+
+func (c *Client) DiscardReviews(repo models.Repo, pull models.PullRequest) error {
+	return c.call("DiscardReviews", PullArgs{Repo: fromRepo(repo), Pull: fromPull(pull)}, &Empty{})
+}
+
+func (c *Client) MergePull(_ logging.SimpleLogging, pull models.PullRequest, pullOptions models.PullRequestOptions) error {
+	return c.call("MergePull", MergeArgs{
+		Pull:                      fromPull(pull),
+		DeleteSourceBranchOnMerge: pullOptions.DeleteSourceBranchOnMerge,
+		MergeMethod:               pullOptions.MergeMethod,
+	}, &Empty{})
+}
+
+func (c *Client) MarkdownPullLink(pull models.PullRequest) (string, error) {
+	var reply StringReply
+	err := c.call("MarkdownPullLink", PullArgs{Repo: fromRepo(pull.BaseRepo), Pull: fromPull(pull)}, &reply)
+	return reply.Value, err
+}
+
+func (c *Client) GetTeamNamesForUser(_ logging.SimpleLogging, repo models.Repo, user models.User) ([]string, error) {
+	var reply StringsReply
+	err := c.call("GetTeamNamesForUser", TeamsArgs{Repo: fromRepo(repo), User: User{Username: user.Username}}, &reply)
+	return reply.Values, err
+}
+
+func (c *Client) GetFileContent(_ logging.SimpleLogging, pull models.PullRequest, fileName string) (bool, []byte, error) {
+	var reply FileReply
+	err := c.call("GetFileContent", FileArgs{Pull: fromPull(pull), FileName: fileName}, &reply)
+	return reply.Found, reply.Content, err
+}
+
+func (c *Client) SupportsSingleFileDownload(repo models.Repo) bool {
+	var reply BoolReply
+	if err := c.call("SupportsSingleFileDownload", RepoArgs{Repo: fromRepo(repo)}, &reply); err != nil {
+		return false
+	}
+	return reply.Value
+}
+
+func (c *Client) GetCloneURL(_ logging.SimpleLogging, _ models.VCSHostType, repo string) (string, error) {
+	var reply StringReply
+	err := c.call("GetCloneURL", CloneURLArgs{RepoFullName: repo}, &reply)
+	return reply.Value, err
+}
+
+func (c *Client) GetPullLabels(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error) {
+	var reply StringsReply
+	err := c.call("GetPullLabels", PullArgs{Repo: fromRepo(repo), Pull: fromPull(pull)}, &reply)
+	return reply.Values, err
+}
+
+func (c *Client) CreateIssue(_ logging.SimpleLogging, repo models.Repo, title string, body string) error {
+	return c.call("CreateIssue", IssueArgs{Repo: fromRepo(repo), Title: title, Body: body}, &Empty{})
+}
+
+func (c *Client) GetPullState(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.PullRequestState, error) {
+	var reply StringReply
+	if err := c.call("GetPullState", PullArgs{Repo: fromRepo(repo), Pull: fromPull(pull)}, &reply); err != nil {
+		return models.OpenPullState, err
+	}
+	return toPullState(reply.Value), nil
+}
+
+// call calls method of the adapter's service.
+func (c *Client) call(method string, args interface{}, reply interface{}) error {
+	if err := c.rpcClient.Call(ServiceName+"."+method, args, reply); err != nil {
+		return errors.Wrapf(err, "calling VCS adapter method %s", method)
+	}
+	return nil
+}
+
+// toRepo converts a repo from the adapter, adding the credentials to clone it.
+func (c *Client) toRepo(repo Repo) (models.Repo, error) {
+	return models.NewRepo(models.VCSAdapter, repo.FullName, repo.CloneURL, c.info.User, c.info.Token)
+}
+
+func (c *Client) toPull(pull PullRequest) (models.PullRequest, error) {
+	baseRepo, err := c.toRepo(pull.BaseRepo)
+	if err != nil {
+		return models.PullRequest{}, errors.Wrap(err, "parsing pull request base repo")
+	}
+	return models.PullRequest{
+		Num:        pull.Num,
+		HeadCommit: pull.HeadCommit,
+		URL:        pull.URL,
+		HeadBranch: pull.HeadBranch,
+		BaseBranch: pull.BaseBranch,
+		Author:     pull.Author,
+		State:      toPullState(pull.State),
+		BaseRepo:   baseRepo,
+	}, nil
+}
+
+func toPullState(state string) models.PullRequestState {
+	if state == ClosedPullState {
+		return models.ClosedPullState
+	}
+	return models.OpenPullState
+}
+
+// fromRepo converts repo for the adapter, without the credentials in its
+// clone URL.
+func fromRepo(repo models.Repo) Repo {
+	cloneURL := repo.CloneURL
+	if u, err := url.Parse(repo.CloneURL); err == nil {
+		u.User = nil
+		cloneURL = u.String()
+	}
+	return Repo{FullName: repo.FullName, CloneURL: cloneURL}
+}
+
+func fromPull(pull models.PullRequest) PullRequest {
+	state := OpenPullState
+	if pull.State == models.ClosedPullState {
+		state = ClosedPullState
+	}
+	return PullRequest{
+		Num:        pull.Num,
+		HeadCommit: pull.HeadCommit,
+		URL:        pull.URL,
+		HeadBranch: pull.HeadBranch,
+		BaseBranch: pull.BaseBranch,
+		Author:     pull.Author,
+		State:      state,
+		BaseRepo:   fromRepo(pull.BaseRepo),
+	}
+}
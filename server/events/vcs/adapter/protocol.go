@@ -0,0 +1,268 @@
+// Package adapter supports VCS hosts that Atlantis has no client for through
+// an external adapter process configured with --vcs-adapter.
+//
+// Adapters are started like step plugins: Atlantis runs the binary with
+// MagicCookieKey set to MagicCookieValue in its environment and the adapter
+// writes a handshake line to stdout that says where it's listening:
+//
+//	<protocol version>|<network>|<address>
+//
+// ex. "1|tcp|127.0.0.1:4321". Atlantis then calls the methods of the
+// ServiceName service over JSON-RPC 1.0, as implemented by Go's
+// net/rpc/jsonrpc, so adapters can be written in any language. Each method
+// takes one of the Args types below as its single parameter, ex.
+// {"method": "VCSAdapter.CreateComment", "params": [{"repo": ..., "pull_num": 1, ...}], "id": 1},
+// and returns one of the Reply types. Errors are returned as JSON-RPC errors.
+//
+// The methods are:
+//
+//	VCSAdapter.Info(Empty) InfoReply
+//	VCSAdapter.ParseWebhook(WebhookArgs) WebhookReply
+//	VCSAdapter.GetModifiedFiles(PullArgs) StringsReply
+//	VCSAdapter.CreateComment(CommentArgs) Empty
+//	VCSAdapter.ReactToComment(ReactionArgs) Empty
+//	VCSAdapter.HidePrevCommandComments(HideCommentsArgs) Empty
+//	VCSAdapter.PullIsApproved(PullArgs) ApprovalReply
+//	VCSAdapter.PullIsMergeable(MergeableArgs) BoolReply
+//	VCSAdapter.UpdateStatus(StatusArgs) Empty
+//	VCSAdapter.DiscardReviews(PullArgs) Empty
+//	VCSAdapter.MergePull(MergeArgs) Empty
+//	VCSAdapter.MarkdownPullLink(PullArgs) StringReply
+//	VCSAdapter.GetTeamNamesForUser(TeamsArgs) StringsReply
+//	VCSAdapter.GetFileContent(FileArgs) FileReply
+//	VCSAdapter.SupportsSingleFileDownload(RepoArgs) BoolReply
+//	VCSAdapter.GetCloneURL(CloneURLArgs) StringReply
+//	VCSAdapter.GetPullLabels(PullArgs) StringsReply
+//	VCSAdapter.CreateIssue(IssueArgs) Empty
+//	VCSAdapter.GetPullState(PullArgs) StringReply
+package adapter
+
+import "time"
+
+// ProtocolVersion is the version of the protocol between Atlantis and VCS
+// adapters. It's increased on incompatible changes.
+const ProtocolVersion = 1
+
+// MagicCookieKey and MagicCookieValue are set in the environment of VCS
+// adapters.
+const (
+	MagicCookieKey   = "ATLANTIS_VCS_ADAPTER_MAGIC_COOKIE"
+	MagicCookieValue = "7f4b0c2e9a6d4e1b8c3f5a7d9e0b2c4d"
+)
+
+// ServiceName is the name of the JSON-RPC service adapters implement.
+const ServiceName = "VCSAdapter"
+
+// Pull request states in PullRequest.State and the reply of GetPullState.
+const (
+	OpenPullState   = "open"
+	ClosedPullState = "closed"
+)
+
+// Webhook events in WebhookReply.Event.
+const (
+	// CommentEvent is a comment on a pull request.
+	CommentEvent = "comment"
+	// PullEvent is a pull request being opened, updated or closed.
+	PullEvent = "pull"
+)
+
+// Pull request events in WebhookReply.PullEvent.
+const (
+	OpenedPullEvent  = "opened"
+	UpdatedPullEvent = "updated"
+	ClosedPullEvent  = "closed"
+)
+
+// Repo is a repository.
+type Repo struct {
+	// FullName is the owner and name of the repo separated by a "/", ex.
+	// "myorg/infra".
+	FullName string `json:"full_name"`
+	// CloneURL is the HTTP or HTTPS URL to clone the repo without
+	// credentials. Its path must be /<full name>.git.
+	CloneURL string `json:"clone_url"`
+}
+
+// PullRequest is a pull request.
+type PullRequest struct {
+	Num        int    `json:"num"`
+	HeadCommit string `json:"head_commit"`
+	URL        string `json:"url"`
+	HeadBranch string `json:"head_branch"`
+	BaseBranch string `json:"base_branch"`
+	Author     string `json:"author"`
+	// State is OpenPullState or ClosedPullState.
+	State    string `json:"state"`
+	BaseRepo Repo   `json:"base_repo"`
+}
+
+// User is a user of the VCS host.
+type User struct {
+	Username string   `json:"username"`
+	Teams    []string `json:"teams,omitempty"`
+}
+
+// Empty is the parameter or reply of methods that don't have one.
+type Empty struct{}
+
+// InfoReply describes the adapter. Info is called once after the adapter is
+// started.
+type InfoReply struct {
+	// Name of the VCS host, used in log messages.
+	Name string `json:"name"`
+	// User is the user Atlantis acts as on the VCS host. Comments that
+	// mention it run commands, ex. "@atlantis-bot plan".
+	User string `json:"user"`
+	// Token is used with User to clone repos over HTTP(S).
+	Token string `json:"token"`
+}
+
+// WebhookArgs is a webhook request received on /events/vcs-adapter.
+type WebhookArgs struct {
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body"`
+}
+
+// WebhookReply is the event a webhook request is for. Adapters validate
+// webhook requests, ex. their signatures, and return an error if they're
+// invalid.
+type WebhookReply struct {
+	// Event is CommentEvent, PullEvent or empty if the webhook should be
+	// ignored.
+	Event string `json:"event"`
+	// IgnoreReason is why the webhook is ignored if Event is empty.
+	IgnoreReason string      `json:"ignore_reason,omitempty"`
+	BaseRepo     Repo        `json:"base_repo"`
+	HeadRepo     Repo        `json:"head_repo"`
+	Pull         PullRequest `json:"pull"`
+	// User is who commented or opened, updated or closed the pull request.
+	User User `json:"user"`
+	// Comment and CommentID are set for CommentEvent.
+	Comment   string `json:"comment,omitempty"`
+	CommentID int64  `json:"comment_id,omitempty"`
+	// PullEvent is OpenedPullEvent, UpdatedPullEvent or ClosedPullEvent
+	// for PullEvent. Other values are ignored.
+	PullEvent string `json:"pull_event,omitempty"`
+}
+
+// RepoArgs are the arguments of methods that only take a repo.
+type RepoArgs struct {
+	Repo Repo `json:"repo"`
+}
+
+// PullArgs are the arguments of methods that take a pull request.
+type PullArgs struct {
+	Repo Repo        `json:"repo"`
+	Pull PullRequest `json:"pull"`
+}
+
+// CommentArgs are the arguments of CreateComment. Command is the command the
+// comment is the output of, ex. "plan", if any.
+type CommentArgs struct {
+	Repo    Repo   `json:"repo"`
+	PullNum int    `json:"pull_num"`
+	Comment string `json:"comment"`
+	Command string `json:"command"`
+}
+
+// ReactionArgs are the arguments of ReactToComment.
+type ReactionArgs struct {
+	Repo      Repo   `json:"repo"`
+	PullNum   int    `json:"pull_num"`
+	CommentID int64  `json:"comment_id"`
+	Reaction  string `json:"reaction"`
+}
+
+// HideCommentsArgs are the arguments of HidePrevCommandComments.
+type HideCommentsArgs struct {
+	Repo    Repo   `json:"repo"`
+	PullNum int    `json:"pull_num"`
+	Command string `json:"command"`
+	Dir     string `json:"dir"`
+}
+
+// MergeableArgs are the arguments of PullIsMergeable. Statuses named
+// VCSStatusName or one of IgnoreVCSStatusNames are set by Atlantis and
+// shouldn't stop the pull request from being mergeable.
+type MergeableArgs struct {
+	Repo                 Repo        `json:"repo"`
+	Pull                 PullRequest `json:"pull"`
+	VCSStatusName        string      `json:"vcs_status_name"`
+	IgnoreVCSStatusNames []string    `json:"ignore_vcs_status_names"`
+}
+
+// StatusArgs are the arguments of UpdateStatus. State is "pending",
+// "success" or "failed".
+type StatusArgs struct {
+	Repo        Repo        `json:"repo"`
+	Pull        PullRequest `json:"pull"`
+	State       string      `json:"state"`
+	Src         string      `json:"src"`
+	Description string      `json:"description"`
+	URL         string      `json:"url"`
+}
+
+// MergeArgs are the arguments of MergePull.
+type MergeArgs struct {
+	Pull                      PullRequest `json:"pull"`
+	DeleteSourceBranchOnMerge bool        `json:"delete_source_branch_on_merge"`
+	MergeMethod               string      `json:"merge_method"`
+}
+
+// TeamsArgs are the arguments of GetTeamNamesForUser.
+type TeamsArgs struct {
+	Repo Repo `json:"repo"`
+	User User `json:"user"`
+}
+
+// FileArgs are the arguments of GetFileContent. The file is read from the
+// head commit of the pull request.
+type FileArgs struct {
+	Pull     PullRequest `json:"pull"`
+	FileName string      `json:"file_name"`
+}
+
+// CloneURLArgs are the arguments of GetCloneURL.
+type CloneURLArgs struct {
+	RepoFullName string `json:"repo_full_name"`
+}
+
+// IssueArgs are the arguments of CreateIssue.
+type IssueArgs struct {
+	Repo  Repo   `json:"repo"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// BoolReply is the reply of methods that return a bool.
+type BoolReply struct {
+	Value bool `json:"value"`
+}
+
+// StringReply is the reply of methods that return a string.
+type StringReply struct {
+	Value string `json:"value"`
+}
+
+// StringsReply is the reply of methods that return a list of strings.
+type StringsReply struct {
+	Values []string `json:"values"`
+}
+
+// ApprovalReply is the reply of PullIsApproved.
+type ApprovalReply struct {
+	IsApproved bool      `json:"is_approved"`
+	ApprovedBy string    `json:"approved_by"`
+	Date       time.Time `json:"date"`
+	// Approvers are the users whose approvals stand, if the VCS host
+	// reports them.
+	Approvers []string `json:"approvers,omitempty"`
+}
+
+// FileReply is the reply of GetFileContent. Content is base64 encoded in
+// JSON.
+type FileReply struct {
+	Found   bool   `json:"found"`
+	Content []byte `json:"content"`
+}
@@ -29,7 +29,16 @@ func NewAzureDevopsClient(hostname string, userName string, token string) (*Azur
 		Username: "",
 		Password: strings.TrimSpace(token),
 	}
-	httpClient := tp.Client()
+	return newAzureDevopsClient(hostname, userName, tp.Client())
+}
+
+// NewAzureDevopsServicePrincipalClient returns an Azure DevOps client that
+// authenticates as an Entra ID service principal.
+func NewAzureDevopsServicePrincipalClient(hostname string, userName string, servicePrincipal *AzureDevopsServicePrincipal) (*AzureDevopsClient, error) {
+	return newAzureDevopsClient(hostname, userName, servicePrincipal.Client())
+}
+
+func newAzureDevopsClient(hostname string, userName string, httpClient *http.Client) (*AzureDevopsClient, error) {
 	httpClient.Timeout = time.Second * 10
 	var adClient, err = azuredevops.NewClient(httpClient)
 	if err != nil {
@@ -139,6 +148,69 @@ func (g *AzureDevopsClient) HidePrevCommandComments(logger logging.SimpleLogging
 	return nil
 }
 
+// CleanUpComments deletes the comment threads started by Atlantis on the pull
+// request or, if collapse is true, closes them so they're collapsed.
+func (g *AzureDevopsClient) CleanUpComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, collapse bool) (int, error) {
+	owner, project, repoName := SplitAzureDevopsRepoFullName(repo.FullName)
+	threadsURL := fmt.Sprintf("%s/%s/_apis/git/repositories/%s/pullrequests/%d/threads", owner, project, repoName, pullNum)
+	req, err := g.Client.NewRequest("GET", threadsURL+"?api-version=5.1-preview.1", nil)
+	if err != nil {
+		return 0, err
+	}
+	var threads struct {
+		Value []*azuredevops.GitPullRequestCommentThread `json:"value"`
+	}
+	if _, err := g.Client.Execute(g.ctx, req, &threads); err != nil {
+		return 0, errors.Wrap(err, "listing comment threads")
+	}
+
+	cleanedUp := 0
+	for _, thread := range threads.Value {
+		if thread.GetIsDeleted() || len(thread.Comments) == 0 || !g.isAtlantisIdentity(thread.Comments[0].GetAuthor()) {
+			continue
+		}
+		threadURL := fmt.Sprintf("%s/%d", threadsURL, thread.GetID())
+		if collapse {
+			if thread.GetStatus() == "closed" {
+				continue
+			}
+			logger.Debug("Closing comment thread %d", thread.GetID())
+			req, err := g.Client.NewRequest("PATCH", threadURL+"?api-version=5.1-preview.1", map[string]string{"status": "closed"})
+			if err != nil {
+				return cleanedUp, err
+			}
+			if _, err := g.Client.Execute(g.ctx, req, nil); err != nil {
+				return cleanedUp, errors.Wrapf(err, "closing comment thread %d", thread.GetID())
+			}
+			cleanedUp++
+			continue
+		}
+		for _, comment := range thread.Comments {
+			if !g.isAtlantisIdentity(comment.GetAuthor()) {
+				continue
+			}
+			logger.Debug("Deleting comment %d of thread %d", comment.GetID(), thread.GetID())
+			req, err := g.Client.NewRequest("DELETE", fmt.Sprintf("%s/comments/%d?api-version=5.1-preview.1", threadURL, comment.GetID()), nil)
+			if err != nil {
+				return cleanedUp, err
+			}
+			if _, err := g.Client.Execute(g.ctx, req, nil); err != nil {
+				return cleanedUp, errors.Wrapf(err, "deleting comment %d of thread %d", comment.GetID(), thread.GetID())
+			}
+		}
+		cleanedUp++
+	}
+	return cleanedUp, nil
+}
+
+// isAtlantisIdentity returns whether identity is the Atlantis user.
+func (g *AzureDevopsClient) isAtlantisIdentity(identity *azuredevops.IdentityRef) bool {
+	if identity == nil {
+		return false
+	}
+	return strings.EqualFold(identity.GetUniqueName(), g.UserName) || strings.EqualFold(identity.GetDisplayName(), g.UserName)
+}
+
 // PullIsApproved returns true if the merge request was approved by another reviewer.
 // https://docs.microsoft.com/en-us/azure/devops/repos/git/branch-policies?view=azure-devops#require-a-minimum-number-of-reviewers
 func (g *AzureDevopsClient) PullIsApproved(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (approvalStatus models.ApprovalStatus, err error) {
@@ -162,9 +234,8 @@ func (g *AzureDevopsClient) PullIsApproved(logger logging.SimpleLogging, repo mo
 		}
 
 		if review.GetVote() == azuredevops.VoteApproved || review.GetVote() == azuredevops.VoteApprovedWithSuggestions {
-			return models.ApprovalStatus{
-				IsApproved: true,
-			}, nil
+			approvalStatus.IsApproved = true
+			approvalStatus.Approvers = append(approvalStatus.Approvers, review.IdentityRef.GetUniqueName())
 		}
 	}
 
@@ -431,3 +502,19 @@ func (g *AzureDevopsClient) GetCloneURL(_ logging.SimpleLogging, VCSHostType mod
 func (g *AzureDevopsClient) GetPullLabels(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest) ([]string, error) {
 	return nil, fmt.Errorf("not yet implemented")
 }
+
+func (g *AzureDevopsClient) CreateIssue(_ logging.SimpleLogging, _ models.Repo, _ string, _ string) error {
+	return fmt.Errorf("not yet implemented")
+}
+
+// GetPullState returns whether pull is still active.
+func (g *AzureDevopsClient) GetPullState(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.PullRequestState, error) {
+	adPull, err := g.GetPullRequest(logger, repo, pull.Num)
+	if err != nil {
+		return models.ClosedPullState, err
+	}
+	if adPull.GetStatus() == azuredevops.PullActive.String() {
+		return models.OpenPullState, nil
+	}
+	return models.ClosedPullState, nil
+}
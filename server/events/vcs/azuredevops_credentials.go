@@ -0,0 +1,149 @@
+package vcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// azureDevopsScope is the scope of Entra ID access tokens for the Azure
+	// DevOps resource. The GUID is the application ID of Azure DevOps.
+	azureDevopsScope = "499b84ac-1321-427f-aa17-267ca6975798/.default"
+	// defaultAzureAuthorityHost is the Entra ID host tokens are requested
+	// from when AuthorityHost isn't set.
+	defaultAzureAuthorityHost = "https://login.microsoftonline.com/"
+	// azureDevopsTokenRefreshBefore is how long before it expires the cached
+	// access token is replaced. Tokens are valid for an hour or longer.
+	azureDevopsTokenRefreshBefore = 5 * time.Minute
+)
+
+// AzureDevopsServicePrincipal authenticates to Azure DevOps as an Entra ID
+// service principal instead of as a user with a personal access token. The
+// service principal authenticates with a client secret or, with workload
+// identity federation, with a token issued by another identity provider, ex.
+// a Kubernetes service account token.
+type AzureDevopsServicePrincipal struct {
+	TenantID string
+	ClientID string
+	// ClientSecret is the client secret of the service principal. Either
+	// ClientSecret or FederatedTokenFile must be set.
+	ClientSecret string
+	// FederatedTokenFile is the file the federated token is read from. It's
+	// re-read for every new access token since it's rotated by its issuer.
+	FederatedTokenFile string
+	// AuthorityHost is the Entra ID host, ex. https://login.microsoftonline.com/.
+	// If empty, defaultAzureAuthorityHost is used.
+	AuthorityHost string
+
+	mutex     sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// azureTokenResponse is the response of the Entra ID token endpoint.
+type azureTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// GetToken returns the cached access token, requesting a new one if there's
+// none or it's about to expire.
+func (p *AzureDevopsServicePrincipal) GetToken() (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.token != "" && time.Until(p.expiresAt) > azureDevopsTokenRefreshBefore {
+		return p.token, nil
+	}
+
+	form := url.Values{
+		"client_id":  {p.ClientID},
+		"scope":      {azureDevopsScope},
+		"grant_type": {"client_credentials"},
+	}
+	if p.FederatedTokenFile != "" {
+		assertion, err := os.ReadFile(p.FederatedTokenFile)
+		if err != nil {
+			return "", errors.Wrap(err, "reading azure federated token file")
+		}
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	} else {
+		form.Set("client_secret", p.ClientSecret)
+	}
+
+	authorityHost := p.AuthorityHost
+	if authorityHost == "" {
+		authorityHost = defaultAzureAuthorityHost
+	}
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(authorityHost, "/"), url.PathEscape(p.TenantID))
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "requesting azure devops access token")
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "reading azure devops access token response")
+	}
+
+	var token azureTokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", errors.Wrapf(err, "parsing azure devops access token response with status %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return "", fmt.Errorf("requesting azure devops access token failed with status %d: %s: %s", resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+	p.token = token.AccessToken
+	p.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return p.token, nil
+}
+
+// Client returns an http.Client that authenticates requests to Azure DevOps
+// as the service principal.
+func (p *AzureDevopsServicePrincipal) Client() *http.Client {
+	return &http.Client{
+		Transport: &azureDevopsBearerTransport{
+			servicePrincipal: p,
+			base:             http.DefaultTransport,
+		},
+	}
+}
+
+// azureDevopsBearerTransport authenticates requests with the access token of
+// a service principal.
+type azureDevopsBearerTransport struct {
+	servicePrincipal *AzureDevopsServicePrincipal
+	base             http.RoundTripper
+}
+
+func (t *azureDevopsBearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.servicePrincipal.GetToken()
+	if err != nil {
+		// RoundTrip must always close the body, including on errors.
+		if req.Body != nil {
+			req.Body.Close() // nolint: errcheck
+		}
+		return nil, err
+	}
+	creq := req.Clone(req.Context())
+	creq.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(creq)
+}
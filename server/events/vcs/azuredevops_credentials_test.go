@@ -0,0 +1,105 @@
+package vcs_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestAzureDevopsServicePrincipal_GetToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "azure-identity-token")
+	Ok(t, os.WriteFile(tokenFile, []byte("federated-token\n"), 0600))
+
+	cases := []struct {
+		description  string
+		principal    *vcs.AzureDevopsServicePrincipal
+		expAssertion string
+		expSecret    string
+	}{
+		{
+			description: "client secret",
+			principal:   &vcs.AzureDevopsServicePrincipal{TenantID: "tenant", ClientID: "client", ClientSecret: "secret"},
+			expSecret:   "secret",
+		},
+		{
+			description:  "federated token file",
+			principal:    &vcs.AzureDevopsServicePrincipal{TenantID: "tenant", ClientID: "client", FederatedTokenFile: tokenFile},
+			expAssertion: "federated-token",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				Equals(t, "/tenant/oauth2/v2.0/token", r.URL.Path)
+				Ok(t, r.ParseForm())
+				Equals(t, "client_credentials", r.PostForm.Get("grant_type"))
+				Equals(t, "client", r.PostForm.Get("client_id"))
+				Equals(t, "499b84ac-1321-427f-aa17-267ca6975798/.default", r.PostForm.Get("scope"))
+				Equals(t, c.expSecret, r.PostForm.Get("client_secret"))
+				Equals(t, c.expAssertion, r.PostForm.Get("client_assertion"))
+				Ok(t, json.NewEncoder(w).Encode(map[string]interface{}{
+					"token_type":   "Bearer",
+					"expires_in":   3599,
+					"access_token": "access-token",
+				}))
+			}))
+			defer server.Close()
+			c.principal.AuthorityHost = server.URL
+
+			token, err := c.principal.GetToken()
+			Ok(t, err)
+			Equals(t, "access-token", token)
+
+			// The token is cached until it's about to expire.
+			token, err = c.principal.GetToken()
+			Ok(t, err)
+			Equals(t, "access-token", token)
+			Equals(t, 1, requests)
+		})
+	}
+}
+
+func TestAzureDevopsServicePrincipal_GetTokenError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		Ok(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":             "invalid_client",
+			"error_description": "AADSTS7000215: Invalid client secret provided.",
+		}))
+	}))
+	defer server.Close()
+
+	principal := &vcs.AzureDevopsServicePrincipal{TenantID: "tenant", ClientID: "client", ClientSecret: "wrong", AuthorityHost: server.URL}
+	_, err := principal.GetToken()
+	ErrEquals(t, "requesting azure devops access token failed with status 401: invalid_client: AADSTS7000215: Invalid client secret provided.", err)
+}
+
+// Requests to Azure DevOps are authenticated with the access token.
+func TestAzureDevopsServicePrincipal_Client(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tenant/oauth2/v2.0/token" {
+			Ok(t, json.NewEncoder(w).Encode(map[string]interface{}{
+				"expires_in":   3599,
+				"access_token": "access-token",
+			}))
+			return
+		}
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	principal := &vcs.AzureDevopsServicePrincipal{TenantID: "tenant", ClientID: "client", ClientSecret: "secret", AuthorityHost: server.URL}
+	resp, err := principal.Client().Get(server.URL + "/org/_apis/projects")
+	Ok(t, err)
+	resp.Body.Close() // nolint: errcheck
+	Equals(t, "Bearer access-token", authorization)
+}
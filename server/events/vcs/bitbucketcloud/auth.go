@@ -0,0 +1,129 @@
+package bitbucketcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// AccessTokenGitUser is the username git authenticates with when the
+	// password is an access token instead of an app password.
+	AccessTokenGitUser = "x-token-auth"
+	// OAuthTokenURL is the endpoint OAuth consumers get access tokens from.
+	OAuthTokenURL = "https://bitbucket.org/site/oauth2/access_token"
+	// oauthTokenRefreshBefore is how long before it expires an OAuth access
+	// token is refreshed. Access tokens are valid for two hours.
+	oauthTokenRefreshBefore = 10 * time.Minute
+)
+
+// TokenSource returns the bearer token requests are authenticated with.
+type TokenSource interface {
+	GetToken() (string, error)
+}
+
+// AccessToken is a repository, project or workspace access token.
+type AccessToken string
+
+// GetToken returns the access token.
+func (t AccessToken) GetToken() (string, error) {
+	return string(t), nil
+}
+
+// OAuthConsumer gets access tokens for an OAuth consumer with the client
+// credentials grant and refreshes them with their refresh token before they
+// expire.
+type OAuthConsumer struct {
+	ClientID     string
+	ClientSecret string
+	// TokenURL is the endpoint access tokens are requested from. If empty,
+	// OAuthTokenURL is used.
+	TokenURL   string
+	HTTPClient *http.Client
+
+	mutex        sync.Mutex
+	accessToken  string
+	refreshToken string
+	expiresAt    time.Time
+}
+
+// oauthTokenResponse is the response of the OAuth token endpoint.
+type oauthTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// GetToken returns the cached access token, refreshing it if there's none or
+// it's about to expire.
+func (c *OAuthConsumer) GetToken() (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.accessToken != "" && time.Until(c.expiresAt) > oauthTokenRefreshBefore {
+		return c.accessToken, nil
+	}
+
+	var token oauthTokenResponse
+	var err error
+	if c.refreshToken != "" {
+		token, err = c.requestToken(url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {c.refreshToken},
+		})
+	}
+	if c.refreshToken == "" || err != nil {
+		// Without a refresh token, or if it was revoked, get a new access
+		// token with the client credentials.
+		token, err = c.requestToken(url.Values{"grant_type": {"client_credentials"}})
+		if err != nil {
+			return "", err
+		}
+	}
+	c.accessToken = token.AccessToken
+	c.refreshToken = token.RefreshToken
+	c.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return c.accessToken, nil
+}
+
+func (c *OAuthConsumer) requestToken(form url.Values) (oauthTokenResponse, error) {
+	var token oauthTokenResponse
+	tokenURL := c.TokenURL
+	if tokenURL == "" {
+		tokenURL = OAuthTokenURL
+	}
+	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return token, err
+	}
+	req.SetBasicAuth(c.ClientID, c.ClientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return token, errors.Wrap(err, "requesting bitbucket oauth access token")
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return token, errors.Wrap(err, "reading bitbucket oauth access token response")
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return token, errors.Wrapf(err, "Could not parse response %q", string(body))
+	}
+	if resp.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return token, fmt.Errorf("requesting bitbucket oauth access token with grant %s failed with status %d: %s: %s", form.Get("grant_type"), resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+	return token, nil
+}
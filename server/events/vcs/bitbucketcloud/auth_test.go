@@ -0,0 +1,105 @@
+package bitbucketcloud_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestOAuthConsumer_GetToken(t *testing.T) {
+	var grants []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, secret, _ := r.BasicAuth()
+		Equals(t, "key", key)
+		Equals(t, "secret", secret)
+		Ok(t, r.ParseForm())
+		grant := r.PostForm.Get("grant_type")
+		grants = append(grants, grant)
+		switch grant {
+		case "client_credentials":
+			// An access token that expires right away so that it's refreshed.
+			fmt.Fprint(w, `{"access_token": "token-1", "refresh_token": "refresh-1", "expires_in": 0}`) // nolint: errcheck
+		case "refresh_token":
+			Equals(t, "refresh-1", r.PostForm.Get("refresh_token"))
+			fmt.Fprint(w, `{"access_token": "token-2", "refresh_token": "refresh-2", "expires_in": 7200}`) // nolint: errcheck
+		}
+	}))
+	defer testServer.Close()
+
+	consumer := &bitbucketcloud.OAuthConsumer{ClientID: "key", ClientSecret: "secret", TokenURL: testServer.URL}
+	token, err := consumer.GetToken()
+	Ok(t, err)
+	Equals(t, "token-1", token)
+
+	token, err = consumer.GetToken()
+	Ok(t, err)
+	Equals(t, "token-2", token)
+
+	// The refreshed token is cached.
+	token, err = consumer.GetToken()
+	Ok(t, err)
+	Equals(t, "token-2", token)
+	Equals(t, []string{"client_credentials", "refresh_token"}, grants)
+}
+
+// A revoked refresh token falls back to the client credentials grant.
+func TestOAuthConsumer_GetTokenRevokedRefreshToken(t *testing.T) {
+	var grants []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Ok(t, r.ParseForm())
+		grant := r.PostForm.Get("grant_type")
+		grants = append(grants, grant)
+		if grant == "refresh_token" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_grant", "error_description": "Invalid refresh_token"}`) // nolint: errcheck
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "token-%d", "refresh_token": "refresh", "expires_in": 0}`, len(grants)) // nolint: errcheck
+	}))
+	defer testServer.Close()
+
+	consumer := &bitbucketcloud.OAuthConsumer{ClientID: "key", ClientSecret: "secret", TokenURL: testServer.URL}
+	_, err := consumer.GetToken()
+	Ok(t, err)
+	token, err := consumer.GetToken()
+	Ok(t, err)
+	Equals(t, "token-3", token)
+	Equals(t, []string{"client_credentials", "refresh_token", "client_credentials"}, grants)
+}
+
+func TestOAuthConsumer_GetTokenError(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": "unauthorized_client", "error_description": "Invalid OAuth client credentials"}`) // nolint: errcheck
+	}))
+	defer testServer.Close()
+
+	consumer := &bitbucketcloud.OAuthConsumer{ClientID: "key", ClientSecret: "wrong", TokenURL: testServer.URL}
+	_, err := consumer.GetToken()
+	ErrEquals(t, "requesting bitbucket oauth access token with grant client_credentials failed with status 400: unauthorized_client: Invalid OAuth client credentials", err)
+}
+
+// Requests are authenticated with a bearer token if the client has a token
+// source.
+func TestClient_AccessToken(t *testing.T) {
+	var authorization string
+	testServer := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer testServer.Close()
+
+	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "", "runatlantis.io")
+	client.BaseURL = testServer.URL
+	client.Tokens = bitbucketcloud.AccessToken("access-token")
+
+	repo := models.Repo{FullName: "owner/repo"}
+	err := client.CreateComment(logging.NewNoopLogger(t), repo, 1, "comment", "")
+	Ok(t, err)
+	Equals(t, "Bearer access-token", authorization)
+}
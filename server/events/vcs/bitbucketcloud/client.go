@@ -21,6 +21,10 @@ type Client struct {
 	Password    string
 	BaseURL     string
 	AtlantisURL string
+	// Tokens authenticates requests with a bearer token instead of Username
+	// and Password, ex. a workspace access token or an OAuth consumer. If
+	// nil, requests use basic auth.
+	Tokens TokenSource
 }
 
 // NewClient builds a bitbucket cloud client. atlantisURL is the
@@ -146,6 +150,31 @@ func (b *Client) HidePrevCommandComments(logger logging.SimpleLogging, repo mode
 	return nil
 }
 
+// CleanUpComments deletes the comments made by Atlantis on the pull request.
+// Bitbucket can't hide comments, so they're deleted even if collapse is true.
+func (b *Client) CleanUpComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, _ bool) (int, error) {
+	me, err := b.GetMyUUID()
+	if err != nil {
+		return 0, errors.Wrapf(err, "Cannot get my uuid! Please check required scope of the auth token!")
+	}
+	comments, err := b.GetPullRequestComments(repo, pullNum)
+	if err != nil {
+		return 0, err
+	}
+	cleanedUp := 0
+	for _, c := range comments {
+		if c.User == nil || c.User.UUID == nil || !strings.EqualFold(*c.User.UUID, me) {
+			continue
+		}
+		logger.Debug("Deleting comment with id %d", *c.ID)
+		if err := b.DeletePullRequestComment(repo, pullNum, *c.ID); err != nil {
+			return cleanedUp, err
+		}
+		cleanedUp++
+	}
+	return cleanedUp, nil
+}
+
 func (b *Client) DeletePullRequestComment(repo models.Repo, pullNum int, commentId int) error {
 	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d/comments/%d", b.BaseURL, repo.FullName, pullNum, commentId)
 	_, err := b.makeRequest("DELETE", path, nil)
@@ -215,9 +244,10 @@ func (b *Client) PullIsApproved(logger logging.SimpleLogging, repo models.Repo,
 		// Bitbucket allows the author to approve their own pull request. This
 		// defeats the purpose of approvals so we don't count that approval.
 		if *participant.Approved && *participant.User.UUID != authorUUID {
-			return models.ApprovalStatus{
-				IsApproved: true,
-			}, nil
+			approvalStatus.IsApproved = true
+			if participant.User.AccountID != nil {
+				approvalStatus.Approvers = append(approvalStatus.Approvers, *participant.User.AccountID)
+			}
 		}
 	}
 	return approvalStatus, nil
@@ -312,7 +342,15 @@ func (b *Client) prepRequest(method string, path string, body io.Reader) (*http.
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth(b.Username, b.Password)
+	if b.Tokens != nil {
+		token, err := b.Tokens.GetToken()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		req.SetBasicAuth(b.Username, b.Password)
+	}
 	if body != nil {
 		req.Header.Add("Content-Type", "application/json")
 	}
@@ -373,3 +411,28 @@ func (b *Client) GetCloneURL(_ logging.SimpleLogging, _ models.VCSHostType, _ st
 func (b *Client) GetPullLabels(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest) ([]string, error) {
 	return nil, fmt.Errorf("not yet implemented")
 }
+
+func (b *Client) CreateIssue(_ logging.SimpleLogging, _ models.Repo, _ string, _ string) error {
+	return fmt.Errorf("not yet implemented")
+}
+
+// GetPullState returns whether pull is still open. Merged, declined and
+// superseded pull requests are closed.
+func (b *Client) GetPullState(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.PullRequestState, error) {
+	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d", b.BaseURL, repo.FullName, pull.Num)
+	resp, err := b.makeRequest("GET", path, nil)
+	if err != nil {
+		return models.ClosedPullState, err
+	}
+	var pullResp PullRequest
+	if err := json.Unmarshal(resp, &pullResp); err != nil {
+		return models.ClosedPullState, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	if pullResp.State == nil {
+		return models.ClosedPullState, fmt.Errorf("API response %q was missing the pull request state", string(resp))
+	}
+	if *pullResp.State == "OPEN" {
+		return models.OpenPullState, nil
+	}
+	return models.ClosedPullState, nil
+}
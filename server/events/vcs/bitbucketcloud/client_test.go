@@ -227,6 +227,41 @@ func TestClient_PullIsApproved(t *testing.T) {
 	}
 }
 
+func TestClient_GetPullState(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	cases := map[string]models.PullRequestState{
+		"OPEN":       models.OpenPullState,
+		"MERGED":     models.ClosedPullState,
+		"DECLINED":   models.ClosedPullState,
+		"SUPERSEDED": models.ClosedPullState,
+	}
+	for state, exp := range cases {
+		t.Run(state, func(t *testing.T) {
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.RequestURI {
+				case "/2.0/repositories/owner/repo/pullrequests/1":
+					w.Write([]byte(fmt.Sprintf(`{"id": 1, "state": %q}`, state))) // nolint: errcheck
+					return
+				default:
+					t.Errorf("got unexpected request at %q", r.RequestURI)
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+			}))
+			defer testServer.Close()
+
+			client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+			client.BaseURL = testServer.URL
+
+			repo, err := models.NewRepo(models.BitbucketCloud, "owner/repo", "https://bitbucket.org/owner/repo.git", "user", "token")
+			Ok(t, err)
+			pullState, err := client.GetPullState(logger, repo, models.PullRequest{Num: 1, BaseRepo: repo})
+			Ok(t, err)
+			Equals(t, exp, pullState)
+		})
+	}
+}
+
 func TestClient_PullIsMergeable(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	cases := map[string]struct {
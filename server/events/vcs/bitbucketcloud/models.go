@@ -91,7 +91,8 @@ type Link struct {
 type Participant struct {
 	Approved *bool `json:"approved,omitempty" validate:"required"`
 	User     *struct {
-		UUID *string `json:"uuid,omitempty" validate:"required"`
+		UUID      *string `json:"uuid,omitempty" validate:"required"`
+		AccountID *string `json:"account_id,omitempty"`
 	} `json:"user,omitempty" validate:"required"`
 }
 type BranchMeta struct {
@@ -154,6 +154,55 @@ func (b *Client) HidePrevCommandComments(_ logging.SimpleLogging, _ models.Repo,
 	return nil
 }
 
+// CleanUpComments deletes the comments made by Atlantis on the pull request.
+// Bitbucket can't hide comments, so they're deleted even if collapse is true.
+func (b *Client) CleanUpComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, _ bool) (int, error) {
+	projectKey, err := b.GetProjectKey(repo.Name, repo.SanitizedCloneURL)
+	if err != nil {
+		return 0, err
+	}
+	pullURL := fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d", b.BaseURL, projectKey, repo.Name, pullNum)
+
+	// Comments can only be deleted at their current version.
+	versionsByID := make(map[int]int)
+	nextPageStart := 0
+	// We'll only loop 1000 times as a safety measure.
+	maxLoops := 1000
+	for i := 0; i < maxLoops; i++ {
+		resp, err := b.makeRequest("GET", fmt.Sprintf("%s/activities?start=%d", pullURL, nextPageStart), nil)
+		if err != nil {
+			return 0, err
+		}
+		var activities Activities
+		if err := json.Unmarshal(resp, &activities); err != nil {
+			return 0, errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		if err := validator.New().Struct(activities); err != nil {
+			return 0, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		}
+		for _, v := range activities.Values {
+			if *v.Action != "COMMENTED" || v.Comment == nil || !strings.EqualFold(*v.Comment.Author.Name, b.Username) {
+				continue
+			}
+			versionsByID[*v.Comment.ID] = *v.Comment.Version
+		}
+		if *activities.IsLastPage {
+			break
+		}
+		nextPageStart = *activities.NextPageStart
+	}
+
+	cleanedUp := 0
+	for id, version := range versionsByID {
+		logger.Debug("Deleting comment %d", id)
+		if _, err := b.makeRequest("DELETE", fmt.Sprintf("%s/comments/%d?version=%d", pullURL, id, version), nil); err != nil {
+			return cleanedUp, err
+		}
+		cleanedUp++
+	}
+	return cleanedUp, nil
+}
+
 // postComment actually posts the comment. It's a helper for CreateComment().
 func (b *Client) postComment(repo models.Repo, pullNum int, comment string) error {
 	bodyBytes, err := json.Marshal(map[string]string{"text": comment})
@@ -189,9 +238,10 @@ func (b *Client) PullIsApproved(logger logging.SimpleLogging, repo models.Repo,
 	}
 	for _, reviewer := range pullResp.Reviewers {
 		if *reviewer.Approved {
-			return models.ApprovalStatus{
-				IsApproved: true,
-			}, nil
+			approvalStatus.IsApproved = true
+			if reviewer.User != nil && reviewer.User.Name != nil {
+				approvalStatus.Approvers = append(approvalStatus.Approvers, *reviewer.User.Name)
+			}
 		}
 	}
 	return approvalStatus, nil
@@ -372,3 +422,32 @@ func (b *Client) GetCloneURL(_ logging.SimpleLogging, _ models.VCSHostType, _ st
 func (b *Client) GetPullLabels(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest) ([]string, error) {
 	return nil, fmt.Errorf("not yet implemented")
 }
+
+func (b *Client) CreateIssue(_ logging.SimpleLogging, _ models.Repo, _ string, _ string) error {
+	return fmt.Errorf("not yet implemented")
+}
+
+// GetPullState returns whether pull is still open. Merged and declined pull
+// requests are closed.
+func (b *Client) GetPullState(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.PullRequestState, error) {
+	projectKey, err := b.GetProjectKey(repo.Name, repo.SanitizedCloneURL)
+	if err != nil {
+		return models.ClosedPullState, err
+	}
+	path := fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d", b.BaseURL, projectKey, repo.Name, pull.Num)
+	resp, err := b.makeRequest("GET", path, nil)
+	if err != nil {
+		return models.ClosedPullState, err
+	}
+	var pullResp PullRequest
+	if err := json.Unmarshal(resp, &pullResp); err != nil {
+		return models.ClosedPullState, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	if pullResp.State == nil {
+		return models.ClosedPullState, fmt.Errorf("API response %q was missing the pull request state", string(resp))
+	}
+	if *pullResp.State == "OPEN" {
+		return models.OpenPullState, nil
+	}
+	return models.ClosedPullState, nil
+}
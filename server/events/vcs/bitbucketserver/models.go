@@ -32,6 +32,9 @@ type PullRequest struct {
 	State     *string `json:"state,omitempty" validate:"required"`
 	Reviewers []struct {
 		Approved *bool `json:"approved,omitempty" validate:"required"`
+		User     *struct {
+			Name *string `json:"name,omitempty"`
+		} `json:"user,omitempty"`
 	} `json:"reviewers,omitempty" validate:"required"`
 }
 
@@ -72,6 +75,22 @@ type Changes struct {
 	IsLastPage    *bool `json:"isLastPage,omitempty" validate:"required"`
 }
 
+// Activities is a page of the activities of a pull request.
+type Activities struct {
+	Values []struct {
+		Action  *string `json:"action,omitempty" validate:"required"`
+		Comment *struct {
+			ID      *int `json:"id,omitempty" validate:"required"`
+			Version *int `json:"version,omitempty" validate:"required"`
+			Author  *struct {
+				Name *string `json:"name,omitempty" validate:"required"`
+			} `json:"author,omitempty" validate:"required"`
+		} `json:"comment,omitempty"`
+	} `json:"values,omitempty" validate:"required"`
+	NextPageStart *int  `json:"nextPageStart,omitempty"`
+	IsLastPage    *bool `json:"isLastPage,omitempty" validate:"required"`
+}
+
 type MergeStatus struct {
 	CanMerge   *bool `json:"canMerge,omitempty" validate:"required"`
 	Conflicted *bool `json:"conflicted,omitempty" validate:"required"`
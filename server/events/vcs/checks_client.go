@@ -0,0 +1,54 @@
+package vcs
+
+import (
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// ChecksClient reports the result of commands as check runs instead of
+// commit statuses. Only GitHub supports check runs.
+type ChecksClient interface {
+	// UpdateCheckRun creates the check run named opts.Name on the head commit
+	// of pull, or updates it if it already exists.
+	UpdateCheckRun(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, opts CheckRunOptions) error
+}
+
+// CheckRunOptions are the contents of a check run.
+type CheckRunOptions struct {
+	// Name identifies the check run on the commit.
+	Name string
+	// Status is the status of the check run. Pending check runs are in
+	// progress, the others are completed.
+	Status models.CommitStatus
+	// Title and Summary are shown at the top of the check run.
+	Title   string
+	Summary string
+	// Text is shown below the summary.
+	Text string
+	// DetailsURL links to the full output of the command.
+	DetailsURL string
+	// ExternalID is the comment command that re-runs the check run when one
+	// of its actions is requested, ex. "atlantis plan -d dir".
+	ExternalID string
+	// Annotations are shown next to the files of the pull request.
+	Annotations []CheckRunAnnotation
+	// Actions are the buttons shown on the check run.
+	Actions []CheckRunAction
+}
+
+// CheckRunAnnotation annotates a file with a failure of a check run.
+type CheckRunAnnotation struct {
+	// Path is the path of the annotated file relative to the repo root.
+	Path    string
+	Title   string
+	Message string
+}
+
+// CheckRunAction is a button that users can click on a check run.
+type CheckRunAction struct {
+	Label       string
+	Description string
+	// Identifier is sent back to Atlantis in the check_run event when the
+	// action is requested.
+	Identifier string
+}
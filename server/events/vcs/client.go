@@ -53,4 +53,11 @@ type Client interface {
 
 	// GetPullLabels returns the labels of a pull request
 	GetPullLabels(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error)
+
+	// CreateIssue opens an issue in repo with the given title and body.
+	CreateIssue(logger logging.SimpleLogging, repo models.Repo, title string, body string) error
+
+	// GetPullState returns whether pull is still open. Merged pull requests
+	// are closed.
+	GetPullState(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.PullRequestState, error)
 }
@@ -0,0 +1,14 @@
+package vcs
+
+import (
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// CommentCleaner cleans up the comments Atlantis made on a pull request.
+type CommentCleaner interface {
+	// CleanUpComments deletes the comments made by Atlantis on the pull
+	// request. If collapse is true, they're collapsed instead on the VCS
+	// hosts that support it. It returns how many comments were cleaned up.
+	CleanUpComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, collapse bool) (int, error)
+}
@@ -4,7 +4,9 @@ package common
 
 import (
 	"fmt"
-	"math"
+	"regexp"
+	"sort"
+	"strings"
 )
 
 // AutomergeCommitMsg returns the commit message to use when automerging.
@@ -14,12 +16,23 @@ func AutomergeCommitMsg(pullNum int) string {
 
 /*
 SplitComment splits comment into a slice of comments that are under maxSize.
-- It appends sepEnd to all comments that have a following comment.
-- It prepends sepStart to all comments that have a preceding comment.
+- It splits between the sections of the comment, i.e. the projects of a
+multi-project comment, so that small projects are kept together in one comment.
+Sections are separated by a "---" line outside of code blocks.
+- Sections that don't fit in a comment are split before a resource of the plan
+output, or else at the start of a line, so that resources and lines aren't cut
+in half.
+- It appends sepEnd to all comments that are followed by a comment that
+continues the same section.
+- It prepends sepStart to all comments that continue the section of the
+preceding comment. Comments that start at a section only get the part of
+sepStart before it opens the output with <details> or a code block.
 - If maxCommentsPerCommand is non-zero, it never returns more than maxCommentsPerCommand
 comments, and it truncates the beginning of the comment to preserve the end of the comment string,
 which usually contains more important information, such as warnings, errors, and the plan summary.
-- SplitComment appends the truncationHeader to the first comment if it would have produced more comments.
+- SplitComment prepends the truncationHeader to the first comment if it would have produced more comments.
+Like sepStart, only its part before it opens the output is used if that
+comment starts at a section.
 */
 func SplitComment(comment string, maxSize int, sepEnd string, sepStart string, maxCommentsPerCommand int, truncationHeader string) []string {
 	if len(comment) <= maxSize {
@@ -28,29 +41,115 @@ func SplitComment(comment string, maxSize int, sepEnd string, sepStart string, m
 
 	// No comment contains both sepEnd and truncationHeader, so we only have to count their max.
 	maxWithSep := maxSize - max(len(sepEnd), len(truncationHeader)) - len(sepStart)
-	var comments []string
-	numPotentialComments := int(math.Ceil(float64(len(comment)) / float64(maxWithSep)))
-	var numComments int
-	if maxCommentsPerCommand == 0 {
-		numComments = numPotentialComments
-	} else {
-		numComments = min(numPotentialComments, maxCommentsPerCommand)
-	}
-	isTruncated := numComments < numPotentialComments
+	sections := sectionStarts(comment)
+
+	// The comment is split from the end so that it's the beginning that's
+	// truncated. splits[i] is where the i-th comment from the end starts and
+	// midSection[i] is whether that's in the middle of a section.
+	var splits []int
+	var midSection []bool
+	isTruncated := false
 	upTo := len(comment)
-	for len(comments) < numComments {
-		downFrom := max(0, upTo-maxWithSep)
-		portion := comment[downFrom:upTo]
-		if len(comments)+1 != numComments {
-			portion = sepStart + portion
-		} else if len(comments)+1 == numComments && isTruncated {
-			portion = truncationHeader + portion
+	for upTo > 0 {
+		if maxCommentsPerCommand != 0 && len(splits) == maxCommentsPerCommand {
+			isTruncated = true
+			break
+		}
+		downFrom, mid := splitPoint(comment, sections, upTo, maxWithSep)
+		splits = append(splits, downFrom)
+		midSection = append(midSection, mid)
+		upTo = downFrom
+	}
+
+	comments := make([]string, len(splits))
+	end := len(comment)
+	for i, start := range splits {
+		portion := comment[start:end]
+		isFirst := i == len(splits)-1
+		if !isFirst {
+			if midSection[i] {
+				portion = sepStart + portion
+			} else {
+				portion = withoutOutput(sepStart) + portion
+			}
+		} else if isTruncated {
+			if midSection[i] {
+				portion = truncationHeader + portion
+			} else {
+				portion = withoutOutput(truncationHeader) + portion
+			}
 		}
-		if len(comments) != 0 {
+		if i != 0 && midSection[i-1] {
 			portion = portion + sepEnd
 		}
-		comments = append([]string{portion}, comments...)
-		upTo = downFrom
+		comments[len(splits)-1-i] = portion
+		end = start
 	}
 	return comments
 }
+
+// withoutOutput returns the part of sep before it opens the output with
+// <details> or a code block. It's used for comments that start at a section
+// since they aren't in the middle of the output.
+func withoutOutput(sep string) string {
+	end := len(sep)
+	for _, opening := range []string{"<details>", "```"} {
+		if i := strings.Index(sep, opening); i >= 0 && i < end {
+			end = i
+		}
+	}
+	return sep[:end]
+}
+
+// resourceHeaderRegex matches the line that starts a resource in a plan, ex.
+// "  # aws_instance.web will be created".
+var resourceHeaderRegex = regexp.MustCompile(`(?m)^[ +~-]*# \S.* (will be|must be|has moved to|has changed|has been) `)
+
+// sectionStarts returns where the sections of comment start. Sections are
+// separated by "---" lines that aren't in a code block.
+func sectionStarts(comment string) []int {
+	var starts []int
+	inCodeBlock := false
+	for pos := 0; pos < len(comment); {
+		line, _, found := strings.Cut(comment[pos:], "\n")
+		next := pos + len(line) + 1
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+		} else if !inCodeBlock && strings.TrimSpace(line) == "---" && found {
+			starts = append(starts, next)
+		}
+		pos = next
+	}
+	return starts
+}
+
+// splitPoint returns where the comment that ends at upTo should start, and
+// whether that's in the middle of a section. It's the earliest section start,
+// resource start or line start that keeps the comment under maxSize, as long
+// as that uses at least half of maxSize, so that splitting on boundaries
+// doesn't produce many more comments.
+func splitPoint(comment string, sections []int, upTo int, maxSize int) (int, bool) {
+	if upTo <= maxSize {
+		return 0, false
+	}
+	from := upTo - maxSize
+	to := from + maxSize/2
+
+	i := sort.SearchInts(sections, from)
+	if i < len(sections) && sections[i] <= to && sections[i] < upTo {
+		return sections[i], false
+	}
+	for _, loc := range resourceHeaderRegex.FindAllStringIndex(comment[from:to], -1) {
+		// The window can start in the middle of a line.
+		if start := from + loc[0]; comment[start-1] == '\n' {
+			return start, true
+		}
+	}
+	if from > 0 && comment[from-1] == '\n' {
+		return from, true
+	}
+	if nl := strings.IndexByte(comment[from:to], '\n'); nl >= 0 {
+		return from + nl + 1, true
+	}
+	return from, true
+}
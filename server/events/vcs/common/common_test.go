@@ -78,6 +78,73 @@ func TestSplitComment_Limited(t *testing.T) {
 		sepStart + comment[len(comment)-expMax:]}, split)
 }
 
+// Small projects should be kept together and split from the others between
+// projects. The comments don't open or close the output since each one is
+// complete.
+func TestSplitComment_Projects(t *testing.T) {
+	project := func(name string) string {
+		return "### " + name + "\n```diff\n" + strings.Repeat("+ resource\n", 5) + "```\n\n---\n"
+	}
+	header := "Ran Plan for 4 projects:\n\n---\n"
+	comment := header + project("one") + project("two") + project("six") + project("ten")
+	sepEnd := "\n```\n</details>\nContinued in next comment."
+	sepStart := "Continued from previous comment.\n<details>\n```diff\n"
+	split := common.SplitComment(comment, 2*len(project("one"))+len(sepEnd)+len(sepStart), sepEnd, sepStart, 0, "")
+
+	Equals(t, []string{
+		header,
+		"Continued from previous comment.\n" + project("one") + project("two"),
+		"Continued from previous comment.\n" + project("six") + project("ten"),
+	}, split)
+}
+
+// Output that doesn't fit in a comment should be split before a resource.
+func TestSplitComment_Resources(t *testing.T) {
+	resource := func(name string) string {
+		return "  # " + name + " will be created\n" + strings.Repeat("  + attribute = value\n", 4)
+	}
+	comment := "```diff\n" + resource("a.one") + resource("a.two") + resource("a.three") + "```\n"
+	sepEnd := "-sepEnd"
+	sepStart := "-sepStart"
+	split := common.SplitComment(comment, len(resource("a.one"))+len(sepEnd)+len(sepStart)+10, sepEnd, sepStart, 0, "")
+
+	Equals(t, []string{
+		"```diff\n" + resource("a.one") + sepEnd,
+		sepStart + resource("a.two") + sepEnd,
+		sepStart + resource("a.three") + "```\n",
+	}, split)
+}
+
+// Output without resources should be split at the start of a line.
+func TestSplitComment_Lines(t *testing.T) {
+	comment := strings.Repeat("a line of output\n", 100)
+	split := common.SplitComment(comment, 200, "-sepEnd", "-sepStart", 0, "")
+
+	Assert(t, len(split) > 1, "expected the comment to be split")
+	for _, c := range split[1:] {
+		Assert(t, strings.HasPrefix(c, "-sepStarta line"), "expected %q to start at a line", c)
+	}
+	for _, c := range split {
+		Assert(t, len(c) <= 200, "expected %q to be under the max size", c)
+	}
+}
+
+// When truncating at a project, only the warning of the truncation header is
+// used since the comment doesn't start in the middle of the output.
+func TestSplitComment_LimitedProjects(t *testing.T) {
+	project := func(name string) string {
+		return "### " + name + "\n```diff\n" + strings.Repeat("+ resource\n", 5) + "```\n\n---\n"
+	}
+	comment := project("one") + project("two") + project("three")
+	truncationHeader := "truncated\n<details>\n```diff\n"
+	split := common.SplitComment(comment, len(project("one"))+len(truncationHeader)+len("-sepStart")+10, "-sepEnd", "-sepStart", 2, truncationHeader)
+
+	Equals(t, []string{
+		"truncated\n" + project("two"),
+		"-sepStart" + project("three"),
+	}, split)
+}
+
 func TestAutomergeCommitMsg(t *testing.T) {
 	tests := []struct {
 		name    string
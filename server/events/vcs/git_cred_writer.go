@@ -6,11 +6,66 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled"
 )
 
+// gitCredsRefreshPeriod is how often the ~/.git-credentials file is rewritten
+// with the current token of credentials that expire.
+const gitCredsRefreshPeriod = time.Minute
+
+// GitTokenSource returns the current token to authenticate to git with.
+type GitTokenSource interface {
+	GetToken() (string, error)
+}
+
+// gitCredsRefresher rewrites the ~/.git-credentials file with the current
+// token of a GitTokenSource whose tokens expire.
+type gitCredsRefresher struct {
+	log         logging.SimpleLogging
+	tokens      GitTokenSource
+	gitUser     string
+	gitHostname string
+	home        string
+}
+
+// NewGitCredsRefreshJob returns a job that keeps the ~/.git-credentials file
+// up to date with the current token of tokens. The file is written once
+// before the job is returned.
+func NewGitCredsRefreshJob(log logging.SimpleLogging, tokens GitTokenSource, gitUser string, gitHostname string, home string) (scheduled.JobDefinition, error) {
+	r := &gitCredsRefresher{
+		log:         log,
+		tokens:      tokens,
+		gitUser:     gitUser,
+		gitHostname: gitHostname,
+		home:        home,
+	}
+	return scheduled.JobDefinition{
+		Job:    r,
+		Period: gitCredsRefreshPeriod,
+	}, r.write()
+}
+
+func (r *gitCredsRefresher) Run() {
+	if err := r.write(); err != nil {
+		r.log.Err(err.Error())
+	}
+}
+
+func (r *gitCredsRefresher) write() error {
+	token, err := r.tokens.GetToken()
+	if err != nil {
+		return errors.Wrap(err, "getting git token")
+	}
+	if err := WriteGitCreds(r.gitUser, token, r.gitHostname, r.home, r.log, true); err != nil {
+		return errors.Wrap(err, "writing ~/.git-credentials file")
+	}
+	return nil
+}
+
 // WriteGitCreds generates a .git-credentials file containing the username and token
 // used for authenticating with git over HTTPS
 // It will create the file in home/.git-credentials
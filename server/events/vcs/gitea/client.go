@@ -18,12 +18,16 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net/http"
+	"path"
 	"strings"
 	"time"
 
 	"code.gitea.io/sdk/gitea"
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/common"
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
@@ -32,6 +36,11 @@ import (
 // Value chosen purposely high, though randomly.
 const giteaPaginationEBreak = 500
 
+// maxCommentLength is the maximum number of chars in a single comment. Gitea
+// and Forgejo don't limit the length of comments but very long ones are slow
+// to render and can be rejected by proxies in front of them.
+const maxCommentLength = 65536
+
 type GiteaClient struct {
 	giteaClient *gitea.Client
 	username    string
@@ -68,7 +77,12 @@ func NewClient(baseURL string, username string, token string, pagesize int, logg
 		gitea.SetUserAgent("atlantis"),
 	)
 
-	if err != nil {
+	// Forgejo and development builds of Gitea can report versions the SDK
+	// doesn't understand, in which case it still returns a usable client.
+	var unknownVersion *gitea.ErrUnknownVersion
+	if errors.As(err, &unknownVersion) && giteaClient != nil {
+		logger.Warn("unable to determine the version of %s, assuming it's compatible: %s", baseURL, err)
+	} else if err != nil {
 		return nil, errors.Wrap(err, "creating gitea client")
 	}
 
@@ -110,7 +124,7 @@ func (c *GiteaClient) GetModifiedFiles(logger logging.SimpleLogging, repo models
 	}
 
 	for page < nextPage {
-		page = +1
+		page++
 		listOptions.ListOptions.Page = page
 		files, resp, err := c.giteaClient.ListPullRequestFiles(repo.Owner, repo.Name, int64(pull.Num), listOptions)
 		if err != nil {
@@ -133,19 +147,33 @@ func (c *GiteaClient) GetModifiedFiles(logger logging.SimpleLogging, repo models
 	return changedFiles, nil
 }
 
-// CreateComment creates a comment on the merge request. As far as we're aware, Gitea has no built in max comment length right now.
+// CreateComment creates a comment on the merge request. If comment is longer
+// than maxCommentLength it's split into multiple comments.
 func (c *GiteaClient) CreateComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string) error {
 	logger.Debug("Creating comment on Gitea pull request %d", pullNum)
 
-	opt := gitea.CreateIssueCommentOption{
-		Body: comment,
+	sepEnd := "\n```\n</details>" +
+		"\n<br>\n\n**Warning**: Output length greater than max comment size. Continued in next comment."
+	sepStart := "Continued from previous comment.\n<details><summary>Show Output</summary>\n\n" +
+		"```diff\n"
+	if command != "" {
+		sepStart = fmt.Sprintf("Continued %s output from previous comment.\n<details><summary>Show Output</summary>\n\n", command) +
+			"```diff\n"
 	}
 
-	_, resp, err := c.giteaClient.CreateIssueComment(repo.Owner, repo.Name, int64(pullNum), opt)
+	for _, body := range common.SplitComment(comment, maxCommentLength, sepEnd, sepStart, 0, "") {
+		opt := gitea.CreateIssueCommentOption{
+			Body: body,
+		}
+
+		_, resp, err := c.giteaClient.CreateIssueComment(repo.Owner, repo.Name, int64(pullNum), opt)
 
-	if err != nil {
-		logger.Debug("POST /repos/%v/%v/issues/%d/comments returned: %v", repo.Owner, repo.Name, pullNum, resp.StatusCode)
-		return err
+		if err != nil {
+			if resp != nil {
+				logger.Debug("POST /repos/%v/%v/issues/%d/comments returned: %v", repo.Owner, repo.Name, pullNum, resp.StatusCode)
+			}
+			return err
+		}
 	}
 
 	logger.Debug("Added comment to Gitea pull request %d: %s", pullNum, comment)
@@ -172,31 +200,9 @@ func (c *GiteaClient) ReactToComment(logger logging.SimpleLogging, repo models.R
 func (c *GiteaClient) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
 	logger.Debug("Hiding previous command comments on Gitea pull request %d", pullNum)
 
-	var allComments []*gitea.Comment
-
-	nextPage := int(1)
-	for {
-		// Initialize ListIssueCommentOptions with the current page
-		opts := gitea.ListIssueCommentOptions{
-			ListOptions: gitea.ListOptions{
-				Page:     nextPage,
-				PageSize: c.pageSize,
-			},
-		}
-
-		comments, resp, err := c.giteaClient.ListIssueComments(repo.Owner, repo.Name, int64(pullNum), opts)
-		if err != nil {
-			logger.Debug("GET /repos/%v/%v/issues/%d/comments returned: %v", repo.Owner, repo.Name, pullNum, resp.StatusCode)
-			return err
-		}
-
-		allComments = append(allComments, comments...)
-
-		// Break the loop if there are no more pages to fetch
-		if resp.NextPage == 0 {
-			break
-		}
-		nextPage = resp.NextPage
+	allComments, err := c.listComments(logger, repo, pullNum)
+	if err != nil {
+		return err
 	}
 
 	currentUser, resp, err := c.giteaClient.GetMyUserInfo()
@@ -205,7 +211,7 @@ func (c *GiteaClient) HidePrevCommandComments(logger logging.SimpleLogging, repo
 		return err
 	}
 
-	summaryHeader := fmt.Sprintf("<!--- +-Superseded Command-+ ---><details><summary>Superseded Atlantis %s</summary>", command)
+	summaryHeader := fmt.Sprintf("%s<details><summary>Superseded Atlantis %s</summary>", supersededCommentMarker, command)
 	summaryFooter := "</details>"
 	lineFeed := "\n"
 
@@ -233,17 +239,121 @@ func (c *GiteaClient) HidePrevCommandComments(logger logging.SimpleLogging, repo
 	return nil
 }
 
-// PullIsApproved returns ApprovalStatus with IsApproved set to true if the pull request has a review that approved the PR.
+// supersededCommentMarker starts the comments that were collapsed since
+// they're outdated.
+const supersededCommentMarker = "<!--- +-Superseded Command-+ --->"
+
+// CleanUpComments deletes the comments made by Atlantis on the pull request
+// or, if collapse is true, collapses them.
+func (c *GiteaClient) CleanUpComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, collapse bool) (int, error) {
+	logger.Debug("Cleaning up comments on Gitea pull request %d", pullNum)
+	allComments, err := c.listComments(logger, repo, pullNum)
+	if err != nil {
+		return 0, err
+	}
+	currentUser, resp, err := c.giteaClient.GetMyUserInfo()
+	if err != nil {
+		logger.Debug("GET /user returned: %v", resp.StatusCode)
+		return 0, err
+	}
+
+	cleanedUp := 0
+	for _, comment := range allComments {
+		if comment.Poster == nil || comment.Poster.UserName != currentUser.UserName {
+			continue
+		}
+		if collapse {
+			if strings.HasPrefix(comment.Body, supersededCommentMarker) {
+				continue
+			}
+			logger.Debug("Hiding comment %d", comment.ID)
+			_, _, err := c.giteaClient.EditIssueComment(repo.Owner, repo.Name, comment.ID, gitea.EditIssueCommentOption{
+				Body: supersededCommentMarker + "<details><summary>Outdated Atlantis comment</summary>\n" + comment.Body + "\n</details>\n",
+			})
+			if err != nil {
+				return cleanedUp, err
+			}
+			cleanedUp++
+			continue
+		}
+		logger.Debug("Deleting comment %d", comment.ID)
+		if _, err := c.giteaClient.DeleteIssueComment(repo.Owner, repo.Name, comment.ID); err != nil {
+			return cleanedUp, err
+		}
+		cleanedUp++
+	}
+	return cleanedUp, nil
+}
+
+// listComments returns the comments on the pull request.
+func (c *GiteaClient) listComments(logger logging.SimpleLogging, repo models.Repo, pullNum int) ([]*gitea.Comment, error) {
+	var allComments []*gitea.Comment
+
+	nextPage := int(1)
+	for {
+		// Initialize ListIssueCommentOptions with the current page
+		opts := gitea.ListIssueCommentOptions{
+			ListOptions: gitea.ListOptions{
+				Page:     nextPage,
+				PageSize: c.pageSize,
+			},
+		}
+
+		comments, resp, err := c.giteaClient.ListIssueComments(repo.Owner, repo.Name, int64(pullNum), opts)
+		if err != nil {
+			logger.Debug("GET /repos/%v/%v/issues/%d/comments returned: %v", repo.Owner, repo.Name, pullNum, resp.StatusCode)
+			return nil, err
+		}
+
+		allComments = append(allComments, comments...)
+
+		// Break the loop if there are no more pages to fetch
+		if resp.NextPage == 0 {
+			break
+		}
+		nextPage = resp.NextPage
+	}
+	return allComments, nil
+}
+
+// PullIsApproved returns ApprovalStatus with IsApproved set to true if the
+// latest review of a reviewer approved the PR and wasn't dismissed since.
 func (c *GiteaClient) PullIsApproved(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.ApprovalStatus, error) {
 	logger.Debug("Checking if Gitea pull request %d is approved", pull.Num)
 
-	page := 0
-	nextPage := 1
-
 	approvalStatus := models.ApprovalStatus{
 		IsApproved: false,
 	}
 
+	reviews, err := c.latestReviews(logger, repo, pull)
+	if err != nil {
+		return approvalStatus, err
+	}
+
+	for _, review := range reviews {
+		if review.State == gitea.ReviewStateApproved && !review.Dismissed {
+			if !approvalStatus.IsApproved {
+				approvalStatus.IsApproved = true
+				approvalStatus.ApprovedBy = review.Reviewer.UserName
+				approvalStatus.Date = review.Submitted
+			}
+			approvalStatus.Approvers = append(approvalStatus.Approvers, review.Reviewer.UserName)
+		}
+	}
+
+	return approvalStatus, nil
+}
+
+// latestReviews returns the latest review of each reviewer of the pull
+// request, in the order they were first submitted. Comments that aren't
+// reviews are left out.
+func (c *GiteaClient) latestReviews(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]*gitea.PullReview, error) {
+	page := 0
+	nextPage := 1
+
+	var reviews []*gitea.PullReview
+	reviewerIndex := make(map[string]int)
+
 	listOptions := gitea.ListPullReviewsOptions{
 		ListOptions: gitea.ListOptions{
 			Page:     1,
@@ -252,23 +362,27 @@ func (c *GiteaClient) PullIsApproved(logger logging.SimpleLogging, repo models.R
 	}
 
 	for page < nextPage {
-		page = +1
+		page++
 		listOptions.ListOptions.Page = page
 		pullReviews, resp, err := c.giteaClient.ListPullReviews(repo.Owner, repo.Name, int64(pull.Num), listOptions)
 
 		if err != nil {
-			logger.Debug("GET /repos/%v/%v/pulls/%d/reviews returned: %v", repo.Owner, repo.Name, pull.Num, resp.StatusCode)
-			return approvalStatus, err
+			if resp != nil {
+				logger.Debug("GET /repos/%v/%v/pulls/%d/reviews returned: %v", repo.Owner, repo.Name, pull.Num, resp.StatusCode)
+			}
+			return nil, err
 		}
 
 		for _, review := range pullReviews {
-			if review.State == gitea.ReviewStateApproved {
-				approvalStatus.IsApproved = true
-				approvalStatus.ApprovedBy = review.Reviewer.UserName
-				approvalStatus.Date = review.Submitted
-
-				return approvalStatus, nil
+			if review.Reviewer == nil || review.State == gitea.ReviewStateComment || review.State == gitea.ReviewStatePending {
+				continue
+			}
+			if i, ok := reviewerIndex[review.Reviewer.UserName]; ok {
+				reviews[i] = review
+				continue
 			}
+			reviewerIndex[review.Reviewer.UserName] = len(reviews)
+			reviews = append(reviews, review)
 		}
 
 		nextPage = resp.NextPage
@@ -279,11 +393,15 @@ func (c *GiteaClient) PullIsApproved(logger logging.SimpleLogging, repo models.R
 		}
 	}
 
-	return approvalStatus, nil
+	return reviews, nil
 }
 
-// PullIsMergeable returns true if the pull request is mergeable
-func (c *GiteaClient) PullIsMergeable(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, _ string, _ []string) (bool, error) {
+// PullIsMergeable returns true if the pull request is open, has no conflicts
+// and meets the branch protection of its base branch, i.e. it has the
+// required number of approvals and the required status checks passed. The
+// apply status of Atlantis, vcsstatusname/apply, is ignored since it can't
+// pass before the apply.
+func (c *GiteaClient) PullIsMergeable(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, vcsstatusname string, _ []string) (bool, error) {
 	logger.Debug("Checking if Gitea pull request %d is mergeable", pull.Num)
 
 	pullRequest, _, err := c.giteaClient.GetPullRequest(repo.Owner, repo.Name, int64(pull.Num))
@@ -294,7 +412,89 @@ func (c *GiteaClient) PullIsMergeable(logger logging.SimpleLogging, repo models.
 
 	logger.Debug("Gitea pull request is mergeable: %v (%v)", pullRequest.Mergeable, pull.Num)
 
-	return pullRequest.Mergeable, nil
+	if !pullRequest.Mergeable || pullRequest.State != gitea.StateOpen || pullRequest.Base == nil {
+		return false, nil
+	}
+
+	branch, resp, err := c.giteaClient.GetRepoBranch(repo.Owner, repo.Name, pullRequest.Base.Ref)
+	if err != nil {
+		if resp != nil {
+			logger.Debug("GET /repos/%v/%v/branches/%s returned: %v", repo.Owner, repo.Name, pullRequest.Base.Ref, resp.StatusCode)
+		}
+		return false, errors.Wrapf(err, "getting base branch %q", pullRequest.Base.Ref)
+	}
+	if !branch.Protected {
+		return true, nil
+	}
+
+	if branch.RequiredApprovals > 0 {
+		reviews, err := c.latestReviews(logger, repo, pull)
+		if err != nil {
+			return false, err
+		}
+		var approvals int64
+		for _, review := range reviews {
+			// Only the reviews of users allowed to approve are official.
+			if review.State == gitea.ReviewStateApproved && review.Official && !review.Dismissed {
+				approvals++
+			}
+		}
+		if approvals < branch.RequiredApprovals {
+			logger.Debug("Gitea pull request %d has %d of %d required approvals", pull.Num, approvals, branch.RequiredApprovals)
+			return false, nil
+		}
+	}
+
+	if branch.EnableStatusCheck && len(branch.StatusCheckContexts) > 0 {
+		passed, err := c.requiredStatusChecksPassed(logger, repo, pull, branch.StatusCheckContexts, vcsstatusname)
+		if err != nil {
+			return false, err
+		}
+		if !passed {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// requiredStatusChecksPassed returns true if the head commit of pull has a
+// successful status for each of the required contexts. The contexts can be
+// glob patterns.
+func (c *GiteaClient) requiredStatusChecksPassed(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, requiredContexts []string, vcsstatusname string) (bool, error) {
+	combined, resp, err := c.giteaClient.GetCombinedStatus(repo.Owner, repo.Name, pull.HeadCommit)
+	if err != nil {
+		if resp != nil {
+			logger.Debug("GET /repos/%v/%v/commits/%s/status returned: %v", repo.Owner, repo.Name, pull.HeadCommit, resp.StatusCode)
+		}
+		return false, errors.Wrap(err, "getting commit statuses")
+	}
+
+	applyStatus := fmt.Sprintf("%s/%s", vcsstatusname, command.Apply.String())
+	for _, required := range requiredContexts {
+		if strings.HasPrefix(required, applyStatus) {
+			continue
+		}
+		found := false
+		for _, status := range combined.Statuses {
+			if matched, _ := path.Match(required, status.Context); !matched && status.Context != required {
+				continue
+			}
+			if strings.HasPrefix(status.Context, applyStatus) {
+				continue
+			}
+			found = true
+			if status.State != gitea.StatusSuccess && status.State != gitea.StatusWarning {
+				logger.Debug("Required status %q of Gitea pull request %d is %s", status.Context, pull.Num, status.State)
+				return false, nil
+			}
+		}
+		if !found {
+			logger.Debug("Required status %q of Gitea pull request %d is missing", required, pull.Num)
+			return false, nil
+		}
+	}
+	return true, nil
 }
 
 // UpdateStatus updates the commit status to state for pull. src is the
@@ -353,7 +553,7 @@ func (c *GiteaClient) DiscardReviews(repo models.Repo, pull models.PullRequest)
 	}
 
 	for page < nextPage {
-		page = +1
+		page++
 		listOptions.ListOptions.Page = page
 		pullReviews, resp, err := c.giteaClient.ListPullReviews(repo.Owner, repo.Name, int64(pull.Num), listOptions)
 
@@ -413,9 +613,53 @@ func (c *GiteaClient) MarkdownPullLink(pull models.PullRequest) (string, error)
 }
 
 // GetTeamNamesForUser returns the names of the teams or groups that the user belongs to (in the organization the repository belongs to).
-func (c *GiteaClient) GetTeamNamesForUser(_ logging.SimpleLogging, _ models.Repo, _ models.User) ([]string, error) {
-	// TODO: implement
-	return nil, errors.New("GetTeamNamesForUser not (yet) implemented for Gitea client")
+// If the repository belongs to a user rather than an organization there are no teams.
+func (c *GiteaClient) GetTeamNamesForUser(logger logging.SimpleLogging, repo models.Repo, user models.User) ([]string, error) {
+	logger.Debug("Getting the teams of %s in Gitea organization %s", user.Username, repo.Owner)
+
+	page := 0
+	nextPage := 1
+	teamNames := make([]string, 0)
+
+	opts := gitea.ListTeamsOptions{
+		ListOptions: gitea.ListOptions{
+			Page:     1,
+			PageSize: c.pageSize,
+		},
+	}
+
+	for page < nextPage {
+		page++
+		opts.ListOptions.Page = page
+
+		teams, resp, err := c.giteaClient.ListOrgTeams(repo.Owner, opts)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return teamNames, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the teams of %s", repo.Owner)
+		}
+
+		for _, team := range teams {
+			_, resp, err := c.giteaClient.GetTeamMember(team.ID, user.Username)
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "checking if %s is a member of team %s", user.Username, team.Name)
+			}
+			teamNames = append(teamNames, team.Name)
+		}
+
+		nextPage = resp.NextPage
+
+		// Emergency break after giteaPaginationEBreak pages
+		if page >= giteaPaginationEBreak {
+			break
+		}
+	}
+
+	return teamNames, nil
 }
 
 // GetFileContent a repository file content from VCS (which support fetch a single file from repository)
@@ -479,7 +723,7 @@ func (c *GiteaClient) GetPullLabels(logger logging.SimpleLogging, repo models.Re
 	}
 
 	for page < nextPage {
-		page = +1
+		page++
 		opts.ListOptions.Page = page
 
 		labels, resp, err := c.giteaClient.GetIssueLabels(repo.Owner, repo.Name, int64(pull.Num), opts)
@@ -515,3 +759,28 @@ func ValidateSignature(payload []byte, signature string, secretKey []byte) error
 
 	return nil
 }
+
+// CreateIssue opens an issue in repo.
+func (c *GiteaClient) CreateIssue(logger logging.SimpleLogging, repo models.Repo, title string, body string) error {
+	logger.Debug("Creating Gitea issue in %s", repo.FullName)
+	_, resp, err := c.giteaClient.CreateIssue(repo.Owner, repo.Name, gitea.CreateIssueOption{
+		Title: title,
+		Body:  body,
+	})
+	if resp != nil {
+		logger.Debug("POST /repos/%v/%v/issues returned: %v", repo.Owner, repo.Name, resp.StatusCode)
+	}
+	return err
+}
+
+// GetPullState returns whether pull is still open.
+func (c *GiteaClient) GetPullState(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.PullRequestState, error) {
+	pr, err := c.GetPullRequest(logger, repo, pull.Num)
+	if err != nil {
+		return models.ClosedPullState, err
+	}
+	if pr.State == gitea.StateOpen {
+		return models.OpenPullState, nil
+	}
+	return models.ClosedPullState, nil
+}
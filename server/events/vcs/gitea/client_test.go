@@ -0,0 +1,272 @@
+package gitea_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+var repo = models.Repo{Owner: "owner", Name: "repo", FullName: "owner/repo"}
+
+// newTestClient returns a client for a Gitea server that serves its version
+// and responds to the other requests with handler.
+func newTestClient(t *testing.T, version string, handler http.HandlerFunc) *gitea.GiteaClient {
+	t.Helper()
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/version" {
+			fmt.Fprintf(w, `{"version":%q}`, version)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(testServer.Close)
+
+	client, err := gitea.NewClient(testServer.URL, "user", "token", 1, logging.NewNoopLogger(t))
+	Ok(t, err)
+	return client
+}
+
+// writePage writes body and, unless page is the last one, a Link header to the
+// next page like Gitea does.
+func writePage(w http.ResponseWriter, r *http.Request, page int, lastPage int, body string) {
+	if page < lastPage {
+		next := *r.URL
+		q := next.Query()
+		q.Set("page", fmt.Sprint(page+1))
+		next.RawQuery = q.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, next.String()))
+	}
+	fmt.Fprint(w, body)
+}
+
+func TestNewClient_Forgejo(t *testing.T) {
+	for _, version := range []string{"7.0.5+gitea-1.21.0", "development"} {
+		t.Run(version, func(t *testing.T) {
+			newTestClient(t, version, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			})
+		})
+	}
+}
+
+func TestGiteaClient_GetModifiedFiles_Pagination(t *testing.T) {
+	client := newTestClient(t, "1.21.0", func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "/api/v1/repos/owner/repo/pulls/1/files", r.URL.Path)
+		switch r.URL.Query().Get("page") {
+		case "1":
+			writePage(w, r, 1, 2, `[{"filename":"main.tf"}]`)
+		case "2":
+			writePage(w, r, 2, 2, `[{"filename":"modules/vpc/main.tf"}]`)
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	})
+
+	files, err := client.GetModifiedFiles(logging.NewNoopLogger(t), repo, models.PullRequest{Num: 1})
+	Ok(t, err)
+	Equals(t, []string{"main.tf", "modules/vpc/main.tf"}, files)
+}
+
+func TestGiteaClient_CreateComment_Split(t *testing.T) {
+	var comments []string
+	client := newTestClient(t, "1.21.0", func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "/api/v1/repos/owner/repo/issues/1/comments", r.URL.Path)
+		var opt struct {
+			Body string `json:"body"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		Ok(t, json.Unmarshal(body, &opt))
+		comments = append(comments, opt.Body)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id":1}`)
+	})
+
+	Ok(t, client.CreateComment(logging.NewNoopLogger(t), repo, 1, "short", "plan"))
+	Equals(t, []string{"short"}, comments)
+
+	comments = nil
+	Ok(t, client.CreateComment(logging.NewNoopLogger(t), repo, 1, strings.Repeat("a", 65536+1), "plan"))
+	Equals(t, 2, len(comments))
+	Assert(t, strings.HasPrefix(comments[1], "Continued plan output from previous comment."), "expected the second comment to continue the first, got %q", comments[1][:50])
+}
+
+func TestGiteaClient_PullIsApproved(t *testing.T) {
+	cases := []struct {
+		description string
+		reviews     []string
+		expApproved bool
+		expBy       string
+	}{
+		{
+			"no reviews",
+			nil,
+			false,
+			"",
+		},
+		{
+			"approved",
+			[]string{`{"state":"COMMENT","user":{"login":"alice"}}`, `{"state":"APPROVED","user":{"login":"bob"}}`},
+			true,
+			"bob",
+		},
+		{
+			"approval dismissed",
+			[]string{`{"state":"APPROVED","dismissed":true,"user":{"login":"bob"}}`},
+			false,
+			"",
+		},
+		{
+			"changes requested after the approval",
+			[]string{`{"state":"APPROVED","user":{"login":"bob"}}`, `{"state":"REQUEST_CHANGES","user":{"login":"bob"}}`},
+			false,
+			"",
+		},
+		{
+			"approved after changes were requested",
+			[]string{`{"state":"REQUEST_CHANGES","user":{"login":"bob"}}`, `{"state":"APPROVED","user":{"login":"bob"}}`},
+			true,
+			"bob",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			client := newTestClient(t, "1.21.0", func(w http.ResponseWriter, r *http.Request) {
+				Equals(t, "/api/v1/repos/owner/repo/pulls/1/reviews", r.URL.Path)
+				if len(c.reviews) == 0 {
+					fmt.Fprint(w, "[]")
+					return
+				}
+				var page int
+				fmt.Sscan(r.URL.Query().Get("page"), &page) // nolint: errcheck
+				writePage(w, r, page, len(c.reviews), fmt.Sprintf("[%s]", c.reviews[page-1]))
+			})
+
+			status, err := client.PullIsApproved(logging.NewNoopLogger(t), repo, models.PullRequest{Num: 1})
+			Ok(t, err)
+			Equals(t, c.expApproved, status.IsApproved)
+			Equals(t, c.expBy, status.ApprovedBy)
+		})
+	}
+}
+
+func TestGiteaClient_PullIsMergeable(t *testing.T) {
+	cases := []struct {
+		description string
+		pull        string
+		branch      string
+		reviews     string
+		statuses    string
+		exp         bool
+	}{
+		{
+			description: "conflicts",
+			pull:        `{"state":"open","mergeable":false,"base":{"ref":"main"}}`,
+			exp:         false,
+		},
+		{
+			description: "closed",
+			pull:        `{"state":"closed","mergeable":true,"base":{"ref":"main"}}`,
+			exp:         false,
+		},
+		{
+			description: "unprotected branch",
+			pull:        `{"state":"open","mergeable":true,"base":{"ref":"main"}}`,
+			branch:      `{"name":"main","protected":false}`,
+			exp:         true,
+		},
+		{
+			description: "missing approvals",
+			pull:        `{"state":"open","mergeable":true,"base":{"ref":"main"}}`,
+			branch:      `{"name":"main","protected":true,"required_approvals":2}`,
+			reviews:     `[{"state":"APPROVED","official":true,"user":{"login":"alice"}},{"state":"APPROVED","official":false,"user":{"login":"bob"}}]`,
+			exp:         false,
+		},
+		{
+			description: "required approvals",
+			pull:        `{"state":"open","mergeable":true,"base":{"ref":"main"}}`,
+			branch:      `{"name":"main","protected":true,"required_approvals":1}`,
+			reviews:     `[{"state":"APPROVED","official":true,"user":{"login":"alice"}}]`,
+			exp:         true,
+		},
+		{
+			description: "required status failed",
+			pull:        `{"state":"open","mergeable":true,"base":{"ref":"main"}}`,
+			branch:      `{"name":"main","protected":true,"enable_status_check":true,"status_check_contexts":["ci/*","atlantis/apply"]}`,
+			statuses:    `{"statuses":[{"context":"ci/test","status":"failure"},{"context":"atlantis/apply","status":"pending"}]}`,
+			exp:         false,
+		},
+		{
+			description: "required status missing",
+			pull:        `{"state":"open","mergeable":true,"base":{"ref":"main"}}`,
+			branch:      `{"name":"main","protected":true,"enable_status_check":true,"status_check_contexts":["ci/test"]}`,
+			statuses:    `{"statuses":[{"context":"atlantis/plan","status":"success"}]}`,
+			exp:         false,
+		},
+		{
+			description: "required statuses passed except apply",
+			pull:        `{"state":"open","mergeable":true,"base":{"ref":"main"}}`,
+			branch:      `{"name":"main","protected":true,"enable_status_check":true,"status_check_contexts":["ci/*","atlantis/*"]}`,
+			statuses:    `{"statuses":[{"context":"ci/test","status":"success"},{"context":"atlantis/plan","status":"success"},{"context":"atlantis/apply: dir1/default","status":"failure"}]}`,
+			exp:         true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			client := newTestClient(t, "1.21.0", func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v1/repos/owner/repo/pulls/1":
+					fmt.Fprint(w, c.pull)
+				case "/api/v1/repos/owner/repo/branches/main":
+					fmt.Fprint(w, c.branch)
+				case "/api/v1/repos/owner/repo/pulls/1/reviews":
+					fmt.Fprint(w, c.reviews)
+				case "/api/v1/repos/owner/repo/commits/sha/status":
+					fmt.Fprint(w, c.statuses)
+				default:
+					t.Errorf("unexpected request to %s", r.URL.Path)
+				}
+			})
+
+			mergeable, err := client.PullIsMergeable(logging.NewNoopLogger(t), repo, models.PullRequest{Num: 1, HeadCommit: "sha"}, "atlantis", nil)
+			Ok(t, err)
+			Equals(t, c.exp, mergeable)
+		})
+	}
+}
+
+func TestGiteaClient_GetTeamNamesForUser(t *testing.T) {
+	client := newTestClient(t, "1.21.0", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/orgs/owner/teams":
+			var page int
+			fmt.Sscan(r.URL.Query().Get("page"), &page) // nolint: errcheck
+			teams := []string{`[{"id":1,"name":"admins"}]`, `[{"id":2,"name":"devs"}]`}
+			writePage(w, r, page, len(teams), teams[page-1])
+		case "/api/v1/teams/1/members/alice":
+			w.WriteHeader(http.StatusNotFound)
+		case "/api/v1/teams/2/members/alice":
+			fmt.Fprint(w, `{"login":"alice"}`)
+		case "/api/v1/orgs/bob/teams":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	})
+
+	teams, err := client.GetTeamNamesForUser(logging.NewNoopLogger(t), repo, models.User{Username: "alice"})
+	Ok(t, err)
+	Equals(t, []string{"devs"}, teams)
+
+	teams, err = client.GetTeamNamesForUser(logging.NewNoopLogger(t), models.Repo{Owner: "bob", Name: "repo"}, models.User{Username: "alice"})
+	Ok(t, err)
+	Equals(t, []string{}, teams)
+}
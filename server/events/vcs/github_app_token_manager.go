@@ -0,0 +1,205 @@
+package vcs
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/google/go-github/v68/github"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled"
+	tally "github.com/uber-go/tally/v4"
+)
+
+const (
+	// githubAppTokenRefreshBefore is how long before it expires a cached
+	// installation token is refreshed by the background refresh. Tokens are
+	// valid for an hour.
+	githubAppTokenRefreshBefore = 10 * time.Minute
+	// githubAppTokenMinValidity is how long a cached installation token must
+	// still be valid for to be used by a request. Tokens that expire sooner
+	// are refreshed before the request is sent.
+	githubAppTokenMinValidity = time.Minute
+	// githubAppTokenRefreshPeriod is how often the background refresh runs.
+	githubAppTokenRefreshPeriod = time.Minute
+)
+
+// GithubAppTokenManager mints installation tokens for a GitHub App and caches
+// them per installation, i.e. per org the app is installed in. Concurrent
+// requests share the cached token instead of each minting their own, and
+// Refresh replaces tokens before they expire so that requests don't fail with
+// a token that expired while they were in flight.
+type GithubAppTokenManager struct {
+	appsTransport *ghinstallation.AppsTransport
+	apiURL        *url.URL
+	scope         tally.Scope
+
+	mutex  sync.Mutex
+	tokens map[int64]*githubAppToken
+}
+
+// githubAppToken is the cached token of an installation. Its mutex is held
+// while the token is minted so that only one mint per installation is in
+// flight at a time.
+type githubAppToken struct {
+	mutex     sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewGithubAppTokenManager returns a token manager for the app with appID and
+// private key on the GitHub host with hostname.
+func NewGithubAppTokenManager(appID int64, key []byte, hostname string, scope tally.Scope) (*GithubAppTokenManager, error) {
+	apiURL := resolveGithubAPIURL(hostname)
+	appsTransport, err := ghinstallation.NewAppsTransport(http.DefaultTransport, appID, key)
+	if err != nil {
+		return nil, err
+	}
+	appsTransport.BaseURL = apiURL.String()
+	return &GithubAppTokenManager{
+		appsTransport: appsTransport,
+		apiURL:        apiURL,
+		scope:         scope,
+		tokens:        make(map[int64]*githubAppToken),
+	}, nil
+}
+
+// Token returns the cached token of installationID, minting a new one if
+// there's none or it's about to expire.
+func (m *GithubAppTokenManager) Token(installationID int64) (string, error) {
+	cached := m.cachedToken(installationID)
+	cached.mutex.Lock()
+	defer cached.mutex.Unlock()
+	if cached.token != "" && time.Until(cached.expiresAt) > githubAppTokenMinValidity {
+		return cached.token, nil
+	}
+	if err := m.mint(installationID, cached); err != nil {
+		return "", err
+	}
+	return cached.token, nil
+}
+
+// Refresh mints new tokens for the cached tokens that expire within
+// githubAppTokenRefreshBefore and reports how long until each token expires.
+func (m *GithubAppTokenManager) Refresh() error {
+	m.mutex.Lock()
+	installationIDs := make([]int64, 0, len(m.tokens))
+	for installationID := range m.tokens {
+		installationIDs = append(installationIDs, installationID)
+	}
+	m.mutex.Unlock()
+
+	var errs []error
+	for _, installationID := range installationIDs {
+		cached := m.cachedToken(installationID)
+		cached.mutex.Lock()
+		if time.Until(cached.expiresAt) <= githubAppTokenRefreshBefore {
+			if err := m.mint(installationID, cached); err != nil {
+				errs = append(errs, errors.Wrapf(err, "installation %d", installationID))
+			}
+		}
+		m.installationScope(installationID).Gauge("expires_in_seconds").Update(time.Until(cached.expiresAt).Seconds())
+		cached.mutex.Unlock()
+	}
+	if len(errs) > 0 {
+		return errors.Wrap(errs[0], "refreshing github app installation tokens")
+	}
+	return nil
+}
+
+// Transport returns a transport that authenticates requests as installationID.
+func (m *GithubAppTokenManager) Transport(installationID int64) http.RoundTripper {
+	return &githubAppTokenTransport{
+		tokens:         m,
+		installationID: installationID,
+		base:           http.DefaultTransport,
+	}
+}
+
+func (m *GithubAppTokenManager) cachedToken(installationID int64) *githubAppToken {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	cached, ok := m.tokens[installationID]
+	if !ok {
+		cached = &githubAppToken{}
+		m.tokens[installationID] = cached
+	}
+	return cached
+}
+
+// mint replaces the token in cached with a new token for installationID. The
+// caller must hold cached's mutex.
+func (m *GithubAppTokenManager) mint(installationID int64, cached *githubAppToken) error {
+	scope := m.installationScope(installationID)
+	client := github.NewClient(&http.Client{Transport: m.appsTransport})
+	client.BaseURL = m.apiURL
+	token, _, err := client.Apps.CreateInstallationToken(context.Background(), installationID, nil)
+	if err != nil {
+		scope.Counter("mint_errors").Inc(1)
+		return errors.Wrap(err, "minting github app installation token")
+	}
+	scope.Counter("mints").Inc(1)
+	cached.token = token.GetToken()
+	cached.expiresAt = token.GetExpiresAt().Time
+	scope.Gauge("expires_in_seconds").Update(time.Until(cached.expiresAt).Seconds())
+	return nil
+}
+
+func (m *GithubAppTokenManager) installationScope(installationID int64) tally.Scope {
+	return m.scope.Tagged(map[string]string{"installation_id": strconv.FormatInt(installationID, 10)})
+}
+
+// githubAppTokenTransport authenticates requests with the cached token of an
+// installation.
+type githubAppTokenTransport struct {
+	tokens         *GithubAppTokenManager
+	installationID int64
+	base           http.RoundTripper
+}
+
+func (t *githubAppTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.tokens.Token(t.installationID)
+	if err != nil {
+		// RoundTrip must always close the body, including on errors.
+		if req.Body != nil {
+			req.Body.Close() // nolint: errcheck
+		}
+		return nil, err
+	}
+	creq := req.Clone(req.Context())
+	creq.Header.Set("Authorization", "token "+token)
+	if creq.Header.Get("Accept") == "" {
+		creq.Header.Set("Accept", "application/vnd.github.v3+json")
+	}
+	return t.base.RoundTrip(creq)
+}
+
+// githubAppTokenRefresher refreshes the cached installation tokens of a
+// GitHub App in the background.
+type githubAppTokenRefresher struct {
+	log    logging.SimpleLogging
+	tokens *GithubAppTokenManager
+}
+
+// NewGithubAppTokenRefreshJob returns a job that refreshes the tokens cached
+// by tokens before they expire.
+func NewGithubAppTokenRefreshJob(log logging.SimpleLogging, tokens *GithubAppTokenManager) scheduled.JobDefinition {
+	return scheduled.JobDefinition{
+		Job: &githubAppTokenRefresher{
+			log:    log,
+			tokens: tokens,
+		},
+		Period: githubAppTokenRefreshPeriod,
+	}
+}
+
+func (r *githubAppTokenRefresher) Run() {
+	if err := r.tokens.Refresh(); err != nil {
+		r.log.Err(err.Error())
+	}
+}
@@ -0,0 +1,108 @@
+package vcs_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/vcs/testdata"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// githubAppTokenServer returns the hostname of a server that mints tokens for
+// installation 1 that expire after expiresIn, and the number of mints.
+func githubAppTokenServer(t *testing.T, expiresIn time.Duration) (string, *int32) {
+	var mints int32
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.RequestURI != "/api/v3/app/installations/1/access_tokens" {
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		n := atomic.AddInt32(&mints, 1)
+		// Slow down minting so concurrent requests overlap.
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprintf(w, `{"token": "token-%d", "expires_at": %q}`, n, time.Now().Add(expiresIn).UTC().Format(time.RFC3339))
+	}))
+	t.Cleanup(testServer.Close)
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	return testServerURL.Host, &mints
+}
+
+func TestGithubAppTokenManager_Token_Cached(t *testing.T) {
+	defer disableSSLVerification()()
+	hostname, mints := githubAppTokenServer(t, time.Hour)
+	tokens, err := vcs.NewGithubAppTokenManager(1, []byte(testdata.GithubPrivateKey), hostname, tally.NoopScope)
+	Ok(t, err)
+
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := tokens.Token(1)
+			Ok(t, err)
+			results[i] = token
+		}(i)
+	}
+	wg.Wait()
+
+	Equals(t, int32(1), atomic.LoadInt32(mints))
+	for _, token := range results {
+		Equals(t, "token-1", token)
+	}
+}
+
+func TestGithubAppTokenManager_Token_Expiring(t *testing.T) {
+	defer disableSSLVerification()()
+	hostname, mints := githubAppTokenServer(t, 30*time.Second)
+	tokens, err := vcs.NewGithubAppTokenManager(1, []byte(testdata.GithubPrivateKey), hostname, tally.NoopScope)
+	Ok(t, err)
+
+	token, err := tokens.Token(1)
+	Ok(t, err)
+	Equals(t, "token-1", token)
+
+	// A token that expires within a minute isn't used for new requests.
+	token, err = tokens.Token(1)
+	Ok(t, err)
+	Equals(t, "token-2", token)
+	Equals(t, int32(2), atomic.LoadInt32(mints))
+}
+
+func TestGithubAppTokenManager_Refresh(t *testing.T) {
+	defer disableSSLVerification()()
+	hostname, mints := githubAppTokenServer(t, 5*time.Minute)
+	scope := tally.NewTestScope("github_app_token", nil)
+	tokens, err := vcs.NewGithubAppTokenManager(1, []byte(testdata.GithubPrivateKey), hostname, scope)
+	Ok(t, err)
+
+	// Nothing is refreshed until a token has been requested.
+	Ok(t, tokens.Refresh())
+	Equals(t, int32(0), atomic.LoadInt32(mints))
+
+	token, err := tokens.Token(1)
+	Ok(t, err)
+	Equals(t, "token-1", token)
+
+	// The token expires within the refresh window so it's replaced.
+	Ok(t, tokens.Refresh())
+	token, err = tokens.Token(1)
+	Ok(t, err)
+	Equals(t, "token-2", token)
+	Equals(t, int32(2), atomic.LoadInt32(mints))
+
+	snapshot := scope.Snapshot()
+	Equals(t, int64(2), snapshot.Counters()["github_app_token.mints+installation_id=1"].Value())
+	expiresIn := snapshot.Gauges()["github_app_token.expires_in_seconds+installation_id=1"].Value()
+	Assert(t, expiresIn > 0 && expiresIn <= 300, "expected token to expire within 5 minutes, got %f seconds", expiresIn)
+}
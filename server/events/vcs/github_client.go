@@ -87,6 +87,7 @@ type GithubClient struct {
 	config                GithubConfig
 	maxCommentsPerCommand int
 	repoIdCache           GitHubRepoIdCache
+	teamNamesCache        *githubTeamNamesCache
 }
 
 // GithubAppTemporarySecrets holds app credentials obtained from github after creation.
@@ -125,8 +126,15 @@ func NewGithubClient(hostname string, credentials GithubCredentials, config Gith
 		return nil, errors.Wrap(err, "error initializing github authentication transport")
 	}
 
+	baseTransport := transport.Transport
+	var teamNamesCache *githubTeamNamesCache
+	if config.ResponseCacheTTL > 0 {
+		baseTransport = newGithubResponseCache(baseTransport, config.ResponseCacheTTL)
+		teamNamesCache = newGithubTeamNamesCache(config.ResponseCacheTTL)
+	}
+
 	transportWithRateLimit, err := github_ratelimit.NewRateLimitWaiterClient(
-		transport.Transport,
+		baseTransport,
 		github_ratelimit.WithTotalSleepLimit(time.Minute, func(callbackContext *github_ratelimit.CallbackContext) {
 			logger.Warn("github rate limit exceeded total sleep time, requests will fail to avoid penalties from github")
 		}))
@@ -167,6 +175,7 @@ func NewGithubClient(hostname string, credentials GithubCredentials, config Gith
 		config:                config,
 		maxCommentsPerCommand: maxCommentsPerCommand,
 		repoIdCache:           NewGitHubRepoIdCache(),
+		teamNamesCache:        teamNamesCache,
 	}, nil
 }
 
@@ -249,8 +258,20 @@ func (g *GithubClient) CreateComment(logger logging.SimpleLogging, repo models.R
 		"> **Warning**: Command output is larger than the maximum number of comments per command. Output truncated.\n<details><summary>Show Output</summary>\n\n" +
 		"```diff\n"
 
-	comments := common.SplitComment(comment, maxCommentLength, sepEnd, sepStart, g.maxCommentsPerCommand, truncationHeader)
+	// Only the first comment of a split comment starts with the header that
+	// HidePrevCommandComments looks for, ex. "Ran Plan for dir: ...", and not
+	// even that one if it's truncated, so every comment ends with it.
+	maxSize := maxCommentLength
+	var marker string
+	if len(comment) > maxCommentLength {
+		marker = commentHeaderMarker(comment)
+		maxSize -= len(marker)
+	}
+	comments := common.SplitComment(comment, maxSize, sepEnd, sepStart, g.maxCommentsPerCommand, truncationHeader)
 	for i := range comments {
+		if len(comments) > 1 {
+			comments[i] += marker
+		}
 		_, resp, err := g.client.Issues.CreateComment(g.ctx, repo.Owner, repo.Name, pullNum, &github.IssueComment{Body: &comments[i]})
 		if resp != nil {
 			logger.Debug("POST /repos/%v/%v/issues/%d/comments returned: %v", repo.Owner, repo.Name, pullNum, resp.StatusCode)
@@ -274,32 +295,13 @@ func (g *GithubClient) ReactToComment(logger logging.SimpleLogging, repo models.
 
 func (g *GithubClient) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
 	logger.Debug("Hiding previous command comments on GitHub pull request %d", pullNum)
-	var allComments []*github.IssueComment
-	nextPage := 0
-	for {
-		comments, resp, err := g.client.Issues.ListComments(g.ctx, repo.Owner, repo.Name, pullNum, &github.IssueListCommentsOptions{
-			Sort:        github.Ptr("created"),
-			Direction:   github.Ptr("asc"),
-			ListOptions: github.ListOptions{Page: nextPage},
-		})
-		if resp != nil {
-			logger.Debug("GET /repos/%v/%v/issues/%d/comments returned: %v", repo.Owner, repo.Name, pullNum, resp.StatusCode)
-		}
-		if err != nil {
-			return errors.Wrap(err, "listing comments")
-		}
-		allComments = append(allComments, comments...)
-		if resp.NextPage == 0 {
-			break
-		}
-		nextPage = resp.NextPage
+	allComments, err := g.listComments(logger, repo, pullNum)
+	if err != nil {
+		return err
 	}
 
 	for _, comment := range allComments {
-		// Using a case insensitive compare here because usernames aren't case
-		// sensitive and users may enter their atlantis users with different
-		// cases.
-		if comment.User != nil && !strings.EqualFold(comment.User.GetLogin(), g.user) {
+		if !g.isAtlantisComment(comment) {
 			continue
 		}
 		// Crude filtering: The comment templates typically include the command name
@@ -311,37 +313,162 @@ func (g *GithubClient) HidePrevCommandComments(logger logging.SimpleLogging, rep
 			continue
 		}
 		firstLine := strings.ToLower(body[0])
-		if !strings.Contains(firstLine, strings.ToLower(command)) {
+		header := strings.ToLower(commentHeader(body))
+		if !strings.Contains(header, strings.ToLower(command)) {
 			continue
 		}
 
-		// If dir was specified, skip processing comments that don't contain the dir in the first line
-		if dir != "" && !strings.Contains(firstLine, strings.ToLower(dir)) {
+		// If dir was specified, skip processing comments that don't contain the dir in the header
+		if dir != "" && !strings.Contains(header, strings.ToLower(dir)) {
 			continue
 		}
 
-		var m struct {
-			MinimizeComment struct {
-				MinimizedComment struct {
-					IsMinimized       githubv4.Boolean
-					MinimizedReason   githubv4.String
-					ViewerCanMinimize githubv4.Boolean
-				}
-			} `graphql:"minimizeComment(input:$input)"`
+		// The continuation comments of a long output only add noise once its
+		// first comment is hidden.
+		if g.config.DeletePrevCommentChunks && isContinuationComment(firstLine) {
+			logger.Debug("Deleting comment %d", comment.GetID())
+			resp, err := g.client.Issues.DeleteComment(g.ctx, repo.Owner, repo.Name, comment.GetID())
+			if resp != nil {
+				logger.Debug("DELETE /repos/%v/%v/issues/comments/%d returned: %v", repo.Owner, repo.Name, comment.GetID(), resp.StatusCode)
+			}
+			if err != nil {
+				return errors.Wrapf(err, "deleting comment %d", comment.GetID())
+			}
+			continue
+		}
+
+		if err := g.minimizeComment(logger, comment.GetNodeID()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CleanUpComments deletes the comments made by Atlantis on the pull request
+// or, if collapse is true, hides them as outdated.
+func (g *GithubClient) CleanUpComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, collapse bool) (int, error) {
+	logger.Debug("Cleaning up comments on GitHub pull request %d", pullNum)
+	allComments, err := g.listComments(logger, repo, pullNum)
+	if err != nil {
+		return 0, err
+	}
+	cleanedUp := 0
+	for _, comment := range allComments {
+		if comment.User == nil || !g.isAtlantisComment(comment) {
+			continue
+		}
+		if collapse {
+			if err := g.minimizeComment(logger, comment.GetNodeID()); err != nil {
+				return cleanedUp, err
+			}
+			cleanedUp++
+			continue
+		}
+		logger.Debug("Deleting comment %d", comment.GetID())
+		resp, err := g.client.Issues.DeleteComment(g.ctx, repo.Owner, repo.Name, comment.GetID())
+		if resp != nil {
+			logger.Debug("DELETE /repos/%v/%v/issues/comments/%d returned: %v", repo.Owner, repo.Name, comment.GetID(), resp.StatusCode)
+		}
+		if err != nil {
+			return cleanedUp, errors.Wrapf(err, "deleting comment %d", comment.GetID())
+		}
+		cleanedUp++
+	}
+	return cleanedUp, nil
+}
+
+// listComments returns the comments on the pull request, oldest first.
+func (g *GithubClient) listComments(logger logging.SimpleLogging, repo models.Repo, pullNum int) ([]*github.IssueComment, error) {
+	var allComments []*github.IssueComment
+	nextPage := 0
+	for {
+		comments, resp, err := g.client.Issues.ListComments(g.ctx, repo.Owner, repo.Name, pullNum, &github.IssueListCommentsOptions{
+			Sort:        github.Ptr("created"),
+			Direction:   github.Ptr("asc"),
+			ListOptions: github.ListOptions{Page: nextPage},
+		})
+		if resp != nil {
+			logger.Debug("GET /repos/%v/%v/issues/%d/comments returned: %v", repo.Owner, repo.Name, pullNum, resp.StatusCode)
 		}
-		input := githubv4.MinimizeCommentInput{
-			Classifier: githubv4.ReportedContentClassifiersOutdated,
-			SubjectID:  comment.GetNodeID(),
+		if err != nil {
+			return nil, errors.Wrap(err, "listing comments")
 		}
-		logger.Debug("Hiding comment %s", comment.GetNodeID())
-		if err := g.v4Client.Mutate(g.ctx, &m, input, nil); err != nil {
-			return errors.Wrapf(err, "minimize comment %s", comment.GetNodeID())
+		allComments = append(allComments, comments...)
+		if resp.NextPage == 0 {
+			break
 		}
+		nextPage = resp.NextPage
 	}
+	return allComments, nil
+}
+
+// isAtlantisComment returns whether comment was made by the Atlantis user.
+// Comments without a user are assumed to be.
+func (g *GithubClient) isAtlantisComment(comment *github.IssueComment) bool {
+	// Using a case insensitive compare here because usernames aren't case
+	// sensitive and users may enter their atlantis users with different
+	// cases.
+	return comment.User == nil || strings.EqualFold(comment.User.GetLogin(), g.user)
+}
 
+// minimizeComment hides the comment with nodeID as outdated.
+func (g *GithubClient) minimizeComment(logger logging.SimpleLogging, nodeID string) error {
+	var m struct {
+		MinimizeComment struct {
+			MinimizedComment struct {
+				IsMinimized       githubv4.Boolean
+				MinimizedReason   githubv4.String
+				ViewerCanMinimize githubv4.Boolean
+			}
+		} `graphql:"minimizeComment(input:$input)"`
+	}
+	input := githubv4.MinimizeCommentInput{
+		Classifier: githubv4.ReportedContentClassifiersOutdated,
+		SubjectID:  nodeID,
+	}
+	logger.Debug("Hiding comment %s", nodeID)
+	if err := g.v4Client.Mutate(g.ctx, &m, input, nil); err != nil {
+		return errors.Wrapf(err, "minimize comment %s", nodeID)
+	}
 	return nil
 }
 
+// commentHeaderMarkerPrefix starts the marker that holds the header of the
+// comments of a split comment, see CreateComment.
+const commentHeaderMarkerPrefix = "<!-- atlantis-comment-header: "
+
+// maxCommentHeaderLength is the max length of the header in the marker.
+const maxCommentHeaderLength = 256
+
+// commentHeaderMarker returns the marker appended to every comment that
+// comment is split into. It holds the first line of comment.
+func commentHeaderMarker(comment string) string {
+	header, _, _ := strings.Cut(comment, "\n")
+	// "--" would end the HTML comment.
+	header = strings.ReplaceAll(header, "--", "")
+	if len(header) > maxCommentHeaderLength {
+		header = strings.ToValidUTF8(header[:maxCommentHeaderLength], "")
+	}
+	return "\n\n" + commentHeaderMarkerPrefix + header + " -->"
+}
+
+// commentHeader returns the header of the comment with the lines body, from
+// its marker if it's part of a split comment or else its first line.
+func commentHeader(body []string) string {
+	last := body[len(body)-1]
+	if header, ok := strings.CutPrefix(last, commentHeaderMarkerPrefix); ok {
+		return strings.TrimSuffix(header, " -->")
+	}
+	return body[0]
+}
+
+// isContinuationComment returns whether the comment with the lower cased
+// firstLine continues the output of a previous comment, see CreateComment.
+func isContinuationComment(firstLine string) bool {
+	return strings.HasPrefix(firstLine, "continued ") && strings.Contains(firstLine, "from previous comment")
+}
+
 // getPRReviews Retrieves PR reviews for a pull request on a specific repository.
 // The reviews are being retrieved using pages with the size of 10 reviews.
 func (g *GithubClient) getPRReviews(repo models.Repo, pull models.PullRequest) (GithubPRReviewSummary, error) {
@@ -397,6 +524,7 @@ func (g *GithubClient) getPRReviews(repo models.Repo, pull models.PullRequest) (
 // PullIsApproved returns true if the pull request was approved.
 func (g *GithubClient) PullIsApproved(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (approvalStatus models.ApprovalStatus, err error) {
 	logger.Debug("Checking if GitHub pull request %d is approved", pull.Num)
+	latestStates := make(map[string]string)
 	nextPage := 0
 	for {
 		opts := github.ListOptions{
@@ -413,12 +541,21 @@ func (g *GithubClient) PullIsApproved(logger logging.SimpleLogging, repo models.
 			return approvalStatus, errors.Wrap(err, "getting reviews")
 		}
 		for _, review := range pageReviews {
-			if review != nil && review.GetState() == "APPROVED" {
-				return models.ApprovalStatus{
-					IsApproved: true,
-					ApprovedBy: *review.User.Login,
-					Date:       review.SubmittedAt.Time,
-				}, nil
+			if review == nil {
+				continue
+			}
+			switch review.GetState() {
+			case "APPROVED":
+				if !approvalStatus.IsApproved {
+					approvalStatus = models.ApprovalStatus{
+						IsApproved: true,
+						ApprovedBy: *review.User.Login,
+						Date:       review.SubmittedAt.Time,
+					}
+				}
+				latestStates[review.GetUser().GetLogin()] = review.GetState()
+			case "CHANGES_REQUESTED", "DISMISSED":
+				latestStates[review.GetUser().GetLogin()] = review.GetState()
 			}
 		}
 		if resp.NextPage == 0 {
@@ -426,6 +563,14 @@ func (g *GithubClient) PullIsApproved(logger logging.SimpleLogging, repo models.
 		}
 		nextPage = resp.NextPage
 	}
+	// Reviews are listed oldest first, so the approvals that stand are the
+	// ones that weren't followed by a request for changes or dismissed.
+	for login, state := range latestStates {
+		if state == "APPROVED" {
+			approvalStatus.Approvers = append(approvalStatus.Approvers, login)
+		}
+	}
+	sort.Strings(approvalStatus.Approvers)
 	return approvalStatus, nil
 }
 
@@ -935,6 +1080,122 @@ func (g *GithubClient) UpdateStatus(logger logging.SimpleLogging, repo models.Re
 	return err
 }
 
+// maxCheckRunTextLength is the maximum number of chars allowed in the summary
+// and text of a check run by GitHub.
+const maxCheckRunTextLength = 65535
+
+// maxCheckRunAnnotations is the maximum number of annotations GitHub accepts
+// in a single request to create or update a check run.
+const maxCheckRunAnnotations = 50
+
+// UpdateCheckRun creates the check run named opts.Name on the head commit of
+// pull, or updates it if Atlantis already created it.
+func (g *GithubClient) UpdateCheckRun(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, opts CheckRunOptions) error {
+	status := "in_progress"
+	var conclusion *string
+	var completedAt *github.Timestamp
+	switch opts.Status {
+	case models.SuccessCommitStatus:
+		status = "completed"
+		conclusion = github.Ptr("success")
+		completedAt = &github.Timestamp{Time: time.Now()}
+	case models.FailedCommitStatus:
+		status = "completed"
+		conclusion = github.Ptr("failure")
+		completedAt = &github.Timestamp{Time: time.Now()}
+	}
+
+	output := &github.CheckRunOutput{
+		Title:   github.Ptr(opts.Title),
+		Summary: github.Ptr(truncateCheckRunText(opts.Summary)),
+	}
+	if opts.Text != "" {
+		output.Text = github.Ptr(truncateCheckRunText(opts.Text))
+	}
+	for i, a := range opts.Annotations {
+		if i == maxCheckRunAnnotations {
+			logger.Debug("only sending the first %d of %d annotations of check run '%s'", maxCheckRunAnnotations, len(opts.Annotations), opts.Name)
+			break
+		}
+		output.Annotations = append(output.Annotations, &github.CheckRunAnnotation{
+			Path:            github.Ptr(a.Path),
+			StartLine:       github.Ptr(1),
+			EndLine:         github.Ptr(1),
+			AnnotationLevel: github.Ptr("failure"),
+			Title:           github.Ptr(a.Title),
+			Message:         github.Ptr(a.Message),
+		})
+	}
+	var actions []*github.CheckRunAction
+	for _, a := range opts.Actions {
+		actions = append(actions, &github.CheckRunAction{
+			Label:       a.Label,
+			Description: a.Description,
+			Identifier:  a.Identifier,
+		})
+	}
+	var detailsURL, externalID *string
+	if opts.DetailsURL != "" {
+		detailsURL = github.Ptr(opts.DetailsURL)
+	}
+	if opts.ExternalID != "" {
+		externalID = github.Ptr(opts.ExternalID)
+	}
+
+	logger.Info("Updating GitHub check run '%s' to '%s'", opts.Name, status)
+
+	existing, resp, err := g.client.Checks.ListCheckRunsForRef(g.ctx, repo.Owner, repo.Name, pull.HeadCommit, &github.ListCheckRunsOptions{
+		CheckName: github.Ptr(opts.Name),
+	})
+	if resp != nil {
+		logger.Debug("GET /repos/%v/%v/commits/%s/check-runs returned: %v", repo.Owner, repo.Name, pull.HeadCommit, resp.StatusCode)
+	}
+	if err != nil {
+		return errors.Wrap(err, "listing check runs")
+	}
+	if len(existing.CheckRuns) > 0 {
+		checkRunID := existing.CheckRuns[0].GetID()
+		_, resp, err = g.client.Checks.UpdateCheckRun(g.ctx, repo.Owner, repo.Name, checkRunID, github.UpdateCheckRunOptions{
+			Name:        opts.Name,
+			DetailsURL:  detailsURL,
+			ExternalID:  externalID,
+			Status:      github.Ptr(status),
+			Conclusion:  conclusion,
+			CompletedAt: completedAt,
+			Output:      output,
+			Actions:     actions,
+		})
+		if resp != nil {
+			logger.Debug("PATCH /repos/%v/%v/check-runs/%d returned: %v", repo.Owner, repo.Name, checkRunID, resp.StatusCode)
+		}
+		return err
+	}
+	_, resp, err = g.client.Checks.CreateCheckRun(g.ctx, repo.Owner, repo.Name, github.CreateCheckRunOptions{
+		Name:        opts.Name,
+		HeadSHA:     pull.HeadCommit,
+		DetailsURL:  detailsURL,
+		ExternalID:  externalID,
+		Status:      github.Ptr(status),
+		Conclusion:  conclusion,
+		CompletedAt: completedAt,
+		Output:      output,
+		Actions:     actions,
+	})
+	if resp != nil {
+		logger.Debug("POST /repos/%v/%v/check-runs returned: %v", repo.Owner, repo.Name, resp.StatusCode)
+	}
+	return err
+}
+
+// truncateCheckRunText truncates text so that it fits in a check run.
+func truncateCheckRunText(text string) string {
+	if len(text) <= maxCheckRunTextLength {
+		return text
+	}
+	const truncated = "\n\n...(truncated)"
+	return text[:maxCheckRunTextLength-len(truncated)] + truncated
+}
+
 // MergePull merges the pull request.
 func (g *GithubClient) MergePull(logger logging.SimpleLogging, pull models.PullRequest, pullOptions models.PullRequestOptions) error {
 	logger.Debug("Merging GitHub pull request %d", pull.Num)
@@ -1022,6 +1283,11 @@ func (g *GithubClient) MarkdownPullLink(pull models.PullRequest) (string, error)
 func (g *GithubClient) GetTeamNamesForUser(logger logging.SimpleLogging, repo models.Repo, user models.User) ([]string, error) {
 	logger.Debug("Getting GitHub team names for user '%s'", user)
 	orgName := repo.Owner
+	if g.teamNamesCache != nil {
+		if teamNames, ok := g.teamNamesCache.Get(orgName, user.Username); ok {
+			return teamNames, nil
+		}
+	}
 	variables := map[string]interface{}{
 		"orgName":    githubv4.String(orgName),
 		"userLogins": []githubv4.String{githubv4.String(user.Username)},
@@ -1058,9 +1324,25 @@ func (g *GithubClient) GetTeamNamesForUser(logger logging.SimpleLogging, repo mo
 		}
 		variables["teamCursor"] = githubv4.NewString(q.Organization.Teams.PageInfo.EndCursor)
 	}
+	if g.teamNamesCache != nil {
+		g.teamNamesCache.Set(orgName, user.Username, teamNames)
+	}
 	return teamNames, nil
 }
 
+// IsOrgMember returns true if user is a member of the organization org. The
+// token needs to be able to read the organization's members to see private
+// memberships.
+// https://docs.github.com/en/rest/orgs/members#check-organization-membership-for-a-user
+func (g *GithubClient) IsOrgMember(logger logging.SimpleLogging, user models.User, org string) (bool, error) {
+	logger.Debug("Checking if GitHub user '%s' is a member of organization '%s'", user.Username, org)
+	isMember, resp, err := g.client.Organizations.IsMember(g.ctx, org, user.Username)
+	if resp != nil {
+		logger.Debug("GET /orgs/%s/members/%s returned: %v", org, user.Username, resp.StatusCode)
+	}
+	return isMember, err
+}
+
 // ExchangeCode returns a newly created app's info
 func (g *GithubClient) ExchangeCode(logger logging.SimpleLogging, code string) (*GithubAppTemporarySecrets, error) {
 	logger.Debug("Exchanging code for app secrets")
@@ -1141,3 +1423,198 @@ func (g *GithubClient) GetPullLabels(logger logging.SimpleLogging, repo models.R
 
 	return labels, nil
 }
+
+// CreateIssue opens an issue in repo.
+func (g *GithubClient) CreateIssue(logger logging.SimpleLogging, repo models.Repo, title string, body string) error {
+	logger.Debug("Creating GitHub issue in %s", repo.FullName)
+	_, resp, err := g.client.Issues.Create(g.ctx, repo.Owner, repo.Name, &github.IssueRequest{
+		Title: github.Ptr(title),
+		Body:  github.Ptr(body),
+	})
+	if resp != nil {
+		logger.Debug("POST /repos/%v/%v/issues returned: %v", repo.Owner, repo.Name, resp.StatusCode)
+	}
+	return err
+}
+
+// GetPullState returns whether pull is still open.
+func (g *GithubClient) GetPullState(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.PullRequestState, error) {
+	githubPull, err := g.GetPullRequest(logger, repo, pull.Num)
+	if err != nil {
+		return models.ClosedPullState, err
+	}
+	if githubPull.GetState() == "open" {
+		return models.OpenPullState, nil
+	}
+	return models.ClosedPullState, nil
+}
+
+// GetEnvironmentProtectionRules returns the types of the protection rules of
+// the environment of repo, ex. required_reviewers and wait_timer.
+func (g *GithubClient) GetEnvironmentProtectionRules(logger logging.SimpleLogging, repo models.Repo, environment string) ([]string, error) {
+	logger.Debug("Getting protection rules of GitHub environment '%s'", environment)
+	env, resp, err := g.client.Repositories.GetEnvironment(g.ctx, repo.Owner, repo.Name, environment)
+	if resp != nil {
+		logger.Debug("GET /repos/%v/%v/environments/%s returned: %v", repo.Owner, repo.Name, environment, resp.StatusCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rules []string
+	for _, rule := range env.ProtectionRules {
+		rules = append(rules, rule.GetType())
+	}
+	return rules, nil
+}
+
+// CreateDeployment creates a deployment of ref to the environment of repo and
+// returns its ID. It doesn't wait for the commit statuses of ref since
+// Atlantis' own statuses are pending while applying.
+func (g *GithubClient) CreateDeployment(logger logging.SimpleLogging, repo models.Repo, ref string, environment string, description string) (int64, error) {
+	logger.Debug("Creating GitHub deployment of '%s' to environment '%s'", ref, environment)
+	deployment, resp, err := g.client.Repositories.CreateDeployment(g.ctx, repo.Owner, repo.Name, &github.DeploymentRequest{
+		Ref:              github.Ptr(ref),
+		Task:             github.Ptr("deploy"),
+		AutoMerge:        github.Ptr(false),
+		RequiredContexts: &[]string{},
+		Environment:      github.Ptr(environment),
+		Description:      github.Ptr(description),
+	})
+	if resp != nil {
+		logger.Debug("POST /repos/%v/%v/deployments returned: %v", repo.Owner, repo.Name, resp.StatusCode)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return deployment.GetID(), nil
+}
+
+// GetDeploymentState returns the state of the latest status of the deployment
+// with deploymentID of repo, ex. waiting or success. It returns an empty
+// string if the deployment has no status yet.
+func (g *GithubClient) GetDeploymentState(logger logging.SimpleLogging, repo models.Repo, deploymentID int64) (string, error) {
+	statuses, resp, err := g.client.Repositories.ListDeploymentStatuses(g.ctx, repo.Owner, repo.Name, deploymentID, &github.ListOptions{PerPage: 1})
+	if resp != nil {
+		logger.Debug("GET /repos/%v/%v/deployments/%d/statuses returned: %v", repo.Owner, repo.Name, deploymentID, resp.StatusCode)
+	}
+	if err != nil {
+		return "", err
+	}
+	// Statuses are listed newest first.
+	if len(statuses) == 0 {
+		return "", nil
+	}
+	return statuses[0].GetState(), nil
+}
+
+// UpdateDeploymentStatus sets the status of the deployment with deploymentID
+// of repo to state, ex. in_progress or success.
+func (g *GithubClient) UpdateDeploymentStatus(logger logging.SimpleLogging, repo models.Repo, deploymentID int64, state string, description string) error {
+	logger.Debug("Updating GitHub deployment %d status to '%s'", deploymentID, state)
+	_, resp, err := g.client.Repositories.CreateDeploymentStatus(g.ctx, repo.Owner, repo.Name, deploymentID, &github.DeploymentStatusRequest{
+		State:       github.Ptr(state),
+		Description: github.Ptr(description),
+	})
+	if resp != nil {
+		logger.Debug("POST /repos/%v/%v/deployments/%d/statuses returned: %v", repo.Owner, repo.Name, deploymentID, resp.StatusCode)
+	}
+	return err
+}
+
+// GetParentPull returns the number of the open pull request of repo whose
+// head branch is the base branch of pull. It returns 0 if there's none.
+func (g *GithubClient) GetParentPull(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (int, error) {
+	logger.Debug("Getting open GitHub pull request with head branch '%s'", pull.BaseBranch)
+	pulls, resp, err := g.client.PullRequests.List(g.ctx, repo.Owner, repo.Name, &github.PullRequestListOptions{
+		State: "open",
+		Head:  repo.Owner + ":" + pull.BaseBranch,
+	})
+	if resp != nil {
+		logger.Debug("GET /repos/%v/%v/pulls returned: %v", repo.Owner, repo.Name, resp.StatusCode)
+	}
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range pulls {
+		if p.GetNumber() != pull.Num {
+			return p.GetNumber(), nil
+		}
+	}
+	return 0, nil
+}
+
+// MaxCommentLength returns the longest comment GitHub accepts.
+func (g *GithubClient) MaxCommentLength() int {
+	return maxCommentLength
+}
+
+// GetSummaryComments returns the bodies of the comments Atlantis made on the
+// pull request that start with marker.
+func (g *GithubClient) GetSummaryComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, marker string) ([]string, error) {
+	comments, err := g.summaryComments(logger, repo, pullNum, marker)
+	if err != nil {
+		return nil, err
+	}
+	var bodies []string
+	for _, comment := range comments {
+		bodies = append(bodies, comment.GetBody())
+	}
+	return bodies, nil
+}
+
+// SetSummaryComments replaces the comments Atlantis made on the pull request
+// that start with marker with bodies.
+func (g *GithubClient) SetSummaryComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, marker string, bodies []string) error {
+	comments, err := g.summaryComments(logger, repo, pullNum, marker)
+	if err != nil {
+		return err
+	}
+	for i := range bodies {
+		if i < len(comments) {
+			if comments[i].GetBody() == bodies[i] {
+				continue
+			}
+			_, resp, err := g.client.Issues.EditComment(g.ctx, repo.Owner, repo.Name, comments[i].GetID(), &github.IssueComment{Body: &bodies[i]})
+			if resp != nil {
+				logger.Debug("PATCH /repos/%v/%v/issues/comments/%d returned: %v", repo.Owner, repo.Name, comments[i].GetID(), resp.StatusCode)
+			}
+			if err != nil {
+				return errors.Wrapf(err, "editing comment %d", comments[i].GetID())
+			}
+			continue
+		}
+		_, resp, err := g.client.Issues.CreateComment(g.ctx, repo.Owner, repo.Name, pullNum, &github.IssueComment{Body: &bodies[i]})
+		if resp != nil {
+			logger.Debug("POST /repos/%v/%v/issues/%d/comments returned: %v", repo.Owner, repo.Name, pullNum, resp.StatusCode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, comment := range comments[min(len(bodies), len(comments)):] {
+		resp, err := g.client.Issues.DeleteComment(g.ctx, repo.Owner, repo.Name, comment.GetID())
+		if resp != nil {
+			logger.Debug("DELETE /repos/%v/%v/issues/comments/%d returned: %v", repo.Owner, repo.Name, comment.GetID(), resp.StatusCode)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "deleting comment %d", comment.GetID())
+		}
+	}
+	return nil
+}
+
+// summaryComments returns the comments Atlantis made on the pull request that
+// start with marker, oldest first.
+func (g *GithubClient) summaryComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, marker string) ([]*github.IssueComment, error) {
+	allComments, err := g.listComments(logger, repo, pullNum)
+	if err != nil {
+		return nil, err
+	}
+	var comments []*github.IssueComment
+	for _, comment := range allComments {
+		if g.isAtlantisComment(comment) && strings.HasPrefix(comment.GetBody(), marker) {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
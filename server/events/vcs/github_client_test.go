@@ -64,7 +64,7 @@ func TestGithubClient_GetModifiedFiles(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logger)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logger)
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -122,7 +122,7 @@ func TestGithubClient_GetModifiedFilesMovedFile(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -219,7 +219,7 @@ func TestGithubClient_PaginatesComments(t *testing.T) {
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
 
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -335,7 +335,7 @@ func TestGithubClient_HideOldComments(t *testing.T) {
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
 
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{atlantisUser, "pass", ""}, vcs.GithubConfig{}, 0,
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: atlantisUser, Token: "pass"}, vcs.GithubConfig{}, 0,
 				logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
@@ -367,6 +367,131 @@ func TestGithubClient_HideOldComments(t *testing.T) {
 	}
 }
 
+func TestGithubClient_HideOldComments_DeletePrevCommentChunks(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	pullRequestNum := 123
+	issueResp := `[
+	{"id": 1, "node_id": "1", "body": "Ran Plan for 2 projects:", "user": {"login": "AtlantisUser"}},
+	{"id": 2, "node_id": "2", "body": "Continued Plan output from previous comment.\nasd", "user": {"login": "AtlantisUser"}},
+	{"id": 3, "node_id": "3", "body": "Continued Plan output from previous comment.\nasd", "user": {"login": "someone-else"}}
+]`
+	var minimized []string
+	var deleted []string
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.RequestURI {
+			case fmt.Sprintf("GET /api/v3/repos/owner/repo/issues/%v/comments?direction=asc&sort=created", pullRequestNum):
+				w.Write([]byte(issueResp)) // nolint: errcheck
+			case "POST /api/graphql":
+				var call struct {
+					Variables struct {
+						Input githubv4.MinimizeCommentInput `json:"input"`
+					} `json:"variables"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+					t.Errorf("parse body error: %v", err)
+				}
+				minimized = append(minimized, fmt.Sprint(call.Variables.Input.SubjectID))
+				w.Write([]byte("{}")) // nolint: errcheck
+			case "DELETE /api/v3/repos/owner/repo/issues/comments/2":
+				deleted = append(deleted, "2")
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}),
+	)
+
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "AtlantisUser", Token: "pass"}, vcs.GithubConfig{DeletePrevCommentChunks: true}, 0,
+		logging.NewNoopLogger(t))
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	err = client.HidePrevCommandComments(
+		logger,
+		models.Repo{
+			FullName: "owner/repo",
+			Owner:    "owner",
+			Name:     "repo",
+			VCSHost: models.VCSHost{
+				Hostname: "github.com",
+				Type:     models.Github,
+			},
+		},
+		pullRequestNum,
+		command.Plan.TitleString(),
+		"",
+	)
+	Ok(t, err)
+	// The first comment is hidden and its continuation is deleted.
+	Equals(t, []string{"1"}, minimized)
+	Equals(t, []string{"2"}, deleted)
+}
+
+func TestGithubClient_HideOldComments_SplitComment(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	pullRequestNum := 123
+	issueResp := `[
+	{"id": 1, "node_id": "1", "body": "Ran Plan for dir: ` + "`app`" + ` workspace: ` + "`default`" + `\nasd\n\n<!-- atlantis-comment-header: Ran Plan for dir: ` + "`app`" + ` workspace: ` + "`default`" + ` -->", "user": {"login": "AtlantisUser"}},
+	{"id": 2, "node_id": "2", "body": "Continued plan output from previous comment.\nasd\n\n<!-- atlantis-comment-header: Ran Plan for dir: ` + "`app`" + ` workspace: ` + "`default`" + ` -->", "user": {"login": "AtlantisUser"}},
+	{"id": 3, "node_id": "3", "body": "> [!WARNING]\nasd\n\n<!-- atlantis-comment-header: Ran Plan for dir: ` + "`app`" + ` workspace: ` + "`default`" + ` -->", "user": {"login": "AtlantisUser"}},
+	{"id": 4, "node_id": "4", "body": "Continued plan output from previous comment.\nasd\n\n<!-- atlantis-comment-header: Ran Plan for dir: ` + "`network`" + ` workspace: ` + "`default`" + ` -->", "user": {"login": "AtlantisUser"}}
+]`
+	var minimized []string
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.RequestURI {
+			case fmt.Sprintf("GET /api/v3/repos/owner/repo/issues/%v/comments?direction=asc&sort=created", pullRequestNum):
+				w.Write([]byte(issueResp)) // nolint: errcheck
+			case "POST /api/graphql":
+				var call struct {
+					Variables struct {
+						Input githubv4.MinimizeCommentInput `json:"input"`
+					} `json:"variables"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+					t.Errorf("parse body error: %v", err)
+				}
+				minimized = append(minimized, fmt.Sprint(call.Variables.Input.SubjectID))
+				w.Write([]byte("{}")) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}),
+	)
+
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "AtlantisUser", Token: "pass"}, vcs.GithubConfig{}, 0,
+		logging.NewNoopLogger(t))
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	err = client.HidePrevCommandComments(
+		logger,
+		models.Repo{
+			FullName: "owner/repo",
+			Owner:    "owner",
+			Name:     "repo",
+			VCSHost: models.VCSHost{
+				Hostname: "github.com",
+				Type:     models.Github,
+			},
+		},
+		pullRequestNum,
+		command.Plan.TitleString(),
+		"app",
+	)
+	Ok(t, err)
+	// The continuation and truncated comments of dir app are hidden by their
+	// header marker, but not the ones of dir network.
+	Equals(t, []string{"1", "2", "3"}, minimized)
+}
+
 func TestGithubClient_UpdateStatus(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	cases := []struct {
@@ -408,7 +533,7 @@ func TestGithubClient_UpdateStatus(t *testing.T) {
 
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -432,6 +557,82 @@ func TestGithubClient_UpdateStatus(t *testing.T) {
 	}
 }
 
+func TestGithubClient_UpdateCheckRun(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	cases := []struct {
+		description string
+		existing    string
+		expMethod   string
+		expPath     string
+	}{
+		{
+			"creates the check run",
+			`{"total_count":0,"check_runs":[]}`,
+			"POST",
+			"/api/v3/repos/owner/repo/check-runs",
+		},
+		{
+			"updates the existing check run",
+			`{"total_count":1,"check_runs":[{"id":4}]}`,
+			"PATCH",
+			"/api/v3/repos/owner/repo/check-runs/4",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			written := false
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch {
+					case r.Method == "GET" && r.RequestURI == "/api/v3/repos/owner/repo/commits/sha/check-runs?check_name=atlantis%2Fplan%3A+dir%2Fdefault":
+						w.Write([]byte(c.existing)) // nolint: errcheck
+					case r.Method == c.expMethod && r.RequestURI == c.expPath:
+						body, err := io.ReadAll(r.Body)
+						Ok(t, err)
+						var opts map[string]any
+						Ok(t, json.Unmarshal(body, &opts))
+						Equals(t, "atlantis/plan: dir/default", opts["name"])
+						Equals(t, "completed", opts["status"])
+						Equals(t, "failure", opts["conclusion"])
+						Equals(t, "atlantis plan -d dir", opts["external_id"])
+						Equals(t, []any{map[string]any{"label": "Re-plan", "description": "Re-plan", "identifier": "replan"}}, opts["actions"])
+						output := opts["output"].(map[string]any)
+						Equals(t, "Plan failed.", output["title"])
+						Equals(t, 1, len(output["annotations"].([]any)))
+						written = true
+						w.Write([]byte(`{"id":4}`)) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			err = client.UpdateCheckRun(logger, models.Repo{
+				FullName: "owner/repo",
+				Owner:    "owner",
+				Name:     "repo",
+			}, models.PullRequest{Num: 1, HeadCommit: "sha"}, vcs.CheckRunOptions{
+				Name:        "atlantis/plan: dir/default",
+				Status:      models.FailedCommitStatus,
+				Title:       "Plan failed.",
+				Summary:     "error",
+				ExternalID:  "atlantis plan -d dir",
+				Annotations: []vcs.CheckRunAnnotation{{Path: "dir", Title: "Policy set tags failed", Message: "missing owner tag"}},
+				Actions:     []vcs.CheckRunAction{{Label: "Re-plan", Description: "Re-plan", Identifier: "replan"}},
+			})
+			Ok(t, err)
+			Assert(t, written, "expected the check run to be written")
+		})
+	}
+}
+
 func TestGithubClient_PullIsApproved(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	respTemplate := `[
@@ -497,7 +698,7 @@ func TestGithubClient_PullIsApproved(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -520,6 +721,50 @@ func TestGithubClient_PullIsApproved(t *testing.T) {
 	Equals(t, false, approvalStatus.IsApproved)
 }
 
+// The approvals that were followed by a request for changes or dismissed
+// don't stand.
+func TestGithubClient_PullIsApproved_Approvers(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	review := `{"id": %d, "user": {"login": %q}, "state": %q, "submitted_at": "2024-01-01T00:00:00Z"}`
+	resp := "[" + strings.Join([]string{
+		fmt.Sprintf(review, 1, "alice", "APPROVED"),
+		fmt.Sprintf(review, 2, "bob", "APPROVED"),
+		fmt.Sprintf(review, 3, "carol", "COMMENTED"),
+		fmt.Sprintf(review, 4, "bob", "CHANGES_REQUESTED"),
+		fmt.Sprintf(review, 5, "dave", "APPROVED"),
+		fmt.Sprintf(review, 6, "dave", "DISMISSED"),
+		fmt.Sprintf(review, 7, "erin", "APPROVED"),
+		fmt.Sprintf(review, 8, "erin", "COMMENTED"),
+	}, ",") + "]"
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v3/repos/owner/repo/pulls/1/reviews?per_page=300":
+				w.Write([]byte(resp)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	approvalStatus, err := client.PullIsApproved(logger, models.Repo{
+		FullName: "owner/repo",
+		Owner:    "owner",
+		Name:     "repo",
+		VCSHost:  models.VCSHost{Type: models.Github, Hostname: "github.com"},
+	}, models.PullRequest{Num: 1})
+	Ok(t, err)
+	Equals(t, true, approvalStatus.IsApproved)
+	Equals(t, "alice", approvalStatus.ApprovedBy)
+	Equals(t, []string{"alice", "erin"}, approvalStatus.Approvers)
+}
+
 func TestGithubClient_PullIsMergeable(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	vcsStatusName := "atlantis-test"
@@ -592,7 +837,7 @@ func TestGithubClient_PullIsMergeable(t *testing.T) {
 				}))
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -881,7 +1126,7 @@ func TestGithubClient_PullIsMergeableWithAllowMergeableBypassApply(t *testing.T)
 				}))
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{AllowMergeableBypassApply: true}, 0, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{AllowMergeableBypassApply: true}, 0, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -966,7 +1211,7 @@ func TestGithubClient_MergePullHandlesError(t *testing.T) {
 
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -1146,7 +1391,7 @@ func TestGithubClient_MergePullCorrectMethod(t *testing.T) {
 
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -1180,7 +1425,7 @@ func TestGithubClient_MergePullCorrectMethod(t *testing.T) {
 }
 
 func TestGithubClient_MarkdownPullLink(t *testing.T) {
-	client, err := vcs.NewGithubClient("hostname", &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient("hostname", &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	pull := models.PullRequest{Num: 1}
 	s, _ := client.MarkdownPullLink(pull)
@@ -1236,7 +1481,7 @@ func TestGithubClient_SplitComments(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 	pull := models.PullRequest{Num: 1}
@@ -1266,6 +1511,11 @@ func TestGithubClient_SplitComments(t *testing.T) {
 	Equals(t, 4, len(githubComments))
 	Assert(t, strings.Contains(firstSplit, command.Plan.String()), fmt.Sprintf("comment should contain the command name but was %q", firstSplit))
 	Assert(t, strings.Contains(secondSplit, "continued from previous comment"), fmt.Sprintf("comment should contain no reference to the command name but was %q", secondSplit))
+
+	// Every comment ends with the header of the split comment.
+	for _, c := range githubComments {
+		Assert(t, strings.HasSuffix(c.Body, "\n\n<!-- atlantis-comment-header: "+strings.Repeat("a", 256)+" -->"), "comment should end with the header marker")
+	}
 }
 
 // Test that we retry the get pull request call if it 404s.
@@ -1295,7 +1545,7 @@ func TestGithubClient_Retry404(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 	repo := models.Repo{
@@ -1341,7 +1591,7 @@ func TestGithubClient_Retry404Files(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 	repo := models.Repo{
@@ -1394,7 +1644,7 @@ func TestGithubClient_GetTeamNamesForUser(t *testing.T) {
 		}))
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logger)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logger)
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -1409,6 +1659,113 @@ func TestGithubClient_GetTeamNamesForUser(t *testing.T) {
 	Equals(t, []string{"Frontend Developers", "frontend-developers", "Employees", "employees"}, teams)
 }
 
+func TestGithubClient_IsOrgMember(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v3/orgs/acme/members/member":
+				w.WriteHeader(http.StatusNoContent)
+			case "/api/v3/orgs/acme/members/outsider":
+				w.WriteHeader(http.StatusNotFound)
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logger)
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	isMember, err := client.IsOrgMember(logger, models.User{Username: "member"}, "acme")
+	Ok(t, err)
+	Equals(t, true, isMember)
+
+	isMember, err = client.IsOrgMember(logger, models.User{Username: "outsider"}, "acme")
+	Ok(t, err)
+	Equals(t, false, isMember)
+}
+
+func TestGithubClient_Deployments(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.RequestURI {
+			case "GET /api/v3/repos/owner/repo/environments/production":
+				w.Write([]byte(`{"name": "production", "protection_rules": [{"id": 1, "type": "required_reviewers"}, {"id": 2, "type": "wait_timer", "wait_timer": 30}]}`)) // nolint: errcheck
+			case "POST /api/v3/repos/owner/repo/deployments":
+				body, err := io.ReadAll(r.Body)
+				Ok(t, err)
+				Equals(t, `{"ref":"sha","task":"deploy","auto_merge":false,"required_contexts":[],"environment":"production","description":"apply"}`+"\n", string(body))
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"id": 42}`)) // nolint: errcheck
+			case "GET /api/v3/repos/owner/repo/deployments/42/statuses?per_page=1":
+				w.Write([]byte(`[{"id": 2, "state": "waiting"}]`)) // nolint: errcheck
+			case "POST /api/v3/repos/owner/repo/deployments/42/statuses":
+				body, err := io.ReadAll(r.Body)
+				Ok(t, err)
+				Equals(t, `{"state":"success","description":"applied"}`+"\n", string(body))
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"id": 3, "state": "success"}`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logger)
+	Ok(t, err)
+	defer disableSSLVerification()()
+	repo := models.Repo{Owner: "owner", Name: "repo", FullName: "owner/repo"}
+
+	rules, err := client.GetEnvironmentProtectionRules(logger, repo, "production")
+	Ok(t, err)
+	Equals(t, []string{"required_reviewers", "wait_timer"}, rules)
+
+	deploymentID, err := client.CreateDeployment(logger, repo, "sha", "production", "apply")
+	Ok(t, err)
+	Equals(t, int64(42), deploymentID)
+
+	state, err := client.GetDeploymentState(logger, repo, deploymentID)
+	Ok(t, err)
+	Equals(t, "waiting", state)
+
+	Ok(t, client.UpdateDeploymentStatus(logger, repo, deploymentID, "success", "applied"))
+}
+
+func TestGithubClient_GetParentPull(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v3/repos/owner/repo/pulls?head=owner%3Afeature-a&state=open":
+				w.Write([]byte(`[{"number": 2}]`)) // nolint: errcheck
+			case "/api/v3/repos/owner/repo/pulls?head=owner%3Amain&state=open":
+				w.Write([]byte(`[]`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logger)
+	Ok(t, err)
+	defer disableSSLVerification()()
+	repo := models.Repo{Owner: "owner", Name: "repo", FullName: "owner/repo"}
+
+	parent, err := client.GetParentPull(logger, repo, models.PullRequest{Num: 5, BaseBranch: "feature-a"})
+	Ok(t, err)
+	Equals(t, 2, parent)
+
+	parent, err = client.GetParentPull(logger, repo, models.PullRequest{Num: 2, BaseBranch: "main"})
+	Ok(t, err)
+	Equals(t, 0, parent)
+}
+
 func TestGithubClient_DiscardReviews(t *testing.T) {
 	type ResponseDef struct {
 		httpCode int
@@ -1594,7 +1951,7 @@ func TestGithubClient_DiscardReviews(t *testing.T) {
 				}))
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 			if err := client.DiscardReviews(tt.args.repo, tt.args.pull); (err != nil) != tt.wantErr {
@@ -1663,7 +2020,7 @@ func TestGithubClient_GetPullLabels(t *testing.T) {
 		}))
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logger)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logger)
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -1679,6 +2036,45 @@ func TestGithubClient_GetPullLabels(t *testing.T) {
 	Equals(t, []string{"docs", "go", "needs tests", "work-in-progress"}, labels)
 }
 
+func TestGithubClient_GetPullState(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	cases := map[string]models.PullRequestState{
+		"open":   models.OpenPullState,
+		"closed": models.ClosedPullState,
+	}
+	for state, exp := range cases {
+		t.Run(state, func(t *testing.T) {
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.RequestURI {
+					case "/api/v3/repos/runatlantis/atlantis/pulls/1":
+						w.Write([]byte(fmt.Sprintf(`{"number": 1, "state": %q}`, state))) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+						return
+					}
+				}))
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logger)
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			pullState, err := client.GetPullState(
+				logger,
+				models.Repo{
+					Owner: "runatlantis",
+					Name:  "atlantis",
+				}, models.PullRequest{
+					Num: 1,
+				})
+			Ok(t, err)
+			Equals(t, exp, pullState)
+		})
+	}
+}
+
 func TestGithubClient_GetPullLabels_EmptyResponse(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	resp := `{
@@ -1700,7 +2096,7 @@ func TestGithubClient_GetPullLabels_EmptyResponse(t *testing.T) {
 		}))
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logger)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{User: "user", Token: "pass"}, vcs.GithubConfig{}, 0, logger)
 	Ok(t, err)
 	defer disableSSLVerification()()
 
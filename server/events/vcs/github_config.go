@@ -1,6 +1,14 @@
 package vcs
 
+import "time"
+
 // GithubConfig allows for custom github-specific functionality and behavior
 type GithubConfig struct {
 	AllowMergeableBypassApply bool
+	// DeletePrevCommentChunks deletes the continuation comments of previous
+	// command outputs instead of hiding them.
+	DeletePrevCommentChunks bool
+	// ResponseCacheTTL is how long the responses of hot reads, ex. the
+	// changed files of a pull request, are cached for. 0 disables the cache.
+	ResponseCacheTTL time.Duration
 }
@@ -7,10 +7,14 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v68/github"
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+	tally "github.com/uber-go/tally/v4"
 )
 
 //go:generate pegomock generate --package mocks -o mocks/mock_github_credentials.go GithubCredentials
@@ -41,11 +45,39 @@ func (c *GithubAnonymousCredentials) GetToken() (string, error) {
 	return "", nil
 }
 
+const (
+	// githubTokenExpirationHeader is the header GitHub sets on the responses
+	// to requests authenticated with a personal access token that expires,
+	// which includes every fine-grained personal access token.
+	githubTokenExpirationHeader = "GitHub-Authentication-Token-Expiration"
+	// githubTokenExpirationWarning is how long before the personal access
+	// token expires Atlantis starts warning about it.
+	githubTokenExpirationWarning = 7 * 24 * time.Hour
+	// githubTokenExpirationWarningPeriod is how often the warning is logged.
+	githubTokenExpirationWarningPeriod = 24 * time.Hour
+)
+
 // GithubUserCredentials implements GithubCredentials for the personal auth token flow.
+// Both classic and fine-grained personal access tokens are supported.
 type GithubUserCredentials struct {
-	User      string
-	Token     string
+	User  string
+	Token string
+	// TokenFile is the file the token is read from. It's read for every
+	// request so that the token can be rotated without restarting Atlantis.
 	TokenFile string
+	// TokenEnv is the environment variable the token is read from when
+	// TokenFile isn't set, ex. ATLANTIS_GH_TOKEN. If it's empty or unset,
+	// Token is used.
+	TokenEnv string
+	// Scope is the scope the token metrics are reported to. If nil, they
+	// aren't reported.
+	Scope tally.Scope
+	// Logger logs a warning when the token is about to expire. If nil, the
+	// warning isn't logged.
+	Logger logging.SimpleLogging
+
+	mutex       sync.Mutex
+	lastWarning time.Time
 }
 
 type GitHubUserTransport struct {
@@ -54,15 +86,69 @@ type GitHubUserTransport struct {
 }
 
 func (t *GitHubUserTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// update token
 	token, err := t.Credentials.GetToken()
+	if err != nil {
+		// RoundTrip must always close the body, including on errors.
+		if req.Body != nil {
+			req.Body.Close() // nolint: errcheck
+		}
+		return nil, err
+	}
+	resp, err := t.roundTrip(req, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The token may have been rotated after it was read. Re-read it and
+	// retry once with the new token.
+	scope := t.Credentials.scope()
+	scope.Counter("auth_failures").Inc(1)
+	rotated, err := t.Credentials.GetToken()
+	if err != nil || rotated == token {
+		return resp, nil
+	}
+	retry, err := cloneRequestForRetry(req)
+	if err != nil || retry == nil {
+		return resp, nil
+	}
+	resp.Body.Close() // nolint: errcheck
+	scope.Counter("retries").Inc(1)
+	return t.roundTrip(retry, rotated)
+}
+
+func (t *GitHubUserTransport) roundTrip(req *http.Request, token string) (*http.Response, error) {
+	// A copy of the transport is used so that concurrent requests don't
+	// race on its password.
+	basicAuth := &github.BasicAuthTransport{
+		Username:  t.Transport.Username,
+		Password:  token,
+		OTP:       t.Transport.OTP,
+		Transport: t.Transport.Transport,
+	}
+	resp, err := basicAuth.RoundTrip(req)
 	if err != nil {
 		return nil, err
 	}
-	t.Transport.Password = token
+	t.Credentials.recordExpiration(resp)
+	return resp, nil
+}
 
-	// defer to the underlying transport
-	return t.Transport.RoundTrip(req)
+// cloneRequestForRetry returns a copy of req with a new body that can be
+// sent after req, or nil if req's body can't be read a second time.
+func cloneRequestForRetry(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req.Clone(req.Context()), nil
+	}
+	if req.GetBody == nil {
+		return nil, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, nil
 }
 
 // Client returns a client for basic auth user credentials.
@@ -77,7 +163,7 @@ func (c *GithubUserCredentials) Client() (*http.Client, error) {
 			Credentials: c,
 			Transport: &github.BasicAuthTransport{
 				Username: strings.TrimSpace(c.User),
-				Password: strings.TrimSpace(password),
+				Password: password,
 			},
 		},
 	}
@@ -89,7 +175,8 @@ func (c *GithubUserCredentials) GetUser() (string, error) {
 	return c.User, nil
 }
 
-// GetToken returns the user token.
+// GetToken returns the user token. It's re-read from TokenFile or TokenEnv
+// every time so that a rotated token is used right away.
 func (c *GithubUserCredentials) GetToken() (string, error) {
 	if c.TokenFile != "" {
 		content, err := os.ReadFile(c.TokenFile)
@@ -97,10 +184,59 @@ func (c *GithubUserCredentials) GetToken() (string, error) {
 			return "", fmt.Errorf("failed reading github token file: %w", err)
 		}
 
-		return string(content), nil
+		return strings.TrimSpace(string(content)), nil
+	}
+	if c.TokenEnv != "" {
+		if token := strings.TrimSpace(os.Getenv(c.TokenEnv)); token != "" {
+			return token, nil
+		}
+	}
+
+	return strings.TrimSpace(c.Token), nil
+}
+
+// recordExpiration reports how long until the token expires if resp says
+// when it does, and warns when that's soon.
+func (c *GithubUserCredentials) recordExpiration(resp *http.Response) {
+	header := resp.Header.Get(githubTokenExpirationHeader)
+	if header == "" {
+		return
+	}
+	expiresAt, err := parseGithubTokenExpiration(header)
+	if err != nil {
+		return
+	}
+	expiresIn := time.Until(expiresAt)
+	c.scope().Gauge("expires_in_seconds").Update(expiresIn.Seconds())
+	if c.Logger == nil || expiresIn > githubTokenExpirationWarning {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if time.Since(c.lastWarning) < githubTokenExpirationWarningPeriod {
+		return
 	}
+	c.lastWarning = time.Now()
+	c.Logger.Warn("the github token of user %q expires at %s, rotate it to keep Atlantis working", c.User, expiresAt.UTC().Format(time.RFC3339))
+}
 
-	return c.Token, nil
+func (c *GithubUserCredentials) scope() tally.Scope {
+	if c.Scope == nil {
+		return tally.NoopScope
+	}
+	return c.Scope
+}
+
+// parseGithubTokenExpiration parses the value of the
+// GitHub-Authentication-Token-Expiration header, ex. "2024-03-09 14:41:54 UTC".
+func parseGithubTokenExpiration(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse github token expiration %q", value)
 }
 
 // GithubAppCredentials implements GithubCredentials for github app installation token flow.
@@ -110,8 +246,13 @@ type GithubAppCredentials struct {
 	Hostname       string
 	apiURL         *url.URL
 	InstallationID int64
-	tr             *ghinstallation.Transport
 	AppSlug        string
+	// Scope is the scope the installation token metrics are reported to. If
+	// nil, they aren't reported.
+	Scope tally.Scope
+
+	mutex  sync.Mutex
+	tokens *GithubAppTokenManager
 }
 
 // Client returns a github app installation client.
@@ -151,15 +292,40 @@ func (c *GithubAppCredentials) GetUser() (string, error) {
 
 // GetToken returns a fresh installation token.
 func (c *GithubAppCredentials) GetToken() (string, error) {
-	tr, err := c.transport()
+	tokens, err := c.TokenManager()
 	if err != nil {
 		return "", errors.Wrap(err, "transport failed")
 	}
+	installationID, err := c.getInstallationID()
+	if err != nil {
+		return "", err
+	}
+	return tokens.Token(installationID)
+}
 
-	return tr.Token(context.Background())
+// TokenManager returns the manager that caches the installation tokens of
+// these credentials.
+func (c *GithubAppCredentials) TokenManager() (*GithubAppTokenManager, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.tokens != nil {
+		return c.tokens, nil
+	}
+	scope := c.Scope
+	if scope == nil {
+		scope = tally.NoopScope
+	}
+	tokens, err := NewGithubAppTokenManager(c.AppID, c.Key, c.Hostname, scope)
+	if err != nil {
+		return nil, err
+	}
+	c.tokens = tokens
+	return tokens, nil
 }
 
 func (c *GithubAppCredentials) getInstallationID() (int64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	if c.InstallationID != 0 {
 		return c.InstallationID, nil
 	}
@@ -190,24 +356,16 @@ func (c *GithubAppCredentials) getInstallationID() (int64, error) {
 	return c.InstallationID, nil
 }
 
-func (c *GithubAppCredentials) transport() (*ghinstallation.Transport, error) {
-	if c.tr != nil {
-		return c.tr, nil
+func (c *GithubAppCredentials) transport() (http.RoundTripper, error) {
+	tokens, err := c.TokenManager()
+	if err != nil {
+		return nil, err
 	}
-
 	installationID, err := c.getInstallationID()
 	if err != nil {
 		return nil, err
 	}
-
-	tr := http.DefaultTransport
-	itr, err := ghinstallation.New(tr, c.AppID, installationID, c.Key)
-	if err == nil {
-		apiURL := c.getAPIURL()
-		itr.BaseURL = strings.TrimSuffix(apiURL.String(), "/")
-		c.tr = itr
-	}
-	return itr, err
+	return tokens.Transport(installationID), nil
 }
 
 func (c *GithubAppCredentials) getAPIURL() *url.URL {
@@ -1,12 +1,21 @@
 package vcs_test
 
 import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/testdata"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
 )
 
 func TestGithubClient_GetUser_AppSlug(t *testing.T) {
@@ -106,3 +115,127 @@ func TestGithubClient_MultipleAppAuthentication(t *testing.T) {
 		t.Errorf("app token was not cached: %q != %q", token, newToken)
 	}
 }
+
+func TestGithubUserCredentials_GetToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	Ok(t, os.WriteFile(tokenFile, []byte("github_pat_file\n"), 0600))
+	t.Setenv("ATLANTIS_TEST_GH_TOKEN", "github_pat_env")
+
+	cases := []struct {
+		description string
+		creds       *vcs.GithubUserCredentials
+		exp         string
+	}{
+		{
+			description: "token",
+			creds:       &vcs.GithubUserCredentials{Token: "github_pat_flag"},
+			exp:         "github_pat_flag",
+		},
+		{
+			description: "token file is trimmed",
+			creds:       &vcs.GithubUserCredentials{Token: "github_pat_flag", TokenFile: tokenFile},
+			exp:         "github_pat_file",
+		},
+		{
+			description: "token env",
+			creds:       &vcs.GithubUserCredentials{Token: "github_pat_flag", TokenEnv: "ATLANTIS_TEST_GH_TOKEN"},
+			exp:         "github_pat_env",
+		},
+		{
+			description: "unset token env",
+			creds:       &vcs.GithubUserCredentials{Token: "github_pat_flag", TokenEnv: "ATLANTIS_TEST_GH_TOKEN_UNSET"},
+			exp:         "github_pat_flag",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			token, err := c.creds.GetToken()
+			Ok(t, err)
+			Equals(t, c.exp, token)
+		})
+	}
+}
+
+// Requests that fail because the token was rotated while they were in flight
+// are retried with the new token.
+func TestGithubUserCredentials_RetriesWithRotatedToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	Ok(t, os.WriteFile(tokenFile, []byte("old"), 0600))
+
+	var passwords []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, password, _ := r.BasicAuth()
+		passwords = append(passwords, password)
+		body, err := io.ReadAll(r.Body)
+		Ok(t, err)
+		bodies = append(bodies, string(body))
+		if password != "new" {
+			// Rotate the token after the request used the old one.
+			Ok(t, os.WriteFile(tokenFile, []byte("new"), 0600))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	scope := tally.NewTestScope("", nil)
+	creds := &vcs.GithubUserCredentials{User: "user", TokenFile: tokenFile, Scope: scope}
+	client, err := creds.Client()
+	Ok(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("body"))
+	Ok(t, err)
+	resp, err := client.Do(req)
+	Ok(t, err)
+	resp.Body.Close() // nolint: errcheck
+
+	Equals(t, http.StatusOK, resp.StatusCode)
+	Equals(t, []string{"old", "new"}, passwords)
+	Equals(t, []string{"body", "body"}, bodies)
+	Equals(t, int64(1), scope.Snapshot().Counters()["auth_failures+"].Value())
+	Equals(t, int64(1), scope.Snapshot().Counters()["retries+"].Value())
+}
+
+// Requests aren't retried if the token is still the one that failed.
+func TestGithubUserCredentials_NoRetryWithSameToken(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	creds := &vcs.GithubUserCredentials{User: "user", Token: "revoked"}
+	client, err := creds.Client()
+	Ok(t, err)
+
+	resp, err := client.Get(server.URL)
+	Ok(t, err)
+	resp.Body.Close() // nolint: errcheck
+
+	Equals(t, http.StatusUnauthorized, resp.StatusCode)
+	Equals(t, 1, requests)
+}
+
+func TestGithubUserCredentials_ReportsExpiration(t *testing.T) {
+	expiresAt := time.Now().Add(48 * time.Hour).UTC()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("GitHub-Authentication-Token-Expiration", expiresAt.Format("2006-01-02 15:04:05 MST"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	scope := tally.NewTestScope("", nil)
+	creds := &vcs.GithubUserCredentials{User: "user", Token: "github_pat_token", Scope: scope, Logger: logging.NewNoopLogger(t)}
+	client, err := creds.Client()
+	Ok(t, err)
+
+	resp, err := client.Get(server.URL)
+	Ok(t, err)
+	resp.Body.Close() // nolint: errcheck
+
+	expiresIn := scope.Snapshot().Gauges()["expires_in_seconds+"].Value()
+	Assert(t, expiresIn > (47*time.Hour).Seconds() && expiresIn <= (48*time.Hour).Seconds(), fmt.Sprintf("unexpected expires_in_seconds %f", expiresIn))
+}
@@ -0,0 +1,252 @@
+package vcs
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// githubResponseCacheMaxEntries is how many responses are cached before
+	// old ones are evicted.
+	githubResponseCacheMaxEntries = 1000
+	// githubResponseCacheMaxAge is how long a cached response is kept for
+	// revalidation after it was last used.
+	githubResponseCacheMaxAge = time.Hour
+	// githubTeamNamesCacheMaxTTL is the longest the teams of a user are
+	// cached for, whatever the TTL of the response cache is, since they're
+	// used to authorize commands and can't be revalidated.
+	githubTeamNamesCacheMaxTTL = time.Minute
+)
+
+// githubCacheablePath is the path of a GitHub REST read that's cached.
+type githubCacheablePath struct {
+	re *regexp.Regexp
+	// revalidate is true if cached responses are always revalidated rather
+	// than used for the TTL without sending a request.
+	revalidate bool
+}
+
+// githubCacheablePaths are the paths of the GitHub REST reads that are cached.
+// These are read many times for every command, ex. the changed files of a pull
+// request are listed to find its projects and again by every autoplan and
+// apply check.
+var githubCacheablePaths = []githubCacheablePath{
+	// Pull request metadata. Whether a pull request is mergeable or approved
+	// and its head commit are checked before applies so they must never be
+	// stale.
+	{re: regexp.MustCompile(`^(/api/v3)?/repos/[^/]+/[^/]+/pulls/\d+$`), revalidate: true},
+	// The changed files of a pull request.
+	{re: regexp.MustCompile(`^(/api/v3)?/repos/[^/]+/[^/]+/pulls/\d+/files$`)},
+}
+
+// githubRepoPath matches the path prefix of the endpoints of a repo.
+var githubRepoPath = regexp.MustCompile(`^(/api/v3)?/repos/[^/]+/[^/]+(/|$)`)
+
+// githubResponseCache is a transport that caches the responses of hot GitHub
+// REST reads. Cached responses are used for ttl without sending a request,
+// except for those of paths that are always revalidated. After that, they're
+// revalidated with a conditional request using their
+// ETag, and reused if GitHub responds that they haven't changed. Conditional
+// requests that return 304 Not Modified don't count against the rate limit.
+// Any write to a repo drops the cached responses of that repo.
+type githubResponseCache struct {
+	base http.RoundTripper
+	ttl  time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]*githubCachedResponse
+}
+
+type githubCachedResponse struct {
+	etag     string
+	header   http.Header
+	body     []byte
+	storedAt time.Time
+	usedAt   time.Time
+}
+
+func newGithubResponseCache(base http.RoundTripper, ttl time.Duration) *githubResponseCache {
+	return &githubResponseCache{
+		base:    base,
+		ttl:     ttl,
+		entries: make(map[string]*githubCachedResponse),
+	}
+}
+
+func (c *githubResponseCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		c.invalidate(req.URL.Path)
+		return c.base.RoundTrip(req)
+	}
+	path, ok := githubCacheablePathOf(req.URL.Path)
+	if !ok {
+		return c.base.RoundTrip(req)
+	}
+
+	key := req.URL.String() + "\n" + req.Header.Get("Accept")
+	entry, fresh := c.get(key)
+	if fresh && !path.revalidate {
+		return entry.response(req), nil
+	}
+
+	outReq := req
+	if entry != nil && entry.etag != "" {
+		outReq = req.Clone(req.Context())
+		outReq.Header.Set("If-None-Match", entry.etag)
+	}
+	resp, err := c.base.RoundTrip(outReq)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close() // nolint: errcheck
+		c.mutex.Lock()
+		entry.storedAt = time.Now()
+		c.mutex.Unlock()
+		return entry.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		c.delete(key)
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close() // nolint: errcheck
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	c.put(key, &githubCachedResponse{
+		etag:     resp.Header.Get("ETag"),
+		header:   resp.Header.Clone(),
+		body:     body,
+		storedAt: time.Now(),
+	})
+	return resp, nil
+}
+
+// response returns a copy of the cached response for req.
+func (e *githubCachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// get returns the cached response for key, if any, and whether it can be used
+// without revalidating it.
+func (c *githubResponseCache) get(key string) (*githubCachedResponse, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry.usedAt = time.Now()
+	return entry, time.Since(entry.storedAt) < c.ttl
+}
+
+func (c *githubResponseCache) put(key string, entry *githubCachedResponse) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry.usedAt = entry.storedAt
+	c.entries[key] = entry
+	if len(c.entries) <= githubResponseCacheMaxEntries {
+		return
+	}
+	for k, e := range c.entries {
+		if time.Since(e.usedAt) > githubResponseCacheMaxAge {
+			delete(c.entries, k)
+		}
+	}
+	if len(c.entries) > githubResponseCacheMaxEntries {
+		c.entries = map[string]*githubCachedResponse{key: entry}
+	}
+}
+
+func (c *githubResponseCache) delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, key)
+}
+
+// invalidate drops the cached responses of the repo that path belongs to.
+func (c *githubResponseCache) invalidate(path string) {
+	prefix := githubRepoPath.FindString(path)
+	if prefix == "" {
+		return
+	}
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key := range c.entries {
+		if strings.Contains(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// githubCacheablePathOf returns the cacheable path that path matches, if any.
+func githubCacheablePathOf(path string) (githubCacheablePath, bool) {
+	for _, p := range githubCacheablePaths {
+		if p.re.MatchString(path) {
+			return p, true
+		}
+	}
+	return githubCacheablePath{}, false
+}
+
+// githubTeamNamesCache caches the teams of users for ttl, which is at most
+// githubTeamNamesCacheMaxTTL. Teams are read with GraphQL which doesn't
+// support conditional requests, so cached teams are used until they expire.
+type githubTeamNamesCache struct {
+	ttl time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]githubTeamNamesEntry
+}
+
+type githubTeamNamesEntry struct {
+	teamNames []string
+	storedAt  time.Time
+}
+
+func newGithubTeamNamesCache(ttl time.Duration) *githubTeamNamesCache {
+	return &githubTeamNamesCache{
+		ttl:     min(ttl, githubTeamNamesCacheMaxTTL),
+		entries: make(map[string]githubTeamNamesEntry),
+	}
+}
+
+func (c *githubTeamNamesCache) Get(org string, user string) ([]string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[org+"/"+user]
+	if !ok || time.Since(entry.storedAt) >= c.ttl {
+		return nil, false
+	}
+	return entry.teamNames, true
+}
+
+func (c *githubTeamNamesCache) Set(org string, user string, teamNames []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key, entry := range c.entries {
+		if time.Since(entry.storedAt) >= c.ttl {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[org+"/"+user] = githubTeamNamesEntry{teamNames: teamNames, storedAt: time.Now()}
+}
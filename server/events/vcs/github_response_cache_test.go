@@ -0,0 +1,125 @@
+package vcs
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// githubCacheTestServer returns a server that responds with the body of the
+// path it's at and an ETag of version, and counts the requests and
+// conditional requests that it got.
+func githubCacheTestServer(t *testing.T, version *int32) (*httptest.Server, *int32, *int32) {
+	var requests, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		etag := fmt.Sprintf(`"v%d"`, atomic.LoadInt32(version))
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, "%s %s", r.URL.Path, etag)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests, &notModified
+}
+
+func githubCacheGet(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	Ok(t, err)
+	defer resp.Body.Close() // nolint: errcheck
+	Equals(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	Ok(t, err)
+	return string(body)
+}
+
+func TestGithubResponseCache(t *testing.T) {
+	version := int32(1)
+	server, requests, notModified := githubCacheTestServer(t, &version)
+	cache := newGithubResponseCache(http.DefaultTransport, time.Hour)
+	client := &http.Client{Transport: cache}
+	filesURL := server.URL + "/repos/owner/repo/pulls/1/files?page=1"
+
+	// Fresh responses are used without sending a request.
+	Equals(t, `/repos/owner/repo/pulls/1/files "v1"`, githubCacheGet(t, client, filesURL))
+	Equals(t, `/repos/owner/repo/pulls/1/files "v1"`, githubCacheGet(t, client, filesURL))
+	Equals(t, int32(1), atomic.LoadInt32(requests))
+
+	// Pull request metadata is always revalidated.
+	pullURL := server.URL + "/repos/owner/repo/pulls/1"
+	Equals(t, `/repos/owner/repo/pulls/1 "v1"`, githubCacheGet(t, client, pullURL))
+	Equals(t, `/repos/owner/repo/pulls/1 "v1"`, githubCacheGet(t, client, pullURL))
+	Equals(t, int32(3), atomic.LoadInt32(requests))
+	Equals(t, int32(1), atomic.LoadInt32(notModified))
+	atomic.StoreInt32(&version, 2)
+	Equals(t, `/repos/owner/repo/pulls/1 "v2"`, githubCacheGet(t, client, pullURL))
+	Equals(t, int32(4), atomic.LoadInt32(requests))
+	atomic.StoreInt32(&version, 1)
+
+	// Reads that aren't hot aren't cached.
+	githubCacheGet(t, client, server.URL+"/repos/owner/repo/issues/1/comments")
+	githubCacheGet(t, client, server.URL+"/repos/owner/repo/issues/1/comments")
+	Equals(t, int32(6), atomic.LoadInt32(requests))
+
+	// Expired responses are revalidated and reused if they haven't changed.
+	cache.ttl = 0
+	Equals(t, `/repos/owner/repo/pulls/1/files "v1"`, githubCacheGet(t, client, filesURL))
+	Equals(t, int32(7), atomic.LoadInt32(requests))
+	Equals(t, int32(2), atomic.LoadInt32(notModified))
+
+	// And replaced if they have.
+	atomic.StoreInt32(&version, 2)
+	Equals(t, `/repos/owner/repo/pulls/1/files "v2"`, githubCacheGet(t, client, filesURL))
+	Equals(t, int32(8), atomic.LoadInt32(requests))
+	Equals(t, int32(2), atomic.LoadInt32(notModified))
+}
+
+func TestGithubResponseCache_Invalidate(t *testing.T) {
+	version := int32(1)
+	server, requests, _ := githubCacheTestServer(t, &version)
+	cache := newGithubResponseCache(http.DefaultTransport, time.Hour)
+	client := &http.Client{Transport: cache}
+	pullURL := server.URL + "/repos/owner/repo/pulls/1/files"
+	otherPullURL := server.URL + "/repos/owner/other/pulls/1/files"
+
+	githubCacheGet(t, client, pullURL)
+	githubCacheGet(t, client, otherPullURL)
+	Equals(t, int32(2), atomic.LoadInt32(requests))
+
+	// A write to a repo drops the cached responses of that repo only.
+	resp, err := client.Post(server.URL+"/repos/owner/repo/issues/1/comments", "application/json", nil)
+	Ok(t, err)
+	resp.Body.Close() // nolint: errcheck
+	githubCacheGet(t, client, pullURL)
+	githubCacheGet(t, client, otherPullURL)
+	Equals(t, int32(4), atomic.LoadInt32(requests))
+}
+
+func TestGithubTeamNamesCache(t *testing.T) {
+	cache := newGithubTeamNamesCache(time.Hour)
+	// Teams authorize commands so they're never cached for long.
+	Equals(t, githubTeamNamesCacheMaxTTL, cache.ttl)
+	_, ok := cache.Get("org", "user")
+	Assert(t, !ok, "expected no cached teams")
+
+	cache.Set("org", "user", []string{"team"})
+	teamNames, ok := cache.Get("org", "user")
+	Assert(t, ok, "expected cached teams")
+	Equals(t, []string{"team"}, teamNames)
+	_, ok = cache.Get("other-org", "user")
+	Assert(t, !ok, "expected no cached teams for another org")
+
+	cache.ttl = 0
+	_, ok = cache.Get("org", "user")
+	Assert(t, !ok, "expected expired teams not to be used")
+}
@@ -205,28 +205,9 @@ func (g *GitlabClient) ReactToComment(logger logging.SimpleLogging, repo models.
 
 func (g *GitlabClient) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
 	logger.Debug("Hiding previous command comments on GitLab merge request %d", pullNum)
-	var allComments []*gitlab.Note
-
-	nextPage := 0
-	for {
-		logger.Debug("/projects/%v/merge_requests/%d/notes", repo.FullName, pullNum)
-		comments, resp, err := g.Client.Notes.ListMergeRequestNotes(repo.FullName, pullNum,
-			&gitlab.ListMergeRequestNotesOptions{
-				Sort:        gitlab.Ptr("asc"),
-				OrderBy:     gitlab.Ptr("created_at"),
-				ListOptions: gitlab.ListOptions{Page: nextPage},
-			})
-		if resp != nil {
-			logger.Debug("GET /projects/%s/merge_requests/%d/notes returned: %d", repo.FullName, pullNum, resp.StatusCode)
-		}
-		if err != nil {
-			return errors.Wrap(err, "listing comments")
-		}
-		allComments = append(allComments, comments...)
-		if resp.NextPage == 0 {
-			break
-		}
-		nextPage = resp.NextPage
+	allComments, err := g.listNotes(logger, repo, pullNum)
+	if err != nil {
+		return err
 	}
 
 	currentUser, _, err := g.Client.Users.CurrentUser()
@@ -234,7 +215,7 @@ func (g *GitlabClient) HidePrevCommandComments(logger logging.SimpleLogging, rep
 		return errors.Wrap(err, "error getting currentuser")
 	}
 
-	summaryHeader := fmt.Sprintf("<!--- +-Superseded Command-+ ---><details><summary>Superseded Atlantis %s</summary>", command)
+	summaryHeader := fmt.Sprintf("%s<details><summary>Superseded Atlantis %s</summary>", supersededCommentMarker, command)
 	summaryFooter := "</details>"
 	lineFeed := "\n"
 
@@ -274,6 +255,82 @@ func (g *GitlabClient) HidePrevCommandComments(logger logging.SimpleLogging, rep
 	return nil
 }
 
+// supersededCommentMarker starts the comments that were collapsed since
+// they're outdated.
+const supersededCommentMarker = "<!--- +-Superseded Command-+ --->"
+
+// CleanUpComments deletes the notes made by Atlantis on the merge request or,
+// if collapse is true, collapses them.
+func (g *GitlabClient) CleanUpComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, collapse bool) (int, error) {
+	logger.Debug("Cleaning up comments on GitLab merge request %d", pullNum)
+	allComments, err := g.listNotes(logger, repo, pullNum)
+	if err != nil {
+		return 0, err
+	}
+	currentUser, _, err := g.Client.Users.CurrentUser()
+	if err != nil {
+		return 0, errors.Wrap(err, "error getting currentuser")
+	}
+
+	cleanedUp := 0
+	for _, comment := range allComments {
+		if comment.System || !strings.EqualFold(comment.Author.Username, currentUser.Username) {
+			continue
+		}
+		if collapse {
+			if strings.HasPrefix(comment.Body, supersededCommentMarker) {
+				continue
+			}
+			collapsedComment := supersededCommentMarker + "<details><summary>Outdated Atlantis comment</summary>\n" + comment.Body + "\n</details>\n"
+			_, resp, err := g.Client.Notes.UpdateMergeRequestNote(repo.FullName, pullNum, comment.ID, &gitlab.UpdateMergeRequestNoteOptions{Body: &collapsedComment})
+			if resp != nil {
+				logger.Debug("PUT /projects/%s/merge_requests/%d/notes/%d returned: %d", repo.FullName, pullNum, comment.ID, resp.StatusCode)
+			}
+			if err != nil {
+				return cleanedUp, errors.Wrapf(err, "updating comment %d", comment.ID)
+			}
+			cleanedUp++
+			continue
+		}
+		resp, err := g.Client.Notes.DeleteMergeRequestNote(repo.FullName, pullNum, comment.ID)
+		if resp != nil {
+			logger.Debug("DELETE /projects/%s/merge_requests/%d/notes/%d returned: %d", repo.FullName, pullNum, comment.ID, resp.StatusCode)
+		}
+		if err != nil {
+			return cleanedUp, errors.Wrapf(err, "deleting comment %d", comment.ID)
+		}
+		cleanedUp++
+	}
+	return cleanedUp, nil
+}
+
+// listNotes returns the notes on the merge request, oldest first.
+func (g *GitlabClient) listNotes(logger logging.SimpleLogging, repo models.Repo, pullNum int) ([]*gitlab.Note, error) {
+	var allComments []*gitlab.Note
+	nextPage := 0
+	for {
+		logger.Debug("/projects/%v/merge_requests/%d/notes", repo.FullName, pullNum)
+		comments, resp, err := g.Client.Notes.ListMergeRequestNotes(repo.FullName, pullNum,
+			&gitlab.ListMergeRequestNotesOptions{
+				Sort:        gitlab.Ptr("asc"),
+				OrderBy:     gitlab.Ptr("created_at"),
+				ListOptions: gitlab.ListOptions{Page: nextPage},
+			})
+		if resp != nil {
+			logger.Debug("GET /projects/%s/merge_requests/%d/notes returned: %d", repo.FullName, pullNum, resp.StatusCode)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "listing comments")
+		}
+		allComments = append(allComments, comments...)
+		if resp.NextPage == 0 {
+			break
+		}
+		nextPage = resp.NextPage
+	}
+	return allComments, nil
+}
+
 // PullIsApproved returns true if the merge request was approved.
 func (g *GitlabClient) PullIsApproved(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (approvalStatus models.ApprovalStatus, err error) {
 	logger.Debug("Checking if GitLab merge request %d is approved", pull.Num)
@@ -284,12 +341,13 @@ func (g *GitlabClient) PullIsApproved(logger logging.SimpleLogging, repo models.
 	if err != nil {
 		return approvalStatus, err
 	}
-	if approvals.ApprovalsLeft > 0 {
-		return approvalStatus, nil
+	for _, approver := range approvals.ApprovedBy {
+		if approver != nil && approver.User != nil {
+			approvalStatus.Approvers = append(approvalStatus.Approvers, approver.User.Username)
+		}
 	}
-	return models.ApprovalStatus{
-		IsApproved: true,
-	}, nil
+	approvalStatus.IsApproved = approvals.ApprovalsLeft <= 0
+	return approvalStatus, nil
 }
 
 // PullIsMergeable returns true if the merge request can be merged.
@@ -315,11 +373,16 @@ func (g *GitlabClient) PullIsMergeable(logger logging.SimpleLogging, repo models
 
 	// Prevent nil pointer error when mr.HeadPipeline is empty
 	// See: https://github.com/runatlantis/atlantis/issues/1852
-	commit := pull.HeadCommit
+	commits := []string{pull.HeadCommit}
 	isPipelineSkipped := false
 	if mr.HeadPipeline != nil {
-		commit = mr.HeadPipeline.SHA
+		commits = []string{mr.HeadPipeline.SHA}
 		isPipelineSkipped = mr.HeadPipeline.Status == "skipped"
+		// Merged results pipelines, ex. of merge trains, run on a merge
+		// commit so our statuses are only on the head commit.
+		if mergedResultsPipeline(mr) != nil && mr.HeadPipeline.SHA != pull.HeadCommit {
+			commits = append(commits, pull.HeadCommit)
+		}
 	}
 
 	// Get project configuration
@@ -332,21 +395,23 @@ func (g *GitlabClient) PullIsMergeable(logger logging.SimpleLogging, repo models
 	}
 
 	// Get Commit Statuses
-	statuses, _, err := g.Client.Commits.GetCommitStatuses(mr.ProjectID, commit, nil)
-	if resp != nil {
-		logger.Debug("GET /projects/%d/commits/%s/statuses returned: %d", mr.ProjectID, commit, resp.StatusCode)
-	}
-	if err != nil {
-		return false, err
-	}
-
-	for _, status := range statuses {
-		// Ignore any commit statuses with 'atlantis/apply' as prefix
-		if strings.HasPrefix(status.Name, fmt.Sprintf("%s/%s", vcsstatusname, command.Apply.String())) {
-			continue
+	for _, commit := range commits {
+		statuses, resp, err := g.Client.Commits.GetCommitStatuses(mr.ProjectID, commit, nil)
+		if resp != nil {
+			logger.Debug("GET /projects/%d/commits/%s/statuses returned: %d", mr.ProjectID, commit, resp.StatusCode)
 		}
-		if !status.AllowFailure && project.OnlyAllowMergeIfPipelineSucceeds && status.Status != "success" {
-			return false, nil
+		if err != nil {
+			return false, err
+		}
+
+		for _, status := range statuses {
+			// Ignore any commit statuses with 'atlantis/apply' as prefix
+			if strings.HasPrefix(status.Name, fmt.Sprintf("%s/%s", vcsstatusname, command.Apply.String())) {
+				continue
+			}
+			if !status.AllowFailure && project.OnlyAllowMergeIfPipelineSucceeds && status.Status != "success" {
+				return false, nil
+			}
 		}
 	}
 
@@ -375,6 +440,17 @@ func (g *GitlabClient) PullIsMergeable(logger logging.SimpleLogging, repo models
 		!mr.WorkInProgress &&
 		(allowSkippedPipeline || !isPipelineSkipped) {
 
+		// approvals_before_merge is deprecated and doesn't account for
+		// approval rules, and the merge status of GitLab versions without
+		// detailed merge statuses doesn't either.
+		approved, err := g.approvalRulesApproved(logger, repo, pull)
+		if err != nil {
+			return false, err
+		}
+		if !approved {
+			logger.Debug("Merge request is not mergeable since its approval rules aren't satisfied")
+			return false, nil
+		}
 		logger.Debug("Merge request is mergeable")
 		return true, nil
 	}
@@ -382,6 +458,44 @@ func (g *GitlabClient) PullIsMergeable(logger logging.SimpleLogging, repo models
 	return false, nil
 }
 
+// approvalRulesApproved returns true if all approval rules of the merge
+// request, ex. a rule requiring two approvals from a code owner group, are
+// approved. Approval rules are a GitLab Premium feature so it also returns
+// true if GitLab doesn't support them.
+func (g *GitlabClient) approvalRulesApproved(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (bool, error) {
+	state, resp, err := g.Client.MergeRequestApprovals.GetApprovalState(repo.FullName, pull.Num)
+	if resp != nil {
+		logger.Debug("GET /projects/%s/merge_requests/%d/approval_state returned: %d", repo.FullName, pull.Num, resp.StatusCode)
+		if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound {
+			return true, nil
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, rule := range state.Rules {
+		if rule != nil && !rule.Approved {
+			logger.Debug("Approval rule %q needs %d approvals but has %d", rule.Name, rule.ApprovalsRequired, len(rule.ApprovedBy))
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// mergedResultsPipeline returns the head pipeline of mr if it runs on the
+// result of merging mr into its target branch, which is the case with merged
+// results pipelines and merge trains, and nil otherwise.
+func mergedResultsPipeline(mr *gitlab.MergeRequest) *gitlab.Pipeline {
+	if mr.HeadPipeline == nil {
+		return nil
+	}
+	ref := fmt.Sprintf("refs/merge-requests/%d/", mr.IID)
+	if mr.HeadPipeline.Ref == ref+"merge" || mr.HeadPipeline.Ref == ref+"train" {
+		return mr.HeadPipeline
+	}
+	return nil
+}
+
 func (g *GitlabClient) SupportsDetailedMergeStatus(logger logging.SimpleLogging) (bool, error) {
 	logger.Debug("Checking if GitLab supports detailed merge status")
 	v, err := g.GetVersion(logger)
@@ -448,12 +562,40 @@ func (g *GitlabClient) UpdateStatus(logger logging.SimpleLogging, repo models.Re
 		}
 	}
 
+	if err := g.setCommitStatus(logger, repo, pull.HeadCommit, commit.ShortID, src, state, setCommitStatusOptions); err != nil {
+		return err
+	}
+
+	// Merged results pipelines, ex. of merge trains, run on a merge commit
+	// rather than the head commit, so GitLab only waits for our statuses
+	// before merging if they're also set on that pipeline.
+	mr, err := g.GetMergeRequest(logger, repo.FullName, pull.Num)
+	if err != nil {
+		logger.Warn("unable to get merge request to update the status of its merged results pipeline: %s", err)
+		return nil
+	}
+	pipeline := mergedResultsPipeline(mr)
+	if pipeline == nil || pipeline.SHA == pull.HeadCommit {
+		return nil
+	}
+	logger.Info("Updating GitLab commit status for '%s' of merged results pipeline %d", src, pipeline.ID)
+	mergedResultsOptions := *setCommitStatusOptions
+	mergedResultsOptions.PipelineID = gitlab.Ptr(pipeline.ID)
+	mergedResultsOptions.Ref = nil
+	return g.setCommitStatus(logger, repo, pipeline.SHA, pipeline.SHA, src, state, &mergedResultsOptions)
+}
+
+// setCommitStatus sets the status of the commit with sha, retrying if GitLab
+// reports a conflict with another update of the status.
+func (g *GitlabClient) setCommitStatus(logger logging.SimpleLogging, repo models.Repo, sha string, shortID string, src string, state models.CommitStatus, setCommitStatusOptions *gitlab.SetCommitStatusOptions) error {
 	var (
 		maxAttempts = 10
 		retryer     = &backoff.Backoff{
 			Jitter: true,
 			Max:    g.PollingInterval,
 		}
+		resp *gitlab.Response
+		err  error
 	)
 
 	for i := 0; i < maxAttempts; i++ {
@@ -461,14 +603,14 @@ func (g *GitlabClient) UpdateStatus(logger logging.SimpleLogging, repo models.Re
 			"attempt", i+1,
 			"max_attempts", maxAttempts,
 			"repo", repo.FullName,
-			"commit", commit.ShortID,
+			"commit", shortID,
 			"state", state.String(),
 		)
 
-		_, resp, err = g.Client.Commits.SetCommitStatus(repo.FullName, pull.HeadCommit, setCommitStatusOptions)
+		_, resp, err = g.Client.Commits.SetCommitStatus(repo.FullName, sha, setCommitStatusOptions)
 
 		if resp != nil {
-			logger.Debug("POST /projects/%s/statuses/%s returned: %d", repo.FullName, pull.HeadCommit, resp.StatusCode)
+			logger.Debug("POST /projects/%s/statuses/%s returned: %d", repo.FullName, sha, resp.StatusCode)
 
 			// GitLab returns a `409 Conflict` status when the commit pipeline status is being changed/locked by another request,
 			// which is likely to happen if you use [`--parallel-pool-size > 1`] and [`parallel-plan|apply`].
@@ -500,7 +642,7 @@ func (g *GitlabClient) UpdateStatus(logger logging.SimpleLogging, repo models.Re
 	}
 
 	// If we got here, we've exhausted all attempts to update the commit status and still failed, so return the error upstream
-	return errors.Wrap(err, fmt.Sprintf("failed to update commit status for '%s' @ '%s' to '%s' after %d attempts", repo.FullName, pull.HeadCommit, src, maxAttempts))
+	return errors.Wrap(err, fmt.Sprintf("failed to update commit status for '%s' @ '%s' to '%s' after %d attempts", repo.FullName, sha, src, maxAttempts))
 }
 
 func (g *GitlabClient) GetMergeRequest(logger logging.SimpleLogging, repoFullName string, pullNum int) (*gitlab.MergeRequest, error) {
@@ -512,6 +654,56 @@ func (g *GitlabClient) GetMergeRequest(logger logging.SimpleLogging, repoFullNam
 	return mr, err
 }
 
+// GetPipelineJobs returns the jobs of the latest pipeline for the commit sha
+// of repo, without retried jobs. It returns no jobs if no pipeline was created
+// for the commit yet.
+func (g *GitlabClient) GetPipelineJobs(logger logging.SimpleLogging, repo models.Repo, sha string) ([]*gitlab.Job, error) {
+	logger.Debug("Getting GitLab pipeline jobs for commit %s", sha)
+	pipelines, resp, err := g.Client.Pipelines.ListProjectPipelines(repo.FullName, &gitlab.ListProjectPipelinesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 1},
+		SHA:         gitlab.Ptr(sha),
+		OrderBy:     gitlab.Ptr("id"),
+		Sort:        gitlab.Ptr("desc"),
+	})
+	if resp != nil {
+		logger.Debug("GET /projects/%s/pipelines returned: %d", repo.FullName, resp.StatusCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(pipelines) == 0 {
+		return nil, nil
+	}
+
+	var jobs []*gitlab.Job
+	opts := &gitlab.ListJobsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := g.Client.Jobs.ListPipelineJobs(repo.FullName, pipelines[0].ID, opts)
+		if resp != nil {
+			logger.Debug("GET /projects/%s/pipelines/%d/jobs returned: %d", repo.FullName, pipelines[0].ID, resp.StatusCode)
+		}
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return jobs, nil
+}
+
+// PlayJob starts the manual job with jobID of repo.
+func (g *GitlabClient) PlayJob(logger logging.SimpleLogging, repo models.Repo, jobID int) error {
+	logger.Debug("Playing GitLab job %d", jobID)
+	_, resp, err := g.Client.Jobs.PlayJob(repo.FullName, jobID, nil)
+	if resp != nil {
+		logger.Debug("POST /projects/%s/jobs/%d/play returned: %d", repo.FullName, jobID, resp.StatusCode)
+	}
+	return err
+}
+
 func (g *GitlabClient) WaitForSuccessPipeline(logger logging.SimpleLogging, ctx context.Context, pull models.PullRequest) {
 	logger.Debug("Waiting for GitLab success pipeline for merge request %d", pull.Num)
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -554,6 +746,13 @@ func (g *GitlabClient) MergePull(logger logging.SimpleLogging, pull models.PullR
 			err, "unable to merge merge request, it was not possible to check the project requirements")
 	}
 
+	// Merge requests of projects with merge trains can only be merged by
+	// adding them to the merge train, which merges them once the merged
+	// results pipeline succeeds.
+	if project != nil && project.MergeTrainsEnabled {
+		return g.addToMergeTrain(logger, pull, mr, pullOptions)
+	}
+
 	if project != nil && project.OnlyAllowMergeIfPipelineSucceeds {
 		g.WaitForSuccessPipeline(logger, context.Background(), pull)
 	}
@@ -571,6 +770,33 @@ func (g *GitlabClient) MergePull(logger logging.SimpleLogging, pull models.PullR
 	return errors.Wrap(err, "unable to merge merge request, it may not be in a mergeable state")
 }
 
+// addToMergeTrain adds the merge request to the merge train of its target
+// branch.
+func (g *GitlabClient) addToMergeTrain(logger logging.SimpleLogging, pull models.PullRequest, mr *gitlab.MergeRequest, pullOptions models.PullRequestOptions) error {
+	// The merge train merges with the settings of the merge request so the
+	// source branch is deleted by setting it on the merge request.
+	if pullOptions.DeleteSourceBranchOnMerge && !mr.ForceRemoveSourceBranch {
+		_, resp, err := g.Client.MergeRequests.UpdateMergeRequest(pull.BaseRepo.FullName, pull.Num, &gitlab.UpdateMergeRequestOptions{
+			RemoveSourceBranch: gitlab.Ptr(true),
+		})
+		if resp != nil {
+			logger.Debug("PUT /projects/%s/merge_requests/%d returned: %d", pull.BaseRepo.FullName, pull.Num, resp.StatusCode)
+		}
+		if err != nil {
+			return errors.Wrap(err, "unable to merge merge request, it was not possible to set it to delete its source branch")
+		}
+	}
+
+	_, resp, err := g.Client.MergeTrains.AddMergeRequestToMergeTrain(mr.ProjectID, pull.Num, &gitlab.AddMergeRequestToMergeTrainOptions{
+		WhenPipelineSucceeds: gitlab.Ptr(true),
+		SHA:                  gitlab.Ptr(mr.SHA),
+	})
+	if resp != nil {
+		logger.Debug("POST /projects/%d/merge_trains/merge_requests/%d returned: %d", mr.ProjectID, pull.Num, resp.StatusCode)
+	}
+	return errors.Wrap(err, "unable to add merge request to the merge train, it may not be in a mergeable state")
+}
+
 // MarkdownPullLink specifies the string used in a pull request comment to reference another pull request.
 func (g *GitlabClient) MarkdownPullLink(pull models.PullRequest) (string, error) {
 	return fmt.Sprintf("!%d", pull.Num), nil
@@ -630,19 +856,10 @@ func (g *GitlabClient) GetTeamNamesForUser(logger logging.SimpleLogging, _ model
 	logger.Debug("Getting GitLab group names for user '%s'", user)
 	var teamNames []string
 
-	users, resp, err := g.Client.Users.ListUsers(&gitlab.ListUsersOptions{Username: &user.Username})
-	if resp.StatusCode == http.StatusNotFound {
-		return teamNames, nil
-	}
-	if err != nil {
-		return nil, errors.Wrapf(err, "GET /users returned: %d", resp.StatusCode)
-	} else if len(users) == 0 {
-		return nil, errors.New("GET /users returned no user")
-	} else if len(users) > 1 {
-		// Theoretically impossible, just being extra safe
-		return nil, errors.New("GET /users returned more than 1 user")
+	userID, err := g.getUserID(user)
+	if err != nil || userID == 0 {
+		return teamNames, err
 	}
-	userID := users[0].ID
 	for _, groupName := range g.ConfiguredGroups {
 		membership, resp, err := g.Client.GroupMembers.GetGroupMember(groupName, userID)
 		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
@@ -658,6 +875,44 @@ func (g *GitlabClient) GetTeamNamesForUser(logger logging.SimpleLogging, _ model
 	return teamNames, nil
 }
 
+// IsOrgMember returns true if user is a member of the GitLab group org,
+// directly or through one of its ancestor groups.
+func (g *GitlabClient) IsOrgMember(logger logging.SimpleLogging, user models.User, org string) (bool, error) {
+	logger.Debug("Checking if GitLab user '%s' is a member of group '%s'", user.Username, org)
+	userID, err := g.getUserID(user)
+	if err != nil || userID == 0 {
+		return false, err
+	}
+	membership, resp, err := g.Client.GroupMembers.GetInheritedGroupMember(org, userID)
+	if resp != nil {
+		logger.Debug("GET /groups/%s/members/all/%d returned: %d", org, userID, resp.StatusCode)
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+			return false, nil
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return membership.State == "active", nil
+}
+
+// getUserID returns the ID of user. It returns 0 if there's no such user.
+func (g *GitlabClient) getUserID(user models.User) (int, error) {
+	users, resp, err := g.Client.Users.ListUsers(&gitlab.ListUsersOptions{Username: &user.Username})
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "GET /users returned: %d", resp.StatusCode)
+	} else if len(users) == 0 {
+		return 0, errors.New("GET /users returned no user")
+	} else if len(users) > 1 {
+		// Theoretically impossible, just being extra safe
+		return 0, errors.New("GET /users returned more than 1 user")
+	}
+	return users[0].ID, nil
+}
+
 // GetFileContent a repository file content from VCS (which support fetch a single file from repository)
 // The first return value indicates whether the repo contains a file or not
 // if BaseRepo had a file, its content will placed on the second return value
@@ -709,3 +964,131 @@ func (g *GitlabClient) GetPullLabels(logger logging.SimpleLogging, repo models.R
 
 	return mr.Labels, nil
 }
+
+// CreateIssue opens an issue in repo.
+func (g *GitlabClient) CreateIssue(logger logging.SimpleLogging, repo models.Repo, title string, body string) error {
+	logger.Debug("Creating GitLab issue in %s", repo.FullName)
+	_, resp, err := g.Client.Issues.CreateIssue(repo.FullName, &gitlab.CreateIssueOptions{
+		Title:       gitlab.Ptr(title),
+		Description: gitlab.Ptr(body),
+	})
+	if resp != nil {
+		logger.Debug("POST /projects/%s/issues returned: %d", repo.FullName, resp.StatusCode)
+	}
+	return err
+}
+
+// GetPullState returns whether the merge request is still open.
+func (g *GitlabClient) GetPullState(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.PullRequestState, error) {
+	mr, err := g.GetMergeRequest(logger, repo.FullName, pull.Num)
+	if err != nil {
+		return models.ClosedPullState, err
+	}
+	if mr.State == "opened" {
+		return models.OpenPullState, nil
+	}
+	return models.ClosedPullState, nil
+}
+
+// GetParentPull returns the IID of the open merge request of repo whose
+// source branch is the target branch of pull. It returns 0 if there's none.
+func (g *GitlabClient) GetParentPull(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (int, error) {
+	logger.Debug("Getting open GitLab merge request with source branch '%s'", pull.BaseBranch)
+	mrs, resp, err := g.Client.MergeRequests.ListProjectMergeRequests(repo.FullName, &gitlab.ListProjectMergeRequestsOptions{
+		State:        gitlab.Ptr("opened"),
+		SourceBranch: gitlab.Ptr(pull.BaseBranch),
+	})
+	if resp != nil {
+		logger.Debug("GET /projects/%s/merge_requests returned: %d", url.QueryEscape(repo.FullName), resp.StatusCode)
+	}
+	if err != nil {
+		return 0, err
+	}
+	for _, mr := range mrs {
+		// Merge requests from forks with the same branch name aren't parents.
+		if mr.IID != pull.Num && mr.SourceProjectID == mr.ProjectID {
+			return mr.IID, nil
+		}
+	}
+	return 0, nil
+}
+
+// MaxCommentLength returns the longest comment GitLab accepts.
+func (g *GitlabClient) MaxCommentLength() int {
+	return gitlabMaxCommentLength
+}
+
+// GetSummaryComments returns the bodies of the comments Atlantis made on the
+// merge request that start with marker.
+func (g *GitlabClient) GetSummaryComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, marker string) ([]string, error) {
+	notes, err := g.summaryNotes(logger, repo, pullNum, marker)
+	if err != nil {
+		return nil, err
+	}
+	var bodies []string
+	for _, note := range notes {
+		bodies = append(bodies, note.Body)
+	}
+	return bodies, nil
+}
+
+// SetSummaryComments replaces the comments Atlantis made on the merge request
+// that start with marker with bodies.
+func (g *GitlabClient) SetSummaryComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, marker string, bodies []string) error {
+	notes, err := g.summaryNotes(logger, repo, pullNum, marker)
+	if err != nil {
+		return err
+	}
+	for i := range bodies {
+		if i < len(notes) {
+			if notes[i].Body == bodies[i] {
+				continue
+			}
+			_, resp, err := g.Client.Notes.UpdateMergeRequestNote(repo.FullName, pullNum, notes[i].ID, &gitlab.UpdateMergeRequestNoteOptions{Body: &bodies[i]})
+			if resp != nil {
+				logger.Debug("PUT /projects/%s/merge_requests/%d/notes/%d returned: %d", repo.FullName, pullNum, notes[i].ID, resp.StatusCode)
+			}
+			if err != nil {
+				return errors.Wrapf(err, "updating comment %d", notes[i].ID)
+			}
+			continue
+		}
+		_, resp, err := g.Client.Notes.CreateMergeRequestNote(repo.FullName, pullNum, &gitlab.CreateMergeRequestNoteOptions{Body: gitlab.Ptr(bodies[i])})
+		if resp != nil {
+			logger.Debug("POST /projects/%s/merge_requests/%d/notes returned: %d", repo.FullName, pullNum, resp.StatusCode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, note := range notes[min(len(bodies), len(notes)):] {
+		resp, err := g.Client.Notes.DeleteMergeRequestNote(repo.FullName, pullNum, note.ID)
+		if resp != nil {
+			logger.Debug("DELETE /projects/%s/merge_requests/%d/notes/%d returned: %d", repo.FullName, pullNum, note.ID, resp.StatusCode)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "deleting comment %d", note.ID)
+		}
+	}
+	return nil
+}
+
+// summaryNotes returns the notes Atlantis made on the merge request that
+// start with marker, oldest first.
+func (g *GitlabClient) summaryNotes(logger logging.SimpleLogging, repo models.Repo, pullNum int, marker string) ([]*gitlab.Note, error) {
+	allNotes, err := g.listNotes(logger, repo, pullNum)
+	if err != nil {
+		return nil, err
+	}
+	currentUser, _, err := g.Client.Users.CurrentUser()
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting currentuser")
+	}
+	var notes []*gitlab.Note
+	for _, note := range allNotes {
+		if !note.System && strings.EqualFold(note.Author.Username, currentUser.Username) && strings.HasPrefix(note.Body, marker) {
+			notes = append(notes, note)
+		}
+	}
+	return notes, nil
+}
@@ -346,6 +346,9 @@ func TestGitlabClient_UpdateStatus(t *testing.T) {
 						_, err = w.Write(setStatusJsonResponse)
 						Ok(t, err)
 
+					case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1":
+						w.Write([]byte(`{"iid": 1}`)) // nolint: errcheck
+
 					case "/api/v4/projects/runatlantis%2Fatlantis/repository/commits/sha":
 						w.WriteHeader(http.StatusOK)
 
@@ -468,6 +471,9 @@ func TestGitlabClient_UpdateStatusGetCommitRetryable(t *testing.T) {
 						_, err = w.Write(getCommitJsonResponse)
 						Ok(t, err)
 
+					case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1":
+						w.Write([]byte(`{"iid": 1}`)) // nolint: errcheck
+
 					case "/api/v4/projects/runatlantis%2Fatlantis/repository/commits/sha":
 						handledNumberOfRequests++
 						noCommitLastPipeline := handledNumberOfRequests <= c.commitsWithNoLastPipeline
@@ -604,6 +610,9 @@ func TestGitlabClient_UpdateStatusSetCommitStatusConflictRetryable(t *testing.T)
 						_, err = w.Write(getCommitJsonResponse)
 						Ok(t, err)
 
+					case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1":
+						w.Write([]byte(`{"iid": 1}`)) // nolint: errcheck
+
 					case "/api/v4/projects/runatlantis%2Fatlantis/repository/commits/sha":
 						w.WriteHeader(http.StatusOK)
 
@@ -825,6 +834,9 @@ func TestGitlabClient_PullIsMergeable(t *testing.T) {
 						case fmt.Sprintf("/api/v4/projects/runatlantis%%2Fatlantis/merge_requests/%v", ciMustPassFailureMR):
 							w.WriteHeader(http.StatusOK)
 							w.Write(detailedMergeStatusCiMustPass) // nolint: errcheck
+						case fmt.Sprintf("/api/v4/projects/runatlantis%%2Fatlantis/merge_requests/%v/approval_state", c.mrID):
+							w.WriteHeader(http.StatusOK)
+							w.Write([]byte(`{"rules": []}`)) // nolint: errcheck
 						case fmt.Sprintf("/api/v4/projects/runatlantis%%2Fatlantis/merge_requests/%v", needRebaseMR):
 							w.WriteHeader(http.StatusOK)
 							w.Write(detailedMergeStatusNeedRebase) // nolint: errcheck
@@ -1076,6 +1088,40 @@ func TestGitlabClient_GetPullLabels(t *testing.T) {
 	Equals(t, []string{"work in progress"}, labels)
 }
 
+func TestGitlabClient_GetPipelineJobs(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.EscapedPath() {
+			case "/api/v4/projects/runatlantis%2Fatlantis/pipelines":
+				Equals(t, "sha", r.URL.Query().Get("sha"))
+				Equals(t, "desc", r.URL.Query().Get("sort"))
+				w.Write([]byte(`[{"id": 7, "sha": "sha", "status": "running"}]`)) // nolint: errcheck
+			case "/api/v4/projects/runatlantis%2Fatlantis/pipelines/7/jobs":
+				if r.URL.Query().Get("page") == "" {
+					w.Header().Set("X-Next-Page", "2")
+					w.Write([]byte(`[{"id": 1, "name": "lint", "status": "success"}]`)) // nolint: errcheck
+					return
+				}
+				w.Write([]byte(`[{"id": 2, "name": "scan", "status": "manual"}]`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	defer testServer.Close()
+
+	internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+	Ok(t, err)
+	client := &GitlabClient{Client: internalClient}
+
+	jobs, err := client.GetPipelineJobs(logger, models.Repo{FullName: "runatlantis/atlantis"}, "sha")
+	Ok(t, err)
+	Equals(t, 2, len(jobs))
+	Equals(t, "lint", jobs[0].Name)
+	Equals(t, "manual", jobs[1].Status)
+}
+
 func TestGitlabClient_GetPullLabels_EmptyResponse(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	pipelineSuccess, err := os.ReadFile("testdata/gitlab-pipeline-success.json")
@@ -1111,6 +1157,71 @@ func TestGitlabClient_GetPullLabels_EmptyResponse(t *testing.T) {
 	Equals(t, 0, len(labels))
 }
 
+func TestGitlabClient_IsOrgMember(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	groupMembershipSuccess, err := os.ReadFile("testdata/gitlab-group-membership-success.json")
+	Ok(t, err)
+	userSuccess, err := os.ReadFile("testdata/gitlab-user-success.json")
+	Ok(t, err)
+
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v4/users?username=testuser":
+				w.Write(userSuccess) // nolint: errcheck
+			case "/api/v4/groups/someorg/members/all/123":
+				w.Write(groupMembershipSuccess) // nolint: errcheck
+			case "/api/v4/groups/otherorg/members/all/123":
+				http.Error(w, "not found", http.StatusNotFound)
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	defer testServer.Close()
+	internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+	Ok(t, err)
+	client := &GitlabClient{Client: internalClient}
+
+	isMember, err := client.IsOrgMember(logger, models.User{Username: "testuser"}, "someorg")
+	Ok(t, err)
+	Equals(t, true, isMember)
+
+	isMember, err = client.IsOrgMember(logger, models.User{Username: "testuser"}, "otherorg")
+	Ok(t, err)
+	Equals(t, false, isMember)
+}
+
+func TestGitlabClient_GetParentPull(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests?source_branch=feature-a&state=opened":
+				// The first merge request is from a fork with the same branch name.
+				w.Write([]byte(`[{"iid": 3, "project_id": 1, "source_project_id": 2}, {"iid": 2, "project_id": 1, "source_project_id": 1}]`)) // nolint: errcheck
+			case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests?source_branch=main&state=opened":
+				w.Write([]byte(`[]`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	defer testServer.Close()
+	internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+	Ok(t, err)
+	client := &GitlabClient{Client: internalClient}
+	repo := models.Repo{FullName: "runatlantis/atlantis"}
+
+	parent, err := client.GetParentPull(logger, repo, models.PullRequest{Num: 5, BaseBranch: "feature-a"})
+	Ok(t, err)
+	Equals(t, 2, parent)
+
+	parent, err = client.GetParentPull(logger, repo, models.PullRequest{Num: 2, BaseBranch: "main"})
+	Ok(t, err)
+	Equals(t, 0, parent)
+}
+
 // GetTeamNamesForUser returns the names of the GitLab groups that the user belongs to.
 func TestGitlabClient_GetTeamNamesForUser(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
@@ -1200,3 +1311,254 @@ func TestGitlabClient_GetTeamNamesForUser(t *testing.T) {
 		})
 	}
 }
+
+// gitlabFixture returns the JSON testdata file with fields overridden.
+func gitlabFixture(t *testing.T, file string, overrides map[string]interface{}) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path.Join("testdata", file))
+	Ok(t, err)
+	var fixture map[string]interface{}
+	Ok(t, json.Unmarshal(data, &fixture))
+	for k, v := range overrides {
+		fixture[k] = v
+	}
+	data, err = json.Marshal(fixture)
+	Ok(t, err)
+	return data
+}
+
+func TestGitlabClient_PullIsMergeable_ApprovalRules(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	gitlabClientUnderTest = true
+	mr := gitlabFixture(t, "gitlab-pipeline-success.json", nil)
+	project := gitlabFixture(t, "gitlab-project-success.json", nil)
+
+	cases := []struct {
+		description   string
+		approvalState string
+		code          int
+		expMergeable  bool
+	}{
+		{
+			"no rules",
+			`{"rules": []}`,
+			http.StatusOK,
+			true,
+		},
+		{
+			"approved rules",
+			`{"rules": [{"name": "security", "approvals_required": 1, "approved": true}]}`,
+			http.StatusOK,
+			true,
+		},
+		{
+			"unapproved rule",
+			`{"rules": [{"name": "security", "approvals_required": 1, "approved": true}, {"name": "platform", "approvals_required": 2, "approved": false}]}`,
+			http.StatusOK,
+			false,
+		},
+		{
+			"approval rules not supported",
+			`{"message": "404 Not Found"}`,
+			http.StatusNotFound,
+			true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			testServer := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.RequestURI {
+					case "/api/v4/":
+						// Rate limiter requests.
+						w.WriteHeader(http.StatusOK)
+					case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1":
+						w.Write(mr) // nolint: errcheck
+					case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1/approval_state":
+						w.WriteHeader(c.code)
+						w.Write([]byte(c.approvalState)) // nolint: errcheck
+					case fmt.Sprintf("/api/v4/projects/%v", projectID):
+						w.Write(project) // nolint: errcheck
+					case fmt.Sprintf("/api/v4/projects/%v/repository/commits/67cb91d3f6198189f433c045154a885784ba6977/statuses", projectID):
+						w.Write([]byte(`[{"status": "success", "name": "atlantis/plan"}]`)) // nolint: errcheck
+					case "/api/v4/version":
+						w.Write([]byte(`{"version": "15.8.3-ee"}`)) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+
+			internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+			Ok(t, err)
+			client := &GitlabClient{Client: internalClient}
+			repo := models.Repo{FullName: "runatlantis/atlantis"}
+
+			mergeable, err := client.PullIsMergeable(logger, repo, models.PullRequest{
+				Num:        1,
+				BaseRepo:   repo,
+				HeadCommit: "67cb91d3f6198189f433c045154a885784ba6977",
+			}, "atlantis", nil)
+			Ok(t, err)
+			Equals(t, c.expMergeable, mergeable)
+		})
+	}
+}
+
+func TestGitlabClient_PullIsMergeable_MergedResultsPipeline(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	gitlabClientUnderTest = true
+	mr := gitlabFixture(t, "gitlab-pipeline-success.json", map[string]interface{}{
+		"iid": 1,
+		"head_pipeline": map[string]interface{}{
+			"id":     488599,
+			"sha":    "mergesha",
+			"ref":    "refs/merge-requests/1/train",
+			"status": "success",
+		},
+	})
+	project := gitlabFixture(t, "gitlab-project-success.json", map[string]interface{}{
+		"merge_pipelines_enabled": true,
+		"merge_trains_enabled":    true,
+	})
+
+	cases := []struct {
+		headCommitStatus string
+		expMergeable     bool
+	}{
+		{"success", true},
+		{"failed", false},
+	}
+	for _, c := range cases {
+		t.Run(c.headCommitStatus, func(t *testing.T) {
+			testServer := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.RequestURI {
+					case "/api/v4/":
+						// Rate limiter requests.
+						w.WriteHeader(http.StatusOK)
+					case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1":
+						w.Write(mr) // nolint: errcheck
+					case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1/approval_state":
+						w.Write([]byte(`{"rules": []}`)) // nolint: errcheck
+					case fmt.Sprintf("/api/v4/projects/%v", projectID):
+						w.Write(project) // nolint: errcheck
+					case fmt.Sprintf("/api/v4/projects/%v/repository/commits/mergesha/statuses", projectID):
+						w.Write([]byte(`[{"status": "success", "name": "test"}]`)) // nolint: errcheck
+					case fmt.Sprintf("/api/v4/projects/%v/repository/commits/headsha/statuses", projectID):
+						fmt.Fprintf(w, `[{"status": %q, "name": "atlantis/plan: dir/default"}]`, c.headCommitStatus)
+					case "/api/v4/version":
+						w.Write([]byte(`{"version": "15.8.3-ee"}`)) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+
+			internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+			Ok(t, err)
+			client := &GitlabClient{Client: internalClient}
+			repo := models.Repo{FullName: "runatlantis/atlantis"}
+
+			mergeable, err := client.PullIsMergeable(logger, repo, models.PullRequest{
+				Num:        1,
+				BaseRepo:   repo,
+				HeadCommit: "headsha",
+			}, "atlantis", nil)
+			Ok(t, err)
+			Equals(t, c.expMergeable, mergeable)
+		})
+	}
+}
+
+func TestGitlabClient_MergePull_MergeTrain(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	mr := gitlabFixture(t, "gitlab-pipeline-success.json", map[string]interface{}{
+		"force_remove_source_branch": false,
+	})
+	project := gitlabFixture(t, "gitlab-project-success.json", map[string]interface{}{
+		"merge_pipelines_enabled": true,
+		"merge_trains_enabled":    true,
+	})
+
+	var requests []string
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.RequestURI {
+			case "GET /api/v4/projects/runatlantis%2Fatlantis/merge_requests/1":
+				w.Write(mr) // nolint: errcheck
+			case "GET /api/v4/projects/4580910":
+				w.Write(project) // nolint: errcheck
+			case "PUT /api/v4/projects/runatlantis%2Fatlantis/merge_requests/1":
+				var body map[string]interface{}
+				Ok(t, json.NewDecoder(r.Body).Decode(&body))
+				Equals(t, map[string]interface{}{"remove_source_branch": true}, body)
+				requests = append(requests, "update")
+				w.Write(mr) // nolint: errcheck
+			case "POST /api/v4/projects/4580910/merge_trains/merge_requests/1":
+				var body map[string]interface{}
+				Ok(t, json.NewDecoder(r.Body).Decode(&body))
+				Equals(t, map[string]interface{}{"when_pipeline_succeeds": true, "sha": "cb86d70f464632bdfbe1bb9bc0f2f9d847a774a0"}, body)
+				requests = append(requests, "merge train")
+				w.Write([]byte(`[]`)) // nolint: errcheck
+			case "GET /api/v4/":
+				// Rate limiter requests.
+				w.WriteHeader(http.StatusOK)
+			default:
+				t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+
+	internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+	Ok(t, err)
+	client := &GitlabClient{Client: internalClient}
+
+	err = client.MergePull(logger, models.PullRequest{
+		Num:      1,
+		BaseRepo: models.Repo{FullName: "runatlantis/atlantis"},
+	}, models.PullRequestOptions{DeleteSourceBranchOnMerge: true})
+	Ok(t, err)
+	Equals(t, []string{"update", "merge train"}, requests)
+}
+
+func TestGitlabClient_UpdateStatusMergedResultsPipeline(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+
+	statusPipelines := map[string]int{}
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v4/projects/runatlantis%2Fatlantis/repository/commits/sha":
+				w.Write([]byte(`{"short_id": "sha", "last_pipeline": {"id": 488598}}`)) // nolint: errcheck
+			case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1":
+				w.Write([]byte(`{"iid": 1, "head_pipeline": {"id": 488599, "sha": "mergesha", "ref": "refs/merge-requests/1/merge"}}`)) // nolint: errcheck
+			case "/api/v4/projects/runatlantis%2Fatlantis/statuses/sha", "/api/v4/projects/runatlantis%2Fatlantis/statuses/mergesha":
+				var body UpdateStatusJsonBody
+				Ok(t, json.NewDecoder(r.Body).Decode(&body))
+				Equals(t, updateStatusSrc, body.Context)
+				statusPipelines[path.Base(r.URL.Path)] = body.PipelineId
+				w.Write([]byte(`{}`)) // nolint: errcheck
+			case "/api/v4/":
+				// Rate limiter requests.
+				w.WriteHeader(http.StatusOK)
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+
+	internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+	Ok(t, err)
+	client := &GitlabClient{Client: internalClient}
+	repo := models.Repo{FullName: "runatlantis/atlantis"}
+
+	err = client.UpdateStatus(logger, repo, models.PullRequest{
+		Num:        1,
+		BaseRepo:   repo,
+		HeadCommit: "sha",
+		HeadBranch: updateStatusHeadBranch,
+	}, models.SuccessCommitStatus, updateStatusSrc, updateStatusDescription, updateStatusTargetUrl)
+	Ok(t, err)
+	Equals(t, map[string]int{"sha": 488598, "mergesha": 488599}, statusPipelines)
+}
@@ -78,3 +78,11 @@ func (a *NotConfiguredVCSClient) GetCloneURL(_ logging.SimpleLogging, _ models.V
 func (a *NotConfiguredVCSClient) GetPullLabels(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest) ([]string, error) {
 	return nil, a.err()
 }
+
+func (a *NotConfiguredVCSClient) CreateIssue(_ logging.SimpleLogging, _ models.Repo, _ string, _ string) error {
+	return a.err()
+}
+
+func (a *NotConfiguredVCSClient) GetPullState(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest) (models.PullRequestState, error) {
+	return models.ClosedPullState, a.err()
+}
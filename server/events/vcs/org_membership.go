@@ -0,0 +1,13 @@
+package vcs
+
+import (
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// OrgMembershipChecker checks if users are members of organizations, ex.
+// GitHub organizations or GitLab groups.
+type OrgMembershipChecker interface {
+	// IsOrgMember returns true if user is a member of the organization org.
+	IsOrgMember(logger logging.SimpleLogging, user models.User, org string) (bool, error)
+}
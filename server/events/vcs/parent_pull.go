@@ -0,0 +1,13 @@
+package vcs
+
+import (
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// ParentPullFinder finds the pull request a pull request is stacked on.
+type ParentPullFinder interface {
+	// GetParentPull returns the number of the open pull request of repo whose
+	// head branch is the base branch of pull. It returns 0 if there's none.
+	GetParentPull(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (int, error)
+}
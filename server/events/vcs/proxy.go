@@ -53,10 +53,17 @@ func NewClientProxy(githubClient Client, gitlabClient Client, bitbucketCloudClie
 			models.BitbucketServer: bitbucketServerClient,
 			models.AzureDevops:     azuredevopsClient,
 			models.Gitea:           giteaClient,
+			models.VCSAdapter:      &NotConfiguredVCSClient{},
 		},
 	}
 }
 
+// Register makes client the client for VCS hosts of type hostType, ex. a
+// client of a VCS adapter for models.VCSAdapter.
+func (d *ClientProxy) Register(hostType models.VCSHostType, client Client) {
+	d.clients[hostType] = client
+}
+
 func (d *ClientProxy) GetModifiedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error) {
 	return d.clients[repo.VCSHost.Type].GetModifiedFiles(logger, repo, pull)
 }
@@ -116,3 +123,11 @@ func (d *ClientProxy) GetCloneURL(logger logging.SimpleLogging, VCSHostType mode
 func (d *ClientProxy) GetPullLabels(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error) {
 	return d.clients[repo.VCSHost.Type].GetPullLabels(logger, repo, pull)
 }
+
+func (d *ClientProxy) CreateIssue(logger logging.SimpleLogging, repo models.Repo, title string, body string) error {
+	return d.clients[repo.VCSHost.Type].CreateIssue(logger, repo, title, body)
+}
+
+func (d *ClientProxy) GetPullState(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.PullRequestState, error) {
+	return d.clients[repo.VCSHost.Type].GetPullState(logger, repo, pull)
+}
@@ -0,0 +1,119 @@
+package vcs
+
+import (
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// ShadowModeChecker returns whether repos are in shadow mode.
+type ShadowModeChecker interface {
+	// ShadowMode returns true if the repo with repoID is in shadow mode.
+	ShadowMode(repoID string) bool
+}
+
+// ShadowModeClient wraps a Client so that nothing is written to the pull
+// requests of repos in shadow mode: no comments, reactions or commit
+// statuses, and no reviews discarded or pulls merged. Everything that only
+// reads from the VCS host is passed through so that commands still run and
+// their output can be seen in the jobs UI and the API.
+type ShadowModeClient struct {
+	Client
+	Checker ShadowModeChecker
+}
+
+// NewShadowModeClient returns a ShadowModeClient that wraps client.
+func NewShadowModeClient(client Client, checker ShadowModeChecker) *ShadowModeClient {
+	return &ShadowModeClient{
+		Client:  client,
+		Checker: checker,
+	}
+}
+
+func (c *ShadowModeClient) CreateComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string) error {
+	if c.shadowed(logger, repo, "comment") {
+		return nil
+	}
+	return c.Client.CreateComment(logger, repo, pullNum, comment, command)
+}
+
+func (c *ShadowModeClient) ReactToComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, commentID int64, reaction string) error {
+	if c.shadowed(logger, repo, "reaction") {
+		return nil
+	}
+	return c.Client.ReactToComment(logger, repo, pullNum, commentID, reaction)
+}
+
+func (c *ShadowModeClient) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
+	if c.shadowed(logger, repo, "hiding of previous comments") {
+		return nil
+	}
+	return c.Client.HidePrevCommandComments(logger, repo, pullNum, command, dir)
+}
+
+func (c *ShadowModeClient) UpdateStatus(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, url string) error {
+	if c.shadowed(logger, repo, "commit status") {
+		return nil
+	}
+	return c.Client.UpdateStatus(logger, repo, pull, state, src, description, url)
+}
+
+func (c *ShadowModeClient) DiscardReviews(repo models.Repo, pull models.PullRequest) error {
+	if c.Checker.ShadowMode(repo.ID()) {
+		return nil
+	}
+	return c.Client.DiscardReviews(repo, pull)
+}
+
+func (c *ShadowModeClient) MergePull(logger logging.SimpleLogging, pull models.PullRequest, pullOptions models.PullRequestOptions) error {
+	if c.shadowed(logger, pull.BaseRepo, "merge") {
+		return nil
+	}
+	return c.Client.MergePull(logger, pull, pullOptions)
+}
+
+func (c *ShadowModeClient) CreateIssue(logger logging.SimpleLogging, repo models.Repo, title string, body string) error {
+	if c.shadowed(logger, repo, "issue") {
+		return nil
+	}
+	return c.Client.CreateIssue(logger, repo, title, body)
+}
+
+// shadowed returns true if repo is in shadow mode, in which case what, which
+// would have been written to it, is logged instead.
+func (c *ShadowModeClient) shadowed(logger logging.SimpleLogging, repo models.Repo, what string) bool {
+	if !c.Checker.ShadowMode(repo.ID()) {
+		return false
+	}
+	logger.Debug("not writing %s to %s since it's in shadow mode", what, repo.FullName)
+	return true
+}
+
+// ShadowModeChecksClient wraps a ChecksClient so that no check runs are
+// created on the pull requests of repos in shadow mode.
+type ShadowModeChecksClient struct {
+	ChecksClient
+	Checker ShadowModeChecker
+}
+
+func (c *ShadowModeChecksClient) UpdateCheckRun(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, opts CheckRunOptions) error {
+	if c.Checker.ShadowMode(repo.ID()) {
+		logger.Debug("not writing check run to %s since it's in shadow mode", repo.FullName)
+		return nil
+	}
+	return c.ChecksClient.UpdateCheckRun(logger, repo, pull, opts)
+}
+
+// ShadowModeSummaryCommenter wraps a SummaryCommenter so that summary comments
+// aren't written on the pull requests of repos in shadow mode.
+type ShadowModeSummaryCommenter struct {
+	SummaryCommenter
+	Checker ShadowModeChecker
+}
+
+func (c *ShadowModeSummaryCommenter) SetSummaryComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, marker string, bodies []string) error {
+	if c.Checker.ShadowMode(repo.ID()) {
+		logger.Debug("not writing summary comment to %s since it's in shadow mode", repo.FullName)
+		return nil
+	}
+	return c.SummaryCommenter.SetSummaryComments(logger, repo, pullNum, marker, bodies)
+}
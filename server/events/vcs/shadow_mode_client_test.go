@@ -0,0 +1,52 @@
+package vcs_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// shadowedRepos is a vcs.ShadowModeChecker for a set of repo IDs.
+type shadowedRepos map[string]bool
+
+func (s shadowedRepos) ShadowMode(repoID string) bool {
+	return s[repoID]
+}
+
+// writeCountingClient counts the comments and statuses written with it.
+type writeCountingClient struct {
+	vcs.NotConfiguredVCSClient
+	comments int
+	statuses int
+}
+
+func (c *writeCountingClient) CreateComment(_ logging.SimpleLogging, _ models.Repo, _ int, _ string, _ string) error {
+	c.comments++
+	return nil
+}
+
+func (c *writeCountingClient) UpdateStatus(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest, _ models.CommitStatus, _ string, _ string, _ string) error {
+	c.statuses++
+	return nil
+}
+
+func TestShadowModeClient(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	shadowed := models.Repo{FullName: "owner/shadowed", VCSHost: models.VCSHost{Hostname: "github.com"}}
+	visible := models.Repo{FullName: "owner/visible", VCSHost: models.VCSHost{Hostname: "github.com"}}
+	underlying := &writeCountingClient{}
+	client := vcs.NewShadowModeClient(underlying, shadowedRepos{shadowed.ID(): true})
+
+	Ok(t, client.CreateComment(logger, shadowed, 1, "comment", "plan"))
+	Ok(t, client.UpdateStatus(logger, shadowed, models.PullRequest{BaseRepo: shadowed}, models.SuccessCommitStatus, "atlantis/plan", "", ""))
+	Equals(t, 0, underlying.comments)
+	Equals(t, 0, underlying.statuses)
+
+	Ok(t, client.CreateComment(logger, visible, 1, "comment", "plan"))
+	Ok(t, client.UpdateStatus(logger, visible, models.PullRequest{BaseRepo: visible}, models.SuccessCommitStatus, "atlantis/plan", "", ""))
+	Equals(t, 1, underlying.comments)
+	Equals(t, 1, underlying.statuses)
+}
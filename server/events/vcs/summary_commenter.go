@@ -0,0 +1,20 @@
+package vcs
+
+import (
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// SummaryCommenter maintains comments on a pull request that Atlantis edits in
+// place rather than posting new ones.
+type SummaryCommenter interface {
+	// MaxCommentLength is the longest comment the VCS host accepts.
+	MaxCommentLength() int
+	// GetSummaryComments returns the bodies of the comments Atlantis made on
+	// the pull request that start with marker, oldest first.
+	GetSummaryComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, marker string) ([]string, error)
+	// SetSummaryComments replaces the comments Atlantis made on the pull
+	// request that start with marker with bodies. Existing comments are
+	// edited in place, missing ones are created and extra ones are deleted.
+	SetSummaryComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, marker string, bodies []string) error
+}
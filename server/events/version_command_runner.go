@@ -8,7 +8,7 @@ func NewVersionCommandRunner(
 	pullUpdater *PullUpdater,
 	prjCmdBuilder ProjectVersionCommandBuilder,
 	prjCmdRunner ProjectVersionCommandRunner,
-	parallelPoolSize int,
+	parallelPoolSize *ParallelPoolSize,
 	silenceVCSStatusNoProjects bool,
 ) *VersionCommandRunner {
 	return &VersionCommandRunner{
@@ -24,7 +24,7 @@ type VersionCommandRunner struct {
 	pullUpdater      *PullUpdater
 	prjCmdBuilder    ProjectVersionCommandBuilder
 	prjCmdRunner     ProjectVersionCommandRunner
-	parallelPoolSize int
+	parallelPoolSize *ParallelPoolSize
 	// SilenceVCSStatusNoProjects is whether any plan should set commit status if no projects
 	// are found
 	silenceVCSStatusNoProjects bool
@@ -47,7 +47,7 @@ func (v *VersionCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 	var result command.Result
 	if v.isParallelEnabled(projectCmds) {
 		ctx.Log.Info("Running version in parallel")
-		result = runProjectCmdsParallelGroups(ctx, projectCmds, v.prjCmdRunner.Version, v.parallelPoolSize)
+		result = runProjectCmdsParallelGroups(ctx, projectCmds, v.prjCmdRunner.Version, v.parallelPoolSize.Get())
 	} else {
 		result = runProjectCmds(projectCmds, v.prjCmdRunner.Version)
 	}
@@ -86,7 +86,13 @@ func (d *DefaultSlackClient) createAttachments(applyResult ApplyResult) []slack.
 		successWord = "failed"
 	}
 
-	text := fmt.Sprintf("Apply %s for <%s|%s>", successWord, applyResult.Pull.URL, applyResult.Repo.FullName)
+	repo := applyResult.Repo.FullName
+	// Applies that aren't for a pull request, ex. scheduled applies, don't
+	// have a URL to link to.
+	if applyResult.Pull.URL != "" {
+		repo = fmt.Sprintf("<%s|%s>", applyResult.Pull.URL, applyResult.Repo.FullName)
+	}
+	text := fmt.Sprintf("Apply %s for %s", successWord, repo)
 	directory := applyResult.Directory
 	// Since "." looks weird, replace it with "/" to make it clear this is the root.
 	if directory == "." {
@@ -19,6 +19,7 @@ import (
 
 	"errors"
 
+	"github.com/runatlantis/atlantis/server/core/expr"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
 )
@@ -55,9 +56,12 @@ type Config struct {
 	Event          string
 	WorkspaceRegex string
 	BranchRegex    string
-	Kind           string
-	Channel        string
-	URL            string
+	// When is an expression that must evaluate to true for the webhook to
+	// be sent, ex. `result == "failure"`.
+	When    string
+	Kind    string
+	Channel string
+	URL     string
 }
 
 type Clients struct {
@@ -82,6 +86,13 @@ func NewMultiWebhookSender(configs []Config, clients Clients) (*MultiWebhookSend
 		if c.Event != ApplyEvent {
 			return nil, fmt.Errorf("\"event: %s\" not supported. Only \"event: %s\" is supported right now", c.Event, ApplyEvent)
 		}
+		var when *expr.Expr
+		if c.When != "" {
+			if when, err = expr.Parse(c.When); err != nil {
+				return nil, fmt.Errorf("invalid \"when\": %w", err)
+			}
+		}
+		var webhook Sender
 		switch c.Kind {
 		case SlackKind:
 			if !clients.Slack.TokenIsSet() {
@@ -94,7 +105,7 @@ func NewMultiWebhookSender(configs []Config, clients Clients) (*MultiWebhookSend
 			if err != nil {
 				return nil, err
 			}
-			webhooks = append(webhooks, slack)
+			webhook = slack
 		case HttpKind:
 			if c.URL == "" {
 				return nil, errors.New("must specify \"url\" if using a webhook of \"kind: http\"")
@@ -105,10 +116,14 @@ func NewMultiWebhookSender(configs []Config, clients Clients) (*MultiWebhookSend
 				BranchRegex:    br,
 				URL:            c.URL,
 			}
-			webhooks = append(webhooks, httpWebhook)
+			webhook = httpWebhook
 		default:
 			return nil, fmt.Errorf("\"kind: %s\" not supported. Only \"kind: %s\" and \"kind: %s\" are supported right now", c.Kind, SlackKind, HttpKind)
 		}
+		if when != nil {
+			webhook = &FilteredSender{Sender: webhook, When: when}
+		}
+		webhooks = append(webhooks, webhook)
 	}
 
 	return &MultiWebhookSender{
@@ -125,3 +140,42 @@ func (w *MultiWebhookSender) Send(log logging.SimpleLogging, result ApplyResult)
 	}
 	return nil
 }
+
+// FilteredSender sends webhooks with Sender if When evaluates to true for the
+// apply result.
+type FilteredSender struct {
+	Sender Sender
+	When   *expr.Expr
+}
+
+// Send sends the webhook if When evaluates to true.
+func (f *FilteredSender) Send(log logging.SimpleLogging, result ApplyResult) error {
+	outcome := "failure"
+	if result.Success {
+		outcome = "success"
+	}
+	send, err := f.When.Eval(expr.Vars{
+		Command: ApplyEvent,
+		Result:  outcome,
+		Repo:    result.Repo.FullName,
+		User:    result.User.Username,
+		Pull: expr.PullVars{
+			Num:        result.Pull.Num,
+			Author:     result.Pull.Author,
+			BaseBranch: result.Pull.BaseBranch,
+			HeadBranch: result.Pull.HeadBranch,
+		},
+		Project: expr.ProjectVars{
+			Name:      result.ProjectName,
+			Dir:       result.Directory,
+			Workspace: result.Workspace,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if !send {
+		return nil
+	}
+	return f.Sender.Send(log, result)
+}
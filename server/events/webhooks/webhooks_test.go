@@ -91,6 +91,42 @@ func TestNewWebhooksManager_InvalidBranchAndWorkspaceRegex(t *testing.T) {
 	Assert(t, strings.Contains(err.Error(), "error parsing regexp"), "expected regex error")
 }
 
+func TestNewWebhooksManager_InvalidWhen(t *testing.T) {
+	t.Log("When given an invalid when expression in a config, an error is returned")
+	RegisterMockTestingT(t)
+	clients := validClients()
+
+	configs := validConfigs()
+	configs[0].When = `branch == "main"`
+	_, err := webhooks.NewMultiWebhookSender(configs, clients)
+	ErrContains(t, `invalid "when": parsing "branch == \"main\"": unknown variable "branch"`, err)
+}
+
+func TestNewWebhooksManager_When(t *testing.T) {
+	t.Log("When given a when expression in a config, webhooks are only sent if it's true")
+	RegisterMockTestingT(t)
+	clients := validClients()
+	When(clients.Slack.TokenIsSet()).ThenReturn(true)
+
+	configs := validConfigs()
+	configs[0].When = `result == "failure" && startswith(project.dir, "prod")`
+	m, err := webhooks.NewMultiWebhookSender(configs, clients)
+	Ok(t, err)
+	Equals(t, 1, len(m.Webhooks)) // nolint: staticcheck
+	filtered, ok := m.Webhooks[0].(*webhooks.FilteredSender)
+	Assert(t, ok, "expected a filtered sender")
+
+	sender := mocks.NewMockSender()
+	filtered.Sender = sender
+	logger := logging.NewNoopLogger(t)
+	success := webhooks.ApplyResult{Success: true, Directory: "prod/network"}
+	failure := webhooks.ApplyResult{Success: false, Directory: "prod/network"}
+	Ok(t, filtered.Send(logger, success))
+	Ok(t, filtered.Send(logger, failure))
+	sender.VerifyWasCalled(Never()).Send(logger, success)
+	sender.VerifyWasCalledOnce().Send(logger, failure)
+}
+
 func TestNewWebhooksManager_NoEvent(t *testing.T) {
 	t.Log("When the event key is not specified in a config, an error is returned")
 	RegisterMockTestingT(t)
@@ -17,7 +17,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -25,6 +27,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/core/runtime"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/utils"
 )
@@ -87,6 +90,22 @@ type FileWorkspace struct {
 	GpgNoSigningEnabled bool
 	// flag indicating if we have to merge with potential new changes upstream (directly after grabbing project lock)
 	CheckForUpstreamChanges bool
+	// GitCredentials resolves the credentials repos are cloned with. If nil,
+	// repos are cloned with their clone URLs as they are.
+	GitCredentials *GitCredentialsResolver
+	// CheckoutFilter is the filter of partial clones, ex. blob:none, so that
+	// the contents of files are only fetched once they're checked out. If
+	// empty, repos are cloned in full.
+	CheckoutFilter string
+	// SparseCheckout is true if only the root files of repos, the dirs of the
+	// files modified by pull requests and SparseCheckoutDirs are checked out.
+	SparseCheckout bool
+	// SparseCheckoutDirs are the dirs that are always checked out with
+	// SparseCheckout, ex. the ones of modules that projects use.
+	SparseCheckoutDirs []string
+	// VCSClient finds the files modified by pull requests. Only required
+	// with SparseCheckout.
+	VCSClient vcs.Client
 }
 
 // Clone git clones headRepo, checks out the branch and then returns the absolute
@@ -99,7 +118,11 @@ func (w *FileWorkspace) Clone(logger logging.SimpleLogging, headRepo models.Repo
 	cloneDir := w.cloneDir(p.BaseRepo, p, workspace)
 	defer func() { w.CheckForUpstreamChanges = false }()
 
-	c := wrappedGitContext{cloneDir, headRepo, p}
+	headRepo, p, sshCommand, err := w.resolveGitCredentials(headRepo, p)
+	if err != nil {
+		return cloneDir, false, err
+	}
+	c := wrappedGitContext{cloneDir, headRepo, p, sshCommand}
 	// If the directory already exists, check if it's at the right commit.
 	// If so, then we do nothing.
 	if _, err := os.Stat(cloneDir); err == nil {
@@ -126,7 +149,7 @@ func (w *FileWorkspace) Clone(logger logging.SimpleLogging, headRepo models.Repo
 		// We're prefix matching here because BitBucket doesn't give us the full
 		// commit, only a 12 character prefix.
 		if strings.HasPrefix(currCommit, p.HeadCommit) {
-			if w.CheckForUpstreamChanges && w.CheckoutMerge && w.recheckDiverged(logger, p, headRepo, cloneDir) {
+			if w.CheckForUpstreamChanges && w.CheckoutMerge && w.recheckDiverged(logger, c) {
 				logger.Info("base branch has been updated, using merge strategy and will clone again")
 				return cloneDir, true, w.mergeAgain(logger, c)
 			}
@@ -149,7 +172,7 @@ func (w *FileWorkspace) Clone(logger logging.SimpleLogging, headRepo models.Repo
 // and we have to perform a new merge.
 // If there are any errors we return false since we prefer things to succeed
 // vs. stopping the plan/apply.
-func (w *FileWorkspace) recheckDiverged(logger logging.SimpleLogging, p models.PullRequest, headRepo models.Repo, cloneDir string) bool {
+func (w *FileWorkspace) recheckDiverged(logger logging.SimpleLogging, c wrappedGitContext) bool {
 	if !w.CheckoutMerge {
 		// It only makes sense to warn that main has diverged if we're using
 		// the checkout merge strategy. If we're just checking out the branch,
@@ -165,19 +188,23 @@ func (w *FileWorkspace) recheckDiverged(logger logging.SimpleLogging, p models.P
 	// as a long term fix, but something like that requires more e2e testing/time
 	cmds := [][]string{
 		{
-			"git", "remote", "set-url", "origin", p.BaseRepo.CloneURL,
+			"git", "remote", "set-url", "origin", c.pr.BaseRepo.CloneURL,
 		},
 		{
-			"git", "remote", "set-url", "head", headRepo.CloneURL,
+			"git", "remote", "set-url", "head", c.head.CloneURL,
 		},
 		{
 			"git", "remote", "update",
 		},
 	}
+	// The repo may have been cloned before it had an SSH key.
+	if c.sshCommand != "" {
+		cmds = append([][]string{{"git", "config", "--local", "core.sshCommand", c.sshCommand}}, cmds...)
+	}
 
 	for _, args := range cmds {
 		cmd := exec.Command(args[0], args[1:]...) // nolint: gosec
-		cmd.Dir = cloneDir
+		cmd.Dir = c.dir
 
 		output, err := cmd.CombinedOutput()
 		if err != nil {
@@ -186,7 +213,7 @@ func (w *FileWorkspace) recheckDiverged(logger logging.SimpleLogging, p models.P
 		}
 	}
 
-	return w.HasDiverged(logger, cloneDir)
+	return w.HasDiverged(logger, c.dir)
 }
 
 func (w *FileWorkspace) HasDiverged(logger logging.SimpleLogging, cloneDir string) bool {
@@ -247,24 +274,42 @@ func (w *FileWorkspace) forceClone(logger logging.SimpleLogging, c wrappedGitCon
 		baseCloneURL = w.TestingOverrideBaseCloneURL
 	}
 
+	// The SSH command is kept in the clone's config for later fetches.
+	cloneArgs := []string{"clone"}
+	if c.sshCommand != "" {
+		cloneArgs = append(cloneArgs, "-c", "core.sshCommand="+c.sshCommand)
+	}
+	if w.CheckoutFilter != "" {
+		cloneArgs = append(cloneArgs, "--filter="+w.CheckoutFilter)
+	}
+	if w.SparseCheckout {
+		cloneArgs = append(cloneArgs, "--sparse")
+	}
+
 	// if branch strategy, use depth=1
 	if !w.CheckoutMerge {
-		return w.wrappedGit(logger, c, "clone", "--depth=1", "--branch", c.pr.HeadBranch, "--single-branch", headCloneURL, c.dir)
+		if err := w.wrappedGit(logger, c, append(cloneArgs, "--depth=1", "--branch", c.pr.HeadBranch, "--single-branch", headCloneURL, c.dir)...); err != nil {
+			return err
+		}
+		return w.setSparseCheckout(logger, c)
 	}
 
 	// if merge strategy...
 
 	// if no checkout depth, omit depth arg
 	if w.CheckoutDepth == 0 {
-		if err := w.wrappedGit(logger, c, "clone", "--branch", c.pr.BaseBranch, "--single-branch", baseCloneURL, c.dir); err != nil {
+		if err := w.wrappedGit(logger, c, append(cloneArgs, "--branch", c.pr.BaseBranch, "--single-branch", baseCloneURL, c.dir)...); err != nil {
 			return err
 		}
 	} else {
-		if err := w.wrappedGit(logger, c, "clone", "--depth", fmt.Sprint(w.CheckoutDepth), "--branch", c.pr.BaseBranch, "--single-branch", baseCloneURL, c.dir); err != nil {
+		if err := w.wrappedGit(logger, c, append(cloneArgs, "--depth", fmt.Sprint(w.CheckoutDepth), "--branch", c.pr.BaseBranch, "--single-branch", baseCloneURL, c.dir)...); err != nil {
 			return err
 		}
 	}
 
+	if err := w.setSparseCheckout(logger, c); err != nil {
+		return err
+	}
 	if err := w.wrappedGit(logger, c, "remote", "add", "head", headCloneURL); err != nil {
 		return err
 	}
@@ -277,6 +322,41 @@ func (w *FileWorkspace) forceClone(logger logging.SimpleLogging, c wrappedGitCon
 	return w.mergeToBaseBranch(logger, c)
 }
 
+// setSparseCheckout checks out the dirs of the files modified by the pull
+// request and SparseCheckoutDirs in a clone made with --sparse, which only has
+// the root files of the repo checked out. It does nothing unless
+// SparseCheckout is set.
+func (w *FileWorkspace) setSparseCheckout(logger logging.SimpleLogging, c wrappedGitContext) error {
+	if !w.SparseCheckout {
+		return nil
+	}
+	modifiedFiles, err := w.VCSClient.GetModifiedFiles(logger, c.pr.BaseRepo, c.pr)
+	if err != nil {
+		return errors.Wrap(err, "getting modified files for sparse checkout")
+	}
+	dirs := sparseCheckoutDirs(modifiedFiles, w.SparseCheckoutDirs)
+	if len(dirs) == 0 {
+		return nil
+	}
+	logger.Debug("checking out dirs %s", strings.Join(dirs, ", "))
+	return w.wrappedGit(logger, c, append([]string{"sparse-checkout", "set", "--"}, dirs...)...)
+}
+
+// sparseCheckoutDirs returns the sorted dirs of modifiedFiles and extraDirs.
+// Root files are always checked out so the root dir isn't returned.
+func sparseCheckoutDirs(modifiedFiles []string, extraDirs []string) []string {
+	var dirs []string
+	for _, file := range modifiedFiles {
+		dirs = append(dirs, path.Dir(file))
+	}
+	for _, dir := range extraDirs {
+		dirs = append(dirs, path.Clean(dir))
+	}
+	dirs = slices.DeleteFunc(dirs, func(dir string) bool { return dir == "." || dir == "/" })
+	slices.Sort(dirs)
+	return slices.Compact(dirs)
+}
+
 // There is a new upstream update that we need, and we want to update to it
 // without deleting any existing plans
 func (w *FileWorkspace) mergeAgain(logger logging.SimpleLogging, c wrappedGitContext) error {
@@ -303,6 +383,9 @@ type wrappedGitContext struct {
 	dir  string
 	head models.Repo
 	pr   models.PullRequest
+	// sshCommand is the SSH command the repo is cloned with. If empty, git's
+	// default is used.
+	sshCommand string
 }
 
 // wrappedGit runs git with additional environment settings required for git merge,
@@ -411,6 +494,29 @@ func (w *FileWorkspace) cloneDir(r models.Repo, p models.PullRequest, workspace
 	return filepath.Join(w.repoPullDir(r, p), workspace)
 }
 
+// resolveGitCredentials returns headRepo and the base repo of p with their
+// clone URLs set to use their credentials, and the SSH command to clone them
+// with. Pull requests from forks are fetched with the SSH key of the base repo
+// if it has one.
+func (w *FileWorkspace) resolveGitCredentials(headRepo models.Repo, p models.PullRequest) (models.Repo, models.PullRequest, string, error) {
+	if w.GitCredentials == nil {
+		return headRepo, p, "", nil
+	}
+	baseRepo, sshCommand, err := w.GitCredentials.Resolve(p.BaseRepo)
+	if err != nil {
+		return headRepo, p, "", err
+	}
+	headRepo, headSSHCommand, err := w.GitCredentials.Resolve(headRepo)
+	if err != nil {
+		return headRepo, p, "", err
+	}
+	if sshCommand == "" {
+		sshCommand = headSSHCommand
+	}
+	p.BaseRepo = baseRepo
+	return headRepo, p, sshCommand, nil
+}
+
 // sanitizeGitCredentials replaces any git clone urls that contain credentials
 // in s with the sanitized versions.
 func (w *FileWorkspace) sanitizeGitCredentials(s string, base models.Repo, head models.Repo) string {
@@ -15,6 +15,7 @@ package events
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -40,6 +41,22 @@ type WorkingDirLocker interface {
 	TryLockPull(repoFullName string, pullNum int) (func(), error)
 }
 
+// WorkingDirLock is a working dir lock held by a running command.
+type WorkingDirLock struct {
+	PullNum int
+	// Workspace and Path are empty if the command locked the whole pull
+	// request.
+	Workspace string
+	Path      string
+}
+
+// WorkingDirLockLister lists the working dir locks held by running commands.
+type WorkingDirLockLister interface {
+	// Locks returns the working dir locks of the pull requests of the repo
+	// named repoFullName.
+	Locks(repoFullName string) []WorkingDirLock
+}
+
 // DefaultWorkingDirLocker implements WorkingDirLocker.
 type DefaultWorkingDirLocker struct {
 	// mutex prevents against multiple threads calling functions on this struct
@@ -93,6 +110,36 @@ func (d *DefaultWorkingDirLocker) TryLock(repoFullName string, pullNum int, work
 	}, nil
 }
 
+// Locks returns the working dir locks of the pull requests of the repo named
+// repoFullName.
+func (d *DefaultWorkingDirLocker) Locks(repoFullName string) []WorkingDirLock {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var locks []WorkingDirLock
+	for _, l := range d.locks {
+		rest, ok := strings.CutPrefix(l, repoFullName+"/")
+		if !ok {
+			continue
+		}
+		// The rest is <pull>, or <pull>/<workspace>/<path> where the path can
+		// contain slashes.
+		parts := strings.SplitN(rest, "/", 3)
+		pullNum, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) == 2 {
+			// It's the lock of a repo whose name starts with repoFullName.
+			continue
+		}
+		lock := WorkingDirLock{PullNum: pullNum}
+		if len(parts) == 3 {
+			lock.Workspace = parts[1]
+			lock.Path = parts[2]
+		}
+		locks = append(locks, lock)
+	}
+	return locks
+}
+
 // Unlock unlocks the workspace for this pull.
 func (d *DefaultWorkingDirLocker) unlock(repoFullName string, pullNum int, workspace string, path string) {
 	d.mutex.Lock()
@@ -214,3 +214,20 @@ func TestLockPull_WorkspaceFirst(t *testing.T) {
 	_, err = locker.TryLockPull("owner/repo", 1)
 	Ok(t, err)
 }
+
+func TestLocks(t *testing.T) {
+	locker := events.NewDefaultWorkingDirLocker()
+	_, err := locker.TryLockPull("owner/repo", 1)
+	Ok(t, err)
+	_, err = locker.TryLock("owner/repo", 2, "default", "modules/vpc")
+	Ok(t, err)
+	_, err = locker.TryLock("owner/repo-other", 3, "default", ".")
+	Ok(t, err)
+	_, err = locker.TryLock("owner/repo/sub", 4, "default", ".")
+	Ok(t, err)
+
+	Equals(t, []events.WorkingDirLock{
+		{PullNum: 1},
+		{PullNum: 2, Workspace: "default", Path: "modules/vpc"},
+	}, locker.Locks("owner/repo"))
+}
@@ -9,10 +9,12 @@ import (
 	"strings"
 	"testing"
 
+	. "github.com/petergtz/pegomock/v4"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -542,6 +544,56 @@ func TestClone_MasterHasDiverged(t *testing.T) {
 	assert.FileExists(t, planFile, "Existing plan file should not have been deleted")
 }
 
+// Test that with SparseCheckout only the root files, the dirs of the modified
+// files and SparseCheckoutDirs are checked out of a partial clone, including
+// after merging the head branch.
+func TestClone_SparseCheckout(t *testing.T) {
+	repoDir := initRepo(t)
+	runCmd(t, repoDir, "git", "config", "--local", "uploadpack.allowFilter", "true")
+	runCmd(t, repoDir, "git", "checkout", "branch")
+	for _, file := range []string{"atlantis.yaml", "project1/main.tf", "project2/main.tf", "modules/vpc/main.tf"} {
+		Ok(t, os.MkdirAll(filepath.Join(repoDir, filepath.Dir(file)), 0700))
+		Ok(t, os.WriteFile(filepath.Join(repoDir, file), []byte("content"), 0600))
+	}
+	runCmd(t, repoDir, "git", "add", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "projects")
+	branchCommit := runCmd(t, repoDir, "git", "rev-parse", "HEAD")
+	runCmd(t, repoDir, "git", "checkout", "main")
+	Ok(t, os.MkdirAll(filepath.Join(repoDir, "project3"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "project3", "main.tf"), []byte("content"), 0600))
+	runCmd(t, repoDir, "git", "add", ".")
+	runCmd(t, repoDir, "git", "commit", "-m", "main-commit")
+
+	logger := logging.NewNoopLogger(t)
+	pull := models.PullRequest{HeadBranch: "branch", BaseBranch: "main"}
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.GetModifiedFiles(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest]())).
+		ThenReturn([]string{"project1/main.tf"}, nil)
+
+	overrideURL := fmt.Sprintf("file://%s", repoDir)
+	wd := &events.FileWorkspace{
+		DataDir:                     t.TempDir(),
+		CheckoutMerge:               true,
+		TestingOverrideHeadCloneURL: overrideURL,
+		TestingOverrideBaseCloneURL: overrideURL,
+		GpgNoSigningEnabled:         true,
+		CheckoutFilter:              "blob:none",
+		SparseCheckout:              true,
+		SparseCheckoutDirs:          []string{"modules/vpc"},
+		VCSClient:                   vcsClient,
+	}
+	cloneDir, _, err := wd.Clone(logger, models.Repo{}, pull, "default")
+	Ok(t, err)
+
+	assert.FileExists(t, filepath.Join(cloneDir, "atlantis.yaml"))
+	assert.FileExists(t, filepath.Join(cloneDir, "project1", "main.tf"))
+	assert.FileExists(t, filepath.Join(cloneDir, "modules", "vpc", "main.tf"))
+	assert.NoDirExists(t, filepath.Join(cloneDir, "project2"))
+	assert.NoDirExists(t, filepath.Join(cloneDir, "project3"))
+	Equals(t, branchCommit, runCmd(t, cloneDir, "git", "rev-parse", "HEAD^2"))
+	Equals(t, "blob:none\n", runCmd(t, cloneDir, "git", "config", "remote.origin.partialclonefilter"))
+}
+
 func TestHasDiverged_MasterHasDiverged(t *testing.T) {
 	// Initialize the git repo.
 	repoDir := initRepo(t)
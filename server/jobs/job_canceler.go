@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrJobCanceled is the cause of the context of a job that was canceled.
+var ErrJobCanceled = errors.New("job was canceled")
+
+// JobCanceler lets running jobs be canceled, ex. from the jobs dashboard, so
+// that a runaway plan can be stopped without restarting Atlantis.
+type JobCanceler struct {
+	cancels     map[string]context.CancelCauseFunc
+	cancelsLock sync.Mutex
+}
+
+func NewJobCanceler() *JobCanceler {
+	return &JobCanceler{
+		cancels: map[string]context.CancelCauseFunc{},
+	}
+}
+
+// Start returns the context of the job with jobID, which is canceled with
+// ErrJobCanceled if the job is canceled, and the func that must be called
+// once the job is done.
+func (c *JobCanceler) Start(jobID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	c.cancelsLock.Lock()
+	c.cancels[jobID] = cancel
+	c.cancelsLock.Unlock()
+	return ctx, func() {
+		c.cancelsLock.Lock()
+		delete(c.cancels, jobID)
+		c.cancelsLock.Unlock()
+		cancel(nil)
+	}
+}
+
+// Cancel cancels the job with jobID. It returns false if the job isn't
+// running.
+func (c *JobCanceler) Cancel(jobID string) bool {
+	c.cancelsLock.Lock()
+	defer c.cancelsLock.Unlock()
+	cancel, ok := c.cancels[jobID]
+	if !ok {
+		return false
+	}
+	cancel(ErrJobCanceled)
+	delete(c.cancels, jobID)
+	return true
+}
+
+// IsRunning returns true if the job with jobID is running and can be
+// canceled.
+func (c *JobCanceler) IsRunning(jobID string) bool {
+	c.cancelsLock.Lock()
+	defer c.cancelsLock.Unlock()
+	_, ok := c.cancels[jobID]
+	return ok
+}
@@ -0,0 +1,38 @@
+package jobs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/jobs"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestJobCanceler_Cancel(t *testing.T) {
+	canceler := jobs.NewJobCanceler()
+	ctx, done := canceler.Start("job")
+	defer done()
+	Assert(t, canceler.IsRunning("job"), "exp job to be running")
+
+	Assert(t, canceler.Cancel("job"), "exp job to be canceled")
+	Equals(t, context.Canceled, ctx.Err())
+	Equals(t, jobs.ErrJobCanceled, context.Cause(ctx))
+	Assert(t, !canceler.IsRunning("job"), "exp canceled job not to be running")
+	Assert(t, !canceler.Cancel("job"), "exp canceled job not to be canceled again")
+}
+
+func TestJobCanceler_Done(t *testing.T) {
+	canceler := jobs.NewJobCanceler()
+	ctx, done := canceler.Start("job")
+	done()
+
+	Assert(t, !canceler.IsRunning("job"), "exp done job not to be running")
+	Assert(t, !canceler.Cancel("job"), "exp done job not to be canceled")
+	Equals(t, context.Canceled, context.Cause(ctx))
+}
+
+func TestJobCanceler_UnknownJob(t *testing.T) {
+	canceler := jobs.NewJobCanceler()
+	Assert(t, !canceler.IsRunning("job"), "exp unknown job not to be running")
+	Assert(t, !canceler.Cancel("job"), "exp unknown job not to be canceled")
+}
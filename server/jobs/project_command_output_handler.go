@@ -30,6 +30,8 @@ type JobIDInfo struct {
 	Time           time.Time
 	TimeFormatted  string
 	JobStep        string
+	// Complete is true once the job is done.
+	Complete bool
 }
 
 type PullInfoWithJobIDs struct {
@@ -66,6 +68,31 @@ type AsyncProjectCommandOutputHandler struct {
 
 	// Tracks all the jobs for a pull request which is used for clean up after a pull request is closed.
 	pullToJobMapping sync.Map
+
+	// store persists the output of jobs so it can be replayed after Atlantis
+	// restarts. It's nil if job output isn't persisted.
+	store JobOutputStore
+	// unpersistedJobs are the running jobs keyed by job ID, with the lines
+	// that haven't been persisted yet.
+	unpersistedJobs     map[string]*unpersistedJob
+	unpersistedJobsLock sync.Mutex
+}
+
+// jobOutputPersistInterval is how often the output of running jobs is
+// persisted. Lines output since then are lost if Atlantis restarts.
+const jobOutputPersistInterval = time.Second
+
+type unpersistedJob struct {
+	job   models.JobOutput
+	lines []string
+}
+
+//go:generate pegomock generate --package mocks -o mocks/mock_job_output_store.go JobOutputStore
+
+// JobOutputStore persists the output of jobs.
+type JobOutputStore interface {
+	AppendJobOutput(job models.JobOutput, lines []string) error
+	GetJobOutput(jobID string) (*models.JobOutput, error)
 }
 
 //go:generate pegomock generate --package mocks -o mocks/mock_project_command_output_handler.go ProjectCommandOutputHandler
@@ -108,6 +135,25 @@ func NewAsyncProjectCommandOutputHandler(
 	}
 }
 
+// NewPersistentProjectCommandOutputHandler returns a handler that also
+// persists the output of jobs to store, so that the output of jobs that ran
+// before Atlantis restarted can still be viewed.
+func NewPersistentProjectCommandOutputHandler(
+	projectCmdOutput chan *ProjectCmdOutputLine,
+	logger logging.SimpleLogging,
+	store JobOutputStore,
+) ProjectCommandOutputHandler {
+	return &AsyncProjectCommandOutputHandler{
+		projectCmdOutput:     projectCmdOutput,
+		logger:               logger,
+		receiverBuffers:      map[string]map[chan string]bool{},
+		projectOutputBuffers: map[string]OutputBuffer{},
+		pullToJobMapping:     sync.Map{},
+		store:                store,
+		unpersistedJobs:      map[string]*unpersistedJob{},
+	}
+}
+
 func (p *AsyncProjectCommandOutputHandler) GetPullToJobMapping() []PullInfoWithJobIDs {
 
 	pullToJobMappings := []PullInfoWithJobIDs{}
@@ -117,16 +163,17 @@ func (p *AsyncProjectCommandOutputHandler) GetPullToJobMapping() []PullInfoWithJ
 		pullInfo := key.(PullInfo)
 		jobIDMap := value.(map[string]JobIDInfo)
 
-		p := PullInfoWithJobIDs{
+		mapping := PullInfoWithJobIDs{
 			Pull:       pullInfo,
 			JobIDInfos: make([]JobIDInfo, 0, len(jobIDMap)),
 		}
 
 		for _, JobIDInfo := range jobIDMap {
-			p.JobIDInfos = append(p.JobIDInfos, JobIDInfo)
+			JobIDInfo.Complete = p.isJobComplete(JobIDInfo.JobID)
+			mapping.JobIDInfos = append(mapping.JobIDInfos, JobIDInfo)
 		}
 
-		pullToJobMappings = append(pullToJobMappings, p)
+		pullToJobMappings = append(pullToJobMappings, mapping)
 		i++
 		return true
 	})
@@ -134,11 +181,24 @@ func (p *AsyncProjectCommandOutputHandler) GetPullToJobMapping() []PullInfoWithJ
 	return pullToJobMappings
 }
 
-func (p *AsyncProjectCommandOutputHandler) IsKeyExists(key string) bool {
+func (p *AsyncProjectCommandOutputHandler) isJobComplete(jobID string) bool {
 	p.projectOutputBuffersLock.RLock()
 	defer p.projectOutputBuffersLock.RUnlock()
+	return p.projectOutputBuffers[jobID].OperationComplete
+}
+
+func (p *AsyncProjectCommandOutputHandler) IsKeyExists(key string) bool {
+	p.projectOutputBuffersLock.RLock()
 	_, ok := p.projectOutputBuffers[key]
-	return ok
+	p.projectOutputBuffersLock.RUnlock()
+	if ok || p.store == nil {
+		return ok
+	}
+	job, err := p.store.GetJobOutput(key)
+	if err != nil {
+		p.logger.Warn("getting output of job %s: %s", key, err)
+	}
+	return job != nil
 }
 
 func (p *AsyncProjectCommandOutputHandler) Send(ctx command.ProjectContext, msg string, operationComplete bool) {
@@ -156,7 +216,7 @@ func (p *AsyncProjectCommandOutputHandler) Send(ctx command.ProjectContext, msg
 			},
 			JobStep: ctx.CommandName.String(),
 		},
-		Line:              msg,
+		Line:              ctx.Secrets.Redact(msg),
 		OperationComplete: operationComplete,
 	}
 }
@@ -184,28 +244,117 @@ func (p *AsyncProjectCommandOutputHandler) Register(jobID string, receiver chan
 }
 
 func (p *AsyncProjectCommandOutputHandler) Handle() {
-	for msg := range p.projectCmdOutput {
-		if msg.OperationComplete {
-			p.completeJob(msg.JobID)
-			continue
+	if p.store == nil {
+		for msg := range p.projectCmdOutput {
+			p.handleMsg(msg)
 		}
+		return
+	}
 
-		// Add job to pullToJob mapping
-		if _, ok := p.pullToJobMapping.Load(msg.JobInfo.PullInfo); !ok {
-			p.pullToJobMapping.Store(msg.JobInfo.PullInfo, map[string]JobIDInfo{})
+	ticker := time.NewTicker(jobOutputPersistInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg, ok := <-p.projectCmdOutput:
+			if !ok {
+				p.persistJobOutputs()
+				return
+			}
+			p.handleMsg(msg)
+		case <-ticker.C:
+			p.persistJobOutputs()
 		}
-		value, _ := p.pullToJobMapping.Load(msg.JobInfo.PullInfo)
-		jobMapping := value.(map[string]JobIDInfo)
-		jobMapping[msg.JobID] = JobIDInfo{
-			JobID:          msg.JobID,
-			JobDescription: msg.JobInfo.JobDescription,
-			Time:           time.Now(),
-			JobStep:        msg.JobInfo.JobStep,
+	}
+}
+
+func (p *AsyncProjectCommandOutputHandler) handleMsg(msg *ProjectCmdOutputLine) {
+	if msg.OperationComplete {
+		p.completeJob(msg.JobID)
+		if p.store != nil {
+			p.persistCompleteJob(msg.JobID)
+		}
+		return
+	}
+
+	// Add job to pullToJob mapping
+	if _, ok := p.pullToJobMapping.Load(msg.JobInfo.PullInfo); !ok {
+		p.pullToJobMapping.Store(msg.JobInfo.PullInfo, map[string]JobIDInfo{})
+	}
+	value, _ := p.pullToJobMapping.Load(msg.JobInfo.PullInfo)
+	jobMapping := value.(map[string]JobIDInfo)
+	jobMapping[msg.JobID] = JobIDInfo{
+		JobID:          msg.JobID,
+		JobDescription: msg.JobInfo.JobDescription,
+		Time:           time.Now(),
+		JobStep:        msg.JobInfo.JobStep,
+	}
+
+	// Forward new message to all receiver channels and output buffer
+	p.writeLogLine(msg.JobID, msg.Line)
+	if p.store != nil {
+		p.addUnpersistedLine(msg)
+	}
+}
+
+func (p *AsyncProjectCommandOutputHandler) addUnpersistedLine(msg *ProjectCmdOutputLine) {
+	p.unpersistedJobsLock.Lock()
+	defer p.unpersistedJobsLock.Unlock()
+	unpersisted, ok := p.unpersistedJobs[msg.JobID]
+	if !ok {
+		unpersisted = &unpersistedJob{
+			job: models.JobOutput{
+				JobID:          msg.JobID,
+				RepoFullName:   msg.JobInfo.RepoFullName,
+				PullNum:        msg.JobInfo.PullNum,
+				ProjectName:    msg.JobInfo.ProjectName,
+				RepoRelDir:     msg.JobInfo.Path,
+				Workspace:      msg.JobInfo.Workspace,
+				HeadCommit:     msg.JobInfo.HeadCommit,
+				JobStep:        msg.JobInfo.JobStep,
+				JobDescription: msg.JobInfo.JobDescription,
+				StartedAt:      time.Now(),
+			},
+		}
+		p.unpersistedJobs[msg.JobID] = unpersisted
+	}
+	unpersisted.lines = append(unpersisted.lines, msg.Line)
+}
+
+// persistJobOutputs persists the lines output by running jobs since they were
+// last persisted.
+func (p *AsyncProjectCommandOutputHandler) persistJobOutputs() {
+	p.unpersistedJobsLock.Lock()
+	defer p.unpersistedJobsLock.Unlock()
+	for _, unpersisted := range p.unpersistedJobs {
+		if len(unpersisted.lines) > 0 {
+			p.persistJobOutput(unpersisted)
 		}
+	}
+}
+
+// persistCompleteJob persists the rest of the output of a job that completed.
+func (p *AsyncProjectCommandOutputHandler) persistCompleteJob(jobID string) {
+	p.unpersistedJobsLock.Lock()
+	defer p.unpersistedJobsLock.Unlock()
+	unpersisted, ok := p.unpersistedJobs[jobID]
+	if !ok {
+		// The job didn't output anything.
+		return
+	}
+	unpersisted.job.Complete = true
+	p.persistJobOutput(unpersisted)
+	delete(p.unpersistedJobs, jobID)
+}
 
-		// Forward new message to all receiver channels and output buffer
-		p.writeLogLine(msg.JobID, msg.Line)
+// persistJobOutput must be called with unpersistedJobsLock held. If the lines
+// can't be persisted they're kept to be retried the next time.
+func (p *AsyncProjectCommandOutputHandler) persistJobOutput(unpersisted *unpersistedJob) {
+	unpersisted.job.UpdatedAt = time.Now()
+	if err := p.store.AppendJobOutput(unpersisted.job, unpersisted.lines); err != nil {
+		p.logger.Warn("persisting output of job %s: %s", unpersisted.job.JobID, err)
+		return
 	}
+	unpersisted.lines = nil
 }
 
 func (p *AsyncProjectCommandOutputHandler) completeJob(jobID string) {
@@ -233,9 +382,21 @@ func (p *AsyncProjectCommandOutputHandler) completeJob(jobID string) {
 
 func (p *AsyncProjectCommandOutputHandler) addChan(ch chan string, jobID string) {
 	p.projectOutputBuffersLock.RLock()
-	outputBuffer := p.projectOutputBuffers[jobID]
+	outputBuffer, ok := p.projectOutputBuffers[jobID]
 	p.projectOutputBuffersLock.RUnlock()
 
+	// Jobs that aren't in memory ran before Atlantis restarted, so their
+	// persisted output is all there is.
+	if !ok && p.store != nil {
+		job, err := p.store.GetJobOutput(jobID)
+		if err != nil {
+			p.logger.Warn("getting output of job %s: %s", jobID, err)
+		}
+		if job != nil {
+			outputBuffer = OutputBuffer{OperationComplete: true, Buffer: job.Lines}
+		}
+	}
+
 	for _, line := range outputBuffer.Buffer {
 		ch <- line
 	}
@@ -318,6 +479,17 @@ func (p *AsyncProjectCommandOutputHandler) CleanUp(pullInfo PullInfo) {
 			p.receiverBuffersLock.Unlock()
 		}
 
+		if p.store != nil {
+			p.unpersistedJobsLock.Lock()
+			for jobID := range jobMapping {
+				if unpersisted, ok := p.unpersistedJobs[jobID]; ok {
+					p.persistJobOutput(unpersisted)
+					delete(p.unpersistedJobs, jobID)
+				}
+			}
+			p.unpersistedJobsLock.Unlock()
+		}
+
 		// Remove job mapping
 		p.pullToJobMapping.Delete(pullInfo)
 	}
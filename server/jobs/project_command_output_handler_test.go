@@ -5,9 +5,11 @@ import (
 	"testing"
 	"time"
 
+	. "github.com/petergtz/pegomock/v4"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/jobs/mocks"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 	"github.com/stretchr/testify/assert"
@@ -93,6 +95,20 @@ func TestProjectCommandOutputHandler(t *testing.T) {
 		Equals(t, expectedMsg, Msg)
 	})
 
+	t.Run("redacts secrets", func(t *testing.T) {
+		projectOutputHandler := createProjectCommandOutputHandler(t)
+		ch := make(chan string, 1)
+		projectOutputHandler.Register(ctx.JobID, ch)
+
+		secretCtx := ctx
+		secretCtx.Secrets = &command.SecretRedactor{}
+		secretCtx.Secrets.Add("s3cr3t-value")
+		projectOutputHandler.Send(secretCtx, "password = s3cr3t-value", false)
+
+		Equals(t, "password = <redacted>", <-ch)
+		close(ch)
+	})
+
 	t.Run("copies buffer to new channels", func(t *testing.T) {
 		var wg sync.WaitGroup
 
@@ -252,3 +268,53 @@ func TestProjectCommandOutputHandler(t *testing.T) {
 		assert.True(t, <-opComplete)
 	})
 }
+
+func TestPersistentProjectCommandOutputHandler(t *testing.T) {
+	ctx := createTestProjectCmdContext(t)
+
+	t.Run("persists the output of a job when it completes", func(t *testing.T) {
+		RegisterMockTestingT(t)
+		store := mocks.NewMockJobOutputStore()
+		projectOutputHandler := jobs.NewPersistentProjectCommandOutputHandler(
+			make(chan *jobs.ProjectCmdOutputLine),
+			logging.NewNoopLogger(t),
+			store,
+		)
+		go projectOutputHandler.Handle()
+
+		projectOutputHandler.Send(ctx, "line 1", false)
+		projectOutputHandler.Send(ctx, "line 2", false)
+		projectOutputHandler.Send(ctx, "", true)
+
+		job, lines := store.VerifyWasCalledEventually(Once(), time.Second).
+			AppendJobOutput(Any[models.JobOutput](), Any[[]string]()).GetCapturedArguments()
+		Equals(t, []string{"line 1", "line 2"}, lines)
+		Equals(t, ctx.JobID, job.JobID)
+		Equals(t, ctx.Pull.Num, job.PullNum)
+		Equals(t, ctx.RepoRelDir, job.RepoRelDir)
+		Equals(t, ctx.Workspace, job.Workspace)
+		Assert(t, job.Complete, "expected the job to be complete")
+	})
+
+	t.Run("replays the persisted output of a job that ran before a restart", func(t *testing.T) {
+		RegisterMockTestingT(t)
+		store := mocks.NewMockJobOutputStore()
+		When(store.GetJobOutput("5678")).ThenReturn(&models.JobOutput{JobID: "5678", Lines: []string{"line 1", "line 2"}}, nil)
+		projectOutputHandler := jobs.NewPersistentProjectCommandOutputHandler(
+			make(chan *jobs.ProjectCmdOutputLine),
+			logging.NewNoopLogger(t),
+			store,
+		)
+
+		Assert(t, projectOutputHandler.IsKeyExists("5678"), "expected the persisted job to exist")
+		Assert(t, !projectOutputHandler.IsKeyExists("unknown"), "expected an unknown job not to exist")
+
+		ch := make(chan string, 2)
+		projectOutputHandler.Register("5678", ch)
+		var received []string
+		for line := range ch {
+			received = append(received, line)
+		}
+		Equals(t, []string{"line 1", "line 2"}, received)
+	})
+}
@@ -48,6 +48,8 @@ func (l *RequestLogger) ServeHTTP(rw http.ResponseWriter, r *http.Request, next
 		r.URL.Path == "/events" ||
 		r.URL.Path == "/healthz" ||
 		r.URL.Path == "/status" ||
+		// Run task requests are authenticated with their HMAC signature.
+		r.URL.Path == "/tfe/run-task" ||
 		strings.HasPrefix(r.URL.Path, "/api/") {
 		allowed = true
 	} else {
@@ -0,0 +1,85 @@
+package scheduled
+
+import (
+	"time"
+
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+const (
+	// BoltDBCompactionPeriod is how often the size of the BoltDB file is
+	// checked.
+	BoltDBCompactionPeriod = time.Hour
+
+	// The file is compacted automatically once at least this fraction of it
+	// is free pages.
+	boltDBCompactFreeRatio = 0.5
+)
+
+// DBCompactor is a database whose file can be compacted.
+type DBCompactor interface {
+	// FileStats returns the size of the database file and how many bytes of
+	// it are free.
+	FileStats() (size int64, free int64, err error)
+	// Compact rewrites the database file without its free space.
+	Compact() (sizeBefore int64, sizeAfter int64, err error)
+}
+
+// BoltDBCompactionJob keeps the BoltDB file from growing without bound. BoltDB
+// never shrinks its file, so space left by deleted locks and pull statuses is
+// only reclaimed by compacting it. The job compacts the file when most of it
+// is free space and warns when it's bigger than the size warning.
+type BoltDBCompactionJob struct {
+	log         logging.SimpleLogging
+	db          DBCompactor
+	autoCompact bool
+	// sizeWarning is the size in bytes above which a warning is logged. It's
+	// 0 if there's no warning.
+	sizeWarning int64
+	scope       tally.Scope
+}
+
+func NewBoltDBCompactionJob(log logging.SimpleLogging, db DBCompactor, autoCompact bool, sizeWarning int64, statsScope tally.Scope) *BoltDBCompactionJob {
+	return &BoltDBCompactionJob{
+		log:         log,
+		db:          db,
+		autoCompact: autoCompact,
+		sizeWarning: sizeWarning,
+		scope:       statsScope.SubScope("boltdb"),
+	}
+}
+
+func (j *BoltDBCompactionJob) Run() {
+	size, free, err := j.db.FileStats()
+	if err != nil {
+		j.log.Err("reading database file stats: %s", err)
+		j.scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+		return
+	}
+
+	if j.autoCompact && free > 0 && float64(free) >= float64(size)*boltDBCompactFreeRatio {
+		j.log.Info("%d of the %d bytes of the database file are free, compacting it", free, size)
+		sizeBefore, sizeAfter, err := j.db.Compact()
+		if err != nil {
+			j.log.Err("compacting database: %s", err)
+			j.scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+		} else {
+			j.log.Info("compacted database from %d to %d bytes", sizeBefore, sizeAfter)
+			j.scope.Counter("compactions").Inc(1)
+			if size, free, err = j.db.FileStats(); err != nil {
+				j.log.Err("reading database file stats: %s", err)
+				j.scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+				return
+			}
+		}
+	}
+
+	j.scope.Gauge("file_size_bytes").Update(float64(size))
+	j.scope.Gauge("free_bytes").Update(float64(free))
+	if j.sizeWarning > 0 && size > j.sizeWarning {
+		j.log.Warn("database file is %d bytes which is above the size warning of %d bytes, lock operations slow down as it grows. It can be compacted with the /api/boltdb/compact endpoint",
+			size, j.sizeWarning)
+	}
+}
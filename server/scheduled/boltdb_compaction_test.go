@@ -0,0 +1,83 @@
+package scheduled
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+type fakeDBCompactor struct {
+	size        int64
+	free        int64
+	compactions int
+	compactErr  error
+}
+
+func (f *fakeDBCompactor) FileStats() (int64, int64, error) {
+	return f.size, f.free, nil
+}
+
+func (f *fakeDBCompactor) Compact() (int64, int64, error) {
+	if f.compactErr != nil {
+		return f.size, 0, f.compactErr
+	}
+	f.compactions++
+	sizeBefore := f.size
+	f.size -= f.free
+	f.free = 0
+	return sizeBefore, f.size, nil
+}
+
+func TestBoltDBCompactionJob_Run(t *testing.T) {
+	cases := []struct {
+		description        string
+		db                 fakeDBCompactor
+		autoCompact        bool
+		expCompactions     int
+		expSize            float64
+		expExecutionErrors int64
+	}{
+		{
+			description:    "mostly free",
+			db:             fakeDBCompactor{size: 1000, free: 600},
+			autoCompact:    true,
+			expCompactions: 1,
+			expSize:        400,
+		},
+		{
+			description: "mostly used",
+			db:          fakeDBCompactor{size: 1000, free: 100},
+			autoCompact: true,
+			expSize:     1000,
+		},
+		{
+			description: "auto compaction disabled",
+			db:          fakeDBCompactor{size: 1000, free: 600},
+			expSize:     1000,
+		},
+		{
+			description:        "compaction fails",
+			db:                 fakeDBCompactor{size: 1000, free: 600, compactErr: errors.New("disk full")},
+			autoCompact:        true,
+			expSize:            1000,
+			expExecutionErrors: 1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			scope := tally.NewTestScope("atlantis", nil)
+			db := c.db
+			NewBoltDBCompactionJob(logging.NewNoopLogger(t), &db, c.autoCompact, 500, scope).Run()
+
+			Equals(t, c.expCompactions, db.compactions)
+			snapshot := scope.Snapshot()
+			Equals(t, c.expSize, snapshot.Gauges()["atlantis.boltdb.file_size_bytes+"].Value())
+			if c.expExecutionErrors > 0 {
+				Equals(t, c.expExecutionErrors, snapshot.Counters()["atlantis.boltdb.execution_error+"].Value())
+			}
+		})
+	}
+}
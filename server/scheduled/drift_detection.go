@@ -0,0 +1,123 @@
+package scheduled
+
+import (
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+//go:generate pegomock generate --package mocks -o mocks/mock_drift_detector.go DriftDetector
+
+// DriftDetector checks the projects configured for drift detection in a repo.
+type DriftDetector interface {
+	// DetectDrift runs a refresh-only plan for each of repo's drift detection
+	// projects and returns their results. An error is returned if none of the
+	// projects could be checked, ex. because the repo couldn't be cloned.
+	DetectDrift(repo valid.Repo) ([]models.DriftStatus, error)
+}
+
+//go:generate pegomock generate --package mocks -o mocks/mock_drift_store.go DriftStore
+
+// DriftStore stores drift detection results.
+type DriftStore interface {
+	UpdateDriftStatus(status models.DriftStatus) error
+	GetDriftStatuses() ([]models.DriftStatus, error)
+}
+
+//go:generate pegomock generate --package mocks -o mocks/mock_drift_notifier.go DriftNotifier
+
+// DriftNotifier notifies users that drift was detected in a project.
+type DriftNotifier interface {
+	// NotifyDrift notifies users of the drift in status according to the
+	// on_drift config of the matching project in repo. It's a no-op if the
+	// project doesn't have on_drift set.
+	NotifyDrift(repo valid.Repo, status models.DriftStatus) error
+}
+
+// DriftDetectionJob periodically checks the configured repos for drift and
+// stores the results.
+type DriftDetectionJob struct {
+	log      logging.SimpleLogging
+	repos    []valid.Repo
+	detector DriftDetector
+	store    DriftStore
+	notifier DriftNotifier
+	scope    tally.Scope
+}
+
+func NewDriftDetectionJob(
+	log logging.SimpleLogging,
+	repos []valid.Repo,
+	detector DriftDetector,
+	store DriftStore,
+	notifier DriftNotifier,
+	statsScope tally.Scope,
+) *DriftDetectionJob {
+	return &DriftDetectionJob{
+		log:      log,
+		repos:    repos,
+		detector: detector,
+		store:    store,
+		notifier: notifier,
+		scope:    statsScope.SubScope("drift_detection"),
+	}
+}
+
+func (j *DriftDetectionJob) Run() {
+	// Users are only notified when a project starts drifting so we need the
+	// results of the last run. If we can't get them, we skip notifying rather
+	// than risk notifying about the same drift again.
+	wasDrifted := make(map[string]bool)
+	previous, err := j.store.GetDriftStatuses()
+	notify := err == nil
+	if err != nil {
+		j.log.Err("getting previous drift statuses, users won't be notified of drift: %s", err)
+	}
+	for _, status := range previous {
+		wasDrifted[status.Key()] = status.Drifted
+	}
+
+	for _, repo := range j.repos {
+		j.log.Info("Checking %s for drift", repo.ID)
+		statuses, err := j.detector.DetectDrift(repo)
+		if err != nil {
+			j.log.Err("checking %s for drift: %s", repo.ID, err)
+			j.scope.Tagged(map[string]string{"repo": repo.ID}).Counter(metrics.ExecutionErrorMetric).Inc(1)
+			continue
+		}
+		for _, status := range statuses {
+			if err := j.store.UpdateDriftStatus(status); err != nil {
+				j.log.Err("storing drift status for %s: %s", status.Key(), err)
+			}
+			j.recordMetrics(status)
+			if notify && status.Drifted && !wasDrifted[status.Key()] {
+				if err := j.notifier.NotifyDrift(repo, status); err != nil {
+					j.log.Err("notifying of drift in %s: %s", status.Key(), err)
+				}
+			}
+		}
+	}
+}
+
+func (j *DriftDetectionJob) recordMetrics(status models.DriftStatus) {
+	scope := j.scope.Tagged(map[string]string{
+		"repo":         status.RepoID,
+		"project":      status.ProjectName,
+		"project_path": status.RepoRelDir,
+		"workspace":    status.Workspace,
+	})
+	if status.Error != "" {
+		scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+		return
+	}
+	scope.Counter(metrics.ExecutionSuccessMetric).Inc(1)
+
+	drifted := 0.0
+	if status.Drifted {
+		drifted = 1
+	}
+	scope.Gauge("drifted").Update(drifted)
+	scope.Gauge("drifted_resources").Update(float64(status.DriftedResources))
+}
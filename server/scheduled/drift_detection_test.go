@@ -0,0 +1,103 @@
+package scheduled
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestDriftDetectionJob_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	detector := mocks.NewMockDriftDetector()
+	store := mocks.NewMockDriftStore()
+	notifier := mocks.NewMockDriftNotifier()
+	scope := tally.NewTestScope("atlantis", nil)
+
+	infra := valid.Repo{ID: "github.com/org/infra"}
+	broken := valid.Repo{ID: "github.com/org/broken"}
+	drifted := models.DriftStatus{
+		RepoID:           infra.ID,
+		RepoFullName:     "org/infra",
+		RepoRelDir:       "prod",
+		Workspace:        "default",
+		Drifted:          true,
+		DriftedResources: 3,
+	}
+	errored := models.DriftStatus{
+		RepoID:       infra.ID,
+		RepoFullName: "org/infra",
+		RepoRelDir:   "staging",
+		Workspace:    "default",
+		Error:        "locked",
+	}
+	When(detector.DetectDrift(Eq(infra))).ThenReturn([]models.DriftStatus{drifted, errored}, nil)
+	When(detector.DetectDrift(Eq(broken))).ThenReturn(nil, errors.New("clone failed"))
+
+	job := NewDriftDetectionJob(logging.NewNoopLogger(t), []valid.Repo{broken, infra}, detector, store, notifier, scope)
+	job.Run()
+
+	store.VerifyWasCalledOnce().UpdateDriftStatus(Eq(drifted))
+	store.VerifyWasCalledOnce().UpdateDriftStatus(Eq(errored))
+	notifier.VerifyWasCalledOnce().NotifyDrift(Eq(infra), Eq(drifted))
+	notifier.VerifyWasCalledOnce().NotifyDrift(Any[valid.Repo](), Any[models.DriftStatus]())
+
+	gauges := scope.Snapshot().Gauges()
+	Equals(t, 3.0, gauges["atlantis.drift_detection.drifted_resources+project=,project_path=prod,repo=github.com/org/infra,workspace=default"].Value())
+	Equals(t, 1.0, gauges["atlantis.drift_detection.drifted+project=,project_path=prod,repo=github.com/org/infra,workspace=default"].Value())
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(1), counters["atlantis.drift_detection.execution_error+repo=github.com/org/broken"].Value())
+	Equals(t, int64(1), counters["atlantis.drift_detection.execution_error+project=,project_path=staging,repo=github.com/org/infra,workspace=default"].Value())
+}
+
+func TestDriftDetectionJob_Run_AlreadyDrifted(t *testing.T) {
+	RegisterMockTestingT(t)
+	detector := mocks.NewMockDriftDetector()
+	store := mocks.NewMockDriftStore()
+	notifier := mocks.NewMockDriftNotifier()
+
+	infra := valid.Repo{ID: "github.com/org/infra"}
+	drifted := models.DriftStatus{
+		RepoID:     infra.ID,
+		RepoRelDir: "prod",
+		Workspace:  "default",
+		Drifted:    true,
+	}
+	When(store.GetDriftStatuses()).ThenReturn([]models.DriftStatus{drifted}, nil)
+	When(detector.DetectDrift(Eq(infra))).ThenReturn([]models.DriftStatus{drifted}, nil)
+
+	job := NewDriftDetectionJob(logging.NewNoopLogger(t), []valid.Repo{infra}, detector, store, notifier, tally.NewTestScope("atlantis", nil))
+	job.Run()
+
+	store.VerifyWasCalledOnce().UpdateDriftStatus(Eq(drifted))
+	notifier.VerifyWasCalled(Never()).NotifyDrift(Any[valid.Repo](), Any[models.DriftStatus]())
+}
+
+func TestDriftDetectionJob_Run_StoreErr(t *testing.T) {
+	RegisterMockTestingT(t)
+	detector := mocks.NewMockDriftDetector()
+	store := mocks.NewMockDriftStore()
+	notifier := mocks.NewMockDriftNotifier()
+
+	infra := valid.Repo{ID: "github.com/org/infra"}
+	drifted := models.DriftStatus{
+		RepoID:     infra.ID,
+		RepoRelDir: "prod",
+		Workspace:  "default",
+		Drifted:    true,
+	}
+	When(store.GetDriftStatuses()).ThenReturn(nil, errors.New("db unavailable"))
+	When(detector.DetectDrift(Eq(infra))).ThenReturn([]models.DriftStatus{drifted}, nil)
+
+	job := NewDriftDetectionJob(logging.NewNoopLogger(t), []valid.Repo{infra}, detector, store, notifier, tally.NewTestScope("atlantis", nil))
+	job.Run()
+
+	store.VerifyWasCalledOnce().UpdateDriftStatus(Eq(drifted))
+	notifier.VerifyWasCalled(Never()).NotifyDrift(Any[valid.Repo](), Any[models.DriftStatus]())
+}
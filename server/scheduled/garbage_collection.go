@@ -0,0 +1,63 @@
+package scheduled
+
+import (
+	"strconv"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+//go:generate pegomock generate --package mocks -o mocks/mock_garbage_collector.go GarbageCollector
+
+// GarbageCollector deletes the plans, working dirs and database entries that
+// are left behind when Atlantis misses a pull request being closed.
+type GarbageCollector interface {
+	// CollectGarbage deletes what's stale or orphaned and returns what was
+	// deleted. If it's a dry run, it returns what would have been deleted.
+	CollectGarbage() (models.GarbageCollectionResult, error)
+}
+
+// GarbageCollectionJob periodically runs a garbage collection and records
+// what was deleted.
+type GarbageCollectionJob struct {
+	log       logging.SimpleLogging
+	collector GarbageCollector
+	scope     tally.Scope
+}
+
+func NewGarbageCollectionJob(
+	log logging.SimpleLogging,
+	collector GarbageCollector,
+	statsScope tally.Scope,
+) *GarbageCollectionJob {
+	return &GarbageCollectionJob{
+		log:       log,
+		collector: collector,
+		scope:     statsScope.SubScope("garbage_collection"),
+	}
+}
+
+func (j *GarbageCollectionJob) Run() {
+	result, err := j.collector.CollectGarbage()
+	scope := j.scope.Tagged(map[string]string{"dry_run": strconv.FormatBool(result.DryRun)})
+	if err != nil {
+		j.log.Err("collecting garbage: %s", err)
+		scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+	} else {
+		scope.Counter(metrics.ExecutionSuccessMetric).Inc(1)
+	}
+
+	// Whatever was deleted before an error is still recorded.
+	scope.Counter("plans_deleted").Inc(int64(result.Plans))
+	scope.Counter("working_dirs_deleted").Inc(int64(result.WorkingDirs))
+	scope.Counter("pull_statuses_deleted").Inc(int64(result.PullStatuses))
+	scope.Counter("locks_deleted").Inc(int64(result.Locks))
+	deleted := "deleted"
+	if result.DryRun {
+		deleted = "would have deleted"
+	}
+	j.log.Info("Garbage collection %s %d plan(s), %d working dir(s), %d pull status(es) and %d lock(s)",
+		deleted, result.Plans, result.WorkingDirs, result.PullStatuses, result.Locks)
+}
@@ -0,0 +1,50 @@
+package scheduled
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestGarbageCollectionJob_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	collector := mocks.NewMockGarbageCollector()
+	scope := tally.NewTestScope("atlantis", nil)
+	When(collector.CollectGarbage()).ThenReturn(models.GarbageCollectionResult{
+		Plans:        2,
+		WorkingDirs:  1,
+		PullStatuses: 1,
+		Locks:        3,
+	}, nil)
+
+	NewGarbageCollectionJob(logging.NewNoopLogger(t), collector, scope).Run()
+
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(1), counters["atlantis.garbage_collection.execution_success+dry_run=false"].Value())
+	Equals(t, int64(2), counters["atlantis.garbage_collection.plans_deleted+dry_run=false"].Value())
+	Equals(t, int64(1), counters["atlantis.garbage_collection.working_dirs_deleted+dry_run=false"].Value())
+	Equals(t, int64(1), counters["atlantis.garbage_collection.pull_statuses_deleted+dry_run=false"].Value())
+	Equals(t, int64(3), counters["atlantis.garbage_collection.locks_deleted+dry_run=false"].Value())
+}
+
+func TestGarbageCollectionJob_Run_Error(t *testing.T) {
+	RegisterMockTestingT(t)
+	collector := mocks.NewMockGarbageCollector()
+	scope := tally.NewTestScope("atlantis", nil)
+	When(collector.CollectGarbage()).ThenReturn(models.GarbageCollectionResult{
+		WorkingDirs: 1,
+		DryRun:      true,
+	}, errors.New("db error"))
+
+	NewGarbageCollectionJob(logging.NewNoopLogger(t), collector, scope).Run()
+
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(1), counters["atlantis.garbage_collection.execution_error+dry_run=true"].Value())
+	Equals(t, int64(1), counters["atlantis.garbage_collection.working_dirs_deleted+dry_run=true"].Value())
+}
@@ -0,0 +1,59 @@
+package scheduled
+
+import (
+	"time"
+
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// JobOutputRetentionPeriod is how often persisted job output is checked for
+// output older than the retention.
+const JobOutputRetentionPeriod = time.Hour
+
+//go:generate pegomock generate --package mocks -o mocks/mock_job_output_deleter.go JobOutputDeleter
+
+// JobOutputDeleter deletes persisted job output.
+type JobOutputDeleter interface {
+	DeleteJobOutputs(cutoff time.Time) (int, error)
+}
+
+// JobOutputRetentionJob periodically deletes the persisted output of jobs
+// that haven't been updated within the retention.
+type JobOutputRetentionJob struct {
+	log       logging.SimpleLogging
+	deleter   JobOutputDeleter
+	retention time.Duration
+	scope     tally.Scope
+	now       func() time.Time
+}
+
+func NewJobOutputRetentionJob(
+	log logging.SimpleLogging,
+	deleter JobOutputDeleter,
+	retention time.Duration,
+	statsScope tally.Scope,
+) *JobOutputRetentionJob {
+	return &JobOutputRetentionJob{
+		log:       log,
+		deleter:   deleter,
+		retention: retention,
+		scope:     statsScope.SubScope("job_output_retention"),
+		now:       time.Now,
+	}
+}
+
+func (j *JobOutputRetentionJob) Run() {
+	deleted, err := j.deleter.DeleteJobOutputs(j.now().Add(-j.retention))
+	if err != nil {
+		j.log.Err("deleting job output: %s", err)
+		j.scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+	} else {
+		j.scope.Counter(metrics.ExecutionSuccessMetric).Inc(1)
+	}
+	j.scope.Counter("jobs_deleted").Inc(int64(deleted))
+	if deleted > 0 {
+		j.log.Info("Deleted the output of %d job(s) older than %s", deleted, j.retention)
+	}
+}
@@ -0,0 +1,43 @@
+package scheduled
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestJobOutputRetentionJob_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	deleter := mocks.NewMockJobOutputDeleter()
+	scope := tally.NewTestScope("atlantis", nil)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	When(deleter.DeleteJobOutputs(Any[time.Time]())).ThenReturn(3, nil)
+
+	job := NewJobOutputRetentionJob(logging.NewNoopLogger(t), deleter, 48*time.Hour, scope)
+	job.now = func() time.Time { return now }
+	job.Run()
+
+	deleter.VerifyWasCalledOnce().DeleteJobOutputs(Eq(now.Add(-48 * time.Hour)))
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(3), counters["atlantis.job_output_retention.jobs_deleted+"].Value())
+	Equals(t, int64(1), counters["atlantis.job_output_retention.execution_success+"].Value())
+}
+
+func TestJobOutputRetentionJob_Run_Error(t *testing.T) {
+	RegisterMockTestingT(t)
+	deleter := mocks.NewMockJobOutputDeleter()
+	scope := tally.NewTestScope("atlantis", nil)
+	When(deleter.DeleteJobOutputs(Any[time.Time]())).ThenReturn(1, errors.New("db error"))
+
+	NewJobOutputRetentionJob(logging.NewNoopLogger(t), deleter, time.Hour, scope).Run()
+
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(1), counters["atlantis.job_output_retention.jobs_deleted+"].Value())
+	Equals(t, int64(1), counters["atlantis.job_output_retention.execution_error+"].Value())
+}
@@ -0,0 +1,107 @@
+package scheduled
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+//go:generate pegomock generate --package mocks -o mocks/mock_lock_lister.go LockLister
+
+// LockLister lists the project locks.
+type LockLister interface {
+	List() ([]models.ProjectLock, error)
+}
+
+//go:generate pegomock generate --package mocks -o mocks/mock_pull_state_getter.go PullStateGetter
+
+// PullStateGetter gets the state of a pull request from its VCS host.
+type PullStateGetter interface {
+	GetPullState(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.PullRequestState, error)
+}
+
+//go:generate pegomock generate --package mocks -o mocks/mock_pull_cleaner.go PullCleaner
+
+// PullCleaner cleans up a closed pull request the same way as when its
+// closed event is received.
+type PullCleaner interface {
+	CleanUpPull(logger logging.SimpleLogging, repo models.Repo, headRepo models.Repo, pull models.PullRequest) error
+}
+
+// OrphanedLockCleanupJob periodically checks whether the pull requests
+// holding locks are still open and cleans up the ones that were closed
+// without Atlantis receiving the closed event, ex. because the webhook was
+// missed.
+type OrphanedLockCleanupJob struct {
+	log     logging.SimpleLogging
+	locks   LockLister
+	vcs     PullStateGetter
+	cleaner PullCleaner
+	scope   tally.Scope
+}
+
+func NewOrphanedLockCleanupJob(
+	log logging.SimpleLogging,
+	locks LockLister,
+	vcs PullStateGetter,
+	cleaner PullCleaner,
+	statsScope tally.Scope,
+) *OrphanedLockCleanupJob {
+	return &OrphanedLockCleanupJob{
+		log:     log,
+		locks:   locks,
+		vcs:     vcs,
+		cleaner: cleaner,
+		scope:   statsScope.SubScope("orphaned_lock_cleanup"),
+	}
+}
+
+func (j *OrphanedLockCleanupJob) Run() {
+	locks, err := j.locks.List()
+	if err != nil {
+		j.log.Err("listing locks: %s", err)
+		j.scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+		return
+	}
+
+	// A pull request can hold many locks so we only check each one once.
+	pulls := make(map[string]models.PullRequest)
+	lockCounts := make(map[string]int)
+	for _, lock := range locks {
+		key := fmt.Sprintf("%s#%d", lock.Pull.BaseRepo.ID(), lock.Pull.Num)
+		pulls[key] = lock.Pull
+		lockCounts[key]++
+	}
+	keys := make([]string, 0, len(pulls))
+	for key := range pulls {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		pull := pulls[key]
+		state, err := j.vcs.GetPullState(j.log, pull.BaseRepo, pull)
+		if err != nil {
+			j.log.Err("getting state of pull request %s: %s", key, err)
+			j.scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+			continue
+		}
+		if state == models.OpenPullState {
+			continue
+		}
+		j.log.Info("Pull request %s was closed without Atlantis cleaning it up, releasing its %d lock(s)", key, lockCounts[key])
+		// The head repo isn't known from the locks.
+		if err := j.cleaner.CleanUpPull(j.log, pull.BaseRepo, models.Repo{}, pull); err != nil {
+			j.log.Err("cleaning up pull request %s: %s", key, err)
+			j.scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+			continue
+		}
+		j.scope.Counter("pulls_cleaned_up").Inc(1)
+		j.scope.Counter("locks_released").Inc(int64(lockCounts[key]))
+	}
+	j.scope.Counter(metrics.ExecutionSuccessMetric).Inc(1)
+}
@@ -0,0 +1,63 @@
+package scheduled
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestOrphanedLockCleanupJob_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	locks := mocks.NewMockLockLister()
+	vcsClient := mocks.NewMockPullStateGetter()
+	cleaner := mocks.NewMockPullCleaner()
+	scope := tally.NewTestScope("atlantis", nil)
+
+	repo := models.Repo{FullName: "org/infra", VCSHost: models.VCSHost{Hostname: "github.com"}}
+	openPull := models.PullRequest{Num: 1, BaseRepo: repo}
+	closedPull := models.PullRequest{Num: 2, BaseRepo: repo}
+	unknownPull := models.PullRequest{Num: 3, BaseRepo: repo}
+	When(locks.List()).ThenReturn([]models.ProjectLock{
+		{Pull: openPull, Workspace: "default"},
+		{Pull: closedPull, Workspace: "default"},
+		{Pull: closedPull, Workspace: "staging"},
+		{Pull: unknownPull, Workspace: "default"},
+	}, nil)
+	When(vcsClient.GetPullState(Any[logging.SimpleLogging](), Eq(repo), Eq(openPull))).ThenReturn(models.OpenPullState, nil)
+	When(vcsClient.GetPullState(Any[logging.SimpleLogging](), Eq(repo), Eq(closedPull))).ThenReturn(models.ClosedPullState, nil)
+	When(vcsClient.GetPullState(Any[logging.SimpleLogging](), Eq(repo), Eq(unknownPull))).ThenReturn(models.ClosedPullState, errors.New("not found"))
+
+	NewOrphanedLockCleanupJob(logging.NewNoopLogger(t), locks, vcsClient, cleaner, scope).Run()
+
+	// Each pull request is only checked once.
+	vcsClient.VerifyWasCalledOnce().GetPullState(Any[logging.SimpleLogging](), Eq(repo), Eq(closedPull))
+	cleaner.VerifyWasCalledOnce().CleanUpPull(Any[logging.SimpleLogging](), Eq(repo), Eq(models.Repo{}), Eq(closedPull))
+	cleaner.VerifyWasCalled(Never()).CleanUpPull(Any[logging.SimpleLogging](), Eq(repo), Any[models.Repo](), Eq(openPull))
+	cleaner.VerifyWasCalled(Never()).CleanUpPull(Any[logging.SimpleLogging](), Eq(repo), Any[models.Repo](), Eq(unknownPull))
+
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(1), counters["atlantis.orphaned_lock_cleanup.pulls_cleaned_up+"].Value())
+	Equals(t, int64(2), counters["atlantis.orphaned_lock_cleanup.locks_released+"].Value())
+	Equals(t, int64(1), counters["atlantis.orphaned_lock_cleanup.execution_error+"].Value())
+}
+
+func TestOrphanedLockCleanupJob_Run_ListError(t *testing.T) {
+	RegisterMockTestingT(t)
+	locks := mocks.NewMockLockLister()
+	vcsClient := mocks.NewMockPullStateGetter()
+	cleaner := mocks.NewMockPullCleaner()
+	scope := tally.NewTestScope("atlantis", nil)
+	When(locks.List()).ThenReturn(nil, errors.New("db error"))
+
+	NewOrphanedLockCleanupJob(logging.NewNoopLogger(t), locks, vcsClient, cleaner, scope).Run()
+
+	vcsClient.VerifyWasCalled(Never()).GetPullState(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest]())
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(1), counters["atlantis.orphaned_lock_cleanup.execution_error+"].Value())
+}
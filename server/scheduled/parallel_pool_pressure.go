@@ -0,0 +1,153 @@
+package scheduled
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+const (
+	// ParallelPoolPressurePeriod is how often the host pressure is checked.
+	ParallelPoolPressurePeriod = 15 * time.Second
+
+	// The pool is halved when the load per CPU rises above the high mark or
+	// the fraction of available memory drops below it, and limited to a
+	// single project at the critical marks.
+	highCPULoad             = 1.0
+	criticalCPULoad         = 2.0
+	highMemoryAvailable     = 0.25
+	criticalMemoryAvailable = 0.1
+)
+
+// PoolLimiter is a parallel pool whose size can be capped.
+type PoolLimiter interface {
+	// Size returns the size of the pool without the cap.
+	Size() int
+	// Limit returns the cap on the size, or 0 if there's no cap.
+	Limit() int
+	// SetLimit caps the size at limit. A limit of 0 removes the cap.
+	SetLimit(limit int)
+}
+
+// HostPressure is how loaded the host Atlantis runs on is.
+type HostPressure struct {
+	// CPULoad is the 1 minute load average divided by the number of CPUs.
+	CPULoad float64
+	// MemoryAvailable is the fraction of memory that's available.
+	MemoryAvailable float64
+}
+
+// ParallelPoolPressureJob caps the size of the parallel pool when the host is
+// under CPU or memory pressure, so that running many projects in parallel
+// doesn't make the host run out of memory, and removes the cap once the
+// pressure is gone. The pressure is read from /proc so it's only supported on
+// Linux.
+type ParallelPoolPressureJob struct {
+	log     logging.SimpleLogging
+	pool    PoolLimiter
+	procDir string
+	scope   tally.Scope
+}
+
+func NewParallelPoolPressureJob(log logging.SimpleLogging, pool PoolLimiter, statsScope tally.Scope) *ParallelPoolPressureJob {
+	return &ParallelPoolPressureJob{
+		log:     log,
+		pool:    pool,
+		procDir: "/proc",
+		scope:   statsScope.SubScope("parallel_pool"),
+	}
+}
+
+func (j *ParallelPoolPressureJob) Run() {
+	pressure, err := readHostPressure(j.procDir)
+	if err != nil {
+		j.log.Debug("reading host pressure: %s", err)
+		j.scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+		return
+	}
+	j.scope.Gauge("cpu_load").Update(pressure.CPULoad)
+	j.scope.Gauge("memory_available").Update(pressure.MemoryAvailable)
+
+	limit := parallelPoolLimit(j.pool.Size(), pressure)
+	if limit != j.pool.Limit() {
+		if limit == 0 {
+			j.log.Info("host pressure is back to normal, removing limit on parallel pool size")
+		} else {
+			j.log.Info("host is under pressure (cpu load %.2f, memory available %.0f%%), limiting parallel pool size to %d",
+				pressure.CPULoad, pressure.MemoryAvailable*100, limit)
+		}
+	}
+	j.pool.SetLimit(limit)
+	j.scope.Gauge("limit").Update(float64(limit))
+}
+
+// parallelPoolLimit returns the cap on a pool of size under pressure, or 0 if
+// it shouldn't be capped.
+func parallelPoolLimit(size int, pressure HostPressure) int {
+	switch {
+	case pressure.CPULoad >= criticalCPULoad || pressure.MemoryAvailable <= criticalMemoryAvailable:
+		return 1
+	case pressure.CPULoad >= highCPULoad || pressure.MemoryAvailable <= highMemoryAvailable:
+		return max((size+1)/2, 1)
+	}
+	return 0
+}
+
+// readHostPressure reads the pressure of the host from procDir, i.e. /proc.
+func readHostPressure(procDir string) (HostPressure, error) {
+	loadavg, err := os.ReadFile(filepath.Join(procDir, "loadavg"))
+	if err != nil {
+		return HostPressure{}, err
+	}
+	fields := strings.Fields(string(loadavg))
+	if len(fields) == 0 {
+		return HostPressure{}, errors.Errorf("unexpected loadavg %q", string(loadavg))
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return HostPressure{}, errors.Wrap(err, "parsing loadavg")
+	}
+
+	meminfo, err := os.Open(filepath.Join(procDir, "meminfo"))
+	if err != nil {
+		return HostPressure{}, err
+	}
+	defer meminfo.Close() // nolint: errcheck
+	mem := make(map[string]float64)
+	scanner := bufio.NewScanner(meminfo)
+	for scanner.Scan() {
+		// Lines look like "MemAvailable:   12345678 kB".
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		mem[strings.TrimSuffix(fields[0], ":")] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return HostPressure{}, err
+	}
+	if mem["MemTotal"] == 0 {
+		return HostPressure{}, errors.New("MemTotal missing from meminfo")
+	}
+	if _, ok := mem["MemAvailable"]; !ok {
+		return HostPressure{}, errors.New("MemAvailable missing from meminfo")
+	}
+
+	return HostPressure{
+		CPULoad:         load / float64(runtime.NumCPU()),
+		MemoryAvailable: mem["MemAvailable"] / mem["MemTotal"],
+	}, nil
+}
@@ -0,0 +1,72 @@
+package scheduled
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+type testPool struct {
+	size  int
+	limit int
+}
+
+func (p *testPool) Size() int          { return p.size }
+func (p *testPool) Limit() int         { return p.limit }
+func (p *testPool) SetLimit(limit int) { p.limit = limit }
+
+// writeProc writes loadavg and meminfo files for a host with load per CPU
+// and memAvailable of 1000 kB of memory to a temp dir.
+func writeProc(t *testing.T, load float64, memAvailable int) string {
+	dir := t.TempDir()
+	loadavg := fmt.Sprintf("%.2f 0.50 0.25 1/100 12345\n", load*float64(runtime.NumCPU()))
+	Ok(t, os.WriteFile(filepath.Join(dir, "loadavg"), []byte(loadavg), 0600))
+	meminfo := fmt.Sprintf("MemTotal:           1000 kB\nMemFree:             100 kB\nMemAvailable:     %5d kB\n", memAvailable)
+	Ok(t, os.WriteFile(filepath.Join(dir, "meminfo"), []byte(meminfo), 0600))
+	return dir
+}
+
+func TestParallelPoolPressureJob_Run(t *testing.T) {
+	cases := []struct {
+		description  string
+		load         float64
+		memAvailable int
+		expLimit     int
+	}{
+		{"no pressure", 0.5, 800, 0},
+		{"high cpu load", 1.5, 800, 5},
+		{"low memory", 0.5, 200, 5},
+		{"critical cpu load", 3, 800, 1},
+		{"critical memory", 0.5, 50, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			pool := &testPool{size: 10, limit: 3}
+			scope := tally.NewTestScope("atlantis", nil)
+			job := NewParallelPoolPressureJob(logging.NewNoopLogger(t), pool, scope)
+			job.procDir = writeProc(t, c.load, c.memAvailable)
+
+			job.Run()
+			Equals(t, c.expLimit, pool.limit)
+			Equals(t, float64(c.expLimit), scope.Snapshot().Gauges()["atlantis.parallel_pool.limit+"].Value())
+		})
+	}
+}
+
+func TestParallelPoolPressureJob_Run_NoProc(t *testing.T) {
+	pool := &testPool{size: 10, limit: 3}
+	scope := tally.NewTestScope("atlantis", nil)
+	job := NewParallelPoolPressureJob(logging.NewNoopLogger(t), pool, scope)
+	job.procDir = t.TempDir()
+
+	// The limit is left alone if the pressure can't be read.
+	job.Run()
+	Equals(t, 3, pool.limit)
+	Equals(t, int64(1), scope.Snapshot().Counters()["atlantis.parallel_pool.execution_error+"].Value())
+}
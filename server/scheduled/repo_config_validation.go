@@ -0,0 +1,133 @@
+package scheduled
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+//go:generate pegomock generate --package mocks -o mocks/mock_repo_config_validator.go RepoConfigValidator
+
+// RepoConfigValidator validates a repo's config against the current
+// server-side config.
+type RepoConfigValidator interface {
+	// ValidateRepoConfig validates the config on the default branch of the
+	// repo with repoID, ex. github.com/runatlantis/atlantis.
+	ValidateRepoConfig(repoID string) models.RepoConfigStatus
+}
+
+//go:generate pegomock generate --package mocks -o mocks/mock_pull_status_lister.go PullStatusLister
+
+// PullStatusLister lists the statuses of the pull requests Atlantis has run
+// commands on.
+type PullStatusLister interface {
+	GetPullStatuses() ([]models.PullStatus, error)
+}
+
+// RepoConfigReport holds the results of the last repo config validation run.
+// It's safe for concurrent use.
+type RepoConfigReport struct {
+	mutex    sync.RWMutex
+	statuses []models.RepoConfigStatus
+}
+
+// Statuses returns the result of the last run for each repo, invalid and
+// errored repos first.
+func (r *RepoConfigReport) Statuses() []models.RepoConfigStatus {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return append([]models.RepoConfigStatus(nil), r.statuses...)
+}
+
+// Update replaces the results with statuses.
+func (r *RepoConfigReport) Update(statuses []models.RepoConfigStatus) {
+	sort.SliceStable(statuses, func(i, j int) bool {
+		if statuses[i].Invalid != statuses[j].Invalid {
+			return statuses[i].Invalid
+		}
+		if (statuses[i].Error != "") != (statuses[j].Error != "") {
+			return statuses[i].Error != ""
+		}
+		return statuses[i].RepoID < statuses[j].RepoID
+	})
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.statuses = statuses
+}
+
+// RepoConfigValidationJob periodically validates the config of every known
+// repo, the ones configured by ID in the server-side config and the ones with
+// open pull requests, so that configs made invalid by a server-side config
+// change are found before users hit them at plan time.
+type RepoConfigValidationJob struct {
+	log       logging.SimpleLogging
+	repoIDs   []string
+	pulls     PullStatusLister
+	validator RepoConfigValidator
+	report    *RepoConfigReport
+	scope     tally.Scope
+}
+
+func NewRepoConfigValidationJob(
+	log logging.SimpleLogging,
+	repoIDs []string,
+	pulls PullStatusLister,
+	validator RepoConfigValidator,
+	report *RepoConfigReport,
+	statsScope tally.Scope,
+) *RepoConfigValidationJob {
+	return &RepoConfigValidationJob{
+		log:       log,
+		repoIDs:   repoIDs,
+		pulls:     pulls,
+		validator: validator,
+		report:    report,
+		scope:     statsScope.SubScope("repo_config_validation"),
+	}
+}
+
+func (j *RepoConfigValidationJob) Run() {
+	repoIDs := append([]string(nil), j.repoIDs...)
+	seen := make(map[string]bool)
+	for _, id := range repoIDs {
+		seen[id] = true
+	}
+	pullStatuses, err := j.pulls.GetPullStatuses()
+	if err != nil {
+		j.log.Err("getting pull statuses, only validating the repos in the server-side config: %s", err)
+	}
+	for _, pullStatus := range pullStatuses {
+		id := pullStatus.Pull.BaseRepo.ID()
+		if !seen[id] {
+			seen[id] = true
+			repoIDs = append(repoIDs, id)
+		}
+	}
+
+	var statuses []models.RepoConfigStatus
+	invalid := 0
+	for _, id := range repoIDs {
+		status := j.validator.ValidateRepoConfig(id)
+		statuses = append(statuses, status)
+		scope := j.scope.Tagged(map[string]string{"repo": id})
+		switch {
+		case status.Invalid:
+			invalid++
+			j.log.Warn("config of %s on branch %s is invalid: %s", id, status.Branch, status.Error)
+			scope.Gauge("invalid").Update(1)
+			scope.Counter(metrics.ExecutionSuccessMetric).Inc(1)
+		case status.Error != "":
+			j.log.Err("validating config of %s: %s", id, status.Error)
+			scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+		default:
+			scope.Gauge("invalid").Update(0)
+			scope.Counter(metrics.ExecutionSuccessMetric).Inc(1)
+		}
+	}
+	j.scope.Gauge("invalid_repos").Update(float64(invalid))
+	j.report.Update(statuses)
+}
@@ -0,0 +1,63 @@
+package scheduled
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestRepoConfigValidationJob_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	pulls := mocks.NewMockPullStatusLister()
+	validator := mocks.NewMockRepoConfigValidator()
+	scope := tally.NewTestScope("atlantis", nil)
+
+	infra := models.RepoConfigStatus{RepoID: "github.com/org/infra", Branch: "main"}
+	app := models.RepoConfigStatus{RepoID: "github.com/org/app", Branch: "main", Invalid: true, Error: "repo config not allowed to set 'workflow' key"}
+	broken := models.RepoConfigStatus{RepoID: "github.com/org/broken", Error: "getting clone url"}
+	appRepo := models.Repo{FullName: "org/app", VCSHost: models.VCSHost{Hostname: "github.com"}}
+	infraRepo := models.Repo{FullName: "org/infra", VCSHost: models.VCSHost{Hostname: "github.com"}}
+	When(pulls.GetPullStatuses()).ThenReturn([]models.PullStatus{
+		{Pull: models.PullRequest{Num: 1, BaseRepo: appRepo}},
+		{Pull: models.PullRequest{Num: 2, BaseRepo: appRepo}},
+		{Pull: models.PullRequest{Num: 3, BaseRepo: infraRepo}},
+	}, nil)
+	When(validator.ValidateRepoConfig(Eq(infra.RepoID))).ThenReturn(infra)
+	When(validator.ValidateRepoConfig(Eq(app.RepoID))).ThenReturn(app)
+	When(validator.ValidateRepoConfig(Eq(broken.RepoID))).ThenReturn(broken)
+
+	report := &RepoConfigReport{}
+	NewRepoConfigValidationJob(logging.NewNoopLogger(t), []string{infra.RepoID, broken.RepoID}, pulls, validator, report, scope).Run()
+
+	// Each repo is validated once even if it has many pull requests.
+	validator.VerifyWasCalledOnce().ValidateRepoConfig(Eq(infra.RepoID))
+	validator.VerifyWasCalledOnce().ValidateRepoConfig(Eq(app.RepoID))
+	Equals(t, []models.RepoConfigStatus{app, broken, infra}, report.Statuses())
+
+	gauges := scope.Snapshot().Gauges()
+	Equals(t, 1.0, gauges["atlantis.repo_config_validation.invalid_repos+"].Value())
+	Equals(t, 1.0, gauges["atlantis.repo_config_validation.invalid+repo=github.com/org/app"].Value())
+	Equals(t, 0.0, gauges["atlantis.repo_config_validation.invalid+repo=github.com/org/infra"].Value())
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(1), counters["atlantis.repo_config_validation.execution_error+repo=github.com/org/broken"].Value())
+}
+
+func TestRepoConfigValidationJob_Run_PullStatusesError(t *testing.T) {
+	RegisterMockTestingT(t)
+	pulls := mocks.NewMockPullStatusLister()
+	validator := mocks.NewMockRepoConfigValidator()
+	infra := models.RepoConfigStatus{RepoID: "github.com/org/infra", Branch: "main"}
+	When(pulls.GetPullStatuses()).ThenReturn(nil, errors.New("db error"))
+	When(validator.ValidateRepoConfig(Eq(infra.RepoID))).ThenReturn(infra)
+
+	report := &RepoConfigReport{}
+	NewRepoConfigValidationJob(logging.NewNoopLogger(t), []string{infra.RepoID}, pulls, validator, report, tally.NewTestScope("atlantis", nil)).Run()
+
+	Equals(t, []models.RepoConfigStatus{infra}, report.Statuses())
+}
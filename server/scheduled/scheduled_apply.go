@@ -0,0 +1,114 @@
+package scheduled
+
+import (
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// ScheduledApplyPeriod is how often the scheduled apply job checks for due
+// schedules. Cron schedules have a resolution of one minute.
+const ScheduledApplyPeriod = time.Minute
+
+//go:generate pegomock generate --package mocks -o mocks/mock_scheduled_applier.go ScheduledApplier
+
+// ScheduledApplier re-plans and applies the projects of a scheduled apply.
+type ScheduledApplier interface {
+	// ScheduledApply plans, policy checks and applies each of the projects in
+	// scheduledApply and returns their results. An error is returned if none
+	// of the projects could be applied, ex. because the repo couldn't be
+	// cloned.
+	ScheduledApply(repo valid.Repo, scheduledApply valid.ScheduledApply) ([]command.ProjectResult, error)
+}
+
+// ScheduledApplyJob applies the configured scheduled applies when their cron
+// schedules are due.
+type ScheduledApplyJob struct {
+	log     logging.SimpleLogging
+	repos   []valid.Repo
+	applier ScheduledApplier
+	scope   tally.Scope
+	now     func() time.Time
+	// lastChecked is the last minute that schedules were checked for.
+	lastChecked time.Time
+}
+
+func NewScheduledApplyJob(
+	log logging.SimpleLogging,
+	repos []valid.Repo,
+	applier ScheduledApplier,
+	statsScope tally.Scope,
+) *ScheduledApplyJob {
+	return &ScheduledApplyJob{
+		log:         log,
+		repos:       repos,
+		applier:     applier,
+		scope:       statsScope.SubScope("scheduled_apply"),
+		now:         time.Now,
+		lastChecked: time.Now().Truncate(time.Minute),
+	}
+}
+
+func (j *ScheduledApplyJob) Run() {
+	// Applies can take longer than a minute so we check every minute since
+	// the last run rather than only the current one. Each scheduled apply is
+	// run at most once per run, even if it was due more than once.
+	now := j.now().Truncate(time.Minute)
+	from := j.lastChecked
+	j.lastChecked = now
+
+	for _, repo := range j.repos {
+		for _, scheduledApply := range repo.ScheduledApplies {
+			if !isDue(scheduledApply.Schedule, from, now) {
+				continue
+			}
+			j.log.Info("Running scheduled apply %q for %s", scheduledApply.Schedule.Spec, repo.ID)
+			results, err := j.applier.ScheduledApply(repo, scheduledApply)
+			if err != nil {
+				j.log.Err("running scheduled apply %q for %s: %s", scheduledApply.Schedule.Spec, repo.ID, err)
+				j.scope.Tagged(map[string]string{"repo": repo.ID}).Counter(metrics.ExecutionErrorMetric).Inc(1)
+				continue
+			}
+			for _, result := range results {
+				j.recordResult(repo, result)
+			}
+		}
+	}
+}
+
+// isDue returns true if schedule matches any minute after from up to and
+// including to.
+func isDue(schedule *valid.CronSchedule, from time.Time, to time.Time) bool {
+	for t := from.Add(time.Minute); !t.After(to); t = t.Add(time.Minute) {
+		if schedule.Matches(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *ScheduledApplyJob) recordResult(repo valid.Repo, result command.ProjectResult) {
+	scope := j.scope.Tagged(map[string]string{
+		"repo":         repo.ID,
+		"project":      result.ProjectName,
+		"project_path": result.RepoRelDir,
+		"workspace":    result.Workspace,
+	})
+	if result.Error != nil || result.Failure != "" {
+		j.log.Err("scheduled %s of %s in %s failed: %s", result.Command, result.RepoRelDir, repo.ID, scheduledApplyFailure(result))
+		scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+		return
+	}
+	scope.Counter(metrics.ExecutionSuccessMetric).Inc(1)
+}
+
+func scheduledApplyFailure(result command.ProjectResult) string {
+	if result.Error != nil {
+		return result.Error.Error()
+	}
+	return result.Failure
+}
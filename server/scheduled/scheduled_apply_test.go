@@ -0,0 +1,70 @@
+package scheduled
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func newScheduledApply(t *testing.T, spec string) valid.ScheduledApply {
+	schedule, err := valid.ParseCronSchedule(spec)
+	Ok(t, err)
+	return valid.ScheduledApply{
+		Schedule: schedule,
+		Projects: []valid.ScheduledApplyProject{{Dir: "certs"}},
+	}
+}
+
+func TestScheduledApplyJob_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	applier := mocks.NewMockScheduledApplier()
+	scope := tally.NewTestScope("atlantis", nil)
+
+	nightly := newScheduledApply(t, "0 3 * * *")
+	hourly := newScheduledApply(t, "@hourly")
+	infra := valid.Repo{ID: "github.com/org/infra", ScheduledApplies: []valid.ScheduledApply{nightly, hourly}}
+	broken := valid.Repo{ID: "github.com/org/broken", ScheduledApplies: []valid.ScheduledApply{nightly}}
+	When(applier.ScheduledApply(Eq(infra), Eq(nightly))).ThenReturn([]command.ProjectResult{
+		{Command: command.Apply, RepoRelDir: "certs", Workspace: "default", ApplySuccess: "Apply complete!"},
+	}, nil)
+	When(applier.ScheduledApply(Eq(infra), Eq(hourly))).ThenReturn([]command.ProjectResult{
+		{Command: command.PolicyCheck, RepoRelDir: "certs", Workspace: "default", Failure: "policies failed"},
+	}, nil)
+	When(applier.ScheduledApply(Eq(broken), Eq(nightly))).ThenReturn(nil, errors.New("clone failed"))
+
+	job := NewScheduledApplyJob(logging.NewNoopLogger(t), []valid.Repo{broken, infra}, applier, scope)
+	now := time.Date(2024, 1, 1, 2, 58, 30, 0, time.UTC)
+	job.lastChecked = now.Truncate(time.Minute)
+	job.now = func() time.Time { return now }
+
+	// Nothing is due at 02:59.
+	now = now.Add(time.Minute)
+	job.Run()
+	applier.VerifyWasCalled(Never()).ScheduledApply(Any[valid.Repo](), Any[valid.ScheduledApply]())
+
+	// Both schedules were due at 03:00, which was missed, so they're run once
+	// at 03:01.
+	now = now.Add(2 * time.Minute)
+	job.Run()
+	applier.VerifyWasCalledOnce().ScheduledApply(Eq(infra), Eq(nightly))
+	applier.VerifyWasCalledOnce().ScheduledApply(Eq(infra), Eq(hourly))
+	applier.VerifyWasCalledOnce().ScheduledApply(Eq(broken), Eq(nightly))
+
+	// Nothing more is due at 03:02.
+	now = now.Add(time.Minute)
+	job.Run()
+	applier.VerifyWasCalled(Times(3)).ScheduledApply(Any[valid.Repo](), Any[valid.ScheduledApply]())
+
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(1), counters["atlantis.scheduled_apply.execution_error+repo=github.com/org/broken"].Value())
+	Equals(t, int64(1), counters["atlantis.scheduled_apply.execution_success+project=,project_path=certs,repo=github.com/org/infra,workspace=default"].Value())
+	Equals(t, int64(1), counters["atlantis.scheduled_apply.execution_error+project=,project_path=certs,repo=github.com/org/infra,workspace=default"].Value())
+}
@@ -0,0 +1,111 @@
+package scheduled
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// TelemetryCounter counts what's reported by telemetry.
+type TelemetryCounter interface {
+	// Flush returns the counts of commands by name and of errors by class
+	// since the last flush and resets them.
+	Flush() (commands map[string]int64, errors map[string]int64)
+}
+
+// TelemetryReport is what's sent to the telemetry endpoint. It's anonymous:
+// it never includes hostnames, repos, users or errors.
+type TelemetryReport struct {
+	// InstanceID is random and changes every time Atlantis starts, so reports
+	// of the same server can be grouped but not traced back to it.
+	InstanceID string `json:"instance_id"`
+	Version    string `json:"version"`
+	// VCSTypes are the VCS hosts Atlantis is configured for, ex. Github.
+	VCSTypes []string `json:"vcs_types"`
+	// Period is how many seconds the counts are for.
+	Period int64 `json:"period_seconds"`
+	// Commands are the counts of project commands by name, ex. plan.
+	Commands map[string]int64 `json:"commands"`
+	// Errors are the counts of project commands that errored by class, ex.
+	// timeout.
+	Errors map[string]int64 `json:"errors"`
+}
+
+// TelemetryJob periodically reports anonymous usage telemetry to an endpoint.
+// Every report is logged in full before it's sent so operators can see
+// exactly what leaves their network.
+type TelemetryJob struct {
+	log        logging.SimpleLogging
+	counter    TelemetryCounter
+	endpoint   string
+	period     time.Duration
+	instanceID string
+	version    string
+	vcsTypes   []string
+	client     *http.Client
+	scope      tally.Scope
+}
+
+func NewTelemetryJob(
+	log logging.SimpleLogging,
+	counter TelemetryCounter,
+	endpoint string,
+	period time.Duration,
+	instanceID string,
+	version string,
+	vcsTypes []string,
+	statsScope tally.Scope,
+) *TelemetryJob {
+	return &TelemetryJob{
+		log:        log,
+		counter:    counter,
+		endpoint:   endpoint,
+		period:     period,
+		instanceID: instanceID,
+		version:    version,
+		vcsTypes:   vcsTypes,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		scope:      statsScope.SubScope("telemetry"),
+	}
+}
+
+func (j *TelemetryJob) Run() {
+	commands, errors := j.counter.Flush()
+	report := TelemetryReport{
+		InstanceID: j.instanceID,
+		Version:    j.version,
+		VCSTypes:   j.vcsTypes,
+		Period:     int64(j.period.Seconds()),
+		Commands:   commands,
+		Errors:     errors,
+	}
+	if err := j.send(report); err != nil {
+		j.log.Warn("sending telemetry: %s", err)
+		j.scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+		return
+	}
+	j.scope.Counter(metrics.ExecutionSuccessMetric).Inc(1)
+}
+
+func (j *TelemetryJob) send(report TelemetryReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	j.log.Info("sending telemetry to %s: %s", j.endpoint, body)
+	resp, err := j.client.Post(j.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", j.endpoint, resp.Status)
+	}
+	return nil
+}
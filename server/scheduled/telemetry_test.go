@@ -0,0 +1,61 @@
+package scheduled
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+type fakeTelemetryCounter struct {
+	commands map[string]int64
+	errors   map[string]int64
+}
+
+func (f *fakeTelemetryCounter) Flush() (map[string]int64, map[string]int64) {
+	return f.commands, f.errors
+}
+
+func TestTelemetryJob_Run(t *testing.T) {
+	var report TelemetryReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, http.MethodPost, r.Method)
+		Equals(t, "application/json", r.Header.Get("Content-Type"))
+		Ok(t, json.NewDecoder(r.Body).Decode(&report))
+	}))
+	defer server.Close()
+	counter := &fakeTelemetryCounter{
+		commands: map[string]int64{"plan": 3, "apply": 1},
+		errors:   map[string]int64{"timeout": 1},
+	}
+	scope := tally.NewTestScope("atlantis", nil)
+
+	NewTelemetryJob(logging.NewNoopLogger(t), counter, server.URL, 24*time.Hour, "id", "v1.0.0", []string{"Github"}, scope).Run()
+
+	Equals(t, TelemetryReport{
+		InstanceID: "id",
+		Version:    "v1.0.0",
+		VCSTypes:   []string{"Github"},
+		Period:     86400,
+		Commands:   map[string]int64{"plan": 3, "apply": 1},
+		Errors:     map[string]int64{"timeout": 1},
+	}, report)
+	Equals(t, int64(1), scope.Snapshot().Counters()["atlantis.telemetry.execution_success+"].Value())
+}
+
+func TestTelemetryJob_Run_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	scope := tally.NewTestScope("atlantis", nil)
+
+	NewTelemetryJob(logging.NewNoopLogger(t), &fakeTelemetryCounter{}, server.URL, time.Hour, "id", "v1.0.0", nil, scope).Run()
+
+	Equals(t, int64(1), scope.Snapshot().Counters()["atlantis.telemetry.execution_error+"].Value())
+}
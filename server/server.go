@@ -38,6 +38,12 @@ import (
 	tally "github.com/uber-go/tally/v4"
 	prometheus "github.com/uber-go/tally/v4/prometheus"
 	"github.com/urfave/negroni/v3"
+	"gocloud.dev/blob"
+	// The drivers of the object stores that plans can be stored in.
+	_ "gocloud.dev/blob/azureblob"
+	_ "gocloud.dev/blob/fileblob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/s3blob"
 
 	cfg "github.com/runatlantis/atlantis/server/core/config"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -48,6 +54,7 @@ import (
 	"github.com/runatlantis/atlantis/server/metrics"
 	"github.com/runatlantis/atlantis/server/scheduled"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/controllers"
@@ -56,12 +63,16 @@ import (
 	"github.com/runatlantis/atlantis/server/controllers/websocket"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/core/runtime"
+	runtimemodels "github.com/runatlantis/atlantis/server/core/runtime/models"
+	"github.com/runatlantis/atlantis/server/core/runtime/plugin"
 	"github.com/runatlantis/atlantis/server/core/runtime/policy"
 	"github.com/runatlantis/atlantis/server/core/terraform"
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/audit"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/vcs/adapter"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
 	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
@@ -83,9 +94,15 @@ const (
 	// binDirName is the name of the directory inside our data dir where
 	// we download binaries.
 	BinDirName = "bin"
+	// TerraformInitCacheDirName is the name of the dir inside our data dir
+	// where we cache the providers and modules downloaded by terraform init.
+	TerraformInitCacheDirName = "init-cache"
 	// terraformPluginCacheDir is the name of the dir inside our data dir
 	// where we tell terraform to cache plugins and modules.
 	TerraformPluginCacheDirName = "plugin-cache"
+	// PolicyBundlesDirName is the name of the dir inside our data dir where
+	// the OPA bundles of bundle policy sets are downloaded.
+	PolicyBundlesDirName = "policy-bundles"
 )
 
 // Server runs the Atlantis web server.
@@ -106,6 +123,9 @@ type Server struct {
 	VCSEventsController            *events_controllers.VCSEventsController
 	GithubAppController            *controllers.GithubAppController
 	LocksController                *controllers.LocksController
+	DriftController                *controllers.DriftController
+	PlanHistoryController          *controllers.PlanHistoryController
+	TFERunTaskController           *controllers.TFERunTaskController
 	StatusController               *controllers.StatusController
 	JobsController                 *controllers.JobsController
 	APIController                  *controllers.APIController
@@ -119,12 +139,25 @@ type Server struct {
 	KeyLastRefreshTime             time.Time
 	SSLCert                        *tls.Certificate
 	Drainer                        *events.Drainer
+	CommitStatusBatcher            *events.CommitStatusBatcher
 	WebAuthentication              bool
 	WebUsername                    string
 	WebPassword                    string
 	ProjectCmdOutputHandler        jobs.ProjectCommandOutputHandler
 	ScheduledExecutorService       *scheduled.ExecutorService
 	DisableGlobalApplyLock         bool
+	// ApplyQueue is the queue that limits concurrent applies. If nil,
+	// applies aren't limited.
+	ApplyQueue *events.ApplyQueue
+	// StepPlugins are the step plugins configured with --step-plugins. If
+	// nil, none are configured.
+	StepPlugins *plugin.Manager
+	// VCSAdapter is the adapter started for --vcs-adapter. If nil, none is
+	// configured.
+	VCSAdapter *adapter.Client
+	// StartupReconciler reconciles the working dirs with the database when
+	// the server starts. If nil, they aren't.
+	StartupReconciler *events.StartupReconciler
 }
 
 // Config holds config for server that isn't passed in by the user.
@@ -134,8 +167,11 @@ type Config struct {
 	AtlantisVersion           string
 	DefaultTFDistributionFlag string
 	DefaultTFVersionFlag      string
+	KubernetesPodTemplateFlag string
 	RepoConfigJSONFlag        string
 	SilenceForkPRErrorsFlag   string
+	StepPluginsFlag           string
+	VCSAdapterFlag            string
 }
 
 // WebhookConfig is nested within UserConfig. It's used to configure webhooks.
@@ -150,6 +186,9 @@ type WebhookConfig struct {
 	// that is being modified for this event. If the regex matches, we'll
 	// send the webhook, ex. "main.*".
 	BranchRegex string `mapstructure:"branch-regex"`
+	// When is an expression that must evaluate to true for the webhook to be
+	// sent, ex. `result == "failure"`.
+	When string `mapstructure:"when"`
 	// Kind is the type of webhook we should send, ex. slack or http.
 	Kind string `mapstructure:"kind"`
 	// Channel is the channel to send this webhook to. It only applies to
@@ -160,6 +199,26 @@ type WebhookConfig struct {
 	URL string `mapstructure:"url"`
 }
 
+// AuditSinkConfig is nested within UserConfig. It's used to configure the
+// sinks that audit events of commands are sent to.
+type AuditSinkConfig struct {
+	// Kind is the type of sink, ex. file or kafka.
+	Kind string `mapstructure:"kind"`
+	// Path is the file that events are appended to. It only applies to file
+	// sinks.
+	Path string `mapstructure:"path"`
+	// URL is where events are posted for http sinks, and the URL of the Kafka
+	// REST proxy for kafka sinks.
+	URL string `mapstructure:"url"`
+	// Topic is the Kafka topic. It only applies to kafka sinks.
+	Topic string `mapstructure:"topic"`
+	// LogGroup, LogStream and Region are the CloudWatch Logs stream that
+	// events are put in. They only apply to cloudwatch sinks.
+	LogGroup  string `mapstructure:"log-group"`
+	LogStream string `mapstructure:"log-stream"`
+	Region    string `mapstructure:"region"`
+}
+
 //go:embed static
 var staticAssets embed.FS
 
@@ -175,15 +234,51 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	}
 
 	var supportedVCSHosts []models.VCSHostType
+	// vcsHostTypes maps the hostnames of the VCS hosts that drift detection
+	// and scheduled applies can clone repos from to their type.
+	vcsHostTypes := make(map[string]models.VCSHostType)
 	var githubClient vcs.IGithubClient
 	var githubAppEnabled bool
 	var githubConfig vcs.GithubConfig
 	var githubCredentials vcs.GithubCredentials
 	var gitlabClient *vcs.GitlabClient
 	var bitbucketCloudClient *bitbucketcloud.Client
+	var bitbucketCloudTokens bitbucketcloud.TokenSource
 	var bitbucketServerClient *bitbucketserver.Client
 	var azuredevopsClient *vcs.AzureDevopsClient
+	var azuredevopsServicePrincipal *vcs.AzureDevopsServicePrincipal
 	var giteaClient *gitea.GiteaClient
+	// orgMembershipCheckers check organization memberships for the user
+	// allowlists of the VCS hosts that support them.
+	orgMembershipCheckers := make(map[models.VCSHostType]vcs.OrgMembershipChecker)
+	// parentPullFinders find the pull requests pull requests are stacked on
+	// for the VCS hosts that support it.
+	parentPullFinders := make(map[models.VCSHostType]vcs.ParentPullFinder)
+	// commentCleaners clean up the comments on closed pull requests for the
+	// VCS hosts that support it.
+	commentCleaners := make(map[models.VCSHostType]vcs.CommentCleaner)
+	// summaryCommenters maintain the summary comments of pull requests for
+	// the VCS hosts that support it.
+	summaryCommenters := make(map[models.VCSHostType]vcs.SummaryCommenter)
+	// githubEnvironmentGate gates applies on GitHub environment protection
+	// rules. It's nil unless GitHub is configured.
+	var githubEnvironmentGate *events.GithubEnvironmentGate
+	// githubChecksClient reports the status of projects as check runs. It's
+	// nil unless --gh-check-runs is set.
+	var githubChecksClient vcs.ChecksClient
+
+	statePushAdmins, err := events.NewUserAllowlist(userConfig.StatePushAdmins)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing --state-push-admins")
+	}
+	forkPRApprovers, err := events.NewUserAllowlist(userConfig.ForkPRApprovers)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing --fork-pr-approvers")
+	}
+	destroyApprovers, err := events.NewUserAllowlist(userConfig.DestroyApprovers)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing --destroy-approvers")
+	}
 
 	policyChecksEnabled := false
 	if userConfig.EnablePolicyChecksFlag {
@@ -195,6 +290,10 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Fork pull requests can't be approved if ok-to-test isn't allowed.
+	if userConfig.ForkPRRequireApproval && !slices.Contains(allowCommands, command.OkToTest) {
+		allowCommands = append(allowCommands, command.OkToTest)
+	}
 	disableApply := true
 	for _, allowCommand := range allowCommands {
 		if allowCommand == command.Apply {
@@ -208,6 +307,12 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	globalCfg := valid.NewGlobalCfgFromArgs(
 		valid.GlobalCfgArgs{
 			PolicyCheckEnabled: userConfig.EnablePolicyChecksFlag,
+			CommandTimeouts: valid.CommandTimeouts{
+				Plan:        time.Duration(userConfig.PlanTimeout) * time.Minute,
+				Apply:       time.Duration(userConfig.ApplyTimeout) * time.Minute,
+				PolicyCheck: time.Duration(userConfig.PolicyCheckTimeout) * time.Minute,
+				Custom:      time.Duration(userConfig.CustomCommandTimeout) * time.Minute,
+			},
 		})
 	if userConfig.RepoConfig != "" {
 		globalCfg, err = validator.ParseGlobalCfg(userConfig.RepoConfig, globalCfg)
@@ -228,17 +333,21 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	}
 
 	if userConfig.GithubUser != "" || userConfig.GithubAppID != 0 {
-		if userConfig.GithubAllowMergeableBypassApply {
-			githubConfig = vcs.GithubConfig{
-				AllowMergeableBypassApply: true,
-			}
+		githubConfig = vcs.GithubConfig{
+			AllowMergeableBypassApply: userConfig.GithubAllowMergeableBypassApply,
+			DeletePrevCommentChunks:   userConfig.GithubDeletePrevCommentChunks,
+			ResponseCacheTTL:          time.Duration(userConfig.GithubResponseCacheTTL) * time.Second,
 		}
 		supportedVCSHosts = append(supportedVCSHosts, models.Github)
+		vcsHostTypes[vcsHostname(userConfig.GithubHostname)] = models.Github
 		if userConfig.GithubUser != "" {
 			githubCredentials = &vcs.GithubUserCredentials{
 				User:      userConfig.GithubUser,
 				Token:     userConfig.GithubToken,
 				TokenFile: userConfig.GithubTokenFile,
+				TokenEnv:  githubTokenEnv(userConfig.GithubToken),
+				Scope:     statsScope.SubScope("github_user_token"),
+				Logger:    logger,
 			}
 		} else if userConfig.GithubAppID != 0 && userConfig.GithubAppKeyFile != "" {
 			privateKey, err := os.ReadFile(userConfig.GithubAppKeyFile)
@@ -251,6 +360,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 				Key:            privateKey,
 				Hostname:       userConfig.GithubHostname,
 				AppSlug:        userConfig.GithubAppSlug,
+				Scope:          statsScope.SubScope("github_app_token"),
 			}
 			githubAppEnabled = true
 		} else if userConfig.GithubAppID != 0 && userConfig.GithubAppKey != "" {
@@ -260,6 +370,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 				Key:            []byte(userConfig.GithubAppKey),
 				Hostname:       userConfig.GithubHostname,
 				AppSlug:        userConfig.GithubAppSlug,
+				Scope:          statsScope.SubScope("github_app_token"),
 			}
 			githubAppEnabled = true
 		}
@@ -271,9 +382,18 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		}
 
 		githubClient = vcs.NewInstrumentedGithubClient(rawGithubClient, statsScope, logger)
+		orgMembershipCheckers[models.Github] = rawGithubClient
+		parentPullFinders[models.Github] = rawGithubClient
+		commentCleaners[models.Github] = rawGithubClient
+		summaryCommenters[models.Github] = &vcs.ShadowModeSummaryCommenter{SummaryCommenter: rawGithubClient, Checker: globalCfg}
+		githubEnvironmentGate = &events.GithubEnvironmentGate{Client: rawGithubClient}
+		if userConfig.GithubCheckRuns {
+			githubChecksClient = rawGithubClient
+		}
 	}
 	if userConfig.GitlabUser != "" {
 		supportedVCSHosts = append(supportedVCSHosts, models.Gitlab)
+		vcsHostTypes[vcsHostname(userConfig.GitlabHostname)] = models.Gitlab
 		var err error
 
 		gitlabGroupAllowlistChecker, err := command.NewTeamAllowlistChecker(userConfig.GitlabGroupAllowlist)
@@ -281,12 +401,20 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			return nil, err
 		}
 
-		gitlabGroups := slices.Concat(gitlabGroupAllowlistChecker.AllTeams(), globalCfg.PolicySets.AllTeams())
+		gitlabGroups := slices.Concat(gitlabGroupAllowlistChecker.AllTeams(), globalCfg.PolicySets.AllTeams(), globalCfg.ApplyApproversTeams(), statePushAdmins.Teams, forkPRApprovers.Teams, destroyApprovers.Teams)
+		// Organizations of the group allowlist are checked with IsOrgMember.
+		gitlabGroups = slices.DeleteFunc(gitlabGroups, func(group string) bool {
+			return strings.HasPrefix(group, command.OrgTeamPrefix)
+		})
 		slices.Sort(gitlabGroups)
 		gitlabClient, err = vcs.NewGitlabClient(userConfig.GitlabHostname, userConfig.GitlabToken, slices.Compact(gitlabGroups), logger)
 		if err != nil {
 			return nil, err
 		}
+		orgMembershipCheckers[models.Gitlab] = gitlabClient
+		parentPullFinders[models.Gitlab] = gitlabClient
+		commentCleaners[models.Gitlab] = gitlabClient
+		summaryCommenters[models.Gitlab] = &vcs.ShadowModeSummaryCommenter{SummaryCommenter: gitlabClient, Checker: globalCfg}
 	}
 	if userConfig.BitbucketUser != "" {
 		if userConfig.BitbucketBaseURL == bitbucketcloud.BaseURL {
@@ -296,6 +424,16 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 				userConfig.BitbucketUser,
 				userConfig.BitbucketToken,
 				userConfig.AtlantisURL)
+			if userConfig.BitbucketOAuthClientID != "" {
+				bitbucketCloudTokens = &bitbucketcloud.OAuthConsumer{
+					ClientID:     userConfig.BitbucketOAuthClientID,
+					ClientSecret: userConfig.BitbucketOAuthClientSecret,
+				}
+			} else if userConfig.BitbucketTokenType == "access-token" {
+				bitbucketCloudTokens = bitbucketcloud.AccessToken(userConfig.BitbucketToken)
+			}
+			bitbucketCloudClient.Tokens = bitbucketCloudTokens
+			commentCleaners[models.BitbucketCloud] = bitbucketCloudClient
 		} else {
 			supportedVCSHosts = append(supportedVCSHosts, models.BitbucketServer)
 			var err error
@@ -308,19 +446,32 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			if err != nil {
 				return nil, errors.Wrapf(err, "setting up Bitbucket Server client")
 			}
+			commentCleaners[models.BitbucketServer] = bitbucketServerClient
 		}
 	}
 	if userConfig.AzureDevopsUser != "" {
 		supportedVCSHosts = append(supportedVCSHosts, models.AzureDevops)
 
 		var err error
-		azuredevopsClient, err = vcs.NewAzureDevopsClient(userConfig.AzureDevOpsHostname, userConfig.AzureDevopsUser, userConfig.AzureDevopsToken)
+		if userConfig.AzureDevopsClientID != "" {
+			azuredevopsServicePrincipal = &vcs.AzureDevopsServicePrincipal{
+				TenantID:           userConfig.AzureDevopsTenantID,
+				ClientID:           userConfig.AzureDevopsClientID,
+				ClientSecret:       userConfig.AzureDevopsClientSecret,
+				FederatedTokenFile: userConfig.AzureDevopsOIDCTokenFile,
+			}
+			azuredevopsClient, err = vcs.NewAzureDevopsServicePrincipalClient(userConfig.AzureDevOpsHostname, userConfig.AzureDevopsUser, azuredevopsServicePrincipal)
+		} else {
+			azuredevopsClient, err = vcs.NewAzureDevopsClient(userConfig.AzureDevOpsHostname, userConfig.AzureDevopsUser, userConfig.AzureDevopsToken)
+		}
 		if err != nil {
 			return nil, err
 		}
+		commentCleaners[models.AzureDevops] = azuredevopsClient
 	}
 	if userConfig.GiteaToken != "" {
 		supportedVCSHosts = append(supportedVCSHosts, models.Gitea)
+		vcsHostTypes[vcsHostname(userConfig.GiteaBaseURL)] = models.Gitea
 
 		giteaClient, err = gitea.NewClient(userConfig.GiteaBaseURL, userConfig.GiteaUser, userConfig.GiteaToken, userConfig.GiteaPageSize, logger)
 		if err != nil {
@@ -329,6 +480,18 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		} else {
 			logger.Info("gitea client configured successfully")
 		}
+		commentCleaners[models.Gitea] = giteaClient
+	}
+
+	var vcsAdapter *adapter.Client
+	if userConfig.VCSAdapter != "" {
+		supportedVCSHosts = append(supportedVCSHosts, models.VCSAdapter)
+
+		var err error
+		vcsAdapter, err = adapter.Start(logger, userConfig.VCSAdapter)
+		if err != nil {
+			return nil, errors.Wrapf(err, "starting --%s", config.VCSAdapterFlag)
+		}
 	}
 
 	var supportedVCSHostsStr []string
@@ -354,7 +517,10 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 				return nil, err
 			}
 		}
-		if userConfig.BitbucketUser != "" {
+		// Git authenticates with access tokens as a different user, and
+		// OAuth access tokens expire, so they're written by a scheduled job
+		// below.
+		if userConfig.BitbucketUser != "" && bitbucketCloudTokens == nil {
 			// The default BitbucketBaseURL is https://api.bitbucket.org which can't actually be used for git
 			// so we override it here only if it's that to be bitbucket.org
 			bitbucketBaseURL := userConfig.BitbucketBaseURL
@@ -365,7 +531,9 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 				return nil, err
 			}
 		}
-		if userConfig.AzureDevopsUser != "" {
+		// The git credentials of a service principal expire, they're written
+		// by a scheduled job below.
+		if userConfig.AzureDevopsUser != "" && azuredevopsServicePrincipal == nil {
 			if err := vcs.WriteGitCreds(userConfig.AzureDevopsUser, userConfig.AzureDevopsToken, "dev.azure.com", home, logger, false); err != nil {
 				return nil, err
 			}
@@ -391,6 +559,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			Event:          c.Event,
 			Kind:           c.Kind,
 			WorkspaceRegex: c.WorkspaceRegex,
+			When:           c.When,
 			URL:            c.URL,
 		}
 		webhooksConfig = append(webhooksConfig, config)
@@ -409,8 +578,32 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "initializing webhooks")
 	}
-	vcsClient := vcs.NewClientProxy(githubClient, gitlabClient, bitbucketCloudClient, bitbucketServerClient, azuredevopsClient, giteaClient)
+	// Nothing is written to the pull requests of repos in shadow mode.
+	clientProxy := vcs.NewClientProxy(githubClient, gitlabClient, bitbucketCloudClient, bitbucketServerClient, azuredevopsClient, giteaClient)
+	if vcsAdapter != nil {
+		clientProxy.Register(models.VCSAdapter, vcsAdapter)
+	}
+	vcsClient := vcs.NewShadowModeClient(clientProxy, globalCfg)
+	// userMemberships looks up the teams and organizations of users for all
+	// the allowlists and policy owners so that they share its cache.
+	userMemberships := &events.UserMemberships{
+		TeamNamesGetter:       vcsClient,
+		OrgMembershipCheckers: orgMembershipCheckers,
+	}
+	statePushAdmins.Memberships = userMemberships
+	forkPRApprovers.Memberships = userMemberships
+	destroyApprovers.Memberships = userMemberships
 	commitStatusUpdater := &events.DefaultCommitStatusUpdater{Client: vcsClient, StatusName: userConfig.VCSStatusName}
+	if githubChecksClient != nil {
+		commitStatusUpdater.ChecksClient = &vcs.ShadowModeChecksClient{ChecksClient: githubChecksClient, Checker: globalCfg}
+	}
+	if userConfig.VCSStatusDebounce > 0 {
+		commitStatusUpdater.Batcher = &events.CommitStatusBatcher{
+			Client:   vcsClient,
+			Debounce: time.Duration(userConfig.VCSStatusDebounce) * time.Millisecond,
+			Scope:    statsScope.SubScope("commit_status"),
+		}
+	}
 
 	binDir, err := mkSubDir(userConfig.DataDir, BinDirName)
 
@@ -424,6 +617,15 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		return nil, err
 	}
 
+	var initCache *runtime.InitCache
+	if userConfig.UseTFInitCache {
+		initCacheDir, err := mkSubDir(userConfig.DataDir, TerraformInitCacheDirName)
+		if err != nil {
+			return nil, err
+		}
+		initCache = &runtime.InitCache{Dir: initCacheDir, MaxAge: runtime.DefaultInitCacheMaxAge}
+	}
+
 	parsedURL, err := ParseAtlantisURL(userConfig.AtlantisURL)
 	if err != nil {
 		return nil, errors.Wrapf(err,
@@ -439,20 +641,66 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		Underlying:                underlyingRouter,
 	}
 
+	var lockingClient locking.Locker
+	var applyLockingClient locking.ApplyLocker
+	var backend locking.Backend
+	// dbCompactor is only set when using BoltDB.
+	var dbCompactor scheduled.DBCompactor
+
+	switch dbtype := userConfig.LockingDBType; dbtype {
+	case "redis":
+		logger.Info("Utilizing Redis DB")
+		backend, err = redis.New(userConfig.RedisHost, userConfig.RedisPort, userConfig.RedisPassword, userConfig.RedisTLSEnabled, userConfig.RedisInsecureSkipVerify, userConfig.RedisDB)
+		if err != nil {
+			return nil, err
+		}
+	case "boltdb":
+		logger.Info("Utilizing BoltDB")
+		var boltDB *db.BoltDB
+		boltDB, err = db.New(userConfig.DataDir)
+		if err != nil {
+			return nil, err
+		}
+		backend = boltDB
+		dbCompactor = boltDB
+	}
+
 	var projectCmdOutputHandler jobs.ProjectCommandOutputHandler
+	jobCanceler := jobs.NewJobCanceler()
 
 	if userConfig.TFEToken != "" && !userConfig.TFELocalExecutionMode {
 		// When TFE is enabled and using remote execution mode log streaming is not necessary.
 		projectCmdOutputHandler = &jobs.NoopProjectOutputHandler{}
 	} else {
 		projectCmdOutput := make(chan *jobs.ProjectCmdOutputLine)
-		projectCmdOutputHandler = jobs.NewAsyncProjectCommandOutputHandler(
-			projectCmdOutput,
-			logger,
-		)
+		if userConfig.PersistJobOutput {
+			projectCmdOutputHandler = jobs.NewPersistentProjectCommandOutputHandler(
+				projectCmdOutput,
+				logger,
+				backend,
+			)
+		} else {
+			projectCmdOutputHandler = jobs.NewAsyncProjectCommandOutputHandler(
+				projectCmdOutput,
+				logger,
+			)
+		}
 	}
 
-	distribution := terraform.NewDistribution(userConfig.DefaultTFDistribution)
+	distribution := terraform.DistributionFactory{TofuDownloadURL: userConfig.TofuDownloadURL}.NewDistribution(userConfig.DefaultTFDistribution)
+
+	// A nil executor runs the shell commands of steps on this server.
+	var executor runtimemodels.Executor
+	if userConfig.Executor == "kubernetes" {
+		executor, err = runtimemodels.NewKubernetesExecutor(
+			userConfig.KubernetesExecutorPodTemplate,
+			userConfig.KubernetesExecutorNamespace,
+			userConfig.DataDir,
+			userConfig.KubernetesExecutorDataDirClaim)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading --%s", config.KubernetesPodTemplateFlag)
+		}
+	}
 
 	terraformClient, err := tfclient.NewClient(
 		logger,
@@ -464,9 +712,11 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		userConfig.DefaultTFVersion,
 		config.DefaultTFVersionFlag,
 		userConfig.TFDownloadURL,
+		userConfig.TofuDownloadURL,
 		userConfig.TFDownload,
 		userConfig.UseTFPluginCache,
-		projectCmdOutputHandler)
+		projectCmdOutputHandler,
+		executor)
 	// The flag.Lookup call is to detect if we're running in a unit test. If we
 	// are, then we don't error out because we don't have/want terraform
 	// installed on our CI system where the unit tests run.
@@ -486,25 +736,6 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		userConfig.QuietPolicyChecks,
 	)
 
-	var lockingClient locking.Locker
-	var applyLockingClient locking.ApplyLocker
-	var backend locking.Backend
-
-	switch dbtype := userConfig.LockingDBType; dbtype {
-	case "redis":
-		logger.Info("Utilizing Redis DB")
-		backend, err = redis.New(userConfig.RedisHost, userConfig.RedisPort, userConfig.RedisPassword, userConfig.RedisTLSEnabled, userConfig.RedisInsecureSkipVerify, userConfig.RedisDB)
-		if err != nil {
-			return nil, err
-		}
-	case "boltdb":
-		logger.Info("Utilizing BoltDB")
-		backend, err = db.New(userConfig.DataDir)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	noOpLocker := locking.NewNoOpLocker()
 	if userConfig.DisableRepoLocking {
 		logger.Info("Repo Locking is disabled")
@@ -520,11 +751,23 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	applyLockingClient = locking.NewApplyClient(backend, disableApply, disableGlobalApplyLock)
 	workingDirLocker := events.NewDefaultWorkingDirLocker()
 
+	gitCredentials := &events.GitCredentialsResolver{GlobalCfg: globalCfg}
+	var sparseCheckoutDirs []string
+	for _, dir := range strings.Split(userConfig.SparseCheckoutDirs, ",") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			sparseCheckoutDirs = append(sparseCheckoutDirs, dir)
+		}
+	}
 	var workingDir events.WorkingDir = &events.FileWorkspace{
-		DataDir:          userConfig.DataDir,
-		CheckoutMerge:    userConfig.CheckoutStrategy == "merge",
-		CheckoutDepth:    userConfig.CheckoutDepth,
-		GithubAppEnabled: githubAppEnabled,
+		DataDir:            userConfig.DataDir,
+		CheckoutMerge:      userConfig.CheckoutStrategy == "merge",
+		CheckoutDepth:      userConfig.CheckoutDepth,
+		GithubAppEnabled:   githubAppEnabled,
+		GitCredentials:     gitCredentials,
+		CheckoutFilter:     userConfig.CheckoutFilter,
+		SparseCheckout:     userConfig.SparseCheckout,
+		SparseCheckoutDirs: sparseCheckoutDirs,
+		VCSClient:          vcsClient,
 	}
 
 	scheduledExecutorService := scheduled.NewExecutorService(
@@ -549,6 +792,35 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			return nil, errors.Wrap(err, "could not write credentials")
 		}
 		scheduledExecutorService.AddJob(tokenJd)
+
+		githubAppTokens, err := githubCredentials.(*vcs.GithubAppCredentials).TokenManager()
+		if err != nil {
+			return nil, errors.Wrap(err, "initializing github app token manager")
+		}
+		scheduledExecutorService.AddJob(vcs.NewGithubAppTokenRefreshJob(logger, githubAppTokens))
+		gitCredentials.GithubAppTokens = githubAppTokens
+	} else {
+		for _, repo := range globalCfg.Repos {
+			if repo.GitCredentials != nil && repo.GitCredentials.GithubAppInstallationID != 0 {
+				return nil, fmt.Errorf("git_credentials of repo %q use github_app_installation_id but no GitHub App is configured", repo.IDString())
+			}
+		}
+	}
+
+	if bitbucketCloudTokens != nil && userConfig.WriteGitCreds {
+		gitCredsJd, err := vcs.NewGitCredsRefreshJob(logger, bitbucketCloudTokens, bitbucketcloud.AccessTokenGitUser, "bitbucket.org", home)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not write bitbucket git credentials")
+		}
+		scheduledExecutorService.AddJob(gitCredsJd)
+	}
+
+	if azuredevopsServicePrincipal != nil && userConfig.WriteGitCreds {
+		gitCredsJd, err := vcs.NewGitCredsRefreshJob(logger, azuredevopsServicePrincipal, userConfig.AzureDevopsUser, "dev.azure.com", home)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not write azure devops git credentials")
+		}
+		scheduledExecutorService.AddJob(gitCredsJd)
 	}
 
 	if userConfig.GithubUser != "" && userConfig.GithubTokenFile != "" && userConfig.WriteGitCreds {
@@ -565,24 +837,49 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		NoOpLocker: noOpLocker,
 		VCSClient:  vcsClient,
 	}
+	var planCache events.PlanCache
+	if userConfig.EnablePlanCache {
+		planCache = &events.DefaultPlanCache{DataDir: userConfig.DataDir}
+	}
+	var planStore events.PlanStore
+	if userConfig.PlanStoreURL != "" {
+		bucket, err := blob.OpenBucket(context.Background(), userConfig.PlanStoreURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "opening plan store")
+		}
+		planStore = &events.BlobPlanStore{Bucket: bucket}
+	}
+	var planHistory *events.PlanHistory
+	if userConfig.EnablePlanHistory || userConfig.PlanDiffComments {
+		planHistory = &events.PlanHistory{DataDir: userConfig.DataDir}
+	}
 	deleteLockCommand := &events.DefaultDeleteLockCommand{
 		Locker:           lockingClient,
 		WorkingDir:       workingDir,
 		WorkingDirLocker: workingDirLocker,
 		Backend:          backend,
+		PlanStore:        planStore,
 	}
 
+	// The destroy on close runner is set once the command runners are
+	// created.
+	basePullClosedExecutor := &events.PullClosedExecutor{
+		Locker:                   lockingClient,
+		WorkingDir:               workingDir,
+		Backend:                  backend,
+		PullClosedTemplate:       &events.PullClosedEventTemplate{},
+		LogStreamResourceCleaner: projectCmdOutputHandler,
+		VCSClient:                vcsClient,
+		PlanCache:                planCache,
+		PlanHistory:              planHistory,
+		PlanStore:                planStore,
+		CommentCleanup:           events.CommentCleanupMode(userConfig.PullClosedComments),
+		CommentCleaners:          commentCleaners,
+	}
 	pullClosedExecutor := events.NewInstrumentedPullClosedExecutor(
 		statsScope,
 		logger,
-		&events.PullClosedExecutor{
-			Locker:                   lockingClient,
-			WorkingDir:               workingDir,
-			Backend:                  backend,
-			PullClosedTemplate:       &events.PullClosedEventTemplate{},
-			LogStreamResourceCleaner: projectCmdOutputHandler,
-			VCSClient:                vcsClient,
-		},
+		basePullClosedExecutor,
 	)
 
 	eventParser := &events.EventParser{
@@ -599,6 +896,10 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		BitbucketServerURL: userConfig.BitbucketBaseURL,
 		AzureDevopsUser:    userConfig.AzureDevopsUser,
 		AzureDevopsToken:   userConfig.AzureDevopsToken,
+
+		AzureDevopsServicePrincipal: azuredevopsServicePrincipal,
+		BitbucketCloudTokens:        bitbucketCloudTokens,
+		ForkPRApprovalLabel:         userConfig.ForkPRApprovalLabel,
 	}
 	commentParser := events.NewCommentParser(
 		userConfig.GithubUser,
@@ -609,6 +910,10 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		userConfig.ExecutableName,
 		allowCommands,
 	)
+	if vcsAdapter != nil {
+		commentParser.VCSAdapterUser = vcsAdapter.User()
+	}
+	commentParser.CustomCommands = globalCfg.CustomCommands
 	defaultTfDistribution := terraformClient.DefaultDistribution()
 	defaultTfVersion := terraformClient.DefaultVersion()
 	pendingPlanFinder := &events.DefaultPendingPlanFinder{}
@@ -618,6 +923,20 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		DefaultTFVersion:        defaultTfVersion,
 		TerraformBinDir:         terraformClient.TerraformBinDir(),
 		ProjectCmdOutputHandler: projectCmdOutputHandler,
+		Executor:                executor,
+	}
+	var stepPlugins *plugin.Manager
+	var pluginStepRunner events.PluginStepRunner
+	if userConfig.StepPlugins != "" {
+		pluginPaths, err := plugin.ParsePaths(userConfig.StepPlugins)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing --%s", config.StepPluginsFlag)
+		}
+		stepPlugins = plugin.NewManager(pluginPaths, logger)
+		pluginStepRunner = &runtime.PluginStepRunner{
+			Plugins:                 stepPlugins,
+			ProjectCmdOutputHandler: projectCmdOutputHandler,
+		}
 	}
 	drainer := &events.Drainer{}
 	statusController := &controllers.StatusController{
@@ -647,7 +966,10 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		CommitStatusUpdater: commitStatusUpdater,
 		Router:              router,
 	}
-	projectCommandBuilder := events.NewInstrumentedProjectCommandBuilder(
+	projectLocker.EventHooks = postWorkflowHooksCommandRunner
+	deleteLockCommand.EventHooks = postWorkflowHooksCommandRunner
+	basePullClosedExecutor.EventHooks = postWorkflowHooksCommandRunner
+	instrumentedProjectCommandBuilder := events.NewInstrumentedProjectCommandBuilder(
 		logger,
 		policyChecksEnabled,
 		validator,
@@ -658,6 +980,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		globalCfg,
 		pendingPlanFinder,
 		commentParser,
+		planStore,
 		userConfig.SkipCloneNoChanges,
 		userConfig.EnableRegExpCmd,
 		userConfig.Automerge,
@@ -672,6 +995,15 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		statsScope,
 		terraformClient,
 	)
+	projectCommandBuilder := &events.HookedProjectCommandBuilder{
+		ProjectCommandBuilder: instrumentedProjectCommandBuilder,
+	}
+	if userConfig.ProjectCommandHookURL != "" {
+		projectCommandBuilder.Hook = &events.ProjectCommandHook{
+			Client: &http.Client{Timeout: time.Minute},
+			URL:    userConfig.ProjectCommandHookURL,
+		}
+	}
 
 	showStepRunner, err := runtime.NewShowStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion)
 
@@ -679,10 +1011,15 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		return nil, errors.Wrap(err, "initializing show step runner")
 	}
 
+	policyBundlesDir, err := mkSubDir(userConfig.DataDir, PolicyBundlesDirName)
+	if err != nil {
+		return nil, err
+	}
+	policyExecutor := policy.NewConfTestExecutorWorkflow(logger, binDir, policyBundlesDir, &policy.ConfTestGoGetterVersionDownloader{})
 	policyCheckStepRunner, err := runtime.NewPolicyCheckStepRunner(
 		defaultTfDistribution,
 		defaultTfVersion,
-		policy.NewConfTestExecutorWorkflow(logger, binDir, &policy.ConfTestGoGetterVersionDownloader{}),
+		policyExecutor,
 	)
 
 	if err != nil {
@@ -695,6 +1032,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 
 	projectCommandRunner := &events.DefaultProjectCommandRunner{
 		VcsClient:        vcsClient,
+		UserMemberships:  userMemberships,
 		Locker:           projectLocker,
 		LockURLGenerator: router,
 		Logger:           logger,
@@ -702,6 +1040,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			TerraformExecutor:     terraformClient,
 			DefaultTFDistribution: defaultTfDistribution,
 			DefaultTFVersion:      defaultTfVersion,
+			Cache:                 initCache,
 		},
 		PlanStepRunner:        runtime.NewPlanStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion, commitStatusUpdater, terraformClient),
 		ShowStepRunner:        showStepRunner,
@@ -720,16 +1059,43 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		MultiEnvStepRunner: &runtime.MultiEnvStepRunner{
 			RunStepRunner: runStepRunner,
 		},
+		PluginStepRunner:          pluginStepRunner,
+		WorkspaceSelectStepRunner: runtime.NewWorkspaceSelectStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
+		VarFileStepRunner:         &runtime.VarFileStepRunner{},
+		FormatCheckStepRunner: &runtime.FormatCheckStepRunner{
+			TerraformExecutor:     terraformClient,
+			DefaultTFDistribution: defaultTfDistribution,
+			DefaultTFVersion:      defaultTfVersion,
+		},
+		AssumeRoleStepRunner:   &runtime.AssumeRoleStepRunner{},
+		CostEstimateStepRunner: runtime.NewCostEstimateStepRunner(binDir, showStepRunner, &policy.ConfTestGoGetterVersionDownloader{}),
+		SecretResolver:         &runtime.SecretResolver{},
+		PlanChangeSuppressor:   &runtime.PlanChangeSuppressor{ShowStepRunner: showStepRunner},
+		TerragruntStepRunner: &runtime.TerragruntStepRunner{
+			DefaultTFDistribution: defaultTfDistribution,
+			DefaultTFVersion:      defaultTfVersion,
+			RunStepRunner:         runStepRunner,
+		},
 		VersionStepRunner: &runtime.VersionStepRunner{
-			TerraformExecutor: terraformClient,
-			DefaultTFVersion:  defaultTfVersion,
+			TerraformExecutor:      terraformClient,
+			DefaultTFDistribution:  defaultTfDistribution,
+			DefaultTFVersion:       defaultTfVersion,
+			DefaultConftestVersion: policyExecutor.DefaultConftestVersion,
 		},
 		ImportStepRunner:          runtime.NewImportStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
 		StateRmStepRunner:         runtime.NewStateRmStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
+		StatePullStepRunner:       runtime.NewStatePullStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
+		StatePushStepRunner:       runtime.NewStatePushStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
 		WorkingDir:                workingDir,
 		Webhooks:                  webhooksManager,
 		WorkingDirLocker:          workingDirLocker,
 		CommandRequirementHandler: applyRequirementHandler,
+		PlanCache:                 planCache,
+		PlanHistory:               planHistory,
+		PlanDiffComments:          userConfig.PlanDiffComments,
+		GithubEnvironmentGate:     githubEnvironmentGate,
+		PlanStore:                 planStore,
+		JobCanceler:               jobCanceler,
 	}
 
 	dbUpdater := &events.DBUpdater{
@@ -741,28 +1107,95 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		VCSClient:            vcsClient,
 		MarkdownRenderer:     markdownRenderer,
 	}
+	if userConfig.SummaryComment {
+		pullUpdater.SummaryComments = &events.SummaryCommentUpdater{
+			Commenters:       summaryCommenters,
+			MarkdownRenderer: markdownRenderer,
+		}
+	}
 
 	autoMerger := &events.AutoMerger{
 		VCSClient:       vcsClient,
 		GlobalAutomerge: userConfig.Automerge,
 	}
 
+	var circuitBreaker *events.CircuitBreaker
+	var breakingProjectCommandRunner events.ProjectCommandRunner = projectCommandRunner
+	if userConfig.CircuitBreakerThreshold > 0 {
+		circuitBreaker = events.NewCircuitBreaker(
+			userConfig.CircuitBreakerThreshold,
+			time.Duration(userConfig.CircuitBreakerCooldown)*time.Minute,
+			statsScope,
+		)
+		breakingProjectCommandRunner = &events.CircuitBreakerProjectCommandRunner{
+			ProjectCommandRunner: projectCommandRunner,
+			CircuitBreaker:       circuitBreaker,
+		}
+	}
+
+	var telemetryRecorder *events.TelemetryRecorder
+	if userConfig.TelemetryEndpoint != "" {
+		telemetryRecorder = events.NewTelemetryRecorder()
+		breakingProjectCommandRunner = &events.TelemetryProjectCommandRunner{
+			ProjectCommandRunner: breakingProjectCommandRunner,
+			Recorder:             telemetryRecorder,
+		}
+	}
+
+	if len(userConfig.AuditSinks) > 0 {
+		var auditConfigs []audit.Config
+		for _, c := range userConfig.AuditSinks {
+			auditConfigs = append(auditConfigs, audit.Config{
+				Kind:      c.Kind,
+				Path:      c.Path,
+				URL:       c.URL,
+				Topic:     c.Topic,
+				LogGroup:  c.LogGroup,
+				LogStream: c.LogStream,
+				Region:    c.Region,
+			})
+		}
+		auditSink, err := audit.NewMultiSink(auditConfigs, audit.Clients{
+			Http:    &http.Client{Timeout: 10 * time.Second},
+			Headers: webhookHeaders,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "initializing audit sinks")
+		}
+		breakingProjectCommandRunner = &events.AuditProjectCommandRunner{
+			ProjectCommandRunner: breakingProjectCommandRunner,
+			Sink:                 auditSink,
+		}
+	}
+
+	var applyQueue *events.ApplyQueue
+	if userConfig.MaxConcurrentApplies > 0 || userConfig.MaxConcurrentAppliesPerRepo > 0 {
+		applyQueue = events.NewApplyQueue(userConfig.MaxConcurrentAppliesPerRepo, userConfig.MaxConcurrentApplies)
+		breakingProjectCommandRunner = &events.ApplyQueueProjectCommandRunner{
+			ProjectCommandRunner: breakingProjectCommandRunner,
+			ApplyQueue:           applyQueue,
+			VCSClient:            vcsClient,
+		}
+	}
+
 	projectOutputWrapper := &events.ProjectOutputWrapper{
 		JobMessageSender:     projectCmdOutputHandler,
-		ProjectCommandRunner: projectCommandRunner,
+		ProjectCommandRunner: breakingProjectCommandRunner,
 		JobURLSetter:         jobs.NewJobURLSetter(router, commitStatusUpdater),
+		JobURLGenerator:      router,
 	}
 	instrumentedProjectCmdRunner := events.NewInstrumentedProjectCommandRunner(
 		statsScope,
 		projectOutputWrapper,
 	)
 
+	parallelPoolSize := events.NewParallelPoolSize(userConfig.ParallelPoolSize)
 	policyCheckCommandRunner := events.NewPolicyCheckCommandRunner(
 		dbUpdater,
 		pullUpdater,
 		commitStatusUpdater,
 		instrumentedProjectCmdRunner,
-		userConfig.ParallelPoolSize,
+		parallelPoolSize,
 		userConfig.SilenceVCSStatusNoProjects,
 		userConfig.QuietPolicyChecks,
 	)
@@ -781,13 +1214,14 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		pullUpdater,
 		policyCheckCommandRunner,
 		autoMerger,
-		userConfig.ParallelPoolSize,
+		parallelPoolSize,
 		userConfig.SilenceNoProjects,
 		backend,
 		lockingClient,
 		userConfig.DiscardApprovalOnPlanFlag,
 		pullReqStatusFetcher,
 	)
+	planCommandRunner.PlanStore = planStore
 
 	applyCommandRunner := events.NewApplyCommandRunner(
 		vcsClient,
@@ -800,11 +1234,18 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		pullUpdater,
 		dbUpdater,
 		backend,
-		userConfig.ParallelPoolSize,
+		parallelPoolSize,
 		userConfig.SilenceNoProjects,
 		userConfig.SilenceVCSStatusNoProjects,
 		pullReqStatusFetcher,
 	)
+	applyCommandRunner.PromotionPlanner = planCommandRunner
+	applyCommandRunner.MaintenanceWindows = globalCfg
+	applyCommandRunner.ApplyApprovers = globalCfg
+	applyCommandRunner.UserMemberships = userMemberships
+	if userConfig.ReplanDependents {
+		applyCommandRunner.DependentsPlanner = planCommandRunner
+	}
 
 	approvePoliciesCommandRunner := events.NewApprovePoliciesCommandRunner(
 		commitStatusUpdater,
@@ -828,7 +1269,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		pullUpdater,
 		projectCommandBuilder,
 		projectOutputWrapper,
-		userConfig.ParallelPoolSize,
+		parallelPoolSize,
 		userConfig.SilenceNoProjects,
 	)
 
@@ -844,6 +1285,34 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		pullUpdater,
 		projectCommandBuilder,
 		instrumentedProjectCmdRunner,
+		&events.StateAuditLog{Path: filepath.Join(userConfig.DataDir, events.StateAuditLogFileName)},
+		statePushAdmins,
+		globalCfg,
+	)
+
+	customCommandRunner := events.NewCustomCommandRunner(
+		pullUpdater,
+		projectCommandBuilder,
+		instrumentedProjectCmdRunner,
+		userConfig.SilenceNoProjects,
+	)
+
+	destroyCommandRunner := events.NewDestroyCommandRunner(
+		vcsClient,
+		pullUpdater,
+		dbUpdater,
+		pullReqStatusFetcher,
+		projectCommandBuilder,
+		instrumentedProjectCmdRunner,
+		planCommandRunner,
+		applyCommandRunner,
+		destroyApprovers,
+	)
+
+	okToTestCommandRunner := events.NewOkToTestCommandRunner(
+		vcsClient,
+		backend,
+		forkPRApprovers,
 	)
 
 	commentCommandRunnerByCmd := map[command.Name]events.CommentCommandRunner{
@@ -854,6 +1323,9 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		command.Version:         versionCommandRunner,
 		command.Import:          importCommandRunner,
 		command.State:           stateCommandRunner,
+		command.OkToTest:        okToTestCommandRunner,
+		command.Custom:          customCommandRunner,
+		command.Destroy:         destroyCommandRunner,
 	}
 
 	var teamAllowlistChecker command.TeamAllowlistChecker
@@ -896,6 +1368,9 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		AllowForkPRsFlag:               config.AllowForkPRsFlag,
 		SilenceForkPRErrors:            userConfig.SilenceForkPRErrors,
 		SilenceForkPRErrorsFlag:        config.SilenceForkPRErrorsFlag,
+		ForkPRRequireApproval:          userConfig.ForkPRRequireApproval,
+		ForkPRApprovalLabel:            userConfig.ForkPRApprovalLabel,
+		ExecutableName:                 userConfig.ExecutableName,
 		DisableAutoplan:                userConfig.DisableAutoplan,
 		DisableAutoplanLabel:           userConfig.DisableAutoplanLabel,
 		Drainer:                        drainer,
@@ -903,8 +1378,22 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		PostWorkflowHooksCommandRunner: postWorkflowHooksCommandRunner,
 		PullStatusFetcher:              backend,
 		TeamAllowlistChecker:           teamAllowlistChecker,
+		UserMemberships:                userMemberships,
 		VarFileAllowlistChecker:        varFileAllowlistChecker,
 		CommitStatusUpdater:            commitStatusUpdater,
+		CircuitBreaker:                 circuitBreaker,
+	}
+	if len(parentPullFinders) > 0 {
+		commandRunner.StackedPullChecker = &events.StackedPullChecker{
+			GlobalCfg:         globalCfg,
+			ParentPullFinders: parentPullFinders,
+		}
+	}
+	if gitlabClient != nil {
+		commandRunner.GitlabCIJobWaiter = &events.GitlabCIJobWaiter{
+			Client:    gitlabClient,
+			GlobalCfg: globalCfg,
+		}
 	}
 	repoAllowlist, err := events.NewRepoAllowlistChecker(userConfig.RepoAllowlist)
 	if err != nil {
@@ -923,6 +1412,33 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		Backend:            backend,
 		DeleteLockCommand:  deleteLockCommand,
 	}
+	driftController := &controllers.DriftController{
+		AtlantisVersion:     config.AtlantisVersion,
+		AtlantisURL:         parsedURL,
+		Logger:              logger,
+		Backend:             backend,
+		DriftTemplate:       web_templates.DriftTemplate,
+		DriftOutputTemplate: web_templates.DriftOutputTemplate,
+	}
+	planHistoryController := &controllers.PlanHistoryController{
+		AtlantisVersion:     config.AtlantisVersion,
+		AtlantisURL:         parsedURL,
+		Logger:              logger,
+		PlanHistory:         planHistory,
+		PlanHistoryTemplate: web_templates.PlanHistoryTemplate,
+	}
+
+	var tfeRunTaskController *controllers.TFERunTaskController
+	if userConfig.TFERunTaskHMACKey != "" {
+		tfeRunTaskController = &controllers.TFERunTaskController{
+			Logger:         logger,
+			HMACKey:        []byte(userConfig.TFERunTaskHMACKey),
+			TFEHostname:    userConfig.TFEHostname,
+			PolicySets:     globalCfg.PolicySets,
+			PolicyExecutor: policyExecutor,
+			Drainer:        drainer,
+		}
+	}
 
 	wsMux := websocket.NewMultiplexor(
 		logger,
@@ -941,25 +1457,212 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		WsMux:                    wsMux,
 		KeyGenerator:             controllers.JobIDKeyGenerator{},
 		StatsScope:               statsScope.SubScope("api"),
+		JobsTemplate:             web_templates.JobsTemplate,
+		ProjectCmdOutputHandler:  projectCmdOutputHandler,
+		JobCanceler:              jobCanceler,
+		WebAuthentication:        userConfig.WebBasicAuth,
+		APISecret:                []byte(userConfig.APISecret),
+	}
+	var repoConfigReport *scheduled.RepoConfigReport
+	if userConfig.RepoConfigValidationInterval > 0 {
+		repoConfigReport = &scheduled.RepoConfigReport{}
+		repoConfigValidator := &events.DefaultRepoConfigValidator{
+			GlobalCfg:        globalCfg,
+			Logger:           logger,
+			Parser:           eventParser,
+			ParserValidator:  validator,
+			Scope:            statsScope.SubScope("repo_config_validation"),
+			VCSClient:        vcsClient,
+			VCSHostTypes:     vcsHostTypes,
+			WorkingDir:       workingDir,
+			WorkingDirLocker: workingDirLocker,
+		}
+		scheduledExecutorService.AddJob(scheduled.JobDefinition{
+			Job:    scheduled.NewRepoConfigValidationJob(logger, globalCfg.RepoIDs(), backend, repoConfigValidator, repoConfigReport, statsScope),
+			Period: time.Duration(userConfig.RepoConfigValidationInterval) * time.Minute,
+		})
+	}
+	planReadTokens, err := controllers.ParseAPIPlanReadTokens(userConfig.APIPlanReadTokens)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing --api-plan-read-tokens")
+	}
+	lockExplainer := &events.LockExplainer{
+		Backend:          backend,
+		WorkingDirLocks:  workingDirLocker,
+		ApplyLockChecker: applyLockingClient,
+		CircuitBreaker:   circuitBreaker,
 	}
 	apiController := &controllers.APIController{
 		APISecret:                      []byte(userConfig.APISecret),
+		PlanReadTokens:                 planReadTokens,
+		Backend:                        backend,
 		Locker:                         lockingClient,
+		LockExplainer:                  lockExplainer,
+		ShadowModeChecker:              globalCfg,
 		Logger:                         logger,
 		Parser:                         eventParser,
+		DBCompactor:                    dbCompactor,
+		JobCanceler:                    jobCanceler,
+		ParallelPoolSize:               parallelPoolSize,
+		AutoplanSimulator:              projectCommandBuilder,
 		ProjectCommandBuilder:          projectCommandBuilder,
+		ProjectRenamer:                 &events.ProjectRenamer{Backend: backend, PlanHistory: planHistory},
 		ProjectPlanCommandRunner:       instrumentedProjectCmdRunner,
 		ProjectApplyCommandRunner:      instrumentedProjectCmdRunner,
 		FailOnPreWorkflowHookError:     userConfig.FailOnPreWorkflowHookError,
 		PreWorkflowHooksCommandRunner:  preWorkflowHooksCommandRunner,
 		PostWorkflowHooksCommandRunner: postWorkflowHooksCommandRunner,
 		RepoAllowlistChecker:           repoAllowlist,
+		RepoConfigReport:               repoConfigReport,
 		Scope:                          statsScope.SubScope("api"),
 		VCSClient:                      vcsClient,
 		WorkingDir:                     workingDir,
 		WorkingDirLocker:               workingDirLocker,
 	}
 
+	if driftRepos := allowlistedRepos(logger, repoAllowlist, globalCfg.DriftDetectionRepos()); len(driftRepos) > 0 && userConfig.DriftDetectionInterval > 0 {
+		driftDetector := &events.DefaultDriftDetector{
+			Locker:                   lockingClient,
+			Logger:                   logger,
+			Parser:                   eventParser,
+			ProjectCommandBuilder:    projectCommandBuilder,
+			ProjectPlanCommandRunner: instrumentedProjectCmdRunner,
+			Scope:                    statsScope.SubScope("drift_detection"),
+			VCSClient:                vcsClient,
+			VCSHostTypes:             vcsHostTypes,
+			WorkingDir:               workingDir,
+			WorkingDirLocker:         workingDirLocker,
+			PlanCache:                planCache,
+			PlanHistory:              planHistory,
+			PlanStore:                planStore,
+		}
+		driftNotifier := &events.DefaultDriftNotifier{
+			ExecutableName: userConfig.ExecutableName,
+			Logger:         logger,
+			Parser:         eventParser,
+			VCSClient:      vcsClient,
+			VCSHostTypes:   vcsHostTypes,
+		}
+		scheduledExecutorService.AddJob(scheduled.JobDefinition{
+			Job:    scheduled.NewDriftDetectionJob(logger, driftRepos, driftDetector, backend, driftNotifier, statsScope),
+			Period: time.Duration(userConfig.DriftDetectionInterval) * time.Minute,
+		})
+	}
+
+	if scheduledApplyRepos := allowlistedRepos(logger, repoAllowlist, globalCfg.ScheduledApplyRepos()); len(scheduledApplyRepos) > 0 {
+		scheduledApplier := &events.DefaultScheduledApplier{
+			Locker:                          lockingClient,
+			Logger:                          logger,
+			Parser:                          eventParser,
+			ProjectCommandBuilder:           projectCommandBuilder,
+			ProjectPlanCommandRunner:        instrumentedProjectCmdRunner,
+			ProjectPolicyCheckCommandRunner: instrumentedProjectCmdRunner,
+			ProjectApplyCommandRunner:       instrumentedProjectCmdRunner,
+			Scope:                           statsScope.SubScope("scheduled_apply"),
+			VCSClient:                       vcsClient,
+			VCSHostTypes:                    vcsHostTypes,
+			Webhooks:                        webhooksManager,
+			WorkingDir:                      workingDir,
+			WorkingDirLocker:                workingDirLocker,
+			MaintenanceWindows:              globalCfg,
+			ShadowModeChecker:               globalCfg,
+			PlanCache:                       planCache,
+			PlanHistory:                     planHistory,
+			PlanStore:                       planStore,
+		}
+		scheduledExecutorService.AddJob(scheduled.JobDefinition{
+			Job:    scheduled.NewScheduledApplyJob(logger, scheduledApplyRepos, scheduledApplier, statsScope),
+			Period: scheduled.ScheduledApplyPeriod,
+		})
+	}
+
+	if userConfig.GCInterval > 0 {
+		garbageCollector := &events.DefaultGarbageCollector{
+			Backend:           backend,
+			DataDir:           userConfig.DataDir,
+			Logger:            logger,
+			PendingPlanFinder: pendingPlanFinder,
+			WorkingDirLocker:  workingDirLocker,
+			VCSClient:         vcsClient,
+			PlanCache:         planCache,
+			PlanHistory:       planHistory,
+			PlanStore:         planStore,
+			PlanMaxAge:        time.Duration(userConfig.GCPlanMaxAge) * time.Hour,
+			WorkingDirMaxAge:  time.Duration(userConfig.GCWorkingDirMaxAge) * time.Hour,
+			// A Redis database can be shared by servers with different data
+			// dirs so we can't tell which pull statuses and locks are orphaned.
+			CollectOrphanedEntries: userConfig.LockingDBType != "redis",
+			DryRun:                 userConfig.GCDryRun,
+		}
+		scheduledExecutorService.AddJob(scheduled.JobDefinition{
+			Job:    scheduled.NewGarbageCollectionJob(logger, garbageCollector, statsScope),
+			Period: time.Duration(userConfig.GCInterval) * time.Minute,
+		})
+	}
+
+	if userConfig.PersistJobOutput && userConfig.JobOutputRetention > 0 {
+		scheduledExecutorService.AddJob(scheduled.JobDefinition{
+			Job:    scheduled.NewJobOutputRetentionJob(logger, backend, time.Duration(userConfig.JobOutputRetention)*time.Hour, statsScope),
+			Period: scheduled.JobOutputRetentionPeriod,
+		})
+	}
+
+	var startupReconciler *events.StartupReconciler
+	if userConfig.ReconcileOnStartup {
+		startupReconciler = &events.StartupReconciler{
+			Backend:             backend,
+			DataDir:             userConfig.DataDir,
+			Logger:              logger,
+			VCSClient:           vcsClient,
+			PendingPlanFinder:   pendingPlanFinder,
+			WorkingDirLocker:    workingDirLocker,
+			CommitStatusUpdater: commitStatusUpdater,
+			PullCleaner:         pullClosedExecutor,
+			PlanStore:           planStore,
+		}
+	}
+
+	if userConfig.OrphanedLockCleanupInterval > 0 {
+		scheduledExecutorService.AddJob(scheduled.JobDefinition{
+			Job:    scheduled.NewOrphanedLockCleanupJob(logger, backend, vcsClient, pullClosedExecutor, statsScope),
+			Period: time.Duration(userConfig.OrphanedLockCleanupInterval) * time.Minute,
+		})
+	}
+
+	if telemetryRecorder != nil {
+		telemetryPeriod := time.Duration(userConfig.TelemetryInterval) * time.Minute
+		logger.Info("anonymous usage telemetry is enabled, reporting to %s every %s", userConfig.TelemetryEndpoint, telemetryPeriod)
+		scheduledExecutorService.AddJob(scheduled.JobDefinition{
+			Job:    scheduled.NewTelemetryJob(logger, telemetryRecorder, userConfig.TelemetryEndpoint, telemetryPeriod, uuid.New().String(), config.AtlantisVersion, supportedVCSHostsStr, statsScope),
+			Period: telemetryPeriod,
+		})
+	}
+
+	if dbCompactor != nil && (userConfig.BoltDBAutoCompact || userConfig.BoltDBSizeWarning > 0) {
+		scheduledExecutorService.AddJob(scheduled.JobDefinition{
+			Job:    scheduled.NewBoltDBCompactionJob(logger, dbCompactor, userConfig.BoltDBAutoCompact, int64(userConfig.BoltDBSizeWarning)*1024*1024, statsScope),
+			Period: scheduled.BoltDBCompactionPeriod,
+		})
+	}
+
+	if userConfig.ParallelPoolAdaptive {
+		scheduledExecutorService.AddJob(scheduled.JobDefinition{
+			Job:    scheduled.NewParallelPoolPressureJob(logger, parallelPoolSize, statsScope),
+			Period: scheduled.ParallelPoolPressurePeriod,
+		})
+	}
+
+	if globalCfg.DestroyOnCloseAllowed() {
+		basePullClosedExecutor.DestroyOnCloseRunner = &events.DefaultDestroyOnCloseRunner{
+			ProjectCommandBuilder:     projectCommandBuilder,
+			ProjectPlanCommandRunner:  instrumentedProjectCmdRunner,
+			ProjectApplyCommandRunner: instrumentedProjectCmdRunner,
+			PullUpdater:               pullUpdater,
+			Scope:                     statsScope,
+			VCSClient:                 vcsClient,
+		}
+	}
+
 	eventsController := &events_controllers.VCSEventsController{
 		CommandRunner:                   commandRunner,
 		PullCleaner:                     pullClosedExecutor,
@@ -983,6 +1686,16 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		AzureDevopsWebhookBasicPassword: []byte(userConfig.AzureDevopsWebhookPassword),
 		AzureDevopsRequestValidator:     &events_controllers.DefaultAzureDevopsRequestValidator{},
 		GiteaWebhookSecret:              []byte(userConfig.GiteaWebhookSecret),
+		WebhookSecretFinder:             globalCfg,
+		DisabledCommandsFinder:          globalCfg,
+		EventHooks:                      postWorkflowHooksCommandRunner,
+		WebhookDeduplicator: &events_controllers.WebhookDeduplicator{
+			Backend: backend,
+			Window:  time.Duration(userConfig.WebhookDedupWindow) * time.Minute,
+		},
+	}
+	if vcsAdapter != nil {
+		eventsController.VCSAdapter = vcsAdapter
 	}
 	githubAppController := &controllers.GithubAppController{
 		AtlantisURL:         parsedURL,
@@ -1009,6 +1722,9 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		VCSEventsController:            eventsController,
 		GithubAppController:            githubAppController,
 		LocksController:                locksController,
+		DriftController:                driftController,
+		PlanHistoryController:          planHistoryController,
+		TFERunTaskController:           tfeRunTaskController,
 		JobsController:                 jobsController,
 		StatusController:               statusController,
 		APIController:                  apiController,
@@ -1019,15 +1735,32 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		SSLKeyFile:                     userConfig.SSLKeyFile,
 		SSLCertFile:                    userConfig.SSLCertFile,
 		DisableGlobalApplyLock:         userConfig.DisableGlobalApplyLock,
+		ApplyQueue:                     applyQueue,
 		Drainer:                        drainer,
+		CommitStatusBatcher:            commitStatusUpdater.Batcher,
 		ProjectCmdOutputHandler:        projectCmdOutputHandler,
+		StepPlugins:                    stepPlugins,
+		VCSAdapter:                     vcsAdapter,
 		WebAuthentication:              userConfig.WebBasicAuth,
 		WebUsername:                    userConfig.WebUsername,
 		WebPassword:                    userConfig.WebPassword,
 		ScheduledExecutorService:       scheduledExecutorService,
+		StartupReconciler:              startupReconciler,
 	}, nil
 }
 
+// reconcileOnStartup reconciles the working dirs and plans with the database
+// and the VCS hosts and logs what was fixed.
+func (s *Server) reconcileOnStartup() {
+	result, err := s.StartupReconciler.Reconcile()
+	if err != nil {
+		s.Logger.Err("reconciling working dirs on startup: %s", err)
+		return
+	}
+	s.Logger.Info("Startup reconciliation cleaned up %d closed pull request(s), deleted %d orphaned working dir(s) and %d orphaned plan(s), discarded %d missing plan(s) and restored the commit statuses of %d pull request(s)",
+		result.ClosedPulls, result.OrphanedWorkingDirs, result.OrphanedPlans, result.MissingPlans, result.RestoredStatuses)
+}
+
 // Start creates the routes and starts serving traffic.
 func (s *Server) Start() error {
 	s.Router.HandleFunc("/", s.Index).Methods("GET").MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
@@ -1037,15 +1770,37 @@ func (s *Server) Start() error {
 	s.Router.HandleFunc("/status", s.StatusController.Get).Methods("GET")
 	s.Router.PathPrefix("/static/").Handler(http.FileServer(http.FS(staticAssets)))
 	s.Router.HandleFunc("/events", s.VCSEventsController.Post).Methods("POST")
+	s.Router.HandleFunc("/events/vcs-adapter", s.VCSEventsController.PostVCSAdapter).Methods("POST")
 	s.Router.HandleFunc("/api/plan", s.APIController.Plan).Methods("POST")
 	s.Router.HandleFunc("/api/apply", s.APIController.Apply).Methods("POST")
+	s.Router.HandleFunc("/api/simulate", s.APIController.Simulate).Methods("POST")
+	s.Router.HandleFunc("/api/drift", s.APIController.Drift).Methods("GET")
+	s.Router.HandleFunc("/api/locks/explain", s.APIController.ExplainLock).Methods("GET")
+	s.Router.HandleFunc("/api/jobs", s.APIController.Jobs).Methods("GET")
+	s.Router.HandleFunc("/api/jobs/{job-id}", s.APIController.GetJob).Methods("GET")
+	s.Router.HandleFunc("/api/jobs/{job-id}/cancel", s.APIController.CancelJob).Methods("POST")
+	s.Router.HandleFunc("/api/repo-configs", s.APIController.RepoConfigs).Methods("GET")
+	s.Router.HandleFunc("/api/parallel-pool-size", s.APIController.GetParallelPoolSize).Methods("GET")
+	s.Router.HandleFunc("/api/parallel-pool-size", s.APIController.SetParallelPoolSize).Methods("POST")
+	s.Router.HandleFunc("/api/boltdb/compact", s.APIController.CompactDB).Methods("POST")
+	s.Router.HandleFunc("/api/projects/rename", s.APIController.RenameProject).Methods("POST")
+	// Repo full names and project dirs contain slashes.
+	s.Router.HandleFunc("/api/plans/{repo:.+}/{pull:[0-9]+}/{project:.+}", s.APIController.GetPlan).Methods("GET")
 	s.Router.HandleFunc("/github-app/exchange-code", s.GithubAppController.ExchangeCode).Methods("GET")
 	s.Router.HandleFunc("/github-app/setup", s.GithubAppController.New).Methods("GET")
 	s.Router.HandleFunc("/locks", s.LocksController.DeleteLock).Methods("DELETE").Queries("id", "{id:.*}")
 	s.Router.HandleFunc("/lock", s.LocksController.GetLock).Methods("GET").
 		Queries(LockViewRouteIDQueryParam, fmt.Sprintf("{%s}", LockViewRouteIDQueryParam)).Name(LockViewRouteName)
+	s.Router.HandleFunc("/drift", s.DriftController.GetDrift).Methods("GET")
+	s.Router.HandleFunc("/drift/output", s.DriftController.GetDriftOutput).Methods("GET").Queries("id", "{id}")
+	s.Router.HandleFunc("/plan-history", s.PlanHistoryController.GetPlanHistory).Methods("GET")
+	s.Router.HandleFunc("/jobs", s.JobsController.GetJobs).Methods("GET")
 	s.Router.HandleFunc("/jobs/{job-id}", s.JobsController.GetProjectJobs).Methods("GET").Name(ProjectJobsViewRouteName)
+	s.Router.HandleFunc("/jobs/{job-id}/cancel", s.JobsController.CancelJob).Methods("POST")
 	s.Router.HandleFunc("/jobs/{job-id}/ws", s.JobsController.GetProjectJobsWS).Methods("GET")
+	if s.TFERunTaskController != nil {
+		s.Router.HandleFunc("/tfe/run-task", s.TFERunTaskController.Post).Methods("POST")
+	}
 
 	r, ok := s.StatsReporter.(prometheus.Reporter)
 	if ok {
@@ -1073,6 +1828,10 @@ func (s *Server) Start() error {
 
 	go s.ScheduledExecutorService.Run()
 
+	if s.StartupReconciler != nil {
+		go s.reconcileOnStartup()
+	}
+
 	go func() {
 		s.ProjectCmdOutputHandler.Handle()
 	}()
@@ -1099,6 +1858,18 @@ func (s *Server) Start() error {
 	s.Logger.Warn("Received interrupt. Waiting for in-progress operations to complete")
 	s.waitForDrain()
 
+	// write the commit statuses that are still queued
+	if s.CommitStatusBatcher != nil {
+		s.CommitStatusBatcher.Flush()
+	}
+
+	if s.StepPlugins != nil {
+		s.StepPlugins.Kill()
+	}
+	if s.VCSAdapter != nil {
+		s.VCSAdapter.Kill()
+	}
+
 	// flush stats before shutdown
 	if err := s.StatsCloser.Close(); err != nil {
 		s.Logger.Err(err.Error())
@@ -1177,6 +1948,7 @@ func (s *Server) Index(w http.ResponseWriter, _ *http.Request) {
 	err = s.IndexTemplate.Execute(w, web_templates.IndexData{
 		Locks:            lockResults,
 		PullToJobMapping: preparePullToJobMappings(s),
+		ApplyQueue:       prepareApplyQueue(s),
 		ApplyLock:        applyLockData,
 		AtlantisVersion:  s.AtlantisVersion,
 		CleanedBasePath:  s.AtlantisURL.Path,
@@ -1186,6 +1958,33 @@ func (s *Server) Index(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
+// prepareApplyQueue returns the applies of the apply queue, or nil if there's
+// no queue.
+func prepareApplyQueue(s *Server) *web_templates.ApplyQueueData {
+	if s.ApplyQueue == nil {
+		return nil
+	}
+	data := &web_templates.ApplyQueueData{}
+	for _, entry := range s.ApplyQueue.List() {
+		applyData := web_templates.ApplyQueueEntryData{
+			RepoFullName:  entry.RepoFullName,
+			PullNum:       entry.PullNum,
+			ProjectName:   entry.ProjectName,
+			Path:          entry.RepoRelDir,
+			Workspace:     entry.Workspace,
+			Status:        "Running",
+			TimeFormatted: entry.EnqueuedAt.Format("2006-01-02 15:04:05"),
+		}
+		if entry.Position > 0 {
+			applyData.Status = fmt.Sprintf("Queued #%d", entry.Position)
+		} else {
+			applyData.StartedFormatted = entry.StartedAt.Format("2006-01-02 15:04:05")
+		}
+		data.Applies = append(data.Applies, applyData)
+	}
+	return data
+}
+
 func preparePullToJobMappings(s *Server) []jobs.PullInfoWithJobIDs {
 
 	pullToJobMappings := s.ProjectCmdOutputHandler.GetPullToJobMapping()
@@ -1280,3 +2079,41 @@ func ParseAtlantisURL(u string) (*url.URL, error) {
 	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
 	return parsed, nil
 }
+
+// vcsHostname returns the hostname of a VCS host that may be configured as
+// either a hostname, ex. github.com, or a URL, ex. https://gitea.com.
+func vcsHostname(hostnameOrURL string) string {
+	if u, err := url.Parse(hostnameOrURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return hostnameOrURL
+}
+
+// githubTokenEnvVar is the environment variable the GitHub token can be set
+// with.
+const githubTokenEnvVar = "ATLANTIS_GH_TOKEN"
+
+// githubTokenEnv returns the environment variable the GitHub token should be
+// re-read from, or "" if the token wasn't set with it, ex. it was set with the
+// --gh-token flag, which takes precedence over the environment variable.
+func githubTokenEnv(token string) string {
+	if token != "" && os.Getenv(githubTokenEnvVar) == token {
+		return githubTokenEnvVar
+	}
+	return ""
+}
+
+// allowlistedRepos returns the repos that are in the repo allowlist. The
+// others are skipped with a warning, since Atlantis doesn't operate on them
+// even if they're configured in the server-side repo config.
+func allowlistedRepos(logger logging.SimpleLogging, allowlist *events.RepoAllowlistChecker, repos []valid.Repo) []valid.Repo {
+	var allowlisted []valid.Repo
+	for _, repo := range repos {
+		if !allowlist.IsRepoIDAllowlisted(repo.ID) {
+			logger.Warn("skipping repo %s since it's not in the repo allowlist", repo.ID)
+			continue
+		}
+		allowlisted = append(allowlisted, repo)
+	}
+	return allowlisted
+}
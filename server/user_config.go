@@ -16,24 +16,39 @@ import (
 type UserConfig struct {
 	AllowForkPRs                bool   `mapstructure:"allow-fork-prs"`
 	AllowCommands               string `mapstructure:"allow-commands"`
+	ApplyTimeout                int    `mapstructure:"apply-timeout"`
 	AtlantisURL                 string `mapstructure:"atlantis-url"`
 	AutoDiscoverModeFlag        string `mapstructure:"autodiscover-mode"`
 	Automerge                   bool   `mapstructure:"automerge"`
 	AutoplanFileList            string `mapstructure:"autoplan-file-list"`
 	AutoplanModules             bool   `mapstructure:"autoplan-modules"`
 	AutoplanModulesFromProjects string `mapstructure:"autoplan-modules-from-projects"`
+	AzureDevopsClientID         string `mapstructure:"azuredevops-client-id"`
+	AzureDevopsClientSecret     string `mapstructure:"azuredevops-client-secret"`
+	AzureDevopsOIDCTokenFile    string `mapstructure:"azuredevops-federated-token-file"`
+	AzureDevopsTenantID         string `mapstructure:"azuredevops-tenant-id"`
 	AzureDevopsToken            string `mapstructure:"azuredevops-token"`
 	AzureDevopsUser             string `mapstructure:"azuredevops-user"`
 	AzureDevopsWebhookPassword  string `mapstructure:"azuredevops-webhook-password"`
 	AzureDevopsWebhookUser      string `mapstructure:"azuredevops-webhook-user"`
 	AzureDevOpsHostname         string `mapstructure:"azuredevops-hostname"`
 	BitbucketBaseURL            string `mapstructure:"bitbucket-base-url"`
+	BitbucketOAuthClientID      string `mapstructure:"bitbucket-oauth-client-id"`
+	BitbucketOAuthClientSecret  string `mapstructure:"bitbucket-oauth-client-secret"`
 	BitbucketToken              string `mapstructure:"bitbucket-token"`
+	BitbucketTokenType          string `mapstructure:"bitbucket-token-type"`
 	BitbucketUser               string `mapstructure:"bitbucket-user"`
 	BitbucketWebhookSecret      string `mapstructure:"bitbucket-webhook-secret"`
+	BoltDBAutoCompact           bool   `mapstructure:"boltdb-auto-compact"`
+	BoltDBSizeWarning           int    `mapstructure:"boltdb-size-warning"`
 	CheckoutDepth               int    `mapstructure:"checkout-depth"`
+	CheckoutFilter              string `mapstructure:"checkout-filter"`
 	CheckoutStrategy            string `mapstructure:"checkout-strategy"`
+	CircuitBreakerCooldown      int    `mapstructure:"circuit-breaker-cooldown"`
+	CircuitBreakerThreshold     int    `mapstructure:"circuit-breaker-threshold"`
+	CustomCommandTimeout        int    `mapstructure:"custom-command-timeout"`
 	DataDir                     string `mapstructure:"data-dir"`
+	DestroyApprovers            string `mapstructure:"destroy-approvers"`
 	DisableApplyAll             bool   `mapstructure:"disable-apply-all"`
 	DisableAutoplan             bool   `mapstructure:"disable-autoplan"`
 	DisableAutoplanLabel        string `mapstructure:"disable-autoplan-label"`
@@ -42,26 +57,40 @@ type UserConfig struct {
 	DisableGlobalApplyLock      bool   `mapstructure:"disable-global-apply-lock"`
 	DisableUnlockLabel          string `mapstructure:"disable-unlock-label"`
 	DiscardApprovalOnPlanFlag   bool   `mapstructure:"discard-approval-on-plan"`
+	DriftDetectionInterval      int    `mapstructure:"drift-detection-interval"`
 	EmojiReaction               string `mapstructure:"emoji-reaction"`
+	EnablePlanCache             bool   `mapstructure:"enable-plan-cache"`
+	EnablePlanHistory           bool   `mapstructure:"enable-plan-history"`
 	EnablePolicyChecksFlag      bool   `mapstructure:"enable-policy-checks"`
 	EnableRegExpCmd             bool   `mapstructure:"enable-regexp-cmd"`
 	EnableDiffMarkdownFormat    bool   `mapstructure:"enable-diff-markdown-format"`
 	ExecutableName              string `mapstructure:"executable-name"`
+	Executor                    string `mapstructure:"executor"`
+	GCDryRun                    bool   `mapstructure:"gc-dry-run"`
+	GCInterval                  int    `mapstructure:"gc-interval"`
+	GCPlanMaxAge                int    `mapstructure:"gc-plan-max-age"`
+	GCWorkingDirMaxAge          int    `mapstructure:"gc-working-dir-max-age"`
 	// Fail and do not run the Atlantis command request if any of the pre workflow hooks error.
 	FailOnPreWorkflowHookError      bool   `mapstructure:"fail-on-pre-workflow-hook-error"`
+	ForkPRApprovalLabel             string `mapstructure:"fork-pr-approval-label"`
+	ForkPRApprovers                 string `mapstructure:"fork-pr-approvers"`
+	ForkPRRequireApproval           bool   `mapstructure:"fork-pr-require-approval"`
 	HideUnchangedPlanComments       bool   `mapstructure:"hide-unchanged-plan-comments"`
 	GithubAllowMergeableBypassApply bool   `mapstructure:"gh-allow-mergeable-bypass-apply"`
+	GithubDeletePrevCommentChunks   bool   `mapstructure:"gh-delete-prev-comment-chunks"`
 	GithubHostname                  string `mapstructure:"gh-hostname"`
 	GithubToken                     string `mapstructure:"gh-token"`
 	GithubTokenFile                 string `mapstructure:"gh-token-file"`
 	GithubUser                      string `mapstructure:"gh-user"`
 	GithubWebhookSecret             string `mapstructure:"gh-webhook-secret"`
 	GithubOrg                       string `mapstructure:"gh-org"`
+	GithubResponseCacheTTL          int    `mapstructure:"gh-response-cache-ttl"`
 	GithubAppID                     int64  `mapstructure:"gh-app-id"`
 	GithubAppKey                    string `mapstructure:"gh-app-key"`
 	GithubAppKeyFile                string `mapstructure:"gh-app-key-file"`
 	GithubAppSlug                   string `mapstructure:"gh-app-slug"`
 	GithubAppInstallationID         int64  `mapstructure:"gh-app-installation-id"`
+	GithubCheckRuns                 bool   `mapstructure:"gh-check-runs"`
 	GithubTeamAllowlist             string `mapstructure:"gh-team-allowlist"`
 	GiteaBaseURL                    string `mapstructure:"gitea-base-url"`
 	GiteaToken                      string `mapstructure:"gitea-token"`
@@ -74,20 +103,38 @@ type UserConfig struct {
 	GitlabUser                      string `mapstructure:"gitlab-user"`
 	GitlabWebhookSecret             string `mapstructure:"gitlab-webhook-secret"`
 	IncludeGitUntrackedFiles        bool   `mapstructure:"include-git-untracked-files"`
+	JobOutputRetention              int    `mapstructure:"job-output-retention"`
+	KubernetesExecutorDataDirClaim  string `mapstructure:"kubernetes-executor-data-dir-claim"`
+	KubernetesExecutorNamespace     string `mapstructure:"kubernetes-executor-namespace"`
+	KubernetesExecutorPodTemplate   string `mapstructure:"kubernetes-executor-pod-template"`
 	APISecret                       string `mapstructure:"api-secret"`
+	APIPlanReadTokens               string `mapstructure:"api-plan-read-tokens"`
 	HidePrevPlanComments            bool   `mapstructure:"hide-prev-plan-comments"`
 	LockingDBType                   string `mapstructure:"locking-db-type"`
 	LogLevel                        string `mapstructure:"log-level"`
 	MarkdownTemplateOverridesDir    string `mapstructure:"markdown-template-overrides-dir"`
 	MaxCommentsPerCommand           int    `mapstructure:"max-comments-per-command"`
+	MaxConcurrentApplies            int    `mapstructure:"max-concurrent-applies"`
+	MaxConcurrentAppliesPerRepo     int    `mapstructure:"max-concurrent-applies-per-repo"`
+	PersistJobOutput                bool   `mapstructure:"persist-job-output"`
 	IgnoreVCSStatusNames            string `mapstructure:"ignore-vcs-status-names"`
+	OrphanedLockCleanupInterval     int    `mapstructure:"orphaned-lock-cleanup-interval"`
 	ParallelPoolSize                int    `mapstructure:"parallel-pool-size"`
 	ParallelPlan                    bool   `mapstructure:"parallel-plan"`
+	PlanDiffComments                bool   `mapstructure:"plan-diff-comments"`
+	PlanStoreURL                    string `mapstructure:"plan-store-url"`
+	PlanTimeout                     int    `mapstructure:"plan-timeout"`
+	PolicyCheckTimeout              int    `mapstructure:"policy-check-timeout"`
 	ParallelApply                   bool   `mapstructure:"parallel-apply"`
+	ParallelPoolAdaptive            bool   `mapstructure:"parallel-pool-adaptive"`
 	StatsNamespace                  string `mapstructure:"stats-namespace"`
 	PlanDrafts                      bool   `mapstructure:"allow-draft-prs"`
 	Port                            int    `mapstructure:"port"`
+	ProjectCommandHookURL           string `mapstructure:"project-command-hook-url"`
+	PullClosedComments              string `mapstructure:"pull-closed-comments"`
 	QuietPolicyChecks               bool   `mapstructure:"quiet-policy-checks"`
+	ReconcileOnStartup              bool   `mapstructure:"reconcile-on-startup"`
+	ReplanDependents                bool   `mapstructure:"replan-dependents"`
 	RedisDB                         int    `mapstructure:"redis-db"`
 	RedisHost                       string `mapstructure:"redis-host"`
 	RedisPassword                   string `mapstructure:"redis-password"`
@@ -96,6 +143,7 @@ type UserConfig struct {
 	RedisInsecureSkipVerify         bool   `mapstructure:"redis-insecure-skip-verify"`
 	RepoConfig                      string `mapstructure:"repo-config"`
 	RepoConfigJSON                  string `mapstructure:"repo-config-json"`
+	RepoConfigValidationInterval    int    `mapstructure:"repo-config-validation-interval"`
 	RepoAllowlist                   string `mapstructure:"repo-allowlist"`
 
 	// SilenceNoProjects is whether Atlantis should respond to a PR if no projects are found.
@@ -110,27 +158,43 @@ type UserConfig struct {
 	SilenceAllowlistErrors     bool            `mapstructure:"silence-allowlist-errors"`
 	SkipCloneNoChanges         bool            `mapstructure:"skip-clone-no-changes"`
 	SlackToken                 string          `mapstructure:"slack-token"`
+	SparseCheckout             bool            `mapstructure:"sparse-checkout"`
+	SparseCheckoutDirs         string          `mapstructure:"sparse-checkout-dirs"`
 	SSLCertFile                string          `mapstructure:"ssl-cert-file"`
 	SSLKeyFile                 string          `mapstructure:"ssl-key-file"`
+	StatePushAdmins            string          `mapstructure:"state-push-admins"`
+	StepPlugins                string          `mapstructure:"step-plugins"`
+	SummaryComment             bool            `mapstructure:"summary-comment"`
 	RestrictFileList           bool            `mapstructure:"restrict-file-list"`
+	TelemetryEndpoint          string          `mapstructure:"telemetry-endpoint"`
+	TelemetryInterval          int             `mapstructure:"telemetry-interval"`
 	TFDistribution             string          `mapstructure:"tf-distribution"` // deprecated in favor of DefaultTFDistribution
 	TFDownload                 bool            `mapstructure:"tf-download"`
 	TFDownloadURL              string          `mapstructure:"tf-download-url"`
+	TofuDownloadURL            string          `mapstructure:"tofu-download-url"`
 	TFEHostname                string          `mapstructure:"tfe-hostname"`
 	TFELocalExecutionMode      bool            `mapstructure:"tfe-local-execution-mode"`
 	TFEToken                   string          `mapstructure:"tfe-token"`
+	TFERunTaskHMACKey          string          `mapstructure:"tfe-run-task-hmac-key"`
 	VarFileAllowlist           string          `mapstructure:"var-file-allowlist"`
+	VCSAdapter                 string          `mapstructure:"vcs-adapter"`
+	VCSStatusDebounce          int             `mapstructure:"vcs-status-debounce"`
 	VCSStatusName              string          `mapstructure:"vcs-status-name"`
 	DefaultTFDistribution      string          `mapstructure:"default-tf-distribution"`
 	DefaultTFVersion           string          `mapstructure:"default-tf-version"`
 	Webhooks                   []WebhookConfig `mapstructure:"webhooks" flag:"false"`
+	WebhookDedupWindow         int             `mapstructure:"webhook-dedup-window"`
 	WebhookHttpHeaders         string          `mapstructure:"webhook-http-headers"`
 	WebBasicAuth               bool            `mapstructure:"web-basic-auth"`
 	WebUsername                string          `mapstructure:"web-username"`
 	WebPassword                string          `mapstructure:"web-password"`
 	WriteGitCreds              bool            `mapstructure:"write-git-creds"`
 	WebsocketCheckOrigin       bool            `mapstructure:"websocket-check-origin"`
+	UseTFInitCache             bool            `mapstructure:"use-tf-init-cache"`
 	UseTFPluginCache           bool            `mapstructure:"use-tf-plugin-cache"`
+
+	// AuditSinks can only be set in the config file, like Webhooks.
+	AuditSinks []AuditSinkConfig `mapstructure:"audit-sinks" flag:"false"`
 }
 
 // ToAllowCommandNames parse AllowCommands into a slice of CommandName